@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 
 	"github.com/gogf/gf/v2/frame/g"
@@ -63,14 +65,19 @@ func (c *FundController) Deposit(r *ghttp.Request) {
 		return
 	}
 
+	message := "充值成功"
+	if fund.Status == types.FundStatusPending {
+		message = "充值金额超过审批阈值，审批通过后入账"
+	}
 	r.Response.WriteJsonExit(g.Map{
 		"code":    0,
-		"message": "充值成功",
+		"message": message,
 		"data":    fund,
 	})
 }
 
-// BatchDeposit 批量资金充值
+// BatchDeposit 批量资金充值，mode 为 all_or_nothing 时任一笔失败整批回滚，默认逐笔入账并返回失败的充值；
+// 幂等键可通过 Idempotency-Key 请求头或请求体传入
 func (c *FundController) BatchDeposit(r *ghttp.Request) {
 	var req types.BatchDepositRequest
 	if err := r.Parse(&req); err != nil {
@@ -80,6 +87,9 @@ func (c *FundController) BatchDeposit(r *ghttp.Request) {
 		})
 		return
 	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	}
 
 	// 验证请求数据
 	if err := req.Validate(); err != nil {
@@ -101,19 +111,31 @@ func (c *FundController) BatchDeposit(r *ghttp.Request) {
 	}
 
 	// 执行批量充值
-	results, err := c.fundService.BatchDeposit(r.Context(), &req, operatorID)
+	result, err := c.fundService.BatchDeposit(r.Context(), &req, operatorID)
 	if err != nil {
+		code := 500
+		if errors.Is(err, types.ErrBatchDepositInProgress) || errors.Is(err, types.ErrBatchDepositKeyReused) {
+			code = 409
+		}
 		r.Response.WriteJsonExit(g.Map{
-			"code":    500,
+			"code":    code,
 			"message": "批量充值失败: " + err.Error(),
 		})
 		return
 	}
 
+	// 没有充值入账时返回失败，各笔充值的失败原因在结果中
+	code, message := 0, "批量充值完成"
+	switch result.Status {
+	case types.BatchDepositStatusPartial:
+		message = fmt.Sprintf("批量充值部分完成：成功%d笔，失败%d笔", result.SucceededCount, result.FailedCount)
+	case types.BatchDepositStatusFailed:
+		code, message = 400, "批量充值失败，没有充值入账"
+	}
 	r.Response.WriteJsonExit(g.Map{
-		"code":    0,
-		"message": "批量充值完成",
-		"data":    results,
+		"code":    code,
+		"message": message,
+		"data":    result,
 	})
 }
 
@@ -157,9 +179,13 @@ func (c *FundController) Allocate(r *ghttp.Request) {
 		return
 	}
 
+	message := "权益分配成功"
+	if fund.Status == types.FundStatusPending {
+		message = "分配金额超过审批阈值，审批通过后入账"
+	}
 	r.Response.WriteJsonExit(g.Map{
 		"code":    0,
-		"message": "权益分配成功",
+		"message": message,
 		"data":    fund,
 	})
 }
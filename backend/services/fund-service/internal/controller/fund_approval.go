@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+
+	"mer-demo/services/fund-service/internal/service"
+	"mer-demo/shared/types"
+)
+
+// ListPendingApprovals 查询待审批的大额资金操作
+func (c *FundController) ListPendingApprovals(r *ghttp.Request) {
+	var query types.FundApprovalQuery
+	if err := r.Parse(&query); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 设置默认分页参数
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 || query.PageSize > 100 {
+		query.PageSize = 20
+	}
+
+	approvals, total, err := c.fundService.ListPendingApprovals(r.Context(), &query)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "查询失败: " + err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code": 0,
+		"data": g.Map{
+			"list":      approvals,
+			"total":     total,
+			"page":      query.Page,
+			"page_size": query.PageSize,
+		},
+	})
+}
+
+// ApproveFund 审批通过大额资金操作
+func (c *FundController) ApproveFund(r *ghttp.Request) {
+	c.decideFund(r, true)
+}
+
+// RejectFund 驳回大额资金操作
+func (c *FundController) RejectFund(r *ghttp.Request) {
+	c.decideFund(r, false)
+}
+
+// decideFund 处理审批或驳回请求
+func (c *FundController) decideFund(r *ghttp.Request, approve bool) {
+	approvalID, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil || approvalID == 0 {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "无效的审批ID",
+		})
+		return
+	}
+
+	var req types.FundApprovalDecisionRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 获取审批人ID
+	approverID := getUserIDFromRequest(r)
+	if approverID == 0 {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    401,
+			"message": "无效的用户上下文",
+		})
+		return
+	}
+
+	var approval *types.FundApproval
+	if approve {
+		approval, err = c.fundService.ApproveFund(r.Context(), approvalID, approverID, req.Comment)
+	} else {
+		approval, err = c.fundService.RejectFund(r.Context(), approvalID, approverID, req.Comment)
+	}
+	if err != nil {
+		code := 500
+		switch {
+		case errors.Is(err, service.ErrFundApprovalNotFound):
+			code = 404
+		case errors.Is(err, service.ErrFundApprovalSelfApprove):
+			code = 403
+		case errors.Is(err, service.ErrFundApprovalNotPending), errors.Is(err, service.ErrFundApprovalDuplicate):
+			code = 409
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "审批失败: " + err.Error(),
+		})
+		return
+	}
+
+	message := "审批成功"
+	switch approval.Status {
+	case types.FundApprovalStatusApproved:
+		message = "审批通过，资金已入账"
+	case types.FundApprovalStatusRejected:
+		message = "已驳回"
+	}
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": message,
+		"data":    approval,
+	})
+}
@@ -16,7 +16,7 @@ import (
 type FundService interface {
 	// 充值相关
 	Deposit(ctx context.Context, req *types.DepositRequest, operatorID uint64) (*types.Fund, error)
-	BatchDeposit(ctx context.Context, req *types.BatchDepositRequest, operatorID uint64) ([]*types.Fund, error)
+	BatchDeposit(ctx context.Context, req *types.BatchDepositRequest, operatorID uint64) (*types.BatchDepositResult, error)
 	
 	// 权益分配
 	Allocate(ctx context.Context, req *types.AllocateRequest, operatorID uint64) (*types.Fund, error)
@@ -32,21 +32,42 @@ type FundService interface {
 	
 	// 冻结/解冻
 	FreezeMerchantBalance(ctx context.Context, merchantID uint64, action string, amount float64, reason string, operatorID uint64) error
+
+	// 大额资金审批
+	ListPendingApprovals(ctx context.Context, query *types.FundApprovalQuery) ([]*types.FundApproval, int64, error)
+	ApproveFund(ctx context.Context, approvalID, approverID uint64, comment string) (*types.FundApproval, error)
+	RejectFund(ctx context.Context, approvalID, approverID uint64, comment string) (*types.FundApproval, error)
 }
 
 // fundService 资金管理服务实现
 type fundService struct {
-	fundRepo repository.FundRepository
+	fundRepo       repository.FundRepository
+	approvalRepo   repository.FundApprovalRepository
+	batchRepo      repository.FundBatchDepositRepository
+	approvalPolicy *types.FundApprovalPolicy
 }
 
 // NewFundService 创建资金管理服务
 func NewFundService() FundService {
 	return &fundService{
-		fundRepo: repository.NewFundRepository(),
+		fundRepo:       repository.NewFundRepository(),
+		approvalRepo:   repository.NewFundApprovalRepository(),
+		batchRepo:      repository.NewFundBatchDepositRepository(),
+		approvalPolicy: LoadFundApprovalPolicy(context.Background()),
+	}
+}
+
+// NewFundServiceForTest 创建测试用资金管理服务，可指定审批策略
+func NewFundServiceForTest(fundRepo repository.FundRepository, approvalRepo repository.FundApprovalRepository, batchRepo repository.FundBatchDepositRepository, approvalPolicy *types.FundApprovalPolicy) FundService {
+	return &fundService{
+		fundRepo:       fundRepo,
+		approvalRepo:   approvalRepo,
+		batchRepo:      batchRepo,
+		approvalPolicy: approvalPolicy,
 	}
 }
 
-// Deposit 单笔资金充值
+// Deposit 单笔资金充值，金额超过审批阈值时资金记录保持待处理状态，审批通过后才入账
 func (s *fundService) Deposit(ctx context.Context, req *types.DepositRequest, operatorID uint64) (*types.Fund, error) {
 	// 获取租户ID
 	tenantID := getTenantIDFromContext(ctx)
@@ -54,7 +75,19 @@ func (s *fundService) Deposit(ctx context.Context, req *types.DepositRequest, op
 		return nil, fmt.Errorf("无效的租户上下文")
 	}
 
+	fund, approval, err := s.deposit(ctx, tenantID, req, operatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditDeposit(ctx, req, operatorID, fund, approval)
+	return fund, nil
+}
+
+// deposit 在事务中创建充值资金记录并入账，大额充值创建审批；在批量充值的事务中调用时随批量事务一起提交或回滚
+func (s *fundService) deposit(ctx context.Context, tenantID uint64, req *types.DepositRequest, operatorID uint64) (*types.Fund, *types.FundApproval, error) {
 	var fund *types.Fund
+	var approval *types.FundApproval
 	var err error
 
 	// 在事务中执行充值操作
@@ -75,83 +108,31 @@ func (s *fundService) Deposit(ctx context.Context, req *types.DepositRequest, op
 			return fmt.Errorf("创建资金记录失败: %v", err)
 		}
 
-		// 获取当前商户余额
-		balance, err := s.fundRepo.GetMerchantBalance(ctx, tenantID, req.MerchantID)
-		if err != nil {
-			return fmt.Errorf("获取商户余额失败: %v", err)
-		}
-
-		// 创建资金流转记录
-		transaction := &types.FundTransaction{
-			TenantID:        tenantID,
-			MerchantID:      req.MerchantID,
-			FundID:          fund.ID,
-			TransactionType: types.TransactionTypeCredit,
-			Amount:          req.Amount,
-			BalanceBefore:   balance.TotalBalance,
-			BalanceAfter:    balance.TotalBalance + req.Amount,
-			OperatorID:      operatorID,
-			Description:     req.Description,
-			CreatedAt:       time.Now(),
+		// 大额充值等待审批，暂不修改商户余额
+		if s.approvalPolicy.RequiresApproval(req.Amount) {
+			approval, err = s.requestApproval(ctx, fund, req.Description, operatorID)
+			return err
 		}
 
-		if err := s.fundRepo.CreateFundTransaction(ctx, transaction); err != nil {
-			return fmt.Errorf("创建流转记录失败: %v", err)
-		}
-
-		// 更新商户余额
-		balance.TotalBalance += req.Amount
-		balance.UpdateAvailableBalance()
-
-		if err := s.fundRepo.UpdateMerchantBalance(ctx, tenantID, req.MerchantID, balance); err != nil {
-			return fmt.Errorf("更新商户余额失败: %v", err)
-		}
-
-		// 更新资金记录状态为已确认
-		if err := s.fundRepo.UpdateFundStatus(ctx, tenantID, fund.ID, types.FundStatusConfirmed); err != nil {
-			return fmt.Errorf("更新资金状态失败: %v", err)
-		}
-
-		fund.Status = types.FundStatusConfirmed
-		
-		// 记录审计日志
-		audit.LogFundDeposit(ctx, tenantID, req.MerchantID, operatorID, req.Amount, req.Currency, fund.ID, req.Description)
-		
-		return nil
+		return s.creditFund(ctx, fund, operatorID, req.Description)
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
-	return fund, nil
+	return fund, approval, nil
 }
 
-// BatchDeposit 批量资金充值
-func (s *fundService) BatchDeposit(ctx context.Context, req *types.BatchDepositRequest, operatorID uint64) ([]*types.Fund, error) {
-	var results []*types.Fund
-	totalAmount := 0.0
-	var fundIDs []uint64
-
-	// 逐个处理充值请求
-	for i, deposit := range req.Deposits {
-		fund, err := s.Deposit(ctx, &deposit, operatorID)
-		if err != nil {
-			return nil, fmt.Errorf("第%d笔充值失败: %v", i+1, err)
-		}
-		results = append(results, fund)
-		totalAmount += deposit.Amount
-		fundIDs = append(fundIDs, fund.ID)
+// auditDeposit 记录充值审计日志，需在充值事务提交后调用
+func (s *fundService) auditDeposit(ctx context.Context, req *types.DepositRequest, operatorID uint64, fund *types.Fund, approval *types.FundApproval) {
+	if approval != nil {
+		audit.LogFundApprovalRequested(ctx, fund.TenantID, req.MerchantID, operatorID, approval.ID, "deposit", req.Amount, approval.RequiredApprovals)
+	} else {
+		audit.LogFundDeposit(ctx, fund.TenantID, req.MerchantID, operatorID, req.Amount, req.Currency, fund.ID, req.Description)
 	}
-
-	// 记录批量充值审计日志
-	tenantID := getTenantIDFromContext(ctx)
-	audit.LogFundBatchDeposit(ctx, tenantID, operatorID, len(req.Deposits), totalAmount, fundIDs, req)
-
-	return results, nil
 }
 
-// Allocate 权益分配
+// Allocate 权益分配，金额超过审批阈值时资金记录保持待处理状态，审批通过后才入账
 func (s *fundService) Allocate(ctx context.Context, req *types.AllocateRequest, operatorID uint64) (*types.Fund, error) {
 	// 获取租户ID
 	tenantID := getTenantIDFromContext(ctx)
@@ -160,6 +141,7 @@ func (s *fundService) Allocate(ctx context.Context, req *types.AllocateRequest,
 	}
 
 	var fund *types.Fund
+	var approval *types.FundApproval
 	var err error
 
 	// 在事务中执行分配操作
@@ -180,56 +162,70 @@ func (s *fundService) Allocate(ctx context.Context, req *types.AllocateRequest,
 			return fmt.Errorf("创建资金记录失败: %v", err)
 		}
 
-		// 获取当前商户余额
-		balance, err := s.fundRepo.GetMerchantBalance(ctx, tenantID, req.MerchantID)
-		if err != nil {
-			return fmt.Errorf("获取商户余额失败: %v", err)
+		// 大额分配等待审批，暂不修改商户余额
+		if s.approvalPolicy.RequiresApproval(req.Amount) {
+			approval, err = s.requestApproval(ctx, fund, req.Description, operatorID)
+			return err
 		}
 
-		// 创建资金流转记录
-		transaction := &types.FundTransaction{
-			TenantID:        tenantID,
-			MerchantID:      req.MerchantID,
-			FundID:          fund.ID,
-			TransactionType: types.TransactionTypeCredit,
-			Amount:          req.Amount,
-			BalanceBefore:   balance.TotalBalance,
-			BalanceAfter:    balance.TotalBalance + req.Amount,
-			OperatorID:      operatorID,
-			Description:     req.Description,
-			CreatedAt:       time.Now(),
-		}
+		return s.creditFund(ctx, fund, operatorID, req.Description)
+	})
 
-		if err := s.fundRepo.CreateFundTransaction(ctx, transaction); err != nil {
-			return fmt.Errorf("创建流转记录失败: %v", err)
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		// 更新商户余额
-		balance.TotalBalance += req.Amount
-		balance.UpdateAvailableBalance()
+	// 记录审计日志
+	if approval != nil {
+		audit.LogFundApprovalRequested(ctx, tenantID, req.MerchantID, operatorID, approval.ID, "allocate", req.Amount, approval.RequiredApprovals)
+	} else {
+		audit.LogFundAllocate(ctx, tenantID, req.MerchantID, operatorID, req.Amount, fund.ID, req.Description)
+	}
 
-		if err := s.fundRepo.UpdateMerchantBalance(ctx, tenantID, req.MerchantID, balance); err != nil {
-			return fmt.Errorf("更新商户余额失败: %v", err)
-		}
+	return fund, nil
+}
 
-		// 更新资金记录状态为已确认
-		if err := s.fundRepo.UpdateFundStatus(ctx, tenantID, fund.ID, types.FundStatusConfirmed); err != nil {
-			return fmt.Errorf("更新资金状态失败: %v", err)
-		}
+// creditFund 资金记录入账：记录流转、增加商户余额并确认资金记录，需在事务中调用
+func (s *fundService) creditFund(ctx context.Context, fund *types.Fund, operatorID uint64, description string) error {
+	// 锁定商户余额，避免并发入账时余额被覆盖
+	balance, err := s.fundRepo.GetMerchantBalanceForUpdate(ctx, fund.TenantID, fund.MerchantID)
+	if err != nil {
+		return fmt.Errorf("获取商户余额失败: %v", err)
+	}
 
-		fund.Status = types.FundStatusConfirmed
-		
-		// 记录审计日志
-		audit.LogFundAllocate(ctx, tenantID, req.MerchantID, operatorID, req.Amount, fund.ID, req.Description)
-		
-		return nil
-	})
+	// 创建资金流转记录
+	transaction := &types.FundTransaction{
+		TenantID:        fund.TenantID,
+		MerchantID:      fund.MerchantID,
+		FundID:          fund.ID,
+		TransactionType: types.TransactionTypeCredit,
+		Amount:          fund.Amount,
+		BalanceBefore:   balance.TotalBalance,
+		BalanceAfter:    balance.TotalBalance + fund.Amount,
+		OperatorID:      operatorID,
+		Description:     description,
+		CreatedAt:       time.Now(),
+	}
 
-	if err != nil {
-		return nil, err
+	if err := s.fundRepo.CreateFundTransaction(ctx, transaction); err != nil {
+		return fmt.Errorf("创建流转记录失败: %v", err)
 	}
 
-	return fund, nil
+	// 更新商户余额
+	balance.TotalBalance += fund.Amount
+	balance.UpdateAvailableBalance()
+
+	if err := s.fundRepo.UpdateMerchantBalance(ctx, fund.TenantID, fund.MerchantID, balance); err != nil {
+		return fmt.Errorf("更新商户余额失败: %v", err)
+	}
+
+	// 更新资金记录状态为已确认
+	if err := s.fundRepo.UpdateFundStatus(ctx, fund.TenantID, fund.ID, types.FundStatusConfirmed); err != nil {
+		return fmt.Errorf("更新资金状态失败: %v", err)
+	}
+
+	fund.Status = types.FundStatusConfirmed
+	return nil
 }
 
 // GetMerchantBalance 获取商户权益余额
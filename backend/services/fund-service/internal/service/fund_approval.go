@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+
+	"mer-demo/shared/audit"
+	"mer-demo/shared/types"
+)
+
+var (
+	// ErrFundApprovalNotFound 审批记录不存在
+	ErrFundApprovalNotFound = errors.New("资金审批记录不存在")
+	// ErrFundApprovalNotPending 审批已完成或已驳回
+	ErrFundApprovalNotPending = errors.New("资金审批已结束")
+	// ErrFundApprovalSelfApprove 发起人不能审批自己提交的资金操作
+	ErrFundApprovalSelfApprove = errors.New("不能审批自己提交的资金操作")
+	// ErrFundApprovalDuplicate 同一审批人只能审批一次
+	ErrFundApprovalDuplicate = errors.New("已审批过该资金操作")
+)
+
+const defaultFundApprovers = 2
+
+// LoadFundApprovalPolicy 读取大额资金审批策略，未配置阈值时不启用审批
+func LoadFundApprovalPolicy(ctx context.Context) *types.FundApprovalPolicy {
+	policy := &types.FundApprovalPolicy{
+		Threshold:         g.Cfg().MustGet(ctx, "fund.approval.threshold", 0).Float64(),
+		RequiredApprovers: g.Cfg().MustGet(ctx, "fund.approval.requiredApprovers", defaultFundApprovers).Int(),
+	}
+	if err := policy.Validate(); err != nil {
+		g.Log().Errorf(ctx, "大额资金审批配置无效，不启用审批: %v", err)
+		return nil
+	}
+	return policy
+}
+
+// requestApproval 为超过阈值的资金记录创建审批，需在事务中调用
+func (s *fundService) requestApproval(ctx context.Context, fund *types.Fund, description string, operatorID uint64) (*types.FundApproval, error) {
+	approval := &types.FundApproval{
+		MerchantID:        fund.MerchantID,
+		FundID:            fund.ID,
+		FundType:          fund.FundType,
+		Amount:            fund.Amount,
+		Currency:          fund.Currency,
+		Description:       description,
+		RequestedBy:       operatorID,
+		RequiredApprovals: s.approvalPolicy.Approvers(),
+		Status:            types.FundApprovalStatusPending,
+	}
+	if err := s.approvalRepo.CreateApproval(ctx, approval); err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+// ListPendingApprovals 查询当前租户待审批的资金操作
+func (s *fundService) ListPendingApprovals(ctx context.Context, query *types.FundApprovalQuery) ([]*types.FundApproval, int64, error) {
+	tenantID := getTenantIDFromContext(ctx)
+	if tenantID == 0 {
+		return nil, 0, fmt.Errorf("无效的租户上下文")
+	}
+	return s.approvalRepo.ListPendingApprovals(ctx, tenantID, query)
+}
+
+// ApproveFund 审批通过，审批人数达到要求时资金入账
+func (s *fundService) ApproveFund(ctx context.Context, approvalID, approverID uint64, comment string) (*types.FundApproval, error) {
+	return s.decide(ctx, approvalID, approverID, types.FundApprovalDecisionApprove, comment)
+}
+
+// RejectFund 驳回审批，资金记录取消，商户余额不变
+func (s *fundService) RejectFund(ctx context.Context, approvalID, approverID uint64, comment string) (*types.FundApproval, error) {
+	return s.decide(ctx, approvalID, approverID, types.FundApprovalDecisionReject, comment)
+}
+
+// decide 在审批记录行锁下记录审批意见并推进审批状态，避免并发审批重复入账
+func (s *fundService) decide(ctx context.Context, approvalID, approverID uint64, decision types.FundApprovalDecisionType, comment string) (*types.FundApproval, error) {
+	tenantID := getTenantIDFromContext(ctx)
+	if tenantID == 0 {
+		return nil, fmt.Errorf("无效的租户上下文")
+	}
+
+	var approval *types.FundApproval
+	err := s.fundRepo.WithTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		var err error
+		approval, err = s.approvalRepo.GetApprovalForUpdate(ctx, tenantID, approvalID)
+		if err != nil {
+			return err
+		}
+		if approval == nil {
+			return ErrFundApprovalNotFound
+		}
+		if !approval.IsPending() {
+			return fmt.Errorf("%w: %s", ErrFundApprovalNotPending, approval.Status)
+		}
+		if approval.RequestedBy == approverID {
+			return ErrFundApprovalSelfApprove
+		}
+
+		decided, err := s.approvalRepo.HasDecision(ctx, tenantID, approvalID, approverID)
+		if err != nil {
+			return err
+		}
+		if decided {
+			return ErrFundApprovalDuplicate
+		}
+
+		if err := s.approvalRepo.CreateDecision(ctx, &types.FundApprovalDecision{
+			TenantID:   tenantID,
+			ApprovalID: approvalID,
+			ApproverID: approverID,
+			Decision:   decision,
+			Comment:    comment,
+		}); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if decision == types.FundApprovalDecisionReject {
+			approval.Status = types.FundApprovalStatusRejected
+			approval.DecidedAt = &now
+			if err := s.fundRepo.UpdateFundStatus(ctx, tenantID, approval.FundID, types.FundStatusCancelled); err != nil {
+				return fmt.Errorf("取消资金记录失败: %v", err)
+			}
+			return s.approvalRepo.UpdateApprovalProgress(ctx, approval)
+		}
+
+		approval.ApprovalCount++
+		if approval.ApprovalCount >= approval.RequiredApprovals {
+			fund, err := s.fundRepo.GetFundByID(ctx, tenantID, approval.FundID)
+			if err != nil {
+				return err
+			}
+			if fund == nil {
+				return fmt.Errorf("资金记录%d不存在", approval.FundID)
+			}
+			// 流转记录的操作人为发起人，审批人记录在审批意见中
+			if err := s.creditFund(ctx, fund, approval.RequestedBy, approval.Description); err != nil {
+				return err
+			}
+			approval.Status = types.FundApprovalStatusApproved
+			approval.DecidedAt = &now
+		}
+		return s.approvalRepo.UpdateApprovalProgress(ctx, approval)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	approved := decision == types.FundApprovalDecisionApprove
+	completed := !approval.IsPending()
+	audit.LogFundApprovalDecision(ctx, tenantID, approval.MerchantID, approverID, approval.ID, approved,
+		approval.ApprovalCount, approval.RequiredApprovals, completed, comment)
+	if approved && completed {
+		if approval.FundType == types.FundTypeDeposit {
+			audit.LogFundDeposit(ctx, tenantID, approval.MerchantID, approval.RequestedBy, approval.Amount, approval.Currency, approval.FundID, approval.Description)
+		} else {
+			audit.LogFundAllocate(ctx, tenantID, approval.MerchantID, approval.RequestedBy, approval.Amount, approval.FundID, approval.Description)
+		}
+	}
+
+	return approval, nil
+}
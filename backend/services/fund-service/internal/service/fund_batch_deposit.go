@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+
+	"mer-demo/shared/audit"
+	"mer-demo/shared/types"
+)
+
+// BatchDeposit 批量资金充值。尽力模式下每笔充值独立入账，失败的充值记录在结果中不影响其他充值；
+// 全部或全不模式下所有充值在同一事务中入账，任一笔失败整批回滚。
+// 携带幂等键时先登记幂等键再入账，相同幂等键重复提交返回首次处理的结果，不会重复入账
+func (s *fundService) BatchDeposit(ctx context.Context, req *types.BatchDepositRequest, operatorID uint64) (*types.BatchDepositResult, error) {
+	tenantID := getTenantIDFromContext(ctx)
+	if tenantID == 0 {
+		return nil, fmt.Errorf("无效的租户上下文")
+	}
+
+	var batch *types.FundBatchDeposit
+	if req.IdempotencyKey != "" {
+		var replayed *types.BatchDepositResult
+		var err error
+		batch, replayed, err = s.reserveBatch(ctx, tenantID, req, operatorID)
+		if err != nil {
+			return nil, err
+		}
+		if replayed != nil {
+			return replayed, nil
+		}
+	}
+
+	var result *types.BatchDepositResult
+	if req.DepositMode() == types.BatchDepositModeAllOrNothing {
+		result = s.depositAllOrNothing(ctx, tenantID, req, operatorID)
+	} else {
+		result = s.depositBestEffort(ctx, tenantID, req, operatorID)
+	}
+	result.Summarize()
+
+	if batch != nil {
+		result.BatchID = batch.ID
+		s.completeBatch(ctx, batch, result)
+	}
+
+	// 审计记录实际入账的笔数和金额，整批回滚时成功笔数为 0
+	audit.LogFundBatchDeposit(ctx, tenantID, operatorID, string(result.Mode), result.SucceededCount, result.FailedCount,
+		result.AppliedAmount, result.FundIDs(), result)
+
+	return result, nil
+}
+
+// depositBestEffort 逐笔在独立事务中充值，失败的充值不影响其他充值
+func (s *fundService) depositBestEffort(ctx context.Context, tenantID uint64, req *types.BatchDepositRequest, operatorID uint64) *types.BatchDepositResult {
+	result := newBatchDepositResult(req)
+	for i := range req.Deposits {
+		fund, approval, err := s.deposit(ctx, tenantID, &req.Deposits[i], operatorID)
+		if err != nil {
+			result.Items[i].Error = err.Error()
+			continue
+		}
+		succeedBatchItem(&result.Items[i], fund)
+		s.auditDeposit(ctx, &req.Deposits[i], operatorID, fund, approval)
+	}
+	return result
+}
+
+// depositAllOrNothing 所有充值在同一事务中执行，任一笔失败整批回滚，事务提交后才记录各笔充值的审计日志
+func (s *fundService) depositAllOrNothing(ctx context.Context, tenantID uint64, req *types.BatchDepositRequest, operatorID uint64) *types.BatchDepositResult {
+	result := newBatchDepositResult(req)
+
+	type appliedDeposit struct {
+		index    int
+		fund     *types.Fund
+		approval *types.FundApproval
+	}
+	var applied []appliedDeposit
+	failedIndex := -1
+
+	err := s.fundRepo.WithTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		for _, i := range merchantLockOrder(req.Deposits) {
+			fund, approval, err := s.deposit(ctx, tenantID, &req.Deposits[i], operatorID)
+			if err != nil {
+				failedIndex = i
+				return err
+			}
+			applied = append(applied, appliedDeposit{index: i, fund: fund, approval: approval})
+		}
+		return nil
+	})
+
+	if err != nil {
+		for i := range result.Items {
+			item := &result.Items[i]
+			switch {
+			case i == failedIndex:
+				item.Error = err.Error()
+			case failedIndex < 0:
+				item.RolledBack = true
+				item.Error = fmt.Sprintf("批量充值事务提交失败，整批未入账: %v", err)
+			default:
+				item.RolledBack = true
+				item.Error = fmt.Sprintf("第%d笔充值失败，整批未入账", failedIndex+1)
+			}
+		}
+		return result
+	}
+
+	for _, deposit := range applied {
+		succeedBatchItem(&result.Items[deposit.index], deposit.fund)
+		s.auditDeposit(ctx, &req.Deposits[deposit.index], operatorID, deposit.fund, deposit.approval)
+	}
+	return result
+}
+
+// reserveBatch 登记幂等键。幂等键已处理完成时返回首次处理的结果；仍在处理或被内容不同的请求复用时返回错误
+func (s *fundService) reserveBatch(ctx context.Context, tenantID uint64, req *types.BatchDepositRequest, operatorID uint64) (*types.FundBatchDeposit, *types.BatchDepositResult, error) {
+	batch := &types.FundBatchDeposit{
+		TenantID:       tenantID,
+		IdempotencyKey: req.IdempotencyKey,
+		Mode:           req.DepositMode(),
+		Status:         types.BatchDepositStatusProcessing,
+		RequestHash:    req.Fingerprint(),
+		OperatorID:     operatorID,
+	}
+	created, err := s.batchRepo.CreateBatch(ctx, batch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if created {
+		return batch, nil, nil
+	}
+
+	existing, err := s.batchRepo.GetBatchByKey(ctx, tenantID, req.IdempotencyKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing == nil {
+		return nil, nil, fmt.Errorf("%w: %s", types.ErrBatchDepositInProgress, req.IdempotencyKey)
+	}
+	if existing.RequestHash != batch.RequestHash {
+		return nil, nil, fmt.Errorf("%w: %s", types.ErrBatchDepositKeyReused, req.IdempotencyKey)
+	}
+	if existing.Status == types.BatchDepositStatusProcessing || existing.ResultJSON == "" {
+		return nil, nil, fmt.Errorf("%w: %s", types.ErrBatchDepositInProgress, req.IdempotencyKey)
+	}
+
+	var replayed types.BatchDepositResult
+	if err := json.Unmarshal([]byte(existing.ResultJSON), &replayed); err != nil {
+		return nil, nil, fmt.Errorf("解析批量充值结果失败: %v", err)
+	}
+	replayed.Replayed = true
+	return nil, &replayed, nil
+}
+
+// completeBatch 记录批量充值结果。充值已提交，记录失败时仅记录日志，幂等键保持处理中以阻止重复入账
+func (s *fundService) completeBatch(ctx context.Context, batch *types.FundBatchDeposit, result *types.BatchDepositResult) {
+	payload, err := json.Marshal(result)
+	if err == nil {
+		err = s.batchRepo.CompleteBatch(ctx, batch.TenantID, batch.ID, result.Status, string(payload))
+	}
+	if err != nil {
+		g.Log().Errorf(ctx, "记录批量充值结果失败: batchID=%d, idempotencyKey=%s, err=%v", batch.ID, batch.IdempotencyKey, err)
+	}
+}
+
+// newBatchDepositResult 按请求顺序初始化各笔充值的结果
+func newBatchDepositResult(req *types.BatchDepositRequest) *types.BatchDepositResult {
+	result := &types.BatchDepositResult{
+		IdempotencyKey: req.IdempotencyKey,
+		Mode:           req.DepositMode(),
+		Items:          make([]types.BatchDepositItemResult, len(req.Deposits)),
+	}
+	for i, deposit := range req.Deposits {
+		result.Items[i] = types.BatchDepositItemResult{
+			Index:      i + 1,
+			MerchantID: deposit.MerchantID,
+			Amount:     deposit.Amount,
+			Currency:   deposit.Currency,
+		}
+	}
+	return result
+}
+
+// succeedBatchItem 记录充值成功的资金记录
+func succeedBatchItem(item *types.BatchDepositItemResult, fund *types.Fund) {
+	item.Success = true
+	item.FundID = fund.ID
+	item.FundStatus = fund.Status
+}
+
+// merchantLockOrder 按商户ID排序的充值处理顺序，并发的整批事务按相同顺序锁定商户余额，避免死锁
+func merchantLockOrder(deposits []types.DepositRequest) []int {
+	order := make([]int, len(deposits))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return deposits[order[a]].MerchantID < deposits[order[b]].MerchantID
+	})
+	return order
+}
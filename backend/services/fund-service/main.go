@@ -5,12 +5,17 @@ import (
 	"github.com/gogf/gf/v2/net/ghttp"
 
 	"mer-demo/services/fund-service/internal/controller"
+	"mer-demo/shared/auth"
+	"mer-demo/shared/handlers"
 	"mer-demo/shared/middleware"
 )
 
 func main() {
 	// 创建HTTP服务器
 	server := g.Server()
+
+	// 应用连接池配置并暴露连接池指标
+	handlers.RegisterDBPoolMetrics(server, "fund-service")
 	
 	// 配置CORS
 	server.Use(middleware.CORS())
@@ -33,6 +38,7 @@ func main() {
 func registerRoutes(server *ghttp.Server) {
 	// 创建控制器实例
 	fundController := controller.NewFundController()
+	authMiddleware := middleware.NewAuthMiddleware()
 	
 	// API v1 路由组
 	v1 := server.Group("/api/v1")
@@ -56,8 +62,15 @@ func registerRoutes(server *ghttp.Server) {
 			// 资金概览统计 (需要查看权限)
 			funds.GET("/summary", middleware.RequireFundView, fundController.GetSummary)
 			
-			// 冻结/解冻权益 (需要冻结权限)
-			funds.PUT("/freeze/:merchant_id", middleware.RequireFundFreeze, fundController.FreezeBalance)
+			// 冻结/解冻权益 (需要冻结权限，且需近期重新验证身份)
+			freeze := funds.Group("/freeze")
+			freeze.Middleware(middleware.RequireFundFreeze, authMiddleware.RequireStepUp(auth.StepUpOperationFundFreeze))
+			freeze.PUT("/:merchant_id", fundController.FreezeBalance)
+			
+			// 大额资金审批 (需要审批权限)
+			funds.GET("/approvals", middleware.RequireFundApprove, fundController.ListPendingApprovals)
+			funds.POST("/approvals/:id/approve", middleware.RequireFundApprove, fundController.ApproveFund)
+			funds.POST("/approvals/:id/reject", middleware.RequireFundApprove, fundController.RejectFund)
 		}
 		
 		// 健康检查
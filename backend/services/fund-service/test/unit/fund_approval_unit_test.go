@@ -0,0 +1,291 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gogf/gf/v2/database/gdb"
+
+	"mer-demo/services/fund-service/internal/service"
+	"mer-demo/shared/repository"
+	"mer-demo/shared/types"
+)
+
+// memoryFundRepository 内存资金仓储，事务返回错误时恢复到事务开始前的状态
+type memoryFundRepository struct {
+	repository.FundRepository
+	funds           map[uint64]*types.Fund
+	transactions    []*types.FundTransaction
+	balance         *types.RightsBalance
+	failedMerchants map[uint64]bool // 查询余额失败的商户
+}
+
+func newMemoryFundRepository() *memoryFundRepository {
+	return &memoryFundRepository{
+		funds:   make(map[uint64]*types.Fund),
+		balance: &types.RightsBalance{TotalBalance: 1000},
+	}
+}
+
+func (m *memoryFundRepository) CreateFund(ctx context.Context, fund *types.Fund) error {
+	fund.ID = uint64(len(m.funds) + 1)
+	copied := *fund
+	m.funds[fund.ID] = &copied
+	return nil
+}
+
+func (m *memoryFundRepository) GetFundByID(ctx context.Context, tenantID, fundID uint64) (*types.Fund, error) {
+	fund, exists := m.funds[fundID]
+	if !exists {
+		return nil, nil
+	}
+	copied := *fund
+	return &copied, nil
+}
+
+func (m *memoryFundRepository) UpdateFundStatus(ctx context.Context, tenantID, fundID uint64, status types.FundStatus) error {
+	m.funds[fundID].Status = status
+	return nil
+}
+
+func (m *memoryFundRepository) CreateFundTransaction(ctx context.Context, transaction *types.FundTransaction) error {
+	m.transactions = append(m.transactions, transaction)
+	return nil
+}
+
+func (m *memoryFundRepository) GetMerchantBalance(ctx context.Context, tenantID, merchantID uint64) (*types.RightsBalance, error) {
+	copied := *m.balance
+	return &copied, nil
+}
+
+func (m *memoryFundRepository) GetMerchantBalanceForUpdate(ctx context.Context, tenantID, merchantID uint64) (*types.RightsBalance, error) {
+	if m.failedMerchants[merchantID] {
+		return nil, errors.New("商户不存在")
+	}
+	return m.GetMerchantBalance(ctx, tenantID, merchantID)
+}
+
+func (m *memoryFundRepository) UpdateMerchantBalance(ctx context.Context, tenantID, merchantID uint64, balance *types.RightsBalance) error {
+	copied := *balance
+	m.balance = &copied
+	return nil
+}
+
+func (m *memoryFundRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context, tx gdb.TX) error) error {
+	funds := make(map[uint64]*types.Fund, len(m.funds))
+	for id, fund := range m.funds {
+		copied := *fund
+		funds[id] = &copied
+	}
+	transactions := len(m.transactions)
+	balance := *m.balance
+
+	if err := fn(ctx, nil); err != nil {
+		m.funds, m.transactions, m.balance = funds, m.transactions[:transactions], &balance
+		return err
+	}
+	return nil
+}
+
+// memoryFundApprovalRepository 内存资金审批仓储
+type memoryFundApprovalRepository struct {
+	approvals map[uint64]*types.FundApproval
+	decisions []*types.FundApprovalDecision
+}
+
+func (m *memoryFundApprovalRepository) CreateApproval(ctx context.Context, approval *types.FundApproval) error {
+	approval.ID = uint64(len(m.approvals) + 1)
+	approval.TenantID = 1
+	copied := *approval
+	m.approvals[approval.ID] = &copied
+	return nil
+}
+
+func (m *memoryFundApprovalRepository) GetApprovalForUpdate(ctx context.Context, tenantID, approvalID uint64) (*types.FundApproval, error) {
+	approval, exists := m.approvals[approvalID]
+	if !exists {
+		return nil, nil
+	}
+	copied := *approval
+	return &copied, nil
+}
+
+func (m *memoryFundApprovalRepository) ListPendingApprovals(ctx context.Context, tenantID uint64, query *types.FundApprovalQuery) ([]*types.FundApproval, int64, error) {
+	var pending []*types.FundApproval
+	for _, approval := range m.approvals {
+		if approval.IsPending() {
+			pending = append(pending, approval)
+		}
+	}
+	return pending, int64(len(pending)), nil
+}
+
+func (m *memoryFundApprovalRepository) UpdateApprovalProgress(ctx context.Context, approval *types.FundApproval) error {
+	copied := *approval
+	m.approvals[approval.ID] = &copied
+	return nil
+}
+
+func (m *memoryFundApprovalRepository) CreateDecision(ctx context.Context, decision *types.FundApprovalDecision) error {
+	m.decisions = append(m.decisions, decision)
+	return nil
+}
+
+func (m *memoryFundApprovalRepository) HasDecision(ctx context.Context, tenantID, approvalID, approverID uint64) (bool, error) {
+	for _, decision := range m.decisions {
+		if decision.ApprovalID == approvalID && decision.ApproverID == approverID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newApprovalTestService() (service.FundService, *memoryFundRepository, *memoryFundApprovalRepository) {
+	fundRepo := newMemoryFundRepository()
+	approvalRepo := &memoryFundApprovalRepository{approvals: make(map[uint64]*types.FundApproval)}
+	policy := &types.FundApprovalPolicy{Threshold: 10000, RequiredApprovers: 2}
+	return service.NewFundServiceForTest(fundRepo, approvalRepo, newMemoryFundBatchDepositRepository(), policy), fundRepo, approvalRepo
+}
+
+// TestFundApprovalThreshold 测试审批阈值边界
+func TestFundApprovalThreshold(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+	tests := []struct {
+		name         string
+		amount       float64
+		wantStatus   types.FundStatus
+		wantBalance  float64
+		wantApproval bool
+	}{
+		{name: "低于阈值直接入账", amount: 9999, wantStatus: types.FundStatusConfirmed, wantBalance: 10999},
+		{name: "等于阈值直接入账", amount: 10000, wantStatus: types.FundStatusConfirmed, wantBalance: 11000},
+		{name: "超过阈值等待审批", amount: 10000.01, wantStatus: types.FundStatusPending, wantBalance: 1000, wantApproval: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fundService, fundRepo, approvalRepo := newApprovalTestService()
+
+			fund, err := fundService.Deposit(ctx, &types.DepositRequest{MerchantID: 1, Amount: tt.amount, Currency: "CNY"}, 100)
+			if err != nil {
+				t.Fatalf("Deposit() error = %v", err)
+			}
+			if fund.Status != tt.wantStatus {
+				t.Errorf("fund status = %v, want %v", fund.Status, tt.wantStatus)
+			}
+			if fundRepo.balance.TotalBalance != tt.wantBalance {
+				t.Errorf("balance = %v, want %v", fundRepo.balance.TotalBalance, tt.wantBalance)
+			}
+			if got := len(approvalRepo.approvals) == 1; got != tt.wantApproval {
+				t.Errorf("approval created = %v, want %v", got, tt.wantApproval)
+			}
+		})
+	}
+
+	t.Run("大额权益分配同样需要审批", func(t *testing.T) {
+		fundService, fundRepo, approvalRepo := newApprovalTestService()
+
+		fund, err := fundService.Allocate(ctx, &types.AllocateRequest{MerchantID: 1, Amount: 20000}, 100)
+		if err != nil {
+			t.Fatalf("Allocate() error = %v", err)
+		}
+		if fund.Status != types.FundStatusPending || fundRepo.balance.TotalBalance != 1000 {
+			t.Errorf("fund status = %v, balance = %v", fund.Status, fundRepo.balance.TotalBalance)
+		}
+		if approvalRepo.approvals[1].FundType != types.FundTypeAllocation {
+			t.Errorf("approval fund type = %v, want allocation", approvalRepo.approvals[1].FundType)
+		}
+	})
+}
+
+// TestFundApprovalWorkflow 测试多人审批完成入账和驳回
+func TestFundApprovalWorkflow(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+	t.Run("审批人数满足后入账", func(t *testing.T) {
+		fundService, fundRepo, _ := newApprovalTestService()
+		fund, err := fundService.Deposit(ctx, &types.DepositRequest{MerchantID: 1, Amount: 50000, Currency: "CNY"}, 100)
+		if err != nil {
+			t.Fatalf("Deposit() error = %v", err)
+		}
+
+		// 发起人不能审批自己的操作
+		if _, err := fundService.ApproveFund(ctx, 1, 100, ""); !errors.Is(err, service.ErrFundApprovalSelfApprove) {
+			t.Errorf("self approve error = %v, want ErrFundApprovalSelfApprove", err)
+		}
+
+		approval, err := fundService.ApproveFund(ctx, 1, 201, "核对无误")
+		if err != nil {
+			t.Fatalf("first ApproveFund() error = %v", err)
+		}
+		if approval.ApprovalCount != 1 || !approval.IsPending() {
+			t.Errorf("after first approval: count = %d, status = %v", approval.ApprovalCount, approval.Status)
+		}
+		if fundRepo.balance.TotalBalance != 1000 {
+			t.Errorf("balance credited before enough approvals: %v", fundRepo.balance.TotalBalance)
+		}
+
+		// 同一审批人不能重复审批
+		if _, err := fundService.ApproveFund(ctx, 1, 201, ""); !errors.Is(err, service.ErrFundApprovalDuplicate) {
+			t.Errorf("duplicate approve error = %v, want ErrFundApprovalDuplicate", err)
+		}
+
+		approval, err = fundService.ApproveFund(ctx, 1, 202, "")
+		if err != nil {
+			t.Fatalf("second ApproveFund() error = %v", err)
+		}
+		if approval.Status != types.FundApprovalStatusApproved || approval.DecidedAt == nil {
+			t.Errorf("after second approval: status = %v", approval.Status)
+		}
+		if fundRepo.balance.TotalBalance != 51000 {
+			t.Errorf("balance = %v, want 51000", fundRepo.balance.TotalBalance)
+		}
+		if fundRepo.funds[fund.ID].Status != types.FundStatusConfirmed {
+			t.Errorf("fund status = %v, want confirmed", fundRepo.funds[fund.ID].Status)
+		}
+		if len(fundRepo.transactions) != 1 || fundRepo.transactions[0].OperatorID != 100 {
+			t.Errorf("transactions = %+v, want one credit by requester", fundRepo.transactions)
+		}
+
+		// 审批完成后不能继续审批
+		if _, err := fundService.ApproveFund(ctx, 1, 203, ""); !errors.Is(err, service.ErrFundApprovalNotPending) {
+			t.Errorf("approve after completion error = %v, want ErrFundApprovalNotPending", err)
+		}
+	})
+
+	t.Run("驳回后取消资金记录且不入账", func(t *testing.T) {
+		fundService, fundRepo, _ := newApprovalTestService()
+		fund, err := fundService.Deposit(ctx, &types.DepositRequest{MerchantID: 1, Amount: 50000, Currency: "CNY"}, 100)
+		if err != nil {
+			t.Fatalf("Deposit() error = %v", err)
+		}
+
+		if _, err := fundService.ApproveFund(ctx, 1, 201, ""); err != nil {
+			t.Fatalf("ApproveFund() error = %v", err)
+		}
+		approval, err := fundService.RejectFund(ctx, 1, 202, "金额有误")
+		if err != nil {
+			t.Fatalf("RejectFund() error = %v", err)
+		}
+		if approval.Status != types.FundApprovalStatusRejected {
+			t.Errorf("status = %v, want rejected", approval.Status)
+		}
+		if fundRepo.funds[fund.ID].Status != types.FundStatusCancelled || fundRepo.balance.TotalBalance != 1000 {
+			t.Errorf("fund status = %v, balance = %v", fundRepo.funds[fund.ID].Status, fundRepo.balance.TotalBalance)
+		}
+
+		pending, total, err := fundService.ListPendingApprovals(ctx, &types.FundApprovalQuery{})
+		if err != nil || total != 0 || len(pending) != 0 {
+			t.Errorf("pending = %v, total = %d, err = %v", pending, total, err)
+		}
+	})
+
+	t.Run("审批记录不存在", func(t *testing.T) {
+		fundService, _, _ := newApprovalTestService()
+		if _, err := fundService.ApproveFund(ctx, 99, 201, ""); !errors.Is(err, service.ErrFundApprovalNotFound) {
+			t.Errorf("error = %v, want ErrFundApprovalNotFound", err)
+		}
+	})
+}
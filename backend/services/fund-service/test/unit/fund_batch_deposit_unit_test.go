@@ -0,0 +1,264 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"mer-demo/services/fund-service/internal/service"
+	"mer-demo/shared/types"
+)
+
+// memoryFundBatchDepositRepository 内存批量充值幂等记录仓储
+type memoryFundBatchDepositRepository struct {
+	mutex   sync.Mutex
+	batches map[string]*types.FundBatchDeposit
+}
+
+func newMemoryFundBatchDepositRepository() *memoryFundBatchDepositRepository {
+	return &memoryFundBatchDepositRepository{batches: make(map[string]*types.FundBatchDeposit)}
+}
+
+func (m *memoryFundBatchDepositRepository) CreateBatch(ctx context.Context, batch *types.FundBatchDeposit) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, exists := m.batches[batch.IdempotencyKey]; exists {
+		return false, nil
+	}
+	batch.ID = uint64(len(m.batches) + 1)
+	copied := *batch
+	m.batches[batch.IdempotencyKey] = &copied
+	return true, nil
+}
+
+func (m *memoryFundBatchDepositRepository) GetBatchByKey(ctx context.Context, tenantID uint64, idempotencyKey string) (*types.FundBatchDeposit, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	batch, exists := m.batches[idempotencyKey]
+	if !exists {
+		return nil, nil
+	}
+	copied := *batch
+	return &copied, nil
+}
+
+func (m *memoryFundBatchDepositRepository) CompleteBatch(ctx context.Context, tenantID, batchID uint64, status types.BatchDepositStatus, resultJSON string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, batch := range m.batches {
+		if batch.ID == batchID {
+			batch.Status = status
+			batch.ResultJSON = resultJSON
+		}
+	}
+	return nil
+}
+
+// newBatchDepositTestService 商户 2 的充值会失败
+func newBatchDepositTestService() (service.FundService, *memoryFundRepository, *memoryFundBatchDepositRepository) {
+	fundRepo := newMemoryFundRepository()
+	fundRepo.failedMerchants = map[uint64]bool{2: true}
+	batchRepo := newMemoryFundBatchDepositRepository()
+	approvalRepo := &memoryFundApprovalRepository{approvals: make(map[uint64]*types.FundApproval)}
+	return service.NewFundServiceForTest(fundRepo, approvalRepo, batchRepo, nil), fundRepo, batchRepo
+}
+
+// batchWithFailure 第 2 笔充值失败的批量充值请求
+func batchWithFailure(mode types.BatchDepositMode, idempotencyKey string) *types.BatchDepositRequest {
+	return &types.BatchDepositRequest{
+		Mode:           mode,
+		IdempotencyKey: idempotencyKey,
+		Deposits: []types.DepositRequest{
+			{MerchantID: 3, Amount: 100, Currency: "CNY"},
+			{MerchantID: 2, Amount: 200, Currency: "CNY"},
+			{MerchantID: 1, Amount: 300, Currency: "CNY"},
+		},
+	}
+}
+
+// TestBatchDepositModes 测试尽力模式和全部或全不模式下一笔充值失败的处理
+func TestBatchDepositModes(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+	t.Run("尽力模式入账成功的充值并返回失败的充值", func(t *testing.T) {
+		fundService, fundRepo, _ := newBatchDepositTestService()
+
+		result, err := fundService.BatchDeposit(ctx, batchWithFailure("", ""), 100)
+		if err != nil {
+			t.Fatalf("BatchDeposit() error = %v", err)
+		}
+		if result.Mode != types.BatchDepositModeBestEffort || result.Status != types.BatchDepositStatusPartial {
+			t.Errorf("mode = %v, status = %v, want best_effort partial", result.Mode, result.Status)
+		}
+		if result.SucceededCount != 2 || result.FailedCount != 1 || result.AppliedAmount != 400 {
+			t.Errorf("succeeded = %d, failed = %d, applied = %v", result.SucceededCount, result.FailedCount, result.AppliedAmount)
+		}
+
+		failed := result.Items[1]
+		if failed.Success || failed.RolledBack || !strings.Contains(failed.Error, "商户不存在") {
+			t.Errorf("failed item = %+v", failed)
+		}
+		for _, i := range []int{0, 2} {
+			item := result.Items[i]
+			if !item.Success || item.FundID == 0 || item.FundStatus != types.FundStatusConfirmed {
+				t.Errorf("item %d = %+v, want confirmed", i+1, item)
+			}
+		}
+		if got := result.FundIDs(); len(got) != 2 {
+			t.Errorf("fund ids = %v, want 2", got)
+		}
+
+		if fundRepo.balance.TotalBalance != 1400 || len(fundRepo.transactions) != 2 || len(fundRepo.funds) != 2 {
+			t.Errorf("balance = %v, transactions = %d, funds = %d", fundRepo.balance.TotalBalance, len(fundRepo.transactions), len(fundRepo.funds))
+		}
+	})
+
+	t.Run("全部或全不模式任一笔失败整批回滚", func(t *testing.T) {
+		fundService, fundRepo, _ := newBatchDepositTestService()
+
+		result, err := fundService.BatchDeposit(ctx, batchWithFailure(types.BatchDepositModeAllOrNothing, ""), 100)
+		if err != nil {
+			t.Fatalf("BatchDeposit() error = %v", err)
+		}
+		if result.Status != types.BatchDepositStatusFailed {
+			t.Errorf("status = %v, want failed", result.Status)
+		}
+		if result.SucceededCount != 0 || result.FailedCount != 3 || result.AppliedAmount != 0 || len(result.FundIDs()) != 0 {
+			t.Errorf("succeeded = %d, failed = %d, applied = %v", result.SucceededCount, result.FailedCount, result.AppliedAmount)
+		}
+
+		if failed := result.Items[1]; failed.RolledBack || !strings.Contains(failed.Error, "商户不存在") {
+			t.Errorf("failed item = %+v", failed)
+		}
+		for _, i := range []int{0, 2} {
+			item := result.Items[i]
+			if item.Success || !item.RolledBack || item.FundID != 0 || !strings.Contains(item.Error, "第2笔充值失败") {
+				t.Errorf("item %d = %+v, want rolled back", i+1, item)
+			}
+		}
+
+		// 失败前已入账的商户 1 的充值随整批回滚
+		if fundRepo.balance.TotalBalance != 1000 || len(fundRepo.transactions) != 0 || len(fundRepo.funds) != 0 {
+			t.Errorf("balance = %v, transactions = %d, funds = %d", fundRepo.balance.TotalBalance, len(fundRepo.transactions), len(fundRepo.funds))
+		}
+	})
+
+	t.Run("全部或全不模式全部成功时一起入账", func(t *testing.T) {
+		fundService, fundRepo, _ := newBatchDepositTestService()
+		req := batchWithFailure(types.BatchDepositModeAllOrNothing, "")
+		req.Deposits[1].MerchantID = 4
+
+		result, err := fundService.BatchDeposit(ctx, req, 100)
+		if err != nil {
+			t.Fatalf("BatchDeposit() error = %v", err)
+		}
+		if result.Status != types.BatchDepositStatusCompleted || result.SucceededCount != 3 || result.AppliedAmount != 600 {
+			t.Errorf("status = %v, succeeded = %d, applied = %v", result.Status, result.SucceededCount, result.AppliedAmount)
+		}
+		for i, item := range result.Items {
+			if item.Index != i+1 || item.MerchantID != req.Deposits[i].MerchantID || !item.Success {
+				t.Errorf("item %d = %+v, want request order", i+1, item)
+			}
+		}
+		if fundRepo.balance.TotalBalance != 1600 || len(fundRepo.transactions) != 3 {
+			t.Errorf("balance = %v, transactions = %d", fundRepo.balance.TotalBalance, len(fundRepo.transactions))
+		}
+	})
+}
+
+// TestBatchDepositIdempotency 测试相同幂等键重复提交不重复入账
+func TestBatchDepositIdempotency(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+	for _, mode := range []types.BatchDepositMode{types.BatchDepositModeBestEffort, types.BatchDepositModeAllOrNothing} {
+		t.Run(string(mode)+"重复提交返回首次处理的结果", func(t *testing.T) {
+			fundService, fundRepo, batchRepo := newBatchDepositTestService()
+
+			first, err := fundService.BatchDeposit(ctx, batchWithFailure(mode, "batch-001"), 100)
+			if err != nil {
+				t.Fatalf("first BatchDeposit() error = %v", err)
+			}
+			if first.Replayed || first.BatchID == 0 {
+				t.Errorf("first result replayed = %v, batch id = %d", first.Replayed, first.BatchID)
+			}
+			if batch := batchRepo.batches["batch-001"]; batch.Status != first.Status || batch.Mode != mode {
+				t.Errorf("batch = %+v, want status %v", batch, first.Status)
+			}
+			balance, transactions := fundRepo.balance.TotalBalance, len(fundRepo.transactions)
+
+			// 失败的商户恢复后用相同幂等键重试，仍返回首次处理的结果
+			fundRepo.failedMerchants = nil
+			second, err := fundService.BatchDeposit(ctx, batchWithFailure(mode, "batch-001"), 100)
+			if err != nil {
+				t.Fatalf("second BatchDeposit() error = %v", err)
+			}
+			if !second.Replayed || second.BatchID != first.BatchID || second.Status != first.Status ||
+				second.SucceededCount != first.SucceededCount || second.AppliedAmount != first.AppliedAmount {
+				t.Errorf("second = %+v, want replay of %+v", second, first)
+			}
+			if fundRepo.balance.TotalBalance != balance || len(fundRepo.transactions) != transactions {
+				t.Errorf("replay changed balance %v -> %v, transactions %d -> %d",
+					balance, fundRepo.balance.TotalBalance, transactions, len(fundRepo.transactions))
+			}
+		})
+	}
+
+	t.Run("幂等键被内容不同的请求复用", func(t *testing.T) {
+		fundService, _, _ := newBatchDepositTestService()
+		if _, err := fundService.BatchDeposit(ctx, batchWithFailure("", "batch-002"), 100); err != nil {
+			t.Fatalf("BatchDeposit() error = %v", err)
+		}
+
+		req := batchWithFailure("", "batch-002")
+		req.Deposits[0].Amount = 150
+		if _, err := fundService.BatchDeposit(ctx, req, 100); !errors.Is(err, types.ErrBatchDepositKeyReused) {
+			t.Errorf("different amount error = %v, want ErrBatchDepositKeyReused", err)
+		}
+		if _, err := fundService.BatchDeposit(ctx, batchWithFailure(types.BatchDepositModeAllOrNothing, "batch-002"), 100); !errors.Is(err, types.ErrBatchDepositKeyReused) {
+			t.Errorf("different mode error = %v, want ErrBatchDepositKeyReused", err)
+		}
+	})
+
+	t.Run("相同幂等键的批量充值正在处理", func(t *testing.T) {
+		fundService, fundRepo, batchRepo := newBatchDepositTestService()
+		req := batchWithFailure("", "batch-003")
+		batchRepo.batches["batch-003"] = &types.FundBatchDeposit{
+			ID:             9,
+			IdempotencyKey: "batch-003",
+			Status:         types.BatchDepositStatusProcessing,
+			RequestHash:    req.Fingerprint(),
+		}
+
+		if _, err := fundService.BatchDeposit(ctx, req, 100); !errors.Is(err, types.ErrBatchDepositInProgress) {
+			t.Errorf("error = %v, want ErrBatchDepositInProgress", err)
+		}
+		if len(fundRepo.funds) != 0 {
+			t.Errorf("funds = %d, want none while batch in progress", len(fundRepo.funds))
+		}
+	})
+
+}
+
+// TestBatchDepositRequestMode 测试批量充值模式校验
+func TestBatchDepositRequestMode(t *testing.T) {
+	req := batchWithFailure("", "")
+	if req.DepositMode() != types.BatchDepositModeBestEffort {
+		t.Errorf("default mode = %v, want best_effort", req.DepositMode())
+	}
+	if err := req.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	req.Mode = "atomic"
+	if err := req.Validate(); err == nil {
+		t.Error("Validate() accepted invalid mode")
+	}
+
+	req.Mode = types.BatchDepositModeAllOrNothing
+	req.IdempotencyKey = strings.Repeat("k", 65)
+	if err := req.Validate(); err == nil {
+		t.Error("Validate() accepted idempotency key longer than 64")
+	}
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+
+	"github.com/gofromzero/mer-sys/backend/services/merchant-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// ContactVerificationController 商户联系方式验证控制器
+type ContactVerificationController struct {
+	service *service.MerchantContactVerificationService
+}
+
+// NewContactVerificationController 创建商户联系方式验证控制器实例
+func NewContactVerificationController() *ContactVerificationController {
+	return &ContactVerificationController{
+		service: service.NewMerchantContactVerificationService(),
+	}
+}
+
+// Send 向商户联系邮箱或手机号发送验证码
+func (c *ContactVerificationController) Send(r *ghttp.Request) {
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "商户ID格式错误",
+		})
+		return
+	}
+
+	var req types.MerchantContactVerificationRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	issued, err := c.service.SendCode(r.GetCtx(), id, req.Channel)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    contactVerificationErrorCode(err),
+			"message": "发送验证码失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "验证码已发送",
+		"data":    issued,
+	})
+}
+
+// Confirm 校验验证码并标记联系方式已验证
+func (c *ContactVerificationController) Confirm(r *ghttp.Request) {
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "商户ID格式错误",
+		})
+		return
+	}
+
+	var req types.MerchantContactConfirmRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	merchant, err := c.service.Confirm(r.GetCtx(), id, req.Channel, req.Code)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    contactVerificationErrorCode(err),
+			"message": "联系方式验证失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "联系方式验证成功",
+		"data":    merchant,
+	})
+}
+
+// contactVerificationErrorCode 按错误类型返回响应码
+func contactVerificationErrorCode(err error) int {
+	switch {
+	case errors.Is(err, service.ErrMerchantContactMissing),
+		errors.Is(err, service.ErrContactCodeExpired),
+		errors.Is(err, service.ErrContactCodeInvalid),
+		errors.Is(err, service.ErrContactCodeTooManyAttempts):
+		return 400
+	case errors.Is(err, service.ErrMerchantContactAlreadyVerified):
+		return 409
+	case errors.Is(err, service.ErrContactCodeResendTooSoon):
+		return 429
+	default:
+		return 500
+	}
+}
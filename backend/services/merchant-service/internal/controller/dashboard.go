@@ -1,11 +1,14 @@
 package controller
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/gogf/gf/v2/errors/gerror"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/gconv"
 
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gofromzero/mer-sys/backend/services/merchant-service/internal/service"
@@ -13,13 +16,18 @@ import (
 
 // DashboardController 仪表板控制器
 type DashboardController struct {
-	dashboardService service.DashboardService
+	dashboardService   service.DashboardService
+	quickActionService service.QuickActionService
+	salesTargetService service.SalesTargetService
 }
 
 // NewDashboardController 创建仪表板控制器实例
 func NewDashboardController() *DashboardController {
+	dashboardService := service.NewDashboardService()
 	return &DashboardController{
-		dashboardService: service.NewDashboardService(),
+		dashboardService:   dashboardService,
+		quickActionService: service.NewQuickActionService(dashboardService),
+		salesTargetService: service.NewSalesTargetService(),
 	}
 }
 
@@ -191,6 +199,236 @@ func (c *DashboardController) GetPendingTasks(r *ghttp.Request) {
 	})
 }
 
+// GetRecentOrders 获取最近订单
+// GET /api/v1/merchant/dashboard/recent-orders?limit=10&status=paid,processing
+func (c *DashboardController) GetRecentOrders(r *ghttp.Request) {
+	ctx := r.GetCtx()
+	
+	// 从JWT中获取租户和商户信息
+	tenantID, merchantID, err := c.extractMerchantInfo(r)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    401,
+			"message": "身份验证失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+	
+	// 请求参数覆盖组件配置
+	query := &service.RecentOrdersQuery{
+		Limit: r.Get("limit").Int(),
+	}
+	if status := r.Get("status").String(); status != "" {
+		query.StatusFilter = strings.Split(status, ",")
+	}
+	
+	// 获取最近订单
+	orders, err := c.dashboardService.GetRecentOrders(ctx, tenantID, merchantID, query)
+	if err != nil {
+		g.Log().Errorf(ctx, "获取最近订单失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "获取最近订单失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+	
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "获取成功",
+		"data":    orders,
+	})
+}
+
+// ListQuickActions 获取当前用户可用的快捷操作
+// GET /api/v1/merchant/dashboard/quick-actions
+func (c *DashboardController) ListQuickActions(r *ghttp.Request) {
+	ctx := r.GetCtx()
+	
+	// 从JWT中获取租户和商户信息
+	tenantID, merchantID, err := c.extractMerchantInfo(r)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    401,
+			"message": "身份验证失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+	
+	actions, err := c.quickActionService.ListActions(ctx, tenantID, merchantID, contextPermissions(r))
+	if err != nil {
+		g.Log().Errorf(ctx, "获取快捷操作失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "获取快捷操作失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+	
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "获取成功",
+		"data":    actions,
+	})
+}
+
+// ExecuteQuickAction 执行快捷操作
+// POST /api/v1/merchant/dashboard/quick-actions/execute
+func (c *DashboardController) ExecuteQuickAction(r *ghttp.Request) {
+	ctx := r.GetCtx()
+	
+	// 从JWT中获取租户和商户信息
+	tenantID, merchantID, err := c.extractMerchantInfo(r)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    401,
+			"message": "身份验证失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+	
+	var req types.ExecuteQuickActionRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+	
+	result, err := c.quickActionService.ExecuteAction(ctx, tenantID, merchantID, r.GetCtxVar("user_id").Uint64(), contextPermissions(r), &req)
+	if err != nil {
+		code := 500
+		if errors.Is(err, service.ErrQuickActionForbidden) {
+			code = 403
+		}
+		g.Log().Errorf(ctx, "执行快捷操作失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "执行快捷操作失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+	
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": result.Message,
+		"data":    result,
+	})
+}
+
+// GetSalesTarget 获取周期销售目标
+// GET /api/v1/merchant/dashboard/sales-target?period=2025-09
+func (c *DashboardController) GetSalesTarget(r *ghttp.Request) {
+	ctx := r.GetCtx()
+	
+	// 从JWT中获取租户和商户信息
+	tenantID, merchantID, err := c.extractMerchantInfo(r)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    401,
+			"message": "身份验证失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+	
+	target, err := c.salesTargetService.GetTarget(ctx, tenantID, merchantID, r.Get("period").String())
+	if err != nil {
+		c.writeSalesTargetError(r, "获取销售目标失败", err)
+		return
+	}
+	
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "获取成功",
+		"data":    target,
+	})
+}
+
+// SetSalesTarget 设置周期销售目标
+// PUT /api/v1/merchant/dashboard/config/sales-target
+func (c *DashboardController) SetSalesTarget(r *ghttp.Request) {
+	ctx := r.GetCtx()
+	
+	// 从JWT中获取租户和商户信息
+	tenantID, merchantID, err := c.extractMerchantInfo(r)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    401,
+			"message": "身份验证失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+	
+	var req types.SetMerchantTargetRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+	
+	target, err := c.salesTargetService.SetTarget(ctx, tenantID, merchantID, r.GetCtxVar("user_id").Uint64(), &req)
+	if err != nil {
+		c.writeSalesTargetError(r, "设置销售目标失败", err)
+		return
+	}
+	
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "设置成功",
+		"data":    target,
+	})
+}
+
+// GetSalesTargetProgress 获取销售目标进度（销售目标组件数据）
+// GET /api/v1/merchant/dashboard/sales-target/progress?period=2025-09
+func (c *DashboardController) GetSalesTargetProgress(r *ghttp.Request) {
+	ctx := r.GetCtx()
+	
+	// 从JWT中获取租户和商户信息
+	tenantID, merchantID, err := c.extractMerchantInfo(r)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    401,
+			"message": "身份验证失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+	
+	progress, err := c.salesTargetService.GetProgress(ctx, tenantID, merchantID, r.Get("period").String())
+	if err != nil {
+		c.writeSalesTargetError(r, "获取销售目标进度失败", err)
+		return
+	}
+	
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "获取成功",
+		"data":    progress,
+	})
+}
+
 // GetNotifications 获取系统通知和公告
 // GET /api/v1/merchant/dashboard/notifications
 func (c *DashboardController) GetNotifications(r *ghttp.Request) {
@@ -422,6 +660,29 @@ func (c *DashboardController) extractMerchantInfo(r *ghttp.Request) (tenantID, m
 	return tenantID, merchantID, nil
 }
 
+// contextPermissions 认证中间件写入上下文的用户权限，不读取请求参数，避免调用方伪造权限
+func contextPermissions(r *ghttp.Request) []string {
+	return gconv.Strings(r.GetCtxVar("permissions").Val())
+}
+
+// writeSalesTargetError 按错误类型返回销售目标接口错误
+func (c *DashboardController) writeSalesTargetError(r *ghttp.Request, message string, err error) {
+	code := 500
+	switch {
+	case errors.Is(err, service.ErrSalesTargetNotFound):
+		code = 404
+	case errors.Is(err, types.ErrInvalidTargetPeriod):
+		code = 400
+	default:
+		g.Log().Errorf(r.GetCtx(), "%s: %v", message, err)
+	}
+	r.Response.WriteJsonExit(g.Map{
+		"code":    code,
+		"message": message,
+		"error":   err.Error(),
+	})
+}
+
 // validatePermission 验证仪表板访问权限
 func (c *DashboardController) validatePermission(r *ghttp.Request, permission string) error {
 	// 从JWT Claims中获取权限列表
@@ -474,6 +735,11 @@ func RegisterDashboardRoutes(group *ghttp.RouterGroup) {
 	dashboardGroup.GET("/rights-trend", controller.GetRightsUsageTrend)   // 获取权益趋势
 	dashboardGroup.GET("/pending-tasks", controller.GetPendingTasks)      // 获取待处理事项
 	dashboardGroup.GET("/notifications", controller.GetNotifications)     // 获取通知公告
+	dashboardGroup.GET("/recent-orders", controller.GetRecentOrders)      // 获取最近订单
+	dashboardGroup.GET("/quick-actions", controller.ListQuickActions)     // 获取可用快捷操作
+	dashboardGroup.POST("/quick-actions/execute", controller.ExecuteQuickAction) // 执行快捷操作
+	dashboardGroup.GET("/sales-target", controller.GetSalesTarget)                // 获取销售目标
+	dashboardGroup.GET("/sales-target/progress", controller.GetSalesTargetProgress) // 获取销售目标进度
 	
 	// 配置管理路由 (需要额外的配置权限)
 	configGroup := dashboardGroup.Group("/config")
@@ -492,6 +758,7 @@ func RegisterDashboardRoutes(group *ghttp.RouterGroup) {
 	configGroup.GET("/", controller.GetDashboardConfig)     // 获取配置
 	configGroup.POST("/", controller.SaveDashboardConfig)  // 保存配置
 	configGroup.PUT("/", controller.UpdateDashboardConfig) // 更新配置
+	configGroup.PUT("/sales-target", controller.SetSalesTarget) // 设置销售目标
 	
 	// 公告操作路由
 	dashboardGroup.POST("/announcements/{id}/read", controller.MarkAnnouncementAsRead) // 标记公告已读
@@ -1,7 +1,10 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
@@ -11,13 +14,15 @@ import (
 
 // MerchantController 商户控制器
 type MerchantController struct {
-	service *service.MerchantService
+	service       *service.MerchantService
+	exportService *service.MerchantExportService
 }
 
 // NewMerchantController 创建商户控制器实例
 func NewMerchantController() *MerchantController {
 	return &MerchantController{
-		service: service.NewMerchantService(),
+		service:       service.NewMerchantService(),
+		exportService: service.NewMerchantExportService(),
 	}
 }
 
@@ -84,6 +89,38 @@ func (c *MerchantController) List(r *ghttp.Request) {
 	})
 }
 
+// Export 导出商户列表
+// 导出当前租户的商户及当前权益余额，用于对账；文件以流式方式直接写入响应
+func (c *MerchantController) Export(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	query := &types.MerchantExportQuery{
+		Format: types.OrderExportFormat(r.Get("format").String()),
+		Status: types.MerchantStatus(r.Get("status").String()),
+	}
+	if err := c.exportService.PrepareQuery(ctx, query); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	fileName := fmt.Sprintf("merchants_%s.%s", time.Now().Format("20060102"), query.Format.Extension())
+	contentType := "text/csv; charset=utf-8"
+	if query.Format == types.OrderExportFormatExcel {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	r.Response.Header().Set("Content-Type", contentType)
+	r.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+	// 响应头已发送，导出中途失败时只能记录日志
+	if _, err := c.exportService.Export(ctx, query, r.Response.RawWriter()); err != nil {
+		g.Log().Errorf(ctx, "导出商户列表失败: %v", err)
+	}
+}
+
 // GetByID 获取商户详情
 func (c *MerchantController) GetByID(r *ghttp.Request) {
 	idStr := r.Get("id").String()
@@ -152,6 +189,53 @@ func (c *MerchantController) Update(r *ghttp.Request) {
 	})
 }
 
+// UpdateRightsReserve 设置商户保留权益
+func (c *MerchantController) UpdateRightsReserve(r *ghttp.Request) {
+	idStr := r.Get("id").String()
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "商户ID格式错误",
+		})
+		return
+	}
+
+	var req types.MerchantRightsReserveRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	balance, err := c.service.UpdateRightsReserve(r.GetCtx(), id, &req)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "设置商户保留权益失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "商户保留权益设置成功",
+		"data":    balance,
+	})
+}
+
 // UpdateStatus 更新商户状态
 func (c *MerchantController) UpdateStatus(r *ghttp.Request) {
 	idStr := r.Get("id").String()
@@ -214,6 +298,14 @@ func (c *MerchantController) Approve(r *ghttp.Request) {
 	}
 
 	err = c.service.ApproveMerchant(r.GetCtx(), id, req.Comment)
+	if errors.Is(err, service.ErrMerchantContactUnverified) {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    409,
+			"message": "商户审批失败",
+			"error":   err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		r.Response.WriteJsonExit(g.Map{
 			"code":    500,
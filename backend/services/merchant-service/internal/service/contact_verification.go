@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/cache"
+	"github.com/gofromzero/mer-sys/backend/shared/notification"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+var (
+	// ErrMerchantContactMissing 商户未登记该渠道的联系方式
+	ErrMerchantContactMissing = errors.New("商户未登记该联系方式")
+	// ErrMerchantContactAlreadyVerified 联系方式已验证
+	ErrMerchantContactAlreadyVerified = errors.New("联系方式已验证")
+	// ErrMerchantContactUnverified 审批前需至少验证一种联系方式
+	ErrMerchantContactUnverified = errors.New("商户联系邮箱和手机号均未验证，无法审批")
+	// ErrContactCodeResendTooSoon 发送验证码过于频繁
+	ErrContactCodeResendTooSoon = errors.New("验证码发送过于频繁，请稍后再试")
+	// ErrContactCodeExpired 验证码不存在或已过期
+	ErrContactCodeExpired = errors.New("验证码已过期，请重新获取")
+	// ErrContactCodeInvalid 验证码错误
+	ErrContactCodeInvalid = errors.New("验证码错误")
+	// ErrContactCodeTooManyAttempts 验证码错误次数过多，需重新获取
+	ErrContactCodeTooManyAttempts = errors.New("验证码错误次数过多，请重新获取")
+)
+
+// 验证码位数
+const contactVerificationCodeLength = 6
+
+// ContactCodeSender 验证码发送渠道，复用通知服务的邮件和短信能力
+type ContactCodeSender interface {
+	SendEmail(ctx context.Context, to, subject, body string) error
+	SendSMS(ctx context.Context, phone, message string) error
+}
+
+// contactVerificationCode 缓存中的验证码记录，只保存验证码摘要
+type contactVerificationCode struct {
+	CodeHash  string    `json:"code_hash"`
+	Target    string    `json:"target"`
+	Attempts  int       `json:"attempts"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MerchantContactVerificationService 商户联系方式验证服务
+type MerchantContactVerificationService struct {
+	merchantRepo repository.MerchantRepository
+	sender       ContactCodeSender
+	codes        *cache.Cache
+	policy       types.MerchantContactVerificationPolicy
+	now          func() time.Time
+}
+
+// NewMerchantContactVerificationService 创建商户联系方式验证服务实例
+func NewMerchantContactVerificationService() *MerchantContactVerificationService {
+	return &MerchantContactVerificationService{
+		merchantRepo: repository.NewMerchantRepository(),
+		sender:       notification.NewNotificationService(),
+		codes:        cache.NewCache("merchant_contact_code"),
+		policy:       LoadMerchantContactVerificationPolicy(context.Background()),
+		now:          time.Now,
+	}
+}
+
+// NewMerchantContactVerificationServiceForTest 创建测试用商户联系方式验证服务实例
+func NewMerchantContactVerificationServiceForTest(merchantRepo repository.MerchantRepository, sender ContactCodeSender, codes *cache.Cache, policy types.MerchantContactVerificationPolicy, now func() time.Time) *MerchantContactVerificationService {
+	return &MerchantContactVerificationService{
+		merchantRepo: merchantRepo,
+		sender:       sender,
+		codes:        codes,
+		policy:       policy,
+		now:          now,
+	}
+}
+
+// LoadMerchantContactVerificationPolicy 读取商户联系方式验证配置，未配置的项使用默认值
+func LoadMerchantContactVerificationPolicy(ctx context.Context) types.MerchantContactVerificationPolicy {
+	policy := types.DefaultMerchantContactVerificationPolicy()
+	cfg := g.Cfg()
+	policy.RequiredForApproval = cfg.MustGet(ctx, "merchant.contact_verification.required_for_approval", policy.RequiredForApproval).Bool()
+	policy.CodeTTL = cfg.MustGet(ctx, "merchant.contact_verification.code_ttl", policy.CodeTTL).Duration()
+	policy.ResendInterval = cfg.MustGet(ctx, "merchant.contact_verification.resend_interval", policy.ResendInterval).Duration()
+	policy.MaxAttempts = cfg.MustGet(ctx, "merchant.contact_verification.max_attempts", policy.MaxAttempts).Int()
+	return policy
+}
+
+// SendCode 向商户登记的联系邮箱或手机号发送验证码，重新发送会使之前的验证码失效
+func (s *MerchantContactVerificationService) SendCode(ctx context.Context, merchantID uint64, channel types.MerchantContactChannel) (*types.MerchantContactVerificationIssued, error) {
+	merchant, target, err := s.loadContact(ctx, merchantID, channel)
+	if err != nil {
+		return nil, err
+	}
+	if merchant.IsContactVerified(channel) {
+		return nil, ErrMerchantContactAlreadyVerified
+	}
+
+	now := s.now()
+	key := contactCodeKey(merchant, channel)
+	var previous contactVerificationCode
+	if err := s.codes.GetStruct(ctx, key, &previous); err == nil && now.Before(previous.IssuedAt.Add(s.policy.ResendInterval)) {
+		return nil, ErrContactCodeResendTooSoon
+	}
+
+	code, err := generateContactCode()
+	if err != nil {
+		return nil, fmt.Errorf("生成验证码失败: %w", err)
+	}
+	record := &contactVerificationCode{
+		CodeHash:  hashContactCode(code),
+		Target:    target,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.policy.CodeTTL),
+	}
+	if err := s.codes.Set(ctx, key, record, s.recordTTL()); err != nil {
+		return nil, fmt.Errorf("存储验证码失败: %w", err)
+	}
+
+	minutes := int(s.policy.CodeTTL.Minutes())
+	switch channel {
+	case types.MerchantContactChannelEmail:
+		err = s.sender.SendEmail(ctx, target, "商户联系邮箱验证",
+			fmt.Sprintf("您好，%s 的联系邮箱验证码为 %s，%d 分钟内有效。", merchant.Name, code, minutes))
+	case types.MerchantContactChannelPhone:
+		err = s.sender.SendSMS(ctx, target, fmt.Sprintf("【商户验证】您的验证码为 %s，%d 分钟内有效。", code, minutes))
+	}
+	if err != nil {
+		s.codes.Delete(ctx, key)
+		return nil, fmt.Errorf("发送验证码失败: %w", err)
+	}
+
+	return &types.MerchantContactVerificationIssued{
+		Channel:     channel,
+		Target:      maskContactTarget(channel, target),
+		ExpiresAt:   record.ExpiresAt,
+		ResendAfter: now.Add(s.policy.ResendInterval),
+	}, nil
+}
+
+// Confirm 校验验证码，通过后记录联系方式的验证时间
+func (s *MerchantContactVerificationService) Confirm(ctx context.Context, merchantID uint64, channel types.MerchantContactChannel, code string) (*types.Merchant, error) {
+	merchant, target, err := s.loadContact(ctx, merchantID, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	key := contactCodeKey(merchant, channel)
+	var record contactVerificationCode
+	if err := s.codes.GetStruct(ctx, key, &record); err != nil || !now.Before(record.ExpiresAt) {
+		return nil, ErrContactCodeExpired
+	}
+	// 发送验证码后联系方式被修改，旧验证码不能用于验证新的联系方式
+	if record.Target != target {
+		s.codes.Delete(ctx, key)
+		return nil, ErrContactCodeExpired
+	}
+
+	if subtle.ConstantTimeCompare([]byte(record.CodeHash), []byte(hashContactCode(strings.TrimSpace(code)))) != 1 {
+		record.Attempts++
+		if s.policy.MaxAttempts > 0 && record.Attempts >= s.policy.MaxAttempts {
+			s.codes.Delete(ctx, key)
+			return nil, ErrContactCodeTooManyAttempts
+		}
+		if err := s.codes.Set(ctx, key, &record, s.recordTTL()); err != nil {
+			return nil, fmt.Errorf("更新验证码失败: %w", err)
+		}
+		return nil, ErrContactCodeInvalid
+	}
+
+	verifiedAt := now
+	if err := s.merchantRepo.UpdateContactVerification(ctx, merchant.ID, channel, &verifiedAt); err != nil {
+		return nil, fmt.Errorf("更新联系方式验证状态失败: %w", err)
+	}
+	s.codes.Delete(ctx, key)
+
+	switch channel {
+	case types.MerchantContactChannelEmail:
+		merchant.ContactEmailVerifiedAt = &verifiedAt
+	case types.MerchantContactChannelPhone:
+		merchant.ContactPhoneVerifiedAt = &verifiedAt
+	}
+
+	userInfo := auth.GetUserInfoFromContext(ctx)
+	audit.LogOperation(ctx, "merchant", "contact_verified", g.Map{
+		"merchant_id":   merchant.ID,
+		"merchant_name": merchant.Name,
+		"channel":       channel,
+		"target":        maskContactTarget(channel, target),
+		"operator_id":   userInfo.UserID,
+	})
+
+	return merchant, nil
+}
+
+// loadContact 获取商户及其在该渠道登记的联系方式
+func (s *MerchantContactVerificationService) loadContact(ctx context.Context, merchantID uint64, channel types.MerchantContactChannel) (*types.Merchant, string, error) {
+	if !channel.IsValid() {
+		return nil, "", fmt.Errorf("无效的验证渠道: %s", channel)
+	}
+
+	merchant, err := s.merchantRepo.GetByID(ctx, merchantID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", fmt.Errorf("商户不存在")
+		}
+		return nil, "", fmt.Errorf("获取商户信息失败: %w", err)
+	}
+
+	target := merchant.ContactTarget(channel)
+	if target == "" {
+		return nil, "", ErrMerchantContactMissing
+	}
+	return merchant, target, nil
+}
+
+// recordTTL 验证码记录的缓存时长，需覆盖有效期和重发间隔
+func (s *MerchantContactVerificationService) recordTTL() time.Duration {
+	if s.policy.ResendInterval > s.policy.CodeTTL {
+		return s.policy.ResendInterval
+	}
+	return s.policy.CodeTTL
+}
+
+// contactCodeKey 验证码缓存键
+func contactCodeKey(merchant *types.Merchant, channel types.MerchantContactChannel) string {
+	return fmt.Sprintf("%d:%d:%s", merchant.TenantID, merchant.ID, channel)
+}
+
+// generateContactCode 生成数字验证码
+func generateContactCode() (string, error) {
+	digits := make([]byte, contactVerificationCodeLength)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+	return string(digits), nil
+}
+
+// hashContactCode 验证码摘要
+func hashContactCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// maskContactTarget 联系方式脱敏
+func maskContactTarget(channel types.MerchantContactChannel, target string) string {
+	rule := audit.MaskRulePhone
+	if channel == types.MerchantContactChannelEmail {
+		rule = audit.MaskRuleEmail
+	}
+	return fmt.Sprint(audit.Mask(target, rule))
+}
@@ -3,11 +3,14 @@ package service
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gogf/gf/v2/errors/gerror"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gcache"
+	"github.com/gogf/gf/v2/util/gconv"
 
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
@@ -27,6 +30,9 @@ type DashboardService interface {
 	// 获取待处理事项汇总
 	GetPendingTasks(ctx context.Context, tenantID, merchantID uint64) ([]types.PendingTask, error)
 	
+	// 获取最近订单（按组件配置的条数和状态过滤）
+	GetRecentOrders(ctx context.Context, tenantID, merchantID uint64, override *RecentOrdersQuery) ([]types.OrderSummary, error)
+	
 	// 获取系统通知和公告
 	GetNotifications(ctx context.Context, tenantID, merchantID uint64) (*NotificationsResponse, error)
 	
@@ -41,20 +47,38 @@ type DashboardService interface {
 
 // dashboardServiceImpl 仪表板服务实现
 type dashboardServiceImpl struct {
-	dashboardRepo repository.DashboardRepository
-	cache         *gcache.Cache
+	dashboardRepo  repository.DashboardRepository
+	taskAggregator *pendingTaskAggregator
+	cache          *gcache.Cache
 }
 
 // NewDashboardService 创建仪表板服务实例
 func NewDashboardService() DashboardService {
+	dashboardRepo := repository.NewDashboardRepository()
+	return &dashboardServiceImpl{
+		dashboardRepo:  dashboardRepo,
+		taskAggregator: newPendingTaskAggregator(dashboardRepo),
+		cache:          gcache.New(),
+	}
+}
+
+// NewDashboardServiceForTest 创建测试用仪表板服务实例
+func NewDashboardServiceForTest(dashboardRepo repository.DashboardRepository) DashboardService {
 	return &dashboardServiceImpl{
-		dashboardRepo: repository.NewDashboardRepository(),
-		cache:         gcache.New(),
+		dashboardRepo:  dashboardRepo,
+		taskAggregator: newPendingTaskAggregator(dashboardRepo),
+		cache:          gcache.New(),
 	}
 }
 
 // 请求和响应结构
 
+// RecentOrdersQuery 最近订单请求参数，非空字段覆盖组件配置
+type RecentOrdersQuery struct {
+	Limit        int      `json:"limit"`
+	StatusFilter []string `json:"status_filter"`
+}
+
 // DashboardConfigRequest 仪表板配置请求
 type DashboardConfigRequest struct {
 	LayoutConfig      *types.LayoutConfig       `json:"layout_config" binding:"required"`
@@ -89,6 +113,11 @@ func (s *dashboardServiceImpl) GetMerchantDashboard(ctx context.Context, tenantI
 		return nil, gerror.Wrapf(err, "获取商户 %d 仪表板数据失败", merchantID)
 	}
 	
+	// 待处理事项按商户配置的触发条件生成
+	if data.PendingTasks, err = s.GetPendingTasks(ctx, tenantID, merchantID); err != nil {
+		return nil, err
+	}
+	
 	// 缓存5分钟
 	s.cache.Set(ctx, cacheKey, data, 5*time.Minute)
 	
@@ -158,7 +187,7 @@ func (s *dashboardServiceImpl) GetRightsUsageTrend(ctx context.Context, tenantID
 	return trends, nil
 }
 
-// GetPendingTasks 获取待处理事项汇总
+// GetPendingTasks 获取待处理事项汇总，触发条件取自待处理事项组件配置，结果按优先级排序
 func (s *dashboardServiceImpl) GetPendingTasks(ctx context.Context, tenantID, merchantID uint64) ([]types.PendingTask, error) {
 	cacheKey := s.getPendingTasksCacheKey(tenantID, merchantID)
 	
@@ -169,8 +198,18 @@ func (s *dashboardServiceImpl) GetPendingTasks(ctx context.Context, tenantID, me
 		}
 	}
 	
-	// 从数据库获取
-	tasks, err := s.dashboardRepo.GetPendingTasks(ctx, tenantID, merchantID)
+	dashboardConfig, err := s.GetDashboardConfig(ctx, tenantID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	
+	triggers, err := parsePendingTaskTriggers(findWidgetConfig(dashboardConfig, types.WidgetTypePendingTasks))
+	if err != nil {
+		return nil, gerror.Wrap(err, "待处理事项组件配置无效")
+	}
+	
+	// 从数据库统计并生成待处理事项
+	tasks, err := s.taskAggregator.Aggregate(ctx, tenantID, merchantID, triggers)
 	if err != nil {
 		return nil, gerror.Wrapf(err, "获取商户 %d 待处理事项失败", merchantID)
 	}
@@ -181,6 +220,57 @@ func (s *dashboardServiceImpl) GetPendingTasks(ctx context.Context, tenantID, me
 	return tasks, nil
 }
 
+// GetRecentOrders 获取最近订单，条数和状态过滤取自最近订单组件配置，请求参数可覆盖
+func (s *dashboardServiceImpl) GetRecentOrders(ctx context.Context, tenantID, merchantID uint64, override *RecentOrdersQuery) ([]types.OrderSummary, error) {
+	dashboardConfig, err := s.GetDashboardConfig(ctx, tenantID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	widgetConfig, err := parseRecentOrdersWidgetConfig(findWidgetConfig(dashboardConfig, types.WidgetTypeRecentOrders))
+	if err != nil {
+		return nil, gerror.Wrap(err, "最近订单组件配置无效")
+	}
+	if override != nil {
+		if override.Limit > 0 {
+			widgetConfig.Limit = override.Limit
+		}
+		if len(override.StatusFilter) > 0 {
+			statuses, err := parseOrderStatusFilter(override.StatusFilter)
+			if err != nil {
+				return nil, err
+			}
+			widgetConfig.StatusFilter = statuses
+		}
+	}
+	if widgetConfig.Limit <= 0 {
+		widgetConfig.Limit = types.DefaultRecentOrdersLimit
+	}
+	if widgetConfig.Limit > types.MaxRecentOrdersLimit {
+		widgetConfig.Limit = types.MaxRecentOrdersLimit
+	}
+
+	cacheKey := s.getRecentOrdersCacheKey(tenantID, merchantID, widgetConfig)
+
+	// 尝试从缓存获取 (订单变化频繁，只做短时间缓存)
+	if cached, err := s.cache.Get(ctx, cacheKey); err == nil && cached != nil {
+		if orders, ok := cached.Val().([]types.OrderSummary); ok {
+			return orders, nil
+		}
+	}
+
+	// 从数据库获取（仓储按商户ID过滤，只返回本商户订单）
+	orders, err := s.dashboardRepo.GetRecentOrders(ctx, tenantID, merchantID, widgetConfig.Limit, widgetConfig.StatusFilter)
+	if err != nil {
+		return nil, gerror.Wrapf(err, "获取商户 %d 最近订单失败", merchantID)
+	}
+
+	// 缓存1分钟
+	s.cache.Set(ctx, cacheKey, orders, time.Minute)
+
+	return orders, nil
+}
+
 // GetNotifications 获取系统通知和公告
 func (s *dashboardServiceImpl) GetNotifications(ctx context.Context, tenantID, merchantID uint64) (*NotificationsResponse, error) {
 	cacheKey := s.getNotificationsCacheKey(tenantID, merchantID)
@@ -270,6 +360,7 @@ func (s *dashboardServiceImpl) SaveDashboardConfig(ctx context.Context, tenantID
 	// 清除缓存
 	s.cache.Remove(ctx, s.getConfigCacheKey(tenantID, merchantID))
 	s.cache.Remove(ctx, s.getDashboardCacheKey(tenantID, merchantID, types.TimePeriodDaily))
+	s.cache.Remove(ctx, s.getPendingTasksCacheKey(tenantID, merchantID))
 	
 	g.Log().Infof(ctx, "商户 %d 仪表板配置保存成功", merchantID)
 	
@@ -321,6 +412,7 @@ func (s *dashboardServiceImpl) validateDashboardConfig(config *DashboardConfigRe
 		types.WidgetTypeRecentOrders:   true,
 		types.WidgetTypeAnnouncements:  true,
 		types.WidgetTypeQuickActions:   true,
+		types.WidgetTypeSalesTarget:    true,
 	}
 	
 	for _, widget := range config.LayoutConfig.Widgets {
@@ -335,13 +427,110 @@ func (s *dashboardServiceImpl) validateDashboardConfig(config *DashboardConfigRe
 		if widget.Size.Height < 1 || widget.Size.Height > 10 {
 			return gerror.Newf("组件 %s 高度无效", widget.ID)
 		}
+		
+		if widget.Type == types.WidgetTypeRecentOrders {
+			if _, err := parseRecentOrdersWidgetConfig(widget.Config); err != nil {
+				return gerror.Wrapf(err, "组件 %s 配置无效", widget.ID)
+			}
+		}
+		
+		if widget.Type == types.WidgetTypePendingTasks {
+			if _, err := parsePendingTaskTriggers(widget.Config); err != nil {
+				return gerror.Wrapf(err, "组件 %s 配置无效", widget.ID)
+			}
+		}
+	}
+	
+	// 组件偏好设置中的配置优先生效，同样需要验证
+	for _, preference := range config.WidgetPreferences {
+		var err error
+		switch preference.WidgetType {
+		case types.WidgetTypeRecentOrders:
+			_, err = parseRecentOrdersWidgetConfig(preference.Config)
+		case types.WidgetTypePendingTasks:
+			_, err = parsePendingTaskTriggers(preference.Config)
+		}
+		if err != nil {
+			return gerror.Wrapf(err, "组件 %v 偏好配置无效", preference.WidgetType)
+		}
 	}
 	
 	return nil
 }
 
+// findWidgetConfig 获取指定类型组件的配置，组件偏好设置优先于布局中的组件配置
+func findWidgetConfig(config *types.DashboardConfig, widgetType types.WidgetType) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	for _, preference := range config.WidgetPreferences {
+		if preference.WidgetType == widgetType && len(preference.Config) > 0 {
+			return preference.Config
+		}
+	}
+	if config.LayoutConfig != nil {
+		for _, widget := range config.LayoutConfig.Widgets {
+			if widget.Type == widgetType && len(widget.Config) > 0 {
+				return widget.Config
+			}
+		}
+	}
+	return nil
+}
+
+// parseRecentOrdersWidgetConfig 解析最近订单组件配置
+func parseRecentOrdersWidgetConfig(config map[string]interface{}) (*types.RecentOrdersWidgetConfig, error) {
+	widgetConfig := &types.RecentOrdersWidgetConfig{}
+	if config == nil {
+		return widgetConfig, nil
+	}
+
+	if limit, ok := config["limit"]; ok {
+		widgetConfig.Limit = gconv.Int(limit)
+		if widgetConfig.Limit < 0 {
+			return nil, gerror.New("最近订单条数不能为负数")
+		}
+	}
+
+	if filter, ok := config["status_filter"]; ok {
+		statuses, err := parseOrderStatusFilter(gconv.Strings(filter))
+		if err != nil {
+			return nil, err
+		}
+		widgetConfig.StatusFilter = statuses
+	}
+
+	return widgetConfig, nil
+}
+
+// parseOrderStatusFilter 解析订单状态过滤条件，支持状态名称（如 paid）和数字状态
+func parseOrderStatusFilter(values []string) ([]types.OrderStatusInt, error) {
+	statuses := make([]types.OrderStatusInt, 0, len(values))
+	for _, value := range values {
+		status, ok := parseOrderStatus(strings.TrimSpace(value))
+		if !ok {
+			return nil, gerror.Newf("无效的订单状态: %s", value)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func parseOrderStatus(value string) (types.OrderStatusInt, bool) {
+	for status := types.OrderStatusIntPending; status <= types.OrderStatusIntCancelled; status++ {
+		if value == string(status.ToOrderStatus()) || value == strconv.Itoa(int(status)) {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
 // 缓存键生成方法
 
+func (s *dashboardServiceImpl) getRecentOrdersCacheKey(tenantID, merchantID uint64, config *types.RecentOrdersWidgetConfig) string {
+	return fmt.Sprintf("recent_orders:merchant:%d:%d:%d:%v", tenantID, merchantID, config.Limit, config.StatusFilter)
+}
+
 func (s *dashboardServiceImpl) getDashboardCacheKey(tenantID, merchantID uint64, period types.TimePeriod) string {
 	return fmt.Sprintf("dashboard:merchant:%d:%d:%v", tenantID, merchantID, period)
 }
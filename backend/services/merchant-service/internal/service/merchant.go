@@ -4,25 +4,39 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gofromzero/mer-sys/backend/shared/audit"
 	"github.com/gofromzero/mer-sys/backend/shared/auth"
 	"github.com/gofromzero/mer-sys/backend/shared/notification"
+	"github.com/gofromzero/mer-sys/backend/shared/quota"
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
 
 // MerchantService 商户服务
 type MerchantService struct {
-	merchantRepo repository.MerchantRepository
+	merchantRepo  repository.MerchantRepository
+	contactPolicy types.MerchantContactVerificationPolicy
+	quotaGuard    *quota.Guard // 为空时不检查租户商户数配额
 }
 
 // NewMerchantService 创建商户服务实例
 func NewMerchantService() *MerchantService {
 	return &MerchantService{
-		merchantRepo: repository.NewMerchantRepository(),
+		merchantRepo:  repository.NewMerchantRepository(),
+		contactPolicy: LoadMerchantContactVerificationPolicy(context.Background()),
+		quotaGuard:    quota.NewGuard(),
+	}
+}
+
+// NewMerchantServiceForTest 创建测试用商户服务实例
+func NewMerchantServiceForTest(merchantRepo repository.MerchantRepository, contactPolicy types.MerchantContactVerificationPolicy) *MerchantService {
+	return &MerchantService{
+		merchantRepo:  merchantRepo,
+		contactPolicy: contactPolicy,
 	}
 }
 
@@ -37,6 +51,13 @@ func (s *MerchantService) RegisterMerchant(ctx context.Context, req *types.Merch
 		return nil, fmt.Errorf("商户代码 %s 已存在", req.Code)
 	}
 
+	// 租户商户数达到配额时拒绝注册
+	if s.quotaGuard != nil {
+		if _, err := s.quotaGuard.Check(ctx, repository.GetTenantIDFromContext(ctx), types.TenantQuotaMerchants, 1); err != nil {
+			return nil, err
+		}
+	}
+
 	// 创建商户实体
 	merchant := &types.Merchant{
 		Name:         req.Name,
@@ -107,8 +128,35 @@ func (s *MerchantService) UpdateMerchant(ctx context.Context, id uint64, req *ty
 		merchant.Name = *req.Name
 	}
 	if req.BusinessInfo != nil {
+		// 联系方式变更后需要重新验证
+		if merchant.ContactTarget(types.MerchantContactChannelEmail) != strings.TrimSpace(req.BusinessInfo.ContactEmail) {
+			merchant.ContactEmailVerifiedAt = nil
+		}
+		if merchant.ContactTarget(types.MerchantContactChannelPhone) != strings.TrimSpace(req.BusinessInfo.ContactPhone) {
+			merchant.ContactPhoneVerifiedAt = nil
+		}
 		merchant.BusinessInfo = req.BusinessInfo
 	}
+	if req.MinOrderAmount != nil {
+		if *req.MinOrderAmount < 0 {
+			return nil, fmt.Errorf("最低起订金额不能为负数")
+		}
+		merchant.MinOrderAmount = *req.MinOrderAmount
+	}
+	if req.PaymentMethods != nil {
+		for _, method := range *req.PaymentMethods {
+			if !method.IsValid() {
+				return nil, fmt.Errorf("不支持的支付方式: %s", method)
+			}
+		}
+		merchant.PaymentMethods = *req.PaymentMethods
+	}
+	if req.NotificationBranding != nil {
+		if err := req.NotificationBranding.Validate(); err != nil {
+			return nil, err
+		}
+		merchant.NotificationBranding = req.NotificationBranding
+	}
 
 	// 保存更新
 	if err := s.merchantRepo.Update(ctx, merchant); err != nil {
@@ -127,6 +175,29 @@ func (s *MerchantService) UpdateMerchant(ctx context.Context, id uint64, req *ty
 	return merchant, nil
 }
 
+// UpdateRightsReserve 设置商户保留权益，普通订单不能占用保留的权益
+func (s *MerchantService) UpdateRightsReserve(ctx context.Context, id uint64, req *types.MerchantRightsReserveRequest) (*types.RightsBalance, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	balance, err := s.merchantRepo.UpdateRightsReserve(ctx, id, req)
+	if err != nil {
+		return nil, fmt.Errorf("设置商户保留权益失败: %w", err)
+	}
+
+	// 记录审计日志
+	userInfo := auth.GetUserInfoFromContext(ctx)
+	audit.LogOperation(ctx, "merchant", "rights_reserve_update", g.Map{
+		"merchant_id":     id,
+		"reserve_amount":  req.ReserveAmount,
+		"reserve_percent": req.ReservePercent,
+		"operator_id":     userInfo.UserID,
+	})
+
+	return balance, nil
+}
+
 // UpdateMerchantStatus 更新商户状态
 func (s *MerchantService) UpdateMerchantStatus(ctx context.Context, id uint64, status types.MerchantStatus, comment string) error {
 	// 验证商户是否存在
@@ -181,6 +252,10 @@ func (s *MerchantService) ApproveMerchant(ctx context.Context, id uint64, commen
 		return fmt.Errorf("商户状态为 %s，无法审批", merchant.Status)
 	}
 
+	if s.contactPolicy.RequiredForApproval && !merchant.HasVerifiedContact() {
+		return ErrMerchantContactUnverified
+	}
+
 	// 获取审批人信息
 	userInfo := auth.GetUserInfoFromContext(ctx)
 	
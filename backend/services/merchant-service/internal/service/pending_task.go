@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/util/gconv"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// pendingTaskAggregator 根据仓储统计数据和触发条件生成待处理事项
+type pendingTaskAggregator struct {
+	dashboardRepo repository.DashboardRepository
+	now           func() time.Time
+}
+
+func newPendingTaskAggregator(dashboardRepo repository.DashboardRepository) *pendingTaskAggregator {
+	return &pendingTaskAggregator{
+		dashboardRepo: dashboardRepo,
+		now:           time.Now,
+	}
+}
+
+// Aggregate 统计商户待处理事项，按优先级从高到低、到期时间从早到晚排序
+func (a *pendingTaskAggregator) Aggregate(ctx context.Context, tenantID, merchantID uint64, triggers map[types.TaskType]types.PendingTaskTrigger) ([]types.PendingTask, error) {
+	now := a.now()
+
+	criteria := &repository.PendingTaskCriteria{}
+	if trigger := triggers[types.TaskTypeOrderProcessing]; trigger.Enabled {
+		criteria.OrderStatuses = trigger.Statuses
+	}
+	if trigger := triggers[types.TaskTypeProductUpdateNeeded]; trigger.Enabled {
+		criteria.StaleBefore = now.AddDate(0, 0, -trigger.StaleDays)
+	}
+	criteria.TrackOrderSLA = triggers[types.TaskTypeOrderSLABreach].Enabled
+	criteria.TrackRightsThreshold = triggers[types.TaskTypeRightsThreshold].Enabled
+
+	stats, err := a.dashboardRepo.GetPendingTaskStats(ctx, tenantID, merchantID, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]types.PendingTask, 0, 5)
+
+	// 待处理订单：超过处理时限后提升为高优先级
+	if trigger := triggers[types.TaskTypeOrderProcessing]; trigger.Enabled && reachesMinCount(stats.PendingOrders, trigger) {
+		task := types.PendingTask{
+			ID:          "pending_orders",
+			Type:        types.TaskTypeOrderProcessing,
+			Description: fmt.Sprintf("有 %d 个订单待处理", stats.PendingOrders),
+			Priority:    types.PriorityNormal,
+			Count:       stats.PendingOrders,
+		}
+		task.DueDate = dueAfter(stats.OldestPendingOrderAt, trigger.DueHours)
+		if isOverdue(task.DueDate, now) {
+			task.Priority = types.PriorityHigh
+		}
+		tasks = append(tasks, task)
+	}
+
+	// 处理超时订单：已超过状态处理时限，需尽快人工处理
+	if trigger := triggers[types.TaskTypeOrderSLABreach]; trigger.Enabled && reachesMinCount(stats.SLABreachedOrders, trigger) {
+		tasks = append(tasks, types.PendingTask{
+			ID:          "order_sla_breach",
+			Type:        types.TaskTypeOrderSLABreach,
+			Description: fmt.Sprintf("有 %d 个订单超过处理时限", stats.SLABreachedOrders),
+			Priority:    types.PriorityUrgent,
+			DueDate:     dueAfter(&now, trigger.DueHours),
+			Count:       stats.SLABreachedOrders,
+		})
+	}
+
+	// 待核销订单：超过处理时限后提升为紧急
+	if trigger := triggers[types.TaskTypeVerificationPending]; trigger.Enabled && reachesMinCount(stats.PendingVerifications, trigger) {
+		task := types.PendingTask{
+			ID:          "pending_verifications",
+			Type:        types.TaskTypeVerificationPending,
+			Description: fmt.Sprintf("有 %d 个订单待核销", stats.PendingVerifications),
+			Priority:    types.PriorityHigh,
+			Count:       stats.PendingVerifications,
+		}
+		task.DueDate = dueAfter(stats.OldestPendingVerification, trigger.DueHours)
+		if isOverdue(task.DueDate, now) {
+			task.Priority = types.PriorityUrgent
+		}
+		tasks = append(tasks, task)
+	}
+
+	// 权益余额越过阈值：由阈值检查任务记录，包含预计耗尽时间，越过紧急阈值为紧急
+	thresholdCrossed := false
+	if trigger := triggers[types.TaskTypeRightsThreshold]; trigger.Enabled && stats.RightsThreshold != nil &&
+		stats.RightsThreshold.Level != types.RightsThresholdNormal {
+		task := types.PendingTask{
+			ID:          "rights_threshold",
+			Type:        types.TaskTypeRightsThreshold,
+			Description: stats.RightsThreshold.Summary(),
+			Priority:    types.PriorityHigh,
+			DueDate:     dueAfter(&stats.RightsThreshold.CrossedAt, trigger.DueHours),
+			Count:       1,
+		}
+		if stats.RightsThreshold.Level == types.RightsThresholdCritical || isOverdue(task.DueDate, now) {
+			task.Priority = types.PriorityUrgent
+		}
+		tasks = append(tasks, task)
+		thresholdCrossed = true
+	}
+
+	// 权益余额预警：低于预警阈值为高优先级，低于紧急阈值或耗尽为紧急；已生成越过阈值事项时不重复提示
+	if trigger := triggers[types.TaskTypeLowBalanceWarning]; trigger.Enabled && !thresholdCrossed && stats.RightsBalance != nil {
+		balance := stats.RightsBalance
+		threshold := trigger.Threshold
+		if threshold <= 0 && balance.WarningThreshold != nil {
+			threshold = *balance.WarningThreshold
+		}
+		if threshold > 0 && balance.AvailableBalance < threshold {
+			task := types.PendingTask{
+				ID:          "low_balance_warning",
+				Type:        types.TaskTypeLowBalanceWarning,
+				Description: fmt.Sprintf("权益余额不足，当前可用: %.2f", balance.AvailableBalance),
+				Priority:    types.PriorityHigh,
+				Count:       1,
+			}
+			if balance.AvailableBalance <= 0 ||
+				(balance.CriticalThreshold != nil && balance.AvailableBalance < *balance.CriticalThreshold) {
+				task.Priority = types.PriorityUrgent
+			}
+			task.DueDate = dueAfter(&now, trigger.DueHours)
+			tasks = append(tasks, task)
+		}
+	}
+
+	// 需要更新的商品
+	if trigger := triggers[types.TaskTypeProductUpdateNeeded]; trigger.Enabled && reachesMinCount(stats.ProductsNeedingUpdate, trigger) {
+		tasks = append(tasks, types.PendingTask{
+			ID:          "product_update_needed",
+			Type:        types.TaskTypeProductUpdateNeeded,
+			Description: fmt.Sprintf("有 %d 个商品库存不足或超过 %d 天未更新", stats.ProductsNeedingUpdate, trigger.StaleDays),
+			Priority:    types.PriorityLow,
+			DueDate:     dueAfter(&now, trigger.DueHours),
+			Count:       stats.ProductsNeedingUpdate,
+		})
+	}
+
+	sortPendingTasks(tasks)
+	return tasks, nil
+}
+
+// sortPendingTasks 按优先级从高到低排序，同优先级按到期时间从早到晚，无到期时间的排在最后
+func sortPendingTasks(tasks []types.PendingTask) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if wi, wj := tasks[i].Priority.Weight(), tasks[j].Priority.Weight(); wi != wj {
+			return wi > wj
+		}
+		if tasks[i].DueDate == nil || tasks[j].DueDate == nil {
+			return tasks[i].DueDate != nil
+		}
+		return tasks[i].DueDate.Before(*tasks[j].DueDate)
+	})
+}
+
+func reachesMinCount(count int, trigger types.PendingTaskTrigger) bool {
+	minCount := trigger.MinCount
+	if minCount < 1 {
+		minCount = 1
+	}
+	return count >= minCount
+}
+
+func dueAfter(since *time.Time, hours int) *time.Time {
+	if since == nil || hours <= 0 {
+		return nil
+	}
+	due := since.Add(time.Duration(hours) * time.Hour)
+	return &due
+}
+
+func isOverdue(due *time.Time, now time.Time) bool {
+	return due != nil && now.After(*due)
+}
+
+// parsePendingTaskTriggers 解析待处理事项组件配置中的触发条件，未配置的字段使用默认值
+//
+// 配置格式: {"triggers": {"order_processing": {"enabled": true, "min_count": 5, "due_hours": 12}}}
+func parsePendingTaskTriggers(config map[string]interface{}) (map[types.TaskType]types.PendingTaskTrigger, error) {
+	triggers := types.DefaultPendingTaskTriggers()
+	if config == nil {
+		return triggers, nil
+	}
+
+	configured, ok := config["triggers"]
+	if !ok {
+		return triggers, nil
+	}
+
+	for name, value := range gconv.Map(configured) {
+		taskType := types.TaskType(name)
+		trigger, ok := triggers[taskType]
+		if !ok {
+			return nil, gerror.Newf("无效的待处理事项类型: %s", name)
+		}
+
+		fields := gconv.Map(value)
+		if enabled, ok := fields["enabled"]; ok {
+			trigger.Enabled = gconv.Bool(enabled)
+		}
+		if minCount, ok := fields["min_count"]; ok {
+			trigger.MinCount = gconv.Int(minCount)
+		}
+		if dueHours, ok := fields["due_hours"]; ok {
+			trigger.DueHours = gconv.Int(dueHours)
+		}
+		if trigger.MinCount < 0 || trigger.DueHours < 0 {
+			return nil, gerror.Newf("待处理事项 %s 的数量和时限不能为负数", name)
+		}
+
+		switch taskType {
+		case types.TaskTypeOrderProcessing:
+			if statuses, ok := fields["statuses"]; ok {
+				parsed, err := parseOrderStatusFilter(gconv.Strings(statuses))
+				if err != nil {
+					return nil, err
+				}
+				if len(parsed) == 0 {
+					return nil, gerror.New("待处理订单状态不能为空")
+				}
+				trigger.Statuses = parsed
+			}
+		case types.TaskTypeLowBalanceWarning:
+			if threshold, ok := fields["threshold"]; ok {
+				trigger.Threshold = gconv.Float64(threshold)
+				if trigger.Threshold < 0 {
+					return nil, gerror.New("余额预警阈值不能为负数")
+				}
+			}
+		case types.TaskTypeProductUpdateNeeded:
+			if staleDays, ok := fields["stale_days"]; ok {
+				trigger.StaleDays = gconv.Int(staleDays)
+				if trigger.StaleDays < 1 {
+					return nil, gerror.New("商品未更新天数必须大于0")
+				}
+			}
+		}
+
+		triggers[taskType] = trigger
+	}
+
+	return triggers, nil
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/util/gconv"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+const (
+	defaultQuickProcessLimit = 20
+	maxQuickProcessLimit     = 100
+)
+
+// ErrQuickActionForbidden 当前用户无权执行快捷操作
+var ErrQuickActionForbidden = errors.New("无权执行该快捷操作")
+
+// quickActionDefinitions 内置快捷操作，列表顺序即默认展示顺序
+var quickActionDefinitions = []types.QuickAction{
+	{
+		Type:        types.QuickActionProcessPendingOrders,
+		Name:        "处理待发订单",
+		Description: "将已支付订单批量转为处理中",
+		Route:       "/orders?status=processing",
+		Params:      []string{"limit"},
+		Permissions: []types.Permission{types.PermissionMerchantOrderProcess, types.PermissionOrderUpdate},
+	},
+	{
+		Type:        types.QuickActionViewLowStock,
+		Name:        "查看低库存",
+		Description: "查看库存低于预警值的商品",
+		Route:       "/products?filter=low_stock",
+		Permissions: []types.Permission{types.PermissionMerchantProductView, types.PermissionProductView},
+	},
+	{
+		Type:        types.QuickActionRechargeRights,
+		Name:        "充值权益",
+		Description: "前往权益充值页面",
+		Route:       "/funds/recharge",
+		Params:      []string{"amount"},
+		Permissions: []types.Permission{types.PermissionFundManage},
+	},
+}
+
+// LowStockProductFinder 低库存商品查询
+type LowStockProductFinder interface {
+	GetLowStockProducts(ctx context.Context) ([]types.Product, error)
+}
+
+// QuickActionService 仪表板快捷操作服务接口
+type QuickActionService interface {
+	// 获取当前用户可用的快捷操作
+	ListActions(ctx context.Context, tenantID, merchantID uint64, permissions []string) ([]types.QuickAction, error)
+
+	// 执行快捷操作
+	ExecuteAction(ctx context.Context, tenantID, merchantID, userID uint64, permissions []string, req *types.ExecuteQuickActionRequest) (*types.QuickActionResult, error)
+}
+
+// quickActionServiceImpl 快捷操作服务实现
+type quickActionServiceImpl struct {
+	dashboardService DashboardService
+	orderRepo        repository.IOrderRepository
+	productRepo      LowStockProductFinder
+}
+
+// NewQuickActionService 创建快捷操作服务实例
+func NewQuickActionService(dashboardService DashboardService) QuickActionService {
+	return &quickActionServiceImpl{
+		dashboardService: dashboardService,
+		orderRepo:        repository.NewOrderRepository(),
+		productRepo:      repository.NewProductRepository(),
+	}
+}
+
+// NewQuickActionServiceForTest 创建测试用快捷操作服务实例
+func NewQuickActionServiceForTest(dashboardRepo repository.DashboardRepository, orderRepo repository.IOrderRepository, productRepo LowStockProductFinder) QuickActionService {
+	return &quickActionServiceImpl{
+		dashboardService: NewDashboardServiceForTest(dashboardRepo),
+		orderRepo:        orderRepo,
+		productRepo:      productRepo,
+	}
+}
+
+// ListActions 获取当前用户可用的快捷操作：只返回组件配置中启用且用户有权限执行的操作
+func (s *quickActionServiceImpl) ListActions(ctx context.Context, tenantID, merchantID uint64, permissions []string) ([]types.QuickAction, error) {
+	enabled, err := s.enabledActions(ctx, tenantID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := make([]types.QuickAction, 0, len(enabled))
+	for _, action := range enabled {
+		if hasAnyPermission(permissions, action.Permissions) {
+			actions = append(actions, action)
+		}
+	}
+	return actions, nil
+}
+
+// ExecuteAction 校验权限后执行快捷操作并记录审计日志
+func (s *quickActionServiceImpl) ExecuteAction(ctx context.Context, tenantID, merchantID, userID uint64, permissions []string, req *types.ExecuteQuickActionRequest) (*types.QuickActionResult, error) {
+	enabled, err := s.enabledActions(ctx, tenantID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var action *types.QuickAction
+	for i := range enabled {
+		if enabled[i].Type == req.Action {
+			action = &enabled[i]
+			break
+		}
+	}
+	if action == nil {
+		return nil, gerror.Newf("快捷操作不存在或未启用: %s", req.Action)
+	}
+
+	details := map[string]interface{}{
+		"action": req.Action,
+		"params": req.Params,
+	}
+	if !hasAnyPermission(permissions, action.Permissions) {
+		audit.LogSecurityViolation(ctx, tenantID, "quick_action_forbidden", "无权执行仪表板快捷操作", details)
+		return nil, ErrQuickActionForbidden
+	}
+
+	// 仓储从上下文读取租户和商户，确保操作限定在当前商户范围内
+	ctx = context.WithValue(ctx, "tenant_id", tenantID)
+	ctx = context.WithValue(ctx, "merchant_id", merchantID)
+
+	var result *types.QuickActionResult
+	switch action.Type {
+	case types.QuickActionProcessPendingOrders:
+		result, err = s.processPendingOrders(ctx, tenantID, merchantID, userID, req.Params)
+	case types.QuickActionViewLowStock:
+		result, err = s.viewLowStock(ctx)
+	case types.QuickActionRechargeRights:
+		result, err = s.rechargeRights(req.Params)
+	default:
+		err = gerror.Newf("不支持的快捷操作: %s", action.Type)
+	}
+	if err != nil {
+		details["error"] = err.Error()
+		audit.LogMerchantOperation(ctx, tenantID, merchantID, userID, "dashboard_quick_action", string(action.Type), "快捷操作执行失败", details)
+		return nil, err
+	}
+
+	result.Action = action.Type
+	if result.Route == "" {
+		result.Route = action.Route
+	}
+	details["message"] = result.Message
+	audit.LogMerchantOperation(ctx, tenantID, merchantID, userID, "dashboard_quick_action", string(action.Type), "快捷操作执行成功", details)
+
+	return result, nil
+}
+
+// processPendingOrders 将最早的一批已支付订单转为处理中
+func (s *quickActionServiceImpl) processPendingOrders(ctx context.Context, tenantID, merchantID, userID uint64, params map[string]interface{}) (*types.QuickActionResult, error) {
+	limit := gconv.Int(params["limit"])
+	if limit <= 0 {
+		limit = defaultQuickProcessLimit
+	}
+	if limit > maxQuickProcessLimit {
+		limit = maxQuickProcessLimit
+	}
+
+	list, err := s.orderRepo.QueryList(ctx, &types.OrderQueryRequest{
+		TenantID:   tenantID,
+		MerchantID: &merchantID,
+		Status:     []types.OrderStatusInt{types.OrderStatusIntPaid},
+		Page:       1,
+		PageSize:   limit,
+		SortBy:     "created_at",
+		SortOrder:  "asc",
+	})
+	if err != nil {
+		return nil, gerror.Wrap(err, "查询待处理订单失败")
+	}
+	if len(list.Items) == 0 {
+		return &types.QuickActionResult{Message: "暂无待处理订单"}, nil
+	}
+
+	orderIDs := make([]uint64, 0, len(list.Items))
+	for _, order := range list.Items {
+		orderIDs = append(orderIDs, order.ID)
+	}
+
+	response, err := s.orderRepo.BatchUpdateStatus(ctx, &types.BatchUpdateOrderStatusRequest{
+		OrderIDs:     orderIDs,
+		Status:       types.OrderStatusIntProcessing,
+		Reason:       "仪表板快捷操作：批量开始处理",
+		OperatorType: types.OrderStatusOperatorTypeMerchant,
+	}, &userID)
+	if err != nil {
+		return nil, gerror.Wrap(err, "批量处理订单失败")
+	}
+
+	return &types.QuickActionResult{
+		Message: fmt.Sprintf("已开始处理 %d 个订单，失败 %d 个", response.SuccessCount, response.FailCount),
+		Data:    response,
+	}, nil
+}
+
+// viewLowStock 查询当前商户的低库存商品
+func (s *quickActionServiceImpl) viewLowStock(ctx context.Context) (*types.QuickActionResult, error) {
+	products, err := s.productRepo.GetLowStockProducts(ctx)
+	if err != nil {
+		return nil, gerror.Wrap(err, "查询低库存商品失败")
+	}
+
+	return &types.QuickActionResult{
+		Message: fmt.Sprintf("共有 %d 个商品库存不足", len(products)),
+		Data:    products,
+	}, nil
+}
+
+// rechargeRights 权益充值需走资金服务的充值流程，这里只生成带预填金额的跳转地址
+func (s *quickActionServiceImpl) rechargeRights(params map[string]interface{}) (*types.QuickActionResult, error) {
+	result := &types.QuickActionResult{Message: "请在充值页面完成权益充值"}
+
+	if amount, ok := params["amount"]; ok {
+		value := gconv.Float64(amount)
+		if value <= 0 {
+			return nil, gerror.New("充值金额必须大于0")
+		}
+		result.Route = fmt.Sprintf("/funds/recharge?amount=%.2f", value)
+	}
+	return result, nil
+}
+
+// enabledActions 获取组件配置中启用的快捷操作，未配置时启用全部内置操作
+func (s *quickActionServiceImpl) enabledActions(ctx context.Context, tenantID, merchantID uint64) ([]types.QuickAction, error) {
+	dashboardConfig, err := s.dashboardService.GetDashboardConfig(ctx, tenantID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	widgetConfig := findWidgetConfig(dashboardConfig, types.WidgetTypeQuickActions)
+	configured, ok := widgetConfig["actions"]
+	if !ok {
+		return quickActionDefinitions, nil
+	}
+
+	var actions []types.QuickAction
+	for _, name := range gconv.Strings(configured) {
+		for _, definition := range quickActionDefinitions {
+			if string(definition.Type) == name {
+				actions = append(actions, definition)
+				break
+			}
+		}
+	}
+	return actions, nil
+}
+
+// hasAnyPermission 检查用户是否拥有任一所需权限
+func hasAnyPermission(granted []string, required []types.Permission) bool {
+	for _, permission := range granted {
+		for _, needed := range required {
+			if permission == string(needed) {
+				return true
+			}
+		}
+	}
+	return false
+}
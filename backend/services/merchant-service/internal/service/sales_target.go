@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// ErrSalesTargetNotFound 当前周期未设置销售目标
+var ErrSalesTargetNotFound = errors.New("该周期未设置销售目标")
+
+// SalesTargetService 商户销售目标服务接口
+type SalesTargetService interface {
+	// 设置周期销售目标，同一周期重复设置时覆盖
+	SetTarget(ctx context.Context, tenantID, merchantID, userID uint64, req *types.SetMerchantTargetRequest) (*types.MerchantTarget, error)
+
+	// 获取周期销售目标，period 为空时取当前周期
+	GetTarget(ctx context.Context, tenantID, merchantID uint64, period string) (*types.MerchantTarget, error)
+
+	// 获取销售目标进度（仪表板销售目标组件数据），period 为空时取当前周期
+	GetProgress(ctx context.Context, tenantID, merchantID uint64, period string) (*types.MerchantTargetProgress, error)
+}
+
+// salesTargetServiceImpl 销售目标服务实现
+type salesTargetServiceImpl struct {
+	targetRepo repository.IMerchantTargetRepository
+}
+
+// NewSalesTargetService 创建销售目标服务实例
+func NewSalesTargetService() SalesTargetService {
+	return &salesTargetServiceImpl{
+		targetRepo: repository.NewMerchantTargetRepository(),
+	}
+}
+
+// NewSalesTargetServiceForTest 创建测试用销售目标服务实例
+func NewSalesTargetServiceForTest(targetRepo repository.IMerchantTargetRepository) SalesTargetService {
+	return &salesTargetServiceImpl{
+		targetRepo: targetRepo,
+	}
+}
+
+// SetTarget 设置周期销售目标
+func (s *salesTargetServiceImpl) SetTarget(ctx context.Context, tenantID, merchantID, userID uint64, req *types.SetMerchantTargetRequest) (*types.MerchantTarget, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	target := &types.MerchantTarget{
+		TenantID:     tenantID,
+		MerchantID:   merchantID,
+		Period:       req.Period,
+		TargetAmount: req.TargetAmount,
+		TargetOrders: req.TargetOrders,
+		CreatedBy:    userID,
+	}
+	if err := s.targetRepo.Save(ctx, target); err != nil {
+		return nil, gerror.Wrapf(err, "设置商户 %d 销售目标失败", merchantID)
+	}
+
+	return target, nil
+}
+
+// GetTarget 获取周期销售目标
+func (s *salesTargetServiceImpl) GetTarget(ctx context.Context, tenantID, merchantID uint64, period string) (*types.MerchantTarget, error) {
+	if period == "" {
+		period = types.TargetPeriodOf(time.Now())
+	}
+	if _, _, err := types.ParseTargetPeriod(period); err != nil {
+		return nil, err
+	}
+
+	target, err := s.targetRepo.GetByPeriod(ctx, tenantID, merchantID, period)
+	if err != nil {
+		return nil, gerror.Wrapf(err, "获取商户 %d 销售目标失败", merchantID)
+	}
+	if target == nil {
+		return nil, ErrSalesTargetNotFound
+	}
+
+	return target, nil
+}
+
+// GetProgress 获取销售目标进度：统计周期内实际销售数据并按已过时间预计周期末达成情况
+func (s *salesTargetServiceImpl) GetProgress(ctx context.Context, tenantID, merchantID uint64, period string) (*types.MerchantTargetProgress, error) {
+	target, err := s.GetTarget(ctx, tenantID, merchantID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := types.ParseTargetPeriod(target.Period)
+	if err != nil {
+		return nil, err
+	}
+
+	actuals, err := s.targetRepo.GetPeriodActuals(ctx, tenantID, merchantID, start, end)
+	if err != nil {
+		return nil, gerror.Wrapf(err, "统计商户 %d 销售数据失败", merchantID)
+	}
+
+	return types.CalculateTargetProgress(target, actuals, time.Now())
+}
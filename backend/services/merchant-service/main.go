@@ -7,7 +7,10 @@ import (
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/os/gctx"
 	"github.com/gofromzero/mer-sys/backend/services/merchant-service/internal/controller"
+	"github.com/gofromzero/mer-sys/backend/shared/handlers"
+	"github.com/gofromzero/mer-sys/backend/shared/maintenance"
 	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 
 	_ "github.com/gogf/gf/contrib/drivers/mysql/v2"
@@ -16,21 +19,38 @@ import (
 func main() {
 	ctx := gctx.GetInitCtx()
 
+	// 数据驻留配置映射了未定义的数据库分组时拒绝启动，避免租户数据落入共享数据库
+	if err := repository.InitTenantDatasourceConfig(ctx); err != nil {
+		g.Log().Fatalf(ctx, "数据驻留配置校验失败: %v", err)
+	}
+
 	// 创建HTTP服务器
 	s := g.Server()
 
+	// 应用连接池配置并暴露连接池指标
+	handlers.RegisterDBPoolMetrics(s, "merchant-service")
+
 	// 设置端口
 	s.SetPort(8082)
 
+	// 维护模式状态在各服务间共享
+	maintenanceManager := maintenance.NewManager()
+
 	// 注册中间件
 	s.Group("/api/v1", func(group *ghttp.RouterGroup) {
 		// 公开路由（不需要认证）
 		group.Middleware(middleware.CORS())
+		// 记录访问日志，配置的路由按采样记录脱敏后的请求/响应体
+		group.Middleware(middleware.AccessLog(middleware.LoadAccessLogConfig(ctx)))
+		// 支持 fields 参数只返回指定的响应字段
+		group.Middleware(middleware.ResponseFields(middleware.LoadResponseFieldsConfig(ctx)))
 
 		// 需要认证的路由
 		group.Group("/", func(authGroup *ghttp.RouterGroup) {
 			// 基础认证中间件
 			authGroup.Middleware(middleware.NewAuthMiddleware().JWTAuth)
+			// 维护模式下拒绝写请求
+			authGroup.Middleware(middleware.MaintenanceMode(maintenanceManager))
 
 			// 商户相关路由
 			merchantController := controller.NewMerchantController()
@@ -45,6 +65,12 @@ func main() {
 				middleware.NewAuthMiddleware().RequirePermissions(types.PermissionMerchantView),
 				merchantController.List)
 			
+			// 导出商户列表及权益余额 - 需要管理权限
+			authGroup.Group("/merchants/export", func(exportGroup *ghttp.RouterGroup) {
+				exportGroup.Middleware(middleware.NewAuthMiddleware().RequirePermissions(types.PermissionMerchantManage))
+				exportGroup.GET("/", merchantController.Export)
+			})
+			
 			// 查看商户详情 - 需要查看权限
 			authGroup.GET("/merchants/:id", 
 				middleware.NewAuthMiddleware().RequirePermissions(types.PermissionMerchantView),
@@ -60,6 +86,20 @@ func main() {
 				middleware.NewAuthMiddleware().RequirePermissions(types.PermissionMerchantManage),
 				merchantController.UpdateStatus)
 			
+			// 设置商户保留权益 - 需要管理权限
+			authGroup.PUT("/merchants/:id/rights-reserve",
+				middleware.NewAuthMiddleware().RequirePermissions(types.PermissionMerchantManage),
+				merchantController.UpdateRightsReserve)
+
+			// 联系方式验证 - 需要更新权限
+			contactVerificationController := controller.NewContactVerificationController()
+			authGroup.POST("/merchants/:id/contact-verification",
+				middleware.NewAuthMiddleware().RequirePermissions(types.PermissionMerchantUpdate),
+				contactVerificationController.Send)
+			authGroup.POST("/merchants/:id/contact-verification/confirm",
+				middleware.NewAuthMiddleware().RequirePermissions(types.PermissionMerchantUpdate),
+				contactVerificationController.Confirm)
+
 			// 审批商户申请 - 需要管理权限（敏感操作）
 			authGroup.POST("/merchants/:id/approve", 
 				middleware.NewAuthMiddleware().RequirePermissions(types.PermissionMerchantManage),
@@ -79,9 +119,11 @@ func main() {
 
 	// 健康检查路由
 	s.BindHandler("/health", func(r *ghttp.Request) {
+		maintenanceStatus, _ := maintenanceManager.Status(r.Context(), 0)
 		r.Response.WriteJson(g.Map{
 			"status": "ok",
 			"service": "merchant-service",
+			"maintenance": maintenanceStatus,
 		})
 	})
 
@@ -0,0 +1,214 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/services/merchant-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/cache"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeContactMerchantRepository 内存商户仓储，只实现联系方式验证和审批用到的方法
+type fakeContactMerchantRepository struct {
+	repository.MerchantRepository
+	merchants map[uint64]*types.Merchant
+	approved  []uint64
+}
+
+func (f *fakeContactMerchantRepository) GetByID(ctx context.Context, id uint64) (*types.Merchant, error) {
+	merchant, exists := f.merchants[id]
+	if !exists {
+		return nil, errors.New("商户不存在")
+	}
+	copied := *merchant
+	return &copied, nil
+}
+
+func (f *fakeContactMerchantRepository) UpdateContactVerification(ctx context.Context, id uint64, channel types.MerchantContactChannel, verifiedAt *time.Time) error {
+	switch channel {
+	case types.MerchantContactChannelEmail:
+		f.merchants[id].ContactEmailVerifiedAt = verifiedAt
+	case types.MerchantContactChannelPhone:
+		f.merchants[id].ContactPhoneVerifiedAt = verifiedAt
+	}
+	return nil
+}
+
+func (f *fakeContactMerchantRepository) UpdateApproval(ctx context.Context, id uint64, status types.MerchantStatus, approvedBy uint64) error {
+	f.merchants[id].Status = status
+	f.approved = append(f.approved, id)
+	return nil
+}
+
+// capturingCodeSender 记录发送的验证码消息
+type capturingCodeSender struct {
+	emails   []string
+	messages []string
+}
+
+func (c *capturingCodeSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	c.emails = append(c.emails, to)
+	c.messages = append(c.messages, body)
+	return nil
+}
+
+func (c *capturingCodeSender) SendSMS(ctx context.Context, phone, message string) error {
+	c.messages = append(c.messages, message)
+	return nil
+}
+
+var contactCodePattern = regexp.MustCompile(`\d{6}`)
+
+// lastCode 最近一次发送的验证码
+func (c *capturingCodeSender) lastCode() string {
+	return contactCodePattern.FindString(c.messages[len(c.messages)-1])
+}
+
+func TestMerchantContactVerification(t *testing.T) {
+	Convey("商户联系方式验证测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		ctx = context.WithValue(ctx, "user_id", uint64(1))
+		ctx = context.WithValue(ctx, "username", "admin")
+
+		repo := &fakeContactMerchantRepository{
+			merchants: map[uint64]*types.Merchant{
+				10: {
+					ID:       10,
+					TenantID: 1,
+					Name:     "测试商户",
+					Status:   types.MerchantStatusPending,
+					BusinessInfo: &types.BusinessInfo{
+						ContactPhone: "13800138000",
+						ContactEmail: "contact@example.com",
+					},
+				},
+				11: {
+					ID:           11,
+					TenantID:     1,
+					Name:         "未登记联系方式的商户",
+					Status:       types.MerchantStatusPending,
+					BusinessInfo: &types.BusinessInfo{},
+				},
+			},
+		}
+		sender := &capturingCodeSender{}
+		now := time.Date(2025, 9, 1, 10, 0, 0, 0, time.Local)
+		clock := func() time.Time { return now }
+		policy := types.DefaultMerchantContactVerificationPolicy()
+		verification := service.NewMerchantContactVerificationServiceForTest(repo, sender, cache.NewMockCache(), policy, clock)
+
+		Convey("发送验证码到登记的联系邮箱，返回脱敏后的联系方式", func() {
+			issued, err := verification.SendCode(ctx, 10, types.MerchantContactChannelEmail)
+			So(err, ShouldBeNil)
+			So(sender.emails, ShouldResemble, []string{"contact@example.com"})
+			So(sender.lastCode(), ShouldHaveLength, 6)
+			So(issued.Target, ShouldNotEqual, "contact@example.com")
+			So(issued.ExpiresAt, ShouldEqual, now.Add(policy.CodeTTL))
+			So(issued.ResendAfter, ShouldEqual, now.Add(policy.ResendInterval))
+		})
+
+		Convey("重发间隔内不能再次发送", func() {
+			_, err := verification.SendCode(ctx, 10, types.MerchantContactChannelPhone)
+			So(err, ShouldBeNil)
+			_, err = verification.SendCode(ctx, 10, types.MerchantContactChannelPhone)
+			So(errors.Is(err, service.ErrContactCodeResendTooSoon), ShouldBeTrue)
+
+			now = now.Add(policy.ResendInterval)
+			_, err = verification.SendCode(ctx, 10, types.MerchantContactChannelPhone)
+			So(err, ShouldBeNil)
+			So(sender.messages, ShouldHaveLength, 2)
+		})
+
+		Convey("未登记联系方式时不能发送验证码", func() {
+			_, err := verification.SendCode(ctx, 11, types.MerchantContactChannelEmail)
+			So(errors.Is(err, service.ErrMerchantContactMissing), ShouldBeTrue)
+			So(sender.messages, ShouldBeEmpty)
+		})
+
+		Convey("正确的验证码标记联系方式已验证", func() {
+			_, err := verification.SendCode(ctx, 10, types.MerchantContactChannelPhone)
+			So(err, ShouldBeNil)
+
+			merchant, err := verification.Confirm(ctx, 10, types.MerchantContactChannelPhone, sender.lastCode())
+			So(err, ShouldBeNil)
+			So(merchant.IsContactVerified(types.MerchantContactChannelPhone), ShouldBeTrue)
+			So(repo.merchants[10].ContactPhoneVerifiedAt, ShouldNotBeNil)
+			So(repo.merchants[10].ContactEmailVerifiedAt, ShouldBeNil)
+
+			// 验证码只能使用一次
+			_, err = verification.Confirm(ctx, 10, types.MerchantContactChannelPhone, sender.lastCode())
+			So(errors.Is(err, service.ErrContactCodeExpired), ShouldBeTrue)
+		})
+
+		Convey("过期的验证码不能使用", func() {
+			_, err := verification.SendCode(ctx, 10, types.MerchantContactChannelEmail)
+			So(err, ShouldBeNil)
+
+			now = now.Add(policy.CodeTTL)
+			_, err = verification.Confirm(ctx, 10, types.MerchantContactChannelEmail, sender.lastCode())
+			So(errors.Is(err, service.ErrContactCodeExpired), ShouldBeTrue)
+			So(repo.merchants[10].ContactEmailVerifiedAt, ShouldBeNil)
+		})
+
+		Convey("错误次数达到上限后验证码失效", func() {
+			_, err := verification.SendCode(ctx, 10, types.MerchantContactChannelEmail)
+			So(err, ShouldBeNil)
+			code := sender.lastCode()
+			wrong := "000000"
+			if code == wrong {
+				wrong = "111111"
+			}
+
+			for i := 1; i < policy.MaxAttempts; i++ {
+				_, err = verification.Confirm(ctx, 10, types.MerchantContactChannelEmail, wrong)
+				So(errors.Is(err, service.ErrContactCodeInvalid), ShouldBeTrue)
+			}
+			_, err = verification.Confirm(ctx, 10, types.MerchantContactChannelEmail, wrong)
+			So(errors.Is(err, service.ErrContactCodeTooManyAttempts), ShouldBeTrue)
+
+			_, err = verification.Confirm(ctx, 10, types.MerchantContactChannelEmail, code)
+			So(errors.Is(err, service.ErrContactCodeExpired), ShouldBeTrue)
+		})
+
+		Convey("发送验证码后联系方式变更，旧验证码失效", func() {
+			_, err := verification.SendCode(ctx, 10, types.MerchantContactChannelEmail)
+			So(err, ShouldBeNil)
+
+			repo.merchants[10].BusinessInfo = &types.BusinessInfo{ContactEmail: "new@example.com"}
+			_, err = verification.Confirm(ctx, 10, types.MerchantContactChannelEmail, sender.lastCode())
+			So(errors.Is(err, service.ErrContactCodeExpired), ShouldBeTrue)
+		})
+
+		Convey("审批前需至少验证一种联系方式", func() {
+			merchantService := service.NewMerchantServiceForTest(repo, policy)
+
+			err := merchantService.ApproveMerchant(ctx, 10, "资料齐全")
+			So(errors.Is(err, service.ErrMerchantContactUnverified), ShouldBeTrue)
+			So(repo.approved, ShouldBeEmpty)
+
+			_, err = verification.SendCode(ctx, 10, types.MerchantContactChannelEmail)
+			So(err, ShouldBeNil)
+			_, err = verification.Confirm(ctx, 10, types.MerchantContactChannelEmail, sender.lastCode())
+			So(err, ShouldBeNil)
+
+			err = merchantService.ApproveMerchant(ctx, 10, "资料齐全")
+			So(err, ShouldBeNil)
+			So(repo.approved, ShouldResemble, []uint64{10})
+		})
+
+		Convey("关闭验证要求时可直接审批", func() {
+			policy.RequiredForApproval = false
+			merchantService := service.NewMerchantServiceForTest(repo, policy)
+
+			err := merchantService.ApproveMerchant(ctx, 11, "")
+			So(err, ShouldBeNil)
+			So(repo.approved, ShouldResemble, []uint64{11})
+		})
+	})
+}
@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/services/merchant-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recentOrder 带商户归属的测试订单
+type recentOrder struct {
+	merchantID uint64
+	summary    types.OrderSummary
+}
+
+// fakeDashboardRepository 内存仪表板仓储
+type fakeDashboardRepository struct {
+	repository.DashboardRepository
+	config       *types.DashboardConfig
+	orders       []recentOrder
+	lastLimit    int
+	taskStats    *repository.PendingTaskStats
+	lastCriteria *repository.PendingTaskCriteria
+}
+
+func (f *fakeDashboardRepository) GetDashboardConfig(ctx context.Context, tenantID, merchantID uint64) (*types.DashboardConfig, error) {
+	return f.config, nil
+}
+
+func (f *fakeDashboardRepository) GetRecentOrders(ctx context.Context, tenantID, merchantID uint64, limit int, statuses []types.OrderStatusInt) ([]types.OrderSummary, error) {
+	f.lastLimit = limit
+	var result []types.OrderSummary
+	for _, order := range f.orders {
+		if order.merchantID != merchantID {
+			continue
+		}
+		if len(statuses) > 0 && !containsStatus(statuses, order.summary.Status) {
+			continue
+		}
+		if len(result) == limit {
+			break
+		}
+		result = append(result, order.summary)
+	}
+	return result, nil
+}
+
+func (f *fakeDashboardRepository) GetPendingTaskStats(ctx context.Context, tenantID, merchantID uint64, criteria *repository.PendingTaskCriteria) (*repository.PendingTaskStats, error) {
+	f.lastCriteria = criteria
+	return f.taskStats, nil
+}
+
+func containsStatus(statuses []types.OrderStatusInt, status types.OrderStatusInt) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDashboardRecentOrders(t *testing.T) {
+	Convey("仪表板最近订单组件测试", t, func() {
+		ctx := context.Background()
+		now := time.Now()
+
+		repo := &fakeDashboardRepository{
+			config: &types.DashboardConfig{
+				MerchantID: 1,
+				WidgetPreferences: []types.WidgetPreference{
+					{
+						WidgetType: types.WidgetTypeRecentOrders,
+						Enabled:    true,
+						Config:     map[string]interface{}{"limit": 2, "status_filter": []interface{}{"paid", "processing"}},
+					},
+				},
+			},
+		}
+		for i := 1; i <= 6; i++ {
+			status := types.OrderStatusIntPaid
+			if i%2 == 0 {
+				status = types.OrderStatusIntCompleted
+			}
+			repo.orders = append(repo.orders, recentOrder{
+				merchantID: 1,
+				summary:    types.OrderSummary{ID: uint64(i), Status: status, CreatedAt: now.Add(-time.Duration(i) * time.Minute)},
+			})
+		}
+		repo.orders = append(repo.orders, recentOrder{
+			merchantID: 2,
+			summary:    types.OrderSummary{ID: 100, Status: types.OrderStatusIntPaid},
+		})
+
+		dashboardService := service.NewDashboardServiceForTest(repo)
+
+		Convey("按组件配置的条数和状态返回本商户订单", func() {
+			orders, err := dashboardService.GetRecentOrders(ctx, 1, 1, nil)
+			So(err, ShouldBeNil)
+			So(len(orders), ShouldEqual, 2)
+			for _, order := range orders {
+				So(order.Status, ShouldEqual, types.OrderStatusIntPaid)
+				So(order.ID, ShouldNotEqual, 100)
+			}
+		})
+
+		Convey("请求参数覆盖组件配置", func() {
+			orders, err := dashboardService.GetRecentOrders(ctx, 1, 1, &service.RecentOrdersQuery{
+				Limit:        5,
+				StatusFilter: []string{"completed"},
+			})
+			So(err, ShouldBeNil)
+			So(len(orders), ShouldEqual, 3)
+			for _, order := range orders {
+				So(order.Status, ShouldEqual, types.OrderStatusIntCompleted)
+			}
+		})
+
+		Convey("条数超过上限时按上限查询", func() {
+			orders, err := dashboardService.GetRecentOrders(ctx, 1, 1, &service.RecentOrdersQuery{
+				Limit:        1000,
+				StatusFilter: []string{"paid", "4"},
+			})
+			So(err, ShouldBeNil)
+			So(len(orders), ShouldEqual, 6)
+			So(repo.lastLimit, ShouldEqual, types.MaxRecentOrdersLimit)
+		})
+
+		Convey("其他商户看不到本商户订单", func() {
+			orders, err := dashboardService.GetRecentOrders(ctx, 1, 2, nil)
+			So(err, ShouldBeNil)
+			So(len(orders), ShouldEqual, 1)
+			So(orders[0].ID, ShouldEqual, 100)
+		})
+
+		Convey("无效的状态过滤被拒绝", func() {
+			_, err := dashboardService.GetRecentOrders(ctx, 1, 1, &service.RecentOrdersQuery{StatusFilter: []string{"shipped"}})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,137 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/services/merchant-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/xuri/excelize/v2"
+)
+
+// exportMerchantRepository 内存商户仓储，按上下文租户和导出条件分批返回商户
+type exportMerchantRepository struct {
+	repository.MerchantRepository
+	merchants []*types.Merchant
+}
+
+func (f *exportMerchantRepository) ListForExport(ctx context.Context, query *types.MerchantExportQuery, afterID uint64, limit int) ([]*types.Merchant, error) {
+	tenantID, _ := ctx.Value("tenant_id").(uint64)
+
+	var result []*types.Merchant
+	for _, merchant := range f.merchants {
+		if merchant.TenantID != tenantID || merchant.ID <= afterID {
+			continue
+		}
+		if query.Status != "" && merchant.Status != query.Status {
+			continue
+		}
+		if query.MerchantID != nil && merchant.ID != *query.MerchantID {
+			continue
+		}
+		result = append(result, merchant)
+		if len(result) == limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// readExportCSV 去掉 BOM 后解析导出的 CSV
+func readExportCSV(data []byte) [][]string {
+	records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF")))).ReadAll()
+	So(err, ShouldBeNil)
+	return records
+}
+
+func TestMerchantExportService(t *testing.T) {
+	Convey("商户列表导出测试", t, func() {
+		repo := &exportMerchantRepository{merchants: []*types.Merchant{
+			{
+				ID: 1, TenantID: 1, Name: "一号商户", Code: "M001", Status: types.MerchantStatusActive,
+				BusinessInfo:  &types.BusinessInfo{ContactName: "张三", ContactPhone: "13800138000", ContactEmail: "a@example.com"},
+				RightsBalance: &types.RightsBalance{TotalBalance: 1000, UsedBalance: 300, FrozenBalance: 200},
+			},
+			{ID: 2, TenantID: 2, Name: "其他租户商户", Code: "X001", Status: types.MerchantStatusActive},
+			{ID: 3, TenantID: 1, Name: "三号商户", Code: "M003", Status: types.MerchantStatusSuspended},
+		}}
+		exportService := service.NewMerchantExportServiceForTest(repo)
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+		Convey("导出当前租户的商户及权益余额", func() {
+			query := &types.MerchantExportQuery{}
+			So(exportService.PrepareQuery(ctx, query), ShouldBeNil)
+			So(query.Format, ShouldEqual, types.OrderExportFormatCSV)
+
+			var buf bytes.Buffer
+			count, err := exportService.Export(ctx, query, &buf)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 2)
+
+			records := readExportCSV(buf.Bytes())
+			So(records, ShouldHaveLength, 3)
+			So(records[0], ShouldResemble, types.MerchantExportHeader)
+			So(records[1][:10], ShouldResemble, []string{
+				"一号商户", "M001", "active", "张三", "13800138000", "a@example.com",
+				"1000.00", "300.00", "200.00", "500.00",
+			})
+			So(records[2][1], ShouldEqual, "M003")
+			So(buf.String(), ShouldNotContainSubstring, "其他租户商户")
+		})
+
+		Convey("其他租户只能导出自己的商户", func() {
+			otherCtx := context.WithValue(context.Background(), "tenant_id", uint64(2))
+			query := &types.MerchantExportQuery{}
+			So(exportService.PrepareQuery(otherCtx, query), ShouldBeNil)
+
+			var buf bytes.Buffer
+			count, err := exportService.Export(otherCtx, query, &buf)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+			So(buf.String(), ShouldContainSubstring, "X001")
+			So(buf.String(), ShouldNotContainSubstring, "M001")
+		})
+
+		Convey("商户用户只能导出本商户", func() {
+			merchantCtx := context.WithValue(ctx, "merchant_id", uint64(3))
+			query := &types.MerchantExportQuery{}
+			So(exportService.PrepareQuery(merchantCtx, query), ShouldBeNil)
+
+			var buf bytes.Buffer
+			count, err := exportService.Export(merchantCtx, query, &buf)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+			So(buf.String(), ShouldContainSubstring, "M003")
+		})
+
+		Convey("按状态过滤并导出Excel", func() {
+			query := &types.MerchantExportQuery{Format: types.OrderExportFormatExcel, Status: types.MerchantStatusSuspended}
+			So(exportService.PrepareQuery(ctx, query), ShouldBeNil)
+
+			var buf bytes.Buffer
+			count, err := exportService.Export(ctx, query, &buf)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+
+			file, err := excelize.OpenReader(&buf)
+			So(err, ShouldBeNil)
+			defer file.Close()
+			rows, err := file.GetRows("Sheet1")
+			So(err, ShouldBeNil)
+			So(rows, ShouldHaveLength, 2)
+			So(rows[1][0], ShouldEqual, "三号商户")
+		})
+
+		Convey("缺少租户信息或条件无效时拒绝导出", func() {
+			So(exportService.PrepareQuery(context.Background(), &types.MerchantExportQuery{}), ShouldNotBeNil)
+			err := exportService.PrepareQuery(ctx, &types.MerchantExportQuery{Status: "closed"})
+			So(err, ShouldNotBeNil)
+			So(strings.Contains(err.Error(), "closed"), ShouldBeTrue)
+		})
+	})
+}
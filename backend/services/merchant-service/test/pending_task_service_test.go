@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/services/merchant-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDashboardPendingTasks(t *testing.T) {
+	Convey("仪表板待处理事项汇总测试", t, func() {
+		ctx := context.Background()
+		now := time.Now()
+		hoursAgo := func(hours int) *time.Time {
+			at := now.Add(-time.Duration(hours) * time.Hour)
+			return &at
+		}
+		warning, critical := 100.0, 20.0
+
+		repo := &fakeDashboardRepository{
+			config:    &types.DashboardConfig{MerchantID: 1},
+			taskStats: &repository.PendingTaskStats{},
+		}
+		dashboardService := service.NewDashboardServiceForTest(repo)
+
+		Convey("没有待处理数据时不生成事项", func() {
+			tasks, err := dashboardService.GetPendingTasks(ctx, 1, 1)
+			So(err, ShouldBeNil)
+			So(tasks, ShouldBeEmpty)
+			So(repo.lastCriteria.OrderStatuses, ShouldResemble, []types.OrderStatusInt{types.OrderStatusIntPending, types.OrderStatusIntProcessing})
+		})
+
+		Convey("待处理订单生成订单处理事项，到期时间从最早订单起算", func() {
+			repo.taskStats.PendingOrders = 3
+			repo.taskStats.OldestPendingOrderAt = hoursAgo(2)
+
+			tasks, err := dashboardService.GetPendingTasks(ctx, 1, 1)
+			So(err, ShouldBeNil)
+			So(len(tasks), ShouldEqual, 1)
+			So(tasks[0].Type, ShouldEqual, types.TaskTypeOrderProcessing)
+			So(tasks[0].Count, ShouldEqual, 3)
+			So(tasks[0].Priority, ShouldEqual, types.PriorityNormal)
+			So(*tasks[0].DueDate, ShouldEqual, repo.taskStats.OldestPendingOrderAt.Add(24*time.Hour))
+		})
+
+		Convey("超过处理时限的订单提升优先级", func() {
+			repo.taskStats.PendingOrders = 1
+			repo.taskStats.OldestPendingOrderAt = hoursAgo(30)
+
+			tasks, err := dashboardService.GetPendingTasks(ctx, 1, 1)
+			So(err, ShouldBeNil)
+			So(tasks[0].Priority, ShouldEqual, types.PriorityHigh)
+		})
+
+		Convey("未核销订单生成核销事项，超时为紧急", func() {
+			repo.taskStats.PendingVerifications = 2
+			repo.taskStats.OldestPendingVerification = hoursAgo(50)
+
+			tasks, err := dashboardService.GetPendingTasks(ctx, 1, 1)
+			So(err, ShouldBeNil)
+			So(len(tasks), ShouldEqual, 1)
+			So(tasks[0].Type, ShouldEqual, types.TaskTypeVerificationPending)
+			So(tasks[0].Priority, ShouldEqual, types.PriorityUrgent)
+		})
+
+		Convey("权益余额低于预警阈值生成余额预警", func() {
+			repo.taskStats.RightsBalance = &types.RightsBalance{AvailableBalance: 50, WarningThreshold: &warning, CriticalThreshold: &critical}
+
+			tasks, err := dashboardService.GetPendingTasks(ctx, 1, 1)
+			So(err, ShouldBeNil)
+			So(len(tasks), ShouldEqual, 1)
+			So(tasks[0].Type, ShouldEqual, types.TaskTypeLowBalanceWarning)
+			So(tasks[0].Priority, ShouldEqual, types.PriorityHigh)
+			So(tasks[0].DueDate, ShouldNotBeNil)
+
+			Convey("低于紧急阈值时为紧急", func() {
+				repo.taskStats.RightsBalance.AvailableBalance = 10
+				tasks, err := service.NewDashboardServiceForTest(repo).GetPendingTasks(ctx, 1, 1)
+				So(err, ShouldBeNil)
+				So(tasks[0].Priority, ShouldEqual, types.PriorityUrgent)
+			})
+		})
+
+		Convey("余额充足时不生成余额预警", func() {
+			repo.taskStats.RightsBalance = &types.RightsBalance{AvailableBalance: 500, WarningThreshold: &warning}
+
+			tasks, err := dashboardService.GetPendingTasks(ctx, 1, 1)
+			So(err, ShouldBeNil)
+			So(tasks, ShouldBeEmpty)
+		})
+
+		Convey("需要更新的商品生成商品更新事项", func() {
+			repo.taskStats.ProductsNeedingUpdate = 4
+
+			tasks, err := dashboardService.GetPendingTasks(ctx, 1, 1)
+			So(err, ShouldBeNil)
+			So(len(tasks), ShouldEqual, 1)
+			So(tasks[0].Type, ShouldEqual, types.TaskTypeProductUpdateNeeded)
+			So(tasks[0].Priority, ShouldEqual, types.PriorityLow)
+			So(repo.lastCriteria.StaleBefore, ShouldHappenWithin, time.Minute, now.AddDate(0, 0, -90))
+		})
+
+		Convey("超过处理时限的订单生成紧急事项", func() {
+			repo.taskStats.SLABreachedOrders = 2
+
+			tasks, err := dashboardService.GetPendingTasks(ctx, 1, 1)
+			So(err, ShouldBeNil)
+			So(repo.lastCriteria.TrackOrderSLA, ShouldBeTrue)
+			So(len(tasks), ShouldEqual, 1)
+			So(tasks[0].Type, ShouldEqual, types.TaskTypeOrderSLABreach)
+			So(tasks[0].Count, ShouldEqual, 2)
+			So(tasks[0].Priority, ShouldEqual, types.PriorityUrgent)
+			So(*tasks[0].DueDate, ShouldHappenWithin, time.Minute, now.Add(4*time.Hour))
+		})
+
+		Convey("事项按优先级排序", func() {
+			repo.taskStats = &repository.PendingTaskStats{
+				PendingOrders:             1,
+				OldestPendingOrderAt:      hoursAgo(1),
+				PendingVerifications:      1,
+				OldestPendingVerification: hoursAgo(1),
+				RightsBalance:             &types.RightsBalance{AvailableBalance: 0, WarningThreshold: &warning},
+				ProductsNeedingUpdate:     1,
+			}
+
+			tasks, err := dashboardService.GetPendingTasks(ctx, 1, 1)
+			So(err, ShouldBeNil)
+			So(len(tasks), ShouldEqual, 4)
+			So(tasks[0].Type, ShouldEqual, types.TaskTypeLowBalanceWarning)
+			So(tasks[1].Type, ShouldEqual, types.TaskTypeVerificationPending)
+			So(tasks[2].Type, ShouldEqual, types.TaskTypeOrderProcessing)
+			So(tasks[3].Type, ShouldEqual, types.TaskTypeProductUpdateNeeded)
+		})
+
+		Convey("触发条件可通过组件配置调整", func() {
+			repo.config.WidgetPreferences = []types.WidgetPreference{
+				{
+					WidgetType: types.WidgetTypePendingTasks,
+					Enabled:    true,
+					Config: map[string]interface{}{
+						"triggers": map[string]interface{}{
+							"order_processing":      map[string]interface{}{"statuses": []string{"paid"}, "min_count": 5},
+							"verification_pending":  map[string]interface{}{"enabled": false},
+							"low_balance_warning":   map[string]interface{}{"threshold": 1000},
+							"product_update_needed": map[string]interface{}{"stale_days": 30},
+							"order_sla_breach":      map[string]interface{}{"enabled": false},
+						},
+					},
+				},
+			}
+			repo.taskStats = &repository.PendingTaskStats{
+				PendingOrders:        3,
+				PendingVerifications: 2,
+				RightsBalance:        &types.RightsBalance{AvailableBalance: 500, WarningThreshold: &warning},
+				SLABreachedOrders:    1,
+			}
+
+			tasks, err := dashboardService.GetPendingTasks(ctx, 1, 1)
+			So(err, ShouldBeNil)
+			So(len(tasks), ShouldEqual, 1)
+			So(tasks[0].Type, ShouldEqual, types.TaskTypeLowBalanceWarning)
+			So(repo.lastCriteria.OrderStatuses, ShouldResemble, []types.OrderStatusInt{types.OrderStatusIntPaid})
+			So(repo.lastCriteria.StaleBefore, ShouldHappenWithin, time.Minute, now.AddDate(0, 0, -30))
+			So(repo.lastCriteria.TrackOrderSLA, ShouldBeFalse)
+		})
+
+		Convey("无效的触发条件配置被拒绝", func() {
+			repo.config.WidgetPreferences = []types.WidgetPreference{
+				{
+					WidgetType: types.WidgetTypePendingTasks,
+					Enabled:    true,
+					Config:     map[string]interface{}{"triggers": map[string]interface{}{"unknown_task": map[string]interface{}{}}},
+				},
+			}
+
+			_, err := dashboardService.GetPendingTasks(ctx, 1, 1)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
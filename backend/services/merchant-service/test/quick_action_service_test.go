@@ -0,0 +1,138 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/services/merchant-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeQuickActionOrderRepository 记录批量处理请求的订单仓储桩
+type fakeQuickActionOrderRepository struct {
+	repository.IOrderRepository
+	paidOrderIDs []uint64
+	queried      *types.OrderQueryRequest
+	updated      *types.BatchUpdateOrderStatusRequest
+}
+
+func (f *fakeQuickActionOrderRepository) QueryList(ctx context.Context, req *types.OrderQueryRequest) (*types.OrderListResponse, error) {
+	f.queried = req
+	response := &types.OrderListResponse{}
+	for _, id := range f.paidOrderIDs {
+		response.Items = append(response.Items, types.OrderSummary{ID: id, Status: types.OrderStatusIntPaid})
+	}
+	return response, nil
+}
+
+func (f *fakeQuickActionOrderRepository) BatchUpdateStatus(ctx context.Context, req *types.BatchUpdateOrderStatusRequest, operatorID *uint64) (*types.BatchUpdateOrderStatusResponse, error) {
+	f.updated = req
+	return &types.BatchUpdateOrderStatusResponse{SuccessCount: len(req.OrderIDs)}, nil
+}
+
+// fakeLowStockProductFinder 低库存商品桩，记录查询时上下文中的商户
+type fakeLowStockProductFinder struct {
+	products   []types.Product
+	merchantID interface{}
+}
+
+func (f *fakeLowStockProductFinder) GetLowStockProducts(ctx context.Context) ([]types.Product, error) {
+	f.merchantID = ctx.Value("merchant_id")
+	return f.products, nil
+}
+
+func TestDashboardQuickActions(t *testing.T) {
+	Convey("仪表板快捷操作测试", t, func() {
+		ctx := context.Background()
+		dashboardRepo := &fakeDashboardRepository{config: &types.DashboardConfig{MerchantID: 1}}
+		orderRepo := &fakeQuickActionOrderRepository{paidOrderIDs: []uint64{11, 12}}
+		productRepo := &fakeLowStockProductFinder{products: []types.Product{{ID: 7, Name: "咖啡豆"}}}
+		quickActionService := service.NewQuickActionServiceForTest(dashboardRepo, orderRepo, productRepo)
+
+		orderStaff := []string{string(types.PermissionMerchantOrderProcess)}
+		productViewer := []string{string(types.PermissionMerchantProductView)}
+
+		Convey("按权限列出可用快捷操作", func() {
+			actions, err := quickActionService.ListActions(ctx, 1, 1, orderStaff)
+			So(err, ShouldBeNil)
+			So(len(actions), ShouldEqual, 1)
+			So(actions[0].Type, ShouldEqual, types.QuickActionProcessPendingOrders)
+
+			actions, err = quickActionService.ListActions(ctx, 1, 1, nil)
+			So(err, ShouldBeNil)
+			So(actions, ShouldBeEmpty)
+		})
+
+		Convey("有订单处理权限时批量处理本商户已支付订单", func() {
+			result, err := quickActionService.ExecuteAction(ctx, 1, 1, 5, orderStaff, &types.ExecuteQuickActionRequest{
+				Action: types.QuickActionProcessPendingOrders,
+				Params: map[string]interface{}{"limit": 500},
+			})
+			So(err, ShouldBeNil)
+			So(result.Action, ShouldEqual, types.QuickActionProcessPendingOrders)
+			So(*orderRepo.queried.MerchantID, ShouldEqual, 1)
+			So(orderRepo.queried.PageSize, ShouldEqual, 100)
+			So(orderRepo.updated.OrderIDs, ShouldResemble, []uint64{11, 12})
+			So(orderRepo.updated.Status, ShouldEqual, types.OrderStatusIntProcessing)
+		})
+
+		Convey("缺少权限时拒绝执行且不调用下游", func() {
+			_, err := quickActionService.ExecuteAction(ctx, 1, 1, 5, productViewer, &types.ExecuteQuickActionRequest{
+				Action: types.QuickActionProcessPendingOrders,
+			})
+			So(errors.Is(err, service.ErrQuickActionForbidden), ShouldBeTrue)
+			So(orderRepo.queried, ShouldBeNil)
+			So(orderRepo.updated, ShouldBeNil)
+
+			_, err = quickActionService.ExecuteAction(ctx, 1, 1, 5, orderStaff, &types.ExecuteQuickActionRequest{
+				Action: types.QuickActionRechargeRights,
+			})
+			So(errors.Is(err, service.ErrQuickActionForbidden), ShouldBeTrue)
+		})
+
+		Convey("查看低库存限定在当前商户", func() {
+			result, err := quickActionService.ExecuteAction(ctx, 1, 3, 5, productViewer, &types.ExecuteQuickActionRequest{
+				Action: types.QuickActionViewLowStock,
+			})
+			So(err, ShouldBeNil)
+			So(result.Data, ShouldResemble, productRepo.products)
+			So(productRepo.merchantID, ShouldEqual, uint64(3))
+		})
+
+		Convey("充值权益生成带金额的跳转地址", func() {
+			fundManager := []string{string(types.PermissionFundManage)}
+			result, err := quickActionService.ExecuteAction(ctx, 1, 1, 5, fundManager, &types.ExecuteQuickActionRequest{
+				Action: types.QuickActionRechargeRights,
+				Params: map[string]interface{}{"amount": "200"},
+			})
+			So(err, ShouldBeNil)
+			So(result.Route, ShouldEqual, "/funds/recharge?amount=200.00")
+
+			_, err = quickActionService.ExecuteAction(ctx, 1, 1, 5, fundManager, &types.ExecuteQuickActionRequest{
+				Action: types.QuickActionRechargeRights,
+				Params: map[string]interface{}{"amount": -1},
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("组件配置未启用的操作不可见也不可执行", func() {
+			dashboardRepo.config.WidgetPreferences = []types.WidgetPreference{
+				{WidgetType: types.WidgetTypeQuickActions, Enabled: true, Config: map[string]interface{}{"actions": []string{"view_low_stock"}}},
+			}
+
+			actions, err := quickActionService.ListActions(ctx, 1, 1, append(orderStaff, productViewer...))
+			So(err, ShouldBeNil)
+			So(len(actions), ShouldEqual, 1)
+			So(actions[0].Type, ShouldEqual, types.QuickActionViewLowStock)
+
+			_, err = quickActionService.ExecuteAction(ctx, 1, 1, 5, orderStaff, &types.ExecuteQuickActionRequest{
+				Action: types.QuickActionProcessPendingOrders,
+			})
+			So(err, ShouldNotBeNil)
+			So(orderRepo.updated, ShouldBeNil)
+		})
+	})
+}
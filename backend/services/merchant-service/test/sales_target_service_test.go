@@ -0,0 +1,103 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/services/merchant-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeMerchantTargetRepository 内存销售目标仓储
+type fakeMerchantTargetRepository struct {
+	targets     map[string]*types.MerchantTarget
+	actuals     *types.MerchantTargetActuals
+	actualStart time.Time
+	actualEnd   time.Time
+}
+
+func (f *fakeMerchantTargetRepository) GetByPeriod(ctx context.Context, tenantID, merchantID uint64, period string) (*types.MerchantTarget, error) {
+	target, exists := f.targets[period]
+	if !exists || target.TenantID != tenantID || target.MerchantID != merchantID {
+		return nil, nil
+	}
+	return target, nil
+}
+
+func (f *fakeMerchantTargetRepository) Save(ctx context.Context, target *types.MerchantTarget) error {
+	f.targets[target.Period] = target
+	return nil
+}
+
+func (f *fakeMerchantTargetRepository) GetPeriodActuals(ctx context.Context, tenantID, merchantID uint64, start, end time.Time) (*types.MerchantTargetActuals, error) {
+	f.actualStart, f.actualEnd = start, end
+	return f.actuals, nil
+}
+
+func TestSalesTargetService(t *testing.T) {
+	Convey("商户销售目标测试", t, func() {
+		ctx := context.Background()
+		repo := &fakeMerchantTargetRepository{
+			targets: map[string]*types.MerchantTarget{},
+			actuals: &types.MerchantTargetActuals{},
+		}
+		targetService := service.NewSalesTargetServiceForTest(repo)
+
+		Convey("设置并获取周期目标，重复设置时覆盖", func() {
+			_, err := targetService.SetTarget(ctx, 1, 10, 7, &types.SetMerchantTargetRequest{Period: "2025-09", TargetAmount: 30000, TargetOrders: 300})
+			So(err, ShouldBeNil)
+			_, err = targetService.SetTarget(ctx, 1, 10, 7, &types.SetMerchantTargetRequest{Period: "2025-09", TargetAmount: 50000})
+			So(err, ShouldBeNil)
+
+			target, err := targetService.GetTarget(ctx, 1, 10, "2025-09")
+			So(err, ShouldBeNil)
+			So(target.TargetAmount, ShouldEqual, 50000)
+			So(target.TargetOrders, ShouldEqual, 0)
+			So(target.CreatedBy, ShouldEqual, 7)
+		})
+
+		Convey("无效的目标不能设置", func() {
+			_, err := targetService.SetTarget(ctx, 1, 10, 7, &types.SetMerchantTargetRequest{Period: "2025/09", TargetAmount: 100})
+			So(errors.Is(err, types.ErrInvalidTargetPeriod), ShouldBeTrue)
+			So(repo.targets, ShouldBeEmpty)
+		})
+
+		Convey("未设置目标时返回未找到", func() {
+			_, err := targetService.GetProgress(ctx, 1, 10, "")
+			So(errors.Is(err, service.ErrSalesTargetNotFound), ShouldBeTrue)
+
+			// 其他商户的目标不可见
+			repo.targets["2025-09"] = &types.MerchantTarget{TenantID: 1, MerchantID: 11, Period: "2025-09", TargetAmount: 100}
+			_, err = targetService.GetTarget(ctx, 1, 10, "2025-09")
+			So(errors.Is(err, service.ErrSalesTargetNotFound), ShouldBeTrue)
+		})
+
+		Convey("已结束周期按整月实际数据计算进度", func() {
+			repo.targets["2025-09"] = &types.MerchantTarget{TenantID: 1, MerchantID: 10, Period: "2025-09", TargetAmount: 30000, TargetOrders: 300}
+			repo.actuals = &types.MerchantTargetActuals{Amount: 24000, Orders: 320}
+
+			progress, err := targetService.GetProgress(ctx, 1, 10, "2025-09")
+			So(err, ShouldBeNil)
+			So(repo.actualStart, ShouldEqual, time.Date(2025, 9, 1, 0, 0, 0, 0, time.Local))
+			So(repo.actualEnd, ShouldEqual, time.Date(2025, 10, 1, 0, 0, 0, 0, time.Local))
+			So(progress.Elapsed, ShouldEqual, 1)
+			So(progress.AmountAttainment, ShouldAlmostEqual, 0.8)
+			So(progress.ProjectedOrders, ShouldEqual, 320)
+			So(progress.BehindPace, ShouldBeTrue)
+			So(progress.PaceAttainment(), ShouldAlmostEqual, 0.8)
+		})
+
+		Convey("未指定周期时使用当前周期", func() {
+			period := types.TargetPeriodOf(time.Now())
+			repo.targets[period] = &types.MerchantTarget{TenantID: 1, MerchantID: 10, Period: period, TargetOrders: 10}
+
+			progress, err := targetService.GetProgress(ctx, 1, 10, "")
+			So(err, ShouldBeNil)
+			So(progress.Period, ShouldEqual, period)
+			So(progress.Elapsed, ShouldBeBetweenOrEqual, 0, 1)
+		})
+	})
+}
@@ -2,11 +2,11 @@ module mer-demo/services/monitoring-service
 
 go 1.25.0
 
-replace mer-demo/shared => ../../shared
+replace github.com/gofromzero/mer-sys/backend/shared => ../../shared
 
 require (
 	github.com/gogf/gf/v2 v2.9.0
-	mer-demo/shared v0.0.0-00010101000000-000000000000
+	github.com/gofromzero/mer-sys/backend/shared v0.0.0-00010101000000-000000000000
 )
 
 require (
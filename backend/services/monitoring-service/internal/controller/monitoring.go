@@ -8,8 +8,8 @@ import (
 	"github.com/gogf/gf/v2/util/gconv"
 
 	"mer-demo/services/monitoring-service/internal/service"
-	"mer-demo/shared/middleware"
-	"mer-demo/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
 
 // MonitoringController 监控控制器
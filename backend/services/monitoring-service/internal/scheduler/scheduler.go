@@ -6,14 +6,16 @@ import (
 
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gtime"
-	"github.com/gogf/gf/v2/os/gtimer"
 
 	"mer-demo/services/monitoring-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/schedule"
 )
 
-// Scheduler 定时任务调度器
+// Scheduler 定时任务调度器，多副本部署时只有领导者副本执行定时任务
 type Scheduler struct {
 	monitoringService service.MonitoringService
+	escalationService *service.AlertEscalationService
+	jobs              *schedule.Scheduler
 	ctx               context.Context
 }
 
@@ -21,6 +23,8 @@ type Scheduler struct {
 func NewScheduler(ctx context.Context) *Scheduler {
 	return &Scheduler{
 		monitoringService: service.NewMonitoringService(),
+		escalationService: service.NewAlertEscalationService(ctx),
+		jobs:              schedule.New("monitoring-service"),
 		ctx:               ctx,
 	}
 }
@@ -29,73 +33,52 @@ func NewScheduler(ctx context.Context) *Scheduler {
 func (s *Scheduler) Start() {
 	g.Log().Info(s.ctx, "Starting monitoring scheduler")
 
-	// 每5分钟执行一次预警检查
-	gtimer.Add(s.ctx, time.Minute*5, s.runPeriodicChecks)
-
-	// 每小时的第1分钟执行使用数据收集
-	gtimer.Add(s.ctx, time.Hour, s.runUsageDataCollection)
-
-	// 每天凌晨2点执行数据清理
-	s.scheduleDataCleanup()
+	jobs := []schedule.Job{
+		// 每5分钟执行一次预警检查
+		{Name: "periodic_checks", Schedule: schedule.Every(time.Minute * 5), Run: s.runPeriodicChecks},
+		// 每分钟检查未解决预警是否需要升级
+		{Name: "alert_escalation", Schedule: schedule.Every(time.Minute), Run: s.runAlertEscalation},
+		// 每小时的第1分钟执行使用数据收集
+		{Name: "usage_data_collection", Schedule: schedule.HourlyAt(1), Run: s.runUsageDataCollection},
+		// 每天凌晨2点执行数据清理
+		{Name: "data_cleanup", Schedule: schedule.DailyAt(2, 0), Run: s.runDataCleanup},
+	}
+	for _, job := range jobs {
+		if err := s.jobs.Register(job); err != nil {
+			g.Log().Error(s.ctx, "Failed to register monitoring job", g.Map{"job": job.Name, "error": err})
+		}
+	}
+	s.jobs.Start(s.ctx)
 
 	g.Log().Info(s.ctx, "Monitoring scheduler started")
 }
 
-// Stop 停止定时任务
+// Stop 停止定时任务，领导者副本释放租约以便其他副本接管
 func (s *Scheduler) Stop() {
 	g.Log().Info(s.ctx, "Stopping monitoring scheduler")
-	// GoFrame的定时器会在context取消时自动停止
+	s.jobs.Stop(s.ctx)
 }
 
 // runPeriodicChecks 执行周期性检查
-func (s *Scheduler) runPeriodicChecks(ctx context.Context) {
+func (s *Scheduler) runPeriodicChecks(ctx context.Context) error {
 	g.Log().Debug(ctx, "Running periodic monitoring checks")
-	
-	if err := s.monitoringService.RunPeriodicChecks(ctx); err != nil {
-		g.Log().Error(ctx, "Periodic checks failed", err)
-	}
+	return s.monitoringService.RunPeriodicChecks(ctx)
 }
 
-// runUsageDataCollection 执行使用数据收集
-func (s *Scheduler) runUsageDataCollection(ctx context.Context) {
-	// 只在每小时的第1分钟执行
-	if gtime.Now().Minute() != 1 {
-		return
-	}
-
-	g.Log().Debug(ctx, "Running usage data collection")
-	
-	if err := s.monitoringService.CollectUsageData(ctx); err != nil {
-		g.Log().Error(ctx, "Usage data collection failed", err)
-	}
+// runAlertEscalation 执行预警升级
+func (s *Scheduler) runAlertEscalation(ctx context.Context) error {
+	_, err := s.escalationService.EscalateAlerts(ctx)
+	return err
 }
 
-// scheduleDataCleanup 安排数据清理任务
-func (s *Scheduler) scheduleDataCleanup() {
-	// 计算到下一个凌晨2点的时间
-	now := gtime.Now()
-	next2AM := now.StartOfDay().Add(time.Hour * 2)
-	if now.After(next2AM.Time) {
-		next2AM = next2AM.AddDate(0, 0, 1)
-	}
-	
-	duration := next2AM.Sub(now.Time)
-	
-	// 首次执行
-	gtimer.SetTimeout(s.ctx, duration, func(ctx context.Context) {
-		s.runDataCleanup(ctx)
-		
-		// 之后每24小时执行一次
-		gtimer.Add(ctx, time.Hour*24, s.runDataCleanup)
-	})
-
-	g.Log().Info(s.ctx, "Data cleanup scheduled", g.Map{
-		"next_run": next2AM.Format("2006-01-02 15:04:05"),
-	})
+// runUsageDataCollection 执行使用数据收集
+func (s *Scheduler) runUsageDataCollection(ctx context.Context) error {
+	g.Log().Debug(ctx, "Running usage data collection")
+	return s.monitoringService.CollectUsageData(ctx)
 }
 
 // runDataCleanup 执行数据清理
-func (s *Scheduler) runDataCleanup(ctx context.Context) {
+func (s *Scheduler) runDataCleanup(ctx context.Context) error {
 	g.Log().Info(ctx, "Running data cleanup")
 	
 	// 清理30天前的已解决预警
@@ -112,4 +95,5 @@ func (s *Scheduler) runDataCleanup(ctx context.Context) {
 	g.Log().Info(ctx, "Would cleanup usage stats before", g.Map{
 		"cutoff_date": statsCutoffDate.Format("2006-01-02"),
 	})
-}
\ No newline at end of file
+	return nil
+}
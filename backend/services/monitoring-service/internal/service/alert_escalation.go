@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/guid"
+
+	"github.com/gofromzero/mer-sys/backend/shared/notification"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// defaultAlertEscalationBatchSize 每批检查的活跃预警数
+const defaultAlertEscalationBatchSize = 100
+
+// AlertEscalationPolicy 预警升级策略：预警持续 After 未解决时升级一次严重程度并通知升级接收人，
+// 之后每隔 After 再升级一次，最多升级 MaxLevel 次
+type AlertEscalationPolicy struct {
+	AlertType types.AlertType
+	After     time.Duration
+	MaxLevel  int
+	Emails    []string // 升级邮件接收人
+	Phones    []string // 升级短信接收人
+	UserIDs   []uint64 // 升级站内通知接收人
+}
+
+// nextEscalationAt 下一次升级时间，从最近一次升级（未升级时从触发）开始计时；已达到升级上限时返回 false
+func (p *AlertEscalationPolicy) nextEscalationAt(alert *types.RightsAlert) (time.Time, bool) {
+	if alert.EscalationLevel >= p.MaxLevel {
+		return time.Time{}, false
+	}
+	since := alert.TriggeredAt
+	if alert.EscalatedAt != nil {
+		since = *alert.EscalatedAt
+	}
+	return since.Add(p.After), true
+}
+
+// AlertEscalationConfig 预警升级配置
+type AlertEscalationConfig struct {
+	BatchSize int
+	Policies  map[types.AlertType]*AlertEscalationPolicy
+}
+
+// alertEscalationPolicyConfig 配置文件中的升级策略
+type alertEscalationPolicyConfig struct {
+	AlertType string   `json:"alertType"`
+	After     string   `json:"after"`
+	MaxLevel  int      `json:"maxLevel"`
+	Emails    []string `json:"emails"`
+	Phones    []string `json:"phones"`
+	UserIDs   []uint64 `json:"userIDs"`
+}
+
+// LoadAlertEscalationConfig 读取 monitoring.escalation 配置，未配置策略的预警类型不升级。
+// 配置格式：policies 为列表，每项包含 alertType、after（如 30m）、maxLevel 及 emails/phones/userIDs 接收人
+func LoadAlertEscalationConfig(ctx context.Context) *AlertEscalationConfig {
+	config := &AlertEscalationConfig{
+		BatchSize: g.Cfg().MustGet(ctx, "monitoring.escalation.batchSize", defaultAlertEscalationBatchSize).Int(),
+		Policies:  make(map[types.AlertType]*AlertEscalationPolicy),
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultAlertEscalationBatchSize
+	}
+
+	var policies []alertEscalationPolicyConfig
+	if err := g.Cfg().MustGet(ctx, "monitoring.escalation.policies").Scan(&policies); err != nil {
+		g.Log().Error(ctx, "解析预警升级策略失败", g.Map{"error": err})
+		return config
+	}
+	for _, item := range policies {
+		policy, err := item.parse()
+		if err != nil {
+			g.Log().Error(ctx, "忽略无效的预警升级策略", g.Map{"alert_type": item.AlertType, "error": err})
+			continue
+		}
+		config.Policies[policy.AlertType] = policy
+	}
+	return config
+}
+
+// parse 校验并转换配置中的升级策略
+func (c *alertEscalationPolicyConfig) parse() (*AlertEscalationPolicy, error) {
+	alertType, ok := types.ParseAlertType(c.AlertType)
+	if !ok {
+		return nil, fmt.Errorf("无效的预警类型")
+	}
+	after, err := time.ParseDuration(c.After)
+	if err != nil || after <= 0 {
+		return nil, fmt.Errorf("升级时长必须为正数")
+	}
+	if c.MaxLevel <= 0 {
+		return nil, fmt.Errorf("最大升级次数必须大于0")
+	}
+	if len(c.Emails)+len(c.Phones)+len(c.UserIDs) == 0 {
+		return nil, fmt.Errorf("至少需要一个升级接收人")
+	}
+	return &AlertEscalationPolicy{
+		AlertType: alertType,
+		After:     after,
+		MaxLevel:  c.MaxLevel,
+		Emails:    c.Emails,
+		Phones:    c.Phones,
+		UserIDs:   c.UserIDs,
+	}, nil
+}
+
+// AlertEscalationService 预警升级服务：活跃预警超过策略时长未解决时逐级提升严重程度，
+// 通知升级接收人并推送 monitoring.alert_escalated 事件；预警解决后不再升级
+type AlertEscalationService struct {
+	monitoringRepo  repository.MonitoringRepository
+	webhookRepo     repository.IOrderWebhookRepository
+	outboxRepo      repository.IOutboxRepository
+	notificationSvc notification.NotificationService
+	config          *AlertEscalationConfig
+	now             func() time.Time
+}
+
+// NewAlertEscalationService 创建预警升级服务实例
+func NewAlertEscalationService(ctx context.Context) *AlertEscalationService {
+	return &AlertEscalationService{
+		monitoringRepo:  repository.NewMonitoringRepository(),
+		webhookRepo:     repository.NewOrderWebhookRepository(),
+		outboxRepo:      repository.NewOutboxRepository(),
+		notificationSvc: notification.NewNotificationService(),
+		config:          LoadAlertEscalationConfig(ctx),
+		now:             time.Now,
+	}
+}
+
+// NewAlertEscalationServiceForTest 创建测试用预警升级服务实例
+func NewAlertEscalationServiceForTest(monitoringRepo repository.MonitoringRepository, webhookRepo repository.IOrderWebhookRepository,
+	outboxRepo repository.IOutboxRepository, notificationSvc notification.NotificationService, config *AlertEscalationConfig, now func() time.Time) *AlertEscalationService {
+	return &AlertEscalationService{
+		monitoringRepo:  monitoringRepo,
+		webhookRepo:     webhookRepo,
+		outboxRepo:      outboxRepo,
+		notificationSvc: notificationSvc,
+		config:          config,
+		now:             now,
+	}
+}
+
+// EscalateAlerts 跨租户分批检查配置了升级策略的活跃预警，升级到期的预警，返回本次升级的预警数。
+// 每次检查每个预警最多升级一级，单个预警升级失败不影响其余预警
+func (s *AlertEscalationService) EscalateAlerts(ctx context.Context) (int, error) {
+	if len(s.config.Policies) == 0 {
+		return 0, nil
+	}
+	alertTypes := make([]types.AlertType, 0, len(s.config.Policies))
+	for alertType := range s.config.Policies {
+		alertTypes = append(alertTypes, alertType)
+	}
+
+	now := s.now()
+	escalated := 0
+	var afterID uint64
+	for {
+		alerts, err := s.monitoringRepo.ListActiveAlertsForEscalation(ctx, alertTypes, afterID, s.config.BatchSize)
+		if err != nil {
+			return escalated, err
+		}
+
+		for _, alert := range alerts {
+			afterID = alert.ID
+			ok, err := s.escalate(ctx, alert, now)
+			if err != nil {
+				g.Log().Error(ctx, "预警升级失败", g.Map{"alert_id": alert.ID, "error": err})
+				continue
+			}
+			if ok {
+				escalated++
+			}
+		}
+
+		if len(alerts) < s.config.BatchSize {
+			break
+		}
+	}
+
+	if escalated > 0 {
+		g.Log().Info(ctx, "预警升级完成", g.Map{"escalated": escalated})
+	}
+	return escalated, nil
+}
+
+// escalate 升级一个到期的预警；预警未到期、已达到升级上限或已被解决时不升级
+func (s *AlertEscalationService) escalate(ctx context.Context, alert *types.RightsAlert, now time.Time) (bool, error) {
+	policy := s.config.Policies[alert.AlertType]
+	if policy == nil || alert.Status != types.AlertStatusActive {
+		return false, nil
+	}
+	dueAt, ok := policy.nextEscalationAt(alert)
+	if !ok || now.Before(dueAt) {
+		return false, nil
+	}
+
+	previousLevel := alert.EscalationLevel
+	alert.EscalationLevel++
+	alert.Severity = alert.Severity.Escalate()
+	alert.EscalatedAt = &now
+	updated, err := s.monitoringRepo.EscalateAlert(ctx, alert, previousLevel)
+	if err != nil || !updated {
+		return false, err
+	}
+
+	ctx = context.WithValue(ctx, "tenant_id", alert.TenantID)
+	s.notifyRecipients(ctx, alert, policy)
+	if err := s.publishEscalation(ctx, alert, policy, now); err != nil {
+		// 推送失败不回滚升级，记录日志即可
+		g.Log().Error(ctx, "写入预警升级推送任务失败", g.Map{"alert_id": alert.ID, "error": err})
+	}
+	return true, nil
+}
+
+// notifyRecipients 通知升级接收人，单个接收人发送失败不影响其他接收人
+func (s *AlertEscalationService) notifyRecipients(ctx context.Context, alert *types.RightsAlert, policy *AlertEscalationPolicy) {
+	title := fmt.Sprintf("预警升级（第%d级）：%s", alert.EscalationLevel, alert.AlertType.String())
+	message := fmt.Sprintf("商户%d的预警已持续未解决，严重程度升级为%s：%s", alert.MerchantID, alert.Severity.String(), alert.Message)
+
+	for _, email := range policy.Emails {
+		if err := s.notificationSvc.SendEmail(ctx, email, title, message); err != nil {
+			g.Log().Error(ctx, "发送预警升级邮件失败", g.Map{"alert_id": alert.ID, "email": email, "error": err})
+		}
+	}
+	for _, phone := range policy.Phones {
+		if err := s.notificationSvc.SendSMS(ctx, phone, message); err != nil {
+			g.Log().Error(ctx, "发送预警升级短信失败", g.Map{"alert_id": alert.ID, "phone": phone, "error": err})
+		}
+	}
+	for _, userID := range policy.UserIDs {
+		if err := s.notificationSvc.SendSystemNotification(ctx, userID, title, message); err != nil {
+			g.Log().Error(ctx, "发送预警升级站内通知失败", g.Map{"alert_id": alert.ID, "user_id": userID, "error": err})
+		}
+	}
+}
+
+// publishEscalation 为预警所属租户订阅了 monitoring.alert_escalated 的启用 Webhook 写入推送任务，
+// 由订单服务的发件箱投递器推送
+func (s *AlertEscalationService) publishEscalation(ctx context.Context, alert *types.RightsAlert, policy *AlertEscalationPolicy, now time.Time) error {
+	webhooks, err := s.webhookRepo.ListEnabled(ctx)
+	if err != nil {
+		return err
+	}
+
+	eventID := guid.S()
+	body, err := json.Marshal(types.NewAlertEscalatedWebhookPayload(eventID, alert, policy.MaxLevel, now))
+	if err != nil {
+		return fmt.Errorf("序列化推送内容失败: %v", err)
+	}
+
+	var failures []string
+	for i := range webhooks {
+		webhook := &webhooks[i]
+		if !webhook.Subscribes(types.OrderWebhookEventAlertEscalated) {
+			continue
+		}
+
+		task, err := json.Marshal(&types.OrderWebhookDeliveryEvent{
+			WebhookID: webhook.ID,
+			EventID:   eventID,
+			Event:     types.OrderWebhookEventAlertEscalated,
+			Body:      string(body),
+		})
+		if err != nil {
+			return fmt.Errorf("序列化推送任务失败: %v", err)
+		}
+
+		err = s.outboxRepo.Enqueue(ctx, &types.OutboxEvent{
+			TenantID:      alert.TenantID,
+			AggregateType: "rights_alert",
+			AggregateID:   alert.ID,
+			EventType:     types.OutboxEventOrderWebhook,
+			Payload:       string(task),
+		})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("Webhook%d: %v", webhook.ID, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("写入预警升级推送任务失败: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
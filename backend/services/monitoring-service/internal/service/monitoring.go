@@ -9,9 +9,9 @@ import (
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gtime"
 
-	"mer-demo/shared/notification"
-	"mer-demo/shared/repository"
-	"mer-demo/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/notification"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
 
 // MonitoringService 监控服务接口
@@ -43,6 +43,7 @@ type monitoringService struct {
 	monitoringRepo    repository.MonitoringRepository
 	fundRepo          repository.FundRepository
 	merchantRepo      repository.MerchantRepository
+	targetRepo        repository.IMerchantTargetRepository
 	notificationSvc   notification.NotificationService
 }
 
@@ -52,6 +53,7 @@ func NewMonitoringService() MonitoringService {
 		monitoringRepo:  repository.NewMonitoringRepository(),
 		fundRepo:        repository.NewFundRepository(),
 		merchantRepo:    repository.NewMerchantRepository(),
+		targetRepo:      repository.NewMerchantTargetRepository(),
 		notificationSvc: notification.NewNotificationService(),
 	}
 }
@@ -234,6 +236,9 @@ func (s *monitoringService) CheckAlertConditions(ctx context.Context, merchantID
 		return err
 	}
 
+	// 检查销售目标进度
+	s.checkSalesTargetPace(ctx, merchant)
+
 	if merchant.RightsBalance == nil {
 		return nil
 	}
@@ -335,6 +340,43 @@ func (s *monitoringService) CheckAlertConditions(ctx context.Context, merchantID
 	return nil
 }
 
+// checkSalesTargetPace 当前周期销售目标预计无法达成时触发预警
+func (s *monitoringService) checkSalesTargetPace(ctx context.Context, merchant *types.Merchant) {
+	now := time.Now()
+	target, err := s.targetRepo.GetByPeriod(ctx, merchant.TenantID, merchant.ID, types.TargetPeriodOf(now))
+	if err != nil || target == nil {
+		return
+	}
+
+	start, end, err := types.ParseTargetPeriod(target.Period)
+	if err != nil {
+		return
+	}
+	actuals, err := s.targetRepo.GetPeriodActuals(ctx, merchant.TenantID, merchant.ID, start, end)
+	if err != nil {
+		g.Log().Error(ctx, "Failed to get sales target actuals", err)
+		return
+	}
+
+	progress, err := types.CalculateTargetProgress(target, actuals, now)
+	if err != nil || !progress.BehindPace {
+		return
+	}
+
+	alert := &types.RightsAlert{
+		TenantID:       merchant.TenantID,
+		MerchantID:     merchant.ID,
+		AlertType:      types.AlertTypeSalesTargetBehind,
+		ThresholdValue: types.TargetPaceThreshold,
+		CurrentValue:   progress.PaceAttainment(),
+		Severity:       types.AlertSeverityWarning,
+		Message:        fmt.Sprintf("商户 %s 销售目标进度落后：%s", merchant.Name, progress.PaceSummary()),
+	}
+	if err := s.TriggerAlert(ctx, alert); err != nil {
+		g.Log().Error(ctx, "Failed to trigger sales target alert", err)
+	}
+}
+
 // GetDashboardData 获取仪表板数据
 func (s *monitoringService) GetDashboardData(ctx context.Context, merchantID *uint64) (*types.MonitoringDashboardData, error) {
 	return s.monitoringRepo.GetDashboardData(ctx, merchantID)
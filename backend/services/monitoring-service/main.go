@@ -9,6 +9,8 @@ import (
 
 	"mer-demo/services/monitoring-service/internal/controller"
 	"mer-demo/services/monitoring-service/internal/scheduler"
+	"github.com/gofromzero/mer-sys/backend/shared/handlers"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
 )
 
 func main() {
@@ -19,8 +21,16 @@ func main() {
 			Usage: "monitoring-service",
 			Brief: "权益监控微服务",
 			Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+				// 数据驻留配置映射了未定义的数据库分组时拒绝启动，避免租户数据落入共享数据库
+				if err := repository.InitTenantDatasourceConfig(ctx); err != nil {
+					g.Log().Fatalf(ctx, "数据驻留配置校验失败: %v", err)
+				}
+
 				s := g.Server()
 
+				// 应用连接池配置并暴露连接池指标
+				handlers.RegisterDBPoolMetrics(s, "monitoring-service")
+
 				// 设置服务端口，默认为8085
 				s.SetPort(g.Cfg().MustGet(ctx, "server.port", 8085).Int())
 
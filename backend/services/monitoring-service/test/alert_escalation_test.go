@@ -0,0 +1,234 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/gogf/gf/v2/util/gconv"
+
+	"mer-demo/services/monitoring-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/notification"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// escalationMonitoringRepository 内存预警仓储
+type escalationMonitoringRepository struct {
+	repository.MonitoringRepository
+	alerts []*types.RightsAlert
+}
+
+func (r *escalationMonitoringRepository) ListActiveAlertsForEscalation(ctx context.Context, alertTypes []types.AlertType, afterID uint64, limit int) ([]*types.RightsAlert, error) {
+	var result []*types.RightsAlert
+	for _, alert := range r.alerts {
+		if alert.ID <= afterID || alert.Status != types.AlertStatusActive {
+			continue
+		}
+		for _, alertType := range alertTypes {
+			if alert.AlertType == alertType {
+				copied := *alert
+				result = append(result, &copied)
+			}
+		}
+		if len(result) == limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (r *escalationMonitoringRepository) EscalateAlert(ctx context.Context, alert *types.RightsAlert, previousLevel int) (bool, error) {
+	for _, stored := range r.alerts {
+		if stored.ID == alert.ID && stored.Status == types.AlertStatusActive && stored.EscalationLevel == previousLevel {
+			stored.Severity = alert.Severity
+			stored.EscalationLevel = alert.EscalationLevel
+			stored.EscalatedAt = alert.EscalatedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *escalationMonitoringRepository) ResolveAlert(ctx context.Context, id uint64, resolution string) error {
+	for _, stored := range r.alerts {
+		if stored.ID == id {
+			stored.Status = types.AlertStatusResolved
+		}
+	}
+	return nil
+}
+
+// escalationWebhookRepository 按租户返回启用的 Webhook
+type escalationWebhookRepository struct {
+	repository.IOrderWebhookRepository
+	webhooks []types.OrderWebhook
+}
+
+func (r *escalationWebhookRepository) ListEnabled(ctx context.Context) ([]types.OrderWebhook, error) {
+	var result []types.OrderWebhook
+	for _, webhook := range r.webhooks {
+		if webhook.Enabled && webhook.TenantID == gconv.Uint64(ctx.Value("tenant_id")) {
+			result = append(result, webhook)
+		}
+	}
+	return result, nil
+}
+
+// escalationOutboxRepository 记录写入的发件箱事件
+type escalationOutboxRepository struct {
+	repository.IOutboxRepository
+	events []types.OutboxEvent
+}
+
+func (r *escalationOutboxRepository) Enqueue(ctx context.Context, event *types.OutboxEvent) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+// escalationNotificationService 记录发送的升级通知
+type escalationNotificationService struct {
+	notification.NotificationService
+	emails []string
+	sms    []string
+	users  []uint64
+}
+
+func (s *escalationNotificationService) SendEmail(ctx context.Context, to, subject, body string) error {
+	s.emails = append(s.emails, to)
+	return nil
+}
+
+func (s *escalationNotificationService) SendSMS(ctx context.Context, phone, message string) error {
+	s.sms = append(s.sms, phone)
+	return nil
+}
+
+func (s *escalationNotificationService) SendSystemNotification(ctx context.Context, userID uint64, title, message string) error {
+	s.users = append(s.users, userID)
+	return nil
+}
+
+// newEscalationFixture 余额严重预警每30分钟升级一次，最多升级2次；余额不足预警未配置策略
+func newEscalationFixture(triggeredAt time.Time, now *time.Time) (*service.AlertEscalationService, *escalationMonitoringRepository, *escalationOutboxRepository, *escalationNotificationService) {
+	monitoringRepo := &escalationMonitoringRepository{alerts: []*types.RightsAlert{
+		{ID: 1, TenantID: 1, MerchantID: 10, AlertType: types.AlertTypeBalanceCritical, Severity: types.AlertSeverityWarning,
+			Status: types.AlertStatusActive, Message: "权益余额严重不足", TriggeredAt: triggeredAt},
+		{ID: 2, TenantID: 1, MerchantID: 11, AlertType: types.AlertTypeBalanceLow, Severity: types.AlertSeverityInfo,
+			Status: types.AlertStatusActive, Message: "权益余额不足", TriggeredAt: triggeredAt},
+	}}
+	webhookRepo := &escalationWebhookRepository{webhooks: []types.OrderWebhook{
+		{ID: 1, TenantID: 1, Enabled: true, Events: types.StringArray{string(types.OrderWebhookEventAlertEscalated)}},
+		{ID: 2, TenantID: 1, Enabled: true, Events: types.StringArray{string(types.OrderWebhookEventPaid)}},
+	}}
+	outboxRepo := &escalationOutboxRepository{}
+	notificationSvc := &escalationNotificationService{}
+	config := &service.AlertEscalationConfig{
+		BatchSize: 1,
+		Policies: map[types.AlertType]*service.AlertEscalationPolicy{
+			types.AlertTypeBalanceCritical: {
+				AlertType: types.AlertTypeBalanceCritical,
+				After:     30 * time.Minute,
+				MaxLevel:  2,
+				Emails:    []string{"ops@example.com"},
+				Phones:    []string{"13800138000"},
+			},
+		},
+	}
+	escalationService := service.NewAlertEscalationServiceForTest(monitoringRepo, webhookRepo, outboxRepo, notificationSvc, config,
+		func() time.Time { return *now })
+	return escalationService, monitoringRepo, outboxRepo, notificationSvc
+}
+
+// TestAlertEscalation_EscalatesThroughLevels 测试未解决预警逐级升级直到上限
+func TestAlertEscalation_EscalatesThroughLevels(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		ctx := context.Background()
+		triggeredAt := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+		now := triggeredAt.Add(20 * time.Minute)
+		escalationService, monitoringRepo, outboxRepo, notificationSvc := newEscalationFixture(triggeredAt, &now)
+		alert := monitoringRepo.alerts[0]
+
+		// 未到升级时间
+		escalated, err := escalationService.EscalateAlerts(ctx)
+		t.AssertNil(err)
+		t.Assert(escalated, 0)
+		t.Assert(alert.EscalationLevel, 0)
+
+		// 第一次升级：严重程度提升，通知升级接收人并推送事件
+		now = triggeredAt.Add(30 * time.Minute)
+		escalated, err = escalationService.EscalateAlerts(ctx)
+		t.AssertNil(err)
+		t.Assert(escalated, 1)
+		t.Assert(alert.EscalationLevel, 1)
+		t.Assert(alert.Severity, types.AlertSeverityCritical)
+		t.Assert(*alert.EscalatedAt, now)
+		t.Assert(notificationSvc.emails, []string{"ops@example.com"})
+		t.Assert(notificationSvc.sms, []string{"13800138000"})
+		t.Assert(len(outboxRepo.events), 1)
+
+		event := outboxRepo.events[0]
+		t.Assert(event.EventType, types.OutboxEventOrderWebhook)
+		t.Assert(event.AggregateID, alert.ID)
+		var task types.OrderWebhookDeliveryEvent
+		t.AssertNil(json.Unmarshal([]byte(event.Payload), &task))
+		t.Assert(task.WebhookID, 1)
+		t.Assert(task.Event, types.OrderWebhookEventAlertEscalated)
+		var payload types.AlertEscalatedWebhookPayload
+		t.AssertNil(json.Unmarshal([]byte(task.Body), &payload))
+		t.Assert(payload.AlertID, alert.ID)
+		t.Assert(payload.EscalationLevel, 1)
+		t.Assert(payload.MaxLevel, 2)
+		t.Assert(payload.Severity, "critical")
+
+		// 下一次升级从上一次升级开始计时
+		now = triggeredAt.Add(50 * time.Minute)
+		escalated, err = escalationService.EscalateAlerts(ctx)
+		t.AssertNil(err)
+		t.Assert(escalated, 0)
+
+		now = triggeredAt.Add(60 * time.Minute)
+		escalated, err = escalationService.EscalateAlerts(ctx)
+		t.AssertNil(err)
+		t.Assert(escalated, 1)
+		t.Assert(alert.EscalationLevel, 2)
+		t.Assert(alert.Severity, types.AlertSeverityCritical)
+		t.Assert(len(notificationSvc.emails), 2)
+		t.Assert(len(outboxRepo.events), 2)
+
+		// 达到升级上限后不再升级，未配置策略的预警类型从不升级
+		now = triggeredAt.Add(5 * time.Hour)
+		escalated, err = escalationService.EscalateAlerts(ctx)
+		t.AssertNil(err)
+		t.Assert(escalated, 0)
+		t.Assert(alert.EscalationLevel, 2)
+		t.Assert(monitoringRepo.alerts[1].EscalationLevel, 0)
+		t.Assert(monitoringRepo.alerts[1].Severity, types.AlertSeverityInfo)
+		t.Assert(len(outboxRepo.events), 2)
+	})
+}
+
+// TestAlertEscalation_ResolvedBeforeEscalation 测试升级前已解决的预警不再升级
+func TestAlertEscalation_ResolvedBeforeEscalation(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		ctx := context.Background()
+		triggeredAt := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+		now := triggeredAt.Add(10 * time.Minute)
+		escalationService, monitoringRepo, outboxRepo, notificationSvc := newEscalationFixture(triggeredAt, &now)
+		alert := monitoringRepo.alerts[0]
+
+		t.AssertNil(monitoringRepo.ResolveAlert(ctx, alert.ID, "已充值"))
+
+		now = triggeredAt.Add(2 * time.Hour)
+		escalated, err := escalationService.EscalateAlerts(ctx)
+		t.AssertNil(err)
+		t.Assert(escalated, 0)
+		t.Assert(alert.EscalationLevel, 0)
+		t.Assert(alert.Severity, types.AlertSeverityWarning)
+		t.AssertNil(alert.EscalatedAt)
+		t.Assert(len(notificationSvc.emails), 0)
+		t.Assert(len(outboxRepo.events), 0)
+	})
+}
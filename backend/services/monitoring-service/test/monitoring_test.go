@@ -8,7 +8,7 @@ import (
 	"github.com/gogf/gf/v2/test/gtest"
 
 	"mer-demo/services/monitoring-service/internal/service"
-	"mer-demo/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
 
 // TestMonitoringService_GetRightsStats 测试权益统计获取
@@ -4,7 +4,7 @@ import (
 	"testing"
 
 	"github.com/gogf/gf/v2/test/gtest"
-	"mer-demo/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
 
 // TestAlertTypeString 测试预警类型字符串转换
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// AttachmentController 证明材料附件控制器
+type AttachmentController struct {
+	attachmentService service.IAttachmentService
+}
+
+// NewAttachmentController 创建附件控制器实例
+func NewAttachmentController() *AttachmentController {
+	return &AttachmentController{
+		attachmentService: service.NewAttachmentService(),
+	}
+}
+
+// Upload 上传附件
+// @Summary 上传证明材料附件
+// @Description 上传退款审批、敏感操作的证明材料，上传后需关联到具体记录
+// @Tags 附件管理
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "附件文件（PDF/JPEG/PNG）"
+// @Success 200 {object} utils.Response{data=types.Attachment} "成功"
+// @Failure 400 {object} utils.Response "文件类型、大小不符合要求或未通过安全扫描"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/attachments [post]
+func (c *AttachmentController) Upload(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	uploadFile := r.GetUploadFile("file")
+	if uploadFile == nil {
+		utils.ErrorResponse(r, 400, "未找到上传文件")
+		return
+	}
+
+	file, err := uploadFile.Open()
+	if err != nil {
+		utils.ErrorResponse(r, 400, "无法打开上传文件")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "读取上传文件失败")
+		return
+	}
+
+	attachment, err := c.attachmentService.Upload(ctx, uploadFile.Filename, data)
+	if err != nil {
+		writeAttachmentError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, attachment)
+}
+
+// Link 关联附件到记录
+// @Summary 关联附件到退款或审计事件
+// @Description 将本人上传的附件关联到退款订单或审计事件，审计日志只记录附件引用
+// @Tags 附件管理
+// @Accept json
+// @Produce json
+// @Param id path int true "附件ID"
+// @Param body body types.LinkAttachmentRequest true "关联附件请求"
+// @Success 200 {object} utils.Response{data=types.Attachment} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 403 {object} utils.Response "权限不足"
+// @Failure 404 {object} utils.Response "附件不存在"
+// @Failure 409 {object} utils.Response "附件已关联"
+// @Router /api/v1/attachments/{id}/link [post]
+func (c *AttachmentController) Link(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "附件ID格式错误")
+		return
+	}
+
+	var req types.LinkAttachmentRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+
+	attachment, err := c.attachmentService.Link(ctx, id, &req)
+	if err != nil {
+		writeAttachmentError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, attachment)
+}
+
+// List 获取记录关联的附件
+// @Summary 获取记录关联的附件列表
+// @Tags 附件管理
+// @Produce json
+// @Param target_type query string true "关联类型：refund/audit_event"
+// @Param target_id query string true "关联记录ID"
+// @Success 200 {object} utils.Response{data=[]types.Attachment} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 403 {object} utils.Response "权限不足"
+// @Router /api/v1/attachments [get]
+func (c *AttachmentController) List(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	targetType := types.AttachmentTargetType(r.Get("target_type").String())
+	targetID := r.Get("target_id").String()
+	if targetID == "" {
+		utils.ErrorResponse(r, 400, "关联记录ID不能为空")
+		return
+	}
+
+	attachments, err := c.attachmentService.ListByTarget(ctx, targetType, targetID)
+	if err != nil {
+		writeAttachmentError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, attachments)
+}
+
+// Download 下载附件
+// @Summary 下载附件
+// @Description 校验访问权限后重定向到对象存储的临时下载链接
+// @Tags 附件管理
+// @Param id path int true "附件ID"
+// @Success 302 "重定向到下载链接"
+// @Failure 403 {object} utils.Response "权限不足"
+// @Failure 404 {object} utils.Response "附件不存在"
+// @Router /api/v1/attachments/{id}/download [get]
+func (c *AttachmentController) Download(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "附件ID格式错误")
+		return
+	}
+
+	url, err := c.attachmentService.GetDownloadURL(ctx, id)
+	if err != nil {
+		writeAttachmentError(r, err)
+		return
+	}
+
+	r.Response.RedirectTo(url)
+}
+
+// writeAttachmentError 按错误类型返回附件接口错误
+func writeAttachmentError(r *ghttp.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrAttachmentNotFound):
+		utils.ErrorResponse(r, 404, err.Error())
+	case errors.Is(err, service.ErrAttachmentForbidden):
+		utils.ErrorResponse(r, 403, err.Error())
+	case errors.Is(err, service.ErrAttachmentAlreadyLinked):
+		utils.ErrorResponse(r, 409, err.Error())
+	case errors.Is(err, service.ErrAttachmentTooLarge),
+		errors.Is(err, service.ErrAttachmentTypeNotAllowed),
+		errors.Is(err, service.ErrAttachmentRejected),
+		errors.Is(err, service.ErrAttachmentInvalidTarget):
+		utils.ErrorResponse(r, 400, err.Error())
+	default:
+		g.Log().Error(r.GetCtx(), "附件操作失败", "error", err)
+		utils.ErrorResponse(r, 500, err.Error())
+	}
+}
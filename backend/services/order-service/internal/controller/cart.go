@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"errors"
+
 	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
@@ -55,8 +57,14 @@ func (c *CartController) AddItem(r *ghttp.Request) {
 
 	err := c.cartService.AddItem(r.Context(), customerID, req.ProductID, req.Quantity)
 	if err != nil {
+		// 超出购买数量或订单金额限制属于请求错误
+		code := 500
+		var limitErr *service.OrderLimitError
+		if errors.As(err, &limitErr) {
+			code = 400
+		}
 		r.Response.WriteJsonExit(g.Map{
-			"code":    500,
+			"code":    code,
 			"message": "添加商品失败",
 			"error":   err.Error(),
 		})
@@ -69,6 +77,41 @@ func (c *CartController) AddItem(r *ghttp.Request) {
 	})
 }
 
+// AddBundle 添加套餐到购物车
+func (c *CartController) AddBundle(r *ghttp.Request) {
+	var req types.AddCartBundleRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	customerID := r.GetCtxVar("user_id").Uint64()
+
+	err := c.cartService.AddBundle(r.Context(), customerID, req.BundleID, req.Quantity)
+	if err != nil {
+		// 套餐不存在、已停售或组成商品库存不足属于请求错误
+		code := 500
+		if errors.Is(err, types.ErrProductBundleNotFound) || errors.Is(err, types.ErrProductBundleUnavailable) {
+			code = 400
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "添加套餐失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "添加成功",
+	})
+}
+
 // UpdateItem 更新购物车商品数量
 func (c *CartController) UpdateItem(r *ghttp.Request) {
 	var req types.UpdateCartItemRequest
@@ -90,10 +133,18 @@ func (c *CartController) UpdateItem(r *ghttp.Request) {
 		return
 	}
 
-	err := c.cartService.UpdateItemQuantity(r.Context(), itemID, req.Quantity)
+	err := c.cartService.UpdateItemQuantity(r.Context(), itemID, req.Quantity, req.Version)
 	if err != nil {
+		// 版本冲突表示已在其他标签页或设备修改，超出购买数量限制或库存属于请求错误
+		code := 500
+		var limitErr *service.OrderLimitError
+		if errors.Is(err, types.ErrCartItemVersionConflict) {
+			code = 409
+		} else if errors.As(err, &limitErr) {
+			code = 400
+		}
 		r.Response.WriteJsonExit(g.Map{
-			"code":    500,
+			"code":    code,
 			"message": "更新商品数量失败",
 			"error":   err.Error(),
 		})
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// CartRecoveryController 购物车找回提醒偏好控制器
+type CartRecoveryController struct {
+	recoveryService *service.CartRecoveryService
+}
+
+// NewCartRecoveryController 创建购物车找回提醒偏好控制器实例
+func NewCartRecoveryController(recoveryService *service.CartRecoveryService) *CartRecoveryController {
+	return &CartRecoveryController{
+		recoveryService: recoveryService,
+	}
+}
+
+// GetPreference 获取当前客户的购物车找回提醒偏好
+func (c *CartRecoveryController) GetPreference(r *ghttp.Request) {
+	customerID := r.GetCtxVar("user_id").Uint64()
+
+	preference, err := c.recoveryService.GetPreference(r.Context(), customerID)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "获取购物车提醒设置失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code": 0,
+		"data": preference,
+	})
+}
+
+// UpdatePreference 当前客户设置是否接收购物车找回提醒
+func (c *CartRecoveryController) UpdatePreference(r *ghttp.Request) {
+	var req types.UpdateCartRecoveryPreferenceRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	customerID := r.GetCtxVar("user_id").Uint64()
+
+	preference, err := c.recoveryService.UpdatePreference(r.Context(), customerID, &req)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "更新购物车提醒设置失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "更新成功",
+		"data":    preference,
+	})
+}
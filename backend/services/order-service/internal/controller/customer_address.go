@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// CustomerAddressController 客户收货地址控制器，客户只能管理自己的地址
+type CustomerAddressController struct {
+	addressService service.ICustomerAddressService
+}
+
+// NewCustomerAddressController 创建客户收货地址控制器实例
+func NewCustomerAddressController(addressService service.ICustomerAddressService) *CustomerAddressController {
+	return &CustomerAddressController{
+		addressService: addressService,
+	}
+}
+
+// CreateAddress 新增收货地址
+// @Summary 新增收货地址
+// @Description 客户的第一个地址自动设为默认地址，每个客户最多保存20个地址
+// @Tags 收货地址
+// @Accept json
+// @Produce json
+// @Param body body types.CustomerAddressRequest true "收货地址"
+// @Success 200 {object} utils.Response{data=types.CustomerAddress} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误或地址数已达上限"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/addresses [post]
+func (c *CustomerAddressController) CreateAddress(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var req types.CustomerAddressRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+
+	address, err := c.addressService.CreateAddress(ctx, r.GetCtxVar("user_id").Uint64(), &req)
+	if err != nil {
+		writeAddressError(r, "新增收货地址失败", err)
+		return
+	}
+
+	utils.SuccessResponse(r, address)
+}
+
+// ListAddresses 获取收货地址列表
+// @Summary 获取收货地址列表
+// @Description 返回当前客户的全部收货地址，默认地址在前
+// @Tags 收货地址
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]types.CustomerAddress} "成功"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/addresses [get]
+func (c *CustomerAddressController) ListAddresses(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	addresses, err := c.addressService.ListAddresses(ctx, r.GetCtxVar("user_id").Uint64())
+	if err != nil {
+		writeAddressError(r, "获取收货地址列表失败", err)
+		return
+	}
+
+	utils.SuccessResponse(r, addresses)
+}
+
+// GetAddress 获取收货地址详情
+// @Summary 获取收货地址详情
+// @Tags 收货地址
+// @Produce json
+// @Param address_id path int true "地址ID"
+// @Success 200 {object} utils.Response{data=types.CustomerAddress} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 404 {object} utils.Response "地址不存在"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/addresses/{address_id} [get]
+func (c *CustomerAddressController) GetAddress(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	addressID, ok := parseAddressID(r)
+	if !ok {
+		return
+	}
+
+	address, err := c.addressService.GetAddress(ctx, r.GetCtxVar("user_id").Uint64(), addressID)
+	if err != nil {
+		writeAddressError(r, "获取收货地址失败", err)
+		return
+	}
+
+	utils.SuccessResponse(r, address)
+}
+
+// UpdateAddress 修改收货地址
+// @Summary 修改收货地址
+// @Description 已下单订单保存的是地址快照，修改地址不影响已下单的订单
+// @Tags 收货地址
+// @Accept json
+// @Produce json
+// @Param address_id path int true "地址ID"
+// @Param body body types.CustomerAddressRequest true "收货地址"
+// @Success 200 {object} utils.Response{data=types.CustomerAddress} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 404 {object} utils.Response "地址不存在"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/addresses/{address_id} [put]
+func (c *CustomerAddressController) UpdateAddress(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	addressID, ok := parseAddressID(r)
+	if !ok {
+		return
+	}
+
+	var req types.CustomerAddressRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+
+	address, err := c.addressService.UpdateAddress(ctx, r.GetCtxVar("user_id").Uint64(), addressID, &req)
+	if err != nil {
+		writeAddressError(r, "修改收货地址失败", err)
+		return
+	}
+
+	utils.SuccessResponse(r, address)
+}
+
+// SetDefaultAddress 设为默认收货地址
+// @Summary 设为默认收货地址
+// @Tags 收货地址
+// @Produce json
+// @Param address_id path int true "地址ID"
+// @Success 200 {object} utils.Response{data=types.CustomerAddress} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 404 {object} utils.Response "地址不存在"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/addresses/{address_id}/default [put]
+func (c *CustomerAddressController) SetDefaultAddress(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	addressID, ok := parseAddressID(r)
+	if !ok {
+		return
+	}
+
+	address, err := c.addressService.SetDefaultAddress(ctx, r.GetCtxVar("user_id").Uint64(), addressID)
+	if err != nil {
+		writeAddressError(r, "设置默认收货地址失败", err)
+		return
+	}
+
+	utils.SuccessResponse(r, address)
+}
+
+// DeleteAddress 删除收货地址
+// @Summary 删除收货地址
+// @Description 删除默认地址后最近修改的其余地址成为默认地址
+// @Tags 收货地址
+// @Produce json
+// @Param address_id path int true "地址ID"
+// @Success 200 {object} utils.Response "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 404 {object} utils.Response "地址不存在"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/addresses/{address_id} [delete]
+func (c *CustomerAddressController) DeleteAddress(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	addressID, ok := parseAddressID(r)
+	if !ok {
+		return
+	}
+
+	if err := c.addressService.DeleteAddress(ctx, r.GetCtxVar("user_id").Uint64(), addressID); err != nil {
+		writeAddressError(r, "删除收货地址失败", err)
+		return
+	}
+
+	utils.SuccessResponse(r, nil)
+}
+
+// parseAddressID 解析路径中的地址ID，格式错误时写入错误响应
+func parseAddressID(r *ghttp.Request) (uint64, bool) {
+	addressID, err := strconv.ParseUint(r.Get("address_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "地址ID格式错误")
+		return 0, false
+	}
+	return addressID, true
+}
+
+// writeAddressError 按错误类型写入收货地址接口的错误响应
+func writeAddressError(r *ghttp.Request, message string, err error) {
+	switch {
+	case errors.Is(err, types.ErrCustomerAddressNotFound):
+		utils.ErrorResponse(r, 404, err.Error())
+	case errors.Is(err, types.ErrInvalidCustomerAddress), errors.Is(err, types.ErrCustomerAddressLimit):
+		utils.ErrorResponse(r, 400, err.Error())
+	default:
+		g.Log().Errorf(r.GetCtx(), "%s: %v", message, err)
+		utils.ErrorResponse(r, 500, err.Error())
+	}
+}
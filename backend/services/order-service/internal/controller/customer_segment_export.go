@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// CustomerSegmentExportController 客户分群营销导出控制器
+type CustomerSegmentExportController struct {
+	exportService *service.CustomerSegmentExportService
+}
+
+// NewCustomerSegmentExportController 创建客户分群营销导出控制器实例
+func NewCustomerSegmentExportController(exportService *service.CustomerSegmentExportService) *CustomerSegmentExportController {
+	return &CustomerSegmentExportController{
+		exportService: exportService,
+	}
+}
+
+// Export 导出客户分群及联系方式
+// @Summary 导出客户分群
+// @Description 按统计周期内已支付或已完成的订单数导出选定分群（new 1单/ordinary 2-5单/loyal 6-10单/vip 11单以上）的客户及联系方式，每行附带分群条件；退订营销信息和已匿名化的客户不导出。导出任务进入导出队列后台生成，通过 /exports/{id} 查询进度并下载
+// @Tags 客户分群
+// @Produce json
+// @Param segment query string true "客户分群：new/ordinary/loyal/vip"
+// @Param start_date query string true "开始日期 YYYY-MM-DD"
+// @Param end_date query string true "结束日期 YYYY-MM-DD（含）"
+// @Param format query string false "导出格式：csv/excel，默认csv"
+// @Success 202 {object} utils.Response{data=types.ExportJob} "已创建导出任务"
+// @Failure 400 {object} utils.Response
+// @Failure 429 {object} utils.Response "超出当天导出用量"
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/customer-segments/export [get]
+func (c *CustomerSegmentExportController) Export(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	query, err := parseCustomerSegmentExportQuery(r)
+	if err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+	if err := query.Validate(); err != nil {
+		utils.ErrorResponse(r, 400, "参数验证失败: "+err.Error())
+		return
+	}
+
+	job, err := c.exportService.CreateExport(ctx, query)
+	if err != nil {
+		writeExportError(r, err)
+		return
+	}
+	r.Response.WriteStatus(http.StatusAccepted)
+	utils.SuccessResponse(r, job)
+}
+
+// parseCustomerSegmentExportQuery 解析导出条件，结束日期当天的订单也会统计
+func parseCustomerSegmentExportQuery(r *ghttp.Request) (*types.CustomerSegmentExportQuery, error) {
+	startDate, err := time.ParseInLocation("2006-01-02", r.Get("start_date").String(), time.Local)
+	if err != nil {
+		return nil, errors.New("开始日期格式错误，应为 YYYY-MM-DD")
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", r.Get("end_date").String(), time.Local)
+	if err != nil {
+		return nil, errors.New("结束日期格式错误，应为 YYYY-MM-DD")
+	}
+
+	query := &types.CustomerSegmentExportQuery{
+		Segment:   types.CustomerSegment(r.Get("segment").String()),
+		StartDate: startDate,
+		EndDate:   endDate.AddDate(0, 0, 1),
+		Format:    types.OrderExportFormat(r.Get("format", string(types.OrderExportFormatCSV)).String()),
+	}
+	return query, nil
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/export"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// ExportController 导出任务控制器，所有导出统一通过该控制器查询进度和下载文件
+type ExportController struct {
+	queue *export.Queue
+}
+
+// NewExportController 创建导出任务控制器实例
+func NewExportController(queue *export.Queue) *ExportController {
+	return &ExportController{
+		queue: queue,
+	}
+}
+
+// Get 获取导出任务状态
+// @Summary 获取导出任务
+// @Description 查询导出任务状态（queued/running/completed/failed），只有导出发起人可以查看
+// @Tags 导出
+// @Produce json
+// @Param id path string true "导出任务ID"
+// @Success 200 {object} utils.Response{data=types.ExportJob}
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/exports/{id} [get]
+func (c *ExportController) Get(r *ghttp.Request) {
+	job, err := c.queue.Get(r.GetCtx(), r.Get("id").String())
+	if err != nil {
+		writeExportError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, job)
+}
+
+// Download 下载导出文件
+// @Summary 下载导出文件
+// @Tags 导出
+// @Produce octet-stream
+// @Param id path string true "导出任务ID"
+// @Success 200 {file} file "导出文件"
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response "文件尚未生成完成"
+// @Failure 410 {object} utils.Response "文件已过期"
+// @Router /api/v1/exports/{id}/download [get]
+func (c *ExportController) Download(r *ghttp.Request) {
+	job, err := c.queue.GetFile(r.GetCtx(), r.Get("id").String())
+	if err != nil {
+		writeExportError(r, err)
+		return
+	}
+
+	r.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, job.FileName))
+	r.Response.ServeFile(job.FilePath)
+}
+
+// writeExportError 按错误类型返回导出错误
+func writeExportError(r *ghttp.Request, err error) {
+	switch {
+	case errors.Is(err, export.ErrJobNotFound):
+		utils.ErrorResponse(r, 404, err.Error())
+	case errors.Is(err, export.ErrJobNotReady):
+		utils.ErrorResponse(r, 409, err.Error())
+	case errors.Is(err, export.ErrJobExpired):
+		utils.ErrorResponse(r, 410, err.Error())
+	case errors.Is(err, export.ErrDailyJobLimitExceeded), errors.Is(err, export.ErrDailySizeLimitExceeded):
+		utils.ErrorResponse(r, 429, err.Error())
+	default:
+		g.Log().Error(r.GetCtx(), "导出任务操作失败", "error", err)
+		utils.ErrorResponse(r, 500, err.Error())
+	}
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// MerchantNotificationPreferenceController 商户通知偏好控制器
+type MerchantNotificationPreferenceController struct {
+	digester *service.MerchantNotificationDigester
+}
+
+// NewMerchantNotificationPreferenceController 创建商户通知偏好控制器实例
+func NewMerchantNotificationPreferenceController(digester *service.MerchantNotificationDigester) *MerchantNotificationPreferenceController {
+	return &MerchantNotificationPreferenceController{
+		digester: digester,
+	}
+}
+
+// GetPreference 获取商户通知偏好
+// @Summary 获取商户通知偏好
+// @Description 未设置时为逐条发送
+// @Tags 商户通知偏好
+// @Produce json
+// @Param merchant_id path uint64 true "商户ID"
+// @Success 200 {object} utils.Response{data=types.MerchantNotificationPreference}
+// @Failure 400 {object} utils.Response
+// @Router /api/v1/orders/merchant-notification-preferences/{merchant_id} [get]
+func (c *MerchantNotificationPreferenceController) GetPreference(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	merchantID, err := strconv.ParseUint(r.Get("merchant_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "无效的商户ID")
+		return
+	}
+
+	preference, err := c.digester.GetPreference(ctx, merchantID)
+	if err != nil {
+		g.Log().Error(ctx, "获取商户通知偏好失败", "error", err)
+		utils.ErrorResponse(r, 500, "获取商户通知偏好失败")
+		return
+	}
+
+	utils.SuccessResponse(r, preference)
+}
+
+// UpdatePreference 更新商户通知偏好
+// @Summary 更新商户通知偏好
+// @Description 开启每小时或每日汇总后，非紧急订单通知合并为汇总邮件发送，支付失败、订单取消等紧急通知仍立即发送
+// @Tags 商户通知偏好
+// @Accept json
+// @Produce json
+// @Param merchant_id path uint64 true "商户ID"
+// @Param body body types.UpdateMerchantNotificationPreferenceRequest true "通知偏好"
+// @Success 200 {object} utils.Response{data=types.MerchantNotificationPreference}
+// @Failure 400 {object} utils.Response
+// @Router /api/v1/orders/merchant-notification-preferences/{merchant_id} [put]
+func (c *MerchantNotificationPreferenceController) UpdatePreference(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	merchantID, err := strconv.ParseUint(r.Get("merchant_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "无效的商户ID")
+		return
+	}
+
+	var req types.UpdateMerchantNotificationPreferenceRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+
+	preference, err := c.digester.UpdatePreference(ctx, merchantID, &req)
+	if err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, preference)
+}
@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// BroadcastController 群发通知控制器
+type BroadcastController struct {
+	broadcastService *service.BroadcastService
+}
+
+// NewBroadcastController 创建群发通知控制器实例
+func NewBroadcastController(broadcastService *service.BroadcastService) *BroadcastController {
+	return &BroadcastController{
+		broadcastService: broadcastService,
+	}
+}
+
+// Preview 预览群发通知接收人数
+// @Summary 预览群发通知接收人数
+// @Description 按接收对象和筛选条件统计接收人数，已退订群发通知的用户不会收到通知
+// @Tags 群发通知
+// @Accept json
+// @Produce json
+// @Param body body types.BroadcastAudienceFilter true "接收人筛选条件"
+// @Success 200 {object} utils.Response{data=types.BroadcastPreview} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/notification-broadcasts/preview [post]
+func (c *BroadcastController) Preview(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var filter types.BroadcastAudienceFilter
+	if err := r.Parse(&filter); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+	if err := filter.Validate(); err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+
+	preview, err := c.broadcastService.Preview(ctx, &filter)
+	if err != nil {
+		g.Log().Errorf(ctx, "预览群发通知接收人失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, preview)
+}
+
+// Create 创建群发通知
+// @Summary 创建群发通知
+// @Description 创建后由后台任务按批发送，内容可使用 {{.Username}} 引用接收人用户名；租户 24 小时内的群发次数有上限
+// @Tags 群发通知
+// @Accept json
+// @Produce json
+// @Param body body types.CreateBroadcastRequest true "群发通知"
+// @Success 200 {object} utils.Response{data=types.NotificationBroadcast} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 429 {object} utils.Response "群发次数已达上限"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/notification-broadcasts [post]
+func (c *BroadcastController) Create(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var req types.CreateBroadcastRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+
+	broadcast, err := c.broadcastService.Create(ctx, r.GetCtxVar("user_id").Uint64(), &req)
+	if err != nil {
+		c.handleError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, broadcast)
+}
+
+// List 获取群发通知列表
+// @Summary 获取群发通知列表
+// @Tags 群发通知
+// @Produce json
+// @Param limit query int false "返回条数" default(50)
+// @Success 200 {object} utils.Response{data=[]types.NotificationBroadcast} "成功"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/notification-broadcasts [get]
+func (c *BroadcastController) List(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	broadcasts, err := c.broadcastService.List(ctx, &types.BroadcastListRequest{Limit: r.Get("limit").Int()})
+	if err != nil {
+		c.handleError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, broadcasts)
+}
+
+// Get 获取群发通知及发送进度
+// @Summary 获取群发通知及发送进度
+// @Tags 群发通知
+// @Produce json
+// @Param id path int true "群发通知ID"
+// @Success 200 {object} utils.Response{data=types.NotificationBroadcast} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 404 {object} utils.Response "群发通知不存在"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/notification-broadcasts/{id} [get]
+func (c *BroadcastController) Get(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "群发通知ID格式错误")
+		return
+	}
+
+	broadcast, err := c.broadcastService.Get(ctx, id)
+	if err != nil {
+		c.handleError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, broadcast)
+}
+
+// ListDeliveries 获取群发通知的发送记录
+// @Summary 获取群发通知的发送记录
+// @Tags 群发通知
+// @Produce json
+// @Param id path int true "群发通知ID"
+// @Param status query string false "发送结果（sent, failed, skipped）"
+// @Param limit query int false "返回条数" default(50)
+// @Success 200 {object} utils.Response{data=[]types.BroadcastDelivery} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 404 {object} utils.Response "群发通知不存在"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/notification-broadcasts/{id}/deliveries [get]
+func (c *BroadcastController) ListDeliveries(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "群发通知ID格式错误")
+		return
+	}
+
+	status := types.BroadcastDeliveryStatus(r.Get("status").String())
+	deliveries, err := c.broadcastService.ListDeliveries(ctx, id, status, &types.BroadcastListRequest{Limit: r.Get("limit").Int()})
+	if err != nil {
+		c.handleError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, deliveries)
+}
+
+// GetPreference 获取当前用户的群发通知偏好
+// @Summary 获取群发通知偏好
+// @Tags 群发通知
+// @Produce json
+// @Success 200 {object} utils.Response{data=types.BroadcastPreference} "成功"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/notification-preferences/broadcast [get]
+func (c *BroadcastController) GetPreference(r *ghttp.Request) {
+	preference, err := c.broadcastService.GetPreference(r.GetCtx(), r.GetCtxVar("user_id").Uint64())
+	if err != nil {
+		c.handleError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, preference)
+}
+
+// UpdatePreference 当前用户设置是否接收群发通知
+// @Summary 设置群发通知偏好
+// @Description 退订后不再收到群发通知，订单相关通知不受影响
+// @Tags 群发通知
+// @Accept json
+// @Produce json
+// @Param body body types.UpdateBroadcastPreferenceRequest true "群发通知偏好"
+// @Success 200 {object} utils.Response{data=types.BroadcastPreference} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/notification-preferences/broadcast [put]
+func (c *BroadcastController) UpdatePreference(r *ghttp.Request) {
+	var req types.UpdateBroadcastPreferenceRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+
+	preference, err := c.broadcastService.UpdatePreference(r.GetCtx(), r.GetCtxVar("user_id").Uint64(), &req)
+	if err != nil {
+		c.handleError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, preference)
+}
+
+// handleError 按错误类型返回对应的状态码
+func (c *BroadcastController) handleError(r *ghttp.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrBroadcastNotFound):
+		utils.ErrorResponse(r, 404, err.Error())
+	case errors.Is(err, types.ErrBroadcastRateLimited):
+		utils.ErrorResponse(r, 429, err.Error())
+	default:
+		g.Log().Error(r.GetCtx(), "群发通知操作失败", "error", err)
+		utils.ErrorResponse(r, 500, err.Error())
+	}
+}
@@ -1,10 +1,14 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/middleware"
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gofromzero/mer-sys/backend/shared/utils"
@@ -15,18 +19,25 @@ import (
 
 // OrderController 订单控制器
 type OrderController struct {
-	orderService service.IOrderService
-	orderRepo    repository.IOrderRepository
+	orderService     service.IOrderService
+	orderRepo        repository.IOrderRepository
+	orderNoteService service.IOrderNoteService
 }
 
-// NewOrderController 创建订单控制器实例
-func NewOrderController() *OrderController {
+// NewOrderController 创建订单控制器实例，stepUp 用于取消已支付订单时校验重新验证
+func NewOrderController(stepUp service.StepUpVerifier) *OrderController {
 	return &OrderController{
-		orderService: service.NewOrderService(),
-		orderRepo:    repository.NewOrderRepository(),
+		orderService:     service.NewOrderService(stepUp),
+		orderRepo:        repository.NewOrderRepository(),
+		orderNoteService: service.NewOrderNoteService(),
 	}
 }
 
+// NewOrderControllerForTest 创建测试用订单控制器实例
+func NewOrderControllerForTest(orderService service.IOrderService) *OrderController {
+	return &OrderController{orderService: orderService}
+}
+
 // CreateOrder 创建订单
 func (c *OrderController) CreateOrder(r *ghttp.Request) {
 	var req types.CreateOrderRequest
@@ -44,8 +55,17 @@ func (c *OrderController) CreateOrder(r *ghttp.Request) {
 
 	order, err := c.orderService.CreateOrder(r.Context(), customerID, &req)
 	if err != nil {
+		// 超出购买数量、订单金额或权益余额限制属于请求错误
+		code := 500
+		var limitErr *service.OrderLimitError
+		switch {
+		case errors.As(err, &limitErr), errors.Is(err, types.ErrCustomerAddressNotFound), errors.Is(err, types.ErrInvalidExternalReference):
+			code = 400
+		case errors.Is(err, types.ErrDuplicateExternalReference):
+			code = 409
+		}
 		r.Response.WriteJsonExit(g.Map{
-			"code":    500,
+			"code":    code,
 			"message": "创建订单失败",
 			"error":   err.Error(),
 		})
@@ -59,6 +79,50 @@ func (c *OrderController) CreateOrder(r *ghttp.Request) {
 	})
 }
 
+// BatchCreateOrders 批量创建订单，失败订单已占用的库存预留会被释放
+func (c *OrderController) BatchCreateOrders(r *ghttp.Request) {
+	var req types.BatchCreateOrderRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if len(req.Orders) == 0 || len(req.Orders) > types.MaxBatchCreateOrders {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   fmt.Sprintf("单次需创建1-%d个订单", types.MaxBatchCreateOrders),
+		})
+		return
+	}
+
+	customerID := r.GetCtxVar("user_id").Uint64()
+
+	result, err := c.orderService.BatchCreateOrders(r.Context(), customerID, &req)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "批量创建订单失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	message := "订单批量创建成功"
+	if result.HasFailures() {
+		message = "部分订单创建失败"
+	}
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": message,
+		"data":    result,
+	})
+}
+
 // GetOrder 获取订单详情
 func (c *OrderController) GetOrder(r *ghttp.Request) {
 	orderID := r.Get("order_id").Uint64()
@@ -79,6 +143,44 @@ func (c *OrderController) GetOrder(r *ghttp.Request) {
 		})
 		return
 	}
+	// status 保持内部状态，status_display 为租户配置的客户可见状态
+	c.orderService.LabelOrderStatuses(r.Context(), r.Get("locale").String(), order)
+
+	r.Response.WriteJsonExit(g.Map{
+		"code": 0,
+		"data": order,
+	})
+}
+
+// LookupOrder 按订单号或外部订单号查询订单
+func (c *OrderController) LookupOrder(r *ghttp.Request) {
+	req := &types.OrderLookupRequest{
+		OrderNumber:       r.Get("order_number").String(),
+		ExternalReference: r.Get("external_reference").String(),
+		Reference:         r.Get("reference").String(),
+	}
+	if err := req.Validate(); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	order, err := c.orderService.LookupOrder(r.Context(), req)
+	if err != nil {
+		code := 500
+		if errors.Is(err, types.ErrOrderReferenceNotFound) {
+			code = 404
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "查询订单失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.orderService.LabelOrderStatuses(r.Context(), r.Get("locale").String(), order)
 
 	r.Response.WriteJsonExit(g.Map{
 		"code": 0,
@@ -128,6 +230,7 @@ func (c *OrderController) ListOrders(r *ghttp.Request) {
 		})
 		return
 	}
+	c.orderService.LabelOrderStatuses(r.Context(), r.Get("locale").String(), orders...)
 
 	r.Response.WriteJsonExit(g.Map{
 		"code": 0,
@@ -151,12 +254,47 @@ func (c *OrderController) CancelOrder(r *ghttp.Request) {
 		return
 	}
 
-	err := c.orderService.CancelOrder(r.Context(), orderID)
+	var req types.CancelOrderRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 接口只允许客户和商户取消，系统和管理员取消走内部流程
+	if req.OperatorType != "" && req.OperatorType != types.OrderStatusOperatorTypeCustomer &&
+		req.OperatorType != types.OrderStatusOperatorTypeMerchant {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "无效的操作员类型",
+		})
+		return
+	}
+
+	result, err := c.orderService.CancelOrder(r.Context(), orderID, &req)
+	var stepUpErr *auth.StepUpRequiredError
+	if errors.As(err, &stepUpErr) {
+		middleware.WriteStepUpRequired(r, stepUpErr)
+		return
+	}
 	if err != nil {
+		code := 500
+		switch {
+		case errors.Is(err, service.ErrOrderCancelForbidden):
+			code = 403
+		case errors.Is(err, service.ErrOrderCancelNotAllowed):
+			code = 409
+		case errors.Is(err, service.ErrRefundNotSupported):
+			code = 501
+		}
 		r.Response.WriteJsonExit(g.Map{
-			"code":    500,
+			"code":    code,
 			"message": "取消订单失败",
 			"error":   err.Error(),
+			"data":    result,
 		})
 		return
 	}
@@ -164,6 +302,113 @@ func (c *OrderController) CancelOrder(r *ghttp.Request) {
 	r.Response.WriteJsonExit(g.Map{
 		"code":    0,
 		"message": "订单取消成功",
+		"data":    result,
+	})
+}
+
+// UpdateOrderItems 修改待支付订单的商品数量或移除商品
+func (c *OrderController) UpdateOrderItems(r *ghttp.Request) {
+	orderID := r.Get("order_id").Uint64()
+	if orderID == 0 {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "订单ID不能为空",
+		})
+		return
+	}
+
+	var req types.UpdateOrderItemsRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	result, err := c.orderService.UpdateOrderItems(r.Context(), orderID, req.Changes)
+	if err != nil {
+		code := 500
+		var limitErr *service.OrderLimitError
+		switch {
+		case errors.Is(err, service.ErrOrderEditForbidden):
+			code = 403
+		case errors.Is(err, service.ErrOrderNotEditable), errors.Is(err, service.ErrOrderCancelNotAllowed):
+			code = 409
+		case errors.Is(err, service.ErrOrderItemNotInOrder), errors.As(err, &limitErr):
+			code = 400
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "修改订单商品失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	message := "订单商品修改成功"
+	if result.Cancelled {
+		message = "订单商品已全部移除，订单已取消"
+	}
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": message,
+		"data":    result,
+	})
+}
+
+// Reorder 基于历史订单再来一单，按当前价格、状态和库存重新校验原订单商品，
+// mode 为 cart 时加入购物车，否则直接创建新订单
+func (c *OrderController) Reorder(r *ghttp.Request) {
+	orderID := r.Get("order_id").Uint64()
+	if orderID == 0 {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "订单ID不能为空",
+		})
+		return
+	}
+
+	var req types.ReorderRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	result, err := c.orderService.Reorder(r.Context(), orderID, &req)
+	if err != nil {
+		code := 500
+		var limitErr *service.OrderLimitError
+		switch {
+		case errors.Is(err, service.ErrReorderForbidden):
+			code = 403
+		case errors.Is(err, service.ErrReorderNoAvailableItems):
+			code = 409
+		case errors.As(err, &limitErr):
+			code = 400
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "再来一单失败",
+			"error":   err.Error(),
+			"data":    result,
+		})
+		return
+	}
+
+	message := "订单创建成功"
+	if result.Mode == types.ReorderModeCart {
+		message = "商品已加入购物车"
+	}
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": message,
+		"data":    result,
 	})
 }
 
@@ -179,6 +424,8 @@ func (c *OrderController) CancelOrder(r *ghttp.Request) {
 // @Param start_date query string false "开始日期 (YYYY-MM-DD)"
 // @Param end_date query string false "结束日期 (YYYY-MM-DD)"
 // @Param search_keyword query string false "搜索关键词（订单号或商品名称）"
+// @Param external_reference query string false "外部订单号（精确匹配）"
+// @Param tags query []string false "订单标签列表（同时带有全部标签）"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(10)
 // @Param sort_by query string false "排序字段" Enums(created_at,updated_at,total_amount)
@@ -236,6 +483,21 @@ func (c *OrderController) QueryOrders(r *ghttp.Request) {
 		req.SearchKeyword = &keyword
 	}
 	
+	// 外部订单号精确匹配
+	if reference := r.Get("external_reference").String(); reference != "" {
+		req.ExternalReference = &reference
+	}
+	
+	// 标签筛选（同时带有全部标签的订单）
+	if tagList := r.Get("tags").Strings(); len(tagList) > 0 {
+		tags, err := types.NormalizeOrderTags(tagList)
+		if err != nil {
+			utils.ErrorResponse(r, 400, "参数验证失败: " + err.Error())
+			return
+		}
+		req.Tags = tags
+	}
+	
 	// 验证请求参数
 	if err := g.Validator().Data(req).Run(ctx); err != nil {
 		utils.ErrorResponse(r, 400, "参数验证失败: " + err.Error())
@@ -289,6 +551,11 @@ func (c *OrderController) GetOrderWithHistory(r *ghttp.Request) {
 		return
 	}
 	
+	// 附加查看者可见的订单备注
+	if err := c.orderNoteService.AttachNotes(ctx, order, isOrderStaff(ctx)); err != nil {
+		g.Log().Warningf(ctx, "获取订单备注失败: %v", err)
+	}
+	
 	utils.SuccessResponse(r, order)
 }
 
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/guid"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeBatchOrderService 记录收到的批量下单请求
+type fakeBatchOrderService struct {
+	service.IOrderService
+	requests []*types.BatchCreateOrderRequest
+}
+
+func (f *fakeBatchOrderService) BatchCreateOrders(ctx context.Context, customerID uint64, req *types.BatchCreateOrderRequest) (*types.BatchCreateOrderResult, error) {
+	f.requests = append(f.requests, req)
+	return &types.BatchCreateOrderResult{}, nil
+}
+
+// startBatchOrderServer 按 main.go 的路由挂载批量下单接口
+func startBatchOrderServer(orderService service.IOrderService) (*ghttp.Server, string) {
+	controller := NewOrderControllerForTest(orderService)
+
+	// 不使用 g.Server，避免读取服务配置中的静态资源目录
+	s := ghttp.GetServer(guid.S())
+	s.Group("/api/v1/orders", func(group *ghttp.RouterGroup) {
+		group.POST("/batch", controller.BatchCreateOrders)
+	})
+	s.SetDumpRouterMap(false)
+	if err := s.Start(); err != nil {
+		panic(err)
+	}
+	return s, fmt.Sprintf("http://127.0.0.1:%d/api/v1/orders/batch", s.GetListenedPort())
+}
+
+// postBatchOrders 提交包含 count 个订单的批量下单请求，返回响应码
+func postBatchOrders(url string, count int) int {
+	orders := make([]g.Map, count)
+	for i := range orders {
+		orders[i] = g.Map{
+			"merchant_id": 1,
+			"items":       []g.Map{{"product_id": i + 1, "quantity": 1}},
+		}
+	}
+	payload, err := json.Marshal(g.Map{"orders": orders})
+	So(err, ShouldBeNil)
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(payload))
+	So(err, ShouldBeNil)
+	defer resp.Body.Close()
+
+	var body struct {
+		Code int `json:"code"`
+	}
+	So(json.NewDecoder(resp.Body).Decode(&body), ShouldBeNil)
+	return body.Code
+}
+
+func TestOrderController_BatchCreateOrders(t *testing.T) {
+	Convey("批量下单订单数量校验测试", t, func() {
+		orderService := &fakeBatchOrderService{}
+		s, url := startBatchOrderServer(orderService)
+		defer s.Shutdown()
+
+		Convey("订单数量在限制内时交给服务处理", func() {
+			So(postBatchOrders(url, 2), ShouldEqual, 0)
+			So(postBatchOrders(url, types.MaxBatchCreateOrders), ShouldEqual, 0)
+			So(orderService.requests, ShouldHaveLength, 2)
+			So(orderService.requests[0].Orders, ShouldHaveLength, 2)
+			So(orderService.requests[1].Orders, ShouldHaveLength, types.MaxBatchCreateOrders)
+		})
+
+		Convey("超过单次订单数量限制时拒绝", func() {
+			So(postBatchOrders(url, types.MaxBatchCreateOrders+1), ShouldEqual, 400)
+			So(orderService.requests, ShouldBeEmpty)
+		})
+
+		Convey("没有订单时拒绝", func() {
+			So(postBatchOrders(url, 0), ShouldEqual, 400)
+			So(orderService.requests, ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// OrderCancellationPolicyController 订单取消策略控制器
+type OrderCancellationPolicyController struct {
+	policyRepo repository.IOrderCancellationPolicyRepository
+}
+
+// NewOrderCancellationPolicyController 创建订单取消策略控制器实例
+func NewOrderCancellationPolicyController() *OrderCancellationPolicyController {
+	return &OrderCancellationPolicyController{
+		policyRepo: repository.NewOrderCancellationPolicyRepository(),
+	}
+}
+
+// GetEffectivePolicy 获取有效的取消策略
+// @Summary 获取有效的取消策略
+// @Description 获取商户的有效取消策略（优先级：商户策略 > 租户默认策略 > 系统默认值）
+// @Tags 订单取消策略
+// @Accept json
+// @Produce json
+// @Param merchant_id path uint64 true "商户ID"
+// @Success 200 {object} utils.Response{data=types.OrderCancellationPolicy}
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/orders/cancellation-policies/effective/{merchant_id} [get]
+func (c *OrderCancellationPolicyController) GetEffectivePolicy(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	merchantID, err := strconv.ParseUint(r.Get("merchant_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "无效的商户ID")
+		return
+	}
+
+	policy, err := c.policyRepo.GetEffectivePolicy(ctx, merchantID)
+	if err != nil {
+		g.Log().Error(ctx, "获取订单取消策略失败", "error", err)
+		utils.ErrorResponse(r, 500, "获取订单取消策略失败")
+		return
+	}
+
+	utils.SuccessResponse(r, policy)
+}
+
+// SavePolicy 保存取消策略
+// @Summary 保存取消策略
+// @Description 保存租户默认或商户级订单取消策略，merchant_id 为空时保存租户默认策略
+// @Tags 订单取消策略
+// @Accept json
+// @Produce json
+// @Param policy body types.OrderCancellationPolicy true "取消策略"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/orders/cancellation-policies [put]
+func (c *OrderCancellationPolicyController) SavePolicy(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var policy types.OrderCancellationPolicy
+	if err := r.Parse(&policy); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败")
+		return
+	}
+
+	if err := policy.Validate(); err != nil {
+		utils.ErrorResponse(r, 400, "参数验证失败: "+err.Error())
+		return
+	}
+
+	if err := c.policyRepo.Save(ctx, &policy); err != nil {
+		g.Log().Error(ctx, "保存订单取消策略失败", "error", err)
+		utils.ErrorResponse(r, 500, "保存订单取消策略失败")
+		return
+	}
+
+	utils.SuccessResponse(r, policy)
+}
@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// OrderDisputeController 订单争议控制器
+type OrderDisputeController struct {
+	disputeService service.IOrderDisputeService
+}
+
+// NewOrderDisputeController 创建订单争议控制器实例
+func NewOrderDisputeController(disputeService service.IOrderDisputeService) *OrderDisputeController {
+	return &OrderDisputeController{
+		disputeService: disputeService,
+	}
+}
+
+// OpenDispute 发起订单争议
+// @Summary 发起订单争议
+// @Description 下单客户对错发、未收到等问题发起争议，争议处理期间订单不会自动完成
+// @Tags 订单争议
+// @Accept json
+// @Produce json
+// @Param order_id path int true "订单ID"
+// @Param body body types.CreateOrderDisputeRequest true "发起订单争议请求"
+// @Success 200 {object} utils.Response{data=types.OrderDispute} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 409 {object} utils.Response "订单已有处理中的争议"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/{order_id}/disputes [post]
+func (c *OrderDisputeController) OpenDispute(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	orderID, err := strconv.ParseUint(r.Get("order_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "订单ID格式错误")
+		return
+	}
+
+	var req types.CreateOrderDisputeRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+
+	dispute, err := c.disputeService.OpenDispute(ctx, orderID, &req)
+	if err != nil {
+		if errors.Is(err, types.ErrOrderDisputeActive) {
+			utils.ErrorResponse(r, 409, err.Error())
+			return
+		}
+		g.Log().Errorf(ctx, "发起订单争议失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, dispute)
+}
+
+// ListOrderDisputes 获取订单的争议记录
+// @Summary 获取订单的争议记录
+// @Description 员工可查看订单的全部争议，客户只能查看自己订单的争议
+// @Tags 订单争议
+// @Produce json
+// @Param order_id path int true "订单ID"
+// @Success 200 {object} utils.Response{data=[]types.OrderDispute} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/{order_id}/disputes [get]
+func (c *OrderDisputeController) ListOrderDisputes(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	orderID, err := strconv.ParseUint(r.Get("order_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "订单ID格式错误")
+		return
+	}
+
+	disputes, err := c.disputeService.ListOrderDisputes(ctx, orderID, isOrderStaff(ctx))
+	if err != nil {
+		g.Log().Errorf(ctx, "获取订单争议失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, disputes)
+}
+
+// ListDisputes 获取争议列表
+// @Summary 获取争议列表
+// @Description 租户或商户员工按状态查看争议，商户用户只能查看本商户的争议
+// @Tags 订单争议
+// @Produce json
+// @Param status query string false "争议状态（open, investigating, resolved, rejected）"
+// @Param merchant_id query int false "商户ID"
+// @Param limit query int false "返回条数" default(100)
+// @Success 200 {object} utils.Response{data=[]types.OrderDispute} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/disputes [get]
+func (c *OrderDisputeController) ListDisputes(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	req := &types.OrderDisputeListRequest{
+		Status: types.OrderDisputeStatus(r.Get("status").String()),
+		Limit:  r.Get("limit").Int(),
+	}
+	if value := r.Get("merchant_id").String(); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.ErrorResponse(r, 400, "无效的商户ID")
+			return
+		}
+		req.MerchantID = &id
+	}
+
+	disputes, err := c.disputeService.ListDisputes(ctx, req)
+	if err != nil {
+		g.Log().Errorf(ctx, "获取争议列表失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, disputes)
+}
+
+// GetDispute 获取争议详情
+// @Summary 获取争议详情
+// @Tags 订单争议
+// @Produce json
+// @Param dispute_id path int true "争议ID"
+// @Success 200 {object} utils.Response{data=types.OrderDispute} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/disputes/{dispute_id} [get]
+func (c *OrderDisputeController) GetDispute(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	disputeID, err := strconv.ParseUint(r.Get("dispute_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "争议ID格式错误")
+		return
+	}
+
+	dispute, err := c.disputeService.GetDispute(ctx, disputeID, isOrderStaff(ctx))
+	if err != nil {
+		g.Log().Errorf(ctx, "获取争议详情失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, dispute)
+}
+
+// UpdateDisputeStatus 更新争议状态
+// @Summary 更新争议状态
+// @Description 员工将争议转为调查中，或填写处理说明后解决、驳回争议
+// @Tags 订单争议
+// @Accept json
+// @Produce json
+// @Param dispute_id path int true "争议ID"
+// @Param body body types.UpdateOrderDisputeStatusRequest true "更新争议状态请求"
+// @Success 200 {object} utils.Response{data=types.OrderDispute} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 403 {object} utils.Response "权限不足"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/disputes/{dispute_id}/status [put]
+func (c *OrderDisputeController) UpdateDisputeStatus(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	disputeID, err := strconv.ParseUint(r.Get("dispute_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "争议ID格式错误")
+		return
+	}
+
+	var req types.UpdateOrderDisputeStatusRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+
+	dispute, err := c.disputeService.UpdateDisputeStatus(ctx, disputeID, &req)
+	if err != nil {
+		g.Log().Errorf(ctx, "更新争议状态失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, dispute)
+}
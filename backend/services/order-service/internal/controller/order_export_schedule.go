@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// OrderExportScheduleController 定时订单导出控制器
+type OrderExportScheduleController struct {
+	scheduleService *service.OrderExportScheduleService
+}
+
+// NewOrderExportScheduleController 创建定时订单导出控制器实例
+func NewOrderExportScheduleController(scheduleService *service.OrderExportScheduleService) *OrderExportScheduleController {
+	return &OrderExportScheduleController{
+		scheduleService: scheduleService,
+	}
+}
+
+// Create 创建定时订单导出
+// @Summary 创建定时订单导出
+// @Description 按每天或每周的计划导出新增订单，通过邮件发送下载链接或上传到对象存储。每次只导出上次投递之后创建的订单，未指定 start_after_order_id 时从当前最新订单之后开始
+// @Tags 订单管理
+// @Accept json
+// @Produce json
+// @Param body body types.CreateOrderExportScheduleRequest true "定时订单导出"
+// @Success 200 {object} utils.Response{data=types.OrderExportSchedule} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/export-schedules [post]
+func (c *OrderExportScheduleController) Create(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var req types.CreateOrderExportScheduleRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+
+	schedule, err := c.scheduleService.CreateSchedule(ctx, r.GetCtxVar("user_id").Uint64(), &req)
+	if err != nil {
+		c.handleError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, schedule)
+}
+
+// List 获取定时订单导出列表
+// @Summary 获取定时订单导出列表
+// @Tags 订单管理
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]types.OrderExportSchedule} "成功"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/export-schedules [get]
+func (c *OrderExportScheduleController) List(r *ghttp.Request) {
+	schedules, err := c.scheduleService.ListSchedules(r.GetCtx())
+	if err != nil {
+		c.handleError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, schedules)
+}
+
+// Enable 启用定时订单导出
+// @Summary 启用定时订单导出
+// @Description 从下一个计划时间开始执行，停用期间的新订单在下次执行时一并导出
+// @Tags 订单管理
+// @Produce json
+// @Param id path int true "定时导出ID"
+// @Success 200 {object} utils.Response{data=types.OrderExportSchedule} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 404 {object} utils.Response "定时导出不存在"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/export-schedules/{id}/enable [put]
+func (c *OrderExportScheduleController) Enable(r *ghttp.Request) {
+	c.setEnabled(r, true)
+}
+
+// Disable 停用定时订单导出
+// @Summary 停用定时订单导出
+// @Tags 订单管理
+// @Produce json
+// @Param id path int true "定时导出ID"
+// @Success 200 {object} utils.Response{data=types.OrderExportSchedule} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 404 {object} utils.Response "定时导出不存在"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/export-schedules/{id}/disable [put]
+func (c *OrderExportScheduleController) Disable(r *ghttp.Request) {
+	c.setEnabled(r, false)
+}
+
+func (c *OrderExportScheduleController) setEnabled(r *ghttp.Request, enabled bool) {
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "定时导出ID格式错误")
+		return
+	}
+
+	schedule, err := c.scheduleService.SetScheduleEnabled(r.GetCtx(), id, enabled)
+	if err != nil {
+		c.handleError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, schedule)
+}
+
+// ListRuns 获取定时订单导出的执行记录
+// @Summary 获取定时订单导出的执行记录
+// @Description 按执行时间倒序返回，包括导出的订单范围、订单数、投递结果和失败原因
+// @Tags 订单管理
+// @Produce json
+// @Param id path int true "定时导出ID"
+// @Param limit query int false "返回条数" default(50)
+// @Success 200 {object} utils.Response{data=[]types.OrderExportRun} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 404 {object} utils.Response "定时导出不存在"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/export-schedules/{id}/runs [get]
+func (c *OrderExportScheduleController) ListRuns(r *ghttp.Request) {
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "定时导出ID格式错误")
+		return
+	}
+
+	runs, err := c.scheduleService.ListRuns(r.GetCtx(), id, r.Get("limit").Int())
+	if err != nil {
+		c.handleError(r, err)
+		return
+	}
+
+	utils.SuccessResponse(r, runs)
+}
+
+// handleError 按错误类型返回对应的状态码
+func (c *OrderExportScheduleController) handleError(r *ghttp.Request, err error) {
+	if errors.Is(err, types.ErrOrderExportScheduleNotFound) {
+		utils.ErrorResponse(r, 404, err.Error())
+		return
+	}
+	g.Log().Error(r.GetCtx(), "定时订单导出操作失败", "error", err)
+	utils.ErrorResponse(r, 500, err.Error())
+}
@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// OrderFulfillmentController 订单履约单控制器
+type OrderFulfillmentController struct {
+	fulfillmentService service.IOrderFulfillmentService
+}
+
+// NewOrderFulfillmentController 创建订单履约单控制器实例
+func NewOrderFulfillmentController(fulfillmentService service.IOrderFulfillmentService) *OrderFulfillmentController {
+	return &OrderFulfillmentController{
+		fulfillmentService: fulfillmentService,
+	}
+}
+
+// SplitOrder 拆分订单履约单
+// @Summary 拆分订单履约单
+// @Description 员工把订单商品分到若干包裹，各包裹独立发货，订单金额和权益消耗按包裹内商品占比分摊
+// @Tags 订单履约
+// @Accept json
+// @Produce json
+// @Param order_id path int true "订单ID"
+// @Param body body types.CreateOrderFulfillmentsRequest true "拆单请求"
+// @Success 200 {object} utils.Response{data=[]types.OrderFulfillment} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 409 {object} utils.Response "订单已拆分履约单"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/{order_id}/fulfillments [post]
+func (c *OrderFulfillmentController) SplitOrder(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	orderID, err := strconv.ParseUint(r.Get("order_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "订单ID格式错误")
+		return
+	}
+
+	var req types.CreateOrderFulfillmentsRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+
+	fulfillments, err := c.fulfillmentService.SplitOrder(ctx, orderID, &req)
+	if err != nil {
+		if errors.Is(err, types.ErrOrderFulfillmentsExist) {
+			utils.ErrorResponse(r, 409, err.Error())
+			return
+		}
+		g.Log().Errorf(ctx, "拆分订单履约单失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, fulfillments)
+}
+
+// ListFulfillments 获取订单的履约单
+// @Summary 获取订单的履约单
+// @Description 员工可查看订单的全部包裹，客户只能查看自己订单的包裹
+// @Tags 订单履约
+// @Produce json
+// @Param order_id path int true "订单ID"
+// @Success 200 {object} utils.Response{data=[]types.OrderFulfillment} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/{order_id}/fulfillments [get]
+func (c *OrderFulfillmentController) ListFulfillments(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	orderID, err := strconv.ParseUint(r.Get("order_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "订单ID格式错误")
+		return
+	}
+
+	fulfillments, err := c.fulfillmentService.ListFulfillments(ctx, orderID, isOrderStaff(ctx))
+	if err != nil {
+		g.Log().Errorf(ctx, "获取订单履约单失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, fulfillments)
+}
+
+// UpdateFulfillmentStatus 更新包裹状态
+// @Summary 更新包裹状态
+// @Description 员工按待发货、已发货、已送达、已完成逐级更新包裹状态，发货时填写运单号，状态变更后通知客户
+// @Tags 订单履约
+// @Accept json
+// @Produce json
+// @Param order_id path int true "订单ID"
+// @Param fulfillment_id path int true "履约单ID"
+// @Param body body types.UpdateFulfillmentStatusRequest true "更新包裹状态请求"
+// @Success 200 {object} utils.Response{data=types.OrderFulfillment} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 404 {object} utils.Response "履约单不存在"
+// @Failure 409 {object} utils.Response "状态不允许变更"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/{order_id}/fulfillments/{fulfillment_id}/status [put]
+func (c *OrderFulfillmentController) UpdateFulfillmentStatus(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	orderID, err := strconv.ParseUint(r.Get("order_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "订单ID格式错误")
+		return
+	}
+	fulfillmentID, err := strconv.ParseUint(r.Get("fulfillment_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "履约单ID格式错误")
+		return
+	}
+
+	var req types.UpdateFulfillmentStatusRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+
+	fulfillment, err := c.fulfillmentService.UpdateFulfillmentStatus(ctx, orderID, fulfillmentID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, types.ErrOrderFulfillmentNotFound):
+			utils.ErrorResponse(r, 404, err.Error())
+		case errors.Is(err, types.ErrFulfillmentStatusTransition):
+			utils.ErrorResponse(r, 409, err.Error())
+		default:
+			g.Log().Errorf(ctx, "更新包裹状态失败: %v", err)
+			utils.ErrorResponse(r, 500, err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(r, fulfillment)
+}
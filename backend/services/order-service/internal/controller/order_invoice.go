@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// OrderInvoiceController 订单发票控制器
+type OrderInvoiceController struct {
+	invoiceService service.IOrderInvoiceService
+}
+
+// NewOrderInvoiceController 创建订单发票控制器实例
+func NewOrderInvoiceController(invoiceService service.IOrderInvoiceService) *OrderInvoiceController {
+	return &OrderInvoiceController{
+		invoiceService: invoiceService,
+	}
+}
+
+// GetInvoice 下载订单发票
+// @Summary 下载订单发票
+// @Description 下载已支付订单的发票PDF，首次下载时开具发票并分配发票号，之后沿用原发票号。只有下单客户和订单所属商户可以下载
+// @Tags 订单发票
+// @Produce application/pdf
+// @Param order_id path int true "订单ID"
+// @Success 200 {file} file "发票PDF"
+// @Failure 400 {object} utils.Response "订单未支付或租户未启用发票"
+// @Failure 403 {object} utils.Response "无权获取该订单的发票"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/{order_id}/invoice [get]
+func (c *OrderInvoiceController) GetInvoice(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	orderID, err := strconv.ParseUint(r.Get("order_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "订单ID格式错误")
+		return
+	}
+
+	invoice, err := c.invoiceService.GetInvoice(ctx, orderID, isOrderStaff(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, types.ErrInvoiceAccessDenied):
+			utils.ErrorResponse(r, 403, err.Error())
+		case errors.Is(err, types.ErrOrderNotInvoiceable), errors.Is(err, types.ErrInvoiceDisabled):
+			utils.ErrorResponse(r, 400, err.Error())
+		default:
+			g.Log().Errorf(ctx, "获取订单发票失败: %v", err)
+			utils.ErrorResponse(r, 500, err.Error())
+		}
+		return
+	}
+
+	r.Response.Header().Set("Content-Type", "application/pdf")
+	r.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, invoice.InvoiceNumber))
+	r.Response.ServeFile(invoice.FilePath)
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// OrderNoteController 订单备注控制器
+type OrderNoteController struct {
+	orderNoteService service.IOrderNoteService
+}
+
+// NewOrderNoteController 创建订单备注控制器实例
+func NewOrderNoteController() *OrderNoteController {
+	return &OrderNoteController{
+		orderNoteService: service.NewOrderNoteService(),
+	}
+}
+
+// AddNote 添加订单备注
+// @Summary 添加订单备注
+// @Description 客服或履约人员为订单添加内部备注或客户可见备注
+// @Tags 订单管理
+// @Accept json
+// @Produce json
+// @Param order_id path int true "订单ID"
+// @Param body body types.CreateOrderNoteRequest true "添加订单备注请求"
+// @Success 200 {object} utils.Response{data=types.OrderNote} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 403 {object} utils.Response "权限不足"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/{order_id}/notes [post]
+func (c *OrderNoteController) AddNote(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	orderID, err := strconv.ParseUint(r.Get("order_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "订单ID格式错误")
+		return
+	}
+
+	var req types.CreateOrderNoteRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+
+	note, err := c.orderNoteService.AddNote(ctx, orderID, &req)
+	if err != nil {
+		g.Log().Errorf(ctx, "添加订单备注失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, note)
+}
+
+// ListNotes 获取订单备注列表
+// @Summary 获取订单备注列表
+// @Description 员工可查看全部备注，下单客户只能查看客户可见备注
+// @Tags 订单管理
+// @Accept json
+// @Produce json
+// @Param order_id path int true "订单ID"
+// @Success 200 {object} utils.Response{data=[]types.OrderNote} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/{order_id}/notes [get]
+func (c *OrderNoteController) ListNotes(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	orderID, err := strconv.ParseUint(r.Get("order_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "订单ID格式错误")
+		return
+	}
+
+	notes, err := c.orderNoteService.ListNotes(ctx, orderID, isOrderStaff(ctx))
+	if err != nil {
+		g.Log().Errorf(ctx, "获取订单备注失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, notes)
+}
+
+// isOrderStaff 判断当前用户是否为可处理订单的员工（租户或商户侧）
+func isOrderStaff(ctx context.Context) bool {
+	return middleware.HasPermissionInContext(ctx, types.PermissionOrderUpdate) ||
+		middleware.HasPermissionInContext(ctx, types.PermissionMerchantOrderProcess)
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// OrderNumberFormatController 订单号格式控制器
+type OrderNumberFormatController struct {
+	formatRepo repository.IOrderNumberFormatRepository
+}
+
+// NewOrderNumberFormatController 创建订单号格式控制器实例
+func NewOrderNumberFormatController() *OrderNumberFormatController {
+	return &OrderNumberFormatController{
+		formatRepo: repository.NewOrderNumberFormatRepository(),
+	}
+}
+
+// GetFormat 获取订单号格式
+// @Summary 获取订单号格式
+// @Description 获取当前租户的订单号格式，未配置时返回默认格式
+// @Tags 订单号格式
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.Response{data=types.OrderNumberFormat}
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/orders/number-format [get]
+func (c *OrderNumberFormatController) GetFormat(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	format, err := c.formatRepo.GetEffectiveFormat(ctx)
+	if err != nil {
+		g.Log().Error(ctx, "获取订单号格式失败", "error", err)
+		utils.ErrorResponse(r, 500, "获取订单号格式失败")
+		return
+	}
+
+	utils.SuccessResponse(r, format)
+}
+
+// SaveFormat 保存订单号格式
+// @Summary 保存订单号格式
+// @Description 保存当前租户的订单号格式，只影响之后创建的订单
+// @Tags 订单号格式
+// @Accept json
+// @Produce json
+// @Param format body types.OrderNumberFormat true "订单号格式"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/orders/number-format [put]
+func (c *OrderNumberFormatController) SaveFormat(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var format types.OrderNumberFormat
+	if err := r.Parse(&format); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败")
+		return
+	}
+
+	if err := format.Validate(); err != nil {
+		utils.ErrorResponse(r, 400, "参数验证失败: "+err.Error())
+		return
+	}
+
+	if err := c.formatRepo.Save(ctx, &format); err != nil {
+		g.Log().Error(ctx, "保存订单号格式失败", "error", err)
+		utils.ErrorResponse(r, 500, "保存订单号格式失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, format)
+}
+
+// ResetFormat 恢复默认订单号格式
+// @Summary 恢复默认订单号格式
+// @Description 删除当前租户的自定义订单号格式
+// @Tags 订单号格式
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/orders/number-format [delete]
+func (c *OrderNumberFormatController) ResetFormat(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	if err := c.formatRepo.Delete(ctx); err != nil {
+		g.Log().Error(ctx, "恢复默认订单号格式失败", "error", err)
+		utils.ErrorResponse(r, 500, "恢复默认订单号格式失败")
+		return
+	}
+
+	utils.SuccessResponse(r, nil)
+}
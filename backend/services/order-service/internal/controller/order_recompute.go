@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// OrderRecomputeController 订单派生字段重算控制器
+type OrderRecomputeController struct {
+	recomputeService service.IOrderRecomputeService
+}
+
+// NewOrderRecomputeController 创建订单派生字段重算控制器实例
+func NewOrderRecomputeController(recomputeService service.IOrderRecomputeService) *OrderRecomputeController {
+	return &OrderRecomputeController{
+		recomputeService: recomputeService,
+	}
+}
+
+// Recompute 重算订单派生字段
+// @Summary 重算订单派生字段
+// @Description 按订单项重新计算当前租户历史订单的订单金额、权益和税费，用于修复计算错误后更正已保存的订单。仅限租户管理员，dry_run 为 true 时只报告将发生的变更
+// @Tags 订单管理
+// @Accept json
+// @Produce json
+// @Param start_date body string true "下单开始日期 YYYY-MM-DD"
+// @Param end_date body string true "下单结束日期 YYYY-MM-DD（含）"
+// @Param status body string false "订单状态：pending/paid/processing/completed/cancelled"
+// @Param dry_run body bool false "试运行，只报告变更不保存"
+// @Success 200 {object} utils.Response{data=types.OrderRecomputeResult} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/recompute [post]
+func (c *OrderRecomputeController) Recompute(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	req, err := parseOrderRecomputeRequest(r)
+	if err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		utils.ErrorResponse(r, 400, "参数验证失败: "+err.Error())
+		return
+	}
+
+	result, err := c.recomputeService.Recompute(ctx, req)
+	if err != nil {
+		g.Log().Errorf(ctx, "重算订单派生字段失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, result)
+}
+
+// parseOrderRecomputeRequest 解析重算条件，结束日期当天创建的订单也会重算
+func parseOrderRecomputeRequest(r *ghttp.Request) (*types.OrderRecomputeRequest, error) {
+	startDate, err := time.ParseInLocation("2006-01-02", r.Get("start_date").String(), time.Local)
+	if err != nil {
+		return nil, errors.New("开始日期格式错误，应为 YYYY-MM-DD")
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", r.Get("end_date").String(), time.Local)
+	if err != nil {
+		return nil, errors.New("结束日期格式错误，应为 YYYY-MM-DD")
+	}
+
+	req := &types.OrderRecomputeRequest{
+		StartDate: startDate,
+		EndDate:   endDate.AddDate(0, 0, 1),
+		DryRun:    r.Get("dry_run").Bool(),
+	}
+	if value := r.Get("status").String(); value != "" {
+		status := types.OrderStatus(value)
+		req.Status = &status
+	}
+	return req, nil
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// OrderReviewController 订单风险审核控制器
+type OrderReviewController struct {
+	reviewService service.IOrderReviewService
+}
+
+// NewOrderReviewController 创建订单风险审核控制器实例
+func NewOrderReviewController(reviewService service.IOrderReviewService) *OrderReviewController {
+	return &OrderReviewController{
+		reviewService: reviewService,
+	}
+}
+
+// ListReviews 获取订单审核队列
+// @Summary 获取订单审核队列
+// @Description 租户或商户员工按状态查看命中风险审核规则的订单，商户用户只能查看本商户的订单
+// @Tags 订单风险审核
+// @Produce json
+// @Param status query string false "审核状态（pending, approved, rejected）" default(pending)
+// @Param merchant_id query int false "商户ID"
+// @Param limit query int false "返回条数" default(100)
+// @Success 200 {object} utils.Response{data=[]types.OrderReview} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/reviews [get]
+func (c *OrderReviewController) ListReviews(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	req := &types.OrderReviewListRequest{
+		Status: types.OrderReviewStatus(r.Get("status").String()),
+		Limit:  r.Get("limit").Int(),
+	}
+	if value := r.Get("merchant_id").String(); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.ErrorResponse(r, 400, "无效的商户ID")
+			return
+		}
+		req.MerchantID = &id
+	}
+
+	reviews, err := c.reviewService.ListReviews(ctx, req)
+	if err != nil {
+		g.Log().Errorf(ctx, "获取订单审核队列失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, reviews)
+}
+
+// GetReview 获取订单审核详情
+// @Summary 获取订单审核详情
+// @Tags 订单风险审核
+// @Produce json
+// @Param review_id path int true "审核ID"
+// @Success 200 {object} utils.Response{data=types.OrderReview} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/reviews/{review_id} [get]
+func (c *OrderReviewController) GetReview(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	reviewID, err := strconv.ParseUint(r.Get("review_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "审核ID格式错误")
+		return
+	}
+
+	review, err := c.reviewService.GetReview(ctx, reviewID)
+	if err != nil {
+		g.Log().Errorf(ctx, "获取订单审核详情失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, review)
+}
+
+// DecideReview 审核待审核订单
+// @Summary 审核待审核订单
+// @Description 员工通过订单后客户可以继续支付；拒绝时须填写审核说明，订单随即取消
+// @Tags 订单风险审核
+// @Accept json
+// @Produce json
+// @Param review_id path int true "审核ID"
+// @Param body body types.OrderReviewDecisionRequest true "审核决定请求"
+// @Success 200 {object} utils.Response{data=types.OrderReview} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 403 {object} utils.Response "权限不足"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/reviews/{review_id}/decision [post]
+func (c *OrderReviewController) DecideReview(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	reviewID, err := strconv.ParseUint(r.Get("review_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "审核ID格式错误")
+		return
+	}
+
+	var req types.OrderReviewDecisionRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+
+	review, err := c.reviewService.Decide(ctx, reviewID, &req)
+	if err != nil {
+		g.Log().Errorf(ctx, "审核订单失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, review)
+}
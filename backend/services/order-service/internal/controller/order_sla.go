@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// OrderSLAController 订单状态处理时限控制器
+type OrderSLAController struct {
+	slaService service.IOrderSLAService
+}
+
+// NewOrderSLAController 创建订单状态处理时限控制器实例
+func NewOrderSLAController(slaService service.IOrderSLAService) *OrderSLAController {
+	return &OrderSLAController{
+		slaService: slaService,
+	}
+}
+
+// GetEffectiveConfig 获取有效的处理时限配置
+// @Summary 获取有效的处理时限配置
+// @Description 获取商户各订单状态的有效处理时限（优先级：商户配置 > 租户默认配置 > 系统默认值）
+// @Tags 订单处理时限
+// @Accept json
+// @Produce json
+// @Param merchant_id path uint64 true "商户ID"
+// @Success 200 {object} utils.Response{data=types.OrderSLAConfig}
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/orders/sla-configs/effective/{merchant_id} [get]
+func (c *OrderSLAController) GetEffectiveConfig(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	merchantID, err := strconv.ParseUint(r.Get("merchant_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "无效的商户ID")
+		return
+	}
+
+	config, err := c.slaService.GetEffectiveConfig(ctx, merchantID)
+	if err != nil {
+		g.Log().Error(ctx, "获取订单处理时限配置失败", "error", err)
+		utils.ErrorResponse(r, 500, "获取订单处理时限配置失败")
+		return
+	}
+
+	utils.SuccessResponse(r, config)
+}
+
+// SaveConfig 保存处理时限配置
+// @Summary 保存处理时限配置
+// @Description 保存租户默认或商户级订单状态处理时限，merchant_id 为空时保存租户默认配置；超时只提醒，不会取消订单
+// @Tags 订单处理时限
+// @Accept json
+// @Produce json
+// @Param config body types.OrderSLAConfig true "处理时限配置"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/orders/sla-configs [put]
+func (c *OrderSLAController) SaveConfig(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var config types.OrderSLAConfig
+	if err := r.Parse(&config); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败")
+		return
+	}
+
+	if err := config.Validate(); err != nil {
+		utils.ErrorResponse(r, 400, "参数验证失败: "+err.Error())
+		return
+	}
+
+	if err := c.slaService.SaveConfig(ctx, &config); err != nil {
+		g.Log().Error(ctx, "保存订单处理时限配置失败", "error", err)
+		utils.ErrorResponse(r, 500, "保存订单处理时限配置失败")
+		return
+	}
+
+	utils.SuccessResponse(r, config)
+}
+
+// ListBreaches 获取超过处理时限的订单
+// @Summary 获取处理超时订单
+// @Description 列出在当前状态停留超过处理时限的订单，按进入当前状态的时间从早到晚排序；商户用户只能查看本商户的订单
+// @Tags 订单处理时限
+// @Produce json
+// @Param merchant_id query int false "商户ID"
+// @Param limit query int false "返回条数，默认100，最大500"
+// @Success 200 {object} utils.Response{data=[]types.OrderSLABreach}
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/orders/sla-breaches [get]
+func (c *OrderSLAController) ListBreaches(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var merchantID *uint64
+	if value := r.Get("merchant_id").String(); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.ErrorResponse(r, 400, "无效的商户ID")
+			return
+		}
+		merchantID = &id
+	}
+
+	breaches, err := c.slaService.ListBreaches(ctx, merchantID, r.Get("limit").Int())
+	if err != nil {
+		g.Log().Error(ctx, "获取处理超时订单失败", "error", err)
+		utils.ErrorResponse(r, 500, "获取处理超时订单失败")
+		return
+	}
+
+	utils.SuccessResponse(r, breaches)
+}
+
+// GetOrderSLA 获取订单各状态停留时间
+// @Summary 获取订单处理时限情况
+// @Description 根据状态历史计算订单在各状态停留的时间，并检查当前状态是否超过处理时限
+// @Tags 订单处理时限
+// @Produce json
+// @Param order_id path uint64 true "订单ID"
+// @Success 200 {object} utils.Response{data=types.OrderSLAStatus}
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/orders/{order_id}/sla [get]
+func (c *OrderSLAController) GetOrderSLA(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	orderID, err := strconv.ParseUint(r.Get("order_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "无效的订单ID")
+		return
+	}
+
+	status, err := c.slaService.GetOrderSLA(ctx, orderID)
+	if err != nil {
+		g.Log().Error(ctx, "获取订单处理时限情况失败", "order_id", orderID, "error", err)
+		utils.ErrorResponse(r, 500, "获取订单处理时限情况失败")
+		return
+	}
+
+	utils.SuccessResponse(r, status)
+}
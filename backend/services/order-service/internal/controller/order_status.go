@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 
@@ -34,6 +35,7 @@ func NewOrderStatusController() *OrderStatusController {
 // @Success 200 {object} utils.Response "成功"
 // @Failure 400 {object} utils.Response "请求参数错误"
 // @Failure 403 {object} utils.Response "权限不足"
+// @Failure 409 {object} utils.Response "订单状态已被并发修改，或订单仍有待补货的预订商品"
 // @Failure 500 {object} utils.Response "内部服务器错误"
 // @Router /api/v1/orders/{order_id}/status [put]
 func (c *OrderStatusController) UpdateOrderStatus(r *ghttp.Request) {
@@ -63,6 +65,16 @@ func (c *OrderStatusController) UpdateOrderStatus(r *ghttp.Request) {
 	// 更新订单状态
 	if err := c.orderStatusService.UpdateOrderStatus(ctx, orderID, &req); err != nil {
 		g.Log().Errorf(ctx, "更新订单状态失败: %v", err)
+		// 并发的状态变更已生效，客户端刷新订单后再决定是否操作
+		if errors.Is(err, types.ErrOrderStatusConflict) {
+			utils.ErrorResponse(r, 409, err.Error())
+			return
+		}
+		// 预订商品补货释放后订单自动转为处理中，也可由商户手动释放
+		if errors.Is(err, types.ErrOrderOnBackorderHold) {
+			utils.ErrorResponse(r, 409, err.Error())
+			return
+		}
 		utils.ErrorResponse(r, 500, err.Error())
 		return
 	}
@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// OrderTagController 订单标签与筛选视图控制器
+type OrderTagController struct {
+	orderTagService service.IOrderTagService
+}
+
+// NewOrderTagController 创建订单标签控制器实例
+func NewOrderTagController() *OrderTagController {
+	return &OrderTagController{
+		orderTagService: service.NewOrderTagService(),
+	}
+}
+
+// AddTags 添加订单标签
+// @Summary 添加订单标签
+// @Description 为订单添加标签（如 VIP、纠纷、优先处理），订单已有的标签忽略
+// @Tags 订单管理
+// @Accept json
+// @Produce json
+// @Param order_id path int true "订单ID"
+// @Param body body types.AddOrderTagsRequest true "添加订单标签请求"
+// @Success 200 {object} utils.Response{data=[]string} "订单当前的全部标签"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/{order_id}/tags [post]
+func (c *OrderTagController) AddTags(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	orderID, err := strconv.ParseUint(r.Get("order_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "订单ID格式错误")
+		return
+	}
+
+	var req types.AddOrderTagsRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+
+	tags, err := c.orderTagService.AddTags(ctx, orderID, req.Tags)
+	if err != nil {
+		g.Log().Errorf(ctx, "添加订单标签失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, tags)
+}
+
+// RemoveTag 移除订单标签
+// @Summary 移除订单标签
+// @Tags 订单管理
+// @Produce json
+// @Param order_id path int true "订单ID"
+// @Param tag path string true "标签"
+// @Success 200 {object} utils.Response{data=[]string} "订单剩余的标签"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/{order_id}/tags/{tag} [delete]
+func (c *OrderTagController) RemoveTag(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	orderID, err := strconv.ParseUint(r.Get("order_id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "订单ID格式错误")
+		return
+	}
+
+	tags, err := c.orderTagService.RemoveTag(ctx, orderID, r.Get("tag").String())
+	if err != nil {
+		g.Log().Errorf(ctx, "移除订单标签失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, tags)
+}
+
+// SaveView 保存订单筛选视图
+// @Summary 保存订单筛选视图
+// @Description 将订单筛选条件保存为当前用户的命名视图
+// @Tags 订单管理
+// @Accept json
+// @Produce json
+// @Param body body types.SaveOrderViewRequest true "保存订单筛选视图请求"
+// @Success 200 {object} utils.Response{data=types.OrderSavedView} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/views [post]
+func (c *OrderTagController) SaveView(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var req types.SaveOrderViewRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败: "+err.Error())
+		return
+	}
+
+	view, err := c.orderTagService.SaveView(ctx, &req)
+	if err != nil {
+		g.Log().Errorf(ctx, "保存订单视图失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, view)
+}
+
+// ListViews 获取当前用户的订单筛选视图
+// @Summary 获取订单筛选视图列表
+// @Tags 订单管理
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]types.OrderSavedView} "成功"
+// @Failure 500 {object} utils.Response "内部服务器错误"
+// @Router /api/v1/orders/views [get]
+func (c *OrderTagController) ListViews(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	views, err := c.orderTagService.ListViews(ctx)
+	if err != nil {
+		g.Log().Errorf(ctx, "获取订单视图列表失败: %v", err)
+		utils.ErrorResponse(r, 500, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(r, views)
+}
+
+// GetView 获取订单筛选视图
+// @Summary 获取订单筛选视图
+// @Tags 订单管理
+// @Produce json
+// @Param view_id path int true "视图ID"
+// @Success 200 {object} utils.Response{data=types.OrderSavedView} "成功"
+// @Failure 404 {object} utils.Response "视图不存在"
+// @Router /api/v1/orders/views/{view_id} [get]
+func (c *OrderTagController) GetView(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	view, err := c.orderTagService.GetView(ctx, r.Get("view_id").Uint64())
+	if err != nil {
+		writeOrderViewError(r, "获取订单视图失败", err)
+		return
+	}
+
+	utils.SuccessResponse(r, view)
+}
+
+// DeleteView 删除订单筛选视图
+// @Summary 删除订单筛选视图
+// @Tags 订单管理
+// @Produce json
+// @Param view_id path int true "视图ID"
+// @Success 200 {object} utils.Response "成功"
+// @Failure 404 {object} utils.Response "视图不存在"
+// @Router /api/v1/orders/views/{view_id} [delete]
+func (c *OrderTagController) DeleteView(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	if err := c.orderTagService.DeleteView(ctx, r.Get("view_id").Uint64()); err != nil {
+		writeOrderViewError(r, "删除订单视图失败", err)
+		return
+	}
+
+	utils.SuccessResponse(r, nil)
+}
+
+// QueryView 按保存的筛选视图查询订单
+// @Summary 按筛选视图查询订单
+// @Tags 订单管理
+// @Produce json
+// @Param view_id path int true "视图ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Success 200 {object} utils.Response{data=types.OrderListResponse} "成功"
+// @Failure 404 {object} utils.Response "视图不存在"
+// @Router /api/v1/orders/views/{view_id}/orders [get]
+func (c *OrderTagController) QueryView(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	page := r.Get("page", 1).Int()
+	pageSize := r.Get("page_size", 10).Int()
+	if page < 1 || pageSize < 1 || pageSize > 100 {
+		utils.ErrorResponse(r, 400, "分页参数错误")
+		return
+	}
+
+	response, err := c.orderTagService.QueryView(ctx, r.Get("view_id").Uint64(), page, pageSize)
+	if err != nil {
+		writeOrderViewError(r, "按视图查询订单失败", err)
+		return
+	}
+
+	utils.SuccessResponse(r, response)
+}
+
+// writeOrderViewError 输出订单视图错误响应，视图不存在时返回404
+func writeOrderViewError(r *ghttp.Request, message string, err error) {
+	if errors.Is(err, service.ErrOrderViewNotFound) {
+		utils.ErrorResponse(r, 404, err.Error())
+		return
+	}
+
+	g.Log().Errorf(r.GetCtx(), "%s: %v", message, err)
+	utils.ErrorResponse(r, 500, message+": "+err.Error())
+}
@@ -261,5 +261,19 @@ func (c *OrderTimeoutConfigController) validateTimeoutConfig(config *types.Order
 		return fmt.Errorf("处理超时时间不能超过720小时（30天）")
 	}
 
+	// 未指定自动完成等待时长时使用默认值
+	if config.AutoCompleteAfterHours == 0 {
+		config.AutoCompleteAfterHours = types.DefaultAutoCompleteAfterHours
+	}
+
+	if config.AutoCompleteAfterHours < 0 || config.AutoCompleteAfterHours > 2160 { // 90天
+		return fmt.Errorf("自动完成等待时间必须在1到2160小时（90天）之间")
+	}
+
+	// 库存预留保留时长只能短于或等于支付超时，0表示与支付超时一致
+	if config.ReservationHoldMinutes < 0 || config.ReservationHoldMinutes > config.PaymentTimeoutMinutes {
+		return fmt.Errorf("库存预留保留时长必须在0到支付超时时间（%d分钟）之间", config.PaymentTimeoutMinutes)
+	}
+
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// OrderWebhookController 订单 Webhook 控制器
+type OrderWebhookController struct {
+	webhookService *service.OrderWebhookService
+}
+
+// NewOrderWebhookController 创建订单 Webhook 控制器实例
+func NewOrderWebhookController() *OrderWebhookController {
+	return &OrderWebhookController{
+		webhookService: service.NewOrderWebhookService(),
+	}
+}
+
+// Create 创建订单 Webhook
+// @Summary 创建订单事件 Webhook
+// @Description 订阅订单创建、支付、完成、取消、退款事件，签名密钥只在创建时返回
+// @Tags 订单Webhook
+// @Accept json
+// @Produce json
+// @Param body body types.OrderWebhookRequest true "Webhook配置"
+// @Success 200 {object} utils.Response{data=types.OrderWebhookCreated} "成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Router /api/v1/order-webhooks [post]
+func (c *OrderWebhookController) Create(r *ghttp.Request) {
+	var req types.OrderWebhookRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+
+	webhook, err := c.webhookService.Create(r.GetCtx(), &req)
+	if err != nil {
+		writeOrderWebhookError(r, err)
+		return
+	}
+	utils.SuccessResponse(r, webhook)
+}
+
+// List 获取订单 Webhook 列表
+// @Summary 获取订单事件 Webhook 列表
+// @Tags 订单Webhook
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]types.OrderWebhook} "成功"
+// @Router /api/v1/order-webhooks [get]
+func (c *OrderWebhookController) List(r *ghttp.Request) {
+	webhooks, err := c.webhookService.List(r.GetCtx())
+	if err != nil {
+		writeOrderWebhookError(r, err)
+		return
+	}
+	utils.SuccessResponse(r, webhooks)
+}
+
+// Update 更新订单 Webhook
+// @Summary 更新订单事件 Webhook
+// @Description 签名密钥为空时保留原密钥
+// @Tags 订单Webhook
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param body body types.OrderWebhookRequest true "Webhook配置"
+// @Success 200 {object} utils.Response{data=types.OrderWebhook} "成功"
+// @Failure 404 {object} utils.Response "Webhook不存在"
+// @Router /api/v1/order-webhooks/{id} [put]
+func (c *OrderWebhookController) Update(r *ghttp.Request) {
+	id := r.Get("id").Uint64()
+	if id == 0 {
+		utils.ErrorResponse(r, 400, "Webhook ID不能为空")
+		return
+	}
+
+	var req types.OrderWebhookRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+
+	webhook, err := c.webhookService.Update(r.GetCtx(), id, &req)
+	if err != nil {
+		writeOrderWebhookError(r, err)
+		return
+	}
+	utils.SuccessResponse(r, webhook)
+}
+
+// Delete 删除订单 Webhook
+// @Summary 删除订单事件 Webhook
+// @Tags 订单Webhook
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} utils.Response "成功"
+// @Failure 404 {object} utils.Response "Webhook不存在"
+// @Router /api/v1/order-webhooks/{id} [delete]
+func (c *OrderWebhookController) Delete(r *ghttp.Request) {
+	id := r.Get("id").Uint64()
+	if id == 0 {
+		utils.ErrorResponse(r, 400, "Webhook ID不能为空")
+		return
+	}
+
+	if err := c.webhookService.Delete(r.GetCtx(), id); err != nil {
+		writeOrderWebhookError(r, err)
+		return
+	}
+	utils.SuccessResponse(r, nil)
+}
+
+// ListDeliveries 获取订单 Webhook 推送记录
+// @Summary 获取订单事件 Webhook 推送记录
+// @Tags 订单Webhook
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param limit query int false "返回条数" default(50)
+// @Success 200 {object} utils.Response{data=[]types.OrderWebhookDelivery} "成功"
+// @Failure 404 {object} utils.Response "Webhook不存在"
+// @Router /api/v1/order-webhooks/{id}/deliveries [get]
+func (c *OrderWebhookController) ListDeliveries(r *ghttp.Request) {
+	id := r.Get("id").Uint64()
+	if id == 0 {
+		utils.ErrorResponse(r, 400, "Webhook ID不能为空")
+		return
+	}
+
+	deliveries, err := c.webhookService.ListDeliveries(r.GetCtx(), id, r.Get("limit", 50).Int())
+	if err != nil {
+		writeOrderWebhookError(r, err)
+		return
+	}
+	utils.SuccessResponse(r, deliveries)
+}
+
+// TestFire 向订单 Webhook 发送测试事件
+// @Summary 测试推送订单事件 Webhook
+// @Description 立即推送一条 webhook.test 事件，返回推送结果
+// @Tags 订单Webhook
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} utils.Response{data=types.OrderWebhookDelivery} "成功"
+// @Failure 404 {object} utils.Response "Webhook不存在"
+// @Router /api/v1/order-webhooks/{id}/test [post]
+func (c *OrderWebhookController) TestFire(r *ghttp.Request) {
+	id := r.Get("id").Uint64()
+	if id == 0 {
+		utils.ErrorResponse(r, 400, "Webhook ID不能为空")
+		return
+	}
+
+	delivery, err := c.webhookService.TestFire(r.GetCtx(), id)
+	if err != nil {
+		writeOrderWebhookError(r, err)
+		return
+	}
+	utils.SuccessResponse(r, delivery)
+}
+
+// writeOrderWebhookError 按错误类型返回对应的 HTTP 状态码
+func writeOrderWebhookError(r *ghttp.Request, err error) {
+	if errors.Is(err, service.ErrOrderWebhookNotFound) {
+		utils.ErrorResponse(r, 404, err.Error())
+		return
+	}
+	g.Log().Error(r.GetCtx(), "订单Webhook操作失败", "error", err)
+	utils.ErrorResponse(r, 500, err.Error())
+}
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"errors"
+
 	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
@@ -40,13 +42,13 @@ func (c *PaymentController) InitiatePayment(r *ghttp.Request) {
 		return
 	}
 
+	if req.Sandbox != nil && !c.applySandboxOutcome(r, orderID, req.Sandbox) {
+		return
+	}
+
 	payment, err := c.paymentService.InitiatePayment(r.Context(), orderID, req.PaymentMethod, req.ReturnURL)
 	if err != nil {
-		r.Response.WriteJsonExit(g.Map{
-			"code":    500,
-			"message": "发起支付失败",
-			"error":   err.Error(),
-		})
+		writePaymentError(r, "发起支付失败", err)
 		return
 	}
 
@@ -57,6 +59,37 @@ func (c *PaymentController) InitiatePayment(r *ghttp.Request) {
 	})
 }
 
+// writePaymentError 输出支付失败响应，商户不接受的支付方式属于请求错误，同时返回可用支付方式；
+// 订单风险审核中返回 409
+func writePaymentError(r *ghttp.Request, message string, err error) {
+	var methodErr *service.PaymentMethodNotAllowedError
+	if errors.As(err, &methodErr) {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": message,
+			"error":   err.Error(),
+			"data": g.Map{
+				"available_payment_methods": methodErr.Allowed,
+			},
+		})
+		return
+	}
+	if errors.Is(err, types.ErrOrderUnderReview) {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    409,
+			"message": message,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    500,
+		"message": message,
+		"error":   err.Error(),
+	})
+}
+
 // GetPaymentStatus 查询支付状态
 func (c *PaymentController) GetPaymentStatus(r *ghttp.Request) {
 	orderID := r.Get("order_id").Uint64()
@@ -107,23 +140,80 @@ func (c *PaymentController) RetryPayment(r *ghttp.Request) {
 		return
 	}
 
+	if req.Sandbox != nil && !c.applySandboxOutcome(r, orderID, req.Sandbox) {
+		return
+	}
+
 	payment, err := c.paymentService.RetryPayment(r.Context(), orderID, req.PaymentMethod, req.ReturnURL)
 	if err != nil {
+		writePaymentError(r, "重新支付失败", err)
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "重新支付发起成功",
+		"data":    payment,
+	})
+}
+
+// SetSandboxOutcome 预设订单的沙箱支付结果（仅沙箱模式下注册该路由）
+func (c *PaymentController) SetSandboxOutcome(r *ghttp.Request) {
+	orderID := r.Get("order_id").Uint64()
+	if orderID == 0 {
 		r.Response.WriteJsonExit(g.Map{
-			"code":    500,
-			"message": "重新支付失败",
+			"code":    400,
+			"message": "订单ID不能为空",
+		})
+		return
+	}
+
+	var req types.SandboxPaymentOutcome
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数错误",
 			"error":   err.Error(),
 		})
 		return
 	}
 
+	if !c.applySandboxOutcome(r, orderID, &req) {
+		return
+	}
+
 	r.Response.WriteJsonExit(g.Map{
 		"code":    0,
-		"message": "重新支付发起成功",
-		"data":    payment,
+		"message": "沙箱支付结果设置成功",
 	})
 }
 
+// applySandboxOutcome 设置沙箱支付结果，失败时直接输出错误响应并返回false
+func (c *PaymentController) applySandboxOutcome(r *ghttp.Request, orderID uint64, outcome *types.SandboxPaymentOutcome) bool {
+	if !c.paymentService.SandboxEnabled() {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    403,
+			"message": "支付沙箱未启用",
+		})
+		return false
+	}
+
+	if err := c.paymentService.SetSandboxOutcome(r.Context(), orderID, outcome); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "设置沙箱支付结果失败",
+			"error":   err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// SandboxEnabled 是否处于支付沙箱模式
+func (c *PaymentController) SandboxEnabled() bool {
+	return c.paymentService.SandboxEnabled()
+}
+
 // AlipayCallback 支付宝支付回调
 func (c *PaymentController) AlipayCallback(r *ghttp.Request) {
 	// 获取回调数据
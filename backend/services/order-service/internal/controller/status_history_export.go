@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// StatusHistoryExportController 订单状态历史导出控制器
+type StatusHistoryExportController struct {
+	exportService service.IStatusHistoryExportService
+}
+
+// NewStatusHistoryExportController 创建订单状态历史导出控制器实例
+func NewStatusHistoryExportController(exportService service.IStatusHistoryExportService) *StatusHistoryExportController {
+	return &StatusHistoryExportController{
+		exportService: exportService,
+	}
+}
+
+// Export 导出时间范围内的订单状态历史
+// @Summary 导出订单状态历史
+// @Description 按时间范围导出当前租户的订单状态变更记录，可按商户和变更后状态过滤，可选择导出列及顺序；导出任务进入导出队列后台生成，通过 /exports/{id} 查询进度并下载
+// @Tags 订单状态管理
+// @Produce json
+// @Param start_date query string true "开始日期 YYYY-MM-DD"
+// @Param end_date query string true "结束日期 YYYY-MM-DD（含）"
+// @Param merchant_id query int false "商户ID"
+// @Param status query string false "变更后状态：pending/paid/processing/completed/cancelled"
+// @Param format query string false "导出格式：csv/excel，默认csv"
+// @Param fields query string false "导出列及顺序，逗号分隔，如 order_number,total_amount,to_status,created_at；默认导出标准列"
+// @Success 202 {object} utils.Response{data=types.ExportJob} "已创建导出任务"
+// @Failure 400 {object} utils.Response
+// @Failure 429 {object} utils.Response "超出当天导出用量"
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/orders/status-history/export [get]
+func (c *StatusHistoryExportController) Export(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	query, err := parseStatusHistoryExportQuery(r)
+	if err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+	if err := c.exportService.PrepareQuery(ctx, query); err != nil {
+		utils.ErrorResponse(r, 400, "参数验证失败: "+err.Error())
+		return
+	}
+
+	job, err := c.exportService.CreateExport(ctx, query)
+	if err != nil {
+		writeExportError(r, err)
+		return
+	}
+	r.Response.WriteStatus(http.StatusAccepted)
+	utils.SuccessResponse(r, job)
+}
+
+// parseStatusHistoryExportQuery 解析导出条件，结束日期当天的记录也会导出
+func parseStatusHistoryExportQuery(r *ghttp.Request) (*types.OrderStatusHistoryExportQuery, error) {
+	startDate, err := time.ParseInLocation("2006-01-02", r.Get("start_date").String(), time.Local)
+	if err != nil {
+		return nil, errors.New("开始日期格式错误，应为 YYYY-MM-DD")
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", r.Get("end_date").String(), time.Local)
+	if err != nil {
+		return nil, errors.New("结束日期格式错误，应为 YYYY-MM-DD")
+	}
+
+	query := &types.OrderStatusHistoryExportQuery{
+		StartDate: startDate,
+		EndDate:   endDate.AddDate(0, 0, 1),
+		Format:    types.OrderExportFormat(r.Get("format").String()),
+		Fields:    types.ParseOrderExportFields(r.Get("fields").String()),
+	}
+	if merchantID := r.Get("merchant_id").Uint64(); merchantID > 0 {
+		query.MerchantID = &merchantID
+	}
+	if statusName := r.Get("status").String(); statusName != "" {
+		status, ok := types.OrderStatus(statusName).ToOrderStatusInt()
+		if !ok {
+			return nil, fmt.Errorf("无效的订单状态: %s", statusName)
+		}
+		query.Status = &status
+	}
+
+	return query, nil
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/oss"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+const (
+	defaultAttachmentMaxSize   = 10 * 1024 * 1024
+	defaultAttachmentURLExpiry = 10 * time.Minute
+)
+
+// defaultAttachmentTypes 默认允许上传的附件类型：扫描件、截图和 PDF 凭证
+var defaultAttachmentTypes = []string{"application/pdf", "image/jpeg", "image/png"}
+
+var (
+	// ErrAttachmentNotFound 附件不存在
+	ErrAttachmentNotFound = errors.New("附件不存在")
+	// ErrAttachmentForbidden 无权访问附件或其关联的记录
+	ErrAttachmentForbidden = errors.New("无权访问该附件")
+	// ErrAttachmentTooLarge 附件超过大小限制
+	ErrAttachmentTooLarge = errors.New("附件大小超过限制")
+	// ErrAttachmentTypeNotAllowed 附件类型不允许上传
+	ErrAttachmentTypeNotAllowed = errors.New("不支持的附件类型")
+	// ErrAttachmentRejected 附件未通过安全扫描
+	ErrAttachmentRejected = errors.New("附件未通过安全扫描")
+	// ErrAttachmentAlreadyLinked 附件已关联到其他记录
+	ErrAttachmentAlreadyLinked = errors.New("附件已关联到其他记录")
+	// ErrAttachmentInvalidTarget 关联的记录类型无效或记录不存在
+	ErrAttachmentInvalidTarget = errors.New("关联记录无效")
+)
+
+// AttachmentStorage 附件对象存储，生产环境使用 OSS
+type AttachmentStorage interface {
+	UploadFromBytes(ctx context.Context, data []byte, fileName, contentType string, options *oss.UploadOptions) (*oss.UploadFileInfo, error)
+	GetSignedURL(ctx context.Context, objectKey string, expiration time.Duration) (string, error)
+}
+
+// AttachmentScanner 附件安全扫描钩子，返回错误时拒绝上传。
+// 默认不做扫描，接入杀毒引擎时替换实现即可
+type AttachmentScanner interface {
+	Scan(ctx context.Context, fileName string, data []byte) error
+}
+
+// noopAttachmentScanner 不做任何检查的扫描器
+type noopAttachmentScanner struct{}
+
+func (noopAttachmentScanner) Scan(ctx context.Context, fileName string, data []byte) error {
+	return nil
+}
+
+// AttachmentConfig 附件上传限制
+type AttachmentConfig struct {
+	MaxSize      int64         // 单个附件大小上限（字节）
+	AllowedTypes []string      // 允许的文件类型，按文件内容识别
+	URLExpiry    time.Duration // 下载链接有效期
+}
+
+// LoadAttachmentConfig 从 order.attachments 配置加载附件上传限制
+func LoadAttachmentConfig(ctx context.Context) *AttachmentConfig {
+	config := &AttachmentConfig{
+		MaxSize:      g.Cfg().MustGet(ctx, "order.attachments.maxSize", defaultAttachmentMaxSize).Int64(),
+		AllowedTypes: g.Cfg().MustGet(ctx, "order.attachments.allowedTypes", defaultAttachmentTypes).Strings(),
+		URLExpiry:    g.Cfg().MustGet(ctx, "order.attachments.urlExpiry", defaultAttachmentURLExpiry).Duration(),
+	}
+	return config.withDefaults()
+}
+
+func (c *AttachmentConfig) withDefaults() *AttachmentConfig {
+	result := *c
+	if result.MaxSize <= 0 {
+		result.MaxSize = defaultAttachmentMaxSize
+	}
+	if len(result.AllowedTypes) == 0 {
+		result.AllowedTypes = defaultAttachmentTypes
+	}
+	if result.URLExpiry <= 0 {
+		result.URLExpiry = defaultAttachmentURLExpiry
+	}
+	return &result
+}
+
+// isAllowedType 检查文件类型是否允许上传
+func (c *AttachmentConfig) isAllowedType(contentType string) bool {
+	for _, allowed := range c.AllowedTypes {
+		if contentType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// IAttachmentService 附件服务接口
+type IAttachmentService interface {
+	Upload(ctx context.Context, fileName string, data []byte) (*types.Attachment, error)
+	Link(ctx context.Context, attachmentID uint64, req *types.LinkAttachmentRequest) (*types.Attachment, error)
+	ListByTarget(ctx context.Context, targetType types.AttachmentTargetType, targetID string) ([]types.Attachment, error)
+	GetDownloadURL(ctx context.Context, attachmentID uint64) (string, error)
+}
+
+// AttachmentService 退款、审计事件等记录的证明材料附件服务
+type AttachmentService struct {
+	attachmentRepo repository.IAttachmentRepository
+	orderRepo      repository.IOrderRepository
+	storage        AttachmentStorage
+	scanner        AttachmentScanner
+	config         *AttachmentConfig
+}
+
+// NewAttachmentService 创建附件服务实例
+func NewAttachmentService() IAttachmentService {
+	return &AttachmentService{
+		attachmentRepo: repository.NewAttachmentRepository(),
+		orderRepo:      repository.NewOrderRepository(),
+		storage:        oss.NewOSSService(),
+		scanner:        noopAttachmentScanner{},
+		config:         LoadAttachmentConfig(context.Background()),
+	}
+}
+
+// NewAttachmentServiceForTest 创建测试用附件服务实例
+func NewAttachmentServiceForTest(attachmentRepo repository.IAttachmentRepository, orderRepo repository.IOrderRepository, storage AttachmentStorage, scanner AttachmentScanner, config *AttachmentConfig) IAttachmentService {
+	if scanner == nil {
+		scanner = noopAttachmentScanner{}
+	}
+	if config == nil {
+		config = &AttachmentConfig{}
+	}
+	return &AttachmentService{
+		attachmentRepo: attachmentRepo,
+		orderRepo:      orderRepo,
+		storage:        storage,
+		scanner:        scanner,
+		config:         config.withDefaults(),
+	}
+}
+
+// Upload 上传附件。附件上传后尚未关联记录，只有上传者本人可以关联或下载
+func (s *AttachmentService) Upload(ctx context.Context, fileName string, data []byte) (*types.Attachment, error) {
+	uploaderID := gconv.Uint64(ctx.Value("user_id"))
+	if uploaderID == 0 {
+		return nil, fmt.Errorf("缺少上传者信息")
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("附件内容不能为空")
+	}
+	if int64(len(data)) > s.config.MaxSize {
+		return nil, fmt.Errorf("%w: 最大 %d 字节", ErrAttachmentTooLarge, s.config.MaxSize)
+	}
+
+	// 按文件内容识别类型，不信任客户端声明的类型和扩展名
+	contentType := strings.TrimSpace(strings.Split(http.DetectContentType(data), ";")[0])
+	if !s.config.isAllowedType(contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrAttachmentTypeNotAllowed, contentType)
+	}
+
+	if err := s.scanner.Scan(ctx, fileName, data); err != nil {
+		g.Log().Warning(ctx, "附件未通过安全扫描", "file_name", fileName, "uploader_id", uploaderID, "error", err)
+		return nil, fmt.Errorf("%w: %v", ErrAttachmentRejected, err)
+	}
+
+	tenantID := gconv.Uint64(ctx.Value("tenant_id"))
+	info, err := s.storage.UploadFromBytes(ctx, data, fileName, contentType, &oss.UploadOptions{
+		Directory:    fmt.Sprintf("attachments/%d", tenantID),
+		FileName:     guid.S() + strings.ToLower(filepath.Ext(fileName)),
+		AllowedTypes: s.config.AllowedTypes,
+		MaxSize:      s.config.MaxSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存附件失败: %v", err)
+	}
+
+	checksum := sha256.Sum256(data)
+	attachment := &types.Attachment{
+		MerchantID:  gconv.Uint64(ctx.Value("merchant_id")),
+		FileName:    filepath.Base(fileName),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Checksum:    hex.EncodeToString(checksum[:]),
+		ObjectKey:   info.ObjectKey,
+		UploadedBy:  uploaderID,
+	}
+	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// Link 将上传者本人上传的附件关联到退款或审计事件，审计日志只记录附件引用
+func (s *AttachmentService) Link(ctx context.Context, attachmentID uint64, req *types.LinkAttachmentRequest) (*types.Attachment, error) {
+	attachment, err := s.getAttachment(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.IsLinked() {
+		return nil, ErrAttachmentAlreadyLinked
+	}
+	if attachment.UploadedBy != gconv.Uint64(ctx.Value("user_id")) {
+		return nil, ErrAttachmentForbidden
+	}
+	if err := s.checkTargetAccess(ctx, req.TargetType, req.TargetID); err != nil {
+		return nil, err
+	}
+
+	if err := s.attachmentRepo.Link(ctx, attachment.ID, req.TargetType, req.TargetID); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	attachment.TargetType = req.TargetType
+	attachment.TargetID = req.TargetID
+	attachment.LinkedAt = &now
+
+	audit.LogOperation(ctx, "attachment", "link", map[string]interface{}{
+		"target_type": req.TargetType,
+		"target_id":   req.TargetID,
+		"attachment":  attachment.Reference(),
+	})
+
+	return attachment, nil
+}
+
+// ListByTarget 获取记录关联的附件
+func (s *AttachmentService) ListByTarget(ctx context.Context, targetType types.AttachmentTargetType, targetID string) ([]types.Attachment, error) {
+	if err := s.checkTargetAccess(ctx, targetType, targetID); err != nil {
+		return nil, err
+	}
+
+	return s.attachmentRepo.ListByTarget(ctx, targetType, targetID)
+}
+
+// GetDownloadURL 获取附件的临时下载链接
+func (s *AttachmentService) GetDownloadURL(ctx context.Context, attachmentID uint64) (string, error) {
+	attachment, err := s.getAttachment(ctx, attachmentID)
+	if err != nil {
+		return "", err
+	}
+
+	if attachment.IsLinked() {
+		err = s.checkTargetAccess(ctx, attachment.TargetType, attachment.TargetID)
+	} else if attachment.UploadedBy != gconv.Uint64(ctx.Value("user_id")) {
+		err = ErrAttachmentForbidden
+	}
+	if err != nil {
+		return "", err
+	}
+
+	url, err := s.storage.GetSignedURL(ctx, attachment.ObjectKey, s.config.URLExpiry)
+	if err != nil {
+		return "", fmt.Errorf("生成附件下载链接失败: %v", err)
+	}
+
+	audit.LogOperation(ctx, "attachment", "download", map[string]interface{}{
+		"attachment": attachment.Reference(),
+	})
+
+	return url, nil
+}
+
+// getAttachment 获取当前租户的附件
+func (s *AttachmentService) getAttachment(ctx context.Context, attachmentID uint64) (*types.Attachment, error) {
+	attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, nil
+}
+
+// checkTargetAccess 校验调用方可以访问关联记录：需要具备该类记录的权限，
+// 商户用户只能访问本商户订单的退款，审计事件仅限租户员工
+func (s *AttachmentService) checkTargetAccess(ctx context.Context, targetType types.AttachmentTargetType, targetID string) error {
+	if !targetType.IsValid() {
+		return fmt.Errorf("%w: 不支持的关联类型 %s", ErrAttachmentInvalidTarget, targetType)
+	}
+	if !hasAnyPermission(ctx, targetType.RequiredPermissions()) {
+		return ErrAttachmentForbidden
+	}
+
+	merchantID := gconv.Uint64(ctx.Value("merchant_id"))
+	switch targetType {
+	case types.AttachmentTargetRefund:
+		orderID, err := strconv.ParseUint(targetID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: 订单ID格式错误", ErrAttachmentInvalidTarget)
+		}
+		order, err := s.orderRepo.GetByID(ctx, orderID)
+		if err != nil || order == nil {
+			return fmt.Errorf("%w: 订单不存在", ErrAttachmentInvalidTarget)
+		}
+		if merchantID > 0 && order.MerchantID != merchantID {
+			return ErrAttachmentForbidden
+		}
+	case types.AttachmentTargetAuditEvent:
+		if merchantID > 0 {
+			return ErrAttachmentForbidden
+		}
+	}
+	return nil
+}
+
+// hasAnyPermission 当前用户是否具备任一权限
+func hasAnyPermission(ctx context.Context, permissions []types.Permission) bool {
+	for _, permission := range permissions {
+		if middleware.HasPermissionInContext(ctx, permission) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/oss"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeAttachmentRepository 内存附件仓储
+type fakeAttachmentRepository struct {
+	attachments map[uint64]*types.Attachment
+}
+
+func (f *fakeAttachmentRepository) Create(ctx context.Context, attachment *types.Attachment) error {
+	attachment.ID = uint64(len(f.attachments) + 1)
+	stored := *attachment
+	f.attachments[attachment.ID] = &stored
+	return nil
+}
+
+func (f *fakeAttachmentRepository) GetByID(ctx context.Context, id uint64) (*types.Attachment, error) {
+	attachment, exists := f.attachments[id]
+	if !exists {
+		return nil, nil
+	}
+	copied := *attachment
+	return &copied, nil
+}
+
+func (f *fakeAttachmentRepository) Link(ctx context.Context, id uint64, targetType types.AttachmentTargetType, targetID string) error {
+	attachment, exists := f.attachments[id]
+	if !exists || attachment.IsLinked() {
+		return fmt.Errorf("附件不存在或已关联")
+	}
+	now := time.Now()
+	attachment.TargetType = targetType
+	attachment.TargetID = targetID
+	attachment.LinkedAt = &now
+	return nil
+}
+
+func (f *fakeAttachmentRepository) ListByTarget(ctx context.Context, targetType types.AttachmentTargetType, targetID string) ([]types.Attachment, error) {
+	var result []types.Attachment
+	for id := uint64(1); id <= uint64(len(f.attachments)); id++ {
+		attachment := f.attachments[id]
+		if attachment.TargetType == targetType && attachment.TargetID == targetID {
+			result = append(result, *attachment)
+		}
+	}
+	return result, nil
+}
+
+// fakeAttachmentStorage 内存对象存储
+type fakeAttachmentStorage struct {
+	objects map[string][]byte
+}
+
+func (f *fakeAttachmentStorage) UploadFromBytes(ctx context.Context, data []byte, fileName, contentType string, options *oss.UploadOptions) (*oss.UploadFileInfo, error) {
+	objectKey := options.Directory + "/" + options.FileName
+	f.objects[objectKey] = data
+	return &oss.UploadFileInfo{FileName: options.FileName, ContentType: contentType, Size: int64(len(data)), ObjectKey: objectKey}, nil
+}
+
+func (f *fakeAttachmentStorage) GetSignedURL(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	return "https://storage.example.com/" + objectKey + "?signature=test", nil
+}
+
+// rejectingScanner 拒绝包含测试病毒特征的文件
+type rejectingScanner struct{}
+
+func (rejectingScanner) Scan(ctx context.Context, fileName string, data []byte) error {
+	if len(data) > 0 && string(data[len(data)-5:]) == "EICAR" {
+		return errors.New("检测到病毒特征")
+	}
+	return nil
+}
+
+var testPDF = []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\ntrailer\n<<>>\n%%EOF")
+
+func TestAttachmentService(t *testing.T) {
+	Convey("附件服务测试", t, func() {
+		orderRepo := &fakeNoteOrderRepository{
+			orders: map[uint64]*types.Order{
+				1: {ID: 1, TenantID: 1, MerchantID: 10, CustomerID: 100},
+			},
+		}
+		attachmentRepo := &fakeAttachmentRepository{attachments: map[uint64]*types.Attachment{}}
+		storage := &fakeAttachmentStorage{objects: map[string][]byte{}}
+		attachmentService := NewAttachmentServiceForTest(attachmentRepo, orderRepo, storage, rejectingScanner{},
+			&AttachmentConfig{MaxSize: 1024})
+
+		tenantCtx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		staffCtx := context.WithValue(tenantCtx, "user_id", uint64(5))
+		staffCtx = context.WithValue(staffCtx, "permissions", []types.Permission{types.PermissionOrderUpdate})
+
+		Convey("上传附件按内容识别类型并记录校验和", func() {
+			attachment, err := attachmentService.Upload(staffCtx, "退款凭证.pdf", testPDF)
+			So(err, ShouldBeNil)
+			So(attachment.ContentType, ShouldEqual, "application/pdf")
+			So(attachment.Size, ShouldEqual, len(testPDF))
+			So(attachment.Checksum, ShouldHaveLength, 64)
+			So(attachment.UploadedBy, ShouldEqual, 5)
+			So(attachment.IsLinked(), ShouldBeFalse)
+			So(storage.objects[attachment.ObjectKey], ShouldResemble, testPDF)
+		})
+
+		Convey("不符合要求的文件被拒绝", func() {
+			_, err := attachmentService.Upload(staffCtx, "说明.pdf", []byte("只是一段文本"))
+			So(errors.Is(err, ErrAttachmentTypeNotAllowed), ShouldBeTrue)
+
+			_, err = attachmentService.Upload(staffCtx, "大文件.pdf", append(append([]byte{}, testPDF...), make([]byte, 1024)...))
+			So(errors.Is(err, ErrAttachmentTooLarge), ShouldBeTrue)
+
+			_, err = attachmentService.Upload(staffCtx, "病毒.pdf", append(append([]byte{}, testPDF...), []byte("EICAR")...))
+			So(errors.Is(err, ErrAttachmentRejected), ShouldBeTrue)
+			So(storage.objects, ShouldBeEmpty)
+		})
+
+		Convey("关联到退款后可以按记录查询和下载", func() {
+			attachment, err := attachmentService.Upload(staffCtx, "退款凭证.pdf", testPDF)
+			So(err, ShouldBeNil)
+
+			linked, err := attachmentService.Link(staffCtx, attachment.ID, &types.LinkAttachmentRequest{
+				TargetType: types.AttachmentTargetRefund,
+				TargetID:   "1",
+			})
+			So(err, ShouldBeNil)
+			So(linked.IsLinked(), ShouldBeTrue)
+
+			attachments, err := attachmentService.ListByTarget(staffCtx, types.AttachmentTargetRefund, "1")
+			So(err, ShouldBeNil)
+			So(attachments, ShouldHaveLength, 1)
+			So(attachments[0].ID, ShouldEqual, attachment.ID)
+
+			url, err := attachmentService.GetDownloadURL(staffCtx, attachment.ID)
+			So(err, ShouldBeNil)
+			So(url, ShouldContainSubstring, attachment.ObjectKey)
+
+			_, err = attachmentService.Link(staffCtx, attachment.ID, &types.LinkAttachmentRequest{
+				TargetType: types.AttachmentTargetRefund,
+				TargetID:   "1",
+			})
+			So(errors.Is(err, ErrAttachmentAlreadyLinked), ShouldBeTrue)
+		})
+
+		Convey("关联到不存在的记录被拒绝", func() {
+			attachment, _ := attachmentService.Upload(staffCtx, "退款凭证.pdf", testPDF)
+
+			_, err := attachmentService.Link(staffCtx, attachment.ID, &types.LinkAttachmentRequest{
+				TargetType: types.AttachmentTargetRefund,
+				TargetID:   "99",
+			})
+			So(errors.Is(err, ErrAttachmentInvalidTarget), ShouldBeTrue)
+
+			_, err = attachmentService.Link(staffCtx, attachment.ID, &types.LinkAttachmentRequest{
+				TargetType: "product",
+				TargetID:   "1",
+			})
+			So(errors.Is(err, ErrAttachmentInvalidTarget), ShouldBeTrue)
+		})
+
+		Convey("访问控制", func() {
+			attachment, _ := attachmentService.Upload(staffCtx, "退款凭证.pdf", testPDF)
+
+			Convey("未关联的附件只有上传者可以下载和关联", func() {
+				otherStaffCtx := context.WithValue(staffCtx, "user_id", uint64(6))
+				_, err := attachmentService.GetDownloadURL(otherStaffCtx, attachment.ID)
+				So(errors.Is(err, ErrAttachmentForbidden), ShouldBeTrue)
+
+				_, err = attachmentService.Link(otherStaffCtx, attachment.ID, &types.LinkAttachmentRequest{
+					TargetType: types.AttachmentTargetRefund,
+					TargetID:   "1",
+				})
+				So(errors.Is(err, ErrAttachmentForbidden), ShouldBeTrue)
+			})
+
+			_, err := attachmentService.Link(staffCtx, attachment.ID, &types.LinkAttachmentRequest{
+				TargetType: types.AttachmentTargetRefund,
+				TargetID:   "1",
+			})
+			So(err, ShouldBeNil)
+
+			Convey("本商户用户可以查看，其他商户用户不能查看", func() {
+				merchantCtx := context.WithValue(tenantCtx, "user_id", uint64(7))
+				merchantCtx = context.WithValue(merchantCtx, "merchant_id", uint64(10))
+				merchantCtx = context.WithValue(merchantCtx, "permissions", []types.Permission{types.PermissionMerchantOrderProcess})
+
+				_, err := attachmentService.GetDownloadURL(merchantCtx, attachment.ID)
+				So(err, ShouldBeNil)
+
+				otherMerchantCtx := context.WithValue(merchantCtx, "merchant_id", uint64(20))
+				_, err = attachmentService.GetDownloadURL(otherMerchantCtx, attachment.ID)
+				So(errors.Is(err, ErrAttachmentForbidden), ShouldBeTrue)
+
+				_, err = attachmentService.ListByTarget(otherMerchantCtx, types.AttachmentTargetRefund, "1")
+				So(errors.Is(err, ErrAttachmentForbidden), ShouldBeTrue)
+			})
+
+			Convey("缺少权限的用户不能查看", func() {
+				customerCtx := context.WithValue(tenantCtx, "user_id", uint64(100))
+				_, err := attachmentService.GetDownloadURL(customerCtx, attachment.ID)
+				So(errors.Is(err, ErrAttachmentForbidden), ShouldBeTrue)
+			})
+
+			Convey("其他租户看不到附件", func() {
+				_, err := attachmentService.GetDownloadURL(staffCtx, 99)
+				So(errors.Is(err, ErrAttachmentNotFound), ShouldBeTrue)
+			})
+		})
+
+		Convey("审计事件附件仅限具备审计权限的租户员工", func() {
+			auditorCtx := context.WithValue(tenantCtx, "user_id", uint64(8))
+			auditorCtx = context.WithValue(auditorCtx, "permissions", []types.Permission{types.PermissionSystemAudit})
+			attachment, _ := attachmentService.Upload(auditorCtx, "操作授权书.png", []byte("\x89PNG\r\n\x1a\n0000"))
+			So(attachment.ContentType, ShouldEqual, "image/png")
+
+			req := &types.LinkAttachmentRequest{TargetType: types.AttachmentTargetAuditEvent, TargetID: "evt-1"}
+			_, err := attachmentService.Link(auditorCtx, attachment.ID, req)
+			So(err, ShouldBeNil)
+
+			_, err = attachmentService.ListByTarget(staffCtx, types.AttachmentTargetAuditEvent, "evt-1")
+			So(errors.Is(err, ErrAttachmentForbidden), ShouldBeTrue)
+
+			merchantAuditorCtx := context.WithValue(auditorCtx, "merchant_id", uint64(10))
+			_, err = attachmentService.ListByTarget(merchantAuditorCtx, types.AttachmentTargetAuditEvent, "evt-1")
+			So(errors.Is(err, ErrAttachmentForbidden), ShouldBeTrue)
+
+			attachments, err := attachmentService.ListByTarget(auditorCtx, types.AttachmentTargetAuditEvent, "evt-1")
+			So(err, ShouldBeNil)
+			So(attachments, ShouldHaveLength, 1)
+		})
+	})
+}
@@ -12,7 +12,8 @@ import (
 type ICartService interface {
 	GetCart(ctx context.Context, customerID uint64) (*types.Cart, error)
 	AddItem(ctx context.Context, customerID uint64, productID uint64, quantity int) error
-	UpdateItemQuantity(ctx context.Context, itemID uint64, quantity int) error
+	AddBundle(ctx context.Context, customerID uint64, bundleID uint64, quantity int) error
+	UpdateItemQuantity(ctx context.Context, itemID uint64, quantity int, version int) error
 	RemoveItem(ctx context.Context, itemID uint64) error
 	ClearCart(ctx context.Context, customerID uint64) error
 	GetCartWithProductDetails(ctx context.Context, customerID uint64) (*types.Cart, error)
@@ -20,13 +21,34 @@ type ICartService interface {
 
 // CartService 购物车服务实现
 type CartService struct {
-	cartRepo repository.ICartRepository
+	cartRepo    repository.ICartRepository
+	productRepo repository.IProductAvailabilityRepository
+	bundleRepo  repository.IProductBundleRepository
 }
 
 // NewCartService 创建购物车服务实例
 func NewCartService() ICartService {
 	return &CartService{
-		cartRepo: repository.NewCartRepository(),
+		cartRepo:    repository.NewCartRepository(),
+		productRepo: repository.NewProductAvailabilityRepository(),
+		bundleRepo:  repository.NewProductBundleRepository(),
+	}
+}
+
+// NewCartServiceForTest 创建测试用购物车服务实例
+func NewCartServiceForTest(cartRepo repository.ICartRepository, productRepo repository.IProductAvailabilityRepository) ICartService {
+	return &CartService{
+		cartRepo:    cartRepo,
+		productRepo: productRepo,
+	}
+}
+
+// NewCartBundleServiceForTest 创建测试用购物车服务实例（用于套餐）
+func NewCartBundleServiceForTest(cartRepo repository.ICartRepository, productRepo repository.IProductAvailabilityRepository, bundleRepo repository.IProductBundleRepository) ICartService {
+	return &CartService{
+		cartRepo:    cartRepo,
+		productRepo: productRepo,
+		bundleRepo:  bundleRepo,
 	}
 }
 
@@ -41,26 +63,91 @@ func (s *CartService) AddItem(ctx context.Context, customerID uint64, productID
 		return fmt.Errorf("商品数量必须大于0")
 	}
 
-	// TODO: 调用Product Service验证商品是否存在和可用
-	// 暂时跳过商品验证
-
 	// 获取或创建购物车
 	cart, err := s.cartRepo.GetOrCreate(ctx, customerID)
 	if err != nil {
 		return fmt.Errorf("获取购物车失败: %v", err)
 	}
 
-	// 添加商品到购物车
-	return s.cartRepo.AddItem(ctx, cart.ID, productID, quantity)
+	// 添加商品到购物车，在锁定购物车期间按累加后的数量校验购买数量限制和库存
+	return s.cartRepo.AddItem(ctx, cart.ID, productID, quantity, s.validateItemQuantity)
+}
+
+// AddBundle 添加套餐到购物车，购物车中已有的同一套餐累加数量
+func (s *CartService) AddBundle(ctx context.Context, customerID uint64, bundleID uint64, quantity int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("套餐数量必须大于0")
+	}
+
+	cart, err := s.cartRepo.GetOrCreate(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("获取购物车失败: %v", err)
+	}
+
+	// 在锁定购物车期间按累加后的数量校验套餐的组成商品库存
+	return s.cartRepo.AddBundle(ctx, cart.ID, bundleID, quantity, s.validateItemQuantity)
+}
+
+// validateItemQuantity 按购物车中该商品修改后的总数量校验商品购买数量限制和库存，
+// 由仓储在锁定购物车期间调用，并发添加同一商品时不会超出库存
+func (s *CartService) validateItemQuantity(ctx context.Context, item types.CartItem) error {
+	if item.BundleID != 0 {
+		return s.validateBundleQuantity(ctx, item.BundleID, item.Quantity)
+	}
+	if s.productRepo == nil {
+		return nil
+	}
+
+	productID, quantity := item.ProductID, item.Quantity
+	products, err := s.productRepo.GetByIDs(ctx, []uint64{productID})
+	if err != nil {
+		return fmt.Errorf("获取商品信息失败: %v", err)
+	}
+	if len(products) == 0 {
+		return fmt.Errorf("商品不存在")
+	}
+
+	if limitErr := checkProductQuantity(&products[0], quantity); limitErr != nil {
+		return limitErr
+	}
+	return nil
+}
+
+// validateBundleQuantity 校验套餐在售且全部组成商品的可用库存足够购买 quantity 份
+func (s *CartService) validateBundleQuantity(ctx context.Context, bundleID uint64, quantity int) error {
+	if s.bundleRepo == nil || s.productRepo == nil {
+		return fmt.Errorf("%w: 套餐%d", types.ErrProductBundleNotFound, bundleID)
+	}
+
+	bundle, err := s.bundleRepo.GetByID(ctx, bundleID)
+	if err != nil {
+		return err
+	}
+	if bundle == nil {
+		return fmt.Errorf("%w: 套餐%d", types.ErrProductBundleNotFound, bundleID)
+	}
+	if !bundle.IsActive() {
+		return fmt.Errorf("%w: 套餐%d已停售", types.ErrProductBundleUnavailable, bundleID)
+	}
+
+	products, err := bundleProducts(ctx, s.productRepo, []types.ProductBundle{*bundle})
+	if err != nil {
+		return err
+	}
+	if availability := bundle.Availability(products, nil); !availability.Allows(quantity) {
+		return fmt.Errorf("%w: 套餐%d组成商品库存不足，可售%d份", types.ErrProductBundleUnavailable, bundleID, availability.Quantity)
+	}
+	return nil
 }
 
-// UpdateItemQuantity 更新购物车商品数量
-func (s *CartService) UpdateItemQuantity(ctx context.Context, itemID uint64, quantity int) error {
+// UpdateItemQuantity 更新购物车商品数量。version 为客户端读取到的购物车项版本，
+// 与当前版本不一致时返回 types.ErrCartItemVersionConflict；为 0 时以最后一次更新为准
+func (s *CartService) UpdateItemQuantity(ctx context.Context, itemID uint64, quantity int, version int) error {
 	if quantity <= 0 {
 		return fmt.Errorf("商品数量必须大于0")
 	}
 
-	return s.cartRepo.UpdateItemQuantity(ctx, itemID, quantity)
+	return s.cartRepo.UpdateItemQuantity(ctx, itemID, quantity, version, s.validateItemQuantity)
 }
 
 // RemoveItem 从购物车中移除商品
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	// 默认的购物车检查频率
+	defaultCartCheckInterval = 15 * time.Minute
+	// 每次检查最多处理的闲置购物车数，未处理完的在下次检查时继续
+	cartRecoveryBatchSize = 200
+)
+
+// CartRecoveryNotifier 购物车找回提醒
+type CartRecoveryNotifier interface {
+	SendCartRecoveryNotification(ctx context.Context, recovery *types.CartRecovery) error
+}
+
+// CartRecoveryService 购物车保留期与找回提醒服务：清空超过保留时间未变更的购物车，
+// 闲置超过配置时间的购物车在每个闲置期内向未退订的客户发送一次找回提醒
+type CartRecoveryService struct {
+	cartRepo       repository.ICartRepository
+	recoveryRepo   repository.ICartRecoveryRepository
+	productRepo    repository.IProductAvailabilityRepository
+	notifier       CartRecoveryNotifier
+	ttl            time.Duration
+	abandonedAfter time.Duration
+	interval       time.Duration
+	now            func() time.Time
+	stopCh         chan struct{}
+	isRunning      bool
+}
+
+// NewCartRecoveryService 创建购物车保留期与找回提醒服务实例
+func NewCartRecoveryService(notifier CartRecoveryNotifier) *CartRecoveryService {
+	ctx := context.Background()
+	ttl := g.Cfg().MustGet(ctx, "cart.ttl", types.DefaultCartTTL).Duration()
+	if ttl <= 0 {
+		ttl = types.DefaultCartTTL
+	}
+	abandonedAfter := g.Cfg().MustGet(ctx, "cart.abandonedAfter", types.DefaultCartAbandonedAfter).Duration()
+	if abandonedAfter <= 0 {
+		abandonedAfter = types.DefaultCartAbandonedAfter
+	}
+	interval := g.Cfg().MustGet(ctx, "cart.checkInterval", defaultCartCheckInterval).Duration()
+	if interval <= 0 {
+		interval = defaultCartCheckInterval
+	}
+	return &CartRecoveryService{
+		cartRepo:       repository.NewCartRepository(),
+		recoveryRepo:   repository.NewCartRecoveryRepository(),
+		productRepo:    repository.NewProductAvailabilityRepository(),
+		notifier:       notifier,
+		ttl:            ttl,
+		abandonedAfter: abandonedAfter,
+		interval:       interval,
+		now:            time.Now,
+		stopCh:         make(chan struct{}),
+	}
+}
+
+// NewCartRecoveryServiceForTest 创建测试用购物车保留期与找回提醒服务实例
+func NewCartRecoveryServiceForTest(cartRepo repository.ICartRepository, recoveryRepo repository.ICartRecoveryRepository, productRepo repository.IProductAvailabilityRepository, notifier CartRecoveryNotifier, ttl, abandonedAfter time.Duration, now func() time.Time) *CartRecoveryService {
+	return &CartRecoveryService{
+		cartRepo:       cartRepo,
+		recoveryRepo:   recoveryRepo,
+		productRepo:    productRepo,
+		notifier:       notifier,
+		ttl:            ttl,
+		abandonedAfter: abandonedAfter,
+		interval:       defaultCartCheckInterval,
+		now:            now,
+		stopCh:         make(chan struct{}),
+	}
+}
+
+// Start 启动后台购物车检查
+func (s *CartRecoveryService) Start(ctx context.Context) {
+	if s.isRunning {
+		return
+	}
+	s.isRunning = true
+	g.Log().Info(ctx, "启动购物车保留期与找回提醒检查",
+		"interval", s.interval,
+		"ttl", s.ttl,
+		"abandoned_after", s.abandonedAfter)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+				if _, err := s.ClearExpiredCarts(ctx); err != nil {
+					g.Log().Error(ctx, "清理过期购物车失败", "error", err)
+				}
+				if _, err := s.SendRecoveryNotifications(ctx); err != nil {
+					g.Log().Error(ctx, "发送购物车找回提醒失败", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止后台购物车检查
+func (s *CartRecoveryService) Stop(ctx context.Context) {
+	if !s.isRunning {
+		return
+	}
+	s.isRunning = false
+	close(s.stopCh)
+	g.Log().Info(ctx, "购物车保留期与找回提醒检查已停止")
+}
+
+// ClearExpiredCarts 删除所有租户中超过保留时间未变更的购物车，返回删除的购物车数
+func (s *CartRecoveryService) ClearExpiredCarts(ctx context.Context) (int, error) {
+	deleted, err := s.cartRepo.CleanExpiredCarts(ctx, s.now().Add(-s.ttl))
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		g.Log().Info(ctx, "已清理过期购物车", "count", deleted, "ttl", s.ttl)
+	}
+	return deleted, nil
+}
+
+// SendRecoveryNotifications 向闲置购物车的客户发送找回提醒，返回发送的提醒数。
+// 发送前按商品当前状态重新校验购物车商品；客户已退订或商品均已无法购买时不发送，
+// 但同样记录为已处理，本次闲置期内不再检查
+func (s *CartRecoveryService) SendRecoveryNotifications(ctx context.Context) (int, error) {
+	now := s.now()
+	carts, err := s.recoveryRepo.ListAbandonedCarts(ctx, now.Add(-s.abandonedAfter), cartRecoveryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range carts {
+		notified, err := s.recoverCart(ctx, &carts[i], now)
+		if err != nil {
+			g.Log().Error(ctx, "处理闲置购物车失败", "cart_id", carts[i].ID, "error", err)
+			continue
+		}
+		if notified {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// recoverCart 重新校验闲置购物车并发送找回提醒，返回是否发送
+func (s *CartRecoveryService) recoverCart(ctx context.Context, cart *types.Cart, now time.Time) (bool, error) {
+	// 定时任务没有请求上下文，按购物车所属租户设置租户上下文
+	tenantCtx := context.WithValue(ctx, "tenant_id", cart.TenantID)
+
+	items, err := s.cartRepo.GetCartItems(tenantCtx, cart.ID)
+	if err != nil {
+		return false, err
+	}
+	cart.Items = items
+	if !cart.RecoveryDue(now, s.abandonedAfter) {
+		return false, nil
+	}
+
+	preference, err := s.recoveryRepo.GetPreference(tenantCtx, cart.CustomerID)
+	if err != nil {
+		return false, err
+	}
+	optedOut := preference != nil && preference.OptedOut
+
+	var recovery *types.CartRecovery
+	if !optedOut {
+		recovery, err = s.revalidate(tenantCtx, cart, now)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// 先记录再发送，多个实例同时处理时只有记录成功的一方发送
+	marked, err := s.recoveryRepo.MarkRecoveryNotified(tenantCtx, cart, now)
+	if err != nil || !marked {
+		return false, err
+	}
+	if optedOut || len(recovery.Items) == 0 {
+		return false, nil
+	}
+
+	if s.notifier != nil {
+		if err := s.notifier.SendCartRecoveryNotification(tenantCtx, recovery); err != nil {
+			return false, err
+		}
+	}
+	g.Log().Info(ctx, "已发送购物车找回提醒",
+		"tenant_id", cart.TenantID,
+		"cart_id", cart.ID,
+		"customer_id", cart.CustomerID,
+		"items", len(recovery.Items),
+		"unavailable", len(recovery.Unavailable))
+	return true, nil
+}
+
+// revalidate 按商品当前状态、可售时间和库存重新校验购物车商品
+func (s *CartRecoveryService) revalidate(ctx context.Context, cart *types.Cart, now time.Time) (*types.CartRecovery, error) {
+	productIDs := make([]uint64, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	products := make(map[uint64]types.Product, len(productIDs))
+	if s.productRepo != nil {
+		found, err := s.productRepo.GetByIDs(ctx, productIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range found {
+			products[product.ID] = product
+		}
+	}
+	return types.BuildCartRecovery(cart, products, now), nil
+}
+
+// GetPreference 获取客户的找回提醒偏好，未设置时默认接收
+func (s *CartRecoveryService) GetPreference(ctx context.Context, customerID uint64) (*types.CartRecoveryPreference, error) {
+	preference, err := s.recoveryRepo.GetPreference(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if preference == nil {
+		preference = &types.CartRecoveryPreference{CustomerID: customerID}
+	}
+	return preference, nil
+}
+
+// UpdatePreference 客户设置是否接收购物车找回提醒
+func (s *CartRecoveryService) UpdatePreference(ctx context.Context, customerID uint64, req *types.UpdateCartRecoveryPreferenceRequest) (*types.CartRecoveryPreference, error) {
+	preference := &types.CartRecoveryPreference{
+		CustomerID: customerID,
+		OptedOut:   req.OptedOut,
+	}
+	if err := s.recoveryRepo.SavePreference(ctx, preference); err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
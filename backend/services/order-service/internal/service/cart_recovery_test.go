@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryCartStore 内存购物车存储，同时实现购物车仓储和找回提醒仓储
+type memoryCartStore struct {
+	carts       map[uint64]*types.Cart
+	preferences map[uint64]*types.CartRecoveryPreference
+}
+
+// memoryRecoveryCartRepository 内存购物车仓储
+type memoryRecoveryCartRepository struct {
+	repository.ICartRepository
+	store *memoryCartStore
+}
+
+func (r *memoryRecoveryCartRepository) GetCartItems(ctx context.Context, cartID uint64) ([]types.CartItem, error) {
+	return r.store.carts[cartID].Items, nil
+}
+
+func (r *memoryRecoveryCartRepository) CleanExpiredCarts(ctx context.Context, untouchedSince time.Time) (int, error) {
+	deleted := 0
+	for id, cart := range r.store.carts {
+		if cart.UpdatedAt.Before(untouchedSince) {
+			delete(r.store.carts, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// memoryCartRecoveryRepository 内存找回提醒仓储，按 SQL 实现的条件筛选和记录
+type memoryCartRecoveryRepository struct {
+	store *memoryCartStore
+}
+
+func (r *memoryCartRecoveryRepository) ListAbandonedCarts(ctx context.Context, idleSince time.Time, limit int) ([]types.Cart, error) {
+	var carts []types.Cart
+	for id := uint64(1); id <= 10; id++ {
+		cart, ok := r.store.carts[id]
+		if !ok || len(cart.Items) == 0 || !cart.UpdatedAt.Before(idleSince) {
+			continue
+		}
+		if cart.RecoveryNotifiedAt != nil && !cart.RecoveryNotifiedAt.Before(cart.UpdatedAt) {
+			continue
+		}
+		copied := *cart
+		copied.Items = nil
+		carts = append(carts, copied)
+	}
+	return carts, nil
+}
+
+func (r *memoryCartRecoveryRepository) MarkRecoveryNotified(ctx context.Context, cart *types.Cart, notifiedAt time.Time) (bool, error) {
+	stored := r.store.carts[cart.ID]
+	if !stored.UpdatedAt.Equal(cart.UpdatedAt) {
+		return false, nil
+	}
+	if stored.RecoveryNotifiedAt != nil && !stored.RecoveryNotifiedAt.Before(stored.UpdatedAt) {
+		return false, nil
+	}
+	stored.RecoveryNotifiedAt = &notifiedAt
+	return true, nil
+}
+
+func (r *memoryCartRecoveryRepository) GetPreference(ctx context.Context, customerID uint64) (*types.CartRecoveryPreference, error) {
+	return r.store.preferences[customerID], nil
+}
+
+func (r *memoryCartRecoveryRepository) SavePreference(ctx context.Context, preference *types.CartRecoveryPreference) error {
+	r.store.preferences[preference.CustomerID] = preference
+	return nil
+}
+
+// recordingCartRecoveryNotifier 记录找回提醒的通知桩
+type recordingCartRecoveryNotifier struct {
+	recoveries []*types.CartRecovery
+}
+
+func (n *recordingCartRecoveryNotifier) SendCartRecoveryNotification(ctx context.Context, recovery *types.CartRecovery) error {
+	n.recoveries = append(n.recoveries, recovery)
+	return nil
+}
+
+func TestCartRecoveryService(t *testing.T) {
+	Convey("购物车保留期与找回提醒测试", t, func() {
+		ctx := context.Background()
+		now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+		ttl := 7 * 24 * time.Hour
+		abandonedAfter := 24 * time.Hour
+
+		store := &memoryCartStore{
+			carts: map[uint64]*types.Cart{
+				// 闲置两天，商品1在售、商品2已下架
+				1: {ID: 1, TenantID: 1, CustomerID: 100, UpdatedAt: now.Add(-48 * time.Hour), Items: []types.CartItem{
+					{ID: 1, CartID: 1, ProductID: 1, Quantity: 2},
+					{ID: 2, CartID: 1, ProductID: 2, Quantity: 1},
+				}},
+				// 刚刚变更
+				2: {ID: 2, TenantID: 1, CustomerID: 200, UpdatedAt: now.Add(-time.Hour), Items: []types.CartItem{
+					{ID: 3, CartID: 2, ProductID: 1, Quantity: 1},
+				}},
+				// 超过保留时间未变更
+				3: {ID: 3, TenantID: 1, CustomerID: 300, UpdatedAt: now.Add(-8 * 24 * time.Hour), Items: []types.CartItem{
+					{ID: 4, CartID: 3, ProductID: 1, Quantity: 1},
+				}},
+				// 闲置但购物车为空
+				4: {ID: 4, TenantID: 1, CustomerID: 400, UpdatedAt: now.Add(-48 * time.Hour)},
+			},
+			preferences: map[uint64]*types.CartRecoveryPreference{},
+		}
+		productRepo := &staticProductAvailabilityRepository{products: []types.Product{
+			{ID: 1, Name: "手冲咖啡豆", PriceAmount: 68, Status: types.ProductStatusActive},
+			{ID: 2, Name: "挂耳咖啡", PriceAmount: 39, Status: types.ProductStatusInactive},
+		}}
+		notifier := &recordingCartRecoveryNotifier{}
+		recoveryService := NewCartRecoveryServiceForTest(
+			&memoryRecoveryCartRepository{store: store},
+			&memoryCartRecoveryRepository{store: store},
+			productRepo, notifier, ttl, abandonedAfter,
+			func() time.Time { return now })
+
+		Convey("清空超过保留时间未变更的购物车", func() {
+			deleted, err := recoveryService.ClearExpiredCarts(ctx)
+			So(err, ShouldBeNil)
+			So(deleted, ShouldEqual, 1)
+			So(store.carts, ShouldNotContainKey, uint64(3))
+			So(store.carts, ShouldContainKey, uint64(1))
+			So(store.carts, ShouldContainKey, uint64(2))
+		})
+
+		Convey("闲置购物车发送找回提醒，只列出重新校验后可购买的商品", func() {
+			sent, err := recoveryService.SendRecoveryNotifications(ctx)
+			So(err, ShouldBeNil)
+			// 购物车3同样闲置，清理前仍会提醒
+			So(sent, ShouldEqual, 2)
+			So(notifier.recoveries[0].Cart.ID, ShouldEqual, 1)
+			So(notifier.recoveries[0].Items, ShouldResemble, []types.CartRecoveryItem{
+				{ProductID: 1, Name: "手冲咖啡豆", Quantity: 2, UnitPrice: 68},
+			})
+			So(notifier.recoveries[0].Unavailable, ShouldResemble, []uint64{2})
+			So(notifier.recoveries[0].TotalAmount, ShouldEqual, 136)
+			So(store.carts[1].RecoveryNotifiedAt, ShouldNotBeNil)
+			So(store.carts[2].RecoveryNotifiedAt, ShouldBeNil)
+
+			Convey("同一闲置期内只提醒一次", func() {
+				sent, err := recoveryService.SendRecoveryNotifications(ctx)
+				So(err, ShouldBeNil)
+				So(sent, ShouldEqual, 0)
+				So(len(notifier.recoveries), ShouldEqual, 2)
+			})
+
+			Convey("购物车再次变更后闲置，开始新的闲置期并再次提醒", func() {
+				store.carts[1].UpdatedAt = now.Add(-30 * time.Hour)
+				notifiedAt := now.Add(-36 * time.Hour)
+				store.carts[1].RecoveryNotifiedAt = &notifiedAt
+
+				sent, err := recoveryService.SendRecoveryNotifications(ctx)
+				So(err, ShouldBeNil)
+				So(sent, ShouldEqual, 1)
+				So(len(notifier.recoveries), ShouldEqual, 3)
+			})
+		})
+
+		Convey("退订的客户不发送找回提醒，本次闲置期也不再检查", func() {
+			_, err := recoveryService.UpdatePreference(ctx, 100, &types.UpdateCartRecoveryPreferenceRequest{OptedOut: true})
+			So(err, ShouldBeNil)
+			delete(store.carts, 3)
+
+			sent, err := recoveryService.SendRecoveryNotifications(ctx)
+			So(err, ShouldBeNil)
+			So(sent, ShouldEqual, 0)
+			So(notifier.recoveries, ShouldBeEmpty)
+			So(store.carts[1].RecoveryNotifiedAt, ShouldNotBeNil)
+
+			preference, err := recoveryService.GetPreference(ctx, 100)
+			So(err, ShouldBeNil)
+			So(preference.OptedOut, ShouldBeTrue)
+		})
+
+		Convey("商品均已无法购买时不发送找回提醒", func() {
+			productRepo.products[0].Status = types.ProductStatusInactive
+			delete(store.carts, 3)
+
+			sent, err := recoveryService.SendRecoveryNotifications(ctx)
+			So(err, ShouldBeNil)
+			So(sent, ShouldEqual, 0)
+			So(notifier.recoveries, ShouldBeEmpty)
+		})
+
+		Convey("未设置偏好时默认接收找回提醒", func() {
+			preference, err := recoveryService.GetPreference(ctx, 200)
+			So(err, ShouldBeNil)
+			So(preference.OptedOut, ShouldBeFalse)
+		})
+	})
+}
+
+func TestCartRecoveryNotification(t *testing.T) {
+	Convey("购物车找回提醒内容测试", t, func() {
+		sms := &recordingSMSService{}
+		email := &contentRecordingEmailService{}
+		notificationService := NewNotificationServiceForTest(sms, email)
+
+		recovery := &types.CartRecovery{
+			Cart:        &types.Cart{ID: 1, TenantID: 1, CustomerID: 100},
+			Items:       []types.CartRecoveryItem{{ProductID: 1, Name: "手冲咖啡豆", Quantity: 2, UnitPrice: 68}},
+			Unavailable: []uint64{2},
+			TotalAmount: 136,
+		}
+		err := notificationService.SendCartRecoveryNotification(context.Background(), recovery)
+		So(err, ShouldBeNil)
+
+		So(len(email.contents), ShouldEqual, 1)
+		So(email.contents[0], ShouldContainSubstring, "手冲咖啡豆 × 2")
+		So(email.contents[0], ShouldContainSubstring, "另有 1 件商品已下架或暂时缺货")
+		So(sms.templateCodes, ShouldResemble, []string{SMS_TEMPLATE_CART_RECOVERY})
+		So(sms.contents[0], ShouldStartWith, "【"+types.DefaultNotificationSignature+"】")
+	})
+}
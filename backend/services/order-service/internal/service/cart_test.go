@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// lockingCartRepository 内存购物车仓储，同一购物车的修改持锁串行执行，与数据库锁定购物车行的行为一致
+type lockingCartRepository struct {
+	repository.ICartRepository
+	mu     sync.Mutex
+	nextID uint64
+	items  map[uint64]*types.CartItem // 按购物车项ID
+}
+
+func newLockingCartRepository() *lockingCartRepository {
+	return &lockingCartRepository{items: make(map[uint64]*types.CartItem)}
+}
+
+func (f *lockingCartRepository) GetOrCreate(ctx context.Context, customerID uint64) (*types.Cart, error) {
+	return &types.Cart{ID: 1, CustomerID: customerID}, nil
+}
+
+func (f *lockingCartRepository) AddItem(ctx context.Context, cartID uint64, productID uint64, quantity int, validate repository.CartItemValidator) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing := f.itemByProduct(productID)
+	total := quantity
+	if existing != nil {
+		total += existing.Quantity
+	}
+	if err := validate(ctx, types.CartItem{ProductID: productID, Quantity: total}); err != nil {
+		return err
+	}
+
+	if existing != nil {
+		existing.Quantity = total
+		existing.Version++
+		return nil
+	}
+	f.nextID++
+	f.items[f.nextID] = &types.CartItem{ID: f.nextID, CartID: cartID, ProductID: productID, Quantity: quantity, Version: 1}
+	return nil
+}
+
+func (f *lockingCartRepository) UpdateItemQuantity(ctx context.Context, itemID uint64, quantity int, version int, validate repository.CartItemValidator) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	item, exists := f.items[itemID]
+	if !exists {
+		return fmt.Errorf("购物车项不存在")
+	}
+	if version > 0 && item.Version != version {
+		return fmt.Errorf("%w: 当前版本 %d", types.ErrCartItemVersionConflict, item.Version)
+	}
+	if err := validate(ctx, types.CartItem{ProductID: item.ProductID, Quantity: quantity}); err != nil {
+		return err
+	}
+	item.Quantity = quantity
+	item.Version++
+	return nil
+}
+
+func (f *lockingCartRepository) itemByProduct(productID uint64) *types.CartItem {
+	for _, item := range f.items {
+		if item.ProductID == productID {
+			return item
+		}
+	}
+	return nil
+}
+
+func TestCartConcurrency(t *testing.T) {
+	Convey("购物车并发修改测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		productRepo := &staticProductAvailabilityRepository{products: []types.Product{
+			{
+				ID:            1,
+				Status:        types.ProductStatusActive,
+				InventoryInfo: &types.InventoryInfo{StockQuantity: 15, ReservedQuantity: 5, TrackInventory: true},
+			},
+		}}
+		cartRepo := newLockingCartRepository()
+		cartService := NewCartServiceForTest(cartRepo, productRepo)
+
+		// addConcurrently 多个协程同时添加同一商品，返回因库存不足被拒绝的次数
+		addConcurrently := func(goroutines, quantity int) int {
+			var (
+				wg       sync.WaitGroup
+				mu       sync.Mutex
+				rejected int
+			)
+			for i := 0; i < goroutines; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := cartService.AddItem(ctx, 100, 1, quantity); err != nil {
+						mu.Lock()
+						defer mu.Unlock()
+						if errors.Is(err, ErrOrderStockInsufficient) {
+							rejected++
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			return rejected
+		}
+
+		Convey("并发添加同一商品时数量累加", func() {
+			So(addConcurrently(5, 2), ShouldEqual, 0)
+
+			item := cartRepo.itemByProduct(1)
+			So(cartRepo.items, ShouldHaveLength, 1)
+			So(item.Quantity, ShouldEqual, 10)
+			So(item.Version, ShouldEqual, 5)
+		})
+
+		Convey("并发添加超过可用库存时最终数量不超过库存", func() {
+			So(addConcurrently(20, 1), ShouldEqual, 10)
+			So(cartRepo.itemByProduct(1).Quantity, ShouldEqual, 10)
+		})
+
+		Convey("更新数量", func() {
+			So(cartService.AddItem(ctx, 100, 1, 2), ShouldBeNil)
+			item := cartRepo.itemByProduct(1)
+
+			Convey("携带过期版本的更新被拒绝", func() {
+				So(cartService.UpdateItemQuantity(ctx, item.ID, 3, item.Version), ShouldBeNil)
+
+				err := cartService.UpdateItemQuantity(ctx, item.ID, 5, item.Version-1)
+				So(errors.Is(err, types.ErrCartItemVersionConflict), ShouldBeTrue)
+				So(item.Quantity, ShouldEqual, 3)
+			})
+
+			Convey("不携带版本时以最后一次更新为准", func() {
+				So(cartService.UpdateItemQuantity(ctx, item.ID, 4, 0), ShouldBeNil)
+				So(cartService.UpdateItemQuantity(ctx, item.ID, 6, 0), ShouldBeNil)
+				So(item.Quantity, ShouldEqual, 6)
+				So(item.Version, ShouldEqual, 3)
+			})
+
+			Convey("更新后的数量超过可用库存被拒绝", func() {
+				err := cartService.UpdateItemQuantity(ctx, item.ID, 11, 0)
+				So(errors.Is(err, ErrOrderStockInsufficient), ShouldBeTrue)
+				So(item.Quantity, ShouldEqual, 2)
+			})
+		})
+	})
+}
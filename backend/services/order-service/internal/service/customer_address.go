@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// ICustomerAddressService 客户收货地址服务接口
+type ICustomerAddressService interface {
+	CreateAddress(ctx context.Context, customerID uint64, req *types.CustomerAddressRequest) (*types.CustomerAddress, error)
+	ListAddresses(ctx context.Context, customerID uint64) ([]types.CustomerAddress, error)
+	GetAddress(ctx context.Context, customerID, addressID uint64) (*types.CustomerAddress, error)
+	UpdateAddress(ctx context.Context, customerID, addressID uint64, req *types.CustomerAddressRequest) (*types.CustomerAddress, error)
+	SetDefaultAddress(ctx context.Context, customerID, addressID uint64) (*types.CustomerAddress, error)
+	DeleteAddress(ctx context.Context, customerID, addressID uint64) error
+}
+
+// CustomerAddressService 客户收货地址服务实现：客户有地址时始终有且只有一个默认地址
+type CustomerAddressService struct {
+	addressRepo repository.ICustomerAddressRepository
+}
+
+// NewCustomerAddressService 创建客户收货地址服务实例
+func NewCustomerAddressService() ICustomerAddressService {
+	return &CustomerAddressService{
+		addressRepo: repository.NewCustomerAddressRepository(),
+	}
+}
+
+// NewCustomerAddressServiceForTest 创建测试用客户收货地址服务实例
+func NewCustomerAddressServiceForTest(addressRepo repository.ICustomerAddressRepository) ICustomerAddressService {
+	return &CustomerAddressService{
+		addressRepo: addressRepo,
+	}
+}
+
+// NewOrderShippingServiceForTest 创建测试用订单服务实例，下单时从地址仓储读取收货地址
+func NewOrderShippingServiceForTest(orderRepo repository.IOrderRepository, addressRepo repository.ICustomerAddressRepository) IOrderService {
+	return &OrderService{
+		orderRepo:   orderRepo,
+		addressRepo: addressRepo,
+	}
+}
+
+// CreateAddress 新增收货地址，客户的第一个地址自动设为默认地址
+func (s *CustomerAddressService) CreateAddress(ctx context.Context, customerID uint64, req *types.CustomerAddressRequest) (*types.CustomerAddress, error) {
+	if err := validateAddressRequest(req); err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.addressRepo.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) >= types.MaxCustomerAddresses {
+		return nil, types.ErrCustomerAddressLimit
+	}
+
+	address := &types.CustomerAddress{
+		CustomerID: customerID,
+		IsDefault:  req.IsDefault || len(addresses) == 0,
+	}
+	req.Apply(address)
+	if err := s.addressRepo.Create(ctx, address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// ListAddresses 获取客户的收货地址，默认地址在前
+func (s *CustomerAddressService) ListAddresses(ctx context.Context, customerID uint64) ([]types.CustomerAddress, error) {
+	return s.addressRepo.ListByCustomer(ctx, customerID)
+}
+
+// GetAddress 获取客户的收货地址，地址不属于该客户时视为不存在
+func (s *CustomerAddressService) GetAddress(ctx context.Context, customerID, addressID uint64) (*types.CustomerAddress, error) {
+	address, err := s.addressRepo.GetByID(ctx, customerID, addressID)
+	if err != nil {
+		return nil, err
+	}
+	if address == nil {
+		return nil, types.ErrCustomerAddressNotFound
+	}
+	return address, nil
+}
+
+// UpdateAddress 修改收货地址；默认地址不能通过修改取消默认，需将其他地址设为默认
+func (s *CustomerAddressService) UpdateAddress(ctx context.Context, customerID, addressID uint64, req *types.CustomerAddressRequest) (*types.CustomerAddress, error) {
+	if err := validateAddressRequest(req); err != nil {
+		return nil, err
+	}
+
+	address, err := s.GetAddress(ctx, customerID, addressID)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Apply(address)
+	address.IsDefault = address.IsDefault || req.IsDefault
+	if err := s.addressRepo.Update(ctx, address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// SetDefaultAddress 将地址设为默认地址，客户的其他地址取消默认
+func (s *CustomerAddressService) SetDefaultAddress(ctx context.Context, customerID, addressID uint64) (*types.CustomerAddress, error) {
+	address, err := s.GetAddress(ctx, customerID, addressID)
+	if err != nil {
+		return nil, err
+	}
+	if address.IsDefault {
+		return address, nil
+	}
+
+	if err := s.addressRepo.SetDefault(ctx, customerID, addressID); err != nil {
+		return nil, err
+	}
+	address.IsDefault = true
+	return address, nil
+}
+
+// DeleteAddress 删除收货地址，删除默认地址后将最近修改的其余地址设为默认。
+// 已下单订单保存的是地址快照，不受删除影响
+func (s *CustomerAddressService) DeleteAddress(ctx context.Context, customerID, addressID uint64) error {
+	address, err := s.GetAddress(ctx, customerID, addressID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.addressRepo.Delete(ctx, customerID, addressID); err != nil {
+		return err
+	}
+	if !address.IsDefault {
+		return nil
+	}
+
+	remaining, err := s.addressRepo.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("地址已删除，但设置新的默认地址失败: %v", err)
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+	if err := s.addressRepo.SetDefault(ctx, customerID, remaining[0].ID); err != nil {
+		return fmt.Errorf("地址已删除，但设置新的默认地址失败: %v", err)
+	}
+	return nil
+}
+
+// validateAddressRequest 规范化并校验收货地址请求，校验失败时返回 ErrInvalidCustomerAddress
+func validateAddressRequest(req *types.CustomerAddressRequest) error {
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", types.ErrInvalidCustomerAddress, err)
+	}
+	return nil
+}
+
+// shippingAddressSnapshot 获取客户地址簿中的地址快照，用于下单时保存收货地址；addressID 为 0 时订单不含收货地址
+func shippingAddressSnapshot(ctx context.Context, addressRepo repository.ICustomerAddressRepository, customerID, addressID uint64) (*types.ShippingAddress, error) {
+	if addressID == 0 {
+		return nil, nil
+	}
+	if addressRepo == nil {
+		return nil, fmt.Errorf("未配置收货地址仓储")
+	}
+
+	address, err := addressRepo.GetByID(ctx, customerID, addressID)
+	if err != nil {
+		return nil, err
+	}
+	if address == nil {
+		return nil, types.ErrCustomerAddressNotFound
+	}
+	return address.Snapshot(), nil
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryAddressRepository 内存收货地址仓储，与数据库实现一样按客户隔离并维护默认标记
+type memoryAddressRepository struct {
+	addresses map[uint64]*types.CustomerAddress
+	nextID    uint64
+	clock     time.Time
+}
+
+func newMemoryAddressRepository() *memoryAddressRepository {
+	return &memoryAddressRepository{
+		addresses: make(map[uint64]*types.CustomerAddress),
+		clock:     time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local),
+	}
+}
+
+// tick 每次写入使用递增的时间，便于按最近修改排序
+func (m *memoryAddressRepository) tick() time.Time {
+	m.clock = m.clock.Add(time.Minute)
+	return m.clock
+}
+
+func (m *memoryAddressRepository) clearDefault(customerID uint64) {
+	for _, address := range m.addresses {
+		if address.CustomerID == customerID {
+			address.IsDefault = false
+		}
+	}
+}
+
+func (m *memoryAddressRepository) Create(ctx context.Context, address *types.CustomerAddress) error {
+	if address.IsDefault {
+		m.clearDefault(address.CustomerID)
+	}
+	m.nextID++
+	address.ID = m.nextID
+	address.CreatedAt = m.tick()
+	address.UpdatedAt = address.CreatedAt
+	stored := *address
+	m.addresses[address.ID] = &stored
+	return nil
+}
+
+func (m *memoryAddressRepository) GetByID(ctx context.Context, customerID, addressID uint64) (*types.CustomerAddress, error) {
+	address, exists := m.addresses[addressID]
+	if !exists || address.CustomerID != customerID {
+		return nil, nil
+	}
+	copied := *address
+	return &copied, nil
+}
+
+func (m *memoryAddressRepository) ListByCustomer(ctx context.Context, customerID uint64) ([]types.CustomerAddress, error) {
+	var addresses []types.CustomerAddress
+	for _, address := range m.addresses {
+		if address.CustomerID == customerID {
+			addresses = append(addresses, *address)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		if addresses[i].IsDefault != addresses[j].IsDefault {
+			return addresses[i].IsDefault
+		}
+		return addresses[i].UpdatedAt.After(addresses[j].UpdatedAt)
+	})
+	return addresses, nil
+}
+
+func (m *memoryAddressRepository) Update(ctx context.Context, address *types.CustomerAddress) error {
+	if address.IsDefault {
+		m.clearDefault(address.CustomerID)
+	}
+	address.UpdatedAt = m.tick()
+	stored := *address
+	m.addresses[address.ID] = &stored
+	return nil
+}
+
+func (m *memoryAddressRepository) SetDefault(ctx context.Context, customerID, addressID uint64) error {
+	m.clearDefault(customerID)
+	if address, exists := m.addresses[addressID]; exists && address.CustomerID == customerID {
+		address.IsDefault = true
+	}
+	return nil
+}
+
+func (m *memoryAddressRepository) Delete(ctx context.Context, customerID, addressID uint64) error {
+	if address, exists := m.addresses[addressID]; exists && address.CustomerID == customerID {
+		delete(m.addresses, addressID)
+	}
+	return nil
+}
+
+// defaultAddressIDs 客户的默认地址ID
+func (m *memoryAddressRepository) defaultAddressIDs(customerID uint64) []uint64 {
+	var ids []uint64
+	for _, address := range m.addresses {
+		if address.CustomerID == customerID && address.IsDefault {
+			ids = append(ids, address.ID)
+		}
+	}
+	return ids
+}
+
+func newAddressRequest(detail string) *types.CustomerAddressRequest {
+	return &types.CustomerAddressRequest{
+		RecipientName: "张三",
+		Phone:         "13800138000",
+		Province:      "浙江省",
+		City:          "杭州市",
+		District:      "西湖区",
+		DetailAddress: detail,
+		PostalCode:    "310000",
+	}
+}
+
+func TestCustomerAddressBook(t *testing.T) {
+	Convey("客户收货地址簿测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		addressRepo := newMemoryAddressRepository()
+		addressService := NewCustomerAddressServiceForTest(addressRepo)
+		customerID := uint64(100)
+
+		Convey("新增地址时规范化并校验字段", func() {
+			req := newAddressRequest("  文三路100号  ")
+			req.RecipientName = " 张三 "
+			address, err := addressService.CreateAddress(ctx, customerID, req)
+			So(err, ShouldBeNil)
+			So(address.ID, ShouldBeGreaterThan, 0)
+			So(address.CustomerID, ShouldEqual, customerID)
+			So(address.RecipientName, ShouldEqual, "张三")
+			So(address.DetailAddress, ShouldEqual, "文三路100号")
+
+			invalid := []func(req *types.CustomerAddressRequest){
+				func(req *types.CustomerAddressRequest) { req.RecipientName = "  " },
+				func(req *types.CustomerAddressRequest) { req.Phone = "12345" },
+				func(req *types.CustomerAddressRequest) { req.City = "" },
+				func(req *types.CustomerAddressRequest) {
+					req.DetailAddress = strings.Repeat("路", types.MaxDetailAddressLength+1)
+				},
+				func(req *types.CustomerAddressRequest) { req.PostalCode = "31000A" },
+			}
+			for _, modify := range invalid {
+				req := newAddressRequest("文三路100号")
+				modify(req)
+				_, err := addressService.CreateAddress(ctx, customerID, req)
+				So(errors.Is(err, types.ErrInvalidCustomerAddress), ShouldBeTrue)
+			}
+
+			// 固定电话和未填写邮编均有效
+			req = newAddressRequest("文三路200号")
+			req.Phone = "0571-88886666"
+			req.PostalCode = ""
+			_, err = addressService.CreateAddress(ctx, customerID, req)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("查询、修改和删除地址", func() {
+			address, err := addressService.CreateAddress(ctx, customerID, newAddressRequest("文三路100号"))
+			So(err, ShouldBeNil)
+
+			found, err := addressService.GetAddress(ctx, customerID, address.ID)
+			So(err, ShouldBeNil)
+			So(found.DetailAddress, ShouldEqual, "文三路100号")
+
+			updated, err := addressService.UpdateAddress(ctx, customerID, address.ID, newAddressRequest("文一路8号"))
+			So(err, ShouldBeNil)
+			So(updated.DetailAddress, ShouldEqual, "文一路8号")
+
+			addresses, err := addressService.ListAddresses(ctx, customerID)
+			So(err, ShouldBeNil)
+			So(addresses, ShouldHaveLength, 1)
+			So(addresses[0].DetailAddress, ShouldEqual, "文一路8号")
+
+			So(addressService.DeleteAddress(ctx, customerID, address.ID), ShouldBeNil)
+			_, err = addressService.GetAddress(ctx, customerID, address.ID)
+			So(errors.Is(err, types.ErrCustomerAddressNotFound), ShouldBeTrue)
+		})
+
+		Convey("客户不能查看、修改或删除其他客户的地址", func() {
+			address, err := addressService.CreateAddress(ctx, customerID, newAddressRequest("文三路100号"))
+			So(err, ShouldBeNil)
+
+			other := uint64(200)
+			_, err = addressService.GetAddress(ctx, other, address.ID)
+			So(errors.Is(err, types.ErrCustomerAddressNotFound), ShouldBeTrue)
+			_, err = addressService.UpdateAddress(ctx, other, address.ID, newAddressRequest("篡改地址"))
+			So(errors.Is(err, types.ErrCustomerAddressNotFound), ShouldBeTrue)
+			_, err = addressService.SetDefaultAddress(ctx, other, address.ID)
+			So(errors.Is(err, types.ErrCustomerAddressNotFound), ShouldBeTrue)
+			So(errors.Is(addressService.DeleteAddress(ctx, other, address.ID), types.ErrCustomerAddressNotFound), ShouldBeTrue)
+
+			addresses, err := addressService.ListAddresses(ctx, other)
+			So(err, ShouldBeNil)
+			So(addresses, ShouldBeEmpty)
+			So(addressRepo.addresses[address.ID].DetailAddress, ShouldEqual, "文三路100号")
+		})
+
+		Convey("地址数达到上限后不能继续新增", func() {
+			for i := 0; i < types.MaxCustomerAddresses; i++ {
+				_, err := addressService.CreateAddress(ctx, customerID, newAddressRequest("文三路100号"))
+				So(err, ShouldBeNil)
+			}
+			_, err := addressService.CreateAddress(ctx, customerID, newAddressRequest("文三路101号"))
+			So(errors.Is(err, types.ErrCustomerAddressLimit), ShouldBeTrue)
+		})
+	})
+}
+
+func TestCustomerAddressDefault(t *testing.T) {
+	Convey("默认收货地址测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		addressRepo := newMemoryAddressRepository()
+		addressService := NewCustomerAddressServiceForTest(addressRepo)
+		customerID := uint64(100)
+
+		first, err := addressService.CreateAddress(ctx, customerID, newAddressRequest("文三路1号"))
+		So(err, ShouldBeNil)
+		second, err := addressService.CreateAddress(ctx, customerID, newAddressRequest("文三路2号"))
+		So(err, ShouldBeNil)
+
+		Convey("第一个地址自动成为默认地址，之后新增的地址不改变默认地址", func() {
+			So(first.IsDefault, ShouldBeTrue)
+			So(second.IsDefault, ShouldBeFalse)
+			So(addressRepo.defaultAddressIDs(customerID), ShouldResemble, []uint64{first.ID})
+
+			addresses, err := addressService.ListAddresses(ctx, customerID)
+			So(err, ShouldBeNil)
+			So(addresses[0].ID, ShouldEqual, first.ID)
+		})
+
+		Convey("新增时设为默认地址会取消原默认地址", func() {
+			req := newAddressRequest("文三路3号")
+			req.IsDefault = true
+			third, err := addressService.CreateAddress(ctx, customerID, req)
+			So(err, ShouldBeNil)
+			So(third.IsDefault, ShouldBeTrue)
+			So(addressRepo.defaultAddressIDs(customerID), ShouldResemble, []uint64{third.ID})
+		})
+
+		Convey("设为默认地址只影响同一客户的地址", func() {
+			otherAddress, err := addressService.CreateAddress(ctx, 200, newAddressRequest("文二路1号"))
+			So(err, ShouldBeNil)
+
+			address, err := addressService.SetDefaultAddress(ctx, customerID, second.ID)
+			So(err, ShouldBeNil)
+			So(address.IsDefault, ShouldBeTrue)
+			So(addressRepo.defaultAddressIDs(customerID), ShouldResemble, []uint64{second.ID})
+			So(addressRepo.defaultAddressIDs(200), ShouldResemble, []uint64{otherAddress.ID})
+		})
+
+		Convey("修改默认地址时不能取消默认", func() {
+			address, err := addressService.UpdateAddress(ctx, customerID, first.ID, newAddressRequest("文三路11号"))
+			So(err, ShouldBeNil)
+			So(address.IsDefault, ShouldBeTrue)
+			So(addressRepo.defaultAddressIDs(customerID), ShouldResemble, []uint64{first.ID})
+		})
+
+		Convey("删除默认地址后最近修改的地址成为默认地址", func() {
+			third, err := addressService.CreateAddress(ctx, customerID, newAddressRequest("文三路3号"))
+			So(err, ShouldBeNil)
+			_, err = addressService.UpdateAddress(ctx, customerID, second.ID, newAddressRequest("文三路22号"))
+			So(err, ShouldBeNil)
+
+			So(addressService.DeleteAddress(ctx, customerID, first.ID), ShouldBeNil)
+			So(addressRepo.defaultAddressIDs(customerID), ShouldResemble, []uint64{second.ID})
+
+			So(addressService.DeleteAddress(ctx, customerID, second.ID), ShouldBeNil)
+			So(addressRepo.defaultAddressIDs(customerID), ShouldResemble, []uint64{third.ID})
+
+			So(addressService.DeleteAddress(ctx, customerID, third.ID), ShouldBeNil)
+			So(addressRepo.defaultAddressIDs(customerID), ShouldBeEmpty)
+		})
+
+		Convey("删除非默认地址不改变默认地址", func() {
+			So(addressService.DeleteAddress(ctx, customerID, second.ID), ShouldBeNil)
+			So(addressRepo.defaultAddressIDs(customerID), ShouldResemble, []uint64{first.ID})
+		})
+	})
+}
+
+func TestOrderShippingAddressSnapshot(t *testing.T) {
+	Convey("下单保存收货地址快照测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		addressRepo := newMemoryAddressRepository()
+		addressService := NewCustomerAddressServiceForTest(addressRepo)
+		orderRepo := &batchOrderRepository{}
+		orderService := NewOrderShippingServiceForTest(orderRepo, addressRepo).(*OrderService)
+		customerID := uint64(100)
+
+		address, err := addressService.CreateAddress(ctx, customerID, newAddressRequest("文三路100号"))
+		So(err, ShouldBeNil)
+
+		req := &types.CreateOrderRequest{MerchantID: 1, ShippingAddressID: address.ID}
+		req.AddItem(1, 1)
+
+		Convey("订单保存下单时的地址，之后修改或删除地址簿不影响订单", func() {
+			order, err := orderService.newOrder(ctx, customerID, req)
+			So(err, ShouldBeNil)
+			So(order.ShippingAddress, ShouldNotBeNil)
+			So(order.ShippingAddress.AddressID, ShouldEqual, address.ID)
+			So(order.ShippingAddress.RecipientName, ShouldEqual, "张三")
+			So(order.ShippingAddress.FullAddress(), ShouldEqual, "浙江省杭州市西湖区文三路100号")
+
+			_, err = addressService.UpdateAddress(ctx, customerID, address.ID, newAddressRequest("文一路8号"))
+			So(err, ShouldBeNil)
+			So(addressService.DeleteAddress(ctx, customerID, address.ID), ShouldBeNil)
+			So(order.ShippingAddress.DetailAddress, ShouldEqual, "文三路100号")
+		})
+
+		Convey("不能使用其他客户的地址下单", func() {
+			_, err := orderService.newOrder(ctx, 200, req)
+			So(errors.Is(err, types.ErrCustomerAddressNotFound), ShouldBeTrue)
+		})
+
+		Convey("未选择收货地址时订单不含收货地址", func() {
+			req.ShippingAddressID = 0
+			order, err := orderService.newOrder(ctx, customerID, req)
+			So(err, ShouldBeNil)
+			So(order.ShippingAddress, ShouldBeNil)
+		})
+
+		Convey("发货通知包含订单的收货地址", func() {
+			order, err := orderService.newOrder(ctx, customerID, req)
+			So(err, ShouldBeNil)
+
+			manager := NewNotificationTemplateManager()
+			template := manager.GetTemplate(NotificationMethodTypeEmail, NotificationCategoryCustomer, NotificationEventFulfillmentShipped, "zh-CN")
+			fulfillment := types.OrderFulfillment{ID: 1, OrderID: order.ID, Status: types.FulfillmentStatusShipped, Carrier: "顺丰", TrackingNumber: "SF100"}
+			data := manager.BuildFulfillmentDataMap(ctx, order, &fulfillment, []types.OrderFulfillment{fulfillment})
+
+			_, content, err := manager.RenderTemplate(template, data)
+			So(err, ShouldBeNil)
+			So(content, ShouldContainSubstring, "收货地址：浙江省杭州市西湖区文三路100号 张三 13800138000")
+		})
+	})
+}
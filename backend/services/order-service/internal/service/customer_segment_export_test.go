@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/export"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeCustomerSegmentSource 客户分群数据源桩，按分群条件过滤后按客户ID分页返回
+type fakeCustomerSegmentSource struct {
+	customers []types.CustomerSegmentExportRow
+	batches   int
+}
+
+func (f *fakeCustomerSegmentSource) ListSegmentMembers(ctx context.Context, query *types.CustomerSegmentExportQuery, afterID uint64, limit int) ([]types.CustomerSegmentExportRow, error) {
+	f.batches++
+	criteria, ok := query.Segment.Criteria()
+	if !ok {
+		return nil, types.ErrInvalidCustomerSegment
+	}
+
+	var rows []types.CustomerSegmentExportRow
+	for _, customer := range f.customers {
+		if customer.CustomerID <= afterID || !criteria.Matches(customer.OrderCount) {
+			continue
+		}
+		rows = append(rows, customer)
+		if len(rows) == limit {
+			break
+		}
+	}
+	return rows, nil
+}
+
+func TestCustomerSegmentExport(t *testing.T) {
+	Convey("客户分群营销导出测试", t, func() {
+		ctx := context.Background()
+		day := time.Date(2025, 9, 1, 0, 0, 0, 0, time.Local)
+
+		source := &fakeCustomerSegmentSource{}
+		for i := 1; i <= 600; i++ {
+			source.customers = append(source.customers, types.CustomerSegmentExportRow{
+				CustomerID:  uint64(i),
+				Username:    fmt.Sprintf("user%03d", i),
+				Email:       fmt.Sprintf("user%03d@example.com", i),
+				Phone:       fmt.Sprintf("138%08d", i),
+				OrderCount:  12,
+				TotalSpent:  1200,
+				LastOrderAt: day.Add(time.Duration(i) * time.Minute),
+			})
+		}
+		source.customers = append(source.customers,
+			types.CustomerSegmentExportRow{CustomerID: 601, Username: "optout", Email: "optout@example.com", OrderCount: 15, LastOrderAt: day, MarketingOptOut: true},
+			types.CustomerSegmentExportRow{CustomerID: 602, Username: "anonymized", OrderCount: 20, LastOrderAt: day, Anonymized: true},
+			types.CustomerSegmentExportRow{CustomerID: 603, Username: "newbie", Email: "newbie@example.com", OrderCount: 1, TotalSpent: 99.5, LastOrderAt: day},
+			types.CustomerSegmentExportRow{CustomerID: 604, Username: "regular", Email: "regular@example.com", OrderCount: 3, TotalSpent: 300, LastOrderAt: day},
+		)
+
+		exports := export.NewQueueForTest(&fakeExportJobRepository{}, types.ExportLimits{}, t.TempDir())
+		exportService := NewCustomerSegmentExportServiceForTest(source, exports)
+
+		newQuery := func(segment types.CustomerSegment) *types.CustomerSegmentExportQuery {
+			return &types.CustomerSegmentExportQuery{
+				Segment:   segment,
+				StartDate: day,
+				EndDate:   day.AddDate(0, 1, 0),
+				Format:    types.OrderExportFormatCSV,
+			}
+		}
+
+		Convey("分批导出分群客户，退订和已匿名化的客户不导出", func() {
+			var buf bytes.Buffer
+			count, err := exportService.Export(ctx, newQuery(types.CustomerSegmentVIP), &buf)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 600)
+			So(source.batches, ShouldEqual, 2)
+
+			records := readExportCSV(buf.Bytes())
+			So(len(records), ShouldEqual, 601)
+			So(records[0], ShouldResemble, customerSegmentExportHeader)
+			for _, record := range records[1:] {
+				So(record[1], ShouldNotEqual, "optout")
+				So(record[1], ShouldNotEqual, "anonymized")
+			}
+		})
+
+		Convey("只导出选定分群的客户，每行附带分群条件", func() {
+			var buf bytes.Buffer
+			count, err := exportService.Export(ctx, newQuery(types.CustomerSegmentNew), &buf)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+
+			records := readExportCSV(buf.Bytes())
+			So(records[1], ShouldResemble, []string{
+				"603", "newbie", "newbie@example.com", "", "1", "99.50", "2025-09-01 00:00:00",
+				"新客户", "统计周期内已支付或已完成订单1单（2025-09-01至2025-09-30）",
+			})
+		})
+
+		Convey("无效分群不能导出", func() {
+			_, err := exportService.CreateExport(ctx, newQuery("gold"))
+			So(err, ShouldEqual, types.ErrInvalidCustomerSegment)
+
+			var buf bytes.Buffer
+			_, err = exportService.Export(ctx, newQuery("gold"), &buf)
+			So(err, ShouldEqual, types.ErrInvalidCustomerSegment)
+		})
+	})
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recordingSMSService 记录发送内容的短信服务桩
+type recordingSMSService struct {
+	templateCodes []string
+	contents      []string
+}
+
+func (r *recordingSMSService) SendSMS(ctx context.Context, customerID uint64, templateCode, content string) error {
+	r.templateCodes = append(r.templateCodes, templateCode)
+	r.contents = append(r.contents, content)
+	return nil
+}
+
+// recordingEmailService 记录邮件主题的邮件服务桩
+type recordingEmailService struct {
+	subjects []string
+}
+
+func (r *recordingEmailService) SendEmail(ctx context.Context, customerID uint64, subject, content string) error {
+	r.subjects = append(r.subjects, subject)
+	return nil
+}
+
+// changeFulfillmentStatus 在测试的履约单列表中变更一个包裹的状态，返回对应的状态变更结果
+func changeFulfillmentStatus(fulfillments []types.OrderFulfillment, index int, status types.FulfillmentStatus) *types.FulfillmentStatusChange {
+	previous := fulfillments[index].Status
+	fulfillments[index].Status = status
+	return &types.FulfillmentStatusChange{
+		Fulfillment:    &fulfillments[index],
+		PreviousStatus: previous,
+		Fulfillments:   fulfillments,
+	}
+}
+
+func TestFulfillmentStatusNotification(t *testing.T) {
+	Convey("拆单履约通知测试", t, func() {
+		ctx := context.Background()
+		sms := &recordingSMSService{}
+		email := &recordingEmailService{}
+		notificationService := NewNotificationServiceForTest(sms, email)
+
+		order := &types.Order{ID: 1, OrderNumber: "ORD202610150001", CustomerID: 100, TotalAmount: 300}
+		fulfillments := []types.OrderFulfillment{
+			{
+				ID:             11,
+				OrderID:        1,
+				Status:         types.FulfillmentStatusPending,
+				Items:          []types.FulfillmentItem{{ProductID: 1, ProductName: "咖啡豆", Quantity: 2}},
+				Carrier:        "顺丰",
+				TrackingNumber: "SF1001",
+			},
+			{
+				ID:      12,
+				OrderID: 1,
+				Status:  types.FulfillmentStatusPending,
+				Items:   []types.FulfillmentItem{{ProductID: 2, ProductName: "手冲壶", Quantity: 1}},
+			},
+		}
+
+		Convey("第一个包裹发货时只通知该包裹的商品", func() {
+			change := changeFulfillmentStatus(fulfillments, 0, types.FulfillmentStatusShipped)
+			err := notificationService.SendFulfillmentStatusNotification(ctx, order, change)
+			So(err, ShouldBeNil)
+
+			So(sms.templateCodes, ShouldResemble, []string{"FULFILLMENT_SHIPPED"})
+			So(sms.contents[0], ShouldContainSubstring, "咖啡豆 x2")
+			So(sms.contents[0], ShouldContainSubstring, "SF1001")
+			So(sms.contents[0], ShouldContainSubstring, "其余 1 个包裹待送达")
+			So(sms.contents[0], ShouldNotContainSubstring, "手冲壶")
+			So(email.subjects, ShouldResemble, []string{"包裹已发货 - ORD202610150001"})
+
+			Convey("第一个包裹送达而另一个仍待发货时不发送整单完成通知", func() {
+				change := changeFulfillmentStatus(fulfillments, 0, types.FulfillmentStatusDelivered)
+				err := notificationService.SendFulfillmentStatusNotification(ctx, order, change)
+				So(err, ShouldBeNil)
+				So(sms.templateCodes, ShouldResemble, []string{"FULFILLMENT_SHIPPED", "FULFILLMENT_DELIVERED"})
+				So(sms.templateCodes, ShouldNotContain, "ORDER_COMPLETED")
+
+				Convey("全部包裹送达后发送整单完成通知", func() {
+					change := changeFulfillmentStatus(fulfillments, 1, types.FulfillmentStatusDelivered)
+					err := notificationService.SendFulfillmentStatusNotification(ctx, order, change)
+					So(err, ShouldBeNil)
+					So(sms.templateCodes, ShouldResemble, []string{"FULFILLMENT_SHIPPED", "FULFILLMENT_DELIVERED", "FULFILLMENT_DELIVERED", "ORDER_COMPLETED"})
+					So(sms.contents[2], ShouldContainSubstring, "手冲壶 x1")
+					So(sms.contents[2], ShouldContainSubstring, "其余 0 个包裹待送达")
+
+					Convey("已送达的包裹转为已完成时不再重复发送送达和整单完成通知", func() {
+						change := changeFulfillmentStatus(fulfillments, 1, types.FulfillmentStatusCompleted)
+						err := notificationService.SendFulfillmentStatusNotification(ctx, order, change)
+						So(err, ShouldBeNil)
+
+						change = changeFulfillmentStatus(fulfillments, 0, types.FulfillmentStatusCompleted)
+						err = notificationService.SendFulfillmentStatusNotification(ctx, order, change)
+						So(err, ShouldBeNil)
+						So(sms.templateCodes, ShouldResemble, []string{"FULFILLMENT_SHIPPED", "FULFILLMENT_DELIVERED", "FULFILLMENT_DELIVERED", "ORDER_COMPLETED"})
+					})
+				})
+			})
+		})
+
+		Convey("待发货状态不通知客户", func() {
+			change := &types.FulfillmentStatusChange{
+				Fulfillment:    &fulfillments[1],
+				PreviousStatus: types.FulfillmentStatusPending,
+				Fulfillments:   fulfillments,
+			}
+			err := notificationService.SendFulfillmentStatusNotification(ctx, order, change)
+			So(err, ShouldBeNil)
+			So(sms.templateCodes, ShouldBeEmpty)
+			So(email.subjects, ShouldBeEmpty)
+		})
+	})
+}
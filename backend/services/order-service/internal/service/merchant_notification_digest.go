@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	defaultMerchantDigestInterval = 5 * time.Minute
+	merchantDigestBatchSize       = 500
+)
+
+// 不参与汇总、始终立即发送的商户通知事件
+var urgentMerchantNotificationEvents = map[NotificationEvent]bool{
+	NotificationEventPaymentFailure: true,
+	NotificationEventOrderCancelled: true, // 订单取消需要商户及时停止备货或处理退款
+	NotificationEventRightsCritical: true, // 权益余额低于紧急阈值，需要商户及时充值
+}
+
+// merchantDigestEventNames 汇总中事件类型的显示名称
+var merchantDigestEventNames = map[NotificationEvent]string{
+	NotificationEventOrderCreated:       "新订单",
+	NotificationEventPaymentSuccess:     "订单已支付",
+	NotificationEventOrderProcessing:    "订单处理中",
+	NotificationEventOrderCompleted:     "订单已完成",
+	NotificationEventOrderCancelled:     "订单已取消",
+	NotificationEventOrderStatusChanged: "订单状态变更",
+	NotificationEventRightsWarning:      "权益余额预警",
+}
+
+// merchantOrderNotificationEvent 商户订单状态变更通知对应的事件类型
+func merchantOrderNotificationEvent(toStatus types.OrderStatusInt) NotificationEvent {
+	switch toStatus {
+	case types.OrderStatusIntPaid:
+		return NotificationEventPaymentSuccess
+	case types.OrderStatusIntProcessing:
+		return NotificationEventOrderProcessing
+	case types.OrderStatusIntCompleted:
+		return NotificationEventOrderCompleted
+	case types.OrderStatusIntCancelled:
+		return NotificationEventOrderCancelled
+	default:
+		return NotificationEventOrderStatusChanged
+	}
+}
+
+// MerchantNotificationDigester 商户通知汇总：开启汇总的商户，非紧急通知先记录下来，
+// 由定时任务按商户设置的频率（每小时/每日）合并为一封汇总邮件发送
+type MerchantNotificationDigester struct {
+	repo          repository.IMerchantNotificationDigestRepository
+	emailService  EmailService
+	orderLinkBase string
+	interval      time.Duration
+	now           func() time.Time
+	stopCh        chan struct{}
+	isRunning     bool
+}
+
+// NewMerchantNotificationDigester 创建商户通知汇总任务，汇总邮件同样受免打扰时段限制
+func NewMerchantNotificationDigester() *MerchantNotificationDigester {
+	ctx := context.Background()
+	quietHours := NewQuietHoursGate(NewTenantQuietHoursPolicyProvider(repository.NewTenantRepository()), repository.NewOutboxRepository())
+	interval := g.Cfg().MustGet(ctx, "notification.digest.interval", defaultMerchantDigestInterval).Duration()
+	if interval <= 0 {
+		interval = defaultMerchantDigestInterval
+	}
+	return &MerchantNotificationDigester{
+		repo:          repository.NewMerchantNotificationDigestRepository(),
+		emailService:  NewQuietHoursEmailService(NewEmailService(), quietHours),
+		orderLinkBase: g.Cfg().MustGet(ctx, "notification.digest.orderLinkBase", "/merchant/orders").String(),
+		interval:      interval,
+		now:           time.Now,
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// NewMerchantNotificationDigesterForTest 创建测试用商户通知汇总任务，可指定当前时间
+func NewMerchantNotificationDigesterForTest(repo repository.IMerchantNotificationDigestRepository, emailService EmailService, orderLinkBase string, now func() time.Time) *MerchantNotificationDigester {
+	return &MerchantNotificationDigester{
+		repo:          repo,
+		emailService:  emailService,
+		orderLinkBase: orderLinkBase,
+		interval:      defaultMerchantDigestInterval,
+		now:           now,
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// Start 启动后台汇总发送循环
+func (d *MerchantNotificationDigester) Start(ctx context.Context) {
+	if d.isRunning {
+		return
+	}
+	d.isRunning = true
+	g.Log().Info(ctx, "启动商户通知汇总任务", "interval", d.interval)
+
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-d.stopCh:
+				return
+			case <-ticker.C:
+				if _, err := d.SendDue(ctx); err != nil {
+					g.Log().Error(ctx, "发送商户通知汇总失败", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止后台汇总发送循环
+func (d *MerchantNotificationDigester) Stop(ctx context.Context) {
+	if !d.isRunning {
+		return
+	}
+	d.isRunning = false
+	close(d.stopCh)
+	g.Log().Info(ctx, "商户通知汇总任务已停止")
+}
+
+// GetPreference 获取商户通知偏好，未设置时为逐条发送
+func (d *MerchantNotificationDigester) GetPreference(ctx context.Context, merchantID uint64) (*types.MerchantNotificationPreference, error) {
+	preference, err := d.repo.GetPreference(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	if preference == nil {
+		preference = &types.MerchantNotificationPreference{
+			MerchantID:      merchantID,
+			DigestFrequency: types.MerchantDigestImmediate,
+			DigestHour:      9,
+		}
+	}
+	return preference, nil
+}
+
+// UpdatePreference 更新商户通知偏好，关闭汇总后已记录的通知在下一轮汇总任务中发送
+func (d *MerchantNotificationDigester) UpdatePreference(ctx context.Context, merchantID uint64, req *types.UpdateMerchantNotificationPreferenceRequest) (*types.MerchantNotificationPreference, error) {
+	preference := &types.MerchantNotificationPreference{
+		MerchantID:      merchantID,
+		DigestFrequency: req.DigestFrequency,
+		DigestHour:      req.DigestHour,
+	}
+	if err := preference.Validate(); err != nil {
+		return nil, err
+	}
+	if err := d.repo.SavePreference(ctx, preference); err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
+
+// DeferMerchantOrderNotification 商户开启汇总且通知不紧急时，为每个接收人记录待汇总通知，
+// 返回通知是否已转入汇总。返回 false 时调用方应立即发送
+func (d *MerchantNotificationDigester) DeferMerchantOrderNotification(ctx context.Context, order *types.Order, event NotificationEvent, summary string, userIDs []uint64) (bool, error) {
+	return d.deferMerchantNotification(ctx, order.MerchantID, event, userIDs, &types.MerchantNotificationDigestEntry{
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+		Summary:     summary,
+		Link:        fmt.Sprintf("%s/%d", strings.TrimRight(d.orderLinkBase, "/"), order.ID),
+	})
+}
+
+// DeferMerchantNotification 与订单无关的商户通知（如权益余额预警），商户开启汇总且通知不紧急时转入汇总，
+// 返回通知是否已转入汇总。返回 false 时调用方应立即发送
+func (d *MerchantNotificationDigester) DeferMerchantNotification(ctx context.Context, merchantID uint64, event NotificationEvent, summary string, userIDs []uint64) (bool, error) {
+	return d.deferMerchantNotification(ctx, merchantID, event, userIDs, &types.MerchantNotificationDigestEntry{Summary: summary})
+}
+
+// deferMerchantNotification 按商户通知偏好判断是否汇总，需要汇总时以 template 为模板为每个接收人记录待汇总通知
+func (d *MerchantNotificationDigester) deferMerchantNotification(ctx context.Context, merchantID uint64, event NotificationEvent, userIDs []uint64, template *types.MerchantNotificationDigestEntry) (bool, error) {
+	if isUrgentNotification(ctx) || urgentMerchantNotificationEvents[event] {
+		return false, nil
+	}
+
+	preference, err := d.repo.GetPreference(ctx, merchantID)
+	if err != nil {
+		return false, err
+	}
+	if !preference.DigestEnabled() {
+		return false, nil
+	}
+
+	now := d.now()
+	for _, userID := range userIDs {
+		entry := *template
+		entry.MerchantID = merchantID
+		entry.UserID = userID
+		entry.EventType = string(event)
+		entry.CreatedAt = now
+		if err := d.repo.AddEntry(ctx, &entry); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// SendDue 发送已到发送时间的汇总，返回发送的汇总数，单个接收人失败不影响其他接收人
+func (d *MerchantNotificationDigester) SendDue(ctx context.Context) (int, error) {
+	recipients, err := d.repo.ListPendingRecipients(ctx, merchantDigestBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	now := d.now()
+	sent := 0
+	for i := range recipients {
+		recipient := &recipients[i]
+		// 定时任务没有请求上下文，按接收人所属租户设置租户上下文
+		tenantCtx := context.WithValue(ctx, "tenant_id", recipient.TenantID)
+
+		preference, err := d.repo.GetPreference(tenantCtx, recipient.MerchantID)
+		if err != nil {
+			g.Log().Error(ctx, "获取商户通知偏好失败", "merchant_id", recipient.MerchantID, "error", err)
+			continue
+		}
+		// 商户关闭汇总后，残留的待汇总通知立即发送
+		if preference.DigestEnabled() && now.Before(preference.DigestDueAt(recipient.FirstAt)) {
+			continue
+		}
+
+		if err := d.sendDigest(tenantCtx, recipient, now); err != nil {
+			g.Log().Error(ctx, "发送商户通知汇总失败",
+				"tenant_id", recipient.TenantID,
+				"merchant_id", recipient.MerchantID,
+				"user_id", recipient.UserID,
+				"error", err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// sendDigest 合并接收人的待汇总通知并发送汇总邮件
+func (d *MerchantNotificationDigester) sendDigest(ctx context.Context, recipient *types.MerchantDigestRecipient, now time.Time) error {
+	entries, err := d.repo.ListPendingEntries(ctx, recipient)
+	if err != nil {
+		return err
+	}
+	digest := types.BuildMerchantNotificationDigest(entries)
+	if digest == nil {
+		return nil
+	}
+
+	subject := fmt.Sprintf("订单通知汇总（%d 条）", digest.Total)
+	if err := d.emailService.SendEmail(ctx, recipient.UserID, subject, generateMerchantDigestEmailContent(digest)); err != nil {
+		return err
+	}
+
+	ids := make([]uint64, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return d.repo.MarkSent(ctx, recipient.TenantID, ids, now)
+}
+
+// generateMerchantDigestEmailContent 生成商户通知汇总邮件内容，按事件类型列出数量和订单链接
+func generateMerchantDigestEmailContent(digest *types.MerchantNotificationDigest) string {
+	var builder strings.Builder
+	builder.WriteString("\n尊敬的商户管理员，\n\n")
+	builder.WriteString(fmt.Sprintf("以下是 %s 至 %s 期间的 %d 条订单通知汇总：\n",
+		digest.Since.Format("2006-01-02 15:04"), digest.Until.Format("2006-01-02 15:04"), digest.Total))
+
+	for _, group := range digest.Groups {
+		name, exists := merchantDigestEventNames[NotificationEvent(group.EventType)]
+		if !exists {
+			name = group.EventType
+		}
+		builder.WriteString(fmt.Sprintf("\n【%s】%d 条\n", name, group.Count))
+		for _, link := range group.Links {
+			builder.WriteString(fmt.Sprintf("- %s\n", link))
+		}
+		if more := group.Count - len(group.Links); more > 0 {
+			builder.WriteString(fmt.Sprintf("- 另有 %d 条，请登录商户管理后台查看\n", more))
+		}
+	}
+
+	builder.WriteString("\n请登录商户管理后台查看详细信息。\n\n此致\n商户管理系统\n")
+	return builder.String()
+}
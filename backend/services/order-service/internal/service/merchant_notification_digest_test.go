@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryDigestRepository 内存商户通知汇总仓储
+type memoryDigestRepository struct {
+	preferences map[uint64]*types.MerchantNotificationPreference
+	entries     []*types.MerchantNotificationDigestEntry
+}
+
+func (m *memoryDigestRepository) GetPreference(ctx context.Context, merchantID uint64) (*types.MerchantNotificationPreference, error) {
+	return m.preferences[merchantID], nil
+}
+
+func (m *memoryDigestRepository) SavePreference(ctx context.Context, preference *types.MerchantNotificationPreference) error {
+	m.preferences[preference.MerchantID] = preference
+	return nil
+}
+
+func (m *memoryDigestRepository) AddEntry(ctx context.Context, entry *types.MerchantNotificationDigestEntry) error {
+	entry.ID = uint64(len(m.entries) + 1)
+	entry.TenantID = ctx.Value("tenant_id").(uint64)
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memoryDigestRepository) ListPendingRecipients(ctx context.Context, limit int) ([]types.MerchantDigestRecipient, error) {
+	index := make(map[[3]uint64]*types.MerchantDigestRecipient)
+	for _, entry := range m.entries {
+		if entry.SentAt != nil {
+			continue
+		}
+		key := [3]uint64{entry.TenantID, entry.MerchantID, entry.UserID}
+		recipient, exists := index[key]
+		if !exists {
+			recipient = &types.MerchantDigestRecipient{TenantID: entry.TenantID, MerchantID: entry.MerchantID, UserID: entry.UserID, FirstAt: entry.CreatedAt}
+			index[key] = recipient
+		}
+		if entry.CreatedAt.Before(recipient.FirstAt) {
+			recipient.FirstAt = entry.CreatedAt
+		}
+	}
+
+	recipients := make([]types.MerchantDigestRecipient, 0, len(index))
+	for _, recipient := range index {
+		recipients = append(recipients, *recipient)
+	}
+	sort.Slice(recipients, func(i, j int) bool { return recipients[i].UserID < recipients[j].UserID })
+	return recipients, nil
+}
+
+func (m *memoryDigestRepository) ListPendingEntries(ctx context.Context, recipient *types.MerchantDigestRecipient) ([]types.MerchantNotificationDigestEntry, error) {
+	var entries []types.MerchantNotificationDigestEntry
+	for _, entry := range m.entries {
+		if entry.SentAt == nil && entry.TenantID == recipient.TenantID && entry.MerchantID == recipient.MerchantID && entry.UserID == recipient.UserID {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *memoryDigestRepository) MarkSent(ctx context.Context, tenantID uint64, ids []uint64, sentAt time.Time) error {
+	for _, id := range ids {
+		m.entries[id-1].SentAt = &sentAt
+	}
+	return nil
+}
+
+// pendingCount 待汇总的通知数
+func (m *memoryDigestRepository) pendingCount() int {
+	count := 0
+	for _, entry := range m.entries {
+		if entry.SentAt == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// digestEmail 发送的邮件
+type digestEmail struct {
+	userID  uint64
+	subject string
+	content string
+}
+
+// capturingEmailService 记录邮件内容的邮件服务桩，商户通知在 goroutine 中发送
+type capturingEmailService struct {
+	mutex  sync.Mutex
+	emails []digestEmail
+}
+
+func (c *capturingEmailService) SendEmail(ctx context.Context, customerID uint64, subject, content string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.emails = append(c.emails, digestEmail{userID: customerID, subject: subject, content: content})
+	return nil
+}
+
+func (c *capturingEmailService) sent() []digestEmail {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]digestEmail(nil), c.emails...)
+}
+
+func TestMerchantNotificationDigest(t *testing.T) {
+	Convey("商户通知汇总", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		now := time.Date(2026, 10, 15, 10, 20, 0, 0, time.Local)
+		clock := func() time.Time { return now }
+
+		repo := &memoryDigestRepository{preferences: map[uint64]*types.MerchantNotificationPreference{
+			1: {MerchantID: 1, DigestFrequency: types.MerchantDigestHourly},
+			2: {MerchantID: 2, DigestFrequency: types.MerchantDigestImmediate},
+		}}
+		digestEmails := &capturingEmailService{}
+		digester := NewMerchantNotificationDigesterForTest(repo, digestEmails, "https://merchant.example.com/orders", clock)
+		email := &capturingEmailService{}
+		notificationService := NewMerchantDigestNotificationServiceForTest(&recordingSMSService{}, email, digester)
+
+		notify := func(orderID, merchantID uint64, from, to types.OrderStatusInt) {
+			order := &types.Order{ID: orderID, MerchantID: merchantID, OrderNumber: fmt.Sprintf("ORD%d", orderID), TotalAmount: 100}
+			history := &types.OrderStatusHistory{OrderID: orderID, FromStatus: from, ToStatus: to, CreatedAt: now}
+			So(notificationService.SendMerchantOrderNotification(ctx, order, history), ShouldBeNil)
+		}
+
+		Convey("开启汇总的商户不再逐条发送非紧急通知", func() {
+			notify(101, 1, types.OrderStatusIntPending, types.OrderStatusIntPaid)
+			notify(102, 1, types.OrderStatusIntPending, types.OrderStatusIntPaid)
+			notify(103, 1, types.OrderStatusIntPending, types.OrderStatusIntPaid)
+			notify(101, 1, types.OrderStatusIntPaid, types.OrderStatusIntProcessing)
+
+			So(email.sent(), ShouldBeEmpty)
+			// 每个商户管理员各记录一条
+			So(repo.pendingCount(), ShouldEqual, 8)
+
+			Convey("未到汇总时间时不发送", func() {
+				sent, err := digester.SendDue(ctx)
+				So(err, ShouldBeNil)
+				So(sent, ShouldEqual, 0)
+				So(digestEmails.sent(), ShouldBeEmpty)
+			})
+
+			Convey("到达下一个整点后按事件类型汇总发送给每个管理员", func() {
+				now = time.Date(2026, 10, 15, 11, 0, 0, 0, time.Local)
+				sent, err := digester.SendDue(ctx)
+				So(err, ShouldBeNil)
+				So(sent, ShouldEqual, 2)
+				So(repo.pendingCount(), ShouldEqual, 0)
+
+				emails := digestEmails.sent()
+				So(emails, ShouldHaveLength, 2)
+				So(emails[0].userID, ShouldEqual, 1000)
+				So(emails[1].userID, ShouldEqual, 1001)
+				So(emails[0].subject, ShouldEqual, "订单通知汇总（4 条）")
+				So(emails[0].content, ShouldContainSubstring, "【订单已支付】3 条")
+				So(emails[0].content, ShouldContainSubstring, "【订单处理中】1 条")
+				So(emails[0].content, ShouldContainSubstring, "https://merchant.example.com/orders/102")
+
+				Convey("已发送的通知不重复汇总", func() {
+					sent, err := digester.SendDue(ctx)
+					So(err, ShouldBeNil)
+					So(sent, ShouldEqual, 0)
+				})
+			})
+		})
+
+		Convey("紧急通知仍立即发送", func() {
+			notify(104, 1, types.OrderStatusIntPaid, types.OrderStatusIntCancelled)
+			So(repo.pendingCount(), ShouldEqual, 0)
+			So(waitForEmails(email, 2), ShouldHaveLength, 2)
+
+			notify(105, 1, types.OrderStatusIntPending, types.OrderStatusIntPaid)
+			So(notificationService.SendMerchantOrderNotification(WithUrgentNotification(ctx),
+				&types.Order{ID: 106, MerchantID: 1, OrderNumber: "ORD106"},
+				&types.OrderStatusHistory{OrderID: 106, FromStatus: types.OrderStatusIntPending, ToStatus: types.OrderStatusIntPaid, CreatedAt: now}), ShouldBeNil)
+			So(repo.pendingCount(), ShouldEqual, 2)
+			So(waitForEmails(email, 4), ShouldHaveLength, 4)
+		})
+
+		Convey("未开启汇总的商户逐条发送", func() {
+			notify(201, 2, types.OrderStatusIntPending, types.OrderStatusIntPaid)
+			So(repo.pendingCount(), ShouldEqual, 0)
+			So(waitForEmails(email, 2), ShouldHaveLength, 2)
+		})
+
+		Convey("关闭汇总后残留的通知在下一轮立即发送", func() {
+			notify(107, 1, types.OrderStatusIntPending, types.OrderStatusIntPaid)
+			_, err := digester.UpdatePreference(ctx, 1, &types.UpdateMerchantNotificationPreferenceRequest{DigestFrequency: types.MerchantDigestImmediate})
+			So(err, ShouldBeNil)
+
+			sent, err := digester.SendDue(ctx)
+			So(err, ShouldBeNil)
+			So(sent, ShouldEqual, 2)
+			So(digestEmails.sent()[0].content, ShouldContainSubstring, "【订单已支付】1 条")
+		})
+	})
+}
+
+// waitForEmails 等待 goroutine 中发送的邮件达到指定数量
+func waitForEmails(email *capturingEmailService, count int) []digestEmail {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if sent := email.sent(); len(sent) >= count {
+			return sent
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return email.sent()
+}
@@ -3,8 +3,10 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
 )
@@ -27,9 +29,24 @@ type NotificationService interface {
 	SendOrderProcessingNotification(ctx context.Context, order *types.Order) error
 	SendOrderCancelledNotification(ctx context.Context, order *types.Order, reason string) error
 	
+	// 拆单履约通知：单个包裹状态变更时通知客户，全部包裹首次送达时才发送订单完成通知
+	SendFulfillmentStatusNotification(ctx context.Context, order *types.Order, change *types.FulfillmentStatusChange) error
+	
 	// 新增：商户端通知
 	SendMerchantOrderNotification(ctx context.Context, order *types.Order, statusHistory *types.OrderStatusHistory) error
 	
+	// 订单超过状态处理时限时提醒商户管理员人工处理
+	SendOrderSLABreachNotification(ctx context.Context, breach *types.OrderSLABreach) error
+	
+	// 商户权益可用余额越过预警或紧急阈值时提醒商户管理员
+	SendRightsThresholdNotification(ctx context.Context, threshold *types.MerchantRightsThreshold) error
+	
+	// 订单争议发起或状态变更时通知下单客户和商户管理员
+	SendOrderDisputeNotification(ctx context.Context, order *types.Order, dispute *types.OrderDispute) error
+	
+	// 购物车闲置时提醒客户完成购买
+	SendCartRecoveryNotification(ctx context.Context, recovery *types.CartRecovery) error
+	
 	// 设置WebSocket通知器
 	SetWebSocketNotifier(notifier WebSocketNotifier)
 	
@@ -43,15 +60,74 @@ type notificationService struct {
 	emailService     EmailService
 	webSocketNotifier WebSocketNotifier
 	templateManager  *NotificationTemplateManager
+	merchantDigester *MerchantNotificationDigester
+	moneyFormat      repository.MoneyFormatProvider // 为空时金额使用默认格式
+	statusLabels     repository.OrderStatusLabelProvider // 为空时订单状态使用内置中文名称
+	dispatcher       *NotificationDispatcher // 为空时使用进程内共享的通知发送池
 }
 
 // NewNotificationService 创建通知服务实例
 func NewNotificationService() NotificationService {
+	// 非紧急短信和邮件受租户免打扰时段限制，延迟的通知由发件箱投递器发送
+	tenantRepo := repository.NewTenantRepository()
+	quietHours := NewQuietHoursGate(NewTenantQuietHoursPolicyProvider(tenantRepo), repository.NewOutboxRepository())
 	return &notificationService{
-		smsService:       NewSMSService(),
-		emailService:     NewEmailService(),
+		smsService:       NewQuietHoursSMSService(NewSMSService(), quietHours),
+		emailService:     NewQuietHoursEmailService(NewEmailService(), quietHours),
 		webSocketNotifier: nil, // 稍后通过SetWebSocketNotifier设置
+		templateManager:  NewBrandedNotificationTemplateManager(repository.NewNotificationBrandingProvider(tenantRepo, repository.NewMerchantRepository())),
+		merchantDigester: NewMerchantNotificationDigester(),
+		moneyFormat:      repository.NewTenantMoneyFormatProvider(tenantRepo),
+		statusLabels:     repository.NewTenantOrderStatusLabelProvider(tenantRepo),
+		dispatcher:       sharedNotificationDispatcher(),
+	}
+}
+
+// NewNotificationServiceForTest 创建测试用通知服务实例
+func NewNotificationServiceForTest(smsService SMSService, emailService EmailService) NotificationService {
+	return &notificationService{
+		smsService:      smsService,
+		emailService:    emailService,
+		templateManager: NewNotificationTemplateManager(),
+	}
+}
+
+// NewBrandedNotificationServiceForTest 创建测试用通知服务实例（按订单所属商户填充通知品牌）
+func NewBrandedNotificationServiceForTest(smsService SMSService, emailService EmailService, branding repository.NotificationBrandingProvider) NotificationService {
+	return &notificationService{
+		smsService:      smsService,
+		emailService:    emailService,
+		templateManager: NewBrandedNotificationTemplateManager(branding),
+	}
+}
+
+// NewLabeledNotificationServiceForTest 创建测试用通知服务实例（按租户配置显示订单状态）
+func NewLabeledNotificationServiceForTest(smsService SMSService, emailService EmailService, statusLabels repository.OrderStatusLabelProvider) NotificationService {
+	return &notificationService{
+		smsService:      smsService,
+		emailService:    emailService,
+		templateManager: NewNotificationTemplateManager(),
+		statusLabels:    statusLabels,
+	}
+}
+
+// NewMerchantDigestNotificationServiceForTest 创建测试用通知服务实例（用于商户通知汇总）
+func NewMerchantDigestNotificationServiceForTest(smsService SMSService, emailService EmailService, merchantDigester *MerchantNotificationDigester) NotificationService {
+	return &notificationService{
+		smsService:       smsService,
+		emailService:     emailService,
 		templateManager:  NewNotificationTemplateManager(),
+		merchantDigester: merchantDigester,
+	}
+}
+
+// NewDispatchingNotificationServiceForTest 创建测试用通知服务实例（使用指定的通知发送池）
+func NewDispatchingNotificationServiceForTest(smsService SMSService, emailService EmailService, dispatcher *NotificationDispatcher) NotificationService {
+	return &notificationService{
+		smsService:      smsService,
+		emailService:    emailService,
+		templateManager: NewNotificationTemplateManager(),
+		dispatcher:      dispatcher,
 	}
 }
 
@@ -60,12 +136,34 @@ func (s *notificationService) SetWebSocketNotifier(notifier WebSocketNotifier) {
 	s.webSocketNotifier = notifier
 }
 
+// dispatch 通过通知发送池异步发送，同一接收人的通知按提交顺序发送；提交失败时记录日志并放弃该通知
+func (s *notificationService) dispatch(ctx context.Context, userID uint64, send func(ctx context.Context)) {
+	dispatcher := s.dispatcher
+	if dispatcher == nil {
+		dispatcher = sharedNotificationDispatcher()
+	}
+	if err := dispatcher.Submit(ctx, fmt.Sprintf("user:%d", userID), send); err != nil {
+		g.Log().Error(ctx, "提交通知失败", "user_id", userID, "error", err)
+	}
+}
+
+// notificationBranding 获取订单所属商户的客户通知品牌
+func (s *notificationService) notificationBranding(ctx context.Context, order *types.Order) *types.NotificationBranding {
+	return s.templateManager.branding.Resolve(ctx, order.TenantID, order.MerchantID)
+}
+
+// customerStatusLabel 获取订单状态面向客户的显示名称和说明，租户未配置时使用内置中文名称
+func (s *notificationService) customerStatusLabel(ctx context.Context, order *types.Order, status types.OrderStatus) types.OrderStatusLabel {
+	return customerOrderStatusLabel(s.statusLabels.Resolve(ctx, order.TenantID), status, customerNotificationLocale)
+}
+
 // SendOrderCreatedNotification 发送订单创建通知
 func (s *notificationService) SendOrderCreatedNotification(ctx context.Context, order *types.Order) error {
 	g.Log().Info(ctx, "发送订单创建通知", "order_id", order.ID, "order_number", order.OrderNumber)
 
 	// 构建模板数据
-	data := s.templateManager.BuildOrderDataMap(order, nil)
+	data := s.templateManager.BuildOrderDataMap(ctx, order, nil)
+	data[MoneyFormatDataKey] = s.moneyFormat.Resolve(ctx, order.TenantID)
 
 	// 发送短信通知
 	if smsTemplate := s.templateManager.GetTemplate(NotificationMethodTypeSMS, NotificationCategoryCustomer, NotificationEventOrderCreated, "zh-CN"); smsTemplate != nil && smsTemplate.Enabled {
@@ -99,7 +197,8 @@ func (s *notificationService) SendPaymentSuccessNotification(ctx context.Context
 	g.Log().Info(ctx, "发送支付成功通知", "order_id", order.ID, "order_number", order.OrderNumber)
 
 	// 构建模板数据
-	data := s.templateManager.BuildOrderDataMap(order, nil)
+	data := s.templateManager.BuildOrderDataMap(ctx, order, nil)
+	data[MoneyFormatDataKey] = s.moneyFormat.Resolve(ctx, order.TenantID)
 
 	// 发送短信通知
 	if smsTemplate := s.templateManager.GetTemplate(NotificationMethodTypeSMS, NotificationCategoryCustomer, NotificationEventPaymentSuccess, "zh-CN"); smsTemplate != nil && smsTemplate.Enabled {
@@ -130,10 +229,13 @@ func (s *notificationService) SendPaymentSuccessNotification(ctx context.Context
 
 // SendPaymentFailureNotification 发送支付失败通知
 func (s *notificationService) SendPaymentFailureNotification(ctx context.Context, order *types.Order) error {
+	// 支付失败需要客户及时处理，不受免打扰时段限制
+	ctx = WithUrgentNotification(ctx)
 	g.Log().Info(ctx, "发送支付失败通知", "order_id", order.ID, "order_number", order.OrderNumber)
 
 	// 短信通知
-	smsContent := fmt.Sprintf("您的订单 %s 支付失败，请重新支付或联系客服。", order.OrderNumber)
+	branding := s.notificationBranding(ctx, order)
+	smsContent := fmt.Sprintf("【%s】您的订单 %s 支付失败，请重新支付或联系客服。", branding.DisplayName, order.OrderNumber)
 
 	if err := s.smsService.SendSMS(ctx, order.CustomerID, "PAYMENT_FAILURE", smsContent); err != nil {
 		g.Log().Error(ctx, "发送支付失败短信通知失败", "error", err)
@@ -141,7 +243,7 @@ func (s *notificationService) SendPaymentFailureNotification(ctx context.Context
 
 	// 邮件通知
 	emailSubject := fmt.Sprintf("支付失败 - %s", order.OrderNumber)
-	emailContent := s.generatePaymentFailureEmailContent(order)
+	emailContent := s.generatePaymentFailureEmailContent(order, s.moneyFormat.Resolve(ctx, order.TenantID), branding)
 
 	if err := s.emailService.SendEmail(ctx, order.CustomerID, emailSubject, emailContent); err != nil {
 		g.Log().Error(ctx, "发送支付失败邮件通知失败", "error", err)
@@ -155,7 +257,8 @@ func (s *notificationService) SendOrderCompletedNotification(ctx context.Context
 	g.Log().Info(ctx, "发送订单完成通知", "order_id", order.ID, "order_number", order.OrderNumber)
 
 	// 短信通知
-	smsContent := fmt.Sprintf("您的订单 %s 已完成，感谢您的使用！如有问题请联系客服。", order.OrderNumber)
+	branding := s.notificationBranding(ctx, order)
+	smsContent := fmt.Sprintf("【%s】您的订单 %s 已完成，感谢您的使用！如有问题请联系客服。", branding.DisplayName, order.OrderNumber)
 
 	if err := s.smsService.SendSMS(ctx, order.CustomerID, "ORDER_COMPLETED", smsContent); err != nil {
 		g.Log().Error(ctx, "发送订单完成短信通知失败", "error", err)
@@ -163,7 +266,7 @@ func (s *notificationService) SendOrderCompletedNotification(ctx context.Context
 
 	// 邮件通知
 	emailSubject := fmt.Sprintf("订单完成 - %s", order.OrderNumber)
-	emailContent := s.generateOrderCompletedEmailContent(order)
+	emailContent := s.generateOrderCompletedEmailContent(order, s.moneyFormat.Resolve(ctx, order.TenantID), branding)
 
 	if err := s.emailService.SendEmail(ctx, order.CustomerID, emailSubject, emailContent); err != nil {
 		g.Log().Error(ctx, "发送订单完成邮件通知失败", "error", err)
@@ -173,7 +276,7 @@ func (s *notificationService) SendOrderCompletedNotification(ctx context.Context
 }
 
 // generateOrderCreatedEmailContent 生成订单创建邮件内容
-func (s *notificationService) generateOrderCreatedEmailContent(order *types.Order) string {
+func (s *notificationService) generateOrderCreatedEmailContent(order *types.Order, moneyFormat *types.MoneyFormatPolicy, branding *types.NotificationBranding) string {
 	return fmt.Sprintf(`
 尊敬的客户，
 
@@ -181,23 +284,24 @@ func (s *notificationService) generateOrderCreatedEmailContent(order *types.Orde
 
 订单信息：
 - 订单编号：%s
-- 订单金额：¥%.2f
+- 订单金额：%s
 - 权益消耗：%.2f
 - 创建时间：%s
 
 请及时支付以完成订单。
 
 此致
-商户系统
+%s
 `,
 		order.OrderNumber,
-		order.TotalAmount,
+		moneyFormat.Format(order.TotalAmount),
 		order.TotalRightsCost,
-		order.CreatedAt.Format("2006-01-02 15:04:05"))
+		order.CreatedAt.Format("2006-01-02 15:04:05"),
+		branding.Signature)
 }
 
 // generatePaymentSuccessEmailContent 生成支付成功邮件内容
-func (s *notificationService) generatePaymentSuccessEmailContent(order *types.Order) string {
+func (s *notificationService) generatePaymentSuccessEmailContent(order *types.Order, moneyFormat *types.MoneyFormatPolicy, branding *types.NotificationBranding) string {
 	return fmt.Sprintf(`
 尊敬的客户，
 
@@ -205,21 +309,22 @@ func (s *notificationService) generatePaymentSuccessEmailContent(order *types.Or
 
 订单信息：
 - 订单编号：%s
-- 支付金额：¥%.2f
+- 支付金额：%s
 - 支付时间：%s
 
 我们将尽快为您处理订单。
 
 此致
-商户系统
+%s
 `,
 		order.OrderNumber,
-		order.TotalAmount,
-		time.Now().Format("2006-01-02 15:04:05"))
+		moneyFormat.Format(order.TotalAmount),
+		time.Now().Format("2006-01-02 15:04:05"),
+		branding.Signature)
 }
 
 // generatePaymentFailureEmailContent 生成支付失败邮件内容
-func (s *notificationService) generatePaymentFailureEmailContent(order *types.Order) string {
+func (s *notificationService) generatePaymentFailureEmailContent(order *types.Order, moneyFormat *types.MoneyFormatPolicy, branding *types.NotificationBranding) string {
 	return fmt.Sprintf(`
 尊敬的客户，
 
@@ -227,19 +332,20 @@ func (s *notificationService) generatePaymentFailureEmailContent(order *types.Or
 
 订单信息：
 - 订单编号：%s
-- 订单金额：¥%.2f
+- 订单金额：%s
 
 请重新尝试支付或联系我们的客服。
 
 此致
-商户系统
+%s
 `,
 		order.OrderNumber,
-		order.TotalAmount)
+		moneyFormat.Format(order.TotalAmount),
+		branding.Signature)
 }
 
 // generateOrderCompletedEmailContent 生成订单完成邮件内容
-func (s *notificationService) generateOrderCompletedEmailContent(order *types.Order) string {
+func (s *notificationService) generateOrderCompletedEmailContent(order *types.Order, moneyFormat *types.MoneyFormatPolicy, branding *types.NotificationBranding) string {
 	return fmt.Sprintf(`
 尊敬的客户，
 
@@ -247,17 +353,20 @@ func (s *notificationService) generateOrderCompletedEmailContent(order *types.Or
 
 订单信息：
 - 订单编号：%s
-- 订单金额：¥%.2f
+- 订单金额：%s
+- 其中税额：%s
 - 完成时间：%s
 
 感谢您的使用！如有任何问题，请联系我们的客服。
 
 此致
-商户系统
+%s
 `,
 		order.OrderNumber,
-		order.TotalAmount,
-		time.Now().Format("2006-01-02 15:04:05"))
+		moneyFormat.Format(order.TotalAmount),
+		moneyFormat.Format(order.TaxAmount),
+		time.Now().Format("2006-01-02 15:04:05"),
+		branding.Signature)
 }
 
 // SendOrderStatusChangedNotification 发送订单状态变更通知
@@ -304,8 +413,12 @@ func (s *notificationService) SendOrderProcessingNotification(ctx context.Contex
 	g.Log().Info(ctx, "发送订单处理中通知", "order_id", order.ID, "order_number", order.OrderNumber)
 
 	// 短信通知
-	smsContent := fmt.Sprintf("您的订单 %s 已开始处理，我们将尽快为您完成订单。",
-		order.OrderNumber)
+	branding := s.notificationBranding(ctx, order)
+	smsContent := fmt.Sprintf("【%s】您的订单 %s 已开始处理，我们将尽快为您完成订单。",
+		branding.DisplayName, order.OrderNumber)
+	if message := s.customerStatusLabel(ctx, order, types.OrderStatusProcessing).Message; message != "" {
+		smsContent += message
+	}
 
 	if err := s.smsService.SendSMS(ctx, order.CustomerID, "ORDER_PROCESSING", smsContent); err != nil {
 		g.Log().Error(ctx, "发送订单处理中短信通知失败", "error", err)
@@ -313,7 +426,7 @@ func (s *notificationService) SendOrderProcessingNotification(ctx context.Contex
 
 	// 邮件通知
 	emailSubject := fmt.Sprintf("订单处理中 - %s", order.OrderNumber)
-	emailContent := s.generateOrderProcessingEmailContent(order)
+	emailContent := s.generateOrderProcessingEmailContent(order, s.moneyFormat.Resolve(ctx, order.TenantID), branding)
 
 	if err := s.emailService.SendEmail(ctx, order.CustomerID, emailSubject, emailContent); err != nil {
 		g.Log().Error(ctx, "发送订单处理中邮件通知失败", "error", err)
@@ -330,8 +443,12 @@ func (s *notificationService) SendOrderCancelledNotification(ctx context.Context
 		"reason", reason)
 
 	// 短信通知
-	smsContent := fmt.Sprintf("您的订单 %s 已被取消，原因：%s。如有疑问请联系客服。",
-		order.OrderNumber, reason)
+	branding := s.notificationBranding(ctx, order)
+	smsContent := fmt.Sprintf("【%s】您的订单 %s 已被取消，原因：%s。如有疑问请联系客服。",
+		branding.DisplayName, order.OrderNumber, reason)
+	if message := s.customerStatusLabel(ctx, order, types.OrderStatusCancelled).Message; message != "" {
+		smsContent += message
+	}
 
 	if err := s.smsService.SendSMS(ctx, order.CustomerID, "ORDER_CANCELLED", smsContent); err != nil {
 		g.Log().Error(ctx, "发送订单取消短信通知失败", "error", err)
@@ -339,7 +456,7 @@ func (s *notificationService) SendOrderCancelledNotification(ctx context.Context
 
 	// 邮件通知
 	emailSubject := fmt.Sprintf("订单取消 - %s", order.OrderNumber)
-	emailContent := s.generateOrderCancelledEmailContent(order, reason)
+	emailContent := s.generateOrderCancelledEmailContent(order, reason, s.moneyFormat.Resolve(ctx, order.TenantID), branding)
 
 	if err := s.emailService.SendEmail(ctx, order.CustomerID, emailSubject, emailContent); err != nil {
 		g.Log().Error(ctx, "发送订单取消邮件通知失败", "error", err)
@@ -350,13 +467,15 @@ func (s *notificationService) SendOrderCancelledNotification(ctx context.Context
 
 // sendGenericStatusChangeNotification 发送通用状态变更通知
 func (s *notificationService) sendGenericStatusChangeNotification(ctx context.Context, order *types.Order, statusHistory *types.OrderStatusHistory) error {
-	// 获取状态中文名称
-	fromStatusName := s.getOrderStatusDisplayName(s.orderStatusIntToString(statusHistory.FromStatus))
-	toStatusName := s.getOrderStatusDisplayName(s.orderStatusIntToString(statusHistory.ToStatus))
+	// 按租户配置获取面向客户的状态名称，未配置时使用内置中文名称
+	fromStatus := s.customerStatusLabel(ctx, order, s.orderStatusIntToString(statusHistory.FromStatus))
+	toStatus := s.customerStatusLabel(ctx, order, s.orderStatusIntToString(statusHistory.ToStatus))
+	fromStatusName, toStatusName := fromStatus.Label, toStatus.Label
 
 	// 短信通知
-	smsContent := fmt.Sprintf("您的订单 %s 状态已从 %s 变更为 %s。",
-		order.OrderNumber, fromStatusName, toStatusName)
+	branding := s.notificationBranding(ctx, order)
+	smsContent := fmt.Sprintf("【%s】您的订单 %s 状态已从 %s 变更为 %s。%s",
+		branding.DisplayName, order.OrderNumber, fromStatusName, toStatusName, toStatus.Message)
 
 	if err := s.smsService.SendSMS(ctx, order.CustomerID, "ORDER_STATUS_CHANGED", smsContent); err != nil {
 		g.Log().Error(ctx, "发送订单状态变更短信通知失败", "error", err)
@@ -364,7 +483,7 @@ func (s *notificationService) sendGenericStatusChangeNotification(ctx context.Co
 
 	// 邮件通知
 	emailSubject := fmt.Sprintf("订单状态变更 - %s", order.OrderNumber)
-	emailContent := s.generateOrderStatusChangeEmailContent(order, statusHistory, fromStatusName, toStatusName)
+	emailContent := s.generateOrderStatusChangeEmailContent(order, statusHistory, fromStatusName, toStatusName, toStatus.Message, s.moneyFormat.Resolve(ctx, order.TenantID), branding)
 
 	if err := s.emailService.SendEmail(ctx, order.CustomerID, emailSubject, emailContent); err != nil {
 		g.Log().Error(ctx, "发送订单状态变更邮件通知失败", "error", err)
@@ -374,7 +493,7 @@ func (s *notificationService) sendGenericStatusChangeNotification(ctx context.Co
 }
 
 // generateOrderProcessingEmailContent 生成订单处理中邮件内容
-func (s *notificationService) generateOrderProcessingEmailContent(order *types.Order) string {
+func (s *notificationService) generateOrderProcessingEmailContent(order *types.Order, moneyFormat *types.MoneyFormatPolicy, branding *types.NotificationBranding) string {
 	return fmt.Sprintf(`
 尊敬的客户，
 
@@ -382,21 +501,22 @@ func (s *notificationService) generateOrderProcessingEmailContent(order *types.O
 
 订单信息：
 - 订单编号：%s
-- 订单金额：¥%.2f
+- 订单金额：%s
 - 处理时间：%s
 
 我们将尽快为您完成订单，请耐心等待。
 
 此致
-商户系统
+%s
 `,
 		order.OrderNumber,
-		order.TotalAmount,
-		time.Now().Format("2006-01-02 15:04:05"))
+		moneyFormat.Format(order.TotalAmount),
+		time.Now().Format("2006-01-02 15:04:05"),
+		branding.Signature)
 }
 
 // generateOrderCancelledEmailContent 生成订单取消邮件内容
-func (s *notificationService) generateOrderCancelledEmailContent(order *types.Order, reason string) string {
+func (s *notificationService) generateOrderCancelledEmailContent(order *types.Order, reason string, moneyFormat *types.MoneyFormatPolicy, branding *types.NotificationBranding) string {
 	return fmt.Sprintf(`
 尊敬的客户，
 
@@ -404,64 +524,59 @@ func (s *notificationService) generateOrderCancelledEmailContent(order *types.Or
 
 订单信息：
 - 订单编号：%s
-- 订单金额：¥%.2f
+- 订单金额：%s
 - 取消时间：%s
 - 取消原因：%s
 
-如有任何疑问，请联系我们的客服。
+%s
 
 此致
-商户系统
+%s
 `,
 		order.OrderNumber,
-		order.TotalAmount,
+		moneyFormat.Format(order.TotalAmount),
 		time.Now().Format("2006-01-02 15:04:05"),
-		reason)
+		reason,
+		branding.SupportLine(),
+		branding.Signature)
 }
 
 // generateOrderStatusChangeEmailContent 生成订单状态变更邮件内容
-func (s *notificationService) generateOrderStatusChangeEmailContent(order *types.Order, statusHistory *types.OrderStatusHistory, fromStatusName, toStatusName string) string {
+func (s *notificationService) generateOrderStatusChangeEmailContent(order *types.Order, statusHistory *types.OrderStatusHistory, fromStatusName, toStatusName, statusMessage string, moneyFormat *types.MoneyFormatPolicy, branding *types.NotificationBranding) string {
+	if statusMessage != "" {
+		statusMessage += "\n"
+	}
 	return fmt.Sprintf(`
 尊敬的客户，
 
 您的订单状态已发生变更。
-
+%s
 订单信息：
 - 订单编号：%s
-- 订单金额：¥%.2f
+- 订单金额：%s
 - 状态变更：%s → %s
 - 变更时间：%s
 - 变更原因：%s
 
-如有任何疑问，请联系我们的客服。
+%s
 
 此致
-商户系统
+%s
 `,
+		statusMessage,
 		order.OrderNumber,
-		order.TotalAmount,
+		moneyFormat.Format(order.TotalAmount),
 		fromStatusName,
 		toStatusName,
 		statusHistory.CreatedAt.Format("2006-01-02 15:04:05"),
-		statusHistory.Reason)
+		statusHistory.Reason,
+		branding.SupportLine(),
+		branding.Signature)
 }
 
-// getOrderStatusDisplayName 获取订单状态显示名称
+// getOrderStatusDisplayName 获取订单状态的内置中文名称，商户和管理员通知始终使用内置名称
 func (s *notificationService) getOrderStatusDisplayName(status types.OrderStatus) string {
-	switch status {
-	case types.OrderStatusPending:
-		return "待支付"
-	case types.OrderStatusPaid:
-		return "已支付"
-	case types.OrderStatusProcessing:
-		return "处理中"
-	case types.OrderStatusCompleted:
-		return "已完成"
-	case types.OrderStatusCancelled:
-		return "已取消"
-	default:
-		return "未知状态"
-	}
+	return orderStatusDisplayName(status)
 }
 
 // SendMerchantOrderNotification 发送商户端订单状态变更通知
@@ -486,15 +601,32 @@ func (s *notificationService) SendMerchantOrderNotification(ctx context.Context,
 	fromStatusName := s.getOrderStatusDisplayName(s.orderStatusIntToString(statusHistory.FromStatus))
 	toStatusName := s.getOrderStatusDisplayName(s.orderStatusIntToString(statusHistory.ToStatus))
 
+	// 开启汇总的商户，非紧急通知合并到定时发送的汇总中，WebSocket 实时推送不受影响
+	digested := false
+	if s.merchantDigester != nil {
+		summary := fmt.Sprintf("订单 %s 状态从 %s 变更为 %s", order.OrderNumber, fromStatusName, toStatusName)
+		var err error
+		digested, err = s.merchantDigester.DeferMerchantOrderNotification(ctx, order,
+			merchantOrderNotificationEvent(statusHistory.ToStatus), summary, merchantAdminIDs)
+		if err != nil {
+			// 无法判断或记录时立即发送，避免丢失通知
+			g.Log().Warning(ctx, "商户通知汇总失败，立即发送", "merchant_id", order.MerchantID, "error", err)
+		}
+	}
+
 	// 为每个商户管理员发送通知
 	for _, adminID := range merchantAdminIDs {
 		// 发送WebSocket实时通知
 		if s.webSocketNotifier != nil {
 			s.webSocketNotifier.SendOrderStatusChangeToUser(ctx, adminID, order.TenantID, order, statusHistory)
 		}
+		if digested {
+			continue
+		}
 		
 		// 发送短信通知（如果配置了）
-		go func(userID uint64) {
+		userID := adminID
+		s.dispatch(ctx, userID, func(ctx context.Context) {
 			smsContent := fmt.Sprintf("商户订单状态变更：订单 %s 状态从 %s 变更为 %s。",
 				order.OrderNumber, fromStatusName, toStatusName)
 			
@@ -508,17 +640,21 @@ func (s *notificationService) SendMerchantOrderNotification(ctx context.Context,
 			g.Log().Info(ctx, "商户端短信通知（模拟）", 
 				"user_id", userID, 
 				"content", smsContent)
-		}(adminID)
+		})
 		
 		// 发送邮件通知
-		go func(userID uint64) {
+		s.dispatch(ctx, userID, func(ctx context.Context) {
 			emailSubject := fmt.Sprintf("商户订单状态变更 - %s", order.OrderNumber)
-			emailContent := s.generateMerchantOrderStatusChangeEmailContent(order, statusHistory, fromStatusName, toStatusName)
+			emailContent := s.generateMerchantOrderStatusChangeEmailContent(order, statusHistory, fromStatusName, toStatusName, s.moneyFormat.Resolve(ctx, order.TenantID))
 			
 			if err := s.emailService.SendEmail(ctx, userID, emailSubject, emailContent); err != nil {
 				g.Log().Error(ctx, "发送商户端邮件通知失败", "error", err, "user_id", userID)
 			}
-		}(adminID)
+		})
+	}
+
+	if digested {
+		return nil
 	}
 
 	// 发送系统内通知
@@ -527,20 +663,226 @@ func (s *notificationService) SendMerchantOrderNotification(ctx context.Context,
 		message := fmt.Sprintf("订单 %s 状态从 %s 变更为 %s，请及时处理。原因：%s",
 			order.OrderNumber, fromStatusName, toStatusName, statusHistory.Reason)
 		
-		go func(userID uint64) {
+		userID := adminID
+		s.dispatch(ctx, userID, func(ctx context.Context) {
 			// 这里应该调用系统通知服务
 			g.Log().Info(ctx, "商户端系统通知（模拟）",
 				"user_id", userID,
 				"title", title,
 				"message", message)
-		}(adminID)
+		})
+	}
+
+	return nil
+}
+
+// SendOrderSLABreachNotification 发送订单处理超时提醒，提醒属于紧急通知，不受免打扰时段和汇总设置影响
+func (s *notificationService) SendOrderSLABreachNotification(ctx context.Context, breach *types.OrderSLABreach) error {
+	merchantAdminIDs := s.getMerchantAdminUserIDs(ctx, breach.MerchantID)
+	if len(merchantAdminIDs) == 0 {
+		g.Log().Warning(ctx, "未找到商户管理员，跳过订单处理超时提醒",
+			"merchant_id", breach.MerchantID,
+			"order_id", breach.OrderID)
+		return nil
 	}
 
+	ctx = WithUrgentNotification(ctx)
+	statusName := s.getOrderStatusDisplayName(breach.Status.ToOrderStatus())
+	subject := fmt.Sprintf("订单处理超时提醒 - %s", breach.OrderNumber)
+	content := fmt.Sprintf(`
+尊敬的商户管理员，
+
+以下订单已超过处理时限，请尽快处理。
+
+订单号：%s
+当前状态：%s
+进入该状态时间：%s
+处理时限：%d 分钟
+已超时：%d 分钟
+
+此邮件由系统自动发送，请勿回复。
+`, breach.OrderNumber, statusName, breach.EnteredAt.Format("2006-01-02 15:04:05"),
+		breach.SLASeconds/60, breach.OverdueSeconds/60)
+
+	for _, adminID := range merchantAdminIDs {
+		if err := s.emailService.SendEmail(ctx, adminID, subject, content); err != nil {
+			g.Log().Error(ctx, "发送订单处理超时提醒失败", "error", err, "user_id", adminID)
+		}
+	}
 	return nil
 }
 
+// SendRightsThresholdNotification 发送权益余额阈值提醒：越过紧急阈值属于紧急通知，立即发送；
+// 越过预警阈值按商户通知偏好转入汇总，立即发送时受免打扰时段限制
+func (s *notificationService) SendRightsThresholdNotification(ctx context.Context, threshold *types.MerchantRightsThreshold) error {
+	merchantAdminIDs := s.getMerchantAdminUserIDs(ctx, threshold.MerchantID)
+	if len(merchantAdminIDs) == 0 {
+		g.Log().Warning(ctx, "未找到商户管理员，跳过权益余额阈值提醒", "merchant_id", threshold.MerchantID)
+		return nil
+	}
+
+	event := NotificationEventRightsWarning
+	if threshold.Level == types.RightsThresholdCritical {
+		event = NotificationEventRightsCritical
+		ctx = WithUrgentNotification(ctx)
+	}
+
+	if s.merchantDigester != nil {
+		digested, err := s.merchantDigester.DeferMerchantNotification(ctx, threshold.MerchantID, event, threshold.Summary(), merchantAdminIDs)
+		if err != nil {
+			// 无法判断或记录时立即发送，避免丢失提醒
+			g.Log().Warning(ctx, "商户通知汇总失败，立即发送", "merchant_id", threshold.MerchantID, "error", err)
+		} else if digested {
+			return nil
+		}
+	}
+
+	subject := fmt.Sprintf("权益余额低于%s提醒 - %s", threshold.Level.DisplayName(), threshold.MerchantName)
+	content := fmt.Sprintf(`
+尊敬的商户管理员，
+
+商户 %s 的权益可用余额已低于%s，请及时充值，避免影响订单支付。
+
+当前可用余额：%.2f
+%s：%.2f
+%s
+
+此邮件由系统自动发送，请勿回复。
+`, threshold.MerchantName, threshold.Level.DisplayName(), threshold.AvailableBalance,
+		threshold.Level.DisplayName(), threshold.Threshold, threshold.DepletionSummary())
+
+	for _, adminID := range merchantAdminIDs {
+		if err := s.emailService.SendEmail(ctx, adminID, subject, content); err != nil {
+			g.Log().Error(ctx, "发送权益余额阈值提醒失败", "error", err, "user_id", adminID)
+		}
+	}
+	return nil
+}
+
+// SendOrderDisputeNotification 发送订单争议通知：争议发起或状态变更时通知下单客户和商户管理员
+func (s *notificationService) SendOrderDisputeNotification(ctx context.Context, order *types.Order, dispute *types.OrderDispute) error {
+	statusName := s.getDisputeStatusDisplayName(dispute.Status)
+	branding := s.notificationBranding(ctx, order)
+	subject := fmt.Sprintf("订单争议%s - %s", statusName, order.OrderNumber)
+
+	resolution := ""
+	if dispute.ResolutionNotes != "" {
+		resolution = fmt.Sprintf("处理说明：%s\n", dispute.ResolutionNotes)
+	}
+	details := fmt.Sprintf(`订单号：%s
+争议编号：%d
+争议原因：%s
+问题描述：%s
+当前状态：%s
+%s`, order.OrderNumber, dispute.ID, s.getDisputeReasonDisplayName(dispute.Reason), dispute.Description, statusName, resolution)
+
+	customerContent := fmt.Sprintf(`
+尊敬的客户，
+
+您对订单提出的争议状态已更新。
+
+%s
+%s
+
+此致
+%s
+`, details, branding.SupportLine(), branding.Signature)
+	if err := s.emailService.SendEmail(ctx, order.CustomerID, subject, customerContent); err != nil {
+		g.Log().Error(ctx, "发送订单争议客户通知失败", "error", err, "dispute_id", dispute.ID)
+	}
+
+	merchantContent := fmt.Sprintf(`
+尊敬的商户管理员，
+
+订单争议状态已更新，争议处理期间该订单不会自动完成。
+
+%s
+此邮件由系统自动发送，请勿回复。
+`, details)
+	for _, adminID := range s.getMerchantAdminUserIDs(ctx, order.MerchantID) {
+		if err := s.emailService.SendEmail(ctx, adminID, subject, merchantContent); err != nil {
+			g.Log().Error(ctx, "发送订单争议商户通知失败", "error", err, "user_id", adminID)
+		}
+	}
+	return nil
+}
+
+// SendCartRecoveryNotification 发送购物车找回提醒，列出重新校验后仍可购买的商品及当前价格；
+// 购物车可能包含多个商户的商品，使用租户默认品牌
+func (s *notificationService) SendCartRecoveryNotification(ctx context.Context, recovery *types.CartRecovery) error {
+	cart := recovery.Cart
+	branding := s.templateManager.branding.Resolve(ctx, cart.TenantID, 0)
+	moneyFormat := s.moneyFormat.Resolve(ctx, cart.TenantID)
+
+	var items strings.Builder
+	for _, item := range recovery.Items {
+		fmt.Fprintf(&items, "%s × %d，单价 %s\n", item.Name, item.Quantity, moneyFormat.Format(item.UnitPrice))
+	}
+	unavailable := ""
+	if len(recovery.Unavailable) > 0 {
+		unavailable = fmt.Sprintf("另有 %d 件商品已下架或暂时缺货，未列出。\n", len(recovery.Unavailable))
+	}
+
+	subject := "您的购物车中还有商品未结算"
+	content := fmt.Sprintf(`
+尊敬的客户，
+
+您的购物车中还有以下商品未结算：
+
+%s
+合计：%s
+%s
+商品价格和库存以下单时为准。如不希望再收到此类提醒，可在购物车设置中关闭。
+
+%s
+
+此致
+%s
+`, items.String(), moneyFormat.Format(recovery.TotalAmount), unavailable, branding.SupportLine(), branding.Signature)
+	if err := s.emailService.SendEmail(ctx, cart.CustomerID, subject, content); err != nil {
+		g.Log().Error(ctx, "发送购物车找回邮件失败", "error", err, "cart_id", cart.ID)
+	}
+
+	smsContent := fmt.Sprintf("【%s】您的购物车中还有%d件商品未结算，合计%s，欢迎回来完成购买。",
+		branding.DisplayName, len(recovery.Items), moneyFormat.Format(recovery.TotalAmount))
+	if err := s.smsService.SendSMS(ctx, cart.CustomerID, SMS_TEMPLATE_CART_RECOVERY, smsContent); err != nil {
+		g.Log().Error(ctx, "发送购物车找回短信失败", "error", err, "cart_id", cart.ID)
+	}
+	return nil
+}
+
+// getDisputeStatusDisplayName 获取争议状态显示名称
+func (s *notificationService) getDisputeStatusDisplayName(status types.OrderDisputeStatus) string {
+	switch status {
+	case types.OrderDisputeStatusOpen:
+		return "已提交"
+	case types.OrderDisputeStatusInvestigating:
+		return "调查中"
+	case types.OrderDisputeStatusResolved:
+		return "已解决"
+	case types.OrderDisputeStatusRejected:
+		return "已驳回"
+	default:
+		return "未知状态"
+	}
+}
+
+// getDisputeReasonDisplayName 获取争议原因显示名称
+func (s *notificationService) getDisputeReasonDisplayName(reason types.OrderDisputeReason) string {
+	switch reason {
+	case types.OrderDisputeReasonWrongItem:
+		return "商品错发"
+	case types.OrderDisputeReasonNotReceived:
+		return "未收到商品"
+	case types.OrderDisputeReasonDamaged:
+		return "商品损坏"
+	default:
+		return "其他"
+	}
+}
+
 // generateMerchantOrderStatusChangeEmailContent 生成商户端订单状态变更邮件内容
-func (s *notificationService) generateMerchantOrderStatusChangeEmailContent(order *types.Order, statusHistory *types.OrderStatusHistory, fromStatusName, toStatusName string) string {
+func (s *notificationService) generateMerchantOrderStatusChangeEmailContent(order *types.Order, statusHistory *types.OrderStatusHistory, fromStatusName, toStatusName string, moneyFormat *types.MoneyFormatPolicy) string {
 	operatorTypeName := s.getOperatorTypeDisplayName(statusHistory.OperatorType)
 	
 	return fmt.Sprintf(`
@@ -551,7 +893,7 @@ func (s *notificationService) generateMerchantOrderStatusChangeEmailContent(orde
 订单信息：
 - 订单编号：%s
 - 客户ID：%d
-- 订单金额：¥%.2f
+- 订单金额：%s
 - 状态变更：%s → %s
 - 变更时间：%s
 - 变更原因：%s
@@ -564,7 +906,7 @@ func (s *notificationService) generateMerchantOrderStatusChangeEmailContent(orde
 `,
 		order.OrderNumber,
 		order.CustomerID,
-		order.TotalAmount,
+		moneyFormat.Format(order.TotalAmount),
 		fromStatusName,
 		toStatusName,
 		statusHistory.CreatedAt.Format("2006-01-02 15:04:05"),
@@ -623,11 +965,57 @@ func (s *notificationService) getMerchantAdminUserIDs(ctx context.Context, merch
 	return adminIDs
 }
 
+// SendFulfillmentStatusNotification 发送履约单（包裹）状态变更通知。
+// 已送达的包裹转为已完成时不重复通知，整单完成通知只在全部包裹首次都送达的那次变更时发送
+func (s *notificationService) SendFulfillmentStatusNotification(ctx context.Context, order *types.Order, change *types.FulfillmentStatusChange) error {
+	fulfillment := change.Fulfillment
+	g.Log().Info(ctx, "发送履约单状态变更通知",
+		"order_id", order.ID,
+		"order_number", order.OrderNumber,
+		"fulfillment_id", fulfillment.ID,
+		"from_status", change.PreviousStatus,
+		"status", fulfillment.Status)
+
+	event, notify := fulfillmentNotificationEvent(fulfillment.Status)
+	if previous, _ := fulfillmentNotificationEvent(change.PreviousStatus); notify && previous != event {
+		data := s.templateManager.BuildFulfillmentDataMap(ctx, order, fulfillment, change.Fulfillments)
+		data[MoneyFormatDataKey] = s.moneyFormat.Resolve(ctx, order.TenantID)
+		if err := s.sendTemplateNotification(ctx, order.CustomerID, NotificationCategoryCustomer, event, data); err != nil {
+			return err
+		}
+	}
+
+	if change.CompletesOrder() {
+		return s.SendOrderCompletedNotification(ctx, order)
+	}
+	return nil
+}
+
+// fulfillmentNotificationEvent 履约单状态对应的客户通知事件，待发货等状态不通知客户
+func fulfillmentNotificationEvent(status types.FulfillmentStatus) (NotificationEvent, bool) {
+	switch status {
+	case types.FulfillmentStatusShipped:
+		return NotificationEventFulfillmentShipped, true
+	case types.FulfillmentStatusDelivered, types.FulfillmentStatusCompleted:
+		return NotificationEventFulfillmentDelivered, true
+	}
+	return "", false
+}
+
 // sendNotificationByTemplate 使用模板发送通知的通用方法
 func (s *notificationService) sendNotificationByTemplate(ctx context.Context, userID uint64, category NotificationCategory, event NotificationEvent, order *types.Order, statusHistory *types.OrderStatusHistory) error {
 	// 构建模板数据
-	data := s.templateManager.BuildOrderDataMap(order, statusHistory)
-	
+	data := s.templateManager.BuildOrderDataMap(ctx, order, statusHistory)
+	data[MoneyFormatDataKey] = s.moneyFormat.Resolve(ctx, order.TenantID)
+	return s.sendTemplateNotification(ctx, userID, category, event, data)
+}
+
+// sendTemplateNotification 使用已构建的模板数据发送短信和邮件通知
+func (s *notificationService) sendTemplateNotification(ctx context.Context, userID uint64, category NotificationCategory, event NotificationEvent, data map[string]interface{}) error {
+	if urgentNotificationEvents[event] {
+		ctx = WithUrgentNotification(ctx)
+	}
+
 	// 发送短信通知
 	if smsTemplate := s.templateManager.GetTemplate(NotificationMethodTypeSMS, category, event, "zh-CN"); smsTemplate != nil && smsTemplate.Enabled {
 		_, smsContent, err := s.templateManager.RenderTemplate(smsTemplate, data)
@@ -678,6 +1066,10 @@ func (s *notificationService) buildSMSTemplateCode(event NotificationEvent) stri
 		return "ORDER_CANCELLED"
 	case NotificationEventOrderStatusChanged:
 		return "ORDER_STATUS_CHANGED"
+	case NotificationEventFulfillmentShipped:
+		return "FULFILLMENT_SHIPPED"
+	case NotificationEventFulfillmentDelivered:
+		return "FULFILLMENT_DELIVERED"
 	default:
 		return "ORDER_STATUS_CHANGED"
 	}
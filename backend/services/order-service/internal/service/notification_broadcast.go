@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	// 默认的群发通知发送频率
+	defaultBroadcastCheckInterval = time.Minute
+	// 每个群发通知每次发送的默认接收人数，控制群发对短信和邮件通道的压力
+	defaultBroadcastBatchSize = 100
+	// 每个租户 24 小时内默认最多创建的群发通知数
+	defaultBroadcastMaxPerDay = 3
+	// 群发通知次数限制的统计窗口
+	broadcastRateLimitWindow = 24 * time.Hour
+	// 每次检查最多处理的群发通知数，未处理的在下次检查时继续
+	broadcastSendingLimit = 20
+)
+
+// ErrBroadcastNotFound 群发通知不存在或不属于当前租户
+var ErrBroadcastNotFound = errors.New("群发通知不存在")
+
+// BroadcastService 群发通知服务：租户按筛选条件向客户或商户用户群发消息。
+// 创建后由后台任务按批发送，每批接收人数受限；已退订群发通知的用户不发送，非紧急通知同样受免打扰时段限制
+type BroadcastService struct {
+	broadcastRepo repository.INotificationBroadcastRepository
+	smsService    SMSService
+	emailService  EmailService
+	batchSize     int
+	maxPerDay     int
+	interval      time.Duration
+	now           func() time.Time
+	stopCh        chan struct{}
+	isRunning     bool
+}
+
+// NewBroadcastService 创建群发通知服务实例
+func NewBroadcastService() *BroadcastService {
+	ctx := context.Background()
+	interval := g.Cfg().MustGet(ctx, "broadcast.checkInterval", defaultBroadcastCheckInterval).Duration()
+	if interval <= 0 {
+		interval = defaultBroadcastCheckInterval
+	}
+	batchSize := g.Cfg().MustGet(ctx, "broadcast.batchSize", defaultBroadcastBatchSize).Int()
+	if batchSize <= 0 {
+		batchSize = defaultBroadcastBatchSize
+	}
+	maxPerDay := g.Cfg().MustGet(ctx, "broadcast.maxPerDay", defaultBroadcastMaxPerDay).Int()
+	if maxPerDay <= 0 {
+		maxPerDay = defaultBroadcastMaxPerDay
+	}
+
+	// 与订单通知使用相同的发送通道，免打扰时段内延迟的消息由发件箱投递器发送
+	quietHours := NewQuietHoursGate(NewTenantQuietHoursPolicyProvider(repository.NewTenantRepository()), repository.NewOutboxRepository())
+	return &BroadcastService{
+		broadcastRepo: repository.NewNotificationBroadcastRepository(),
+		smsService:    NewQuietHoursSMSService(NewSMSService(), quietHours),
+		emailService:  NewQuietHoursEmailService(NewEmailService(), quietHours),
+		batchSize:     batchSize,
+		maxPerDay:     maxPerDay,
+		interval:      interval,
+		now:           time.Now,
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// NewBroadcastServiceForTest 创建测试用群发通知服务实例
+func NewBroadcastServiceForTest(broadcastRepo repository.INotificationBroadcastRepository, smsService SMSService, emailService EmailService, batchSize, maxPerDay int, now func() time.Time) *BroadcastService {
+	return &BroadcastService{
+		broadcastRepo: broadcastRepo,
+		smsService:    smsService,
+		emailService:  emailService,
+		batchSize:     batchSize,
+		maxPerDay:     maxPerDay,
+		interval:      defaultBroadcastCheckInterval,
+		now:           now,
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// Start 启动后台群发通知发送
+func (s *BroadcastService) Start(ctx context.Context) {
+	if s.isRunning {
+		return
+	}
+	s.isRunning = true
+	g.Log().Info(ctx, "启动群发通知发送",
+		"interval", s.interval,
+		"batch_size", s.batchSize)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+				if _, err := s.SendPending(ctx); err != nil {
+					g.Log().Error(ctx, "发送群发通知失败", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止后台群发通知发送
+func (s *BroadcastService) Stop(ctx context.Context) {
+	if !s.isRunning {
+		return
+	}
+	s.isRunning = false
+	close(s.stopCh)
+	g.Log().Info(ctx, "群发通知发送已停止")
+}
+
+// Preview 预览符合筛选条件的接收人数，已退订的接收人不会收到通知
+func (s *BroadcastService) Preview(ctx context.Context, filter *types.BroadcastAudienceFilter) (*types.BroadcastPreview, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	total, optedOut, err := s.broadcastRepo.CountRecipients(ctx, filter, s.now())
+	if err != nil {
+		return nil, err
+	}
+	return &types.BroadcastPreview{
+		Recipients:  total,
+		OptedOut:    optedOut,
+		Deliverable: total - optedOut,
+	}, nil
+}
+
+// Create 创建群发通知，由后台任务按批发送。租户 24 小时内创建的群发通知达到上限时返回 ErrBroadcastRateLimited
+func (s *BroadcastService) Create(ctx context.Context, userID uint64, req *types.CreateBroadcastRequest) (*types.NotificationBroadcast, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	count, err := s.broadcastRepo.CountSince(ctx, now.Add(-broadcastRateLimitWindow))
+	if err != nil {
+		return nil, err
+	}
+	if count >= s.maxPerDay {
+		return nil, fmt.Errorf("%w（24小时内最多%d次）", types.ErrBroadcastRateLimited, s.maxPerDay)
+	}
+
+	total, _, err := s.broadcastRepo.CountRecipients(ctx, &req.Filter, now)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make(types.StringArray, 0, len(req.Channels))
+	for _, channel := range req.Channels {
+		channels = append(channels, string(channel))
+	}
+	broadcast := &types.NotificationBroadcast{
+		Subject:         req.Subject,
+		Content:         req.Content,
+		Channels:        channels,
+		Filter:          req.Filter,
+		Status:          types.BroadcastStatusSending,
+		CreatedBy:       userID,
+		TotalRecipients: total,
+		CreatedAt:       now,
+	}
+	if err := s.broadcastRepo.Create(ctx, broadcast); err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "notification_broadcast", "create", map[string]interface{}{
+		"broadcast_id": broadcast.ID,
+		"audience":     broadcast.Filter.Audience,
+		"channels":     broadcast.Channels,
+		"recipients":   total,
+	})
+	g.Log().Info(ctx, "已创建群发通知",
+		"broadcast_id", broadcast.ID,
+		"audience", broadcast.Filter.Audience,
+		"recipients", total)
+	return broadcast, nil
+}
+
+// Get 获取当前租户的群发通知及发送进度
+func (s *BroadcastService) Get(ctx context.Context, id uint64) (*types.NotificationBroadcast, error) {
+	broadcast, err := s.broadcastRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if broadcast == nil {
+		return nil, ErrBroadcastNotFound
+	}
+	return broadcast, nil
+}
+
+// List 获取当前租户最近的群发通知
+func (s *BroadcastService) List(ctx context.Context, req *types.BroadcastListRequest) ([]types.NotificationBroadcast, error) {
+	return s.broadcastRepo.List(ctx, req.EffectiveLimit())
+}
+
+// ListDeliveries 获取群发通知的发送记录，status 为空时返回全部记录
+func (s *BroadcastService) ListDeliveries(ctx context.Context, id uint64, status types.BroadcastDeliveryStatus, req *types.BroadcastListRequest) ([]types.BroadcastDelivery, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.broadcastRepo.ListDeliveries(ctx, id, status, req.EffectiveLimit())
+}
+
+// SendPending 为所有租户发送中的群发通知各发送一批，返回本次处理的接收人数
+func (s *BroadcastService) SendPending(ctx context.Context) (int, error) {
+	broadcasts, err := s.broadcastRepo.ListSending(ctx, broadcastSendingLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range broadcasts {
+		count, err := s.sendBatch(ctx, &broadcasts[i])
+		if err != nil {
+			g.Log().Error(ctx, "发送群发通知失败", "broadcast_id", broadcasts[i].ID, "error", err)
+			continue
+		}
+		processed += count
+	}
+	return processed, nil
+}
+
+// sendBatch 向下一批接收人发送群发通知并记录发送结果，返回处理的接收人数。
+// 接收人不足一批时该通知发送完成
+func (s *BroadcastService) sendBatch(ctx context.Context, broadcast *types.NotificationBroadcast) (int, error) {
+	// 定时任务没有请求上下文，按群发通知所属租户设置租户上下文
+	tenantCtx := context.WithValue(ctx, "tenant_id", broadcast.TenantID)
+
+	recipients, err := s.broadcastRepo.ListRecipients(tenantCtx, &broadcast.Filter, broadcast.CreatedAt, broadcast.CursorUserID, s.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var completedAt *time.Time
+	if len(recipients) < s.batchSize {
+		now := s.now()
+		completedAt = &now
+	}
+	if len(recipients) == 0 {
+		return 0, s.broadcastRepo.RecordBatch(tenantCtx, broadcast.ID, nil, types.BroadcastProgress{}, completedAt)
+	}
+
+	// 先推进进度再发送，多个实例同时处理时只有推进成功的一方发送这批接收人
+	claimed, err := s.broadcastRepo.ClaimBatch(tenantCtx, broadcast.ID, broadcast.CursorUserID, recipients[len(recipients)-1].UserID)
+	if err != nil || !claimed {
+		return 0, err
+	}
+
+	var deliveries []types.BroadcastDelivery
+	var progress types.BroadcastProgress
+	for i := range recipients {
+		recipientDeliveries := s.deliver(tenantCtx, broadcast, &recipients[i])
+		progress.Record(recipientDeliveries)
+		deliveries = append(deliveries, recipientDeliveries...)
+	}
+	if err := s.broadcastRepo.RecordBatch(tenantCtx, broadcast.ID, deliveries, progress, completedAt); err != nil {
+		return 0, err
+	}
+	broadcast.Apply(progress)
+
+	g.Log().Info(ctx, "已发送一批群发通知",
+		"tenant_id", broadcast.TenantID,
+		"broadcast_id", broadcast.ID,
+		"recipients", progress.Processed,
+		"sent", progress.Sent,
+		"failed", progress.Failed,
+		"skipped", progress.Skipped,
+		"completed", completedAt != nil)
+	return progress.Processed, nil
+}
+
+// deliver 通过群发通知的各个渠道向接收人发送消息，已退订的接收人记录为跳过
+func (s *BroadcastService) deliver(ctx context.Context, broadcast *types.NotificationBroadcast, recipient *types.BroadcastRecipient) []types.BroadcastDelivery {
+	if recipient.OptedOut {
+		return []types.BroadcastDelivery{{UserID: recipient.UserID, Status: types.BroadcastDeliverySkipped}}
+	}
+
+	content, renderErr := broadcast.Render(recipient)
+	deliveries := make([]types.BroadcastDelivery, 0, len(broadcast.Channels))
+	for _, channel := range broadcast.Channels {
+		delivery := types.BroadcastDelivery{
+			UserID:  recipient.UserID,
+			Channel: types.NotificationChannel(channel),
+			Status:  types.BroadcastDeliverySent,
+		}
+
+		err := renderErr
+		if err == nil {
+			switch delivery.Channel {
+			case types.NotificationChannelSMS:
+				err = s.smsService.SendSMS(ctx, recipient.UserID, SMS_TEMPLATE_BROADCAST, content)
+			case types.NotificationChannelEmail:
+				err = s.emailService.SendEmail(ctx, recipient.UserID, broadcast.Subject, content)
+			default:
+				err = fmt.Errorf("不支持的发送渠道: %s", channel)
+			}
+		}
+		if err != nil {
+			delivery.Status = types.BroadcastDeliveryFailed
+			delivery.Error = err.Error()
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries
+}
+
+// GetPreference 获取用户的群发通知偏好，未设置时默认接收
+func (s *BroadcastService) GetPreference(ctx context.Context, userID uint64) (*types.BroadcastPreference, error) {
+	preference, err := s.broadcastRepo.GetPreference(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if preference == nil {
+		preference = &types.BroadcastPreference{UserID: userID}
+	}
+	return preference, nil
+}
+
+// UpdatePreference 用户设置是否接收群发通知，对发送中的群发通知尚未发送的批次同样生效
+func (s *BroadcastService) UpdatePreference(ctx context.Context, userID uint64, req *types.UpdateBroadcastPreferenceRequest) (*types.BroadcastPreference, error) {
+	preference := &types.BroadcastPreference{
+		UserID:   userID,
+		OptedOut: req.OptedOut,
+	}
+	if err := s.broadcastRepo.SavePreference(ctx, preference); err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// broadcastUser 群发通知测试用户，orders 为下单的商户及下单时间
+type broadcastUser struct {
+	id         uint64
+	username   string
+	customer   bool
+	merchantID *uint64
+	createdAt  time.Time
+	orders     map[uint64]time.Time
+}
+
+// memoryBroadcastRepository 内存群发通知仓储，按 SQL 实现的条件筛选接收人
+type memoryBroadcastRepository struct {
+	users       []broadcastUser
+	preferences map[uint64]bool
+	broadcasts  []*types.NotificationBroadcast
+	deliveries  []types.BroadcastDelivery
+}
+
+func (m *memoryBroadcastRepository) Create(ctx context.Context, broadcast *types.NotificationBroadcast) error {
+	broadcast.ID = uint64(len(m.broadcasts) + 1)
+	broadcast.TenantID = 1
+	stored := *broadcast
+	m.broadcasts = append(m.broadcasts, &stored)
+	return nil
+}
+
+func (m *memoryBroadcastRepository) GetByID(ctx context.Context, id uint64) (*types.NotificationBroadcast, error) {
+	for _, broadcast := range m.broadcasts {
+		if broadcast.ID == id {
+			found := *broadcast
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryBroadcastRepository) List(ctx context.Context, limit int) ([]types.NotificationBroadcast, error) {
+	var broadcasts []types.NotificationBroadcast
+	for i := len(m.broadcasts) - 1; i >= 0 && len(broadcasts) < limit; i-- {
+		broadcasts = append(broadcasts, *m.broadcasts[i])
+	}
+	return broadcasts, nil
+}
+
+func (m *memoryBroadcastRepository) CountSince(ctx context.Context, since time.Time) (int, error) {
+	count := 0
+	for _, broadcast := range m.broadcasts {
+		if !broadcast.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *memoryBroadcastRepository) CountRecipients(ctx context.Context, filter *types.BroadcastAudienceFilter, until time.Time) (int, int, error) {
+	recipients, _ := m.ListRecipients(ctx, filter, until, 0, len(m.users))
+	optedOut := 0
+	for _, recipient := range recipients {
+		if recipient.OptedOut {
+			optedOut++
+		}
+	}
+	return len(recipients), optedOut, nil
+}
+
+func (m *memoryBroadcastRepository) ListRecipients(ctx context.Context, filter *types.BroadcastAudienceFilter, until time.Time, afterUserID uint64, limit int) ([]types.BroadcastRecipient, error) {
+	var recipients []types.BroadcastRecipient
+	for _, user := range m.users {
+		if len(recipients) >= limit {
+			break
+		}
+		if user.id <= afterUserID || user.createdAt.After(until) || !m.matches(filter, user) {
+			continue
+		}
+		recipients = append(recipients, types.BroadcastRecipient{UserID: user.id, Username: user.username, OptedOut: m.preferences[user.id]})
+	}
+	return recipients, nil
+}
+
+func (m *memoryBroadcastRepository) matches(filter *types.BroadcastAudienceFilter, user broadcastUser) bool {
+	if filter.RegisteredAfter != nil && user.createdAt.Before(*filter.RegisteredAfter) {
+		return false
+	}
+	if filter.RegisteredBefore != nil && !user.createdAt.Before(*filter.RegisteredBefore) {
+		return false
+	}
+	if filter.Audience == types.BroadcastAudienceMerchants {
+		return user.merchantID != nil && (len(filter.MerchantIDs) == 0 || containsID(filter.MerchantIDs, *user.merchantID))
+	}
+	if !user.customer || user.merchantID != nil {
+		return false
+	}
+	if len(filter.MerchantIDs) == 0 && filter.OrderedSince == nil {
+		return true
+	}
+	for merchantID, orderedAt := range user.orders {
+		if len(filter.MerchantIDs) > 0 && !containsID(filter.MerchantIDs, merchantID) {
+			continue
+		}
+		if filter.OrderedSince != nil && orderedAt.Before(*filter.OrderedSince) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func (m *memoryBroadcastRepository) ListSending(ctx context.Context, limit int) ([]types.NotificationBroadcast, error) {
+	var broadcasts []types.NotificationBroadcast
+	for _, broadcast := range m.broadcasts {
+		if broadcast.Status == types.BroadcastStatusSending && len(broadcasts) < limit {
+			broadcasts = append(broadcasts, *broadcast)
+		}
+	}
+	return broadcasts, nil
+}
+
+func (m *memoryBroadcastRepository) ClaimBatch(ctx context.Context, broadcastID uint64, fromUserID, toUserID uint64) (bool, error) {
+	broadcast := m.broadcasts[broadcastID-1]
+	if broadcast.Status != types.BroadcastStatusSending || broadcast.CursorUserID != fromUserID {
+		return false, nil
+	}
+	broadcast.CursorUserID = toUserID
+	return true, nil
+}
+
+func (m *memoryBroadcastRepository) RecordBatch(ctx context.Context, broadcastID uint64, deliveries []types.BroadcastDelivery, progress types.BroadcastProgress, completedAt *time.Time) error {
+	for _, delivery := range deliveries {
+		delivery.BroadcastID = broadcastID
+		m.deliveries = append(m.deliveries, delivery)
+	}
+	broadcast := m.broadcasts[broadcastID-1]
+	broadcast.Apply(progress)
+	if completedAt != nil {
+		broadcast.Status = types.BroadcastStatusCompleted
+		broadcast.CompletedAt = completedAt
+	}
+	return nil
+}
+
+func (m *memoryBroadcastRepository) ListDeliveries(ctx context.Context, broadcastID uint64, status types.BroadcastDeliveryStatus, limit int) ([]types.BroadcastDelivery, error) {
+	var deliveries []types.BroadcastDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.BroadcastID == broadcastID && (status == "" || delivery.Status == status) && len(deliveries) < limit {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (m *memoryBroadcastRepository) GetPreference(ctx context.Context, userID uint64) (*types.BroadcastPreference, error) {
+	optedOut, exists := m.preferences[userID]
+	if !exists {
+		return nil, nil
+	}
+	return &types.BroadcastPreference{TenantID: 1, UserID: userID, OptedOut: optedOut}, nil
+}
+
+func (m *memoryBroadcastRepository) SavePreference(ctx context.Context, preference *types.BroadcastPreference) error {
+	m.preferences[preference.UserID] = preference.OptedOut
+	return nil
+}
+
+func containsID(ids []uint64, id uint64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// broadcastRecordingSender 按渠道记录接收人和内容的短信、邮件服务桩，failEmail 中的用户发送邮件失败
+type broadcastRecordingSender struct {
+	sms       []uint64
+	email     []uint64
+	contents  []string
+	failEmail map[uint64]bool
+}
+
+func (r *broadcastRecordingSender) SendSMS(ctx context.Context, customerID uint64, templateCode, content string) error {
+	r.sms = append(r.sms, customerID)
+	r.contents = append(r.contents, content)
+	return nil
+}
+
+func (r *broadcastRecordingSender) SendEmail(ctx context.Context, customerID uint64, subject, content string) error {
+	if r.failEmail[customerID] {
+		return fmt.Errorf("邮箱地址无效")
+	}
+	r.email = append(r.email, customerID)
+	return nil
+}
+
+func TestBroadcastService(t *testing.T) {
+	Convey("群发通知测试", t, func() {
+		now := time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC)
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		registered := now.Add(-90 * 24 * time.Hour)
+		merchantA, merchantB := uint64(10), uint64(20)
+
+		repo := &memoryBroadcastRepository{
+			users: []broadcastUser{
+				{id: 1, username: "alice", customer: true, createdAt: registered, orders: map[uint64]time.Time{merchantA: now.Add(-2 * 24 * time.Hour)}},
+				{id: 2, username: "bob", customer: true, createdAt: registered, orders: map[uint64]time.Time{merchantB: now.Add(-40 * 24 * time.Hour)}},
+				{id: 3, username: "carol", customer: true, createdAt: now.Add(-24 * time.Hour)},
+				{id: 4, username: "dave", customer: true, createdAt: registered, orders: map[uint64]time.Time{merchantA: now.Add(-10 * 24 * time.Hour)}},
+				{id: 5, username: "shop-a", merchantID: &merchantA, createdAt: registered},
+				{id: 6, username: "shop-b", merchantID: &merchantB, createdAt: registered},
+			},
+			preferences: map[uint64]bool{4: true},
+		}
+		sender := &broadcastRecordingSender{failEmail: map[uint64]bool{}}
+		broadcastService := NewBroadcastServiceForTest(repo, sender, sender, 2, 2, func() time.Time { return now })
+
+		Convey("按接收对象和筛选条件统计接收人", func() {
+			preview, err := broadcastService.Preview(ctx, &types.BroadcastAudienceFilter{Audience: types.BroadcastAudienceCustomers})
+			So(err, ShouldBeNil)
+			So(preview.Recipients, ShouldEqual, 4)
+			So(preview.OptedOut, ShouldEqual, 1)
+			So(preview.Deliverable, ShouldEqual, 3)
+
+			preview, err = broadcastService.Preview(ctx, &types.BroadcastAudienceFilter{Audience: types.BroadcastAudienceCustomers, MerchantIDs: []uint64{merchantA}})
+			So(err, ShouldBeNil)
+			So(preview.Recipients, ShouldEqual, 2)
+
+			since := now.Add(-30 * 24 * time.Hour)
+			preview, err = broadcastService.Preview(ctx, &types.BroadcastAudienceFilter{Audience: types.BroadcastAudienceCustomers, OrderedSince: &since})
+			So(err, ShouldBeNil)
+			So(preview.Recipients, ShouldEqual, 2)
+
+			after := now.Add(-7 * 24 * time.Hour)
+			preview, err = broadcastService.Preview(ctx, &types.BroadcastAudienceFilter{Audience: types.BroadcastAudienceCustomers, RegisteredAfter: &after})
+			So(err, ShouldBeNil)
+			So(preview.Recipients, ShouldEqual, 1)
+
+			preview, err = broadcastService.Preview(ctx, &types.BroadcastAudienceFilter{Audience: types.BroadcastAudienceMerchants, MerchantIDs: []uint64{merchantB}})
+			So(err, ShouldBeNil)
+			So(preview.Recipients, ShouldEqual, 1)
+
+			_, err = broadcastService.Preview(ctx, &types.BroadcastAudienceFilter{Audience: types.BroadcastAudienceMerchants, OrderedSince: &since})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("创建后按批发送，已退订的接收人跳过", func() {
+			broadcast, err := broadcastService.Create(ctx, 900, &types.CreateBroadcastRequest{
+				Subject:  "服务条款更新",
+				Content:  "{{.Username}}，您好：我们的服务条款已更新。",
+				Channels: []types.NotificationChannel{types.NotificationChannelSMS, types.NotificationChannelEmail},
+				Filter:   types.BroadcastAudienceFilter{Audience: types.BroadcastAudienceCustomers},
+			})
+			So(err, ShouldBeNil)
+			So(broadcast.Status, ShouldEqual, types.BroadcastStatusSending)
+			So(broadcast.TotalRecipients, ShouldEqual, 4)
+			So(broadcast.CreatedBy, ShouldEqual, 900)
+
+			sender.failEmail[3] = true
+
+			processed, err := broadcastService.SendPending(ctx)
+			So(err, ShouldBeNil)
+			So(processed, ShouldEqual, 2)
+			So(sender.sms, ShouldResemble, []uint64{1, 2})
+			So(sender.contents[0], ShouldEqual, "alice，您好：我们的服务条款已更新。")
+
+			progress, err := broadcastService.Get(ctx, broadcast.ID)
+			So(err, ShouldBeNil)
+			So(progress.Status, ShouldEqual, types.BroadcastStatusSending)
+			So(progress.ProcessedCount, ShouldEqual, 2)
+
+			processed, err = broadcastService.SendPending(ctx)
+			So(err, ShouldBeNil)
+			So(processed, ShouldEqual, 2)
+
+			// 最后一批恰好满一批时，下次检查没有剩余接收人后完成
+			processed, err = broadcastService.SendPending(ctx)
+			So(err, ShouldBeNil)
+			So(processed, ShouldEqual, 0)
+
+			Convey("发送统计与发送记录一致", func() {
+				progress, err := broadcastService.Get(ctx, broadcast.ID)
+				So(err, ShouldBeNil)
+				So(progress.Status, ShouldEqual, types.BroadcastStatusCompleted)
+				So(progress.CompletedAt, ShouldNotBeNil)
+				So(progress.ProcessedCount, ShouldEqual, 4)
+				So(progress.SkippedCount, ShouldEqual, 1)
+				So(progress.SentCount, ShouldEqual, 5)
+				So(progress.FailedCount, ShouldEqual, 1)
+
+				So(sender.sms, ShouldResemble, []uint64{1, 2, 3})
+				So(sender.email, ShouldResemble, []uint64{1, 2})
+
+				failed, err := broadcastService.ListDeliveries(ctx, broadcast.ID, types.BroadcastDeliveryFailed, &types.BroadcastListRequest{})
+				So(err, ShouldBeNil)
+				So(failed, ShouldHaveLength, 1)
+				So(failed[0].UserID, ShouldEqual, 3)
+				So(failed[0].Channel, ShouldEqual, types.NotificationChannelEmail)
+				So(failed[0].Error, ShouldNotBeEmpty)
+
+				skipped, err := broadcastService.ListDeliveries(ctx, broadcast.ID, types.BroadcastDeliverySkipped, &types.BroadcastListRequest{})
+				So(err, ShouldBeNil)
+				So(skipped, ShouldHaveLength, 1)
+				So(skipped[0].UserID, ShouldEqual, 4)
+
+				all, err := broadcastService.ListDeliveries(ctx, broadcast.ID, "", &types.BroadcastListRequest{})
+				So(err, ShouldBeNil)
+				So(all, ShouldHaveLength, progress.SentCount+progress.FailedCount+progress.SkippedCount)
+			})
+
+			Convey("已完成的群发通知不再发送", func() {
+				processed, err := broadcastService.SendPending(ctx)
+				So(err, ShouldBeNil)
+				So(processed, ShouldEqual, 0)
+				So(sender.sms, ShouldHaveLength, 3)
+			})
+		})
+
+		Convey("发送过程中退订的用户不再收到后续批次", func() {
+			broadcast, err := broadcastService.Create(ctx, 900, &types.CreateBroadcastRequest{
+				Content:  "店庆促销即将开始",
+				Channels: []types.NotificationChannel{types.NotificationChannelSMS},
+				Filter:   types.BroadcastAudienceFilter{Audience: types.BroadcastAudienceCustomers},
+			})
+			So(err, ShouldBeNil)
+
+			_, err = broadcastService.SendPending(ctx)
+			So(err, ShouldBeNil)
+
+			preference, err := broadcastService.UpdatePreference(ctx, 3, &types.UpdateBroadcastPreferenceRequest{OptedOut: true})
+			So(err, ShouldBeNil)
+			So(preference.OptedOut, ShouldBeTrue)
+
+			_, err = broadcastService.SendPending(ctx)
+			So(err, ShouldBeNil)
+			So(sender.sms, ShouldResemble, []uint64{1, 2})
+
+			progress, err := broadcastService.Get(ctx, broadcast.ID)
+			So(err, ShouldBeNil)
+			So(progress.SkippedCount, ShouldEqual, 2)
+			So(progress.SentCount, ShouldEqual, 2)
+		})
+
+		Convey("发送给商户用户", func() {
+			_, err := broadcastService.Create(ctx, 900, &types.CreateBroadcastRequest{
+				Subject:  "结算规则调整",
+				Content:  "结算周期调整为T+1",
+				Channels: []types.NotificationChannel{types.NotificationChannelEmail},
+				Filter:   types.BroadcastAudienceFilter{Audience: types.BroadcastAudienceMerchants},
+			})
+			So(err, ShouldBeNil)
+
+			_, err = broadcastService.SendPending(ctx)
+			So(err, ShouldBeNil)
+			_, err = broadcastService.SendPending(ctx)
+			So(err, ShouldBeNil)
+			So(sender.email, ShouldResemble, []uint64{5, 6})
+			So(sender.sms, ShouldBeEmpty)
+		})
+
+		Convey("24小时内群发次数达到上限被拒绝", func() {
+			req := &types.CreateBroadcastRequest{
+				Content:  "系统维护通知",
+				Channels: []types.NotificationChannel{types.NotificationChannelSMS},
+				Filter:   types.BroadcastAudienceFilter{Audience: types.BroadcastAudienceCustomers},
+			}
+			_, err := broadcastService.Create(ctx, 900, req)
+			So(err, ShouldBeNil)
+			_, err = broadcastService.Create(ctx, 900, req)
+			So(err, ShouldBeNil)
+
+			_, err = broadcastService.Create(ctx, 900, req)
+			So(errors.Is(err, types.ErrBroadcastRateLimited), ShouldBeTrue)
+			So(repo.broadcasts, ShouldHaveLength, 2)
+
+			Convey("超过统计窗口后可以再次群发", func() {
+				later := NewBroadcastServiceForTest(repo, sender, sender, 2, 2, func() time.Time { return now.Add(25 * time.Hour) })
+				_, err := later.Create(ctx, 900, req)
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("内容模板或渠道无效时拒绝创建", func() {
+			_, err := broadcastService.Create(ctx, 900, &types.CreateBroadcastRequest{
+				Content:  "{{.Unknown}}",
+				Channels: []types.NotificationChannel{types.NotificationChannelSMS},
+				Filter:   types.BroadcastAudienceFilter{Audience: types.BroadcastAudienceCustomers},
+			})
+			So(err, ShouldNotBeNil)
+
+			_, err = broadcastService.Create(ctx, 900, &types.CreateBroadcastRequest{
+				Content:  "促销通知",
+				Channels: []types.NotificationChannel{types.NotificationChannelEmail},
+				Filter:   types.BroadcastAudienceFilter{Audience: types.BroadcastAudienceCustomers},
+			})
+			So(err, ShouldNotBeNil)
+			So(repo.broadcasts, ShouldBeEmpty)
+		})
+	})
+}
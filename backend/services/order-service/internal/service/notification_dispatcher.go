@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	defaultNotificationWorkers   = 8
+	defaultNotificationQueueSize = 256
+)
+
+// ErrNotificationDispatcherClosed 通知发送池已关闭
+var ErrNotificationDispatcherClosed = errors.New("通知发送池已关闭")
+
+// NotificationDispatchConfig 外发通知并发配置
+type NotificationDispatchConfig struct {
+	Workers   int // 同时发送的通知数上限
+	QueueSize int // 每个发送协程排队的通知数上限，队列满时提交方等待
+}
+
+// LoadNotificationDispatchConfig 读取 notification.dispatch 配置
+func LoadNotificationDispatchConfig(ctx context.Context) NotificationDispatchConfig {
+	config := NotificationDispatchConfig{
+		Workers:   g.Cfg().MustGet(ctx, "notification.dispatch.workers", defaultNotificationWorkers).Int(),
+		QueueSize: g.Cfg().MustGet(ctx, "notification.dispatch.queueSize", defaultNotificationQueueSize).Int(),
+	}
+	if config.Workers <= 0 {
+		config.Workers = defaultNotificationWorkers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultNotificationQueueSize
+	}
+	return config
+}
+
+// notificationTask 待发送的通知
+type notificationTask struct {
+	ctx  context.Context
+	send func(ctx context.Context)
+}
+
+// NotificationDispatcher 外发通知发送池：固定数量的发送协程从各自的有界队列依次取出通知发送，
+// 同一接收人的通知固定由同一个协程发送以保持发送顺序；队列满时提交方等待，避免突发通知耗尽资源或触发服务商限流
+type NotificationDispatcher struct {
+	queues []chan notificationTask
+	mutex  sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewNotificationDispatcher 创建通知发送池并启动发送协程
+func NewNotificationDispatcher(config NotificationDispatchConfig) *NotificationDispatcher {
+	d := &NotificationDispatcher{queues: make([]chan notificationTask, config.Workers)}
+	for i := range d.queues {
+		d.queues[i] = make(chan notificationTask, config.QueueSize)
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+	return d
+}
+
+var (
+	sharedDispatcherOnce sync.Once
+	sharedDispatcher     *NotificationDispatcher
+)
+
+// sharedNotificationDispatcher 进程内共享的通知发送池，所有通知服务实例共用同一并发上限
+func sharedNotificationDispatcher() *NotificationDispatcher {
+	sharedDispatcherOnce.Do(func() {
+		sharedDispatcher = NewNotificationDispatcher(LoadNotificationDispatchConfig(context.Background()))
+	})
+	return sharedDispatcher
+}
+
+// Submit 将通知加入接收人所在协程的队列，队列满时等待直到有空位或 ctx 结束。
+// 通知在请求结束后才可能发送，发送时使用不会随请求取消的上下文；send 内不能再向发送池提交通知
+func (d *NotificationDispatcher) Submit(ctx context.Context, recipient string, send func(ctx context.Context)) error {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if d.closed {
+		return ErrNotificationDispatcherClosed
+	}
+
+	task := notificationTask{ctx: context.WithoutCancel(ctx), send: send}
+	select {
+	case d.queue(recipient) <- task:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close 停止接收新通知，等待已排队的通知发送完成
+func (d *NotificationDispatcher) Close() {
+	d.mutex.Lock()
+	if d.closed {
+		d.mutex.Unlock()
+		return
+	}
+	d.closed = true
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.mutex.Unlock()
+	d.wg.Wait()
+}
+
+// queue 按接收人选择队列
+func (d *NotificationDispatcher) queue(recipient string) chan notificationTask {
+	hash := fnv.New32a()
+	hash.Write([]byte(recipient))
+	return d.queues[hash.Sum32()%uint32(len(d.queues))]
+}
+
+// work 依次发送队列中的通知，单条通知异常不影响后续通知
+func (d *NotificationDispatcher) work(queue chan notificationTask) {
+	defer d.wg.Done()
+	for task := range queue {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					g.Log().Error(task.ctx, "发送通知异常", "panic", r)
+				}
+			}()
+			task.send(task.ctx)
+		}()
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// throttledEmailService 模拟较慢的邮件服务商，记录同时发送的邮件数峰值和每个接收人的邮件顺序
+type throttledEmailService struct {
+	delay    time.Duration
+	inFlight int32
+	peak     int32
+	mutex    sync.Mutex
+	subjects map[uint64][]string
+}
+
+func (e *throttledEmailService) SendEmail(ctx context.Context, userID uint64, subject, content string) error {
+	current := atomic.AddInt32(&e.inFlight, 1)
+	defer atomic.AddInt32(&e.inFlight, -1)
+	for {
+		peak := atomic.LoadInt32(&e.peak)
+		if current <= peak || atomic.CompareAndSwapInt32(&e.peak, peak, current) {
+			break
+		}
+	}
+	time.Sleep(e.delay)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.subjects[userID] = append(e.subjects[userID], subject)
+	return nil
+}
+
+func (e *throttledEmailService) sentCount() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	count := 0
+	for _, subjects := range e.subjects {
+		count += len(subjects)
+	}
+	return count
+}
+
+func TestNotificationDispatcher(t *testing.T) {
+	Convey("外发通知发送池", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+		Convey("大量商户通知的并发数和协程数保持在上限内，同一接收人按顺序收到", func() {
+			dispatcher := NewNotificationDispatcher(NotificationDispatchConfig{Workers: 4, QueueSize: 8})
+			email := &throttledEmailService{delay: time.Millisecond, subjects: make(map[uint64][]string)}
+			notificationService := NewDispatchingNotificationServiceForTest(&recordingSMSService{}, email, dispatcher)
+
+			baseline := runtime.NumGoroutine()
+			var peakGoroutines int32
+			stop := make(chan struct{})
+			sampled := make(chan struct{})
+			go func() {
+				defer close(sampled)
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					if n := int32(runtime.NumGoroutine()); n > atomic.LoadInt32(&peakGoroutines) {
+						atomic.StoreInt32(&peakGoroutines, n)
+					}
+					time.Sleep(100 * time.Microsecond)
+				}
+			}()
+
+			// 每个订单通知 2 个商户管理员，每人短信、邮件、系统通知各一条
+			const orders = 200
+			for i := 1; i <= orders; i++ {
+				order := &types.Order{ID: uint64(i), MerchantID: 1, OrderNumber: fmt.Sprintf("ORD%03d", i), TotalAmount: 100}
+				history := &types.OrderStatusHistory{OrderID: order.ID, FromStatus: types.OrderStatusIntPending, ToStatus: types.OrderStatusIntPaid, CreatedAt: time.Now()}
+				So(notificationService.SendMerchantOrderNotification(ctx, order, history), ShouldBeNil)
+			}
+			dispatcher.Close()
+			close(stop)
+			<-sampled
+
+			So(email.sentCount(), ShouldEqual, orders*2)
+			So(atomic.LoadInt32(&email.peak), ShouldBeLessThanOrEqualTo, 4)
+			// 发送协程数固定，不随通知数增长
+			So(int(atomic.LoadInt32(&peakGoroutines))-baseline, ShouldBeLessThanOrEqualTo, 4+2)
+
+			for _, adminID := range []uint64{1000, 1001} {
+				subjects := email.subjects[adminID]
+				So(subjects, ShouldHaveLength, orders)
+				for i, subject := range subjects {
+					So(subject, ShouldEqual, fmt.Sprintf("商户订单状态变更 - ORD%03d", i+1))
+				}
+			}
+		})
+
+		Convey("队列满时提交方等待，超时后放弃提交", func() {
+			dispatcher := NewNotificationDispatcher(NotificationDispatchConfig{Workers: 1, QueueSize: 1})
+			release := make(chan struct{})
+			started := make(chan struct{})
+			blocking := func(ctx context.Context) {
+				select {
+				case started <- struct{}{}:
+				default:
+				}
+				<-release
+			}
+
+			So(dispatcher.Submit(ctx, "user:1", blocking), ShouldBeNil)
+			<-started
+			So(dispatcher.Submit(ctx, "user:1", blocking), ShouldBeNil)
+
+			waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			defer cancel()
+			So(dispatcher.Submit(waitCtx, "user:1", blocking), ShouldEqual, context.DeadlineExceeded)
+
+			close(release)
+			dispatcher.Close()
+			So(dispatcher.Submit(ctx, "user:1", blocking), ShouldEqual, ErrNotificationDispatcherClosed)
+		})
+
+		Convey("单条通知异常不影响后续通知", func() {
+			dispatcher := NewNotificationDispatcher(NotificationDispatchConfig{Workers: 1, QueueSize: 4})
+			var sent int32
+			So(dispatcher.Submit(ctx, "user:1", func(ctx context.Context) { panic("服务商异常") }), ShouldBeNil)
+			So(dispatcher.Submit(ctx, "user:1", func(ctx context.Context) { atomic.AddInt32(&sent, 1) }), ShouldBeNil)
+			dispatcher.Close()
+			So(atomic.LoadInt32(&sent), ShouldEqual, 1)
+		})
+	})
+}
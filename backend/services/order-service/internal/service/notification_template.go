@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
 )
@@ -55,11 +56,16 @@ const (
 	NotificationEventOrderCompleted       NotificationEvent = "order_completed"
 	NotificationEventOrderCancelled       NotificationEvent = "order_cancelled"
 	NotificationEventOrderStatusChanged   NotificationEvent = "order_status_changed"
+	NotificationEventFulfillmentShipped   NotificationEvent = "fulfillment_shipped"
+	NotificationEventFulfillmentDelivered NotificationEvent = "fulfillment_delivered"
+	NotificationEventRightsWarning        NotificationEvent = "rights_warning"
+	NotificationEventRightsCritical       NotificationEvent = "rights_critical"
 )
 
 // NotificationTemplateManager 通知模板管理器
 type NotificationTemplateManager struct {
 	templates map[string]*NotificationTemplate
+	branding  repository.NotificationBrandingProvider // 为空时使用默认品牌
 }
 
 // NewNotificationTemplateManager 创建通知模板管理器
@@ -74,6 +80,13 @@ func NewNotificationTemplateManager() *NotificationTemplateManager {
 	return manager
 }
 
+// NewBrandedNotificationTemplateManager 创建按订单所属商户填充通知品牌的通知模板管理器
+func NewBrandedNotificationTemplateManager(branding repository.NotificationBrandingProvider) *NotificationTemplateManager {
+	manager := NewNotificationTemplateManager()
+	manager.branding = branding
+	return manager
+}
+
 // initializeDefaultTemplates 初始化默认模板
 func (m *NotificationTemplateManager) initializeDefaultTemplates() {
 	defaultTemplates := []*NotificationTemplate{
@@ -85,8 +98,8 @@ func (m *NotificationTemplateManager) initializeDefaultTemplates() {
 			Event:    NotificationEventOrderCreated,
 			Language: "zh-CN",
 			Subject:  "",
-			Content:  "您的订单 {{.OrderNumber}} 已创建成功，金额 ¥{{.TotalAmount}}，请及时支付。",
-			Variables: []string{"OrderNumber", "TotalAmount"},
+			Content:  "【{{.MerchantName}}】您的订单 {{.OrderNumber}} 已创建成功，金额 {{formatMoney .TotalAmount}}，请及时支付。",
+			Variables: []string{"MerchantName", "OrderNumber", "TotalAmount"},
 			Enabled:  true,
 		},
 		{
@@ -96,8 +109,8 @@ func (m *NotificationTemplateManager) initializeDefaultTemplates() {
 			Event:    NotificationEventPaymentSuccess,
 			Language: "zh-CN",
 			Subject:  "",
-			Content:  "您的订单 {{.OrderNumber}} 支付成功，金额 ¥{{.TotalAmount}}，我们将尽快处理。",
-			Variables: []string{"OrderNumber", "TotalAmount"},
+			Content:  "【{{.MerchantName}}】您的订单 {{.OrderNumber}} 支付成功，金额 {{formatMoney .TotalAmount}}，我们将尽快处理。",
+			Variables: []string{"MerchantName", "OrderNumber", "TotalAmount"},
 			Enabled:  true,
 		},
 		{
@@ -107,8 +120,8 @@ func (m *NotificationTemplateManager) initializeDefaultTemplates() {
 			Event:    NotificationEventOrderProcessing,
 			Language: "zh-CN",
 			Subject:  "",
-			Content:  "您的订单 {{.OrderNumber}} 已开始处理，我们将尽快为您完成。",
-			Variables: []string{"OrderNumber"},
+			Content:  "【{{.MerchantName}}】您的订单 {{.OrderNumber}} 已开始处理，我们将尽快为您完成。",
+			Variables: []string{"MerchantName", "OrderNumber"},
 			Enabled:  true,
 		},
 		{
@@ -118,8 +131,8 @@ func (m *NotificationTemplateManager) initializeDefaultTemplates() {
 			Event:    NotificationEventOrderCompleted,
 			Language: "zh-CN",
 			Subject:  "",
-			Content:  "您的订单 {{.OrderNumber}} 已完成，感谢使用！",
-			Variables: []string{"OrderNumber"},
+			Content:  "【{{.MerchantName}}】您的订单 {{.OrderNumber}} 已完成，感谢使用！",
+			Variables: []string{"MerchantName", "OrderNumber"},
 			Enabled:  true,
 		},
 		{
@@ -129,8 +142,30 @@ func (m *NotificationTemplateManager) initializeDefaultTemplates() {
 			Event:    NotificationEventOrderCancelled,
 			Language: "zh-CN",
 			Subject:  "",
-			Content:  "您的订单 {{.OrderNumber}} 已取消，原因：{{.Reason}}。如有疑问请联系客服。",
-			Variables: []string{"OrderNumber", "Reason"},
+			Content:  "【{{.MerchantName}}】您的订单 {{.OrderNumber}} 已取消，原因：{{.Reason}}。如有疑问请联系客服。",
+			Variables: []string{"MerchantName", "OrderNumber", "Reason"},
+			Enabled:  true,
+		},
+		{
+			ID:       "customer_sms_fulfillment_shipped_zh_cn",
+			Type:     NotificationMethodTypeSMS,
+			Category: NotificationCategoryCustomer,
+			Event:    NotificationEventFulfillmentShipped,
+			Language: "zh-CN",
+			Subject:  "",
+			Content:  "【{{.MerchantName}}】您的订单 {{.OrderNumber}} 中的 {{.FulfillmentItems}} 已发货，{{.Carrier}} 单号 {{.TrackingNumber}}，其余 {{.PendingFulfillmentCount}} 个包裹待送达。",
+			Variables: []string{"MerchantName", "OrderNumber", "FulfillmentItems", "Carrier", "TrackingNumber", "PendingFulfillmentCount"},
+			Enabled:  true,
+		},
+		{
+			ID:       "customer_sms_fulfillment_delivered_zh_cn",
+			Type:     NotificationMethodTypeSMS,
+			Category: NotificationCategoryCustomer,
+			Event:    NotificationEventFulfillmentDelivered,
+			Language: "zh-CN",
+			Subject:  "",
+			Content:  "【{{.MerchantName}}】您的订单 {{.OrderNumber}} 中的 {{.FulfillmentItems}} 已送达，其余 {{.PendingFulfillmentCount}} 个包裹待送达。",
+			Variables: []string{"MerchantName", "OrderNumber", "FulfillmentItems", "PendingFulfillmentCount"},
 			Enabled:  true,
 		},
 		
@@ -148,15 +183,19 @@ func (m *NotificationTemplateManager) initializeDefaultTemplates() {
 
 订单信息：
 - 订单编号：{{.OrderNumber}}
-- 订单金额：¥{{.TotalAmount}}
+- 订单金额：{{formatMoney .TotalAmount}}
+- 其中税额：{{formatMoney .TaxAmount}}
 - 权益消耗：{{.TotalRightsCost}}
 - 创建时间：{{.CreatedAt}}
+- 收货地址：{{.ShippingAddress}}
 
 请及时支付以完成订单。
 
+{{.SupportLine}}
+
 此致
-商户系统`,
-			Variables: []string{"OrderNumber", "TotalAmount", "TotalRightsCost", "CreatedAt"},
+{{.Signature}}`,
+			Variables: []string{"OrderNumber", "TotalAmount", "TaxAmount", "TotalRightsCost", "CreatedAt", "ShippingAddress", "SupportLine", "Signature"},
 			Enabled:  true,
 		},
 		{
@@ -172,14 +211,17 @@ func (m *NotificationTemplateManager) initializeDefaultTemplates() {
 
 订单信息：
 - 订单编号：{{.OrderNumber}}
-- 支付金额：¥{{.TotalAmount}}
+- 支付金额：{{formatMoney .TotalAmount}}
+- 其中税额：{{formatMoney .TaxAmount}}
 - 支付时间：{{.PaymentTime}}
 
 我们将尽快为您处理订单。
 
+{{.SupportLine}}
+
 此致
-商户系统`,
-			Variables: []string{"OrderNumber", "TotalAmount", "PaymentTime"},
+{{.Signature}}`,
+			Variables: []string{"OrderNumber", "TotalAmount", "TaxAmount", "PaymentTime", "SupportLine", "Signature"},
 			Enabled:  true,
 		},
 		{
@@ -195,14 +237,69 @@ func (m *NotificationTemplateManager) initializeDefaultTemplates() {
 
 订单信息：
 - 订单编号：{{.OrderNumber}}
-- 订单金额：¥{{.TotalAmount}}
+- 订单金额：{{formatMoney .TotalAmount}}
 - 处理时间：{{.ProcessingTime}}
 
 我们将尽快为您完成订单，请耐心等待。
 
+{{.SupportLine}}
+
 此致
-商户系统`,
-			Variables: []string{"OrderNumber", "TotalAmount", "ProcessingTime"},
+{{.Signature}}`,
+			Variables: []string{"OrderNumber", "TotalAmount", "ProcessingTime", "SupportLine", "Signature"},
+			Enabled:  true,
+		},
+		{
+			ID:       "customer_email_fulfillment_shipped_zh_cn",
+			Type:     NotificationMethodTypeEmail,
+			Category: NotificationCategoryCustomer,
+			Event:    NotificationEventFulfillmentShipped,
+			Language: "zh-CN",
+			Subject:  "包裹已发货 - {{.OrderNumber}}",
+			Content: `尊敬的客户，
+
+您订单中的部分商品已发货！
+
+包裹信息：
+- 订单编号：{{.OrderNumber}}
+- 包裹编号：{{.FulfillmentID}}
+- 发货商品：{{.FulfillmentItems}}
+- 物流公司：{{.Carrier}}
+- 物流单号：{{.TrackingNumber}}
+- 收货地址：{{.ShippingAddress}}
+
+订单共 {{.FulfillmentCount}} 个包裹，其余 {{.PendingFulfillmentCount}} 个包裹尚未送达，我们会在每个包裹状态变化时通知您。
+
+{{.SupportLine}}
+
+此致
+{{.Signature}}`,
+			Variables: []string{"OrderNumber", "FulfillmentID", "FulfillmentItems", "Carrier", "TrackingNumber", "ShippingAddress", "FulfillmentCount", "PendingFulfillmentCount", "SupportLine", "Signature"},
+			Enabled:  true,
+		},
+		{
+			ID:       "customer_email_fulfillment_delivered_zh_cn",
+			Type:     NotificationMethodTypeEmail,
+			Category: NotificationCategoryCustomer,
+			Event:    NotificationEventFulfillmentDelivered,
+			Language: "zh-CN",
+			Subject:  "包裹已送达 - {{.OrderNumber}}",
+			Content: `尊敬的客户，
+
+您订单中的一个包裹已送达！
+
+包裹信息：
+- 订单编号：{{.OrderNumber}}
+- 包裹编号：{{.FulfillmentID}}
+- 送达商品：{{.FulfillmentItems}}
+
+订单共 {{.FulfillmentCount}} 个包裹，其余 {{.PendingFulfillmentCount}} 个包裹尚未送达。
+
+{{.SupportLine}}
+
+此致
+{{.Signature}}`,
+			Variables: []string{"OrderNumber", "FulfillmentID", "FulfillmentItems", "FulfillmentCount", "PendingFulfillmentCount", "SupportLine", "Signature"},
 			Enabled:  true,
 		},
 		
@@ -234,17 +331,18 @@ func (m *NotificationTemplateManager) initializeDefaultTemplates() {
 订单信息：
 - 订单编号：{{.OrderNumber}}
 - 客户ID：{{.CustomerID}}
-- 订单金额：¥{{.TotalAmount}}
+- 订单金额：{{formatMoney .TotalAmount}}
 - 状态变更：{{.FromStatus}} → {{.ToStatus}}
 - 变更时间：{{.UpdatedAt}}
 - 变更原因：{{.Reason}}
 - 操作类型：{{.OperatorType}}
+- 收货地址：{{.ShippingAddress}}
 
 请登录商户管理后台查看详细信息。
 
 此致
 商户管理系统`,
-			Variables: []string{"OrderNumber", "CustomerID", "TotalAmount", "FromStatus", "ToStatus", "UpdatedAt", "Reason", "OperatorType"},
+			Variables: []string{"OrderNumber", "CustomerID", "TotalAmount", "FromStatus", "ToStatus", "UpdatedAt", "Reason", "OperatorType", "ShippingAddress"},
 			Enabled:  true,
 		},
 	}
@@ -274,7 +372,10 @@ func (m *NotificationTemplateManager) buildTemplateID(methodType NotificationMet
 	return string(category) + "_" + string(methodType) + "_" + string(event) + "_" + strings.ToLower(strings.ReplaceAll(language, "-", "_"))
 }
 
-// RenderTemplate 渲染模板
+// MoneyFormatDataKey 模板数据中金额显示格式的键，值为 *types.MoneyFormatPolicy
+const MoneyFormatDataKey = "MoneyFormat"
+
+// RenderTemplate 渲染模板，{{.Key}} 替换为原值，{{formatMoney .Key}} 按金额显示格式替换
 func (m *NotificationTemplateManager) RenderTemplate(template *NotificationTemplate, data map[string]interface{}) (string, string, error) {
 	if template == nil {
 		return "", "", fmt.Errorf("模板为空")
@@ -283,8 +384,18 @@ func (m *NotificationTemplateManager) RenderTemplate(template *NotificationTempl
 	// 简单的模板变量替换（生产环境建议使用 text/template）
 	subject := template.Subject
 	content := template.Content
+
+	// 金额按 MoneyFormat 指定的租户货币和区域格式化，未指定时使用人民币格式
+	moneyFormat, _ := data[MoneyFormatDataKey].(*types.MoneyFormatPolicy)
 	
 	for key, value := range data {
+		if amount, ok := value.(float64); ok {
+			moneyPlaceholder := "{{formatMoney ." + key + "}}"
+			formatted := moneyFormat.Format(amount)
+			subject = strings.ReplaceAll(subject, moneyPlaceholder, formatted)
+			content = strings.ReplaceAll(content, moneyPlaceholder, formatted)
+		}
+
 		placeholder := "{{." + key + "}}"
 		valueStr := fmt.Sprintf("%v", value)
 		subject = strings.ReplaceAll(subject, placeholder, valueStr)
@@ -294,17 +405,24 @@ func (m *NotificationTemplateManager) RenderTemplate(template *NotificationTempl
 	return subject, content, nil
 }
 
-// BuildOrderDataMap 构建订单数据映射
-func (m *NotificationTemplateManager) BuildOrderDataMap(order *types.Order, statusHistory *types.OrderStatusHistory) map[string]interface{} {
+// BuildOrderDataMap 构建订单数据映射，包含按订单所属商户解析的通知品牌
+func (m *NotificationTemplateManager) BuildOrderDataMap(ctx context.Context, order *types.Order, statusHistory *types.OrderStatusHistory) map[string]interface{} {
+	branding := m.branding.Resolve(ctx, order.TenantID, order.MerchantID)
 	data := map[string]interface{}{
 		"OrderNumber":      order.OrderNumber,
 		"TotalAmount":      order.TotalAmount,
+		"TaxAmount":        order.TaxAmount,
 		"TotalRightsCost":  order.TotalRightsCost,
 		"CreatedAt":        order.CreatedAt.Format("2006-01-02 15:04:05"),
 		"PaymentTime":      time.Now().Format("2006-01-02 15:04:05"),
 		"ProcessingTime":   time.Now().Format("2006-01-02 15:04:05"),
 		"CustomerID":       order.CustomerID,
 		"MerchantID":       order.MerchantID,
+		"MerchantName":     branding.DisplayName,
+		"SupportContact":   branding.SupportContact,
+		"SupportLine":      branding.SupportLine(),
+		"Signature":        branding.Signature,
+		"ShippingAddress":  shippingAddressText(order.ShippingAddress),
 	}
 	
 	if statusHistory != nil {
@@ -318,6 +436,40 @@ func (m *NotificationTemplateManager) BuildOrderDataMap(order *types.Order, stat
 	return data
 }
 
+// shippingAddressText 通知中显示的收货信息，订单无收货地址时显示无需配送
+func shippingAddressText(address *types.ShippingAddress) string {
+	if address == nil {
+		return "无需配送"
+	}
+	return address.String()
+}
+
+// BuildFulfillmentDataMap 构建履约单数据映射，包含本次变更涉及的商品和订单其余未送达包裹数
+func (m *NotificationTemplateManager) BuildFulfillmentDataMap(ctx context.Context, order *types.Order, fulfillment *types.OrderFulfillment, fulfillments []types.OrderFulfillment) map[string]interface{} {
+	data := m.BuildOrderDataMap(ctx, order, nil)
+
+	items := make([]string, 0, len(fulfillment.Items))
+	for _, item := range fulfillment.Items {
+		items = append(items, fmt.Sprintf("%s x%d", item.ProductName, item.Quantity))
+	}
+
+	pending := 0
+	for _, other := range fulfillments {
+		if other.ID != fulfillment.ID && !other.Status.IsFinished() {
+			pending++
+		}
+	}
+
+	data["FulfillmentID"] = fulfillment.ID
+	data["FulfillmentItems"] = strings.Join(items, "、")
+	data["Carrier"] = fulfillment.Carrier
+	data["TrackingNumber"] = fulfillment.TrackingNumber
+	data["FulfillmentCount"] = len(fulfillments)
+	data["PendingFulfillmentCount"] = pending
+
+	return data
+}
+
 // getOrderStatusDisplayName 获取订单状态显示名称
 func (m *NotificationTemplateManager) getOrderStatusDisplayName(status types.OrderStatus) string {
 	switch status {
@@ -1,9 +1,14 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
 
@@ -46,7 +51,7 @@ func TestNotificationTemplateManager(t *testing.T) {
 		CreatedAt:        time.Now(),
 	}
 
-	data := manager.BuildOrderDataMap(testOrder, nil)
+	data := manager.BuildOrderDataMap(context.Background(), testOrder, nil)
 	subject, content, err := manager.RenderTemplate(template, data)
 
 	if err != nil {
@@ -57,7 +62,7 @@ func TestNotificationTemplateManager(t *testing.T) {
 		t.Error("短信模板不应该有主题")
 	}
 
-	expectedContent := "您的订单 ORD20250828001 已创建成功，金额 ¥299.99，请及时支付。"
+	expectedContent := "【商户系统】您的订单 ORD20250828001 已创建成功，金额 ¥299.99，请及时支付。"
 	if content != expectedContent {
 		t.Errorf("模板内容不正确，期望: %s, 实际: %s", expectedContent, content)
 	}
@@ -86,7 +91,7 @@ func TestNotificationTemplateManagerEmailTemplate(t *testing.T) {
 		CreatedAt:        time.Now(),
 	}
 
-	data := manager.BuildOrderDataMap(testOrder, nil)
+	data := manager.BuildOrderDataMap(context.Background(), testOrder, nil)
 	subject, content, err := manager.RenderTemplate(template, data)
 
 	if err != nil {
@@ -143,7 +148,7 @@ func TestNotificationTemplateManagerMerchantTemplate(t *testing.T) {
 		CreatedAt:    time.Now(),
 	}
 
-	data := manager.BuildOrderDataMap(testOrder, statusHistory)
+	data := manager.BuildOrderDataMap(context.Background(), testOrder, statusHistory)
 	subject, content, err := manager.RenderTemplate(template, data)
 
 	if err != nil {
@@ -209,6 +214,215 @@ func TestNotificationTemplateManagerTemplateToggle(t *testing.T) {
 	}
 }
 
+func TestNotificationTemplateManagerMoneyFormat(t *testing.T) {
+	manager := NewNotificationTemplateManager()
+
+	smsTemplate := manager.GetTemplate(NotificationMethodTypeSMS, NotificationCategoryCustomer, NotificationEventOrderCreated, "zh-CN")
+	emailTemplate := manager.GetTemplate(NotificationMethodTypeEmail, NotificationCategoryCustomer, NotificationEventOrderCreated, "zh-CN")
+	if smsTemplate == nil || emailTemplate == nil {
+		t.Fatal("客户端订单创建模板不存在")
+	}
+
+	testOrder := &types.Order{
+		OrderNumber: "ORD20250828001",
+		TotalAmount: 1299.99,
+		CreatedAt:   time.Now(),
+	}
+
+	tests := []struct {
+		name        string
+		moneyFormat *types.MoneyFormatPolicy
+		amount      string
+	}{
+		{name: "未配置使用人民币", moneyFormat: nil, amount: "¥1,299.99"},
+		{name: "人民币", moneyFormat: &types.MoneyFormatPolicy{Currency: "CNY", Locale: "zh-CN"}, amount: "¥1,299.99"},
+		{name: "美元", moneyFormat: &types.MoneyFormatPolicy{Currency: "USD", Locale: "en-US"}, amount: "$1,299.99"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := manager.BuildOrderDataMap(context.Background(), testOrder, nil)
+			if tt.moneyFormat != nil {
+				data[MoneyFormatDataKey] = tt.moneyFormat
+			}
+
+			_, content, err := manager.RenderTemplate(smsTemplate, data)
+			if err != nil {
+				t.Fatalf("短信模板渲染失败: %v", err)
+			}
+			expectedContent := "【商户系统】您的订单 ORD20250828001 已创建成功，金额 " + tt.amount + "，请及时支付。"
+			if content != expectedContent {
+				t.Errorf("短信内容不正确，期望: %s, 实际: %s", expectedContent, content)
+			}
+
+			_, content, err = manager.RenderTemplate(emailTemplate, data)
+			if err != nil {
+				t.Fatalf("邮件模板渲染失败: %v", err)
+			}
+			if !contains(content, "订单金额："+tt.amount) {
+				t.Errorf("邮件内容应该包含格式化后的订单金额 %s，实际: %s", tt.amount, content)
+			}
+			if contains(content, "formatMoney") {
+				t.Error("邮件内容不应该包含未渲染的金额占位符")
+			}
+		})
+	}
+}
+
+// brandingTenantRepository 返回指定租户配置的租户仓储桩
+type brandingTenantRepository struct {
+	repository.ITenantRepository
+	config types.TenantConfig
+}
+
+func (r *brandingTenantRepository) GetByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	config, err := json.Marshal(r.config)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Tenant{ID: id, Config: string(config)}, nil
+}
+
+// brandingMerchantRepository 按ID返回商户的商户仓储桩，同时记录查询时的租户
+type brandingMerchantRepository struct {
+	repository.MerchantRepository
+	merchants map[uint64]*types.Merchant
+	tenantIDs []interface{}
+}
+
+func (r *brandingMerchantRepository) GetByID(ctx context.Context, id uint64) (*types.Merchant, error) {
+	r.tenantIDs = append(r.tenantIDs, ctx.Value("tenant_id"))
+	merchant, exists := r.merchants[id]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	return merchant, nil
+}
+
+// contentRecordingEmailService 记录邮件内容的邮件服务桩
+type contentRecordingEmailService struct {
+	contents []string
+}
+
+func (r *contentRecordingEmailService) SendEmail(ctx context.Context, customerID uint64, subject, content string) error {
+	r.contents = append(r.contents, content)
+	return nil
+}
+
+func TestNotificationTemplateManagerBranding(t *testing.T) {
+	tenantRepo := &brandingTenantRepository{config: types.TenantConfig{
+		NotificationBranding: &types.NotificationBranding{DisplayName: "云集商城", SupportContact: "400-800-1234"},
+	}}
+	merchantRepo := &brandingMerchantRepository{merchants: map[uint64]*types.Merchant{
+		10: {ID: 10, NotificationBranding: &types.NotificationBranding{
+			DisplayName:    "山野咖啡",
+			SupportContact: "service@shanye.example",
+			Signature:      "山野咖啡 客户服务部",
+		}},
+		20: {ID: 20, NotificationBranding: &types.NotificationBranding{DisplayName: "茶语小铺"}},
+		30: {ID: 30},
+	}}
+	manager := NewBrandedNotificationTemplateManager(repository.NewNotificationBrandingProvider(tenantRepo, merchantRepo))
+
+	smsTemplate := manager.GetTemplate(NotificationMethodTypeSMS, NotificationCategoryCustomer, NotificationEventOrderCreated, "zh-CN")
+	emailTemplate := manager.GetTemplate(NotificationMethodTypeEmail, NotificationCategoryCustomer, NotificationEventOrderCreated, "zh-CN")
+
+	tests := []struct {
+		name        string
+		merchantID  uint64
+		smsPrefix   string
+		supportLine string
+		signature   string
+	}{
+		{name: "商户配置的品牌", merchantID: 10, smsPrefix: "【山野咖啡】", supportLine: "如有疑问，请联系客服：service@shanye.example", signature: "此致\n山野咖啡 客户服务部"},
+		{name: "商户只配置展示名称时以展示名称落款并使用租户客服", merchantID: 20, smsPrefix: "【茶语小铺】", supportLine: "如有疑问，请联系客服：400-800-1234", signature: "此致\n茶语小铺"},
+		{name: "商户未配置时使用租户默认品牌", merchantID: 30, smsPrefix: "【云集商城】", supportLine: "如有疑问，请联系客服：400-800-1234", signature: "此致\n云集商城"},
+		{name: "商户不存在时使用租户默认品牌", merchantID: 99, smsPrefix: "【云集商城】", supportLine: "如有疑问，请联系客服：400-800-1234", signature: "此致\n云集商城"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &types.Order{TenantID: 1, MerchantID: tt.merchantID, OrderNumber: "ORD20261015001", TotalAmount: 88, CreatedAt: time.Now()}
+			data := manager.BuildOrderDataMap(context.Background(), order, nil)
+
+			_, content, err := manager.RenderTemplate(smsTemplate, data)
+			if err != nil {
+				t.Fatalf("短信模板渲染失败: %v", err)
+			}
+			if !strings.HasPrefix(content, tt.smsPrefix+"您的订单 ORD20261015001") {
+				t.Errorf("短信应以 %s 开头，实际: %s", tt.smsPrefix, content)
+			}
+
+			_, content, err = manager.RenderTemplate(emailTemplate, data)
+			if err != nil {
+				t.Fatalf("邮件模板渲染失败: %v", err)
+			}
+			if !strings.Contains(content, tt.supportLine) {
+				t.Errorf("邮件应包含客服提示 %s，实际: %s", tt.supportLine, content)
+			}
+			if !strings.HasSuffix(content, tt.signature) {
+				t.Errorf("邮件应以 %q 落款，实际: %s", tt.signature, content)
+			}
+			if strings.Contains(content, "{{") {
+				t.Errorf("邮件中存在未替换的模板变量: %s", content)
+			}
+		})
+	}
+
+	// 后台任务发送通知时上下文中没有租户，查询商户时使用订单的租户
+	for _, tenantID := range merchantRepo.tenantIDs {
+		if tenantID != uint64(1) {
+			t.Errorf("查询商户时的租户应为订单租户 1，实际: %v", tenantID)
+		}
+	}
+}
+
+func TestNotificationTemplateManagerDefaultBranding(t *testing.T) {
+	tenantRepo := &brandingTenantRepository{}
+	merchantRepo := &brandingMerchantRepository{merchants: map[uint64]*types.Merchant{10: {ID: 10}}}
+
+	managers := map[string]*NotificationTemplateManager{
+		"未配置品牌提供者":    NewNotificationTemplateManager(),
+		"商户和租户都未配置品牌": NewBrandedNotificationTemplateManager(repository.NewNotificationBrandingProvider(tenantRepo, merchantRepo)),
+	}
+	for name, manager := range managers {
+		t.Run(name, func(t *testing.T) {
+			emailTemplate := manager.GetTemplate(NotificationMethodTypeEmail, NotificationCategoryCustomer, NotificationEventPaymentSuccess, "zh-CN")
+			order := &types.Order{TenantID: 1, MerchantID: 10, OrderNumber: "ORD20261015002", TotalAmount: 88}
+
+			_, content, err := manager.RenderTemplate(emailTemplate, manager.BuildOrderDataMap(context.Background(), order, nil))
+			if err != nil {
+				t.Fatalf("邮件模板渲染失败: %v", err)
+			}
+			if !strings.HasSuffix(content, "如有疑问，请联系我们的客服。\n\n此致\n商户系统") {
+				t.Errorf("未配置品牌时应使用默认落款，实际: %s", content)
+			}
+		})
+	}
+}
+
+func TestNotificationServiceBranding(t *testing.T) {
+	tenantRepo := &brandingTenantRepository{}
+	merchantRepo := &brandingMerchantRepository{merchants: map[uint64]*types.Merchant{
+		10: {ID: 10, NotificationBranding: &types.NotificationBranding{DisplayName: "山野咖啡", SupportContact: "400-600-7788"}},
+	}}
+	sms := &recordingSMSService{}
+	email := &contentRecordingEmailService{}
+	notificationService := NewBrandedNotificationServiceForTest(sms, email, repository.NewNotificationBrandingProvider(tenantRepo, merchantRepo))
+
+	order := &types.Order{TenantID: 1, MerchantID: 10, CustomerID: 100, OrderNumber: "ORD20261015003", TotalAmount: 88}
+	if err := notificationService.SendOrderCancelledNotification(context.Background(), order, "客户取消"); err != nil {
+		t.Fatalf("发送订单取消通知失败: %v", err)
+	}
+
+	if len(sms.contents) != 1 || !strings.HasPrefix(sms.contents[0], "【山野咖啡】") {
+		t.Errorf("取消短信应带商户展示名称，实际: %v", sms.contents)
+	}
+	if len(email.contents) != 1 || !strings.Contains(email.contents[0], "此致\n山野咖啡\n") {
+		t.Errorf("取消邮件应以商户展示名称落款，实际: %v", email.contents)
+	}
+}
+
 // contains 检查字符串是否包含子串的辅助函数
 func contains(s, substr string) bool {
 	for i := 0; i <= len(s)-len(substr); i++ {
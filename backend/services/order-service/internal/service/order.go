@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
@@ -13,71 +14,98 @@ type IOrderService interface {
 	CreateOrder(ctx context.Context, customerID uint64, req *types.CreateOrderRequest) (*types.Order, error)
 	GetOrder(ctx context.Context, orderID uint64) (*types.Order, error)
 	ListOrders(ctx context.Context, customerID uint64, status types.OrderStatus, page, limit int) ([]*types.Order, int, error)
-	CancelOrder(ctx context.Context, orderID uint64) error
+	CancelOrder(ctx context.Context, orderID uint64, req *types.CancelOrderRequest) (*types.OrderCancellationResult, error)
 	GetOrderConfirmation(ctx context.Context, customerID uint64, req *types.CreateOrderRequest) (*types.OrderConfirmation, error)
+	UpdateOrderItems(ctx context.Context, orderID uint64, changes []types.OrderItemChange) (*types.OrderItemsModificationResult, error)
+	BatchCreateOrders(ctx context.Context, customerID uint64, req *types.BatchCreateOrderRequest) (*types.BatchCreateOrderResult, error)
+	Reorder(ctx context.Context, orderID uint64, req *types.ReorderRequest) (*types.ReorderResult, error)
+	LabelOrderStatuses(ctx context.Context, locale string, orders ...*types.Order)
+	LookupOrder(ctx context.Context, req *types.OrderLookupRequest) (*types.Order, error)
 }
 
 // OrderService 订单服务实现
 type OrderService struct {
 	orderRepo           repository.IOrderRepository
 	cartRepo            repository.ICartRepository
+	policyRepo          repository.IOrderCancellationPolicyRepository
+	productRepo         repository.IProductAvailabilityRepository
+	bundleRepo          repository.IProductBundleRepository
+	merchantRepo        repository.MerchantRepository
+	refunder            OrderRefunder
 	notificationService NotificationService
+	noteRepo            repository.IOrderNoteRepository
+	reservationAdjuster OrderReservationAdjuster
+	inventoryReserver   OrderInventoryReserver
+	reservationReleaser ReservationReleaser
+	rightsRepo          repository.IOrderRightsReservationRepository
+	freezeRights        bool                                  // 下单时冻结权益直到支付
+	rightsRefund        repository.RightsRefundPolicyProvider // 为空时取消退款后按退款比例退还权益
+	webhooks            OrderEventPublisher
+	reviews             OrderReviewHolder
+	taxPolicy           repository.TaxPolicyProvider          // 为空时不计税
+	statusLabels        repository.OrderStatusLabelProvider   // 为空时状态使用内置中文名称
+	addressRepo         repository.ICustomerAddressRepository // 收货地址簿，下单时保存所选地址的快照
+	stepUp              StepUpVerifier                        // 取消订单需要退款时校验重新验证，为空时不校验
 }
 
 // NewOrderService 创建订单服务实例
-func NewOrderService() IOrderService {
+func NewOrderService(stepUp StepUpVerifier) IOrderService {
+	reservationRepo := repository.NewInventoryReservationRepository()
+	tenantRepo := repository.NewTenantRepository()
 	return &OrderService{
 		orderRepo:           repository.NewOrderRepository(),
 		cartRepo:            repository.NewCartRepository(),
+		policyRepo:          repository.NewOrderCancellationPolicyRepository(),
+		productRepo:         repository.NewProductAvailabilityRepository(),
+		bundleRepo:          repository.NewProductBundleRepository(),
+		merchantRepo:        repository.NewMerchantRepository(),
+		refunder:            NewPaymentService(),
 		notificationService: NewNotificationService(),
+		noteRepo:            repository.NewOrderNoteRepository(),
+		reservationAdjuster: newInventoryReservationAdjuster(),
+		inventoryReserver:   newInventoryOrderReserver(reservationRepo),
+		reservationReleaser: newInventoryReservationReleaser(reservationRepo),
+		rightsRepo:          repository.NewOrderRightsReservationRepository(),
+		freezeRights:        loadRightsFreezeEnabled(context.Background()),
+		rightsRefund:        repository.NewTenantRightsRefundPolicyProvider(tenantRepo),
+		webhooks:            NewOrderWebhookService(),
+		reviews:             NewOrderReviewService(NewOrderStatusService()),
+		taxPolicy:           repository.NewTenantTaxPolicyProvider(tenantRepo),
+		statusLabels:        repository.NewTenantOrderStatusLabelProvider(tenantRepo),
+		addressRepo:         repository.NewCustomerAddressRepository(),
+		stepUp:              stepUp,
+	}
+}
+
+// NewOrderServiceForTest 创建测试用订单服务实例
+func NewOrderServiceForTest(orderRepo repository.IOrderRepository, policyRepo repository.IOrderCancellationPolicyRepository, productRepo repository.IProductAvailabilityRepository, merchantRepo repository.MerchantRepository, refunder OrderRefunder) IOrderService {
+	return &OrderService{
+		orderRepo:    orderRepo,
+		policyRepo:   policyRepo,
+		productRepo:  productRepo,
+		merchantRepo: merchantRepo,
+		refunder:     refunder,
 	}
 }
 
 // CreateOrder 创建订单
 func (s *OrderService) CreateOrder(ctx context.Context, customerID uint64, req *types.CreateOrderRequest) (*types.Order, error) {
-	// 首先获取订单确认信息，验证库存和权益
-	confirmation, err := s.GetOrderConfirmation(ctx, customerID, req)
+	order, err := s.newOrder(ctx, customerID, req)
 	if err != nil {
-		return nil, fmt.Errorf("获取订单确认信息失败: %v", err)
+		return nil, err
 	}
 
-	if !confirmation.CanCreate {
-		return nil, fmt.Errorf("无法创建订单: %s", confirmation.ErrorMessage)
-	}
-
-	// 生成订单号
-	orderNumber, err := s.orderRepo.GenerateOrderNumber(ctx)
+	err = s.orderRepo.Create(ctx, order)
 	if err != nil {
-		return nil, fmt.Errorf("生成订单号失败: %v", err)
+		return nil, fmt.Errorf("创建订单失败: %w", err)
 	}
 
-	// 创建订单
-	// 转换订单项为正确的类型
-	items := make([]types.OrderItem, 0, len(confirmation.Items))
-	for _, item := range confirmation.Items {
-		items = append(items, types.OrderItem{
-			ProductID:  item.ProductID,
-			Quantity:   item.Quantity,
-			Price:      item.UnitPrice,
-			RightsCost: item.UnitRightsCost,
-		})
-	}
-
-	order := &types.Order{
-		MerchantID:      req.MerchantID,
-		CustomerID:      customerID,
-		OrderNumber:     orderNumber,
-		Status:          types.OrderStatusPending,
-		Items:           items,
-		PaymentInfo:     nil, // 支付信息在支付时填充
-		TotalAmount:     confirmation.TotalAmount,
-		TotalRightsCost: confirmation.TotalRightsCost,
-	}
-
-	err = s.orderRepo.Create(ctx, order)
-	if err != nil {
-		return nil, fmt.Errorf("创建订单失败: %v", err)
+	// 冻结权益需要订单ID，冻结失败时取消刚创建的订单
+	if err := s.freezeOrderRights(ctx, order); err != nil {
+		s.cancelRightsRejectedOrder(ctx, order, err)
+		return nil, fmt.Errorf("无法创建订单: %w", err)
 	}
+	s.holdForReview(ctx, order)
 
 	// 清空购物车（如果是从购物车创建的订单）
 	// TODO: 这里应该只清空已购买的商品项，暂时先全部清空
@@ -86,6 +114,8 @@ func (s *OrderService) CreateOrder(ctx context.Context, customerID uint64, req *
 		s.cartRepo.ClearCart(ctx, cart.ID)
 	}
 
+	publishOrderEvent(ctx, s.webhooks, types.OrderWebhookEventCreated, order, nil)
+
 	// 发送订单创建通知
 	go func() {
 		if err := s.notificationService.SendOrderCreatedNotification(context.Background(), order); err != nil {
@@ -97,6 +127,76 @@ func (s *OrderService) CreateOrder(ctx context.Context, customerID uint64, req *
 	return order, nil
 }
 
+// newOrder 校验库存、权益和下单限制并生成待支付订单，不保存
+func (s *OrderService) newOrder(ctx context.Context, customerID uint64, req *types.CreateOrderRequest) (*types.Order, error) {
+	externalReference, err := s.checkExternalReference(ctx, req.ExternalReference)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建订单: %w", err)
+	}
+
+	// 首先获取订单确认信息，验证库存和权益
+	confirmation, rejection, err := s.confirmOrder(ctx, customerID, req)
+	if err != nil {
+		return nil, fmt.Errorf("获取订单确认信息失败: %v", err)
+	}
+
+	// 超出购买数量或订单金额限制时返回可识别的错误
+	if rejection != nil {
+		return nil, fmt.Errorf("无法创建订单: %w", rejection)
+	}
+	if !confirmation.CanCreate {
+		return nil, fmt.Errorf("无法创建订单: %s", confirmation.ErrorMessage)
+	}
+
+	// 保存下单时的收货地址快照，之后修改地址簿不影响订单
+	shippingAddress, err := shippingAddressSnapshot(ctx, s.addressRepo, customerID, req.ShippingAddressID)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建订单: %w", err)
+	}
+
+	// 生成订单号
+	orderNumber, err := s.orderRepo.GenerateOrderNumber(ctx, req.MerchantID)
+	if err != nil {
+		return nil, fmt.Errorf("生成订单号失败: %v", err)
+	}
+
+	// 创建订单
+	// 转换订单项为正确的类型
+	items := make([]types.OrderItem, 0, len(confirmation.Items))
+	for _, item := range confirmation.Items {
+		orderItem := types.OrderItem{
+			ProductID:           item.ProductID,
+			Quantity:            item.Quantity,
+			Price:               item.UnitPrice,
+			RightsCost:          item.UnitRightsCost,
+			BackorderedQuantity: item.BackorderedQuantity,
+			EstimatedRestockAt:  item.EstimatedRestockAt,
+		}
+		if item.BundleID != 0 {
+			orderItem.BundleID = item.BundleID
+			orderItem.BundleName = item.ProductName
+			orderItem.BundleComponents = item.BundleComponents
+		}
+		items = append(items, orderItem)
+	}
+
+	order := &types.Order{
+		MerchantID:        req.MerchantID,
+		CustomerID:        customerID,
+		OrderNumber:       orderNumber,
+		Status:            types.OrderStatusPending,
+		Items:             items,
+		PaymentInfo:       nil, // 支付信息在支付时填充
+		TotalAmount:       confirmation.TotalAmount,
+		TotalRightsCost:   confirmation.TotalRightsCost,
+		ShippingAddress:   shippingAddress,
+		ExternalReference: externalReference,
+	}
+	order.ApplyTax(confirmation.Tax)
+
+	return order, nil
+}
+
 // GetOrder 获取订单详情
 func (s *OrderService) GetOrder(ctx context.Context, orderID uint64) (*types.Order, error) {
 	return s.orderRepo.GetByID(ctx, orderID)
@@ -107,34 +207,38 @@ func (s *OrderService) ListOrders(ctx context.Context, customerID uint64, status
 	return s.orderRepo.List(ctx, customerID, status, page, limit)
 }
 
-// CancelOrder 取消订单
-func (s *OrderService) CancelOrder(ctx context.Context, orderID uint64) error {
-	// 获取订单详情
-	order, err := s.orderRepo.GetByID(ctx, orderID)
-	if err != nil {
-		return fmt.Errorf("订单不存在: %v", err)
-	}
-
-	// 检查订单状态，只有待支付的订单可以取消
-	if order.Status != types.OrderStatusPending {
-		return fmt.Errorf("订单状态为 %s，无法取消", order.Status)
-	}
-
-	// 更新订单状态
-	return s.orderRepo.UpdateStatus(ctx, orderID, types.OrderStatusCancelled)
-}
-
 // GetOrderConfirmation 获取订单确认信息
 func (s *OrderService) GetOrderConfirmation(ctx context.Context, customerID uint64, req *types.CreateOrderRequest) (*types.OrderConfirmation, error) {
+	confirmation, _, err := s.confirmOrder(ctx, customerID, req)
+	return confirmation, err
+}
+
+// confirmOrder 计算订单确认信息和税费，超出购买数量、订单金额或权益余额限制时同时返回限制错误
+func (s *OrderService) confirmOrder(ctx context.Context, customerID uint64, req *types.CreateOrderRequest) (*types.OrderConfirmation, *OrderLimitError, error) {
 	confirmation := &types.OrderConfirmation{
-		Items:           make([]types.OrderConfirmationItem, 0, len(req.Items)),
+		Items:           make([]types.OrderConfirmationItem, 0, len(req.Items)+len(req.Bundles)),
 		TotalAmount:     0,
 		TotalRightsCost: 0,
 		CanCreate:       true,
 	}
 
+	products, err := s.orderProducts(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 同一商品可能出现在多个订单项中，购买数量限制按合计数量校验
+	quantities := make(map[uint64]int, len(req.Items))
+	for _, item := range req.Items {
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	var rejection *OrderLimitError
+	now := time.Now()
+	// 同一商品的多个订单项依次占用可用库存
+	requested := make(map[uint64]int, len(req.Items))
+
 	// TODO: 这里应该调用Product Service获取商品信息和库存
-	// TODO: 这里应该调用Fund Service检查权益余额
 	// 为了演示，暂时使用模拟数据
 	for _, item := range req.Items {
 		// 模拟商品价格
@@ -142,6 +246,12 @@ func (s *OrderService) GetOrderConfirmation(ctx context.Context, customerID uint
 		unitRightsCost := 10.0
 		stockAvailable := 50
 
+		product, exists := products[item.ProductID]
+		tracked := exists && product.InventoryInfo != nil && product.InventoryInfo.TrackInventory
+		if tracked {
+			stockAvailable = product.InventoryInfo.AvailableStock()
+		}
+
 		confirmationItem := types.OrderConfirmationItem{
 			ProductID:          item.ProductID,
 			ProductName:        fmt.Sprintf("商品%d", item.ProductID),
@@ -154,23 +264,100 @@ func (s *OrderService) GetOrderConfirmation(ctx context.Context, customerID uint
 			StockSufficient:    item.Quantity <= stockAvailable,
 		}
 
-		if !confirmationItem.StockSufficient {
+		if tracked {
+			// 超出可用库存的部分在预订上限内以预订方式接受，超出上限时由购买数量校验拒绝
+			inventory := *product.InventoryInfo
+			inventory.ReservedQuantity += requested[item.ProductID]
+			requested[item.ProductID] += item.Quantity
+			backordered, err := inventory.CheckReserve(item.Quantity)
+			confirmationItem.StockSufficient = err == nil
+			if err == nil && backordered > 0 {
+				confirmationItem.BackorderedQuantity = backordered
+				confirmationItem.EstimatedRestockAt = inventory.EstimatedRestockAt
+			}
+		} else if !confirmationItem.StockSufficient {
 			confirmation.CanCreate = false
 			confirmation.ErrorMessage = fmt.Sprintf("商品%d库存不足，可用库存: %d", item.ProductID, stockAvailable)
 		}
 
+		if exists {
+			// 不在可售时间窗口内的商品（未到开始时间或已过结束时间）不能下单
+			if !product.InAvailabilityWindow(now) {
+				confirmation.CanCreate = false
+				confirmation.ErrorMessage = fmt.Sprintf("商品%d不在可售时间内", item.ProductID)
+			} else if err := checkProductQuantity(product, quantities[item.ProductID]); err != nil && rejection == nil {
+				rejection = err
+				confirmation.CanCreate = false
+				confirmation.ErrorMessage = err.Error()
+			}
+		}
+
 		confirmation.Items = append(confirmation.Items, confirmationItem)
 		confirmation.TotalAmount += confirmationItem.SubtotalAmount
 		confirmation.TotalRightsCost += confirmationItem.SubtotalRightsCost
 	}
 
-	// 模拟检查权益余额
-	confirmation.AvailableRights = 1000.0 // 模拟可用权益
-	if confirmation.TotalRightsCost > confirmation.AvailableRights {
-		confirmation.CanCreate = false
-		confirmation.ErrorMessage = fmt.Sprintf("权益余额不足，需要权益: %.2f，可用权益: %.2f",
-			confirmation.TotalRightsCost, confirmation.AvailableRights)
+	// 套餐的组成商品占用商品订单项之后剩余的库存
+	if err := s.confirmOrderBundles(ctx, req, confirmation, requested); err != nil {
+		return nil, nil, err
 	}
 
-	return confirmation, nil
+	// 地区税率按商户经营地区匹配
+	region := ""
+	if s.merchantRepo != nil {
+		merchant, err := s.merchantRepo.GetByID(ctx, req.MerchantID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取商户信息失败: %v", err)
+		}
+		if merchant != nil && merchant.BusinessInfo != nil {
+			region = merchant.BusinessInfo.Region
+		}
+		if merchant != nil && merchant.RightsBalance != nil {
+			confirmation.AvailableRights = merchant.RightsBalance.GetConsumableBalance()
+		}
+		if merchant != nil {
+			confirmation.AvailablePaymentMethods = merchant.PaymentMethods.Effective()
+		}
+		if rejection == nil {
+			rejection = checkMerchantMinimumAmount(merchant, confirmation.TotalAmount)
+		}
+		// 下单时只做预校验，创建订单后冻结权益时在行锁下再次校验
+		if rejection == nil {
+			rejection = checkMerchantRights(merchant, confirmation.TotalRightsCost)
+		}
+		if rejection != nil {
+			confirmation.CanCreate = false
+			confirmation.ErrorMessage = rejection.Error()
+		}
+	}
+
+	// 起订金额和权益按商品金额校验，税费在校验后计入订单金额
+	if err := s.applyOrderTax(ctx, customerID, region, confirmation, products); err != nil {
+		return nil, nil, err
+	}
+
+	return confirmation, rejection, nil
+}
+
+// orderProducts 获取订单中的商品，用于校验可售时间窗口和购买数量限制
+func (s *OrderService) orderProducts(ctx context.Context, req *types.CreateOrderRequest) (map[uint64]*types.Product, error) {
+	if s.productRepo == nil || len(req.Items) == 0 {
+		return nil, nil
+	}
+
+	productIDs := make([]uint64, 0, len(req.Items))
+	for _, item := range req.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	products, err := s.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("获取商品信息失败: %v", err)
+	}
+
+	result := make(map[uint64]*types.Product, len(products))
+	for i := range products {
+		result[products[i].ID] = &products[i]
+	}
+	return result, nil
 }
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// staticProductAvailabilityRepository 固定商品数据的可售时间窗口仓储桩
+type staticProductAvailabilityRepository struct {
+	products []types.Product
+}
+
+func (f *staticProductAvailabilityRepository) GetByIDs(ctx context.Context, ids []uint64) ([]types.Product, error) {
+	return f.products, nil
+}
+
+func (f *staticProductAvailabilityRepository) ListDueForTransition(ctx context.Context, now time.Time, limit int) ([]types.Product, error) {
+	return nil, nil
+}
+
+func (f *staticProductAvailabilityRepository) TransitionStatus(ctx context.Context, product *types.Product, from, to types.ProductStatus) (bool, error) {
+	return false, nil
+}
+
+func TestOrderProductAvailability(t *testing.T) {
+	Convey("下单校验商品可售时间窗口", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		future := time.Now().Add(time.Hour)
+		past := time.Now().Add(-time.Hour)
+
+		productRepo := &staticProductAvailabilityRepository{products: []types.Product{
+			{ID: 1, Status: types.ProductStatusActive},
+			{ID: 2, Status: types.ProductStatusScheduled, AvailableFrom: &future},
+			{ID: 3, Status: types.ProductStatusActive, AvailableUntil: &past},
+		}}
+		orderService := NewOrderServiceForTest(nil, nil, productRepo, nil, nil)
+
+		newRequest := func(productIDs ...uint64) *types.CreateOrderRequest {
+			req := &types.CreateOrderRequest{MerchantID: 1}
+			for _, id := range productIDs {
+				req.Items = append(req.Items, struct {
+					ProductID uint64 `json:"product_id" v:"required#商品ID不能为空"`
+					Quantity  int    `json:"quantity" v:"required|min:1#数量不能为空|数量必须大于0"`
+				}{ProductID: id, Quantity: 1})
+			}
+			return req
+		}
+
+		Convey("可售商品可以下单", func() {
+			confirmation, err := orderService.GetOrderConfirmation(ctx, 100, newRequest(1))
+			So(err, ShouldBeNil)
+			So(confirmation.CanCreate, ShouldBeTrue)
+		})
+
+		Convey("未到开始时间的商品不能下单", func() {
+			confirmation, err := orderService.GetOrderConfirmation(ctx, 100, newRequest(1, 2))
+			So(err, ShouldBeNil)
+			So(confirmation.CanCreate, ShouldBeFalse)
+			So(confirmation.ErrorMessage, ShouldEqual, "商品2不在可售时间内")
+
+			_, err = orderService.CreateOrder(ctx, 100, newRequest(1, 2))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "商品2不在可售时间内")
+		})
+
+		Convey("已过结束时间的商品不能下单", func() {
+			confirmation, err := orderService.GetOrderConfirmation(ctx, 100, newRequest(3))
+			So(err, ShouldBeNil)
+			So(confirmation.CanCreate, ShouldBeFalse)
+			So(confirmation.ErrorMessage, ShouldEqual, "商品3不在可售时间内")
+		})
+	})
+}
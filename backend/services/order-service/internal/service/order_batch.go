@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// OrderInventoryReserver 为订单预留商品库存
+type OrderInventoryReserver interface {
+	ReserveOrderItem(ctx context.Context, order *types.Order, productID uint64, quantity int) (*types.InventoryReservation, error)
+}
+
+// inventoryOrderReserver 在同一事务中占用商品库存并创建订单预留记录
+type inventoryOrderReserver struct {
+	productRepo       *repository.ProductRepository
+	reservationRepo   repository.IInventoryReservationRepository
+	timeoutConfigRepo *repository.OrderTimeoutConfigRepository
+}
+
+// newInventoryOrderReserver 创建订单库存预留器
+func newInventoryOrderReserver(reservationRepo repository.IInventoryReservationRepository) OrderInventoryReserver {
+	return &inventoryOrderReserver{
+		productRepo:       repository.NewProductRepository(),
+		reservationRepo:   reservationRepo,
+		timeoutConfigRepo: repository.NewOrderTimeoutConfigRepository(),
+	}
+}
+
+// ReserveOrderItem 预留订单商品库存，到期时间按商户超时配置的预留保留时长设置
+func (r *inventoryOrderReserver) ReserveOrderItem(ctx context.Context, order *types.Order, productID uint64, quantity int) (*types.InventoryReservation, error) {
+	config, err := r.timeoutConfigRepo.GetEffectiveConfig(ctx, order.MerchantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取订单超时配置失败: %v", err)
+	}
+	expiresAt := config.ReservationExpiresAt(time.Now())
+
+	reservation := &types.InventoryReservation{
+		TenantID:         order.TenantID,
+		ProductID:        productID,
+		ReservedQuantity: quantity,
+		ReferenceType:    types.ReservationReferenceOrder,
+		ReferenceID:      strconv.FormatUint(order.ID, 10),
+		Status:           types.ReservationStatusActive,
+		ExpiresAt:        &expiresAt,
+	}
+
+	// 商品库存按商户隔离，预留时需要订单所属商户上下文
+	merchantCtx := context.WithValue(ctx, "merchant_id", order.MerchantID)
+	err = repository.TenantTransaction(merchantCtx, func(ctx context.Context, tx gdb.TX) error {
+		backordered, err := r.productRepo.ReserveInventory(ctx, productID, quantity)
+		if err != nil {
+			return err
+		}
+		reservation.BackorderedQuantity = backordered
+		return r.reservationRepo.Create(ctx, reservation)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("预留商品%d库存失败: %w", productID, err)
+	}
+	return reservation, nil
+}
+
+// batchReservationLedger 记录批量下单过程中各订单创建的库存预留，用于失败订单的补偿释放
+type batchReservationLedger struct {
+	orders       map[int]*types.Order
+	reservations map[int][]types.InventoryReservation
+}
+
+func newBatchReservationLedger() *batchReservationLedger {
+	return &batchReservationLedger{
+		orders:       make(map[int]*types.Order),
+		reservations: make(map[int][]types.InventoryReservation),
+	}
+}
+
+// trackOrder 记录批次中已保存的订单
+func (l *batchReservationLedger) trackOrder(index int, order *types.Order) {
+	l.orders[index] = order
+}
+
+// trackReservation 记录订单已创建的库存预留
+func (l *batchReservationLedger) trackReservation(index int, reservation *types.InventoryReservation) {
+	l.reservations[index] = append(l.reservations[index], *reservation)
+}
+
+// NewOrderBatchServiceForTest 创建测试用订单服务实例（用于批量下单）
+func NewOrderBatchServiceForTest(orderRepo repository.IOrderRepository, productRepo repository.IProductAvailabilityRepository, reserver OrderInventoryReserver, releaser ReservationReleaser) IOrderService {
+	return &OrderService{
+		orderRepo:           orderRepo,
+		productRepo:         productRepo,
+		inventoryReserver:   reserver,
+		reservationReleaser: releaser,
+	}
+}
+
+// BatchCreateOrders 批量创建订单并预留库存。订单逐个创建，任一步骤失败的订单会被取消，
+// 其已创建的库存预留统一在批次结束时释放；AllOrNothing 时任一订单失败，
+// 之前已成功的订单同样取消并释放预留，避免库存泄漏
+func (s *OrderService) BatchCreateOrders(ctx context.Context, customerID uint64, req *types.BatchCreateOrderRequest) (*types.BatchCreateOrderResult, error) {
+	if req == nil || len(req.Orders) == 0 {
+		return nil, fmt.Errorf("订单不能为空")
+	}
+	if len(req.Orders) > types.MaxBatchCreateOrders {
+		return nil, fmt.Errorf("单次最多创建%d个订单", types.MaxBatchCreateOrders)
+	}
+
+	ledger := newBatchReservationLedger()
+	failed := make(map[int]error)
+	for i := range req.Orders {
+		if err := s.createBatchOrder(ctx, customerID, &req.Orders[i], i, ledger); err != nil {
+			failed[i] = err
+			if req.AllOrNothing {
+				break
+			}
+		}
+	}
+
+	// 整批失败时，已成功的订单也需要回滚
+	if req.AllOrNothing && len(failed) > 0 {
+		for index := range ledger.orders {
+			if _, exists := failed[index]; !exists {
+				failed[index] = fmt.Errorf("同批次订单创建失败，整批回滚")
+			}
+		}
+	}
+
+	result := &types.BatchCreateOrderResult{
+		Orders:   make([]*types.Order, 0, len(req.Orders)),
+		Failures: make([]types.BatchCreateOrderFailure, 0, len(failed)),
+	}
+	result.ReleasedReservations = s.compensateBatch(ctx, ledger, failed)
+
+	for index, err := range failed {
+		failure := types.BatchCreateOrderFailure{Index: index, Error: err.Error()}
+		if order, exists := ledger.orders[index]; exists {
+			failure.OrderID = order.ID
+		}
+		result.Failures = append(result.Failures, failure)
+	}
+	sort.Slice(result.Failures, func(i, j int) bool {
+		return result.Failures[i].Index < result.Failures[j].Index
+	})
+
+	for i := range req.Orders {
+		order, exists := ledger.orders[i]
+		if !exists || failed[i] != nil {
+			continue
+		}
+		s.holdForReview(ctx, order)
+		result.Orders = append(result.Orders, order)
+		publishOrderEvent(ctx, s.webhooks, types.OrderWebhookEventCreated, order, nil)
+		if s.notificationService != nil {
+			go func(order *types.Order) {
+				if err := s.notificationService.SendOrderCreatedNotification(context.Background(), order); err != nil {
+					// 通知发送失败不影响订单创建
+					fmt.Printf("发送订单创建通知失败: %v\n", err)
+				}
+			}(order)
+		}
+	}
+
+	return result, nil
+}
+
+// createBatchOrder 创建批次中的单个订单，逐个商品（套餐按组成商品）预留库存后冻结商户权益，保存后的订单和预留都记入台账
+func (s *OrderService) createBatchOrder(ctx context.Context, customerID uint64, req *types.CreateOrderRequest, index int, ledger *batchReservationLedger) error {
+	order, err := s.newOrder(ctx, customerID, req)
+	if err != nil {
+		return err
+	}
+	if err := s.orderRepo.Create(ctx, order); err != nil {
+		return fmt.Errorf("创建订单失败: %w", err)
+	}
+	order.TenantID = gconv.Uint64(ctx.Value("tenant_id"))
+	ledger.trackOrder(index, order)
+
+	// 同一商品的多个订单项合并预留，套餐按组成商品分别预留
+	productIDs, quantities := orderStockQuantities(order.Items)
+	for _, productID := range productIDs {
+		reservation, err := s.inventoryReserver.ReserveOrderItem(ctx, order, productID, quantities[productID])
+		if err != nil {
+			return err
+		}
+		ledger.trackReservation(index, reservation)
+	}
+	return s.freezeOrderRights(ctx, order)
+}
+
+// compensateBatch 取消失败的订单并释放其库存预留和冻结权益，返回释放的库存预留数；
+// 补偿失败只记录日志，残留预留到期后由预留到期任务释放
+func (s *OrderService) compensateBatch(ctx context.Context, ledger *batchReservationLedger, failed map[int]error) int {
+	released := 0
+	for index, cause := range failed {
+		order, exists := ledger.orders[index]
+		if !exists {
+			continue
+		}
+
+		// 先关闭订单再释放库存，避免库存被他人占用后订单仍能支付
+		if err := s.orderRepo.UpdateStatusWithHistory(ctx, order.ID, types.OrderStatusIntCancelled,
+			"批量下单失败，订单自动取消", types.OrderStatusOperatorTypeSystem, nil,
+			map[string]interface{}{"cancel_reason": "batch_failed", "error": cause.Error()}); err != nil {
+			g.Log().Errorf(ctx, "批量下单失败订单 %d 取消失败: %v", order.ID, err)
+		}
+		order.Status = types.OrderStatusCancelled
+
+		if err := releaseOrderRights(ctx, s.rightsRepo, order); err != nil {
+			g.Log().Errorf(ctx, "批量下单失败订单 %d 退回冻结权益失败: %v", order.ID, err)
+		}
+
+		// 商品库存按商户隔离，释放时需要订单所属商户上下文
+		merchantCtx := context.WithValue(ctx, "merchant_id", order.MerchantID)
+		for i := range ledger.reservations[index] {
+			reservation := &ledger.reservations[index][i]
+			if err := s.reservationReleaser.ReleaseReservation(merchantCtx, reservation, types.ReservationStatusReleased); err != nil {
+				g.Log().Errorf(ctx, "批量下单失败订单 %d 释放库存预留 %d 失败: %v", order.ID, reservation.ID, err)
+				continue
+			}
+			reservation.Status = types.ReservationStatusReleased
+			released++
+		}
+	}
+	return released
+}
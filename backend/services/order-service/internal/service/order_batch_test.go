@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// batchOrderRepository 记录创建和取消操作的订单仓储桩
+type batchOrderRepository struct {
+	repository.IOrderRepository
+	created   []*types.Order
+	cancelled []uint64
+}
+
+func (f *batchOrderRepository) GenerateOrderNumber(ctx context.Context, merchantID uint64) (string, error) {
+	return fmt.Sprintf("ORD%d-%d", merchantID, len(f.created)+1), nil
+}
+
+func (f *batchOrderRepository) Create(ctx context.Context, order *types.Order) error {
+	order.ID = uint64(len(f.created) + 1)
+	f.created = append(f.created, order)
+	return nil
+}
+
+func (f *batchOrderRepository) UpdateStatusWithHistory(ctx context.Context, id uint64, status types.OrderStatusInt, reason string, operatorType types.OrderStatusOperatorType, operatorID *uint64, metadata interface{}) error {
+	if status == types.OrderStatusIntCancelled {
+		f.cancelled = append(f.cancelled, id)
+	}
+	return nil
+}
+
+// memoryInventory 内存库存，同时作为订单库存预留器和预留释放器
+type memoryInventory struct {
+	products     map[uint64]*types.InventoryInfo
+	reservations map[uint64]*types.InventoryReservation
+	nextID       uint64
+}
+
+func (m *memoryInventory) ReserveOrderItem(ctx context.Context, order *types.Order, productID uint64, quantity int) (*types.InventoryReservation, error) {
+	inventory := m.products[productID]
+	if _, err := inventory.CheckReserve(quantity); err != nil {
+		return nil, fmt.Errorf("预留商品%d库存失败: %w", productID, err)
+	}
+	inventory.ReservedQuantity += quantity
+
+	m.nextID++
+	reservation := &types.InventoryReservation{
+		ID:               m.nextID,
+		ProductID:        productID,
+		ReservedQuantity: quantity,
+		ReferenceType:    types.ReservationReferenceOrder,
+		ReferenceID:      fmt.Sprintf("%d", order.ID),
+		Status:           types.ReservationStatusActive,
+	}
+	m.reservations[reservation.ID] = reservation
+	return reservation, nil
+}
+
+func (m *memoryInventory) ReleaseReservation(ctx context.Context, reservation *types.InventoryReservation, status types.ReservationStatus) error {
+	stored := m.reservations[reservation.ID]
+	if stored.Status != types.ReservationStatusActive {
+		return fmt.Errorf("预留%d已释放", reservation.ID)
+	}
+	stored.Status = status
+	m.products[reservation.ProductID].ReservedQuantity -= reservation.ReservedQuantity
+	return nil
+}
+
+// activeReserved 商品仍处于活跃状态的预留总数
+func (m *memoryInventory) activeReserved(productID uint64) int {
+	total := 0
+	for _, reservation := range m.reservations {
+		if reservation.ProductID == productID && reservation.Status == types.ReservationStatusActive {
+			total += reservation.ReservedQuantity
+		}
+	}
+	return total
+}
+
+func TestBatchCreateOrders(t *testing.T) {
+	Convey("批量下单库存预留补偿", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+		// 下单校验使用的商品快照库存充足，实际库存已被其他订单占用，预留时才发现不足
+		productRepo := &staticProductAvailabilityRepository{products: []types.Product{
+			{ID: 1, Status: types.ProductStatusActive, InventoryInfo: &types.InventoryInfo{StockQuantity: 100, TrackInventory: true}},
+			{ID: 2, Status: types.ProductStatusActive, InventoryInfo: &types.InventoryInfo{StockQuantity: 100, TrackInventory: true}},
+		}}
+		inventory := &memoryInventory{
+			products: map[uint64]*types.InventoryInfo{
+				1: {StockQuantity: 10, TrackInventory: true},
+				2: {StockQuantity: 5, TrackInventory: true},
+			},
+			reservations: make(map[uint64]*types.InventoryReservation),
+		}
+		orderRepo := &batchOrderRepository{}
+		orderService := NewOrderBatchServiceForTest(orderRepo, productRepo, inventory, inventory)
+
+		newRequest := func(quantities map[uint64]int, productIDs ...uint64) types.CreateOrderRequest {
+			req := types.CreateOrderRequest{MerchantID: 1}
+			for _, productID := range productIDs {
+				req.Items = append(req.Items, struct {
+					ProductID uint64 `json:"product_id" v:"required#商品ID不能为空"`
+					Quantity  int    `json:"quantity" v:"required|min:1#数量不能为空|数量必须大于0"`
+				}{ProductID: productID, Quantity: quantities[productID]})
+			}
+			return req
+		}
+
+		Convey("批次中间的订单预留失败，只释放该订单已占用的预留", func() {
+			result, err := orderService.BatchCreateOrders(ctx, 100, &types.BatchCreateOrderRequest{
+				Orders: []types.CreateOrderRequest{
+					newRequest(map[uint64]int{1: 3}, 1),
+					// 商品1预留成功后商品2库存不足
+					newRequest(map[uint64]int{1: 2, 2: 6}, 1, 2),
+					newRequest(map[uint64]int{1: 4, 2: 1}, 1, 2),
+				},
+			})
+			So(err, ShouldBeNil)
+			So(result.Orders, ShouldHaveLength, 2)
+			So(result.Failures, ShouldHaveLength, 1)
+			So(result.Failures[0].Index, ShouldEqual, 1)
+			So(result.Failures[0].OrderID, ShouldEqual, 2)
+			So(result.ReleasedReservations, ShouldEqual, 1)
+			So(orderRepo.cancelled, ShouldResemble, []uint64{2})
+
+			// 成功订单的预留保留，失败订单的预留全部归还
+			So(inventory.products[1].ReservedQuantity, ShouldEqual, 7)
+			So(inventory.products[2].ReservedQuantity, ShouldEqual, 1)
+			So(inventory.activeReserved(1), ShouldEqual, 7)
+			So(inventory.activeReserved(2), ShouldEqual, 1)
+		})
+
+		Convey("整批模式下后续订单失败，之前成功订单的预留也全部释放", func() {
+			result, err := orderService.BatchCreateOrders(ctx, 100, &types.BatchCreateOrderRequest{
+				Orders: []types.CreateOrderRequest{
+					newRequest(map[uint64]int{1: 3, 2: 2}, 1, 2),
+					newRequest(map[uint64]int{1: 4}, 1),
+					newRequest(map[uint64]int{1: 3, 2: 4}, 1, 2),
+					newRequest(map[uint64]int{1: 1}, 1),
+				},
+				AllOrNothing: true,
+			})
+			So(err, ShouldBeNil)
+			So(result.Orders, ShouldBeEmpty)
+			So(result.Failures, ShouldHaveLength, 3)
+			So(result.ReleasedReservations, ShouldEqual, 4)
+			So(orderRepo.cancelled, ShouldHaveLength, 3)
+			// 失败后不再创建后续订单
+			So(orderRepo.created, ShouldHaveLength, 3)
+
+			So(inventory.products[1].ReservedQuantity, ShouldEqual, 0)
+			So(inventory.products[2].ReservedQuantity, ShouldEqual, 0)
+			So(inventory.activeReserved(1), ShouldEqual, 0)
+			So(inventory.activeReserved(2), ShouldEqual, 0)
+		})
+
+		Convey("全部成功时保留所有预留", func() {
+			result, err := orderService.BatchCreateOrders(ctx, 100, &types.BatchCreateOrderRequest{
+				Orders: []types.CreateOrderRequest{
+					newRequest(map[uint64]int{1: 5}, 1),
+					newRequest(map[uint64]int{2: 5}, 2),
+				},
+				AllOrNothing: true,
+			})
+			So(err, ShouldBeNil)
+			So(result.HasFailures(), ShouldBeFalse)
+			So(result.Orders, ShouldHaveLength, 2)
+			So(result.ReleasedReservations, ShouldEqual, 0)
+			So(inventory.products[1].ReservedQuantity, ShouldEqual, 5)
+			So(inventory.products[2].ReservedQuantity, ShouldEqual, 5)
+		})
+	})
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// NewOrderBundleServiceForTest 创建测试用订单服务实例（用于套餐下单）
+func NewOrderBundleServiceForTest(orderRepo repository.IOrderRepository, productRepo repository.IProductAvailabilityRepository, bundleRepo repository.IProductBundleRepository, reserver OrderInventoryReserver, releaser ReservationReleaser) IOrderService {
+	return &OrderService{
+		orderRepo:           orderRepo,
+		productRepo:         productRepo,
+		bundleRepo:          bundleRepo,
+		inventoryReserver:   reserver,
+		reservationReleaser: releaser,
+	}
+}
+
+// confirmOrderBundles 按套餐价格和套餐权益成本计算套餐确认项，不按组成商品价格累加。
+// 套餐的组成商品依次占用同一订单中剩余的可用库存，requested 为之前的订单项已占用的数量；
+// 套餐不存在、已停售或任一组成商品库存不足时不能下单
+func (s *OrderService) confirmOrderBundles(ctx context.Context, req *types.CreateOrderRequest, confirmation *types.OrderConfirmation, requested map[uint64]int) error {
+	if len(req.Bundles) == 0 {
+		return nil
+	}
+
+	bundles, products, err := s.orderBundles(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range req.Bundles {
+		bundle, exists := bundles[line.BundleID]
+		if !exists || bundle.MerchantID != req.MerchantID || !bundle.IsActive() {
+			confirmation.CanCreate = false
+			confirmation.ErrorMessage = fmt.Sprintf("套餐%d不存在或已停售", line.BundleID)
+			continue
+		}
+
+		availability := bundle.Availability(products, requested)
+		confirmationItem := types.OrderConfirmationItem{
+			ProductName:        bundle.Name,
+			Quantity:           line.Quantity,
+			UnitPrice:          bundle.Price,
+			UnitRightsCost:     bundle.RightsCost,
+			SubtotalAmount:     bundle.Price * float64(line.Quantity),
+			SubtotalRightsCost: bundle.RightsCost * float64(line.Quantity),
+			StockAvailable:     availability.Quantity,
+			StockSufficient:    availability.Allows(line.Quantity),
+			BundleID:           bundle.ID,
+			BundleComponents:   bundle.Components,
+		}
+		if !confirmationItem.StockSufficient {
+			confirmation.CanCreate = false
+			confirmation.ErrorMessage = fmt.Sprintf("套餐%d库存不足，可售%d份", bundle.ID, availability.Quantity)
+		}
+		for productID, quantity := range bundle.Components.Quantities(line.Quantity) {
+			requested[productID] += quantity
+		}
+
+		confirmation.Items = append(confirmation.Items, confirmationItem)
+		confirmation.TotalAmount += confirmationItem.SubtotalAmount
+		confirmation.TotalRightsCost += confirmationItem.SubtotalRightsCost
+	}
+	return nil
+}
+
+// orderBundles 获取订单中的套餐及其组成商品，未配置套餐仓储时套餐均视为不存在
+func (s *OrderService) orderBundles(ctx context.Context, req *types.CreateOrderRequest) (map[uint64]*types.ProductBundle, map[uint64]*types.Product, error) {
+	if s.bundleRepo == nil {
+		return nil, nil, nil
+	}
+
+	bundleIDs := make([]uint64, 0, len(req.Bundles))
+	for _, line := range req.Bundles {
+		bundleIDs = append(bundleIDs, line.BundleID)
+	}
+	bundles, err := s.bundleRepo.GetByIDs(ctx, bundleIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	products, err := bundleProducts(ctx, s.productRepo, bundles)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make(map[uint64]*types.ProductBundle, len(bundles))
+	for i := range bundles {
+		result[bundles[i].ID] = &bundles[i]
+	}
+	return result, products, nil
+}
+
+// bundleProducts 获取套餐的全部组成商品
+func bundleProducts(ctx context.Context, productRepo repository.IProductAvailabilityRepository, bundles []types.ProductBundle) (map[uint64]*types.Product, error) {
+	productIDs := make([]uint64, 0)
+	for _, bundle := range bundles {
+		for _, component := range bundle.Components {
+			productIDs = append(productIDs, component.ProductID)
+		}
+	}
+	if productRepo == nil || len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	products, err := productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("获取套餐商品信息失败: %v", err)
+	}
+
+	result := make(map[uint64]*types.Product, len(products))
+	for i := range products {
+		result[products[i].ID] = &products[i]
+	}
+	return result, nil
+}
+
+// orderStockQuantities 按首次出现的顺序汇总订单占用的各商品库存数量，套餐订单项按组成商品展开
+func orderStockQuantities(items []types.OrderItem) ([]uint64, map[uint64]int) {
+	quantities := make(map[uint64]int, len(items))
+	productIDs := make([]uint64, 0, len(items))
+	add := func(productID uint64, quantity int) {
+		if _, exists := quantities[productID]; !exists {
+			productIDs = append(productIDs, productID)
+		}
+		quantities[productID] += quantity
+	}
+
+	for _, item := range items {
+		if !item.IsBundle() {
+			add(item.ProductID, item.Quantity)
+			continue
+		}
+		for _, component := range item.BundleComponents {
+			add(component.ProductID, component.Quantity*item.Quantity)
+		}
+	}
+	return productIDs, quantities
+}
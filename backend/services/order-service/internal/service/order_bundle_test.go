@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// staticBundleRepository 固定套餐数据的套餐仓储桩
+type staticBundleRepository struct {
+	repository.IProductBundleRepository
+	bundles map[uint64]*types.ProductBundle
+}
+
+func (f *staticBundleRepository) GetByID(ctx context.Context, id uint64) (*types.ProductBundle, error) {
+	bundle, exists := f.bundles[id]
+	if !exists {
+		return nil, nil
+	}
+	copied := *bundle
+	return &copied, nil
+}
+
+func (f *staticBundleRepository) GetByIDs(ctx context.Context, ids []uint64) ([]types.ProductBundle, error) {
+	var bundles []types.ProductBundle
+	for _, id := range ids {
+		if bundle, exists := f.bundles[id]; exists {
+			bundles = append(bundles, *bundle)
+		}
+	}
+	return bundles, nil
+}
+
+// bundleCartRepository 记录套餐购物车项的购物车仓储桩
+type bundleCartRepository struct {
+	repository.ICartRepository
+	bundles map[uint64]int
+}
+
+func (f *bundleCartRepository) GetOrCreate(ctx context.Context, customerID uint64) (*types.Cart, error) {
+	return &types.Cart{ID: 1, CustomerID: customerID}, nil
+}
+
+func (f *bundleCartRepository) AddBundle(ctx context.Context, cartID uint64, bundleID uint64, quantity int, validate repository.CartItemValidator) error {
+	total := f.bundles[bundleID] + quantity
+	if err := validate(ctx, types.CartItem{BundleID: bundleID, Quantity: total}); err != nil {
+		return err
+	}
+	f.bundles[bundleID] = total
+	return nil
+}
+
+func TestProductBundleOrders(t *testing.T) {
+	Convey("套餐下单和加入购物车", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+		// 早餐套餐：2个面包（商品1）+ 1杯咖啡（商品2），套餐价低于单买
+		productRepo := &staticProductAvailabilityRepository{products: []types.Product{
+			{ID: 1, MerchantID: 1, Status: types.ProductStatusActive, InventoryInfo: &types.InventoryInfo{StockQuantity: 9, TrackInventory: true}},
+			{ID: 2, MerchantID: 1, Status: types.ProductStatusActive, InventoryInfo: &types.InventoryInfo{StockQuantity: 3, TrackInventory: true}},
+			{ID: 3, MerchantID: 1, Status: types.ProductStatusActive},
+		}}
+		bundleRepo := &staticBundleRepository{bundles: map[uint64]*types.ProductBundle{
+			10: {ID: 10, MerchantID: 1, Name: "早餐套餐", Price: 168, RightsCost: 15, Status: types.ProductBundleStatusActive,
+				Components: types.ProductBundleComponents{{ProductID: 1, Quantity: 2}, {ProductID: 2, Quantity: 1}}},
+			11: {ID: 11, MerchantID: 1, Name: "停售套餐", Price: 50, Status: types.ProductBundleStatusInactive,
+				Components: types.ProductBundleComponents{{ProductID: 3, Quantity: 1}}},
+		}}
+		inventory := &memoryInventory{
+			products: map[uint64]*types.InventoryInfo{
+				1: {StockQuantity: 9, TrackInventory: true},
+				2: {StockQuantity: 3, TrackInventory: true},
+			},
+			reservations: make(map[uint64]*types.InventoryReservation),
+		}
+		orderRepo := &batchOrderRepository{}
+		orderService := NewOrderBundleServiceForTest(orderRepo, productRepo, bundleRepo, inventory, inventory)
+
+		bundleRequest := func(bundleID uint64, quantity int) *types.CreateOrderRequest {
+			return &types.CreateOrderRequest{
+				MerchantID: 1,
+				Bundles:    []types.OrderBundleRequest{{BundleID: bundleID, Quantity: quantity}},
+			}
+		}
+
+		Convey("套餐按套餐价格和权益成本计价，不按组成商品累加", func() {
+			req := bundleRequest(10, 2)
+			req.AddItem(3, 1)
+			confirmation, err := orderService.GetOrderConfirmation(ctx, 100, req)
+			So(err, ShouldBeNil)
+			So(confirmation.CanCreate, ShouldBeTrue)
+			So(confirmation.Items, ShouldHaveLength, 2)
+
+			bundleItem := confirmation.Items[1]
+			So(bundleItem.BundleID, ShouldEqual, 10)
+			So(bundleItem.ProductID, ShouldEqual, 0)
+			So(bundleItem.ProductName, ShouldEqual, "早餐套餐")
+			So(bundleItem.UnitPrice, ShouldEqual, 168)
+			So(bundleItem.SubtotalAmount, ShouldEqual, 336)
+			So(bundleItem.SubtotalRightsCost, ShouldEqual, 30)
+			So(bundleItem.StockAvailable, ShouldEqual, 3)
+			// 商品3按单品价格计价，加上2份套餐价格
+			So(confirmation.TotalAmount, ShouldEqual, 100+336)
+			So(confirmation.TotalRightsCost, ShouldEqual, 10+30)
+		})
+
+		Convey("任一组成商品库存不足一份套餐时不能下单", func() {
+			// 咖啡只有3杯，最多3份套餐
+			confirmation, err := orderService.GetOrderConfirmation(ctx, 100, bundleRequest(10, 4))
+			So(err, ShouldBeNil)
+			So(confirmation.CanCreate, ShouldBeFalse)
+			So(confirmation.Items[0].StockSufficient, ShouldBeFalse)
+			So(confirmation.ErrorMessage, ShouldContainSubstring, "套餐10库存不足")
+
+			// 同一订单中单买的面包先占用库存，剩余面包只够2份套餐
+			req := bundleRequest(10, 3)
+			req.AddItem(1, 4)
+			confirmation, err = orderService.GetOrderConfirmation(ctx, 100, req)
+			So(err, ShouldBeNil)
+			So(confirmation.CanCreate, ShouldBeFalse)
+			So(confirmation.Items[1].StockAvailable, ShouldEqual, 2)
+		})
+
+		Convey("停售或不存在的套餐不能下单", func() {
+			confirmation, err := orderService.GetOrderConfirmation(ctx, 100, bundleRequest(11, 1))
+			So(err, ShouldBeNil)
+			So(confirmation.CanCreate, ShouldBeFalse)
+
+			confirmation, err = orderService.GetOrderConfirmation(ctx, 100, bundleRequest(99, 1))
+			So(err, ShouldBeNil)
+			So(confirmation.CanCreate, ShouldBeFalse)
+		})
+
+		Convey("下单时按组成商品分别预留库存，订单项保存套餐快照", func() {
+			result, err := orderService.BatchCreateOrders(ctx, 100, &types.BatchCreateOrderRequest{
+				Orders: []types.CreateOrderRequest{*bundleRequest(10, 2)},
+			})
+			So(err, ShouldBeNil)
+			So(result.Failures, ShouldBeEmpty)
+			So(result.Orders, ShouldHaveLength, 1)
+
+			order := result.Orders[0]
+			So(order.Items, ShouldHaveLength, 1)
+			So(order.Items[0].BundleID, ShouldEqual, 10)
+			So(order.Items[0].BundleName, ShouldEqual, "早餐套餐")
+			So(order.Items[0].Price, ShouldEqual, 168)
+			So(order.Items[0].BundleComponents, ShouldResemble, bundleRepo.bundles[10].Components)
+			So(order.TotalAmount, ShouldEqual, 336)
+
+			So(inventory.activeReserved(1), ShouldEqual, 4)
+			So(inventory.activeReserved(2), ShouldEqual, 2)
+		})
+
+		Convey("组成商品预留失败时释放该订单已预留的其他组成商品", func() {
+			// 下单校验后咖啡被其他订单占用
+			inventory.products[2].ReservedQuantity = 2
+			result, err := orderService.BatchCreateOrders(ctx, 100, &types.BatchCreateOrderRequest{
+				Orders: []types.CreateOrderRequest{*bundleRequest(10, 2)},
+			})
+			So(err, ShouldBeNil)
+			So(result.Failures, ShouldHaveLength, 1)
+			So(result.ReleasedReservations, ShouldEqual, 1)
+			So(inventory.activeReserved(1), ShouldEqual, 0)
+			So(inventory.products[1].ReservedQuantity, ShouldEqual, 0)
+			So(inventory.products[2].ReservedQuantity, ShouldEqual, 2)
+		})
+
+		Convey("加入购物车时按累加后的份数校验组成商品库存", func() {
+			cartRepo := &bundleCartRepository{bundles: make(map[uint64]int)}
+			cartService := NewCartBundleServiceForTest(cartRepo, productRepo, bundleRepo)
+
+			So(cartService.AddBundle(ctx, 100, 10, 2), ShouldBeNil)
+			So(cartService.AddBundle(ctx, 100, 10, 1), ShouldBeNil)
+			err := cartService.AddBundle(ctx, 100, 10, 1)
+			So(errors.Is(err, types.ErrProductBundleUnavailable), ShouldBeTrue)
+			So(cartRepo.bundles[10], ShouldEqual, 3)
+
+			err = cartService.AddBundle(ctx, 100, 11, 1)
+			So(errors.Is(err, types.ErrProductBundleUnavailable), ShouldBeTrue)
+			err = cartService.AddBundle(ctx, 100, 99, 1)
+			So(errors.Is(err, types.ErrProductBundleNotFound), ShouldBeTrue)
+		})
+	})
+}
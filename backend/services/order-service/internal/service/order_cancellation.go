@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+var (
+	// ErrOrderCancelNotAllowed 取消策略不允许在订单当前状态下取消
+	ErrOrderCancelNotAllowed = errors.New("订单当前状态不允许取消")
+	// ErrOrderCancelForbidden 操作员无权取消该订单
+	ErrOrderCancelForbidden = errors.New("无权取消该订单")
+	// ErrOrderRefundFailed 订单已取消但自动退款失败，需要人工处理
+	ErrOrderRefundFailed = errors.New("订单已取消，自动退款失败")
+)
+
+// OrderCancelError 订单取消被拒绝，可通过 errors.Is 判断具体原因
+type OrderCancelError struct {
+	OrderID         uint64
+	Status          types.OrderStatusInt
+	OperatorType    types.OrderStatusOperatorType
+	AllowedStatuses []types.OrderStatusInt
+	Err             error
+}
+
+func (e *OrderCancelError) Error() string {
+	if errors.Is(e.Err, ErrOrderCancelNotAllowed) {
+		return fmt.Sprintf("%v: 订单 %d 状态为 %s，%s 可取消的状态为 %v", e.Err, e.OrderID, e.Status, e.OperatorType, e.AllowedStatuses)
+	}
+	return fmt.Sprintf("%v: 订单 %d", e.Err, e.OrderID)
+}
+
+func (e *OrderCancelError) Unwrap() error {
+	return e.Err
+}
+
+// OrderRefunder 订单退款
+type OrderRefunder interface {
+	// CheckRefundable 校验订单能否原路退款，取消已支付订单前调用
+	CheckRefundable(ctx context.Context, order *types.Order) error
+	RefundPayment(ctx context.Context, order *types.Order, amount float64, reason string) error
+}
+
+// StepUpVerifier 校验当前会话是否近期重新验证过身份，需要重新验证时返回 *auth.StepUpRequiredError
+type StepUpVerifier interface {
+	VerifyStepUp(ctx context.Context, operation auth.StepUpOperation) error
+}
+
+// CancelOrder 按取消策略取消订单，已支付订单在策略启用时自动退款（客户取消时扣除取消手续费），
+// 退款后按租户的权益退还策略将退款比例对应的权益退还给商户；需要退款但支付渠道不支持退款时拒绝取消。
+// 需要退款时要求当前会话近期重新验证过身份，未支付或不退款的取消不需要
+func (s *OrderService) CancelOrder(ctx context.Context, orderID uint64, req *types.CancelOrderRequest) (*types.OrderCancellationResult, error) {
+	if req == nil {
+		req = &types.CancelOrderRequest{}
+	}
+	operatorType := req.OperatorType
+	if operatorType == "" {
+		operatorType = types.OrderStatusOperatorTypeCustomer
+	}
+	if !operatorType.IsValid() {
+		return nil, fmt.Errorf("无效的操作员类型: %s", operatorType)
+	}
+
+	// 获取订单详情
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("订单不存在: %v", err)
+	}
+
+	if err := s.checkCancelPermission(ctx, order, operatorType); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.policyRepo.GetEffectivePolicy(ctx, order.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, ok := order.Status.ToOrderStatusInt()
+	if !ok || !policy.CanCancel(operatorType, status) {
+		return nil, &OrderCancelError{
+			OrderID:         order.ID,
+			Status:          status,
+			OperatorType:    operatorType,
+			AllowedStatuses: policy.AllowedStatuses(operatorType),
+			Err:             ErrOrderCancelNotAllowed,
+		}
+	}
+
+	result := &types.OrderCancellationResult{OrderID: order.ID}
+	paid := order.PaymentInfo != nil && order.PaymentInfo.PaidAt != nil
+	var paidAmount float64
+	if paid && policy.RefundOnCancel {
+		paidAmount = order.PaymentInfo.Amount
+		if paidAmount <= 0 {
+			paidAmount = order.TotalAmount
+		}
+		// 手续费只在客户主动取消时收取，商户或系统取消全额退款
+		if operatorType == types.OrderStatusOperatorTypeCustomer {
+			result.CancellationFee = policy.CancellationFee(paidAmount)
+		}
+		result.RefundAmount = paidAmount - result.CancellationFee
+	}
+
+	// 无法自动退款时拒绝取消，避免订单已取消而款项没有退回
+	if result.RefundAmount > 0 {
+		if s.stepUp != nil {
+			if err := s.stepUp.VerifyStepUp(ctx, auth.StepUpOperationOrderRefund); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.refunder.CheckRefundable(ctx, order); err != nil {
+			return nil, &OrderCancelError{OrderID: order.ID, Status: status, OperatorType: operatorType, Err: err}
+		}
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "订单取消"
+	}
+	var operatorID *uint64
+	if userID := gconv.Uint64(ctx.Value("user_id")); userID > 0 {
+		operatorID = &userID
+	}
+	metadata := map[string]interface{}{
+		"refund_on_cancel": policy.RefundOnCancel,
+		"refund_amount":    result.RefundAmount,
+		"cancellation_fee": result.CancellationFee,
+	}
+	if err := s.orderRepo.UpdateStatusWithHistory(ctx, order.ID, types.OrderStatusIntCancelled, reason, operatorType, operatorID, metadata); err != nil {
+		return nil, fmt.Errorf("取消订单失败: %v", err)
+	}
+
+	// 先取消再退款，避免退款成功但订单仍可继续履约
+	if result.RefundAmount > 0 {
+		if err := s.refunder.RefundPayment(ctx, order, result.RefundAmount, reason); err != nil {
+			g.Log().Errorf(ctx, "订单 %d 取消后自动退款失败: %v", order.ID, err)
+			return result, fmt.Errorf("%w: %v", ErrOrderRefundFailed, err)
+		}
+		result.Refunded = true
+
+		// 退款已完成，权益退还失败只记录日志，不影响取消结果
+		rightsRefunded, err := s.restoreOrderRights(ctx, order, paidAmount, result.RefundAmount, reason)
+		if err != nil {
+			g.Log().Errorf(ctx, "订单 %d 退款后退还权益失败: %v", order.ID, err)
+		}
+		result.RightsRefunded = rightsRefunded
+	}
+
+	return result, nil
+}
+
+// checkCancelPermission 客户只能取消自己的订单，商户只能取消本商户的订单
+func (s *OrderService) checkCancelPermission(ctx context.Context, order *types.Order, operatorType types.OrderStatusOperatorType) error {
+	var allowed bool
+	switch operatorType {
+	case types.OrderStatusOperatorTypeCustomer:
+		allowed = order.CustomerID == gconv.Uint64(ctx.Value("user_id"))
+	case types.OrderStatusOperatorTypeMerchant:
+		allowed = order.MerchantID == gconv.Uint64(ctx.Value("merchant_id"))
+	default:
+		allowed = true
+	}
+
+	if !allowed {
+		return &OrderCancelError{OrderID: order.ID, OperatorType: operatorType, Err: ErrOrderCancelForbidden}
+	}
+	return nil
+}
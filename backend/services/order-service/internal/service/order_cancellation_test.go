@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// cancellableOrderRepository 记录取消操作的订单仓储桩
+type cancellableOrderRepository struct {
+	repository.IOrderRepository
+	order        *types.Order
+	cancelled    bool
+	operatorType types.OrderStatusOperatorType
+}
+
+func (f *cancellableOrderRepository) GetByID(ctx context.Context, id uint64) (*types.Order, error) {
+	return f.order, nil
+}
+
+func (f *cancellableOrderRepository) UpdateStatusWithHistory(ctx context.Context, id uint64, status types.OrderStatusInt, reason string, operatorType types.OrderStatusOperatorType, operatorID *uint64, metadata interface{}) error {
+	f.cancelled = status == types.OrderStatusIntCancelled
+	f.operatorType = operatorType
+	return nil
+}
+
+// staticCancellationPolicyRepository 返回固定取消策略的仓储桩
+type staticCancellationPolicyRepository struct {
+	repository.IOrderCancellationPolicyRepository
+	policy *types.OrderCancellationPolicy
+}
+
+func (f *staticCancellationPolicyRepository) GetEffectivePolicy(ctx context.Context, merchantID uint64) (*types.OrderCancellationPolicy, error) {
+	return f.policy, nil
+}
+
+// recordingRefunder 记录退款金额的退款桩
+type recordingRefunder struct {
+	amounts       []float64
+	err           error
+	refundableErr error
+}
+
+func (r *recordingRefunder) CheckRefundable(ctx context.Context, order *types.Order) error {
+	return r.refundableErr
+}
+
+func (r *recordingRefunder) RefundPayment(ctx context.Context, order *types.Order, amount float64, reason string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.amounts = append(r.amounts, amount)
+	return nil
+}
+
+// staleStepUpVerifier 始终要求重新验证身份的桩，记录校验的操作
+type staleStepUpVerifier struct {
+	operations []auth.StepUpOperation
+}
+
+func (v *staleStepUpVerifier) VerifyStepUp(ctx context.Context, operation auth.StepUpOperation) error {
+	v.operations = append(v.operations, operation)
+	return &auth.StepUpRequiredError{Operation: operation, Freshness: auth.DefaultStepUpFreshness}
+}
+
+func TestOrderCancellationPolicy(t *testing.T) {
+	Convey("订单取消策略测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		customerCtx := context.WithValue(ctx, "user_id", uint64(100))
+		merchantCtx := context.WithValue(context.WithValue(ctx, "user_id", uint64(9)), "merchant_id", uint64(1))
+		paidAt := time.Now()
+
+		pendingOrder := func() *types.Order {
+			return &types.Order{ID: 1, MerchantID: 1, CustomerID: 100, Status: types.OrderStatusPending, TotalAmount: 200}
+		}
+		paidOrder := func() *types.Order {
+			order := pendingOrder()
+			order.Status = types.OrderStatusPaid
+			order.PaymentInfo = &types.PaymentInfo{Method: "alipay", Amount: 200, PaidAt: &paidAt}
+			return order
+		}
+
+		orderRepo := &cancellableOrderRepository{}
+		policy := types.DefaultOrderCancellationPolicy(1)
+		refunder := &recordingRefunder{}
+		orderService := NewOrderServiceForTest(orderRepo, &staticCancellationPolicyRepository{policy: policy}, nil, nil, refunder)
+
+		Convey("默认策略：客户可取消待支付订单，无需退款", func() {
+			orderRepo.order = pendingOrder()
+			result, err := orderService.CancelOrder(customerCtx, 1, &types.CancelOrderRequest{})
+			So(err, ShouldBeNil)
+			So(orderRepo.cancelled, ShouldBeTrue)
+			So(orderRepo.operatorType, ShouldEqual, types.OrderStatusOperatorTypeCustomer)
+			So(result.Refunded, ShouldBeFalse)
+			So(refunder.amounts, ShouldBeEmpty)
+		})
+
+		Convey("默认策略：客户不能取消已支付订单", func() {
+			orderRepo.order = paidOrder()
+			_, err := orderService.CancelOrder(customerCtx, 1, &types.CancelOrderRequest{})
+			So(errors.Is(err, ErrOrderCancelNotAllowed), ShouldBeTrue)
+
+			var cancelErr *OrderCancelError
+			So(errors.As(err, &cancelErr), ShouldBeTrue)
+			So(cancelErr.Status, ShouldEqual, types.OrderStatusIntPaid)
+			So(orderRepo.cancelled, ShouldBeFalse)
+		})
+
+		Convey("默认策略：商户取消已支付订单自动全额退款", func() {
+			orderRepo.order = paidOrder()
+			result, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(err, ShouldBeNil)
+			So(result.Refunded, ShouldBeTrue)
+			So(result.RefundAmount, ShouldEqual, 200)
+			So(refunder.amounts, ShouldResemble, []float64{200})
+		})
+
+		Convey("默认策略不允许取消处理中订单，策略放开后可以取消", func() {
+			orderRepo.order = paidOrder()
+			orderRepo.order.Status = types.OrderStatusProcessing
+			_, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(errors.Is(err, ErrOrderCancelNotAllowed), ShouldBeTrue)
+
+			policy.MerchantCancelStatuses = append(policy.MerchantCancelStatuses, types.OrderStatusIntProcessing)
+			_, err = orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(err, ShouldBeNil)
+			So(orderRepo.cancelled, ShouldBeTrue)
+		})
+
+		Convey("关闭取消退款时已支付订单取消后不退款", func() {
+			policy.RefundOnCancel = false
+			orderRepo.order = paidOrder()
+			result, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(err, ShouldBeNil)
+			So(orderRepo.cancelled, ShouldBeTrue)
+			So(result.Refunded, ShouldBeFalse)
+			So(refunder.amounts, ShouldBeEmpty)
+		})
+
+		Convey("客户取消已支付订单按比例扣除手续费", func() {
+			policy.CustomerCancelStatuses = []types.OrderStatusInt{types.OrderStatusIntPending, types.OrderStatusIntPaid}
+			policy.FeeType = types.CancellationFeePercentage
+			policy.FeeValue = 10
+			orderRepo.order = paidOrder()
+
+			result, err := orderService.CancelOrder(customerCtx, 1, &types.CancelOrderRequest{})
+			So(err, ShouldBeNil)
+			So(result.CancellationFee, ShouldEqual, 20)
+			So(result.RefundAmount, ShouldEqual, 180)
+			So(refunder.amounts, ShouldResemble, []float64{180})
+
+			Convey("商户取消不收取手续费", func() {
+				orderRepo.order = paidOrder()
+				result, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+				So(err, ShouldBeNil)
+				So(result.CancellationFee, ShouldEqual, 0)
+				So(result.RefundAmount, ShouldEqual, 200)
+			})
+		})
+
+		Convey("固定手续费不超过支付金额", func() {
+			policy.CustomerCancelStatuses = []types.OrderStatusInt{types.OrderStatusIntPaid}
+			policy.FeeType = types.CancellationFeeFixed
+			policy.FeeValue = 500
+			orderRepo.order = paidOrder()
+
+			result, err := orderService.CancelOrder(customerCtx, 1, &types.CancelOrderRequest{})
+			So(err, ShouldBeNil)
+			So(result.CancellationFee, ShouldEqual, 200)
+			So(result.RefundAmount, ShouldEqual, 0)
+			So(result.Refunded, ShouldBeFalse)
+			So(refunder.amounts, ShouldBeEmpty)
+		})
+
+		Convey("退款失败时返回退款失败错误，订单保持已取消", func() {
+			refunder.err = errors.New("渠道不可用")
+			orderRepo.order = paidOrder()
+			result, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(errors.Is(err, ErrOrderRefundFailed), ShouldBeTrue)
+			So(orderRepo.cancelled, ShouldBeTrue)
+			So(result.Refunded, ShouldBeFalse)
+		})
+
+		Convey("支付渠道不支持退款时拒绝取消已支付订单，订单保持原状态", func() {
+			refunder.refundableErr = ErrRefundNotSupported
+			orderRepo.order = paidOrder()
+			result, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(errors.Is(err, ErrRefundNotSupported), ShouldBeTrue)
+			So(result, ShouldBeNil)
+			So(orderRepo.cancelled, ShouldBeFalse)
+			So(refunder.amounts, ShouldBeEmpty)
+
+			// 无需退款的待支付订单不受影响
+			orderRepo.order = pendingOrder()
+			_, err = orderService.CancelOrder(customerCtx, 1, &types.CancelOrderRequest{})
+			So(err, ShouldBeNil)
+			So(orderRepo.cancelled, ShouldBeTrue)
+		})
+
+		Convey("只有需要退款的取消要求重新验证身份", func() {
+			stepUp := &staleStepUpVerifier{}
+			orderService.(*OrderService).stepUp = stepUp
+
+			orderRepo.order = paidOrder()
+			result, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(errors.Is(err, auth.ErrStepUpRequired), ShouldBeTrue)
+			So(result, ShouldBeNil)
+			So(orderRepo.cancelled, ShouldBeFalse)
+			So(refunder.amounts, ShouldBeEmpty)
+			So(stepUp.operations, ShouldResemble, []auth.StepUpOperation{auth.StepUpOperationOrderRefund})
+
+			// 待支付订单和不退款的取消不需要重新验证
+			orderRepo.order = pendingOrder()
+			_, err = orderService.CancelOrder(customerCtx, 1, &types.CancelOrderRequest{})
+			So(err, ShouldBeNil)
+			So(orderRepo.cancelled, ShouldBeTrue)
+
+			policy.RefundOnCancel = false
+			orderRepo.order = paidOrder()
+			_, err = orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(err, ShouldBeNil)
+			So(stepUp.operations, ShouldHaveLength, 1)
+		})
+
+		Convey("只能取消自己的订单或本商户的订单", func() {
+			orderRepo.order = pendingOrder()
+			otherCustomer := context.WithValue(ctx, "user_id", uint64(200))
+			_, err := orderService.CancelOrder(otherCustomer, 1, &types.CancelOrderRequest{})
+			So(errors.Is(err, ErrOrderCancelForbidden), ShouldBeTrue)
+
+			otherMerchant := context.WithValue(ctx, "merchant_id", uint64(2))
+			_, err = orderService.CancelOrder(otherMerchant, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(errors.Is(err, ErrOrderCancelForbidden), ShouldBeTrue)
+			So(orderRepo.cancelled, ShouldBeFalse)
+		})
+
+		Convey("无效的策略配置被拒绝", func() {
+			So((&types.OrderCancellationPolicy{FeeType: types.CancellationFeePercentage, FeeValue: 120}).Validate(), ShouldNotBeNil)
+			So((&types.OrderCancellationPolicy{
+				FeeType:                types.CancellationFeeNone,
+				CustomerCancelStatuses: []types.OrderStatusInt{types.OrderStatusIntCompleted},
+			}).Validate(), ShouldNotBeNil)
+			So(types.DefaultOrderCancellationPolicy(1).Validate(), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// OrderDisputeNotifier 订单争议状态变更通知，同时通知下单客户和商户
+type OrderDisputeNotifier interface {
+	SendOrderDisputeNotification(ctx context.Context, order *types.Order, dispute *types.OrderDispute) error
+}
+
+// IOrderDisputeService 订单争议服务接口
+type IOrderDisputeService interface {
+	// 下单客户对订单发起争议，同一订单同一时间只能有一条未结束的争议
+	OpenDispute(ctx context.Context, orderID uint64, req *types.CreateOrderDisputeRequest) (*types.OrderDispute, error)
+	// 获取订单的争议记录：员工可查看，客户只能查看自己的订单
+	ListOrderDisputes(ctx context.Context, orderID uint64, isStaff bool) ([]types.OrderDispute, error)
+	// 员工按状态查看争议列表，商户用户只能查看本商户的争议
+	ListDisputes(ctx context.Context, req *types.OrderDisputeListRequest) ([]types.OrderDispute, error)
+	GetDispute(ctx context.Context, disputeID uint64, isStaff bool) (*types.OrderDispute, error)
+	// 员工更新争议状态，结束争议时记录处理说明
+	UpdateDisputeStatus(ctx context.Context, disputeID uint64, req *types.UpdateOrderDisputeStatusRequest) (*types.OrderDispute, error)
+}
+
+// OrderDisputeService 订单争议服务实现
+type OrderDisputeService struct {
+	orderRepo   repository.IOrderRepository
+	disputeRepo repository.IOrderDisputeRepository
+	notifier    OrderDisputeNotifier
+	now         func() time.Time
+}
+
+// NewOrderDisputeService 创建订单争议服务实例
+func NewOrderDisputeService(notifier OrderDisputeNotifier) IOrderDisputeService {
+	return &OrderDisputeService{
+		orderRepo:   repository.NewOrderRepository(),
+		disputeRepo: repository.NewOrderDisputeRepository(),
+		notifier:    notifier,
+		now:         time.Now,
+	}
+}
+
+// NewOrderDisputeServiceForTest 创建测试用订单争议服务实例
+func NewOrderDisputeServiceForTest(orderRepo repository.IOrderRepository, disputeRepo repository.IOrderDisputeRepository, notifier OrderDisputeNotifier, now func() time.Time) IOrderDisputeService {
+	return &OrderDisputeService{
+		orderRepo:   orderRepo,
+		disputeRepo: disputeRepo,
+		notifier:    notifier,
+		now:         now,
+	}
+}
+
+// OpenDispute 下单客户对订单发起争议
+func (s *OrderDisputeService) OpenDispute(ctx context.Context, orderID uint64, req *types.CreateOrderDisputeRequest) (*types.OrderDispute, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	customerID := gconv.Uint64(ctx.Value("user_id"))
+	if customerID == 0 {
+		return nil, fmt.Errorf("缺少用户信息")
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.CustomerID != customerID {
+		return nil, fmt.Errorf("只能对自己的订单发起争议")
+	}
+	if !types.CanDisputeOrder(order.Status) {
+		return nil, fmt.Errorf("订单状态为 %s，不能发起争议", order.Status)
+	}
+
+	disputes, err := s.disputeRepo.ListByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range disputes {
+		if existing.Status.IsActive() {
+			return nil, types.ErrOrderDisputeActive
+		}
+	}
+
+	dispute := &types.OrderDispute{
+		OrderID:     order.ID,
+		MerchantID:  order.MerchantID,
+		CustomerID:  customerID,
+		Reason:      req.Reason,
+		Description: req.Description,
+		Status:      types.OrderDisputeStatusOpen,
+	}
+	if err := s.disputeRepo.Create(ctx, dispute); err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "order_dispute", "open", map[string]interface{}{
+		"order_id":   order.ID,
+		"dispute_id": dispute.ID,
+		"reason":     dispute.Reason,
+	})
+	s.notify(ctx, order, dispute)
+
+	return dispute, nil
+}
+
+// ListOrderDisputes 获取订单的争议记录
+func (s *OrderDisputeService) ListOrderDisputes(ctx context.Context, orderID uint64, isStaff bool) ([]types.OrderDispute, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(ctx, order.MerchantID, order.CustomerID, isStaff); err != nil {
+		return nil, err
+	}
+
+	return s.disputeRepo.ListByOrderID(ctx, order.ID)
+}
+
+// ListDisputes 员工查看争议列表
+func (s *OrderDisputeService) ListDisputes(ctx context.Context, req *types.OrderDisputeListRequest) ([]types.OrderDispute, error) {
+	if req.Status != "" && !req.Status.IsValid() {
+		return nil, fmt.Errorf("无效的争议状态: %s", req.Status)
+	}
+	if merchantID := gconv.Uint64(ctx.Value("merchant_id")); merchantID > 0 {
+		req.MerchantID = &merchantID
+	}
+	if req.Limit <= 0 {
+		req.Limit = types.DefaultOrderDisputeListLimit
+	}
+	if req.Limit > types.MaxOrderDisputeListLimit {
+		req.Limit = types.MaxOrderDisputeListLimit
+	}
+
+	return s.disputeRepo.List(ctx, req)
+}
+
+// GetDispute 获取争议详情
+func (s *OrderDisputeService) GetDispute(ctx context.Context, disputeID uint64, isStaff bool) (*types.OrderDispute, error) {
+	dispute, err := s.getDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(ctx, dispute.MerchantID, dispute.CustomerID, isStaff); err != nil {
+		return nil, err
+	}
+	return dispute, nil
+}
+
+// UpdateDisputeStatus 员工更新争议状态（仅员工可调用，路由层校验权限）
+func (s *OrderDisputeService) UpdateDisputeStatus(ctx context.Context, disputeID uint64, req *types.UpdateOrderDisputeStatusRequest) (*types.OrderDispute, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	handlerID := gconv.Uint64(ctx.Value("user_id"))
+	if handlerID == 0 {
+		return nil, fmt.Errorf("缺少处理人信息")
+	}
+
+	dispute, err := s.getDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(ctx, dispute.MerchantID, dispute.CustomerID, true); err != nil {
+		return nil, err
+	}
+	if !dispute.Status.CanTransitionTo(req.Status) {
+		return nil, fmt.Errorf("争议状态不能从 %s 变更为 %s", dispute.Status, req.Status)
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, dispute.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromStatus := dispute.Status
+	dispute.Status = req.Status
+	dispute.HandlerID = &handlerID
+	if req.ResolutionNotes != "" {
+		dispute.ResolutionNotes = req.ResolutionNotes
+	}
+	if req.Status.IsClosed() {
+		resolvedAt := s.now()
+		dispute.ResolvedAt = &resolvedAt
+	}
+	if err := s.disputeRepo.UpdateStatus(ctx, dispute); err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "order_dispute", "update_status", map[string]interface{}{
+		"order_id":    dispute.OrderID,
+		"dispute_id":  dispute.ID,
+		"from_status": fromStatus,
+		"to_status":   dispute.Status,
+	})
+	s.notify(ctx, order, dispute)
+
+	return dispute, nil
+}
+
+// getDispute 获取当前租户的争议，不存在时返回错误
+func (s *OrderDisputeService) getDispute(ctx context.Context, disputeID uint64) (*types.OrderDispute, error) {
+	dispute, err := s.disputeRepo.GetByID(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute == nil {
+		return nil, fmt.Errorf("订单争议不存在")
+	}
+	return dispute, nil
+}
+
+// checkAccess 商户用户只能访问本商户的争议，客户只能访问自己发起的争议
+func (s *OrderDisputeService) checkAccess(ctx context.Context, merchantID, customerID uint64, isStaff bool) error {
+	if userMerchantID := gconv.Uint64(ctx.Value("merchant_id")); userMerchantID > 0 && merchantID != userMerchantID {
+		return fmt.Errorf("无权访问该订单争议")
+	}
+	if !isStaff && customerID != gconv.Uint64(ctx.Value("user_id")) {
+		return fmt.Errorf("无权访问该订单争议")
+	}
+	return nil
+}
+
+// notify 通知客户和商户争议状态变更，通知失败不影响争议处理
+func (s *OrderDisputeService) notify(ctx context.Context, order *types.Order, dispute *types.OrderDispute) {
+	if s.notifier == nil {
+		return
+	}
+	if err := s.notifier.SendOrderDisputeNotification(ctx, order, dispute); err != nil {
+		g.Log().Error(ctx, "发送订单争议通知失败", "dispute_id", dispute.ID, "error", err)
+	}
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeDisputeOrderRepository 按ID返回订单的订单仓储桩
+type fakeDisputeOrderRepository struct {
+	repository.IOrderRepository
+	orders map[uint64]*types.Order
+}
+
+func (f *fakeDisputeOrderRepository) GetByID(ctx context.Context, id uint64) (*types.Order, error) {
+	order, exists := f.orders[id]
+	if !exists {
+		return nil, fmt.Errorf("订单不存在")
+	}
+	return order, nil
+}
+
+// memoryOrderDisputeRepository 内存订单争议仓储
+type memoryOrderDisputeRepository struct {
+	disputes []*types.OrderDispute
+}
+
+func (m *memoryOrderDisputeRepository) Create(ctx context.Context, dispute *types.OrderDispute) error {
+	dispute.ID = uint64(len(m.disputes) + 1)
+	stored := *dispute
+	m.disputes = append(m.disputes, &stored)
+	return nil
+}
+
+func (m *memoryOrderDisputeRepository) GetByID(ctx context.Context, id uint64) (*types.OrderDispute, error) {
+	for _, dispute := range m.disputes {
+		if dispute.ID == id {
+			found := *dispute
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryOrderDisputeRepository) ListByOrderID(ctx context.Context, orderID uint64) ([]types.OrderDispute, error) {
+	var disputes []types.OrderDispute
+	for _, dispute := range m.disputes {
+		if dispute.OrderID == orderID {
+			disputes = append(disputes, *dispute)
+		}
+	}
+	return disputes, nil
+}
+
+func (m *memoryOrderDisputeRepository) List(ctx context.Context, req *types.OrderDisputeListRequest) ([]types.OrderDispute, error) {
+	var disputes []types.OrderDispute
+	for _, dispute := range m.disputes {
+		if req.Status != "" && dispute.Status != req.Status {
+			continue
+		}
+		if req.MerchantID != nil && dispute.MerchantID != *req.MerchantID {
+			continue
+		}
+		disputes = append(disputes, *dispute)
+	}
+	return disputes, nil
+}
+
+func (m *memoryOrderDisputeRepository) UpdateStatus(ctx context.Context, dispute *types.OrderDispute) error {
+	for i, existing := range m.disputes {
+		if existing.ID == dispute.ID {
+			updated := *dispute
+			m.disputes[i] = &updated
+			return nil
+		}
+	}
+	return fmt.Errorf("订单争议不存在")
+}
+
+func (m *memoryOrderDisputeRepository) ActiveOrderIDs(ctx context.Context, orderIDs []uint64) (map[uint64]bool, error) {
+	active := make(map[uint64]bool)
+	for _, dispute := range m.disputes {
+		for _, orderID := range orderIDs {
+			if dispute.OrderID == orderID && dispute.Status.IsActive() {
+				active[orderID] = true
+			}
+		}
+	}
+	return active, nil
+}
+
+// recordingDisputeNotifier 记录争议通知的通知器桩
+type recordingDisputeNotifier struct {
+	statuses []types.OrderDisputeStatus
+}
+
+func (r *recordingDisputeNotifier) SendOrderDisputeNotification(ctx context.Context, order *types.Order, dispute *types.OrderDispute) error {
+	r.statuses = append(r.statuses, dispute.Status)
+	return nil
+}
+
+func TestOrderDisputeService(t *testing.T) {
+	Convey("订单争议测试", t, func() {
+		now := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+		customerCtx := context.WithValue(context.WithValue(context.Background(), "tenant_id", uint64(1)), "user_id", uint64(100))
+		staffCtx := context.WithValue(context.WithValue(context.Background(), "tenant_id", uint64(1)), "user_id", uint64(900))
+
+		orderRepo := &fakeDisputeOrderRepository{orders: map[uint64]*types.Order{
+			1: {ID: 1, MerchantID: 10, CustomerID: 100, OrderNumber: "ORD001", Status: types.OrderStatusProcessing},
+			2: {ID: 2, MerchantID: 10, CustomerID: 100, OrderNumber: "ORD002", Status: types.OrderStatusPending},
+			3: {ID: 3, MerchantID: 20, CustomerID: 200, OrderNumber: "ORD003", Status: types.OrderStatusCompleted},
+		}}
+		disputeRepo := &memoryOrderDisputeRepository{}
+		notifier := &recordingDisputeNotifier{}
+		disputeService := NewOrderDisputeServiceForTest(orderRepo, disputeRepo, notifier, func() time.Time { return now })
+
+		openReq := &types.CreateOrderDisputeRequest{Reason: types.OrderDisputeReasonNotReceived, Description: "物流显示已签收但未收到"}
+
+		Convey("下单客户可以发起争议", func() {
+			dispute, err := disputeService.OpenDispute(customerCtx, 1, openReq)
+			So(err, ShouldBeNil)
+			So(dispute.Status, ShouldEqual, types.OrderDisputeStatusOpen)
+			So(dispute.OrderID, ShouldEqual, 1)
+			So(dispute.MerchantID, ShouldEqual, 10)
+			So(dispute.CustomerID, ShouldEqual, 100)
+			So(notifier.statuses, ShouldResemble, []types.OrderDisputeStatus{types.OrderDisputeStatusOpen})
+
+			Convey("同一订单不能同时有两条未结束的争议", func() {
+				_, err := disputeService.OpenDispute(customerCtx, 1, openReq)
+				So(err, ShouldEqual, types.ErrOrderDisputeActive)
+			})
+
+			Convey("争议结束后可以再次发起", func() {
+				_, err := disputeService.UpdateDisputeStatus(staffCtx, dispute.ID, &types.UpdateOrderDisputeStatusRequest{
+					Status: types.OrderDisputeStatusRejected, ResolutionNotes: "物流确认已妥投",
+				})
+				So(err, ShouldBeNil)
+
+				_, err = disputeService.OpenDispute(customerCtx, 1, openReq)
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("不能对他人的订单发起争议", func() {
+			_, err := disputeService.OpenDispute(customerCtx, 3, openReq)
+			So(err, ShouldNotBeNil)
+			So(disputeRepo.disputes, ShouldBeEmpty)
+		})
+
+		Convey("未支付订单不能发起争议", func() {
+			_, err := disputeService.OpenDispute(customerCtx, 2, openReq)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("无效的争议原因", func() {
+			_, err := disputeService.OpenDispute(customerCtx, 1, &types.CreateOrderDisputeRequest{Reason: "unknown", Description: "描述"})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("员工处理争议", func() {
+			dispute, err := disputeService.OpenDispute(customerCtx, 1, openReq)
+			So(err, ShouldBeNil)
+
+			investigating, err := disputeService.UpdateDisputeStatus(staffCtx, dispute.ID, &types.UpdateOrderDisputeStatusRequest{
+				Status: types.OrderDisputeStatusInvestigating,
+			})
+			So(err, ShouldBeNil)
+			So(investigating.Status, ShouldEqual, types.OrderDisputeStatusInvestigating)
+			So(*investigating.HandlerID, ShouldEqual, 900)
+			So(investigating.ResolvedAt, ShouldBeNil)
+
+			Convey("结束争议必须填写处理说明", func() {
+				_, err := disputeService.UpdateDisputeStatus(staffCtx, dispute.ID, &types.UpdateOrderDisputeStatusRequest{
+					Status: types.OrderDisputeStatusResolved,
+				})
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("解决争议后记录处理说明和结束时间并通知双方", func() {
+				resolved, err := disputeService.UpdateDisputeStatus(staffCtx, dispute.ID, &types.UpdateOrderDisputeStatusRequest{
+					Status: types.OrderDisputeStatusResolved, ResolutionNotes: "已补发商品",
+				})
+				So(err, ShouldBeNil)
+				So(resolved.ResolutionNotes, ShouldEqual, "已补发商品")
+				So(*resolved.ResolvedAt, ShouldEqual, now)
+				So(notifier.statuses, ShouldResemble, []types.OrderDisputeStatus{
+					types.OrderDisputeStatusOpen, types.OrderDisputeStatusInvestigating, types.OrderDisputeStatusResolved,
+				})
+
+				Convey("已结束的争议不能再变更", func() {
+					_, err := disputeService.UpdateDisputeStatus(staffCtx, dispute.ID, &types.UpdateOrderDisputeStatusRequest{
+						Status: types.OrderDisputeStatusInvestigating,
+					})
+					So(err, ShouldNotBeNil)
+				})
+			})
+
+			Convey("其他商户的员工不能处理争议", func() {
+				merchantCtx := context.WithValue(staffCtx, "merchant_id", uint64(20))
+				_, err := disputeService.UpdateDisputeStatus(merchantCtx, dispute.ID, &types.UpdateOrderDisputeStatusRequest{
+					Status: types.OrderDisputeStatusRejected, ResolutionNotes: "驳回",
+				})
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("客户只能查看自己订单的争议", func() {
+			_, err := disputeService.OpenDispute(customerCtx, 1, openReq)
+			So(err, ShouldBeNil)
+
+			disputes, err := disputeService.ListOrderDisputes(customerCtx, 1, false)
+			So(err, ShouldBeNil)
+			So(len(disputes), ShouldEqual, 1)
+
+			otherCustomerCtx := context.WithValue(customerCtx, "user_id", uint64(200))
+			_, err = disputeService.ListOrderDisputes(otherCustomerCtx, 1, false)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestOrderDisputePausesAutoComplete(t *testing.T) {
+	Convey("未结束的争议暂停订单自动完成", t, func() {
+		ctx := context.WithValue(context.WithValue(context.Background(), "tenant_id", uint64(1)), "user_id", uint64(100))
+		staffCtx := context.WithValue(ctx, "user_id", uint64(900))
+		now := time.Now()
+
+		disputed := &types.Order{ID: 1, MerchantID: 10, CustomerID: 100, Status: types.OrderStatusProcessing, StatusUpdatedAt: now.Add(-80 * time.Hour)}
+		undisputed := &types.Order{ID: 2, MerchantID: 10, CustomerID: 100, Status: types.OrderStatusProcessing, StatusUpdatedAt: now.Add(-80 * time.Hour)}
+
+		disputeRepo := &memoryOrderDisputeRepository{}
+		disputeService := NewOrderDisputeServiceForTest(
+			&fakeDisputeOrderRepository{orders: map[uint64]*types.Order{1: disputed, 2: undisputed}},
+			disputeRepo, nil, time.Now)
+		dispute, err := disputeService.OpenDispute(ctx, 1, &types.CreateOrderDisputeRequest{
+			Reason: types.OrderDisputeReasonWrongItem, Description: "收到的商品与订单不符",
+		})
+		So(err, ShouldBeNil)
+
+		statusService := &recordingOrderStatusService{updates: map[uint64]*types.UpdateOrderStatusRequest{}}
+		timeoutService := NewOrderTimeoutServiceForTest(
+			&fakeAutoCompleteOrderRepository{candidates: []*types.Order{disputed, undisputed}}, statusService, nil, nil)
+		timeoutService.disputeRepo = disputeRepo
+		config := &types.OrderTimeoutConfig{TenantID: 1, AutoCompleteEnabled: true, AutoCompleteAfterHours: 72}
+
+		Convey("存在未结束争议的订单不自动完成，其他订单照常完成", func() {
+			So(timeoutService.autoCompleteOrdersByConfig(ctx, config), ShouldBeNil)
+			So(statusService.updates, ShouldNotContainKey, uint64(1))
+			So(statusService.updates, ShouldContainKey, uint64(2))
+		})
+
+		Convey("调查中的争议同样暂停自动完成", func() {
+			_, err := disputeService.UpdateDisputeStatus(staffCtx, dispute.ID, &types.UpdateOrderDisputeStatusRequest{
+				Status: types.OrderDisputeStatusInvestigating,
+			})
+			So(err, ShouldBeNil)
+
+			So(timeoutService.autoCompleteOrdersByConfig(ctx, config), ShouldBeNil)
+			So(statusService.updates, ShouldNotContainKey, uint64(1))
+		})
+
+		Convey("争议结束后恢复自动完成", func() {
+			_, err := disputeService.UpdateDisputeStatus(staffCtx, dispute.ID, &types.UpdateOrderDisputeStatusRequest{
+				Status: types.OrderDisputeStatusResolved, ResolutionNotes: "已补发正确商品",
+			})
+			So(err, ShouldBeNil)
+
+			So(timeoutService.autoCompleteOrdersByConfig(ctx, config), ShouldBeNil)
+			So(statusService.updates, ShouldContainKey, uint64(1))
+		})
+	})
+}
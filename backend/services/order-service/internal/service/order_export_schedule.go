@@ -0,0 +1,482 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/export"
+	"github.com/gofromzero/mer-sys/backend/shared/notification"
+	"github.com/gofromzero/mer-sys/backend/shared/oss"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	// 默认的定时订单导出检查频率
+	defaultOrderExportCheckInterval = time.Minute
+	// 每次检查最多处理的定时导出和待投递执行数，未处理的在下次检查时继续
+	orderExportScheduleLimit = 50
+	// 每批读取的导出订单数
+	orderExtractBatchSize = 500
+	// 上传到对象存储的导出文件大小上限
+	orderExportMaxUploadSize = 100 << 20
+	// 执行记录默认返回条数
+	defaultOrderExportRunLimit = 50
+)
+
+// orderExtractHeader 定时订单导出文件表头
+var orderExtractHeader = []string{"订单ID", "订单号", "外部订单号", "商户ID", "客户ID", "订单状态", "商品金额", "税费", "订单金额", "权益成本", "下单时间"}
+
+// orderExportContentTypes 导出格式对应的文件类型
+var orderExportContentTypes = map[types.OrderExportFormat]string{
+	types.OrderExportFormatCSV:   "text/csv",
+	types.OrderExportFormatExcel: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// OrderExportMailer 定时订单导出邮件发送
+type OrderExportMailer interface {
+	SendEmail(ctx context.Context, to, subject, body string) error
+}
+
+// OrderExportStorage 定时订单导出文件的对象存储
+type OrderExportStorage interface {
+	UploadFromBytes(ctx context.Context, data []byte, fileName, contentType string, options *oss.UploadOptions) (*oss.UploadFileInfo, error)
+}
+
+// OrderExportScheduleService 定时订单导出服务：按租户配置的计划将新增订单导出给财务系统。
+// 到期的定时导出进入导出队列生成文件，生成完成后通过邮件发送下载链接或上传到对象存储；
+// 每次只导出上次投递之后创建的订单，投递失败时不推进导出位置，下次执行重新导出同一范围
+type OrderExportScheduleService struct {
+	scheduleRepo    repository.IOrderExportScheduleRepository
+	exports         *export.Queue
+	mailer          OrderExportMailer
+	storage         OrderExportStorage
+	downloadBaseURL string
+	interval        time.Duration
+	now             func() time.Time
+	stopCh          chan struct{}
+	isRunning       bool
+}
+
+// NewOrderExportScheduleService 创建定时订单导出服务实例，并在导出队列中注册订单导出
+func NewOrderExportScheduleService(exports *export.Queue) *OrderExportScheduleService {
+	ctx := context.Background()
+	interval := g.Cfg().MustGet(ctx, "order_export.checkInterval", defaultOrderExportCheckInterval).Duration()
+	if interval <= 0 {
+		interval = defaultOrderExportCheckInterval
+	}
+
+	s := NewOrderExportScheduleServiceForTest(repository.NewOrderExportScheduleRepository(), exports,
+		notification.NewNotificationService(), oss.NewOSSService(), time.Now)
+	s.interval = interval
+	s.downloadBaseURL = strings.TrimSuffix(g.Cfg().MustGet(ctx, "order_export.downloadBaseUrl").String(), "/")
+	return s
+}
+
+// NewOrderExportScheduleServiceForTest 创建测试用定时订单导出服务实例
+func NewOrderExportScheduleServiceForTest(scheduleRepo repository.IOrderExportScheduleRepository, exports *export.Queue, mailer OrderExportMailer, storage OrderExportStorage, now func() time.Time) *OrderExportScheduleService {
+	s := &OrderExportScheduleService{
+		scheduleRepo: scheduleRepo,
+		exports:      exports,
+		mailer:       mailer,
+		storage:      storage,
+		interval:     defaultOrderExportCheckInterval,
+		now:          now,
+		stopCh:       make(chan struct{}),
+	}
+	exports.Register(types.ExportKindOrderExtract, s.generateExtract)
+	return s
+}
+
+// Start 启动后台定时订单导出
+func (s *OrderExportScheduleService) Start(ctx context.Context) {
+	if s.isRunning {
+		return
+	}
+	s.isRunning = true
+	g.Log().Info(ctx, "启动定时订单导出", "interval", s.interval)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+				if _, err := s.DeliverCompleted(ctx); err != nil {
+					g.Log().Error(ctx, "投递定时订单导出失败", "error", err)
+				}
+				if _, err := s.RunDue(ctx); err != nil {
+					g.Log().Error(ctx, "执行定时订单导出失败", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止后台定时订单导出
+func (s *OrderExportScheduleService) Stop(ctx context.Context) {
+	if !s.isRunning {
+		return
+	}
+	s.isRunning = false
+	close(s.stopCh)
+	g.Log().Info(ctx, "定时订单导出已停止")
+}
+
+// CreateSchedule 创建定时订单导出，未指定起始订单时只导出创建之后的新订单
+func (s *OrderExportScheduleService) CreateSchedule(ctx context.Context, userID uint64, req *types.CreateOrderExportScheduleRequest) (*types.OrderExportSchedule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	schedule := &types.OrderExportSchedule{
+		Name:        strings.TrimSpace(req.Name),
+		Filter:      req.Filter,
+		Format:      req.Format,
+		Frequency:   req.Frequency,
+		Weekday:     req.Weekday,
+		Hour:        req.Hour,
+		Minute:      req.Minute,
+		Destination: req.Destination,
+		Recipient:   req.Recipient,
+		Enabled:     true,
+		CreatedBy:   userID,
+	}
+	if req.StartAfterOrderID != nil {
+		schedule.LastOrderID = *req.StartAfterOrderID
+	} else {
+		latest, err := s.scheduleRepo.LatestOrderID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		schedule.LastOrderID = latest
+	}
+	schedule.NextRunAt = schedule.NextRunAfter(s.now())
+
+	if err := s.scheduleRepo.Create(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "order_export_schedule", "create", map[string]interface{}{
+		"schedule_id":   schedule.ID,
+		"frequency":     schedule.Frequency,
+		"destination":   schedule.Destination,
+		"recipient":     schedule.Recipient,
+		"last_order_id": schedule.LastOrderID,
+	})
+	return schedule, nil
+}
+
+// ListSchedules 获取当前租户的定时订单导出
+func (s *OrderExportScheduleService) ListSchedules(ctx context.Context) ([]types.OrderExportSchedule, error) {
+	return s.scheduleRepo.List(ctx)
+}
+
+// SetScheduleEnabled 启用或停用定时订单导出，重新启用时从下一个计划时间开始执行，
+// 停用期间的新订单在下次执行时一并导出
+func (s *OrderExportScheduleService) SetScheduleEnabled(ctx context.Context, id uint64, enabled bool) (*types.OrderExportSchedule, error) {
+	schedule, err := s.getSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	nextRunAt := schedule.NextRunAfter(s.now())
+	if err := s.scheduleRepo.SetEnabled(ctx, id, enabled, nextRunAt); err != nil {
+		return nil, err
+	}
+	schedule.Enabled = enabled
+	if enabled {
+		schedule.NextRunAt = nextRunAt
+	}
+
+	audit.LogOperation(ctx, "order_export_schedule", "set_enabled", map[string]interface{}{
+		"schedule_id": id,
+		"enabled":     enabled,
+	})
+	return schedule, nil
+}
+
+// ListRuns 获取定时订单导出最近的执行记录
+func (s *OrderExportScheduleService) ListRuns(ctx context.Context, id uint64, limit int) ([]types.OrderExportRun, error) {
+	if _, err := s.getSchedule(ctx, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > defaultOrderExportRunLimit {
+		limit = defaultOrderExportRunLimit
+	}
+	return s.scheduleRepo.ListRuns(ctx, id, limit)
+}
+
+// getSchedule 获取当前租户的定时订单导出
+func (s *OrderExportScheduleService) getSchedule(ctx context.Context, id uint64) (*types.OrderExportSchedule, error) {
+	schedule, err := s.scheduleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return nil, types.ErrOrderExportScheduleNotFound
+	}
+	return schedule, nil
+}
+
+// RunDue 为所有租户到期的定时导出创建导出任务，返回本次创建的执行数
+func (s *OrderExportScheduleService) RunDue(ctx context.Context) (int, error) {
+	now := s.now()
+	schedules, err := s.scheduleRepo.ListDue(ctx, now, orderExportScheduleLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	started := 0
+	for i := range schedules {
+		ok, err := s.run(ctx, &schedules[i], now)
+		if err != nil {
+			g.Log().Error(ctx, "执行定时订单导出失败", "schedule_id", schedules[i].ID, "error", err)
+			continue
+		}
+		if ok {
+			started++
+		}
+	}
+	return started, nil
+}
+
+// run 将上次投递之后到当前最新订单之间的订单加入导出队列。
+// 上一次执行仍在生成时本次顺延，避免两次执行导出同一范围
+func (s *OrderExportScheduleService) run(ctx context.Context, schedule *types.OrderExportSchedule, now time.Time) (bool, error) {
+	// 定时任务没有请求上下文，按定时导出所属租户和创建人设置上下文，导出任务归属于创建人
+	tenantCtx := context.WithValue(ctx, "tenant_id", schedule.TenantID)
+	tenantCtx = context.WithValue(tenantCtx, "user_id", schedule.CreatedBy)
+
+	generating, err := s.scheduleRepo.HasGeneratingRun(tenantCtx, schedule.ID)
+	if err != nil || generating {
+		return false, err
+	}
+
+	// 先推进下一次执行时间，多个实例同时执行时只有推进成功的一方创建导出
+	claimed, err := s.scheduleRepo.ClaimRun(tenantCtx, schedule, schedule.NextRunAfter(now), now)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	through, err := s.scheduleRepo.LatestOrderID(tenantCtx)
+	if err != nil {
+		return false, err
+	}
+	if through < schedule.LastOrderID {
+		through = schedule.LastOrderID
+	}
+
+	run := &types.OrderExportRun{
+		ScheduleID:     schedule.ID,
+		Status:         types.OrderExportRunStatusGenerating,
+		FromOrderID:    schedule.LastOrderID,
+		ThroughOrderID: through,
+		StartedAt:      now,
+	}
+	job, err := s.exports.Enqueue(tenantCtx, &export.Request{
+		Kind:     types.ExportKindOrderExtract,
+		Format:   string(schedule.Format),
+		FileName: fmt.Sprintf("orders_%d_%s.%s", schedule.ID, now.Format("20060102_1504"), schedule.Format.Extension()),
+		Params: &types.OrderExtractParams{
+			ScheduleID:     schedule.ID,
+			Filter:         schedule.Filter,
+			Format:         schedule.Format,
+			AfterOrderID:   run.FromOrderID,
+			ThroughOrderID: run.ThroughOrderID,
+		},
+	})
+	if err != nil {
+		// 导出位置未推进，下次执行时重新导出
+		run.Status = types.OrderExportRunStatusFailed
+		run.ErrorMessage = fmt.Sprintf("创建导出任务失败: %v", err)
+		run.CompletedAt = &now
+	} else {
+		run.ExportJobID = job.UUID
+	}
+	if err := s.scheduleRepo.CreateRun(tenantCtx, run); err != nil {
+		return false, err
+	}
+
+	g.Log().Info(ctx, "已执行定时订单导出",
+		"tenant_id", schedule.TenantID,
+		"schedule_id", schedule.ID,
+		"from_order_id", run.FromOrderID,
+		"through_order_id", run.ThroughOrderID,
+		"status", run.Status)
+	return run.Status == types.OrderExportRunStatusGenerating, nil
+}
+
+// DeliverCompleted 投递所有租户已生成完成的导出文件并记录执行结果，返回本次结束的执行数
+func (s *OrderExportScheduleService) DeliverCompleted(ctx context.Context) (int, error) {
+	runs, err := s.scheduleRepo.ListGeneratingRuns(ctx, orderExportScheduleLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	for i := range runs {
+		ok, err := s.deliver(ctx, &runs[i])
+		if err != nil {
+			g.Log().Error(ctx, "投递定时订单导出失败", "run_id", runs[i].ID, "error", err)
+			continue
+		}
+		if ok {
+			completed++
+		}
+	}
+	return completed, nil
+}
+
+// deliver 导出任务结束后投递导出文件，导出任务仍在生成时返回 false。
+// 投递成功后导出位置推进到本次导出的上界，生成或投递失败时保持不变
+func (s *OrderExportScheduleService) deliver(ctx context.Context, run *types.OrderExportRun) (bool, error) {
+	tenantCtx := context.WithValue(ctx, "tenant_id", run.TenantID)
+
+	job, err := s.exports.Get(tenantCtx, run.ExportJobID)
+	if err != nil {
+		return false, err
+	}
+	if !job.Status.IsTerminal() {
+		return false, nil
+	}
+
+	schedule, err := s.scheduleRepo.GetByID(tenantCtx, run.ScheduleID)
+	if err != nil {
+		return false, err
+	}
+
+	now := s.now()
+	run.CompletedAt = &now
+	run.RowCount = job.RowCount
+	switch {
+	case job.Status == types.ExportJobStatusFailed:
+		run.Status = types.OrderExportRunStatusFailed
+		run.ErrorMessage = "生成导出文件失败: " + job.ErrorMessage
+	case schedule == nil:
+		run.Status = types.OrderExportRunStatusFailed
+		run.ErrorMessage = types.ErrOrderExportScheduleNotFound.Error()
+	default:
+		run.Status = types.OrderExportRunStatusDelivered
+		if err := s.send(tenantCtx, schedule, job); err != nil {
+			run.Status = types.OrderExportRunStatusFailed
+			run.ErrorMessage = err.Error()
+		}
+	}
+
+	if err := s.scheduleRepo.CompleteRun(tenantCtx, run); err != nil {
+		return false, err
+	}
+
+	g.Log().Info(ctx, "定时订单导出执行结束",
+		"tenant_id", run.TenantID,
+		"schedule_id", run.ScheduleID,
+		"run_id", run.ID,
+		"rows", run.RowCount,
+		"status", run.Status,
+		"error", run.ErrorMessage)
+	return true, nil
+}
+
+// send 按定时导出的投递方式发送导出文件
+func (s *OrderExportScheduleService) send(ctx context.Context, schedule *types.OrderExportSchedule, job *types.ExportJob) error {
+	switch schedule.Destination {
+	case types.OrderExportDestinationEmail:
+		subject := fmt.Sprintf("定时订单导出：%s", schedule.Name)
+		body := fmt.Sprintf("定时订单导出「%s」已生成，共 %d 条订单。<br/>下载地址：%s/api/v1/exports/%s/download",
+			schedule.Name, job.RowCount, s.downloadBaseURL, job.UUID)
+		for _, recipient := range schedule.Recipients() {
+			if err := s.mailer.SendEmail(ctx, recipient, subject, body); err != nil {
+				return fmt.Errorf("发送导出邮件到 %s 失败: %v", recipient, err)
+			}
+		}
+		return nil
+	case types.OrderExportDestinationStorage:
+		data, err := os.ReadFile(job.FilePath)
+		if err != nil {
+			return fmt.Errorf("读取导出文件失败: %v", err)
+		}
+		contentType := orderExportContentTypes[schedule.Format]
+		_, err = s.storage.UploadFromBytes(ctx, data, job.FileName, contentType, &oss.UploadOptions{
+			Directory:    fmt.Sprintf("exports/%d/%s", schedule.TenantID, schedule.Recipient),
+			AllowedTypes: []string{contentType},
+			MaxSize:      orderExportMaxUploadSize,
+		})
+		if err != nil {
+			return fmt.Errorf("上传导出文件失败: %v", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的投递方式: %s", schedule.Destination)
+	}
+}
+
+// generateExtract 导出队列中的定时订单导出生成器，按订单ID顺序分批导出范围内的订单
+func (s *OrderExportScheduleService) generateExtract(ctx context.Context, job *types.ExportJob, w io.Writer) (int, error) {
+	var params types.OrderExtractParams
+	if err := json.Unmarshal([]byte(job.Params), &params); err != nil {
+		return 0, fmt.Errorf("解析导出条件失败: %v", err)
+	}
+
+	writer, err := export.NewRowWriter(params.Format, w)
+	if err != nil {
+		return 0, err
+	}
+	if err := writer.WriteRow(orderExtractHeader); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	afterID := params.AfterOrderID
+	for {
+		rows, err := s.scheduleRepo.ListOrders(ctx, &params.Filter, afterID, params.ThroughOrderID, orderExtractBatchSize)
+		if err != nil {
+			return count, err
+		}
+
+		for i := range rows {
+			if err := writer.WriteRow(orderExtractRecord(&rows[i])); err != nil {
+				return count, fmt.Errorf("写入导出记录失败: %v", err)
+			}
+		}
+		count += len(rows)
+
+		if len(rows) < orderExtractBatchSize {
+			break
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+
+	if err := writer.Close(); err != nil {
+		return count, fmt.Errorf("生成导出文件失败: %v", err)
+	}
+	return count, nil
+}
+
+// orderExtractRecord 将订单转换为导出行
+func orderExtractRecord(row *types.OrderExtractRow) []string {
+	return []string{
+		strconv.FormatUint(row.ID, 10),
+		row.OrderNumber,
+		row.ExternalReference,
+		strconv.FormatUint(row.MerchantID, 10),
+		strconv.FormatUint(row.CustomerID, 10),
+		string(row.Status),
+		strconv.FormatFloat(row.SubtotalAmount, 'f', 2, 64),
+		strconv.FormatFloat(row.TaxAmount, 'f', 2, 64),
+		strconv.FormatFloat(row.TotalAmount, 'f', 2, 64),
+		strconv.FormatFloat(row.TotalRightsCost, 'f', 2, 64),
+		row.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/export"
+	"github.com/gofromzero/mer-sys/backend/shared/oss"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryOrderExportScheduleRepository 内存定时订单导出仓储
+type memoryOrderExportScheduleRepository struct {
+	repository.IOrderExportScheduleRepository
+	mu        sync.Mutex
+	schedules []*types.OrderExportSchedule
+	runs      []*types.OrderExportRun
+	orders    []types.OrderExtractRow
+}
+
+func (m *memoryOrderExportScheduleRepository) Create(ctx context.Context, schedule *types.OrderExportSchedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	schedule.ID = uint64(len(m.schedules) + 1)
+	schedule.TenantID = gconv.Uint64(ctx.Value("tenant_id"))
+	saved := *schedule
+	m.schedules = append(m.schedules, &saved)
+	return nil
+}
+
+func (m *memoryOrderExportScheduleRepository) GetByID(ctx context.Context, id uint64) (*types.OrderExportSchedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, schedule := range m.schedules {
+		if schedule.ID == id && schedule.TenantID == gconv.Uint64(ctx.Value("tenant_id")) {
+			found := *schedule
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryOrderExportScheduleRepository) SetEnabled(ctx context.Context, id uint64, enabled bool, nextRunAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	schedule := m.schedules[id-1]
+	schedule.Enabled = enabled
+	if enabled {
+		schedule.NextRunAt = nextRunAt
+	}
+	return nil
+}
+
+func (m *memoryOrderExportScheduleRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]types.OrderExportSchedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []types.OrderExportSchedule
+	for _, schedule := range m.schedules {
+		if schedule.Enabled && !schedule.NextRunAt.After(now) {
+			due = append(due, *schedule)
+		}
+	}
+	return due, nil
+}
+
+func (m *memoryOrderExportScheduleRepository) ClaimRun(ctx context.Context, schedule *types.OrderExportSchedule, nextRunAt time.Time, now time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	saved := m.schedules[schedule.ID-1]
+	if !saved.NextRunAt.Equal(schedule.NextRunAt) {
+		return false, nil
+	}
+	saved.NextRunAt = nextRunAt
+	saved.LastRunAt = &now
+	return true, nil
+}
+
+func (m *memoryOrderExportScheduleRepository) HasGeneratingRun(ctx context.Context, scheduleID uint64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, run := range m.runs {
+		if run.ScheduleID == scheduleID && run.Status == types.OrderExportRunStatusGenerating {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryOrderExportScheduleRepository) CreateRun(ctx context.Context, run *types.OrderExportRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run.ID = uint64(len(m.runs) + 1)
+	run.TenantID = gconv.Uint64(ctx.Value("tenant_id"))
+	saved := *run
+	m.runs = append(m.runs, &saved)
+	return nil
+}
+
+func (m *memoryOrderExportScheduleRepository) ListGeneratingRuns(ctx context.Context, limit int) ([]types.OrderExportRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var runs []types.OrderExportRun
+	for _, run := range m.runs {
+		if run.Status == types.OrderExportRunStatusGenerating {
+			runs = append(runs, *run)
+		}
+	}
+	return runs, nil
+}
+
+func (m *memoryOrderExportScheduleRepository) CompleteRun(ctx context.Context, run *types.OrderExportRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.runs[run.ID-1].Status != types.OrderExportRunStatusGenerating {
+		return nil
+	}
+	saved := *run
+	m.runs[run.ID-1] = &saved
+	schedule := m.schedules[run.ScheduleID-1]
+	if run.Status == types.OrderExportRunStatusDelivered && schedule.LastOrderID == run.FromOrderID {
+		schedule.LastOrderID = run.ThroughOrderID
+	}
+	return nil
+}
+
+func (m *memoryOrderExportScheduleRepository) LatestOrderID(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.orders) == 0 {
+		return 0, nil
+	}
+	return m.orders[len(m.orders)-1].ID, nil
+}
+
+func (m *memoryOrderExportScheduleRepository) ListOrders(ctx context.Context, filter *types.OrderExportFilter, afterID, throughID uint64, limit int) ([]types.OrderExtractRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rows []types.OrderExtractRow
+	for _, order := range m.orders {
+		if order.ID <= afterID || order.ID > throughID {
+			continue
+		}
+		if filter.MerchantID != nil && order.MerchantID != *filter.MerchantID {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !containsOrderStatus(filter.Statuses, order.Status) {
+			continue
+		}
+		rows = append(rows, order)
+		if len(rows) == limit {
+			break
+		}
+	}
+	return rows, nil
+}
+
+func containsOrderStatus(statuses []types.OrderStatus, status types.OrderStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// addOrders 追加订单，订单ID依次递增
+func (m *memoryOrderExportScheduleRepository) addOrders(merchantID uint64, status types.OrderStatus, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := 0; i < count; i++ {
+		id := uint64(len(m.orders) + 1)
+		m.orders = append(m.orders, types.OrderExtractRow{
+			ID:          id,
+			OrderNumber: fmt.Sprintf("ORD%04d", id),
+			MerchantID:  merchantID,
+			Status:      status,
+			TotalAmount: 100,
+		})
+	}
+}
+
+func (m *memoryOrderExportScheduleRepository) schedule(id uint64) types.OrderExportSchedule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.schedules[id-1]
+}
+
+func (m *memoryOrderExportScheduleRepository) lastRun() types.OrderExportRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.runs[len(m.runs)-1]
+}
+
+// recordingExportMailer 记录发送的导出邮件
+type recordingExportMailer struct {
+	mu     sync.Mutex
+	emails map[string][]string
+}
+
+func (r *recordingExportMailer) SendEmail(ctx context.Context, to, subject, body string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emails[to] = append(r.emails[to], body)
+	return nil
+}
+
+// flakyExportStorage 内存对象存储，fail 为 true 时上传失败
+type flakyExportStorage struct {
+	fail    bool
+	objects map[string][]byte
+}
+
+func (f *flakyExportStorage) UploadFromBytes(ctx context.Context, data []byte, fileName, contentType string, options *oss.UploadOptions) (*oss.UploadFileInfo, error) {
+	if f.fail {
+		return nil, errors.New("对象存储不可用")
+	}
+	objectKey := options.Directory + "/" + fileName
+	f.objects[objectKey] = data
+	return &oss.UploadFileInfo{FileName: fileName, ContentType: contentType, Size: int64(len(data)), ObjectKey: objectKey}, nil
+}
+
+func TestOrderExportSchedule(t *testing.T) {
+	Convey("定时订单导出测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		now := time.Date(2025, 9, 1, 8, 0, 0, 0, time.Local)
+
+		repo := &memoryOrderExportScheduleRepository{}
+		repo.addOrders(10, types.OrderStatusPaid, 3)
+		mailer := &recordingExportMailer{emails: make(map[string][]string)}
+		storage := &flakyExportStorage{objects: make(map[string][]byte)}
+		exportJobs := &fakeExportJobRepository{}
+		exports := export.NewQueueForTest(exportJobs, types.ExportLimits{}, t.TempDir())
+		scheduleService := NewOrderExportScheduleServiceForTest(repo, exports, mailer, storage, func() time.Time { return now })
+
+		// tick 模拟一次后台检查：执行到期的定时导出，生成导出文件后投递
+		tick := func() int {
+			started, err := scheduleService.RunDue(context.Background())
+			So(err, ShouldBeNil)
+			_, err = exports.DispatchPending(context.Background())
+			So(err, ShouldBeNil)
+			exports.Wait()
+			_, err = scheduleService.DeliverCompleted(context.Background())
+			So(err, ShouldBeNil)
+			return started
+		}
+
+		// exportedOrderNumbers 读取执行对应的导出文件中的订单号
+		exportedOrderNumbers := func(run types.OrderExportRun) []string {
+			job, err := exports.GetFile(ctx, run.ExportJobID)
+			So(err, ShouldBeNil)
+			data, err := os.ReadFile(job.FilePath)
+			So(err, ShouldBeNil)
+			records := readExportCSV(data)
+			So(records[0], ShouldResemble, orderExtractHeader)
+			var numbers []string
+			for _, record := range records[1:] {
+				numbers = append(numbers, record[1])
+			}
+			return numbers
+		}
+
+		Convey("创建时从当前最新订单之后开始导出，并计算下一次执行时间", func() {
+			schedule, err := scheduleService.CreateSchedule(ctx, 5, &types.CreateOrderExportScheduleRequest{
+				Name:        "每日订单",
+				Frequency:   types.OrderExportFrequencyDaily,
+				Hour:        9,
+				Destination: types.OrderExportDestinationEmail,
+				Recipient:   "finance@example.com",
+			})
+			So(err, ShouldBeNil)
+			So(schedule.Format, ShouldEqual, types.OrderExportFormatCSV)
+			So(schedule.LastOrderID, ShouldEqual, 3)
+			So(schedule.NextRunAt, ShouldEqual, time.Date(2025, 9, 1, 9, 0, 0, 0, time.Local))
+
+			weekly, err := scheduleService.CreateSchedule(ctx, 5, &types.CreateOrderExportScheduleRequest{
+				Name:        "每周订单",
+				Frequency:   types.OrderExportFrequencyWeekly,
+				Weekday:     int(time.Monday),
+				Hour:        8,
+				Destination: types.OrderExportDestinationStorage,
+				Recipient:   "/finance/orders/",
+			})
+			So(err, ShouldBeNil)
+			So(weekly.Recipient, ShouldEqual, "finance/orders")
+			// 2025-09-01 是星期一，当天 8:00 已到，下一次为下周一
+			So(weekly.NextRunAt, ShouldEqual, time.Date(2025, 9, 8, 8, 0, 0, 0, time.Local))
+		})
+
+		Convey("无效的配置被拒绝", func() {
+			_, err := scheduleService.CreateSchedule(ctx, 5, &types.CreateOrderExportScheduleRequest{
+				Name: "邮箱错误", Frequency: types.OrderExportFrequencyDaily,
+				Destination: types.OrderExportDestinationEmail, Recipient: "finance@example.com, not-an-email",
+			})
+			So(err, ShouldNotBeNil)
+
+			_, err = scheduleService.CreateSchedule(ctx, 5, &types.CreateOrderExportScheduleRequest{
+				Name: "目录错误", Frequency: types.OrderExportFrequencyDaily,
+				Destination: types.OrderExportDestinationStorage, Recipient: "finance/../other",
+			})
+			So(err, ShouldNotBeNil)
+
+			_, err = scheduleService.CreateSchedule(ctx, 5, &types.CreateOrderExportScheduleRequest{
+				Name: "频率错误", Frequency: "monthly",
+				Destination: types.OrderExportDestinationEmail, Recipient: "finance@example.com",
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("到期后生成导出文件并发送邮件，之后每次只导出新增订单", func() {
+			schedule, err := scheduleService.CreateSchedule(ctx, 5, &types.CreateOrderExportScheduleRequest{
+				Name:        "每日订单",
+				Frequency:   types.OrderExportFrequencyDaily,
+				Hour:        9,
+				Destination: types.OrderExportDestinationEmail,
+				Recipient:   "finance@example.com, audit@example.com",
+			})
+			So(err, ShouldBeNil)
+			repo.addOrders(10, types.OrderStatusPaid, 2)
+
+			// 未到执行时间
+			So(tick(), ShouldEqual, 0)
+			So(mailer.emails, ShouldBeEmpty)
+
+			now = time.Date(2025, 9, 1, 9, 0, 0, 0, time.Local)
+			So(tick(), ShouldEqual, 1)
+
+			run := repo.lastRun()
+			So(run.Status, ShouldEqual, types.OrderExportRunStatusDelivered)
+			So(run.FromOrderID, ShouldEqual, 3)
+			So(run.ThroughOrderID, ShouldEqual, 5)
+			So(run.RowCount, ShouldEqual, 2)
+			So(exportedOrderNumbers(run), ShouldResemble, []string{"ORD0004", "ORD0005"})
+			So(mailer.emails["finance@example.com"], ShouldHaveLength, 1)
+			So(mailer.emails["audit@example.com"], ShouldHaveLength, 1)
+			So(mailer.emails["finance@example.com"][0], ShouldContainSubstring, "/api/v1/exports/"+run.ExportJobID+"/download")
+
+			saved := repo.schedule(schedule.ID)
+			So(saved.LastOrderID, ShouldEqual, 5)
+			So(saved.NextRunAt, ShouldEqual, time.Date(2025, 9, 2, 9, 0, 0, 0, time.Local))
+			So(*saved.LastRunAt, ShouldEqual, now)
+
+			// 同一计划时间不会重复执行
+			So(tick(), ShouldEqual, 0)
+
+			// 第二次执行只导出上次投递之后的订单
+			repo.addOrders(10, types.OrderStatusPaid, 1)
+			now = time.Date(2025, 9, 2, 9, 0, 30, 0, time.Local)
+			So(tick(), ShouldEqual, 1)
+
+			run = repo.lastRun()
+			So(run.FromOrderID, ShouldEqual, 5)
+			So(run.ThroughOrderID, ShouldEqual, 6)
+			So(exportedOrderNumbers(run), ShouldResemble, []string{"ORD0006"})
+			So(repo.schedule(schedule.ID).LastOrderID, ShouldEqual, 6)
+
+			// 没有新增订单时仍投递空文件
+			now = time.Date(2025, 9, 3, 9, 0, 0, 0, time.Local)
+			So(tick(), ShouldEqual, 1)
+			run = repo.lastRun()
+			So(run.Status, ShouldEqual, types.OrderExportRunStatusDelivered)
+			So(run.RowCount, ShouldEqual, 0)
+			So(exportedOrderNumbers(run), ShouldBeEmpty)
+		})
+
+		Convey("投递失败时不推进导出位置，下次执行重新导出同一范围", func() {
+			schedule, err := scheduleService.CreateSchedule(ctx, 5, &types.CreateOrderExportScheduleRequest{
+				Name:        "对象存储",
+				Frequency:   types.OrderExportFrequencyDaily,
+				Hour:        9,
+				Destination: types.OrderExportDestinationStorage,
+				Recipient:   "finance/orders",
+			})
+			So(err, ShouldBeNil)
+			repo.addOrders(10, types.OrderStatusPaid, 2)
+
+			storage.fail = true
+			now = time.Date(2025, 9, 1, 9, 0, 0, 0, time.Local)
+			So(tick(), ShouldEqual, 1)
+
+			failed := repo.lastRun()
+			So(failed.Status, ShouldEqual, types.OrderExportRunStatusFailed)
+			So(failed.ErrorMessage, ShouldContainSubstring, "对象存储不可用")
+			So(failed.CompletedAt, ShouldNotBeNil)
+			So(repo.schedule(schedule.ID).LastOrderID, ShouldEqual, 3)
+
+			storage.fail = false
+			repo.addOrders(10, types.OrderStatusPaid, 1)
+			now = time.Date(2025, 9, 2, 9, 0, 0, 0, time.Local)
+			So(tick(), ShouldEqual, 1)
+
+			run := repo.lastRun()
+			So(run.Status, ShouldEqual, types.OrderExportRunStatusDelivered)
+			So(run.FromOrderID, ShouldEqual, 3)
+			So(run.ThroughOrderID, ShouldEqual, 6)
+			So(exportedOrderNumbers(run), ShouldResemble, []string{"ORD0004", "ORD0005", "ORD0006"})
+			So(repo.schedule(schedule.ID).LastOrderID, ShouldEqual, 6)
+
+			job, err := exports.GetFile(ctx, run.ExportJobID)
+			So(err, ShouldBeNil)
+			So(storage.objects, ShouldContainKey, "exports/1/finance/orders/"+job.FileName)
+		})
+
+		Convey("上一次执行仍在生成时本次顺延", func() {
+			schedule, err := scheduleService.CreateSchedule(ctx, 5, &types.CreateOrderExportScheduleRequest{
+				Name:        "每日订单",
+				Frequency:   types.OrderExportFrequencyDaily,
+				Hour:        9,
+				Destination: types.OrderExportDestinationEmail,
+				Recipient:   "finance@example.com",
+			})
+			So(err, ShouldBeNil)
+			repo.addOrders(10, types.OrderStatusPaid, 1)
+
+			now = time.Date(2025, 9, 1, 9, 0, 0, 0, time.Local)
+			started, err := scheduleService.RunDue(context.Background())
+			So(err, ShouldBeNil)
+			So(started, ShouldEqual, 1)
+
+			repo.addOrders(10, types.OrderStatusPaid, 1)
+			now = time.Date(2025, 9, 2, 9, 0, 0, 0, time.Local)
+			started, err = scheduleService.RunDue(context.Background())
+			So(err, ShouldBeNil)
+			So(started, ShouldEqual, 0)
+
+			// 上一次投递完成后，顺延的执行导出之后的订单
+			So(tick(), ShouldEqual, 0)
+			So(repo.schedule(schedule.ID).LastOrderID, ShouldEqual, 4)
+			So(tick(), ShouldEqual, 1)
+			run := repo.lastRun()
+			So(run.FromOrderID, ShouldEqual, 4)
+			So(run.ThroughOrderID, ShouldEqual, 5)
+			So(exportedOrderNumbers(run), ShouldResemble, []string{"ORD0005"})
+			So(repo.schedule(schedule.ID).LastOrderID, ShouldEqual, 5)
+		})
+
+		Convey("按商户和订单状态筛选导出的订单", func() {
+			merchantID := uint64(11)
+			schedule, err := scheduleService.CreateSchedule(ctx, 5, &types.CreateOrderExportScheduleRequest{
+				Name:        "商户已完成订单",
+				Filter:      types.OrderExportFilter{MerchantID: &merchantID, Statuses: []types.OrderStatus{types.OrderStatusCompleted}},
+				Frequency:   types.OrderExportFrequencyDaily,
+				Hour:        9,
+				Destination: types.OrderExportDestinationEmail,
+				Recipient:   "finance@example.com",
+			})
+			So(err, ShouldBeNil)
+			repo.addOrders(10, types.OrderStatusCompleted, 1)
+			repo.addOrders(11, types.OrderStatusPaid, 1)
+			repo.addOrders(11, types.OrderStatusCompleted, 1)
+
+			now = time.Date(2025, 9, 1, 9, 0, 0, 0, time.Local)
+			So(tick(), ShouldEqual, 1)
+
+			run := repo.lastRun()
+			So(exportedOrderNumbers(run), ShouldResemble, []string{"ORD0006"})
+			// 不符合条件的订单同样不会在下次导出
+			So(repo.schedule(schedule.ID).LastOrderID, ShouldEqual, 6)
+		})
+
+		Convey("停用的定时导出不执行，其他租户看不到", func() {
+			schedule, err := scheduleService.CreateSchedule(ctx, 5, &types.CreateOrderExportScheduleRequest{
+				Name:        "每日订单",
+				Frequency:   types.OrderExportFrequencyDaily,
+				Hour:        9,
+				Destination: types.OrderExportDestinationEmail,
+				Recipient:   "finance@example.com",
+			})
+			So(err, ShouldBeNil)
+
+			_, err = scheduleService.SetScheduleEnabled(ctx, schedule.ID, false)
+			So(err, ShouldBeNil)
+			now = time.Date(2025, 9, 1, 9, 0, 0, 0, time.Local)
+			So(tick(), ShouldEqual, 0)
+
+			otherTenant := context.WithValue(context.Background(), "tenant_id", uint64(2))
+			_, err = scheduleService.ListRuns(otherTenant, schedule.ID, 0)
+			So(errors.Is(err, types.ErrOrderExportScheduleNotFound), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// OrderFulfillmentNotifier 履约单状态变更通知
+type OrderFulfillmentNotifier interface {
+	SendFulfillmentStatusNotification(ctx context.Context, order *types.Order, change *types.FulfillmentStatusChange) error
+}
+
+// IOrderFulfillmentService 订单履约单服务接口
+type IOrderFulfillmentService interface {
+	// 把订单拆分为若干包裹，金额和权益消耗按包裹内商品占比分摊
+	SplitOrder(ctx context.Context, orderID uint64, req *types.CreateOrderFulfillmentsRequest) ([]types.OrderFulfillment, error)
+	// 获取订单的履约单：员工可查看，客户只能查看自己的订单
+	ListFulfillments(ctx context.Context, orderID uint64, isStaff bool) ([]types.OrderFulfillment, error)
+	// 更新单个包裹的状态并通知客户
+	UpdateFulfillmentStatus(ctx context.Context, orderID, fulfillmentID uint64, req *types.UpdateFulfillmentStatusRequest) (*types.OrderFulfillment, error)
+}
+
+// OrderFulfillmentService 订单履约单服务实现
+type OrderFulfillmentService struct {
+	orderRepo       repository.IOrderRepository
+	fulfillmentRepo repository.IOrderFulfillmentRepository
+	notifier        OrderFulfillmentNotifier
+}
+
+// NewOrderFulfillmentService 创建订单履约单服务实例
+func NewOrderFulfillmentService(notifier OrderFulfillmentNotifier) IOrderFulfillmentService {
+	return &OrderFulfillmentService{
+		orderRepo:       repository.NewOrderRepository(),
+		fulfillmentRepo: repository.NewOrderFulfillmentRepository(),
+		notifier:        notifier,
+	}
+}
+
+// NewOrderFulfillmentServiceForTest 创建测试用订单履约单服务实例
+func NewOrderFulfillmentServiceForTest(orderRepo repository.IOrderRepository, fulfillmentRepo repository.IOrderFulfillmentRepository, notifier OrderFulfillmentNotifier) IOrderFulfillmentService {
+	return &OrderFulfillmentService{
+		orderRepo:       orderRepo,
+		fulfillmentRepo: fulfillmentRepo,
+		notifier:        notifier,
+	}
+}
+
+// SplitOrder 拆分订单履约单（仅员工可调用，路由层校验权限）
+func (s *OrderFulfillmentService) SplitOrder(ctx context.Context, orderID uint64, req *types.CreateOrderFulfillmentsRequest) ([]types.OrderFulfillment, error) {
+	order, err := s.getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !types.CanSplitFulfillments(order.Status) {
+		return nil, fmt.Errorf("订单状态为 %s，不能拆分履约单", order.Status)
+	}
+	if err := req.Validate(order); err != nil {
+		return nil, err
+	}
+
+	fulfillments := make([]types.OrderFulfillment, len(req.Packages))
+	for i, pkg := range req.Packages {
+		fulfillments[i].Items = pkg.Items
+	}
+	types.ProrateOrderFulfillments(order, fulfillments, types.DefaultProrationDecimals)
+	if err := s.fulfillmentRepo.CreateBatch(ctx, order.ID, fulfillments); err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "order_fulfillment", "split", map[string]interface{}{
+		"order_id": order.ID,
+		"packages": len(fulfillments),
+	})
+	return fulfillments, nil
+}
+
+// ListFulfillments 获取订单的全部履约单
+func (s *OrderFulfillmentService) ListFulfillments(ctx context.Context, orderID uint64, isStaff bool) ([]types.OrderFulfillment, error) {
+	order, err := s.getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !isStaff && order.CustomerID != gconv.Uint64(ctx.Value("user_id")) {
+		return nil, fmt.Errorf("无权访问该订单履约单")
+	}
+	return s.fulfillmentRepo.ListByOrderID(ctx, order.ID)
+}
+
+// UpdateFulfillmentStatus 更新包裹状态（仅员工可调用，路由层校验权限），通知失败不影响状态变更
+func (s *OrderFulfillmentService) UpdateFulfillmentStatus(ctx context.Context, orderID, fulfillmentID uint64, req *types.UpdateFulfillmentStatusRequest) (*types.OrderFulfillment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	order, err := s.getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := s.fulfillmentRepo.UpdateStatus(ctx, order.ID, fulfillmentID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "order_fulfillment", "update_status", map[string]interface{}{
+		"order_id":       order.ID,
+		"fulfillment_id": fulfillmentID,
+		"from_status":    change.PreviousStatus,
+		"to_status":      change.Fulfillment.Status,
+	})
+	if s.notifier != nil {
+		if err := s.notifier.SendFulfillmentStatusNotification(ctx, order, change); err != nil {
+			g.Log().Error(ctx, "发送履约单状态变更通知失败", "fulfillment_id", fulfillmentID, "error", err)
+		}
+	}
+
+	return change.Fulfillment, nil
+}
+
+// getOrder 获取订单，商户用户只能访问本商户的订单
+func (s *OrderFulfillmentService) getOrder(ctx context.Context, orderID uint64) (*types.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if merchantID := gconv.Uint64(ctx.Value("merchant_id")); merchantID > 0 && order.MerchantID != merchantID {
+		return nil, fmt.Errorf("无权访问该订单")
+	}
+	return order, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryOrderFulfillmentRepository 内存订单履约单仓储
+type memoryOrderFulfillmentRepository struct {
+	fulfillments []types.OrderFulfillment
+}
+
+func (m *memoryOrderFulfillmentRepository) CreateBatch(ctx context.Context, orderID uint64, fulfillments []types.OrderFulfillment) error {
+	for _, existing := range m.fulfillments {
+		if existing.OrderID == orderID {
+			return types.ErrOrderFulfillmentsExist
+		}
+	}
+	for i := range fulfillments {
+		fulfillments[i].ID = uint64(len(m.fulfillments) + 1)
+		fulfillments[i].OrderID = orderID
+		fulfillments[i].Status = types.FulfillmentStatusPending
+		m.fulfillments = append(m.fulfillments, fulfillments[i])
+	}
+	return nil
+}
+
+func (m *memoryOrderFulfillmentRepository) ListByOrderID(ctx context.Context, orderID uint64) ([]types.OrderFulfillment, error) {
+	var fulfillments []types.OrderFulfillment
+	for _, fulfillment := range m.fulfillments {
+		if fulfillment.OrderID == orderID {
+			fulfillments = append(fulfillments, fulfillment)
+		}
+	}
+	return fulfillments, nil
+}
+
+func (m *memoryOrderFulfillmentRepository) UpdateStatus(ctx context.Context, orderID, fulfillmentID uint64, req *types.UpdateFulfillmentStatusRequest) (*types.FulfillmentStatusChange, error) {
+	for i := range m.fulfillments {
+		fulfillment := &m.fulfillments[i]
+		if fulfillment.ID != fulfillmentID || fulfillment.OrderID != orderID {
+			continue
+		}
+		if !fulfillment.Status.CanTransitionTo(req.Status) {
+			return nil, types.ErrFulfillmentStatusTransition
+		}
+		previous := fulfillment.Status
+		fulfillment.Status = req.Status
+		if req.Status == types.FulfillmentStatusShipped {
+			fulfillment.TrackingNumber = req.TrackingNumber
+		}
+
+		fulfillments, _ := m.ListByOrderID(ctx, orderID)
+		updated := *fulfillment
+		return &types.FulfillmentStatusChange{Fulfillment: &updated, PreviousStatus: previous, Fulfillments: fulfillments}, nil
+	}
+	return nil, types.ErrOrderFulfillmentNotFound
+}
+
+func TestOrderFulfillmentService(t *testing.T) {
+	Convey("订单履约单服务测试", t, func() {
+		ctx := context.WithValue(context.Background(), "user_id", uint64(7))
+		order := &types.Order{
+			ID:          1,
+			MerchantID:  10,
+			CustomerID:  100,
+			OrderNumber: "ORD202610150001",
+			Status:      types.OrderStatusPaid,
+			Items: []types.OrderItem{
+				{ProductID: 1, Quantity: 2, Price: 100},
+				{ProductID: 2, Quantity: 1, Price: 100},
+			},
+			TotalAmount: 300,
+		}
+		sms := &recordingSMSService{}
+		fulfillmentRepo := &memoryOrderFulfillmentRepository{}
+		fulfillmentService := NewOrderFulfillmentServiceForTest(
+			&fakeDisputeOrderRepository{orders: map[uint64]*types.Order{1: order}},
+			fulfillmentRepo,
+			NewNotificationServiceForTest(sms, &recordingEmailService{}),
+		)
+
+		splitRequest := &types.CreateOrderFulfillmentsRequest{Packages: []types.CreateFulfillmentPackage{
+			{Items: []types.FulfillmentItem{{ProductID: 1, ProductName: "咖啡豆", Quantity: 2}}},
+			{Items: []types.FulfillmentItem{{ProductID: 2, ProductName: "手冲壶", Quantity: 1}}},
+		}}
+
+		Convey("包裹商品数量与订单不一致时拒绝拆单", func() {
+			_, err := fulfillmentService.SplitOrder(ctx, 1, &types.CreateOrderFulfillmentsRequest{Packages: []types.CreateFulfillmentPackage{
+				{Items: []types.FulfillmentItem{{ProductID: 1, Quantity: 1}}},
+			}})
+			So(err, ShouldNotBeNil)
+			So(fulfillmentRepo.fulfillments, ShouldBeEmpty)
+		})
+
+		Convey("拆单后按商品金额分摊，同一订单不能重复拆单", func() {
+			fulfillments, err := fulfillmentService.SplitOrder(ctx, 1, splitRequest)
+			So(err, ShouldBeNil)
+			So(fulfillments, ShouldHaveLength, 2)
+			So(fulfillments[0].Amount, ShouldEqual, 200)
+			So(fulfillments[1].Amount, ShouldEqual, 100)
+
+			_, err = fulfillmentService.SplitOrder(ctx, 1, splitRequest)
+			So(err, ShouldEqual, types.ErrOrderFulfillmentsExist)
+		})
+
+		Convey("包裹逐级流转时整单完成通知只发送一次", func() {
+			fulfillments, err := fulfillmentService.SplitOrder(ctx, 1, splitRequest)
+			So(err, ShouldBeNil)
+
+			update := func(index int, status types.FulfillmentStatus) {
+				_, err := fulfillmentService.UpdateFulfillmentStatus(ctx, 1, fulfillments[index].ID, &types.UpdateFulfillmentStatusRequest{
+					Status:         status,
+					TrackingNumber: "SF1001",
+				})
+				So(err, ShouldBeNil)
+			}
+
+			update(0, types.FulfillmentStatusShipped)
+			update(1, types.FulfillmentStatusShipped)
+			update(0, types.FulfillmentStatusDelivered)
+			update(1, types.FulfillmentStatusDelivered)
+			update(0, types.FulfillmentStatusCompleted)
+			update(1, types.FulfillmentStatusCompleted)
+
+			So(sms.templateCodes, ShouldResemble, []string{
+				"FULFILLMENT_SHIPPED", "FULFILLMENT_SHIPPED",
+				"FULFILLMENT_DELIVERED", "FULFILLMENT_DELIVERED", "ORDER_COMPLETED",
+			})
+		})
+
+		Convey("不能跳过状态或回退", func() {
+			fulfillments, err := fulfillmentService.SplitOrder(ctx, 1, splitRequest)
+			So(err, ShouldBeNil)
+
+			_, err = fulfillmentService.UpdateFulfillmentStatus(ctx, 1, fulfillments[0].ID, &types.UpdateFulfillmentStatusRequest{
+				Status: types.FulfillmentStatusDelivered,
+			})
+			So(err, ShouldEqual, types.ErrFulfillmentStatusTransition)
+			So(sms.templateCodes, ShouldBeEmpty)
+		})
+
+		Convey("发货时必须填写运单号", func() {
+			_, err := fulfillmentService.UpdateFulfillmentStatus(ctx, 1, 1, &types.UpdateFulfillmentStatusRequest{
+				Status: types.FulfillmentStatusShipped,
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("客户只能查看自己订单的包裹", func() {
+			_, err := fulfillmentService.ListFulfillments(ctx, 1, false)
+			So(err, ShouldNotBeNil)
+
+			customerCtx := context.WithValue(context.Background(), "user_id", uint64(100))
+			_, err = fulfillmentService.ListFulfillments(customerCtx, 1, false)
+			So(err, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,394 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/pdf"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+const defaultInvoiceStorageDir = "/tmp/invoices"
+
+// InvoiceConfig 发票PDF生成配置
+type InvoiceConfig struct {
+	StorageDir string   // 发票PDF保存目录，按租户分子目录
+	Converters []string // PDF转换器顺序
+	Retries    int      // 每个转换器失败后的重试次数
+}
+
+// LoadInvoiceConfig 从 order.invoice 配置加载发票PDF生成配置
+func LoadInvoiceConfig(ctx context.Context) *InvoiceConfig {
+	config := &InvoiceConfig{
+		StorageDir: g.Cfg().MustGet(ctx, "order.invoice.storageDir", defaultInvoiceStorageDir).String(),
+		Converters: g.Cfg().MustGet(ctx, "order.invoice.converters", pdf.DefaultConverterNames).Strings(),
+		Retries:    g.Cfg().MustGet(ctx, "order.invoice.retries", 0).Int(),
+	}
+	if config.StorageDir == "" {
+		config.StorageDir = defaultInvoiceStorageDir
+	}
+	if config.Retries < 0 {
+		config.Retries = 0
+	}
+	return config
+}
+
+// IOrderInvoiceService 订单发票服务接口
+type IOrderInvoiceService interface {
+	GetInvoice(ctx context.Context, orderID uint64, isStaff bool) (*types.OrderInvoice, error)
+}
+
+// OrderInvoiceService 订单发票服务实现：首次获取时分配发票号并保存发票，之后沿用原发票号，
+// PDF文件丢失时按保存的发票号重新生成
+type OrderInvoiceService struct {
+	orderRepo    repository.IOrderRepository
+	invoiceRepo  repository.IOrderInvoiceRepository
+	merchantRepo repository.MerchantRepository
+	policy       repository.InvoicePolicyProvider // 为空时使用默认发票策略
+	moneyFormat  repository.MoneyFormatProvider   // 为空时金额使用默认格式
+	config       *InvoiceConfig
+	converters   map[string]pdf.Converter
+	now          func() time.Time
+}
+
+// NewOrderInvoiceService 创建订单发票服务实例
+func NewOrderInvoiceService() IOrderInvoiceService {
+	tenantRepo := repository.NewTenantRepository()
+	return &OrderInvoiceService{
+		orderRepo:    repository.NewOrderRepository(),
+		invoiceRepo:  repository.NewOrderInvoiceRepository(),
+		merchantRepo: repository.NewMerchantRepository(),
+		policy:       repository.NewTenantInvoicePolicyProvider(tenantRepo),
+		moneyFormat:  repository.NewTenantMoneyFormatProvider(tenantRepo),
+		config:       LoadInvoiceConfig(context.Background()),
+		converters:   pdf.Converters(),
+		now:          time.Now,
+	}
+}
+
+// NewOrderInvoiceServiceForTest 创建测试用订单发票服务实例，使用指定的PDF转换器和保存目录
+func NewOrderInvoiceServiceForTest(orderRepo repository.IOrderRepository, invoiceRepo repository.IOrderInvoiceRepository, merchantRepo repository.MerchantRepository, tenantRepo repository.ITenantRepository, converter pdf.Converter, storageDir string, now func() time.Time) IOrderInvoiceService {
+	return &OrderInvoiceService{
+		orderRepo:    orderRepo,
+		invoiceRepo:  invoiceRepo,
+		merchantRepo: merchantRepo,
+		policy:       repository.NewTenantInvoicePolicyProvider(tenantRepo),
+		moneyFormat:  repository.NewTenantMoneyFormatProvider(tenantRepo),
+		config:       &InvoiceConfig{StorageDir: storageDir, Converters: []string{"test"}},
+		converters:   map[string]pdf.Converter{"test": converter},
+		now:          now,
+	}
+}
+
+// GetInvoice 获取订单发票，订单尚未开票时开具发票，PDF文件不存在时生成。
+// 只有下单客户和订单所属商户的员工可以获取；已开具的发票在订单退款或取消后仍可下载
+func (s *OrderInvoiceService) GetInvoice(ctx context.Context, orderID uint64, isStaff bool) (*types.OrderInvoice, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInvoiceAccess(ctx, order, isStaff); err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.invoiceRepo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil {
+		if invoice, err = s.issue(ctx, order); err != nil {
+			return nil, err
+		}
+	}
+
+	if invoice.FilePath != "" {
+		if _, statErr := os.Stat(invoice.FilePath); statErr == nil {
+			return invoice, nil
+		}
+	}
+
+	filePath, err := s.generatePDF(ctx, order, invoice)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.invoiceRepo.UpdateFilePath(ctx, invoice.ID, filePath); err != nil {
+		// 下次下载时重新生成，不影响本次下载
+		g.Log().Warning(ctx, "保存发票文件路径失败", "invoice_number", invoice.InvoiceNumber, "error", err)
+	}
+	invoice.FilePath = filePath
+	return invoice, nil
+}
+
+// checkInvoiceAccess 下单客户可以获取自己订单的发票，员工只能获取本商户订单的发票
+func checkInvoiceAccess(ctx context.Context, order *types.Order, isStaff bool) error {
+	if order.CustomerID == gconv.Uint64(ctx.Value("user_id")) {
+		return nil
+	}
+	merchantID := gconv.Uint64(ctx.Value("merchant_id"))
+	if isStaff && merchantID > 0 && merchantID == order.MerchantID {
+		return nil
+	}
+	return types.ErrInvoiceAccessDenied
+}
+
+// issue 分配发票号并保存发票。同一订单并发开票时只有一张发票保存成功，其余请求使用已保存的发票，
+// 未保存成功的请求分配的序号不再使用
+func (s *OrderInvoiceService) issue(ctx context.Context, order *types.Order) (*types.OrderInvoice, error) {
+	if !types.CanInvoiceOrder(order.Status) {
+		return nil, types.ErrOrderNotInvoiceable
+	}
+
+	policy, err := s.invoicePolicy(ctx, order.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.Enabled() {
+		return nil, types.ErrInvoiceDisabled
+	}
+
+	sequence, err := s.invoiceRepo.NextSequence(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	issuedAt := s.now()
+	invoice := &types.OrderInvoice{
+		OrderID:        order.ID,
+		MerchantID:     order.MerchantID,
+		CustomerID:     order.CustomerID,
+		InvoiceNumber:  policy.InvoiceNumber(issuedAt, sequence),
+		TotalAmount:    order.TotalAmount,
+		SubtotalAmount: order.SubtotalAmount,
+		TaxAmount:      order.TaxAmount,
+		IssuedAt:       issuedAt,
+	}
+	if err := s.invoiceRepo.Create(ctx, invoice); err != nil {
+		existing, getErr := s.invoiceRepo.GetByOrderID(ctx, order.ID)
+		if getErr == nil && existing != nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "order_invoice", "issue", map[string]interface{}{
+		"order_id":       order.ID,
+		"invoice_id":     invoice.ID,
+		"invoice_number": invoice.InvoiceNumber,
+	})
+	return invoice, nil
+}
+
+// invoicePolicy 获取租户的发票策略，未配置时返回 nil，表示使用默认策略
+func (s *OrderInvoiceService) invoicePolicy(ctx context.Context, tenantID uint64) (*types.InvoicePolicy, error) {
+	if s.policy == nil {
+		return nil, nil
+	}
+	policy, err := s.policy(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取发票策略失败: %v", err)
+	}
+	return policy, nil
+}
+
+// generatePDF 生成发票PDF，返回文件路径
+func (s *OrderInvoiceService) generatePDF(ctx context.Context, order *types.Order, invoice *types.OrderInvoice) (string, error) {
+	policy, err := s.invoicePolicy(ctx, order.TenantID)
+	if err != nil {
+		return "", err
+	}
+	document, err := s.buildDocument(ctx, order, invoice, policy)
+	if err != nil {
+		return "", err
+	}
+	content, err := renderInvoiceHTML(document, s.moneyFormat.Resolve(ctx, order.TenantID))
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(s.config.StorageDir, fmt.Sprint(order.TenantID))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建发票目录失败: %v", err)
+	}
+	htmlPath := filepath.Join(dir, "temp_"+invoice.InvoiceNumber+".html")
+	pdfPath := filepath.Join(dir, invoice.InvoiceNumber+".pdf")
+	if err := os.WriteFile(htmlPath, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("保存发票HTML文件失败: %v", err)
+	}
+	defer os.Remove(htmlPath)
+
+	if _, err := pdf.Convert(ctx, s.converters, s.config.Converters, s.config.Retries, htmlPath, pdfPath); err != nil {
+		return "", fmt.Errorf("生成发票PDF失败: %w", err)
+	}
+	return pdfPath, nil
+}
+
+// buildDocument 组装发票内容：开票方为商户（可由发票策略指定名称），购买方为下单客户
+func (s *OrderInvoiceService) buildDocument(ctx context.Context, order *types.Order, invoice *types.OrderInvoice, policy *types.InvoicePolicy) (*types.InvoiceDocument, error) {
+	document := &types.InvoiceDocument{
+		InvoiceNumber:  invoice.InvoiceNumber,
+		IssuedAt:       invoice.IssuedAt.Format("2006-01-02"),
+		OrderNumber:    order.OrderNumber,
+		OrderDate:      order.CreatedAt.Format("2006-01-02"),
+		Buyer:          types.InvoiceParty{Name: fmt.Sprintf("客户%d", order.CustomerID)},
+		TaxInclusive:   order.Tax != nil && order.Tax.Inclusive,
+		SubtotalAmount: invoice.SubtotalAmount,
+		TaxAmount:      invoice.TaxAmount,
+		TotalAmount:    invoice.TotalAmount,
+	}
+
+	if s.merchantRepo != nil {
+		merchant, err := s.merchantRepo.GetByID(ctx, order.MerchantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取商户信息失败: %v", err)
+		}
+		if merchant != nil {
+			document.Issuer.Name = merchant.Name
+			if info := merchant.BusinessInfo; info != nil {
+				document.Issuer.TaxID = info.License
+				document.Issuer.Address = info.Address
+				document.Issuer.Phone = info.ContactPhone
+			}
+		}
+	}
+	if policy != nil {
+		if policy.IssuerName != "" {
+			document.Issuer.Name = policy.IssuerName
+		}
+		document.Footer = policy.Footer
+	}
+
+	if address := order.ShippingAddress; address != nil {
+		document.Buyer.Name = address.RecipientName
+		document.Buyer.Address = address.FullAddress()
+		document.Buyer.Phone = address.Phone
+	}
+
+	// 税费明细与订单项按顺序一一对应，订单项修改后无法对应时不显示逐项税额
+	var taxLines []types.OrderTaxLine
+	if order.Tax != nil && len(order.Tax.Lines) == len(order.Items) {
+		taxLines = order.Tax.Lines
+	}
+	for i, item := range order.Items {
+		line := types.InvoiceLine{
+			Description: fmt.Sprintf("商品%d", item.ProductID),
+			Quantity:    item.Quantity,
+			UnitPrice:   item.Price,
+			Amount:      item.Price * float64(item.Quantity),
+			TaxRate:     "-",
+		}
+		if item.IsBundle() {
+			line.Description = fmt.Sprintf("套餐%d %s", item.BundleID, item.BundleName)
+		}
+		if taxLines != nil && taxLines[i].ProductID == item.ProductID {
+			line.TaxAmount = taxLines[i].TaxAmount
+			line.TaxRate = fmt.Sprintf("%g%%", taxLines[i].Rate*100)
+			if taxLines[i].Exempt {
+				line.TaxRate = "免税"
+			}
+		}
+		document.Lines = append(document.Lines, line)
+	}
+
+	return document, nil
+}
+
+// invoiceTemplate 发票HTML模板，由PDF转换器转换为A4页面
+var invoiceTemplate = template.Must(template.New("invoice").Funcs(types.MoneyTemplateFuncs(nil)).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+    <meta charset="UTF-8">
+    <title>发票 {{.InvoiceNumber}}</title>
+    <style>
+        body { font-family: 'SimHei', sans-serif; margin: 20px; line-height: 1.6; }
+        .header { text-align: center; border-bottom: 2px solid #333; padding-bottom: 10px; margin-bottom: 20px; }
+        .header h1 { margin: 0; }
+        .parties { width: 100%; margin-bottom: 20px; }
+        .parties td { vertical-align: top; width: 50%; }
+        .lines { width: 100%; border-collapse: collapse; margin-bottom: 20px; }
+        .lines th, .lines td { border: 1px solid #ddd; padding: 8px; text-align: right; }
+        .lines th { background-color: #f5f5f5; }
+        .lines td.description { text-align: left; }
+        .totals { width: 40%; margin-left: 60%; border-collapse: collapse; }
+        .totals td { padding: 4px 8px; text-align: right; }
+        .total { font-weight: bold; font-size: 16px; }
+        .footer { margin-top: 40px; border-top: 1px solid #ddd; padding-top: 10px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>发票</h1>
+        <div>发票号码：{{.InvoiceNumber}}　开票日期：{{.IssuedAt}}</div>
+        <div>订单编号：{{.OrderNumber}}　下单日期：{{.OrderDate}}</div>
+    </div>
+
+    <table class="parties">
+        <tr>
+            <td>
+                <strong>开票方</strong><br>
+                {{.Issuer.Name}}<br>
+                {{if .Issuer.TaxID}}纳税人识别号：{{.Issuer.TaxID}}<br>{{end}}
+                {{if .Issuer.Address}}地址：{{.Issuer.Address}}<br>{{end}}
+                {{if .Issuer.Phone}}电话：{{.Issuer.Phone}}{{end}}
+            </td>
+            <td>
+                <strong>购买方</strong><br>
+                {{.Buyer.Name}}<br>
+                {{if .Buyer.Address}}地址：{{.Buyer.Address}}<br>{{end}}
+                {{if .Buyer.Phone}}电话：{{.Buyer.Phone}}{{end}}
+            </td>
+        </tr>
+    </table>
+
+    <table class="lines">
+        <tr>
+            <th>商品</th>
+            <th>数量</th>
+            <th>单价</th>
+            <th>金额{{if .TaxInclusive}}（含税）{{end}}</th>
+            <th>税率</th>
+            <th>税额</th>
+        </tr>
+        {{range .Lines}}
+        <tr>
+            <td class="description">{{.Description}}</td>
+            <td>{{.Quantity}}</td>
+            <td>{{formatMoney .UnitPrice}}</td>
+            <td>{{formatMoney .Amount}}</td>
+            <td>{{.TaxRate}}</td>
+            <td>{{formatMoney .TaxAmount}}</td>
+        </tr>
+        {{end}}
+    </table>
+
+    <table class="totals">
+        <tr><td>不含税金额</td><td>{{formatMoney .SubtotalAmount}}</td></tr>
+        <tr><td>税额</td><td>{{formatMoney .TaxAmount}}</td></tr>
+        <tr class="total"><td>价税合计</td><td>{{formatMoney .TotalAmount}}</td></tr>
+    </table>
+
+    {{if .Footer}}<div class="footer">{{.Footer}}</div>{{end}}
+</body>
+</html>`))
+
+// renderInvoiceHTML 渲染发票HTML，金额按租户的货币和区域格式显示
+func renderInvoiceHTML(document *types.InvoiceDocument, moneyFormat *types.MoneyFormatPolicy) (string, error) {
+	tmpl, err := invoiceTemplate.Clone()
+	if err != nil {
+		return "", fmt.Errorf("创建发票模板失败: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Funcs(types.MoneyTemplateFuncs(moneyFormat)).Execute(&buf, document); err != nil {
+		return "", fmt.Errorf("渲染发票模板失败: %v", err)
+	}
+	return buf.String(), nil
+}
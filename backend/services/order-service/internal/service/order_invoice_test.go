@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryOrderInvoiceRepository 内存订单发票仓储，按租户分配序号并校验发票号唯一
+type memoryOrderInvoiceRepository struct {
+	sequences map[uint64]uint64
+	invoices  []*types.OrderInvoice
+}
+
+func newMemoryOrderInvoiceRepository() *memoryOrderInvoiceRepository {
+	return &memoryOrderInvoiceRepository{sequences: make(map[uint64]uint64)}
+}
+
+func (m *memoryOrderInvoiceRepository) NextSequence(ctx context.Context) (uint64, error) {
+	tenantID := gconv.Uint64(ctx.Value("tenant_id"))
+	m.sequences[tenantID]++
+	return m.sequences[tenantID], nil
+}
+
+func (m *memoryOrderInvoiceRepository) Create(ctx context.Context, invoice *types.OrderInvoice) error {
+	invoice.TenantID = gconv.Uint64(ctx.Value("tenant_id"))
+	for _, existing := range m.invoices {
+		if existing.TenantID != invoice.TenantID {
+			continue
+		}
+		if existing.OrderID == invoice.OrderID || existing.InvoiceNumber == invoice.InvoiceNumber {
+			return fmt.Errorf("保存发票失败: Duplicate entry")
+		}
+	}
+	invoice.ID = uint64(len(m.invoices) + 1)
+	stored := *invoice
+	m.invoices = append(m.invoices, &stored)
+	return nil
+}
+
+func (m *memoryOrderInvoiceRepository) GetByOrderID(ctx context.Context, orderID uint64) (*types.OrderInvoice, error) {
+	tenantID := gconv.Uint64(ctx.Value("tenant_id"))
+	for _, invoice := range m.invoices {
+		if invoice.TenantID == tenantID && invoice.OrderID == orderID {
+			found := *invoice
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryOrderInvoiceRepository) UpdateFilePath(ctx context.Context, invoiceID uint64, filePath string) error {
+	for _, invoice := range m.invoices {
+		if invoice.ID == invoiceID {
+			invoice.FilePath = filePath
+		}
+	}
+	return nil
+}
+
+// copyHTMLConverter 将HTML原样复制为PDF文件，便于检查发票内容
+func copyHTMLConverter(htmlPath, pdfPath string) error {
+	content, err := os.ReadFile(htmlPath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(pdfPath, content, 0644)
+}
+
+func invoiceContext(tenantID, userID, merchantID uint64) context.Context {
+	ctx := context.WithValue(context.Background(), "tenant_id", tenantID)
+	ctx = context.WithValue(ctx, "user_id", userID)
+	if merchantID > 0 {
+		ctx = context.WithValue(ctx, "merchant_id", merchantID)
+	}
+	return ctx
+}
+
+func TestOrderInvoiceService(t *testing.T) {
+	Convey("订单发票", t, func() {
+		issuedAt := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+		orders := map[uint64]*types.Order{
+			1: {
+				ID: 1, TenantID: 1, MerchantID: 10, CustomerID: 100, OrderNumber: "ORD001",
+				Status: types.OrderStatusPaid, CreatedAt: issuedAt,
+				Items: []types.OrderItem{
+					{ProductID: 501, Quantity: 2, Price: 50},
+					{ProductID: 502, Quantity: 1, Price: 20},
+				},
+				SubtotalAmount: 106.19, TaxAmount: 13.81, TotalAmount: 120,
+				Tax: &types.OrderTax{
+					Inclusive: true,
+					Lines: []types.OrderTaxLine{
+						{ProductID: 501, Rate: 0.13, NetAmount: 88.50, TaxAmount: 11.50},
+						{ProductID: 502, Rate: 0.13, NetAmount: 17.69, TaxAmount: 2.31},
+					},
+				},
+				ShippingAddress: &types.ShippingAddress{
+					RecipientName: "张三", Phone: "13800138000",
+					Province: "浙江省", City: "杭州市", District: "西湖区", DetailAddress: "文三路100号",
+				},
+			},
+			2: {ID: 2, TenantID: 1, MerchantID: 10, CustomerID: 101, OrderNumber: "ORD002", Status: types.OrderStatusCompleted, TotalAmount: 30},
+			3: {ID: 3, TenantID: 1, MerchantID: 10, CustomerID: 100, OrderNumber: "ORD003", Status: types.OrderStatusPending, TotalAmount: 30},
+		}
+		merchants := &brandingMerchantRepository{merchants: map[uint64]*types.Merchant{
+			10: {ID: 10, Name: "示例商户", BusinessInfo: &types.BusinessInfo{License: "91330100MA0000000X", Address: "杭州市滨江区", ContactPhone: "0571-88888888"}},
+		}}
+		invoiceRepo := newMemoryOrderInvoiceRepository()
+		tenantRepo := &brandingTenantRepository{}
+		storageDir := t.TempDir()
+		invoiceService := NewOrderInvoiceServiceForTest(&fakeDisputeOrderRepository{orders: orders}, invoiceRepo, merchants, tenantRepo,
+			copyHTMLConverter, storageDir, func() time.Time { return issuedAt })
+
+		Convey("每个订单分配唯一发票号，重复下载沿用原发票号", func() {
+			first, err := invoiceService.GetInvoice(invoiceContext(1, 100, 0), 1, false)
+			So(err, ShouldBeNil)
+			So(first.InvoiceNumber, ShouldEqual, "INV202600000001")
+			So(first.FilePath, ShouldStartWith, filepath.Join(storageDir, "1"))
+
+			second, err := invoiceService.GetInvoice(invoiceContext(1, 101, 0), 2, false)
+			So(err, ShouldBeNil)
+			So(second.InvoiceNumber, ShouldEqual, "INV202600000002")
+
+			again, err := invoiceService.GetInvoice(invoiceContext(1, 0, 10), 1, true)
+			So(err, ShouldBeNil)
+			So(again.InvoiceNumber, ShouldEqual, first.InvoiceNumber)
+			So(len(invoiceRepo.invoices), ShouldEqual, 2)
+
+			Convey("PDF文件丢失后按原发票号重新生成", func() {
+				So(os.Remove(again.FilePath), ShouldBeNil)
+				regenerated, err := invoiceService.GetInvoice(invoiceContext(1, 100, 0), 1, false)
+				So(err, ShouldBeNil)
+				So(regenerated.InvoiceNumber, ShouldEqual, first.InvoiceNumber)
+				_, statErr := os.Stat(regenerated.FilePath)
+				So(statErr, ShouldBeNil)
+			})
+		})
+
+		Convey("发票包含订单、税费、商户和客户信息", func() {
+			invoice, err := invoiceService.GetInvoice(invoiceContext(1, 100, 0), 1, false)
+			So(err, ShouldBeNil)
+
+			raw, err := os.ReadFile(invoice.FilePath)
+			So(err, ShouldBeNil)
+			content := string(raw)
+			for _, expected := range []string{
+				invoice.InvoiceNumber, "ORD001", "2026-03-15",
+				"示例商户", "91330100MA0000000X", "0571-88888888",
+				"张三", "浙江省杭州市西湖区文三路100号", "13800138000",
+				"商品501", "13%", "¥11.50", "¥13.81", "¥106.19", "¥120.00", "（含税）",
+			} {
+				So(content, ShouldContainSubstring, expected)
+			}
+		})
+
+		Convey("按租户发票策略生成发票号和开票方", func() {
+			tenantRepo.config = types.TenantConfig{Invoice: &types.InvoicePolicy{
+				Prefix: "FP", SequenceWidth: 5, IssuerName: "示例商贸有限公司", Footer: "感谢惠顾",
+			}}
+			invoice, err := invoiceService.GetInvoice(invoiceContext(1, 100, 0), 1, false)
+			So(err, ShouldBeNil)
+			So(invoice.InvoiceNumber, ShouldEqual, "FP202600001")
+
+			raw, err := os.ReadFile(invoice.FilePath)
+			So(err, ShouldBeNil)
+			So(string(raw), ShouldContainSubstring, "示例商贸有限公司")
+			So(string(raw), ShouldContainSubstring, "感谢惠顾")
+		})
+
+		Convey("不同租户的发票号独立编号", func() {
+			orders[4] = &types.Order{ID: 4, TenantID: 2, MerchantID: 10, CustomerID: 100, OrderNumber: "ORD004", Status: types.OrderStatusPaid}
+			_, err := invoiceService.GetInvoice(invoiceContext(1, 100, 0), 1, false)
+			So(err, ShouldBeNil)
+			invoice, err := invoiceService.GetInvoice(invoiceContext(2, 100, 0), 4, false)
+			So(err, ShouldBeNil)
+			So(invoice.InvoiceNumber, ShouldEqual, "INV202600000001")
+			So(strings.Contains(invoice.FilePath, filepath.Join(storageDir, "2")), ShouldBeTrue)
+		})
+
+		Convey("只有下单客户和订单所属商户可以获取发票", func() {
+			_, err := invoiceService.GetInvoice(invoiceContext(1, 101, 0), 1, false)
+			So(err, ShouldEqual, types.ErrInvoiceAccessDenied)
+
+			_, err = invoiceService.GetInvoice(invoiceContext(1, 0, 11), 1, true)
+			So(err, ShouldEqual, types.ErrInvoiceAccessDenied)
+			So(len(invoiceRepo.invoices), ShouldEqual, 0)
+		})
+
+		Convey("未支付订单和停用发票的租户不能开票", func() {
+			_, err := invoiceService.GetInvoice(invoiceContext(1, 100, 0), 3, false)
+			So(err, ShouldEqual, types.ErrOrderNotInvoiceable)
+
+			tenantRepo.config = types.TenantConfig{Invoice: &types.InvoicePolicy{Disabled: true}}
+			_, err = invoiceService.GetInvoice(invoiceContext(1, 100, 0), 1, false)
+			So(err, ShouldEqual, types.ErrInvoiceDisabled)
+			So(invoiceRepo.sequences[1], ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+var (
+	// ErrOrderQuantityBelowMinimum 商品购买数量低于最小购买数量
+	ErrOrderQuantityBelowMinimum = errors.New("商品购买数量低于最小购买数量")
+	// ErrOrderQuantityAboveMaximum 商品购买数量超过最大购买数量
+	ErrOrderQuantityAboveMaximum = errors.New("商品购买数量超过最大购买数量")
+	// ErrOrderStockInsufficient 已预留库存加本次购买数量超过商品库存
+	ErrOrderStockInsufficient = errors.New("商品库存不足")
+	// ErrOrderBackorderLimitExceeded 接受预订的商品，购买数量超出可用库存加预订上限
+	ErrOrderBackorderLimitExceeded = errors.New("商品库存不足且超出可预订数量")
+	// ErrOrderAmountBelowMinimum 订单金额低于商户最低起订金额
+	ErrOrderAmountBelowMinimum = errors.New("订单金额低于商户最低起订金额")
+	// ErrOrderRightsInsufficient 订单所需权益超过商户可用权益余额
+	ErrOrderRightsInsufficient = errors.New("商户可用权益余额不足")
+	// ErrOrderRightsReserved 订单所需权益将占用商户的保留权益
+	ErrOrderRightsReserved = errors.New("商户可使用权益不足，剩余权益为保留权益")
+)
+
+// OrderLimitError 购买数量、订单金额或权益余额超出限制，可通过 errors.Is 判断具体原因
+type OrderLimitError struct {
+	ProductID  uint64
+	MerchantID uint64
+	Limit      float64
+	Actual     float64
+	Err        error
+}
+
+func (e *OrderLimitError) Error() string {
+	if errors.Is(e.Err, ErrOrderRightsInsufficient) {
+		return fmt.Sprintf("%v: 商户 %d 可用权益 %.2f，需要权益 %.2f", e.Err, e.MerchantID, e.Limit, e.Actual)
+	}
+	if errors.Is(e.Err, ErrOrderRightsReserved) {
+		return fmt.Sprintf("%v: 商户 %d 可使用权益 %.2f，需要权益 %.2f", e.Err, e.MerchantID, e.Limit, e.Actual)
+	}
+	if e.ProductID == 0 {
+		return fmt.Sprintf("%v: 商户 %d 最低 %.2f，实际 %.2f", e.Err, e.MerchantID, e.Limit, e.Actual)
+	}
+	return fmt.Sprintf("%v: 商品 %d 限制 %.0f，实际 %.0f", e.Err, e.ProductID, e.Limit, e.Actual)
+}
+
+func (e *OrderLimitError) Unwrap() error {
+	return e.Err
+}
+
+// checkProductQuantity 校验商品购买数量，quantity 为本次购买后的总数量（含购物车中已有数量）；
+// 跟踪库存的商品还要求已预留库存加本次购买数量不超过库存，接受预订的商品可在预订上限内超出
+func checkProductQuantity(product *types.Product, quantity int) *OrderLimitError {
+	if product.MinOrderQuantity > 0 && quantity < product.MinOrderQuantity {
+		return &OrderLimitError{
+			ProductID: product.ID,
+			Limit:     float64(product.MinOrderQuantity),
+			Actual:    float64(quantity),
+			Err:       ErrOrderQuantityBelowMinimum,
+		}
+	}
+	if product.MaxOrderQuantity > 0 && quantity > product.MaxOrderQuantity {
+		return &OrderLimitError{
+			ProductID: product.ID,
+			Limit:     float64(product.MaxOrderQuantity),
+			Actual:    float64(quantity),
+			Err:       ErrOrderQuantityAboveMaximum,
+		}
+	}
+
+	inventory := product.InventoryInfo
+	if inventory == nil {
+		return nil
+	}
+	if _, err := inventory.CheckReserve(quantity); err != nil {
+		if errors.Is(err, types.ErrBackorderLimitExceeded) {
+			return &OrderLimitError{
+				ProductID: product.ID,
+				Limit:     float64(inventory.AvailableStock() + inventory.BackorderLimit),
+				Actual:    float64(quantity),
+				Err:       ErrOrderBackorderLimitExceeded,
+			}
+		}
+		return &OrderLimitError{
+			ProductID: product.ID,
+			Limit:     float64(inventory.AvailableStock()),
+			Actual:    float64(quantity),
+			Err:       ErrOrderStockInsufficient,
+		}
+	}
+	return nil
+}
+
+// checkMerchantMinimumAmount 校验订单金额是否达到商户最低起订金额，未配置时不限制
+func checkMerchantMinimumAmount(merchant *types.Merchant, amount float64) *OrderLimitError {
+	if merchant == nil || merchant.MinOrderAmount <= 0 || amount >= merchant.MinOrderAmount {
+		return nil
+	}
+	return &OrderLimitError{
+		MerchantID: merchant.ID,
+		Limit:      merchant.MinOrderAmount,
+		Actual:     amount,
+		Err:        ErrOrderAmountBelowMinimum,
+	}
+}
+
+// checkMerchantRights 校验商户可用权益余额是否足够支付订单权益，且支付后不占用商户的保留权益，
+// 商户未开通权益余额时不限制
+func checkMerchantRights(merchant *types.Merchant, rightsCost float64) *OrderLimitError {
+	if merchant == nil || merchant.RightsBalance == nil {
+		return nil
+	}
+	available := merchant.RightsBalance.GetAvailableBalance()
+	if rightsCost > available {
+		return &OrderLimitError{
+			MerchantID: merchant.ID,
+			Limit:      available,
+			Actual:     rightsCost,
+			Err:        ErrOrderRightsInsufficient,
+		}
+	}
+	if consumable := merchant.RightsBalance.GetConsumableBalance(); rightsCost > consumable {
+		return &OrderLimitError{
+			MerchantID: merchant.ID,
+			Limit:      consumable,
+			Actual:     rightsCost,
+			Err:        ErrOrderRightsReserved,
+		}
+	}
+	return nil
+}
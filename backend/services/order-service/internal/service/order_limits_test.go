@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeLimitCartRepository 内存购物车仓储
+type fakeLimitCartRepository struct {
+	repository.ICartRepository
+	items map[uint64]int
+}
+
+func (f *fakeLimitCartRepository) GetOrCreate(ctx context.Context, customerID uint64) (*types.Cart, error) {
+	return &types.Cart{ID: 1, CustomerID: customerID}, nil
+}
+
+func (f *fakeLimitCartRepository) AddItem(ctx context.Context, cartID uint64, productID uint64, quantity int, validate repository.CartItemValidator) error {
+	if validate != nil {
+		if err := validate(ctx, types.CartItem{ProductID: productID, Quantity: f.items[productID] + quantity}); err != nil {
+			return err
+		}
+	}
+	f.items[productID] += quantity
+	return nil
+}
+
+// staticMerchantRepository 固定商户数据的商户仓储桩
+type staticMerchantRepository struct {
+	repository.MerchantRepository
+	merchant *types.Merchant
+}
+
+func (f *staticMerchantRepository) GetByID(ctx context.Context, id uint64) (*types.Merchant, error) {
+	return f.merchant, nil
+}
+
+func TestOrderLimits(t *testing.T) {
+	Convey("购买数量和订单金额限制", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+		productRepo := &staticProductAvailabilityRepository{products: []types.Product{
+			{
+				ID:               1,
+				Status:           types.ProductStatusActive,
+				MinOrderQuantity: 2,
+				MaxOrderQuantity: 5,
+				InventoryInfo:    &types.InventoryInfo{StockQuantity: 100, ReservedQuantity: 10, TrackInventory: true},
+			},
+		}}
+		merchantRepo := &staticMerchantRepository{merchant: &types.Merchant{ID: 1, MinOrderAmount: 300}}
+
+		newRequest := func(quantity int) *types.CreateOrderRequest {
+			req := &types.CreateOrderRequest{MerchantID: 1}
+			req.Items = append(req.Items, struct {
+				ProductID uint64 `json:"product_id" v:"required#商品ID不能为空"`
+				Quantity  int    `json:"quantity" v:"required|min:1#数量不能为空|数量必须大于0"`
+			}{ProductID: 1, Quantity: quantity})
+			return req
+		}
+
+		Convey("下单时", func() {
+			orderService := NewOrderServiceForTest(nil, nil, productRepo, merchantRepo, nil)
+
+			Convey("满足限制的订单可以创建", func() {
+				confirmation, err := orderService.GetOrderConfirmation(ctx, 100, newRequest(3))
+				So(err, ShouldBeNil)
+				So(confirmation.CanCreate, ShouldBeTrue)
+			})
+
+			Convey("订单金额低于商户最低起订金额被拒绝", func() {
+				confirmation, err := orderService.GetOrderConfirmation(ctx, 100, newRequest(2))
+				So(err, ShouldBeNil)
+				So(confirmation.CanCreate, ShouldBeFalse)
+
+				_, err = orderService.CreateOrder(ctx, 100, newRequest(2))
+				So(errors.Is(err, ErrOrderAmountBelowMinimum), ShouldBeTrue)
+
+				var limitErr *OrderLimitError
+				So(errors.As(err, &limitErr), ShouldBeTrue)
+				So(limitErr.MerchantID, ShouldEqual, 1)
+				So(limitErr.Limit, ShouldEqual, 300)
+				So(limitErr.Actual, ShouldEqual, 200)
+			})
+
+			Convey("购买数量超过最大购买数量被拒绝", func() {
+				_, err := orderService.CreateOrder(ctx, 100, newRequest(6))
+				So(errors.Is(err, ErrOrderQuantityAboveMaximum), ShouldBeTrue)
+
+				var limitErr *OrderLimitError
+				So(errors.As(err, &limitErr), ShouldBeTrue)
+				So(limitErr.ProductID, ShouldEqual, 1)
+				So(limitErr.Limit, ShouldEqual, 5)
+			})
+
+			Convey("同一商品的多个订单项按合计数量校验", func() {
+				req := newRequest(3)
+				req.Items = append(req.Items, req.Items[0])
+
+				_, err := orderService.CreateOrder(ctx, 100, req)
+				So(errors.Is(err, ErrOrderQuantityAboveMaximum), ShouldBeTrue)
+			})
+
+			Convey("已预留库存加购买数量超过库存被拒绝", func() {
+				productRepo.products[0].InventoryInfo.ReservedQuantity = 97
+
+				_, err := orderService.CreateOrder(ctx, 100, newRequest(4))
+				So(errors.Is(err, ErrOrderStockInsufficient), ShouldBeTrue)
+			})
+
+			Convey("接受预订的商品缺货时按预订接受并标记预计补货日期", func() {
+				restockAt := time.Date(2025, 9, 20, 0, 0, 0, 0, time.Local)
+				inventory := productRepo.products[0].InventoryInfo
+				inventory.ReservedQuantity = 98
+				inventory.AllowBackorder = true
+				inventory.BackorderLimit = 3
+				inventory.EstimatedRestockAt = &restockAt
+
+				req := newRequest(3)
+				req.Items = append(req.Items, req.Items[0])
+				req.Items[1].Quantity = 2
+
+				confirmation, err := orderService.GetOrderConfirmation(ctx, 100, req)
+				So(err, ShouldBeNil)
+				So(confirmation.CanCreate, ShouldBeTrue)
+				So(confirmation.Items[0].BackorderedQuantity, ShouldEqual, 1)
+				So(confirmation.Items[1].BackorderedQuantity, ShouldEqual, 2)
+				So(confirmation.Items[1].StockSufficient, ShouldBeTrue)
+				So(*confirmation.Items[1].EstimatedRestockAt, ShouldEqual, restockAt)
+			})
+
+			Convey("超出预订上限被拒绝", func() {
+				inventory := productRepo.products[0].InventoryInfo
+				inventory.ReservedQuantity = 98
+				inventory.AllowBackorder = true
+				inventory.BackorderLimit = 2
+
+				_, err := orderService.CreateOrder(ctx, 100, newRequest(5))
+				So(errors.Is(err, ErrOrderBackorderLimitExceeded), ShouldBeTrue)
+
+				var limitErr *OrderLimitError
+				So(errors.As(err, &limitErr), ShouldBeTrue)
+				So(limitErr.Limit, ShouldEqual, 4)
+			})
+		})
+
+		Convey("加入购物车时", func() {
+			cartRepo := &fakeLimitCartRepository{items: map[uint64]int{}}
+			cartService := NewCartServiceForTest(cartRepo, productRepo)
+
+			Convey("低于最小购买数量被拒绝", func() {
+				err := cartService.AddItem(ctx, 100, 1, 1)
+				So(errors.Is(err, ErrOrderQuantityBelowMinimum), ShouldBeTrue)
+				So(cartRepo.items, ShouldBeEmpty)
+			})
+
+			Convey("购物车已有数量加本次数量超过最大购买数量被拒绝", func() {
+				So(cartService.AddItem(ctx, 100, 1, 4), ShouldBeNil)
+
+				err := cartService.AddItem(ctx, 100, 1, 2)
+				So(errors.Is(err, ErrOrderQuantityAboveMaximum), ShouldBeTrue)
+				So(cartRepo.items[1], ShouldEqual, 4)
+
+				So(cartService.AddItem(ctx, 100, 1, 1), ShouldBeNil)
+				So(cartRepo.items[1], ShouldEqual, 5)
+			})
+		})
+	})
+}
@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+var (
+	// ErrOrderNotEditable 只有待支付订单可以修改商品，已支付订单需走退款流程
+	ErrOrderNotEditable = errors.New("订单当前状态不允许修改商品")
+	// ErrOrderEditForbidden 只有下单客户可以修改订单商品
+	ErrOrderEditForbidden = errors.New("无权修改该订单")
+	// ErrOrderItemNotInOrder 只能修改订单中已有商品的数量，不能新增商品
+	ErrOrderItemNotInOrder = errors.New("订单中不存在该商品")
+)
+
+// OrderReservationAdjuster 调整订单的库存预留
+type OrderReservationAdjuster interface {
+	// 将订单中商品的预留数量调整为 quantity，0 表示释放该商品的全部预留
+	AdjustOrderReservation(ctx context.Context, order *types.Order, productID uint64, quantity int) error
+}
+
+// inventoryReservationAdjuster 在同一事务中释放商品原预留并按新数量重新预留
+type inventoryReservationAdjuster struct {
+	productRepo     *repository.ProductRepository
+	reservationRepo repository.IInventoryReservationRepository
+}
+
+// newInventoryReservationAdjuster 创建库存预留调整器
+func newInventoryReservationAdjuster() OrderReservationAdjuster {
+	return &inventoryReservationAdjuster{
+		productRepo:     repository.NewProductRepository(),
+		reservationRepo: repository.NewInventoryReservationRepository(),
+	}
+}
+
+// AdjustOrderReservation 调整订单商品的预留数量，订单没有活跃预留（未跟踪库存）时不做处理
+func (a *inventoryReservationAdjuster) AdjustOrderReservation(ctx context.Context, order *types.Order, productID uint64, quantity int) error {
+	reservations, err := a.reservationRepo.GetByReference(ctx, order.TenantID, types.ReservationReferenceOrder, strconv.FormatUint(order.ID, 10))
+	if err != nil {
+		return fmt.Errorf("获取订单库存预留失败: %v", err)
+	}
+
+	var active []types.InventoryReservation
+	for _, reservation := range reservations {
+		if reservation.Status == types.ReservationStatusActive {
+			active = append(active, reservation)
+		}
+	}
+	if len(active) == 0 {
+		return nil
+	}
+
+	// 新预留沿用订单原预留的到期时间，修改商品不延长支付期限
+	expiresAt := active[0].ExpiresAt
+	var current []types.InventoryReservation
+	reserved := 0
+	for _, reservation := range active {
+		if reservation.ProductID == productID {
+			current = append(current, reservation)
+			reserved += reservation.ReservedQuantity
+		}
+	}
+	if reserved == quantity {
+		return nil
+	}
+
+	// 商品库存按商户隔离，调整时需要订单所属商户上下文
+	merchantCtx := context.WithValue(ctx, "merchant_id", order.MerchantID)
+	return repository.TenantTransaction(merchantCtx, func(ctx context.Context, tx gdb.TX) error {
+		for _, reservation := range current {
+			if err := a.reservationRepo.UpdateStatus(ctx, reservation.TenantID, reservation.ID, types.ReservationStatusReleased); err != nil {
+				return err
+			}
+		}
+		if reserved > 0 {
+			if err := a.productRepo.ReleaseInventory(ctx, productID, reserved); err != nil {
+				return err
+			}
+		}
+		if quantity == 0 {
+			return nil
+		}
+
+		backordered, err := a.productRepo.ReserveInventory(ctx, productID, quantity)
+		if err != nil {
+			return err
+		}
+		return a.reservationRepo.Create(ctx, &types.InventoryReservation{
+			TenantID:            order.TenantID,
+			ProductID:           productID,
+			ReservedQuantity:    quantity,
+			BackorderedQuantity: backordered,
+			ReferenceType:       types.ReservationReferenceOrder,
+			ReferenceID:         strconv.FormatUint(order.ID, 10),
+			Status:              types.ReservationStatusActive,
+			ExpiresAt:           expiresAt,
+		})
+	})
+}
+
+// NewOrderModificationServiceForTest 创建测试用订单服务实例（用于修改订单商品）
+func NewOrderModificationServiceForTest(orderRepo repository.IOrderRepository, policyRepo repository.IOrderCancellationPolicyRepository, noteRepo repository.IOrderNoteRepository, reservationAdjuster OrderReservationAdjuster) IOrderService {
+	return &OrderService{
+		orderRepo:           orderRepo,
+		policyRepo:          policyRepo,
+		noteRepo:            noteRepo,
+		reservationAdjuster: reservationAdjuster,
+	}
+}
+
+// UpdateOrderItems 修改待支付订单的商品数量或移除商品，重新计算金额和权益并调整库存预留；
+// 商品全部移除时取消订单
+func (s *OrderService) UpdateOrderItems(ctx context.Context, orderID uint64, changes []types.OrderItemChange) (*types.OrderItemsModificationResult, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("修改内容不能为空")
+	}
+	targets := make(map[uint64]int, len(changes))
+	for _, change := range changes {
+		if change.Quantity < 0 {
+			return nil, fmt.Errorf("商品%d数量不能为负数", change.ProductID)
+		}
+		if _, exists := targets[change.ProductID]; exists {
+			return nil, fmt.Errorf("商品%d重复修改", change.ProductID)
+		}
+		targets[change.ProductID] = change.Quantity
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("订单不存在: %v", err)
+	}
+	if order.CustomerID != gconv.Uint64(ctx.Value("user_id")) {
+		return nil, ErrOrderEditForbidden
+	}
+	if order.Status != types.OrderStatusPending || (order.PaymentInfo != nil && order.PaymentInfo.PaidAt != nil) {
+		return nil, fmt.Errorf("%w: 订单 %d 状态为 %s", ErrOrderNotEditable, order.ID, order.Status)
+	}
+
+	// 套餐订单项不能按商品修改数量
+	current := make(map[uint64]int, len(order.Items))
+	for _, item := range order.Items {
+		if item.IsBundle() {
+			continue
+		}
+		current[item.ProductID] += item.Quantity
+	}
+
+	result := &types.OrderItemsModificationResult{
+		Order:              order,
+		PreviousAmount:     order.TotalAmount,
+		PreviousRightsCost: order.TotalRightsCost,
+	}
+	for _, change := range changes {
+		quantity, exists := current[change.ProductID]
+		if !exists {
+			return nil, fmt.Errorf("%w: 商品%d", ErrOrderItemNotInOrder, change.ProductID)
+		}
+		if quantity != change.Quantity {
+			result.Adjustments = append(result.Adjustments, types.OrderItemAdjustment{
+				ProductID:    change.ProductID,
+				FromQuantity: quantity,
+				ToQuantity:   change.Quantity,
+			})
+		}
+	}
+	if len(result.Adjustments) == 0 {
+		return result, nil
+	}
+
+	// 同一商品的多个订单项合并为一项，数量按修改后的数量
+	items := make([]types.OrderItem, 0, len(order.Items))
+	merged := make(map[uint64]bool, len(order.Items))
+	for _, item := range order.Items {
+		quantity, changed := targets[item.ProductID]
+		if !changed {
+			items = append(items, item)
+			continue
+		}
+		if merged[item.ProductID] || quantity == 0 {
+			continue
+		}
+		merged[item.ProductID] = true
+		item.Quantity = quantity
+		items = append(items, item)
+	}
+
+	if len(items) == 0 {
+		return s.cancelEmptiedOrder(ctx, order, result)
+	}
+
+	if err := s.checkModifiedOrderLimits(ctx, order, items, result.Adjustments); err != nil {
+		return nil, fmt.Errorf("无法修改订单: %w", err)
+	}
+
+	// 先调整库存预留再保存订单，避免订单数量超过已锁定的库存
+	adjusted, err := s.adjustReservations(ctx, order, result.Adjustments)
+	if err != nil {
+		return nil, err
+	}
+
+	order.Items = items
+	order.TotalAmount, order.TotalRightsCost = orderItemTotals(items)
+	// 按下单时确定的税率重新计算税费
+	order.ApplyTax(order.Tax.Reprice(items))
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		s.rollbackReservations(ctx, order, adjusted)
+		return nil, fmt.Errorf("保存订单失败: %v", err)
+	}
+
+	s.recordItemsModification(ctx, result)
+	return result, nil
+}
+
+// cancelEmptiedOrder 商品全部移除后取消订单并释放库存预留
+func (s *OrderService) cancelEmptiedOrder(ctx context.Context, order *types.Order, result *types.OrderItemsModificationResult) (*types.OrderItemsModificationResult, error) {
+	_, err := s.CancelOrder(ctx, order.ID, &types.CancelOrderRequest{
+		Reason:       "订单商品已全部移除",
+		OperatorType: types.OrderStatusOperatorTypeCustomer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	order.Status = types.OrderStatusCancelled
+	result.Cancelled = true
+
+	// 先取消订单再释放库存，释放失败不影响取消结果，残留预留到期后自动释放
+	if _, err := s.adjustReservations(ctx, order, result.Adjustments); err != nil {
+		g.Log().Errorf(ctx, "订单 %d 取消后释放库存预留失败: %v", order.ID, err)
+	}
+
+	s.recordItemsModification(ctx, result)
+	return result, nil
+}
+
+// checkModifiedOrderLimits 校验修改后的购买数量限制、库存和商户最低起订金额
+func (s *OrderService) checkModifiedOrderLimits(ctx context.Context, order *types.Order, items []types.OrderItem, adjustments []types.OrderItemAdjustment) error {
+	if s.productRepo != nil {
+		productIDs := make([]uint64, 0, len(adjustments))
+		for _, adjustment := range adjustments {
+			if adjustment.ToQuantity > 0 {
+				productIDs = append(productIDs, adjustment.ProductID)
+			}
+		}
+		products, err := s.productRepo.GetByIDs(ctx, productIDs)
+		if err != nil {
+			return fmt.Errorf("获取商品信息失败: %v", err)
+		}
+		for i := range products {
+			product := products[i]
+			for _, adjustment := range adjustments {
+				if adjustment.ProductID != product.ID {
+					continue
+				}
+				// 已预留库存中包含本订单的原数量，校验库存时先扣除
+				if product.InventoryInfo != nil {
+					inventory := *product.InventoryInfo
+					inventory.ReservedQuantity -= adjustment.FromQuantity
+					if inventory.ReservedQuantity < 0 {
+						inventory.ReservedQuantity = 0
+					}
+					product.InventoryInfo = &inventory
+				}
+				if err := checkProductQuantity(&product, adjustment.ToQuantity); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if s.merchantRepo != nil {
+		merchant, err := s.merchantRepo.GetByID(ctx, order.MerchantID)
+		if err != nil {
+			return fmt.Errorf("获取商户信息失败: %v", err)
+		}
+		amount, _ := orderItemTotals(items)
+		if err := checkMerchantMinimumAmount(merchant, amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adjustReservations 按调整记录依次调整库存预留，失败时回滚已调整的预留并返回错误
+func (s *OrderService) adjustReservations(ctx context.Context, order *types.Order, adjustments []types.OrderItemAdjustment) ([]types.OrderItemAdjustment, error) {
+	if s.reservationAdjuster == nil {
+		return nil, nil
+	}
+
+	adjusted := make([]types.OrderItemAdjustment, 0, len(adjustments))
+	for _, adjustment := range adjustments {
+		if err := s.reservationAdjuster.AdjustOrderReservation(ctx, order, adjustment.ProductID, adjustment.ToQuantity); err != nil {
+			s.rollbackReservations(ctx, order, adjusted)
+			return nil, fmt.Errorf("调整商品%d库存预留失败: %w", adjustment.ProductID, err)
+		}
+		adjusted = append(adjusted, adjustment)
+	}
+	return adjusted, nil
+}
+
+// rollbackReservations 将已调整的库存预留恢复为原数量
+func (s *OrderService) rollbackReservations(ctx context.Context, order *types.Order, adjusted []types.OrderItemAdjustment) {
+	for _, adjustment := range adjusted {
+		if err := s.reservationAdjuster.AdjustOrderReservation(ctx, order, adjustment.ProductID, adjustment.FromQuantity); err != nil {
+			g.Log().Errorf(ctx, "订单 %d 商品 %d 库存预留回滚失败: %v", order.ID, adjustment.ProductID, err)
+		}
+	}
+}
+
+// recordItemsModification 以客户可见备注记录修改内容并写入审计日志，备注写入失败不影响修改结果
+func (s *OrderService) recordItemsModification(ctx context.Context, result *types.OrderItemsModificationResult) {
+	order := result.Order
+	parts := make([]string, 0, len(result.Adjustments))
+	for _, adjustment := range result.Adjustments {
+		if adjustment.ToQuantity == 0 {
+			parts = append(parts, fmt.Sprintf("移除商品%d（原数量 %d）", adjustment.ProductID, adjustment.FromQuantity))
+		} else {
+			parts = append(parts, fmt.Sprintf("商品%d数量 %d → %d", adjustment.ProductID, adjustment.FromQuantity, adjustment.ToQuantity))
+		}
+	}
+	content := "修改订单商品：" + strings.Join(parts, "；")
+	if result.Cancelled {
+		content += "。商品已全部移除，订单已取消"
+	} else {
+		content += fmt.Sprintf("。订单金额 %.2f → %.2f，权益 %.2f → %.2f",
+			result.PreviousAmount, order.TotalAmount, result.PreviousRightsCost, order.TotalRightsCost)
+	}
+
+	if s.noteRepo != nil {
+		note := &types.OrderNote{
+			OrderID:    order.ID,
+			MerchantID: order.MerchantID,
+			AuthorID:   gconv.Uint64(ctx.Value("user_id")),
+			AuthorName: gconv.String(ctx.Value("username")),
+			Visibility: types.OrderNoteVisibilityCustomer,
+			Content:    content,
+		}
+		if err := s.noteRepo.Create(ctx, note); err != nil {
+			g.Log().Errorf(ctx, "记录订单 %d 商品修改备注失败: %v", order.ID, err)
+		}
+	}
+
+	audit.LogOperation(ctx, "order", "update_items", map[string]interface{}{
+		"order_id":             order.ID,
+		"adjustments":          result.Adjustments,
+		"previous_amount":      result.PreviousAmount,
+		"total_amount":         order.TotalAmount,
+		"previous_rights_cost": result.PreviousRightsCost,
+		"total_rights_cost":    order.TotalRightsCost,
+		"cancelled":            result.Cancelled,
+	})
+}
+
+// orderItemTotals 按订单项锁定的单价计算订单金额和权益
+func orderItemTotals(items []types.OrderItem) (float64, float64) {
+	var amount, rightsCost float64
+	for _, item := range items {
+		amount += item.Price * float64(item.Quantity)
+		rightsCost += item.RightsCost * float64(item.Quantity)
+	}
+	return amount, rightsCost
+}
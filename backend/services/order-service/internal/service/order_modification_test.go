@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// editableOrderRepository 记录订单保存和取消操作的订单仓储桩
+type editableOrderRepository struct {
+	repository.IOrderRepository
+	order     *types.Order
+	saved     *types.Order
+	cancelled bool
+}
+
+func (f *editableOrderRepository) GetByID(ctx context.Context, id uint64) (*types.Order, error) {
+	return f.order, nil
+}
+
+func (f *editableOrderRepository) Update(ctx context.Context, order *types.Order) error {
+	saved := *order
+	f.saved = &saved
+	return nil
+}
+
+func (f *editableOrderRepository) UpdateStatusWithHistory(ctx context.Context, id uint64, status types.OrderStatusInt, reason string, operatorType types.OrderStatusOperatorType, operatorID *uint64, metadata interface{}) error {
+	f.cancelled = status == types.OrderStatusIntCancelled
+	return nil
+}
+
+// recordingReservationAdjuster 记录每个商品最终预留数量的库存预留调整桩
+type recordingReservationAdjuster struct {
+	reserved map[uint64]int
+	failFor  uint64
+}
+
+func (a *recordingReservationAdjuster) AdjustOrderReservation(ctx context.Context, order *types.Order, productID uint64, quantity int) error {
+	if productID == a.failFor {
+		return errors.New("库存不足")
+	}
+	a.reserved[productID] = quantity
+	return nil
+}
+
+func TestUpdateOrderItems(t *testing.T) {
+	Convey("修改待支付订单商品测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		customerCtx := context.WithValue(ctx, "user_id", uint64(100))
+
+		orderRepo := &editableOrderRepository{order: &types.Order{
+			ID:         1,
+			MerchantID: 1,
+			CustomerID: 100,
+			Status:     types.OrderStatusPending,
+			Items: []types.OrderItem{
+				{ProductID: 10, Quantity: 2, Price: 100, RightsCost: 10},
+				{ProductID: 20, Quantity: 1, Price: 50, RightsCost: 5},
+			},
+			TotalAmount:     250,
+			TotalRightsCost: 25,
+		}}
+		noteRepo := &fakeOrderNoteRepository{}
+		adjuster := &recordingReservationAdjuster{reserved: map[uint64]int{10: 2, 20: 1}}
+		policyRepo := &staticCancellationPolicyRepository{policy: types.DefaultOrderCancellationPolicy(1)}
+		orderService := NewOrderModificationServiceForTest(orderRepo, policyRepo, noteRepo, adjuster)
+
+		Convey("修改商品数量后重新计算金额和权益并调整库存预留", func() {
+			result, err := orderService.UpdateOrderItems(customerCtx, 1, []types.OrderItemChange{{ProductID: 10, Quantity: 5}})
+			So(err, ShouldBeNil)
+			So(result.Cancelled, ShouldBeFalse)
+			So(result.PreviousAmount, ShouldEqual, 250)
+			So(result.Adjustments, ShouldResemble, []types.OrderItemAdjustment{{ProductID: 10, FromQuantity: 2, ToQuantity: 5}})
+
+			So(orderRepo.saved, ShouldNotBeNil)
+			So(orderRepo.saved.Items[0].Quantity, ShouldEqual, 5)
+			So(orderRepo.saved.TotalAmount, ShouldEqual, 550)
+			So(orderRepo.saved.TotalRightsCost, ShouldEqual, 55)
+			So(adjuster.reserved, ShouldResemble, map[uint64]int{10: 5, 20: 1})
+
+			So(noteRepo.notes, ShouldHaveLength, 1)
+			So(noteRepo.notes[0].Visibility, ShouldEqual, types.OrderNoteVisibilityCustomer)
+			So(noteRepo.notes[0].Content, ShouldContainSubstring, "250.00 → 550.00")
+		})
+
+		Convey("移除商品后释放该商品的库存预留", func() {
+			result, err := orderService.UpdateOrderItems(customerCtx, 1, []types.OrderItemChange{{ProductID: 20, Quantity: 0}})
+			So(err, ShouldBeNil)
+			So(result.Cancelled, ShouldBeFalse)
+			So(orderRepo.saved.Items, ShouldResemble, []types.OrderItem{{ProductID: 10, Quantity: 2, Price: 100, RightsCost: 10}})
+			So(orderRepo.saved.TotalAmount, ShouldEqual, 200)
+			So(orderRepo.saved.TotalRightsCost, ShouldEqual, 20)
+			So(adjuster.reserved[20], ShouldEqual, 0)
+			So(orderRepo.cancelled, ShouldBeFalse)
+		})
+
+		Convey("商品全部移除时取消订单并释放全部库存预留", func() {
+			result, err := orderService.UpdateOrderItems(customerCtx, 1, []types.OrderItemChange{
+				{ProductID: 10, Quantity: 0},
+				{ProductID: 20, Quantity: 0},
+			})
+			So(err, ShouldBeNil)
+			So(result.Cancelled, ShouldBeTrue)
+			So(orderRepo.cancelled, ShouldBeTrue)
+			So(orderRepo.saved, ShouldBeNil)
+			So(adjuster.reserved, ShouldResemble, map[uint64]int{10: 0, 20: 0})
+			So(noteRepo.notes[0].Content, ShouldContainSubstring, "订单已取消")
+		})
+
+		Convey("已支付订单不能修改商品", func() {
+			paidAt := time.Now()
+			orderRepo.order.Status = types.OrderStatusPaid
+			orderRepo.order.PaymentInfo = &types.PaymentInfo{Method: "alipay", Amount: 250, PaidAt: &paidAt}
+
+			_, err := orderService.UpdateOrderItems(customerCtx, 1, []types.OrderItemChange{{ProductID: 10, Quantity: 1}})
+			So(errors.Is(err, ErrOrderNotEditable), ShouldBeTrue)
+			So(orderRepo.saved, ShouldBeNil)
+			So(adjuster.reserved, ShouldResemble, map[uint64]int{10: 2, 20: 1})
+		})
+
+		Convey("只有下单客户可以修改订单", func() {
+			otherCtx := context.WithValue(ctx, "user_id", uint64(101))
+			_, err := orderService.UpdateOrderItems(otherCtx, 1, []types.OrderItemChange{{ProductID: 10, Quantity: 1}})
+			So(errors.Is(err, ErrOrderEditForbidden), ShouldBeTrue)
+		})
+
+		Convey("不能新增订单中没有的商品", func() {
+			_, err := orderService.UpdateOrderItems(customerCtx, 1, []types.OrderItemChange{{ProductID: 30, Quantity: 1}})
+			So(errors.Is(err, ErrOrderItemNotInOrder), ShouldBeTrue)
+		})
+
+		Convey("库存预留调整失败时回滚已调整的预留且不保存订单", func() {
+			adjuster.failFor = 20
+			_, err := orderService.UpdateOrderItems(customerCtx, 1, []types.OrderItemChange{
+				{ProductID: 10, Quantity: 3},
+				{ProductID: 20, Quantity: 4},
+			})
+			So(err, ShouldNotBeNil)
+			So(orderRepo.saved, ShouldBeNil)
+			So(adjuster.reserved, ShouldResemble, map[uint64]int{10: 2, 20: 1})
+		})
+	})
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// IOrderNoteService 订单备注服务接口
+type IOrderNoteService interface {
+	AddNote(ctx context.Context, orderID uint64, req *types.CreateOrderNoteRequest) (*types.OrderNote, error)
+	ListNotes(ctx context.Context, orderID uint64, isStaff bool) ([]types.OrderNote, error)
+	AttachNotes(ctx context.Context, order *types.Order, isStaff bool) error
+}
+
+// OrderNoteService 订单备注服务实现
+type OrderNoteService struct {
+	orderRepo repository.IOrderRepository
+	noteRepo  repository.IOrderNoteRepository
+}
+
+// NewOrderNoteService 创建订单备注服务实例
+func NewOrderNoteService() IOrderNoteService {
+	return &OrderNoteService{
+		orderRepo: repository.NewOrderRepository(),
+		noteRepo:  repository.NewOrderNoteRepository(),
+	}
+}
+
+// NewOrderNoteServiceForTest 创建测试用订单备注服务实例
+func NewOrderNoteServiceForTest(orderRepo repository.IOrderRepository, noteRepo repository.IOrderNoteRepository) IOrderNoteService {
+	return &OrderNoteService{
+		orderRepo: orderRepo,
+		noteRepo:  noteRepo,
+	}
+}
+
+// AddNote 为订单添加备注（仅员工可调用，路由层校验权限）
+func (s *OrderNoteService) AddNote(ctx context.Context, orderID uint64, req *types.CreateOrderNoteRequest) (*types.OrderNote, error) {
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = types.OrderNoteVisibilityInternal
+	}
+	if !visibility.IsValid() {
+		return nil, fmt.Errorf("无效的备注可见范围: %s", visibility)
+	}
+
+	authorID := gconv.Uint64(ctx.Value("user_id"))
+	if authorID == 0 {
+		return nil, fmt.Errorf("缺少备注作者信息")
+	}
+
+	order, err := s.getScopedOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	note := &types.OrderNote{
+		OrderID:    order.ID,
+		MerchantID: order.MerchantID,
+		AuthorID:   authorID,
+		AuthorName: gconv.String(ctx.Value("username")),
+		Visibility: visibility,
+		Content:    req.Content,
+	}
+	if err := s.noteRepo.Create(ctx, note); err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "order_note", "create", map[string]interface{}{
+		"order_id":   order.ID,
+		"note_id":    note.ID,
+		"visibility": note.Visibility,
+	})
+
+	return note, nil
+}
+
+// ListNotes 获取订单备注：员工可见全部备注，下单客户只能看到客户可见备注
+func (s *OrderNoteService) ListNotes(ctx context.Context, orderID uint64, isStaff bool) ([]types.OrderNote, error) {
+	order, err := s.getScopedOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.listVisibleNotes(ctx, order, isStaff)
+}
+
+// AttachNotes 将查看者可见的备注填充到订单详情中
+func (s *OrderNoteService) AttachNotes(ctx context.Context, order *types.Order, isStaff bool) error {
+	if err := s.checkMerchantScope(ctx, order); err != nil {
+		return err
+	}
+
+	notes, err := s.listVisibleNotes(ctx, order, isStaff)
+	if err != nil {
+		return err
+	}
+
+	order.Notes = notes
+	return nil
+}
+
+// listVisibleNotes 按查看者身份过滤备注
+func (s *OrderNoteService) listVisibleNotes(ctx context.Context, order *types.Order, isStaff bool) ([]types.OrderNote, error) {
+	if !isStaff && order.CustomerID != gconv.Uint64(ctx.Value("user_id")) {
+		return nil, fmt.Errorf("无权查看该订单备注")
+	}
+
+	return s.noteRepo.ListByOrderID(ctx, order.ID, isStaff)
+}
+
+// getScopedOrder 获取当前租户和商户范围内的订单
+func (s *OrderNoteService) getScopedOrder(ctx context.Context, orderID uint64) (*types.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkMerchantScope(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// checkMerchantScope 商户用户只能访问本商户的订单
+func (s *OrderNoteService) checkMerchantScope(ctx context.Context, order *types.Order) error {
+	merchantID := gconv.Uint64(ctx.Value("merchant_id"))
+	if merchantID > 0 && order.MerchantID != merchantID {
+		return fmt.Errorf("无权访问该订单")
+	}
+	return nil
+}
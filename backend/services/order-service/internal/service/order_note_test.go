@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeNoteOrderRepository 订单仓储桩
+type fakeNoteOrderRepository struct {
+	repository.IOrderRepository
+	orders map[uint64]*types.Order
+}
+
+func (f *fakeNoteOrderRepository) GetByID(ctx context.Context, id uint64) (*types.Order, error) {
+	order, exists := f.orders[id]
+	if !exists {
+		return nil, fmt.Errorf("订单不存在")
+	}
+	return order, nil
+}
+
+// fakeOrderNoteRepository 内存订单备注仓储
+type fakeOrderNoteRepository struct {
+	notes []types.OrderNote
+}
+
+func (f *fakeOrderNoteRepository) Create(ctx context.Context, note *types.OrderNote) error {
+	note.ID = uint64(len(f.notes) + 1)
+	f.notes = append(f.notes, *note)
+	return nil
+}
+
+func (f *fakeOrderNoteRepository) ListByOrderID(ctx context.Context, orderID uint64, includeInternal bool) ([]types.OrderNote, error) {
+	var result []types.OrderNote
+	for _, note := range f.notes {
+		if note.OrderID != orderID {
+			continue
+		}
+		if !includeInternal && note.Visibility != types.OrderNoteVisibilityCustomer {
+			continue
+		}
+		result = append(result, note)
+	}
+	return result, nil
+}
+
+func TestOrderNoteService(t *testing.T) {
+	Convey("订单备注服务测试", t, func() {
+		orderRepo := &fakeNoteOrderRepository{
+			orders: map[uint64]*types.Order{
+				1: {ID: 1, TenantID: 1, MerchantID: 10, CustomerID: 100},
+			},
+		}
+		noteRepo := &fakeOrderNoteRepository{}
+		noteService := NewOrderNoteServiceForTest(orderRepo, noteRepo)
+
+		staffCtx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		staffCtx = context.WithValue(staffCtx, "user_id", uint64(5))
+		staffCtx = context.WithValue(staffCtx, "username", "support")
+
+		Convey("员工添加备注默认仅内部可见并记录作者", func() {
+			note, err := noteService.AddNote(staffCtx, 1, &types.CreateOrderNoteRequest{Content: "客户来电要求改期"})
+			So(err, ShouldBeNil)
+			So(note.Visibility, ShouldEqual, types.OrderNoteVisibilityInternal)
+			So(note.AuthorID, ShouldEqual, 5)
+			So(note.AuthorName, ShouldEqual, "support")
+			So(note.MerchantID, ShouldEqual, 10)
+		})
+
+		Convey("无效的可见范围被拒绝", func() {
+			_, err := noteService.AddNote(staffCtx, 1, &types.CreateOrderNoteRequest{Content: "x", Visibility: "public"})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("其他商户的用户不能添加或查看备注", func() {
+			otherMerchantCtx := context.WithValue(staffCtx, "merchant_id", uint64(20))
+			_, err := noteService.AddNote(otherMerchantCtx, 1, &types.CreateOrderNoteRequest{Content: "x"})
+			So(err, ShouldNotBeNil)
+
+			_, err = noteService.ListNotes(otherMerchantCtx, 1, true)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("按查看者身份过滤备注", func() {
+			_, err := noteService.AddNote(staffCtx, 1, &types.CreateOrderNoteRequest{Content: "内部备注"})
+			So(err, ShouldBeNil)
+			_, err = noteService.AddNote(staffCtx, 1, &types.CreateOrderNoteRequest{Content: "已安排发货", Visibility: types.OrderNoteVisibilityCustomer})
+			So(err, ShouldBeNil)
+
+			notes, err := noteService.ListNotes(staffCtx, 1, true)
+			So(err, ShouldBeNil)
+			So(len(notes), ShouldEqual, 2)
+
+			customerCtx := context.WithValue(staffCtx, "user_id", uint64(100))
+			notes, err = noteService.ListNotes(customerCtx, 1, false)
+			So(err, ShouldBeNil)
+			So(len(notes), ShouldEqual, 1)
+			So(notes[0].Content, ShouldEqual, "已安排发货")
+
+			strangerCtx := context.WithValue(staffCtx, "user_id", uint64(200))
+			_, err = noteService.ListNotes(strangerCtx, 1, false)
+			So(err, ShouldNotBeNil)
+
+			order := &types.Order{ID: 1, MerchantID: 10, CustomerID: 100}
+			So(noteService.AttachNotes(staffCtx, order, true), ShouldBeNil)
+			So(len(order.Notes), ShouldEqual, 2)
+		})
+	})
+}
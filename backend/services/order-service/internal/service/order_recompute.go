@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"math"
+	"reflect"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// IOrderRecomputeService 订单派生字段重算服务接口
+type IOrderRecomputeService interface {
+	Recompute(ctx context.Context, req *types.OrderRecomputeRequest) (*types.OrderRecomputeResult, error)
+}
+
+// OrderRecomputeService 按订单项重新计算历史订单的金额、权益和税费，用于修复计算错误后更正已保存的订单
+type OrderRecomputeService struct {
+	orderRepo repository.IOrderRepository
+	batchSize int
+}
+
+// NewOrderRecomputeService 创建订单派生字段重算服务实例
+func NewOrderRecomputeService() IOrderRecomputeService {
+	return &OrderRecomputeService{
+		orderRepo: repository.NewOrderRepository(),
+		batchSize: types.OrderRecomputeBatchSize,
+	}
+}
+
+// NewOrderRecomputeServiceForTest 创建测试用订单派生字段重算服务实例
+func NewOrderRecomputeServiceForTest(orderRepo repository.IOrderRepository, batchSize int) IOrderRecomputeService {
+	return &OrderRecomputeService{
+		orderRepo: orderRepo,
+		batchSize: batchSize,
+	}
+}
+
+// Recompute 分批重算当前租户符合条件的订单，只保存与重算结果不一致的订单；试运行时只报告变更。
+// 订单项和下单时确定的税率是源数据，不会被修改
+func (s *OrderRecomputeService) Recompute(ctx context.Context, req *types.OrderRecomputeRequest) (*types.OrderRecomputeResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	result := &types.OrderRecomputeResult{
+		DryRun: req.DryRun,
+		Failed: []uint64{},
+		Orders: []types.OrderRecomputeChange{},
+	}
+
+	var afterID uint64
+	for !result.Truncated {
+		orders, err := s.orderRepo.ListForRecompute(ctx, req, afterID, s.batchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, order := range orders {
+			if result.Scanned >= types.MaxOrderRecomputeOrders {
+				result.Truncated = true
+				break
+			}
+			afterID = order.ID
+			result.Scanned++
+			s.recomputeOrder(ctx, order, result)
+		}
+
+		if len(orders) < s.batchSize {
+			break
+		}
+	}
+
+	audit.LogOperation(ctx, "order", "recompute", map[string]interface{}{
+		"start_date": req.StartDate,
+		"end_date":   req.EndDate,
+		"status":     req.Status,
+		"dry_run":    req.DryRun,
+		"scanned":    result.Scanned,
+		"changed":    result.Changed,
+		"updated":    result.Updated,
+		"failed":     result.Failed,
+		"truncated":  result.Truncated,
+	})
+
+	return result, nil
+}
+
+// recomputeOrder 重算一个订单并记录变更，保存失败的订单记入结果，不中断其余订单的重算
+func (s *OrderRecomputeService) recomputeOrder(ctx context.Context, order *types.Order, result *types.OrderRecomputeResult) {
+	recomputed, changes := recomputeDerivedFields(order)
+	if len(changes) == 0 {
+		return
+	}
+
+	result.Changed++
+	if len(result.Orders) < types.MaxOrderRecomputeReported {
+		result.Orders = append(result.Orders, types.OrderRecomputeChange{
+			OrderID:     order.ID,
+			OrderNumber: order.OrderNumber,
+			Changes:     changes,
+		})
+	}
+	if result.DryRun {
+		return
+	}
+
+	if err := s.orderRepo.UpdateDerivedFields(ctx, recomputed); err != nil {
+		g.Log().Error(ctx, "保存订单重算结果失败", "order_id", order.ID, "error", err)
+		result.Failed = append(result.Failed, order.ID)
+		return
+	}
+	result.Updated++
+}
+
+// recomputeDerivedFields 按订单项重新计算订单金额和权益，按下单时确定的税率重新计算税费，
+// 返回重算后的订单副本及与原订单不一致的字段。没有订单项的订单缺少源数据，不做重算
+func recomputeDerivedFields(order *types.Order) (*types.Order, []types.OrderFieldChange) {
+	if len(order.Items) == 0 {
+		return order, nil
+	}
+
+	recomputed := *order
+	recomputed.TotalAmount, recomputed.TotalRightsCost = orderItemTotals(order.Items)
+	recomputed.ApplyTax(order.Tax.Reprice(order.Items))
+
+	var changes []types.OrderFieldChange
+	compare := func(field string, from, to float64) {
+		if math.Abs(from-to) >= 0.005 {
+			changes = append(changes, types.OrderFieldChange{Field: field, From: from, To: to})
+		}
+	}
+	compare(types.OrderDerivedFieldTotalAmount, order.TotalAmount, recomputed.TotalAmount)
+	compare(types.OrderDerivedFieldTotalRightsCost, order.TotalRightsCost, recomputed.TotalRightsCost)
+	compare(types.OrderDerivedFieldSubtotalAmount, order.SubtotalAmount, recomputed.SubtotalAmount)
+	compare(types.OrderDerivedFieldTaxAmount, order.TaxAmount, recomputed.TaxAmount)
+	if !reflect.DeepEqual(order.Tax, recomputed.Tax) {
+		changes = append(changes, types.OrderFieldChange{Field: types.OrderDerivedFieldTaxBreakdown, From: order.Tax, To: recomputed.Tax})
+	}
+
+	return &recomputed, changes
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recomputeOrderRepository 内存订单仓储，记录分批读取次数和保存的派生字段
+type recomputeOrderRepository struct {
+	repository.IOrderRepository
+	orders  []*types.Order
+	batches int
+	failIDs map[uint64]bool
+}
+
+func (f *recomputeOrderRepository) ListForRecompute(ctx context.Context, req *types.OrderRecomputeRequest, afterID uint64, limit int) ([]*types.Order, error) {
+	f.batches++
+	var orders []*types.Order
+	for _, order := range f.orders {
+		if order.ID <= afterID || order.CreatedAt.Before(req.StartDate) || !order.CreatedAt.Before(req.EndDate) {
+			continue
+		}
+		if req.Status != nil && order.Status != *req.Status {
+			continue
+		}
+		copied := *order
+		orders = append(orders, &copied)
+		if len(orders) == limit {
+			break
+		}
+	}
+	return orders, nil
+}
+
+func (f *recomputeOrderRepository) UpdateDerivedFields(ctx context.Context, order *types.Order) error {
+	if f.failIDs[order.ID] {
+		return fmt.Errorf("更新订单派生字段失败")
+	}
+	for _, stored := range f.orders {
+		if stored.ID == order.ID {
+			stored.TotalAmount = order.TotalAmount
+			stored.TotalRightsCost = order.TotalRightsCost
+			stored.SubtotalAmount = order.SubtotalAmount
+			stored.TaxAmount = order.TaxAmount
+			stored.Tax = order.Tax
+		}
+	}
+	return nil
+}
+
+func (f *recomputeOrderRepository) get(id uint64) *types.Order {
+	for _, order := range f.orders {
+		if order.ID == id {
+			return order
+		}
+	}
+	return nil
+}
+
+func TestOrderRecompute(t *testing.T) {
+	Convey("重算订单派生字段", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		createdAt := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+
+		// 订单 1：金额和权益正确；订单 2：金额、权益被破坏；订单 3：税额被破坏；订单 4：已取消且金额被破坏
+		newOrders := func() []*types.Order {
+			return []*types.Order{
+				{ID: 1, OrderNumber: "ORD001", Status: types.OrderStatusPaid, CreatedAt: createdAt,
+					Items:       []types.OrderItem{{ProductID: 1, Quantity: 2, Price: 50, RightsCost: 10}},
+					TotalAmount: 100, SubtotalAmount: 100, TotalRightsCost: 20},
+				{ID: 2, OrderNumber: "ORD002", Status: types.OrderStatusPaid, CreatedAt: createdAt,
+					Items:       []types.OrderItem{{ProductID: 1, Quantity: 3, Price: 50, RightsCost: 10}, {ProductID: 2, Quantity: 1, Price: 20, RightsCost: 5}},
+					TotalAmount: 150, SubtotalAmount: 150, TotalRightsCost: 30},
+				{ID: 3, OrderNumber: "ORD003", Status: types.OrderStatusCompleted, CreatedAt: createdAt,
+					Items:       []types.OrderItem{{ProductID: 1, Quantity: 1, Price: 100}},
+					TotalAmount: 113, SubtotalAmount: 100, TaxAmount: 0,
+					Tax: &types.OrderTax{RoundingMode: types.TaxRoundingHalfUp,
+						Lines:     []types.OrderTaxLine{{ProductID: 1, Rate: 0.13, NetAmount: 100, TaxAmount: 0}},
+						NetAmount: 100, TaxAmount: 0, GrossAmount: 100}},
+				{ID: 4, OrderNumber: "ORD004", Status: types.OrderStatusCancelled, CreatedAt: createdAt,
+					Items:       []types.OrderItem{{ProductID: 1, Quantity: 1, Price: 50}},
+					TotalAmount: 1},
+				{ID: 5, OrderNumber: "ORD005", Status: types.OrderStatusPaid, CreatedAt: createdAt.AddDate(0, 1, 0),
+					Items:       []types.OrderItem{{ProductID: 1, Quantity: 1, Price: 50}},
+					TotalAmount: 1},
+			}
+		}
+		orderRepo := &recomputeOrderRepository{orders: newOrders(), failIDs: map[uint64]bool{}}
+		recomputeService := NewOrderRecomputeServiceForTest(orderRepo, 2)
+		req := &types.OrderRecomputeRequest{
+			StartDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local),
+			EndDate:   time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local),
+		}
+
+		Convey("分批更正被破坏的派生字段，不修改订单项和状态", func() {
+			result, err := recomputeService.Recompute(ctx, req)
+			So(err, ShouldBeNil)
+			So(result.Scanned, ShouldEqual, 4)
+			So(result.Changed, ShouldEqual, 3)
+			So(result.Updated, ShouldEqual, 3)
+			So(orderRepo.batches, ShouldEqual, 3)
+
+			order := orderRepo.get(2)
+			So(order.TotalAmount, ShouldEqual, 170)
+			So(order.SubtotalAmount, ShouldEqual, 170)
+			So(order.TotalRightsCost, ShouldEqual, 35)
+			So(order.Items, ShouldResemble, newOrders()[1].Items)
+			So(order.Status, ShouldEqual, types.OrderStatusPaid)
+
+			order = orderRepo.get(3)
+			So(order.TaxAmount, ShouldEqual, 13)
+			So(order.SubtotalAmount, ShouldEqual, 100)
+			So(order.TotalAmount, ShouldEqual, 113)
+			So(order.Tax.Lines[0].Rate, ShouldEqual, 0.13)
+			So(order.Tax.Lines[0].TaxAmount, ShouldEqual, 13)
+
+			So(orderRepo.get(4).TotalAmount, ShouldEqual, 50)
+			So(orderRepo.get(5).TotalAmount, ShouldEqual, 1)
+			So(orderRepo.get(1), ShouldResemble, newOrders()[0])
+
+			Convey("再次重算没有变更", func() {
+				result, err := recomputeService.Recompute(ctx, req)
+				So(err, ShouldBeNil)
+				So(result.Changed, ShouldEqual, 0)
+				So(result.Orders, ShouldBeEmpty)
+			})
+		})
+
+		Convey("试运行只报告变更", func() {
+			req.DryRun = true
+			result, err := recomputeService.Recompute(ctx, req)
+			So(err, ShouldBeNil)
+			So(result.Changed, ShouldEqual, 3)
+			So(result.Updated, ShouldEqual, 0)
+			So(orderRepo.orders, ShouldResemble, newOrders())
+
+			So(result.Orders[0].OrderID, ShouldEqual, 2)
+			So(result.Orders[0].Changes, ShouldResemble, []types.OrderFieldChange{
+				{Field: types.OrderDerivedFieldTotalAmount, From: 150.0, To: 170.0},
+				{Field: types.OrderDerivedFieldTotalRightsCost, From: 30.0, To: 35.0},
+				{Field: types.OrderDerivedFieldSubtotalAmount, From: 150.0, To: 170.0},
+			})
+			So(result.Orders[1].Changes[0].Field, ShouldEqual, types.OrderDerivedFieldTaxAmount)
+			So(result.Orders[1].Changes[1].Field, ShouldEqual, types.OrderDerivedFieldTaxBreakdown)
+		})
+
+		Convey("按状态筛选订单", func() {
+			status := types.OrderStatusCancelled
+			req.Status = &status
+			result, err := recomputeService.Recompute(ctx, req)
+			So(err, ShouldBeNil)
+			So(result.Scanned, ShouldEqual, 1)
+			So(result.Updated, ShouldEqual, 1)
+			So(orderRepo.get(2).TotalAmount, ShouldEqual, 150)
+		})
+
+		Convey("保存失败的订单记入结果，不影响其余订单", func() {
+			orderRepo.failIDs[2] = true
+			result, err := recomputeService.Recompute(ctx, req)
+			So(err, ShouldBeNil)
+			So(result.Failed, ShouldResemble, []uint64{2})
+			So(result.Updated, ShouldEqual, 2)
+			So(orderRepo.get(2).TotalAmount, ShouldEqual, 150)
+		})
+
+		Convey("校验重算时间范围", func() {
+			_, err := recomputeService.Recompute(ctx, &types.OrderRecomputeRequest{StartDate: req.EndDate, EndDate: req.StartDate})
+			So(err, ShouldNotBeNil)
+			So(orderRepo.batches, ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// checkExternalReference 规范化下单时传入的外部订单号，并检查同一租户下是否已被使用；
+// 数据库唯一索引仍是并发下单时的最终保证
+func (s *OrderService) checkExternalReference(ctx context.Context, reference string) (string, error) {
+	reference, err := types.NormalizeExternalReference(reference)
+	if err != nil || reference == "" {
+		return reference, err
+	}
+
+	existing, err := s.orderRepo.GetByExternalReference(ctx, reference)
+	switch {
+	case errors.Is(err, types.ErrOrderReferenceNotFound):
+		return reference, nil
+	case err != nil:
+		return "", fmt.Errorf("检查外部订单号失败: %v", err)
+	}
+	return "", fmt.Errorf("%w: %s 已用于订单 %s", types.ErrDuplicateExternalReference, reference, existing.OrderNumber)
+}
+
+// LookupOrder 按订单号或外部订单号查询订单；按查询编号查询时先匹配订单号，未找到再匹配外部订单号
+func (s *OrderService) LookupOrder(ctx context.Context, req *types.OrderLookupRequest) (*types.Order, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.TrimSpace(req.OrderNumber) != "":
+		return s.orderRepo.GetByOrderNumber(ctx, strings.TrimSpace(req.OrderNumber))
+	case strings.TrimSpace(req.ExternalReference) != "":
+		return s.orderRepo.GetByExternalReference(ctx, strings.TrimSpace(req.ExternalReference))
+	}
+
+	reference := strings.TrimSpace(req.Reference)
+	order, err := s.orderRepo.GetByOrderNumber(ctx, reference)
+	if !errors.Is(err, types.ErrOrderReferenceNotFound) {
+		return order, err
+	}
+	return s.orderRepo.GetByExternalReference(ctx, reference)
+}
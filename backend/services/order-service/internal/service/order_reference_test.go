@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// referenceOrderRepository 按订单号和外部订单号查找已创建订单的订单仓储桩，
+// 保存时与数据库唯一索引一样拒绝重复的外部订单号
+type referenceOrderRepository struct {
+	batchOrderRepository
+}
+
+func (f *referenceOrderRepository) Create(ctx context.Context, order *types.Order) error {
+	if order.ExternalReference != "" {
+		if _, err := f.GetByExternalReference(ctx, order.ExternalReference); err == nil {
+			return fmt.Errorf("%w: %s", types.ErrDuplicateExternalReference, order.ExternalReference)
+		}
+	}
+	return f.batchOrderRepository.Create(ctx, order)
+}
+
+func (f *referenceOrderRepository) GetByOrderNumber(ctx context.Context, orderNumber string) (*types.Order, error) {
+	for _, order := range f.created {
+		if order.OrderNumber == orderNumber {
+			return order, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: 订单号 %s", types.ErrOrderReferenceNotFound, orderNumber)
+}
+
+func (f *referenceOrderRepository) GetByExternalReference(ctx context.Context, reference string) (*types.Order, error) {
+	for _, order := range f.created {
+		if order.ExternalReference == reference {
+			return order, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: 外部订单号 %s", types.ErrOrderReferenceNotFound, reference)
+}
+
+func TestOrderExternalReference(t *testing.T) {
+	Convey("外部订单号", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		productRepo := &staticProductAvailabilityRepository{products: []types.Product{
+			{ID: 3, MerchantID: 1, Status: types.ProductStatusActive, InventoryInfo: &types.InventoryInfo{StockQuantity: 10, TrackInventory: true}},
+		}}
+		inventory := &memoryInventory{
+			products:     map[uint64]*types.InventoryInfo{3: {StockQuantity: 10, TrackInventory: true}},
+			reservations: make(map[uint64]*types.InventoryReservation),
+		}
+		orderRepo := &referenceOrderRepository{}
+		orderService := NewOrderBatchServiceForTest(orderRepo, productRepo, inventory, inventory)
+
+		createOrder := func(reference string) *types.BatchCreateOrderResult {
+			req := types.CreateOrderRequest{MerchantID: 1, ExternalReference: reference}
+			req.AddItem(3, 1)
+			result, err := orderService.BatchCreateOrders(ctx, 100, &types.BatchCreateOrderRequest{
+				Orders: []types.CreateOrderRequest{req},
+			})
+			So(err, ShouldBeNil)
+			return result
+		}
+
+		Convey("下单时保存去除首尾空白后的外部订单号", func() {
+			result := createOrder("  ERP-2024/0001 ")
+			So(result.Failures, ShouldBeEmpty)
+			So(result.Orders[0].ExternalReference, ShouldEqual, "ERP-2024/0001")
+
+			// 未指定外部订单号的订单互不冲突
+			So(createOrder("").Failures, ShouldBeEmpty)
+			So(createOrder("").Failures, ShouldBeEmpty)
+			So(orderRepo.created, ShouldHaveLength, 3)
+		})
+
+		Convey("同一租户下重复的外部订单号被拒绝", func() {
+			So(createOrder("ERP-0001").Failures, ShouldBeEmpty)
+
+			result := createOrder("ERP-0001")
+			So(result.Orders, ShouldBeEmpty)
+			So(result.Failures, ShouldHaveLength, 1)
+			So(result.Failures[0].Error, ShouldContainSubstring, types.ErrDuplicateExternalReference.Error())
+			So(orderRepo.created, ShouldHaveLength, 1)
+		})
+
+		Convey("格式无效的外部订单号被拒绝", func() {
+			result := createOrder("ERP 0001")
+			So(result.Failures, ShouldHaveLength, 1)
+			So(result.Failures[0].Error, ShouldContainSubstring, "外部订单号只能包含")
+			So(orderRepo.created, ShouldBeEmpty)
+		})
+
+		Convey("按订单号或外部订单号查询订单", func() {
+			created := createOrder("ERP-0002").Orders[0]
+			lookup := orderService.LookupOrder
+
+			order, err := lookup(ctx, &types.OrderLookupRequest{ExternalReference: "ERP-0002"})
+			So(err, ShouldBeNil)
+			So(order.ID, ShouldEqual, created.ID)
+
+			order, err = lookup(ctx, &types.OrderLookupRequest{OrderNumber: created.OrderNumber})
+			So(err, ShouldBeNil)
+			So(order.ID, ShouldEqual, created.ID)
+
+			// 查询编号先匹配订单号，再匹配外部订单号
+			order, err = lookup(ctx, &types.OrderLookupRequest{Reference: created.OrderNumber})
+			So(err, ShouldBeNil)
+			So(order.ID, ShouldEqual, created.ID)
+			order, err = lookup(ctx, &types.OrderLookupRequest{Reference: "ERP-0002"})
+			So(err, ShouldBeNil)
+			So(order.ID, ShouldEqual, created.ID)
+
+			_, err = lookup(ctx, &types.OrderLookupRequest{Reference: "ERP-9999"})
+			So(errors.Is(err, types.ErrOrderReferenceNotFound), ShouldBeTrue)
+
+			_, err = lookup(ctx, &types.OrderLookupRequest{OrderNumber: created.OrderNumber, ExternalReference: "ERP-0002"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
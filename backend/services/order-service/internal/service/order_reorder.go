@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+var (
+	// ErrReorderForbidden 只有原订单的下单客户可以再来一单
+	ErrReorderForbidden = errors.New("无权基于该订单再次下单")
+	// ErrReorderNoAvailableItems 原订单中的商品均已无法购买
+	ErrReorderNoAvailableItems = errors.New("原订单中的商品均已无法购买")
+)
+
+// NewOrderReorderServiceForTest 创建测试用订单服务实例（用于再来一单）
+func NewOrderReorderServiceForTest(orderRepo repository.IOrderRepository, cartRepo repository.ICartRepository, productRepo repository.IProductAvailabilityRepository, merchantRepo repository.MerchantRepository) IOrderService {
+	return &OrderService{
+		orderRepo:    orderRepo,
+		cartRepo:     cartRepo,
+		productRepo:  productRepo,
+		merchantRepo: merchantRepo,
+	}
+}
+
+// Reorder 基于客户的历史订单再来一单：按当前商品状态、库存和价格重新校验原订单商品，
+// 已下架或无库存的商品不再购买，库存不足的按可用库存购买，价格变化的按当前价格购买，变化情况在结果中逐项说明。
+// 原订单中的商品均无法购买时返回结果和 ErrReorderNoAvailableItems
+func (s *OrderService) Reorder(ctx context.Context, orderID uint64, req *types.ReorderRequest) (*types.ReorderResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	mode := req.Mode
+	if mode == "" {
+		mode = types.ReorderModeOrder
+	}
+
+	source, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("订单不存在: %v", err)
+	}
+	customerID := gconv.Uint64(ctx.Value("user_id"))
+	if source.CustomerID != customerID {
+		return nil, ErrReorderForbidden
+	}
+
+	result := &types.ReorderResult{
+		SourceOrderID: source.ID,
+		Mode:          mode,
+		Issues:        []types.ReorderItemIssue{},
+	}
+	createReq, previous, err := s.reorderRequest(ctx, source, result)
+	if err != nil {
+		return nil, err
+	}
+	if len(createReq.Items) == 0 && len(createReq.Bundles) == 0 {
+		return result, ErrReorderNoAvailableItems
+	}
+
+	if mode == types.ReorderModeCart {
+		if err := s.reorderToCart(ctx, customerID, createReq, previous, result); err != nil {
+			return nil, err
+		}
+	} else if err := s.reorderToOrder(ctx, customerID, createReq, previous, result); err != nil {
+		return nil, err
+	}
+
+	g.Log().Info(ctx, "再来一单完成",
+		"source_order_id", source.ID,
+		"mode", mode,
+		"issues", len(result.Issues))
+	return result, nil
+}
+
+// reorderRequest 按商品当前状态和库存组装下单请求，同一商品的多个订单项合并，无法按原样购买的商品记录到结果中；
+// 套餐按原数量加入请求，由下单确认按套餐当前价格和组成商品库存校验。返回原订单中按商品合并后的订单项
+func (s *OrderService) reorderRequest(ctx context.Context, source *types.Order, result *types.ReorderResult) (*types.CreateOrderRequest, map[uint64]types.OrderItem, error) {
+	previous := make(map[uint64]types.OrderItem, len(source.Items))
+	sourceReq := &types.CreateOrderRequest{MerchantID: source.MerchantID}
+	createReq := &types.CreateOrderRequest{MerchantID: source.MerchantID}
+	for _, item := range source.Items {
+		if item.IsBundle() {
+			createReq.Bundles = append(createReq.Bundles, types.OrderBundleRequest{BundleID: item.BundleID, Quantity: item.Quantity})
+			continue
+		}
+		merged, exists := previous[item.ProductID]
+		if !exists {
+			merged = item
+			merged.Quantity = 0
+			sourceReq.AddItem(item.ProductID, 0)
+		}
+		merged.Quantity += item.Quantity
+		previous[item.ProductID] = merged
+	}
+
+	products, err := s.orderProducts(ctx, sourceReq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	for _, item := range sourceReq.Items {
+		quantity := previous[item.ProductID].Quantity
+		issue := types.ReorderItemIssue{
+			ProductID:         item.ProductID,
+			PreviousQuantity:  quantity,
+			PreviousUnitPrice: previous[item.ProductID].Price,
+		}
+
+		// 未配置商品仓储时不校验商品状态，与下单确认保持一致
+		if s.productRepo != nil {
+			product, exists := products[item.ProductID]
+			if !exists || product.Status != types.ProductStatusActive || !product.InAvailabilityWindow(now) {
+				issue.Reason = types.ReorderIssueUnavailable
+				issue.Message = fmt.Sprintf("商品%d已下架或不在可售时间内", item.ProductID)
+				result.Issues = append(result.Issues, issue)
+				continue
+			}
+
+			if inventory := product.InventoryInfo; inventory != nil && inventory.TrackInventory {
+				if _, err := inventory.CheckReserve(quantity); err != nil {
+					// 允许预订的商品在预订上限内仍可购买
+					purchasable := inventory.AvailableStock() - inventory.MinStockQuantity()
+					if purchasable <= 0 {
+						issue.Reason = types.ReorderIssueOutOfStock
+						issue.Message = fmt.Sprintf("商品%d已无可用库存", item.ProductID)
+						result.Issues = append(result.Issues, issue)
+						continue
+					}
+					quantity = purchasable
+					issue.Reason = types.ReorderIssueQuantityReduced
+					issue.Quantity = quantity
+					issue.Message = fmt.Sprintf("商品%d可用库存不足，数量由%d调整为%d", item.ProductID, issue.PreviousQuantity, quantity)
+					result.Issues = append(result.Issues, issue)
+				}
+			}
+		}
+
+		createReq.AddItem(item.ProductID, quantity)
+	}
+
+	return createReq, previous, nil
+}
+
+// reorderToOrder 重新校验下单限制和权益后创建新订单，新订单使用新的订单号并处于待支付状态
+func (s *OrderService) reorderToOrder(ctx context.Context, customerID uint64, req *types.CreateOrderRequest, previous map[uint64]types.OrderItem, result *types.ReorderResult) error {
+	order, err := s.newOrder(ctx, customerID, req)
+	if err != nil {
+		return err
+	}
+	for _, item := range order.Items {
+		if item.IsBundle() {
+			continue
+		}
+		appendReorderPriceIssue(result, previous[item.ProductID], item.Quantity, item.Price)
+	}
+
+	if err := s.orderRepo.Create(ctx, order); err != nil {
+		return fmt.Errorf("创建订单失败: %v", err)
+	}
+	if err := s.freezeOrderRights(ctx, order); err != nil {
+		s.cancelRightsRejectedOrder(ctx, order, err)
+		return fmt.Errorf("无法创建订单: %w", err)
+	}
+	s.holdForReview(ctx, order)
+	result.Order = order
+
+	publishOrderEvent(ctx, s.webhooks, types.OrderWebhookEventCreated, order, nil)
+	if s.notificationService != nil {
+		go func() {
+			if err := s.notificationService.SendOrderCreatedNotification(context.Background(), order); err != nil {
+				// 通知发送失败不影响订单创建
+				fmt.Printf("发送订单创建通知失败: %v\n", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// reorderToCart 将可购买的商品按当前价格加入购物车，购物车中已有的商品累加数量
+func (s *OrderService) reorderToCart(ctx context.Context, customerID uint64, req *types.CreateOrderRequest, previous map[uint64]types.OrderItem, result *types.ReorderResult) error {
+	confirmation, _, err := s.confirmOrder(ctx, customerID, req)
+	if err != nil {
+		return fmt.Errorf("获取订单确认信息失败: %v", err)
+	}
+
+	cart, err := s.cartRepo.GetOrCreate(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("获取购物车失败: %v", err)
+	}
+	for _, item := range confirmation.Items {
+		if item.BundleID != 0 {
+			if err := s.cartRepo.AddBundle(ctx, cart.ID, item.BundleID, item.Quantity, nil); err != nil {
+				return fmt.Errorf("加入购物车失败: %v", err)
+			}
+			result.CartItems = append(result.CartItems, types.OrderItem{
+				Quantity:         item.Quantity,
+				Price:            item.UnitPrice,
+				RightsCost:       item.UnitRightsCost,
+				BundleID:         item.BundleID,
+				BundleName:       item.ProductName,
+				BundleComponents: item.BundleComponents,
+			})
+			continue
+		}
+		if err := s.cartRepo.AddItem(ctx, cart.ID, item.ProductID, item.Quantity, nil); err != nil {
+			return fmt.Errorf("加入购物车失败: %v", err)
+		}
+		appendReorderPriceIssue(result, previous[item.ProductID], item.Quantity, item.UnitPrice)
+		result.CartItems = append(result.CartItems, types.OrderItem{
+			ProductID:  item.ProductID,
+			Quantity:   item.Quantity,
+			Price:      item.UnitPrice,
+			RightsCost: item.UnitRightsCost,
+		})
+	}
+	return nil
+}
+
+// appendReorderPriceIssue 当前单价与原订单不同时记录价格变化
+func appendReorderPriceIssue(result *types.ReorderResult, previous types.OrderItem, quantity int, unitPrice float64) {
+	if math.Abs(unitPrice-previous.Price) < 0.005 {
+		return
+	}
+	result.Issues = append(result.Issues, types.ReorderItemIssue{
+		ProductID:         previous.ProductID,
+		Reason:            types.ReorderIssuePriceChanged,
+		Message:           fmt.Sprintf("商品%d价格由%.2f调整为%.2f", previous.ProductID, previous.Price, unitPrice),
+		PreviousQuantity:  previous.Quantity,
+		Quantity:          quantity,
+		PreviousUnitPrice: previous.Price,
+		UnitPrice:         unitPrice,
+	})
+}
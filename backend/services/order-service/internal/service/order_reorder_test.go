@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// reorderOrderRepository 提供历史订单并记录新建订单的订单仓储桩
+type reorderOrderRepository struct {
+	batchOrderRepository
+	orders map[uint64]*types.Order
+}
+
+func (f *reorderOrderRepository) GetByID(ctx context.Context, id uint64) (*types.Order, error) {
+	order, exists := f.orders[id]
+	if !exists {
+		return nil, fmt.Errorf("订单不存在")
+	}
+	return order, nil
+}
+
+func TestOrderReorder(t *testing.T) {
+	Convey("再来一单", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		ctx = context.WithValue(ctx, "user_id", uint64(100))
+
+		source := &types.Order{
+			ID:          9,
+			MerchantID:  1,
+			CustomerID:  100,
+			OrderNumber: "ORD1-OLD",
+			Status:      types.OrderStatusCompleted,
+			Items: []types.OrderItem{
+				{ProductID: 10, Quantity: 2, Price: 100, RightsCost: 10},
+				{ProductID: 11, Quantity: 3, Price: 80, RightsCost: 10},
+				{ProductID: 10, Quantity: 1, Price: 100, RightsCost: 10},
+			},
+		}
+		orderRepo := &reorderOrderRepository{orders: map[uint64]*types.Order{9: source}}
+		cartRepo := &fakeLimitCartRepository{items: map[uint64]int{}}
+		productRepo := &staticProductAvailabilityRepository{products: []types.Product{
+			{ID: 10, Status: types.ProductStatusActive},
+			{ID: 11, Status: types.ProductStatusActive},
+		}}
+		orderService := NewOrderReorderServiceForTest(orderRepo, cartRepo, productRepo, nil)
+
+		Convey("商品均可购买时创建新订单，合并重复商品并提示价格变化", func() {
+			result, err := orderService.Reorder(ctx, 9, &types.ReorderRequest{})
+			So(err, ShouldBeNil)
+			So(result.Mode, ShouldEqual, types.ReorderModeOrder)
+			So(result.SourceOrderID, ShouldEqual, 9)
+
+			order := result.Order
+			So(order, ShouldNotBeNil)
+			So(orderRepo.created, ShouldHaveLength, 1)
+			So(order.OrderNumber, ShouldEqual, "ORD1-1")
+			So(order.Status, ShouldEqual, types.OrderStatusPending)
+			So(order.CustomerID, ShouldEqual, 100)
+			So(order.Items, ShouldHaveLength, 2)
+			So(order.Items[0].ProductID, ShouldEqual, 10)
+			So(order.Items[0].Quantity, ShouldEqual, 3)
+			So(order.Items[1].Quantity, ShouldEqual, 3)
+			So(order.TotalAmount, ShouldEqual, 600)
+
+			So(result.Issues, ShouldHaveLength, 1)
+			So(result.Issues[0].ProductID, ShouldEqual, 11)
+			So(result.Issues[0].Reason, ShouldEqual, types.ReorderIssuePriceChanged)
+			So(result.Issues[0].PreviousUnitPrice, ShouldEqual, 80)
+			So(result.Issues[0].UnitPrice, ShouldEqual, 100)
+		})
+
+		Convey("部分商品不可购买时只购买可购买的商品并逐项说明", func() {
+			productRepo.products = []types.Product{
+				{ID: 10, Status: types.ProductStatusActive, InventoryInfo: &types.InventoryInfo{TrackInventory: true, StockQuantity: 2}},
+				{ID: 11, Status: types.ProductStatusInactive},
+			}
+
+			result, err := orderService.Reorder(ctx, 9, &types.ReorderRequest{})
+			So(err, ShouldBeNil)
+			So(result.Order.Items, ShouldHaveLength, 1)
+			So(result.Order.Items[0].ProductID, ShouldEqual, 10)
+			So(result.Order.Items[0].Quantity, ShouldEqual, 2)
+
+			So(result.Issues, ShouldHaveLength, 2)
+			So(result.Issues[0].ProductID, ShouldEqual, 10)
+			So(result.Issues[0].Reason, ShouldEqual, types.ReorderIssueQuantityReduced)
+			So(result.Issues[0].PreviousQuantity, ShouldEqual, 3)
+			So(result.Issues[0].Quantity, ShouldEqual, 2)
+			So(result.Issues[1].ProductID, ShouldEqual, 11)
+			So(result.Issues[1].Reason, ShouldEqual, types.ReorderIssueUnavailable)
+		})
+
+		Convey("商品均不可购买时不创建订单", func() {
+			productRepo.products = []types.Product{
+				{ID: 10, Status: types.ProductStatusActive, InventoryInfo: &types.InventoryInfo{TrackInventory: true, StockQuantity: 5, ReservedQuantity: 5}},
+			}
+
+			result, err := orderService.Reorder(ctx, 9, &types.ReorderRequest{})
+			So(err, ShouldEqual, ErrReorderNoAvailableItems)
+			So(result.Issues, ShouldHaveLength, 2)
+			So(result.Issues[0].Reason, ShouldEqual, types.ReorderIssueOutOfStock)
+			So(result.Issues[1].Reason, ShouldEqual, types.ReorderIssueUnavailable)
+			So(orderRepo.created, ShouldBeEmpty)
+		})
+
+		Convey("加入购物车时不创建订单", func() {
+			result, err := orderService.Reorder(ctx, 9, &types.ReorderRequest{Mode: types.ReorderModeCart})
+			So(err, ShouldBeNil)
+			So(result.Order, ShouldBeNil)
+			So(result.CartItems, ShouldHaveLength, 2)
+			So(cartRepo.items, ShouldResemble, map[uint64]int{10: 3, 11: 3})
+			So(orderRepo.created, ShouldBeEmpty)
+		})
+
+		Convey("只有原订单的下单客户可以再来一单", func() {
+			otherCtx := context.WithValue(ctx, "user_id", uint64(200))
+			_, err := orderService.Reorder(otherCtx, 9, &types.ReorderRequest{})
+			So(err, ShouldEqual, ErrReorderForbidden)
+			So(orderRepo.created, ShouldBeEmpty)
+		})
+
+		Convey("不支持的方式被拒绝", func() {
+			_, err := orderService.Reorder(ctx, 9, &types.ReorderRequest{Mode: "wishlist"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
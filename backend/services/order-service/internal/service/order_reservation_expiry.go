@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// ReservationReleaser 释放订单库存预留
+type ReservationReleaser interface {
+	ReleaseReservation(ctx context.Context, reservation *types.InventoryReservation, status types.ReservationStatus) error
+}
+
+// inventoryReservationReleaser 在同一事务中更新预留状态并归还商品预留库存
+type inventoryReservationReleaser struct {
+	productRepo     *repository.ProductRepository
+	reservationRepo repository.IInventoryReservationRepository
+}
+
+// newInventoryReservationReleaser 创建库存预留释放器
+func newInventoryReservationReleaser(reservationRepo repository.IInventoryReservationRepository) ReservationReleaser {
+	return &inventoryReservationReleaser{
+		productRepo:     repository.NewProductRepository(),
+		reservationRepo: reservationRepo,
+	}
+}
+
+// ReleaseReservation 释放预留，先更新预留状态避免重复释放库存
+func (r *inventoryReservationReleaser) ReleaseReservation(ctx context.Context, reservation *types.InventoryReservation, status types.ReservationStatus) error {
+	return repository.TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if err := r.reservationRepo.UpdateStatus(ctx, reservation.TenantID, reservation.ID, status); err != nil {
+			return err
+		}
+		return r.productRepo.ReleaseInventory(ctx, reservation.ProductID, reservation.ReservedQuantity)
+	})
+}
+
+// orderReservations 同一订单的预留记录
+type orderReservations struct {
+	tenantID     uint64
+	orderID      uint64
+	reservations []types.InventoryReservation
+}
+
+// processExpiredReservations 处理到期的订单库存预留，与支付超时相互独立：
+// 预留保留时长可短于支付超时，到期后仍待支付的订单因库存已释放而自动关闭
+func (s *OrderTimeoutService) processExpiredReservations(ctx context.Context) error {
+	reservations, err := s.reservationRepo.GetExpiredByReferenceType(ctx, types.ReservationReferenceOrder, 100)
+	if err != nil {
+		return fmt.Errorf("获取到期库存预留失败: %v", err)
+	}
+
+	for _, group := range groupReservationsByOrder(ctx, reservations) {
+		// 定时任务没有请求上下文，按预留所属租户设置租户上下文
+		tenantCtx := context.WithValue(ctx, "tenant_id", group.tenantID)
+		if err := s.expireOrderReservations(tenantCtx, group.orderID, group.reservations); err != nil {
+			g.Log().Error(ctx, "处理到期库存预留失败",
+				"tenant_id", group.tenantID,
+				"order_id", group.orderID,
+				"error", err)
+		}
+	}
+
+	return nil
+}
+
+// groupReservationsByOrder 按订单分组预留记录，保持到期先后顺序
+func groupReservationsByOrder(ctx context.Context, reservations []types.InventoryReservation) []*orderReservations {
+	var groups []*orderReservations
+	index := make(map[string]*orderReservations)
+	for _, reservation := range reservations {
+		orderID, err := strconv.ParseUint(reservation.ReferenceID, 10, 64)
+		if err != nil {
+			g.Log().Warning(ctx, "库存预留关联的订单ID无效", "reservation_id", reservation.ID, "reference_id", reservation.ReferenceID)
+			continue
+		}
+
+		key := fmt.Sprintf("%d-%d", reservation.TenantID, orderID)
+		group, exists := index[key]
+		if !exists {
+			group = &orderReservations{tenantID: reservation.TenantID, orderID: orderID}
+			index[key] = group
+			groups = append(groups, group)
+		}
+		group.reservations = append(group.reservations, reservation)
+	}
+	return groups
+}
+
+// expireOrderReservations 处理单个订单的到期预留
+func (s *OrderTimeoutService) expireOrderReservations(ctx context.Context, orderID uint64, reservations []types.InventoryReservation) error {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("获取订单失败: %v", err)
+	}
+
+	switch order.Status {
+	case types.OrderStatusPending:
+		updateReq := &types.UpdateOrderStatusRequest{
+			Status:       types.OrderStatusIntCancelled,
+			Reason:       "库存预留已到期，订单自动关闭",
+			OperatorType: types.OrderStatusOperatorTypeSystem,
+			Metadata: map[string]interface{}{
+				"timeout_type":  "reservation_expired",
+				"cancel_reason": "out_of_stock",
+				"expired_at":    reservations[0].ExpiresAt,
+			},
+		}
+		// 先关闭订单再释放库存，避免库存被他人占用后订单仍能支付
+		if err := s.orderStatusService.UpdateOrderStatus(ctx, order.ID, updateReq); err != nil {
+			return fmt.Errorf("关闭预留到期订单失败: %v", err)
+		}
+	case types.OrderStatusCancelled:
+		// 订单已取消，只需释放残留的预留
+	default:
+		// 已支付的订单库存归订单所有，预留由后续履约流程确认
+		g.Log().Warning(ctx, "订单已支付，跳过到期库存预留释放",
+			"order_id", order.ID,
+			"status", order.Status)
+		return nil
+	}
+
+	if err := releaseOrderReservations(ctx, s.reservationReleaser, order, reservations, types.ReservationStatusExpired); err != nil {
+		return err
+	}
+
+	g.Log().Info(ctx, "库存预留到期，订单已关闭并释放库存",
+		"order_id", order.ID,
+		"order_number", order.OrderNumber,
+		"reservation_count", len(reservations))
+
+	return nil
+}
+
+// releaseOrderReservations 释放订单的活跃预留，单个预留失败不影响其他预留
+func releaseOrderReservations(ctx context.Context, releaser ReservationReleaser, order *types.Order, reservations []types.InventoryReservation, status types.ReservationStatus) error {
+	// 商品库存按商户隔离，释放时需要订单所属商户上下文
+	merchantCtx := context.WithValue(ctx, "merchant_id", order.MerchantID)
+
+	var failures []string
+	for i := range reservations {
+		reservation := &reservations[i]
+		if reservation.Status != types.ReservationStatusActive {
+			continue
+		}
+		if err := releaser.ReleaseReservation(merchantCtx, reservation, status); err != nil {
+			failures = append(failures, fmt.Sprintf("预留%d: %v", reservation.ID, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("释放库存预留失败: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
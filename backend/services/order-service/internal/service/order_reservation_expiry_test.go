@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// reservationOrderRepository 按ID返回订单的订单仓储桩
+type reservationOrderRepository struct {
+	repository.IOrderRepository
+	orders map[uint64]*types.Order
+}
+
+func (f *reservationOrderRepository) GetByID(ctx context.Context, id uint64) (*types.Order, error) {
+	order, ok := f.orders[id]
+	if !ok {
+		return nil, errors.New("订单不存在")
+	}
+	return order, nil
+}
+
+// fakeReservationRepository 返回固定预留记录的库存预留仓储桩
+type fakeReservationRepository struct {
+	repository.IInventoryReservationRepository
+	reservations []types.InventoryReservation
+}
+
+func (f *fakeReservationRepository) GetExpiredByReferenceType(ctx context.Context, referenceType string, limit int) ([]types.InventoryReservation, error) {
+	var expired []types.InventoryReservation
+	for _, reservation := range f.reservations {
+		if reservation.ReferenceType == referenceType && reservation.Status == types.ReservationStatusActive && reservation.IsExpired() {
+			expired = append(expired, reservation)
+		}
+	}
+	return expired, nil
+}
+
+func (f *fakeReservationRepository) GetByReference(ctx context.Context, tenantID uint64, referenceType, referenceID string) ([]types.InventoryReservation, error) {
+	var matched []types.InventoryReservation
+	for _, reservation := range f.reservations {
+		if reservation.TenantID == tenantID && reservation.ReferenceType == referenceType && reservation.ReferenceID == referenceID {
+			matched = append(matched, reservation)
+		}
+	}
+	return matched, nil
+}
+
+// recordingReservationReleaser 记录释放操作的预留释放器桩
+type recordingReservationReleaser struct {
+	released   map[uint64]types.ReservationStatus
+	merchantID uint64
+	failIDs    map[uint64]bool
+}
+
+func (r *recordingReservationReleaser) ReleaseReservation(ctx context.Context, reservation *types.InventoryReservation, status types.ReservationStatus) error {
+	if r.failIDs[reservation.ID] {
+		return errors.New("库存释放失败")
+	}
+	r.released[reservation.ID] = status
+	r.merchantID = ctx.Value("merchant_id").(uint64)
+	return nil
+}
+
+func TestOrderReservationExpiry(t *testing.T) {
+	Convey("库存预留到期处理测试", t, func() {
+		ctx := context.Background()
+		now := time.Now()
+		config := &types.OrderTimeoutConfig{PaymentTimeoutMinutes: 30, ReservationHoldMinutes: 5}
+
+		// 订单10分钟前创建：预留5分钟已到期，支付超时30分钟尚未到期
+		createdAt := now.Add(-10 * time.Minute)
+		reservationExpiresAt := config.ReservationExpiresAt(createdAt)
+		reservation := func(id uint64, orderID string, productID uint64) types.InventoryReservation {
+			return types.InventoryReservation{
+				ID: id, TenantID: 1, ProductID: productID, ReservedQuantity: 2,
+				ReferenceType: types.ReservationReferenceOrder, ReferenceID: orderID,
+				Status: types.ReservationStatusActive, ExpiresAt: &reservationExpiresAt,
+			}
+		}
+
+		pending := &types.Order{ID: 1, TenantID: 1, MerchantID: 10, Status: types.OrderStatusPending, CreatedAt: createdAt}
+		paid := &types.Order{ID: 2, TenantID: 1, MerchantID: 10, Status: types.OrderStatusPaid, CreatedAt: createdAt}
+		cancelled := &types.Order{ID: 3, TenantID: 1, MerchantID: 10, Status: types.OrderStatusCancelled, CreatedAt: createdAt}
+
+		orderRepo := &reservationOrderRepository{orders: map[uint64]*types.Order{1: pending, 2: paid, 3: cancelled}}
+		reservationRepo := &fakeReservationRepository{}
+		statusService := &recordingOrderStatusService{updates: map[uint64]*types.UpdateOrderStatusRequest{}}
+		releaser := &recordingReservationReleaser{released: map[uint64]types.ReservationStatus{}, failIDs: map[uint64]bool{}}
+		timeoutService := NewOrderTimeoutServiceForTest(orderRepo, statusService, reservationRepo, releaser)
+
+		Convey("预留保留时长短于支付超时，预留先于支付超时到期", func() {
+			So(reservationExpiresAt, ShouldHappenBefore, createdAt.Add(30*time.Minute))
+			So(reservationExpiresAt, ShouldHappenBefore, now)
+			So(now.Sub(pending.CreatedAt), ShouldBeLessThan, time.Duration(config.PaymentTimeoutMinutes)*time.Minute)
+		})
+
+		Convey("预留到期时待支付订单自动关闭并释放库存", func() {
+			reservationRepo.reservations = []types.InventoryReservation{reservation(11, "1", 100), reservation(12, "1", 101)}
+
+			So(timeoutService.processExpiredReservations(ctx), ShouldBeNil)
+
+			req, exists := statusService.updates[1]
+			So(exists, ShouldBeTrue)
+			So(req.Status, ShouldEqual, types.OrderStatusIntCancelled)
+			So(req.OperatorType, ShouldEqual, types.OrderStatusOperatorTypeSystem)
+			metadata := req.Metadata.(map[string]interface{})
+			So(metadata["timeout_type"], ShouldEqual, "reservation_expired")
+			So(metadata["cancel_reason"], ShouldEqual, "out_of_stock")
+
+			So(releaser.released, ShouldResemble, map[uint64]types.ReservationStatus{
+				11: types.ReservationStatusExpired,
+				12: types.ReservationStatusExpired,
+			})
+			So(releaser.merchantID, ShouldEqual, 10)
+		})
+
+		Convey("未到期的预留不处理", func() {
+			future := now.Add(time.Minute)
+			notExpired := reservation(11, "1", 100)
+			notExpired.ExpiresAt = &future
+			reservationRepo.reservations = []types.InventoryReservation{notExpired}
+
+			So(timeoutService.processExpiredReservations(ctx), ShouldBeNil)
+			So(statusService.updates, ShouldBeEmpty)
+			So(releaser.released, ShouldBeEmpty)
+		})
+
+		Convey("已支付订单的预留不释放，订单不关闭", func() {
+			reservationRepo.reservations = []types.InventoryReservation{reservation(21, "2", 100)}
+
+			So(timeoutService.processExpiredReservations(ctx), ShouldBeNil)
+			So(statusService.updates, ShouldBeEmpty)
+			So(releaser.released, ShouldBeEmpty)
+		})
+
+		Convey("已取消订单只释放残留预留", func() {
+			reservationRepo.reservations = []types.InventoryReservation{reservation(31, "3", 100)}
+
+			So(timeoutService.processExpiredReservations(ctx), ShouldBeNil)
+			So(statusService.updates, ShouldBeEmpty)
+			So(releaser.released[31], ShouldEqual, types.ReservationStatusExpired)
+		})
+
+		Convey("单个预留释放失败不影响其他预留", func() {
+			reservationRepo.reservations = []types.InventoryReservation{reservation(11, "1", 100), reservation(12, "1", 101)}
+			releaser.failIDs[11] = true
+
+			err := timeoutService.expireOrderReservations(ctx, 1, reservationRepo.reservations)
+			So(err, ShouldNotBeNil)
+			So(releaser.released, ShouldContainKey, uint64(12))
+		})
+
+		Convey("订单取消后释放钩子释放仍活跃的预留", func() {
+			released := reservation(41, "1", 100)
+			released.Status = types.ReservationStatusExpired
+			reservationRepo.reservations = []types.InventoryReservation{reservation(11, "1", 100), released}
+
+			resourceReleaser := NewOrderResourceReleaser(reservationRepo, releaser, nil)
+			So(resourceReleaser.ReleaseOrderResources(ctx, pending), ShouldBeNil)
+			So(releaser.released, ShouldResemble, map[uint64]types.ReservationStatus{11: types.ReservationStatusReleased})
+		})
+
+		Convey("预留保留时长配置", func() {
+			So(config.ReservationHold(), ShouldEqual, 5*time.Minute)
+
+			// 未配置时与支付超时一致
+			config.ReservationHoldMinutes = 0
+			So(config.ReservationHold(), ShouldEqual, 30*time.Minute)
+
+			// 不能超过支付超时
+			config.ReservationHoldMinutes = 60
+			So(config.ReservationHold(), ShouldEqual, 30*time.Minute)
+		})
+	})
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// OrderReviewHolder 下单后按租户审核规则评估订单，命中规则时将订单置为待审核
+type OrderReviewHolder interface {
+	// 命中规则时创建待审核记录并返回，未命中时返回 nil
+	HoldIfMatched(ctx context.Context, order *types.Order) (*types.OrderReview, error)
+}
+
+// IOrderReviewService 订单风险审核服务接口
+type IOrderReviewService interface {
+	OrderReviewHolder
+	// 员工按状态查看审核队列，商户用户只能查看本商户的订单
+	ListReviews(ctx context.Context, req *types.OrderReviewListRequest) ([]types.OrderReview, error)
+	GetReview(ctx context.Context, reviewID uint64) (*types.OrderReview, error)
+	// 员工通过或拒绝待审核订单，拒绝时取消订单
+	Decide(ctx context.Context, reviewID uint64, req *types.OrderReviewDecisionRequest) (*types.OrderReview, error)
+}
+
+// OrderReviewService 订单风险审核服务实现
+type OrderReviewService struct {
+	reviewRepo    repository.IOrderReviewRepository
+	policies      repository.OrderReviewPolicyProvider
+	statusService IOrderStatusService
+	now           func() time.Time
+}
+
+// NewOrderReviewService 创建订单风险审核服务实例
+func NewOrderReviewService(statusService IOrderStatusService) IOrderReviewService {
+	return &OrderReviewService{
+		reviewRepo:    repository.NewOrderReviewRepository(),
+		policies:      repository.NewTenantOrderReviewPolicyProvider(repository.NewTenantRepository()),
+		statusService: statusService,
+		now:           time.Now,
+	}
+}
+
+// NewOrderReviewServiceForTest 创建测试用订单风险审核服务实例
+func NewOrderReviewServiceForTest(reviewRepo repository.IOrderReviewRepository, tenantRepo repository.ITenantRepository, statusService IOrderStatusService, now func() time.Time) IOrderReviewService {
+	return &OrderReviewService{
+		reviewRepo:    reviewRepo,
+		policies:      repository.NewTenantOrderReviewPolicyProvider(tenantRepo),
+		statusService: statusService,
+		now:           now,
+	}
+}
+
+// HoldIfMatched 按租户审核规则评估刚创建的订单。审核规则读取失败时不拦截下单，只记录日志
+func (s *OrderReviewService) HoldIfMatched(ctx context.Context, order *types.Order) (*types.OrderReview, error) {
+	tenantID := order.TenantID
+	if tenantID == 0 {
+		tenantID = gconv.Uint64(ctx.Value("tenant_id"))
+	}
+	policy, err := s.policies(ctx, tenantID)
+	if err != nil {
+		g.Log().Warning(ctx, "获取订单审核规则失败，跳过风险审核", "order_id", order.ID, "error", err)
+		return nil, nil
+	}
+	rules := policy.RulesFor(order.MerchantID)
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	var stats types.OrderReviewCustomerStats
+	if types.NeedsCustomerStats(rules) {
+		stats, err = s.customerStats(ctx, order.CustomerID, types.VelocityWindow(rules))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	matches := types.EvaluateOrderReviewRules(rules, order, stats)
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	review := &types.OrderReview{
+		OrderID:      order.ID,
+		MerchantID:   order.MerchantID,
+		CustomerID:   order.CustomerID,
+		Status:       types.OrderReviewStatusPending,
+		MatchedRules: matches,
+	}
+	if err := s.reviewRepo.Create(ctx, review); err != nil {
+		return nil, err
+	}
+
+	ruleNames := make([]string, 0, len(matches))
+	for _, match := range matches {
+		ruleNames = append(ruleNames, match.Rule)
+	}
+	audit.LogOperation(ctx, "order_review", "hold", map[string]interface{}{
+		"order_id":  order.ID,
+		"review_id": review.ID,
+		"rules":     ruleNames,
+	})
+	g.Log().Info(ctx, "订单命中风险审核规则，等待人工审核",
+		"order_id", order.ID,
+		"order_number", order.OrderNumber,
+		"rules", ruleNames)
+
+	return review, nil
+}
+
+// customerStats 统计客户此前的订单数和统计窗口内的订单数，订单已保存，统计结果包含本单
+func (s *OrderReviewService) customerStats(ctx context.Context, customerID uint64, window time.Duration) (types.OrderReviewCustomerStats, error) {
+	var stats types.OrderReviewCustomerStats
+
+	total, err := s.reviewRepo.CountCustomerOrders(ctx, customerID, nil)
+	if err != nil {
+		return stats, err
+	}
+	if total > 0 {
+		stats.PreviousOrders = total - 1
+	}
+
+	if window > 0 {
+		since := s.now().Add(-window)
+		stats.RecentOrders, err = s.reviewRepo.CountCustomerOrders(ctx, customerID, &since)
+		if err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// ListReviews 员工查看审核队列，未指定状态时返回待审核的记录
+func (s *OrderReviewService) ListReviews(ctx context.Context, req *types.OrderReviewListRequest) ([]types.OrderReview, error) {
+	if req.Status == "" {
+		req.Status = types.OrderReviewStatusPending
+	}
+	if !req.Status.IsValid() {
+		return nil, fmt.Errorf("无效的审核状态: %s", req.Status)
+	}
+	if merchantID := gconv.Uint64(ctx.Value("merchant_id")); merchantID > 0 {
+		req.MerchantID = &merchantID
+	}
+	if req.Limit <= 0 {
+		req.Limit = types.DefaultOrderReviewListLimit
+	}
+	if req.Limit > types.MaxOrderReviewListLimit {
+		req.Limit = types.MaxOrderReviewListLimit
+	}
+
+	return s.reviewRepo.List(ctx, req)
+}
+
+// GetReview 获取审核记录详情
+func (s *OrderReviewService) GetReview(ctx context.Context, reviewID uint64) (*types.OrderReview, error) {
+	return s.getReview(ctx, reviewID)
+}
+
+// Decide 员工通过或拒绝待审核订单（仅员工可调用，路由层校验权限）。
+// 通过后订单从通过时起重新计算支付超时；拒绝时先记录审核结果再取消订单，取消失败的订单仍会按支付超时取消
+func (s *OrderReviewService) Decide(ctx context.Context, reviewID uint64, req *types.OrderReviewDecisionRequest) (*types.OrderReview, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	reviewerID := gconv.Uint64(ctx.Value("user_id"))
+	if reviewerID == 0 {
+		return nil, fmt.Errorf("缺少审核人信息")
+	}
+
+	review, err := s.getReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != types.OrderReviewStatusPending {
+		return nil, fmt.Errorf("订单审核已处理，当前状态: %s", review.Status)
+	}
+
+	decidedAt := s.now()
+	review.Status = types.OrderReviewStatusApproved
+	if req.Decision == types.OrderReviewDecisionReject {
+		review.Status = types.OrderReviewStatusRejected
+	}
+	review.DecisionNotes = req.Notes
+	review.ReviewerID = &reviewerID
+	review.DecidedAt = &decidedAt
+	if err := s.reviewRepo.UpdateDecision(ctx, review); err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "order_review", string(req.Decision), map[string]interface{}{
+		"order_id":  review.OrderID,
+		"review_id": review.ID,
+		"notes":     review.DecisionNotes,
+	})
+
+	if review.Status == types.OrderReviewStatusRejected {
+		operatorType := types.OrderStatusOperatorTypeAdmin
+		if gconv.Uint64(ctx.Value("merchant_id")) > 0 {
+			operatorType = types.OrderStatusOperatorTypeMerchant
+		}
+		err := s.statusService.UpdateOrderStatus(ctx, review.OrderID, &types.UpdateOrderStatusRequest{
+			Status:       types.OrderStatusIntCancelled,
+			Reason:       "订单未通过风险审核",
+			OperatorType: operatorType,
+			Metadata: map[string]interface{}{
+				"cancel_reason": "review_rejected",
+				"review_id":     review.ID,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("审核已拒绝，但取消订单失败: %v", err)
+		}
+	}
+
+	return review, nil
+}
+
+// getReview 获取当前租户的审核记录，商户用户只能访问本商户的订单
+func (s *OrderReviewService) getReview(ctx context.Context, reviewID uint64) (*types.OrderReview, error) {
+	review, err := s.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil {
+		return nil, fmt.Errorf("订单审核记录不存在")
+	}
+	if merchantID := gconv.Uint64(ctx.Value("merchant_id")); merchantID > 0 && review.MerchantID != merchantID {
+		return nil, fmt.Errorf("无权访问该订单审核记录")
+	}
+	return review, nil
+}
+
+// orderUnderReview 订单最近一次审核是否仍待审核，未配置审核仓储时视为不在审核中
+func orderUnderReview(ctx context.Context, reviewRepo repository.IOrderReviewRepository, orderID uint64) (bool, error) {
+	if reviewRepo == nil {
+		return false, nil
+	}
+	review, err := reviewRepo.GetLatestByOrderID(ctx, orderID)
+	if err != nil {
+		return false, fmt.Errorf("查询订单审核状态失败: %v", err)
+	}
+	return review != nil && review.Status == types.OrderReviewStatusPending, nil
+}
+
+// holdForReview 新订单保存后按风险审核规则评估，命中时订单进入待审核；评估失败不影响下单，只记录日志
+func (s *OrderService) holdForReview(ctx context.Context, order *types.Order) {
+	if s.reviews == nil {
+		return
+	}
+	review, err := s.reviews.HoldIfMatched(ctx, order)
+	if err != nil {
+		g.Log().Warning(ctx, "订单风险审核评估失败", "order_id", order.ID, "error", err)
+		return
+	}
+	order.Review = review
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// reviewTenantRepository 返回带订单审核规则配置的租户仓储桩
+type reviewTenantRepository struct {
+	repository.ITenantRepository
+	policy *types.OrderReviewPolicy
+}
+
+func (r *reviewTenantRepository) GetByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	config, err := json.Marshal(types.TenantConfig{OrderReview: r.policy})
+	if err != nil {
+		return nil, err
+	}
+	return &types.Tenant{ID: id, Config: string(config)}, nil
+}
+
+// memoryOrderReviewRepository 内存订单审核仓储，按下单时间统计客户订单
+type memoryOrderReviewRepository struct {
+	reviews    []*types.OrderReview
+	orderTimes map[uint64][]time.Time
+}
+
+func (m *memoryOrderReviewRepository) Create(ctx context.Context, review *types.OrderReview) error {
+	review.ID = uint64(len(m.reviews) + 1)
+	stored := *review
+	m.reviews = append(m.reviews, &stored)
+	return nil
+}
+
+func (m *memoryOrderReviewRepository) GetByID(ctx context.Context, id uint64) (*types.OrderReview, error) {
+	for _, review := range m.reviews {
+		if review.ID == id {
+			found := *review
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryOrderReviewRepository) GetLatestByOrderID(ctx context.Context, orderID uint64) (*types.OrderReview, error) {
+	for i := len(m.reviews) - 1; i >= 0; i-- {
+		if m.reviews[i].OrderID == orderID {
+			found := *m.reviews[i]
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryOrderReviewRepository) List(ctx context.Context, req *types.OrderReviewListRequest) ([]types.OrderReview, error) {
+	var reviews []types.OrderReview
+	for _, review := range m.reviews {
+		if review.Status != req.Status {
+			continue
+		}
+		if req.MerchantID != nil && review.MerchantID != *req.MerchantID {
+			continue
+		}
+		reviews = append(reviews, *review)
+	}
+	return reviews, nil
+}
+
+func (m *memoryOrderReviewRepository) UpdateDecision(ctx context.Context, review *types.OrderReview) error {
+	for i, existing := range m.reviews {
+		if existing.ID == review.ID {
+			if existing.Status != types.OrderReviewStatusPending {
+				return fmt.Errorf("订单审核记录已处理")
+			}
+			updated := *review
+			m.reviews[i] = &updated
+			return nil
+		}
+	}
+	return fmt.Errorf("订单审核记录不存在")
+}
+
+func (m *memoryOrderReviewRepository) CountCustomerOrders(ctx context.Context, customerID uint64, since *time.Time) (int, error) {
+	count := 0
+	for _, createdAt := range m.orderTimes[customerID] {
+		if since == nil || !createdAt.Before(*since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func TestOrderReviewService(t *testing.T) {
+	Convey("订单风险审核测试", t, func() {
+		now := time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC)
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		staffCtx := context.WithValue(ctx, "user_id", uint64(900))
+		merchantCtx := context.WithValue(context.WithValue(ctx, "user_id", uint64(901)), "merchant_id", uint64(20))
+
+		tenantRepo := &reviewTenantRepository{policy: &types.OrderReviewPolicy{
+			Enabled: true,
+			Rules: []types.OrderReviewRule{
+				{Name: "大额订单", Condition: types.OrderReviewConditionAmountAtLeast, Threshold: 5000},
+				{Name: "频繁下单", Condition: types.OrderReviewConditionOrderVelocity, Threshold: 3, WindowMinutes: 10},
+			},
+		}}
+		reviewRepo := &memoryOrderReviewRepository{orderTimes: map[uint64][]time.Time{
+			100: {now.Add(-30 * 24 * time.Hour), now},
+		}}
+		statusService := &recordingOrderStatusService{updates: make(map[uint64]*types.UpdateOrderStatusRequest)}
+		reviewService := NewOrderReviewServiceForTest(reviewRepo, tenantRepo, statusService, func() time.Time { return now })
+
+		bigOrder := &types.Order{ID: 1, TenantID: 1, MerchantID: 10, CustomerID: 100, OrderNumber: "ORD001", Status: types.OrderStatusPending, TotalAmount: 8000}
+		cleanOrder := &types.Order{ID: 2, TenantID: 1, MerchantID: 10, CustomerID: 100, OrderNumber: "ORD002", Status: types.OrderStatusPending, TotalAmount: 200}
+
+		Convey("命中规则的订单进入待审核", func() {
+			review, err := reviewService.HoldIfMatched(ctx, bigOrder)
+			So(err, ShouldBeNil)
+			So(review, ShouldNotBeNil)
+			So(review.Status, ShouldEqual, types.OrderReviewStatusPending)
+			So(review.OrderID, ShouldEqual, 1)
+			So(review.MatchedRules, ShouldHaveLength, 1)
+			So(review.MatchedRules[0].Rule, ShouldEqual, "大额订单")
+			So(review.MatchedRules[0].Actual, ShouldEqual, 8000)
+
+			queue, err := reviewService.ListReviews(staffCtx, &types.OrderReviewListRequest{})
+			So(err, ShouldBeNil)
+			So(queue, ShouldHaveLength, 1)
+
+			Convey("审核中的订单不能支付", func() {
+				orderRepo := &fakePaymentOrderRepository{orders: map[uint64]*types.Order{1: bigOrder}}
+				paymentService := newPaymentService(orderRepo, &silentNotificationService{}, &PaymentSandboxConfig{Enabled: true, Secret: "test-secret"})
+				paymentService.reviewRepo = reviewRepo
+
+				_, err := paymentService.InitiatePayment(ctx, 1, types.PaymentMethodAlipay, "")
+				So(err, ShouldEqual, types.ErrOrderUnderReview)
+
+				Convey("审核通过后可以支付", func() {
+					approved, err := reviewService.Decide(staffCtx, review.ID, &types.OrderReviewDecisionRequest{Decision: types.OrderReviewDecisionApprove})
+					So(err, ShouldBeNil)
+					So(approved.Status, ShouldEqual, types.OrderReviewStatusApproved)
+					So(*approved.ReviewerID, ShouldEqual, 900)
+					So(*approved.DecidedAt, ShouldEqual, now)
+					So(statusService.updates, ShouldBeEmpty)
+
+					payment, err := paymentService.InitiatePayment(ctx, 1, types.PaymentMethodAlipay, "")
+					So(err, ShouldBeNil)
+					So(payment, ShouldNotBeNil)
+				})
+			})
+
+			Convey("审核拒绝时取消订单", func() {
+				_, err := reviewService.Decide(staffCtx, review.ID, &types.OrderReviewDecisionRequest{Decision: types.OrderReviewDecisionReject})
+				So(err, ShouldNotBeNil)
+
+				rejected, err := reviewService.Decide(staffCtx, review.ID, &types.OrderReviewDecisionRequest{Decision: types.OrderReviewDecisionReject, Notes: "收货地址与支付地区不符"})
+				So(err, ShouldBeNil)
+				So(rejected.Status, ShouldEqual, types.OrderReviewStatusRejected)
+				So(statusService.updates[1], ShouldNotBeNil)
+				So(statusService.updates[1].Status, ShouldEqual, types.OrderStatusIntCancelled)
+				So(statusService.updates[1].OperatorType, ShouldEqual, types.OrderStatusOperatorTypeAdmin)
+
+				Convey("已处理的审核不能再次处理", func() {
+					_, err := reviewService.Decide(staffCtx, review.ID, &types.OrderReviewDecisionRequest{Decision: types.OrderReviewDecisionApprove})
+					So(err, ShouldNotBeNil)
+				})
+			})
+
+			Convey("其他商户的用户不能查看或处理该审核", func() {
+				queue, err := reviewService.ListReviews(merchantCtx, &types.OrderReviewListRequest{})
+				So(err, ShouldBeNil)
+				So(queue, ShouldBeEmpty)
+
+				_, err = reviewService.Decide(merchantCtx, review.ID, &types.OrderReviewDecisionRequest{Decision: types.OrderReviewDecisionApprove})
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("未命中规则的订单不进入审核", func() {
+			review, err := reviewService.HoldIfMatched(ctx, cleanOrder)
+			So(err, ShouldBeNil)
+			So(review, ShouldBeNil)
+			So(reviewRepo.reviews, ShouldBeEmpty)
+
+			orderRepo := &fakePaymentOrderRepository{orders: map[uint64]*types.Order{2: cleanOrder}}
+			paymentService := newPaymentService(orderRepo, &silentNotificationService{}, &PaymentSandboxConfig{Enabled: true, Secret: "test-secret"})
+			paymentService.reviewRepo = reviewRepo
+			_, err = paymentService.InitiatePayment(ctx, 2, types.PaymentMethodAlipay, "")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("统计窗口内频繁下单的客户进入审核", func() {
+			reviewRepo.orderTimes[100] = append(reviewRepo.orderTimes[100], now.Add(-5*time.Minute), now.Add(-2*time.Minute))
+
+			review, err := reviewService.HoldIfMatched(ctx, cleanOrder)
+			So(err, ShouldBeNil)
+			So(review, ShouldNotBeNil)
+			So(review.MatchedRules[0].Rule, ShouldEqual, "频繁下单")
+			So(review.MatchedRules[0].Actual, ShouldEqual, 3)
+		})
+
+		Convey("未启用审核规则时不进入审核", func() {
+			tenantRepo.policy.Enabled = false
+
+			review, err := reviewService.HoldIfMatched(ctx, bigOrder)
+			So(err, ShouldBeNil)
+			So(review, ShouldBeNil)
+		})
+
+		Convey("下单时命中规则的订单带有审核信息", func() {
+			orderService := &OrderService{reviews: reviewService}
+
+			orderService.holdForReview(ctx, bigOrder)
+			So(bigOrder.Review, ShouldNotBeNil)
+			So(bigOrder.Review.Status, ShouldEqual, types.OrderReviewStatusPending)
+
+			orderService.holdForReview(ctx, cleanOrder)
+			So(cleanOrder.Review, ShouldBeNil)
+		})
+	})
+}
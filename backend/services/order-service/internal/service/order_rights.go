@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// loadRightsFreezeEnabled 是否在下单时冻结商户权益直到支付，默认开启
+func loadRightsFreezeEnabled(ctx context.Context) bool {
+	return g.Cfg().MustGet(ctx, "order.rights.freezeUntilPayment", true).Bool()
+}
+
+// NewOrderRightsServiceForTest 创建测试用订单服务实例（用于下单权益校验和冻结）
+func NewOrderRightsServiceForTest(orderRepo repository.IOrderRepository, cartRepo repository.ICartRepository, merchantRepo repository.MerchantRepository, rightsRepo repository.IOrderRightsReservationRepository, notificationService NotificationService) IOrderService {
+	return &OrderService{
+		orderRepo:           orderRepo,
+		cartRepo:            cartRepo,
+		merchantRepo:        merchantRepo,
+		rightsRepo:          rightsRepo,
+		freezeRights:        true,
+		notificationService: notificationService,
+	}
+}
+
+// freezeOrderRights 冻结订单所需的商户权益直到支付。下单预校验后余额可能已被并发订单占用，
+// 冻结时在行锁下再次校验，余额不足时返回 ErrOrderRightsInsufficient，将占用保留权益时返回 ErrOrderRightsReserved
+func (s *OrderService) freezeOrderRights(ctx context.Context, order *types.Order) error {
+	if s.rightsRepo == nil || !s.freezeRights || order.TotalRightsCost <= 0 {
+		return nil
+	}
+
+	balance, err := s.rightsRepo.Freeze(ctx, &types.OrderRightsReservation{
+		MerchantID: order.MerchantID,
+		OrderID:    order.ID,
+		Amount:     order.TotalRightsCost,
+	})
+	if errors.Is(err, types.ErrRightsBalanceInsufficient) {
+		return &OrderLimitError{
+			MerchantID: order.MerchantID,
+			Limit:      balance.GetAvailableBalance(),
+			Actual:     order.TotalRightsCost,
+			Err:        ErrOrderRightsInsufficient,
+		}
+	}
+	if errors.Is(err, types.ErrRightsReserveBreached) {
+		return &OrderLimitError{
+			MerchantID: order.MerchantID,
+			Limit:      balance.GetConsumableBalance(),
+			Actual:     order.TotalRightsCost,
+			Err:        ErrOrderRightsReserved,
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("冻结商户权益失败: %v", err)
+	}
+	return nil
+}
+
+// releaseOrderRights 退回订单仍冻结的商户权益，没有冻结记录时不做处理，可重复调用
+func releaseOrderRights(ctx context.Context, rightsRepo repository.IOrderRightsReservationRepository, order *types.Order) error {
+	if rightsRepo == nil || order.TotalRightsCost <= 0 {
+		return nil
+	}
+
+	reservation, err := rightsRepo.Settle(ctx, order.TenantID, order.ID, types.OrderRightsReservationReleased)
+	if err != nil {
+		return err
+	}
+	if reservation != nil {
+		g.Log().Info(ctx, "订单冻结权益已退回",
+			"order_id", order.ID,
+			"merchant_id", order.MerchantID,
+			"released_rights", reservation.Amount)
+	}
+	return nil
+}
+
+// NewOrderRightsRefundServiceForTest 创建测试用订单服务实例（用于取消退款后退还权益）
+func NewOrderRightsRefundServiceForTest(orderRepo repository.IOrderRepository, policyRepo repository.IOrderCancellationPolicyRepository, refunder OrderRefunder, rightsRepo repository.IOrderRightsReservationRepository, rightsRefund repository.RightsRefundPolicyProvider) IOrderService {
+	return &OrderService{
+		orderRepo:    orderRepo,
+		policyRepo:   policyRepo,
+		refunder:     refunder,
+		rightsRepo:   rightsRepo,
+		rightsRefund: rightsRefund,
+	}
+}
+
+// restoreOrderRights 订单退款后按退款金额占支付金额的比例退还支付时扣减的权益，返回实际退还的权益。
+// 租户关闭权益退还、订单没有权益消耗或权益已全部退还时不处理
+func (s *OrderService) restoreOrderRights(ctx context.Context, order *types.Order, paidAmount, refundAmount float64, reason string) (float64, error) {
+	if s.rightsRepo == nil || order.TotalRightsCost <= 0 {
+		return 0, nil
+	}
+
+	if s.rightsRefund != nil {
+		policy, err := s.rightsRefund(ctx, order.TenantID)
+		if err != nil {
+			return 0, fmt.Errorf("获取权益退还策略失败: %v", err)
+		}
+		if !policy.Enabled() {
+			return 0, nil
+		}
+	}
+
+	operatorID := gconv.Uint64(ctx.Value("user_id"))
+	refund, err := s.rightsRepo.Restore(ctx, &types.OrderRightsRefund{
+		TenantID:     order.TenantID,
+		MerchantID:   order.MerchantID,
+		OrderID:      order.ID,
+		Amount:       types.RightsRefundAmount(order.TotalRightsCost, paidAmount, refundAmount),
+		RefundAmount: refundAmount,
+		Reason:       reason,
+		OperatorID:   operatorID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if refund == nil {
+		return 0, nil
+	}
+
+	audit.LogFundRightsRefund(ctx, order.TenantID, refund.MerchantID, operatorID, order.ID, refund.FundID, refund.Amount, refundAmount, reason)
+	return refund.Amount, nil
+}
+
+// cancelRightsRejectedOrder 冻结权益失败时取消已保存的订单
+func (s *OrderService) cancelRightsRejectedOrder(ctx context.Context, order *types.Order, cause error) {
+	if err := s.orderRepo.UpdateStatusWithHistory(ctx, order.ID, types.OrderStatusIntCancelled,
+		"冻结商户权益失败，订单自动取消", types.OrderStatusOperatorTypeSystem, nil,
+		map[string]interface{}{"cancel_reason": "rights_insufficient", "error": cause.Error()}); err != nil {
+		g.Log().Errorf(ctx, "冻结权益失败订单 %d 取消失败: %v", order.ID, err)
+	}
+	order.Status = types.OrderStatusCancelled
+}
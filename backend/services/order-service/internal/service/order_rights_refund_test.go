@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOrderRightsRefund(t *testing.T) {
+	Convey("订单取消退款后退还权益", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		merchantCtx := context.WithValue(context.WithValue(ctx, "user_id", uint64(9)), "merchant_id", uint64(1))
+		customerCtx := context.WithValue(ctx, "user_id", uint64(100))
+		paidAt := time.Now()
+
+		// 订单支付 200，支付时扣减权益 50：商户已使用权益 70，可用 30
+		paidOrder := func() *types.Order {
+			return &types.Order{ID: 1, TenantID: 1, MerchantID: 1, CustomerID: 100, Status: types.OrderStatusPaid,
+				TotalAmount: 200, TotalRightsCost: 50,
+				PaymentInfo: &types.PaymentInfo{Method: "alipay", Amount: 200, PaidAt: &paidAt}}
+		}
+		balance := &types.RightsBalance{TotalBalance: 100, UsedBalance: 70}
+		balance.UpdateAvailableBalance()
+		rightsRepo := &memoryRightsRepository{
+			balances: map[uint64]*types.RightsBalance{1: balance},
+			reservations: map[uint64]*types.OrderRightsReservation{
+				1: {TenantID: 1, MerchantID: 1, OrderID: 1, Amount: 50, Status: types.OrderRightsReservationConsumed},
+			},
+		}
+
+		orderRepo := &cancellableOrderRepository{order: paidOrder()}
+		policy := types.DefaultOrderCancellationPolicy(1)
+		refunder := &recordingRefunder{}
+		var refundPolicy *types.OrderRightsRefundPolicy
+		orderService := NewOrderRightsRefundServiceForTest(orderRepo, &staticCancellationPolicyRepository{policy: policy}, refunder, rightsRepo,
+			func(ctx context.Context, tenantID uint64) (*types.OrderRightsRefundPolicy, error) {
+				return refundPolicy, nil
+			})
+
+		Convey("全额退款退还订单全部权益", func() {
+			result, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(err, ShouldBeNil)
+			So(result.RefundAmount, ShouldEqual, 200)
+			So(result.RightsRefunded, ShouldEqual, 50)
+			So(balance.UsedBalance, ShouldEqual, 20)
+			So(balance.AvailableBalance, ShouldEqual, 80)
+
+			So(rightsRepo.refunds, ShouldHaveLength, 1)
+			So(rightsRepo.refunds[0].RefundAmount, ShouldEqual, 200)
+			So(rightsRepo.refunds[0].OperatorID, ShouldEqual, 9)
+		})
+
+		Convey("扣除取消手续费的部分退款按退款比例退还权益", func() {
+			policy.CustomerCancelStatuses = []types.OrderStatusInt{types.OrderStatusIntPending, types.OrderStatusIntPaid}
+			policy.FeeType = types.CancellationFeePercentage
+			policy.FeeValue = 10
+
+			result, err := orderService.CancelOrder(customerCtx, 1, &types.CancelOrderRequest{})
+			So(err, ShouldBeNil)
+			So(result.RefundAmount, ShouldEqual, 180)
+			So(result.RightsRefunded, ShouldEqual, 45)
+			So(balance.UsedBalance, ShouldEqual, 25)
+		})
+
+		Convey("累计退还的权益不超过订单扣减的权益", func() {
+			_, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(err, ShouldBeNil)
+
+			result, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(err, ShouldBeNil)
+			So(result.RightsRefunded, ShouldEqual, 0)
+			So(balance.UsedBalance, ShouldEqual, 20)
+			So(rightsRepo.refunds, ShouldHaveLength, 1)
+		})
+
+		Convey("租户关闭权益退还时退款后权益仍计为已使用", func() {
+			refundPolicy = &types.OrderRightsRefundPolicy{Disabled: true}
+
+			result, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(err, ShouldBeNil)
+			So(result.Refunded, ShouldBeTrue)
+			So(result.RightsRefunded, ShouldEqual, 0)
+			So(balance.UsedBalance, ShouldEqual, 70)
+			So(rightsRepo.refunds, ShouldBeEmpty)
+		})
+
+		Convey("取消不退款时不退还权益", func() {
+			policy.RefundOnCancel = false
+
+			result, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(err, ShouldBeNil)
+			So(result.RightsRefunded, ShouldEqual, 0)
+			So(balance.UsedBalance, ShouldEqual, 70)
+		})
+
+		Convey("退款失败时不退还权益", func() {
+			refunder.err = errors.New("渠道不可用")
+
+			_, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(errors.Is(err, ErrOrderRefundFailed), ShouldBeTrue)
+			So(balance.UsedBalance, ShouldEqual, 70)
+		})
+
+		Convey("读取退还策略失败时取消仍然成功", func() {
+			orderService = NewOrderRightsRefundServiceForTest(orderRepo, &staticCancellationPolicyRepository{policy: policy}, refunder, rightsRepo,
+				func(ctx context.Context, tenantID uint64) (*types.OrderRightsRefundPolicy, error) {
+					return nil, errors.New("租户配置不可用")
+				})
+
+			result, err := orderService.CancelOrder(merchantCtx, 1, &types.CancelOrderRequest{OperatorType: types.OrderStatusOperatorTypeMerchant})
+			So(err, ShouldBeNil)
+			So(result.Refunded, ShouldBeTrue)
+			So(result.RightsRefunded, ShouldEqual, 0)
+		})
+	})
+
+	Convey("按退款比例计算退还的权益", t, func() {
+		So(types.RightsRefundAmount(50, 200, 200), ShouldEqual, 50)
+		So(types.RightsRefundAmount(50, 200, 180), ShouldEqual, 45)
+		So(types.RightsRefundAmount(10, 3, 1), ShouldEqual, 3.33)
+		So(types.RightsRefundAmount(50, 0, 10), ShouldEqual, 50)
+		So(types.RightsRefundAmount(50, 200, 0), ShouldEqual, 0)
+		So(types.RightsRefundAmount(0, 200, 200), ShouldEqual, 0)
+	})
+}
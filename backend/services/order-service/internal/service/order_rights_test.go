@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryRightsRepository 内存商户权益余额及订单权益冻结记录
+type memoryRightsRepository struct {
+	balances     map[uint64]*types.RightsBalance
+	reservations map[uint64]*types.OrderRightsReservation
+	refunds      []*types.OrderRightsRefund
+}
+
+func (m *memoryRightsRepository) Freeze(ctx context.Context, reservation *types.OrderRightsReservation) (*types.RightsBalance, error) {
+	balance := m.balances[reservation.MerchantID]
+	if balance == nil {
+		return nil, nil
+	}
+	before := *balance
+	if err := balance.Freeze(reservation.Amount); err != nil {
+		return &before, err
+	}
+	reservation.TenantID = ctx.Value("tenant_id").(uint64)
+	reservation.Status = types.OrderRightsReservationActive
+	m.reservations[reservation.OrderID] = reservation
+	return &before, nil
+}
+
+func (m *memoryRightsRepository) Settle(ctx context.Context, tenantID, orderID uint64, status types.OrderRightsReservationStatus) (*types.OrderRightsReservation, error) {
+	reservation, exists := m.reservations[orderID]
+	if !exists || reservation.TenantID != tenantID || reservation.Status != types.OrderRightsReservationActive {
+		return nil, nil
+	}
+	reservation.Status = status
+	if status == types.OrderRightsReservationConsumed {
+		m.balances[reservation.MerchantID].ConsumeFrozen(reservation.Amount)
+	} else {
+		m.balances[reservation.MerchantID].Unfreeze(reservation.Amount)
+	}
+	return reservation, nil
+}
+
+func (m *memoryRightsRepository) Restore(ctx context.Context, refund *types.OrderRightsRefund) (*types.OrderRightsRefund, error) {
+	reservation, exists := m.reservations[refund.OrderID]
+	if !exists || reservation.TenantID != refund.TenantID || reservation.Status != types.OrderRightsReservationConsumed {
+		return nil, nil
+	}
+	remaining := reservation.Amount
+	for _, restored := range m.refunds {
+		if restored.OrderID == refund.OrderID {
+			remaining -= restored.Amount
+		}
+	}
+	amount := m.balances[reservation.MerchantID].RestoreUsed(math.Min(refund.Amount, remaining))
+	if amount <= 0 {
+		return nil, nil
+	}
+	record := *refund
+	record.ID = uint64(len(m.refunds) + 1)
+	record.FundID = record.ID
+	record.Amount = amount
+	m.refunds = append(m.refunds, &record)
+	return &record, nil
+}
+
+// rightsCartRepository 下单后清空购物车的购物车仓储桩
+type rightsCartRepository struct {
+	repository.ICartRepository
+}
+
+func (f *rightsCartRepository) GetOrCreate(ctx context.Context, customerID uint64) (*types.Cart, error) {
+	return &types.Cart{ID: 1, CustomerID: customerID}, nil
+}
+
+func (f *rightsCartRepository) ClearCart(ctx context.Context, cartID uint64) error {
+	return nil
+}
+
+// createdNotificationService 忽略下单通知的通知服务桩
+type createdNotificationService struct {
+	NotificationService
+}
+
+func (s *createdNotificationService) SendOrderCreatedNotification(ctx context.Context, order *types.Order) error {
+	return nil
+}
+
+func TestOrderRights(t *testing.T) {
+	Convey("下单权益校验和冻结", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+		// 下单校验读取的商户余额快照：可用权益 80
+		merchantRepo := &staticMerchantRepository{merchant: &types.Merchant{ID: 1,
+			RightsBalance: &types.RightsBalance{TotalBalance: 100, UsedBalance: 20}}}
+		rightsRepo := &memoryRightsRepository{
+			balances:     map[uint64]*types.RightsBalance{1: {TotalBalance: 100, UsedBalance: 20}},
+			reservations: make(map[uint64]*types.OrderRightsReservation),
+		}
+		orderRepo := &batchOrderRepository{}
+		orderService := NewOrderRightsServiceForTest(orderRepo, &rightsCartRepository{}, merchantRepo, rightsRepo, &createdNotificationService{})
+
+		// 每件商品消耗 10 权益
+		newRequest := func(quantity int) *types.CreateOrderRequest {
+			req := &types.CreateOrderRequest{MerchantID: 1}
+			req.Items = append(req.Items, struct {
+				ProductID uint64 `json:"product_id" v:"required#商品ID不能为空"`
+				Quantity  int    `json:"quantity" v:"required|min:1#数量不能为空|数量必须大于0"`
+			}{ProductID: 1, Quantity: quantity})
+			return req
+		}
+
+		Convey("权益充足时创建订单并冻结权益", func() {
+			order, err := orderService.CreateOrder(ctx, 100, newRequest(5))
+			So(err, ShouldBeNil)
+			So(order.TotalRightsCost, ShouldEqual, 50)
+			So(rightsRepo.reservations[order.ID].Status, ShouldEqual, types.OrderRightsReservationActive)
+			So(rightsRepo.balances[1].FrozenBalance, ShouldEqual, 50)
+			So(rightsRepo.balances[1].AvailableBalance, ShouldEqual, 30)
+
+			confirmation, err := orderService.GetOrderConfirmation(ctx, 100, newRequest(8))
+			So(err, ShouldBeNil)
+			So(confirmation.AvailableRights, ShouldEqual, 80)
+			So(confirmation.CanCreate, ShouldBeTrue)
+		})
+
+		Convey("超出可用权益时拒绝下单", func() {
+			order, err := orderService.CreateOrder(ctx, 100, newRequest(9))
+			So(order, ShouldBeNil)
+			So(errors.Is(err, ErrOrderRightsInsufficient), ShouldBeTrue)
+
+			var limitErr *OrderLimitError
+			So(errors.As(err, &limitErr), ShouldBeTrue)
+			So(limitErr.Limit, ShouldEqual, 80)
+			So(limitErr.Actual, ShouldEqual, 90)
+			So(orderRepo.created, ShouldBeEmpty)
+			So(rightsRepo.balances[1].FrozenBalance, ShouldEqual, 0)
+		})
+
+		Convey("冻结时余额已被其他订单占用，取消刚创建的订单", func() {
+			So(rightsRepo.balances[1].Freeze(60), ShouldBeNil)
+
+			order, err := orderService.CreateOrder(ctx, 100, newRequest(5))
+			So(order, ShouldBeNil)
+			So(errors.Is(err, ErrOrderRightsInsufficient), ShouldBeTrue)
+
+			var limitErr *OrderLimitError
+			So(errors.As(err, &limitErr), ShouldBeTrue)
+			So(limitErr.Limit, ShouldEqual, 20)
+			So(orderRepo.created, ShouldHaveLength, 1)
+			So(orderRepo.cancelled, ShouldResemble, []uint64{1})
+			So(rightsRepo.reservations, ShouldBeEmpty)
+			So(rightsRepo.balances[1].FrozenBalance, ShouldEqual, 60)
+		})
+
+		Convey("订单取消或超时后退回冻结的权益", func() {
+			order, err := orderService.CreateOrder(ctx, 100, newRequest(5))
+			So(err, ShouldBeNil)
+			order.TenantID = 1
+
+			releaser := NewOrderResourceReleaser(&fakeReservationRepository{}, &recordingReservationReleaser{}, rightsRepo)
+			So(releaser.ReleaseOrderResources(ctx, order), ShouldBeNil)
+			So(rightsRepo.reservations[order.ID].Status, ShouldEqual, types.OrderRightsReservationReleased)
+			So(rightsRepo.balances[1].FrozenBalance, ShouldEqual, 0)
+			So(rightsRepo.balances[1].AvailableBalance, ShouldEqual, 80)
+
+			// 重复投递的取消事件不会重复退回
+			So(releaser.ReleaseOrderResources(ctx, order), ShouldBeNil)
+			So(rightsRepo.balances[1].FrozenBalance, ShouldEqual, 0)
+			So(rightsRepo.balances[1].AvailableBalance, ShouldEqual, 80)
+		})
+
+		Convey("支付后冻结的权益转为已使用，之后取消不再退回", func() {
+			order, err := orderService.CreateOrder(ctx, 100, newRequest(5))
+			So(err, ShouldBeNil)
+			order.TenantID = 1
+
+			paymentService := newPaymentService(&fakePaymentOrderRepository{orders: map[uint64]*types.Order{}}, &silentNotificationService{}, nil)
+			paymentService.rightsRepo = rightsRepo
+			So(paymentService.markOrderPaid(ctx, order, time.Now()), ShouldBeNil)
+			So(rightsRepo.reservations[order.ID].Status, ShouldEqual, types.OrderRightsReservationConsumed)
+			So(rightsRepo.balances[1].UsedBalance, ShouldEqual, 70)
+
+			releaser := NewOrderResourceReleaser(&fakeReservationRepository{}, &recordingReservationReleaser{}, rightsRepo)
+			So(releaser.ReleaseOrderResources(ctx, order), ShouldBeNil)
+			So(rightsRepo.balances[1].FrozenBalance, ShouldEqual, 0)
+			So(rightsRepo.balances[1].AvailableBalance, ShouldEqual, 30)
+		})
+
+		Convey("商户未开通权益余额时不校验也不冻结", func() {
+			merchantRepo.merchant.RightsBalance = nil
+			delete(rightsRepo.balances, 1)
+
+			order, err := orderService.CreateOrder(ctx, 100, newRequest(20))
+			So(err, ShouldBeNil)
+			So(rightsRepo.reservations, ShouldNotContainKey, order.ID)
+		})
+	})
+}
+
+func TestOrderRightsReserve(t *testing.T) {
+	Convey("下单不能占用商户保留权益", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+		// 可用权益 80，保留 30，普通订单可使用 50
+		reserve := 30.0
+		newBalance := func() *types.RightsBalance {
+			return &types.RightsBalance{TotalBalance: 100, UsedBalance: 20, ReserveAmount: &reserve}
+		}
+		merchantRepo := &staticMerchantRepository{merchant: &types.Merchant{ID: 1, RightsBalance: newBalance()}}
+		rightsRepo := &memoryRightsRepository{
+			balances:     map[uint64]*types.RightsBalance{1: newBalance()},
+			reservations: make(map[uint64]*types.OrderRightsReservation),
+		}
+		orderRepo := &batchOrderRepository{}
+		orderService := NewOrderRightsServiceForTest(orderRepo, &rightsCartRepository{}, merchantRepo, rightsRepo, &createdNotificationService{})
+
+		// 每件商品消耗 10 权益
+		newRequest := func(quantity int) *types.CreateOrderRequest {
+			req := &types.CreateOrderRequest{MerchantID: 1}
+			req.Items = append(req.Items, struct {
+				ProductID uint64 `json:"product_id" v:"required#商品ID不能为空"`
+				Quantity  int    `json:"quantity" v:"required|min:1#数量不能为空|数量必须大于0"`
+			}{ProductID: 1, Quantity: quantity})
+			return req
+		}
+
+		Convey("订单确认显示扣除保留权益后的可使用权益", func() {
+			confirmation, err := orderService.GetOrderConfirmation(ctx, 100, newRequest(6))
+			So(err, ShouldBeNil)
+			So(confirmation.AvailableRights, ShouldEqual, 50)
+			So(confirmation.CanCreate, ShouldBeFalse)
+		})
+
+		Convey("未达到保留权益下限时正常下单", func() {
+			order, err := orderService.CreateOrder(ctx, 100, newRequest(3))
+			So(err, ShouldBeNil)
+			So(rightsRepo.balances[1].FrozenBalance, ShouldEqual, 30)
+			So(rightsRepo.balances[1].GetConsumableBalance(), ShouldEqual, 20)
+			So(rightsRepo.reservations[order.ID].Amount, ShouldEqual, 30)
+		})
+
+		Convey("下单后恰好剩余保留权益时允许下单", func() {
+			_, err := orderService.CreateOrder(ctx, 100, newRequest(5))
+			So(err, ShouldBeNil)
+			So(rightsRepo.balances[1].AvailableBalance, ShouldEqual, reserve)
+			So(rightsRepo.balances[1].GetConsumableBalance(), ShouldEqual, 0)
+		})
+
+		Convey("占用保留权益时拒绝下单", func() {
+			order, err := orderService.CreateOrder(ctx, 100, newRequest(6))
+			So(order, ShouldBeNil)
+			So(errors.Is(err, ErrOrderRightsReserved), ShouldBeTrue)
+
+			var limitErr *OrderLimitError
+			So(errors.As(err, &limitErr), ShouldBeTrue)
+			So(limitErr.Limit, ShouldEqual, 50)
+			So(limitErr.Actual, ShouldEqual, 60)
+			So(orderRepo.created, ShouldBeEmpty)
+			So(rightsRepo.balances[1].FrozenBalance, ShouldEqual, 0)
+		})
+
+		Convey("冻结时可使用权益已被其他订单占用，取消刚创建的订单", func() {
+			So(rightsRepo.balances[1].Freeze(30), ShouldBeNil)
+
+			order, err := orderService.CreateOrder(ctx, 100, newRequest(3))
+			So(order, ShouldBeNil)
+			So(errors.Is(err, ErrOrderRightsReserved), ShouldBeTrue)
+
+			var limitErr *OrderLimitError
+			So(errors.As(err, &limitErr), ShouldBeTrue)
+			So(limitErr.Limit, ShouldEqual, 20)
+			So(orderRepo.cancelled, ShouldResemble, []uint64{1})
+			So(rightsRepo.balances[1].FrozenBalance, ShouldEqual, 30)
+		})
+	})
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// 默认的处理超时检查频率
+const defaultOrderSLACheckInterval = 5 * time.Minute
+
+// OrderSLAAlerter 订单处理超时提醒
+type OrderSLAAlerter interface {
+	SendOrderSLABreachNotification(ctx context.Context, breach *types.OrderSLABreach) error
+}
+
+// IOrderSLAService 订单状态处理时限服务接口
+type IOrderSLAService interface {
+	GetEffectiveConfig(ctx context.Context, merchantID uint64) (*types.OrderSLAConfig, error)
+	SaveConfig(ctx context.Context, config *types.OrderSLAConfig) error
+	// 列出当前租户超过当前状态处理时限的订单，商户用户只能查看本商户的订单
+	ListBreaches(ctx context.Context, merchantID *uint64, limit int) ([]types.OrderSLABreach, error)
+	// 计算订单在各状态停留的时间及当前状态是否超时
+	GetOrderSLA(ctx context.Context, orderID uint64) (*types.OrderSLAStatus, error)
+}
+
+// OrderSLAService 订单状态处理时限服务：跟踪订单在各状态停留的时间，超时只提醒人工处理，不会取消订单
+type OrderSLAService struct {
+	slaRepo   repository.IOrderSLARepository
+	orderRepo repository.IOrderRepository
+	alerter   OrderSLAAlerter
+	interval  time.Duration
+	now       func() time.Time
+	stopCh    chan struct{}
+	isRunning bool
+}
+
+// NewOrderSLAService 创建订单状态处理时限服务实例
+func NewOrderSLAService(alerter OrderSLAAlerter) *OrderSLAService {
+	interval := g.Cfg().MustGet(context.Background(), "order.sla.checkInterval", defaultOrderSLACheckInterval).Duration()
+	if interval <= 0 {
+		interval = defaultOrderSLACheckInterval
+	}
+	return &OrderSLAService{
+		slaRepo:   repository.NewOrderSLARepository(),
+		orderRepo: repository.NewOrderRepository(),
+		alerter:   alerter,
+		interval:  interval,
+		now:       time.Now,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// NewOrderSLAServiceForTest 创建测试用订单状态处理时限服务实例
+func NewOrderSLAServiceForTest(slaRepo repository.IOrderSLARepository, orderRepo repository.IOrderRepository, alerter OrderSLAAlerter, now func() time.Time) *OrderSLAService {
+	return &OrderSLAService{
+		slaRepo:   slaRepo,
+		orderRepo: orderRepo,
+		alerter:   alerter,
+		interval:  defaultOrderSLACheckInterval,
+		now:       now,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// GetEffectiveConfig 获取商户有效的处理时限配置
+func (s *OrderSLAService) GetEffectiveConfig(ctx context.Context, merchantID uint64) (*types.OrderSLAConfig, error) {
+	return s.slaRepo.GetEffectiveConfig(ctx, merchantID)
+}
+
+// SaveConfig 保存租户默认或商户级处理时限配置
+func (s *OrderSLAService) SaveConfig(ctx context.Context, config *types.OrderSLAConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	return s.slaRepo.Save(ctx, config)
+}
+
+// ListBreaches 列出当前租户超过处理时限的订单，按进入当前状态的时间从早到晚排序
+func (s *OrderSLAService) ListBreaches(ctx context.Context, merchantID *uint64, limit int) ([]types.OrderSLABreach, error) {
+	if limit <= 0 {
+		limit = types.DefaultOrderSLAListLimit
+	}
+	if limit > types.MaxOrderSLAListLimit {
+		limit = types.MaxOrderSLAListLimit
+	}
+	if scoped := gconv.Uint64(ctx.Value("merchant_id")); scoped > 0 {
+		merchantID = &scoped
+	}
+
+	breaches, _, err := s.findBreaches(ctx, gconv.Uint64(ctx.Value("tenant_id")), merchantID, limit)
+	return breaches, err
+}
+
+// GetOrderSLA 根据状态历史计算订单在各状态停留的时间，并检查当前状态是否超过处理时限
+func (s *OrderSLAService) GetOrderSLA(ctx context.Context, orderID uint64) (*types.OrderSLAStatus, error) {
+	order, err := s.orderRepo.GetByIDWithHistory(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if merchantID := gconv.Uint64(ctx.Value("merchant_id")); merchantID > 0 && order.MerchantID != merchantID {
+		return nil, fmt.Errorf("无权访问该订单")
+	}
+	current, ok := order.Status.ToOrderStatusInt()
+	if !ok {
+		return nil, fmt.Errorf("无效的订单状态: %s", order.Status)
+	}
+
+	config, err := s.slaRepo.GetEffectiveConfig(ctx, order.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	durations, enteredAt := types.ComputeOrderStatusDurations(order.CreatedAt, current, order.StatusHistory, now)
+	for i := range durations {
+		if limit, ok := config.Limit(durations[i].Status); ok {
+			durations[i].SLASeconds = int64(limit / time.Second)
+			durations[i].Breached = durations[i].Seconds > durations[i].SLASeconds
+		}
+	}
+
+	entry := &types.OrderStatusEntry{
+		TenantID:    order.TenantID,
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+		MerchantID:  order.MerchantID,
+		Status:      current,
+		EnteredAt:   enteredAt,
+	}
+	return &types.OrderSLAStatus{
+		OrderID:       order.ID,
+		CurrentStatus: current,
+		EnteredAt:     enteredAt,
+		Durations:     durations,
+		Breach:        entry.EvaluateSLA(config, now),
+	}, nil
+}
+
+// Start 启动后台处理超时检查，超时订单提醒商户人工处理
+func (s *OrderSLAService) Start(ctx context.Context) {
+	if s.isRunning {
+		return
+	}
+	s.isRunning = true
+	g.Log().Info(ctx, "启动订单处理超时检查", "interval", s.interval)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+				if _, err := s.CheckBreaches(ctx); err != nil {
+					g.Log().Error(ctx, "订单处理超时检查失败", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止后台处理超时检查
+func (s *OrderSLAService) Stop(ctx context.Context) {
+	if !s.isRunning {
+		return
+	}
+	s.isRunning = false
+	close(s.stopCh)
+	g.Log().Info(ctx, "订单处理超时检查已停止")
+}
+
+// CheckBreaches 检查所有租户的处理超时订单并发送提醒，每个订单每次进入超时状态只提醒一次；返回发送的提醒数
+func (s *OrderSLAService) CheckBreaches(ctx context.Context) (int, error) {
+	breaches, entries, err := s.findBreaches(ctx, 0, nil, types.MaxOrderSLAListLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	alerted := 0
+	for i := range breaches {
+		breach := &breaches[i]
+		// 定时任务没有请求上下文，按订单所属租户设置租户上下文
+		tenantCtx := context.WithValue(ctx, "tenant_id", breach.TenantID)
+
+		first, err := s.slaRepo.MarkAlerted(tenantCtx, &entries[i])
+		if err != nil {
+			g.Log().Error(ctx, "记录订单处理超时提醒失败", "order_id", breach.OrderID, "error", err)
+			continue
+		}
+		if !first {
+			continue
+		}
+
+		g.Log().Warning(ctx, "订单超过处理时限",
+			"tenant_id", breach.TenantID,
+			"merchant_id", breach.MerchantID,
+			"order_number", breach.OrderNumber,
+			"status", breach.Status,
+			"overdue_seconds", breach.OverdueSeconds)
+		if s.alerter != nil {
+			if err := s.alerter.SendOrderSLABreachNotification(tenantCtx, breach); err != nil {
+				g.Log().Error(ctx, "发送订单处理超时提醒失败", "order_id", breach.OrderID, "error", err)
+			}
+		}
+		alerted++
+	}
+	return alerted, nil
+}
+
+// findBreaches 查询超时订单，返回超时信息及对应的订单状态记录（下标一一对应）。
+// 未限定商户时先按各配置中最短的时限筛选候选订单，再按订单所属商户的有效配置判断是否超时
+func (s *OrderSLAService) findBreaches(ctx context.Context, tenantID uint64, merchantID *uint64, limit int) ([]types.OrderSLABreach, []types.OrderStatusEntry, error) {
+	configs := make(map[[2]uint64]*types.OrderSLAConfig)
+	var limits map[types.OrderStatusInt]time.Duration
+	if merchantID != nil {
+		config, err := s.slaRepo.GetEffectiveConfig(ctx, *merchantID)
+		if err != nil {
+			return nil, nil, err
+		}
+		configs[[2]uint64{tenantID, *merchantID}] = config
+		limits = config.Limits()
+	} else {
+		saved, err := s.slaRepo.ListConfigs(ctx, tenantID)
+		if err != nil {
+			return nil, nil, err
+		}
+		limits = minimumOrderSLALimits(types.DefaultOrderSLAConfig(tenantID), saved)
+	}
+
+	now := s.now()
+	entries, err := s.slaRepo.ListBreachEntries(ctx, &repository.OrderSLAEntryFilter{
+		TenantID:   tenantID,
+		MerchantID: merchantID,
+		Limits:     limits,
+		Now:        now,
+		Limit:      limit,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	breaches := make([]types.OrderSLABreach, 0, len(entries))
+	breachEntries := make([]types.OrderStatusEntry, 0, len(entries))
+	for _, entry := range entries {
+		key := [2]uint64{entry.TenantID, entry.MerchantID}
+		config, ok := configs[key]
+		if !ok {
+			tenantCtx := context.WithValue(ctx, "tenant_id", entry.TenantID)
+			config, err = s.slaRepo.GetEffectiveConfig(tenantCtx, entry.MerchantID)
+			if err != nil {
+				return nil, nil, err
+			}
+			configs[key] = config
+		}
+
+		if breach := entry.EvaluateSLA(config, now); breach != nil {
+			breaches = append(breaches, *breach)
+			breachEntries = append(breachEntries, entry)
+		}
+	}
+	return breaches, breachEntries, nil
+}
+
+// minimumOrderSLALimits 合并默认配置和已保存配置，取各状态最短的处理时限
+func minimumOrderSLALimits(defaults *types.OrderSLAConfig, configs []types.OrderSLAConfig) map[types.OrderStatusInt]time.Duration {
+	limits := defaults.Limits()
+	for i := range configs {
+		for status, limit := range configs[i].Limits() {
+			if current, ok := limits[status]; !ok || limit < current {
+				limits[status] = limit
+			}
+		}
+	}
+	return limits
+}
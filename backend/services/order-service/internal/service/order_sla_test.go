@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryOrderSLARepository 内存实现的处理时限仓储，按过滤条件模拟超时订单查询
+type memoryOrderSLARepository struct {
+	configs map[uint64]*types.OrderSLAConfig // 按商户保存的配置，键为0表示租户默认配置
+	entries []types.OrderStatusEntry
+	alerted map[string]bool
+	filters []*repository.OrderSLAEntryFilter
+}
+
+func newMemoryOrderSLARepository() *memoryOrderSLARepository {
+	return &memoryOrderSLARepository{
+		configs: make(map[uint64]*types.OrderSLAConfig),
+		alerted: make(map[string]bool),
+	}
+}
+
+func (r *memoryOrderSLARepository) GetEffectiveConfig(ctx context.Context, merchantID uint64) (*types.OrderSLAConfig, error) {
+	if config, ok := r.configs[merchantID]; ok {
+		return config, nil
+	}
+	if config, ok := r.configs[0]; ok {
+		return config, nil
+	}
+	return types.DefaultOrderSLAConfig(gconv.Uint64(ctx.Value("tenant_id"))), nil
+}
+
+func (r *memoryOrderSLARepository) ListConfigs(ctx context.Context, tenantID uint64) ([]types.OrderSLAConfig, error) {
+	configs := make([]types.OrderSLAConfig, 0, len(r.configs))
+	for _, config := range r.configs {
+		configs = append(configs, *config)
+	}
+	return configs, nil
+}
+
+func (r *memoryOrderSLARepository) Save(ctx context.Context, config *types.OrderSLAConfig) error {
+	var merchantID uint64
+	if config.MerchantID != nil {
+		merchantID = *config.MerchantID
+	}
+	r.configs[merchantID] = config
+	return nil
+}
+
+func (r *memoryOrderSLARepository) ListBreachEntries(ctx context.Context, filter *repository.OrderSLAEntryFilter) ([]types.OrderStatusEntry, error) {
+	r.filters = append(r.filters, filter)
+	entries := []types.OrderStatusEntry{}
+	for _, entry := range r.entries {
+		if filter.TenantID > 0 && entry.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.MerchantID != nil && entry.MerchantID != *filter.MerchantID {
+			continue
+		}
+		limit, ok := filter.Limits[entry.Status]
+		if !ok || !entry.EnteredAt.Before(filter.Now.Add(-limit)) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (r *memoryOrderSLARepository) MarkAlerted(ctx context.Context, entry *types.OrderStatusEntry) (bool, error) {
+	key := fmt.Sprintf("%d:%d:%d", entry.OrderID, entry.Status, entry.EnteredAt.Unix())
+	if r.alerted[key] {
+		return false, nil
+	}
+	r.alerted[key] = true
+	return true, nil
+}
+
+// historyOrderRepository 返回带状态历史订单的订单仓储桩
+type historyOrderRepository struct {
+	repository.IOrderRepository
+	order *types.Order
+}
+
+func (f *historyOrderRepository) GetByIDWithHistory(ctx context.Context, id uint64) (*types.Order, error) {
+	return f.order, nil
+}
+
+// recordingSLAAlerter 记录处理超时提醒的提醒桩
+type recordingSLAAlerter struct {
+	breaches []types.OrderSLABreach
+}
+
+func (a *recordingSLAAlerter) SendOrderSLABreachNotification(ctx context.Context, breach *types.OrderSLABreach) error {
+	a.breaches = append(a.breaches, *breach)
+	return nil
+}
+
+func TestOrderSLA(t *testing.T) {
+	Convey("订单状态处理时限测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)
+		hoursAgo := func(hours int) time.Time { return now.Add(-time.Duration(hours) * time.Hour) }
+
+		slaRepo := newMemoryOrderSLARepository()
+		slaRepo.entries = []types.OrderStatusEntry{
+			// 已支付30小时未处理，超过默认24小时时限
+			{TenantID: 1, OrderID: 1, OrderNumber: "ORD001", MerchantID: 1, Status: types.OrderStatusIntPaid, EnteredAt: hoursAgo(30)},
+			// 已支付2小时，未超时
+			{TenantID: 1, OrderID: 2, OrderNumber: "ORD002", MerchantID: 1, Status: types.OrderStatusIntPaid, EnteredAt: hoursAgo(2)},
+			// 处理中50小时，超过默认48小时时限
+			{TenantID: 1, OrderID: 3, OrderNumber: "ORD003", MerchantID: 2, Status: types.OrderStatusIntProcessing, EnteredAt: hoursAgo(50)},
+			// 待支付订单默认不跟踪
+			{TenantID: 1, OrderID: 4, OrderNumber: "ORD004", MerchantID: 1, Status: types.OrderStatusIntPending, EnteredAt: hoursAgo(100)},
+		}
+		orderRepo := &historyOrderRepository{}
+		alerter := &recordingSLAAlerter{}
+		slaService := NewOrderSLAServiceForTest(slaRepo, orderRepo, alerter, func() time.Time { return now })
+
+		Convey("只列出超过当前状态处理时限的订单", func() {
+			breaches, err := slaService.ListBreaches(ctx, nil, 0)
+			So(err, ShouldBeNil)
+			So(len(breaches), ShouldEqual, 2)
+			So(breaches[0].OrderNumber, ShouldEqual, "ORD001")
+			So(breaches[0].SLASeconds, ShouldEqual, 24*3600)
+			So(breaches[0].OverdueSeconds, ShouldEqual, 6*3600)
+			So(breaches[1].OrderNumber, ShouldEqual, "ORD003")
+			So(breaches[1].Status, ShouldEqual, types.OrderStatusIntProcessing)
+		})
+
+		Convey("商户级配置优先于默认配置", func() {
+			merchantID := uint64(1)
+			slaRepo.configs[1] = &types.OrderSLAConfig{
+				TenantID:   1,
+				MerchantID: &merchantID,
+				Statuses: []types.OrderStatusSLA{
+					{Status: types.OrderStatusIntPaid, Minutes: 60},
+					{Status: types.OrderStatusIntPending, Minutes: 48 * 60},
+				},
+			}
+
+			breaches, err := slaService.ListBreaches(ctx, nil, 0)
+			So(err, ShouldBeNil)
+			numbers := make([]string, 0, len(breaches))
+			for _, breach := range breaches {
+				numbers = append(numbers, breach.OrderNumber)
+			}
+			So(numbers, ShouldResemble, []string{"ORD001", "ORD002", "ORD003", "ORD004"})
+			So(slaRepo.filters[0].Limits[types.OrderStatusIntPaid], ShouldEqual, time.Hour)
+
+			Convey("其他商户仍按默认配置判断", func() {
+				slaRepo.entries = append(slaRepo.entries, types.OrderStatusEntry{
+					TenantID: 1, OrderID: 5, OrderNumber: "ORD005", MerchantID: 2, Status: types.OrderStatusIntPaid, EnteredAt: hoursAgo(3),
+				})
+				breaches, err := slaService.ListBreaches(ctx, nil, 0)
+				So(err, ShouldBeNil)
+				for _, breach := range breaches {
+					So(breach.OrderNumber, ShouldNotEqual, "ORD005")
+				}
+			})
+		})
+
+		Convey("商户用户只能查看本商户的超时订单", func() {
+			merchantCtx := context.WithValue(ctx, "merchant_id", uint64(2))
+			other := uint64(1)
+
+			breaches, err := slaService.ListBreaches(merchantCtx, &other, 0)
+			So(err, ShouldBeNil)
+			So(len(breaches), ShouldEqual, 1)
+			So(breaches[0].OrderNumber, ShouldEqual, "ORD003")
+		})
+
+		Convey("超时订单只提醒一次，未超时订单不提醒", func() {
+			alerted, err := slaService.CheckBreaches(context.Background())
+			So(err, ShouldBeNil)
+			So(alerted, ShouldEqual, 2)
+			So(len(alerter.breaches), ShouldEqual, 2)
+			So(alerter.breaches[0].OrderID, ShouldEqual, 1)
+			So(alerter.breaches[1].OrderID, ShouldEqual, 3)
+			So(slaRepo.filters[0].TenantID, ShouldEqual, 0)
+
+			alerted, err = slaService.CheckBreaches(context.Background())
+			So(err, ShouldBeNil)
+			So(alerted, ShouldEqual, 0)
+			So(len(alerter.breaches), ShouldEqual, 2)
+		})
+
+		Convey("根据状态历史计算各状态停留时间", func() {
+			orderRepo.order = &types.Order{
+				ID:          6,
+				TenantID:    1,
+				MerchantID:  1,
+				OrderNumber: "ORD006",
+				Status:      types.OrderStatusProcessing,
+				CreatedAt:   hoursAgo(80),
+				StatusHistory: []types.OrderStatusHistory{
+					{FromStatus: types.OrderStatusIntPaid, ToStatus: types.OrderStatusIntProcessing, CreatedAt: hoursAgo(50)},
+					{FromStatus: types.OrderStatusIntPending, ToStatus: types.OrderStatusIntPaid, CreatedAt: hoursAgo(79)},
+				},
+			}
+
+			status, err := slaService.GetOrderSLA(ctx, 6)
+			So(err, ShouldBeNil)
+			So(status.CurrentStatus, ShouldEqual, types.OrderStatusIntProcessing)
+			So(status.EnteredAt, ShouldEqual, hoursAgo(50))
+			So(status.Durations, ShouldResemble, []types.OrderStatusDuration{
+				{Status: types.OrderStatusIntPending, Seconds: 3600},
+				{Status: types.OrderStatusIntPaid, Seconds: 29 * 3600, SLASeconds: 24 * 3600, Breached: true},
+				{Status: types.OrderStatusIntProcessing, Seconds: 50 * 3600, SLASeconds: 48 * 3600, Breached: true},
+			})
+			So(status.Breach, ShouldNotBeNil)
+			So(status.Breach.OverdueSeconds, ShouldEqual, 2*3600)
+
+			Convey("当前状态未超时时没有超时信息", func() {
+				orderRepo.order.StatusHistory[0].CreatedAt = hoursAgo(10)
+
+				status, err := slaService.GetOrderSLA(ctx, 6)
+				So(err, ShouldBeNil)
+				So(status.Breach, ShouldBeNil)
+				So(status.Durations[2].Breached, ShouldBeFalse)
+			})
+
+			Convey("商户用户不能查看其他商户的订单", func() {
+				_, err := slaService.GetOrderSLA(context.WithValue(ctx, "merchant_id", uint64(2)), 6)
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("无效的处理时限配置被拒绝", func() {
+			err := slaService.SaveConfig(ctx, &types.OrderSLAConfig{
+				Statuses: []types.OrderStatusSLA{{Status: types.OrderStatusIntCompleted, Minutes: 60}},
+			})
+			So(err, ShouldNotBeNil)
+
+			err = slaService.SaveConfig(ctx, &types.OrderSLAConfig{
+				Statuses: []types.OrderStatusSLA{{Status: types.OrderStatusIntPaid, Minutes: 0}},
+			})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
@@ -17,11 +18,14 @@ type IOrderStatusService interface {
 	ValidateStatusTransition(ctx context.Context, orderID uint64, toStatus types.OrderStatusInt) error
 }
 
+// defaultStatusConflictRetries 状态变更被并发请求抢先时的默认重试次数
+const defaultStatusConflictRetries = 1
+
 // OrderStatusService 订单状态管理服务实现
 type OrderStatusService struct {
 	orderRepo         repository.IOrderRepository
 	statusHistoryRepo *repository.OrderStatusHistoryRepository
-	notificationService NotificationService
+	conflictRetries   int // 状态变更被并发请求抢先时重新校验并重试的次数
 }
 
 // NewOrderStatusService 创建订单状态管理服务实例
@@ -29,7 +33,15 @@ func NewOrderStatusService() IOrderStatusService {
 	return &OrderStatusService{
 		orderRepo:         repository.NewOrderRepository(),
 		statusHistoryRepo: repository.NewOrderStatusHistoryRepository(),
-		notificationService: NewNotificationService(),
+		conflictRetries:   g.Cfg().MustGet(context.Background(), "order.status.conflictRetries", defaultStatusConflictRetries).Int(),
+	}
+}
+
+// NewOrderStatusServiceForTest 创建测试用订单状态管理服务实例
+func NewOrderStatusServiceForTest(orderRepo repository.IOrderRepository) IOrderStatusService {
+	return &OrderStatusService{
+		orderRepo:       orderRepo,
+		conflictRetries: defaultStatusConflictRetries,
 	}
 }
 
@@ -53,51 +65,9 @@ func (s *OrderStatusService) UpdateOrderStatus(ctx context.Context, orderID uint
 		return fmt.Errorf("非系统操作必须提供操作员ID")
 	}
 	
-	// 获取更新前的订单信息以便发送通知
-	order, err := s.orderRepo.GetByID(ctx, orderID)
-	if err != nil {
-		return fmt.Errorf("获取订单信息失败: %v", err)
-	}
-	
-	// 更新订单状态并记录历史
-	err = s.orderRepo.UpdateStatusWithHistory(
-		ctx,
-		orderID,
-		req.Status,
-		req.Reason,
-		req.OperatorType,
-		operatorID,
-		req.Metadata,
-	)
-	if err != nil {
-		return fmt.Errorf("更新订单状态失败: %v", err)
-	}
-	
-	// 获取更新后的订单信息
-	updatedOrder, err := s.orderRepo.GetByID(ctx, orderID)
-	if err != nil {
-		g.Log().Errorf(ctx, "获取更新后的订单信息失败: %v", err)
-		// 不返回错误，因为状态已经更新成功
-	} else {
-		// 发送状态变更通知（异步执行，不阻塞主流程）
-		go func() {
-			// 创建带有超时的上下文，防止goroutine泄露
-			notifyCtx := context.WithValue(context.Background(), "tenant_id", ctx.Value("tenant_id"))
-			notifyCtx = context.WithValue(notifyCtx, "user_id", ctx.Value("user_id"))
-			
-			// 创建状态历史记录用于通知
-			statusHistory := &types.OrderStatusHistory{
-				OrderID:      orderID,
-				FromStatus:   s.orderStatusToInt(order.Status),
-				ToStatus:     req.Status,
-				Reason:       req.Reason,
-				OperatorType: req.OperatorType,
-			}
-			
-			if err := s.notificationService.SendOrderStatusChangedNotification(notifyCtx, updatedOrder, statusHistory); err != nil {
-				g.Log().Errorf(notifyCtx, "发送订单状态变更通知失败: %v", err)
-			}
-		}()
+	// 更新订单状态并记录历史，状态变更事件在同一事务中写入发件箱，由发件箱投递器发送通知
+	if err := s.updateStatusWithRetry(ctx, orderID, req, operatorID); err != nil {
+		return fmt.Errorf("更新订单状态失败: %w", err)
 	}
 	
 	g.Log().Infof(ctx, "订单状态更新成功: orderID=%d, status=%s, operator=%s", 
@@ -106,6 +76,19 @@ func (s *OrderStatusService) UpdateOrderStatus(ctx context.Context, orderID uint
 	return nil
 }
 
+// updateStatusWithRetry 更新订单状态，被并发的状态变更抢先时重新读取状态校验后重试。
+// 重新校验不通过时返回状态不允许转换的错误，重试次数用完仍冲突时返回 types.ErrOrderStatusConflict
+func (s *OrderStatusService) updateStatusWithRetry(ctx context.Context, orderID uint64, req *types.UpdateOrderStatusRequest, operatorID *uint64) error {
+	for attempt := 0; ; attempt++ {
+		err := s.orderRepo.UpdateStatusWithHistory(ctx, orderID, req.Status, req.Reason, req.OperatorType, operatorID, req.Metadata)
+		if !errors.Is(err, types.ErrOrderStatusConflict) || attempt >= s.conflictRetries {
+			return err
+		}
+		g.Log().Warningf(ctx, "订单状态已被并发修改，重新校验后重试: orderID=%d, status=%s, attempt=%d",
+			orderID, req.Status.String(), attempt+1)
+	}
+}
+
 // BatchUpdateOrderStatus 批量更新订单状态
 func (s *OrderStatusService) BatchUpdateOrderStatus(ctx context.Context, req *types.BatchUpdateOrderStatusRequest) (*types.BatchUpdateOrderStatusResponse, error) {
 	// 验证操作员类型
@@ -140,38 +123,7 @@ func (s *OrderStatusService) BatchUpdateOrderStatus(ctx context.Context, req *ty
 		return nil, fmt.Errorf("批量更新订单状态失败: %v", err)
 	}
 	
-	// 为成功更新的订单发送通知（异步执行）
-	if response.SuccessCount > 0 {
-		go func() {
-			// 创建带有超时的上下文，防止goroutine泄露
-			notifyCtx := context.WithValue(context.Background(), "tenant_id", ctx.Value("tenant_id"))
-			notifyCtx = context.WithValue(notifyCtx, "user_id", ctx.Value("user_id"))
-			
-			// 这里简化处理，因为响应中没有具体的成功订单ID列表
-			// 在实际实现中，Repository应该返回成功的订单ID列表
-			for _, orderID := range req.OrderIDs {
-				// 获取更新后的订单信息
-				order, err := s.orderRepo.GetByID(notifyCtx, orderID)
-				if err != nil {
-					g.Log().Errorf(notifyCtx, "获取订单信息失败，跳过通知发送: orderID=%d, error=%v", orderID, err)
-					continue
-				}
-				
-				// 创建状态历史记录用于通知
-				statusHistory := &types.OrderStatusHistory{
-					OrderID:      orderID,
-					FromStatus:   types.OrderStatusIntPaid, // 批量操作通常从paid状态开始
-					ToStatus:     req.Status,
-					Reason:       req.Reason,
-					OperatorType: req.OperatorType,
-				}
-				
-				if err := s.notificationService.SendOrderStatusChangedNotification(notifyCtx, order, statusHistory); err != nil {
-					g.Log().Errorf(notifyCtx, "发送批量订单状态变更通知失败: orderID=%d, error=%v", orderID, err)
-				}
-			}
-		}()
-	}
+	// 成功更新的订单已各自写入发件箱事件，由发件箱投递器发送通知
 	
 	g.Log().Infof(ctx, "批量更新订单状态完成: success=%d, fail=%d, operator=%s", 
 		response.SuccessCount, response.FailCount, req.OperatorType.String())
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// customerNotificationLocale 客户通知使用的区域，与通知模板一致
+const customerNotificationLocale = "zh-CN"
+
+// NewOrderStatusLabelServiceForTest 创建测试用订单服务实例（用于面向客户的订单状态显示）
+func NewOrderStatusLabelServiceForTest(orderRepo repository.IOrderRepository, tenantRepo repository.ITenantRepository) IOrderService {
+	return &OrderService{
+		orderRepo:    orderRepo,
+		statusLabels: repository.NewTenantOrderStatusLabelProvider(tenantRepo),
+	}
+}
+
+// LabelOrderStatuses 按租户配置为客户查询的订单填充状态显示名称和说明，订单的 status 保持内部状态不变
+func (s *OrderService) LabelOrderStatuses(ctx context.Context, locale string, orders ...*types.Order) {
+	policy := s.statusLabels.Resolve(ctx, gconv.Uint64(ctx.Value("tenant_id")))
+	for _, order := range orders {
+		label := customerOrderStatusLabel(policy, order.Status, locale)
+		order.StatusDisplay = &label
+	}
+}
+
+// customerOrderStatusLabel 获取订单状态面向客户的显示，租户未配置显示名称时使用内置中文名称
+func customerOrderStatusLabel(policy *types.OrderStatusLabelPolicy, status types.OrderStatus, locale string) types.OrderStatusLabel {
+	label := types.OrderStatusLabel{Label: orderStatusDisplayName(status)}
+	if custom, ok := policy.Lookup(status, locale); ok {
+		if name := strings.TrimSpace(custom.Label); name != "" {
+			label.Label = name
+		}
+		label.Message = strings.TrimSpace(custom.Message)
+	}
+	return label
+}
+
+// orderStatusDisplayName 订单状态的内置中文名称
+func orderStatusDisplayName(status types.OrderStatus) string {
+	switch status {
+	case types.OrderStatusPending:
+		return "待支付"
+	case types.OrderStatusPaid:
+		return "已支付"
+	case types.OrderStatusProcessing:
+		return "处理中"
+	case types.OrderStatusCompleted:
+		return "已完成"
+	case types.OrderStatusCancelled:
+		return "已取消"
+	case types.OrderStatusRefunded:
+		return "已退款"
+	default:
+		return "未知状态"
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOrderStatusLabels(t *testing.T) {
+	Convey("面向客户的订单状态显示测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		tenantRepo := &brandingTenantRepository{config: types.TenantConfig{
+			OrderStatusLabels: &types.OrderStatusLabelPolicy{
+				Labels: map[types.OrderStatus]types.OrderStatusLabel{
+					types.OrderStatusPaid:       {Label: "已付款", Message: "商家正在备货，请耐心等待。"},
+					types.OrderStatusProcessing: {Label: "已发货", Message: "包裹已在路上，请留意物流信息。"},
+					types.OrderStatusRefunded:   {Message: "退款将在 1-3 个工作日内原路退回。"},
+				},
+				Locales: map[string]map[types.OrderStatus]types.OrderStatusLabel{
+					"en-US": {types.OrderStatusProcessing: {Label: "Shipped", Message: "Your parcel is on its way."}},
+				},
+			},
+		}}
+
+		Convey("客户查询订单时填充自定义状态显示，status 保持内部状态", func() {
+			orderService := NewOrderStatusLabelServiceForTest(&batchOrderRepository{}, tenantRepo)
+			processing := &types.Order{ID: 1, TenantID: 1, Status: types.OrderStatusProcessing}
+			completed := &types.Order{ID: 2, TenantID: 1, Status: types.OrderStatusCompleted}
+			refunded := &types.Order{ID: 3, TenantID: 1, Status: types.OrderStatusRefunded}
+
+			orderService.LabelOrderStatuses(ctx, "", processing, completed, refunded)
+			So(processing.Status, ShouldEqual, types.OrderStatusProcessing)
+			So(processing.StatusDisplay.Label, ShouldEqual, "已发货")
+			So(processing.StatusDisplay.Message, ShouldEqual, "包裹已在路上，请留意物流信息。")
+
+			// 未配置的状态和只配置说明的状态使用内置中文名称
+			So(completed.StatusDisplay.Label, ShouldEqual, "已完成")
+			So(completed.StatusDisplay.Message, ShouldBeEmpty)
+			So(refunded.StatusDisplay.Label, ShouldEqual, "已退款")
+			So(refunded.StatusDisplay.Message, ShouldEqual, "退款将在 1-3 个工作日内原路退回。")
+
+			data, err := json.Marshal(processing)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldContainSubstring, `"status":"processing"`)
+			So(string(data), ShouldContainSubstring, `"status_display":{"label":"已发货"`)
+		})
+
+		Convey("按区域使用对应的状态显示，区域未配置的状态使用通用配置", func() {
+			orderService := NewOrderStatusLabelServiceForTest(&batchOrderRepository{}, tenantRepo)
+			processing := &types.Order{ID: 1, TenantID: 1, Status: types.OrderStatusProcessing}
+			paid := &types.Order{ID: 2, TenantID: 1, Status: types.OrderStatusPaid}
+
+			orderService.LabelOrderStatuses(ctx, "en-US", processing, paid)
+			So(processing.StatusDisplay.Label, ShouldEqual, "Shipped")
+			So(paid.StatusDisplay.Label, ShouldEqual, "已付款")
+		})
+
+		Convey("租户未配置时使用内置中文名称", func() {
+			orderService := NewOrderStatusLabelServiceForTest(&batchOrderRepository{}, &brandingTenantRepository{})
+			order := &types.Order{ID: 1, TenantID: 1, Status: types.OrderStatusPaid}
+
+			orderService.LabelOrderStatuses(ctx, "", order)
+			So(order.StatusDisplay.Label, ShouldEqual, "已支付")
+			So(order.StatusDisplay.Message, ShouldBeEmpty)
+		})
+
+		Convey("客户状态变更通知使用自定义状态名称和说明", func() {
+			sms := &recordingSMSService{}
+			email := &contentRecordingEmailService{}
+			labeled := NewLabeledNotificationServiceForTest(sms, email, repository.NewTenantOrderStatusLabelProvider(tenantRepo))
+
+			order := &types.Order{ID: 1, TenantID: 1, CustomerID: 100, OrderNumber: "ORD20261015004", TotalAmount: 88}
+			history := &types.OrderStatusHistory{
+				FromStatus: types.OrderStatusIntPending,
+				ToStatus:   types.OrderStatusIntPaid,
+				CreatedAt:  time.Now(),
+			}
+			So(labeled.(*notificationService).sendGenericStatusChangeNotification(context.Background(), order, history), ShouldBeNil)
+
+			So(sms.contents, ShouldHaveLength, 1)
+			So(sms.contents[0], ShouldEqual, "【商户系统】您的订单 ORD20261015004 状态已从 待支付 变更为 已付款。商家正在备货，请耐心等待。")
+			So(email.contents, ShouldHaveLength, 1)
+			So(email.contents[0], ShouldContainSubstring, "状态变更：待支付 → 已付款")
+			So(email.contents[0], ShouldContainSubstring, "商家正在备货，请耐心等待。")
+
+			Convey("订单处理中通知附带自定义说明", func() {
+				So(labeled.SendOrderProcessingNotification(context.Background(), order), ShouldBeNil)
+				So(sms.contents, ShouldHaveLength, 2)
+				So(strings.HasSuffix(sms.contents[1], "包裹已在路上，请留意物流信息。"), ShouldBeTrue)
+			})
+		})
+
+		Convey("商户端通知始终使用内置状态名称", func() {
+			labeled := NewLabeledNotificationServiceForTest(&recordingSMSService{}, &contentRecordingEmailService{}, repository.NewTenantOrderStatusLabelProvider(tenantRepo)).(*notificationService)
+
+			So(labeled.getOrderStatusDisplayName(types.OrderStatusProcessing), ShouldEqual, "处理中")
+			So(labeled.getOrderStatusDisplayName(types.OrderStatusRefunded), ShouldEqual, "已退款")
+		})
+
+		Convey("校验状态显示配置", func() {
+			So(tenantRepo.config.OrderStatusLabels.Validate(), ShouldBeNil)
+
+			invalid := &types.OrderStatusLabelPolicy{Labels: map[types.OrderStatus]types.OrderStatusLabel{"shipped": {Label: "已发货"}}}
+			So(invalid.Validate(), ShouldNotBeNil)
+
+			tooLong := &types.OrderStatusLabelPolicy{Locales: map[string]map[types.OrderStatus]types.OrderStatusLabel{
+				"en-US": {types.OrderStatusPaid: {Label: strings.Repeat("a", types.MaxOrderStatusLabelLength+1)}},
+			}}
+			So(tooLong.Validate(), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// guardedStatusOrderRepository 按状态条件更新的订单仓储桩，与数据库实现一样先读取状态校验，
+// 再以“状态仍为读取时的状态”为条件更新并写入历史。前 racers 次读取会互相等待，
+// 模拟并发请求都在对方更新前读到相同状态
+type guardedStatusOrderRepository struct {
+	repository.IOrderRepository
+	mutex   sync.Mutex
+	order   *types.Order
+	history []types.OrderStatusHistory
+
+	reads   int
+	racers  int
+	barrier sync.WaitGroup
+}
+
+func newGuardedStatusOrderRepository(status types.OrderStatus, racers int) *guardedStatusOrderRepository {
+	repo := &guardedStatusOrderRepository{
+		order:  &types.Order{ID: 1, TenantID: 1, Status: status},
+		racers: racers,
+	}
+	repo.barrier.Add(racers)
+	return repo
+}
+
+func (f *guardedStatusOrderRepository) UpdateStatusWithHistory(ctx context.Context, id uint64, status types.OrderStatusInt, reason string, operatorType types.OrderStatusOperatorType, operatorID *uint64, metadata interface{}) error {
+	f.mutex.Lock()
+	current := f.order.Status
+	f.reads++
+	racing := f.reads <= f.racers
+	f.mutex.Unlock()
+	if racing {
+		f.barrier.Done()
+		f.barrier.Wait()
+	}
+
+	currentInt, _ := current.ToOrderStatusInt()
+	if !currentInt.IsValidTransition(status) {
+		return fmt.Errorf("不允许从状态 %s 转换到 %s", currentInt.String(), status.String())
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.order.Status != current {
+		return fmt.Errorf("%w: 订单 %d 已不是 %s 状态", types.ErrOrderStatusConflict, id, currentInt.String())
+	}
+	f.order.Status = status.ToOrderStatus()
+	f.history = append(f.history, types.OrderStatusHistory{
+		OrderID:      id,
+		FromStatus:   currentInt,
+		ToStatus:     status,
+		Reason:       reason,
+		OperatorType: operatorType,
+	})
+	return nil
+}
+
+// concurrentStatusUpdates 同时提交多个状态变更，返回各自的结果
+func concurrentStatusUpdates(statusService IOrderStatusService, targets ...types.OrderStatusInt) []error {
+	ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+	ctx = context.WithValue(ctx, "user_id", uint64(7))
+
+	results := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target types.OrderStatusInt) {
+			defer wg.Done()
+			results[i] = statusService.UpdateOrderStatus(ctx, 1, &types.UpdateOrderStatusRequest{
+				Status:       target,
+				Reason:       "并发状态变更",
+				OperatorType: types.OrderStatusOperatorTypeMerchant,
+			})
+		}(i, target)
+	}
+	wg.Wait()
+	return results
+}
+
+func TestConcurrentOrderStatusTransitions(t *testing.T) {
+	Convey("并发状态变更测试", t, func() {
+		Convey("完成和取消同时提交时只有一个生效，只写入一条历史", func() {
+			orderRepo := newGuardedStatusOrderRepository(types.OrderStatusProcessing, 2)
+			results := concurrentStatusUpdates(NewOrderStatusServiceForTest(orderRepo),
+				types.OrderStatusIntCompleted, types.OrderStatusIntCancelled)
+
+			succeeded := 0
+			for _, err := range results {
+				if err == nil {
+					succeeded++
+					continue
+				}
+				// 失败方重新读取到终态后校验不通过，不会覆盖胜出方的状态
+				So(err.Error(), ShouldContainSubstring, "不允许从状态")
+			}
+			So(succeeded, ShouldEqual, 1)
+			So(orderRepo.history, ShouldHaveLength, 1)
+			So(orderRepo.history[0].FromStatus, ShouldEqual, types.OrderStatusIntProcessing)
+			So(orderRepo.order.Status, ShouldEqual, orderRepo.history[0].ToStatus.ToOrderStatus())
+		})
+
+		Convey("重复提交同一状态变更时只生效一次", func() {
+			orderRepo := newGuardedStatusOrderRepository(types.OrderStatusPaid, 2)
+			results := concurrentStatusUpdates(NewOrderStatusServiceForTest(orderRepo),
+				types.OrderStatusIntProcessing, types.OrderStatusIntProcessing)
+
+			So((results[0] == nil) != (results[1] == nil), ShouldBeTrue)
+			So(orderRepo.history, ShouldHaveLength, 1)
+			So(orderRepo.order.Status, ShouldEqual, types.OrderStatusProcessing)
+		})
+
+		Convey("不重试时直接返回状态冲突", func() {
+			orderRepo := newGuardedStatusOrderRepository(types.OrderStatusProcessing, 2)
+			statusService := &OrderStatusService{orderRepo: orderRepo}
+			results := concurrentStatusUpdates(statusService,
+				types.OrderStatusIntCompleted, types.OrderStatusIntCancelled)
+
+			conflicts := 0
+			for _, err := range results {
+				if errors.Is(err, types.ErrOrderStatusConflict) {
+					conflicts++
+				}
+			}
+			So(conflicts, ShouldEqual, 1)
+			So(orderRepo.history, ShouldHaveLength, 1)
+		})
+	})
+}
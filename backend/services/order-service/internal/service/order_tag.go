@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// ErrOrderViewNotFound 订单筛选视图不存在或不属于当前用户
+var ErrOrderViewNotFound = errors.New("订单视图不存在")
+
+// IOrderTagService 订单标签与筛选视图服务接口
+type IOrderTagService interface {
+	AddTags(ctx context.Context, orderID uint64, tags []string) ([]string, error)
+	RemoveTag(ctx context.Context, orderID uint64, tag string) ([]string, error)
+
+	SaveView(ctx context.Context, req *types.SaveOrderViewRequest) (*types.OrderSavedView, error)
+	ListViews(ctx context.Context) ([]types.OrderSavedView, error)
+	GetView(ctx context.Context, viewID uint64) (*types.OrderSavedView, error)
+	DeleteView(ctx context.Context, viewID uint64) error
+	QueryView(ctx context.Context, viewID uint64, page, pageSize int) (*types.OrderListResponse, error)
+}
+
+// OrderTagService 订单标签与筛选视图服务实现
+type OrderTagService struct {
+	orderRepo repository.IOrderRepository
+	tagRepo   repository.IOrderTagRepository
+	viewRepo  repository.IOrderSavedViewRepository
+}
+
+// NewOrderTagService 创建订单标签服务实例
+func NewOrderTagService() IOrderTagService {
+	return &OrderTagService{
+		orderRepo: repository.NewOrderRepository(),
+		tagRepo:   repository.NewOrderTagRepository(),
+		viewRepo:  repository.NewOrderSavedViewRepository(),
+	}
+}
+
+// NewOrderTagServiceForTest 创建测试用订单标签服务实例
+func NewOrderTagServiceForTest(orderRepo repository.IOrderRepository, tagRepo repository.IOrderTagRepository, viewRepo repository.IOrderSavedViewRepository) IOrderTagService {
+	return &OrderTagService{
+		orderRepo: orderRepo,
+		tagRepo:   tagRepo,
+		viewRepo:  viewRepo,
+	}
+}
+
+// AddTags 为订单添加标签，返回订单当前的全部标签
+func (s *OrderTagService) AddTags(ctx context.Context, orderID uint64, tags []string) ([]string, error) {
+	normalized, err := types.NormalizeOrderTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("标签不能为空")
+	}
+
+	order, err := s.getScopedOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.orderTags(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	merged, _ := types.NormalizeOrderTags(append(existing, normalized...))
+	if len(merged) > types.MaxOrderTagsPerOrder {
+		return nil, fmt.Errorf("单个订单最多添加%d个标签", types.MaxOrderTagsPerOrder)
+	}
+
+	if err := s.tagRepo.AddTags(ctx, order.ID, normalized, gconv.Uint64(ctx.Value("user_id"))); err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "order_tag", "add", map[string]interface{}{
+		"order_id": order.ID,
+		"tags":     normalized,
+	})
+
+	return s.orderTags(ctx, order.ID)
+}
+
+// RemoveTag 移除订单标签，返回订单剩余的标签
+func (s *OrderTagService) RemoveTag(ctx context.Context, orderID uint64, tag string) ([]string, error) {
+	normalized, err := types.NormalizeOrderTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.getScopedOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tagRepo.RemoveTag(ctx, order.ID, normalized); err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "order_tag", "remove", map[string]interface{}{
+		"order_id": order.ID,
+		"tag":      normalized,
+	})
+
+	return s.orderTags(ctx, order.ID)
+}
+
+// SaveView 保存当前用户的订单筛选视图
+func (s *OrderTagService) SaveView(ctx context.Context, req *types.SaveOrderViewRequest) (*types.OrderSavedView, error) {
+	userID, err := currentViewUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := req.Filters
+	if filters.Tags, err = types.NormalizeOrderTags(filters.Tags); err != nil {
+		return nil, err
+	}
+
+	view := &types.OrderSavedView{
+		UserID:  userID,
+		Name:    req.Name,
+		Filters: filters,
+	}
+	if err := s.viewRepo.Create(ctx, view); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// ListViews 获取当前用户的全部订单筛选视图
+func (s *OrderTagService) ListViews(ctx context.Context) ([]types.OrderSavedView, error) {
+	userID, err := currentViewUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.viewRepo.ListByUser(ctx, userID)
+}
+
+// GetView 获取当前用户的订单筛选视图
+func (s *OrderTagService) GetView(ctx context.Context, viewID uint64) (*types.OrderSavedView, error) {
+	userID, err := currentViewUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	view, err := s.viewRepo.GetByID(ctx, userID, viewID)
+	if err != nil {
+		return nil, err
+	}
+	if view == nil {
+		return nil, ErrOrderViewNotFound
+	}
+	return view, nil
+}
+
+// DeleteView 删除当前用户的订单筛选视图
+func (s *OrderTagService) DeleteView(ctx context.Context, viewID uint64) error {
+	view, err := s.GetView(ctx, viewID)
+	if err != nil {
+		return err
+	}
+	return s.viewRepo.Delete(ctx, view.UserID, view.ID)
+}
+
+// QueryView 按保存的筛选条件查询订单，商户用户只能查询本商户的订单
+func (s *OrderTagService) QueryView(ctx context.Context, viewID uint64, page, pageSize int) (*types.OrderListResponse, error) {
+	view, err := s.GetView(ctx, viewID)
+	if err != nil {
+		return nil, err
+	}
+
+	req := view.Filters.ToQueryRequest(page, pageSize)
+	if merchantID := gconv.Uint64(ctx.Value("merchant_id")); merchantID > 0 {
+		req.MerchantID = &merchantID
+	}
+	return s.orderRepo.QueryList(ctx, req)
+}
+
+// orderTags 获取订单当前的标签
+func (s *OrderTagService) orderTags(ctx context.Context, orderID uint64) ([]string, error) {
+	tagMap, err := s.tagRepo.ListByOrderIDs(ctx, []uint64{orderID})
+	if err != nil {
+		return nil, err
+	}
+	if tags := tagMap[orderID]; tags != nil {
+		return tags, nil
+	}
+	return []string{}, nil
+}
+
+// getScopedOrder 获取当前租户和商户范围内的订单
+func (s *OrderTagService) getScopedOrder(ctx context.Context, orderID uint64) (*types.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	merchantID := gconv.Uint64(ctx.Value("merchant_id"))
+	if merchantID > 0 && order.MerchantID != merchantID {
+		return nil, fmt.Errorf("无权访问该订单")
+	}
+	return order, nil
+}
+
+// currentViewUser 获取筛选视图所属的当前用户
+func currentViewUser(ctx context.Context) (uint64, error) {
+	userID := gconv.Uint64(ctx.Value("user_id"))
+	if userID == 0 {
+		return 0, fmt.Errorf("缺少用户信息")
+	}
+	return userID, nil
+}
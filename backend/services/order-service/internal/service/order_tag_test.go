@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeTagOrderRepository 订单仓储桩，记录最近一次列表查询条件
+type fakeTagOrderRepository struct {
+	fakeNoteOrderRepository
+	tagRepo   *fakeOrderTagRepository
+	lastQuery *types.OrderQueryRequest
+}
+
+// QueryList 按标签筛选订单，模拟仓储中“同时带有全部标签”的查询条件
+func (f *fakeTagOrderRepository) QueryList(ctx context.Context, req *types.OrderQueryRequest) (*types.OrderListResponse, error) {
+	f.lastQuery = req
+
+	response := &types.OrderListResponse{Page: req.Page, PageSize: req.PageSize}
+	for _, order := range f.orders {
+		if req.MerchantID != nil && order.MerchantID != *req.MerchantID {
+			continue
+		}
+		if !hasAllTags(f.tagRepo.tags[order.ID], req.Tags) {
+			continue
+		}
+		response.Items = append(response.Items, types.OrderSummary{ID: order.ID, Tags: f.tagRepo.tags[order.ID]})
+	}
+	response.Total = int64(len(response.Items))
+	return response, nil
+}
+
+func hasAllTags(orderTags, wanted []string) bool {
+	for _, tag := range wanted {
+		found := false
+		for _, orderTag := range orderTags {
+			if orderTag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// fakeOrderTagRepository 内存订单标签仓储
+type fakeOrderTagRepository struct {
+	tags map[uint64][]string
+}
+
+func (f *fakeOrderTagRepository) AddTags(ctx context.Context, orderID uint64, tags []string, createdBy uint64) error {
+	for _, tag := range tags {
+		if !hasAllTags(f.tags[orderID], []string{tag}) {
+			f.tags[orderID] = append(f.tags[orderID], tag)
+		}
+	}
+	return nil
+}
+
+func (f *fakeOrderTagRepository) RemoveTag(ctx context.Context, orderID uint64, tag string) error {
+	var remaining []string
+	for _, existing := range f.tags[orderID] {
+		if existing != tag {
+			remaining = append(remaining, existing)
+		}
+	}
+	f.tags[orderID] = remaining
+	return nil
+}
+
+func (f *fakeOrderTagRepository) ListByOrderIDs(ctx context.Context, orderIDs []uint64) (map[uint64][]string, error) {
+	result := make(map[uint64][]string)
+	for _, id := range orderIDs {
+		if tags := f.tags[id]; len(tags) > 0 {
+			result[id] = append([]string(nil), tags...)
+		}
+	}
+	return result, nil
+}
+
+// fakeOrderSavedViewRepository 内存订单筛选视图仓储
+type fakeOrderSavedViewRepository struct {
+	views []types.OrderSavedView
+}
+
+func (f *fakeOrderSavedViewRepository) Create(ctx context.Context, view *types.OrderSavedView) error {
+	view.ID = uint64(len(f.views) + 1)
+	f.views = append(f.views, *view)
+	return nil
+}
+
+func (f *fakeOrderSavedViewRepository) GetByID(ctx context.Context, userID, viewID uint64) (*types.OrderSavedView, error) {
+	for i := range f.views {
+		if f.views[i].ID == viewID && f.views[i].UserID == userID {
+			view := f.views[i]
+			return &view, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeOrderSavedViewRepository) ListByUser(ctx context.Context, userID uint64) ([]types.OrderSavedView, error) {
+	var result []types.OrderSavedView
+	for _, view := range f.views {
+		if view.UserID == userID {
+			result = append(result, view)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeOrderSavedViewRepository) Delete(ctx context.Context, userID, viewID uint64) error {
+	var remaining []types.OrderSavedView
+	for _, view := range f.views {
+		if view.ID != viewID || view.UserID != userID {
+			remaining = append(remaining, view)
+		}
+	}
+	f.views = remaining
+	return nil
+}
+
+func TestOrderTagService(t *testing.T) {
+	Convey("订单标签与筛选视图测试", t, func() {
+		tagRepo := &fakeOrderTagRepository{tags: make(map[uint64][]string)}
+		orderRepo := &fakeTagOrderRepository{
+			fakeNoteOrderRepository: fakeNoteOrderRepository{
+				orders: map[uint64]*types.Order{
+					1: {ID: 1, TenantID: 1, MerchantID: 10},
+					2: {ID: 2, TenantID: 1, MerchantID: 10},
+					3: {ID: 3, TenantID: 1, MerchantID: 20},
+				},
+			},
+			tagRepo: tagRepo,
+		}
+		viewRepo := &fakeOrderSavedViewRepository{}
+		tagService := NewOrderTagServiceForTest(orderRepo, tagRepo, viewRepo)
+
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		ctx = context.WithValue(ctx, "user_id", uint64(5))
+
+		Convey("添加标签时去除空白并忽略重复标签", func() {
+			tags, err := tagService.AddTags(ctx, 1, []string{" VIP ", "priority", "VIP"})
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, []string{"VIP", "priority"})
+
+			tags, err = tagService.AddTags(ctx, 1, []string{"priority", "dispute"})
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, []string{"VIP", "priority", "dispute"})
+		})
+
+		Convey("空标签或超长标签被拒绝", func() {
+			_, err := tagService.AddTags(ctx, 1, []string{"  "})
+			So(err, ShouldNotBeNil)
+
+			_, err = tagService.AddTags(ctx, 1, []string{"这是一个明显超过三十二个字符长度限制的订单标签名称用于测试长度校验"})
+			So(err, ShouldNotBeNil)
+			So(tagRepo.tags[1], ShouldBeEmpty)
+		})
+
+		Convey("移除标签后返回剩余标签", func() {
+			_, err := tagService.AddTags(ctx, 1, []string{"VIP", "dispute"})
+			So(err, ShouldBeNil)
+
+			tags, err := tagService.RemoveTag(ctx, 1, "dispute")
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, []string{"VIP"})
+
+			tags, err = tagService.RemoveTag(ctx, 1, "VIP")
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, []string{})
+		})
+
+		Convey("商户用户不能为其他商户的订单添加标签", func() {
+			merchantCtx := context.WithValue(ctx, "merchant_id", uint64(10))
+			_, err := tagService.AddTags(merchantCtx, 3, []string{"VIP"})
+			So(err, ShouldNotBeNil)
+			So(tagRepo.tags[3], ShouldBeEmpty)
+		})
+
+		Convey("保存的视图按标签筛选订单", func() {
+			_, err := tagService.AddTags(ctx, 1, []string{"VIP", "priority"})
+			So(err, ShouldBeNil)
+			_, err = tagService.AddTags(ctx, 2, []string{"VIP"})
+			So(err, ShouldBeNil)
+			_, err = tagService.AddTags(ctx, 3, []string{"VIP", "priority"})
+			So(err, ShouldBeNil)
+
+			view, err := tagService.SaveView(ctx, &types.SaveOrderViewRequest{
+				Name:    "VIP优先",
+				Filters: types.OrderViewFilters{Tags: []string{"VIP", " priority"}},
+			})
+			So(err, ShouldBeNil)
+			So(view.UserID, ShouldEqual, 5)
+			So(view.Filters.Tags, ShouldResemble, []string{"VIP", "priority"})
+
+			response, err := tagService.QueryView(ctx, view.ID, 1, 20)
+			So(err, ShouldBeNil)
+			So(orderRepo.lastQuery.Tags, ShouldResemble, []string{"VIP", "priority"})
+			So(orderRepo.lastQuery.SortBy, ShouldEqual, "created_at")
+			So(response.Total, ShouldEqual, 2)
+
+			Convey("商户用户按视图查询时只返回本商户订单", func() {
+				merchantCtx := context.WithValue(ctx, "merchant_id", uint64(10))
+				response, err := tagService.QueryView(merchantCtx, view.ID, 1, 20)
+				So(err, ShouldBeNil)
+				So(response.Total, ShouldEqual, 1)
+				So(response.Items[0].ID, ShouldEqual, 1)
+				So(response.Items[0].Tags, ShouldResemble, []string{"VIP", "priority"})
+			})
+		})
+
+		Convey("视图只能由创建者获取", func() {
+			view, err := tagService.SaveView(ctx, &types.SaveOrderViewRequest{Name: "纠纷订单", Filters: types.OrderViewFilters{Tags: []string{"dispute"}}})
+			So(err, ShouldBeNil)
+
+			got, err := tagService.GetView(ctx, view.ID)
+			So(err, ShouldBeNil)
+			So(got.Name, ShouldEqual, "纠纷订单")
+
+			views, err := tagService.ListViews(ctx)
+			So(err, ShouldBeNil)
+			So(len(views), ShouldEqual, 1)
+
+			otherCtx := context.WithValue(ctx, "user_id", uint64(6))
+			_, err = tagService.GetView(otherCtx, view.ID)
+			So(errors.Is(err, ErrOrderViewNotFound), ShouldBeTrue)
+			So(errors.Is(tagService.DeleteView(otherCtx, view.ID), ErrOrderViewNotFound), ShouldBeTrue)
+
+			views, err = tagService.ListViews(otherCtx)
+			So(err, ShouldBeNil)
+			So(views, ShouldBeEmpty)
+
+			So(tagService.DeleteView(ctx, view.ID), ShouldBeNil)
+			_, err = tagService.GetView(ctx, view.ID)
+			So(errors.Is(err, ErrOrderViewNotFound), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// NewOrderTaxServiceForTest 创建测试用订单服务实例（用于下单计税）
+func NewOrderTaxServiceForTest(orderRepo repository.IOrderRepository, productRepo repository.IProductAvailabilityRepository, merchantRepo repository.MerchantRepository, tenantRepo repository.ITenantRepository) IOrderService {
+	return &OrderService{
+		orderRepo:    orderRepo,
+		productRepo:  productRepo,
+		merchantRepo: merchantRepo,
+		taxPolicy:    repository.NewTenantTaxPolicyProvider(tenantRepo),
+	}
+}
+
+// applyOrderTax 按租户税费策略计算订单税费并计入确认信息的订单金额，未启用计税时商品金额即订单金额。
+// 税费策略读取失败时不能下单，避免少收或多收税费
+func (s *OrderService) applyOrderTax(ctx context.Context, customerID uint64, region string, confirmation *types.OrderConfirmation, products map[uint64]*types.Product) error {
+	confirmation.SubtotalAmount = confirmation.TotalAmount
+	if s.taxPolicy == nil {
+		return nil
+	}
+
+	policy, err := s.taxPolicy(ctx, gconv.Uint64(ctx.Value("tenant_id")))
+	if err != nil {
+		return fmt.Errorf("获取税费策略失败: %v", err)
+	}
+
+	items := make([]types.TaxItem, 0, len(confirmation.Items))
+	for _, item := range confirmation.Items {
+		taxItem := types.TaxItem{
+			ProductID: item.ProductID,
+			Amount:    item.SubtotalAmount,
+		}
+		if product, ok := products[item.ProductID]; ok {
+			taxItem.CategoryID = product.CategoryID
+		}
+		items = append(items, taxItem)
+	}
+
+	tax := policy.Calculate(region, customerID, items)
+	if tax == nil {
+		return nil
+	}
+	confirmation.Tax = tax
+	confirmation.SubtotalAmount = tax.NetAmount
+	confirmation.TaxAmount = tax.TaxAmount
+	confirmation.TotalAmount = tax.GrossAmount
+	return nil
+}
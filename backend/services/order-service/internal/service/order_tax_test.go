@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// taxTenantRepository 返回带税费策略配置的租户仓储桩
+type taxTenantRepository struct {
+	repository.ITenantRepository
+	policy *types.TaxPolicy
+}
+
+func (r *taxTenantRepository) GetByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	config, err := json.Marshal(types.TenantConfig{Tax: r.policy})
+	if err != nil {
+		return nil, err
+	}
+	return &types.Tenant{ID: id, Config: string(config)}, nil
+}
+
+func TestOrderTax(t *testing.T) {
+	Convey("下单按租户税费策略计税", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		food := uint64(10)
+
+		productRepo := &staticProductAvailabilityRepository{products: []types.Product{
+			{ID: 1, Status: types.ProductStatusActive, CategoryID: &food},
+			{ID: 2, Status: types.ProductStatusActive},
+		}}
+		merchantRepo := &staticMerchantRepository{merchant: &types.Merchant{ID: 1, BusinessInfo: &types.BusinessInfo{}}}
+		tenantRepo := &taxTenantRepository{policy: &types.TaxPolicy{
+			Enabled:       true,
+			Rate:          0.13,
+			CategoryRates: map[uint64]float64{food: 0.06},
+		}}
+		orderService := NewOrderTaxServiceForTest(&batchOrderRepository{}, productRepo, merchantRepo, tenantRepo).(*OrderService)
+
+		// 测试商品单价均为 100
+		req := &types.CreateOrderRequest{MerchantID: 1}
+		req.AddItem(1, 2)
+		req.AddItem(2, 1)
+
+		Convey("价格不含税时税额加在商品金额上，分类税率优先于默认税率", func() {
+			order, err := orderService.newOrder(ctx, 100, req)
+			So(err, ShouldBeNil)
+			So(order.SubtotalAmount, ShouldEqual, 300)
+			So(order.TaxAmount, ShouldEqual, 25)
+			So(order.TotalAmount, ShouldEqual, 325)
+			So(order.Tax.Lines[0].Rate, ShouldEqual, 0.06)
+			So(order.Tax.Lines[0].TaxAmount, ShouldEqual, 12)
+			So(order.Tax.Lines[1].Rate, ShouldEqual, 0.13)
+			So(order.Tax.Lines[1].TaxAmount, ShouldEqual, 13)
+
+			confirmation, err := orderService.GetOrderConfirmation(ctx, 100, req)
+			So(err, ShouldBeNil)
+			So(confirmation.TaxAmount, ShouldEqual, 25)
+			So(confirmation.TotalAmount, ShouldEqual, 325)
+		})
+
+		Convey("价格含税时从商品金额中拆出税额，订单金额不变", func() {
+			tenantRepo.policy.Inclusive = true
+
+			order, err := orderService.newOrder(ctx, 100, req)
+			So(err, ShouldBeNil)
+			So(order.TotalAmount, ShouldEqual, 300)
+			// 200 × 0.06 / 1.06 = 11.32，100 × 0.13 / 1.13 = 11.50
+			So(order.TaxAmount, ShouldEqual, 22.82)
+			So(order.SubtotalAmount, ShouldEqual, 277.18)
+		})
+
+		Convey("商户所在地区配置了地区税率时使用地区税率", func() {
+			merchantRepo.merchant.BusinessInfo.Region = "HK"
+			tenantRepo.policy.Regions = map[string]*types.TaxRegion{"HK": {Rate: 0}}
+
+			order, err := orderService.newOrder(ctx, 100, req)
+			So(err, ShouldBeNil)
+			So(order.Tax.Region, ShouldEqual, "HK")
+			So(order.TaxAmount, ShouldEqual, 0)
+			So(order.TotalAmount, ShouldEqual, 300)
+		})
+
+		Convey("免税客户不计税", func() {
+			tenantRepo.policy.ExemptCustomerIDs = []uint64{100}
+
+			order, err := orderService.newOrder(ctx, 100, req)
+			So(err, ShouldBeNil)
+			So(order.Tax.CustomerExempt, ShouldBeTrue)
+			So(order.TaxAmount, ShouldEqual, 0)
+			So(order.TotalAmount, ShouldEqual, 300)
+		})
+
+		Convey("未配置税费策略时商品金额即订单金额", func() {
+			tenantRepo.policy = nil
+
+			order, err := orderService.newOrder(ctx, 100, req)
+			So(err, ShouldBeNil)
+			So(order.Tax, ShouldBeNil)
+			So(order.SubtotalAmount, ShouldEqual, 300)
+			So(order.TotalAmount, ShouldEqual, 300)
+		})
+	})
+}
@@ -2,36 +2,105 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/schedule"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
 )
 
+const (
+	// 超时候选订单的默认超时时长
+	defaultPendingTimeoutWindow    = 30 * time.Minute
+	defaultProcessingTimeoutWindow = 24 * time.Hour
+
+	defaultTimeoutBatchSize  = 100
+	defaultTimeoutBatchDelay = time.Second
+	defaultTimeoutMaxPerRun  = 1000
+)
+
+// OrderTimeoutBatchConfig 超时订单分批处理配置，避免故障恢复后积压的大量超时订单集中访问数据库和通知渠道
+type OrderTimeoutBatchConfig struct {
+	BatchSize  int           // 每批处理的订单数
+	BatchDelay time.Duration // 两批之间的间隔
+	MaxPerRun  int           // 每轮每种状态最多处理的订单数，超出部分下一轮继续处理
+}
+
+// LoadOrderTimeoutBatchConfig 从 order.timeout 配置加载超时订单分批处理配置，未配置或无效的项使用默认值
+func LoadOrderTimeoutBatchConfig(ctx context.Context) *OrderTimeoutBatchConfig {
+	config := &OrderTimeoutBatchConfig{
+		BatchSize:  g.Cfg().MustGet(ctx, "order.timeout.batchSize", defaultTimeoutBatchSize).Int(),
+		BatchDelay: g.Cfg().MustGet(ctx, "order.timeout.batchDelay", defaultTimeoutBatchDelay).Duration(),
+		MaxPerRun:  g.Cfg().MustGet(ctx, "order.timeout.maxPerRun", defaultTimeoutMaxPerRun).Int(),
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultTimeoutBatchSize
+	}
+	if config.BatchDelay < 0 {
+		config.BatchDelay = defaultTimeoutBatchDelay
+	}
+	if config.MaxPerRun < config.BatchSize {
+		config.MaxPerRun = config.BatchSize
+	}
+	return config
+}
+
 // OrderTimeoutService 订单超时处理服务
 type OrderTimeoutService struct {
 	orderRepo         repository.IOrderRepository
 	timeoutConfigRepo *repository.OrderTimeoutConfigRepository
 	orderStatusService IOrderStatusService
 	notificationService NotificationService
-	stopCh            chan struct{}
+	reservationRepo   repository.IInventoryReservationRepository
+	reservationReleaser ReservationReleaser
+	disputeRepo       repository.IOrderDisputeRepository // 为空时不检查订单争议
+	jobs              *schedule.Scheduler                // 多副本部署时只有领导者副本检查超时订单
+	batchConfig       *OrderTimeoutBatchConfig
+	cursorMu          sync.Mutex
+	cursors           map[types.OrderStatus]uint64 // 上一轮达到处理上限时各状态的续处理位置
 	isRunning         bool
 }
 
 // NewOrderTimeoutService 创建订单超时处理服务实例
 func NewOrderTimeoutService(orderStatusService IOrderStatusService, notificationService NotificationService) *OrderTimeoutService {
+	reservationRepo := repository.NewInventoryReservationRepository()
 	return &OrderTimeoutService{
 		orderRepo:           repository.NewOrderRepository(),
 		timeoutConfigRepo:   repository.NewOrderTimeoutConfigRepository(),
 		orderStatusService:  orderStatusService,
 		notificationService: notificationService,
-		stopCh:             make(chan struct{}),
+		reservationRepo:     reservationRepo,
+		reservationReleaser: newInventoryReservationReleaser(reservationRepo),
+		disputeRepo:         repository.NewOrderDisputeRepository(),
+		jobs:                schedule.New("order-timeout"),
+		batchConfig:         LoadOrderTimeoutBatchConfig(context.Background()),
+		cursors:             make(map[types.OrderStatus]uint64),
 		isRunning:          false,
 	}
 }
 
+// NewOrderTimeoutServiceForTest 创建测试用订单超时处理服务实例
+func NewOrderTimeoutServiceForTest(orderRepo repository.IOrderRepository, orderStatusService IOrderStatusService, reservationRepo repository.IInventoryReservationRepository, reservationReleaser ReservationReleaser) *OrderTimeoutService {
+	return &OrderTimeoutService{
+		orderRepo:           orderRepo,
+		orderStatusService:  orderStatusService,
+		reservationRepo:     reservationRepo,
+		reservationReleaser: reservationReleaser,
+		jobs:                schedule.New("order-timeout"),
+		batchConfig:         LoadOrderTimeoutBatchConfig(context.Background()),
+		cursors:             make(map[types.OrderStatus]uint64),
+	}
+}
+
+// SetBatchConfig 设置超时订单分批处理配置
+func (s *OrderTimeoutService) SetBatchConfig(config *OrderTimeoutBatchConfig) {
+	s.batchConfig = config
+}
+
 // StartTimeoutMonitor 启动超时监控定时任务
 func (s *OrderTimeoutService) StartTimeoutMonitor(ctx context.Context) {
 	if s.isRunning {
@@ -42,8 +111,16 @@ func (s *OrderTimeoutService) StartTimeoutMonitor(ctx context.Context) {
 	s.isRunning = true
 	g.Log().Info(ctx, "启动订单超时监控服务")
 
-	// 启动定时检查任务
-	go s.runTimeoutCheck(ctx)
+	// 每分钟检查一次，重复启动时任务已注册
+	err := s.jobs.Register(schedule.Job{
+		Name:     "ProcessTimeoutOrders",
+		Schedule: schedule.Every(time.Minute),
+		Run:      s.processTimeoutOrders,
+	})
+	if err != nil && !errors.Is(err, schedule.ErrJobExists) {
+		g.Log().Error(ctx, "注册超时检查任务失败", "error", err)
+	}
+	s.jobs.Start(ctx)
 }
 
 // StopTimeoutMonitor 停止超时监控
@@ -53,27 +130,10 @@ func (s *OrderTimeoutService) StopTimeoutMonitor(ctx context.Context) {
 	}
 
 	s.isRunning = false
-	close(s.stopCh)
+	s.jobs.Stop(ctx)
 	g.Log().Info(ctx, "订单超时监控服务已停止")
 }
 
-// runTimeoutCheck 运行超时检查循环
-func (s *OrderTimeoutService) runTimeoutCheck(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Minute) // 每分钟检查一次
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-s.stopCh:
-			return
-		case <-ticker.C:
-			if err := s.processTimeoutOrders(ctx); err != nil {
-				g.Log().Error(ctx, "处理超时订单失败", "error", err)
-			}
-		}
-	}
-}
-
 // ProcessTimeoutOrders 处理超时订单（公开方法）
 func (s *OrderTimeoutService) ProcessTimeoutOrders(ctx context.Context) error {
 	return s.processTimeoutOrders(ctx)
@@ -93,71 +153,223 @@ func (s *OrderTimeoutService) processTimeoutOrders(ctx context.Context) error {
 		}
 	}
 
+	// 库存预留到期独立于支付超时处理
+	if err := s.processExpiredReservations(ctx); err != nil {
+		g.Log().Error(ctx, "处理到期库存预留失败", "error", err)
+	}
+
+	if err := s.processAutoCompleteOrders(ctx); err != nil {
+		g.Log().Error(ctx, "自动完成订单失败", "error", err)
+	}
+
 	return nil
 }
 
-// processTimeoutOrdersByStatus 处理特定状态的超时订单
-func (s *OrderTimeoutService) processTimeoutOrdersByStatus(ctx context.Context, status types.OrderStatusInt) error {
-	// 获取该状态的超时订单
-	orders, err := s.getTimeoutOrders(ctx, status)
+// processAutoCompleteOrders 按各租户/商户的自动完成配置完成超出等待时长的处理中订单
+func (s *OrderTimeoutService) processAutoCompleteOrders(ctx context.Context) error {
+	configs, err := s.timeoutConfigRepo.ListAutoCompleteEnabled(ctx)
 	if err != nil {
-		return fmt.Errorf("获取超时订单失败: %v", err)
+		return err
 	}
 
-	if len(orders) == 0 {
+	for i := range configs {
+		config := &configs[i]
+		// 定时任务没有请求上下文，按配置所属租户设置租户上下文
+		tenantCtx := context.WithValue(ctx, "tenant_id", config.TenantID)
+		if err := s.autoCompleteOrdersByConfig(tenantCtx, config); err != nil {
+			g.Log().Error(ctx, "按配置自动完成订单失败",
+				"tenant_id", config.TenantID,
+				"config_id", config.ID,
+				"error", err)
+		}
+	}
+
+	return nil
+}
+
+// autoCompleteOrdersByConfig 自动完成单个配置范围内满足条件的订单
+func (s *OrderTimeoutService) autoCompleteOrdersByConfig(ctx context.Context, config *types.OrderTimeoutConfig) error {
+	if !config.AutoCompleteEnabled {
 		return nil
 	}
+	if config.AutoCompleteAfterHours <= 0 {
+		config.AutoCompleteAfterHours = types.DefaultAutoCompleteAfterHours
+	}
+
+	orders, err := s.orderRepo.GetAutoCompleteCandidates(ctx, config, 100)
+	if err != nil {
+		return fmt.Errorf("获取待自动完成订单失败: %v", err)
+	}
 
-	g.Log().Info(ctx, "发现超时订单", "status", status, "count", len(orders))
+	disputed, err := s.disputedOrderIDs(ctx, orders)
+	if err != nil {
+		return err
+	}
 
-	// 批量处理超时订单
+	now := time.Now()
 	for _, order := range orders {
-		if err := s.processTimeoutOrder(ctx, order); err != nil {
-			g.Log().Error(ctx, "处理单个超时订单失败", 
-				"order_id", order.ID, 
+		if !shouldAutoComplete(order, config, now) {
+			continue
+		}
+		// 争议处理期间暂停自动完成，争议结束后恢复
+		if disputed[order.ID] {
+			g.Log().Info(ctx, "订单存在未结束的争议，暂停自动完成",
+				"order_id", order.ID,
+				"order_number", order.OrderNumber)
+			continue
+		}
+		if err := s.autoCompleteOrder(ctx, order, config); err != nil {
+			g.Log().Error(ctx, "自动完成订单失败",
+				"order_id", order.ID,
 				"order_number", order.OrderNumber,
 				"error", err)
-			continue
 		}
 	}
 
 	return nil
 }
 
-// getTimeoutOrders 获取超时订单列表
-func (s *OrderTimeoutService) getTimeoutOrders(ctx context.Context, status types.OrderStatusInt) ([]*types.Order, error) {
-	// 根据状态构建不同的超时查询条件
-	var timeCondition string
-	var timeoutMinutes int
+// disputedOrderIDs 查询候选订单中存在未结束争议的订单
+func (s *OrderTimeoutService) disputedOrderIDs(ctx context.Context, orders []*types.Order) (map[uint64]bool, error) {
+	if s.disputeRepo == nil || len(orders) == 0 {
+		return nil, nil
+	}
+
+	orderIDs := make([]uint64, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+	disputed, err := s.disputeRepo.ActiveOrderIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单争议失败: %v", err)
+	}
+	return disputed, nil
+}
+
+// shouldAutoComplete 判断订单是否满足自动完成条件：处理中、超过等待时长且不在等待核销；
+// 含预订商品的订单从预计补货日期起计算等待时长，未提供补货日期时不自动完成
+func shouldAutoComplete(order *types.Order, config *types.OrderTimeoutConfig, now time.Time) bool {
+	if order.Status != types.OrderStatusProcessing {
+		return false
+	}
+	// 核销类订单需由商户核销后完成，不能自动完成
+	if order.IsAwaitingVerification() {
+		return false
+	}
+
+	lastChangedAt, ok := processingSince(order)
+	if !ok {
+		return false
+	}
+	window := time.Duration(config.AutoCompleteAfterHours) * time.Hour
+	return !lastChangedAt.IsZero() && now.Sub(lastChangedAt) >= window
+}
+
+// processingSince 处理中订单开始计算等待时长的时间：最后一次状态变更时间，
+// 预订商品预计补货前订单无法发货，因此不早于预计补货日期；预订商品未提供补货日期时返回 false
+func processingSince(order *types.Order) (time.Time, bool) {
+	since := order.StatusUpdatedAt
+	if since.IsZero() {
+		since = order.UpdatedAt
+	}
 
+	restockAt, known := order.BackorderRestockAt()
+	if !known {
+		return time.Time{}, false
+	}
+	if restockAt != nil && restockAt.After(since) {
+		since = *restockAt
+	}
+	return since, true
+}
+
+// processTimeoutOrdersByStatus 处理特定状态的超时订单
+func (s *OrderTimeoutService) processTimeoutOrdersByStatus(ctx context.Context, status types.OrderStatusInt) error {
+	// 按默认超时时长筛选候选订单，各商户配置的超时时长在处理单个订单时判断
+	var window time.Duration
 	switch status {
 	case types.OrderStatusIntPending:
-		// 待支付订单：检查创建时间 + 支付超时时间
-		timeoutMinutes = 30 // 默认30分钟，后续从配置获取
-		timeCondition = fmt.Sprintf("created_at < DATE_SUB(NOW(), INTERVAL %d MINUTE)", timeoutMinutes)
+		window = defaultPendingTimeoutWindow
 	case types.OrderStatusIntProcessing:
-		// 处理中订单：检查最后状态更新时间 + 处理超时时间
-		timeoutHours := 24 // 默认24小时，后续从配置获取
-		timeCondition = fmt.Sprintf("status_updated_at < DATE_SUB(NOW(), INTERVAL %d HOUR)", timeoutHours)
+		window = defaultProcessingTimeoutWindow
 	default:
-		return nil, fmt.Errorf("不支持的订单状态超时检查: %s", status)
+		return fmt.Errorf("不支持的订单状态超时检查: %s", status)
 	}
 
-	// 查询超时订单
-	query := fmt.Sprintf(`
-		SELECT o.* FROM orders o 
-		WHERE o.status = ? 
-		AND %s
-		LIMIT 100
-	`, timeCondition)
+	_, err := s.processTimeoutBatches(ctx, types.OrderStatus(s.orderStatusToString(status)), time.Now().Add(-window), s.processTimeoutOrder)
+	return err
+}
 
-	var orders []*types.Order
-	err := g.DB().Raw(query, s.orderStatusToString(status)).Scan(&orders)
-	if err != nil {
-		return nil, fmt.Errorf("查询超时订单失败: %v", err)
+// processTimeoutBatches 按订单ID升序分批处理超时订单，先创建的订单先处理，两批之间间隔 BatchDelay。
+// 每批处理完成后记录处理位置，本轮达到 MaxPerRun 时停止，下一轮从记录的位置继续；没有剩余订单时下一轮从头检查。
+// 单个订单处理失败只记录日志，返回本轮处理的订单数
+func (s *OrderTimeoutService) processTimeoutBatches(ctx context.Context, status types.OrderStatus, deadline time.Time, handle func(ctx context.Context, order *types.Order) error) (int, error) {
+	config := s.batchConfig
+	afterID := s.timeoutCursor(status)
+	processed := 0
+
+	for batch := 1; processed < config.MaxPerRun; batch++ {
+		if batch > 1 && config.BatchDelay > 0 {
+			select {
+			case <-ctx.Done():
+				return processed, ctx.Err()
+			case <-time.After(config.BatchDelay):
+			}
+		}
+
+		limit := config.BatchSize
+		if remaining := config.MaxPerRun - processed; remaining < limit {
+			limit = remaining
+		}
+		orders, err := s.orderRepo.GetTimeoutOrders(ctx, status, deadline, afterID, limit)
+		if err != nil {
+			return processed, fmt.Errorf("获取超时订单失败: %v", err)
+		}
+
+		for _, order := range orders {
+			// 定时任务没有请求上下文，按订单所属租户设置租户上下文
+			tenantCtx := context.WithValue(ctx, "tenant_id", order.TenantID)
+			if err := handle(tenantCtx, order); err != nil {
+				g.Log().Error(ctx, "处理单个超时订单失败",
+					"order_id", order.ID,
+					"order_number", order.OrderNumber,
+					"error", err)
+			}
+			afterID = order.ID
+		}
+		processed += len(orders)
+
+		if len(orders) < limit {
+			s.setTimeoutCursor(status, 0)
+			if processed > 0 {
+				g.Log().Info(ctx, "超时订单处理完成", "status", status, "batches", batch, "processed", processed)
+			}
+			return processed, nil
+		}
+
+		s.setTimeoutCursor(status, afterID)
+		g.Log().Info(ctx, "超时订单分批处理中", "status", status, "batch", batch, "processed", processed, "last_order_id", afterID)
 	}
 
-	return orders, nil
+	g.Log().Warning(ctx, "本轮超时订单处理数量已达上限，剩余订单下一轮继续处理",
+		"status", status,
+		"max_per_run", config.MaxPerRun,
+		"last_order_id", afterID)
+	return processed, nil
+}
+
+// timeoutCursor 获取状态对应的续处理位置
+func (s *OrderTimeoutService) timeoutCursor(status types.OrderStatus) uint64 {
+	s.cursorMu.Lock()
+	defer s.cursorMu.Unlock()
+	return s.cursors[status]
+}
+
+// setTimeoutCursor 记录状态对应的续处理位置，0 表示下一轮从头检查
+func (s *OrderTimeoutService) setTimeoutCursor(status types.OrderStatus, afterID uint64) {
+	s.cursorMu.Lock()
+	defer s.cursorMu.Unlock()
+	s.cursors[status] = afterID
 }
 
 // processTimeoutOrder 处理单个超时订单
@@ -212,11 +424,7 @@ func (s *OrderTimeoutService) processPendingTimeoutOrder(ctx context.Context, or
 		return fmt.Errorf("自动取消超时订单失败: %v", err)
 	}
 
-	// 释放库存和权益
-	if err := s.releaseOrderResources(ctx, order); err != nil {
-		g.Log().Error(ctx, "释放订单资源失败", "order_id", order.ID, "error", err)
-		// 不返回错误，因为订单状态已更新
-	}
+	// 库存预留和权益由订单取消的状态变更钩子释放
 
 	g.Log().Info(ctx, "待支付超时订单已自动取消", 
 		"order_id", order.ID, 
@@ -234,47 +442,46 @@ func (s *OrderTimeoutService) processProcessingTimeoutOrder(ctx context.Context,
 		return fmt.Errorf("获取超时配置失败: %v", err)
 	}
 
-	// 检查是否真的超时了
+	// 检查是否真的超时了，预订商品等待补货期间不发送处理超时提醒
 	timeoutDuration := time.Duration(config.ProcessingTimeoutHours) * time.Hour
-	if !order.StatusUpdatedAt.IsZero() && time.Since(order.StatusUpdatedAt) < timeoutDuration {
+	since, ok := processingSince(order)
+	if !ok {
+		return nil
+	}
+	if !since.IsZero() && time.Since(since) < timeoutDuration {
 		// 还未真正超时
 		return nil
 	}
 
-	// 根据配置决定处理方式
-	if config.AutoCompleteEnabled {
-		// 自动完成订单
-		return s.autoCompleteOrder(ctx, order, config)
-	} else {
-		// 发送超时提醒通知，不自动处理
-		return s.sendProcessingTimeoutNotification(ctx, order, config)
-	}
+	// 处理超时只发送提醒，自动完成由自动完成等待时长单独控制
+	return s.sendProcessingTimeoutNotification(ctx, order, config)
 }
 
 // autoCompleteOrder 自动完成订单
 func (s *OrderTimeoutService) autoCompleteOrder(ctx context.Context, order *types.Order, config *types.OrderTimeoutConfig) error {
 	updateReq := &types.UpdateOrderStatusRequest{
 		Status:       types.OrderStatusIntCompleted,
-		Reason:       "订单处理超时自动完成",
+		Reason:       "订单超过自动完成等待时长，系统自动完成",
 		OperatorType: types.OrderStatusOperatorTypeSystem,
 		Metadata:     map[string]interface{}{
-			"timeout_type": "processing_timeout",
+			"timeout_type": "auto_complete",
 			"auto_complete": true,
 			"timeout_config": map[string]interface{}{
-				"processing_timeout_hours": config.ProcessingTimeoutHours,
+				"auto_complete_after_hours": config.AutoCompleteAfterHours,
 			},
 		},
 	}
 
+	// 状态服务负责写入状态历史并发送完成通知
 	err := s.orderStatusService.UpdateOrderStatus(ctx, order.ID, updateReq)
 	if err != nil {
-		return fmt.Errorf("自动完成超时订单失败: %v", err)
+		return fmt.Errorf("自动完成订单失败: %v", err)
 	}
 
-	g.Log().Info(ctx, "处理中超时订单已自动完成", 
+	g.Log().Info(ctx, "处理中订单已自动完成", 
 		"order_id", order.ID, 
 		"order_number", order.OrderNumber,
-		"timeout_hours", config.ProcessingTimeoutHours)
+		"auto_complete_after_hours", config.AutoCompleteAfterHours)
 
 	return nil
 }
@@ -297,89 +504,6 @@ func (s *OrderTimeoutService) sendProcessingTimeoutNotification(ctx context.Cont
 	return nil
 }
 
-// releaseOrderResources 释放订单资源（库存和权益）
-func (s *OrderTimeoutService) releaseOrderResources(ctx context.Context, order *types.Order) error {
-	// 释放库存
-	if err := s.releaseInventory(ctx, order); err != nil {
-		return fmt.Errorf("释放库存失败: %v", err)
-	}
-
-	// 释放权益
-	if err := s.releaseRights(ctx, order); err != nil {
-		return fmt.Errorf("释放权益失败: %v", err)
-	}
-
-	// 处理支付退款
-	if err := s.processRefund(ctx, order); err != nil {
-		return fmt.Errorf("处理退款失败: %v", err)
-	}
-
-	return nil
-}
-
-// releaseInventory 释放库存
-func (s *OrderTimeoutService) releaseInventory(ctx context.Context, order *types.Order) error {
-	g.Log().Info(ctx, "释放订单库存", "order_id", order.ID)
-
-	// 这里应该调用库存服务释放库存
-	// 暂时模拟实现
-	for _, item := range order.Items {
-		g.Log().Info(ctx, "释放商品库存", 
-			"order_id", order.ID,
-			"product_id", item.ProductID,
-			"quantity", item.Quantity)
-	}
-
-	return nil
-}
-
-// releaseRights 释放权益
-func (s *OrderTimeoutService) releaseRights(ctx context.Context, order *types.Order) error {
-	if order.TotalRightsCost <= 0 {
-		return nil // 没有使用权益
-	}
-
-	g.Log().Info(ctx, "释放订单权益", 
-		"order_id", order.ID,
-		"rights_cost", order.TotalRightsCost)
-
-	// 这里应该调用权益服务释放权益
-	// 暂时模拟实现
-	g.Log().Info(ctx, "权益释放完成", 
-		"order_id", order.ID,
-		"customer_id", order.CustomerID,
-		"released_rights", order.TotalRightsCost)
-
-	return nil
-}
-
-// processRefund 处理退款
-func (s *OrderTimeoutService) processRefund(ctx context.Context, order *types.Order) error {
-	// 只有已支付的订单才需要退款（检查订单状态和支付信息）
-	if order.PaymentInfo == nil || order.PaymentInfo.PaidAt == nil {
-		return nil // 未支付，无需退款
-	}
-
-	// 检查订单状态是否需要退款
-	currentStatus := s.stringToOrderStatusInt(order.Status)
-	if currentStatus == types.OrderStatusIntPending {
-		return nil // 待支付状态不需要退款
-	}
-
-	g.Log().Info(ctx, "处理订单退款", 
-		"order_id", order.ID,
-		"refund_amount", order.TotalAmount)
-
-	// 这里应该调用支付服务处理退款
-	// 暂时模拟实现
-	g.Log().Info(ctx, "退款处理完成", 
-		"order_id", order.ID,
-		"refund_amount", order.TotalAmount,
-		"payment_method", order.PaymentInfo.Method)
-
-	return nil
-}
-
 // GetTimeoutStatistics 获取超时统计信息
 func (s *OrderTimeoutService) GetTimeoutStatistics(ctx context.Context, merchantID *uint64) (*types.OrderTimeoutStatistics, error) {
 	// 构建查询条件
@@ -406,7 +530,7 @@ func (s *OrderTimeoutService) GetTimeoutStatistics(ctx context.Context, merchant
 		Count  int     `db:"count"`
 		Amount float64 `db:"amount"`
 	}
-	err := g.DB().Raw(pendingTimeoutQuery, args[0]).Scan(&pendingTimeoutResult)
+	err := repository.TenantDB(ctx).Raw(pendingTimeoutQuery, args[0]).Scan(&pendingTimeoutResult)
 	if err != nil {
 		return nil, fmt.Errorf("统计待支付超时订单失败: %v", err)
 	}
@@ -426,7 +550,7 @@ func (s *OrderTimeoutService) GetTimeoutStatistics(ctx context.Context, merchant
 		Count  int     `db:"count"`
 		Amount float64 `db:"amount"`
 	}
-	err = g.DB().Raw(processingTimeoutQuery, args[0]).Scan(&processingTimeoutResult)
+	err = repository.TenantDB(ctx).Raw(processingTimeoutQuery, args[0]).Scan(&processingTimeoutResult)
 	if err != nil {
 		return nil, fmt.Errorf("统计处理中超时订单失败: %v", err)
 	}
@@ -448,7 +572,7 @@ func (s *OrderTimeoutService) GetTimeoutStatistics(ctx context.Context, merchant
 		Count  int     `db:"count"`
 		Amount float64 `db:"amount"`
 	}
-	err = g.DB().Raw(todayCancelledQuery, args[0]).Scan(&todayCancelledResult)
+	err = repository.TenantDB(ctx).Raw(todayCancelledQuery, args[0]).Scan(&todayCancelledResult)
 	if err != nil {
 		return nil, fmt.Errorf("统计今日自动取消订单失败: %v", err)
 	}
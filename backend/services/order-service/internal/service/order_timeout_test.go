@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeAutoCompleteOrderRepository 返回固定候选订单的订单仓储桩
+type fakeAutoCompleteOrderRepository struct {
+	repository.IOrderRepository
+	candidates []*types.Order
+}
+
+func (f *fakeAutoCompleteOrderRepository) GetAutoCompleteCandidates(ctx context.Context, timeoutConfig *types.OrderTimeoutConfig, limit int) ([]*types.Order, error) {
+	return f.candidates, nil
+}
+
+// recordingOrderStatusService 记录状态变更请求的订单状态服务桩
+type recordingOrderStatusService struct {
+	IOrderStatusService
+	updates map[uint64]*types.UpdateOrderStatusRequest
+}
+
+func (r *recordingOrderStatusService) UpdateOrderStatus(ctx context.Context, orderID uint64, req *types.UpdateOrderStatusRequest) error {
+	r.updates[orderID] = req
+	return nil
+}
+
+// backlogOrderRepository 积压超时订单的订单仓储桩，按订单ID升序分页返回并记录每次查询的数量上限
+type backlogOrderRepository struct {
+	repository.IOrderRepository
+	orders map[uint64]*types.Order
+	limits []int
+}
+
+func (f *backlogOrderRepository) GetTimeoutOrders(ctx context.Context, status types.OrderStatus, deadline time.Time, afterID uint64, limit int) ([]*types.Order, error) {
+	f.limits = append(f.limits, limit)
+	var orders []*types.Order
+	for _, order := range f.orders {
+		if order.Status == status && order.ID > afterID && order.CreatedAt.Before(deadline) {
+			orders = append(orders, order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+func TestOrderTimeoutBatches(t *testing.T) {
+	Convey("超时订单分批处理测试", t, func() {
+		now := time.Now()
+		orderRepo := &backlogOrderRepository{orders: make(map[uint64]*types.Order)}
+		for id := uint64(1); id <= 250; id++ {
+			orderRepo.orders[id] = &types.Order{
+				ID:        id,
+				TenantID:  id%2 + 1,
+				Status:    types.OrderStatusPending,
+				CreatedAt: now.Add(-time.Hour).Add(time.Duration(id) * time.Second),
+			}
+		}
+		timeoutService := NewOrderTimeoutServiceForTest(orderRepo, nil, nil, nil)
+		timeoutService.SetBatchConfig(&OrderTimeoutBatchConfig{BatchSize: 40, BatchDelay: 5 * time.Millisecond, MaxPerRun: 100})
+
+		// 偶数ID的订单取消成功，奇数ID的订单处理失败仍保持待支付
+		var handled []uint64
+		tenantMismatch := 0
+		handle := func(ctx context.Context, order *types.Order) error {
+			handled = append(handled, order.ID)
+			if ctx.Value("tenant_id") != order.TenantID {
+				tenantMismatch++
+			}
+			if order.ID%2 == 1 {
+				return errors.New("通知渠道不可用")
+			}
+			order.Status = types.OrderStatusCancelled
+			return nil
+		}
+		deadline := now
+
+		Convey("每轮按批次从最早的订单开始处理，达到上限后下一轮继续", func() {
+			started := time.Now()
+			processed, err := timeoutService.processTimeoutBatches(context.Background(), types.OrderStatusPending, deadline, handle)
+			So(err, ShouldBeNil)
+			So(processed, ShouldEqual, 100)
+			So(orderRepo.limits, ShouldResemble, []int{40, 40, 20})
+			So(time.Since(started), ShouldBeGreaterThanOrEqualTo, 10*time.Millisecond)
+			So(handled[0], ShouldEqual, 1)
+			So(handled[99], ShouldEqual, 100)
+			So(sort.SliceIsSorted(handled, func(i, j int) bool { return handled[i] < handled[j] }), ShouldBeTrue)
+			So(tenantMismatch, ShouldEqual, 0)
+
+			processed, err = timeoutService.processTimeoutBatches(context.Background(), types.OrderStatusPending, deadline, handle)
+			So(err, ShouldBeNil)
+			So(processed, ShouldEqual, 100)
+			So(handled[100], ShouldEqual, 101)
+
+			Convey("积压处理完后下一轮从头检查仍未处理成功的订单", func() {
+				processed, err = timeoutService.processTimeoutBatches(context.Background(), types.OrderStatusPending, deadline, handle)
+				So(err, ShouldBeNil)
+				So(processed, ShouldEqual, 50)
+				So(handled[len(handled)-1], ShouldEqual, 250)
+
+				handled = nil
+				processed, err = timeoutService.processTimeoutBatches(context.Background(), types.OrderStatusPending, deadline, handle)
+				So(err, ShouldBeNil)
+				So(processed, ShouldEqual, 100)
+				So(handled[0], ShouldEqual, 1)
+				So(handled[1], ShouldEqual, 3)
+			})
+		})
+
+		Convey("没有超时订单时只查询一次", func() {
+			processed, err := timeoutService.processTimeoutBatches(context.Background(), types.OrderStatusPending, now.Add(-2*time.Hour), handle)
+			So(err, ShouldBeNil)
+			So(processed, ShouldEqual, 0)
+			So(orderRepo.limits, ShouldHaveLength, 1)
+		})
+
+		Convey("任务取消时在两批之间停止，下一轮从已处理的位置继续", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			processed, err := timeoutService.processTimeoutBatches(ctx, types.OrderStatusPending, deadline, handle)
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+			So(processed, ShouldEqual, 40)
+
+			handled = nil
+			_, err = timeoutService.processTimeoutBatches(context.Background(), types.OrderStatusPending, deadline, handle)
+			So(err, ShouldBeNil)
+			So(handled[0], ShouldEqual, 41)
+		})
+	})
+}
+
+func TestOrderAutoComplete(t *testing.T) {
+	Convey("订单自动完成测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		now := time.Now()
+		verifiedAt := now.Add(-90 * time.Hour)
+
+		overdue := &types.Order{ID: 1, Status: types.OrderStatusProcessing, StatusUpdatedAt: now.Add(-80 * time.Hour)}
+		recent := &types.Order{ID: 2, Status: types.OrderStatusProcessing, StatusUpdatedAt: now.Add(-10 * time.Hour)}
+		awaitingVerification := &types.Order{
+			ID: 3, Status: types.OrderStatusProcessing, StatusUpdatedAt: now.Add(-100 * time.Hour),
+			VerificationInfo: &types.VerificationInfo{VerificationCode: "V123"},
+		}
+		verified := &types.Order{
+			ID: 4, Status: types.OrderStatusProcessing, StatusUpdatedAt: now.Add(-100 * time.Hour),
+			VerificationInfo: &types.VerificationInfo{VerificationCode: "V456", VerifiedAt: &verifiedAt},
+		}
+
+		orderRepo := &fakeAutoCompleteOrderRepository{candidates: []*types.Order{overdue, recent, awaitingVerification, verified}}
+		statusService := &recordingOrderStatusService{updates: map[uint64]*types.UpdateOrderStatusRequest{}}
+		timeoutService := NewOrderTimeoutServiceForTest(orderRepo, statusService, nil, nil)
+		config := &types.OrderTimeoutConfig{TenantID: 1, AutoCompleteEnabled: true, AutoCompleteAfterHours: 72}
+
+		Convey("超过等待时长的订单由系统自动完成", func() {
+			So(timeoutService.autoCompleteOrdersByConfig(ctx, config), ShouldBeNil)
+
+			req, exists := statusService.updates[1]
+			So(exists, ShouldBeTrue)
+			So(req.Status, ShouldEqual, types.OrderStatusIntCompleted)
+			So(req.OperatorType, ShouldEqual, types.OrderStatusOperatorTypeSystem)
+			So(statusService.updates, ShouldContainKey, uint64(4))
+		})
+
+		Convey("未到等待时长的订单不自动完成", func() {
+			So(timeoutService.autoCompleteOrdersByConfig(ctx, config), ShouldBeNil)
+			So(statusService.updates, ShouldNotContainKey, uint64(2))
+		})
+
+		Convey("等待核销的订单不自动完成", func() {
+			So(timeoutService.autoCompleteOrdersByConfig(ctx, config), ShouldBeNil)
+			So(statusService.updates, ShouldNotContainKey, uint64(3))
+		})
+
+		Convey("未启用自动完成时不处理任何订单", func() {
+			config.AutoCompleteEnabled = false
+			So(timeoutService.autoCompleteOrdersByConfig(ctx, config), ShouldBeNil)
+			So(len(statusService.updates), ShouldEqual, 0)
+		})
+
+		Convey("等待时长边界判断", func() {
+			order := &types.Order{Status: types.OrderStatusProcessing, StatusUpdatedAt: now.Add(-72 * time.Hour)}
+			So(shouldAutoComplete(order, config, now), ShouldBeTrue)
+			So(shouldAutoComplete(order, config, now.Add(-time.Minute)), ShouldBeFalse)
+
+			order.Status = types.OrderStatusCompleted
+			So(shouldAutoComplete(order, config, now), ShouldBeFalse)
+		})
+
+		Convey("含预订商品的订单从预计补货日期起计算等待时长", func() {
+			restockAt := now.Add(-24 * time.Hour)
+			order := &types.Order{
+				Status:          types.OrderStatusProcessing,
+				StatusUpdatedAt: now.Add(-96 * time.Hour),
+				Items: []types.OrderItem{
+					{ProductID: 1, Quantity: 2},
+					{ProductID: 2, Quantity: 3, BackorderedQuantity: 1, EstimatedRestockAt: &restockAt},
+				},
+			}
+			So(shouldAutoComplete(order, config, now), ShouldBeFalse)
+			So(shouldAutoComplete(order, config, now.Add(48*time.Hour)), ShouldBeTrue)
+
+			// 未提供补货日期的预订订单不自动完成
+			order.Items[1].EstimatedRestockAt = nil
+			So(shouldAutoComplete(order, config, now.Add(480*time.Hour)), ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,410 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+// 订单 Webhook 请求头，接收方用签名头校验请求来源
+const (
+	OrderWebhookSignatureHeader = "X-Webhook-Signature"
+	OrderWebhookTimestampHeader = "X-Webhook-Timestamp"
+	OrderWebhookEventHeader     = "X-Webhook-Event"
+	OrderWebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+const (
+	orderWebhookTimeout = 10 * time.Second
+	// 推送记录中保存的响应内容长度上限
+	orderWebhookResponseLimit = 200
+)
+
+// ErrOrderWebhookNotFound Webhook 不存在或不属于当前租户
+var ErrOrderWebhookNotFound = errors.New("Webhook不存在")
+
+// SignOrderWebhook 计算推送签名：hex(HMAC-SHA256(secret, "<timestamp>.<body>"))，
+// 签名头格式为 "sha256=<签名>"
+func SignOrderWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// OrderEventPublisher 发布订单生命周期事件
+type OrderEventPublisher interface {
+	PublishOrderEvent(ctx context.Context, event types.OrderWebhookEvent, order *types.Order, data map[string]interface{}) error
+}
+
+// OrderWebhookService 订单 Webhook 服务：管理租户的推送配置，
+// 订单事件按订阅的 Webhook 拆分为发件箱事件，由发件箱投递器签名推送并按退避策略重试
+type OrderWebhookService struct {
+	webhookRepo repository.IOrderWebhookRepository
+	outboxRepo  repository.IOutboxRepository
+	client      *http.Client
+}
+
+// NewOrderWebhookService 创建订单 Webhook 服务实例
+func NewOrderWebhookService() *OrderWebhookService {
+	return &OrderWebhookService{
+		webhookRepo: repository.NewOrderWebhookRepository(),
+		outboxRepo:  repository.NewOutboxRepository(),
+		client:      &http.Client{Timeout: orderWebhookTimeout},
+	}
+}
+
+// NewOrderWebhookServiceForTest 创建测试用订单 Webhook 服务实例
+func NewOrderWebhookServiceForTest(webhookRepo repository.IOrderWebhookRepository, outboxRepo repository.IOutboxRepository, client *http.Client) *OrderWebhookService {
+	return &OrderWebhookService{
+		webhookRepo: webhookRepo,
+		outboxRepo:  outboxRepo,
+		client:      client,
+	}
+}
+
+// Create 创建 Webhook，未指定签名密钥时自动生成；密钥只在创建时返回
+func (s *OrderWebhookService) Create(ctx context.Context, req *types.OrderWebhookRequest) (*types.OrderWebhookCreated, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	webhook := &types.OrderWebhook{
+		URL:            req.URL,
+		Secret:         secret,
+		Events:         webhookEvents(req.Events),
+		ConfigSections: types.StringArray(req.ConfigSections),
+		Enabled:        req.Enabled == nil || *req.Enabled,
+	}
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "order_webhook", "create", map[string]interface{}{
+		"webhook_id":      webhook.ID,
+		"url":             webhook.URL,
+		"events":          webhook.Events,
+		"config_sections": webhook.ConfigSections,
+	})
+
+	return &types.OrderWebhookCreated{OrderWebhook: *webhook, Secret: secret}, nil
+}
+
+// Update 更新 Webhook，签名密钥为空时保留原密钥
+func (s *OrderWebhookService) Update(ctx context.Context, id uint64, req *types.OrderWebhookRequest) (*types.OrderWebhook, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.getWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.URL = req.URL
+	webhook.Events = webhookEvents(req.Events)
+	webhook.ConfigSections = types.StringArray(req.ConfigSections)
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "order_webhook", "update", map[string]interface{}{
+		"webhook_id":      webhook.ID,
+		"url":             webhook.URL,
+		"events":          webhook.Events,
+		"config_sections": webhook.ConfigSections,
+		"enabled":         webhook.Enabled,
+		"secret_rotated":  req.Secret != "",
+	})
+	return webhook, nil
+}
+
+// Delete 删除 Webhook，已排队的推送在投递时丢弃
+func (s *OrderWebhookService) Delete(ctx context.Context, id uint64) error {
+	if _, err := s.getWebhook(ctx, id); err != nil {
+		return err
+	}
+	if err := s.webhookRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	audit.LogOperation(ctx, "order_webhook", "delete", map[string]interface{}{"webhook_id": id})
+	return nil
+}
+
+// List 获取当前租户的 Webhook 列表
+func (s *OrderWebhookService) List(ctx context.Context) ([]types.OrderWebhook, error) {
+	return s.webhookRepo.List(ctx)
+}
+
+// ListDeliveries 获取 Webhook 最近的推送记录
+func (s *OrderWebhookService) ListDeliveries(ctx context.Context, id uint64, limit int) ([]types.OrderWebhookDelivery, error) {
+	if _, err := s.getWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	return s.webhookRepo.ListDeliveries(ctx, id, limit)
+}
+
+// TestFire 立即向 Webhook 推送一条测试事件（不重试），返回推送记录
+func (s *OrderWebhookService) TestFire(ctx context.Context, id uint64) (*types.OrderWebhookDelivery, error) {
+	webhook, err := s.getWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	payload := &types.OrderWebhookPayload{
+		ID:         guid.S(),
+		Event:      types.OrderWebhookEventTest,
+		TenantID:   webhook.TenantID,
+		OccurredAt: now,
+		Order: &types.OrderWebhookOrderSummary{
+			OrderNumber: "TEST-ORDER",
+			Status:      types.OrderStatusPending,
+			Items:       []types.OrderItem{},
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化推送内容失败: %v", err)
+	}
+
+	delivery := s.send(ctx, webhook, payload.ID, payload.Event, body)
+	delivery.Attempt = 1
+	if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		g.Log().Warning(ctx, "记录Webhook测试推送失败", "webhook_id", webhook.ID, "error", err)
+	}
+	return delivery, nil
+}
+
+// PublishOrderEvent 为订阅了该事件的每个启用 Webhook 写入一条发件箱推送任务。
+// 载荷在事件发生时生成，重试时内容和事件ID保持不变
+func (s *OrderWebhookService) PublishOrderEvent(ctx context.Context, event types.OrderWebhookEvent, order *types.Order, data map[string]interface{}) error {
+	tenantID := order.TenantID
+	if tenantID > 0 {
+		ctx = context.WithValue(ctx, "tenant_id", tenantID)
+	} else {
+		tenantID = gconv.Uint64(ctx.Value("tenant_id"))
+	}
+
+	webhooks, err := s.webhookRepo.ListEnabled(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload := &types.OrderWebhookPayload{
+		ID:         guid.S(),
+		Event:      event,
+		TenantID:   tenantID,
+		OccurredAt: time.Now(),
+		Order:      types.NewOrderWebhookOrderSummary(order),
+		Data:       data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化推送内容失败: %v", err)
+	}
+
+	var failures []string
+	for i := range webhooks {
+		webhook := &webhooks[i]
+		if !webhook.Subscribes(event) {
+			continue
+		}
+
+		task, err := json.Marshal(&types.OrderWebhookDeliveryEvent{
+			WebhookID: webhook.ID,
+			EventID:   payload.ID,
+			Event:     event,
+			Body:      string(body),
+		})
+		if err != nil {
+			return fmt.Errorf("序列化推送任务失败: %v", err)
+		}
+
+		err = s.outboxRepo.Enqueue(ctx, &types.OutboxEvent{
+			TenantID:      tenantID,
+			AggregateType: "order",
+			AggregateID:   order.ID,
+			EventType:     types.OutboxEventOrderWebhook,
+			Payload:       string(task),
+		})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("Webhook%d: %v", webhook.ID, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("写入Webhook推送任务失败: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Deliver 投递一条发件箱推送任务并记录推送结果，推送失败时返回错误由发件箱按退避策略重试；
+// Webhook 已删除或停用时丢弃任务
+func (s *OrderWebhookService) Deliver(ctx context.Context, event *types.OutboxEvent) error {
+	var task types.OrderWebhookDeliveryEvent
+	if err := json.Unmarshal([]byte(event.Payload), &task); err != nil {
+		return fmt.Errorf("解析推送任务失败: %v", err)
+	}
+
+	webhook, err := s.webhookRepo.GetByID(ctx, task.WebhookID)
+	if err != nil {
+		return err
+	}
+	if webhook == nil || !webhook.Enabled {
+		g.Log().Info(ctx, "Webhook已删除或停用，丢弃推送任务",
+			"webhook_id", task.WebhookID,
+			"event_id", task.EventID)
+		return nil
+	}
+
+	delivery := s.send(ctx, webhook, task.EventID, task.Event, []byte(task.Body))
+	delivery.Attempt = event.Attempts + 1
+	if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		g.Log().Warning(ctx, "记录Webhook推送失败", "webhook_id", webhook.ID, "event_id", task.EventID, "error", err)
+	}
+
+	if !delivery.Success {
+		return fmt.Errorf("推送Webhook%d失败: %s", webhook.ID, delivery.Error)
+	}
+	return nil
+}
+
+// send 签名并推送，2xx 响应视为成功
+func (s *OrderWebhookService) send(ctx context.Context, webhook *types.OrderWebhook, eventID string, event types.OrderWebhookEvent, body []byte) *types.OrderWebhookDelivery {
+	delivery := &types.OrderWebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   eventID,
+		Event:     event,
+	}
+
+	start := time.Now()
+	defer func() {
+		delivery.DurationMs = time.Since(start).Milliseconds()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(OrderWebhookEventHeader, string(event))
+	req.Header.Set(OrderWebhookDeliveryHeader, eventID)
+	req.Header.Set(OrderWebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(OrderWebhookSignatureHeader, SignOrderWebhook(webhook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	defer resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		content, _ := io.ReadAll(io.LimitReader(resp.Body, orderWebhookResponseLimit))
+		delivery.Error = fmt.Sprintf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(content)))
+	}
+	return delivery
+}
+
+// getWebhook 获取当前租户的 Webhook
+func (s *OrderWebhookService) getWebhook(ctx context.Context, id uint64) (*types.OrderWebhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil {
+		return nil, ErrOrderWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// RegisterOrderWebhookHook 注册订单状态变更推送钩子，支付、完成、取消时推送订阅的 Webhook
+func RegisterOrderWebhookHook(registry *StatusHookRegistry, publisher OrderEventPublisher) error {
+	return registry.Register("order_webhooks", AnyOrderStatus, AnyOrderStatus,
+		func(ctx context.Context, order *types.Order, transition *types.OrderStatusChangedEvent) error {
+			event := types.OrderWebhookEventForStatus(transition.ToStatus)
+			if event == "" {
+				return nil
+			}
+			return publisher.PublishOrderEvent(ctx, event, order, map[string]interface{}{
+				"from_status": transition.FromStatus,
+				"to_status":   transition.ToStatus,
+				"reason":      transition.Reason,
+			})
+		})
+}
+
+// publishOrderEvent 发布订单事件，发布失败只记录日志不影响业务操作
+func publishOrderEvent(ctx context.Context, publisher OrderEventPublisher, event types.OrderWebhookEvent, order *types.Order, data map[string]interface{}) {
+	if publisher == nil {
+		return
+	}
+	if err := publisher.PublishOrderEvent(ctx, event, order, data); err != nil {
+		g.Log().Error(ctx, "发布订单Webhook事件失败", "order_id", order.ID, "event", event, "error", err)
+	}
+}
+
+func webhookEvents(events []types.OrderWebhookEvent) types.StringArray {
+	result := make(types.StringArray, 0, len(events))
+	seen := make(map[types.OrderWebhookEvent]bool, len(events))
+	for _, event := range events {
+		if !seen[event] {
+			seen[event] = true
+			result = append(result, string(event))
+		}
+	}
+	return result
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成签名密钥失败: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/cache"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryOrderWebhookRepository 按租户隔离的内存 Webhook 仓储
+type memoryOrderWebhookRepository struct {
+	webhooks   []types.OrderWebhook
+	deliveries []types.OrderWebhookDelivery
+}
+
+func (m *memoryOrderWebhookRepository) Create(ctx context.Context, webhook *types.OrderWebhook) error {
+	webhook.ID = uint64(len(m.webhooks) + 1)
+	webhook.TenantID = gconv.Uint64(ctx.Value("tenant_id"))
+	m.webhooks = append(m.webhooks, *webhook)
+	return nil
+}
+
+func (m *memoryOrderWebhookRepository) Update(ctx context.Context, webhook *types.OrderWebhook) error {
+	m.webhooks[webhook.ID-1] = *webhook
+	return nil
+}
+
+func (m *memoryOrderWebhookRepository) Delete(ctx context.Context, id uint64) error {
+	m.webhooks[id-1].TenantID = 0
+	return nil
+}
+
+func (m *memoryOrderWebhookRepository) GetByID(ctx context.Context, id uint64) (*types.OrderWebhook, error) {
+	if id == 0 || int(id) > len(m.webhooks) || m.webhooks[id-1].TenantID != gconv.Uint64(ctx.Value("tenant_id")) {
+		return nil, nil
+	}
+	webhook := m.webhooks[id-1]
+	return &webhook, nil
+}
+
+func (m *memoryOrderWebhookRepository) List(ctx context.Context) ([]types.OrderWebhook, error) {
+	var result []types.OrderWebhook
+	for _, webhook := range m.webhooks {
+		if webhook.TenantID == gconv.Uint64(ctx.Value("tenant_id")) {
+			result = append(result, webhook)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryOrderWebhookRepository) ListEnabled(ctx context.Context) ([]types.OrderWebhook, error) {
+	webhooks, _ := m.List(ctx)
+	var result []types.OrderWebhook
+	for _, webhook := range webhooks {
+		if webhook.Enabled {
+			result = append(result, webhook)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryOrderWebhookRepository) CreateDelivery(ctx context.Context, delivery *types.OrderWebhookDelivery) error {
+	delivery.ID = uint64(len(m.deliveries) + 1)
+	delivery.TenantID = gconv.Uint64(ctx.Value("tenant_id"))
+	m.deliveries = append(m.deliveries, *delivery)
+	return nil
+}
+
+func (m *memoryOrderWebhookRepository) ListDeliveries(ctx context.Context, webhookID uint64, limit int) ([]types.OrderWebhookDelivery, error) {
+	var result []types.OrderWebhookDelivery
+	for i := len(m.deliveries) - 1; i >= 0 && len(result) < limit; i-- {
+		if m.deliveries[i].WebhookID == webhookID {
+			result = append(result, m.deliveries[i])
+		}
+	}
+	return result, nil
+}
+
+// webhookReceiver 记录收到的推送请求，按配置的次数返回失败
+type webhookReceiver struct {
+	mu       sync.Mutex
+	failures int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (w *webhookReceiver) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failures > 0 {
+		w.failures--
+		http.Error(rw, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.requests = append(w.requests, r)
+	w.bodies = append(w.bodies, body)
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (w *webhookReceiver) events() []types.OrderWebhookEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var events []types.OrderWebhookEvent
+	for _, r := range w.requests {
+		events = append(events, types.OrderWebhookEvent(r.Header.Get(OrderWebhookEventHeader)))
+	}
+	return events
+}
+
+func TestSignOrderWebhook(t *testing.T) {
+	Convey("Webhook 签名测试", t, func() {
+		body := []byte(`{"event":"order.paid"}`)
+		signature := SignOrderWebhook("0123456789abcdef", 1700000000, body)
+
+		So(signature, ShouldStartWith, "sha256=")
+		So(signature, ShouldHaveLength, len("sha256=")+64)
+		So(SignOrderWebhook("0123456789abcdef", 1700000000, body), ShouldEqual, signature)
+		So(SignOrderWebhook("0123456789abcdeX", 1700000000, body), ShouldNotEqual, signature)
+		So(SignOrderWebhook("0123456789abcdef", 1700000001, body), ShouldNotEqual, signature)
+		So(SignOrderWebhook("0123456789abcdef", 1700000000, []byte(`{"event":"order.refunded"}`)), ShouldNotEqual, signature)
+	})
+}
+
+func TestOrderWebhookDelivery(t *testing.T) {
+	Convey("订单 Webhook 推送测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+		receiver := &webhookReceiver{}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+
+		webhookRepo := &memoryOrderWebhookRepository{}
+		outbox := &fakeOutboxRepository{}
+		order := &types.Order{
+			ID:          1,
+			TenantID:    1,
+			OrderNumber: "ORD202601010001",
+			CustomerID:  100,
+			Status:      types.OrderStatusPaid,
+			Items:       []types.OrderItem{{ProductID: 10, Quantity: 2, Price: 100}},
+			TotalAmount: 200,
+		}
+		orderRepo := &fakeOutboxOrderRepository{orders: map[uint64]*types.Order{1: order}, outbox: outbox}
+
+		webhookService := NewOrderWebhookServiceForTest(webhookRepo, outbox, server.Client())
+		hooks := NewStatusHookRegistry()
+		So(RegisterOrderWebhookHook(hooks, webhookService), ShouldBeNil)
+		dispatcher := NewOutboxDispatcherForTest(outbox, orderRepo, hooks, nil, nil, webhookService, cache.NewMockCache())
+
+		created, err := webhookService.Create(ctx, &types.OrderWebhookRequest{
+			URL:    server.URL,
+			Secret: "0123456789abcdef",
+			Events: []types.OrderWebhookEvent{types.OrderWebhookEventCreated, types.OrderWebhookEventCompleted, types.OrderWebhookEventRefunded},
+		})
+		So(err, ShouldBeNil)
+		So(created.Secret, ShouldEqual, "0123456789abcdef")
+		So(created.Enabled, ShouldBeTrue)
+
+		dispatchAll := func() {
+			for i := 0; i < 3; i++ {
+				_, err := dispatcher.DispatchPending(context.Background())
+				So(err, ShouldBeNil)
+			}
+		}
+
+		Convey("推送请求带有可校验的签名和完整订单摘要", func() {
+			So(webhookService.PublishOrderEvent(ctx, types.OrderWebhookEventCreated, order, nil), ShouldBeNil)
+			dispatchAll()
+
+			So(receiver.requests, ShouldHaveLength, 1)
+			request := receiver.requests[0]
+			timestamp, err := strconv.ParseInt(request.Header.Get(OrderWebhookTimestampHeader), 10, 64)
+			So(err, ShouldBeNil)
+			So(request.Header.Get(OrderWebhookSignatureHeader), ShouldEqual, SignOrderWebhook("0123456789abcdef", timestamp, receiver.bodies[0]))
+
+			var payload types.OrderWebhookPayload
+			So(json.Unmarshal(receiver.bodies[0], &payload), ShouldBeNil)
+			So(payload.Event, ShouldEqual, types.OrderWebhookEventCreated)
+			So(payload.ID, ShouldEqual, request.Header.Get(OrderWebhookDeliveryHeader))
+			So(payload.TenantID, ShouldEqual, 1)
+			So(payload.Order.OrderNumber, ShouldEqual, "ORD202601010001")
+			So(payload.Order.TotalAmount, ShouldEqual, 200)
+			So(payload.Order.Items, ShouldHaveLength, 1)
+		})
+
+		Convey("租户配置变更推送带有签名和订阅分区内的差异", func() {
+			configHook, err := webhookService.Create(ctx, &types.OrderWebhookRequest{
+				URL:            server.URL,
+				Secret:         "fedcba9876543210",
+				Events:         []types.OrderWebhookEvent{types.OrderWebhookEventTenantConfigChanged},
+				ConfigSections: []string{"settings"},
+			})
+			So(err, ShouldBeNil)
+			So(configHook.ConfigSections, ShouldResemble, types.StringArray{"settings"})
+
+			changes, err := types.DiffTenantConfig(
+				&types.TenantConfig{MaxUsers: 10, Settings: map[string]string{"currency": "CNY"}},
+				&types.TenantConfig{MaxUsers: 20, Settings: map[string]string{"currency": "USD"}},
+			)
+			So(err, ShouldBeNil)
+			body, _ := json.Marshal(types.NewTenantConfigWebhookPayload("cfg-1", 1, time.Now(), types.FilterTenantConfigChanges(changes, configHook.ConfigSections)))
+			task, _ := json.Marshal(&types.OrderWebhookDeliveryEvent{
+				WebhookID: configHook.ID,
+				EventID:   "cfg-1",
+				Event:     types.OrderWebhookEventTenantConfigChanged,
+				Body:      string(body),
+			})
+			So(outbox.Enqueue(ctx, &types.OutboxEvent{TenantID: 1, EventType: types.OutboxEventOrderWebhook, Payload: string(task)}), ShouldBeNil)
+			dispatchAll()
+
+			So(receiver.events(), ShouldResemble, []types.OrderWebhookEvent{types.OrderWebhookEventTenantConfigChanged})
+			request := receiver.requests[0]
+			timestamp, err := strconv.ParseInt(request.Header.Get(OrderWebhookTimestampHeader), 10, 64)
+			So(err, ShouldBeNil)
+			So(request.Header.Get(OrderWebhookSignatureHeader), ShouldEqual, SignOrderWebhook("fedcba9876543210", timestamp, receiver.bodies[0]))
+			So(request.Header.Get(OrderWebhookDeliveryHeader), ShouldEqual, "cfg-1")
+
+			var payload types.TenantConfigWebhookPayload
+			So(json.Unmarshal(receiver.bodies[0], &payload), ShouldBeNil)
+			So(payload.Sections, ShouldResemble, []string{"settings"})
+			So(payload.Changes, ShouldResemble, []types.TenantConfigChange{
+				{Key: "settings.currency", Section: "settings", OldValue: "CNY", NewValue: "USD"},
+			})
+		})
+
+		Convey("每个订阅的事件只推送一次，未订阅的事件不推送", func() {
+			So(webhookService.PublishOrderEvent(ctx, types.OrderWebhookEventCreated, order, nil), ShouldBeNil)
+			So(webhookService.PublishOrderEvent(ctx, types.OrderWebhookEventPaid, order, nil), ShouldBeNil)
+			So(orderRepo.UpdateStatusWithHistory(ctx, 1, types.OrderStatusIntCompleted, "确认收货", types.OrderStatusOperatorTypeCustomer, nil, nil), ShouldBeNil)
+			So(webhookService.PublishOrderEvent(ctx, types.OrderWebhookEventRefunded, order, map[string]interface{}{"refund_amount": 50}), ShouldBeNil)
+			dispatchAll()
+
+			So(receiver.events(), ShouldResemble, []types.OrderWebhookEvent{
+				types.OrderWebhookEventCreated,
+				types.OrderWebhookEventRefunded,
+				types.OrderWebhookEventCompleted,
+			})
+			So(webhookRepo.deliveries, ShouldHaveLength, 3)
+		})
+
+		Convey("推送失败时按退避策略重试并记录每次尝试", func() {
+			receiver.failures = 1
+			So(webhookService.PublishOrderEvent(ctx, types.OrderWebhookEventCreated, order, nil), ShouldBeNil)
+
+			_, err := dispatcher.DispatchPending(context.Background())
+			So(err, ShouldBeNil)
+			So(receiver.requests, ShouldHaveLength, 0)
+			So(outbox.events[0].Status, ShouldEqual, types.OutboxEventStatusPending)
+			So(outbox.events[0].AvailableAt.After(time.Now()), ShouldBeTrue)
+
+			outbox.events[0].AvailableAt = time.Now()
+			dispatchAll()
+			So(receiver.requests, ShouldHaveLength, 1)
+			So(outbox.events[0].Status, ShouldEqual, types.OutboxEventStatusProcessed)
+
+			deliveries, err := webhookService.ListDeliveries(ctx, created.ID, 10)
+			So(err, ShouldBeNil)
+			So(deliveries, ShouldHaveLength, 2)
+			So(deliveries[0].Attempt, ShouldEqual, 2)
+			So(deliveries[0].Success, ShouldBeTrue)
+			So(deliveries[1].Attempt, ShouldEqual, 1)
+			So(deliveries[1].Success, ShouldBeFalse)
+			So(deliveries[1].StatusCode, ShouldEqual, http.StatusServiceUnavailable)
+		})
+
+		Convey("停用的 Webhook 不再推送已排队的事件", func() {
+			So(webhookService.PublishOrderEvent(ctx, types.OrderWebhookEventCreated, order, nil), ShouldBeNil)
+			disabled := false
+			_, err := webhookService.Update(ctx, created.ID, &types.OrderWebhookRequest{
+				URL:     server.URL,
+				Events:  []types.OrderWebhookEvent{types.OrderWebhookEventCreated},
+				Enabled: &disabled,
+			})
+			So(err, ShouldBeNil)
+
+			dispatchAll()
+			So(receiver.requests, ShouldHaveLength, 0)
+			So(outbox.events[0].Status, ShouldEqual, types.OutboxEventStatusProcessed)
+		})
+
+		Convey("其他租户的 Webhook 不接收推送", func() {
+			otherCtx := context.WithValue(context.Background(), "tenant_id", uint64(2))
+			otherOrder := *order
+			otherOrder.TenantID = 2
+			So(webhookService.PublishOrderEvent(otherCtx, types.OrderWebhookEventCreated, &otherOrder, nil), ShouldBeNil)
+			So(outbox.events, ShouldHaveLength, 0)
+
+			_, err := webhookService.TestFire(otherCtx, created.ID)
+			So(err, ShouldEqual, ErrOrderWebhookNotFound)
+		})
+
+		Convey("测试推送立即发送并记录推送结果", func() {
+			delivery, err := webhookService.TestFire(ctx, created.ID)
+			So(err, ShouldBeNil)
+			So(delivery.Success, ShouldBeTrue)
+			So(delivery.Attempt, ShouldEqual, 1)
+			So(receiver.events(), ShouldResemble, []types.OrderWebhookEvent{types.OrderWebhookEventTest})
+			So(outbox.events, ShouldHaveLength, 0)
+		})
+	})
+}
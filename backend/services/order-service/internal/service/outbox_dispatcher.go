@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/cache"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	outboxDispatchInterval = 5 * time.Second
+	outboxBatchSize        = 100
+	outboxMaxRetryDelay    = 30 * time.Minute
+	// 已投递标记保留时间，覆盖投递后标记失败导致的重复投递窗口
+	outboxDeliveredTTL = 7 * 24 * time.Hour
+)
+
+// OutboxDispatcher 发件箱投递器：读取未投递的事件并调用订阅的状态变更钩子、发送免打扰时段延迟的通知、推送订单 Webhook，投递成功后标记完成
+type OutboxDispatcher struct {
+	outboxRepo   repository.IOutboxRepository
+	orderRepo    repository.IOrderRepository
+	hooks        *StatusHookRegistry
+	smsService   SMSService
+	emailService EmailService
+	webhooks     *OrderWebhookService
+	delivered    *cache.Cache
+	stopCh       chan struct{}
+	isRunning    bool
+}
+
+// NewOutboxDispatcher 创建发件箱投递器
+func NewOutboxDispatcher(notificationService NotificationService) *OutboxDispatcher {
+	webhooks := NewOrderWebhookService()
+	return &OutboxDispatcher{
+		outboxRepo: repository.NewOutboxRepository(),
+		orderRepo:  repository.NewOrderRepository(),
+		hooks:      NewDefaultStatusHookRegistry(notificationService, webhooks),
+		// 延迟通知到期后直接发送，不再经过免打扰时段判断
+		smsService:   NewSMSService(),
+		emailService: NewEmailService(),
+		webhooks:     webhooks,
+		delivered:    cache.NewCache("outbox_delivered"),
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// NewOutboxDispatcherForTest 创建测试用发件箱投递器
+func NewOutboxDispatcherForTest(outboxRepo repository.IOutboxRepository, orderRepo repository.IOrderRepository, hooks *StatusHookRegistry, smsService SMSService, emailService EmailService, webhooks *OrderWebhookService, delivered *cache.Cache) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		outboxRepo:   outboxRepo,
+		orderRepo:    orderRepo,
+		hooks:        hooks,
+		smsService:   smsService,
+		emailService: emailService,
+		webhooks:     webhooks,
+		delivered:    delivered,
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Start 启动后台投递循环，启动时会先投递重启前遗留的事件
+func (d *OutboxDispatcher) Start(ctx context.Context) {
+	if d.isRunning {
+		return
+	}
+	d.isRunning = true
+	g.Log().Info(ctx, "启动发件箱投递器")
+
+	go func() {
+		ticker := time.NewTicker(outboxDispatchInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := d.DispatchPending(ctx); err != nil {
+				g.Log().Error(ctx, "投递发件箱事件失败", "error", err)
+			}
+
+			select {
+			case <-d.stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop 停止后台投递循环
+func (d *OutboxDispatcher) Stop(ctx context.Context) {
+	if !d.isRunning {
+		return
+	}
+	d.isRunning = false
+	close(d.stopCh)
+	g.Log().Info(ctx, "发件箱投递器已停止")
+}
+
+// DispatchPending 投递一批待投递事件，返回成功投递的数量
+func (d *OutboxDispatcher) DispatchPending(ctx context.Context) (int, error) {
+	events, err := d.outboxRepo.FetchPending(ctx, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for i := range events {
+		event := &events[i]
+		if err := d.dispatch(ctx, event); err != nil {
+			g.Log().Warning(ctx, "发件箱事件投递失败，稍后重试",
+				"event_id", event.ID,
+				"event_type", event.EventType,
+				"attempts", event.Attempts+1,
+				"error", err)
+			if markErr := d.outboxRepo.MarkFailed(ctx, event.TenantID, event.ID, err.Error(), time.Now().Add(retryDelay(event.Attempts))); markErr != nil {
+				g.Log().Error(ctx, "记录事件投递失败失败", "event_id", event.ID, "error", markErr)
+			}
+			continue
+		}
+
+		if err := d.outboxRepo.MarkProcessed(ctx, event.TenantID, event.ID); err != nil {
+			// 钩子已执行，下次重试时由已投递标记去重
+			g.Log().Error(ctx, "标记事件已投递失败", "event_id", event.ID, "error", err)
+			continue
+		}
+		dispatched++
+	}
+
+	return dispatched, nil
+}
+
+// outboxEventKey 事件的已投递标记键。各数据源的事件ID独立自增，租户只属于一个数据源，按租户和事件ID区分
+func outboxEventKey(event *types.OutboxEvent) string {
+	return fmt.Sprintf("%d-%d", event.TenantID, event.ID)
+}
+
+// dispatch 投递单个事件，同一事件只会成功投递一次
+func (d *OutboxDispatcher) dispatch(ctx context.Context, event *types.OutboxEvent) error {
+	deliveredKey := outboxEventKey(event)
+	if delivered, _ := d.delivered.Exists(ctx, deliveredKey); delivered {
+		return nil
+	}
+
+	// 后台投递没有请求上下文，按事件所属租户设置租户上下文
+	tenantCtx := context.WithValue(ctx, "tenant_id", event.TenantID)
+
+	switch event.EventType {
+	case types.OutboxEventOrderStatusChanged:
+		if err := d.handleOrderStatusChanged(tenantCtx, event); err != nil {
+			return err
+		}
+	case types.OutboxEventNotificationDeferred:
+		if err := d.handleDeferredNotification(tenantCtx, event); err != nil {
+			return err
+		}
+	case types.OutboxEventOrderWebhook:
+		if d.webhooks == nil {
+			g.Log().Warning(ctx, "未配置订单Webhook服务，丢弃推送任务", "event_id", event.ID)
+			return nil
+		}
+		if err := d.webhooks.Deliver(tenantCtx, event); err != nil {
+			return err
+		}
+	default:
+		g.Log().Warning(ctx, "未知的发件箱事件类型，直接标记完成", "event_id", event.ID, "event_type", event.EventType)
+		return nil
+	}
+
+	if err := d.delivered.Set(ctx, deliveredKey, 1, outboxDeliveredTTL); err != nil {
+		g.Log().Warning(ctx, "记录事件已投递标记失败", "event_id", event.ID, "error", err)
+	}
+	return nil
+}
+
+// handleOrderStatusChanged 执行订阅了该状态变更的钩子。
+// 每个钩子单独记录已执行标记，事件重试时只重新执行失败的钩子，保证每次状态变更每个钩子只执行一次
+func (d *OutboxDispatcher) handleOrderStatusChanged(ctx context.Context, event *types.OutboxEvent) error {
+	var payload types.OrderStatusChangedEvent
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return fmt.Errorf("解析事件载荷失败: %v", err)
+	}
+
+	hooks := d.hooks.Hooks(payload.FromStatus, payload.ToStatus)
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	order, err := d.orderRepo.GetByID(ctx, payload.OrderID)
+	if err != nil {
+		return fmt.Errorf("获取订单信息失败: %v", err)
+	}
+
+	var failures []string
+	for _, hook := range hooks {
+		hookKey := fmt.Sprintf("%s:%s", outboxEventKey(event), hook.Name)
+		if executed, _ := d.delivered.Exists(ctx, hookKey); executed {
+			continue
+		}
+
+		if err := hook.Hook(ctx, order, &payload); err != nil {
+			g.Log().Warning(ctx, "订单状态变更钩子执行失败",
+				"event_id", event.ID,
+				"order_id", payload.OrderID,
+				"hook", hook.Name,
+				"error", err)
+			failures = append(failures, fmt.Sprintf("%s: %v", hook.Name, err))
+			continue
+		}
+
+		if err := d.delivered.Set(ctx, hookKey, 1, outboxDeliveredTTL); err != nil {
+			g.Log().Warning(ctx, "记录钩子已执行标记失败", "event_id", event.ID, "hook", hook.Name, "error", err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("状态变更钩子执行失败: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// handleDeferredNotification 发送免打扰时段内延迟的通知
+func (d *OutboxDispatcher) handleDeferredNotification(ctx context.Context, event *types.OutboxEvent) error {
+	var notification types.DeferredNotification
+	if err := json.Unmarshal([]byte(event.Payload), &notification); err != nil {
+		return fmt.Errorf("解析事件载荷失败: %v", err)
+	}
+
+	var err error
+	switch notification.Channel {
+	case types.NotificationChannelSMS:
+		err = d.smsService.SendSMS(ctx, notification.UserID, notification.TemplateCode, notification.Content)
+	case types.NotificationChannelEmail:
+		err = d.emailService.SendEmail(ctx, notification.UserID, notification.Subject, notification.Content)
+	default:
+		g.Log().Warning(ctx, "未知的通知渠道，丢弃延迟通知", "event_id", event.ID, "channel", notification.Channel)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("发送延迟通知失败: %v", err)
+	}
+
+	g.Log().Info(ctx, "延迟通知已发送",
+		"event_id", event.ID,
+		"user_id", notification.UserID,
+		"channel", notification.Channel,
+		"deferred_at", notification.DeferredAt)
+	return nil
+}
+
+// retryDelay 按失败次数指数退避，最长30分钟
+func retryDelay(attempts int) time.Duration {
+	delay := outboxDispatchInterval
+	for i := 0; i < attempts && delay < outboxMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxRetryDelay {
+		delay = outboxMaxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/cache"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeOutboxRepository 内存发件箱
+type fakeOutboxRepository struct {
+	events       []types.OutboxEvent
+	failMarkOnce bool
+}
+
+func (f *fakeOutboxRepository) FetchPending(ctx context.Context, limit int) ([]types.OutboxEvent, error) {
+	var pending []types.OutboxEvent
+	for _, event := range f.events {
+		if event.Status == types.OutboxEventStatusPending && !event.AvailableAt.After(time.Now()) {
+			pending = append(pending, event)
+		}
+	}
+	return pending, nil
+}
+
+func (f *fakeOutboxRepository) MarkProcessed(ctx context.Context, tenantID, id uint64) error {
+	if f.failMarkOnce {
+		f.failMarkOnce = false
+		return fmt.Errorf("数据库连接中断")
+	}
+	f.events[id-1].Status = types.OutboxEventStatusProcessed
+	return nil
+}
+
+func (f *fakeOutboxRepository) MarkFailed(ctx context.Context, tenantID, id uint64, reason string, retryAt time.Time) error {
+	f.events[id-1].Attempts++
+	f.events[id-1].LastError = reason
+	f.events[id-1].AvailableAt = retryAt
+	return nil
+}
+
+func (f *fakeOutboxRepository) Enqueue(ctx context.Context, event *types.OutboxEvent) error {
+	event.ID = uint64(len(f.events) + 1)
+	event.Status = types.OutboxEventStatusPending
+	f.events = append(f.events, *event)
+	return nil
+}
+
+// fakeOutboxOrderRepository 状态更新时在“同一事务”中写入发件箱事件的订单仓储桩
+type fakeOutboxOrderRepository struct {
+	repository.IOrderRepository
+	orders map[uint64]*types.Order
+	outbox *fakeOutboxRepository
+}
+
+func (f *fakeOutboxOrderRepository) GetByID(ctx context.Context, id uint64) (*types.Order, error) {
+	order, exists := f.orders[id]
+	if !exists {
+		return nil, fmt.Errorf("订单不存在")
+	}
+	return order, nil
+}
+
+func (f *fakeOutboxOrderRepository) UpdateStatusWithHistory(ctx context.Context, id uint64, status types.OrderStatusInt, reason string, operatorType types.OrderStatusOperatorType, operatorID *uint64, metadata interface{}) error {
+	order := f.orders[id]
+	payload, _ := json.Marshal(&types.OrderStatusChangedEvent{
+		OrderID:      id,
+		FromStatus:   types.OrderStatusIntPaid,
+		ToStatus:     status,
+		Reason:       reason,
+		OperatorType: operatorType,
+	})
+	order.Status = status.ToOrderStatus()
+	f.outbox.events = append(f.outbox.events, types.OutboxEvent{
+		ID:          uint64(len(f.outbox.events) + 1),
+		TenantID:    order.TenantID,
+		EventType:   types.OutboxEventOrderStatusChanged,
+		Payload:     string(payload),
+		Status:      types.OutboxEventStatusPending,
+		AvailableAt: time.Now(),
+	})
+	return nil
+}
+
+// recordingNotificationService 记录状态变更通知的通知服务桩
+type recordingNotificationService struct {
+	NotificationService
+	failures int
+	sent     []*types.OrderStatusHistory
+}
+
+func (r *recordingNotificationService) SendOrderStatusChangedNotification(ctx context.Context, order *types.Order, statusHistory *types.OrderStatusHistory) error {
+	if r.failures > 0 {
+		r.failures--
+		return fmt.Errorf("短信网关不可用")
+	}
+	r.sent = append(r.sent, statusHistory)
+	return nil
+}
+
+func TestOutboxDispatcher(t *testing.T) {
+	Convey("发件箱投递测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		ctx = context.WithValue(ctx, "user_id", uint64(7))
+
+		outbox := &fakeOutboxRepository{}
+		orderRepo := &fakeOutboxOrderRepository{
+			orders: map[uint64]*types.Order{1: {ID: 1, TenantID: 1, Status: types.OrderStatusPaid}},
+			outbox: outbox,
+		}
+		notifier := &recordingNotificationService{}
+		hooks := NewStatusHookRegistry()
+		So(RegisterStatusNotificationHook(hooks, notifier), ShouldBeNil)
+		delivered := cache.NewMockCache()
+
+		// 状态更新提交后进程崩溃：请求内不发送通知，事件只存在于发件箱中
+		statusService := NewOrderStatusServiceForTest(orderRepo)
+		err := statusService.UpdateOrderStatus(ctx, 1, &types.UpdateOrderStatusRequest{
+			Status:       types.OrderStatusIntProcessing,
+			Reason:       "商户接单",
+			OperatorType: types.OrderStatusOperatorTypeMerchant,
+		})
+		So(err, ShouldBeNil)
+		So(len(notifier.sent), ShouldEqual, 0)
+		So(len(outbox.events), ShouldEqual, 1)
+
+		Convey("重启后的投递器投递崩溃前提交的事件", func() {
+			dispatcher := NewOutboxDispatcherForTest(outbox, orderRepo, hooks, nil, nil, nil, delivered)
+			count, err := dispatcher.DispatchPending(context.Background())
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+			So(len(notifier.sent), ShouldEqual, 1)
+			So(notifier.sent[0].ToStatus, ShouldEqual, types.OrderStatusIntProcessing)
+			So(outbox.events[0].Status, ShouldEqual, types.OutboxEventStatusProcessed)
+
+			count, err = dispatcher.DispatchPending(context.Background())
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 0)
+			So(len(notifier.sent), ShouldEqual, 1)
+		})
+
+		Convey("通知发送后标记失败时重试不重复通知", func() {
+			outbox.failMarkOnce = true
+			dispatcher := NewOutboxDispatcherForTest(outbox, orderRepo, hooks, nil, nil, nil, delivered)
+			count, _ := dispatcher.DispatchPending(context.Background())
+			So(count, ShouldEqual, 0)
+			So(outbox.events[0].Status, ShouldEqual, types.OutboxEventStatusPending)
+
+			restarted := NewOutboxDispatcherForTest(outbox, orderRepo, hooks, nil, nil, nil, delivered)
+			count, _ = restarted.DispatchPending(context.Background())
+			So(count, ShouldEqual, 1)
+			So(len(notifier.sent), ShouldEqual, 1)
+			So(outbox.events[0].Status, ShouldEqual, types.OutboxEventStatusProcessed)
+		})
+
+		Convey("通知失败时事件保留并延后重试", func() {
+			notifier.failures = 1
+			dispatcher := NewOutboxDispatcherForTest(outbox, orderRepo, hooks, nil, nil, nil, delivered)
+			count, _ := dispatcher.DispatchPending(context.Background())
+			So(count, ShouldEqual, 0)
+			So(outbox.events[0].Status, ShouldEqual, types.OutboxEventStatusPending)
+			So(outbox.events[0].Attempts, ShouldEqual, 1)
+			So(outbox.events[0].AvailableAt.After(time.Now()), ShouldBeTrue)
+
+			outbox.events[0].AvailableAt = time.Now()
+			count, _ = dispatcher.DispatchPending(context.Background())
+			So(count, ShouldEqual, 1)
+			So(len(notifier.sent), ShouldEqual, 1)
+		})
+	})
+}
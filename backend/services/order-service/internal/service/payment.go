@@ -2,33 +2,96 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
 )
 
+var (
+	// ErrPaymentMethodNotAllowed 商户未启用该支付方式
+	ErrPaymentMethodNotAllowed = errors.New("商户不支持该支付方式")
+	// ErrRefundNotSupported 支付渠道尚未接入退款，已支付的订单不能自动退款
+	ErrRefundNotSupported = errors.New("支付渠道暂不支持退款")
+)
+
+// PaymentMethodNotAllowedError 支付方式不在商户启用范围内，Allowed 为商户接受的支付方式
+type PaymentMethodNotAllowedError struct {
+	MerchantID uint64
+	Method     types.PaymentMethod
+	Allowed    []types.PaymentMethod
+}
+
+func (e *PaymentMethodNotAllowedError) Error() string {
+	return fmt.Sprintf("%v: 商户 %d 不接受 %s，可用支付方式: %v", ErrPaymentMethodNotAllowed, e.MerchantID, e.Method, e.Allowed)
+}
+
+func (e *PaymentMethodNotAllowedError) Unwrap() error {
+	return ErrPaymentMethodNotAllowed
+}
+
 // IPaymentService 支付服务接口
 type IPaymentService interface {
 	InitiatePayment(ctx context.Context, orderID uint64, paymentMethod types.PaymentMethod, returnURL string) (*types.PaymentInfo, error)
 	GetPaymentStatus(ctx context.Context, orderID uint64) (string, error)
 	RetryPayment(ctx context.Context, orderID uint64, paymentMethod types.PaymentMethod, returnURL string) (*types.PaymentInfo, error)
 	HandleAlipayCallback(ctx context.Context, callbackData map[string]interface{}) error
+	CheckRefundable(ctx context.Context, order *types.Order) error
+	RefundPayment(ctx context.Context, order *types.Order, amount float64, reason string) error
+	ReconcilePayment(ctx context.Context, orderID uint64) (*types.PaymentReconciliation, error)
+
+	// 支付沙箱（仅非生产环境可启用）
+	SandboxEnabled() bool
+	SetSandboxOutcome(ctx context.Context, orderID uint64, outcome *types.SandboxPaymentOutcome) error
 }
 
 // PaymentService 支付服务实现
 type PaymentService struct {
 	orderRepo           repository.IOrderRepository
 	notificationService NotificationService
+	provider            PaymentProvider
+	sandbox             *SandboxPaymentProvider // 非沙箱模式下为nil
+	webhooks            OrderEventPublisher
+	rightsRepo          repository.IOrderRightsReservationRepository
+	merchantRepo        repository.MerchantRepository // 为nil时不校验商户支付方式
+	reviewRepo          repository.IOrderReviewRepository // 为nil时不检查风险审核
 }
 
 // NewPaymentService 创建支付服务实例
 func NewPaymentService() IPaymentService {
-	return &PaymentService{
-		orderRepo:           repository.NewOrderRepository(),
-		notificationService: NewNotificationService(),
+	sandboxConfig, err := LoadPaymentSandboxConfig(context.Background())
+	if err != nil {
+		// 启动时已校验，这里再兜底一次，确保沙箱不会在生产环境生效
+		g.Log().Errorf(context.Background(), "支付沙箱配置无效，使用真实支付渠道: %v", err)
+		sandboxConfig = nil
+	}
+	service := newPaymentService(repository.NewOrderRepository(), NewNotificationService(), sandboxConfig)
+	service.webhooks = NewOrderWebhookService()
+	service.rightsRepo = repository.NewOrderRightsReservationRepository()
+	service.merchantRepo = repository.NewMerchantRepository()
+	service.reviewRepo = repository.NewOrderReviewRepository()
+	return service
+}
+
+// NewPaymentServiceForTest 创建测试用支付服务实例
+func NewPaymentServiceForTest(orderRepo repository.IOrderRepository, notificationService NotificationService, sandboxConfig *PaymentSandboxConfig) IPaymentService {
+	return newPaymentService(orderRepo, notificationService, sandboxConfig)
+}
+
+func newPaymentService(orderRepo repository.IOrderRepository, notificationService NotificationService, sandboxConfig *PaymentSandboxConfig) *PaymentService {
+	service := &PaymentService{
+		orderRepo:           orderRepo,
+		notificationService: notificationService,
+		provider:            NewAlipayProvider(),
 	}
+	if sandboxConfig != nil && sandboxConfig.Enabled {
+		service.sandbox = NewSandboxPaymentProvider(sandboxConfig, service.provider)
+		service.provider = service.sandbox
+	}
+	return service
 }
 
 // InitiatePayment 发起支付
@@ -44,11 +107,23 @@ func (s *PaymentService) InitiatePayment(ctx context.Context, orderID uint64, pa
 		return nil, fmt.Errorf("订单状态不正确，当前状态: %s", order.Status)
 	}
 
+	underReview, err := orderUnderReview(ctx, s.reviewRepo, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if underReview {
+		return nil, types.ErrOrderUnderReview
+	}
+
+	if err := s.checkMerchantPaymentMethod(ctx, order.MerchantID, paymentMethod); err != nil {
+		return nil, err
+	}
+
 	// 根据支付方式创建支付
 	var paymentInfo *types.PaymentInfo
 	switch paymentMethod {
 	case types.PaymentMethodAlipay:
-		paymentInfo, err = s.createAlipayPayment(ctx, order, returnURL)
+		paymentInfo, err = s.createProviderPayment(ctx, order, returnURL)
 	case types.PaymentMethodWechat:
 		return nil, fmt.Errorf("暂不支持微信支付")
 	case types.PaymentMethodBalance:
@@ -68,9 +143,70 @@ func (s *PaymentService) InitiatePayment(ctx context.Context, orderID uint64, pa
 		return nil, fmt.Errorf("更新订单支付信息失败: %v", err)
 	}
 
+	// 沙箱模式下立即模拟网关回调，走与真实回调相同的处理流程
+	if s.sandbox != nil {
+		callbackLost := s.sandbox.CallbackLost(order.ID)
+		callbackData := s.sandbox.BuildCallback(order)
+		if callbackLost {
+			g.Log().Info(ctx, "沙箱模拟支付回调丢失，订单等待支付对账", "order_id", order.ID)
+			return paymentInfo, nil
+		}
+		if err := s.HandleAlipayCallback(ctx, callbackData); err != nil {
+			return nil, fmt.Errorf("沙箱支付回调处理失败: %v", err)
+		}
+	}
+
 	return paymentInfo, nil
 }
 
+// checkMerchantPaymentMethod 校验商户是否接受该支付方式
+func (s *PaymentService) checkMerchantPaymentMethod(ctx context.Context, merchantID uint64, method types.PaymentMethod) error {
+	if s.merchantRepo == nil {
+		return nil
+	}
+
+	merchant, err := s.merchantRepo.GetByID(ctx, merchantID)
+	if err != nil {
+		return fmt.Errorf("获取商户信息失败: %v", err)
+	}
+	if merchant == nil || merchant.PaymentMethods.Allows(method) {
+		return nil
+	}
+	return &PaymentMethodNotAllowedError{
+		MerchantID: merchantID,
+		Method:     method,
+		Allowed:    merchant.PaymentMethods.Effective(),
+	}
+}
+
+// SandboxEnabled 是否处于支付沙箱模式
+func (s *PaymentService) SandboxEnabled() bool {
+	return s.sandbox != nil
+}
+
+// SetSandboxOutcome 预设订单下一次支付的模拟结果
+func (s *PaymentService) SetSandboxOutcome(ctx context.Context, orderID uint64, outcome *types.SandboxPaymentOutcome) error {
+	if s.sandbox == nil {
+		return fmt.Errorf("支付沙箱未启用")
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("订单不存在: %v", err)
+	}
+	return s.sandbox.SetOutcome(order.ID, outcome)
+}
+
+// createProviderPayment 在支付渠道创建支付，沙箱模式下模拟延迟超过网关超时时间视为失败
+func (s *PaymentService) createProviderPayment(ctx context.Context, order *types.Order, returnURL string) (*types.PaymentInfo, error) {
+	if s.sandbox != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.sandbox.config.GatewayTimeout)
+		defer cancel()
+	}
+	return s.provider.CreatePayment(ctx, order, returnURL)
+}
+
 // sendPaymentNotification 发送支付状态变更通知
 func (s *PaymentService) sendPaymentNotification(ctx context.Context, order *types.Order, originalStatus types.OrderStatus) {
 	// 只有当状态发生变更时才发送通知
@@ -135,9 +271,52 @@ func (s *PaymentService) RetryPayment(ctx context.Context, orderID uint64, payme
 	return s.InitiatePayment(ctx, orderID, paymentMethod, returnURL)
 }
 
+// CheckRefundable 校验已支付订单能否原路退款，支付渠道未接入退款时返回 ErrRefundNotSupported
+func (s *PaymentService) CheckRefundable(ctx context.Context, order *types.Order) error {
+	if order.PaymentInfo == nil || order.PaymentInfo.PaidAt == nil {
+		return fmt.Errorf("订单未支付，无需退款")
+	}
+	if !s.provider.SupportsRefund() {
+		return ErrRefundNotSupported
+	}
+	return nil
+}
+
+// RefundPayment 通过支付渠道原路退还已支付订单的款项
+func (s *PaymentService) RefundPayment(ctx context.Context, order *types.Order, amount float64, reason string) error {
+	if err := s.CheckRefundable(ctx, order); err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return fmt.Errorf("退款金额必须大于0")
+	}
+	if order.PaymentInfo.Amount > 0 && amount > order.PaymentInfo.Amount {
+		return fmt.Errorf("退款金额 %.2f 超过支付金额 %.2f", amount, order.PaymentInfo.Amount)
+	}
+
+	if err := s.provider.Refund(ctx, order, amount, reason); err != nil {
+		return fmt.Errorf("支付渠道退款失败: %w", err)
+	}
+	g.Log().Info(ctx, "订单退款完成",
+		"order_id", order.ID,
+		"refund_amount", amount,
+		"payment_method", order.PaymentInfo.Method,
+		"transaction_id", order.PaymentInfo.TransactionID,
+		"reason", reason)
+
+	publishOrderEvent(ctx, s.webhooks, types.OrderWebhookEventRefunded, order, map[string]interface{}{
+		"refund_amount": amount,
+		"reason":        reason,
+	})
+	return nil
+}
+
 // HandleAlipayCallback 处理支付宝支付回调
 func (s *PaymentService) HandleAlipayCallback(ctx context.Context, callbackData map[string]interface{}) error {
-	// TODO: 验证支付宝回调签名
+	// 验证支付宝回调签名（沙箱签名仅在沙箱模式下被接受）
+	if err := s.provider.VerifyCallback(ctx, callbackData); err != nil {
+		return fmt.Errorf("回调签名校验失败: %v", err)
+	}
 
 	// 获取回调中的订单号
 	outTradeNo, ok := callbackData["out_trade_no"].(string)
@@ -157,18 +336,12 @@ func (s *PaymentService) HandleAlipayCallback(ctx context.Context, callbackData
 		return fmt.Errorf("订单不存在: %v", err)
 	}
 
-	// 记录原始状态用于判断是否需要发送通知
-	originalStatus := order.Status
-
 	// 根据交易状态更新订单
 	switch tradeStatus {
 	case "TRADE_SUCCESS", "TRADE_FINISHED":
-		// 支付成功
-		order.Status = types.OrderStatusPaid
-		// 更新支付时间
-		now := time.Now()
-		if order.PaymentInfo != nil {
-			order.PaymentInfo.PaidAt = &now
+		// 支付成功，订单已由对账等途径处理时不重复更新和通知
+		if err := s.markOrderPaid(ctx, order, time.Now()); err != nil {
+			return err
 		}
 
 	case "TRADE_CLOSED":
@@ -176,32 +349,85 @@ func (s *PaymentService) HandleAlipayCallback(ctx context.Context, callbackData
 		// 不修改订单状态
 	}
 
-	// 更新订单
-	err = s.orderRepo.Update(ctx, order)
-	if err != nil {
+	// TODO: 支付成功后，应该扣减库存
+
+	return nil
+}
+
+// markOrderPaid 将待支付订单标记为已支付并发送支付成功通知，回调与对账共用。
+// 订单已不是待支付状态时直接返回，保证重复回调或回调与对账并发时副作用只触发一次
+func (s *PaymentService) markOrderPaid(ctx context.Context, order *types.Order, paidAt time.Time) error {
+	if order.Status != types.OrderStatusPending {
+		return nil
+	}
+
+	originalStatus := order.Status
+	order.Status = types.OrderStatusPaid
+	if order.PaymentInfo != nil {
+		order.PaymentInfo.PaidAt = &paidAt
+	}
+
+	if err := s.orderRepo.Update(ctx, order); err != nil {
 		return fmt.Errorf("更新订单失败: %v", err)
 	}
 
+	// 下单时冻结的权益转为已使用；失败时权益保持冻结，不影响支付结果
+	if s.rightsRepo != nil {
+		if _, err := s.rightsRepo.Settle(ctx, order.TenantID, order.ID, types.OrderRightsReservationConsumed); err != nil {
+			g.Log().Errorf(ctx, "订单 %d 支付后扣减冻结权益失败: %v", order.ID, err)
+		}
+	}
+
+	publishOrderEvent(ctx, s.webhooks, types.OrderWebhookEventPaid, order, nil)
+
 	// 发送状态变更通知
 	go s.sendPaymentNotification(context.Background(), order, originalStatus)
 
-	// TODO: 支付成功后，应该：
-	// 1. 扣减库存
-	// 2. 扣减权益余额
-
 	return nil
 }
 
-// createAlipayPayment 创建支付宝支付
-func (s *PaymentService) createAlipayPayment(ctx context.Context, order *types.Order, returnURL string) (*types.PaymentInfo, error) {
-	// TODO: 集成支付宝SDK创建支付
-	// 这里使用模拟数据
+// ReconcilePayment 向支付渠道查询待支付订单的交易状态，渠道已支付时按支付成功处理
+func (s *PaymentService) ReconcilePayment(ctx context.Context, orderID uint64) (*types.PaymentReconciliation, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("订单不存在: %v", err)
+	}
+
+	record := &types.PaymentReconciliation{
+		TenantID:    order.TenantID,
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+	}
+	if order.Status != types.OrderStatusPending {
+		record.Outcome = types.PaymentReconciliationSettled
+		record.Message = fmt.Sprintf("订单当前状态为 %s，无需对账", order.Status)
+		return record, nil
+	}
+
+	result, err := s.provider.QueryStatus(ctx, order)
+	if err != nil {
+		return nil, fmt.Errorf("查询支付渠道交易状态失败: %v", err)
+	}
+	record.TradeStatus = result.TradeStatus
+	record.TransactionID = result.TransactionID
+
+	if !result.IsPaid() {
+		record.Outcome = types.PaymentReconciliationUnpaid
+		return record, nil
+	}
 
-	paymentID := fmt.Sprintf("alipay_%d", order.ID)
+	paidAt := time.Now()
+	if result.PaidAt != nil {
+		paidAt = *result.PaidAt
+	}
+	if order.PaymentInfo != nil && order.PaymentInfo.TransactionID == "" {
+		order.PaymentInfo.TransactionID = result.TransactionID
+	}
+	if err := s.markOrderPaid(ctx, order, paidAt); err != nil {
+		return nil, err
+	}
 
-	return &types.PaymentInfo{
-		Method:        string(types.PaymentMethodAlipay),
-		TransactionID: paymentID,
-		Amount:        order.TotalAmount,
-	}, nil
+	record.Outcome = types.PaymentReconciliationRecovered
+	record.Message = "支付回调缺失，已按渠道交易状态补记为已支付"
+	return record, nil
 }
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMerchantPaymentMethods(t *testing.T) {
+	Convey("商户支付方式配置", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		orderRepo := &fakePaymentOrderRepository{
+			orders: map[uint64]*types.Order{
+				1: {ID: 1, TenantID: 1, MerchantID: 1, OrderNumber: "ORD202610150101", Status: types.OrderStatusPending, TotalAmount: 100},
+			},
+		}
+		merchantRepo := &staticMerchantRepository{merchant: &types.Merchant{
+			ID:             1,
+			PaymentMethods: types.PaymentMethodList{types.PaymentMethodWechat, types.PaymentMethodBalance},
+		}}
+		paymentService := newPaymentService(orderRepo, &silentNotificationService{}, &PaymentSandboxConfig{Enabled: true, Secret: "test-secret"})
+		paymentService.merchantRepo = merchantRepo
+
+		Convey("商户未启用的支付方式被拒绝并返回可用支付方式", func() {
+			_, err := paymentService.InitiatePayment(ctx, 1, types.PaymentMethodAlipay, "")
+			So(errors.Is(err, ErrPaymentMethodNotAllowed), ShouldBeTrue)
+
+			var methodErr *PaymentMethodNotAllowedError
+			So(errors.As(err, &methodErr), ShouldBeTrue)
+			So(methodErr.MerchantID, ShouldEqual, 1)
+			So(methodErr.Method, ShouldEqual, types.PaymentMethodAlipay)
+			So(methodErr.Allowed, ShouldResemble, []types.PaymentMethod{types.PaymentMethodWechat, types.PaymentMethodBalance})
+			So(err.Error(), ShouldContainSubstring, "wechat")
+
+			So(orderRepo.orders[1].PaymentInfo, ShouldBeNil)
+			So(orderRepo.orders[1].Status, ShouldEqual, types.OrderStatusPending)
+		})
+
+		Convey("重新支付同样校验商户支付方式", func() {
+			_, err := paymentService.RetryPayment(ctx, 1, types.PaymentMethodAlipay, "")
+			So(errors.Is(err, ErrPaymentMethodNotAllowed), ShouldBeTrue)
+		})
+
+		Convey("未配置支付方式的商户接受全部支付方式", func() {
+			merchantRepo.merchant = &types.Merchant{ID: 1}
+
+			payment, err := paymentService.InitiatePayment(ctx, 1, types.PaymentMethodAlipay, "")
+			So(err, ShouldBeNil)
+			So(payment.Method, ShouldEqual, string(types.PaymentMethodAlipay))
+		})
+
+		Convey("订单确认信息返回商户可用支付方式", func() {
+			orderService := NewOrderServiceForTest(nil, nil, nil, merchantRepo, nil)
+			req := &types.CreateOrderRequest{MerchantID: 1}
+			req.Items = append(req.Items, struct {
+				ProductID uint64 `json:"product_id" v:"required#商品ID不能为空"`
+				Quantity  int    `json:"quantity" v:"required|min:1#数量不能为空|数量必须大于0"`
+			}{ProductID: 1, Quantity: 1})
+
+			confirmation, err := orderService.GetOrderConfirmation(ctx, 100, req)
+			So(err, ShouldBeNil)
+			So(confirmation.AvailablePaymentMethods, ShouldResemble, []types.PaymentMethod{types.PaymentMethodWechat, types.PaymentMethodBalance})
+		})
+	})
+}
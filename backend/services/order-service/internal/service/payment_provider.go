@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// PaymentProvider 第三方支付渠道
+type PaymentProvider interface {
+	// CreatePayment 在支付渠道创建支付单
+	CreatePayment(ctx context.Context, order *types.Order, returnURL string) (*types.PaymentInfo, error)
+	// VerifyCallback 校验支付回调签名
+	VerifyCallback(ctx context.Context, callbackData map[string]interface{}) error
+	// QueryStatus 向支付渠道查询订单的交易状态，用于回调丢失时对账
+	QueryStatus(ctx context.Context, order *types.Order) (*types.PaymentQueryResult, error)
+	// SupportsRefund 渠道是否已接入退款接口
+	SupportsRefund() bool
+	// Refund 在支付渠道原路退款，渠道未接入退款接口时返回 ErrRefundNotSupported
+	Refund(ctx context.Context, order *types.Order, amount float64, reason string) error
+}
+
+// AlipayProvider 支付宝支付渠道
+type AlipayProvider struct {
+	publicKey string
+}
+
+// NewAlipayProvider 创建支付宝支付渠道
+func NewAlipayProvider() *AlipayProvider {
+	return &AlipayProvider{
+		publicKey: loadAlipayPublicKey(context.Background()),
+	}
+}
+
+// loadAlipayPublicKey 读取用于校验回调签名的支付宝公钥
+func loadAlipayPublicKey(ctx context.Context) string {
+	return strings.TrimSpace(g.Cfg().MustGet(ctx, "payment.alipay.public_key").String())
+}
+
+// ValidateAlipayConfig 校验支付宝回调验签配置：未启用沙箱时必须配置可解析的支付宝RSA公钥，
+// 否则所有真实回调都会因无法验签而被拒绝
+func ValidateAlipayConfig(ctx context.Context, sandboxConfig *PaymentSandboxConfig) error {
+	return checkAlipayPublicKey(loadAlipayPublicKey(ctx), sandboxConfig)
+}
+
+// checkAlipayPublicKey 检查支付宝公钥，只有启用沙箱时允许不配置
+func checkAlipayPublicKey(publicKey string, sandboxConfig *PaymentSandboxConfig) error {
+	if publicKey == "" {
+		if sandboxConfig != nil && sandboxConfig.Enabled {
+			return nil
+		}
+		return fmt.Errorf("未配置 payment.alipay.public_key，无法校验支付宝回调签名")
+	}
+	if _, err := parseAlipayPublicKey(publicKey); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseAlipayPublicKey 解析 Base64 编码的 PKIX 格式支付宝RSA公钥
+func parseAlipayPublicKey(publicKey string) (*rsa.PublicKey, error) {
+	keyBytes, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("支付宝公钥格式错误: %v", err)
+	}
+	key, err := x509.ParsePKIXPublicKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析支付宝公钥失败: %v", err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("支付宝公钥不是RSA公钥")
+	}
+	return rsaKey, nil
+}
+
+// CreatePayment 创建支付宝支付
+func (p *AlipayProvider) CreatePayment(ctx context.Context, order *types.Order, returnURL string) (*types.PaymentInfo, error) {
+	// TODO: 集成支付宝SDK创建支付
+	// 这里使用模拟数据
+
+	paymentID := fmt.Sprintf("alipay_%d", order.ID)
+
+	return &types.PaymentInfo{
+		Method:        string(types.PaymentMethodAlipay),
+		TransactionID: paymentID,
+		Amount:        order.TotalAmount,
+	}, nil
+}
+
+// QueryStatus 查询支付宝交易状态
+func (p *AlipayProvider) QueryStatus(ctx context.Context, order *types.Order) (*types.PaymentQueryResult, error) {
+	// TODO: 集成支付宝SDK调用 alipay.trade.query
+	// 这里使用模拟数据，视为买家尚未付款
+
+	result := &types.PaymentQueryResult{TradeStatus: "WAIT_BUYER_PAY"}
+	if order.PaymentInfo != nil {
+		result.TransactionID = order.PaymentInfo.TransactionID
+		result.Amount = order.PaymentInfo.Amount
+	}
+	return result, nil
+}
+
+// SupportsRefund 支付宝退款接口尚未接入
+func (p *AlipayProvider) SupportsRefund() bool {
+	return false
+}
+
+// Refund 支付宝退款
+func (p *AlipayProvider) Refund(ctx context.Context, order *types.Order, amount float64, reason string) error {
+	// TODO: 集成支付宝SDK调用 alipay.trade.refund
+	// 接入前不能模拟退款成功，否则订单会被标记为已退款而款项并未退回
+	return ErrRefundNotSupported
+}
+
+// VerifyCallback 使用支付宝公钥校验RSA2回调签名，沙箱签名一律拒绝
+func (p *AlipayProvider) VerifyCallback(ctx context.Context, callbackData map[string]interface{}) error {
+	signType := gconv.String(callbackData["sign_type"])
+	if signType == sandboxSignType {
+		return fmt.Errorf("非沙箱模式不接受沙箱签名")
+	}
+	if signType != "RSA2" {
+		return fmt.Errorf("不支持的签名类型: %s", signType)
+	}
+	if p.publicKey == "" {
+		return fmt.Errorf("未配置支付宝公钥，无法校验回调签名")
+	}
+
+	sign, err := base64.StdEncoding.DecodeString(gconv.String(callbackData["sign"]))
+	if err != nil {
+		return fmt.Errorf("回调签名格式错误: %v", err)
+	}
+
+	rsaKey, err := parseAlipayPublicKey(p.publicKey)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256([]byte(callbackSignContent(callbackData)))
+	if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], sign); err != nil {
+		return fmt.Errorf("回调签名校验失败")
+	}
+	return nil
+}
+
+// callbackSignContent 按支付宝规则生成待签名字符串：除 sign、sign_type 外的非空参数按键名排序后拼接
+func callbackSignContent(callbackData map[string]interface{}) string {
+	keys := make([]string, 0, len(callbackData))
+	for key, value := range callbackData {
+		if key == "sign" || key == "sign_type" || gconv.String(value) == "" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+gconv.String(callbackData[key]))
+	}
+	return strings.Join(pairs, "&")
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	defaultReconciliationInterval  = 5 * time.Minute
+	defaultReconciliationMinAge    = 10 * time.Minute
+	defaultReconciliationLookback  = 24 * time.Hour
+	defaultReconciliationBatchSize = 100
+)
+
+// PaymentReconciliationConfig 支付对账配置
+type PaymentReconciliationConfig struct {
+	Enabled   bool
+	Interval  time.Duration // 对账频率
+	MinAge    time.Duration // 发起支付超过该时长仍待支付的订单才对账，给正常回调留出时间
+	Lookback  time.Duration // 只对账该时长内创建的订单
+	BatchSize int           // 每轮最多对账的订单数
+}
+
+// LoadPaymentReconciliationConfig 从 payment.reconciliation 配置加载支付对账配置，未配置或无效的项使用默认值
+func LoadPaymentReconciliationConfig(ctx context.Context) *PaymentReconciliationConfig {
+	config := &PaymentReconciliationConfig{
+		Enabled:   g.Cfg().MustGet(ctx, "payment.reconciliation.enabled", true).Bool(),
+		Interval:  g.Cfg().MustGet(ctx, "payment.reconciliation.interval", defaultReconciliationInterval).Duration(),
+		MinAge:    g.Cfg().MustGet(ctx, "payment.reconciliation.minAge", defaultReconciliationMinAge).Duration(),
+		Lookback:  g.Cfg().MustGet(ctx, "payment.reconciliation.lookback", defaultReconciliationLookback).Duration(),
+		BatchSize: g.Cfg().MustGet(ctx, "payment.reconciliation.batchSize", defaultReconciliationBatchSize).Int(),
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaultReconciliationInterval
+	}
+	if config.MinAge <= 0 {
+		config.MinAge = defaultReconciliationMinAge
+	}
+	if config.Lookback <= config.MinAge {
+		config.Lookback = defaultReconciliationLookback
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultReconciliationBatchSize
+	}
+	return config
+}
+
+// PaymentReconciler 支付对账任务：定期向支付渠道查询长时间待支付的订单，补记回调丢失的支付
+type PaymentReconciler struct {
+	config         *PaymentReconciliationConfig
+	orderRepo      repository.IOrderRepository
+	recordRepo     repository.IPaymentReconciliationRepository
+	paymentService IPaymentService
+	stopCh         chan struct{}
+	isRunning      bool
+}
+
+// NewPaymentReconciler 创建支付对账任务
+func NewPaymentReconciler(paymentService IPaymentService) *PaymentReconciler {
+	return &PaymentReconciler{
+		config:         LoadPaymentReconciliationConfig(context.Background()),
+		orderRepo:      repository.NewOrderRepository(),
+		recordRepo:     repository.NewPaymentReconciliationRepository(),
+		paymentService: paymentService,
+		stopCh:         make(chan struct{}),
+	}
+}
+
+// NewPaymentReconcilerForTest 创建测试用支付对账任务
+func NewPaymentReconcilerForTest(config *PaymentReconciliationConfig, orderRepo repository.IOrderRepository, recordRepo repository.IPaymentReconciliationRepository, paymentService IPaymentService) *PaymentReconciler {
+	return &PaymentReconciler{
+		config:         config,
+		orderRepo:      orderRepo,
+		recordRepo:     recordRepo,
+		paymentService: paymentService,
+		stopCh:         make(chan struct{}),
+	}
+}
+
+// Start 启动后台对账循环
+func (r *PaymentReconciler) Start(ctx context.Context) {
+	if r.isRunning {
+		return
+	}
+	if !r.config.Enabled {
+		g.Log().Info(ctx, "支付对账未启用")
+		return
+	}
+	r.isRunning = true
+	g.Log().Info(ctx, "启动支付对账任务", "interval", r.config.Interval, "min_age", r.config.MinAge)
+
+	go func() {
+		ticker := time.NewTicker(r.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stopCh:
+				return
+			case <-ticker.C:
+				if _, err := r.Reconcile(ctx); err != nil {
+					g.Log().Error(ctx, "支付对账失败", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止后台对账循环
+func (r *PaymentReconciler) Stop(ctx context.Context) {
+	if !r.isRunning {
+		return
+	}
+	r.isRunning = false
+	close(r.stopCh)
+	g.Log().Info(ctx, "支付对账任务已停止")
+}
+
+// Reconcile 执行一轮对账，单个订单失败不影响其他订单
+func (r *PaymentReconciler) Reconcile(ctx context.Context) (*types.PaymentReconciliationSummary, error) {
+	now := time.Now()
+	orders, err := r.orderRepo.GetPendingPaymentOrders(ctx, now.Add(-r.config.MinAge), now.Add(-r.config.Lookback), r.config.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &types.PaymentReconciliationSummary{}
+	for _, order := range orders {
+		// 定时任务没有请求上下文，按订单所属租户设置租户上下文
+		tenantCtx := context.WithValue(ctx, "tenant_id", order.TenantID)
+
+		record, err := r.paymentService.ReconcilePayment(tenantCtx, order.ID)
+		if err != nil {
+			g.Log().Error(ctx, "订单支付对账失败", "order_id", order.ID, "error", err)
+			record = &types.PaymentReconciliation{
+				TenantID:    order.TenantID,
+				OrderID:     order.ID,
+				OrderNumber: order.OrderNumber,
+				Outcome:     types.PaymentReconciliationFailed,
+				Message:     truncateReconciliationMessage(err.Error()),
+			}
+		}
+		summary.Add(record.Outcome)
+
+		if record.Outcome == types.PaymentReconciliationRecovered {
+			g.Log().Warning(ctx, "支付回调缺失，订单已由对账恢复为已支付",
+				"order_id", order.ID,
+				"order_number", order.OrderNumber,
+				"transaction_id", record.TransactionID)
+		}
+		if err := r.recordRepo.Create(ctx, record); err != nil {
+			g.Log().Error(ctx, "记录支付对账结果失败", "order_id", order.ID, "error", err)
+		}
+	}
+
+	if summary.Scanned > 0 {
+		g.Log().Info(ctx, "支付对账完成",
+			"scanned", summary.Scanned,
+			"recovered", summary.Recovered,
+			"unpaid", summary.Unpaid,
+			"failed", summary.Failed)
+	}
+	return summary, nil
+}
+
+// truncateReconciliationMessage 截断对账说明，与 payment_reconciliations.message 字段长度一致
+func truncateReconciliationMessage(message string) string {
+	runes := []rune(message)
+	if len(runes) > 500 {
+		return fmt.Sprintf("%s...", string(runes[:497]))
+	}
+	return message
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// reconciliationOrderRepository 支持查询待对账订单的内存订单仓储
+type reconciliationOrderRepository struct {
+	*fakePaymentOrderRepository
+}
+
+func (f *reconciliationOrderRepository) GetPendingPaymentOrders(ctx context.Context, updatedBefore, createdAfter time.Time, limit int) ([]*types.Order, error) {
+	var orders []*types.Order
+	for _, order := range f.orders {
+		if order.Status == types.OrderStatusPending && order.PaymentInfo != nil {
+			copied := *order
+			orders = append(orders, &copied)
+		}
+	}
+	return orders, nil
+}
+
+// memoryReconciliationRepository 内存支付对账记录仓储
+type memoryReconciliationRepository struct {
+	records []*types.PaymentReconciliation
+}
+
+func (f *memoryReconciliationRepository) Create(ctx context.Context, record *types.PaymentReconciliation) error {
+	record.ID = uint64(len(f.records) + 1)
+	f.records = append(f.records, record)
+	return nil
+}
+
+func (f *memoryReconciliationRepository) ListByOrderID(ctx context.Context, orderID uint64) ([]types.PaymentReconciliation, error) {
+	var records []types.PaymentReconciliation
+	for _, record := range f.records {
+		if record.OrderID == orderID {
+			records = append(records, *record)
+		}
+	}
+	return records, nil
+}
+
+// countingPaymentNotificationService 记录支付成功通知的通知服务桩
+type countingPaymentNotificationService struct {
+	NotificationService
+	paid chan uint64
+}
+
+func (s *countingPaymentNotificationService) SendPaymentSuccessNotification(ctx context.Context, order *types.Order) error {
+	s.paid <- order.ID
+	return nil
+}
+
+// drainNotifications 收集异步发送的支付成功通知
+func drainNotifications(paid chan uint64) []uint64 {
+	var orderIDs []uint64
+	for {
+		select {
+		case orderID := <-paid:
+			orderIDs = append(orderIDs, orderID)
+		case <-time.After(100 * time.Millisecond):
+			return orderIDs
+		}
+	}
+}
+
+func TestPaymentReconciliation(t *testing.T) {
+	Convey("支付对账测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		orderRepo := &reconciliationOrderRepository{&fakePaymentOrderRepository{
+			orders: map[uint64]*types.Order{
+				1: {ID: 1, TenantID: 1, OrderNumber: "ORD202610150001", Status: types.OrderStatusPending, TotalAmount: 99.5},
+				2: {ID: 2, TenantID: 1, OrderNumber: "ORD202610150002", Status: types.OrderStatusPending, TotalAmount: 10},
+			},
+		}}
+		recordRepo := &memoryReconciliationRepository{}
+		notifications := &countingPaymentNotificationService{paid: make(chan uint64, 10)}
+		paymentService := NewPaymentServiceForTest(orderRepo, notifications, &PaymentSandboxConfig{
+			Enabled:        true,
+			Secret:         "test-secret",
+			GatewayTimeout: 50 * time.Millisecond,
+		})
+		reconciler := NewPaymentReconcilerForTest(&PaymentReconciliationConfig{
+			Enabled:   true,
+			Interval:  time.Minute,
+			MinAge:    10 * time.Minute,
+			Lookback:  24 * time.Hour,
+			BatchSize: 100,
+		}, orderRepo, recordRepo, paymentService)
+
+		Convey("回调丢失的已支付订单由对账恢复", func() {
+			So(paymentService.SetSandboxOutcome(ctx, 1, &types.SandboxPaymentOutcome{Outcome: types.SandboxOutcomeCallbackLost}), ShouldBeNil)
+			_, err := paymentService.InitiatePayment(ctx, 1, types.PaymentMethodAlipay, "")
+			So(err, ShouldBeNil)
+
+			// 网关已扣款，但回调丢失，订单仍待支付
+			So(orderRepo.orders[1].Status, ShouldEqual, types.OrderStatusPending)
+			So(drainNotifications(notifications.paid), ShouldBeEmpty)
+
+			summary, err := reconciler.Reconcile(context.Background())
+			So(err, ShouldBeNil)
+			So(summary.Scanned, ShouldEqual, 1)
+			So(summary.Recovered, ShouldEqual, 1)
+
+			So(orderRepo.orders[1].Status, ShouldEqual, types.OrderStatusPaid)
+			So(orderRepo.orders[1].PaymentInfo.PaidAt, ShouldNotBeNil)
+			So(drainNotifications(notifications.paid), ShouldResemble, []uint64{1})
+
+			So(recordRepo.records, ShouldHaveLength, 1)
+			So(recordRepo.records[0].OrderID, ShouldEqual, 1)
+			So(recordRepo.records[0].Outcome, ShouldEqual, types.PaymentReconciliationRecovered)
+			So(recordRepo.records[0].TradeStatus, ShouldEqual, "TRADE_SUCCESS")
+			So(recordRepo.records[0].TransactionID, ShouldEqual, SandboxTransactionID("ORD202610150001"))
+
+			Convey("迟到的回调不会重复触发支付成功副作用", func() {
+				order := orderRepo.orders[1]
+				err := paymentService.HandleAlipayCallback(ctx, paymentService.(*PaymentService).sandbox.BuildCallback(order))
+				So(err, ShouldBeNil)
+				So(drainNotifications(notifications.paid), ShouldBeEmpty)
+
+				// 再次对账时订单已不在待支付列表中
+				summary, err := reconciler.Reconcile(context.Background())
+				So(err, ShouldBeNil)
+				So(summary.Scanned, ShouldEqual, 0)
+			})
+		})
+
+		Convey("渠道未支付的订单保持待支付并记录对账结果", func() {
+			So(paymentService.SetSandboxOutcome(ctx, 2, &types.SandboxPaymentOutcome{Outcome: types.SandboxOutcomeFail}), ShouldBeNil)
+			_, err := paymentService.InitiatePayment(ctx, 2, types.PaymentMethodAlipay, "")
+			So(err, ShouldBeNil)
+
+			summary, err := reconciler.Reconcile(context.Background())
+			So(err, ShouldBeNil)
+			So(summary.Unpaid, ShouldEqual, 1)
+			So(orderRepo.orders[2].Status, ShouldEqual, types.OrderStatusPending)
+			So(recordRepo.records[0].Outcome, ShouldEqual, types.PaymentReconciliationUnpaid)
+			So(recordRepo.records[0].TradeStatus, ShouldEqual, "TRADE_CLOSED")
+			So(drainNotifications(notifications.paid), ShouldBeEmpty)
+		})
+
+		Convey("已不是待支付的订单不查询渠道", func() {
+			orderRepo.orders[1].Status = types.OrderStatusPaid
+
+			record, err := paymentService.ReconcilePayment(ctx, 1)
+			So(err, ShouldBeNil)
+			So(record.Outcome, ShouldEqual, types.PaymentReconciliationSettled)
+			So(record.TradeStatus, ShouldBeEmpty)
+		})
+
+		Convey("单个订单对账失败时记录失败结果", func() {
+			orderRepo.orders[3] = &types.Order{ID: 3, TenantID: 1, OrderNumber: "ORD202610150003", Status: types.OrderStatusPending, PaymentInfo: &types.PaymentInfo{}}
+			failing := NewPaymentReconcilerForTest(reconciler.config, orderRepo, recordRepo, &failingReconcilePaymentService{})
+
+			summary, err := failing.Reconcile(context.Background())
+			So(err, ShouldBeNil)
+			So(summary.Failed, ShouldEqual, 1)
+			So(recordRepo.records[0].Outcome, ShouldEqual, types.PaymentReconciliationFailed)
+			So(recordRepo.records[0].Message, ShouldContainSubstring, "渠道不可用")
+		})
+	})
+}
+
+// failingReconcilePaymentService 对账时总是失败的支付服务桩
+type failingReconcilePaymentService struct {
+	IPaymentService
+}
+
+func (s *failingReconcilePaymentService) ReconcilePayment(ctx context.Context, orderID uint64) (*types.PaymentReconciliation, error) {
+	return nil, fmt.Errorf("渠道不可用")
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/genv"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+const (
+	sandboxSignType              = "SANDBOX"
+	defaultSandboxSecret         = "mer-sys-sandbox"
+	defaultSandboxGatewayTimeout = 10 * time.Second
+)
+
+// sandboxAllowedEnvs 允许启用支付沙箱的运行环境，未声明环境视为生产环境
+var sandboxAllowedEnvs = map[string]bool{
+	"local":       true,
+	"dev":         true,
+	"development": true,
+	"test":        true,
+	"testing":     true,
+	"staging":     true,
+}
+
+// PaymentSandboxConfig 支付沙箱配置
+type PaymentSandboxConfig struct {
+	Enabled        bool
+	Secret         string        // 沙箱回调签名密钥
+	GatewayTimeout time.Duration // 模拟网关超时时间
+}
+
+// LoadPaymentSandboxConfig 加载支付沙箱配置。
+// 运行环境取自 app.env 配置或 APP_ENV 环境变量，只有明确声明为非生产环境时才允许启用沙箱
+func LoadPaymentSandboxConfig(ctx context.Context) (*PaymentSandboxConfig, error) {
+	config := &PaymentSandboxConfig{
+		Enabled:        g.Cfg().MustGet(ctx, "payment.sandbox.enabled").Bool(),
+		Secret:         g.Cfg().MustGet(ctx, "payment.sandbox.secret", defaultSandboxSecret).String(),
+		GatewayTimeout: g.Cfg().MustGet(ctx, "payment.sandbox.gatewayTimeout", defaultSandboxGatewayTimeout).Duration(),
+	}
+	if !config.Enabled {
+		return config, nil
+	}
+
+	env := g.Cfg().MustGet(ctx, "app.env", genv.Get("APP_ENV").String()).String()
+	if err := checkSandboxEnv(env); err != nil {
+		return nil, err
+	}
+	if config.Secret == "" {
+		config.Secret = defaultSandboxSecret
+	}
+	if config.GatewayTimeout <= 0 {
+		config.GatewayTimeout = defaultSandboxGatewayTimeout
+	}
+	return config, nil
+}
+
+// checkSandboxEnv 检查运行环境是否允许启用支付沙箱
+func checkSandboxEnv(env string) error {
+	if !sandboxAllowedEnvs[strings.ToLower(strings.TrimSpace(env))] {
+		return fmt.Errorf("运行环境 %q 不允许启用支付沙箱", env)
+	}
+	return nil
+}
+
+// SandboxPaymentProvider 支付沙箱模拟器：按预设结果模拟网关响应并生成沙箱签名的回调
+type SandboxPaymentProvider struct {
+	config   *PaymentSandboxConfig
+	fallback PaymentProvider // 沙箱模式下仍可校验真实渠道的回调
+
+	mu       sync.Mutex
+	outcomes map[uint64]*types.SandboxPaymentOutcome
+	trades   map[string]*types.PaymentQueryResult // 按订单号记录模拟网关侧的交易状态，供对账查询
+}
+
+// NewSandboxPaymentProvider 创建支付沙箱模拟器
+func NewSandboxPaymentProvider(config *PaymentSandboxConfig, fallback PaymentProvider) *SandboxPaymentProvider {
+	return &SandboxPaymentProvider{
+		config:   config,
+		fallback: fallback,
+		outcomes: make(map[uint64]*types.SandboxPaymentOutcome),
+		trades:   make(map[string]*types.PaymentQueryResult),
+	}
+}
+
+// SetOutcome 预设订单的模拟支付结果
+func (p *SandboxPaymentProvider) SetOutcome(orderID uint64, outcome *types.SandboxPaymentOutcome) error {
+	if outcome == nil || !outcome.Outcome.IsValid() {
+		return fmt.Errorf("无效的沙箱模拟结果")
+	}
+	if outcome.DelayMs < 0 {
+		return fmt.Errorf("模拟延迟不能为负数")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.outcomes[orderID] = outcome
+	return nil
+}
+
+// CreatePayment 模拟创建支付，交易号由订单号确定性生成
+func (p *SandboxPaymentProvider) CreatePayment(ctx context.Context, order *types.Order, returnURL string) (*types.PaymentInfo, error) {
+	outcome := p.outcomeFor(order.ID)
+	if outcome.Outcome == types.SandboxOutcomeDelay {
+		timer := time.NewTimer(time.Duration(outcome.DelayMs) * time.Millisecond)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("支付网关响应超时: %v", ctx.Err())
+		}
+	}
+
+	return &types.PaymentInfo{
+		Method:        string(types.PaymentMethodAlipay),
+		TransactionID: SandboxTransactionID(order.OrderNumber),
+		Amount:        order.TotalAmount,
+	}, nil
+}
+
+// BuildCallback 按预设结果生成沙箱签名的支付宝回调数据，记录网关侧交易状态，并清除该订单的预设
+func (p *SandboxPaymentProvider) BuildCallback(order *types.Order) map[string]interface{} {
+	outcome := p.outcomeFor(order.ID)
+	p.mu.Lock()
+	delete(p.outcomes, order.ID)
+	p.mu.Unlock()
+
+	tradeStatus := "TRADE_SUCCESS"
+	if outcome.Outcome == types.SandboxOutcomeFail {
+		tradeStatus = "TRADE_CLOSED"
+	}
+
+	trade := &types.PaymentQueryResult{
+		TradeStatus:   tradeStatus,
+		TransactionID: SandboxTransactionID(order.OrderNumber),
+		Amount:        order.TotalAmount,
+	}
+	if trade.IsPaid() {
+		paidAt := time.Now()
+		trade.PaidAt = &paidAt
+	}
+	p.mu.Lock()
+	p.trades[order.OrderNumber] = trade
+	p.mu.Unlock()
+
+	callbackData := map[string]interface{}{
+		"out_trade_no": order.OrderNumber,
+		"trade_no":     SandboxTransactionID(order.OrderNumber),
+		"trade_status": tradeStatus,
+		"total_amount": fmt.Sprintf("%.2f", order.TotalAmount),
+		"sign_type":    sandboxSignType,
+	}
+	callbackData["sign"] = p.sign(callbackData)
+	return callbackData
+}
+
+// CallbackLost 预设结果是否为回调丢失，需在 BuildCallback 清除预设之前调用
+func (p *SandboxPaymentProvider) CallbackLost(orderID uint64) bool {
+	return p.outcomeFor(orderID).Outcome == types.SandboxOutcomeCallbackLost
+}
+
+// QueryStatus 返回模拟网关记录的交易状态，未发起过模拟支付的订单视为等待付款
+func (p *SandboxPaymentProvider) QueryStatus(ctx context.Context, order *types.Order) (*types.PaymentQueryResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if trade, exists := p.trades[order.OrderNumber]; exists {
+		copied := *trade
+		return &copied, nil
+	}
+	return &types.PaymentQueryResult{TradeStatus: "WAIT_BUYER_PAY"}, nil
+}
+
+// SupportsRefund 沙箱模拟退款
+func (p *SandboxPaymentProvider) SupportsRefund() bool {
+	return true
+}
+
+// Refund 模拟网关退款，直接视为退款成功
+func (p *SandboxPaymentProvider) Refund(ctx context.Context, order *types.Order, amount float64, reason string) error {
+	return nil
+}
+
+// VerifyCallback 沙箱签名使用沙箱密钥校验，其余签名交由真实渠道校验
+func (p *SandboxPaymentProvider) VerifyCallback(ctx context.Context, callbackData map[string]interface{}) error {
+	if gconv.String(callbackData["sign_type"]) != sandboxSignType {
+		return p.fallback.VerifyCallback(ctx, callbackData)
+	}
+
+	if !hmac.Equal([]byte(gconv.String(callbackData["sign"])), []byte(p.sign(callbackData))) {
+		return fmt.Errorf("沙箱回调签名校验失败")
+	}
+	return nil
+}
+
+// outcomeFor 获取订单的预设结果，未预设时默认支付成功
+func (p *SandboxPaymentProvider) outcomeFor(orderID uint64) *types.SandboxPaymentOutcome {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if outcome, exists := p.outcomes[orderID]; exists {
+		return outcome
+	}
+	return &types.SandboxPaymentOutcome{Outcome: types.SandboxOutcomeSuccess}
+}
+
+// sign 使用沙箱密钥对回调数据做HMAC-SHA256签名
+func (p *SandboxPaymentProvider) sign(callbackData map[string]interface{}) string {
+	mac := hmac.New(sha256.New, []byte(p.config.Secret))
+	mac.Write([]byte(callbackSignContent(callbackData)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SandboxTransactionID 根据订单号生成确定性的沙箱交易号
+func SandboxTransactionID(orderNumber string) string {
+	sum := sha256.Sum256([]byte(orderNumber))
+	return "sandbox_" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakePaymentOrderRepository 内存订单仓储
+type fakePaymentOrderRepository struct {
+	repository.IOrderRepository
+	orders map[uint64]*types.Order
+}
+
+func (f *fakePaymentOrderRepository) GetByID(ctx context.Context, id uint64) (*types.Order, error) {
+	order, exists := f.orders[id]
+	if !exists {
+		return nil, fmt.Errorf("订单不存在")
+	}
+	copied := *order
+	return &copied, nil
+}
+
+func (f *fakePaymentOrderRepository) GetByOrderNumber(ctx context.Context, orderNumber string) (*types.Order, error) {
+	for _, order := range f.orders {
+		if order.OrderNumber == orderNumber {
+			copied := *order
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("订单不存在")
+}
+
+func (f *fakePaymentOrderRepository) Update(ctx context.Context, order *types.Order) error {
+	copied := *order
+	f.orders[order.ID] = &copied
+	return nil
+}
+
+// silentNotificationService 忽略支付通知的通知服务桩
+type silentNotificationService struct {
+	NotificationService
+}
+
+func (s *silentNotificationService) SendPaymentSuccessNotification(ctx context.Context, order *types.Order) error {
+	return nil
+}
+
+func (s *silentNotificationService) SendOrderCompletedNotification(ctx context.Context, order *types.Order) error {
+	return nil
+}
+
+func TestPaymentSandbox(t *testing.T) {
+	Convey("支付沙箱模式测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		orderRepo := &fakePaymentOrderRepository{
+			orders: map[uint64]*types.Order{
+				1: {ID: 1, TenantID: 1, OrderNumber: "ORD202610150001", Status: types.OrderStatusPending, TotalAmount: 99.5},
+			},
+		}
+		paymentService := NewPaymentServiceForTest(orderRepo, &silentNotificationService{}, &PaymentSandboxConfig{
+			Enabled:        true,
+			Secret:         "test-secret",
+			GatewayTimeout: 50 * time.Millisecond,
+		})
+
+		Convey("默认模拟支付成功，交易号由订单号确定性生成", func() {
+			payment, err := paymentService.InitiatePayment(ctx, 1, types.PaymentMethodAlipay, "")
+			So(err, ShouldBeNil)
+			So(payment.TransactionID, ShouldEqual, SandboxTransactionID("ORD202610150001"))
+			So(payment.TransactionID, ShouldNotEqual, SandboxTransactionID("ORD202610150002"))
+			So(orderRepo.orders[1].Status, ShouldEqual, types.OrderStatusPaid)
+			So(orderRepo.orders[1].PaymentInfo.PaidAt, ShouldNotBeNil)
+
+			status, err := paymentService.GetPaymentStatus(ctx, 1)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, "paid")
+		})
+
+		Convey("模拟支付失败时订单保持待支付，可重新支付", func() {
+			err := paymentService.SetSandboxOutcome(ctx, 1, &types.SandboxPaymentOutcome{Outcome: types.SandboxOutcomeFail})
+			So(err, ShouldBeNil)
+
+			_, err = paymentService.InitiatePayment(ctx, 1, types.PaymentMethodAlipay, "")
+			So(err, ShouldBeNil)
+			So(orderRepo.orders[1].Status, ShouldEqual, types.OrderStatusPending)
+
+			_, err = paymentService.RetryPayment(ctx, 1, types.PaymentMethodAlipay, "")
+			So(err, ShouldBeNil)
+			So(orderRepo.orders[1].Status, ShouldEqual, types.OrderStatusPaid)
+		})
+
+		Convey("网关延迟在超时时间内时支付成功", func() {
+			err := paymentService.SetSandboxOutcome(ctx, 1, &types.SandboxPaymentOutcome{Outcome: types.SandboxOutcomeDelay, DelayMs: 10})
+			So(err, ShouldBeNil)
+
+			_, err = paymentService.InitiatePayment(ctx, 1, types.PaymentMethodAlipay, "")
+			So(err, ShouldBeNil)
+			So(orderRepo.orders[1].Status, ShouldEqual, types.OrderStatusPaid)
+		})
+
+		Convey("网关延迟超过超时时间时支付失败且不修改订单", func() {
+			err := paymentService.SetSandboxOutcome(ctx, 1, &types.SandboxPaymentOutcome{Outcome: types.SandboxOutcomeDelay, DelayMs: 500})
+			So(err, ShouldBeNil)
+
+			_, err = paymentService.InitiatePayment(ctx, 1, types.PaymentMethodAlipay, "")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "超时")
+			So(orderRepo.orders[1].Status, ShouldEqual, types.OrderStatusPending)
+			So(orderRepo.orders[1].PaymentInfo, ShouldBeNil)
+		})
+
+		Convey("无效的模拟结果被拒绝", func() {
+			err := paymentService.SetSandboxOutcome(ctx, 1, &types.SandboxPaymentOutcome{Outcome: "refund"})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("沙箱签名被篡改的回调被拒绝", func() {
+			err := paymentService.HandleAlipayCallback(ctx, map[string]interface{}{
+				"out_trade_no": "ORD202610150001",
+				"trade_status": "TRADE_SUCCESS",
+				"sign_type":    sandboxSignType,
+				"sign":         "forged",
+			})
+			So(err, ShouldNotBeNil)
+			So(orderRepo.orders[1].Status, ShouldEqual, types.OrderStatusPending)
+		})
+
+		Convey("非沙箱模式下拒绝沙箱签名和模拟设置", func() {
+			sandbox := NewSandboxPaymentProvider(&PaymentSandboxConfig{Enabled: true, Secret: "test-secret"}, nil)
+			callbackData := sandbox.BuildCallback(orderRepo.orders[1])
+
+			liveService := NewPaymentServiceForTest(orderRepo, &silentNotificationService{}, nil)
+			So(liveService.SandboxEnabled(), ShouldBeFalse)
+			So(liveService.HandleAlipayCallback(ctx, callbackData), ShouldNotBeNil)
+			So(orderRepo.orders[1].Status, ShouldEqual, types.OrderStatusPending)
+
+			err := liveService.SetSandboxOutcome(ctx, 1, &types.SandboxPaymentOutcome{Outcome: types.SandboxOutcomeSuccess})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("未接入退款的支付渠道拒绝退款，沙箱模拟退款成功", func() {
+			paidAt := time.Now()
+			paidOrder := &types.Order{ID: 1, TenantID: 1, Status: types.OrderStatusPaid,
+				PaymentInfo: &types.PaymentInfo{Method: "alipay", Amount: 99.5, PaidAt: &paidAt}}
+
+			liveService := NewPaymentServiceForTest(orderRepo, &silentNotificationService{}, nil)
+			So(errors.Is(liveService.CheckRefundable(ctx, paidOrder), ErrRefundNotSupported), ShouldBeTrue)
+			So(errors.Is(liveService.RefundPayment(ctx, paidOrder, 99.5, "订单取消"), ErrRefundNotSupported), ShouldBeTrue)
+
+			So(paymentService.CheckRefundable(ctx, paidOrder), ShouldBeNil)
+			So(paymentService.RefundPayment(ctx, paidOrder, 99.5, "订单取消"), ShouldBeNil)
+			So(paymentService.RefundPayment(ctx, paidOrder, 100, "订单取消"), ShouldNotBeNil)
+		})
+
+		Convey("未启用沙箱时必须配置可解析的支付宝公钥", func() {
+			privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			So(err, ShouldBeNil)
+			keyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+			So(err, ShouldBeNil)
+			publicKey := base64.StdEncoding.EncodeToString(keyBytes)
+
+			So(checkAlipayPublicKey(publicKey, &PaymentSandboxConfig{}), ShouldBeNil)
+			So(checkAlipayPublicKey("", &PaymentSandboxConfig{}), ShouldNotBeNil)
+			So(checkAlipayPublicKey("", nil), ShouldNotBeNil)
+			So(checkAlipayPublicKey("not-a-key", &PaymentSandboxConfig{}), ShouldNotBeNil)
+			So(checkAlipayPublicKey("", &PaymentSandboxConfig{Enabled: true}), ShouldBeNil)
+		})
+
+		Convey("只有明确声明的非生产环境允许启用沙箱", func() {
+			So(checkSandboxEnv("test"), ShouldBeNil)
+			So(checkSandboxEnv("Development"), ShouldBeNil)
+			So(checkSandboxEnv("production"), ShouldNotBeNil)
+			So(checkSandboxEnv("prod"), ShouldNotBeNil)
+			So(checkSandboxEnv(""), ShouldNotBeNil)
+		})
+	})
+}
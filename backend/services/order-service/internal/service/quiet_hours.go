@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// urgentNotificationKey 紧急通知上下文标记
+type urgentNotificationKey struct{}
+
+// 不受免打扰时段限制的通知事件
+var urgentNotificationEvents = map[NotificationEvent]bool{
+	NotificationEventPaymentFailure: true,
+}
+
+// WithUrgentNotification 标记紧急通知（如支付失败、账号安全），紧急通知在免打扰时段内也立即发送
+func WithUrgentNotification(ctx context.Context) context.Context {
+	return context.WithValue(ctx, urgentNotificationKey{}, true)
+}
+
+// isUrgentNotification 判断上下文中的通知是否为紧急通知
+func isUrgentNotification(ctx context.Context) bool {
+	urgent, _ := ctx.Value(urgentNotificationKey{}).(bool)
+	return urgent
+}
+
+// QuietHoursPolicyProvider 按租户获取通知免打扰时段策略
+type QuietHoursPolicyProvider func(ctx context.Context, tenantID uint64) (*types.QuietHoursPolicy, error)
+
+// UserTimezoneResolver 获取接收人时区，返回空字符串时使用租户策略配置的时区
+type UserTimezoneResolver func(ctx context.Context, userID uint64) string
+
+// NewTenantQuietHoursPolicyProvider 创建从租户配置读取免打扰时段策略的提供者
+func NewTenantQuietHoursPolicyProvider(tenantRepo repository.ITenantRepository) QuietHoursPolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.QuietHoursPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.QuietHours, nil
+	}
+}
+
+// QuietHoursGate 通知免打扰时段控制：非紧急通知在免打扰时段内写入发件箱，
+// 到达允许发送时间后由发件箱投递器发送；紧急通知不受限制
+type QuietHoursGate struct {
+	policyProvider   QuietHoursPolicyProvider
+	timezoneResolver UserTimezoneResolver
+	outboxRepo       repository.IOutboxRepository
+	now              func() time.Time
+}
+
+// NewQuietHoursGate 创建免打扰时段控制
+func NewQuietHoursGate(policyProvider QuietHoursPolicyProvider, outboxRepo repository.IOutboxRepository) *QuietHoursGate {
+	return &QuietHoursGate{
+		policyProvider: policyProvider,
+		outboxRepo:     outboxRepo,
+		now:            time.Now,
+	}
+}
+
+// NewQuietHoursGateForTest 创建测试用免打扰时段控制，可指定当前时间
+func NewQuietHoursGateForTest(policyProvider QuietHoursPolicyProvider, timezoneResolver UserTimezoneResolver, outboxRepo repository.IOutboxRepository, now func() time.Time) *QuietHoursGate {
+	return &QuietHoursGate{
+		policyProvider:   policyProvider,
+		timezoneResolver: timezoneResolver,
+		outboxRepo:       outboxRepo,
+		now:              now,
+	}
+}
+
+// SetUserTimezoneResolver 设置接收人时区解析，未设置时按租户策略的时区判断
+func (q *QuietHoursGate) SetUserTimezoneResolver(resolver UserTimezoneResolver) {
+	q.timezoneResolver = resolver
+}
+
+// DeferIfQuiet 当前处于接收人的免打扰时段时延迟发送通知，返回通知是否已延迟。
+// 返回 false 时调用方应立即发送
+func (q *QuietHoursGate) DeferIfQuiet(ctx context.Context, notification *types.DeferredNotification) (bool, error) {
+	if isUrgentNotification(ctx) {
+		return false, nil
+	}
+
+	tenantID := gconv.Uint64(ctx.Value("tenant_id"))
+	if tenantID == 0 {
+		return false, nil
+	}
+
+	policy, err := q.policyProvider(ctx, tenantID)
+	if err != nil {
+		return false, err
+	}
+	if policy == nil || !policy.Enabled {
+		return false, nil
+	}
+
+	timezone := ""
+	if q.timezoneResolver != nil {
+		timezone = q.timezoneResolver(ctx, notification.UserID)
+	}
+
+	now := q.now()
+	sendAt, err := policy.NextAllowedTime(now, timezone)
+	if err != nil {
+		return false, err
+	}
+	if !sendAt.After(now) {
+		return false, nil
+	}
+
+	notification.DeferredAt = now
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return false, fmt.Errorf("序列化延迟通知失败: %v", err)
+	}
+
+	event := &types.OutboxEvent{
+		TenantID:      tenantID,
+		AggregateType: "user",
+		AggregateID:   notification.UserID,
+		EventType:     types.OutboxEventNotificationDeferred,
+		Payload:       string(payload),
+		AvailableAt:   sendAt,
+	}
+	if err := q.outboxRepo.Enqueue(ctx, event); err != nil {
+		return false, err
+	}
+
+	g.Log().Info(ctx, "免打扰时段内延迟发送通知",
+		"event_id", event.ID,
+		"user_id", notification.UserID,
+		"channel", notification.Channel,
+		"send_at", sendAt)
+	return true, nil
+}
+
+// quietHoursSMSService 受免打扰时段控制的短信服务
+type quietHoursSMSService struct {
+	next SMSService
+	gate *QuietHoursGate
+}
+
+// NewQuietHoursSMSService 创建受免打扰时段控制的短信服务
+func NewQuietHoursSMSService(next SMSService, gate *QuietHoursGate) SMSService {
+	return &quietHoursSMSService{next: next, gate: gate}
+}
+
+// SendSMS 免打扰时段内延迟发送非紧急短信，其余情况立即发送
+func (s *quietHoursSMSService) SendSMS(ctx context.Context, customerID uint64, templateCode, content string) error {
+	deferred, err := s.gate.DeferIfQuiet(ctx, &types.DeferredNotification{
+		Channel:      types.NotificationChannelSMS,
+		UserID:       customerID,
+		TemplateCode: templateCode,
+		Content:      content,
+	})
+	if err != nil {
+		// 无法判断或延迟时立即发送，避免丢失通知
+		g.Log().Warning(ctx, "免打扰时段判断失败，立即发送短信", "customer_id", customerID, "error", err)
+	}
+	if deferred {
+		return nil
+	}
+	return s.next.SendSMS(ctx, customerID, templateCode, content)
+}
+
+// quietHoursEmailService 受免打扰时段控制的邮件服务
+type quietHoursEmailService struct {
+	next EmailService
+	gate *QuietHoursGate
+}
+
+// NewQuietHoursEmailService 创建受免打扰时段控制的邮件服务
+func NewQuietHoursEmailService(next EmailService, gate *QuietHoursGate) EmailService {
+	return &quietHoursEmailService{next: next, gate: gate}
+}
+
+// SendEmail 免打扰时段内延迟发送非紧急邮件，其余情况立即发送
+func (s *quietHoursEmailService) SendEmail(ctx context.Context, customerID uint64, subject, content string) error {
+	deferred, err := s.gate.DeferIfQuiet(ctx, &types.DeferredNotification{
+		Channel: types.NotificationChannelEmail,
+		UserID:  customerID,
+		Subject: subject,
+		Content: content,
+	})
+	if err != nil {
+		g.Log().Warning(ctx, "免打扰时段判断失败，立即发送邮件", "customer_id", customerID, "error", err)
+	}
+	if deferred {
+		return nil
+	}
+	return s.next.SendEmail(ctx, customerID, subject, content)
+}
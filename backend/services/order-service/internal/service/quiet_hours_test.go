@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/cache"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestQuietHoursNotification(t *testing.T) {
+	Convey("通知免打扰时段测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		shanghai, err := time.LoadLocation("Asia/Shanghai")
+		So(err, ShouldBeNil)
+
+		// 次日凌晨3点处于 22:00-08:00 免打扰时段内，投递器按真实时间判断事件是否到期
+		tomorrow := time.Now().In(shanghai).AddDate(0, 0, 1)
+		now := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 3, 0, 0, 0, shanghai)
+		windowOpen := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 8, 0, 0, 0, shanghai)
+		policy := &types.QuietHoursPolicy{Enabled: true, Start: "22:00", End: "08:00", Timezone: "Asia/Shanghai"}
+		provider := func(ctx context.Context, tenantID uint64) (*types.QuietHoursPolicy, error) {
+			return policy, nil
+		}
+
+		outbox := &fakeOutboxRepository{}
+		gate := NewQuietHoursGateForTest(provider, nil, outbox, func() time.Time { return now })
+		sms := &recordingSMSService{}
+		email := &recordingEmailService{}
+		notificationService := NewNotificationServiceForTest(NewQuietHoursSMSService(sms, gate), NewQuietHoursEmailService(email, gate))
+
+		order := &types.Order{ID: 1, TenantID: 1, OrderNumber: "ORD202610150001", CustomerID: 100, TotalAmount: 300}
+
+		Convey("免打扰时段内的非紧急通知延迟到时段结束后发送", func() {
+			err := notificationService.SendOrderCompletedNotification(ctx, order)
+			So(err, ShouldBeNil)
+			So(sms.templateCodes, ShouldBeEmpty)
+			So(email.subjects, ShouldBeEmpty)
+
+			So(len(outbox.events), ShouldEqual, 2)
+			for _, event := range outbox.events {
+				So(event.EventType, ShouldEqual, types.OutboxEventNotificationDeferred)
+				So(event.TenantID, ShouldEqual, 1)
+				So(event.AvailableAt.Equal(windowOpen), ShouldBeTrue)
+			}
+
+			var deferred types.DeferredNotification
+			So(json.Unmarshal([]byte(outbox.events[0].Payload), &deferred), ShouldBeNil)
+			So(deferred.Channel, ShouldEqual, types.NotificationChannelSMS)
+			So(deferred.UserID, ShouldEqual, 100)
+			So(deferred.TemplateCode, ShouldEqual, "ORDER_COMPLETED")
+
+			Convey("时段结束前投递器不发送，结束后发送", func() {
+				flushedSMS := &recordingSMSService{}
+				flushedEmail := &recordingEmailService{}
+				dispatcher := NewOutboxDispatcherForTest(outbox, nil, NewStatusHookRegistry(), flushedSMS, flushedEmail, nil, cache.NewMockCache())
+
+				dispatched, err := dispatcher.DispatchPending(ctx)
+				So(err, ShouldBeNil)
+				So(dispatched, ShouldEqual, 0)
+				So(flushedSMS.templateCodes, ShouldBeEmpty)
+
+				// 模拟到达允许发送时间
+				for i := range outbox.events {
+					outbox.events[i].AvailableAt = time.Now().Add(-time.Second)
+				}
+				dispatched, err = dispatcher.DispatchPending(ctx)
+				So(err, ShouldBeNil)
+				So(dispatched, ShouldEqual, 2)
+				So(flushedSMS.templateCodes, ShouldResemble, []string{"ORDER_COMPLETED"})
+				So(flushedSMS.contents[0], ShouldEqual, deferred.Content)
+				So(len(flushedEmail.subjects), ShouldEqual, 1)
+				So(outbox.events[0].Status, ShouldEqual, types.OutboxEventStatusProcessed)
+				So(outbox.events[1].Status, ShouldEqual, types.OutboxEventStatusProcessed)
+			})
+		})
+
+		Convey("紧急通知在免打扰时段内立即发送", func() {
+			err := notificationService.SendPaymentFailureNotification(ctx, order)
+			So(err, ShouldBeNil)
+			So(sms.templateCodes, ShouldResemble, []string{"PAYMENT_FAILURE"})
+			So(len(email.subjects), ShouldEqual, 1)
+			So(outbox.events, ShouldBeEmpty)
+		})
+
+		Convey("标记为紧急的通知立即发送", func() {
+			err := NewQuietHoursSMSService(sms, gate).SendSMS(WithUrgentNotification(ctx), 100, "SECURITY_ALERT", "您的账号在新设备登录")
+			So(err, ShouldBeNil)
+			So(sms.templateCodes, ShouldResemble, []string{"SECURITY_ALERT"})
+			So(outbox.events, ShouldBeEmpty)
+		})
+
+		Convey("免打扰时段外立即发送", func() {
+			now = now.Add(7 * time.Hour)
+			err := notificationService.SendOrderCompletedNotification(ctx, order)
+			So(err, ShouldBeNil)
+			So(sms.templateCodes, ShouldResemble, []string{"ORDER_COMPLETED"})
+			So(outbox.events, ShouldBeEmpty)
+		})
+
+		Convey("按接收人时区判断免打扰时段", func() {
+			// 上海凌晨3点为伦敦前一天20点，不在免打扰时段内
+			gate.SetUserTimezoneResolver(func(ctx context.Context, userID uint64) string {
+				return "Europe/London"
+			})
+			err := notificationService.SendOrderCompletedNotification(ctx, order)
+			So(err, ShouldBeNil)
+			So(sms.templateCodes, ShouldResemble, []string{"ORDER_COMPLETED"})
+			So(outbox.events, ShouldBeEmpty)
+		})
+
+		Convey("租户未启用免打扰时段时立即发送", func() {
+			policy.Enabled = false
+			err := notificationService.SendOrderCompletedNotification(ctx, order)
+			So(err, ShouldBeNil)
+			So(sms.templateCodes, ShouldResemble, []string{"ORDER_COMPLETED"})
+			So(outbox.events, ShouldBeEmpty)
+		})
+	})
+}
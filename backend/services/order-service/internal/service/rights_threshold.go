@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	// 默认的权益余额阈值检查频率
+	defaultRightsThresholdCheckInterval = 15 * time.Minute
+	// 每轮最多检查的商户数
+	rightsThresholdBatchSize = 1000
+)
+
+// RightsThresholdAlerter 权益余额阈值提醒
+type RightsThresholdAlerter interface {
+	SendRightsThresholdNotification(ctx context.Context, threshold *types.MerchantRightsThreshold) error
+}
+
+// RightsThresholdMonitor 商户权益余额阈值检查：定期比较商户可用余额与商户配置的预警/紧急阈值，
+// 余额越过更严重的阈值时提醒商户管理员一次，并记录预警级别供商户仪表板生成待处理事项
+type RightsThresholdMonitor struct {
+	repo      repository.IRightsThresholdRepository
+	alerter   RightsThresholdAlerter
+	interval  time.Duration
+	now       func() time.Time
+	stopCh    chan struct{}
+	isRunning bool
+}
+
+// NewRightsThresholdMonitor 创建商户权益余额阈值检查任务
+func NewRightsThresholdMonitor(alerter RightsThresholdAlerter) *RightsThresholdMonitor {
+	interval := g.Cfg().MustGet(context.Background(), "order.rights.thresholdCheckInterval", defaultRightsThresholdCheckInterval).Duration()
+	if interval <= 0 {
+		interval = defaultRightsThresholdCheckInterval
+	}
+	return &RightsThresholdMonitor{
+		repo:     repository.NewRightsThresholdRepository(),
+		alerter:  alerter,
+		interval: interval,
+		now:      time.Now,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// NewRightsThresholdMonitorForTest 创建测试用商户权益余额阈值检查任务，可指定当前时间
+func NewRightsThresholdMonitorForTest(repo repository.IRightsThresholdRepository, alerter RightsThresholdAlerter, now func() time.Time) *RightsThresholdMonitor {
+	return &RightsThresholdMonitor{
+		repo:     repo,
+		alerter:  alerter,
+		interval: defaultRightsThresholdCheckInterval,
+		now:      now,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start 启动后台权益余额阈值检查
+func (m *RightsThresholdMonitor) Start(ctx context.Context) {
+	if m.isRunning {
+		return
+	}
+	m.isRunning = true
+	g.Log().Info(ctx, "启动权益余额阈值检查", "interval", m.interval)
+
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.stopCh:
+				return
+			case <-ticker.C:
+				if _, err := m.CheckThresholds(ctx); err != nil {
+					g.Log().Error(ctx, "权益余额阈值检查失败", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止后台权益余额阈值检查
+func (m *RightsThresholdMonitor) Stop(ctx context.Context) {
+	if !m.isRunning {
+		return
+	}
+	m.isRunning = false
+	close(m.stopCh)
+	g.Log().Info(ctx, "权益余额阈值检查已停止")
+}
+
+// CheckThresholds 检查所有租户配置了阈值的商户，余额从上次检查的级别越过更严重的阈值时发送提醒；
+// 级别不变或余额回升时只更新记录，回升后再次越过阈值会重新提醒。返回发送的提醒数
+func (m *RightsThresholdMonitor) CheckThresholds(ctx context.Context) (int, error) {
+	usages, err := m.repo.ListMerchantUsage(ctx, 0, types.RightsDepletionWindowDays, rightsThresholdBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	now := m.now()
+	alerted := 0
+	for i := range usages {
+		current := types.NewMerchantRightsThreshold(&usages[i], now)
+		if current == nil {
+			continue
+		}
+		// 定时任务没有请求上下文，按商户所属租户设置租户上下文
+		tenantCtx := context.WithValue(ctx, "tenant_id", current.TenantID)
+
+		previous, err := m.repo.GetThreshold(tenantCtx, current.TenantID, current.MerchantID)
+		if err != nil {
+			g.Log().Error(ctx, "获取商户权益预警级别失败", "merchant_id", current.MerchantID, "error", err)
+			continue
+		}
+		previousLevel := types.RightsThresholdNormal
+		if previous != nil {
+			previousLevel = previous.Level
+			if previous.Level == current.Level {
+				current.CrossedAt = previous.CrossedAt
+			}
+		}
+
+		if err := m.repo.SaveThreshold(tenantCtx, current); err != nil {
+			g.Log().Error(ctx, "记录商户权益预警级别失败", "merchant_id", current.MerchantID, "error", err)
+			continue
+		}
+		if !current.Crossed(previousLevel) {
+			continue
+		}
+
+		g.Log().Warning(ctx, "商户权益余额越过阈值",
+			"tenant_id", current.TenantID,
+			"merchant_id", current.MerchantID,
+			"level", current.Level,
+			"threshold", current.Threshold,
+			"available_balance", current.AvailableBalance)
+		if m.alerter != nil {
+			if err := m.alerter.SendRightsThresholdNotification(tenantCtx, current); err != nil {
+				g.Log().Error(ctx, "发送权益余额阈值提醒失败", "merchant_id", current.MerchantID, "error", err)
+			}
+		}
+		alerted++
+	}
+	return alerted, nil
+}
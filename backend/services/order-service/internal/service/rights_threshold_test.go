@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryRightsThresholdRepository 内存实现的权益余额阈值检查仓储
+type memoryRightsThresholdRepository struct {
+	usages     []types.MerchantRightsUsage
+	thresholds map[uint64]*types.MerchantRightsThreshold
+}
+
+func (r *memoryRightsThresholdRepository) ListMerchantUsage(ctx context.Context, tenantID uint64, days int, limit int) ([]types.MerchantRightsUsage, error) {
+	return r.usages, nil
+}
+
+func (r *memoryRightsThresholdRepository) GetThreshold(ctx context.Context, tenantID, merchantID uint64) (*types.MerchantRightsThreshold, error) {
+	return r.thresholds[merchantID], nil
+}
+
+func (r *memoryRightsThresholdRepository) SaveThreshold(ctx context.Context, threshold *types.MerchantRightsThreshold) error {
+	r.thresholds[threshold.MerchantID] = threshold
+	return nil
+}
+
+// recordingRightsThresholdAlerter 记录权益余额阈值提醒的提醒桩
+type recordingRightsThresholdAlerter struct {
+	thresholds []types.MerchantRightsThreshold
+}
+
+func (a *recordingRightsThresholdAlerter) SendRightsThresholdNotification(ctx context.Context, threshold *types.MerchantRightsThreshold) error {
+	a.thresholds = append(a.thresholds, *threshold)
+	return nil
+}
+
+func TestRightsThresholdMonitor(t *testing.T) {
+	Convey("权益余额阈值检查", t, func() {
+		ctx := context.Background()
+		now := time.Date(2026, 10, 15, 10, 0, 0, 0, time.Local)
+		clock := func() time.Time { return now }
+
+		// 商户总权益 1000，预警阈值 200，紧急阈值 50，最近日均消耗 20
+		warning, critical := 200.0, 50.0
+		balance := &types.RightsBalance{TotalBalance: 1000, UsedBalance: 700, WarningThreshold: &warning, CriticalThreshold: &critical}
+		repo := &memoryRightsThresholdRepository{
+			usages: []types.MerchantRightsUsage{
+				{TenantID: 1, MerchantID: 1, MerchantName: "测试商户", RightsBalance: balance, AverageDailyUsage: 20},
+			},
+			thresholds: make(map[uint64]*types.MerchantRightsThreshold),
+		}
+		alerter := &recordingRightsThresholdAlerter{}
+		monitor := NewRightsThresholdMonitorForTest(repo, alerter, clock)
+
+		check := func(used float64) int {
+			balance.UsedBalance = used
+			alerted, err := monitor.CheckThresholds(ctx)
+			So(err, ShouldBeNil)
+			return alerted
+		}
+
+		Convey("余额高于预警阈值时只记录级别", func() {
+			So(check(700), ShouldEqual, 0)
+			So(repo.thresholds[1].Level, ShouldEqual, types.RightsThresholdNormal)
+			So(alerter.thresholds, ShouldBeEmpty)
+		})
+
+		Convey("越过预警阈值时提醒一次并包含预计耗尽时间", func() {
+			So(check(850), ShouldEqual, 1)
+			alert := alerter.thresholds[0]
+			So(alert.Level, ShouldEqual, types.RightsThresholdWarning)
+			So(alert.Threshold, ShouldEqual, 200)
+			So(alert.AvailableBalance, ShouldEqual, 150)
+			So(alert.MerchantName, ShouldEqual, "测试商户")
+			// 可用 150，日均 20，约 8 天后耗尽
+			So(*alert.ProjectedDepletionAt, ShouldEqual, now.AddDate(0, 0, 8))
+			So(alert.Summary(), ShouldContainSubstring, "已低于预警阈值 200.00")
+			So(alert.Summary(), ShouldContainSubstring, "预计 2026-10-23 耗尽（约 8 天）")
+		})
+
+		Convey("越过紧急阈值时再次提醒", func() {
+			So(check(850), ShouldEqual, 1)
+			So(check(960), ShouldEqual, 1)
+			So(alerter.thresholds, ShouldHaveLength, 2)
+			So(alerter.thresholds[1].Level, ShouldEqual, types.RightsThresholdCritical)
+			So(alerter.thresholds[1].Threshold, ShouldEqual, 50)
+		})
+
+		Convey("余额从正常直接降到紧急阈值以下时按紧急级别提醒一次", func() {
+			So(check(980), ShouldEqual, 1)
+			So(alerter.thresholds[0].Level, ShouldEqual, types.RightsThresholdCritical)
+		})
+
+		Convey("停留在同一级别时不重复提醒，记录进入级别的时间", func() {
+			So(check(850), ShouldEqual, 1)
+			crossedAt := now
+
+			now = now.Add(time.Hour)
+			So(check(860), ShouldEqual, 0)
+			So(check(870), ShouldEqual, 0)
+			So(alerter.thresholds, ShouldHaveLength, 1)
+			So(repo.thresholds[1].AvailableBalance, ShouldEqual, 130)
+			So(repo.thresholds[1].CrossedAt, ShouldEqual, crossedAt)
+
+			// 紧急级别回升到预警级别不提醒
+			So(check(960), ShouldEqual, 1)
+			So(check(900), ShouldEqual, 0)
+			So(repo.thresholds[1].Level, ShouldEqual, types.RightsThresholdWarning)
+			So(alerter.thresholds, ShouldHaveLength, 2)
+		})
+
+		Convey("余额回升后再次越过阈值时重新提醒", func() {
+			So(check(850), ShouldEqual, 1)
+			So(check(500), ShouldEqual, 0)
+			So(repo.thresholds[1].Level, ShouldEqual, types.RightsThresholdNormal)
+			So(check(850), ShouldEqual, 1)
+			So(alerter.thresholds, ShouldHaveLength, 2)
+		})
+
+		Convey("最近没有消耗时无法预计耗尽时间", func() {
+			repo.usages[0].AverageDailyUsage = 0
+			So(check(850), ShouldEqual, 1)
+			So(alerter.thresholds[0].ProjectedDepletionAt, ShouldBeNil)
+			So(alerter.thresholds[0].Summary(), ShouldContainSubstring, "暂无法预计耗尽时间")
+		})
+	})
+
+	Convey("权益余额阈值提醒按商户通知偏好发送", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		now := time.Date(2026, 10, 15, 10, 0, 0, 0, time.Local)
+		repo := &memoryDigestRepository{preferences: map[uint64]*types.MerchantNotificationPreference{
+			1: {MerchantID: 1, DigestFrequency: types.MerchantDigestHourly},
+		}}
+		digester := NewMerchantNotificationDigesterForTest(repo, &capturingEmailService{}, "https://merchant.example.com/orders", func() time.Time { return now })
+		email := &capturingEmailService{}
+		notificationService := NewMerchantDigestNotificationServiceForTest(&recordingSMSService{}, email, digester)
+
+		depletion := now.AddDate(0, 0, 8)
+		threshold := &types.MerchantRightsThreshold{TenantID: 1, MerchantID: 1, MerchantName: "测试商户",
+			Level: types.RightsThresholdWarning, Threshold: 200, AvailableBalance: 150, AverageDailyUsage: 20, ProjectedDepletionAt: &depletion}
+
+		Convey("开启汇总时预警阈值提醒转入汇总", func() {
+			So(notificationService.SendRightsThresholdNotification(ctx, threshold), ShouldBeNil)
+			So(email.sent(), ShouldBeEmpty)
+			So(repo.pendingCount(), ShouldEqual, 2)
+			So(repo.entries[0].EventType, ShouldEqual, string(NotificationEventRightsWarning))
+			So(repo.entries[0].Summary, ShouldContainSubstring, "预计 2026-10-23 耗尽")
+		})
+
+		Convey("紧急阈值提醒立即发送给商户管理员", func() {
+			threshold.Level = types.RightsThresholdCritical
+			threshold.Threshold = 50
+			threshold.AvailableBalance = 40
+			threshold.ProjectedDepletionAt = types.ProjectRightsDepletion(40, 20, now)
+
+			So(notificationService.SendRightsThresholdNotification(ctx, threshold), ShouldBeNil)
+			So(repo.pendingCount(), ShouldEqual, 0)
+			sent := email.sent()
+			So(sent, ShouldHaveLength, 2)
+			So(sent[0].userID, ShouldEqual, 1000)
+			So(sent[0].subject, ShouldEqual, "权益余额低于紧急阈值提醒 - 测试商户")
+			So(sent[0].content, ShouldContainSubstring, "当前可用余额：40.00")
+			So(sent[0].content, ShouldContainSubstring, "预计 2026-10-17 耗尽（约 2 天）")
+		})
+
+		Convey("逐条发送的商户立即收到预警阈值提醒", func() {
+			repo.preferences[1].DigestFrequency = types.MerchantDigestImmediate
+
+			So(notificationService.SendRightsThresholdNotification(ctx, threshold), ShouldBeNil)
+			So(email.sent(), ShouldHaveLength, 2)
+			So(email.sent()[1].userID, ShouldEqual, 1001)
+		})
+	})
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/shared/export"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// 每批读取的状态历史条数
+const statusHistoryExportBatchSize = 500
+
+// statusHistoryExportHeader 未指定导出字段时的导出文件表头
+var statusHistoryExportHeader = statusHistoryExportLabels(types.DefaultOrderExportFields)
+
+// StatusHistorySource 订单状态历史导出数据源
+type StatusHistorySource interface {
+	ListForExport(ctx context.Context, query *types.OrderStatusHistoryExportQuery, afterID uint64, limit int) ([]types.OrderStatusHistoryExportRow, error)
+}
+
+// IStatusHistoryExportService 订单状态历史导出服务接口
+type IStatusHistoryExportService interface {
+	// 校验导出条件并限定到当前用户可见的范围
+	PrepareQuery(ctx context.Context, query *types.OrderStatusHistoryExportQuery) error
+	// 将状态历史分批写入 w，返回导出的记录数
+	Export(ctx context.Context, query *types.OrderStatusHistoryExportQuery, w io.Writer) (int, error)
+	// 创建导出任务，文件由导出队列在后台生成
+	CreateExport(ctx context.Context, query *types.OrderStatusHistoryExportQuery) (*types.ExportJob, error)
+}
+
+// StatusHistoryExportService 订单状态历史导出服务
+type StatusHistoryExportService struct {
+	historySource StatusHistorySource
+	exports       *export.Queue
+}
+
+// NewStatusHistoryExportService 创建订单状态历史导出服务实例，并在导出队列中注册状态历史导出
+func NewStatusHistoryExportService(exports *export.Queue) IStatusHistoryExportService {
+	return NewStatusHistoryExportServiceForTest(repository.NewOrderStatusHistoryRepository(), exports)
+}
+
+// NewStatusHistoryExportServiceForTest 创建测试用订单状态历史导出服务实例
+func NewStatusHistoryExportServiceForTest(historySource StatusHistorySource, exports *export.Queue) *StatusHistoryExportService {
+	s := &StatusHistoryExportService{
+		historySource: historySource,
+		exports:       exports,
+	}
+	exports.Register(types.ExportKindOrderStatusHistory, s.generateExport)
+	return s
+}
+
+// PrepareQuery 校验导出条件，商户用户只能导出本商户的订单；未指定导出字段时使用标准列
+func (s *StatusHistoryExportService) PrepareQuery(ctx context.Context, query *types.OrderStatusHistoryExportQuery) error {
+	if query.Format == "" {
+		query.Format = types.OrderExportFormatCSV
+	}
+	if len(query.Fields) == 0 {
+		query.Fields = append([]types.OrderExportField(nil), types.DefaultOrderExportFields...)
+	}
+	if err := query.Validate(); err != nil {
+		return err
+	}
+
+	if merchantID := gconv.Uint64(ctx.Value("merchant_id")); merchantID > 0 {
+		query.MerchantID = &merchantID
+	}
+	return nil
+}
+
+// Export 分批读取状态历史，按导出字段的顺序写入 w
+func (s *StatusHistoryExportService) Export(ctx context.Context, query *types.OrderStatusHistoryExportQuery, w io.Writer) (int, error) {
+	fields := query.Fields
+	if len(fields) == 0 {
+		fields = types.DefaultOrderExportFields
+	}
+
+	writer, err := export.NewRowWriter(query.Format, w)
+	if err != nil {
+		return 0, err
+	}
+	if err := writer.WriteRow(statusHistoryExportLabels(fields)); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	var afterID uint64
+	for {
+		rows, err := s.historySource.ListForExport(ctx, query, afterID, statusHistoryExportBatchSize)
+		if err != nil {
+			return count, err
+		}
+
+		for i := range rows {
+			if err := writer.WriteRow(statusHistoryExportRecord(&rows[i], fields)); err != nil {
+				return count, fmt.Errorf("写入导出记录失败: %v", err)
+			}
+		}
+		count += len(rows)
+
+		if len(rows) < statusHistoryExportBatchSize {
+			break
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+
+	if err := writer.Close(); err != nil {
+		return count, fmt.Errorf("生成导出文件失败: %v", err)
+	}
+	return count, nil
+}
+
+// CreateExport 将导出请求加入导出队列，导出条件需先经过 PrepareQuery 校验
+func (s *StatusHistoryExportService) CreateExport(ctx context.Context, query *types.OrderStatusHistoryExportQuery) (*types.ExportJob, error) {
+	fileName := fmt.Sprintf("order_status_history_%s_%s.%s",
+		query.StartDate.Format("20060102"), query.EndDate.AddDate(0, 0, -1).Format("20060102"), query.Format.Extension())
+
+	return s.exports.Enqueue(ctx, &export.Request{
+		Kind:     types.ExportKindOrderStatusHistory,
+		Format:   string(query.Format),
+		FileName: fileName,
+		Params:   query,
+	})
+}
+
+// generateExport 导出队列中的状态历史生成器
+func (s *StatusHistoryExportService) generateExport(ctx context.Context, job *types.ExportJob, w io.Writer) (int, error) {
+	var query types.OrderStatusHistoryExportQuery
+	if err := json.Unmarshal([]byte(job.Params), &query); err != nil {
+		return 0, fmt.Errorf("解析导出条件失败: %v", err)
+	}
+	return s.Export(ctx, &query, w)
+}
+
+// statusHistoryExportLabels 导出字段对应的表头
+func statusHistoryExportLabels(fields []types.OrderExportField) []string {
+	labels := make([]string, len(fields))
+	for i, field := range fields {
+		labels[i] = field.Label()
+	}
+	return labels
+}
+
+// statusHistoryExportRecord 按导出字段的顺序将状态历史转换为导出行
+func statusHistoryExportRecord(row *types.OrderStatusHistoryExportRow, fields []types.OrderExportField) []string {
+	record := make([]string, len(fields))
+	for i, field := range fields {
+		switch field {
+		case types.OrderExportFieldOrderNumber:
+			record[i] = row.OrderNumber
+		case types.OrderExportFieldOrderID:
+			record[i] = strconv.FormatUint(row.OrderID, 10)
+		case types.OrderExportFieldMerchantID:
+			record[i] = strconv.FormatUint(row.MerchantID, 10)
+		case types.OrderExportFieldCustomerID:
+			record[i] = strconv.FormatUint(row.CustomerID, 10)
+		case types.OrderExportFieldTotalAmount:
+			record[i] = strconv.FormatFloat(row.TotalAmount, 'f', 2, 64)
+		case types.OrderExportFieldFromStatus:
+			record[i] = orderStatusLabel(row.FromStatus)
+		case types.OrderExportFieldToStatus:
+			record[i] = orderStatusLabel(row.ToStatus)
+		case types.OrderExportFieldOperatorType:
+			record[i] = row.OperatorType.String()
+		case types.OrderExportFieldOperatorID:
+			if row.OperatorID != nil {
+				record[i] = strconv.FormatUint(*row.OperatorID, 10)
+			}
+		case types.OrderExportFieldReason:
+			record[i] = row.Reason
+		case types.OrderExportFieldCreatedAt:
+			record[i] = row.CreatedAt.Format("2006-01-02 15:04:05")
+		}
+	}
+	return record
+}
+
+// orderStatusLabel 订单状态名称，订单创建记录没有原状态
+func orderStatusLabel(status types.OrderStatusInt) string {
+	if status == 0 {
+		return ""
+	}
+	return string(status.ToOrderStatus())
+}
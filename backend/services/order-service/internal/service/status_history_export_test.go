@@ -0,0 +1,300 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/export"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/xuri/excelize/v2"
+)
+
+// fakeStatusHistorySource 内存状态历史数据源，按条件过滤并分页
+type fakeStatusHistorySource struct {
+	rows    []types.OrderStatusHistoryExportRow
+	batches int
+}
+
+func (f *fakeStatusHistorySource) ListForExport(ctx context.Context, query *types.OrderStatusHistoryExportQuery, afterID uint64, limit int) ([]types.OrderStatusHistoryExportRow, error) {
+	f.batches++
+	var result []types.OrderStatusHistoryExportRow
+	for _, row := range f.rows {
+		if row.ID <= afterID || row.CreatedAt.Before(query.StartDate) || !row.CreatedAt.Before(query.EndDate) {
+			continue
+		}
+		if query.MerchantID != nil && row.MerchantID != *query.MerchantID {
+			continue
+		}
+		if query.Status != nil && row.ToStatus != *query.Status {
+			continue
+		}
+		result = append(result, row)
+		if len(result) == limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// fakeExportJobRepository 内存导出任务仓储
+type fakeExportJobRepository struct {
+	mu   sync.Mutex
+	jobs []*types.ExportJob
+}
+
+func (f *fakeExportJobRepository) Create(ctx context.Context, job *types.ExportJob) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	job.ID = uint64(len(f.jobs) + 1)
+	job.TenantID = gconv.Uint64(ctx.Value("tenant_id"))
+	job.CreatedAt = time.Now()
+	saved := *job
+	f.jobs = append(f.jobs, &saved)
+	return nil
+}
+
+func (f *fakeExportJobRepository) Transition(ctx context.Context, job *types.ExportJob, from types.ExportJobStatus) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.jobs[job.ID-1].Status != from {
+		return false, nil
+	}
+	saved := *job
+	f.jobs[job.ID-1] = &saved
+	return true, nil
+}
+
+func (f *fakeExportJobRepository) GetByUUID(ctx context.Context, uuid string) (*types.ExportJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, job := range f.jobs {
+		if job.UUID == uuid {
+			found := *job
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeExportJobRepository) CountByStatus(ctx context.Context, status types.ExportJobStatus) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeExportJobRepository) UsageSince(ctx context.Context, since time.Time) (*types.ExportUsage, error) {
+	return &types.ExportUsage{}, nil
+}
+
+func (f *fakeExportJobRepository) ListQueued(ctx context.Context, kinds []string, limit int) ([]types.ExportJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var queued []types.ExportJob
+	for _, job := range f.jobs {
+		if job.Status == types.ExportJobStatusQueued {
+			queued = append(queued, *job)
+		}
+	}
+	return queued, nil
+}
+
+func (f *fakeExportJobRepository) FailStale(ctx context.Context, startedBefore time.Time, reason string) (int, error) {
+	return 0, nil
+}
+
+// readExportCSV 去掉 BOM 后解析导出的 CSV
+func readExportCSV(data []byte) [][]string {
+	So(bytes.HasPrefix(data, []byte("\xEF\xBB\xBF")), ShouldBeTrue)
+	records, err := csv.NewReader(bytes.NewReader(data[3:])).ReadAll()
+	So(err, ShouldBeNil)
+	return records
+}
+
+func TestStatusHistoryExport(t *testing.T) {
+	Convey("订单状态历史导出测试", t, func() {
+		ctx := context.Background()
+		day := time.Date(2025, 9, 1, 0, 0, 0, 0, time.Local)
+		operatorID := uint64(7)
+
+		source := &fakeStatusHistorySource{}
+		for i := 1; i <= 1200; i++ {
+			source.rows = append(source.rows, types.OrderStatusHistoryExportRow{
+				ID:           uint64(i),
+				OrderID:      uint64(i),
+				OrderNumber:  fmt.Sprintf("ORD%04d", i),
+				MerchantID:   uint64(10 + i%2),
+				FromStatus:   types.OrderStatusIntPending,
+				ToStatus:     types.OrderStatusIntPaid,
+				OperatorType: types.OrderStatusOperatorTypeSystem,
+				CreatedAt:    day.Add(time.Duration(i) * time.Minute),
+			})
+		}
+		source.rows = append(source.rows, types.OrderStatusHistoryExportRow{
+			ID:           1201,
+			OrderID:      5,
+			OrderNumber:  "ORD-CANCEL",
+			MerchantID:   11,
+			FromStatus:   types.OrderStatusIntPaid,
+			ToStatus:     types.OrderStatusIntCancelled,
+			OperatorType: types.OrderStatusOperatorTypeMerchant,
+			OperatorID:   &operatorID,
+			Reason:       "缺货",
+			CreatedAt:    day.Add(2 * time.Hour),
+		})
+
+		exports := export.NewQueueForTest(&fakeExportJobRepository{}, types.ExportLimits{}, t.TempDir())
+		exportService := NewStatusHistoryExportServiceForTest(source, exports)
+
+		newQuery := func(days int) *types.OrderStatusHistoryExportQuery {
+			return &types.OrderStatusHistoryExportQuery{StartDate: day, EndDate: day.AddDate(0, 0, days)}
+		}
+
+		Convey("未指定格式时默认CSV，无效条件被拒绝", func() {
+			query := newQuery(1)
+			So(exportService.PrepareQuery(ctx, query), ShouldBeNil)
+			So(query.Format, ShouldEqual, types.OrderExportFormatCSV)
+
+			invalid := newQuery(1)
+			invalid.Format = "pdf"
+			So(exportService.PrepareQuery(ctx, invalid), ShouldNotBeNil)
+
+			reversed := &types.OrderStatusHistoryExportQuery{StartDate: day, EndDate: day.AddDate(0, 0, -1)}
+			So(exportService.PrepareQuery(ctx, reversed), ShouldNotBeNil)
+		})
+
+		Convey("商户用户只能导出本商户的记录", func() {
+			otherMerchant := uint64(99)
+			query := newQuery(1)
+			query.MerchantID = &otherMerchant
+
+			merchantCtx := context.WithValue(ctx, "merchant_id", uint64(10))
+			So(exportService.PrepareQuery(merchantCtx, query), ShouldBeNil)
+			So(*query.MerchantID, ShouldEqual, 10)
+		})
+
+		Convey("分批导出全部记录", func() {
+			query := newQuery(1)
+			So(exportService.PrepareQuery(ctx, query), ShouldBeNil)
+
+			var buf bytes.Buffer
+			count, err := exportService.Export(ctx, query, &buf)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1201)
+			So(source.batches, ShouldEqual, 3)
+
+			records := readExportCSV(buf.Bytes())
+			So(len(records), ShouldEqual, 1202)
+			So(records[0], ShouldResemble, statusHistoryExportHeader)
+		})
+
+		Convey("按商户和变更后状态过滤", func() {
+			merchantID := uint64(11)
+			status := types.OrderStatusIntCancelled
+			query := newQuery(1)
+			query.MerchantID = &merchantID
+			query.Status = &status
+			So(exportService.PrepareQuery(ctx, query), ShouldBeNil)
+
+			var buf bytes.Buffer
+			count, err := exportService.Export(ctx, query, &buf)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+
+			records := readExportCSV(buf.Bytes())
+			So(records[1], ShouldResemble, []string{
+				"ORD-CANCEL", "5", "11", "paid", "cancelled", "merchant", "7", "缺货",
+				day.Add(2 * time.Hour).Format("2006-01-02 15:04:05"),
+			})
+		})
+
+		Convey("按选择的字段和顺序导出", func() {
+			merchantID := uint64(11)
+			status := types.OrderStatusIntCancelled
+			query := newQuery(1)
+			query.MerchantID = &merchantID
+			query.Status = &status
+			query.Fields = types.ParseOrderExportFields("created_at, order_number,to_status,operator_id")
+			So(exportService.PrepareQuery(ctx, query), ShouldBeNil)
+
+			var buf bytes.Buffer
+			_, err := exportService.Export(ctx, query, &buf)
+			So(err, ShouldBeNil)
+
+			records := readExportCSV(buf.Bytes())
+			So(records[0], ShouldResemble, []string{"变更时间", "订单号", "新状态", "操作人ID"})
+			So(records[1], ShouldResemble, []string{
+				day.Add(2 * time.Hour).Format("2006-01-02 15:04:05"), "ORD-CANCEL", "cancelled", "7",
+			})
+		})
+
+		Convey("未指定字段时使用标准列", func() {
+			query := newQuery(1)
+			So(exportService.PrepareQuery(ctx, query), ShouldBeNil)
+			So(query.Fields, ShouldResemble, types.DefaultOrderExportFields)
+		})
+
+		Convey("未知字段或重复字段被拒绝", func() {
+			query := newQuery(1)
+			query.Fields = types.ParseOrderExportFields("order_number,password")
+			err := exportService.PrepareQuery(ctx, query)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "password")
+
+			query = newQuery(1)
+			query.Fields = types.ParseOrderExportFields("order_number,order_number")
+			So(exportService.PrepareQuery(ctx, query), ShouldNotBeNil)
+		})
+
+		Convey("导出Excel文件", func() {
+			query := newQuery(1)
+			query.Format = types.OrderExportFormatExcel
+			So(exportService.PrepareQuery(ctx, query), ShouldBeNil)
+
+			var buf bytes.Buffer
+			count, err := exportService.Export(ctx, query, &buf)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1201)
+
+			file, err := excelize.OpenReader(&buf)
+			So(err, ShouldBeNil)
+			defer file.Close()
+			rows, err := file.GetRows("Sheet1")
+			So(err, ShouldBeNil)
+			So(len(rows), ShouldEqual, 1202)
+			So(rows[0][0], ShouldEqual, "订单号")
+		})
+
+		Convey("导出请求进入导出队列，由队列生成文件", func() {
+			tenantCtx := context.WithValue(ctx, "tenant_id", uint64(1))
+			query := newQuery(60)
+			So(exportService.PrepareQuery(tenantCtx, query), ShouldBeNil)
+
+			job, err := exportService.CreateExport(tenantCtx, query)
+			So(err, ShouldBeNil)
+			So(job.Kind, ShouldEqual, types.ExportKindOrderStatusHistory)
+			So(job.Status, ShouldEqual, types.ExportJobStatusQueued)
+			So(job.FileName, ShouldEqual, "order_status_history_20250901_20251030.csv")
+			So(source.batches, ShouldEqual, 0)
+
+			started, err := exports.DispatchPending(ctx)
+			So(err, ShouldBeNil)
+			So(started, ShouldEqual, 1)
+			exports.Wait()
+
+			completed, err := exports.GetFile(tenantCtx, job.UUID)
+			So(err, ShouldBeNil)
+			So(completed.RowCount, ShouldEqual, 1201)
+
+			data, err := os.ReadFile(completed.FilePath)
+			So(err, ShouldBeNil)
+			So(len(readExportCSV(data)), ShouldEqual, 1202)
+			So(completed.SizeBytes, ShouldEqual, len(data))
+		})
+	})
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// AnyOrderStatus 注册状态变更钩子时匹配任意状态
+const AnyOrderStatus types.OrderStatusInt = 0
+
+// StatusTransitionHook 订单状态变更钩子。
+// 状态变更与发件箱事件在同一事务中提交，钩子由发件箱投递器在提交后调用；
+// 返回错误时事件稍后重试，已成功的钩子不会重复执行
+type StatusTransitionHook func(ctx context.Context, order *types.Order, transition *types.OrderStatusChangedEvent) error
+
+// RegisteredStatusHook 已注册的状态变更钩子
+type RegisteredStatusHook struct {
+	Name       string // 钩子名称，用于去重标记和日志，注册表内唯一
+	FromStatus types.OrderStatusInt
+	ToStatus   types.OrderStatusInt
+	Hook       StatusTransitionHook
+}
+
+// matches 钩子是否订阅了该状态变更
+func (h *RegisteredStatusHook) matches(from, to types.OrderStatusInt) bool {
+	return (h.FromStatus == AnyOrderStatus || h.FromStatus == from) &&
+		(h.ToStatus == AnyOrderStatus || h.ToStatus == to)
+}
+
+// StatusHookRegistry 订单状态变更钩子注册表，按 (原状态, 新状态) 订阅
+type StatusHookRegistry struct {
+	mu    sync.RWMutex
+	hooks []*RegisteredStatusHook
+}
+
+// NewStatusHookRegistry 创建空的状态变更钩子注册表
+func NewStatusHookRegistry() *StatusHookRegistry {
+	return &StatusHookRegistry{}
+}
+
+// Register 订阅状态变更，from/to 为 AnyOrderStatus 时匹配任意状态
+func (r *StatusHookRegistry) Register(name string, from, to types.OrderStatusInt, hook StatusTransitionHook) error {
+	if name == "" || hook == nil {
+		return fmt.Errorf("钩子名称和处理函数不能为空")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, registered := range r.hooks {
+		if registered.Name == name {
+			return fmt.Errorf("状态变更钩子 %s 已注册", name)
+		}
+	}
+	r.hooks = append(r.hooks, &RegisteredStatusHook{Name: name, FromStatus: from, ToStatus: to, Hook: hook})
+	return nil
+}
+
+// Hooks 获取订阅了该状态变更的钩子，按注册顺序返回
+func (r *StatusHookRegistry) Hooks(from, to types.OrderStatusInt) []*RegisteredStatusHook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*RegisteredStatusHook
+	for _, hook := range r.hooks {
+		if hook.matches(from, to) {
+			matched = append(matched, hook)
+		}
+	}
+	return matched
+}
+
+// NewDefaultStatusHookRegistry 创建注册了内置钩子的注册表：状态变更通知、取消后释放订单资源、订单 Webhook 推送
+func NewDefaultStatusHookRegistry(notificationService NotificationService, webhookPublisher OrderEventPublisher) *StatusHookRegistry {
+	reservationRepo := repository.NewInventoryReservationRepository()
+	releaser := NewOrderResourceReleaser(reservationRepo, newInventoryReservationReleaser(reservationRepo), repository.NewOrderRightsReservationRepository())
+
+	// 新建的注册表中内置钩子名称不会冲突
+	registry := NewStatusHookRegistry()
+	_ = RegisterStatusNotificationHook(registry, notificationService)
+	_ = RegisterResourceReleaseHook(registry, releaser)
+	_ = RegisterOrderWebhookHook(registry, webhookPublisher)
+	return registry
+}
+
+// RegisterStatusNotificationHook 注册状态变更通知钩子，任意状态变更都通知客户和商户
+func RegisterStatusNotificationHook(registry *StatusHookRegistry, notificationService NotificationService) error {
+	return registry.Register("status_notification", AnyOrderStatus, AnyOrderStatus,
+		func(ctx context.Context, order *types.Order, transition *types.OrderStatusChangedEvent) error {
+			if transition.OperatorID != nil {
+				ctx = context.WithValue(ctx, "user_id", *transition.OperatorID)
+			}
+			statusHistory := &types.OrderStatusHistory{
+				TenantID:     order.TenantID,
+				OrderID:      transition.OrderID,
+				FromStatus:   transition.FromStatus,
+				ToStatus:     transition.ToStatus,
+				Reason:       transition.Reason,
+				OperatorID:   transition.OperatorID,
+				OperatorType: transition.OperatorType,
+			}
+			return notificationService.SendOrderStatusChangedNotification(ctx, order, statusHistory)
+		})
+}
+
+// RegisterResourceReleaseHook 注册订单取消后释放库存预留和权益的钩子。
+// 取消退款的金额和手续费需要同步返回给调用方，仍由 CancelOrder 在取消后直接处理
+func RegisterResourceReleaseHook(registry *StatusHookRegistry, releaser *OrderResourceReleaser) error {
+	return registry.Register("release_order_resources", AnyOrderStatus, types.OrderStatusIntCancelled,
+		func(ctx context.Context, order *types.Order, transition *types.OrderStatusChangedEvent) error {
+			return releaser.ReleaseOrderResources(ctx, order)
+		})
+}
+
+// OrderResourceReleaser 释放订单占用的库存预留和权益
+type OrderResourceReleaser struct {
+	reservationRepo     repository.IInventoryReservationRepository
+	reservationReleaser ReservationReleaser
+	rightsRepo          repository.IOrderRightsReservationRepository
+}
+
+// NewOrderResourceReleaser 创建订单资源释放器，rightsRepo 为 nil 时不释放权益
+func NewOrderResourceReleaser(reservationRepo repository.IInventoryReservationRepository, reservationReleaser ReservationReleaser, rightsRepo repository.IOrderRightsReservationRepository) *OrderResourceReleaser {
+	return &OrderResourceReleaser{
+		reservationRepo:     reservationRepo,
+		reservationReleaser: reservationReleaser,
+		rightsRepo:          rightsRepo,
+	}
+}
+
+// ReleaseOrderResources 释放订单资源（库存和权益），已释放的预留和权益会被跳过，可重复调用
+func (r *OrderResourceReleaser) ReleaseOrderResources(ctx context.Context, order *types.Order) error {
+	if err := r.releaseInventory(ctx, order); err != nil {
+		return fmt.Errorf("释放库存失败: %v", err)
+	}
+
+	if err := releaseOrderRights(ctx, r.rightsRepo, order); err != nil {
+		return fmt.Errorf("释放权益失败: %v", err)
+	}
+	return nil
+}
+
+// releaseInventory 释放订单仍活跃的库存预留
+func (r *OrderResourceReleaser) releaseInventory(ctx context.Context, order *types.Order) error {
+	g.Log().Info(ctx, "释放订单库存", "order_id", order.ID)
+
+	reservations, err := r.reservationRepo.GetByReference(ctx, order.TenantID, types.ReservationReferenceOrder, strconv.FormatUint(order.ID, 10))
+	if err != nil {
+		return fmt.Errorf("获取订单库存预留失败: %v", err)
+	}
+
+	return releaseOrderReservations(ctx, r.reservationReleaser, order, reservations, types.ReservationStatusReleased)
+}
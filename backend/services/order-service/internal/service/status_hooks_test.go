@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/cache"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// countingHook 记录执行次数的状态变更钩子，failures 次数内返回错误
+type countingHook struct {
+	calls    int
+	failures int
+}
+
+func (h *countingHook) handle(ctx context.Context, order *types.Order, transition *types.OrderStatusChangedEvent) error {
+	if h.failures > 0 {
+		h.failures--
+		return fmt.Errorf("下游服务不可用")
+	}
+	h.calls++
+	return nil
+}
+
+func TestStatusHookRegistry(t *testing.T) {
+	Convey("订单状态变更钩子测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		ctx = context.WithValue(ctx, "user_id", uint64(7))
+
+		outbox := &fakeOutboxRepository{}
+		orderRepo := &fakeOutboxOrderRepository{
+			orders: map[uint64]*types.Order{1: {ID: 1, TenantID: 1, MerchantID: 10, Status: types.OrderStatusPaid}},
+			outbox: outbox,
+		}
+		statusService := NewOrderStatusServiceForTest(orderRepo)
+		hooks := NewStatusHookRegistry()
+		dispatcher := NewOutboxDispatcherForTest(outbox, orderRepo, hooks, nil, nil, nil, cache.NewMockCache())
+
+		processing := &countingHook{}
+		cancelled := &countingHook{}
+		anyHook := &countingHook{}
+		So(hooks.Register("on_processing", types.OrderStatusIntPaid, types.OrderStatusIntProcessing, processing.handle), ShouldBeNil)
+		So(hooks.Register("on_cancelled", AnyOrderStatus, types.OrderStatusIntCancelled, cancelled.handle), ShouldBeNil)
+		So(hooks.Register("on_any", AnyOrderStatus, AnyOrderStatus, anyHook.handle), ShouldBeNil)
+
+		updateStatus := func(status types.OrderStatusInt) {
+			err := statusService.UpdateOrderStatus(ctx, 1, &types.UpdateOrderStatusRequest{
+				Status:       status,
+				Reason:       "测试状态变更",
+				OperatorType: types.OrderStatusOperatorTypeMerchant,
+			})
+			So(err, ShouldBeNil)
+		}
+
+		Convey("钩子名称不能重复注册", func() {
+			So(hooks.Register("on_processing", AnyOrderStatus, AnyOrderStatus, processing.handle), ShouldNotBeNil)
+		})
+
+		Convey("按原状态和新状态匹配钩子", func() {
+			names := func(matched []*RegisteredStatusHook) []string {
+				var result []string
+				for _, hook := range matched {
+					result = append(result, hook.Name)
+				}
+				return result
+			}
+			So(names(hooks.Hooks(types.OrderStatusIntPaid, types.OrderStatusIntProcessing)), ShouldResemble, []string{"on_processing", "on_any"})
+			So(names(hooks.Hooks(types.OrderStatusIntPending, types.OrderStatusIntCancelled)), ShouldResemble, []string{"on_cancelled", "on_any"})
+			So(names(hooks.Hooks(types.OrderStatusIntPending, types.OrderStatusIntProcessing)), ShouldResemble, []string{"on_any"})
+		})
+
+		Convey("状态变更提交后订阅的钩子只执行一次", func() {
+			updateStatus(types.OrderStatusIntProcessing)
+			// 钩子由投递器在提交后执行，请求内不执行
+			So(processing.calls, ShouldEqual, 0)
+
+			count, err := dispatcher.DispatchPending(context.Background())
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+			So(processing.calls, ShouldEqual, 1)
+			So(anyHook.calls, ShouldEqual, 1)
+			So(cancelled.calls, ShouldEqual, 0)
+
+			// 重复投递不会再次执行
+			outbox.events[0].Status = types.OutboxEventStatusPending
+			_, err = dispatcher.DispatchPending(context.Background())
+			So(err, ShouldBeNil)
+			So(processing.calls, ShouldEqual, 1)
+			So(anyHook.calls, ShouldEqual, 1)
+		})
+
+		Convey("其他钩子失败重试时已成功的钩子不重复执行", func() {
+			anyHook.failures = 1
+			updateStatus(types.OrderStatusIntCancelled)
+
+			count, _ := dispatcher.DispatchPending(context.Background())
+			So(count, ShouldEqual, 0)
+			So(outbox.events[0].Status, ShouldEqual, types.OutboxEventStatusPending)
+			So(outbox.events[0].LastError, ShouldContainSubstring, "on_any")
+			So(cancelled.calls, ShouldEqual, 1)
+			So(anyHook.calls, ShouldEqual, 0)
+
+			outbox.events[0].AvailableAt = time.Now()
+			count, _ = dispatcher.DispatchPending(context.Background())
+			So(count, ShouldEqual, 1)
+			So(cancelled.calls, ShouldEqual, 1)
+			So(anyHook.calls, ShouldEqual, 1)
+			So(processing.calls, ShouldEqual, 0)
+		})
+
+		Convey("订单取消后释放仍活跃的库存预留", func() {
+			expiresAt := time.Now().Add(time.Minute)
+			reservationRepo := &fakeReservationRepository{reservations: []types.InventoryReservation{{
+				ID: 11, TenantID: 1, ProductID: 100, ReservedQuantity: 2,
+				ReferenceType: types.ReservationReferenceOrder, ReferenceID: "1",
+				Status: types.ReservationStatusActive, ExpiresAt: &expiresAt,
+			}}}
+			releaser := &recordingReservationReleaser{released: map[uint64]types.ReservationStatus{}, failIDs: map[uint64]bool{}}
+			So(RegisterResourceReleaseHook(hooks, NewOrderResourceReleaser(reservationRepo, releaser, nil)), ShouldBeNil)
+
+			updateStatus(types.OrderStatusIntCancelled)
+			count, err := dispatcher.DispatchPending(context.Background())
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+			So(releaser.released, ShouldResemble, map[uint64]types.ReservationStatus{11: types.ReservationStatusReleased})
+			So(releaser.merchantID, ShouldEqual, 10)
+		})
+	})
+}
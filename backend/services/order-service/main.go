@@ -4,7 +4,12 @@ import (
 	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/controller"
 	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/service"
 	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/export"
+	"github.com/gofromzero/mer-sys/backend/shared/handlers"
+	"github.com/gofromzero/mer-sys/backend/shared/maintenance"
 	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/os/gctx"
@@ -17,25 +22,104 @@ func main() {
 	auth.NewJWTManager()
 	ctx := gctx.GetInitCtx()
 
+	// 支付沙箱严禁在生产环境启用，配置不合法时拒绝启动
+	sandboxConfig, err := service.LoadPaymentSandboxConfig(ctx)
+	if err != nil {
+		g.Log().Fatalf(ctx, "支付沙箱配置校验失败: %v", err)
+	}
+
+	// 未启用沙箱时支付宝回调必须验签，未配置公钥时拒绝启动
+	if err := service.ValidateAlipayConfig(ctx, sandboxConfig); err != nil {
+		g.Log().Fatalf(ctx, "支付宝配置校验失败: %v", err)
+	}
+
+	// 数据驻留配置映射了未定义的数据库分组时拒绝启动，避免租户数据落入共享数据库
+	if err := repository.InitTenantDatasourceConfig(ctx); err != nil {
+		g.Log().Fatalf(ctx, "数据驻留配置校验失败: %v", err)
+	}
+
 	s := g.Server()
 
+	// 应用连接池配置并暴露连接池指标
+	handlers.RegisterDBPoolMetrics(s, "order-service")
+
 	// 创建中间件实例
 	authMiddleware := middleware.NewAuthMiddleware()
 
+	// 维护模式下拒绝写请求，状态在各服务间共享
+	maintenanceManager := maintenance.NewManager()
+	maintenanceGuard := middleware.MaintenanceMode(maintenanceManager)
+
 	// 创建WebSocket控制器（作为通知器使用）
 	webSocketController := controller.NewWebSocketController()
 	
 	// 创建其他控制器
-	orderController := controller.NewOrderController()
+	orderController := controller.NewOrderController(authMiddleware)
 	cartController := controller.NewCartController()
 	paymentController := controller.NewPaymentController()
 	orderStatusController := controller.NewOrderStatusController()
+	orderNoteController := controller.NewOrderNoteController()
+	orderTagController := controller.NewOrderTagController()
 	
 	// 创建超时相关控制器
 	orderStatusService := service.NewOrderStatusService()
 	notificationService := service.NewNotificationService()
 	orderTimeoutController := controller.NewOrderTimeoutController(orderStatusService, notificationService)
 	orderTimeoutConfigController := controller.NewOrderTimeoutConfigController()
+	cancellationPolicyController := controller.NewOrderCancellationPolicyController()
+	orderNumberFormatController := controller.NewOrderNumberFormatController()
+	exportQueue := export.NewQueue()
+	statusHistoryExportController := controller.NewStatusHistoryExportController(service.NewStatusHistoryExportService(exportQueue))
+	exportController := controller.NewExportController(exportQueue)
+	customerSegmentExportController := controller.NewCustomerSegmentExportController(service.NewCustomerSegmentExportService(exportQueue))
+	orderExportScheduleService := service.NewOrderExportScheduleService(exportQueue)
+	orderExportScheduleController := controller.NewOrderExportScheduleController(orderExportScheduleService)
+	attachmentController := controller.NewAttachmentController()
+	orderWebhookController := controller.NewOrderWebhookController()
+	merchantDigester := service.NewMerchantNotificationDigester()
+	merchantNotificationPreferenceController := controller.NewMerchantNotificationPreferenceController(merchantDigester)
+	orderSLAService := service.NewOrderSLAService(notificationService)
+	rightsThresholdMonitor := service.NewRightsThresholdMonitor(notificationService)
+	orderSLAController := controller.NewOrderSLAController(orderSLAService)
+	orderDisputeController := controller.NewOrderDisputeController(service.NewOrderDisputeService(notificationService))
+	orderFulfillmentController := controller.NewOrderFulfillmentController(service.NewOrderFulfillmentService(notificationService))
+	orderReviewController := controller.NewOrderReviewController(service.NewOrderReviewService(orderStatusService))
+	cartRecoveryService := service.NewCartRecoveryService(notificationService)
+	cartRecoveryController := controller.NewCartRecoveryController(cartRecoveryService)
+	customerAddressController := controller.NewCustomerAddressController(service.NewCustomerAddressService())
+	orderInvoiceController := controller.NewOrderInvoiceController(service.NewOrderInvoiceService())
+	orderRecomputeController := controller.NewOrderRecomputeController(service.NewOrderRecomputeService())
+	broadcastService := service.NewBroadcastService()
+	broadcastController := controller.NewBroadcastController(broadcastService)
+
+	// 启动发件箱投递器，投递订单状态变更等事件（包括重启前未投递的事件）
+	outboxDispatcher := service.NewOutboxDispatcher(notificationService)
+	outboxDispatcher.Start(ctx)
+
+	// 启动商户通知汇总任务，按商户设置的频率发送非紧急通知汇总
+	merchantDigester.Start(ctx)
+
+	// 启动订单处理超时检查，超过状态处理时限的订单提醒商户人工处理（不会取消订单）
+	orderSLAService.Start(ctx)
+
+	// 启动权益余额阈值检查，商户可用权益越过预警或紧急阈值时提醒商户管理员（每次越过只提醒一次）
+	rightsThresholdMonitor.Start(ctx)
+
+	// 启动购物车检查，清空超过保留时间未变更的购物车，闲置购物车提醒客户完成购买
+	cartRecoveryService.Start(ctx)
+
+	// 启动群发通知发送，每个群发通知每次按批发送给一部分接收人
+	broadcastService.Start(ctx)
+
+	// 启动导出队列，按租户并发数限制生成排队的导出文件
+	exportQueue.Start(ctx)
+
+	// 启动定时订单导出，到期的定时导出进入导出队列，生成完成后投递给财务系统
+	orderExportScheduleService.Start(ctx)
+
+	// 启动支付对账任务，补记支付回调丢失的订单
+	paymentReconciler := service.NewPaymentReconciler(service.NewPaymentService())
+	paymentReconciler.Start(ctx)
 	
 	// 为了简化实现，我们暂时注释掉WebSocket集成
 	// 在生产环境中，应该通过依赖注入或服务发现来设置
@@ -43,25 +127,51 @@ func main() {
 
 	// 注册路由
 	s.Group("/api/v1", func(group *ghttp.RouterGroup) {
+		// 记录访问日志，配置的路由按采样记录脱敏后的请求/响应体
+		group.Middleware(middleware.AccessLog(middleware.LoadAccessLogConfig(ctx)))
+		// 支持 fields 参数只返回指定的响应字段
+		group.Middleware(middleware.ResponseFields(middleware.LoadResponseFieldsConfig(ctx)))
+
 		// 购物车路由（需要认证）
 		group.Group("/cart", func(cartGroup *ghttp.RouterGroup) {
-			cartGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation)
+			cartGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard)
 
 			cartGroup.GET("/", cartController.GetCart)
 			cartGroup.POST("/items", cartController.AddItem)
+			cartGroup.POST("/bundles", cartController.AddBundle)
 			cartGroup.PUT("/items/:item_id", cartController.UpdateItem)
 			cartGroup.DELETE("/items/:item_id", cartController.RemoveItem)
 			cartGroup.DELETE("/", cartController.ClearCart)
+			cartGroup.GET("/recovery-preference", cartRecoveryController.GetPreference)
+			cartGroup.PUT("/recovery-preference", cartRecoveryController.UpdatePreference)
+		})
+
+		// 收货地址簿路由（需要认证，客户只能管理自己的地址）
+		group.Group("/addresses", func(addressGroup *ghttp.RouterGroup) {
+			addressGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard)
+
+			addressGroup.GET("/", customerAddressController.ListAddresses)
+			addressGroup.POST("/", customerAddressController.CreateAddress)
+			addressGroup.GET("/:address_id", customerAddressController.GetAddress)
+			addressGroup.PUT("/:address_id", customerAddressController.UpdateAddress)
+			addressGroup.PUT("/:address_id/default", customerAddressController.SetDefaultAddress)
+			addressGroup.DELETE("/:address_id", customerAddressController.DeleteAddress)
 		})
 
 		// 订单路由（需要认证）
 		group.Group("/orders", func(orderGroup *ghttp.RouterGroup) {
-			orderGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation)
+			orderGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard)
 
 			orderGroup.POST("/", orderController.CreateOrder)
+			orderGroup.POST("/batch", orderController.BatchCreateOrders)
 			orderGroup.GET("/", orderController.ListOrders)
+			// 按订单号或外部订单号查询
+			orderGroup.GET("/lookup", orderController.LookupOrder)
 			orderGroup.GET("/:order_id", orderController.GetOrder)
+			// 取消已支付订单会原路退款，需要退款时由服务校验近期重新验证身份
 			orderGroup.PUT("/:order_id/cancel", orderController.CancelOrder)
+			orderGroup.PUT("/:order_id/items", orderController.UpdateOrderItems)
+			orderGroup.POST("/:order_id/reorder", orderController.Reorder)
 
 			// 高级查询功能
 			orderGroup.GET("/query", orderController.QueryOrders)
@@ -74,6 +184,79 @@ func main() {
 			orderGroup.GET("/:order_id/status-history", orderStatusController.GetOrderStatusHistory)
 			orderGroup.GET("/:order_id/validate-status-transition", orderStatusController.ValidateStatusTransition)
 			orderGroup.POST("/batch-update-status", orderStatusController.BatchUpdateOrderStatus)
+
+			// 订单派生字段重算路由（仅限租户管理员）
+			orderGroup.POST("/recompute",
+				authMiddleware.RequirePermissions(types.PermissionOrderManage),
+				orderRecomputeController.Recompute)
+
+			// 订单状态历史导出路由（进入导出队列后台生成，通过 /exports/:id 下载）
+			orderGroup.GET("/status-history/export",
+				authMiddleware.RequireAnyPermission(types.PermissionReportExport, types.PermissionMerchantReportExport),
+				statusHistoryExportController.Export)
+
+			// 定时订单导出路由（仅限租户管理员）
+			orderGroup.Group("/export-schedules", func(scheduleGroup *ghttp.RouterGroup) {
+				scheduleGroup.Middleware(authMiddleware.RequirePermissions(types.PermissionOrderManage))
+				scheduleGroup.POST("/", orderExportScheduleController.Create)
+				scheduleGroup.GET("/", orderExportScheduleController.List)
+				scheduleGroup.PUT("/:id/enable", orderExportScheduleController.Enable)
+				scheduleGroup.PUT("/:id/disable", orderExportScheduleController.Disable)
+				scheduleGroup.GET("/:id/runs", orderExportScheduleController.ListRuns)
+			})
+
+			// 订单备注路由（添加备注仅限租户或商户员工）
+			orderGroup.POST("/:order_id/notes",
+				authMiddleware.RequireAnyPermission(types.PermissionOrderUpdate, types.PermissionMerchantOrderProcess),
+				orderNoteController.AddNote)
+			orderGroup.GET("/:order_id/notes", orderNoteController.ListNotes)
+
+			// 订单标签路由（添加和移除标签仅限租户或商户员工）
+			orderGroup.POST("/:order_id/tags",
+				authMiddleware.RequireAnyPermission(types.PermissionOrderUpdate, types.PermissionMerchantOrderProcess),
+				orderTagController.AddTags)
+			orderGroup.DELETE("/:order_id/tags/:tag",
+				authMiddleware.RequireAnyPermission(types.PermissionOrderUpdate, types.PermissionMerchantOrderProcess),
+				orderTagController.RemoveTag)
+
+			// 订单争议路由（客户发起争议，处理争议仅限租户或商户员工）
+			orderGroup.POST("/:order_id/disputes", orderDisputeController.OpenDispute)
+			orderGroup.GET("/:order_id/disputes", orderDisputeController.ListOrderDisputes)
+			orderGroup.GET("/disputes",
+				authMiddleware.RequireAnyPermission(types.PermissionOrderUpdate, types.PermissionMerchantOrderProcess),
+				orderDisputeController.ListDisputes)
+			orderGroup.GET("/disputes/:dispute_id", orderDisputeController.GetDispute)
+			orderGroup.PUT("/disputes/:dispute_id/status",
+				authMiddleware.RequireAnyPermission(types.PermissionOrderUpdate, types.PermissionMerchantOrderProcess),
+				orderDisputeController.UpdateDisputeStatus)
+
+			// 订单履约路由（拆单和更新包裹状态仅限租户或商户员工，客户可查看自己订单的包裹）
+			orderGroup.Group("/:order_id/fulfillments", func(fulfillmentGroup *ghttp.RouterGroup) {
+				fulfillmentGroup.GET("/", orderFulfillmentController.ListFulfillments)
+				fulfillmentGroup.Group("/", func(staffGroup *ghttp.RouterGroup) {
+					staffGroup.Middleware(authMiddleware.RequireAnyPermission(types.PermissionOrderUpdate, types.PermissionMerchantOrderProcess))
+					staffGroup.POST("/", orderFulfillmentController.SplitOrder)
+					staffGroup.PUT("/:fulfillment_id/status", orderFulfillmentController.UpdateFulfillmentStatus)
+				})
+			})
+
+			// 订单发票路由（下单客户和订单所属商户可下载）
+			orderGroup.GET("/:order_id/invoice", orderInvoiceController.GetInvoice)
+
+			// 订单风险审核路由（审核队列和审核决定仅限租户或商户员工）
+			orderGroup.Group("/reviews", func(reviewGroup *ghttp.RouterGroup) {
+				reviewGroup.Middleware(authMiddleware.RequireAnyPermission(types.PermissionOrderUpdate, types.PermissionMerchantOrderProcess))
+				reviewGroup.GET("/", orderReviewController.ListReviews)
+				reviewGroup.GET("/:review_id", orderReviewController.GetReview)
+				reviewGroup.POST("/:review_id/decision", orderReviewController.DecideReview)
+			})
+
+			// 订单筛选视图路由（按用户保存的命名筛选条件）
+			orderGroup.POST("/views", orderTagController.SaveView)
+			orderGroup.GET("/views", orderTagController.ListViews)
+			orderGroup.GET("/views/:view_id", orderTagController.GetView)
+			orderGroup.DELETE("/views/:view_id", orderTagController.DeleteView)
+			orderGroup.GET("/views/:view_id/orders", orderTagController.QueryView)
 			
 			// 订单超时管理路由
 			orderGroup.POST("/timeout/start", orderTimeoutController.StartTimeoutMonitor)
@@ -90,10 +273,106 @@ func main() {
 			orderGroup.PUT("/timeout-configs", orderTimeoutConfigController.UpdateTimeoutConfig)
 			orderGroup.DELETE("/timeout-configs/:id", orderTimeoutConfigController.DeleteTimeoutConfig)
 
+			// 订单取消策略路由（修改策略仅限租户员工）
+			orderGroup.GET("/cancellation-policies/effective/:merchant_id", cancellationPolicyController.GetEffectivePolicy)
+			orderGroup.PUT("/cancellation-policies",
+				authMiddleware.RequireAnyPermission(types.PermissionOrderUpdate),
+				cancellationPolicyController.SavePolicy)
+
+			// 订单处理时限路由（修改配置仅限租户员工）
+			orderGroup.GET("/sla-configs/effective/:merchant_id", orderSLAController.GetEffectiveConfig)
+			orderGroup.PUT("/sla-configs",
+				authMiddleware.RequireAnyPermission(types.PermissionOrderUpdate),
+				orderSLAController.SaveConfig)
+			orderGroup.GET("/sla-breaches", orderSLAController.ListBreaches)
+			orderGroup.GET("/:order_id/sla", orderSLAController.GetOrderSLA)
+
+			// 商户通知偏好路由（修改偏好仅限有订单管理权限的用户）
+			orderGroup.GET("/merchant-notification-preferences/:merchant_id", merchantNotificationPreferenceController.GetPreference)
+			orderGroup.PUT("/merchant-notification-preferences/:merchant_id",
+				authMiddleware.RequireAnyPermission(types.PermissionOrderUpdate),
+				merchantNotificationPreferenceController.UpdatePreference)
+
+			// 订单号格式路由（修改格式仅限租户员工）
+			orderGroup.GET("/number-format", orderNumberFormatController.GetFormat)
+			orderGroup.PUT("/number-format",
+				authMiddleware.RequireAnyPermission(types.PermissionOrderUpdate),
+				orderNumberFormatController.SaveFormat)
+			orderGroup.DELETE("/number-format",
+				authMiddleware.RequireAnyPermission(types.PermissionOrderUpdate),
+				orderNumberFormatController.ResetFormat)
+
 			// 支付相关路由
 			orderGroup.POST("/:order_id/pay", paymentController.InitiatePayment)
 			orderGroup.GET("/:order_id/payment-status", paymentController.GetPaymentStatus)
 			orderGroup.POST("/:order_id/retry-payment", paymentController.RetryPayment)
+
+			// 支付沙箱测试路由（仅沙箱模式下注册）
+			if paymentController.SandboxEnabled() {
+				orderGroup.POST("/:order_id/sandbox-outcome", paymentController.SetSandboxOutcome)
+				g.Log().Warning(ctx, "支付沙箱模式已启用，支付将由模拟器处理")
+			}
+		})
+
+		// 证明材料附件路由（退款凭证、审计事件证明材料，按关联记录类型进一步校验权限）
+		group.Group("/attachments", func(attachmentGroup *ghttp.RouterGroup) {
+			attachmentGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard,
+				authMiddleware.RequireAnyPermission(types.PermissionSystemAudit, types.PermissionOrderUpdate, types.PermissionMerchantOrderProcess))
+
+			attachmentGroup.POST("/", attachmentController.Upload)
+			attachmentGroup.GET("/", attachmentController.List)
+			attachmentGroup.POST("/:id/link", attachmentController.Link)
+			attachmentGroup.GET("/:id/download", attachmentController.Download)
+		})
+
+		// 导出任务路由：查询导出进度并下载文件
+		group.Group("/exports", func(exportGroup *ghttp.RouterGroup) {
+			exportGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard,
+				authMiddleware.RequireAnyPermission(types.PermissionReportExport, types.PermissionMerchantReportExport))
+
+			exportGroup.GET("/:id", exportController.Get)
+			exportGroup.GET("/:id/download", exportController.Download)
+		})
+
+		// 客户分群营销导出路由：导出内容包含客户联系方式，需同时具有报表导出和用户查看权限
+		group.Group("/customer-segments", func(segmentGroup *ghttp.RouterGroup) {
+			segmentGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard,
+				authMiddleware.RequirePermissions(types.PermissionReportExport, types.PermissionUserView))
+
+			segmentGroup.GET("/export", customerSegmentExportController.Export)
+		})
+
+		// 订单事件 Webhook 路由（配置推送地址仅限租户管理员）
+		group.Group("/order-webhooks", func(webhookGroup *ghttp.RouterGroup) {
+			webhookGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard,
+				authMiddleware.RequireAnyPermission(types.PermissionTenantManage, types.PermissionSystemConfig))
+
+			webhookGroup.POST("/", orderWebhookController.Create)
+			webhookGroup.GET("/", orderWebhookController.List)
+			webhookGroup.PUT("/:id", orderWebhookController.Update)
+			webhookGroup.DELETE("/:id", orderWebhookController.Delete)
+			webhookGroup.GET("/:id/deliveries", orderWebhookController.ListDeliveries)
+			webhookGroup.POST("/:id/test", orderWebhookController.TestFire)
+		})
+
+		// 群发通知路由（仅限有群发通知权限的租户员工）
+		group.Group("/notification-broadcasts", func(broadcastGroup *ghttp.RouterGroup) {
+			broadcastGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard,
+				authMiddleware.RequirePermissions(types.PermissionNotificationBroadcast))
+
+			broadcastGroup.POST("/preview", broadcastController.Preview)
+			broadcastGroup.POST("/", broadcastController.Create)
+			broadcastGroup.GET("/", broadcastController.List)
+			broadcastGroup.GET("/:id", broadcastController.Get)
+			broadcastGroup.GET("/:id/deliveries", broadcastController.ListDeliveries)
+		})
+
+		// 群发通知偏好路由（客户和商户用户退订群发通知）
+		group.Group("/notification-preferences", func(preferenceGroup *ghttp.RouterGroup) {
+			preferenceGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard)
+
+			preferenceGroup.GET("/broadcast", broadcastController.GetPreference)
+			preferenceGroup.PUT("/broadcast", broadcastController.UpdatePreference)
 		})
 
 		// 支付回调路由（无需认证，但需要验证签名）
@@ -110,9 +389,11 @@ func main() {
 
 	// 健康检查端点
 	s.BindHandler("/health", func(r *ghttp.Request) {
+		maintenanceStatus, _ := maintenanceManager.Status(r.Context(), 0)
 		r.Response.WriteJsonExit(g.Map{
-			"status":  "healthy",
-			"service": "order-service",
+			"status":      "healthy",
+			"service":     "order-service",
+			"maintenance": maintenanceStatus,
 		})
 	})
 
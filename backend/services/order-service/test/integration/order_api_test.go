@@ -11,6 +11,7 @@ import (
 
 	"github.com/gofromzero/mer-sys/backend/services/order-service/internal/controller"
 	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/test/fixtures"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
@@ -63,7 +64,7 @@ func TestOrderAPI(t *testing.T) {
 		defer testServer.Close()
 
 		Convey("购物车API测试", func() {
-			token := generateTestJWT(1, 1) // customer_id=1, tenant_id=1
+			token := generateTestJWT(t, 1, 1) // customer_id=1, tenant_id=1
 
 			Convey("获取购物车", func() {
 				req, _ := http.NewRequest("GET", testServer.URL+"/api/v1/cart", nil)
@@ -94,7 +95,7 @@ func TestOrderAPI(t *testing.T) {
 		})
 
 		Convey("订单API测试", func() {
-			token := generateTestJWT(1, 1)
+			token := generateTestJWT(t, 1, 1)
 
 			Convey("获取订单确认信息", func() {
 				reqBody := map[string]interface{}{
@@ -170,7 +171,7 @@ func TestOrderAPI(t *testing.T) {
 		})
 
 		Convey("支付API测试", func() {
-			token := generateTestJWT(1, 1)
+			token := generateTestJWT(t, 1, 1)
 
 			// 先创建订单
 			orderReqBody := map[string]interface{}{
@@ -236,8 +237,8 @@ func TestOrderAPI(t *testing.T) {
 		})
 
 		Convey("多租户隔离测试", func() {
-			tenant1Token := generateTestJWT(1, 1) // customer_id=1, tenant_id=1
-			tenant2Token := generateTestJWT(2, 2) // customer_id=2, tenant_id=2
+			tenant1Token := generateTestJWT(t, 1, 1) // customer_id=1, tenant_id=1
+			tenant2Token := generateTestJWT(t, 2, 2) // customer_id=2, tenant_id=2
 
 			// 租户1创建订单
 			reqBody := map[string]interface{}{
@@ -281,9 +282,12 @@ func TestOrderAPI(t *testing.T) {
 	})
 }
 
-// generateTestJWT 生成测试用的JWT token
-func generateTestJWT(customerID, tenantID uint64) string {
-	// 这里应该使用实际的JWT生成逻辑
-	// 为了简化测试，返回一个Mock token
-	return fmt.Sprintf("test_jwt_token_customer_%d_tenant_%d", customerID, tenantID)
+// generateTestJWT 生成可通过认证中间件校验的测试JWT token，测试结束时自动吊销
+func generateTestJWT(t *testing.T, customerID, tenantID uint64) string {
+	user := &types.User{ID: customerID, TenantID: tenantID, Username: fmt.Sprintf("customer%d", customerID)}
+	return fixtures.New(t).MintToken(user,
+		types.PermissionOrderCreate,
+		types.PermissionOrderView,
+		types.PermissionOrderUpdate,
+	)
 }
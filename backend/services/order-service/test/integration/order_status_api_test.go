@@ -75,7 +75,7 @@ func TestOrderStatusAPI(t *testing.T) {
 		defer testServer.Close()
 
 		Convey("订单状态管理API测试", func() {
-			token := generateTestJWT(1, 1)
+			token := generateTestJWT(t, 1, 1)
 
 			// 首先创建一个测试订单
 			orderReqBody := map[string]interface{}{
@@ -179,7 +179,7 @@ func TestOrderStatusAPI(t *testing.T) {
 		})
 
 		Convey("批量订单状态更新API测试", func() {
-			token := generateTestJWT(1, 1)
+			token := generateTestJWT(t, 1, 1)
 
 			// 创建多个测试订单
 			var orderIDs []int
@@ -240,7 +240,7 @@ func TestOrderStatusAPI(t *testing.T) {
 		})
 
 		Convey("订单高级查询API测试", func() {
-			token := generateTestJWT(1, 1)
+			token := generateTestJWT(t, 1, 1)
 
 			Convey("订单列表查询", func() {
 				// 测试基础查询
@@ -314,7 +314,7 @@ func TestOrderStatusAPI(t *testing.T) {
 		})
 
 		Convey("订单超时管理API测试", func() {
-			token := generateTestJWT(1, 1)
+			token := generateTestJWT(t, 1, 1)
 
 			Convey("获取超时统计", func() {
 				req, _ := http.NewRequest("GET", testServer.URL+"/api/v1/orders/timeout/statistics", nil)
@@ -372,7 +372,7 @@ func TestOrderStatusAPI(t *testing.T) {
 		})
 
 		Convey("超时配置管理API测试", func() {
-			token := generateTestJWT(1, 1)
+			token := generateTestJWT(t, 1, 1)
 
 			Convey("创建超时配置", func() {
 				configReqBody := map[string]interface{}{
@@ -420,8 +420,8 @@ func TestOrderStatusAPI(t *testing.T) {
 		})
 
 		Convey("多租户隔离API测试", func() {
-			tenant1Token := generateTestJWT(1, 1)
-			tenant2Token := generateTestJWT(2, 2)
+			tenant1Token := generateTestJWT(t, 1, 1)
+			tenant2Token := generateTestJWT(t, 2, 2)
 
 			// 租户1创建订单
 			orderReqBody := map[string]interface{}{
@@ -488,7 +488,7 @@ func TestOrderStatusAPI(t *testing.T) {
 		})
 
 		Convey("错误处理API测试", func() {
-			token := generateTestJWT(1, 1)
+			token := generateTestJWT(t, 1, 1)
 
 			Convey("不存在的订单状态更新", func() {
 				updateReqBody := map[string]interface{}{
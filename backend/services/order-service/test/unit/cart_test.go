@@ -38,12 +38,12 @@ func TestCartService(t *testing.T) {
 			So(err, ShouldBeNil)
 
 			// 更新数量
-			err = cartService.UpdateItemQuantity(ctx, 1, 3)
+			err = cartService.UpdateItemQuantity(ctx, 1, 3, 0)
 			So(err, ShouldBeNil)
 		})
 
 		Convey("更新数量时必须大于0", func() {
-			err := cartService.UpdateItemQuantity(ctx, 1, -1)
+			err := cartService.UpdateItemQuantity(ctx, 1, -1, 0)
 			So(err, ShouldNotBeNil)
 			So(err.Error(), ShouldContainSubstring, "商品数量必须大于0")
 		})
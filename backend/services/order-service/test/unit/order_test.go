@@ -70,7 +70,8 @@ func TestOrderService(t *testing.T) {
 			So(err, ShouldBeNil)
 
 			// 取消订单
-			err = orderService.CancelOrder(ctx, order.ID)
+			customerCtx := context.WithValue(ctx, "user_id", uint64(1))
+			_, err = orderService.CancelOrder(customerCtx, order.ID, &types.CancelOrderRequest{Reason: "不想要了"})
 			So(err, ShouldBeNil)
 		})
 	})
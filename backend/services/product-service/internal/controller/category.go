@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -309,4 +310,65 @@ func (c *CategoryController) GetCategoryPath(r *ghttp.Request) {
 		"message": "获取成功",
 		"data":    path,
 	})
-}
\ No newline at end of file
+}
+// BatchOperation 批量启用、禁用或删除分类
+func (c *CategoryController) BatchOperation(r *ghttp.Request) {
+	var req types.CategoryBatchRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "参数解析失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := validateCategoryBatchRequest(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "参数验证失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	result, err := c.categoryService.BatchOperation(r.GetCtx(), &req)
+	if err != nil {
+		code := 500
+		if errors.Is(err, service.ErrCategoryReassignInvalid) {
+			code = 400
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "批量操作分类失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": fmt.Sprintf("批量操作完成，成功 %d 个，失败 %d 个", result.SuccessCount, result.FailureCount),
+		"data":    result,
+	})
+}
+
+// validateCategoryBatchRequest 验证分类批量操作请求
+func validateCategoryBatchRequest(req *types.CategoryBatchRequest) error {
+	if len(req.CategoryIDs) == 0 {
+		return fmt.Errorf("分类ID列表不能为空")
+	}
+	if len(req.CategoryIDs) > 100 {
+		return fmt.Errorf("批量操作的分类数量不能超过100个")
+	}
+	if !req.Action.IsValid() {
+		return fmt.Errorf("无效的操作类型")
+	}
+	if req.ChildPolicy != "" && !req.ChildPolicy.IsValid() {
+		return fmt.Errorf("无效的子分类处理策略")
+	}
+	return nil
+}
@@ -246,6 +246,38 @@ func (c *InventoryController) ReleaseInventory(r *ghttp.Request) {
 	})
 }
 
+// ReleaseBackorders 按当前库存释放商品的预订订单
+// POST /api/v1/products/{id}/inventory/backorders/release
+func (c *InventoryController) ReleaseBackorders(r *ghttp.Request) {
+	productIDStr := r.Get("id").String()
+	productID, err := strconv.ParseUint(productIDStr, 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "商品ID格式错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 先下单的预订订单先释放，全部预订商品已释放的已支付订单转为处理中
+	result, err := c.inventoryService.ReleaseBackorders(r.Context(), productID)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "释放预订订单失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "释放预订订单成功",
+		"data":    result,
+	})
+}
+
 // BatchAdjustInventory 批量调整库存
 // POST /api/v1/products/inventory/batch-adjust
 func (c *InventoryController) BatchAdjustInventory(r *ghttp.Request) {
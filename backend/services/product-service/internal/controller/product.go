@@ -78,7 +78,9 @@ func (c *ProductController) GetProduct(r *ghttp.Request) {
 		return
 	}
 	
-	product, err := c.productService.GetProduct(r.GetCtx(), id)
+	// 商户管理商品时可查看不在可售时间窗口内的商品
+	includeUnavailable := r.GetQuery("include_unavailable").Bool()
+	product, err := c.productService.GetProduct(r.GetCtx(), id, includeUnavailable)
 	if err != nil {
 		r.Response.WriteJsonExit(g.Map{
 			"code":    404,
@@ -243,6 +245,7 @@ func (c *ProductController) ListProducts(r *ghttp.Request) {
 	req.Keyword = r.GetQuery("keyword").String()
 	req.SortBy = r.GetQuery("sort_by").String()
 	req.SortOrder = r.GetQuery("sort_order").String()
+	req.IncludeUnavailable = r.GetQuery("include_unavailable").Bool()
 	
 	if categoryIDStr := r.GetQuery("category_id").String(); categoryIDStr != "" {
 		if categoryID, err := strconv.ParseUint(categoryIDStr, 10, 64); err == nil {
@@ -437,6 +440,9 @@ func validateCreateProductRequest(req *types.CreateProductRequest) error {
 	if req.Inventory.StockQuantity < 0 {
 		return fmt.Errorf("库存数量不能为负数")
 	}
+	if err := types.ValidateBackorderLimit(&req.Inventory); err != nil {
+		return err
+	}
 	if len(req.Tags) > 20 {
 		return fmt.Errorf("商品标签不能超过20个")
 	}
@@ -445,6 +451,12 @@ func validateCreateProductRequest(req *types.CreateProductRequest) error {
 			return fmt.Errorf("标签长度不能超过50个字符")
 		}
 	}
+	if err := types.ValidateAvailabilityWindow(req.AvailableFrom, req.AvailableUntil); err != nil {
+		return err
+	}
+	if err := types.ValidateOrderQuantityLimits(req.MinOrderQuantity, req.MaxOrderQuantity); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -470,6 +482,9 @@ func validateUpdateProductRequest(req *types.UpdateProductRequest) error {
 	if req.Inventory != nil && req.Inventory.StockQuantity < 0 {
 		return fmt.Errorf("库存数量不能为负数")
 	}
+	if err := types.ValidateBackorderLimit(req.Inventory); err != nil {
+		return err
+	}
 	if len(req.Tags) > 20 {
 		return fmt.Errorf("商品标签不能超过20个")
 	}
@@ -485,6 +500,7 @@ func validateUpdateProductRequest(req *types.UpdateProductRequest) error {
 func validateUpdateProductStatusRequest(req *types.UpdateProductStatusRequest) error {
 	validStatuses := []types.ProductStatus{
 		types.ProductStatusDraft,
+		types.ProductStatusScheduled,
 		types.ProductStatusActive,
 		types.ProductStatusInactive,
 		types.ProductStatusDeleted,
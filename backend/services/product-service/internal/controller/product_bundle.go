@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// ProductBundleController 商品套餐控制器
+type ProductBundleController struct {
+	bundleService *service.ProductBundleService
+}
+
+// NewProductBundleController 创建商品套餐控制器实例
+func NewProductBundleController() *ProductBundleController {
+	return &ProductBundleController{
+		bundleService: service.NewProductBundleService(),
+	}
+}
+
+// CreateBundle 创建套餐
+func (c *ProductBundleController) CreateBundle(r *ghttp.Request) {
+	var req types.CreateProductBundleRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "参数解析失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	bundle, err := c.bundleService.CreateBundle(r.GetCtx(), &req)
+	if err != nil {
+		code := 500
+		if errors.Is(err, types.ErrInvalidProductBundle) {
+			code = 400
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "创建套餐失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "创建成功",
+		"data":    bundle,
+	})
+}
+
+// GetBundle 获取套餐详情及可售情况
+func (c *ProductBundleController) GetBundle(r *ghttp.Request) {
+	bundleID, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "无效的套餐ID",
+			"data":    nil,
+		})
+		return
+	}
+
+	bundle, err := c.bundleService.GetBundle(r.GetCtx(), bundleID)
+	if err != nil {
+		code := 500
+		if errors.Is(err, types.ErrProductBundleNotFound) {
+			code = 404
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "获取套餐失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "获取成功",
+		"data":    bundle,
+	})
+}
+
+// ListBundles 获取商户的套餐列表
+func (c *ProductBundleController) ListBundles(r *ghttp.Request) {
+	merchantID := r.Get("merchant_id").Uint64()
+	if merchantID == 0 {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "商户ID不能为空",
+			"data":    nil,
+		})
+		return
+	}
+
+	bundles, err := c.bundleService.ListBundles(r.GetCtx(), merchantID, types.ProductBundleStatus(r.Get("status").String()))
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "获取套餐列表失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "获取成功",
+		"data":    bundles,
+	})
+}
+
+// UpdateBundleStatus 套餐上架或停售
+func (c *ProductBundleController) UpdateBundleStatus(r *ghttp.Request) {
+	bundleID, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "无效的套餐ID",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req types.UpdateProductBundleStatusRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "参数解析失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := c.bundleService.UpdateBundleStatus(r.GetCtx(), bundleID, req.Status); err != nil {
+		code := 500
+		switch {
+		case errors.Is(err, types.ErrProductBundleNotFound):
+			code = 404
+		case errors.Is(err, types.ErrInvalidProductBundle):
+			code = 400
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "更新套餐状态失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "更新成功",
+		"data":    nil,
+	})
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/service"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// ProductRecommendationController 商品搭配推荐控制器
+type ProductRecommendationController struct {
+	recommendationService *service.ProductRecommendationService
+}
+
+// NewProductRecommendationController 创建商品搭配推荐控制器实例，与后台计算任务共用服务以便刷新后清除缓存
+func NewProductRecommendationController(recommendationService *service.ProductRecommendationService) *ProductRecommendationController {
+	return &ProductRecommendationController{
+		recommendationService: recommendationService,
+	}
+}
+
+// GetRecommendations 获取经常与该商品一起购买的商品
+func (c *ProductRecommendationController) GetRecommendations(r *ghttp.Request) {
+	productID, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "商品ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	recommendations, err := c.recommendationService.GetRecommendations(r.GetCtx(), productID, r.Get("limit").Int())
+	if err != nil {
+		code := 500
+		if errors.Is(err, service.ErrRecommendationProductNotFound) {
+			code = 404
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "获取搭配推荐失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "获取成功",
+		"data":    recommendations,
+	})
+}
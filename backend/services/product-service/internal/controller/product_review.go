@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// ProductReviewController 商品评价控制器
+type ProductReviewController struct {
+	reviewService *service.ProductReviewService
+}
+
+// NewProductReviewController 创建商品评价控制器实例
+func NewProductReviewController() *ProductReviewController {
+	return &ProductReviewController{
+		reviewService: service.NewProductReviewService(),
+	}
+}
+
+// SubmitReview 提交商品评价
+func (c *ProductReviewController) SubmitReview(r *ghttp.Request) {
+	productID, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "无效的商品ID",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req types.CreateProductReviewRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "参数解析失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	review, err := c.reviewService.SubmitReview(r.GetCtx(), productID, &req)
+	if err != nil {
+		code := 400
+		switch {
+		case errors.Is(err, service.ErrReviewNotPurchaser):
+			code = 403
+		case errors.Is(err, service.ErrReviewAlreadyExists):
+			code = 409
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "提交评价失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "评价成功",
+		"data":    review,
+	})
+}
+
+// ListReviews 获取商品评价列表，拥有商品编辑权限的用户可查看被标记的评价
+func (c *ProductReviewController) ListReviews(r *ghttp.Request) {
+	productID, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "无效的商品ID",
+			"data":    nil,
+		})
+		return
+	}
+
+	ctx := r.GetCtx()
+	includeFlagged := r.Get("include_flagged").Bool() &&
+		(middleware.HasPermissionInContext(ctx, types.PermissionProductUpdate) ||
+			middleware.HasPermissionInContext(ctx, types.PermissionMerchantProductEdit))
+
+	result, err := c.reviewService.ListReviews(ctx, productID, includeFlagged, r.Get("page", 1).Int(), r.Get("page_size", 20).Int())
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "获取商品评价失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "获取成功",
+		"data":    result,
+	})
+}
+
+// FlagReview 标记或取消标记不当评价
+func (c *ProductReviewController) FlagReview(r *ghttp.Request) {
+	productID, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "无效的商品ID",
+			"data":    nil,
+		})
+		return
+	}
+	reviewID, err := strconv.ParseUint(r.Get("review_id").String(), 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "无效的评价ID",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req types.FlagProductReviewRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "参数解析失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	review, err := c.reviewService.FlagReview(r.GetCtx(), productID, reviewID, &req)
+	if err != nil {
+		code := 400
+		if errors.Is(err, service.ErrReviewForbidden) {
+			code = 403
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "标记评价失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "操作成功",
+		"data":    review,
+	})
+}
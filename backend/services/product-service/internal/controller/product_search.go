@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// ProductSearchController 商品搜索控制器
+type ProductSearchController struct {
+	searchService *service.ProductSearchService
+}
+
+// NewProductSearchController 创建商品搜索控制器实例
+func NewProductSearchController() *ProductSearchController {
+	return &ProductSearchController{
+		searchService: service.NewProductSearchService(),
+	}
+}
+
+// Search 按关键词搜索商品，结果按相关度排序并返回得分
+func (c *ProductSearchController) Search(r *ghttp.Request) {
+	req := types.ProductSearchRequest{
+		Keyword:  strings.TrimSpace(r.Get("keyword").String()),
+		Page:     r.Get("page", 1).Int(),
+		PageSize: r.Get("page_size", 20).Int(),
+	}
+	if req.Keyword == "" {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "搜索关键词不能为空",
+			"data":    nil,
+		})
+		return
+	}
+	if categoryID := r.Get("category_id").Uint64(); categoryID > 0 {
+		req.CategoryID = &categoryID
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	result, err := c.searchService.Search(r.GetCtx(), &req)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "搜索商品失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "搜索成功",
+		"data":    result,
+	})
+}
+
+// GetSynonyms 获取当前租户的搜索同义词词典
+func (c *ProductSearchController) GetSynonyms(r *ghttp.Request) {
+	dictionary, err := c.searchService.GetSynonyms(r.GetCtx())
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "获取同义词词典失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "获取成功",
+		"data":    dictionary,
+	})
+}
+
+// SaveSynonyms 整体替换当前租户的搜索同义词词典
+func (c *ProductSearchController) SaveSynonyms(r *ghttp.Request) {
+	var dictionary types.ProductSynonymDictionary
+	if err := r.Parse(&dictionary); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "参数解析失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := c.searchService.SaveSynonyms(r.GetCtx(), &dictionary); err != nil {
+		code := 500
+		if errors.Is(err, types.ErrInvalidSynonymGroup) {
+			code = 400
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    code,
+			"message": "保存同义词词典失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    200,
+		"message": "保存成功",
+		"data":    dictionary,
+	})
+}
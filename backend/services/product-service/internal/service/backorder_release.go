@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// backorderReleaseReason 补货释放后订单转为处理中的状态变更原因
+const backorderReleaseReason = "预订商品已补货"
+
+// BackorderReleaseService 预订订单补货释放服务：商品补货后按下单先后释放待补货的预订订单，
+// 全部预订商品已释放的已支付订单转为处理中，并通过状态变更通知客户
+type BackorderReleaseService struct {
+	backorderRepo repository.IBackorderRepository
+	autoRelease   bool
+}
+
+// NewBackorderReleaseService 创建预订订单补货释放服务实例，product.backorder.autoRelease 关闭时补货后需由商户手动释放
+func NewBackorderReleaseService() *BackorderReleaseService {
+	autoRelease := g.Cfg().MustGet(context.Background(), "product.backorder.autoRelease", true).Bool()
+	return NewBackorderReleaseServiceForTest(repository.NewBackorderRepository(), autoRelease)
+}
+
+// NewBackorderReleaseServiceForTest 创建测试用预订订单补货释放服务实例
+func NewBackorderReleaseServiceForTest(backorderRepo repository.IBackorderRepository, autoRelease bool) *BackorderReleaseService {
+	return &BackorderReleaseService{
+		backorderRepo: backorderRepo,
+		autoRelease:   autoRelease,
+	}
+}
+
+// HandleRestock 商品库存增加后的处理，开启自动释放时释放预订订单；未开启或库存未增加时返回 nil
+func (s *BackorderReleaseService) HandleRestock(ctx context.Context, productID uint64, increase int) (*types.BackorderReleaseResult, error) {
+	if !s.autoRelease || increase <= 0 {
+		return nil, nil
+	}
+	return s.ReleaseBackorders(ctx, productID)
+}
+
+// ReleaseBackorders 按当前库存释放商品的预订订单，先下单的订单先释放
+func (s *BackorderReleaseService) ReleaseBackorders(ctx context.Context, productID uint64) (*types.BackorderReleaseResult, error) {
+	if productID == 0 {
+		return nil, errors.New("商品ID不能为空")
+	}
+
+	result, err := s.backorderRepo.ReleaseBackorders(ctx, productID, backorderReleaseReason)
+	if err != nil {
+		return nil, err
+	}
+	if result.Released > 0 {
+		g.Log().Infof(ctx, "预订订单补货释放: productID=%d, released=%d, outstanding=%d, processingOrders=%v",
+			productID, result.Released, result.Outstanding, result.ProcessingOrderIDs)
+	}
+	return result, nil
+}
@@ -9,13 +9,26 @@ import (
 
 // CategoryService 分类服务
 type CategoryService struct {
-	categoryRepo *repository.CategoryRepository
+	categoryRepo       *repository.CategoryRepository
+	batchRepo          repository.ICategoryBatchRepository
+	defaultChildPolicy types.CategoryChildPolicy
 }
 
 // NewCategoryService 创建分类服务实例
 func NewCategoryService() *CategoryService {
+	categoryRepo := repository.NewCategoryRepository()
 	return &CategoryService{
-		categoryRepo: repository.NewCategoryRepository(),
+		categoryRepo:       categoryRepo,
+		batchRepo:          categoryRepo,
+		defaultChildPolicy: LoadCategoryChildPolicy(context.Background()),
+	}
+}
+
+// NewCategoryServiceForTest 创建测试用分类服务实例，只支持批量操作
+func NewCategoryServiceForTest(batchRepo repository.ICategoryBatchRepository, defaultChildPolicy types.CategoryChildPolicy) *CategoryService {
+	return &CategoryService{
+		batchRepo:          batchRepo,
+		defaultChildPolicy: defaultChildPolicy,
 	}
 }
 
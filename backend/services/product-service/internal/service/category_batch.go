@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+var (
+	// ErrCategoryNotFound 分类不存在或不属于当前租户
+	ErrCategoryNotFound = errors.New("分类不存在")
+	// ErrCategoryHasChildren 子分类处理策略为 block 时，存在子分类的分类不能删除
+	ErrCategoryHasChildren = errors.New("分类存在子分类，不能删除")
+	// ErrCategoryHasProducts 分类下存在商品且未指定转移分类，删除会导致商品失去分类
+	ErrCategoryHasProducts = errors.New("分类下存在商品，请指定商品转移的目标分类")
+	// ErrCategoryReassignInvalid 商品转移的目标分类无效
+	ErrCategoryReassignInvalid = errors.New("商品转移的目标分类无效")
+)
+
+// LoadCategoryChildPolicy 从 product.category.childPolicy 配置加载删除分类时子分类的默认处理策略，默认拒绝删除
+func LoadCategoryChildPolicy(ctx context.Context) types.CategoryChildPolicy {
+	policy := types.CategoryChildPolicy(g.Cfg().MustGet(ctx, "product.category.childPolicy", string(types.CategoryChildPolicyBlock)).String())
+	if !policy.IsValid() {
+		g.Log().Warning(ctx, "分类子分类处理策略配置无效，使用默认策略", "policy", policy)
+		return types.CategoryChildPolicyBlock
+	}
+	return policy
+}
+
+// BatchOperation 批量启用、禁用或删除分类，逐个分类处理并返回每个分类的结果
+func (s *CategoryService) BatchOperation(ctx context.Context, req *types.CategoryBatchRequest) (*types.CategoryBatchResult, error) {
+	if !req.Action.IsValid() {
+		return nil, fmt.Errorf("无效的批量操作类型: %s", req.Action)
+	}
+	if len(req.CategoryIDs) == 0 {
+		return nil, fmt.Errorf("分类ID列表不能为空")
+	}
+
+	policy := req.ChildPolicy
+	if policy == "" {
+		policy = s.defaultChildPolicy
+	}
+	if !policy.IsValid() {
+		return nil, fmt.Errorf("无效的子分类处理策略: %s", policy)
+	}
+
+	categories, err := s.batchRepo.ListAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tree := newCategoryTree(categories)
+
+	var reassignTo *types.ProductCategory
+	if req.Action == types.CategoryBatchDelete && req.ReassignCategoryID != nil {
+		reassignTo = tree.categories[*req.ReassignCategoryID]
+		if reassignTo == nil {
+			return nil, ErrCategoryReassignInvalid
+		}
+	}
+
+	result := &types.CategoryBatchResult{Action: req.Action}
+	seen := make(map[uint64]bool, len(req.CategoryIDs))
+	for _, id := range req.CategoryIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		item := types.CategoryBatchItemResult{CategoryID: id}
+		switch req.Action {
+		case types.CategoryBatchEnable:
+			err = s.updateCategoryStatus(ctx, tree, id, types.CategoryStatusActive)
+		case types.CategoryBatchDisable:
+			err = s.updateCategoryStatus(ctx, tree, id, types.CategoryStatusInactive)
+		case types.CategoryBatchDelete:
+			err = s.deleteCategory(ctx, tree, id, policy, reassignTo, &item)
+		}
+
+		if err != nil {
+			item.Error = err.Error()
+			result.FailureCount++
+		} else {
+			item.Success = true
+			result.SuccessCount++
+		}
+		result.Results = append(result.Results, item)
+	}
+
+	audit.LogOperation(ctx, "product_category", "batch_"+string(req.Action), map[string]interface{}{
+		"category_ids":         req.CategoryIDs,
+		"child_policy":         policy,
+		"reassign_category_id": req.ReassignCategoryID,
+		"success_count":        result.SuccessCount,
+		"failure_count":        result.FailureCount,
+		"results":              result.Results,
+	})
+
+	return result, nil
+}
+
+// updateCategoryStatus 更新单个分类的启用状态
+func (s *CategoryService) updateCategoryStatus(ctx context.Context, tree *categoryTree, id uint64, status types.CategoryStatus) error {
+	category := tree.categories[id]
+	if category == nil {
+		return ErrCategoryNotFound
+	}
+	if category.Status == status {
+		return nil
+	}
+
+	if err := s.batchRepo.UpdateStatus(ctx, id, status); err != nil {
+		return err
+	}
+	category.Status = status
+	return nil
+}
+
+// deleteCategory 按子分类处理策略删除单个分类，存在商品时必须指定转移分类
+func (s *CategoryService) deleteCategory(ctx context.Context, tree *categoryTree, id uint64, policy types.CategoryChildPolicy, reassignTo *types.ProductCategory, item *types.CategoryBatchItemResult) error {
+	category := tree.categories[id]
+	if category == nil {
+		// 已随本批次中的父分类一起删除
+		if tree.deleted[id] {
+			return nil
+		}
+		return ErrCategoryNotFound
+	}
+
+	deletion := &types.CategoryDeletion{DeleteIDs: []uint64{id}, ReassignTo: reassignTo}
+	children := tree.children(id)
+	switch {
+	case len(children) == 0:
+	case policy == types.CategoryChildPolicyBlock:
+		return ErrCategoryHasChildren
+	case policy == types.CategoryChildPolicyCascade:
+		item.CascadedIDs = tree.descendants(id)
+		deletion.DeleteIDs = append(deletion.DeleteIDs, item.CascadedIDs...)
+	case policy == types.CategoryChildPolicyReparent:
+		for _, child := range children {
+			item.ReparentedIDs = append(item.ReparentedIDs, child.ID)
+			deletion.Moves = append(deletion.Moves, tree.moveSubtree(child, category.ParentID)...)
+		}
+	}
+
+	for _, deleteID := range deletion.DeleteIDs {
+		if reassignTo != nil && reassignTo.ID == deleteID {
+			return fmt.Errorf("%w: 目标分类将被删除", ErrCategoryReassignInvalid)
+		}
+	}
+
+	counts, err := s.batchRepo.CountProductsByCategory(ctx, deletion.DeleteIDs)
+	if err != nil {
+		return err
+	}
+	products := 0
+	for _, count := range counts {
+		products += count
+	}
+	if products > 0 && reassignTo == nil {
+		return fmt.Errorf("%w（共 %d 个商品）", ErrCategoryHasProducts, products)
+	}
+	if products == 0 {
+		deletion.ReassignTo = nil
+	}
+
+	reassigned, err := s.batchRepo.ApplyDeletion(ctx, deletion)
+	if err != nil {
+		return err
+	}
+	item.ReassignedProducts = reassigned
+
+	tree.apply(deletion)
+	return nil
+}
+
+// categoryTree 当前租户分类树的内存视图，批量操作过程中同步更新
+type categoryTree struct {
+	categories map[uint64]*types.ProductCategory
+	deleted    map[uint64]bool
+}
+
+func newCategoryTree(categories []types.ProductCategory) *categoryTree {
+	tree := &categoryTree{
+		categories: make(map[uint64]*types.ProductCategory, len(categories)),
+		deleted:    make(map[uint64]bool),
+	}
+	for i := range categories {
+		category := categories[i]
+		tree.categories[category.ID] = &category
+	}
+	return tree
+}
+
+// children 直接子分类，按ID排序保证结果稳定
+func (t *categoryTree) children(parentID uint64) []*types.ProductCategory {
+	var children []*types.ProductCategory
+	for _, category := range t.categories {
+		if category.ParentID != nil && *category.ParentID == parentID {
+			children = append(children, category)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].ID < children[j].ID })
+	return children
+}
+
+// descendants 全部子孙分类
+func (t *categoryTree) descendants(parentID uint64) []uint64 {
+	var ids []uint64
+	for _, child := range t.children(parentID) {
+		ids = append(ids, child.ID)
+		ids = append(ids, t.descendants(child.ID)...)
+	}
+	return ids
+}
+
+// moveSubtree 计算分类移动到新父分类下后，它及其子孙分类的层级和路径
+func (t *categoryTree) moveSubtree(category *types.ProductCategory, parentID *uint64) []types.CategoryMove {
+	move := types.CategoryMove{CategoryID: category.ID, ParentID: parentID, Level: 1, Path: category.Name}
+	if parentID != nil {
+		if parent := t.categories[*parentID]; parent != nil {
+			move.Level = parent.Level + 1
+			move.Path = parent.Path + "/" + category.Name
+		}
+	}
+
+	moves := []types.CategoryMove{move}
+	for _, child := range t.children(category.ID) {
+		moves = append(moves, t.moveChild(child, move)...)
+	}
+	return moves
+}
+
+// moveChild 父分类位置变化后子分类的新层级和路径（父分类不变）
+func (t *categoryTree) moveChild(category *types.ProductCategory, parent types.CategoryMove) []types.CategoryMove {
+	parentID := parent.CategoryID
+	move := types.CategoryMove{
+		CategoryID: category.ID,
+		ParentID:   &parentID,
+		Level:      parent.Level + 1,
+		Path:       parent.Path + "/" + category.Name,
+	}
+
+	moves := []types.CategoryMove{move}
+	for _, child := range t.children(category.ID) {
+		moves = append(moves, t.moveChild(child, move)...)
+	}
+	return moves
+}
+
+// apply 将已提交的删除同步到内存视图
+func (t *categoryTree) apply(deletion *types.CategoryDeletion) {
+	for _, move := range deletion.Moves {
+		if category := t.categories[move.CategoryID]; category != nil {
+			category.ParentID = move.ParentID
+			category.Level = move.Level
+			category.Path = move.Path
+		}
+	}
+	for _, id := range deletion.DeleteIDs {
+		delete(t.categories, id)
+		t.deleted[id] = true
+	}
+}
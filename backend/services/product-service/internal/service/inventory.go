@@ -24,6 +24,9 @@ type IInventoryService interface {
 	ReleaseInventory(ctx context.Context, req *types.InventoryReleaseRequest) error
 	ConfirmReservation(ctx context.Context, reservationID uint64) error
 	
+	// 预订订单补货释放
+	ReleaseBackorders(ctx context.Context, productID uint64) (*types.BackorderReleaseResult, error)
+	
 	// 库存监控
 	CheckLowStockAlerts(ctx context.Context) error
 	ProcessExpiredReservations(ctx context.Context) error
@@ -31,19 +34,23 @@ type IInventoryService interface {
 
 // inventoryService 库存服务实现
 type inventoryService struct {
-	productRepo     *repository.ProductRepository
-	recordRepo      repository.IInventoryRecordRepository
-	reservationRepo repository.IInventoryReservationRepository
-	alertRepo       repository.IInventoryAlertRepository
+	productRepo       *repository.ProductRepository
+	recordRepo        repository.IInventoryRecordRepository
+	reservationRepo   repository.IInventoryReservationRepository
+	alertRepo         repository.IInventoryAlertRepository
+	timeoutConfigRepo *repository.OrderTimeoutConfigRepository
+	backorderRelease  *BackorderReleaseService
 }
 
 // NewInventoryService 创建库存服务实例
 func NewInventoryService() IInventoryService {
 	return &inventoryService{
-		productRepo:     repository.NewProductRepository(),
-		recordRepo:      repository.NewInventoryRecordRepository(),
-		reservationRepo: repository.NewInventoryReservationRepository(),
-		alertRepo:       repository.NewInventoryAlertRepository(),
+		productRepo:       repository.NewProductRepository(),
+		recordRepo:        repository.NewInventoryRecordRepository(),
+		reservationRepo:   repository.NewInventoryReservationRepository(),
+		alertRepo:         repository.NewInventoryAlertRepository(),
+		timeoutConfigRepo: repository.NewOrderTimeoutConfigRepository(),
+		backorderRelease:  NewBackorderReleaseService(),
 	}
 }
 
@@ -69,9 +76,12 @@ func (s *inventoryService) GetInventoryInfo(ctx context.Context, productID uint6
 
 	// 转换为扩展库存信息并更新预留数量
 	extendedInfo := types.ExtendedInventoryInfo{
-		StockQuantity:    product.InventoryInfo.StockQuantity,
-		ReservedQuantity: totalReserved,
-		TrackInventory:   product.InventoryInfo.TrackInventory,
+		StockQuantity:      product.InventoryInfo.StockQuantity,
+		ReservedQuantity:   totalReserved,
+		TrackInventory:     product.InventoryInfo.TrackInventory,
+		AllowBackorder:     product.InventoryInfo.AllowBackorder,
+		BackorderLimit:     product.InventoryInfo.BackorderLimit,
+		EstimatedRestockAt: product.InventoryInfo.EstimatedRestockAt,
 	}
 
 	response := &types.InventoryResponse{
@@ -138,6 +148,11 @@ func (s *inventoryService) AdjustInventory(ctx context.Context, req *types.Inven
 		g.Log().Errorf(ctx, "记录库存变更历史失败: %v", err)
 	}
 
+	// 补货后释放预订订单，释放失败不影响已完成的库存调整，可由商户手动重新释放
+	if _, err := s.backorderRelease.HandleRestock(ctx, req.ProductID, adjustment); err != nil {
+		g.Log().Errorf(ctx, "补货释放预订订单失败: productID=%d, error=%v", req.ProductID, err)
+	}
+
 	// 检查预警
 	go func() {
 		if err := s.checkProductAlerts(ctx, req.ProductID); err != nil {
@@ -198,8 +213,28 @@ func (s *inventoryService) ReserveInventory(ctx context.Context, req *types.Inve
 		return nil, fmt.Errorf("获取库存信息失败: %w", err)
 	}
 
-	if inventoryInfo.AvailableStock < req.Quantity {
-		return nil, fmt.Errorf("可用库存不足: 需要%d，可用%d", req.Quantity, inventoryInfo.AvailableStock)
+	// 接受预订的商品在预订上限内允许超出可用库存
+	extendedInfo := inventoryInfo.InventoryInfo
+	inventory := types.InventoryInfo{
+		StockQuantity:    extendedInfo.StockQuantity,
+		ReservedQuantity: extendedInfo.ReservedQuantity,
+		TrackInventory:   extendedInfo.TrackInventory,
+		AllowBackorder:   extendedInfo.AllowBackorder,
+		BackorderLimit:   extendedInfo.BackorderLimit,
+	}
+	if _, err := inventory.CheckReserve(req.Quantity); err != nil {
+		return nil, fmt.Errorf("%w: 需要%d，可用%d", err, req.Quantity, inventoryInfo.AvailableStock)
+	}
+
+	// 订单预留未指定到期时间时，按商户超时配置的预留保留时长设置
+	expiresAt := req.ExpiresAt
+	if expiresAt == nil && req.ReferenceType == types.ReservationReferenceOrder {
+		config, err := s.timeoutConfigRepo.GetEffectiveConfig(ctx, getMerchantIDFromContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("获取订单超时配置失败: %w", err)
+		}
+		orderExpiresAt := config.ReservationExpiresAt(time.Now())
+		expiresAt = &orderExpiresAt
 	}
 
 	// 创建预留记录
@@ -210,17 +245,19 @@ func (s *inventoryService) ReserveInventory(ctx context.Context, req *types.Inve
 		ReferenceType:    req.ReferenceType,
 		ReferenceID:      req.ReferenceID,
 		Status:           types.ReservationStatusActive,
-		ExpiresAt:        req.ExpiresAt,
+		ExpiresAt:        expiresAt,
 		CreatedAt:        time.Now(),
 		UpdatedAt:        time.Now(),
 	}
 
 	// 执行预留操作（原子操作）
-	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
-		// 预留库存
-		if err := s.productRepo.ReserveInventory(ctx, req.ProductID, req.Quantity); err != nil {
+	err = repository.TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		// 预留库存，并发预留时以行锁内的库存为准计算预订数量
+		backordered, err := s.productRepo.ReserveInventory(ctx, req.ProductID, req.Quantity)
+		if err != nil {
 			return err
 		}
+		reservation.BackorderedQuantity = backordered
 
 		// 创建预留记录
 		if err := s.reservationRepo.Create(ctx, reservation); err != nil {
@@ -271,7 +308,7 @@ func (s *inventoryService) ReleaseInventory(ctx context.Context, req *types.Inve
 	}
 
 	// 执行释放操作（原子操作）
-	return g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+	return repository.TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
 		// 释放库存
 		if err := s.productRepo.ReleaseInventory(ctx, reservation.ProductID, reservation.ReservedQuantity); err != nil {
 			return err
@@ -316,7 +353,7 @@ func (s *inventoryService) ConfirmReservation(ctx context.Context, reservationID
 	}
 
 	// 执行确认操作（原子操作）
-	return g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+	return repository.TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
 		// 从库存中扣减数量
 		_, err := s.productRepo.AdjustInventory(ctx, reservation.ProductID, -reservation.ReservedQuantity, "确认预留消费")
 		if err != nil {
@@ -351,6 +388,11 @@ func (s *inventoryService) ConfirmReservation(ctx context.Context, reservationID
 	})
 }
 
+// ReleaseBackorders 按当前库存手动释放商品的预订订单
+func (s *inventoryService) ReleaseBackorders(ctx context.Context, productID uint64) (*types.BackorderReleaseResult, error) {
+	return s.backorderRelease.ReleaseBackorders(ctx, productID)
+}
+
 // CheckLowStockAlerts 检查低库存预警
 func (s *inventoryService) CheckLowStockAlerts(ctx context.Context) error {
 	// 获取低库存商品
@@ -379,8 +421,11 @@ func (s *inventoryService) ProcessExpiredReservations(ctx context.Context) error
 		return fmt.Errorf("获取过期预留失败: %w", err)
 	}
 
-	// 处理每个过期预留
+	// 处理每个过期预留（订单预留到期需同时关闭订单，由订单超时监控处理）
 	for _, reservation := range expiredReservations {
+		if reservation.ReferenceType == types.ReservationReferenceOrder {
+			continue
+		}
 		if err := s.processExpiredReservation(ctx, &reservation); err != nil {
 			g.Log().Errorf(ctx, "处理过期预留%d失败: %v", reservation.ID, err)
 		}
@@ -456,7 +501,7 @@ func (s *inventoryService) processExpiredReservation(ctx context.Context, reserv
 	tenantID := getTenantIDFromContext(ctx)
 	
 	// 执行释放操作
-	return g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+	return repository.TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
 		// 释放库存
 		if err := s.productRepo.ReleaseInventory(ctx, reservation.ProductID, reservation.ReservedQuantity); err != nil {
 			return err
@@ -502,4 +547,12 @@ func getUserIDFromContext(ctx context.Context) uint64 {
 		}
 	}
 	return 0
-}
\ No newline at end of file
+}
+func getMerchantIDFromContext(ctx context.Context) uint64 {
+	if merchantID := ctx.Value("merchant_id"); merchantID != nil {
+		if id, ok := merchantID.(uint64); ok {
+			return id
+		}
+	}
+	return 0
+}
@@ -2,21 +2,28 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"mime/multipart"
+	"time"
 
 	"github.com/gofromzero/mer-sys/backend/shared/oss"
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
 )
 
+// ErrProductUnavailable 商品不在可售时间窗口内
+var ErrProductUnavailable = errors.New("商品不在可售时间内")
+
 // ProductService 商品服务
 type ProductService struct {
 	productRepo  *repository.ProductRepository
 	categoryRepo *repository.CategoryRepository
 	historyRepo  *repository.ProductHistoryRepository
 	ossService   *oss.OSSService
+	reviewRepo   repository.IProductReviewRepository
 }
 
 // NewProductService 创建商品服务实例
@@ -26,6 +33,7 @@ func NewProductService() *ProductService {
 		categoryRepo: repository.NewCategoryRepository(),
 		historyRepo:  repository.NewProductHistoryRepository(),
 		ossService:   oss.NewOSSService(),
+		reviewRepo:   repository.NewProductReviewRepository(),
 	}
 }
 
@@ -51,6 +59,10 @@ func (s *ProductService) CreateProduct(ctx context.Context, req *types.CreatePro
 		InventoryInfo: &req.Inventory,
 		Status:      types.ProductStatusDraft, // 默认为草稿状态
 		Images:      types.ProductImages{}, // 初始化空图片数组
+		AvailableFrom:  req.AvailableFrom,
+		AvailableUntil: req.AvailableUntil,
+		MinOrderQuantity: req.MinOrderQuantity,
+		MaxOrderQuantity: req.MaxOrderQuantity,
 	}
 	
 	// 设置分类路径（这里暂时不使用，但保留逻辑供将来扩展）
@@ -77,9 +89,25 @@ func (s *ProductService) CreateProduct(ctx context.Context, req *types.CreatePro
 	return product, nil
 }
 
-// GetProduct 获取商品详情
-func (s *ProductService) GetProduct(ctx context.Context, id uint64) (*types.ProductResponse, error) {
-	return s.productRepo.GetByIDWithCategory(ctx, id)
+// GetProduct 获取商品详情，includeUnavailable 为 false 时不返回可售时间窗口外的商品
+func (s *ProductService) GetProduct(ctx context.Context, id uint64, includeUnavailable bool) (*types.ProductResponse, error) {
+	product, err := s.productRepo.GetByIDWithCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	
+	if !includeUnavailable && !product.InAvailabilityWindow(time.Now()) {
+		return nil, ErrProductUnavailable
+	}
+	
+	// 评分汇总获取失败不影响商品详情展示
+	if rating, err := s.reviewRepo.GetRatingSummary(ctx, id); err == nil {
+		product.Rating = rating
+	} else {
+		g.Log().Warningf(ctx, "获取商品 %d 评分汇总失败: %v", id, err)
+	}
+	
+	return product, nil
 }
 
 // UpdateProduct 更新商品信息
@@ -164,6 +192,58 @@ func (s *ProductService) UpdateProduct(ctx context.Context, id uint64, req *type
 		}
 	}
 	
+	if req.ClearAvailability {
+		if oldProduct.AvailableFrom != nil || oldProduct.AvailableUntil != nil {
+			updates["available_from"] = nil
+			updates["available_until"] = nil
+			changes["availability"] = map[string]interface{}{
+				"old": availabilityWindow(oldProduct.AvailableFrom, oldProduct.AvailableUntil),
+				"new": nil,
+			}
+		}
+	} else if req.AvailableFrom != nil || req.AvailableUntil != nil {
+		// 只传一端时保留另一端的原值
+		from, until := oldProduct.AvailableFrom, oldProduct.AvailableUntil
+		if req.AvailableFrom != nil {
+			from = req.AvailableFrom
+		}
+		if req.AvailableUntil != nil {
+			until = req.AvailableUntil
+		}
+		if err := types.ValidateAvailabilityWindow(from, until); err != nil {
+			return nil, err
+		}
+		
+		updates["available_from"] = from
+		updates["available_until"] = until
+		changes["availability"] = map[string]interface{}{
+			"old": availabilityWindow(oldProduct.AvailableFrom, oldProduct.AvailableUntil),
+			"new": availabilityWindow(from, until),
+		}
+	}
+	
+	if req.MinOrderQuantity != nil || req.MaxOrderQuantity != nil {
+		minQuantity, maxQuantity := oldProduct.MinOrderQuantity, oldProduct.MaxOrderQuantity
+		if req.MinOrderQuantity != nil {
+			minQuantity = *req.MinOrderQuantity
+		}
+		if req.MaxOrderQuantity != nil {
+			maxQuantity = *req.MaxOrderQuantity
+		}
+		if err := types.ValidateOrderQuantityLimits(minQuantity, maxQuantity); err != nil {
+			return nil, err
+		}
+		
+		if minQuantity != oldProduct.MinOrderQuantity || maxQuantity != oldProduct.MaxOrderQuantity {
+			updates["min_order_quantity"] = minQuantity
+			updates["max_order_quantity"] = maxQuantity
+			changes["order_quantity_limits"] = map[string]interface{}{
+				"old": map[string]interface{}{"min": oldProduct.MinOrderQuantity, "max": oldProduct.MaxOrderQuantity},
+				"new": map[string]interface{}{"min": minQuantity, "max": maxQuantity},
+			}
+		}
+	}
+	
 	if len(updates) == 0 {
 		// 没有任何更新
 		return oldProduct, nil
@@ -199,6 +279,11 @@ func (s *ProductService) UpdateProductStatus(ctx context.Context, id uint64, req
 		return nil // 状态未变更
 	}
 	
+	// 定时上架需要先设置可售开始时间，由定时任务到点上架
+	if req.Status == types.ProductStatusScheduled && oldProduct.AvailableFrom == nil {
+		return fmt.Errorf("available_from is required for scheduled products")
+	}
+	
 	// 状态流转验证
 	err = s.validateStatusTransition(oldProduct.Status, req.Status)
 	if err != nil {
@@ -387,7 +472,13 @@ func (s *ProductService) validateStatusTransition(from, to types.ProductStatus)
 	// 定义允许的状态流转
 	allowedTransitions := map[types.ProductStatus][]types.ProductStatus{
 		types.ProductStatusDraft: {
+			types.ProductStatusScheduled,
+			types.ProductStatusActive,
+			types.ProductStatusDeleted,
+		},
+		types.ProductStatusScheduled: {
 			types.ProductStatusActive,
+			types.ProductStatusInactive,
 			types.ProductStatusDeleted,
 		},
 		types.ProductStatusActive: {
@@ -395,6 +486,7 @@ func (s *ProductService) validateStatusTransition(from, to types.ProductStatus)
 			types.ProductStatusDeleted,
 		},
 		types.ProductStatusInactive: {
+			types.ProductStatusScheduled,
 			types.ProductStatusActive,
 			types.ProductStatusDeleted,
 		},
@@ -413,4 +505,12 @@ func (s *ProductService) validateStatusTransition(from, to types.ProductStatus)
 	}
 	
 	return fmt.Errorf("invalid status transition from %s to %s", from, to)
+}
+
+// availabilityWindow 构建可售时间窗口变更记录
+func availabilityWindow(from, until *time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"available_from":  from,
+		"available_until": until,
+	}
 }
\ No newline at end of file
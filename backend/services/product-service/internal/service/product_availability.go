@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	productAvailabilityInterval  = time.Minute
+	productAvailabilityBatchSize = 200
+)
+
+// ProductAvailabilityResult 一次定时上下架的执行结果
+type ProductAvailabilityResult struct {
+	Activated   int `json:"activated"`
+	Deactivated int `json:"deactivated"`
+}
+
+// ProductAvailabilityScheduler 商品定时上下架任务：待上架商品到达可售开始时间后上架，
+// 在售商品到达可售结束时间后下架
+type ProductAvailabilityScheduler struct {
+	productRepo repository.IProductAvailabilityRepository
+	now         func() time.Time
+	stopCh      chan struct{}
+	isRunning   bool
+}
+
+// NewProductAvailabilityScheduler 创建商品定时上下架任务
+func NewProductAvailabilityScheduler() *ProductAvailabilityScheduler {
+	return &ProductAvailabilityScheduler{
+		productRepo: repository.NewProductAvailabilityRepository(),
+		now:         time.Now,
+		stopCh:      make(chan struct{}),
+	}
+}
+
+// NewProductAvailabilitySchedulerForTest 创建测试用商品定时上下架任务，可指定当前时间
+func NewProductAvailabilitySchedulerForTest(productRepo repository.IProductAvailabilityRepository, now func() time.Time) *ProductAvailabilityScheduler {
+	return &ProductAvailabilityScheduler{
+		productRepo: productRepo,
+		now:         now,
+		stopCh:      make(chan struct{}),
+	}
+}
+
+// Start 启动后台定时上下架循环
+func (s *ProductAvailabilityScheduler) Start(ctx context.Context) {
+	if s.isRunning {
+		return
+	}
+	s.isRunning = true
+	g.Log().Info(ctx, "启动商品定时上下架任务")
+
+	go func() {
+		ticker := time.NewTicker(productAvailabilityInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.RunOnce(ctx); err != nil {
+				g.Log().Error(ctx, "商品定时上下架失败", "error", err)
+			}
+
+			select {
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop 停止后台定时上下架循环
+func (s *ProductAvailabilityScheduler) Stop(ctx context.Context) {
+	if !s.isRunning {
+		return
+	}
+	s.isRunning = false
+	close(s.stopCh)
+	g.Log().Info(ctx, "商品定时上下架任务已停止")
+}
+
+// RunOnce 处理一批到达上架或下架时间的商品
+func (s *ProductAvailabilityScheduler) RunOnce(ctx context.Context) (*ProductAvailabilityResult, error) {
+	now := s.now()
+	products, err := s.productRepo.ListDueForTransition(ctx, now, productAvailabilityBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ProductAvailabilityResult{}
+	for i := range products {
+		product := &products[i]
+		to := product.ScheduledStatusAt(now)
+		if to == product.Status {
+			continue
+		}
+
+		// 后台任务没有请求上下文，按商品所属租户设置租户上下文
+		tenantCtx := context.WithValue(ctx, "tenant_id", product.TenantID)
+		updated, err := s.productRepo.TransitionStatus(tenantCtx, product, product.Status, to)
+		if err != nil {
+			g.Log().Warning(ctx, "商品定时上下架失败，下次重试",
+				"product_id", product.ID,
+				"from", product.Status,
+				"to", to,
+				"error", err)
+			continue
+		}
+		if !updated {
+			// 商户已手动修改了状态
+			continue
+		}
+
+		if to == types.ProductStatusActive {
+			result.Activated++
+		} else {
+			result.Deactivated++
+		}
+		g.Log().Info(ctx, "商品定时上下架",
+			"tenant_id", product.TenantID,
+			"product_id", product.ID,
+			"from", product.Status,
+			"to", to)
+	}
+
+	return result, nil
+}
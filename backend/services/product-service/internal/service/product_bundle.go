@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// ProductBundleService 商品套餐服务
+type ProductBundleService struct {
+	bundleRepo  repository.IProductBundleRepository
+	productRepo repository.IProductAvailabilityRepository
+}
+
+// NewProductBundleService 创建商品套餐服务实例
+func NewProductBundleService() *ProductBundleService {
+	return &ProductBundleService{
+		bundleRepo:  repository.NewProductBundleRepository(),
+		productRepo: repository.NewProductAvailabilityRepository(),
+	}
+}
+
+// NewProductBundleServiceForTest 使用指定仓储创建商品套餐服务（用于测试）
+func NewProductBundleServiceForTest(bundleRepo repository.IProductBundleRepository, productRepo repository.IProductAvailabilityRepository) *ProductBundleService {
+	return &ProductBundleService{
+		bundleRepo:  bundleRepo,
+		productRepo: productRepo,
+	}
+}
+
+// CreateBundle 创建套餐，组成商品必须存在且属于套餐所属商户，新建的套餐直接在售
+func (s *ProductBundleService) CreateBundle(ctx context.Context, req *types.CreateProductBundleRequest) (*types.ProductBundleDetail, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	products, err := s.products(ctx, req.ProductIDs())
+	if err != nil {
+		return nil, err
+	}
+	for _, component := range req.Components {
+		product, exists := products[component.ProductID]
+		if !exists || product.MerchantID != req.MerchantID {
+			return nil, fmt.Errorf("%w: 商品%d不存在或不属于该商户", types.ErrInvalidProductBundle, component.ProductID)
+		}
+	}
+
+	bundle := &types.ProductBundle{
+		MerchantID:  req.MerchantID,
+		Name:        req.Name,
+		Description: req.Description,
+		Components:  types.ProductBundleComponents(req.Components),
+		Price:       req.Price,
+		RightsCost:  req.RightsCost,
+		Status:      types.ProductBundleStatusActive,
+	}
+	if err := s.bundleRepo.Create(ctx, bundle); err != nil {
+		return nil, err
+	}
+	return &types.ProductBundleDetail{ProductBundle: bundle, Availability: bundle.Availability(products, nil)}, nil
+}
+
+// GetBundle 获取套餐及其按组成商品库存计算的可售情况
+func (s *ProductBundleService) GetBundle(ctx context.Context, bundleID uint64) (*types.ProductBundleDetail, error) {
+	bundle, err := s.bundleRepo.GetByID(ctx, bundleID)
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		return nil, types.ErrProductBundleNotFound
+	}
+
+	details, err := s.describe(ctx, []types.ProductBundle{*bundle})
+	if err != nil {
+		return nil, err
+	}
+	return &details[0], nil
+}
+
+// ListBundles 获取商户的套餐及其可售情况，status 为空时不限状态
+func (s *ProductBundleService) ListBundles(ctx context.Context, merchantID uint64, status types.ProductBundleStatus) ([]types.ProductBundleDetail, error) {
+	bundles, err := s.bundleRepo.ListByMerchant(ctx, merchantID, status)
+	if err != nil {
+		return nil, err
+	}
+	return s.describe(ctx, bundles)
+}
+
+// UpdateBundleStatus 套餐上架或停售，停售后不能再加入购物车或下单，已下单的订单不受影响
+func (s *ProductBundleService) UpdateBundleStatus(ctx context.Context, bundleID uint64, status types.ProductBundleStatus) error {
+	if !status.IsValid() {
+		return fmt.Errorf("%w: 状态%s无效", types.ErrInvalidProductBundle, status)
+	}
+
+	bundle, err := s.bundleRepo.GetByID(ctx, bundleID)
+	if err != nil {
+		return err
+	}
+	if bundle == nil {
+		return types.ErrProductBundleNotFound
+	}
+	return s.bundleRepo.UpdateStatus(ctx, bundleID, status)
+}
+
+// describe 批量计算套餐的可售情况
+func (s *ProductBundleService) describe(ctx context.Context, bundles []types.ProductBundle) ([]types.ProductBundleDetail, error) {
+	productIDs := make([]uint64, 0)
+	for _, bundle := range bundles {
+		for _, component := range bundle.Components {
+			productIDs = append(productIDs, component.ProductID)
+		}
+	}
+	products, err := s.products(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]types.ProductBundleDetail, 0, len(bundles))
+	for i := range bundles {
+		details = append(details, types.ProductBundleDetail{
+			ProductBundle: &bundles[i],
+			Availability:  bundles[i].Availability(products, nil),
+		})
+	}
+	return details, nil
+}
+
+// products 获取套餐组成商品
+func (s *ProductBundleService) products(ctx context.Context, productIDs []uint64) (map[uint64]*types.Product, error) {
+	found, err := s.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("获取套餐商品信息失败: %v", err)
+	}
+
+	products := make(map[uint64]*types.Product, len(found))
+	for i := range found {
+		products[found[i].ID] = &found[i]
+	}
+	return products, nil
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcache"
+)
+
+const (
+	productRecommendationInterval  = time.Hour
+	productRecommendationCacheTTL  = 10 * time.Minute
+	productRecommendationOrderPage = 500
+)
+
+// ErrRecommendationProductNotFound 商品不存在或不属于当前租户
+var ErrRecommendationProductNotFound = errors.New("商品不存在")
+
+// ProductRecommendationService 商品搭配推荐服务：后台任务定期按已完成订单统计商品一起购买的次数，
+// 查询时排除已下架或无库存的搭配商品
+type ProductRecommendationService struct {
+	recommendationRepo repository.IProductRecommendationRepository
+	productRepo        repository.IProductAvailabilityRepository
+	config             *types.ProductRecommendationConfig
+	cache              *gcache.Cache
+	cacheTTL           time.Duration
+	interval           time.Duration
+	now                func() time.Time
+	stopCh             chan struct{}
+	isRunning          bool
+}
+
+// NewProductRecommendationService 创建商品搭配推荐服务，从 product.recommendation 配置加载计算参数
+func NewProductRecommendationService() *ProductRecommendationService {
+	ctx := context.Background()
+	config := (&types.ProductRecommendationConfig{
+		LookbackDays:   g.Cfg().MustGet(ctx, "product.recommendation.lookbackDays", 0).Int(),
+		MinCoPurchases: g.Cfg().MustGet(ctx, "product.recommendation.minCoPurchases", 0).Int(),
+		MaxPerProduct:  g.Cfg().MustGet(ctx, "product.recommendation.maxPerProduct", 0).Int(),
+	}).WithDefaults()
+
+	interval := g.Cfg().MustGet(ctx, "product.recommendation.refreshInterval", productRecommendationInterval.String()).Duration()
+	if interval <= 0 {
+		interval = productRecommendationInterval
+	}
+	cacheTTL := g.Cfg().MustGet(ctx, "product.recommendation.cacheTTL", productRecommendationCacheTTL.String()).Duration()
+	if cacheTTL <= 0 {
+		cacheTTL = productRecommendationCacheTTL
+	}
+
+	return &ProductRecommendationService{
+		recommendationRepo: repository.NewProductRecommendationRepository(),
+		productRepo:        repository.NewProductAvailabilityRepository(),
+		config:             config,
+		cache:              gcache.New(),
+		cacheTTL:           cacheTTL,
+		interval:           interval,
+		now:                time.Now,
+		stopCh:             make(chan struct{}),
+	}
+}
+
+// NewProductRecommendationServiceForTest 使用指定仓储和计算配置创建商品搭配推荐服务（用于测试）
+func NewProductRecommendationServiceForTest(recommendationRepo repository.IProductRecommendationRepository, productRepo repository.IProductAvailabilityRepository, config *types.ProductRecommendationConfig, now func() time.Time) *ProductRecommendationService {
+	return &ProductRecommendationService{
+		recommendationRepo: recommendationRepo,
+		productRepo:        productRepo,
+		config:             config.WithDefaults(),
+		cache:              gcache.New(),
+		cacheTTL:           productRecommendationCacheTTL,
+		interval:           productRecommendationInterval,
+		now:                now,
+		stopCh:             make(chan struct{}),
+	}
+}
+
+// Start 启动后台定时计算循环，启动时立即计算一次
+func (s *ProductRecommendationService) Start(ctx context.Context) {
+	if s.isRunning {
+		return
+	}
+	s.isRunning = true
+	g.Log().Info(ctx, "启动商品搭配推荐计算任务", "interval", s.interval)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.Refresh(ctx); err != nil {
+				g.Log().Error(ctx, "计算商品搭配推荐失败", "error", err)
+			}
+
+			select {
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop 停止后台定时计算循环
+func (s *ProductRecommendationService) Stop(ctx context.Context) {
+	if !s.isRunning {
+		return
+	}
+	s.isRunning = false
+	close(s.stopCh)
+	g.Log().Info(ctx, "商品搭配推荐计算任务已停止")
+}
+
+// Refresh 为最近有已完成订单的每个租户重新计算搭配数据，单个租户失败不影响其他租户
+func (s *ProductRecommendationService) Refresh(ctx context.Context) error {
+	since := s.now().AddDate(0, 0, -s.config.LookbackDays)
+	tenantIDs, err := s.recommendationRepo.ListTenantsWithCompletedOrders(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	for _, tenantID := range tenantIDs {
+		// 后台任务没有请求上下文，按租户设置租户上下文
+		tenantCtx := context.WithValue(ctx, "tenant_id", tenantID)
+		if err := s.RefreshTenant(tenantCtx); err != nil {
+			g.Log().Warning(ctx, "计算租户商品搭配推荐失败，下次重试", "tenant_id", tenantID, "error", err)
+		}
+	}
+	return nil
+}
+
+// RefreshTenant 按当前租户最近的已完成订单重新计算搭配数据并替换原有数据
+func (s *ProductRecommendationService) RefreshTenant(ctx context.Context) error {
+	now := s.now()
+	since := now.AddDate(0, 0, -s.config.LookbackDays)
+	counter := types.NewCoPurchaseCounter()
+
+	var afterOrderID uint64
+	for {
+		orders, err := s.recommendationRepo.ListCompletedOrders(ctx, since, afterOrderID, productRecommendationOrderPage)
+		if err != nil {
+			return err
+		}
+		for i := range orders {
+			counter.Add(&orders[i])
+		}
+		if len(orders) < productRecommendationOrderPage {
+			break
+		}
+		afterOrderID = orders[len(orders)-1].ID
+	}
+
+	tenantID := g.NewVar(ctx.Value("tenant_id")).Uint64()
+	if err := s.recommendationRepo.ReplaceCoPurchases(ctx, counter.Results(s.config, tenantID, now)); err != nil {
+		return err
+	}
+
+	// 缓存按租户和商品区分，重新计算后整体失效即可
+	if err := s.cache.Clear(ctx); err != nil {
+		g.Log().Warning(ctx, "清除商品搭配推荐缓存失败", "error", err)
+	}
+	return nil
+}
+
+// GetRecommendations 获取商品的搭配推荐，只返回同一商户下当前可推荐的商品
+func (s *ProductRecommendationService) GetRecommendations(ctx context.Context, productID uint64, limit int) (*types.ProductRecommendationResponse, error) {
+	if limit <= 0 {
+		limit = types.DefaultProductRecommendationLimit
+	}
+	if limit > types.MaxProductRecommendationLimit {
+		limit = types.MaxProductRecommendationLimit
+	}
+
+	products, err := s.productRepo.GetByIDs(ctx, []uint64{productID})
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, ErrRecommendationProductNotFound
+	}
+	product := products[0]
+
+	coPurchases, err := s.getCoPurchases(ctx, product.TenantID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &types.ProductRecommendationResponse{
+		ProductID:       productID,
+		Recommendations: []types.ProductRecommendation{},
+	}
+	if len(coPurchases) == 0 {
+		return response, nil
+	}
+	computedAt := coPurchases[0].ComputedAt
+	response.ComputedAt = &computedAt
+
+	relatedIDs := make([]uint64, 0, len(coPurchases))
+	for _, coPurchase := range coPurchases {
+		relatedIDs = append(relatedIDs, coPurchase.RelatedProductID)
+	}
+	related, err := s.productRepo.GetByIDs(ctx, relatedIDs)
+	if err != nil {
+		return nil, err
+	}
+	recommendable := make(map[uint64]bool, len(related))
+	now := s.now()
+	for i := range related {
+		if related[i].MerchantID == product.MerchantID && related[i].Recommendable(now) {
+			recommendable[related[i].ID] = true
+		}
+	}
+
+	for _, coPurchase := range coPurchases {
+		if !recommendable[coPurchase.RelatedProductID] {
+			continue
+		}
+		response.Recommendations = append(response.Recommendations, types.ProductRecommendation{
+			ProductID:       coPurchase.RelatedProductID,
+			CoPurchaseCount: coPurchase.CoPurchaseCount,
+		})
+		if len(response.Recommendations) >= limit {
+			break
+		}
+	}
+	return response, nil
+}
+
+// getCoPurchases 获取商品的搭配记录，优先使用缓存。搭配商品的上下架和库存在查询时实时过滤，不缓存
+func (s *ProductRecommendationService) getCoPurchases(ctx context.Context, tenantID, productID uint64) ([]types.ProductCoPurchase, error) {
+	key := fmt.Sprintf("product_recommendation:%d:%d", tenantID, productID)
+	if cached, err := s.cache.Get(ctx, key); err == nil && cached != nil {
+		if coPurchases, ok := cached.Val().([]types.ProductCoPurchase); ok {
+			return coPurchases, nil
+		}
+	}
+
+	coPurchases, err := s.recommendationRepo.ListCoPurchases(ctx, productID, types.MaxProductRecommendationLimit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(ctx, key, coPurchases, s.cacheTTL); err != nil {
+		g.Log().Warning(ctx, "缓存商品搭配推荐失败", "error", err)
+	}
+	return coPurchases, nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+var (
+	// ErrReviewNotPurchaser 只有已完成订单中购买了该商品的客户才能评价
+	ErrReviewNotPurchaser = errors.New("只有购买过该商品的客户才能评价")
+	// ErrReviewAlreadyExists 订单中的商品已评价
+	ErrReviewAlreadyExists = errors.New("该订单商品已评价")
+	// ErrReviewForbidden 无权处理该评价
+	ErrReviewForbidden = errors.New("无权处理该评价")
+)
+
+// ProductReviewService 商品评价服务
+type ProductReviewService struct {
+	orderRepo  repository.IOrderRepository
+	reviewRepo repository.IProductReviewRepository
+}
+
+// NewProductReviewService 创建商品评价服务实例
+func NewProductReviewService() *ProductReviewService {
+	return &ProductReviewService{
+		orderRepo:  repository.NewOrderRepository(),
+		reviewRepo: repository.NewProductReviewRepository(),
+	}
+}
+
+// NewProductReviewServiceForTest 使用指定仓储创建商品评价服务（用于测试）
+func NewProductReviewServiceForTest(orderRepo repository.IOrderRepository, reviewRepo repository.IProductReviewRepository) *ProductReviewService {
+	return &ProductReviewService{
+		orderRepo:  orderRepo,
+		reviewRepo: reviewRepo,
+	}
+}
+
+// SubmitReview 提交商品评价，评价人必须是订单的客户，订单已完成且包含该商品
+func (s *ProductReviewService) SubmitReview(ctx context.Context, productID uint64, req *types.CreateProductReviewRequest) (*types.ProductReview, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	customerID := gconv.Uint64(ctx.Value("user_id"))
+	order, err := s.orderRepo.GetByID(ctx, req.OrderID)
+	if err != nil || order == nil {
+		return nil, ErrReviewNotPurchaser
+	}
+	if customerID == 0 || order.CustomerID != customerID || order.Status != types.OrderStatusCompleted || !orderContainsProduct(order, productID) {
+		return nil, ErrReviewNotPurchaser
+	}
+
+	exists, err := s.reviewRepo.ExistsForOrderItem(ctx, order.ID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrReviewAlreadyExists
+	}
+
+	review := &types.ProductReview{
+		MerchantID: order.MerchantID,
+		ProductID:  productID,
+		OrderID:    order.ID,
+		CustomerID: customerID,
+		Rating:     req.Rating,
+		Comment:    strings.TrimSpace(req.Comment),
+	}
+	if err := s.reviewRepo.Create(ctx, review); err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// ListReviews 分页获取商品评价及评分汇总，includeFlagged 仅供审核人员使用
+func (s *ProductReviewService) ListReviews(ctx context.Context, productID uint64, includeFlagged bool, page, pageSize int) (*types.ProductReviewListResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	reviews, total, err := s.reviewRepo.ListByProduct(ctx, productID, includeFlagged, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := s.reviewRepo.GetRatingSummary(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.ProductReviewListResponse{
+		Reviews:  reviews,
+		Summary:  summary,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// GetRatingSummary 获取商品评分汇总
+func (s *ProductReviewService) GetRatingSummary(ctx context.Context, productID uint64) (*types.ProductRatingSummary, error) {
+	return s.reviewRepo.GetRatingSummary(ctx, productID)
+}
+
+// FlagReview 标记或取消标记不当评价，商户员工只能处理本商户商品的评价
+func (s *ProductReviewService) FlagReview(ctx context.Context, productID, reviewID uint64, req *types.FlagProductReviewRequest) (*types.ProductReview, error) {
+	if req.Flagged && strings.TrimSpace(req.Reason) == "" {
+		return nil, fmt.Errorf("标记原因不能为空")
+	}
+
+	review, err := s.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.ProductID != productID {
+		return nil, fmt.Errorf("商品评价不存在")
+	}
+	if merchantID := gconv.Uint64(ctx.Value("merchant_id")); merchantID > 0 && merchantID != review.MerchantID {
+		return nil, ErrReviewForbidden
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if !req.Flagged {
+		reason = ""
+	}
+	if err := s.reviewRepo.SetFlag(ctx, review.ID, req.Flagged, reason, gconv.Uint64(ctx.Value("user_id"))); err != nil {
+		return nil, err
+	}
+
+	return s.reviewRepo.GetByID(ctx, review.ID)
+}
+
+// orderContainsProduct 检查订单是否包含指定商品
+func orderContainsProduct(order *types.Order, productID uint64) bool {
+	for _, item := range order.Items {
+		if item.ProductID == productID {
+			return true
+		}
+	}
+	return false
+}
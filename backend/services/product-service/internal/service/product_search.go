@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// maxSearchCandidates SQL 搜索时参与相关度排序的最大候选商品数
+const maxSearchCandidates = 500
+
+// ProductSearchEngine 商品搜索引擎接口，可由 SQL 或外部搜索引擎实现
+type ProductSearchEngine interface {
+	// 按已扩展同义词的查询词搜索商品，结果按相关度从高到低排序并分页
+	Search(ctx context.Context, query *types.ProductSearchQuery) (*types.ProductSearchResponse, error)
+}
+
+// SQLProductSearchEngine 基于数据库模糊匹配获取候选商品，在内存中计算相关度排序
+type SQLProductSearchEngine struct {
+	repo    repository.IProductSearchRepository
+	ranking *types.ProductSearchRanking
+}
+
+// NewSQLProductSearchEngine 创建基于数据库的商品搜索引擎
+func NewSQLProductSearchEngine(repo repository.IProductSearchRepository, ranking *types.ProductSearchRanking) *SQLProductSearchEngine {
+	if ranking == nil {
+		ranking = types.DefaultProductSearchRanking()
+	}
+	return &SQLProductSearchEngine{
+		repo:    repo,
+		ranking: ranking.WithDefaults(),
+	}
+}
+
+// Search 获取候选商品并按相关度排序分页
+func (e *SQLProductSearchEngine) Search(ctx context.Context, query *types.ProductSearchQuery) (*types.ProductSearchResponse, error) {
+	texts := make([]string, len(query.Terms))
+	for i, term := range query.Terms {
+		texts[i] = term.Text
+	}
+
+	docs, err := e.repo.ListSearchCandidates(ctx, texts, query.CategoryID, maxSearchCandidates)
+	if err != nil {
+		return nil, err
+	}
+	hits := types.RankProductSearchHits(docs, query.Terms, e.ranking)
+
+	response := &types.ProductSearchResponse{
+		Hits:     []types.ProductSearchHit{},
+		Total:    int64(len(hits)),
+		Page:     query.Page,
+		PageSize: query.PageSize,
+		Terms:    query.Terms,
+	}
+	start := (query.Page - 1) * query.PageSize
+	if start < len(hits) {
+		end := start + query.PageSize
+		if end > len(hits) {
+			end = len(hits)
+		}
+		response.Hits = hits[start:end]
+	}
+	return response, nil
+}
+
+// LoadProductSearchRanking 从 product.search.ranking 配置加载字段权重，未配置的权重使用默认值
+func LoadProductSearchRanking(ctx context.Context) *types.ProductSearchRanking {
+	ranking := &types.ProductSearchRanking{
+		NameWeight:        g.Cfg().MustGet(ctx, "product.search.ranking.nameWeight", 0).Float64(),
+		TagWeight:         g.Cfg().MustGet(ctx, "product.search.ranking.tagWeight", 0).Float64(),
+		CategoryWeight:    g.Cfg().MustGet(ctx, "product.search.ranking.categoryWeight", 0).Float64(),
+		DescriptionWeight: g.Cfg().MustGet(ctx, "product.search.ranking.descriptionWeight", 0).Float64(),
+		SynonymFactor:     g.Cfg().MustGet(ctx, "product.search.ranking.synonymFactor", 0).Float64(),
+	}
+	return ranking.WithDefaults()
+}
+
+// ProductSearchService 商品搜索服务，负责同义词扩展并交给搜索引擎执行
+type ProductSearchService struct {
+	engine      ProductSearchEngine
+	synonymRepo repository.IProductSynonymRepository
+}
+
+// NewProductSearchService 创建商品搜索服务实例
+func NewProductSearchService() *ProductSearchService {
+	return &ProductSearchService{
+		engine:      NewSQLProductSearchEngine(repository.NewProductSearchRepository(), LoadProductSearchRanking(context.Background())),
+		synonymRepo: repository.NewProductSynonymRepository(),
+	}
+}
+
+// NewProductSearchServiceForTest 使用指定搜索引擎和同义词仓储创建商品搜索服务（用于测试）
+func NewProductSearchServiceForTest(engine ProductSearchEngine, synonymRepo repository.IProductSynonymRepository) *ProductSearchService {
+	return &ProductSearchService{
+		engine:      engine,
+		synonymRepo: synonymRepo,
+	}
+}
+
+// Search 按租户同义词词典扩展关键词后搜索商品
+func (s *ProductSearchService) Search(ctx context.Context, req *types.ProductSearchRequest) (*types.ProductSearchResponse, error) {
+	dictionary, err := s.synonymRepo.GetDictionary(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &types.ProductSearchQuery{
+		Terms:      dictionary.Expand(strings.TrimSpace(req.Keyword)),
+		CategoryID: req.CategoryID,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 {
+		query.PageSize = 20
+	}
+	if len(query.Terms) == 0 {
+		return &types.ProductSearchResponse{Hits: []types.ProductSearchHit{}, Page: query.Page, PageSize: query.PageSize}, nil
+	}
+
+	return s.engine.Search(ctx, query)
+}
+
+// GetSynonyms 获取当前租户的同义词词典
+func (s *ProductSearchService) GetSynonyms(ctx context.Context) (*types.ProductSynonymDictionary, error) {
+	return s.synonymRepo.GetDictionary(ctx)
+}
+
+// SaveSynonyms 校验并整体替换当前租户的同义词词典
+func (s *ProductSearchService) SaveSynonyms(ctx context.Context, dictionary *types.ProductSynonymDictionary) error {
+	if err := dictionary.Normalize(); err != nil {
+		return err
+	}
+	if err := s.synonymRepo.SaveDictionary(ctx, dictionary); err != nil {
+		return err
+	}
+
+	audit.LogOperation(ctx, "product_search_synonyms", "update", map[string]interface{}{
+		"group_count": len(dictionary.Groups),
+	})
+	return nil
+}
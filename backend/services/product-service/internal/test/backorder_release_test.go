@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// memoryBackorderRepository 内存预订订单仓储，与数据库实现一样在同一把锁内按当前库存分配并转换订单状态
+type memoryBackorderRepository struct {
+	mutex     sync.Mutex
+	inventory types.InventoryInfo
+	orders    []*types.Order // 按下单时间升序
+}
+
+func (f *memoryBackorderRepository) ReleaseBackorders(ctx context.Context, productID uint64, reason string) (*types.BackorderReleaseResult, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	result := &types.BackorderReleaseResult{ProductID: productID}
+	var held []*types.Order
+	for _, order := range f.orders {
+		if quantity := order.BackorderedQuantityOf(productID); quantity > 0 {
+			result.Outstanding += quantity
+			held = append(held, order)
+		}
+	}
+
+	result.Releases = types.AllocateBackorders(productID, f.inventory.ReleasableBackorders(result.Outstanding), held)
+	for _, release := range result.Releases {
+		result.Released += release.Quantity
+		for _, order := range held {
+			if order.ID == release.OrderID && release.Fulfilled && order.Status == types.OrderStatusPaid {
+				order.Status = types.OrderStatusProcessing
+				result.ProcessingOrderIDs = append(result.ProcessingOrderIDs, order.ID)
+			}
+		}
+	}
+	return result, nil
+}
+
+// restock 增加库存后触发补货处理
+func (f *memoryBackorderRepository) restock(ctx context.Context, releaser *service.BackorderReleaseService, quantity int) (*types.BackorderReleaseResult, error) {
+	f.mutex.Lock()
+	f.inventory.StockQuantity += quantity
+	f.mutex.Unlock()
+	return releaser.HandleRestock(ctx, 7, quantity)
+}
+
+// newBackorderRepository 三个已支付的预订订单，预订数量已从库存扣减，库存为 -6
+func newBackorderRepository() *memoryBackorderRepository {
+	return &memoryBackorderRepository{
+		inventory: types.InventoryInfo{StockQuantity: -6, TrackInventory: true, AllowBackorder: true, BackorderLimit: 10},
+		orders: []*types.Order{
+			{ID: 1, Status: types.OrderStatusPaid, Items: []types.OrderItem{{ProductID: 7, Quantity: 2, BackorderedQuantity: 2}}},
+			{ID: 2, Status: types.OrderStatusPaid, Items: []types.OrderItem{{ProductID: 7, Quantity: 3, BackorderedQuantity: 3}}},
+			{ID: 3, Status: types.OrderStatusPaid, Items: []types.OrderItem{{ProductID: 7, Quantity: 1, BackorderedQuantity: 1}}},
+		},
+	}
+}
+
+func TestBackorderReleaseOnRestock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("补货部分满足时按下单先后释放", func(t *testing.T) {
+		repo := newBackorderRepository()
+		releaser := service.NewBackorderReleaseServiceForTest(repo, true)
+
+		result, err := repo.restock(ctx, releaser, 3)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.Equal(t, 6, result.Outstanding)
+		assert.Equal(t, 3, result.Released)
+		assert.Equal(t, []uint64{1}, result.ProcessingOrderIDs)
+
+		// 订单1全部释放转为处理中，订单2释放1件继续等待，订单3不释放
+		assert.Equal(t, types.OrderStatusProcessing, repo.orders[0].Status)
+		assert.Equal(t, types.OrderStatusPaid, repo.orders[1].Status)
+		assert.Equal(t, 2, repo.orders[1].BackorderedQuantityOf(7))
+		assert.Equal(t, 1, repo.orders[2].BackorderedQuantityOf(7))
+
+		// 再次补货时从仍在等待的订单继续释放，已释放的数量不会重复释放
+		result, err = repo.restock(ctx, releaser, 3)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Released)
+		assert.Equal(t, []uint64{2, 3}, result.ProcessingOrderIDs)
+	})
+
+	t.Run("补货全部满足时所有订单转为处理中", func(t *testing.T) {
+		repo := newBackorderRepository()
+		releaser := service.NewBackorderReleaseServiceForTest(repo, true)
+
+		result, err := repo.restock(ctx, releaser, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 6, result.Released)
+		assert.Equal(t, []uint64{1, 2, 3}, result.ProcessingOrderIDs)
+		for _, order := range repo.orders {
+			assert.False(t, order.HasBackorderedItems())
+			assert.Equal(t, types.OrderStatusProcessing, order.Status)
+		}
+	})
+
+	t.Run("待支付订单只释放预订数量", func(t *testing.T) {
+		repo := newBackorderRepository()
+		repo.orders[0].Status = types.OrderStatusPending
+		releaser := service.NewBackorderReleaseServiceForTest(repo, true)
+
+		result, err := repo.restock(ctx, releaser, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Released)
+		assert.Empty(t, result.ProcessingOrderIDs)
+		assert.Equal(t, types.OrderStatusPending, repo.orders[0].Status)
+		assert.False(t, repo.orders[0].HasBackorderedItems())
+	})
+
+	t.Run("关闭自动释放时补货后由商户手动释放", func(t *testing.T) {
+		repo := newBackorderRepository()
+		releaser := service.NewBackorderReleaseServiceForTest(repo, false)
+
+		result, err := repo.restock(ctx, releaser, 10)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+		assert.Equal(t, types.OrderStatusPaid, repo.orders[0].Status)
+
+		result, err = releaser.ReleaseBackorders(ctx, 7)
+		require.NoError(t, err)
+		assert.Equal(t, 6, result.Released)
+		assert.Len(t, result.ProcessingOrderIDs, 3)
+	})
+
+	t.Run("库存减少不触发释放", func(t *testing.T) {
+		repo := newBackorderRepository()
+		releaser := service.NewBackorderReleaseServiceForTest(repo, true)
+
+		result, err := repo.restock(ctx, releaser, -1)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+}
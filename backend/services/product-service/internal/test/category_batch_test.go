@@ -0,0 +1,242 @@
+package test
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// memoryCategoryRepository 内存分类仓储，products 记录商品所属分类
+type memoryCategoryRepository struct {
+	categories map[uint64]*types.ProductCategory
+	products   map[uint64]uint64
+}
+
+func (f *memoryCategoryRepository) ListAllCategories(ctx context.Context) ([]types.ProductCategory, error) {
+	var categories []types.ProductCategory
+	for _, category := range f.categories {
+		categories = append(categories, *category)
+	}
+	return categories, nil
+}
+
+func (f *memoryCategoryRepository) CountProductsByCategory(ctx context.Context, categoryIDs []uint64) (map[uint64]int, error) {
+	counts := make(map[uint64]int)
+	for _, categoryID := range f.products {
+		for _, id := range categoryIDs {
+			if categoryID == id {
+				counts[id]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (f *memoryCategoryRepository) UpdateStatus(ctx context.Context, id uint64, status types.CategoryStatus) error {
+	f.categories[id].Status = status
+	return nil
+}
+
+func (f *memoryCategoryRepository) ApplyDeletion(ctx context.Context, deletion *types.CategoryDeletion) (int, error) {
+	reassigned := 0
+	if deletion.ReassignTo != nil {
+		for productID, categoryID := range f.products {
+			for _, id := range deletion.DeleteIDs {
+				if categoryID == id {
+					f.products[productID] = deletion.ReassignTo.ID
+					reassigned++
+				}
+			}
+		}
+	}
+	for _, move := range deletion.Moves {
+		category := f.categories[move.CategoryID]
+		category.ParentID = move.ParentID
+		category.Level = move.Level
+		category.Path = move.Path
+	}
+	for _, id := range deletion.DeleteIDs {
+		delete(f.categories, id)
+	}
+	return reassigned, nil
+}
+
+func (f *memoryCategoryRepository) ids() []uint64 {
+	var ids []uint64
+	for id := range f.categories {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+// newCategoryFixture 食品(1) > 饮料(2) > 茶饮(3) > 奶茶(4)，食品(1) > 零食(5)，服装(6)
+func newCategoryFixture() *memoryCategoryRepository {
+	return &memoryCategoryRepository{
+		categories: map[uint64]*types.ProductCategory{
+			1: {ID: 1, Name: "食品", Level: 1, Path: "食品", Status: types.CategoryStatusActive},
+			2: {ID: 2, Name: "饮料", ParentID: uint64Ptr(1), Level: 2, Path: "食品/饮料", Status: types.CategoryStatusActive},
+			3: {ID: 3, Name: "茶饮", ParentID: uint64Ptr(2), Level: 3, Path: "食品/饮料/茶饮", Status: types.CategoryStatusActive},
+			4: {ID: 4, Name: "奶茶", ParentID: uint64Ptr(3), Level: 4, Path: "食品/饮料/茶饮/奶茶", Status: types.CategoryStatusActive},
+			5: {ID: 5, Name: "零食", ParentID: uint64Ptr(1), Level: 2, Path: "食品/零食", Status: types.CategoryStatusActive},
+			6: {ID: 6, Name: "服装", Level: 1, Path: "服装", Status: types.CategoryStatusActive},
+		},
+		products: map[uint64]uint64{},
+	}
+}
+
+func TestCategoryBatch_EnableDisable(t *testing.T) {
+	repo := newCategoryFixture()
+	categoryService := service.NewCategoryServiceForTest(repo, types.CategoryChildPolicyBlock)
+
+	result, err := categoryService.BatchOperation(context.Background(), &types.CategoryBatchRequest{
+		CategoryIDs: []uint64{5, 6, 99},
+		Action:      types.CategoryBatchDisable,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.SuccessCount)
+	assert.Equal(t, 1, result.FailureCount)
+	assert.False(t, result.Results[2].Success)
+	assert.Equal(t, service.ErrCategoryNotFound.Error(), result.Results[2].Error)
+	assert.Equal(t, types.CategoryStatusInactive, repo.categories[5].Status)
+	assert.Equal(t, types.CategoryStatusInactive, repo.categories[6].Status)
+	assert.Equal(t, types.CategoryStatusActive, repo.categories[1].Status)
+}
+
+func TestCategoryBatch_DeleteBlockPolicy(t *testing.T) {
+	repo := newCategoryFixture()
+	categoryService := service.NewCategoryServiceForTest(repo, types.CategoryChildPolicyBlock)
+
+	result, err := categoryService.BatchOperation(context.Background(), &types.CategoryBatchRequest{
+		CategoryIDs: []uint64{2, 5},
+		Action:      types.CategoryBatchDelete,
+	})
+	require.NoError(t, err)
+
+	// 饮料存在子分类被拒绝，零食没有子分类可以删除
+	assert.False(t, result.Results[0].Success)
+	assert.Equal(t, service.ErrCategoryHasChildren.Error(), result.Results[0].Error)
+	assert.True(t, result.Results[1].Success)
+	assert.Equal(t, []uint64{1, 2, 3, 4, 6}, repo.ids())
+}
+
+func TestCategoryBatch_DeleteCascadePolicy(t *testing.T) {
+	repo := newCategoryFixture()
+	categoryService := service.NewCategoryServiceForTest(repo, types.CategoryChildPolicyBlock)
+
+	result, err := categoryService.BatchOperation(context.Background(), &types.CategoryBatchRequest{
+		CategoryIDs: []uint64{2, 3},
+		Action:      types.CategoryBatchDelete,
+		ChildPolicy: types.CategoryChildPolicyCascade,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.SuccessCount)
+	assert.Equal(t, []uint64{3, 4}, result.Results[0].CascadedIDs)
+	// 茶饮已随饮料一起删除
+	assert.True(t, result.Results[1].Success)
+	assert.Equal(t, []uint64{1, 5, 6}, repo.ids())
+}
+
+func TestCategoryBatch_DeleteReparentPolicy(t *testing.T) {
+	repo := newCategoryFixture()
+	categoryService := service.NewCategoryServiceForTest(repo, types.CategoryChildPolicyReparent)
+
+	result, err := categoryService.BatchOperation(context.Background(), &types.CategoryBatchRequest{
+		CategoryIDs: []uint64{2},
+		Action:      types.CategoryBatchDelete,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Results[0].Success)
+	assert.Equal(t, []uint64{3}, result.Results[0].ReparentedIDs)
+
+	// 茶饮上移到食品下，奶茶的层级和路径随之更新
+	assert.Equal(t, uint64(1), *repo.categories[3].ParentID)
+	assert.Equal(t, 2, repo.categories[3].Level)
+	assert.Equal(t, "食品/茶饮", repo.categories[3].Path)
+	assert.Equal(t, uint64(3), *repo.categories[4].ParentID)
+	assert.Equal(t, 3, repo.categories[4].Level)
+	assert.Equal(t, "食品/茶饮/奶茶", repo.categories[4].Path)
+
+	// 删除顶级分类时子分类成为顶级分类
+	result, err = categoryService.BatchOperation(context.Background(), &types.CategoryBatchRequest{
+		CategoryIDs: []uint64{1},
+		Action:      types.CategoryBatchDelete,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Results[0].Success)
+	assert.Nil(t, repo.categories[3].ParentID)
+	assert.Equal(t, 1, repo.categories[3].Level)
+	assert.Equal(t, "茶饮/奶茶", repo.categories[4].Path)
+}
+
+func TestCategoryBatch_ProductOrphanGuard(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("未指定转移分类时拒绝删除有商品的分类", func(t *testing.T) {
+		repo := newCategoryFixture()
+		repo.products[100] = 4
+		categoryService := service.NewCategoryServiceForTest(repo, types.CategoryChildPolicyCascade)
+
+		result, err := categoryService.BatchOperation(ctx, &types.CategoryBatchRequest{
+			CategoryIDs: []uint64{2, 6},
+			Action:      types.CategoryBatchDelete,
+		})
+		require.NoError(t, err)
+		// 级联删除的孙分类下有商品，整个饮料分类都不删除
+		assert.False(t, result.Results[0].Success)
+		assert.Contains(t, result.Results[0].Error, service.ErrCategoryHasProducts.Error())
+		assert.True(t, result.Results[1].Success)
+		assert.Equal(t, []uint64{1, 2, 3, 4, 5}, repo.ids())
+		assert.Equal(t, uint64(4), repo.products[100])
+	})
+
+	t.Run("指定转移分类时商品转移后删除", func(t *testing.T) {
+		repo := newCategoryFixture()
+		repo.products[100] = 4
+		repo.products[101] = 2
+		categoryService := service.NewCategoryServiceForTest(repo, types.CategoryChildPolicyCascade)
+
+		result, err := categoryService.BatchOperation(ctx, &types.CategoryBatchRequest{
+			CategoryIDs:        []uint64{2},
+			Action:             types.CategoryBatchDelete,
+			ReassignCategoryID: uint64Ptr(5),
+		})
+		require.NoError(t, err)
+		require.True(t, result.Results[0].Success)
+		assert.Equal(t, 2, result.Results[0].ReassignedProducts)
+		assert.Equal(t, uint64(5), repo.products[100])
+		assert.Equal(t, uint64(5), repo.products[101])
+	})
+
+	t.Run("转移目标分类不能是被删除的分类", func(t *testing.T) {
+		repo := newCategoryFixture()
+		repo.products[100] = 4
+		categoryService := service.NewCategoryServiceForTest(repo, types.CategoryChildPolicyCascade)
+
+		result, err := categoryService.BatchOperation(ctx, &types.CategoryBatchRequest{
+			CategoryIDs:        []uint64{2},
+			Action:             types.CategoryBatchDelete,
+			ReassignCategoryID: uint64Ptr(3),
+		})
+		require.NoError(t, err)
+		assert.False(t, result.Results[0].Success)
+		assert.Equal(t, uint64(4), repo.products[100])
+
+		_, err = categoryService.BatchOperation(ctx, &types.CategoryBatchRequest{
+			CategoryIDs:        []uint64{2},
+			Action:             types.CategoryBatchDelete,
+			ReassignCategoryID: uint64Ptr(99),
+		})
+		assert.ErrorIs(t, err, service.ErrCategoryReassignInvalid)
+	})
+}
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// memoryAvailabilityRepository 内存商品可售时间窗口仓储
+type memoryAvailabilityRepository struct {
+	products map[uint64]*types.Product
+}
+
+func (f *memoryAvailabilityRepository) GetByIDs(ctx context.Context, ids []uint64) ([]types.Product, error) {
+	var products []types.Product
+	for _, id := range ids {
+		if product, ok := f.products[id]; ok {
+			products = append(products, *product)
+		}
+	}
+	return products, nil
+}
+
+func (f *memoryAvailabilityRepository) ListDueForTransition(ctx context.Context, now time.Time, limit int) ([]types.Product, error) {
+	var products []types.Product
+	for _, product := range f.products {
+		if product.ScheduledStatusAt(now) != product.Status {
+			products = append(products, *product)
+		}
+	}
+	return products, nil
+}
+
+func (f *memoryAvailabilityRepository) TransitionStatus(ctx context.Context, product *types.Product, from, to types.ProductStatus) (bool, error) {
+	current := f.products[product.ID]
+	if current.Status != from {
+		return false, nil
+	}
+	current.Status = to
+	current.Version++
+	return true, nil
+}
+
+func TestProductAvailabilityScheduler(t *testing.T) {
+	launch := time.Date(2026, 11, 11, 0, 0, 0, 0, time.Local)
+	retire := launch.Add(24 * time.Hour)
+	now := launch.Add(-time.Hour)
+
+	repo := &memoryAvailabilityRepository{products: map[uint64]*types.Product{
+		// 双十一限时抢购：到点上架，一天后下架
+		1: {ID: 1, TenantID: 1, Status: types.ProductStatusScheduled, AvailableFrom: &launch, AvailableUntil: &retire},
+		// 季节性商品：已在售，到期下架
+		2: {ID: 2, TenantID: 1, Status: types.ProductStatusActive, AvailableUntil: &retire},
+		// 不受时间限制的商品
+		3: {ID: 3, TenantID: 2, Status: types.ProductStatusActive},
+	}}
+	scheduler := service.NewProductAvailabilitySchedulerForTest(repo, func() time.Time { return now })
+	ctx := context.Background()
+
+	t.Run("未到开始时间时不上架", func(t *testing.T) {
+		result, err := scheduler.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Activated)
+		assert.Equal(t, 0, result.Deactivated)
+		assert.Equal(t, types.ProductStatusScheduled, repo.products[1].Status)
+		assert.False(t, repo.products[1].InAvailabilityWindow(now))
+	})
+
+	t.Run("到达开始时间后自动上架", func(t *testing.T) {
+		now = launch
+		result, err := scheduler.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Activated)
+		assert.Equal(t, types.ProductStatusActive, repo.products[1].Status)
+		assert.True(t, repo.products[1].InAvailabilityWindow(now))
+		assert.Equal(t, types.ProductStatusActive, repo.products[2].Status)
+	})
+
+	t.Run("到达结束时间后自动下架", func(t *testing.T) {
+		now = retire
+		result, err := scheduler.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Activated)
+		assert.Equal(t, 2, result.Deactivated)
+		assert.Equal(t, types.ProductStatusInactive, repo.products[1].Status)
+		assert.Equal(t, types.ProductStatusInactive, repo.products[2].Status)
+		assert.False(t, repo.products[2].InAvailabilityWindow(now))
+		assert.Equal(t, types.ProductStatusActive, repo.products[3].Status)
+
+		// 已下架的商品不会被再次处理
+		result, err = scheduler.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Deactivated)
+	})
+}
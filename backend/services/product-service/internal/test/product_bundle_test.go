@@ -0,0 +1,113 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// memoryBundleRepository 内存商品套餐仓储
+type memoryBundleRepository struct {
+	repository.IProductBundleRepository
+	bundles map[uint64]*types.ProductBundle
+}
+
+func (f *memoryBundleRepository) Create(ctx context.Context, bundle *types.ProductBundle) error {
+	bundle.ID = uint64(len(f.bundles) + 1)
+	copied := *bundle
+	f.bundles[bundle.ID] = &copied
+	return nil
+}
+
+func (f *memoryBundleRepository) GetByID(ctx context.Context, id uint64) (*types.ProductBundle, error) {
+	bundle, exists := f.bundles[id]
+	if !exists {
+		return nil, nil
+	}
+	copied := *bundle
+	return &copied, nil
+}
+
+func (f *memoryBundleRepository) UpdateStatus(ctx context.Context, id uint64, status types.ProductBundleStatus) error {
+	f.bundles[id].Status = status
+	return nil
+}
+
+func TestProductBundleService(t *testing.T) {
+	productRepo := &memoryAvailabilityRepository{products: map[uint64]*types.Product{
+		1: {ID: 1, MerchantID: 1, Status: types.ProductStatusActive, InventoryInfo: &types.InventoryInfo{StockQuantity: 10, ReservedQuantity: 3, TrackInventory: true}},
+		2: {ID: 2, MerchantID: 1, Status: types.ProductStatusActive, InventoryInfo: &types.InventoryInfo{StockQuantity: 5, TrackInventory: true}},
+		3: {ID: 3, MerchantID: 1, Status: types.ProductStatusActive},
+		4: {ID: 4, MerchantID: 2, Status: types.ProductStatusActive},
+	}}
+	bundleRepo := &memoryBundleRepository{bundles: make(map[uint64]*types.ProductBundle)}
+	bundleService := service.NewProductBundleServiceForTest(bundleRepo, productRepo)
+	ctx := context.Background()
+
+	t.Run("套餐可售份数取决于库存最少的组成商品", func(t *testing.T) {
+		detail, err := bundleService.CreateBundle(ctx, &types.CreateProductBundleRequest{
+			MerchantID: 1,
+			Name:       "家庭套餐",
+			Components: []types.ProductBundleComponent{{ProductID: 1, Quantity: 2}, {ProductID: 2, Quantity: 1}, {ProductID: 3, Quantity: 1}},
+			Price:      199,
+			RightsCost: 20,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, types.ProductBundleStatusActive, detail.Status)
+		// 商品1可用7件只够3份，商品2够5份，商品3不跟踪库存
+		assert.True(t, detail.Availability.InStock)
+		assert.False(t, detail.Availability.Unlimited)
+		assert.Equal(t, 3, detail.Availability.Quantity)
+		assert.True(t, detail.Availability.Allows(3))
+		assert.False(t, detail.Availability.Allows(4))
+	})
+
+	t.Run("任一组成商品无库存或下架时套餐不可售", func(t *testing.T) {
+		productRepo.products[2].InventoryInfo.ReservedQuantity = 5
+		detail, err := bundleService.GetBundle(ctx, 1)
+		require.NoError(t, err)
+		assert.False(t, detail.Availability.InStock)
+		assert.Equal(t, uint64(2), detail.Availability.UnavailableProductID)
+		productRepo.products[2].InventoryInfo.ReservedQuantity = 0
+
+		productRepo.products[3].Status = types.ProductStatusInactive
+		detail, err = bundleService.GetBundle(ctx, 1)
+		require.NoError(t, err)
+		assert.False(t, detail.Availability.Allows(1))
+		productRepo.products[3].Status = types.ProductStatusActive
+	})
+
+	t.Run("组成商品不跟踪库存时不限制可售份数", func(t *testing.T) {
+		bundle := &types.ProductBundle{Components: types.ProductBundleComponents{{ProductID: 3, Quantity: 5}}}
+		availability := bundle.Availability(map[uint64]*types.Product{3: productRepo.products[3]}, nil)
+		assert.True(t, availability.Unlimited)
+		assert.True(t, availability.Allows(1000))
+	})
+
+	t.Run("组成商品必须属于套餐商户且不能重复", func(t *testing.T) {
+		_, err := bundleService.CreateBundle(ctx, &types.CreateProductBundleRequest{
+			MerchantID: 1, Name: "跨商户套餐", Price: 99,
+			Components: []types.ProductBundleComponent{{ProductID: 1, Quantity: 1}, {ProductID: 4, Quantity: 1}},
+		})
+		assert.ErrorIs(t, err, types.ErrInvalidProductBundle)
+
+		_, err = bundleService.CreateBundle(ctx, &types.CreateProductBundleRequest{
+			MerchantID: 1, Name: "重复商品套餐", Price: 99,
+			Components: []types.ProductBundleComponent{{ProductID: 1, Quantity: 1}, {ProductID: 1, Quantity: 2}},
+		})
+		assert.ErrorIs(t, err, types.ErrInvalidProductBundle)
+		assert.Len(t, bundleRepo.bundles, 1)
+	})
+
+	t.Run("停售套餐", func(t *testing.T) {
+		require.NoError(t, bundleService.UpdateBundleStatus(ctx, 1, types.ProductBundleStatusInactive))
+		assert.Equal(t, types.ProductBundleStatusInactive, bundleRepo.bundles[1].Status)
+		assert.ErrorIs(t, bundleService.UpdateBundleStatus(ctx, 99, types.ProductBundleStatusActive), types.ErrProductBundleNotFound)
+	})
+}
@@ -0,0 +1,216 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// memoryRecommendationRepository 内存商品搭配推荐仓储，已完成订单和搭配记录按租户保存
+type memoryRecommendationRepository struct {
+	orders              map[uint64][]types.Order
+	coPurchases         map[uint64][]types.ProductCoPurchase
+	listCoPurchaseCalls int
+}
+
+func tenantOf(ctx context.Context) uint64 {
+	tenantID, _ := ctx.Value("tenant_id").(uint64)
+	return tenantID
+}
+
+func (f *memoryRecommendationRepository) ListTenantsWithCompletedOrders(ctx context.Context, since time.Time) ([]uint64, error) {
+	var tenantIDs []uint64
+	for tenantID := range f.orders {
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+	return tenantIDs, nil
+}
+
+func (f *memoryRecommendationRepository) ListCompletedOrders(ctx context.Context, since time.Time, afterOrderID uint64, limit int) ([]types.Order, error) {
+	var orders []types.Order
+	for _, order := range f.orders[tenantOf(ctx)] {
+		if order.ID > afterOrderID && len(orders) < limit {
+			orders = append(orders, order)
+		}
+	}
+	return orders, nil
+}
+
+func (f *memoryRecommendationRepository) ReplaceCoPurchases(ctx context.Context, coPurchases []types.ProductCoPurchase) error {
+	f.coPurchases[tenantOf(ctx)] = coPurchases
+	return nil
+}
+
+func (f *memoryRecommendationRepository) ListCoPurchases(ctx context.Context, productID uint64, limit int) ([]types.ProductCoPurchase, error) {
+	f.listCoPurchaseCalls++
+	var coPurchases []types.ProductCoPurchase
+	for _, coPurchase := range f.coPurchases[tenantOf(ctx)] {
+		if coPurchase.ProductID == productID {
+			coPurchases = append(coPurchases, coPurchase)
+		}
+	}
+	types.SortCoPurchases(coPurchases)
+	if len(coPurchases) > limit {
+		coPurchases = coPurchases[:limit]
+	}
+	return coPurchases, nil
+}
+
+// completedOrder 构造包含指定商品的已完成订单
+func completedOrder(id, merchantID uint64, productIDs ...uint64) types.Order {
+	order := types.Order{ID: id, MerchantID: merchantID, Status: types.OrderStatusCompleted}
+	for _, productID := range productIDs {
+		order.Items = append(order.Items, types.OrderItem{ProductID: productID, Quantity: 1})
+	}
+	return order
+}
+
+func recommendedIDs(response *types.ProductRecommendationResponse) []uint64 {
+	ids := []uint64{}
+	for _, recommendation := range response.Recommendations {
+		ids = append(ids, recommendation.ProductID)
+	}
+	return ids
+}
+
+func TestProductRecommendationService(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)
+	ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+	recommendationRepo := &memoryRecommendationRepository{
+		orders: map[uint64][]types.Order{
+			1: {
+				// 咖啡豆(1)常与滤纸(2)、磨豆机(3)一起购买
+				completedOrder(1, 10, 1, 2, 3),
+				completedOrder(2, 10, 1, 2, 3),
+				completedOrder(3, 10, 1, 2, 2),
+				// 马克杯(4)与咖啡豆一起购买 2 次，与磨豆机次数相同
+				completedOrder(4, 10, 1, 4),
+				completedOrder(5, 10, 1, 4),
+				// 手冲壶(5)只一起购买过 1 次，达不到推荐门槛
+				completedOrder(6, 10, 1, 5),
+				// 已下架(6)和无库存(7)的商品
+				completedOrder(7, 10, 1, 6, 7),
+				completedOrder(8, 10, 1, 6, 7),
+			},
+			// 其他租户的订单不影响当前租户
+			2: {
+				completedOrder(9, 20, 1, 5),
+				completedOrder(10, 20, 1, 5),
+			},
+		},
+		coPurchases: map[uint64][]types.ProductCoPurchase{},
+	}
+
+	active := func(id, merchantID uint64) *types.Product {
+		return &types.Product{ID: id, TenantID: 1, MerchantID: merchantID, Status: types.ProductStatusActive}
+	}
+	productRepo := &memoryAvailabilityRepository{products: map[uint64]*types.Product{
+		1: active(1, 10),
+		2: active(2, 10),
+		3: active(3, 10),
+		4: active(4, 10),
+		5: active(5, 10),
+		6: {ID: 6, TenantID: 1, MerchantID: 10, Status: types.ProductStatusInactive},
+		7: {ID: 7, TenantID: 1, MerchantID: 10, Status: types.ProductStatusActive,
+			InventoryInfo: &types.InventoryInfo{StockQuantity: 3, ReservedQuantity: 3, TrackInventory: true}},
+	}}
+
+	recommendationService := service.NewProductRecommendationServiceForTest(recommendationRepo, productRepo,
+		&types.ProductRecommendationConfig{MinCoPurchases: 2}, func() time.Time { return now })
+
+	t.Run("尚未计算时没有推荐", func(t *testing.T) {
+		response, err := recommendationService.GetRecommendations(ctx, 1, 0)
+		require.NoError(t, err)
+		assert.Empty(t, response.Recommendations)
+		assert.Nil(t, response.ComputedAt)
+	})
+
+	require.NoError(t, recommendationService.Refresh(context.Background()))
+
+	t.Run("按一起购买次数排序，次数相同时按商品ID", func(t *testing.T) {
+		response, err := recommendationService.GetRecommendations(ctx, 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{2, 3, 4}, recommendedIDs(response))
+		assert.Equal(t, 3, response.Recommendations[0].CoPurchaseCount)
+		assert.Equal(t, 2, response.Recommendations[1].CoPurchaseCount)
+		require.NotNil(t, response.ComputedAt)
+		assert.True(t, response.ComputedAt.Equal(now))
+	})
+
+	t.Run("同一订单中重复的商品只计一次", func(t *testing.T) {
+		response, err := recommendationService.GetRecommendations(ctx, 2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{1, 3}, recommendedIDs(response))
+		assert.Equal(t, 3, response.Recommendations[0].CoPurchaseCount)
+	})
+
+	t.Run("限制返回数量", func(t *testing.T) {
+		response, err := recommendationService.GetRecommendations(ctx, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{2, 3}, recommendedIDs(response))
+	})
+
+	t.Run("排除已下架和无库存的商品", func(t *testing.T) {
+		stored := recommendationRepo.coPurchases[1]
+		var related []uint64
+		for _, coPurchase := range stored {
+			if coPurchase.ProductID == 1 {
+				related = append(related, coPurchase.RelatedProductID)
+			}
+		}
+		assert.Contains(t, related, uint64(6))
+		assert.Contains(t, related, uint64(7))
+		assert.NotContains(t, related, uint64(5))
+
+		response, err := recommendationService.GetRecommendations(ctx, 6, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{1}, recommendedIDs(response))
+	})
+
+	t.Run("只推荐同一商户的商品", func(t *testing.T) {
+		productRepo.products[3].MerchantID = 11
+		defer func() { productRepo.products[3].MerchantID = 10 }()
+
+		response, err := recommendationService.GetRecommendations(ctx, 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{2, 4}, recommendedIDs(response))
+	})
+
+	t.Run("商品不存在", func(t *testing.T) {
+		_, err := recommendationService.GetRecommendations(ctx, 99, 0)
+		assert.ErrorIs(t, err, service.ErrRecommendationProductNotFound)
+	})
+
+	t.Run("使用缓存，重新计算后失效", func(t *testing.T) {
+		_, err := recommendationService.GetRecommendations(ctx, 4, 0)
+		require.NoError(t, err)
+		calls := recommendationRepo.listCoPurchaseCalls
+		_, err = recommendationService.GetRecommendations(ctx, 4, 0)
+		require.NoError(t, err)
+		assert.Equal(t, calls, recommendationRepo.listCoPurchaseCalls)
+
+		// 新的订单让马克杯与滤纸达到推荐门槛
+		recommendationRepo.orders[1] = append(recommendationRepo.orders[1],
+			completedOrder(11, 10, 2, 4), completedOrder(12, 10, 2, 4))
+		require.NoError(t, recommendationService.Refresh(context.Background()))
+
+		response, err := recommendationService.GetRecommendations(ctx, 4, 0)
+		require.NoError(t, err)
+		assert.Equal(t, calls+1, recommendationRepo.listCoPurchaseCalls)
+		assert.Equal(t, []uint64{1, 2}, recommendedIDs(response))
+	})
+
+	t.Run("其他租户单独计算", func(t *testing.T) {
+		coPurchases := recommendationRepo.coPurchases[2]
+		require.Len(t, coPurchases, 2)
+		assert.Equal(t, uint64(2), coPurchases[0].TenantID)
+		assert.Equal(t, uint64(20), coPurchases[0].MerchantID)
+	})
+}
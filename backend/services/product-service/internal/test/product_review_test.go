@@ -0,0 +1,247 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// reviewOrderRepository 按ID返回订单的订单仓储桩
+type reviewOrderRepository struct {
+	repository.IOrderRepository
+	orders map[uint64]*types.Order
+}
+
+func (f *reviewOrderRepository) GetByID(ctx context.Context, id uint64) (*types.Order, error) {
+	order, ok := f.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("订单不存在")
+	}
+	return order, nil
+}
+
+// memoryReviewRepository 内存商品评价仓储
+type memoryReviewRepository struct {
+	reviews []*types.ProductReview
+}
+
+func (f *memoryReviewRepository) Create(ctx context.Context, review *types.ProductReview) error {
+	review.ID = uint64(len(f.reviews) + 1)
+	f.reviews = append(f.reviews, review)
+	return nil
+}
+
+func (f *memoryReviewRepository) GetByID(ctx context.Context, id uint64) (*types.ProductReview, error) {
+	for _, review := range f.reviews {
+		if review.ID == id {
+			return review, nil
+		}
+	}
+	return nil, fmt.Errorf("商品评价不存在")
+}
+
+func (f *memoryReviewRepository) ExistsForOrderItem(ctx context.Context, orderID, productID uint64) (bool, error) {
+	for _, review := range f.reviews {
+		if review.OrderID == orderID && review.ProductID == productID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *memoryReviewRepository) ListByProduct(ctx context.Context, productID uint64, includeFlagged bool, page, pageSize int) ([]types.ProductReview, int, error) {
+	var reviews []types.ProductReview
+	for _, review := range f.reviews {
+		if review.ProductID == productID && (includeFlagged || !review.Flagged) {
+			reviews = append(reviews, *review)
+		}
+	}
+	return reviews, len(reviews), nil
+}
+
+func (f *memoryReviewRepository) SetFlag(ctx context.Context, id uint64, flagged bool, reason string, operatorID uint64) error {
+	review, err := f.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	review.Flagged = flagged
+	review.FlagReason = reason
+	return nil
+}
+
+func (f *memoryReviewRepository) GetRatingSummary(ctx context.Context, productID uint64) (*types.ProductRatingSummary, error) {
+	ratingCounts := make(map[int]int)
+	for _, review := range f.reviews {
+		if review.ProductID == productID && !review.Flagged {
+			ratingCounts[review.Rating]++
+		}
+	}
+	return types.NewProductRatingSummary(productID, ratingCounts), nil
+}
+
+func newReviewTestService() (*service.ProductReviewService, *memoryReviewRepository) {
+	orderRepo := &reviewOrderRepository{orders: map[uint64]*types.Order{
+		1: {ID: 1, MerchantID: 10, CustomerID: 100, Status: types.OrderStatusCompleted, Items: []types.OrderItem{{ProductID: 7, Quantity: 1}, {ProductID: 8, Quantity: 2}}},
+		2: {ID: 2, MerchantID: 10, CustomerID: 100, Status: types.OrderStatusPaid, Items: []types.OrderItem{{ProductID: 7, Quantity: 1}}},
+		3: {ID: 3, MerchantID: 10, CustomerID: 200, Status: types.OrderStatusCompleted, Items: []types.OrderItem{{ProductID: 7, Quantity: 1}}},
+	}}
+	reviewRepo := &memoryReviewRepository{}
+	return service.NewProductReviewServiceForTest(orderRepo, reviewRepo), reviewRepo
+}
+
+func customerContext(customerID uint64) context.Context {
+	return context.WithValue(context.WithValue(context.Background(), "tenant_id", uint64(1)), "user_id", customerID)
+}
+
+func TestProductReviewService_OnlyPurchasersCanReview(t *testing.T) {
+	reviewService, _ := newReviewTestService()
+	ctx := customerContext(100)
+
+	review, err := reviewService.SubmitReview(ctx, 7, &types.CreateProductReviewRequest{OrderID: 1, Rating: 5, Comment: " 很好 "})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), review.MerchantID)
+	assert.Equal(t, uint64(100), review.CustomerID)
+	assert.Equal(t, "很好", review.Comment)
+
+	// 他人的订单
+	_, err = reviewService.SubmitReview(customerContext(200), 8, &types.CreateProductReviewRequest{OrderID: 1, Rating: 1})
+	assert.ErrorIs(t, err, service.ErrReviewNotPurchaser)
+
+	// 订单未完成
+	_, err = reviewService.SubmitReview(ctx, 7, &types.CreateProductReviewRequest{OrderID: 2, Rating: 4})
+	assert.ErrorIs(t, err, service.ErrReviewNotPurchaser)
+
+	// 订单中没有该商品
+	_, err = reviewService.SubmitReview(ctx, 9, &types.CreateProductReviewRequest{OrderID: 1, Rating: 4})
+	assert.ErrorIs(t, err, service.ErrReviewNotPurchaser)
+
+	// 订单不存在
+	_, err = reviewService.SubmitReview(ctx, 7, &types.CreateProductReviewRequest{OrderID: 99, Rating: 4})
+	assert.ErrorIs(t, err, service.ErrReviewNotPurchaser)
+
+	// 未登录
+	_, err = reviewService.SubmitReview(context.Background(), 7, &types.CreateProductReviewRequest{OrderID: 1, Rating: 4})
+	assert.ErrorIs(t, err, service.ErrReviewNotPurchaser)
+}
+
+func TestProductReviewService_OneReviewPerOrderItem(t *testing.T) {
+	reviewService, _ := newReviewTestService()
+	ctx := customerContext(100)
+
+	_, err := reviewService.SubmitReview(ctx, 7, &types.CreateProductReviewRequest{OrderID: 1, Rating: 5})
+	require.NoError(t, err)
+
+	_, err = reviewService.SubmitReview(ctx, 7, &types.CreateProductReviewRequest{OrderID: 1, Rating: 3})
+	assert.ErrorIs(t, err, service.ErrReviewAlreadyExists)
+
+	// 同一订单中的其他商品可以单独评价
+	_, err = reviewService.SubmitReview(ctx, 8, &types.CreateProductReviewRequest{OrderID: 1, Rating: 3})
+	assert.NoError(t, err)
+}
+
+func TestProductReviewService_InvalidRequest(t *testing.T) {
+	reviewService, _ := newReviewTestService()
+	ctx := customerContext(100)
+
+	_, err := reviewService.SubmitReview(ctx, 7, &types.CreateProductReviewRequest{OrderID: 1, Rating: 0})
+	assert.Error(t, err)
+	_, err = reviewService.SubmitReview(ctx, 7, &types.CreateProductReviewRequest{OrderID: 1, Rating: 6})
+	assert.Error(t, err)
+	_, err = reviewService.SubmitReview(ctx, 7, &types.CreateProductReviewRequest{Rating: 5})
+	assert.Error(t, err)
+}
+
+func TestProductReviewService_RatingAggregation(t *testing.T) {
+	reviewService, _ := newReviewTestService()
+
+	_, err := reviewService.SubmitReview(customerContext(100), 7, &types.CreateProductReviewRequest{OrderID: 1, Rating: 5})
+	require.NoError(t, err)
+	_, err = reviewService.SubmitReview(customerContext(200), 7, &types.CreateProductReviewRequest{OrderID: 3, Rating: 2})
+	require.NoError(t, err)
+
+	result, err := reviewService.ListReviews(customerContext(100), 7, false, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Total)
+	assert.Equal(t, 2, result.Summary.ReviewCount)
+	assert.Equal(t, 3.5, result.Summary.AverageRating)
+	assert.Equal(t, map[int]int{5: 1, 2: 1}, result.Summary.Distribution)
+
+	// 没有评价的商品
+	summary, err := reviewService.GetRatingSummary(context.Background(), 8)
+	require.NoError(t, err)
+	assert.Equal(t, 0, summary.ReviewCount)
+	assert.Equal(t, 0.0, summary.AverageRating)
+}
+
+func TestNewProductRatingSummary(t *testing.T) {
+	summary := types.NewProductRatingSummary(7, map[int]int{5: 3, 4: 1, 1: 1})
+	assert.Equal(t, 5, summary.ReviewCount)
+	assert.Equal(t, 4.0, summary.AverageRating)
+
+	// 平均分保留一位小数：(5+4+4)/3 = 4.333...
+	summary = types.NewProductRatingSummary(7, map[int]int{5: 1, 4: 2})
+	assert.Equal(t, 4.3, summary.AverageRating)
+
+	// 超出范围的评分被忽略
+	summary = types.NewProductRatingSummary(7, map[int]int{0: 2, 6: 1, 3: 1})
+	assert.Equal(t, 1, summary.ReviewCount)
+	assert.Equal(t, 3.0, summary.AverageRating)
+	assert.Equal(t, map[int]int{3: 1}, summary.Distribution)
+}
+
+func TestProductReviewService_FlagReview(t *testing.T) {
+	reviewService, reviewRepo := newReviewTestService()
+
+	_, err := reviewService.SubmitReview(customerContext(100), 7, &types.CreateProductReviewRequest{OrderID: 1, Rating: 1, Comment: "不当内容"})
+	require.NoError(t, err)
+	_, err = reviewService.SubmitReview(customerContext(200), 7, &types.CreateProductReviewRequest{OrderID: 3, Rating: 5})
+	require.NoError(t, err)
+
+	merchantCtx := context.WithValue(customerContext(9), "merchant_id", uint64(10))
+
+	// 标记必须填写原因
+	_, err = reviewService.FlagReview(merchantCtx, 7, 1, &types.FlagProductReviewRequest{Flagged: true})
+	assert.Error(t, err)
+
+	// 其他商户不能处理
+	otherMerchantCtx := context.WithValue(customerContext(9), "merchant_id", uint64(11))
+	_, err = reviewService.FlagReview(otherMerchantCtx, 7, 1, &types.FlagProductReviewRequest{Flagged: true, Reason: "辱骂"})
+	assert.ErrorIs(t, err, service.ErrReviewForbidden)
+
+	// 评价不属于该商品
+	_, err = reviewService.FlagReview(merchantCtx, 8, 1, &types.FlagProductReviewRequest{Flagged: true, Reason: "辱骂"})
+	assert.Error(t, err)
+
+	review, err := reviewService.FlagReview(merchantCtx, 7, 1, &types.FlagProductReviewRequest{Flagged: true, Reason: "辱骂"})
+	require.NoError(t, err)
+	assert.True(t, review.Flagged)
+	assert.Equal(t, "辱骂", review.FlagReason)
+
+	// 被标记的评价不对外展示，也不计入评分
+	result, err := reviewService.ListReviews(context.Background(), 7, false, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+	assert.Equal(t, 1, result.Summary.ReviewCount)
+	assert.Equal(t, 5.0, result.Summary.AverageRating)
+
+	result, err = reviewService.ListReviews(context.Background(), 7, true, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Total)
+
+	// 取消标记后恢复计入评分
+	review, err = reviewService.FlagReview(merchantCtx, 7, 1, &types.FlagProductReviewRequest{Flagged: false, Reason: "误标"})
+	require.NoError(t, err)
+	assert.False(t, review.Flagged)
+	assert.Empty(t, reviewRepo.reviews[0].FlagReason)
+
+	summary, err := reviewService.GetRatingSummary(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, summary.AverageRating)
+}
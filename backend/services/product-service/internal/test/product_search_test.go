@@ -0,0 +1,116 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// memorySearchRepository 内存候选商品仓储，按名称模糊匹配任一查询词
+type memorySearchRepository struct {
+	docs  []types.ProductSearchDocument
+	terms []string
+}
+
+func (f *memorySearchRepository) ListSearchCandidates(ctx context.Context, terms []string, categoryID *uint64, limit int) ([]types.ProductSearchDocument, error) {
+	f.terms = terms
+	var docs []types.ProductSearchDocument
+	for _, doc := range f.docs {
+		for _, term := range terms {
+			if strings.Contains(strings.ToLower(doc.Product.Name), strings.ToLower(term)) {
+				docs = append(docs, doc)
+				break
+			}
+		}
+	}
+	return docs, nil
+}
+
+// memorySynonymRepository 内存同义词词典仓储
+type memorySynonymRepository struct {
+	dictionary *types.ProductSynonymDictionary
+}
+
+func (f *memorySynonymRepository) GetDictionary(ctx context.Context) (*types.ProductSynonymDictionary, error) {
+	if f.dictionary == nil {
+		return &types.ProductSynonymDictionary{}, nil
+	}
+	return f.dictionary, nil
+}
+
+func (f *memorySynonymRepository) SaveDictionary(ctx context.Context, dictionary *types.ProductSynonymDictionary) error {
+	f.dictionary = dictionary
+	return nil
+}
+
+func newSearchFixture() *memorySearchRepository {
+	return &memorySearchRepository{docs: []types.ProductSearchDocument{
+		{Product: types.Product{ID: 1, Name: "青苹果"}},
+		{Product: types.Product{ID: 2, Name: "苹果汁"}},
+		{Product: types.Product{ID: 3, Name: "苹果"}},
+		{Product: types.Product{ID: 4, Name: "Apple"}},
+		{Product: types.Product{ID: 5, Name: "香蕉"}},
+	}}
+}
+
+func hitIDs(result *types.ProductSearchResponse) []uint64 {
+	var ids []uint64
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestProductSearch_ExactRanksAbovePartial(t *testing.T) {
+	engine := service.NewSQLProductSearchEngine(newSearchFixture(), nil)
+	searchService := service.NewProductSearchServiceForTest(engine, &memorySynonymRepository{})
+
+	result, err := searchService.Search(context.Background(), &types.ProductSearchRequest{Keyword: "苹果"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+	// 完全匹配 > 前缀匹配 > 包含匹配
+	assert.Equal(t, []uint64{3, 2, 1}, hitIDs(result))
+	assert.Greater(t, result.Hits[0].Score, result.Hits[1].Score)
+	assert.Greater(t, result.Hits[1].Score, result.Hits[2].Score)
+}
+
+func TestProductSearch_SynonymsExpandQuery(t *testing.T) {
+	repo := newSearchFixture()
+	synonyms := &memorySynonymRepository{}
+	searchService := service.NewProductSearchServiceForTest(service.NewSQLProductSearchEngine(repo, nil), synonyms)
+	ctx := context.Background()
+
+	require.NoError(t, searchService.SaveSynonyms(ctx, &types.ProductSynonymDictionary{
+		Groups: [][]string{{"苹果", " Apple ", "apple"}},
+	}))
+	assert.Equal(t, [][]string{{"苹果", "Apple"}}, synonyms.dictionary.Groups)
+
+	result, err := searchService.Search(ctx, &types.ProductSearchRequest{Keyword: "苹果"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"苹果", "Apple"}, repo.terms)
+	// 同义词命中的商品被召回，但排在原词完全匹配之后
+	assert.Equal(t, []uint64{3, 4, 2, 1}, hitIDs(result))
+	assert.Equal(t, []string{"Apple"}, result.Hits[1].MatchedTerms)
+}
+
+func TestProductSearch_Pagination(t *testing.T) {
+	searchService := service.NewProductSearchServiceForTest(service.NewSQLProductSearchEngine(newSearchFixture(), nil), &memorySynonymRepository{})
+
+	result, err := searchService.Search(context.Background(), &types.ProductSearchRequest{Keyword: "苹果", Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+	assert.Equal(t, []uint64{1}, hitIDs(result))
+}
+
+func TestProductSearch_InvalidSynonyms(t *testing.T) {
+	searchService := service.NewProductSearchServiceForTest(service.NewSQLProductSearchEngine(newSearchFixture(), nil), &memorySynonymRepository{})
+
+	err := searchService.SaveSynonyms(context.Background(), &types.ProductSynonymDictionary{Groups: [][]string{{"苹果"}}})
+	assert.ErrorIs(t, err, types.ErrInvalidSynonymGroup)
+}
@@ -2,8 +2,13 @@ package main
 
 import (
 	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/controller"
+	"github.com/gofromzero/mer-sys/backend/services/product-service/internal/service"
 	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/handlers"
+	"github.com/gofromzero/mer-sys/backend/shared/maintenance"
 	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/os/gctx"
@@ -16,41 +21,108 @@ func main() {
 	auth.NewJWTManager()
 	ctx := gctx.GetInitCtx()
 
+	// 数据驻留配置映射了未定义的数据库分组时拒绝启动，避免租户数据落入共享数据库
+	if err := repository.InitTenantDatasourceConfig(ctx); err != nil {
+		g.Log().Fatalf(ctx, "数据驻留配置校验失败: %v", err)
+	}
+
 	s := g.Server()
 
+	// 应用连接池配置并暴露连接池指标
+	handlers.RegisterDBPoolMetrics(s, "product-service")
+
 	// 创建中间件实例
 	authMiddleware := middleware.NewAuthMiddleware()
 
+	// 维护模式下拒绝写请求，状态在各服务间共享
+	maintenanceManager := maintenance.NewManager()
+	maintenanceGuard := middleware.MaintenanceMode(maintenanceManager)
+
 	// 创建控制器
 	productController := controller.NewProductController()
 	categoryController := controller.NewCategoryController()
+	reviewController := controller.NewProductReviewController()
+	searchController := controller.NewProductSearchController()
+	bundleController := controller.NewProductBundleController()
+	inventoryController := controller.NewInventoryController()
+
+	// 启动商品定时上下架任务
+	availabilityScheduler := service.NewProductAvailabilityScheduler()
+	availabilityScheduler.Start(ctx)
+	defer availabilityScheduler.Stop(ctx)
+
+	// 启动商品搭配推荐计算任务，推荐接口与任务共用服务
+	recommendationService := service.NewProductRecommendationService()
+	recommendationService.Start(ctx)
+	defer recommendationService.Stop(ctx)
+	recommendationController := controller.NewProductRecommendationController(recommendationService)
 
 	// 注册路由
 	s.Group("/api/v1", func(group *ghttp.RouterGroup) {
+		// 记录访问日志，配置的路由按采样记录脱敏后的请求/响应体
+		group.Middleware(middleware.AccessLog(middleware.LoadAccessLogConfig(ctx)))
+		// 支持 fields 参数只返回指定的响应字段
+		group.Middleware(middleware.ResponseFields(middleware.LoadResponseFieldsConfig(ctx)))
+
 		// 商品路由（需要认证和商户权限）
 		group.Group("/products", func(productGroup *ghttp.RouterGroup) {
 			// 添加认证和商户权限中间件
-			productGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation)
+			productGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard)
 			
 			productGroup.POST("/", productController.CreateProduct)
 			productGroup.GET("/", productController.ListProducts)
+			productGroup.GET("/search", searchController.Search)
+			productGroup.GET("/search/synonyms", searchController.GetSynonyms)
+			productGroup.PUT("/search/synonyms",
+				authMiddleware.RequireAnyPermission(types.PermissionProductManage, types.PermissionProductUpdate),
+				searchController.SaveSynonyms)
 			productGroup.GET("/:id", productController.GetProduct)
 			productGroup.PUT("/:id", productController.UpdateProduct)
 			productGroup.DELETE("/:id", productController.DeleteProduct)
 			productGroup.PATCH("/:id/status", productController.UpdateProductStatus)
 			productGroup.POST("/:id/images", productController.UploadImage)
 			productGroup.GET("/:id/history", productController.GetProductHistory)
+			productGroup.GET("/:id/recommendations", recommendationController.GetRecommendations)
 			productGroup.POST("/batch", productController.BatchOperation)
+
+			// 预订订单补货释放（关闭自动释放时由商户补货后手动释放）
+			productGroup.POST("/:id/inventory/backorders/release",
+				authMiddleware.RequireAnyPermission(types.PermissionProductUpdate, types.PermissionMerchantProductEdit),
+				inventoryController.ReleaseBackorders)
+
+			// 商品评价路由（标记不当评价仅限拥有商品编辑权限的员工）
+			productGroup.POST("/:id/reviews", reviewController.SubmitReview)
+			productGroup.GET("/:id/reviews", reviewController.ListReviews)
+			productGroup.PATCH("/:id/reviews/:review_id/flag",
+				authMiddleware.RequireAnyPermission(types.PermissionProductUpdate, types.PermissionMerchantProductEdit),
+				reviewController.FlagReview)
+		})
+
+		// 商品套餐路由（创建和上下架仅限拥有商品管理权限的用户）
+		group.Group("/bundles", func(bundleGroup *ghttp.RouterGroup) {
+			bundleGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard)
+
+			bundleGroup.GET("/", bundleController.ListBundles)
+			bundleGroup.GET("/:id", bundleController.GetBundle)
+			bundleGroup.POST("/",
+				authMiddleware.RequireAnyPermission(types.PermissionProductManage, types.PermissionProductCreate, types.PermissionMerchantProductCreate),
+				bundleController.CreateBundle)
+			bundleGroup.PATCH("/:id/status",
+				authMiddleware.RequireAnyPermission(types.PermissionProductManage, types.PermissionProductUpdate, types.PermissionMerchantProductEdit),
+				bundleController.UpdateBundleStatus)
 		})
 
 		// 分类路由（需要认证和商户权限）
 		group.Group("/categories", func(categoryGroup *ghttp.RouterGroup) {
 			// 添加认证和商户权限中间件
-			categoryGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation)
+			categoryGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard)
 			
 			categoryGroup.POST("/", categoryController.CreateCategory)
 			categoryGroup.GET("/", categoryController.GetCategoryList)
 			categoryGroup.GET("/tree", categoryController.GetCategoryTree)
+			categoryGroup.POST("/batch",
+				authMiddleware.RequireAnyPermission(types.PermissionProductManage, types.PermissionProductUpdate),
+				categoryController.BatchOperation)
 			categoryGroup.GET("/:id", categoryController.GetCategory)
 			categoryGroup.PUT("/:id", categoryController.UpdateCategory)
 			categoryGroup.DELETE("/:id", categoryController.DeleteCategory)
@@ -61,9 +133,11 @@ func main() {
 
 	// 健康检查端点
 	s.BindHandler("/health", func(r *ghttp.Request) {
+		maintenanceStatus, _ := maintenanceManager.Status(r.Context(), 0)
 		r.Response.WriteJsonExit(g.Map{
-			"status":  "healthy",
-			"service": "product-service",
+			"status":      "healthy",
+			"service":     "product-service",
+			"maintenance": maintenanceStatus,
 		})
 	})
 
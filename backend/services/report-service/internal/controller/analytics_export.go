@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofromzero/mer-sys/backend/services/report-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/export"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// AnalyticsExportController 分析数据导出控制器
+type AnalyticsExportController struct {
+	exportService *service.AnalyticsExportService
+}
+
+// NewAnalyticsExportController 创建分析数据导出控制器实例
+func NewAnalyticsExportController(exportService *service.AnalyticsExportService) *AnalyticsExportController {
+	return &AnalyticsExportController{
+		exportService: exportService,
+	}
+}
+
+// ExportFacts 创建规范化的分析事实数据导出任务
+// @Summary 导出分析事实数据
+// @Description 将财务、商户运营或客户分析数据展开为每行一个指标的事实数据（日期、商户、类别、指标、值），供BI工具加载；时间范围不能超过租户套餐下该数据集的上限。导出任务进入导出队列后台生成，通过 /analytics/facts/exports/{id} 查询进度并下载
+// @Tags 数据分析
+// @Produce json
+// @Param dataset query string true "数据集" Enums(financial, merchant_operation, customer_analysis)
+// @Param start_date query string true "开始日期" format(date)
+// @Param end_date query string true "结束日期" format(date)
+// @Param format query string false "文件格式" Enums(csv, jsonl) default(csv)
+// @Success 202 {object} utils.Response{data=types.ExportJob} "已创建导出任务"
+// @Failure 400 {object} utils.Response
+// @Failure 429 {object} utils.Response "超出当天导出用量"
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/analytics/facts [get]
+func (c *AnalyticsExportController) ExportFacts(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	startDate, err := parseDate(r.Get("start_date").String())
+	if err != nil {
+		utils.ErrorResponse(r, 400, "开始日期格式无效")
+		return
+	}
+	endDate, err := parseDate(r.Get("end_date").String())
+	if err != nil {
+		utils.ErrorResponse(r, 400, "结束日期格式无效")
+		return
+	}
+
+	query := &types.AnalyticsFactsExportQuery{
+		Dataset:   types.ReportType(r.Get("dataset").String()),
+		Format:    types.AnalyticsFactFormat(r.Get("format").String()),
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+	if err := c.exportService.PrepareFactsExport(ctx, query); err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+
+	job, err := c.exportService.CreateFactsExport(ctx, query)
+	if err != nil {
+		writeExportError(r, err)
+		return
+	}
+	r.Response.WriteStatus(http.StatusAccepted)
+	utils.SuccessResponse(r, job)
+}
+
+// GetExport 获取分析事实数据导出任务状态
+// @Summary 获取分析事实数据导出任务
+// @Description 查询导出任务状态（queued/running/completed/failed），只有导出发起人可以查看
+// @Tags 数据分析
+// @Produce json
+// @Param id path string true "导出任务ID"
+// @Success 200 {object} utils.Response{data=types.ExportJob}
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/analytics/facts/exports/{id} [get]
+func (c *AnalyticsExportController) GetExport(r *ghttp.Request) {
+	job, err := c.exportService.GetFactsExport(r.GetCtx(), r.Get("id").String())
+	if err != nil {
+		writeExportError(r, err)
+		return
+	}
+	utils.SuccessResponse(r, job)
+}
+
+// DownloadExport 下载分析事实数据导出文件
+// @Summary 下载分析事实数据导出文件
+// @Tags 数据分析
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param id path string true "导出任务ID"
+// @Success 200 {file} file "导出文件"
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response "文件尚未生成完成"
+// @Failure 410 {object} utils.Response "文件已过期"
+// @Router /api/v1/analytics/facts/exports/{id}/download [get]
+func (c *AnalyticsExportController) DownloadExport(r *ghttp.Request) {
+	job, err := c.exportService.GetFactsExportFile(r.GetCtx(), r.Get("id").String())
+	if err != nil {
+		writeExportError(r, err)
+		return
+	}
+
+	r.Response.Header().Set("Content-Type", types.AnalyticsFactFormat(job.Format).ContentType())
+	r.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment;filename=%s`, job.FileName))
+	r.Response.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
+	r.Response.ServeFile(job.FilePath)
+}
+
+// writeExportError 按错误类型返回导出错误
+func writeExportError(r *ghttp.Request, err error) {
+	switch {
+	case errors.Is(err, export.ErrJobNotFound):
+		utils.ErrorResponse(r, 404, err.Error())
+	case errors.Is(err, export.ErrJobNotReady):
+		utils.ErrorResponse(r, 409, err.Error())
+	case errors.Is(err, export.ErrJobExpired):
+		utils.ErrorResponse(r, 410, err.Error())
+	case errors.Is(err, export.ErrDailyJobLimitExceeded), errors.Is(err, export.ErrDailySizeLimitExceeded):
+		utils.ErrorResponse(r, 429, err.Error())
+	default:
+		g.Log().Error(r.GetCtx(), "导出任务操作失败", "error", err)
+		utils.ErrorResponse(r, 500, "导出分析事实数据失败")
+	}
+}
@@ -1,7 +1,10 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -16,6 +19,7 @@ import (
 type ReportController struct {
 	generatorService service.IReportGeneratorService
 	analyticsService service.IAnalyticsService
+	downloadService  *service.ReportDownloadService
 }
 
 // NewReportController 创建报表控制器实例
@@ -23,6 +27,7 @@ func NewReportController() *ReportController {
 	return &ReportController{
 		generatorService: service.NewReportGeneratorService(),
 		analyticsService: service.NewAnalyticsService(),
+		downloadService:  service.NewReportDownloadService(),
 	}
 }
 
@@ -33,8 +38,9 @@ func NewReportController() *ReportController {
 // @Accept json
 // @Produce json
 // @Param request body types.ReportCreateRequest true "报表生成参数"
-// @Success 200 {object} utils.Response{data=types.Report}
+// @Success 200 {object} utils.Response{data=types.Report} "生成中或排队中（queue_position 为预计排队位置）"
 // @Failure 400 {object} utils.Response
+// @Failure 429 {object} utils.Response "排队中的报表过多"
 // @Failure 500 {object} utils.Response
 // @Router /api/v1/reports/generate [post]
 func (c *ReportController) GenerateReport(r *ghttp.Request) {
@@ -47,6 +53,10 @@ func (c *ReportController) GenerateReport(r *ghttp.Request) {
 	}
 	
 	report, err := c.generatorService.GenerateReport(ctx, &req)
+	if errors.Is(err, service.ErrReportQueueFull) {
+		utils.ErrorResponse(r, 429, err.Error())
+		return
+	}
 	if err != nil {
 		g.Log().Error(ctx, "生成报表失败", "error", err)
 		utils.ErrorResponse(r, 500, "生成报表失败")
@@ -195,15 +205,23 @@ func (c *ReportController) DownloadReport(r *ghttp.Request) {
 		return
 	}
 	
-	filePath, err := c.generatorService.DownloadReport(ctx, uuid)
+	report, err := c.generatorService.DownloadReport(ctx, uuid)
 	if err != nil {
 		g.Log().Error(ctx, "下载报表失败", "uuid", uuid, "error", err)
 		utils.ErrorResponse(r, 404, "报表文件不存在或未生成完成")
 		return
 	}
 	
-	// 返回文件
-	r.Response.ServeFileDownload(filePath)
+	c.downloadService.RecordDirectDownload(ctx, report, downloadRequester(r))
+	serveReportFile(r, report)
+}
+
+// serveReportFile 返回报表文件，按报表实际文件格式返回内容类型，PDF转换失败按HTML交付的报表返回HTML
+func serveReportFile(r *ghttp.Request, report *types.Report) {
+	r.Response.Header().Set("Content-Type", report.FileFormat.ContentType())
+	r.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment;filename=%s`, url.QueryEscape(filepath.Base(report.FilePath))))
+	r.Response.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
+	r.Response.ServeFile(report.FilePath)
 }
 
 // GetFinancialAnalytics 获取财务分析数据
@@ -338,6 +356,62 @@ func (c *ReportController) GetCustomerAnalytics(r *ghttp.Request) {
 	utils.SuccessResponse(r, data)
 }
 
+// GetMerchantSummary 获取商户汇总数据
+// @Summary 获取商户汇总数据
+// @Description 一次返回单个商户的收入、订单数、客户数、客单价、权益消耗及与上一周期的增长率
+// @Tags 数据分析
+// @Accept json
+// @Produce json
+// @Param merchant_id query uint64 true "商户ID"
+// @Param start query string true "开始日期" format(date)
+// @Param end query string true "结束日期（含当天）" format(date)
+// @Success 200 {object} utils.Response{data=types.MerchantSummary}
+// @Failure 400 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/analytics/merchant-summary [get]
+func (c *ReportController) GetMerchantSummary(r *ghttp.Request) {
+	ctx := r.GetCtx()
+	
+	merchantID, err := strconv.ParseUint(r.Get("merchant_id").String(), 10, 64)
+	if err != nil || merchantID == 0 {
+		utils.ErrorResponse(r, 400, "商户ID格式无效")
+		return
+	}
+	
+	startDate, err := parseDate(r.Get("start").String())
+	if err != nil {
+		utils.ErrorResponse(r, 400, "开始日期格式无效")
+		return
+	}
+	
+	endDate, err := parseDate(r.Get("end").String())
+	if err != nil {
+		utils.ErrorResponse(r, 400, "结束日期格式无效")
+		return
+	}
+	
+	// 结束日期包含当天
+	data, err := c.analyticsService.GetMerchantSummary(ctx, merchantID, startDate, endDate.AddDate(0, 0, 1))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMerchantSummaryInvalidRange):
+			utils.ErrorResponse(r, 400, err.Error())
+		case errors.Is(err, service.ErrMerchantSummaryForbidden):
+			utils.ErrorResponse(r, 403, err.Error())
+		case errors.Is(err, service.ErrMerchantSummaryNotFound):
+			utils.ErrorResponse(r, 404, err.Error())
+		default:
+			g.Log().Error(ctx, "获取商户汇总数据失败", "merchant_id", merchantID, "error", err)
+			utils.ErrorResponse(r, 500, "获取商户汇总数据失败")
+		}
+		return
+	}
+	
+	utils.SuccessResponse(r, data)
+}
+
 // CustomQuery 自定义数据查询
 // @Summary 自定义数据查询
 // @Description 执行自定义的数据分析查询
@@ -465,6 +539,59 @@ func (c *ReportController) ClearCache(r *ghttp.Request) {
 	})
 }
 
+// RunQueryChecks 校验分析查询
+// @Summary 校验分析查询
+// @Description 对指定租户执行所有已注册的分析查询，检查结果结构和基本约束（计数非负、百分比合计约为100、必需字段存在），并列出被降级处理的子查询错误。仅限运维人员使用
+// @Tags 数据分析
+// @Produce json
+// @Param start_date query string false "开始日期，默认30天前" format(date)
+// @Param end_date query string false "结束日期，默认今天" format(date)
+// @Param tenant_id query uint64 false "租户ID，默认当前租户"
+// @Param merchant_id query uint64 false "商户ID，为空时跳过商户级检查"
+// @Success 200 {object} utils.Response{data=types.AnalyticsCheckReport}
+// @Failure 400 {object} utils.Response
+// @Router /api/v1/analytics/diagnostics/query-checks [get]
+func (c *ReportController) RunQueryChecks(r *ghttp.Request) {
+	ctx := r.GetCtx()
+	
+	req := &types.AnalyticsCheckRequest{
+		TenantID:  r.Get("tenant_id", ctx.Value("tenant_id")).Uint64(),
+		StartDate: time.Now().AddDate(0, 0, -30),
+		EndDate:   time.Now(),
+	}
+	if req.TenantID == 0 {
+		utils.ErrorResponse(r, 400, "租户ID不能为空")
+		return
+	}
+	
+	if value := r.Get("start_date").String(); value != "" {
+		startDate, err := parseDate(value)
+		if err != nil {
+			utils.ErrorResponse(r, 400, "开始日期格式无效")
+			return
+		}
+		req.StartDate = startDate
+	}
+	if value := r.Get("end_date").String(); value != "" {
+		endDate, err := parseDate(value)
+		if err != nil {
+			utils.ErrorResponse(r, 400, "结束日期格式无效")
+			return
+		}
+		req.EndDate = endDate
+	}
+	if value := r.Get("merchant_id").String(); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.ErrorResponse(r, 400, "商户ID格式无效")
+			return
+		}
+		req.MerchantID = &id
+	}
+	
+	utils.SuccessResponse(r, c.analyticsService.RunQueryChecks(ctx, req))
+}
+
 // parseDate 解析日期字符串
 func parseDate(dateStr string) (time.Time, error) {
 	// 支持多种日期格式
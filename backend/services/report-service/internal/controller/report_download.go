@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/gofromzero/mer-sys/backend/services/report-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// ReportDownloadController 报表签名下载控制器
+type ReportDownloadController struct {
+	downloadService *service.ReportDownloadService
+}
+
+// NewReportDownloadController 创建报表签名下载控制器实例
+func NewReportDownloadController(downloadService *service.ReportDownloadService) *ReportDownloadController {
+	return &ReportDownloadController{
+		downloadService: downloadService,
+	}
+}
+
+// IssueDownloadURL 签发报表下载链接
+// @Summary 签发报表下载链接
+// @Description 为已生成完成的报表签发有时效的下载链接。本地交付时链接由报表服务校验签名后返回文件，可按配置绑定申请链接的IP；对象存储交付时返回对象存储签名链接，下载不经过报表服务
+// @Tags 报表管理
+// @Produce json
+// @Param uuid path string true "报表UUID"
+// @Success 200 {object} utils.Response{data=types.ReportDownloadURL}
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Failure 503 {object} utils.Response "未配置签名密钥"
+// @Router /api/v1/reports/{uuid}/download-url [get]
+func (c *ReportDownloadController) IssueDownloadURL(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	uuid := r.Get("uuid").String()
+	if uuid == "" {
+		utils.ErrorResponse(r, 400, "报表UUID不能为空")
+		return
+	}
+
+	downloadURL, err := c.downloadService.IssueDownloadURL(ctx, uuid, downloadRequester(r))
+	if err != nil {
+		if errors.Is(err, types.ErrReportDownloadSecretMissing) {
+			g.Log().Error(ctx, "签发报表下载链接失败", "uuid", uuid, "error", err)
+			utils.ErrorResponse(r, 503, "报表下载链接暂不可用")
+			return
+		}
+		g.Log().Error(ctx, "签发报表下载链接失败", "uuid", uuid, "error", err)
+		utils.ErrorResponse(r, 404, "报表文件不存在或未生成完成")
+		return
+	}
+	utils.SuccessResponse(r, downloadURL)
+}
+
+// SignedDownload 通过签名链接下载报表
+// @Summary 通过签名链接下载报表
+// @Description 校验签名、有效期和绑定的IP后返回报表文件，不需要认证
+// @Tags 报表管理
+// @Produce application/octet-stream
+// @Param uuid path string true "报表UUID"
+// @Param tenant_id query int true "租户ID"
+// @Param user_id query int false "签发链接的用户ID"
+// @Param expires query int true "到期时间（Unix秒）"
+// @Param ip query string false "绑定的IP"
+// @Param signature query string true "签名"
+// @Success 200 {file} file
+// @Failure 403 {object} utils.Response "签名无效、链接已过期或IP不一致"
+// @Failure 404 {object} utils.Response
+// @Failure 503 {object} utils.Response "未配置签名密钥"
+// @Router /api/v1/report-downloads/{uuid} [get]
+func (c *ReportDownloadController) SignedDownload(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	link, err := service.ParseReportDownloadLink(r.Get("uuid").String(), r.URL.Query())
+	if err != nil {
+		utils.ErrorResponse(r, 403, err.Error())
+		return
+	}
+
+	report, err := c.downloadService.OpenSignedDownload(ctx, link, downloadRequester(r))
+	if err != nil {
+		if errors.Is(err, types.ErrReportDownloadSecretMissing) {
+			g.Log().Error(ctx, "签名链接下载报表失败", "uuid", link.ReportUUID, "error", err)
+			utils.ErrorResponse(r, 503, "报表下载链接暂不可用")
+			return
+		}
+		if errors.Is(err, types.ErrReportDownloadSignatureInvalid) ||
+			errors.Is(err, types.ErrReportDownloadURLExpired) ||
+			errors.Is(err, types.ErrReportDownloadIPMismatch) {
+			utils.ErrorResponse(r, 403, err.Error())
+			return
+		}
+		g.Log().Error(ctx, "签名链接下载报表失败", "uuid", link.ReportUUID, "error", err)
+		utils.ErrorResponse(r, 404, "报表文件不存在或未生成完成")
+		return
+	}
+	serveReportFile(r, report)
+}
+
+// ListDownloadLogs 获取报表下载记录
+// @Summary 获取报表下载记录
+// @Description 按时间倒序返回报表最近100条下载记录：谁在何时通过哪种方式下载。对象存储交付时记录的是签发链接
+// @Tags 报表管理
+// @Produce json
+// @Param uuid path string true "报表UUID"
+// @Success 200 {object} utils.Response{data=[]types.ReportDownloadLog}
+// @Failure 404 {object} utils.Response
+// @Router /api/v1/reports/{uuid}/download-logs [get]
+func (c *ReportDownloadController) ListDownloadLogs(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	logs, err := c.downloadService.ListDownloadLogs(ctx, r.Get("uuid").String())
+	if err != nil {
+		utils.ErrorResponse(r, 404, err.Error())
+		return
+	}
+	utils.SuccessResponse(r, logs)
+}
+
+// downloadRequester 下载请求方，未认证的请求没有用户ID
+func downloadRequester(r *ghttp.Request) *service.ReportDownloadRequester {
+	requester := &service.ReportDownloadRequester{
+		// 链接绑定的IP不能取自客户端可随意伪造的转发头
+		ClientIP:  middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+	if userID := gconv.Uint64(r.GetCtx().Value("user_id")); userID != 0 {
+		requester.UserID = &userID
+	}
+	return requester
+}
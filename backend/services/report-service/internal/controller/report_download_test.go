@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadRequester(t *testing.T) {
+	t.Run("客户端携带的转发头不能改变绑定校验使用的IP", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/reports/download", nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		req.Header.Set("X-Forwarded-For", "10.0.0.1")
+		req.Header.Set("User-Agent", "curl")
+
+		requester := downloadRequester(&ghttp.Request{Request: req})
+		assert.Equal(t, "203.0.113.7", requester.ClientIP)
+		assert.Equal(t, "curl", requester.UserAgent)
+		assert.Nil(t, requester.UserID)
+	})
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/report-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// ReportJobController 定时报表执行记录控制器
+type ReportJobController struct {
+	schedulerService service.ISchedulerService
+}
+
+// NewReportJobController 创建定时报表执行记录控制器实例
+func NewReportJobController(schedulerService service.ISchedulerService) *ReportJobController {
+	return &ReportJobController{
+		schedulerService: schedulerService,
+	}
+}
+
+// ListJobs 获取最近的定时报表执行任务
+// @Summary 获取定时报表执行任务列表
+// @Description 获取最近的定时报表执行任务，可按模板和状态筛选
+// @Tags 报表模板
+// @Accept json
+// @Produce json
+// @Param template_id query uint64 false "模板ID"
+// @Param status query string false "任务状态" Enums(pending, running, completed, failed)
+// @Param limit query int false "返回条数" default(20)
+// @Success 200 {object} utils.Response{data=[]types.ReportJob}
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/report-jobs [get]
+func (c *ReportJobController) ListJobs(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	req := &types.ReportJobListRequest{
+		Limit: r.Get("limit", 20).Int(),
+	}
+
+	if templateIDStr := r.Get("template_id").String(); templateIDStr != "" {
+		templateID, err := strconv.ParseUint(templateIDStr, 10, 64)
+		if err != nil {
+			utils.ErrorResponse(r, 400, "无效的模板ID")
+			return
+		}
+		req.TemplateID = &templateID
+	}
+
+	if statusStr := r.Get("status").String(); statusStr != "" {
+		status := types.JobStatus(statusStr)
+		switch status {
+		case types.JobStatusPending, types.JobStatusRunning, types.JobStatusCompleted, types.JobStatusFailed:
+			req.Status = &status
+		default:
+			utils.ErrorResponse(r, 400, "无效的任务状态")
+			return
+		}
+	}
+
+	jobs, err := c.schedulerService.ListJobRuns(ctx, req)
+	if err != nil {
+		g.Log().Error(ctx, "获取报表任务列表失败", "error", err)
+		utils.ErrorResponse(r, 500, "获取报表任务列表失败")
+		return
+	}
+
+	utils.SuccessResponse(r, jobs)
+}
+
+// GetJob 获取报表任务及其每次执行记录
+// @Summary 获取报表任务执行详情
+// @Description 获取报表任务及其每次执行的状态、错误和耗时
+// @Tags 报表模板
+// @Accept json
+// @Produce json
+// @Param id path uint64 true "任务ID"
+// @Success 200 {object} utils.Response{data=types.ReportJobDetail}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/report-jobs/{id} [get]
+func (c *ReportJobController) GetJob(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "无效的任务ID")
+		return
+	}
+
+	detail, err := c.schedulerService.GetJobRun(ctx, id)
+	if errors.Is(err, service.ErrReportJobNotFound) {
+		utils.ErrorResponse(r, 404, err.Error())
+		return
+	}
+	if err != nil {
+		g.Log().Error(ctx, "获取报表任务失败", "job_id", id, "error", err)
+		utils.ErrorResponse(r, 500, "获取报表任务失败")
+		return
+	}
+
+	utils.SuccessResponse(r, detail)
+}
+
+// RetryJob 手动重试失败的报表任务
+// @Summary 手动重试报表任务
+// @Description 将最终失败的定时报表任务重新排队立即执行
+// @Tags 报表模板
+// @Accept json
+// @Produce json
+// @Param id path uint64 true "任务ID"
+// @Success 200 {object} utils.Response{data=types.ReportJob}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/report-jobs/{id}/retry [post]
+func (c *ReportJobController) RetryJob(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "无效的任务ID")
+		return
+	}
+
+	job, err := c.schedulerService.RetryJob(ctx, id)
+	switch {
+	case errors.Is(err, service.ErrReportJobNotFound):
+		utils.ErrorResponse(r, 404, err.Error())
+		return
+	case errors.Is(err, service.ErrReportJobNotFailed):
+		utils.ErrorResponse(r, 409, err.Error())
+		return
+	case err != nil:
+		g.Log().Error(ctx, "重试报表任务失败", "job_id", id, "error", err)
+		utils.ErrorResponse(r, 500, "重试报表任务失败")
+		return
+	}
+
+	utils.SuccessResponse(r, job)
+}
@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/report-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// SettlementController 商户结算控制器
+type SettlementController struct {
+	settlementService *service.SettlementService
+}
+
+// NewSettlementController 创建商户结算控制器实例
+func NewSettlementController() *SettlementController {
+	return &SettlementController{
+		settlementService: service.NewSettlementService(),
+	}
+}
+
+// GenerateSettlements 生成商户结算单
+// @Summary 生成商户结算单
+// @Description 按周期汇总各商户的订单收入和退款，按租户结算规则扣除平台手续费，生成待付款结算单。周期包含结束日期当天，同一商户的结算周期不能重叠
+// @Tags 商户结算
+// @Accept json
+// @Produce json
+// @Param request body types.SettlementGenerateRequest true "结算周期"
+// @Success 200 {object} utils.Response{data=types.SettlementStatement}
+// @Failure 400 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/settlements [post]
+func (c *SettlementController) GenerateSettlements(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var req types.SettlementGenerateRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, "请求参数解析失败")
+		return
+	}
+	if req.StartDate.IsZero() || req.EndDate.IsZero() {
+		utils.ErrorResponse(r, 400, "结算周期不能为空")
+		return
+	}
+	if req.EndDate.Before(req.StartDate) {
+		utils.ErrorResponse(r, 400, "结束日期不能早于开始日期")
+		return
+	}
+
+	statement, err := c.settlementService.GenerateSettlements(ctx, &req)
+	if errors.Is(err, types.ErrSettlementPeriodOverlap) {
+		utils.ErrorResponse(r, 409, err.Error())
+		return
+	}
+	if err != nil {
+		g.Log().Error(ctx, "生成商户结算单失败", "error", err)
+		utils.ErrorResponse(r, 500, "生成商户结算单失败")
+		return
+	}
+
+	utils.SuccessResponse(r, statement)
+}
+
+// ListSettlements 获取商户结算单列表
+// @Summary 获取商户结算单列表
+// @Description 分页获取商户结算单，可按商户和结算状态筛选
+// @Tags 商户结算
+// @Accept json
+// @Produce json
+// @Param merchant_id query uint64 false "商户ID"
+// @Param status query string false "结算状态" Enums(pending, paid)
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} utils.Response{data=object}
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/settlements [get]
+func (c *SettlementController) ListSettlements(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	query := &types.SettlementQuery{
+		MerchantID: r.Get("merchant_id").Uint64(),
+		Page:       r.Get("page", 1).Int(),
+		PageSize:   r.Get("page_size", 20).Int(),
+	}
+	if statusStr := r.Get("status").String(); statusStr != "" {
+		status := types.SettlementStatus(statusStr)
+		if status != types.SettlementStatusPending && status != types.SettlementStatusPaid {
+			utils.ErrorResponse(r, 400, "无效的结算状态")
+			return
+		}
+		query.Status = status
+	}
+
+	settlements, total, err := c.settlementService.ListSettlements(ctx, query)
+	if err != nil {
+		g.Log().Error(ctx, "获取商户结算单列表失败", "error", err)
+		utils.ErrorResponse(r, 500, "获取商户结算单列表失败")
+		return
+	}
+
+	utils.SuccessResponse(r, g.Map{
+		"items":     settlements,
+		"total":     total,
+		"page":      query.Page,
+		"page_size": query.PageSize,
+		"has_next":  int64(query.Page*query.PageSize) < total,
+	})
+}
+
+// GetSettlement 获取商户结算单
+// @Summary 获取商户结算单
+// @Description 获取结算单的收入、退款、手续费、应付金额和付款状态
+// @Tags 商户结算
+// @Accept json
+// @Produce json
+// @Param id path uint64 true "结算单ID"
+// @Success 200 {object} utils.Response{data=types.MerchantSettlement}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/settlements/{id} [get]
+func (c *SettlementController) GetSettlement(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "无效的结算单ID")
+		return
+	}
+
+	settlement, err := c.settlementService.GetSettlement(ctx, id)
+	if errors.Is(err, types.ErrSettlementNotFound) {
+		utils.ErrorResponse(r, 404, err.Error())
+		return
+	}
+	if err != nil {
+		g.Log().Error(ctx, "获取商户结算单失败", "settlement_id", id, "error", err)
+		utils.ErrorResponse(r, 500, "获取商户结算单失败")
+		return
+	}
+
+	utils.SuccessResponse(r, settlement)
+}
+
+// MarkPaid 标记商户结算单已付款
+// @Summary 标记结算单已付款
+// @Description 登记付款凭证号并将待付款结算单标记为已付款，已付款的结算单不能重复标记
+// @Tags 商户结算
+// @Accept json
+// @Produce json
+// @Param id path uint64 true "结算单ID"
+// @Param request body types.SettlementPayRequest true "付款信息"
+// @Success 200 {object} utils.Response{data=types.MerchantSettlement}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/settlements/{id}/pay [post]
+func (c *SettlementController) MarkPaid(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "无效的结算单ID")
+		return
+	}
+
+	var req types.SettlementPayRequest
+	if err := r.Parse(&req); err != nil {
+		utils.ErrorResponse(r, 400, err.Error())
+		return
+	}
+
+	settlement, err := c.settlementService.MarkPaid(ctx, id, req.PaymentReference)
+	switch {
+	case errors.Is(err, types.ErrSettlementNotFound):
+		utils.ErrorResponse(r, 404, err.Error())
+		return
+	case errors.Is(err, types.ErrSettlementAlreadyPaid):
+		utils.ErrorResponse(r, 409, err.Error())
+		return
+	case err != nil:
+		g.Log().Error(ctx, "标记商户结算单已付款失败", "settlement_id", id, "error", err)
+		utils.ErrorResponse(r, 500, "标记商户结算单已付款失败")
+		return
+	}
+
+	utils.SuccessResponse(r, settlement)
+}
+
+// DownloadStatement 下载商户结算单PDF
+// @Summary 下载商户结算单
+// @Description 生成并下载单个商户的结算单PDF，PDF转换工具不可用时返回HTML
+// @Tags 商户结算
+// @Produce application/pdf
+// @Param id path uint64 true "结算单ID"
+// @Success 200 {file} file
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/settlements/{id}/statement [get]
+func (c *SettlementController) DownloadStatement(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(r, 400, "无效的结算单ID")
+		return
+	}
+
+	result, err := c.settlementService.GenerateStatementPDF(ctx, id)
+	if errors.Is(err, types.ErrSettlementNotFound) {
+		utils.ErrorResponse(r, 404, err.Error())
+		return
+	}
+	if err != nil {
+		g.Log().Error(ctx, "生成商户结算单PDF失败", "settlement_id", id, "error", err)
+		utils.ErrorResponse(r, 500, "生成商户结算单失败")
+		return
+	}
+
+	r.Response.Header().Set("Content-Type", result.FileFormat.ContentType())
+	r.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment;filename=%s`, url.QueryEscape(filepath.Base(result.FilePath))))
+	r.Response.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
+	r.Response.ServeFile(result.FilePath)
+}
@@ -17,19 +17,33 @@ type IAnalyticsService interface {
 	GetFinancialData(ctx context.Context, startDate, endDate time.Time, merchantID *uint64) (*types.FinancialReportData, error)
 	GetMerchantOperationData(ctx context.Context, startDate, endDate time.Time) (*types.MerchantOperationReport, error)
 	GetCustomerAnalysisData(ctx context.Context, startDate, endDate time.Time) (*types.CustomerAnalysisReport, error)
+	GetMerchantSummary(ctx context.Context, merchantID uint64, startDate, endDate time.Time) (*types.MerchantSummary, error)
+	GetReconciliationData(ctx context.Context, startDate, endDate time.Time, merchantID *uint64) (*types.ReconciliationReportData, error)
 	CustomQuery(ctx context.Context, req *types.AnalyticsQueryRequest) (interface{}, error)
 	ClearCache(ctx context.Context, pattern string) error
+	// 执行已注册的分析查询并校验结果约束，供运维排查报表数据问题
+	RunQueryChecks(ctx context.Context, req *types.AnalyticsCheckRequest) *types.AnalyticsCheckReport
 }
 
 // AnalyticsService 数据分析服务实现
 type AnalyticsService struct {
-	reportRepo repository.IReportRepository
+	reportRepo   repository.IReportRepository
+	merchantRepo repository.MerchantRepository
 }
 
 // NewAnalyticsService 创建数据分析服务实例
 func NewAnalyticsService() IAnalyticsService {
 	return &AnalyticsService{
-		reportRepo: repository.NewReportRepository(),
+		reportRepo:   repository.NewReportRepository(),
+		merchantRepo: repository.NewMerchantRepository(),
+	}
+}
+
+// NewAnalyticsServiceForTest 创建测试用数据分析服务实例
+func NewAnalyticsServiceForTest(reportRepo repository.IReportRepository, merchantRepo repository.MerchantRepository) IAnalyticsService {
+	return &AnalyticsService{
+		reportRepo:   reportRepo,
+		merchantRepo: merchantRepo,
 	}
 }
 
@@ -204,6 +218,15 @@ func (s *AnalyticsService) ClearCache(ctx context.Context, pattern string) error
 	return nil
 }
 
+// RunQueryChecks 绕过缓存直接执行已注册的分析查询，校验结果结构并返回被降级处理的子查询错误
+func (s *AnalyticsService) RunQueryChecks(ctx context.Context, req *types.AnalyticsCheckRequest) *types.AnalyticsCheckReport {
+	report := repository.RunAnalyticsQueryChecks(ctx, s.reportRepo, req)
+	if !report.Passed {
+		g.Log().Warning(ctx, "分析查询校验未通过", "tenant_id", req.TenantID, "failed", len(report.Failed()))
+	}
+	return report
+}
+
 // buildCacheKey 构建缓存键
 func (s *AnalyticsService) buildCacheKey(metricType string, tenantID uint64, startDate, endDate time.Time, merchantID *uint64) string {
 	key := fmt.Sprintf("%s:%d:%s:%s", 
@@ -288,7 +311,7 @@ func (s *AnalyticsService) getRevenueTrend(ctx context.Context, tenantID uint64,
 	args = append(args, dateFormat)
 	
 	var trends []map[string]interface{}
-	err := g.DB().Raw(query, args...).Scan(&trends)
+	err := repository.TenantDB(ctx).Raw(query, args...).Scan(&trends)
 	
 	return trends, err
 }
@@ -328,7 +351,7 @@ func (s *AnalyticsService) getOrderStats(ctx context.Context, tenantID uint64, s
 	query += " GROUP BY status ORDER BY count DESC"
 	
 	var stats []map[string]interface{}
-	err := g.DB().Raw(query, args...).Scan(&stats)
+	err := repository.TenantDB(ctx).Raw(query, args...).Scan(&stats)
 	
 	return stats, err
 }
@@ -363,21 +386,17 @@ func (s *AnalyticsService) getMerchantComparison(ctx context.Context, tenantID u
 	query += " GROUP BY m.id, m.name ORDER BY total_revenue DESC LIMIT 20"
 	
 	var comparison []map[string]interface{}
-	err := g.DB().Raw(query, args...).Scan(&comparison)
+	err := repository.TenantDB(ctx).Raw(query, args...).Scan(&comparison)
 	
 	return comparison, err
 }
 
 // getCustomerSegments 获取客户分群数据
 func (s *AnalyticsService) getCustomerSegments(ctx context.Context, tenantID uint64, startDate, endDate time.Time, filters map[string]interface{}) (interface{}, error) {
-	query := `
+	// 分群条件与客户分群营销导出共用
+	query := fmt.Sprintf(`
 		SELECT 
-			CASE 
-				WHEN order_count = 1 THEN '新客户'
-				WHEN order_count BETWEEN 2 AND 5 THEN '普通客户'  
-				WHEN order_count BETWEEN 6 AND 10 THEN '忠诚客户'
-				ELSE 'VIP客户'
-			END as segment,
+			%s as segment,
 			COUNT(*) as customer_count,
 			AVG(total_spent) as avg_spent,
 			AVG(order_count) as avg_orders
@@ -392,20 +411,14 @@ func (s *AnalyticsService) getCustomerSegments(ctx context.Context, tenantID uin
 				AND o.status IN ('completed', 'paid')
 			GROUP BY o.customer_id
 		) customer_stats
-		GROUP BY 
-			CASE 
-				WHEN order_count = 1 THEN '新客户'
-				WHEN order_count BETWEEN 2 AND 5 THEN '普通客户'  
-				WHEN order_count BETWEEN 6 AND 10 THEN '忠诚客户'
-				ELSE 'VIP客户'
-			END
+		GROUP BY segment
 		ORDER BY customer_count DESC
-	`
+	`, types.CustomerSegmentSQL("order_count"))
 	
 	args := []interface{}{tenantID, startDate, endDate}
 	
 	var segments []map[string]interface{}
-	err := g.DB().Raw(query, args...).Scan(&segments)
+	err := repository.TenantDB(ctx).Raw(query, args...).Scan(&segments)
 	
 	return segments, err
 }
@@ -432,7 +445,7 @@ func (s *AnalyticsService) getRightsUsage(ctx context.Context, tenantID uint64,
 	}
 	
 	var usage map[string]interface{}
-	err := g.DB().Raw(query, args...).Scan(&usage)
+	err := repository.TenantDB(ctx).Raw(query, args...).Scan(&usage)
 	
 	return usage, err
 }
@@ -484,7 +497,7 @@ func (s *AnalyticsService) getProductPerformance(ctx context.Context, tenantID u
 	query += " GROUP BY p.id, p.name, c.name ORDER BY total_revenue DESC LIMIT 50"
 	
 	var products []map[string]interface{}
-	err := g.DB().Raw(query, args...).Scan(&products)
+	err := repository.TenantDB(ctx).Raw(query, args...).Scan(&products)
 	
 	return products, err
 }
@@ -517,7 +530,7 @@ func (s *AnalyticsService) getPaymentMethodStats(ctx context.Context, tenantID u
 	query += " GROUP BY payment_method ORDER BY transaction_count DESC"
 	
 	var paymentStats []map[string]interface{}
-	err := g.DB().Raw(query, args...).Scan(&paymentStats)
+	err := repository.TenantDB(ctx).Raw(query, args...).Scan(&paymentStats)
 	
 	return paymentStats, err
 }
@@ -552,7 +565,7 @@ func (s *AnalyticsService) getGeographicAnalysis(ctx context.Context, tenantID u
 	query += " GROUP BY u.province, u.city ORDER BY total_revenue DESC LIMIT 100"
 	
 	var geoStats []map[string]interface{}
-	err := g.DB().Raw(query, args...).Scan(&geoStats)
+	err := repository.TenantDB(ctx).Raw(query, args...).Scan(&geoStats)
 	
 	return geoStats, err
 }
@@ -606,7 +619,7 @@ func (s *AnalyticsService) getCustomerRetentionAnalysis(ctx context.Context, ten
 	args := []interface{}{tenantID, startDate, tenantID, endDate}
 	
 	var retentionStats []map[string]interface{}
-	err := g.DB().Raw(query, args...).Scan(&retentionStats)
+	err := repository.TenantDB(ctx).Raw(query, args...).Scan(&retentionStats)
 	
 	return retentionStats, err
 }
@@ -675,7 +688,7 @@ func (s *AnalyticsService) getSalesFunnelAnalysis(ctx context.Context, tenantID
 	funnelQuery += " ORDER BY FIELD(stage, 'orders_created', 'orders_paid', 'orders_completed')"
 	
 	var funnelStats []map[string]interface{}
-	err := g.DB().Raw(funnelQuery, completedArgs...).Scan(&funnelStats)
+	err := repository.TenantDB(ctx).Raw(funnelQuery, completedArgs...).Scan(&funnelStats)
 	
 	return funnelStats, err
 }
\ No newline at end of file
@@ -0,0 +1,521 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/export"
+	"github.com/gofromzero/mer-sys/backend/shared/oss"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/schedule"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+const (
+	// 每天导出前一天分析数据的时间
+	analyticsExportHour   = 2
+	analyticsExportMinute = 30
+	// 每次查询的租户数
+	analyticsExportTenantPageSize = 100
+	// 单个事实数据文件的大小上限
+	maxAnalyticsExportFileSize = 100 << 20
+)
+
+// AnalyticsExportStorage 定时分析数据导出文件的对象存储
+type AnalyticsExportStorage interface {
+	UploadFromBytes(ctx context.Context, data []byte, fileName, contentType string, options *oss.UploadOptions) (*oss.UploadFileInfo, error)
+}
+
+// AnalyticsExportService 分析数据导出服务：将财务、商户运营和客户分析数据展开为规范化的事实行
+// （每行一个指标，日期、商户、类别作为维度），供BI工具加载。数据来自分析服务的查询，与分析接口和报表一致；
+// 比率类指标按分析接口返回的原值导出，占比等可由BI工具重新计算的派生指标不导出。
+// 定时任务每天将前一天的数据按租户配置导出到对象存储；按需导出进入导出队列在后台生成文件
+type AnalyticsExportService struct {
+	analytics    IAnalyticsService
+	storage      AnalyticsExportStorage
+	tenantRepo   repository.ITenantRepository
+	policies     repository.AnalyticsExportPolicyProvider
+	exports      *export.Queue
+	rangeLimits  *ReportRangeLimits
+	planProvider TenantPlanProvider
+	now          func() time.Time
+	jobs         *schedule.Scheduler // 多副本部署时只有领导者副本执行导出
+	isRunning    bool
+}
+
+// NewAnalyticsExportService 创建分析数据导出服务实例，并在导出队列中注册分析事实数据导出
+func NewAnalyticsExportService(exports *export.Queue) *AnalyticsExportService {
+	tenantRepo := repository.NewTenantRepository()
+	s := NewAnalyticsExportServiceForTest(NewAnalyticsService(), oss.NewOSSService(), tenantRepo, exports,
+		LoadReportRangeLimits(context.Background()), time.Now)
+	s.jobs = schedule.New("report-service-analytics-export")
+	return s
+}
+
+// NewAnalyticsExportServiceForTest 创建测试用分析数据导出服务实例，可指定当前时间
+func NewAnalyticsExportServiceForTest(analytics IAnalyticsService, storage AnalyticsExportStorage, tenantRepo repository.ITenantRepository,
+	exports *export.Queue, rangeLimits *ReportRangeLimits, now func() time.Time) *AnalyticsExportService {
+	s := &AnalyticsExportService{
+		analytics:    analytics,
+		storage:      storage,
+		tenantRepo:   tenantRepo,
+		policies:     repository.NewTenantAnalyticsExportPolicyProvider(tenantRepo),
+		exports:      exports,
+		rangeLimits:  rangeLimits,
+		planProvider: NewTenantPlanProvider(tenantRepo),
+		now:          now,
+		jobs:         schedule.NewForTest("report-service-analytics-export", "test", schedule.NewMemoryLeaderLock(now), 0, now),
+	}
+	exports.Register(types.ExportKindAnalyticsFacts, s.generateFactsExport)
+	return s
+}
+
+// Start 启动每天的分析数据定时导出
+func (s *AnalyticsExportService) Start(ctx context.Context) error {
+	if s.isRunning {
+		return fmt.Errorf("分析数据导出任务已经在运行")
+	}
+
+	err := s.jobs.Register(schedule.Job{
+		Name:     "ExportDailyAnalytics",
+		Schedule: schedule.DailyAt(analyticsExportHour, analyticsExportMinute),
+		Run: func(ctx context.Context) error {
+			_, err := s.ExportDaily(ctx)
+			return err
+		},
+	})
+	if err != nil && !errors.Is(err, schedule.ErrJobExists) {
+		return fmt.Errorf("添加分析数据导出定时器失败: %v", err)
+	}
+
+	s.jobs.Start(ctx)
+	s.isRunning = true
+	g.Log().Info(ctx, "分析数据定时导出已启动")
+	return nil
+}
+
+// Stop 停止分析数据定时导出
+func (s *AnalyticsExportService) Stop(ctx context.Context) {
+	if !s.isRunning {
+		return
+	}
+	s.jobs.Stop(ctx)
+	s.isRunning = false
+	g.Log().Info(ctx, "分析数据定时导出已停止")
+}
+
+// PrepareFactsExport 校验导出条件：数据集和格式须受支持，时间范围不能超过租户套餐下该数据集的上限
+func (s *AnalyticsExportService) PrepareFactsExport(ctx context.Context, query *types.AnalyticsFactsExportQuery) error {
+	if query.EndDate.Before(query.StartDate) {
+		return fmt.Errorf("结束日期不能早于开始日期")
+	}
+	policy := &types.AnalyticsExportPolicy{Datasets: []types.ReportType{query.Dataset}, Format: query.Format}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	query.Format = policy.ExportFormat()
+
+	plan := s.planProvider.Plan(ctx, gconv.Uint64(ctx.Value("tenant_id")))
+	return s.rangeLimits.Check(plan, query.Dataset, query.StartDate, query.EndDate)
+}
+
+// CreateFactsExport 将分析事实数据导出加入导出队列，导出条件需先经过 PrepareFactsExport 校验
+func (s *AnalyticsExportService) CreateFactsExport(ctx context.Context, query *types.AnalyticsFactsExportQuery) (*types.ExportJob, error) {
+	fileName := fmt.Sprintf("%s_facts_%s_%s.%s", query.Dataset,
+		query.StartDate.Format("20060102"), query.EndDate.Format("20060102"), query.Format)
+
+	return s.exports.Enqueue(ctx, &export.Request{
+		Kind:     types.ExportKindAnalyticsFacts,
+		Format:   string(query.Format),
+		FileName: fileName,
+		Params:   query,
+	})
+}
+
+// GetFactsExport 获取分析事实数据导出任务
+func (s *AnalyticsExportService) GetFactsExport(ctx context.Context, uuid string) (*types.ExportJob, error) {
+	job, err := s.exports.Get(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+	// 导出任务表由各服务共用，其他类型的导出文件不在本服务生成
+	if job.Kind != types.ExportKindAnalyticsFacts {
+		return nil, export.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// GetFactsExportFile 获取已生成完成且未过期的分析事实数据导出任务，用于下载文件
+func (s *AnalyticsExportService) GetFactsExportFile(ctx context.Context, uuid string) (*types.ExportJob, error) {
+	if _, err := s.GetFactsExport(ctx, uuid); err != nil {
+		return nil, err
+	}
+	return s.exports.GetFile(ctx, uuid)
+}
+
+// generateFactsExport 导出队列中的分析事实数据生成器
+func (s *AnalyticsExportService) generateFactsExport(ctx context.Context, job *types.ExportJob, w io.Writer) (int, error) {
+	var query types.AnalyticsFactsExportQuery
+	if err := json.Unmarshal([]byte(job.Params), &query); err != nil {
+		return 0, fmt.Errorf("解析导出条件失败: %v", err)
+	}
+
+	facts, err := s.ExportFacts(ctx, query.Dataset, query.StartDate, query.EndDate)
+	if err != nil {
+		return 0, err
+	}
+	data, err := WriteAnalyticsFacts(facts, query.Format)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return 0, fmt.Errorf("写入导出文件失败: %w", err)
+	}
+	return len(facts), nil
+}
+
+// ExportFacts 查询当前租户指定数据集在时间范围内的分析数据并展开为事实行
+func (s *AnalyticsExportService) ExportFacts(ctx context.Context, dataset types.ReportType, startDate, endDate time.Time) ([]types.AnalyticsFact, error) {
+	switch dataset {
+	case types.ReportTypeFinancial:
+		data, err := s.analytics.GetFinancialData(ctx, startDate, endDate, nil)
+		if err != nil {
+			return nil, err
+		}
+		return FlattenFinancialData(data, startDate), nil
+	case types.ReportTypeMerchantOperation:
+		data, err := s.analytics.GetMerchantOperationData(ctx, startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		return FlattenMerchantOperationData(data, startDate), nil
+	case types.ReportTypeCustomerAnalysis:
+		data, err := s.analytics.GetCustomerAnalysisData(ctx, startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		return FlattenCustomerAnalysisData(data, startDate), nil
+	default:
+		return nil, fmt.Errorf("不支持导出的分析数据集: %s", dataset)
+	}
+}
+
+// ExportDaily 为开启了分析数据导出的活跃租户导出前一天的数据，返回上传的文件数。
+// 单个租户导出失败不影响其他租户
+func (s *AnalyticsExportService) ExportDaily(ctx context.Context) (int, error) {
+	now := s.now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -1)
+
+	uploaded := 0
+	for page := 1; ; page++ {
+		tenants, total, err := s.tenantRepo.List(ctx, &types.ListTenantsRequest{
+			Page:     page,
+			PageSize: analyticsExportTenantPageSize,
+			Status:   types.TenantStatusActive,
+		})
+		if err != nil {
+			return uploaded, fmt.Errorf("查询租户失败: %v", err)
+		}
+
+		for _, tenant := range tenants {
+			count, err := s.ExportTenant(ctx, tenant.ID, day)
+			if err != nil {
+				g.Log().Error(ctx, "租户分析数据导出失败", "tenant_id", tenant.ID, "date", day.Format("2006-01-02"), "error", err)
+			}
+			uploaded += count
+		}
+
+		if len(tenants) == 0 || page*analyticsExportTenantPageSize >= total {
+			return uploaded, nil
+		}
+	}
+}
+
+// ExportTenant 按租户配置导出指定日期的分析数据到对象存储，每个数据集一个文件，返回上传的文件数。
+// 未开启导出的租户跳过；同一日期重复导出时覆盖之前的文件
+func (s *AnalyticsExportService) ExportTenant(ctx context.Context, tenantID uint64, day time.Time) (int, error) {
+	policy, err := s.policies(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	if policy == nil || !policy.Enabled {
+		return 0, nil
+	}
+
+	// 定时任务没有请求上下文，按租户设置租户上下文
+	tenantCtx := context.WithValue(ctx, "tenant_id", tenantID)
+	startDate := day
+	endDate := day.AddDate(0, 0, 1).Add(-time.Second)
+	format := policy.ExportFormat()
+
+	uploaded := 0
+	for _, dataset := range policy.ExportDatasets() {
+		facts, err := s.ExportFacts(tenantCtx, dataset, startDate, endDate)
+		if err != nil {
+			return uploaded, fmt.Errorf("导出%s分析数据失败: %v", dataset, err)
+		}
+		data, err := WriteAnalyticsFacts(facts, format)
+		if err != nil {
+			return uploaded, err
+		}
+
+		directory := fmt.Sprintf("analytics/%d/%s/dt=%s", tenantID, dataset, day.Format("2006-01-02"))
+		_, err = s.storage.UploadFromBytes(tenantCtx, data, "facts."+string(format), format.ContentType(), &oss.UploadOptions{
+			Directory:    directory,
+			AllowedTypes: []string{format.ContentType()},
+			MaxSize:      maxAnalyticsExportFileSize,
+		})
+		if err != nil {
+			return uploaded, fmt.Errorf("上传%s分析数据失败: %v", dataset, err)
+		}
+		uploaded++
+		g.Log().Info(ctx, "分析数据已导出", "tenant_id", tenantID, "dataset", dataset, "directory", directory, "rows", len(facts))
+	}
+	return uploaded, nil
+}
+
+// WriteAnalyticsFacts 按指定格式输出事实行，CSV带表头
+func WriteAnalyticsFacts(facts []types.AnalyticsFact, format types.AnalyticsFactFormat) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case types.AnalyticsFactFormatCSV:
+		writer := csv.NewWriter(&buf)
+		if err := writer.Write(types.AnalyticsFactColumns); err != nil {
+			return nil, fmt.Errorf("写入CSV表头失败: %v", err)
+		}
+		for i := range facts {
+			if err := writer.Write(facts[i].Record()); err != nil {
+				return nil, fmt.Errorf("写入CSV数据失败: %v", err)
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return nil, fmt.Errorf("写入CSV数据失败: %v", err)
+		}
+	case types.AnalyticsFactFormatJSONL:
+		encoder := json.NewEncoder(&buf)
+		for i := range facts {
+			if err := encoder.Encode(&facts[i]); err != nil {
+				return nil, fmt.Errorf("写入JSON数据失败: %v", err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("不支持的分析数据导出格式: %s", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// analyticsFactBuilder 按数据集和日期生成事实行
+type analyticsFactBuilder struct {
+	dataset types.ReportType
+	date    string
+	facts   []types.AnalyticsFact
+}
+
+func newAnalyticsFactBuilder(dataset types.ReportType, startDate time.Time) *analyticsFactBuilder {
+	return &analyticsFactBuilder{dataset: dataset, date: startDate.Format("2006-01-02")}
+}
+
+// total 区间汇总的指标
+func (b *analyticsFactBuilder) total(metric string, value float64, unit string) {
+	b.add(types.AnalyticsFact{Grain: types.AnalyticsFactGrainPeriod, Metric: metric, Value: value, Unit: unit})
+}
+
+// money 区间汇总的金额指标
+func (b *analyticsFactBuilder) money(metric string, value types.Money) {
+	b.total(metric, value.Amount, value.Currency)
+}
+
+// monthly 月度指标，month 为 YYYY-MM，无法解析时跳过
+func (b *analyticsFactBuilder) monthly(month string, fact types.AnalyticsFact) {
+	parsed, err := time.Parse("2006-01", month)
+	if err != nil {
+		return
+	}
+	fact.Date = parsed.Format("2006-01-02")
+	fact.Grain = types.AnalyticsFactGrainMonth
+	b.add(fact)
+}
+
+func (b *analyticsFactBuilder) add(fact types.AnalyticsFact) {
+	fact.Dataset = b.dataset
+	if fact.Date == "" {
+		fact.Date = b.date
+	}
+	b.facts = append(b.facts, fact)
+}
+
+// FlattenFinancialData 将财务数据展开为事实行：区间汇总指标、按商户和类别的收入、按类型的支出、
+// 按类别的权益使用及月度趋势
+func FlattenFinancialData(data *types.FinancialReportData, startDate time.Time) []types.AnalyticsFact {
+	b := newAnalyticsFactBuilder(types.ReportTypeFinancial, startDate)
+	if data == nil {
+		return b.facts
+	}
+
+	b.money("total_revenue", data.TotalRevenue)
+	b.money("total_expenditure", data.TotalExpenditure)
+	b.money("net_profit", data.NetProfit)
+	b.money("order_amount", data.OrderAmount)
+	b.money("tax_collected", data.TaxCollected)
+	b.money("net_revenue", data.NetRevenue)
+	b.total("rights_distributed", float64(data.RightsDistributed), types.AnalyticsFactUnitRights)
+	b.total("rights_consumed", float64(data.RightsConsumed), types.AnalyticsFactUnitRights)
+	b.total("rights_balance", float64(data.RightsBalance), types.AnalyticsFactUnitRights)
+	b.total("merchant_count", float64(data.MerchantCount), types.AnalyticsFactUnitCount)
+	b.total("active_merchant_count", float64(data.ActiveMerchantCount), types.AnalyticsFactUnitCount)
+	b.total("customer_count", float64(data.CustomerCount), types.AnalyticsFactUnitCount)
+	b.total("active_customer_count", float64(data.ActiveCustomerCount), types.AnalyticsFactUnitCount)
+	b.total("order_count", float64(data.OrderCount), types.AnalyticsFactUnitCount)
+
+	breakdown := data.Breakdown
+	if breakdown == nil {
+		return b.facts
+	}
+	for _, item := range breakdown.RevenueByMerchant {
+		merchant := types.AnalyticsFact{Grain: types.AnalyticsFactGrainPeriod, MerchantID: item.MerchantID, MerchantName: item.MerchantName}
+		b.add(withMetric(merchant, "revenue", item.Revenue.Amount, item.Revenue.Currency))
+		b.add(withMetric(merchant, "order_count", float64(item.OrderCount), types.AnalyticsFactUnitCount))
+	}
+	for _, item := range breakdown.RevenueByCategory {
+		category := types.AnalyticsFact{Grain: types.AnalyticsFactGrainPeriod, CategoryID: item.CategoryID, CategoryName: item.CategoryName}
+		b.add(withMetric(category, "revenue", item.Revenue.Amount, item.Revenue.Currency))
+		b.add(withMetric(category, "order_count", float64(item.OrderCount), types.AnalyticsFactUnitCount))
+	}
+	for _, item := range breakdown.ExpenditureByType {
+		b.add(types.AnalyticsFact{Grain: types.AnalyticsFactGrainPeriod, CategoryName: item.Type,
+			Metric: "expenditure", Value: item.Amount.Amount, Unit: item.Amount.Currency})
+	}
+	for _, item := range breakdown.RightsByCategory {
+		category := types.AnalyticsFact{Grain: types.AnalyticsFactGrainPeriod, CategoryID: item.CategoryID, CategoryName: item.CategoryName}
+		b.add(withMetric(category, "rights_distributed", float64(item.Distributed), types.AnalyticsFactUnitRights))
+		b.add(withMetric(category, "rights_consumed", float64(item.Consumed), types.AnalyticsFactUnitRights))
+		b.add(withMetric(category, "rights_balance", float64(item.Balance), types.AnalyticsFactUnitRights))
+	}
+	for _, item := range breakdown.MonthlyTrend {
+		b.monthly(item.Month, types.AnalyticsFact{Metric: "revenue", Value: item.Revenue.Amount, Unit: item.Revenue.Currency})
+		b.monthly(item.Month, types.AnalyticsFact{Metric: "expenditure", Value: item.Expenditure.Amount, Unit: item.Expenditure.Currency})
+		b.monthly(item.Month, types.AnalyticsFact{Metric: "net_profit", Value: item.NetProfit.Amount, Unit: item.NetProfit.Currency})
+		b.monthly(item.Month, types.AnalyticsFact{Metric: "order_count", Value: float64(item.OrderCount), Unit: types.AnalyticsFactUnitCount})
+		b.monthly(item.Month, types.AnalyticsFact{Metric: "rights_distributed", Value: float64(item.RightsDistributed), Unit: types.AnalyticsFactUnitRights})
+		b.monthly(item.Month, types.AnalyticsFact{Metric: "rights_consumed", Value: float64(item.RightsConsumed), Unit: types.AnalyticsFactUnitRights})
+	}
+	return b.facts
+}
+
+// FlattenMerchantOperationData 将商户运营数据展开为事实行：按商户的经营指标和评价、商户月度趋势、
+// 按类别的经营指标及整体增长率
+func FlattenMerchantOperationData(data *types.MerchantOperationReport, startDate time.Time) []types.AnalyticsFact {
+	b := newAnalyticsFactBuilder(types.ReportTypeMerchantOperation, startDate)
+	if data == nil {
+		return b.facts
+	}
+
+	for _, item := range data.MerchantRankings {
+		merchant := types.AnalyticsFact{Grain: types.AnalyticsFactGrainPeriod, MerchantID: item.MerchantID, MerchantName: item.MerchantName}
+		b.add(withMetric(merchant, "revenue", item.TotalRevenue.Amount, item.TotalRevenue.Currency))
+		b.add(withMetric(merchant, "order_count", float64(item.OrderCount), types.AnalyticsFactUnitCount))
+		b.add(withMetric(merchant, "customer_count", float64(item.CustomerCount), types.AnalyticsFactUnitCount))
+		b.add(withMetric(merchant, "average_order_value", item.AverageOrderValue.Amount, item.AverageOrderValue.Currency))
+		b.add(withMetric(merchant, "growth_rate", item.GrowthRate, types.AnalyticsFactUnitRate))
+	}
+	for _, trend := range data.PerformanceTrends {
+		merchant := types.AnalyticsFact{MerchantID: trend.MerchantID, MerchantName: trend.MerchantName}
+		for _, item := range trend.TrendData {
+			b.monthly(item.Month, withMetric(merchant, "revenue", item.Revenue.Amount, item.Revenue.Currency))
+			b.monthly(item.Month, withMetric(merchant, "order_count", float64(item.OrderCount), types.AnalyticsFactUnitCount))
+			b.monthly(item.Month, withMetric(merchant, "customer_count", float64(item.CustomerCount), types.AnalyticsFactUnitCount))
+		}
+	}
+	for _, item := range data.CategoryAnalysis {
+		category := types.AnalyticsFact{Grain: types.AnalyticsFactGrainPeriod, CategoryID: item.CategoryID, CategoryName: item.CategoryName}
+		b.add(withMetric(category, "revenue", item.Revenue.Amount, item.Revenue.Currency))
+		b.add(withMetric(category, "order_count", float64(item.OrderCount), types.AnalyticsFactUnitCount))
+		b.add(withMetric(category, "merchant_count", float64(item.MerchantCount), types.AnalyticsFactUnitCount))
+		b.add(withMetric(category, "growth_rate", item.GrowthRate, types.AnalyticsFactUnitRate))
+	}
+	if growth := data.GrowthMetrics; growth != nil {
+		b.total("revenue_growth_rate", growth.RevenueGrowthRate, types.AnalyticsFactUnitRate)
+		b.total("order_growth_rate", growth.OrderGrowthRate, types.AnalyticsFactUnitRate)
+		b.total("merchant_growth_rate", growth.MerchantGrowthRate, types.AnalyticsFactUnitRate)
+		b.total("customer_growth_rate", growth.CustomerGrowthRate, types.AnalyticsFactUnitRate)
+		b.total("average_order_value_growth_rate", growth.AverageOrderValueGrowth, types.AnalyticsFactUnitRate)
+	}
+	for _, item := range data.Satisfaction {
+		merchant := types.AnalyticsFact{Grain: types.AnalyticsFactGrainPeriod, MerchantID: item.MerchantID, MerchantName: item.MerchantName}
+		b.add(withMetric(merchant, "average_rating", item.AverageRating, types.AnalyticsFactUnitScore))
+		b.add(withMetric(merchant, "review_count", float64(item.ReviewCount), types.AnalyticsFactUnitCount))
+		b.add(withMetric(merchant, "low_rating_count", float64(item.LowRatingCount), types.AnalyticsFactUnitCount))
+	}
+	return b.facts
+}
+
+// FlattenCustomerAnalysisData 将客户分析数据展开为事实行：月度用户增长、活跃度、消费行为、
+// 留存及流失指标。同期群留存率按同期群月份输出，指标名带期数，如 cohort_retention_rate_1
+func FlattenCustomerAnalysisData(data *types.CustomerAnalysisReport, startDate time.Time) []types.AnalyticsFact {
+	b := newAnalyticsFactBuilder(types.ReportTypeCustomerAnalysis, startDate)
+	if data == nil {
+		return b.facts
+	}
+
+	for _, item := range data.UserGrowth {
+		b.monthly(item.Month, types.AnalyticsFact{Metric: "new_users", Value: float64(item.NewUsers), Unit: types.AnalyticsFactUnitCount})
+		b.monthly(item.Month, types.AnalyticsFact{Metric: "active_users", Value: float64(item.ActiveUsers), Unit: types.AnalyticsFactUnitCount})
+		b.monthly(item.Month, types.AnalyticsFact{Metric: "cumulative_users", Value: float64(item.CumulativeUsers), Unit: types.AnalyticsFactUnitCount})
+		b.monthly(item.Month, types.AnalyticsFact{Metric: "retention_rate", Value: item.RetentionRate, Unit: types.AnalyticsFactUnitRate})
+	}
+	if activity := data.ActivityMetrics; activity != nil {
+		b.total("dau", float64(activity.DAU), types.AnalyticsFactUnitCount)
+		b.total("wau", float64(activity.WAU), types.AnalyticsFactUnitCount)
+		b.total("mau", float64(activity.MAU), types.AnalyticsFactUnitCount)
+		b.total("average_session_time", activity.AverageSessionTime, types.AnalyticsFactUnitMinute)
+		b.total("average_order_frequency", activity.AverageOrderFreq, types.AnalyticsFactUnitCount)
+	}
+	if consumption := data.ConsumptionBehavior; consumption != nil {
+		b.money("average_order_value", consumption.AverageOrderValue)
+		b.total("repurchase_rate", consumption.RepurchaseRate, types.AnalyticsFactUnitRate)
+		b.total("average_order_count", consumption.AverageOrderCount, types.AnalyticsFactUnitCount)
+		for _, item := range consumption.PreferredCategories {
+			category := types.AnalyticsFact{Grain: types.AnalyticsFactGrainPeriod, CategoryID: item.CategoryID, CategoryName: item.CategoryName}
+			b.add(withMetric(category, "order_count", float64(item.OrderCount), types.AnalyticsFactUnitCount))
+			b.add(withMetric(category, "revenue", item.Revenue.Amount, item.Revenue.Currency))
+		}
+		for _, item := range consumption.PaymentMethods {
+			method := types.AnalyticsFact{Grain: types.AnalyticsFactGrainPeriod, CategoryName: item.Method}
+			b.add(withMetric(method, "payment_count", float64(item.Count), types.AnalyticsFactUnitCount))
+			b.add(withMetric(method, "payment_amount", item.Amount.Amount, item.Amount.Currency))
+		}
+	}
+	if retention := data.RetentionAnalysis; retention != nil {
+		b.total("day1_retention", retention.Day1Retention, types.AnalyticsFactUnitRate)
+		b.total("day7_retention", retention.Day7Retention, types.AnalyticsFactUnitRate)
+		b.total("day30_retention", retention.Day30Retention, types.AnalyticsFactUnitRate)
+		for _, cohort := range retention.CohortAnalysis {
+			b.monthly(cohort.Cohort, types.AnalyticsFact{Metric: "cohort_users", Value: float64(cohort.Users), Unit: types.AnalyticsFactUnitCount})
+			for period, rate := range cohort.RetentionRates {
+				b.monthly(cohort.Cohort, types.AnalyticsFact{Metric: fmt.Sprintf("cohort_retention_rate_%d", period+1), Value: rate, Unit: types.AnalyticsFactUnitRate})
+			}
+		}
+	}
+	if churn := data.ChurnAnalysis; churn != nil {
+		b.total("churn_rate", churn.ChurnRate, types.AnalyticsFactUnitRate)
+		b.total("risk_user_count", float64(churn.RiskUserCount), types.AnalyticsFactUnitCount)
+	}
+	return b.facts
+}
+
+// withMetric 在维度相同的事实行上设置指标
+func withMetric(dimensions types.AnalyticsFact, metric string, value float64, unit string) types.AnalyticsFact {
+	dimensions.Metric = metric
+	dimensions.Value = value
+	dimensions.Unit = unit
+	return dimensions
+}
@@ -0,0 +1,530 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/export"
+	"github.com/gofromzero/mer-sys/backend/shared/oss"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cny(amount float64) types.Money {
+	return types.Money{Amount: amount, Currency: "CNY"}
+}
+
+func newTestFinancialData() *types.FinancialReportData {
+	return &types.FinancialReportData{
+		TotalRevenue:        cny(3000),
+		TotalExpenditure:    cny(800),
+		NetProfit:           cny(2200),
+		OrderAmount:         cny(3180),
+		TaxCollected:        cny(180),
+		NetRevenue:          cny(3000),
+		RightsDistributed:   500,
+		RightsConsumed:      300,
+		RightsBalance:       200,
+		MerchantCount:       2,
+		ActiveMerchantCount: 2,
+		CustomerCount:       40,
+		ActiveCustomerCount: 25,
+		OrderCount:          30,
+		Breakdown: &types.FinancialBreakdown{
+			RevenueByMerchant: []types.MerchantRevenue{
+				{MerchantID: 1, MerchantName: "星河贸易", Revenue: cny(2000), OrderCount: 18, Percentage: 66.67},
+				{MerchantID: 2, MerchantName: "云海百货", Revenue: cny(1000), OrderCount: 12, Percentage: 33.33},
+			},
+			RevenueByCategory: []types.CategoryRevenue{
+				{CategoryID: 10, CategoryName: "食品", Revenue: cny(1800), OrderCount: 20},
+				{CategoryID: 11, CategoryName: "日用", Revenue: cny(1200), OrderCount: 10},
+			},
+			ExpenditureByType: []types.ExpenditureItem{
+				{Type: "权益成本", Amount: cny(800), Percentage: 100},
+			},
+			RightsByCategory: []types.RightsUsage{
+				{CategoryID: 10, CategoryName: "食品", Distributed: 500, Consumed: 300, Balance: 200},
+			},
+			MonthlyTrend: []types.MonthlyFinancial{
+				{Month: "2026-09", Revenue: cny(1000), Expenditure: cny(300), NetProfit: cny(700), OrderCount: 10, RightsDistributed: 200, RightsConsumed: 100},
+				{Month: "2026-10", Revenue: cny(2000), Expenditure: cny(500), NetProfit: cny(1500), OrderCount: 20, RightsDistributed: 300, RightsConsumed: 200},
+			},
+		},
+	}
+}
+
+// findFacts 按指标和维度筛选事实行
+func findFacts(facts []types.AnalyticsFact, metric string, match func(types.AnalyticsFact) bool) []types.AnalyticsFact {
+	var found []types.AnalyticsFact
+	for _, fact := range facts {
+		if fact.Metric == metric && match(fact) {
+			found = append(found, fact)
+		}
+	}
+	return found
+}
+
+func sumFacts(facts []types.AnalyticsFact) float64 {
+	total := 0.0
+	for _, fact := range facts {
+		total += fact.Value
+	}
+	return total
+}
+
+func isTotal(fact types.AnalyticsFact) bool {
+	return fact.Grain == types.AnalyticsFactGrainPeriod && fact.MerchantID == 0 && fact.CategoryID == 0 && fact.CategoryName == ""
+}
+
+func byMerchant(fact types.AnalyticsFact) bool {
+	return fact.Grain == types.AnalyticsFactGrainPeriod && fact.MerchantID > 0
+}
+
+func byCategory(fact types.AnalyticsFact) bool {
+	return fact.Grain == types.AnalyticsFactGrainPeriod && fact.CategoryID > 0
+}
+
+func byMonth(fact types.AnalyticsFact) bool {
+	return fact.Grain == types.AnalyticsFactGrainMonth
+}
+
+func TestFlattenFinancialData(t *testing.T) {
+	start := time.Date(2026, 10, 14, 0, 0, 0, 0, time.Local)
+	data := newTestFinancialData()
+	facts := FlattenFinancialData(data, start)
+
+	t.Run("区间汇总指标与源数据一致", func(t *testing.T) {
+		revenue := findFacts(facts, "total_revenue", isTotal)
+		require.Len(t, revenue, 1)
+		assert.Equal(t, types.AnalyticsFact{Dataset: types.ReportTypeFinancial, Date: "2026-10-14", Grain: types.AnalyticsFactGrainPeriod,
+			Metric: "total_revenue", Value: 3000, Unit: "CNY"}, revenue[0])
+		assert.Equal(t, float64(data.OrderCount), sumFacts(findFacts(facts, "order_count", isTotal)))
+		assert.Equal(t, float64(data.RightsConsumed), sumFacts(findFacts(facts, "rights_consumed", isTotal)))
+		assert.Equal(t, data.TaxCollected.Amount, sumFacts(findFacts(facts, "tax_collected", isTotal)))
+	})
+
+	t.Run("按商户和类别的收入合计等于总收入", func(t *testing.T) {
+		merchantRevenue := findFacts(facts, "revenue", byMerchant)
+		require.Len(t, merchantRevenue, 2)
+		assert.Equal(t, uint64(1), merchantRevenue[0].MerchantID)
+		assert.Equal(t, "星河贸易", merchantRevenue[0].MerchantName)
+		assert.Equal(t, 2000.0, merchantRevenue[0].Value)
+		assert.Equal(t, data.TotalRevenue.Amount, sumFacts(merchantRevenue))
+		assert.Equal(t, float64(data.OrderCount), sumFacts(findFacts(facts, "order_count", byMerchant)))
+
+		assert.Equal(t, data.TotalRevenue.Amount, sumFacts(findFacts(facts, "revenue", byCategory)))
+		assert.Equal(t, float64(data.OrderCount), sumFacts(findFacts(facts, "order_count", byCategory)))
+		assert.Equal(t, float64(data.RightsBalance), sumFacts(findFacts(facts, "rights_balance", byCategory)))
+	})
+
+	t.Run("支出按类型记在类别名称列", func(t *testing.T) {
+		expenditure := findFacts(facts, "expenditure", func(f types.AnalyticsFact) bool { return f.Grain == types.AnalyticsFactGrainPeriod })
+		require.Len(t, expenditure, 1)
+		assert.Equal(t, "权益成本", expenditure[0].CategoryName)
+		assert.Equal(t, data.TotalExpenditure.Amount, expenditure[0].Value)
+	})
+
+	t.Run("月度趋势按月份日期输出", func(t *testing.T) {
+		monthly := findFacts(facts, "revenue", byMonth)
+		require.Len(t, monthly, 2)
+		assert.Equal(t, "2026-09-01", monthly[0].Date)
+		assert.Equal(t, "2026-10-01", monthly[1].Date)
+		assert.Equal(t, data.TotalRevenue.Amount, sumFacts(monthly))
+		assert.Equal(t, float64(data.RightsDistributed), sumFacts(findFacts(facts, "rights_distributed", byMonth)))
+	})
+
+	t.Run("占比等派生指标不导出", func(t *testing.T) {
+		for _, fact := range facts {
+			assert.NotContains(t, fact.Metric, "percentage")
+			assert.Equal(t, types.ReportTypeFinancial, fact.Dataset)
+		}
+	})
+
+	t.Run("没有明细数据时只输出汇总指标", func(t *testing.T) {
+		data.Breakdown = nil
+		summary := FlattenFinancialData(data, start)
+		assert.Len(t, summary, 14)
+		for _, fact := range summary {
+			assert.True(t, isTotal(fact), fact.Metric)
+		}
+	})
+}
+
+func TestFlattenMerchantOperationData(t *testing.T) {
+	start := time.Date(2026, 10, 14, 0, 0, 0, 0, time.Local)
+	data := &types.MerchantOperationReport{
+		MerchantRankings: []types.MerchantRanking{
+			{Rank: 1, MerchantID: 1, MerchantName: "星河贸易", TotalRevenue: cny(2000), OrderCount: 18, CustomerCount: 15, AverageOrderValue: cny(111.11), GrowthRate: 12.5},
+			{Rank: 2, MerchantID: 2, MerchantName: "云海百货", TotalRevenue: cny(1000), OrderCount: 12, CustomerCount: 10, AverageOrderValue: cny(83.33), GrowthRate: -4},
+		},
+		PerformanceTrends: []types.MerchantTrend{
+			{MerchantID: 1, MerchantName: "星河贸易", TrendData: []types.MonthlyTrendData{
+				{Month: "2026-09", Revenue: cny(800), OrderCount: 8, CustomerCount: 7},
+				{Month: "2026-10", Revenue: cny(1200), OrderCount: 10, CustomerCount: 9},
+			}},
+		},
+		CategoryAnalysis: []types.CategoryAnalysis{
+			{CategoryID: 10, CategoryName: "食品", Revenue: cny(3000), OrderCount: 30, MerchantCount: 2, MarketShare: 100, GrowthRate: 8},
+		},
+		GrowthMetrics: &types.GrowthMetrics{RevenueGrowthRate: 6.5, OrderGrowthRate: 3},
+		Satisfaction: []types.MerchantSatisfaction{
+			{MerchantID: 1, MerchantName: "星河贸易", AverageRating: 4.6, ReviewCount: 20, LowRatingCount: 1},
+		},
+	}
+	facts := FlattenMerchantOperationData(data, start)
+
+	merchantOne := func(f types.AnalyticsFact) bool { return byMerchant(f) && f.MerchantID == 1 }
+	assert.Equal(t, 2000.0, sumFacts(findFacts(facts, "revenue", merchantOne)))
+	assert.Equal(t, 15.0, sumFacts(findFacts(facts, "customer_count", merchantOne)))
+	assert.Equal(t, 12.5, sumFacts(findFacts(facts, "growth_rate", merchantOne)))
+	assert.Equal(t, 4.6, sumFacts(findFacts(facts, "average_rating", merchantOne)))
+	assert.Equal(t, 3000.0, sumFacts(findFacts(facts, "revenue", byMerchant)))
+	assert.Equal(t, 3000.0, sumFacts(findFacts(facts, "revenue", byCategory)))
+	assert.Equal(t, 2.0, sumFacts(findFacts(facts, "merchant_count", byCategory)))
+	assert.Empty(t, findFacts(facts, "market_share", byCategory))
+
+	// 商户月度趋势同时带商户和月份维度，合计等于该商户区间收入
+	trend := findFacts(facts, "revenue", byMonth)
+	require.Len(t, trend, 2)
+	assert.Equal(t, uint64(1), trend[0].MerchantID)
+	assert.Equal(t, "2026-09-01", trend[0].Date)
+	assert.Equal(t, 2000.0, sumFacts(trend))
+
+	growth := findFacts(facts, "revenue_growth_rate", isTotal)
+	require.Len(t, growth, 1)
+	assert.Equal(t, 6.5, growth[0].Value)
+	assert.Equal(t, types.AnalyticsFactUnitRate, growth[0].Unit)
+}
+
+func TestFlattenCustomerAnalysisData(t *testing.T) {
+	start := time.Date(2026, 10, 14, 0, 0, 0, 0, time.Local)
+	data := &types.CustomerAnalysisReport{
+		UserGrowth: []types.UserGrowthData{
+			{Month: "2026-09", NewUsers: 30, ActiveUsers: 20, CumulativeUsers: 130, RetentionRate: 60},
+			{Month: "2026-10", NewUsers: 25, ActiveUsers: 22, CumulativeUsers: 155, RetentionRate: 64},
+		},
+		ActivityMetrics: &types.ActivityMetrics{DAU: 12, WAU: 40, MAU: 90, AverageSessionTime: 6.5, AverageOrderFreq: 1.4},
+		ConsumptionBehavior: &types.ConsumptionBehavior{
+			AverageOrderValue: cny(100),
+			RepurchaseRate:    35,
+			PreferredCategories: []types.CategoryPreference{
+				{CategoryID: 10, CategoryName: "食品", OrderCount: 20, Revenue: cny(1800)},
+			},
+			PaymentMethods: []types.PaymentMethodStats{
+				{Method: "alipay", Count: 18, Amount: cny(1900)},
+				{Method: "wechat", Count: 12, Amount: cny(1100)},
+			},
+		},
+		RetentionAnalysis: &types.RetentionAnalysis{
+			Day1Retention: 45, Day7Retention: 30, Day30Retention: 18,
+			CohortAnalysis: []types.CohortData{{Cohort: "2026-08", Users: 50, RetentionRates: []float64{40, 25}}},
+		},
+		ChurnAnalysis: &types.ChurnAnalysis{ChurnRate: 8, RiskUserCount: 6},
+	}
+	facts := FlattenCustomerAnalysisData(data, start)
+
+	assert.Equal(t, 55.0, sumFacts(findFacts(facts, "new_users", byMonth)))
+	cumulative := findFacts(facts, "cumulative_users", byMonth)
+	require.Len(t, cumulative, 2)
+	assert.Equal(t, "2026-10-01", cumulative[1].Date)
+	assert.Equal(t, 155.0, cumulative[1].Value)
+
+	assert.Equal(t, 90.0, sumFacts(findFacts(facts, "mau", isTotal)))
+	assert.Equal(t, 100.0, sumFacts(findFacts(facts, "average_order_value", isTotal)))
+	assert.Equal(t, 1800.0, sumFacts(findFacts(facts, "revenue", byCategory)))
+
+	// 支付方式记在类别名称列，合计等于各支付方式金额之和
+	payments := findFacts(facts, "payment_amount", func(f types.AnalyticsFact) bool { return f.CategoryName != "" })
+	require.Len(t, payments, 2)
+	assert.Equal(t, "alipay", payments[0].CategoryName)
+	assert.Equal(t, 3000.0, sumFacts(payments))
+
+	cohort := findFacts(facts, "cohort_retention_rate_2", byMonth)
+	require.Len(t, cohort, 1)
+	assert.Equal(t, "2026-08-01", cohort[0].Date)
+	assert.Equal(t, 25.0, cohort[0].Value)
+	assert.Equal(t, 50.0, sumFacts(findFacts(facts, "cohort_users", byMonth)))
+	assert.Equal(t, 6.0, sumFacts(findFacts(facts, "risk_user_count", isTotal)))
+}
+
+func TestWriteAnalyticsFacts(t *testing.T) {
+	facts := FlattenFinancialData(newTestFinancialData(), time.Date(2026, 10, 14, 0, 0, 0, 0, time.Local))
+
+	t.Run("CSV带表头，每个事实一行", func(t *testing.T) {
+		data, err := WriteAnalyticsFacts(facts, types.AnalyticsFactFormatCSV)
+		require.NoError(t, err)
+
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, len(facts)+1)
+		assert.Equal(t, types.AnalyticsFactColumns, records[0])
+		assert.Equal(t, []string{"financial", "2026-10-14", "period", "0", "", "0", "", "total_revenue", "3000", "CNY"}, records[1])
+		assert.Contains(t, records, []string{"financial", "2026-10-14", "period", "1", "星河贸易", "0", "", "revenue", "2000", "CNY"})
+	})
+
+	t.Run("JSON Lines每行一个事实", func(t *testing.T) {
+		data, err := WriteAnalyticsFacts(facts, types.AnalyticsFactFormatJSONL)
+		require.NoError(t, err)
+
+		var decoded []types.AnalyticsFact
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			var fact types.AnalyticsFact
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &fact))
+			decoded = append(decoded, fact)
+		}
+		assert.Equal(t, facts, decoded)
+	})
+
+	t.Run("不支持的格式", func(t *testing.T) {
+		_, err := WriteAnalyticsFacts(facts, "parquet")
+		assert.Error(t, err)
+	})
+}
+
+// exportAnalyticsService 返回固定分析数据的分析服务，记录查询的租户和时间范围
+type exportAnalyticsService struct {
+	IAnalyticsService
+	financial *types.FinancialReportData
+	queries   []string
+}
+
+func (f *exportAnalyticsService) record(ctx context.Context, dataset string, startDate, endDate time.Time) {
+	f.queries = append(f.queries, dataset+"|"+startDate.Format(time.DateTime)+"|"+endDate.Format(time.DateTime))
+	_ = ctx.Value("tenant_id").(uint64)
+}
+
+func (f *exportAnalyticsService) GetFinancialData(ctx context.Context, startDate, endDate time.Time, merchantID *uint64) (*types.FinancialReportData, error) {
+	f.record(ctx, "financial", startDate, endDate)
+	return f.financial, nil
+}
+
+func (f *exportAnalyticsService) GetMerchantOperationData(ctx context.Context, startDate, endDate time.Time) (*types.MerchantOperationReport, error) {
+	f.record(ctx, "merchant_operation", startDate, endDate)
+	return &types.MerchantOperationReport{}, nil
+}
+
+func (f *exportAnalyticsService) GetCustomerAnalysisData(ctx context.Context, startDate, endDate time.Time) (*types.CustomerAnalysisReport, error) {
+	f.record(ctx, "customer_analysis", startDate, endDate)
+	return &types.CustomerAnalysisReport{}, nil
+}
+
+// exportTenantRepository 内存租户仓储
+type exportTenantRepository struct {
+	repository.ITenantRepository
+	tenants []types.Tenant
+}
+
+func (f *exportTenantRepository) GetByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	for i := range f.tenants {
+		if f.tenants[i].ID == id {
+			return &f.tenants[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *exportTenantRepository) List(ctx context.Context, req *types.ListTenantsRequest) ([]types.Tenant, int, error) {
+	return f.tenants, len(f.tenants), nil
+}
+
+// capturingExportStorage 记录上传的文件
+type capturingExportStorage struct {
+	uploads map[string][]byte
+}
+
+func (f *capturingExportStorage) UploadFromBytes(ctx context.Context, data []byte, fileName, contentType string, options *oss.UploadOptions) (*oss.UploadFileInfo, error) {
+	objectKey := options.Directory + "/" + fileName
+	f.uploads[objectKey] = data
+	return &oss.UploadFileInfo{FileName: fileName, ContentType: contentType, ObjectKey: objectKey}, nil
+}
+
+func TestAnalyticsExportService_ExportDaily(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 15, 2, 30, 0, 0, time.Local)
+	analytics := &exportAnalyticsService{financial: newTestFinancialData()}
+	storage := &capturingExportStorage{uploads: map[string][]byte{}}
+	tenantRepo := &exportTenantRepository{tenants: []types.Tenant{
+		{ID: 1, Config: `{"analytics_export":{"enabled":true,"datasets":["financial"],"format":"jsonl"}}`},
+		{ID: 2, Config: `{"analytics_export":{"enabled":true}}`},
+		{ID: 3, Config: `{"analytics_export":{"enabled":false}}`},
+		{ID: 4},
+	}}
+	exportService := NewAnalyticsExportServiceForTest(analytics, storage, tenantRepo,
+		export.NewQueueForTest(&memoryExportJobRepository{}, types.ExportLimits{}, t.TempDir()), &ReportRangeLimits{}, func() time.Time { return now })
+
+	uploaded, err := exportService.ExportDaily(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, uploaded)
+
+	t.Run("导出前一天整天的数据", func(t *testing.T) {
+		assert.Contains(t, analytics.queries, "financial|2026-10-14 00:00:00|2026-10-14 23:59:59")
+		assert.Len(t, analytics.queries, 4)
+	})
+
+	t.Run("按租户、数据集和日期分区存放", func(t *testing.T) {
+		assert.Contains(t, storage.uploads, "analytics/1/financial/dt=2026-10-14/facts.jsonl")
+		assert.Contains(t, storage.uploads, "analytics/2/financial/dt=2026-10-14/facts.csv")
+		assert.Contains(t, storage.uploads, "analytics/2/merchant_operation/dt=2026-10-14/facts.csv")
+		assert.Contains(t, storage.uploads, "analytics/2/customer_analysis/dt=2026-10-14/facts.csv")
+	})
+
+	t.Run("上传的事实数据与分析数据一致", func(t *testing.T) {
+		expected, err := WriteAnalyticsFacts(FlattenFinancialData(analytics.financial, now.AddDate(0, 0, -1)), types.AnalyticsFactFormatJSONL)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(storage.uploads["analytics/1/financial/dt=2026-10-14/facts.jsonl"]))
+
+		// 没有数据的数据集只有表头
+		assert.Equal(t, "dataset,date,grain,merchant_id,merchant_name,category_id,category_name,metric,value,unit\n",
+			string(storage.uploads["analytics/2/customer_analysis/dt=2026-10-14/facts.csv"]))
+	})
+}
+
+// memoryExportJobRepository 内存导出任务仓储
+type memoryExportJobRepository struct {
+	mu   sync.Mutex
+	jobs []*types.ExportJob
+}
+
+func (m *memoryExportJobRepository) Create(ctx context.Context, job *types.ExportJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job.ID = uint64(len(m.jobs) + 1)
+	job.TenantID = gconv.Uint64(ctx.Value("tenant_id"))
+	job.CreatedAt = time.Now()
+	saved := *job
+	m.jobs = append(m.jobs, &saved)
+	return nil
+}
+
+func (m *memoryExportJobRepository) Transition(ctx context.Context, job *types.ExportJob, from types.ExportJobStatus) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.jobs[job.ID-1].Status != from {
+		return false, nil
+	}
+	saved := *job
+	m.jobs[job.ID-1] = &saved
+	return true, nil
+}
+
+func (m *memoryExportJobRepository) GetByUUID(ctx context.Context, uuid string) (*types.ExportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		if job.UUID == uuid && job.TenantID == gconv.Uint64(ctx.Value("tenant_id")) {
+			found := *job
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryExportJobRepository) CountByStatus(ctx context.Context, status types.ExportJobStatus) (int, error) {
+	return 0, nil
+}
+
+func (m *memoryExportJobRepository) UsageSince(ctx context.Context, since time.Time) (*types.ExportUsage, error) {
+	return &types.ExportUsage{}, nil
+}
+
+func (m *memoryExportJobRepository) ListQueued(ctx context.Context, kinds []string, limit int) ([]types.ExportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var queued []types.ExportJob
+	for _, job := range m.jobs {
+		if job.Status == types.ExportJobStatusQueued && slices.Contains(kinds, job.Kind) && len(queued) < limit {
+			queued = append(queued, *job)
+		}
+	}
+	return queued, nil
+}
+
+func (m *memoryExportJobRepository) FailStale(ctx context.Context, startedBefore time.Time, reason string) (int, error) {
+	return 0, nil
+}
+
+func TestAnalyticsExportService_FactsExport(t *testing.T) {
+	ctx := context.WithValue(context.WithValue(context.Background(), "tenant_id", uint64(1)), "user_id", uint64(7))
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.Local)
+	analytics := &exportAnalyticsService{financial: newTestFinancialData()}
+	tenantRepo := &exportTenantRepository{tenants: []types.Tenant{{ID: 1, Config: `{"plan":"basic"}`}}}
+	repo := &memoryExportJobRepository{}
+	queue := export.NewQueueForTest(repo, types.ExportLimits{}, t.TempDir())
+	limits := &ReportRangeLimits{Default: 365, Plans: map[string]*ReportRangeLimits{"basic": {Default: 31}}}
+	exportService := NewAnalyticsExportServiceForTest(analytics, &capturingExportStorage{uploads: map[string][]byte{}}, tenantRepo,
+		queue, limits, func() time.Time { return now })
+
+	startDate := time.Date(2026, 9, 1, 0, 0, 0, 0, time.Local)
+
+	t.Run("导出任务在后台生成与分析数据一致的文件", func(t *testing.T) {
+		query := &types.AnalyticsFactsExportQuery{Dataset: types.ReportTypeFinancial, StartDate: startDate, EndDate: startDate.AddDate(0, 0, 30)}
+		require.NoError(t, exportService.PrepareFactsExport(ctx, query))
+		assert.Equal(t, types.AnalyticsFactFormatCSV, query.Format)
+
+		job, err := exportService.CreateFactsExport(ctx, query)
+		require.NoError(t, err)
+		assert.Equal(t, types.ExportJobStatusQueued, job.Status)
+		assert.Equal(t, "financial_facts_20260901_20261001.csv", job.FileName)
+		assert.Empty(t, analytics.queries, "创建任务时不查询分析数据")
+
+		started, err := queue.DispatchPending(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, started)
+		queue.Wait()
+
+		completed, err := exportService.GetFactsExportFile(ctx, job.UUID)
+		require.NoError(t, err)
+		data, err := os.ReadFile(completed.FilePath)
+		require.NoError(t, err)
+		facts := FlattenFinancialData(analytics.financial, startDate)
+		expected, err := WriteAnalyticsFacts(facts, types.AnalyticsFactFormatCSV)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(data))
+		assert.Equal(t, len(facts), completed.RowCount)
+	})
+
+	t.Run("时间范围超过套餐上限时不创建任务", func(t *testing.T) {
+		queries := len(analytics.queries)
+		query := &types.AnalyticsFactsExportQuery{Dataset: types.ReportTypeFinancial, StartDate: startDate, EndDate: startDate.AddDate(0, 2, 0)}
+		err := exportService.PrepareFactsExport(ctx, query)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "basic套餐下financial报表的上限为31天")
+		assert.Len(t, analytics.queries, queries)
+	})
+
+	t.Run("不支持的数据集和格式", func(t *testing.T) {
+		assert.Error(t, exportService.PrepareFactsExport(ctx, &types.AnalyticsFactsExportQuery{Dataset: types.ReportTypeReconciliation, StartDate: startDate, EndDate: startDate}))
+		assert.Error(t, exportService.PrepareFactsExport(ctx, &types.AnalyticsFactsExportQuery{Dataset: types.ReportTypeFinancial, Format: "parquet", StartDate: startDate, EndDate: startDate}))
+		assert.Error(t, exportService.PrepareFactsExport(ctx, &types.AnalyticsFactsExportQuery{Dataset: types.ReportTypeFinancial, StartDate: startDate, EndDate: startDate.AddDate(0, 0, -1)}))
+	})
+
+	t.Run("其他类型的导出任务不可见", func(t *testing.T) {
+		other := &types.ExportJob{UUID: "exp_other", Kind: types.ExportKindOrderStatusHistory, Status: types.ExportJobStatusCompleted}
+		require.NoError(t, repo.Create(ctx, other))
+		_, err := exportService.GetFactsExport(ctx, "exp_other")
+		assert.ErrorIs(t, err, export.ErrJobNotFound)
+	})
+}
+
+func TestAnalyticsExportPolicy_Validate(t *testing.T) {
+	assert.NoError(t, (&types.AnalyticsExportPolicy{Enabled: true}).Validate())
+	assert.NoError(t, (&types.AnalyticsExportPolicy{Datasets: []types.ReportType{types.ReportTypeCustomerAnalysis}, Format: types.AnalyticsFactFormatJSONL}).Validate())
+	assert.Error(t, (&types.AnalyticsExportPolicy{Format: "parquet"}).Validate())
+	assert.Error(t, (&types.AnalyticsExportPolicy{Datasets: []types.ReportType{types.ReportTypeReconciliation}}).Validate())
+
+	policy := &types.AnalyticsExportPolicy{}
+	assert.Equal(t, types.AnalyticsExportDatasets, policy.ExportDatasets())
+	assert.Equal(t, types.AnalyticsFactFormatCSV, policy.ExportFormat())
+}
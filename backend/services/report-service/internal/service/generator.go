@@ -21,7 +21,7 @@ type IReportGeneratorService interface {
 	GetReportByUUID(ctx context.Context, uuid string) (*types.Report, error)
 	ListReports(ctx context.Context, req *types.ReportListRequest) ([]*types.Report, int, error)
 	DeleteReport(ctx context.Context, reportID uint64) error
-	DownloadReport(ctx context.Context, reportUUID string) (string, error)
+	DownloadReport(ctx context.Context, reportUUID string) (*types.Report, error)
 	CleanupCache(ctx context.Context) error
 	GetCacheStats(ctx context.Context) (map[string]interface{}, error)
 	WarmupCache(ctx context.Context, reportType types.ReportType) error
@@ -34,6 +34,10 @@ type ReportGeneratorService struct {
 	templateEngine   ITemplateEngine
 	pdfGenerator     IPDFGenerator
 	cacheManager     ICacheManager
+	rangeLimits      *ReportRangeLimits
+	planProvider     TenantPlanProvider
+	queue            *ReportGenerationQueue
+	branding         *ReportBrandingLoader // 为空时使用默认品牌
 }
 
 // NewReportGeneratorService 创建报表生成服务实例
@@ -44,6 +48,10 @@ func NewReportGeneratorService() IReportGeneratorService {
 		templateEngine:   NewTemplateEngine(),
 		pdfGenerator:     NewPDFGenerator(),
 		cacheManager:     NewCacheManager(),
+		rangeLimits:      LoadReportRangeLimits(context.Background()),
+		planProvider:     NewTenantPlanProvider(repository.NewTenantRepository()),
+		queue:            DefaultReportGenerationQueue(),
+		branding:         NewReportBrandingLoader(),
 	}
 }
 
@@ -60,7 +68,7 @@ func (s *ReportGeneratorService) GenerateReport(ctx context.Context, req *types.
 		"file_format", req.FileFormat)
 	
 	// 验证请求参数
-	if err := s.validateGenerateRequest(req); err != nil {
+	if err := s.validateGenerateRequest(ctx, tenantID, req); err != nil {
 		return nil, fmt.Errorf("参数验证失败: %v", err)
 	}
 	
@@ -82,7 +90,7 @@ func (s *ReportGeneratorService) GenerateReport(ctx context.Context, req *types.
 		PeriodType:  req.PeriodType,
 		StartDate:   req.StartDate,
 		EndDate:     req.EndDate,
-		Status:      types.ReportStatusGenerating,
+		Status:      types.ReportStatusQueued,
 		FileFormat:  req.FileFormat,
 		GeneratedBy: userID,
 		ExpiresAt:   timePtr(time.Now().Add(30 * 24 * time.Hour)), // 30天后过期
@@ -93,20 +101,51 @@ func (s *ReportGeneratorService) GenerateReport(ctx context.Context, req *types.
 		return nil, fmt.Errorf("创建报表记录失败: %v", err)
 	}
 	
-	// 异步生成报表
-	go s.generateReportAsync(context.Background(), report, req)
+	// 提交到生成队列，并发已满时排队等待
+	position, err := s.queue.Submit(tenantID, report.UUID, func() {
+		s.generateReportAsync(context.Background(), report, req)
+	})
+	if err != nil {
+		if delErr := s.reportRepo.DeleteReport(ctx, report.ID); delErr != nil {
+			g.Log().Warning(ctx, "删除未入队的报表记录失败", "report_id", report.ID, "error", delErr)
+		}
+		return nil, err
+	}
+	if position > 0 {
+		g.Log().Info(ctx, "报表生成排队中", "report_uuid", report.UUID, "queue_position", position)
+		// 报表记录会被生成任务修改，返回副本
+		queued := *report
+		queued.QueuePosition = position
+		return &queued, nil
+	}
 	
 	return report, nil
 }
 
 // GetReport 获取报表信息
 func (s *ReportGeneratorService) GetReport(ctx context.Context, reportID uint64) (*types.Report, error) {
-	return s.reportRepo.GetReportByID(ctx, reportID)
+	report, err := s.reportRepo.GetReportByID(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withQueuePosition(report), nil
 }
 
 // GetReportByUUID 根据UUID获取报表
 func (s *ReportGeneratorService) GetReportByUUID(ctx context.Context, uuid string) (*types.Report, error) {
-	return s.reportRepo.GetReportByUUID(ctx, uuid)
+	report, err := s.reportRepo.GetReportByUUID(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+	return s.withQueuePosition(report), nil
+}
+
+// withQueuePosition 为排队中的报表填充预计排队位置
+func (s *ReportGeneratorService) withQueuePosition(report *types.Report) *types.Report {
+	if report != nil && report.Status == types.ReportStatusQueued {
+		report.QueuePosition = s.queue.Position(report.TenantID, report.UUID)
+	}
+	return report
 }
 
 // ListReports 获取报表列表
@@ -132,31 +171,31 @@ func (s *ReportGeneratorService) DeleteReport(ctx context.Context, reportID uint
 	return s.reportRepo.DeleteReport(ctx, reportID)
 }
 
-// DownloadReport 下载报表文件
-func (s *ReportGeneratorService) DownloadReport(ctx context.Context, reportUUID string) (string, error) {
+// DownloadReport 获取可下载的报表，返回的报表文件格式与实际文件一致
+func (s *ReportGeneratorService) DownloadReport(ctx context.Context, reportUUID string) (*types.Report, error) {
 	report, err := s.reportRepo.GetReportByUUID(ctx, reportUUID)
 	if err != nil {
-		return "", fmt.Errorf("报表不存在: %v", err)
+		return nil, fmt.Errorf("报表不存在: %v", err)
 	}
 	
 	if report.Status != types.ReportStatusCompleted {
-		return "", fmt.Errorf("报表尚未生成完成，当前状态: %s", report.Status)
+		return nil, fmt.Errorf("报表尚未生成完成，当前状态: %s", report.Status)
 	}
 	
 	if report.FilePath == "" {
-		return "", fmt.Errorf("报表文件路径为空")
+		return nil, fmt.Errorf("报表文件路径为空")
 	}
 	
 	// 检查文件是否存在
 	if _, err := os.Stat(report.FilePath); os.IsNotExist(err) {
-		return "", fmt.Errorf("报表文件不存在")
+		return nil, fmt.Errorf("报表文件不存在")
 	}
 	
-	return report.FilePath, nil
+	return report, nil
 }
 
 // validateGenerateRequest 验证报表生成请求
-func (s *ReportGeneratorService) validateGenerateRequest(req *types.ReportCreateRequest) error {
+func (s *ReportGeneratorService) validateGenerateRequest(ctx context.Context, tenantID uint64, req *types.ReportCreateRequest) error {
 	if req.StartDate.After(req.EndDate) {
 		return fmt.Errorf("开始日期不能晚于结束日期")
 	}
@@ -165,15 +204,13 @@ func (s *ReportGeneratorService) validateGenerateRequest(req *types.ReportCreate
 		return fmt.Errorf("结束日期不能晚于当前时间")
 	}
 	
-	// 检查时间范围是否合理
-	duration := req.EndDate.Sub(req.StartDate)
-	maxDuration := 365 * 24 * time.Hour // 最大1年
-	
-	if duration > maxDuration {
-		return fmt.Errorf("时间范围不能超过1年")
-	}
-	
-	return nil
+	// 检查时间范围是否超过租户套餐下该报表类型的上限，避免无限制的大范围扫描
+	return s.rangeLimits.Check(s.tenantPlan(ctx, tenantID), req.ReportType, req.StartDate, req.EndDate)
+}
+
+// tenantPlan 获取租户订阅套餐，获取失败时按默认套餐处理
+func (s *ReportGeneratorService) tenantPlan(ctx context.Context, tenantID uint64) string {
+	return s.planProvider.Plan(ctx, tenantID)
 }
 
 // generateReportAsync 异步生成报表
@@ -200,8 +237,8 @@ func (s *ReportGeneratorService) generateReportAsync(ctx context.Context, report
 			report.FilePath = filePath
 			report.DataSummary = dataSummary
 			
-			// 缓存生成的报表
-			if s.cacheManager.ShouldUseCache(req) {
+			// 缓存生成的报表，按其他格式交付的报表不缓存，避免后续请求命中降级结果
+			if s.cacheManager.ShouldUseCache(req) && report.FileFormat == req.FileFormat {
 				if cacheErr := s.cacheManager.CacheReport(ctx, req, report); cacheErr != nil {
 					g.Log().Warning(ctx, "缓存报表失败", "report_id", report.ID, "error", cacheErr)
 				} else {
@@ -234,27 +271,47 @@ func (s *ReportGeneratorService) generateReportAsync(ctx context.Context, report
 			return
 		}
 		
+	case types.ReportTypeReconciliation:
+		data, err = s.analyticsService.GetReconciliationData(ctx, req.StartDate, req.EndDate, req.MerchantID)
+		if err != nil {
+			return
+		}
+		
 	default:
 		err = fmt.Errorf("不支持的报表类型: %s", req.ReportType)
 		return
 	}
 	
 	// 生成数据摘要
-	if dataSummary, err = json.Marshal(s.generateDataSummary(data)); err != nil {
-		g.Log().Warning(ctx, "生成数据摘要失败", "error", err)
-	}
+	summary := s.generateDataSummary(data)
 	
 	// 根据文件格式生成文件
 	switch req.FileFormat {
 	case types.FileFormatExcel:
 		filePath, err = s.generateExcelReport(ctx, report, data)
 	case types.FileFormatPDF:
-		filePath, err = s.generatePDFReport(ctx, report, data)
+		var result *PDFResult
+		if result, err = s.generatePDFReport(ctx, report, data); err == nil {
+			filePath = result.FilePath
+			// PDF转换失败按HTML交付时，报表格式随实际文件调整，下载时返回正确的内容类型
+			report.FileFormat = result.FileFormat
+			summary["pdf_converter"] = result.Converter
+			summary["delivered_format"] = result.FileFormat
+		}
 	case types.FileFormatJSON:
 		filePath, err = s.generateJSONReport(ctx, report, data)
 	default:
 		err = fmt.Errorf("不支持的文件格式: %s", req.FileFormat)
 	}
+	if err != nil {
+		return
+	}
+	
+	if summaryJSON, marshalErr := json.Marshal(summary); marshalErr != nil {
+		g.Log().Warning(ctx, "生成数据摘要失败", "error", marshalErr)
+	} else {
+		dataSummary = summaryJSON
+	}
 }
 
 // generateExcelReport 生成Excel报表
@@ -270,8 +327,10 @@ func (s *ReportGeneratorService) generateExcelReport(ctx context.Context, report
 		"report_type", report.ReportType,
 		"report_uuid", report.UUID)
 	
-	// 删除默认的Sheet1工作表
-	f.DeleteSheet("Sheet1")
+	// 默认的Sheet1工作表作为报表信息页，显示租户的Logo和公司名称
+	if err := f.SetSheetName("Sheet1", excelBrandingSheet); err != nil {
+		return "", fmt.Errorf("创建报表信息工作表失败: %v", err)
+	}
 	
 	// 根据报表类型创建不同的工作表结构
 	switch report.ReportType {
@@ -290,6 +349,11 @@ func (s *ReportGeneratorService) generateExcelReport(ctx context.Context, report
 		if err != nil {
 			return "", fmt.Errorf("创建客户分析报表Excel失败: %v", err)
 		}
+	case types.ReportTypeReconciliation:
+		err := s.createReconciliationExcelSheets(f, data.(*types.ReconciliationReportData))
+		if err != nil {
+			return "", fmt.Errorf("创建财务对账报表Excel失败: %v", err)
+		}
 	}
 	
 	// 应用Excel样式
@@ -297,6 +361,18 @@ func (s *ReportGeneratorService) generateExcelReport(ctx context.Context, report
 		g.Log().Warning(ctx, "应用Excel样式失败", "error", err)
 	}
 	
+	// 对账异常行需要在通用样式之后标红
+	if reconciliation, ok := data.(*types.ReconciliationReportData); ok {
+		if err := s.highlightReconciliationAnomalies(f, reconciliation); err != nil {
+			g.Log().Warning(ctx, "标记对账异常失败", "error", err)
+		}
+	}
+	
+	// 报表品牌在通用样式之后写入，避免报表信息页套用数据表样式
+	if err := writeExcelBrandingSheet(f, report, s.branding.Load(ctx, report.TenantID)); err != nil {
+		g.Log().Warning(ctx, "写入报表品牌失败", "error", err)
+	}
+	
 	// 确保报表目录存在
 	reportDir := s.getReportDir()
 	if err := os.MkdirAll(reportDir, 0755); err != nil {
@@ -320,7 +396,7 @@ func (s *ReportGeneratorService) generateExcelReport(ctx context.Context, report
 }
 
 // generatePDFReport 生成PDF报表
-func (s *ReportGeneratorService) generatePDFReport(ctx context.Context, report *types.Report, data interface{}) (string, error) {
+func (s *ReportGeneratorService) generatePDFReport(ctx context.Context, report *types.Report, data interface{}) (*PDFResult, error) {
 	return s.pdfGenerator.GeneratePDFReport(ctx, report, data)
 }
 
@@ -372,6 +448,10 @@ func (s *ReportGeneratorService) fillFinancialDataToExcel(f *excelize.File, shee
 	f.SetCellValue(sheetName, "B7", data.MerchantCount)
 	f.SetCellValue(sheetName, "A8", "客户总数")
 	f.SetCellValue(sheetName, "B8", data.CustomerCount)
+	f.SetCellValue(sheetName, "A9", "代收税额")
+	f.SetCellValue(sheetName, "B9", data.TaxCollected.Amount)
+	f.SetCellValue(sheetName, "A10", "不含税收入")
+	f.SetCellValue(sheetName, "B10", data.NetRevenue.Amount)
 	
 	// 如果有分解数据，添加更多工作表
 	if data.Breakdown != nil {
@@ -499,6 +579,7 @@ func (s *ReportGeneratorService) generateDataSummary(data interface{}) map[strin
 	case *types.FinancialReportData:
 		summary["type"] = "financial"
 		summary["total_revenue"] = d.TotalRevenue.Amount
+		summary["tax_collected"] = d.TaxCollected.Amount
 		summary["order_count"] = d.OrderCount
 		summary["merchant_count"] = d.MerchantCount
 		summary["customer_count"] = d.CustomerCount
@@ -507,6 +588,7 @@ func (s *ReportGeneratorService) generateDataSummary(data interface{}) map[strin
 		summary["type"] = "merchant_operation"
 		summary["merchant_count"] = len(d.MerchantRankings)
 		summary["category_count"] = len(d.CategoryAnalysis)
+		summary["satisfaction_merchant_count"] = len(d.Satisfaction)
 		
 	case *types.CustomerAnalysisReport:
 		summary["type"] = "customer_analysis"
@@ -514,6 +596,12 @@ func (s *ReportGeneratorService) generateDataSummary(data interface{}) map[strin
 		if d.ActivityMetrics != nil {
 			summary["mau"] = d.ActivityMetrics.MAU
 		}
+		
+	case *types.ReconciliationReportData:
+		summary["type"] = "reconciliation"
+		summary["merchant_count"] = d.MerchantCount
+		summary["anomaly_merchants"] = d.AnomalyMerchants
+		summary["total_revenue"] = d.TotalRevenue
 	}
 	
 	summary["generated_at"] = time.Now()
@@ -577,6 +665,14 @@ func (s *ReportGeneratorService) createFinancialExcelSheets(f *excelize.File, da
 	f.SetCellValue(overviewSheet, "B9", data.RightsConsumed)
 	f.SetCellValue(overviewSheet, "C9", "份")
 	
+	f.SetCellValue(overviewSheet, "A10", "代收税额")
+	f.SetCellValue(overviewSheet, "B10", data.TaxCollected.Amount)
+	f.SetCellValue(overviewSheet, "C10", "元")
+	
+	f.SetCellValue(overviewSheet, "A11", "不含税收入")
+	f.SetCellValue(overviewSheet, "B11", data.NetRevenue.Amount)
+	f.SetCellValue(overviewSheet, "C11", "元")
+	
 	// 创建商户收入排行工作表
 	if data.Breakdown != nil && len(data.Breakdown.RevenueByMerchant) > 0 {
 		merchantSheet := "商户收入排行"
@@ -684,6 +780,26 @@ func (s *ReportGeneratorService) createMerchantOperationExcelSheets(f *excelize.
 		}
 	}
 	
+	// 商品评价满意度工作表
+	if len(data.Satisfaction) > 0 {
+		satisfactionSheet := "评价满意度"
+		f.NewSheet(satisfactionSheet)
+		
+		f.SetCellValue(satisfactionSheet, "A1", "商户商品评价满意度")
+		f.SetCellValue(satisfactionSheet, "A3", "商户名称")
+		f.SetCellValue(satisfactionSheet, "B3", "平均评分")
+		f.SetCellValue(satisfactionSheet, "C3", "评价数量")
+		f.SetCellValue(satisfactionSheet, "D3", "差评数量")
+		
+		for i, item := range data.Satisfaction {
+			row := i + 4
+			f.SetCellValue(satisfactionSheet, fmt.Sprintf("A%d", row), item.MerchantName)
+			f.SetCellValue(satisfactionSheet, fmt.Sprintf("B%d", row), item.AverageRating)
+			f.SetCellValue(satisfactionSheet, fmt.Sprintf("C%d", row), item.ReviewCount)
+			f.SetCellValue(satisfactionSheet, fmt.Sprintf("D%d", row), item.LowRatingCount)
+		}
+	}
+	
 	return nil
 }
 
@@ -806,6 +922,11 @@ func (s *ReportGeneratorService) applyExcelStyles(f *excelize.File) error {
 	// 应用样式到所有工作表
 	sheets := f.GetSheetList()
 	for _, sheetName := range sheets {
+		// 报表信息页单独排版
+		if sheetName == excelBrandingSheet {
+			continue
+		}
+		
 		// 应用标题样式
 		f.SetCellStyle(sheetName, "A1", "A1", titleStyle)
 		
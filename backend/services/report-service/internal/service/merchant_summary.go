@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// merchantSummaryCacheTTL 商户汇总数据缓存时间，仪表盘频繁刷新，缓存时间短于运营报表
+const merchantSummaryCacheTTL = 10 * time.Minute
+
+var (
+	// ErrMerchantSummaryForbidden 商户用户只能查看本商户的汇总数据
+	ErrMerchantSummaryForbidden = errors.New("无权查看该商户的数据")
+	// ErrMerchantSummaryNotFound 商户不存在或不属于当前租户
+	ErrMerchantSummaryNotFound = errors.New("商户不存在")
+	// ErrMerchantSummaryInvalidRange 统计时间范围无效
+	ErrMerchantSummaryInvalidRange = errors.New("结束时间必须晚于开始时间")
+)
+
+// GetMerchantSummary 获取单个商户在 [startDate, endDate) 内的关键指标及与上一周期的对比，
+// 用于商户仪表盘一次请求获取全部数据
+func (s *AnalyticsService) GetMerchantSummary(ctx context.Context, merchantID uint64, startDate, endDate time.Time) (*types.MerchantSummary, error) {
+	if !endDate.After(startDate) {
+		return nil, ErrMerchantSummaryInvalidRange
+	}
+
+	tenantID, err := s.authorizeMerchantSummary(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := s.buildCacheKey("merchant_summary", tenantID, startDate, endDate, &merchantID)
+	cachedData, err := s.getFromCache(ctx, cacheKey)
+	if err == nil && cachedData != nil {
+		var data types.MerchantSummary
+		if err := json.Unmarshal(cachedData.Data, &data); err == nil {
+			g.Log().Debug(ctx, "商户汇总数据从缓存获取", "cache_key", cacheKey)
+			return &data, nil
+		}
+	}
+
+	current, err := s.reportRepo.GetMerchantSummaryFigures(ctx, tenantID, merchantID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("获取商户汇总数据失败: %v", err)
+	}
+
+	previousStart, previousEnd := types.PreviousPeriod(startDate, endDate)
+	previous, err := s.reportRepo.GetMerchantSummaryFigures(ctx, tenantID, merchantID, previousStart, previousEnd)
+	if err != nil {
+		return nil, fmt.Errorf("获取商户上一周期汇总数据失败: %v", err)
+	}
+
+	data := types.NewMerchantSummary(merchantID, startDate, endDate, *current, *previous)
+
+	s.setToCache(ctx, cacheKey, "merchant_summary", data, merchantSummaryCacheTTL)
+
+	return data, nil
+}
+
+// authorizeMerchantSummary 校验调用方可以查看该商户：商户用户只能查看本商户，
+// 租户用户只能查看本租户下的商户。返回当前租户ID
+func (s *AnalyticsService) authorizeMerchantSummary(ctx context.Context, merchantID uint64) (uint64, error) {
+	tenantID, _ := ctx.Value("tenant_id").(uint64)
+	if tenantID == 0 {
+		return 0, fmt.Errorf("缺少租户信息")
+	}
+
+	if callerMerchantID, ok := ctx.Value("merchant_id").(uint64); ok && callerMerchantID != merchantID {
+		return 0, ErrMerchantSummaryForbidden
+	}
+
+	merchant, err := s.merchantRepo.GetByID(ctx, merchantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrMerchantSummaryNotFound
+		}
+		return 0, fmt.Errorf("获取商户信息失败: %v", err)
+	}
+	if merchant == nil || merchant.TenantID != tenantID {
+		return 0, ErrMerchantSummaryNotFound
+	}
+
+	return tenantID, nil
+}
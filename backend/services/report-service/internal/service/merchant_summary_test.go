@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// summaryReportRepository 按时间段返回固定指标的报表仓储，记录查询范围
+type summaryReportRepository struct {
+	repository.IReportRepository
+	figures map[time.Time]types.MerchantSummaryFigures
+	cache   map[string]*types.AnalyticsCache
+	queries int
+}
+
+func (f *summaryReportRepository) GetMerchantSummaryFigures(ctx context.Context, tenantID, merchantID uint64, startDate, endDate time.Time) (*types.MerchantSummaryFigures, error) {
+	f.queries++
+	figures := f.figures[startDate]
+	return &figures, nil
+}
+
+func (f *summaryReportRepository) GetAnalyticsCache(ctx context.Context, cacheKey string) (*types.AnalyticsCache, error) {
+	return f.cache[cacheKey], nil
+}
+
+func (f *summaryReportRepository) SetAnalyticsCache(ctx context.Context, cache *types.AnalyticsCache) error {
+	f.cache[cache.CacheKey] = cache
+	return nil
+}
+
+// summaryMerchantRepository 内存商户仓储
+type summaryMerchantRepository struct {
+	repository.MerchantRepository
+	merchants map[uint64]*types.Merchant
+}
+
+func (f *summaryMerchantRepository) GetByID(ctx context.Context, id uint64) (*types.Merchant, error) {
+	merchant, exists := f.merchants[id]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	return merchant, nil
+}
+
+func newMerchantSummaryTestService() (IAnalyticsService, *summaryReportRepository) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	reportRepo := &summaryReportRepository{
+		figures: map[time.Time]types.MerchantSummaryFigures{
+			start:                   {Revenue: 1200, OrderCount: 12, CustomerCount: 8, AvgOrderValue: 100, RightsConsumed: 60},
+			start.AddDate(0, 0, -7): {Revenue: 800, OrderCount: 10, CustomerCount: 8, AvgOrderValue: 80, RightsConsumed: 40},
+		},
+		cache: map[string]*types.AnalyticsCache{},
+	}
+	merchantRepo := &summaryMerchantRepository{merchants: map[uint64]*types.Merchant{
+		1: {ID: 1, TenantID: 1},
+		2: {ID: 2, TenantID: 1},
+		3: {ID: 3, TenantID: 2},
+	}}
+	return NewAnalyticsServiceForTest(reportRepo, merchantRepo), reportRepo
+}
+
+func TestGetMerchantSummary_Figures(t *testing.T) {
+	analyticsService, reportRepo := newMerchantSummaryTestService()
+	ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+
+	summary, err := analyticsService.GetMerchantSummary(ctx, 1, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), summary.MerchantID)
+	assert.Equal(t, 1200.0, summary.Current.Revenue)
+	assert.Equal(t, 12, summary.Current.OrderCount)
+	assert.Equal(t, 800.0, summary.Previous.Revenue)
+	assert.Equal(t, start.AddDate(0, 0, -7), summary.PreviousStartDate)
+	assert.Equal(t, 50.0, *summary.Growth.Revenue)
+	assert.Equal(t, 20.0, *summary.Growth.OrderCount)
+	assert.Equal(t, 0.0, *summary.Growth.CustomerCount)
+	assert.Equal(t, 25.0, *summary.Growth.AvgOrderValue)
+	assert.Equal(t, 50.0, *summary.Growth.RightsConsumed)
+	assert.Equal(t, 2, reportRepo.queries)
+
+	// 第二次请求从缓存获取
+	cached, err := analyticsService.GetMerchantSummary(ctx, 1, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, summary.Current, cached.Current)
+	assert.Equal(t, 2, reportRepo.queries)
+}
+
+func TestGetMerchantSummary_Scope(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	tenantCtx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+
+	t.Run("租户用户可以查看本租户下的任意商户", func(t *testing.T) {
+		analyticsService, _ := newMerchantSummaryTestService()
+		_, err := analyticsService.GetMerchantSummary(tenantCtx, 2, start, end)
+		assert.NoError(t, err)
+	})
+
+	t.Run("商户用户只能查看本商户", func(t *testing.T) {
+		analyticsService, reportRepo := newMerchantSummaryTestService()
+		merchantCtx := context.WithValue(tenantCtx, "merchant_id", uint64(1))
+
+		_, err := analyticsService.GetMerchantSummary(merchantCtx, 1, start, end)
+		assert.NoError(t, err)
+
+		_, err = analyticsService.GetMerchantSummary(merchantCtx, 2, start, end)
+		assert.ErrorIs(t, err, ErrMerchantSummaryForbidden)
+		assert.Equal(t, 2, reportRepo.queries)
+	})
+
+	t.Run("不能查看其他租户的商户", func(t *testing.T) {
+		analyticsService, reportRepo := newMerchantSummaryTestService()
+		_, err := analyticsService.GetMerchantSummary(tenantCtx, 3, start, end)
+		assert.ErrorIs(t, err, ErrMerchantSummaryNotFound)
+
+		_, err = analyticsService.GetMerchantSummary(tenantCtx, 99, start, end)
+		assert.ErrorIs(t, err, ErrMerchantSummaryNotFound)
+		assert.Equal(t, 0, reportRepo.queries)
+	})
+
+	t.Run("无效的时间范围", func(t *testing.T) {
+		analyticsService, _ := newMerchantSummaryTestService()
+		_, err := analyticsService.GetMerchantSummary(tenantCtx, 1, end, start)
+		assert.ErrorIs(t, err, ErrMerchantSummaryInvalidRange)
+	})
+}
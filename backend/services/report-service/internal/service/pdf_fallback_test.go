@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unavailableConverter 模拟未安装的转换器，记录调用次数
+func unavailableConverter(calls *int) func(htmlPath, pdfPath string) error {
+	return func(htmlPath, pdfPath string) error {
+		*calls++
+		return errors.New("转换器未安装")
+	}
+}
+
+func newPDFFallbackTestReport() *types.Report {
+	return &types.Report{
+		UUID:       "pdf-fallback",
+		ReportType: types.ReportTypeFinancial,
+		FileFormat: types.FileFormatPDF,
+	}
+}
+
+func TestGeneratePDFReport_AllConvertersUnavailable(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("按HTML交付", func(t *testing.T) {
+		dir := t.TempDir()
+		var primaryCalls, secondaryCalls int
+		generator := NewPDFGeneratorForTest(&PDFConversionConfig{
+			Converters: []string{"primary", "secondary"},
+			Retries:    1,
+			Fallback:   PDFFallbackHTML,
+		}, map[string]func(htmlPath, pdfPath string) error{
+			"primary":   unavailableConverter(&primaryCalls),
+			"secondary": unavailableConverter(&secondaryCalls),
+		}, dir)
+
+		result, err := generator.GeneratePDFReport(ctx, newPDFFallbackTestReport(), &types.FinancialReportData{})
+		require.NoError(t, err)
+		assert.Equal(t, types.FileFormatHTML, result.FileFormat)
+		assert.Empty(t, result.Converter)
+		assert.Equal(t, ".html", filepath.Ext(result.FilePath))
+		assert.Equal(t, 2, primaryCalls)
+		assert.Equal(t, 2, secondaryCalls)
+
+		// 只保留交付的HTML文件，不残留临时文件
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, filepath.Base(result.FilePath), entries[0].Name())
+		assert.NotContains(t, entries[0].Name(), "temp_")
+	})
+
+	t.Run("报表生成失败", func(t *testing.T) {
+		dir := t.TempDir()
+		var calls int
+		generator := NewPDFGeneratorForTest(&PDFConversionConfig{
+			Converters: []string{"primary"},
+			Fallback:   PDFFallbackFail,
+		}, map[string]func(htmlPath, pdfPath string) error{
+			"primary": unavailableConverter(&calls),
+		}, dir)
+
+		result, err := generator.GeneratePDFReport(ctx, newPDFFallbackTestReport(), &types.FinancialReportData{})
+		assert.ErrorIs(t, err, ErrPDFConversionFailed)
+		assert.Nil(t, result)
+		assert.Equal(t, 1, calls)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestGeneratePDFReport_SecondaryConverter(t *testing.T) {
+	dir := t.TempDir()
+	var primaryCalls, secondaryCalls int
+	generator := NewPDFGeneratorForTest(&PDFConversionConfig{
+		Converters: []string{"primary", "secondary"},
+		Retries:    2,
+		Fallback:   PDFFallbackFail,
+	}, map[string]func(htmlPath, pdfPath string) error{
+		"primary": unavailableConverter(&primaryCalls),
+		"secondary": func(htmlPath, pdfPath string) error {
+			secondaryCalls++
+			return os.WriteFile(pdfPath, []byte("%PDF-1.4"), 0644)
+		},
+	}, dir)
+
+	result, err := generator.GeneratePDFReport(context.Background(), newPDFFallbackTestReport(), &types.FinancialReportData{})
+	require.NoError(t, err)
+	assert.Equal(t, types.FileFormatPDF, result.FileFormat)
+	assert.Equal(t, "secondary", result.Converter)
+	assert.Equal(t, ".pdf", filepath.Ext(result.FilePath))
+	assert.Equal(t, 3, primaryCalls)
+	assert.Equal(t, 1, secondaryCalls)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, filepath.Base(result.FilePath), entries[0].Name())
+}
+
+func TestFileFormatContentType(t *testing.T) {
+	assert.Equal(t, "application/pdf", types.FileFormatPDF.ContentType())
+	assert.Equal(t, "text/html; charset=utf-8", types.FileFormatHTML.ContentType())
+	assert.Equal(t, "application/octet-stream", types.FileFormat("unknown").ContentType())
+}
@@ -6,99 +6,197 @@ import (
 	"fmt"
 	"html/template"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"time"
 
+	"github.com/gofromzero/mer-sys/backend/shared/pdf"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
 )
 
+// ErrPDFConversionFailed 所有PDF转换器均失败
+var ErrPDFConversionFailed = pdf.ErrConversionFailed
+
+// PDFFallbackPolicy 所有PDF转换器都失败时的处理策略
+type PDFFallbackPolicy string
+
+const (
+	PDFFallbackFail PDFFallbackPolicy = "fail" // 报表生成失败
+	PDFFallbackHTML PDFFallbackPolicy = "html" // 以HTML格式交付，报表文件格式改为html
+)
+
+// PDFConversionConfig PDF转换配置
+type PDFConversionConfig struct {
+	Converters []string          `json:"converters"` // 转换器顺序，首个为主转换器，其余依次作为备用
+	Retries    int               `json:"retries"`    // 每个转换器失败后的重试次数
+	Fallback   PDFFallbackPolicy `json:"fallback"`   // 所有转换器都失败时的处理策略
+}
+
+// defaultPDFConverters 默认转换器顺序
+var defaultPDFConverters = pdf.DefaultConverterNames
+
+// LoadPDFConversionConfig 从 report.pdf 配置加载PDF转换配置，未配置的项使用默认值
+func LoadPDFConversionConfig(ctx context.Context) *PDFConversionConfig {
+	config := &PDFConversionConfig{}
+	if err := g.Cfg().MustGet(ctx, "report.pdf").Scan(config); err != nil {
+		g.Log().Warning(ctx, "PDF转换配置无效，使用默认值", "error", err)
+		config = &PDFConversionConfig{}
+	}
+	if len(config.Converters) == 0 {
+		config.Converters = defaultPDFConverters
+	}
+	if config.Retries < 0 {
+		config.Retries = 0
+	}
+	if config.Fallback != PDFFallbackFail {
+		config.Fallback = PDFFallbackHTML
+	}
+	return config
+}
+
+// PDFResult PDF报表生成结果
+type PDFResult struct {
+	FilePath   string           `json:"file_path"`
+	FileFormat types.FileFormat `json:"file_format"` // 按HTML交付时为 html
+	Converter  string           `json:"converter"`   // 生成PDF的转换器，按HTML交付时为空
+}
+
 // IPDFGenerator PDF生成器接口
 type IPDFGenerator interface {
-	GeneratePDFReport(ctx context.Context, report *types.Report, data interface{}) (string, error)
+	GeneratePDFReport(ctx context.Context, report *types.Report, data interface{}) (*PDFResult, error)
 	CreateHTMLTemplate(reportType types.ReportType, data interface{}) (string, error)
 	GetSupportedPDFConverters() []string
 }
 
+// pdfConverter 将HTML文件转换为PDF文件
+type pdfConverter = pdf.Converter
+
 // PDFGenerator PDF生成器实现
 type PDFGenerator struct {
 	templateEngine ITemplateEngine
+	config         *PDFConversionConfig
+	converters     map[string]pdfConverter
+	reportDir      string
+	moneyFormat    repository.MoneyFormatProvider // 为空时金额使用默认格式
+	branding       *ReportBrandingLoader          // 为空时使用默认品牌
 }
 
 // NewPDFGenerator 创建PDF生成器实例
 func NewPDFGenerator() IPDFGenerator {
 	return &PDFGenerator{
 		templateEngine: NewTemplateEngine(),
+		config:         LoadPDFConversionConfig(context.Background()),
+		converters:     pdf.Converters(),
+		moneyFormat:    repository.NewTenantMoneyFormatProvider(repository.NewTenantRepository()),
+		branding:       NewReportBrandingLoader(),
+	}
+}
+
+// NewPDFGeneratorForTest 创建使用指定转换器和报表目录的PDF生成器
+func NewPDFGeneratorForTest(config *PDFConversionConfig, converters map[string]func(htmlPath, pdfPath string) error, reportDir string) IPDFGenerator {
+	p := &PDFGenerator{
+		config:     config,
+		converters: make(map[string]pdfConverter, len(converters)),
+		reportDir:  reportDir,
 	}
+	for name, convert := range converters {
+		p.converters[name] = convert
+	}
+	return p
 }
 
-// GeneratePDFReport 生成PDF报表
-func (p *PDFGenerator) GeneratePDFReport(ctx context.Context, report *types.Report, data interface{}) (string, error) {
+// GeneratePDFReport 生成PDF报表。所有转换器都失败时按配置的策略报错或以HTML交付
+func (p *PDFGenerator) GeneratePDFReport(ctx context.Context, report *types.Report, data interface{}) (*PDFResult, error) {
 	g.Log().Info(ctx, "开始生成PDF报表", 
 		"report_type", report.ReportType,
 		"report_uuid", report.UUID)
 	
-	// 创建HTML内容
-	htmlContent, err := p.CreateHTMLTemplate(report.ReportType, data)
+	// 创建HTML内容，金额按租户的货币和区域格式显示，页眉页脚使用租户的报表品牌
+	htmlContent, err := p.createHTMLTemplate(report.ReportType, data, p.moneyFormat.Resolve(ctx, report.TenantID), p.branding.Load(ctx, report.TenantID))
 	if err != nil {
-		return "", fmt.Errorf("创建HTML模板失败: %v", err)
+		return nil, fmt.Errorf("创建HTML模板失败: %v", err)
 	}
 	
 	// 确保报表目录存在
 	reportDir := p.getReportDir()
 	if err := os.MkdirAll(reportDir, 0755); err != nil {
-		return "", fmt.Errorf("创建报表目录失败: %v", err)
+		return nil, fmt.Errorf("创建报表目录失败: %v", err)
 	}
 	
 	// 生成文件路径
-	htmlFilename := fmt.Sprintf("temp_%s_%s_%s.html", 
-		report.ReportType, 
-		report.UUID,
-		time.Now().Format("20060102_150405"))
-	htmlPath := filepath.Join(reportDir, htmlFilename)
-	
-	pdfFilename := fmt.Sprintf("%s_%s_%s.pdf", 
+	baseName := fmt.Sprintf("%s_%s_%s", 
 		report.ReportType, 
 		report.UUID,
 		time.Now().Format("20060102_150405"))
-	pdfPath := filepath.Join(reportDir, pdfFilename)
+	htmlPath := filepath.Join(reportDir, "temp_"+baseName+".html")
+	pdfPath := filepath.Join(reportDir, baseName+".pdf")
 	
 	// 保存HTML文件
 	if err := os.WriteFile(htmlPath, []byte(htmlContent), 0644); err != nil {
-		return "", fmt.Errorf("保存HTML文件失败: %v", err)
+		return nil, fmt.Errorf("保存HTML文件失败: %v", err)
 	}
 	
 	// 尝试转换为PDF
-	pdfOutputPath, err := p.convertHTMLToPDF(ctx, htmlPath, pdfPath)
+	converter, err := p.convertHTMLToPDF(ctx, htmlPath, pdfPath)
 	if err != nil {
-		g.Log().Warning(ctx, "PDF转换失败，返回HTML文件", "error", err)
-		return htmlPath, nil // 转换失败时返回HTML文件
+		if p.fallback() == PDFFallbackFail {
+			os.Remove(htmlPath)
+			return nil, err
+		}
+		
+		// 以HTML交付时去掉临时文件前缀，下载时按HTML格式返回
+		deliveredPath := filepath.Join(reportDir, baseName+".html")
+		if renameErr := os.Rename(htmlPath, deliveredPath); renameErr != nil {
+			os.Remove(htmlPath)
+			return nil, fmt.Errorf("保存HTML报表失败: %v", renameErr)
+		}
+		g.Log().Warning(ctx, "PDF转换失败，报表以HTML格式交付", "report_uuid", report.UUID, "error", err)
+		return &PDFResult{FilePath: deliveredPath, FileFormat: types.FileFormatHTML}, nil
 	}
 	
 	// 清理临时HTML文件
 	os.Remove(htmlPath)
 	
-	g.Log().Info(ctx, "PDF报表生成成功", "file_path", pdfOutputPath)
-	return pdfOutputPath, nil
+	g.Log().Info(ctx, "PDF报表生成成功", "file_path", pdfPath, "converter", converter)
+	return &PDFResult{FilePath: pdfPath, FileFormat: types.FileFormatPDF, Converter: converter}, nil
 }
 
-// CreateHTMLTemplate 创建HTML模板
+// fallback 所有转换器都失败时的处理策略
+func (p *PDFGenerator) fallback() PDFFallbackPolicy {
+	if p.config == nil {
+		return PDFFallbackHTML
+	}
+	return p.config.Fallback
+}
+
+// CreateHTMLTemplate 创建HTML模板，金额使用默认格式，品牌使用默认品牌
 func (p *PDFGenerator) CreateHTMLTemplate(reportType types.ReportType, data interface{}) (string, error) {
+	return p.createHTMLTemplate(reportType, data, types.DefaultMoneyFormatPolicy(), nil)
+}
+
+// createHTMLTemplate 创建HTML模板，模板中的 formatMoney 按指定的金额显示格式格式化金额，
+// 页眉和页脚按指定的报表品牌显示，品牌为空时使用默认品牌
+func (p *PDFGenerator) createHTMLTemplate(reportType types.ReportType, data interface{}, moneyFormat *types.MoneyFormatPolicy, branding *ReportBrandingAssets) (string, error) {
+	if branding == nil {
+		branding = defaultReportBrandingAssets()
+	}
 	switch reportType {
 	case types.ReportTypeFinancial:
-		return p.createFinancialHTMLTemplate(data.(*types.FinancialReportData))
+		return p.createFinancialHTMLTemplate(data.(*types.FinancialReportData), moneyFormat, branding)
 	case types.ReportTypeMerchantOperation:
-		return p.createMerchantOperationHTMLTemplate(data.(*types.MerchantOperationReport))
+		return p.createMerchantOperationHTMLTemplate(data.(*types.MerchantOperationReport), moneyFormat, branding)
 	case types.ReportTypeCustomerAnalysis:
-		return p.createCustomerAnalysisHTMLTemplate(data.(*types.CustomerAnalysisReport))
+		return p.createCustomerAnalysisHTMLTemplate(data.(*types.CustomerAnalysisReport), branding)
+	case types.ReportTypeSettlement:
+		return p.createSettlementHTMLTemplate(data.(*types.SettlementStatement), moneyFormat, branding)
 	default:
 		return "", fmt.Errorf("不支持的报表类型: %s", reportType)
 	}
 }
 
 // createFinancialHTMLTemplate 创建财务报表HTML模板
-func (p *PDFGenerator) createFinancialHTMLTemplate(data *types.FinancialReportData) (string, error) {
+func (p *PDFGenerator) createFinancialHTMLTemplate(data *types.FinancialReportData, moneyFormat *types.MoneyFormatPolicy, branding *ReportBrandingAssets) (string, error) {
 	tmplStr := `
 <!DOCTYPE html>
 <html lang="zh-CN">
@@ -177,11 +275,27 @@ func (p *PDFGenerator) createFinancialHTMLTemplate(data *types.FinancialReportDa
             color: #666; 
             font-size: 12px; 
         }
+        .brand .logo { 
+            max-height: 60px; 
+        }
+        .brand .company { 
+            font-size: 16px; 
+            font-weight: bold; 
+        }
+        .header .header-text { 
+            color: #666; 
+            font-size: 14px; 
+        }
     </style>
 </head>
 <body>
     <div class="header">
+        <div class="brand">
+            {{with .Branding.LogoDataURI}}<img class="logo" src="{{.}}" alt="logo">{{end}}
+            <div class="company">{{.Branding.CompanyName}}</div>
+        </div>
         <h1>财务报表</h1>
+        {{with .Branding.HeaderText}}<div class="header-text">{{.}}</div>{{end}}
         <div class="date">生成时间: {{.GeneratedAt}}</div>
     </div>
     
@@ -193,13 +307,18 @@ func (p *PDFGenerator) createFinancialHTMLTemplate(data *types.FinancialReportDa
         </tr>
         <tr>
             <td>总收入</td>
-            <td class="amount positive">¥{{.TotalRevenue}}</td>
-            <td>已支付订单总金额</td>
+            <td class="amount positive">{{formatMoney .TotalRevenue}}</td>
+            <td>已支付订单总金额（含税）</td>
+        </tr>
+        <tr>
+            <td>代收税额</td>
+            <td class="amount">{{formatMoney .TaxCollected}}</td>
+            <td>已支付订单的税额</td>
         </tr>
         <tr>
             <td>净利润</td>
-            <td class="amount positive">¥{{.NetProfit}}</td>
-            <td>总收入减去总支出</td>
+            <td class="amount positive">{{formatMoney .NetProfit}}</td>
+            <td>不含税收入减去总支出</td>
         </tr>
         <tr>
             <td>订单总数</td>
@@ -242,7 +361,7 @@ func (p *PDFGenerator) createFinancialHTMLTemplate(data *types.FinancialReportDa
         <tr>
             <td>{{add $index 1}}</td>
             <td>{{$merchant.MerchantName}}</td>
-            <td class="amount">¥{{$merchant.Revenue}}</td>
+            <td class="amount">{{formatMoney $merchant.Revenue}}</td>
             <td>{{$merchant.OrderCount}}</td>
             <td>{{$merchant.Percentage}}%</td>
         </tr>
@@ -262,7 +381,7 @@ func (p *PDFGenerator) createFinancialHTMLTemplate(data *types.FinancialReportDa
         {{range .MonthlyTrend}}
         <tr>
             <td>{{.Month}}</td>
-            <td class="amount">¥{{.Revenue}}</td>
+            <td class="amount">{{formatMoney .Revenue}}</td>
             <td>{{.OrderCount}}</td>
             <td>{{.RightsConsumed}}</td>
         </tr>
@@ -271,7 +390,7 @@ func (p *PDFGenerator) createFinancialHTMLTemplate(data *types.FinancialReportDa
     {{end}}
     
     <div class="footer">
-        <p>本报表由MER系统自动生成 | 数据仅供内部使用</p>
+        <p>{{.Branding.FooterText}}</p>
     </div>
 </body>
 </html>
@@ -282,7 +401,7 @@ func (p *PDFGenerator) createFinancialHTMLTemplate(data *types.FinancialReportDa
 		"add": func(a, b int) int {
 			return a + b
 		},
-	}).Parse(tmplStr)
+	}).Funcs(types.MoneyTemplateFuncs(moneyFormat)).Parse(tmplStr)
 	if err != nil {
 		return "", fmt.Errorf("解析HTML模板失败: %v", err)
 	}
@@ -290,8 +409,10 @@ func (p *PDFGenerator) createFinancialHTMLTemplate(data *types.FinancialReportDa
 	// 准备模板数据
 	templateData := map[string]interface{}{
 		"GeneratedAt":            time.Now().Format("2006-01-02 15:04:05"),
-		"TotalRevenue":           fmt.Sprintf("%.2f", data.TotalRevenue.Amount),
-		"NetProfit":              fmt.Sprintf("%.2f", data.NetProfit.Amount),
+		"Branding":               branding,
+		"TotalRevenue":           data.TotalRevenue.Amount,
+		"TaxCollected":           data.TaxCollected.Amount,
+		"NetProfit":              data.NetProfit.Amount,
 		"OrderCount":             data.OrderCount,
 		"MerchantCount":          data.MerchantCount,
 		"CustomerCount":          data.CustomerCount,
@@ -310,7 +431,7 @@ func (p *PDFGenerator) createFinancialHTMLTemplate(data *types.FinancialReportDa
 		for _, merchant := range merchants {
 			merchantData = append(merchantData, map[string]interface{}{
 				"MerchantName": merchant.MerchantName,
-				"Revenue":      fmt.Sprintf("%.2f", merchant.Revenue.Amount),
+				"Revenue":      merchant.Revenue.Amount,
 				"OrderCount":   merchant.OrderCount,
 				"Percentage":   fmt.Sprintf("%.2f", merchant.Percentage),
 			})
@@ -324,7 +445,7 @@ func (p *PDFGenerator) createFinancialHTMLTemplate(data *types.FinancialReportDa
 		for _, trend := range data.Breakdown.MonthlyTrend {
 			trendData = append(trendData, map[string]interface{}{
 				"Month":          trend.Month,
-				"Revenue":        fmt.Sprintf("%.2f", trend.Revenue.Amount),
+				"Revenue":        trend.Revenue.Amount,
 				"OrderCount":     trend.OrderCount,
 				"RightsConsumed": trend.RightsConsumed,
 			})
@@ -342,7 +463,7 @@ func (p *PDFGenerator) createFinancialHTMLTemplate(data *types.FinancialReportDa
 }
 
 // createMerchantOperationHTMLTemplate 创建商户运营报表HTML模板
-func (p *PDFGenerator) createMerchantOperationHTMLTemplate(data *types.MerchantOperationReport) (string, error) {
+func (p *PDFGenerator) createMerchantOperationHTMLTemplate(data *types.MerchantOperationReport, moneyFormat *types.MoneyFormatPolicy, branding *ReportBrandingAssets) (string, error) {
 	tmplStr := `
 <!DOCTYPE html>
 <html lang="zh-CN">
@@ -391,11 +512,27 @@ func (p *PDFGenerator) createMerchantOperationHTMLTemplate(data *types.MerchantO
         .growth-negative { 
             color: #e74c3c; 
         }
+        .brand .logo { 
+            max-height: 60px; 
+        }
+        .brand .company { 
+            font-size: 16px; 
+            font-weight: bold; 
+        }
+        .header .header-text { 
+            color: #666; 
+            font-size: 14px; 
+        }
     </style>
 </head>
 <body>
     <div class="header">
+        <div class="brand">
+            {{with .Branding.LogoDataURI}}<img class="logo" src="{{.}}" alt="logo">{{end}}
+            <div class="company">{{.Branding.CompanyName}}</div>
+        </div>
         <h1>商户运营报表</h1>
+        {{with .Branding.HeaderText}}<div class="header-text">{{.}}</div>{{end}}
         <div class="date">生成时间: {{.GeneratedAt}}</div>
     </div>
     
@@ -415,31 +552,32 @@ func (p *PDFGenerator) createMerchantOperationHTMLTemplate(data *types.MerchantO
         <tr>
             <td>{{.Rank}}</td>
             <td>{{.MerchantName}}</td>
-            <td class="amount">¥{{.Revenue}}</td>
+            <td class="amount">{{formatMoney .Revenue}}</td>
             <td>{{.OrderCount}}</td>
             <td>{{.CustomerCount}}</td>
-            <td class="amount">¥{{.AvgOrderValue}}</td>
-            <td class="{{if gt .GrowthRate 0}}growth-positive{{else}}growth-negative{{end}}">{{.GrowthRate}}%</td>
+            <td class="amount">{{formatMoney .AvgOrderValue}}</td>
+            <td class="{{if gt .GrowthRate 0.0}}growth-positive{{else}}growth-negative{{end}}">{{printf "%.2f" .GrowthRate}}%</td>
         </tr>
         {{end}}
     </table>
     {{end}}
     
     <div class="footer">
-        <p>本报表由MER系统自动生成</p>
+        <p>{{.Branding.FooterText}}</p>
     </div>
 </body>
 </html>
 `
 	
 	// 类似财务报表的模板处理逻辑...
-	tmpl, err := template.New("merchant").Parse(tmplStr)
+	tmpl, err := template.New("merchant").Funcs(types.MoneyTemplateFuncs(moneyFormat)).Parse(tmplStr)
 	if err != nil {
 		return "", fmt.Errorf("解析商户运营HTML模板失败: %v", err)
 	}
 	
 	templateData := map[string]interface{}{
 		"GeneratedAt": time.Now().Format("2006-01-02 15:04:05"),
+		"Branding":    branding,
 	}
 	
 	// 处理商户排行数据
@@ -449,11 +587,11 @@ func (p *PDFGenerator) createMerchantOperationHTMLTemplate(data *types.MerchantO
 			rankingData = append(rankingData, map[string]interface{}{
 				"Rank":         ranking.Rank,
 				"MerchantName": ranking.MerchantName,
-				"Revenue":      fmt.Sprintf("%.2f", ranking.TotalRevenue.Amount),
+				"Revenue":      ranking.TotalRevenue.Amount,
 				"OrderCount":   ranking.OrderCount,
 				"CustomerCount": ranking.CustomerCount,
-				"AvgOrderValue": fmt.Sprintf("%.2f", ranking.AverageOrderValue.Amount),
-				"GrowthRate":   fmt.Sprintf("%.2f", ranking.GrowthRate),
+				"AvgOrderValue": ranking.AverageOrderValue.Amount,
+				"GrowthRate":   ranking.GrowthRate,
 			})
 		}
 		templateData["MerchantRankings"] = rankingData
@@ -468,7 +606,7 @@ func (p *PDFGenerator) createMerchantOperationHTMLTemplate(data *types.MerchantO
 }
 
 // createCustomerAnalysisHTMLTemplate 创建客户分析报表HTML模板
-func (p *PDFGenerator) createCustomerAnalysisHTMLTemplate(data *types.CustomerAnalysisReport) (string, error) {
+func (p *PDFGenerator) createCustomerAnalysisHTMLTemplate(data *types.CustomerAnalysisReport, branding *ReportBrandingAssets) (string, error) {
 	// 简化的客户分析HTML模板
 	tmplStr := `
 <!DOCTYPE html>
@@ -502,11 +640,27 @@ func (p *PDFGenerator) createCustomerAnalysisHTMLTemplate(data *types.CustomerAn
         .metrics-table th { 
             background-color: #f5f5f5; 
         }
+        .brand .logo { 
+            max-height: 60px; 
+        }
+        .brand .company { 
+            font-size: 16px; 
+            font-weight: bold; 
+        }
+        .header .header-text { 
+            color: #666; 
+            font-size: 14px; 
+        }
     </style>
 </head>
 <body>
     <div class="header">
+        <div class="brand">
+            {{with .Branding.LogoDataURI}}<img class="logo" src="{{.}}" alt="logo">{{end}}
+            <div class="company">{{.Branding.CompanyName}}</div>
+        </div>
         <h1>客户分析报表</h1>
+        {{with .Branding.HeaderText}}<div class="header-text">{{.}}</div>{{end}}
         <div class="date">生成时间: {{.GeneratedAt}}</div>
     </div>
     
@@ -533,7 +687,7 @@ func (p *PDFGenerator) createCustomerAnalysisHTMLTemplate(data *types.CustomerAn
     {{end}}
     
     <div class="footer">
-        <p>本报表由MER系统自动生成</p>
+        <p>{{.Branding.FooterText}}</p>
     </div>
 </body>
 </html>
@@ -547,6 +701,7 @@ func (p *PDFGenerator) createCustomerAnalysisHTMLTemplate(data *types.CustomerAn
 	templateData := map[string]interface{}{
 		"GeneratedAt":     time.Now().Format("2006-01-02 15:04:05"),
 		"ActivityMetrics": data.ActivityMetrics,
+		"Branding":        branding,
 	}
 	
 	var buf bytes.Buffer
@@ -559,195 +714,26 @@ func (p *PDFGenerator) createCustomerAnalysisHTMLTemplate(data *types.CustomerAn
 
 // getReportDir 获取报表存储目录
 func (p *PDFGenerator) getReportDir() string {
+	if p.reportDir != "" {
+		return p.reportDir
+	}
 	baseDir := g.Cfg().MustGet(context.Background(), "report.storage_dir", "/tmp/reports").String()
 	return baseDir
 }
 
-// convertHTMLToPDF 将HTML转换为PDF
+// convertHTMLToPDF 按配置顺序尝试转换器将HTML转换为PDF，每个转换器失败后按配置重试，
+// 返回生成PDF的转换器名称
 func (p *PDFGenerator) convertHTMLToPDF(ctx context.Context, htmlPath, pdfPath string) (string, error) {
-	g.Log().Debug(ctx, "尝试将HTML转换为PDF", 
-		"html_path", htmlPath, 
-		"pdf_path", pdfPath)
-	
-	// 尝试多种PDF转换方案
-	converters := []struct {
-		name string
-		convert func(string, string) error
-	}{
-		{"wkhtmltopdf", p.convertWithWkhtml},
-		{"chrome/chromium", p.convertWithChrome},
-		{"phantomjs", p.convertWithPhantom},
-	}
-	
-	for _, converter := range converters {
-		g.Log().Debug(ctx, "尝试PDF转换器", "converter", converter.name)
-		if err := converter.convert(htmlPath, pdfPath); err != nil {
-			g.Log().Warning(ctx, "PDF转换器失败", 
-				"converter", converter.name, 
-				"error", err)
-			continue
-		}
-		
-		// 验证PDF文件是否生成成功
-		if _, err := os.Stat(pdfPath); err == nil {
-			g.Log().Info(ctx, "PDF转换成功", 
-				"converter", converter.name,
-				"pdf_path", pdfPath)
-			return pdfPath, nil
-		}
-	}
-	
-	return "", fmt.Errorf("所有PDF转换器均失败")
-}
-
-// convertWithWkhtml 使用wkhtmltopdf转换
-func (p *PDFGenerator) convertWithWkhtml(htmlPath, pdfPath string) error {
-	// 检查wkhtmltopdf是否可用
-	if _, err := exec.LookPath("wkhtmltopdf"); err != nil {
-		return fmt.Errorf("wkhtmltopdf未安装: %v", err)
-	}
-	
-	// 执行转换命令
-	cmd := exec.Command("wkhtmltopdf", 
-		"--page-size", "A4",
-		"--encoding", "UTF-8",
-		"--margin-top", "10mm",
-		"--margin-right", "10mm", 
-		"--margin-bottom", "10mm",
-		"--margin-left", "10mm",
-		htmlPath, pdfPath)
-	
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("wkhtmltopdf执行失败: %v, stderr: %s", err, stderr.String())
-	}
-	
-	return nil
-}
-
-// convertWithChrome 使用Chrome/Chromium转换
-func (p *PDFGenerator) convertWithChrome(htmlPath, pdfPath string) error {
-	// 尝试常见的Chrome路径
-	chromePaths := []string{
-		"google-chrome",
-		"chromium-browser", 
-		"chromium",
-		"chrome",
-		"/usr/bin/google-chrome",
-		"/usr/bin/chromium-browser",
-		"/usr/bin/chromium",
-	}
-	
-	var chromeCmd string
-	for _, path := range chromePaths {
-		if _, err := exec.LookPath(path); err == nil {
-			chromeCmd = path
-			break
-		}
+	names := defaultPDFConverters
+	retries := 0
+	if p.config != nil {
+		names = p.config.Converters
+		retries = p.config.Retries
 	}
-	
-	if chromeCmd == "" {
-		return fmt.Errorf("未找到Chrome/Chromium浏览器")
-	}
-	
-	// 执行Chrome转换命令
-	cmd := exec.Command(chromeCmd,
-		"--headless",
-		"--disable-gpu",
-		"--no-sandbox",
-		"--print-to-pdf="+pdfPath,
-		"file://"+htmlPath)
-	
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("Chrome转换失败: %v, stderr: %s", err, stderr.String())
-	}
-	
-	return nil
-}
-
-// convertWithPhantom 使用PhantomJS转换
-func (p *PDFGenerator) convertWithPhantom(htmlPath, pdfPath string) error {
-	// 检查phantomjs是否可用
-	if _, err := exec.LookPath("phantomjs"); err != nil {
-		return fmt.Errorf("phantomjs未安装: %v", err)
-	}
-	
-	// 创建临时的JS脚本
-	jsScript := fmt.Sprintf(`
-		var page = require('webpage').create();
-		page.paperSize = {
-			format: 'A4',
-			margin: {
-				top: '10mm',
-				left: '10mm',
-				right: '10mm',
-				bottom: '10mm'
-			}
-		};
-		
-		page.open('%s', function() {
-			setTimeout(function() {
-				page.render('%s');
-				phantom.exit();
-			}, 1000);
-		});
-	`, "file://"+htmlPath, pdfPath)
-	
-	scriptPath := filepath.Join(filepath.Dir(pdfPath), "convert_script.js")
-	if err := os.WriteFile(scriptPath, []byte(jsScript), 0644); err != nil {
-		return fmt.Errorf("创建转换脚本失败: %v", err)
-	}
-	defer os.Remove(scriptPath)
-	
-	// 执行PhantomJS转换
-	cmd := exec.Command("phantomjs", scriptPath)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("PhantomJS转换失败: %v, stderr: %s", err, stderr.String())
-	}
-	
-	return nil
+	return pdf.Convert(ctx, p.converters, names, retries, htmlPath, pdfPath)
 }
 
 // GetSupportedPDFConverters 获取支持的PDF转换器列表
 func (p *PDFGenerator) GetSupportedPDFConverters() []string {
-	var supported []string
-	
-	converters := []struct {
-		name string
-		check func() bool
-	}{
-		{"wkhtmltopdf", func() bool {
-			_, err := exec.LookPath("wkhtmltopdf")
-			return err == nil
-		}},
-		{"chrome", func() bool {
-			chromePaths := []string{"google-chrome", "chromium-browser", "chromium", "chrome"}
-			for _, path := range chromePaths {
-				if _, err := exec.LookPath(path); err == nil {
-					return true
-				}
-			}
-			return false
-		}},
-		{"phantomjs", func() bool {
-			_, err := exec.LookPath("phantomjs")
-			return err == nil
-		}},
-	}
-	
-	for _, converter := range converters {
-		if converter.check() {
-			supported = append(supported, converter.name)
-		}
-	}
-	
-	return supported
-}
\ No newline at end of file
+	return pdf.SupportedConverters()
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMoneyFormatTestFinancialData() *types.FinancialReportData {
+	return &types.FinancialReportData{
+		TotalRevenue: types.Money{Amount: 1234567.89},
+		NetProfit:    types.Money{Amount: 98765.4},
+		Breakdown: &types.FinancialBreakdown{
+			RevenueByMerchant: []types.MerchantRevenue{
+				{MerchantName: "测试商户", Revenue: types.Money{Amount: 12345.6}, OrderCount: 10},
+			},
+		},
+	}
+}
+
+func TestCreateHTMLTemplate_MoneyFormat(t *testing.T) {
+	generator := NewPDFGeneratorForTest(nil, nil, t.TempDir()).(*PDFGenerator)
+
+	tests := []struct {
+		name        string
+		moneyFormat *types.MoneyFormatPolicy
+		expected    []string
+	}{
+		{
+			name:        "人民币",
+			moneyFormat: &types.MoneyFormatPolicy{Currency: "CNY", Locale: "zh-CN"},
+			expected:    []string{"¥1,234,567.89", "¥98,765.40", "¥12,345.60"},
+		},
+		{
+			name:        "美元",
+			moneyFormat: &types.MoneyFormatPolicy{Currency: "USD", Locale: "en-US"},
+			expected:    []string{"$1,234,567.89", "$98,765.40", "$12,345.60"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, err := generator.createHTMLTemplate(types.ReportTypeFinancial, newMoneyFormatTestFinancialData(), tt.moneyFormat, nil)
+			require.NoError(t, err)
+			for _, amount := range tt.expected {
+				assert.Contains(t, html, amount)
+			}
+		})
+	}
+
+	t.Run("商户运营报表", func(t *testing.T) {
+		html, err := generator.createHTMLTemplate(types.ReportTypeMerchantOperation, &types.MerchantOperationReport{
+			MerchantRankings: []types.MerchantRanking{
+				{Rank: 1, MerchantName: "测试商户", TotalRevenue: types.Money{Amount: 80000}, AverageOrderValue: types.Money{Amount: 1250.5}},
+			},
+		}, &types.MoneyFormatPolicy{Currency: "USD", Locale: "en-US"}, nil)
+		require.NoError(t, err)
+		assert.Contains(t, html, "$80,000.00")
+		assert.Contains(t, html, "$1,250.50")
+		assert.NotContains(t, html, "¥")
+	})
+}
+
+func TestGeneratePDFReport_UsesTenantMoneyFormat(t *testing.T) {
+	dir := t.TempDir()
+	generator := NewPDFGeneratorForTest(&PDFConversionConfig{Fallback: PDFFallbackHTML}, nil, dir).(*PDFGenerator)
+
+	var requestedTenant uint64
+	generator.moneyFormat = func(ctx context.Context, tenantID uint64) (*types.MoneyFormatPolicy, error) {
+		requestedTenant = tenantID
+		return &types.MoneyFormatPolicy{Currency: "USD", Locale: "en-US"}, nil
+	}
+
+	result, err := generator.GeneratePDFReport(context.Background(), &types.Report{
+		TenantID:   7,
+		UUID:       "money-format",
+		ReportType: types.ReportTypeFinancial,
+		FileFormat: types.FileFormatPDF,
+	}, newMoneyFormatTestFinancialData())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), requestedTenant)
+
+	content, err := os.ReadFile(result.FilePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "$1,234,567.89")
+	assert.NotContains(t, string(content), "¥")
+}
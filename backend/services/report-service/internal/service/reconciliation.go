@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	reconciliationSummarySheet = "对账汇总"
+	reconciliationDetailSheet  = "商户对账明细"
+)
+
+// GetReconciliationData 获取财务对账数据。对账结果用于核查资金差异，不使用分析缓存
+func (s *AnalyticsService) GetReconciliationData(ctx context.Context, startDate, endDate time.Time, merchantID *uint64) (*types.ReconciliationReportData, error) {
+	tenantID := ctx.Value("tenant_id").(uint64)
+
+	g.Log().Info(ctx, "开始查询财务对账数据", "tenant_id", tenantID, "start_date", startDate, "end_date", endDate)
+
+	figures, err := s.reportRepo.GetReconciliationFigures(ctx, tenantID, startDate, endDate, merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取财务对账数据失败: %v", err)
+	}
+
+	data := types.NewReconciliationReport(startDate, endDate, figures)
+	if data.AnomalyMerchants > 0 {
+		g.Log().Warning(ctx, "财务对账存在异常",
+			"tenant_id", tenantID,
+			"merchant_count", data.MerchantCount,
+			"anomaly_merchants", data.AnomalyMerchants)
+	}
+
+	return data, nil
+}
+
+// createReconciliationExcelSheets 创建财务对账报表Excel工作表：汇总页列出总额和异常商户，明细页列出全部商户
+func (s *ReportGeneratorService) createReconciliationExcelSheets(f *excelize.File, data *types.ReconciliationReportData) error {
+	index, err := f.NewSheet(reconciliationSummarySheet)
+	if err != nil {
+		return fmt.Errorf("创建对账汇总工作表失败: %v", err)
+	}
+	f.SetActiveSheet(index)
+
+	f.SetCellValue(reconciliationSummarySheet, "A1", fmt.Sprintf("财务对账汇总（%s 至 %s）",
+		data.StartDate.Format("2006-01-02"), data.EndDate.Format("2006-01-02")))
+	f.SetCellValue(reconciliationSummarySheet, "A3", "指标")
+	f.SetCellValue(reconciliationSummarySheet, "B3", "数值")
+
+	summaryRows := []struct {
+		label string
+		value interface{}
+	}{
+		{"订单收入", data.TotalRevenue},
+		{"权益消耗", data.TotalRightsConsumed},
+		{"资金充值", data.TotalDeposits},
+		{"权益分配", data.TotalAllocations},
+		{"商户数", data.MerchantCount},
+		{"异常商户数", data.AnomalyMerchants},
+	}
+	for i, item := range summaryRows {
+		row := i + 4
+		f.SetCellValue(reconciliationSummarySheet, fmt.Sprintf("A%d", row), item.label)
+		f.SetCellValue(reconciliationSummarySheet, fmt.Sprintf("B%d", row), item.value)
+	}
+
+	// 异常列表紧跟在汇总指标之后
+	row := len(summaryRows) + 5
+	f.SetCellValue(reconciliationSummarySheet, fmt.Sprintf("A%d", row), "异常明细")
+	row++
+	if data.AnomalyMerchants == 0 {
+		f.SetCellValue(reconciliationSummarySheet, fmt.Sprintf("A%d", row), "无异常")
+	} else {
+		f.SetCellValue(reconciliationSummarySheet, fmt.Sprintf("A%d", row), "商户ID")
+		f.SetCellValue(reconciliationSummarySheet, fmt.Sprintf("B%d", row), "商户名称")
+		f.SetCellValue(reconciliationSummarySheet, fmt.Sprintf("C%d", row), "异常类型")
+		f.SetCellValue(reconciliationSummarySheet, fmt.Sprintf("D%d", row), "说明")
+		for _, merchant := range data.Merchants {
+			for _, anomaly := range merchant.Anomalies {
+				row++
+				f.SetCellValue(reconciliationSummarySheet, fmt.Sprintf("A%d", row), merchant.MerchantID)
+				f.SetCellValue(reconciliationSummarySheet, fmt.Sprintf("B%d", row), merchant.MerchantName)
+				f.SetCellValue(reconciliationSummarySheet, fmt.Sprintf("C%d", row), string(anomaly.Type))
+				f.SetCellValue(reconciliationSummarySheet, fmt.Sprintf("D%d", row), anomaly.Message)
+			}
+		}
+	}
+
+	if _, err := f.NewSheet(reconciliationDetailSheet); err != nil {
+		return fmt.Errorf("创建商户对账明细工作表失败: %v", err)
+	}
+
+	f.SetCellValue(reconciliationDetailSheet, "A1", "商户对账明细")
+	headers := []string{"商户ID", "商户名称", "订单收入", "订单数", "权益消耗", "资金充值", "权益分配", "累计分配", "累计消耗", "权益结余", "对账结果"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 3)
+		f.SetCellValue(reconciliationDetailSheet, cell, header)
+	}
+
+	for i, merchant := range data.Merchants {
+		status := "一致"
+		if !merchant.Balanced() {
+			status = "异常"
+		}
+		values := []interface{}{
+			merchant.MerchantID,
+			merchant.MerchantName,
+			merchant.OrderRevenue,
+			merchant.PaidOrderCount,
+			merchant.RightsConsumed,
+			merchant.Deposits,
+			merchant.Allocations,
+			merchant.TotalCredited,
+			merchant.TotalConsumed,
+			merchant.RightsBalance,
+			status,
+		}
+		for j, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+4)
+			f.SetCellValue(reconciliationDetailSheet, cell, value)
+		}
+	}
+
+	return nil
+}
+
+// highlightReconciliationAnomalies 将明细页中存在异常的商户行标红
+func (s *ReportGeneratorService) highlightReconciliationAnomalies(f *excelize.File, data *types.ReconciliationReportData) error {
+	anomalyStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{
+			Bold:  true,
+			Color: "#C00000",
+		},
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{"#FDE9E7"},
+			Pattern: 1,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("创建异常样式失败: %v", err)
+	}
+
+	for i, merchant := range data.Merchants {
+		if merchant.Balanced() {
+			continue
+		}
+		row := i + 4
+		if err := f.SetCellStyle(reconciliationDetailSheet, fmt.Sprintf("A%d", row), fmt.Sprintf("K%d", row), anomalyStyle); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
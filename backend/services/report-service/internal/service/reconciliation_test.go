@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+// reconciliationReportRepository 返回预置对账数据的报表仓储
+type reconciliationReportRepository struct {
+	repository.IReportRepository
+	figures    []types.MerchantReconciliationFigures
+	merchantID *uint64
+}
+
+func (f *reconciliationReportRepository) GetReconciliationFigures(ctx context.Context, tenantID uint64, startDate, endDate time.Time, merchantID *uint64) ([]types.MerchantReconciliationFigures, error) {
+	f.merchantID = merchantID
+	return f.figures, nil
+}
+
+// seededReconciliationFigures 预置三家商户：商户2权益消耗超过分配，商户3有收入但没有任何资金记录
+func seededReconciliationFigures() []types.MerchantReconciliationFigures {
+	return []types.MerchantReconciliationFigures{
+		{MerchantID: 1, MerchantName: "正常商户", OrderRevenue: 1000, PaidOrderCount: 10, RightsConsumed: 100,
+			Deposits: 500, Allocations: 200, TotalCredited: 700, TotalConsumed: 300},
+		{MerchantID: 2, MerchantName: "超额消耗商户", OrderRevenue: 600, PaidOrderCount: 6, RightsConsumed: 90,
+			Allocations: 50, TotalCredited: 50, TotalConsumed: 90},
+		{MerchantID: 3, MerchantName: "无资金记录商户", OrderRevenue: 300, PaidOrderCount: 3},
+	}
+}
+
+func TestGetReconciliationData(t *testing.T) {
+	reportRepo := &reconciliationReportRepository{figures: seededReconciliationFigures()}
+	analyticsService := NewAnalyticsServiceForTest(reportRepo, nil)
+	ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 9, 30, 23, 59, 59, 0, time.UTC)
+	merchantID := uint64(2)
+
+	data, err := analyticsService.GetReconciliationData(ctx, start, end, &merchantID)
+	require.NoError(t, err)
+	assert.Equal(t, &merchantID, reportRepo.merchantID)
+	assert.Equal(t, 3, data.MerchantCount)
+	assert.Equal(t, 2, data.AnomalyMerchants)
+	assert.Equal(t, 1900.0, data.TotalRevenue)
+	assert.Equal(t, 500.0, data.TotalDeposits)
+	assert.Equal(t, 250.0, data.TotalAllocations)
+
+	assert.True(t, data.Merchants[0].Balanced())
+	require.Len(t, data.Merchants[1].Anomalies, 1)
+	assert.Equal(t, types.ReconciliationAnomalyRightsOverConsumed, data.Merchants[1].Anomalies[0].Type)
+	assert.Equal(t, -40.0, data.Merchants[1].RightsBalance)
+	require.Len(t, data.Merchants[2].Anomalies, 2)
+	assert.Equal(t, types.ReconciliationAnomalyRevenueWithoutRights, data.Merchants[2].Anomalies[0].Type)
+	assert.Equal(t, types.ReconciliationAnomalyRevenueWithoutFunds, data.Merchants[2].Anomalies[1].Type)
+}
+
+func TestCreateReconciliationExcelSheets(t *testing.T) {
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 9, 30, 23, 59, 59, 0, time.UTC)
+	data := types.NewReconciliationReport(start, end, seededReconciliationFigures())
+	generator := &ReportGeneratorService{}
+
+	f := excelize.NewFile()
+	require.NoError(t, generator.createReconciliationExcelSheets(f, data))
+	require.NoError(t, f.DeleteSheet("Sheet1"))
+	require.NoError(t, generator.applyExcelStyles(f))
+	require.NoError(t, generator.highlightReconciliationAnomalies(f, data))
+
+	path := t.TempDir() + "/reconciliation.xlsx"
+	require.NoError(t, f.SaveAs(path))
+	require.NoError(t, f.Close())
+
+	saved, err := excelize.OpenFile(path)
+	require.NoError(t, err)
+	defer saved.Close()
+
+	assert.Equal(t, []string{reconciliationSummarySheet, reconciliationDetailSheet}, saved.GetSheetList())
+	assert.Equal(t, reconciliationSummarySheet, saved.GetSheetName(saved.GetActiveSheetIndex()))
+
+	summaryRows, err := saved.GetRows(reconciliationSummarySheet)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"异常商户数", "2"}, summaryRows[8])
+	assert.Equal(t, "异常明细", summaryRows[10][0])
+	// 三条异常：商户2超额消耗，商户3缺少权益消耗和资金记录
+	anomalyRows := summaryRows[12:]
+	require.Len(t, anomalyRows, 3)
+	assert.Equal(t, []string{"2", "超额消耗商户", string(types.ReconciliationAnomalyRightsOverConsumed)}, anomalyRows[0][:3])
+	assert.Equal(t, "3", anomalyRows[1][0])
+	assert.Equal(t, "3", anomalyRows[2][0])
+
+	detailRows, err := saved.GetRows(reconciliationDetailSheet)
+	require.NoError(t, err)
+	require.Len(t, detailRows, 6)
+	assert.Equal(t, "一致", detailRows[3][10])
+	assert.Equal(t, "异常", detailRows[4][10])
+	assert.Equal(t, "异常", detailRows[5][10])
+
+	// 异常行使用标红样式，正常行保持通用数据样式
+	normalStyle, err := saved.GetCellStyle(reconciliationDetailSheet, "A4")
+	require.NoError(t, err)
+	anomalyStyle, err := saved.GetCellStyle(reconciliationDetailSheet, "A5")
+	require.NoError(t, err)
+	assert.NotEqual(t, normalStyle, anomalyStyle)
+	style, err := saved.GetStyle(anomalyStyle)
+	require.NoError(t, err)
+	assert.Equal(t, "C00000", style.Font.Color)
+}
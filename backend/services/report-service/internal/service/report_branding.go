@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	// reportLogoFetchTimeout 下载报表Logo的超时时间
+	reportLogoFetchTimeout = 5 * time.Second
+	// maxReportLogoSize 报表Logo图片的最大字节数
+	maxReportLogoSize = 1 << 20
+	// excelBrandingSheet Excel报表首个工作表，显示Logo、公司名称和报表信息
+	excelBrandingSheet = "报表信息"
+)
+
+// ReportLogoFetcher 下载报表Logo图片
+type ReportLogoFetcher func(ctx context.Context, url string) ([]byte, error)
+
+// ReportBrandingAssets 报表生效的品牌及已下载的Logo图片
+type ReportBrandingAssets struct {
+	*types.ReportBranding
+	Logo          []byte // Logo图片内容，未配置或下载失败时为空
+	LogoExtension string // Logo图片扩展名，如 .png、.jpg
+	logoMimeType  string
+}
+
+// LogoDataURI 嵌入HTML的Logo地址，没有Logo时为空
+func (a *ReportBrandingAssets) LogoDataURI() template.URL {
+	if a == nil || len(a.Logo) == 0 {
+		return ""
+	}
+	return template.URL("data:" + a.logoMimeType + ";base64," + base64.StdEncoding.EncodeToString(a.Logo))
+}
+
+// ReportBrandingLoader 按租户加载报表品牌和Logo图片
+type ReportBrandingLoader struct {
+	provider  repository.ReportBrandingProvider
+	fetchLogo ReportLogoFetcher
+}
+
+// NewReportBrandingLoader 创建从租户配置读取报表品牌、通过HTTP下载Logo的加载器
+func NewReportBrandingLoader() *ReportBrandingLoader {
+	return &ReportBrandingLoader{
+		provider:  repository.NewTenantReportBrandingProvider(repository.NewTenantRepository()),
+		fetchLogo: fetchReportLogo,
+	}
+}
+
+// NewReportBrandingLoaderForTest 创建使用指定品牌提供者和Logo下载函数的加载器
+func NewReportBrandingLoaderForTest(provider repository.ReportBrandingProvider, fetchLogo ReportLogoFetcher) *ReportBrandingLoader {
+	return &ReportBrandingLoader{
+		provider:  provider,
+		fetchLogo: fetchLogo,
+	}
+}
+
+// Load 获取租户生效的报表品牌。Logo下载失败或不是PNG、JPEG图片时只记录警告，报表不带Logo继续生成
+func (l *ReportBrandingLoader) Load(ctx context.Context, tenantID uint64) *ReportBrandingAssets {
+	if l == nil {
+		return defaultReportBrandingAssets()
+	}
+
+	assets := &ReportBrandingAssets{ReportBranding: l.provider.Resolve(ctx, tenantID)}
+	if assets.LogoURL == "" || l.fetchLogo == nil {
+		return assets
+	}
+
+	logo, err := l.fetchLogo(ctx, assets.LogoURL)
+	if err != nil {
+		g.Log().Warningf(ctx, "下载租户 %d 报表Logo失败，报表不显示Logo: %v", tenantID, err)
+		return assets
+	}
+
+	switch mimeType := http.DetectContentType(logo); mimeType {
+	case "image/png":
+		assets.Logo, assets.LogoExtension, assets.logoMimeType = logo, ".png", mimeType
+	case "image/jpeg":
+		assets.Logo, assets.LogoExtension, assets.logoMimeType = logo, ".jpg", mimeType
+	default:
+		g.Log().Warningf(ctx, "租户 %d 报表Logo格式 %s 不受支持，报表不显示Logo", tenantID, mimeType)
+	}
+	return assets
+}
+
+// defaultReportBrandingAssets 未配置品牌加载器时使用的默认品牌
+func defaultReportBrandingAssets() *ReportBrandingAssets {
+	return &ReportBrandingAssets{ReportBranding: types.DefaultReportBranding()}
+}
+
+// fetchReportLogo 通过HTTP下载Logo图片，超过大小限制时返回错误
+func fetchReportLogo(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, reportLogoFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Logo地址返回状态码 %d", resp.StatusCode)
+	}
+
+	logo, err := io.ReadAll(io.LimitReader(resp.Body, maxReportLogoSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(logo) > maxReportLogoSize {
+		return nil, fmt.Errorf("Logo图片超过%d字节", maxReportLogoSize)
+	}
+	return logo, nil
+}
+
+// writeExcelBrandingSheet 填写报表信息工作表，并为所有工作表设置打印页眉页脚
+func writeExcelBrandingSheet(f *excelize.File, report *types.Report, branding *ReportBrandingAssets) error {
+	if branding == nil {
+		branding = defaultReportBrandingAssets()
+	}
+
+	if len(branding.Logo) > 0 {
+		if err := f.MergeCell(excelBrandingSheet, "A1", "C1"); err != nil {
+			return fmt.Errorf("合并Logo单元格失败: %v", err)
+		}
+		if err := f.SetRowHeight(excelBrandingSheet, 1, 60); err != nil {
+			return fmt.Errorf("设置Logo行高失败: %v", err)
+		}
+		if err := f.AddPictureFromBytes(excelBrandingSheet, "A1", &excelize.Picture{
+			Extension: branding.LogoExtension,
+			File:      branding.Logo,
+			Format:    &excelize.GraphicOptions{AltText: branding.CompanyName, AutoFit: true, LockAspectRatio: true},
+		}); err != nil {
+			return fmt.Errorf("插入Logo失败: %v", err)
+		}
+	}
+
+	companyStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 16}})
+	if err != nil {
+		return fmt.Errorf("创建公司名称样式失败: %v", err)
+	}
+	f.SetCellValue(excelBrandingSheet, "A2", branding.CompanyName)
+	f.SetCellStyle(excelBrandingSheet, "A2", "A2", companyStyle)
+	f.SetCellValue(excelBrandingSheet, "A3", branding.HeaderText)
+	f.SetCellValue(excelBrandingSheet, "A5", "报表类型")
+	f.SetCellValue(excelBrandingSheet, "B5", string(report.ReportType))
+	f.SetCellValue(excelBrandingSheet, "A6", "统计周期")
+	f.SetCellValue(excelBrandingSheet, "B6", fmt.Sprintf("%s 至 %s", report.StartDate.Format("2006-01-02"), report.EndDate.Format("2006-01-02")))
+	f.SetCellValue(excelBrandingSheet, "A7", "生成时间")
+	f.SetCellValue(excelBrandingSheet, "B7", time.Now().Format("2006-01-02 15:04:05"))
+	f.SetCellValue(excelBrandingSheet, "A9", branding.FooterText)
+	f.SetColWidth(excelBrandingSheet, "A", "A", 15)
+	f.SetColWidth(excelBrandingSheet, "B", "B", 30)
+
+	// 页眉左侧为公司名称、右侧为页眉文字，页脚左侧为声明、右侧为页码
+	header := "&L" + escapeExcelHeaderFooter(branding.CompanyName)
+	if branding.HeaderText != "" {
+		header += "&R" + escapeExcelHeaderFooter(branding.HeaderText)
+	}
+	footer := "&L" + escapeExcelHeaderFooter(branding.FooterText) + "&R第 &P 页，共 &N 页"
+	for _, sheet := range f.GetSheetList() {
+		if err := f.SetHeaderFooter(sheet, &excelize.HeaderFooterOptions{OddHeader: header, OddFooter: footer}); err != nil {
+			return fmt.Errorf("设置工作表 %s 页眉页脚失败: %v", sheet, err)
+		}
+	}
+	return nil
+}
+
+// escapeExcelHeaderFooter 转义页眉页脚中的 & 控制符
+func escapeExcelHeaderFooter(text string) string {
+	return strings.ReplaceAll(text, "&", "&&")
+}
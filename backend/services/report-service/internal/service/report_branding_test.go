@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func newTestLogo(t *testing.T) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))))
+	return buf.Bytes()
+}
+
+func newTestBrandingLoader(branding *types.ReportBranding, logo []byte, fetchErr error) *ReportBrandingLoader {
+	return NewReportBrandingLoaderForTest(
+		func(ctx context.Context, tenantID uint64) (*types.ReportBranding, error) {
+			return branding, nil
+		},
+		func(ctx context.Context, url string) ([]byte, error) {
+			return logo, fetchErr
+		},
+	)
+}
+
+func newTestBranding() *types.ReportBranding {
+	return &types.ReportBranding{
+		LogoURL:     "https://cdn.example.com/logo.png",
+		CompanyName: "星河贸易",
+		HeaderText:  "财务部 · 月度经营分析",
+		FooterText:  "机密文件，未经许可不得外传",
+	}
+}
+
+func TestReportBrandingLoader_Load(t *testing.T) {
+	ctx := context.Background()
+	logo := newTestLogo(t)
+
+	t.Run("租户品牌和Logo", func(t *testing.T) {
+		assets := newTestBrandingLoader(newTestBranding(), logo, nil).Load(ctx, 1)
+		assert.Equal(t, "星河贸易", assets.CompanyName)
+		assert.Equal(t, logo, assets.Logo)
+		assert.Equal(t, ".png", assets.LogoExtension)
+		assert.Contains(t, string(assets.LogoDataURI()), "data:image/png;base64,")
+	})
+
+	t.Run("只配置公司名称时页脚使用公司名称", func(t *testing.T) {
+		assets := newTestBrandingLoader(&types.ReportBranding{CompanyName: "星河贸易"}, nil, nil).Load(ctx, 1)
+		assert.Equal(t, "本报表由星河贸易自动生成 | 数据仅供内部使用", assets.FooterText)
+		assert.Empty(t, assets.LogoDataURI())
+	})
+
+	t.Run("Logo下载失败时不显示Logo", func(t *testing.T) {
+		assets := newTestBrandingLoader(newTestBranding(), nil, errors.New("timeout")).Load(ctx, 1)
+		assert.Equal(t, "星河贸易", assets.CompanyName)
+		assert.Empty(t, assets.Logo)
+	})
+
+	t.Run("Logo不是图片时不显示Logo", func(t *testing.T) {
+		assets := newTestBrandingLoader(newTestBranding(), []byte("<html></html>"), nil).Load(ctx, 1)
+		assert.Empty(t, assets.Logo)
+	})
+
+	t.Run("未配置品牌", func(t *testing.T) {
+		assets := newTestBrandingLoader(nil, nil, nil).Load(ctx, 1)
+		assert.Equal(t, types.DefaultReportBranding(), assets.ReportBranding)
+	})
+}
+
+func TestReportBranding_Validate(t *testing.T) {
+	assert.NoError(t, newTestBranding().Validate())
+	assert.Error(t, (&types.ReportBranding{LogoURL: "file:///etc/passwd"}).Validate())
+	assert.Error(t, (&types.ReportBranding{LogoURL: "javascript:alert(1)"}).Validate())
+	assert.Error(t, (&types.ReportBranding{CompanyName: string(make([]rune, types.MaxReportCompanyNameLength+1))}).Validate())
+}
+
+func TestCreateHTMLTemplate_Branding(t *testing.T) {
+	generator := NewPDFGeneratorForTest(nil, nil, t.TempDir()).(*PDFGenerator)
+	branded := newTestBrandingLoader(newTestBranding(), newTestLogo(t), nil).Load(context.Background(), 1)
+
+	reports := []struct {
+		reportType types.ReportType
+		data       interface{}
+	}{
+		{types.ReportTypeFinancial, newMoneyFormatTestFinancialData()},
+		{types.ReportTypeMerchantOperation, &types.MerchantOperationReport{}},
+		{types.ReportTypeCustomerAnalysis, &types.CustomerAnalysisReport{}},
+	}
+
+	for _, report := range reports {
+		t.Run(string(report.reportType), func(t *testing.T) {
+			html, err := generator.createHTMLTemplate(report.reportType, report.data, types.DefaultMoneyFormatPolicy(), branded)
+			require.NoError(t, err)
+			assert.Contains(t, html, `<img class="logo" src="data:image/png;base64,`)
+			assert.Contains(t, html, "星河贸易")
+			assert.Contains(t, html, "财务部 · 月度经营分析")
+			assert.Contains(t, html, "机密文件，未经许可不得外传")
+			assert.NotContains(t, html, "MER系统")
+
+			html, err = generator.createHTMLTemplate(report.reportType, report.data, types.DefaultMoneyFormatPolicy(), nil)
+			require.NoError(t, err)
+			assert.NotContains(t, html, "<img")
+			assert.Contains(t, html, "本报表由MER系统自动生成 | 数据仅供内部使用")
+			assert.NotContains(t, html, "星河贸易")
+		})
+	}
+}
+
+func TestWriteExcelBrandingSheet(t *testing.T) {
+	generator := &ReportGeneratorService{}
+	report := &types.Report{
+		ReportType: types.ReportTypeFinancial,
+		StartDate:  time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:    time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC),
+	}
+
+	build := func(t *testing.T, branding *ReportBrandingAssets) *excelize.File {
+		f := excelize.NewFile()
+		require.NoError(t, f.SetSheetName("Sheet1", excelBrandingSheet))
+		require.NoError(t, generator.createFinancialExcelSheets(f, newMoneyFormatTestFinancialData()))
+		require.NoError(t, generator.applyExcelStyles(f))
+		require.NoError(t, writeExcelBrandingSheet(f, report, branding))
+
+		path := filepath.Join(t.TempDir(), "report.xlsx")
+		require.NoError(t, f.SaveAs(path))
+		require.NoError(t, f.Close())
+
+		saved, err := excelize.OpenFile(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { saved.Close() })
+		return saved
+	}
+
+	t.Run("租户品牌", func(t *testing.T) {
+		f := build(t, newTestBrandingLoader(newTestBranding(), newTestLogo(t), nil).Load(context.Background(), 1))
+
+		sheets := f.GetSheetList()
+		assert.Equal(t, excelBrandingSheet, sheets[0])
+		// 数据工作表仍为打开时的活动工作表
+		assert.Equal(t, "财务概览", f.GetSheetName(f.GetActiveSheetIndex()))
+
+		pictures, err := f.GetPictures(excelBrandingSheet, "A1")
+		require.NoError(t, err)
+		require.Len(t, pictures, 1)
+		assert.Equal(t, ".png", pictures[0].Extension)
+
+		company, _ := f.GetCellValue(excelBrandingSheet, "A2")
+		header, _ := f.GetCellValue(excelBrandingSheet, "A3")
+		footer, _ := f.GetCellValue(excelBrandingSheet, "A9")
+		assert.Equal(t, "星河贸易", company)
+		assert.Equal(t, "财务部 · 月度经营分析", header)
+		assert.Equal(t, "机密文件，未经许可不得外传", footer)
+
+		for _, sheet := range sheets {
+			options, err := f.GetHeaderFooter(sheet)
+			require.NoError(t, err)
+			assert.Equal(t, "&L星河贸易&R财务部 · 月度经营分析", options.OddHeader, sheet)
+			assert.Contains(t, options.OddFooter, "&L机密文件，未经许可不得外传", sheet)
+		}
+
+		// 数据工作表的标题仍然套用通用样式，报表信息页不受影响
+		title, _ := f.GetCellValue("财务概览", "A1")
+		assert.Equal(t, "财务报表概览", title)
+	})
+
+	t.Run("默认品牌", func(t *testing.T) {
+		f := build(t, nil)
+
+		pictures, err := f.GetPictures(excelBrandingSheet, "A1")
+		require.NoError(t, err)
+		assert.Empty(t, pictures)
+
+		company, _ := f.GetCellValue(excelBrandingSheet, "A2")
+		footer, _ := f.GetCellValue(excelBrandingSheet, "A9")
+		assert.Equal(t, types.DefaultReportCompanyName, company)
+		assert.Equal(t, "本报表由MER系统自动生成 | 数据仅供内部使用", footer)
+
+		options, err := f.GetHeaderFooter("财务概览")
+		require.NoError(t, err)
+		assert.Equal(t, "&LMER系统", options.OddHeader)
+	})
+}
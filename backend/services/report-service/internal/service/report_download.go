@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/oss"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	// 未配置时签名下载链接的有效期
+	defaultReportDownloadURLExpiry = 15 * time.Minute
+	// 签名下载链接有效期上限
+	maxReportDownloadURLExpiry = 7 * 24 * time.Hour
+	// 上传到对象存储的报表文件大小上限
+	maxReportObjectSize = 200 << 20
+	// 下载记录接口返回的记录数
+	reportDownloadLogLimit = 100
+)
+
+// ReportDownloadConfig 报表签名下载链接配置（report.download 节点）
+type ReportDownloadConfig struct {
+	Backend   types.ReportDownloadBackend // local 由报表服务校验签名后返回文件；oss 上传到对象存储后签发对象存储链接
+	URLExpiry time.Duration               // 链接有效期
+	BindIP    bool                        // 本地签名链接只能在申请链接的IP使用
+	Secret    string                      // 本地签名链接的签名密钥，必须单独配置，为空时拒绝签发和校验链接
+	BaseURL   string                      // 本地签名链接的外部访问地址，未配置时返回相对路径
+}
+
+// LoadReportDownloadConfig 从 report.download 配置加载签名下载链接配置，未配置的项使用默认值。
+// 签名密钥 report.download.signing_secret 必须配置，不使用其他密钥代替
+func LoadReportDownloadConfig(ctx context.Context) (*ReportDownloadConfig, error) {
+	cfg := g.Cfg()
+	config := &ReportDownloadConfig{
+		Backend:   types.ReportDownloadBackend(cfg.MustGet(ctx, "report.download.backend", string(types.ReportDownloadBackendLocal)).String()),
+		URLExpiry: cfg.MustGet(ctx, "report.download.url_expiry", defaultReportDownloadURLExpiry.String()).Duration(),
+		BindIP:    cfg.MustGet(ctx, "report.download.bind_ip", false).Bool(),
+		Secret:    cfg.MustGet(ctx, "report.download.signing_secret", "").String(),
+		BaseURL:   cfg.MustGet(ctx, "report.download.base_url", "").String(),
+	}
+	if config.Secret == "" {
+		return nil, fmt.Errorf("%w: report.download.signing_secret", types.ErrReportDownloadSecretMissing)
+	}
+	return config.normalize(), nil
+}
+
+// normalize 无效的交付方式按本地处理，有效期限制在 (0, 7天] 内
+func (c *ReportDownloadConfig) normalize() *ReportDownloadConfig {
+	if c.Backend != types.ReportDownloadBackendOSS {
+		c.Backend = types.ReportDownloadBackendLocal
+	}
+	if c.URLExpiry <= 0 {
+		c.URLExpiry = defaultReportDownloadURLExpiry
+	}
+	if c.URLExpiry > maxReportDownloadURLExpiry {
+		c.URLExpiry = maxReportDownloadURLExpiry
+	}
+	return c
+}
+
+// ReportDownloadLink 本地签名下载链接中的参数
+type ReportDownloadLink struct {
+	ReportUUID string
+	TenantID   uint64
+	UserID     uint64 // 签发链接的用户
+	ExpiresAt  int64  // Unix 秒
+	IP         string // 绑定的IP，未绑定时为空
+	Signature  string
+}
+
+// ReportURLSigner 本地签名下载链接的签名器，签名覆盖报表、租户、签发用户、到期时间和绑定的IP，任一参数被修改签名即失效
+type ReportURLSigner struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewReportURLSigner 创建签名器
+func NewReportURLSigner(secret string, now func() time.Time) *ReportURLSigner {
+	return &ReportURLSigner{secret: []byte(secret), now: now}
+}
+
+// Sign 计算链接签名
+func (s *ReportURLSigner) Sign(link *ReportDownloadLink) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%d\n%d\n%d\n%s", link.ReportUUID, link.TenantID, link.UserID, link.ExpiresAt, link.IP)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验链接签名、有效期和绑定的IP
+func (s *ReportURLSigner) Verify(link *ReportDownloadLink, clientIP string) error {
+	expected := s.Sign(link)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(link.Signature))) {
+		return types.ErrReportDownloadSignatureInvalid
+	}
+	if !s.now().Before(time.Unix(link.ExpiresAt, 0)) {
+		return types.ErrReportDownloadURLExpired
+	}
+	if link.IP != "" && link.IP != clientIP {
+		return types.ErrReportDownloadIPMismatch
+	}
+	return nil
+}
+
+// ReportDownloadStorage 报表对象存储
+type ReportDownloadStorage interface {
+	UploadFromBytes(ctx context.Context, data []byte, fileName, contentType string, options *oss.UploadOptions) (*oss.UploadFileInfo, error)
+	GetSignedURL(ctx context.Context, objectKey string, expiration time.Duration) (string, error)
+}
+
+// ReportDownloadRequester 下载或申请下载链接的请求方
+type ReportDownloadRequester struct {
+	UserID    *uint64
+	ClientIP  string
+	UserAgent string
+}
+
+// ReportDownloadService 报表签名下载服务：签发有时效的下载链接，大量下载不必经过认证接口。
+// 本地交付时链接指向报表服务的签名下载接口，由服务校验签名、有效期和绑定的IP后返回文件；
+// 对象存储交付时报表首次下载前上传到对象存储，链接由对象存储或CDN直接响应，不经过报表服务。
+// 每次下载（对象存储交付时为每次签发链接）记录访问日志
+type ReportDownloadService struct {
+	reportRepo repository.IReportRepository
+	logRepo    repository.IReportDownloadLogRepository
+	storage    ReportDownloadStorage
+	config     *ReportDownloadConfig
+	signer     *ReportURLSigner
+	now        func() time.Time
+}
+
+// NewReportDownloadService 创建报表签名下载服务实例
+func NewReportDownloadService() *ReportDownloadService {
+	ctx := context.Background()
+	config, err := LoadReportDownloadConfig(ctx)
+	if err != nil {
+		// 服务仍可启动，但不签发和校验下载链接
+		g.Log().Error(ctx, "加载报表下载配置失败，签名下载链接不可用", "error", err)
+		config = &ReportDownloadConfig{}
+	}
+	return NewReportDownloadServiceForTest(repository.NewReportRepository(), repository.NewReportDownloadLogRepository(),
+		oss.NewOSSService(), config, time.Now)
+}
+
+// NewReportDownloadServiceForTest 创建测试用报表签名下载服务实例，可指定当前时间
+func NewReportDownloadServiceForTest(reportRepo repository.IReportRepository, logRepo repository.IReportDownloadLogRepository, storage ReportDownloadStorage, config *ReportDownloadConfig, now func() time.Time) *ReportDownloadService {
+	config.normalize()
+	return &ReportDownloadService{
+		reportRepo: reportRepo,
+		logRepo:    logRepo,
+		storage:    storage,
+		config:     config,
+		signer:     NewReportURLSigner(config.Secret, now),
+		now:        now,
+	}
+}
+
+// IssueDownloadURL 为当前租户已生成完成的报表签发下载链接
+func (s *ReportDownloadService) IssueDownloadURL(ctx context.Context, reportUUID string, requester *ReportDownloadRequester) (*types.ReportDownloadURL, error) {
+	if s.config.Secret == "" {
+		return nil, types.ErrReportDownloadSecretMissing
+	}
+	report, err := s.downloadableReport(ctx, reportUUID)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := s.now().Add(s.config.URLExpiry).Truncate(time.Second)
+
+	if s.config.Backend == types.ReportDownloadBackendOSS {
+		if err := s.ensureObject(ctx, report); err != nil {
+			return nil, err
+		}
+		signedURL, err := s.storage.GetSignedURL(ctx, report.ObjectKey, s.config.URLExpiry)
+		if err != nil {
+			return nil, fmt.Errorf("生成对象存储下载链接失败: %v", err)
+		}
+		s.recordDownload(ctx, report, types.ReportDownloadChannelObjectStorage, requester)
+		return &types.ReportDownloadURL{URL: signedURL, Backend: types.ReportDownloadBackendOSS, ExpiresAt: expiresAt}, nil
+	}
+
+	link := &ReportDownloadLink{
+		ReportUUID: report.UUID,
+		TenantID:   report.TenantID,
+		ExpiresAt:  expiresAt.Unix(),
+	}
+	if requester.UserID != nil {
+		link.UserID = *requester.UserID
+	}
+	if s.config.BindIP {
+		link.IP = requester.ClientIP
+	}
+	link.Signature = s.signer.Sign(link)
+
+	return &types.ReportDownloadURL{
+		URL:       s.localURL(link),
+		Backend:   types.ReportDownloadBackendLocal,
+		ExpiresAt: expiresAt,
+		BoundIP:   link.IP,
+	}, nil
+}
+
+// OpenSignedDownload 校验本地签名下载链接，返回可下载的报表并记录下载
+func (s *ReportDownloadService) OpenSignedDownload(ctx context.Context, link *ReportDownloadLink, requester *ReportDownloadRequester) (*types.Report, error) {
+	if s.config.Secret == "" {
+		return nil, types.ErrReportDownloadSecretMissing
+	}
+	if err := s.signer.Verify(link, requester.ClientIP); err != nil {
+		return nil, err
+	}
+
+	// 签名链接不经过认证，租户和下载用户以签发链接时为准
+	ctx = context.WithValue(ctx, "tenant_id", link.TenantID)
+	report, err := s.downloadableReport(ctx, link.ReportUUID)
+	if err != nil {
+		return nil, err
+	}
+	if link.UserID != 0 {
+		userID := link.UserID
+		requester = &ReportDownloadRequester{UserID: &userID, ClientIP: requester.ClientIP, UserAgent: requester.UserAgent}
+	}
+	s.recordDownload(ctx, report, types.ReportDownloadChannelSignedURL, requester)
+	return report, nil
+}
+
+// RecordDirectDownload 记录认证后直接下载报表
+func (s *ReportDownloadService) RecordDirectDownload(ctx context.Context, report *types.Report, requester *ReportDownloadRequester) {
+	s.recordDownload(ctx, report, types.ReportDownloadChannelDirect, requester)
+}
+
+// ListDownloadLogs 获取当前租户下报表最近的下载记录
+func (s *ReportDownloadService) ListDownloadLogs(ctx context.Context, reportUUID string) ([]types.ReportDownloadLog, error) {
+	report, err := s.reportRepo.GetReportByUUID(ctx, reportUUID)
+	if err != nil || report == nil || report.ID == 0 {
+		return nil, fmt.Errorf("报表不存在")
+	}
+	return s.logRepo.ListByReport(ctx, report.ID, reportDownloadLogLimit)
+}
+
+// ParseReportDownloadLink 解析本地签名下载链接的查询参数
+func ParseReportDownloadLink(reportUUID string, query url.Values) (*ReportDownloadLink, error) {
+	tenantID, err := strconv.ParseUint(query.Get("tenant_id"), 10, 64)
+	if err != nil {
+		return nil, types.ErrReportDownloadSignatureInvalid
+	}
+	expiresAt, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return nil, types.ErrReportDownloadSignatureInvalid
+	}
+	var userID uint64
+	if value := query.Get("user_id"); value != "" {
+		if userID, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return nil, types.ErrReportDownloadSignatureInvalid
+		}
+	}
+	return &ReportDownloadLink{
+		ReportUUID: reportUUID,
+		TenantID:   tenantID,
+		UserID:     userID,
+		ExpiresAt:  expiresAt,
+		IP:         query.Get("ip"),
+		Signature:  query.Get("signature"),
+	}, nil
+}
+
+// localURL 本地签名下载链接
+func (s *ReportDownloadService) localURL(link *ReportDownloadLink) string {
+	query := url.Values{}
+	query.Set("tenant_id", strconv.FormatUint(link.TenantID, 10))
+	if link.UserID != 0 {
+		query.Set("user_id", strconv.FormatUint(link.UserID, 10))
+	}
+	query.Set("expires", strconv.FormatInt(link.ExpiresAt, 10))
+	if link.IP != "" {
+		query.Set("ip", link.IP)
+	}
+	query.Set("signature", link.Signature)
+	return fmt.Sprintf("%s/api/v1/report-downloads/%s?%s",
+		strings.TrimSuffix(s.config.BaseURL, "/"), url.PathEscape(link.ReportUUID), query.Encode())
+}
+
+// downloadableReport 获取已生成完成且文件存在的报表
+func (s *ReportDownloadService) downloadableReport(ctx context.Context, reportUUID string) (*types.Report, error) {
+	report, err := s.reportRepo.GetReportByUUID(ctx, reportUUID)
+	if err != nil || report == nil || report.ID == 0 {
+		return nil, fmt.Errorf("报表不存在")
+	}
+	if report.Status != types.ReportStatusCompleted {
+		return nil, fmt.Errorf("报表尚未生成完成，当前状态: %s", report.Status)
+	}
+	// 已上传到对象存储的报表不再依赖本地文件
+	if s.config.Backend == types.ReportDownloadBackendOSS && report.ObjectKey != "" {
+		return report, nil
+	}
+	if report.FilePath == "" {
+		return nil, fmt.Errorf("报表文件路径为空")
+	}
+	if _, err := os.Stat(report.FilePath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("报表文件不存在")
+	}
+	return report, nil
+}
+
+// ensureObject 报表尚未上传到对象存储时上传并记录对象键
+func (s *ReportDownloadService) ensureObject(ctx context.Context, report *types.Report) error {
+	if report.ObjectKey != "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(report.FilePath)
+	if err != nil {
+		return fmt.Errorf("读取报表文件失败: %v", err)
+	}
+	contentType := report.FileFormat.ContentType()
+	info, err := s.storage.UploadFromBytes(ctx, data, filepath.Base(report.FilePath), contentType, &oss.UploadOptions{
+		Directory:    fmt.Sprintf("reports/%d/%s", report.TenantID, report.UUID),
+		FileName:     filepath.Base(report.FilePath),
+		AllowedTypes: []string{contentType},
+		MaxSize:      maxReportObjectSize,
+	})
+	if err != nil {
+		return fmt.Errorf("上传报表到对象存储失败: %v", err)
+	}
+
+	report.ObjectKey = info.ObjectKey
+	if err := s.reportRepo.UpdateReport(ctx, report); err != nil {
+		return fmt.Errorf("记录报表对象键失败: %v", err)
+	}
+	return nil
+}
+
+// recordDownload 记录下载访问日志，记录失败不影响下载
+func (s *ReportDownloadService) recordDownload(ctx context.Context, report *types.Report, channel types.ReportDownloadChannel, requester *ReportDownloadRequester) {
+	log := &types.ReportDownloadLog{
+		TenantID:   report.TenantID,
+		ReportID:   report.ID,
+		ReportUUID: report.UUID,
+		Channel:    channel,
+		UserID:     requester.UserID,
+		ClientIP:   requester.ClientIP,
+		UserAgent:  truncateUserAgent(requester.UserAgent),
+		CreatedAt:  s.now(),
+	}
+	if err := s.logRepo.Create(ctx, log); err != nil {
+		g.Log().Warning(ctx, "记录报表下载失败", "report_uuid", report.UUID, "channel", channel, "error", err)
+	}
+}
+
+// truncateUserAgent 截断过长的 User-Agent，与下载日志字段长度一致
+func truncateUserAgent(userAgent string) string {
+	const maxLength = 255
+	if len(userAgent) <= maxLength {
+		return userAgent
+	}
+	return userAgent[:maxLength]
+}
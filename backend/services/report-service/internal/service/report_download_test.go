@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/oss"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// downloadReportRepository 按租户隔离的报表仓储桩
+type downloadReportRepository struct {
+	repository.IReportRepository
+	reports map[string]*types.Report
+	updates int
+}
+
+func (f *downloadReportRepository) GetReportByUUID(ctx context.Context, uuid string) (*types.Report, error) {
+	report, ok := f.reports[uuid]
+	if !ok || report.TenantID != gconv.Uint64(ctx.Value("tenant_id")) {
+		return &types.Report{}, nil
+	}
+	copied := *report
+	return &copied, nil
+}
+
+func (f *downloadReportRepository) UpdateReport(ctx context.Context, report *types.Report) error {
+	f.updates++
+	copied := *report
+	f.reports[report.UUID] = &copied
+	return nil
+}
+
+// memoryReportDownloadLogRepository 内存下载访问日志
+type memoryReportDownloadLogRepository struct {
+	logs []types.ReportDownloadLog
+}
+
+func (f *memoryReportDownloadLogRepository) Create(ctx context.Context, log *types.ReportDownloadLog) error {
+	log.ID = uint64(len(f.logs) + 1)
+	f.logs = append(f.logs, *log)
+	return nil
+}
+
+func (f *memoryReportDownloadLogRepository) ListByReport(ctx context.Context, reportID uint64, limit int) ([]types.ReportDownloadLog, error) {
+	var logs []types.ReportDownloadLog
+	for i := len(f.logs) - 1; i >= 0 && len(logs) < limit; i-- {
+		if f.logs[i].ReportID == reportID {
+			logs = append(logs, f.logs[i])
+		}
+	}
+	return logs, nil
+}
+
+// fakeReportStorage 记录上传次数的对象存储桩
+type fakeReportStorage struct {
+	uploads int
+}
+
+func (f *fakeReportStorage) UploadFromBytes(ctx context.Context, data []byte, fileName, contentType string, options *oss.UploadOptions) (*oss.UploadFileInfo, error) {
+	f.uploads++
+	return &oss.UploadFileInfo{ObjectKey: options.Directory + "/" + fileName}, nil
+}
+
+func (f *fakeReportStorage) GetSignedURL(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	return "https://cdn.example.com/" + objectKey + "?Expires=" + expiration.String(), nil
+}
+
+// parseIssuedLink 从签发的本地下载链接中解析参数
+func parseIssuedLink(t *testing.T, downloadURL string) *ReportDownloadLink {
+	parsed, err := url.Parse(downloadURL)
+	require.NoError(t, err)
+	uuid := strings.TrimPrefix(parsed.Path, "/api/v1/report-downloads/")
+	link, err := ParseReportDownloadLink(uuid, parsed.Query())
+	require.NoError(t, err)
+	return link
+}
+
+func TestReportDownloadService(t *testing.T) {
+	now := time.Date(2025, 9, 1, 10, 0, 0, 0, time.Local)
+	clock := func() time.Time { return now }
+
+	filePath := filepath.Join(t.TempDir(), "financial.xlsx")
+	require.NoError(t, os.WriteFile(filePath, []byte("report"), 0o644))
+
+	userID := uint64(7)
+	ctx := context.WithValue(context.WithValue(context.Background(), "tenant_id", uint64(1)), "user_id", userID)
+	requester := &ReportDownloadRequester{UserID: &userID, ClientIP: "10.0.0.1", UserAgent: "test-agent"}
+	anonymous := func(ip string) *ReportDownloadRequester {
+		return &ReportDownloadRequester{ClientIP: ip, UserAgent: "curl"}
+	}
+
+	newService := func(config *ReportDownloadConfig) (*ReportDownloadService, *downloadReportRepository, *memoryReportDownloadLogRepository, *fakeReportStorage) {
+		reportRepo := &downloadReportRepository{reports: map[string]*types.Report{
+			"rpt-1": {ID: 11, UUID: "rpt-1", TenantID: 1, Status: types.ReportStatusCompleted, FilePath: filePath, FileFormat: types.FileFormatExcel},
+			"rpt-2": {ID: 12, UUID: "rpt-2", TenantID: 2, Status: types.ReportStatusCompleted, FilePath: filePath, FileFormat: types.FileFormatExcel},
+		}}
+		logRepo := &memoryReportDownloadLogRepository{}
+		storage := &fakeReportStorage{}
+		return NewReportDownloadServiceForTest(reportRepo, logRepo, storage, config, clock), reportRepo, logRepo, storage
+	}
+
+	t.Run("签名链接下载并记录签发链接的用户", func(t *testing.T) {
+		downloads, _, logRepo, _ := newService(&ReportDownloadConfig{Secret: "secret", URLExpiry: 10 * time.Minute})
+
+		downloadURL, err := downloads.IssueDownloadURL(ctx, "rpt-1", requester)
+		require.NoError(t, err)
+		assert.Equal(t, types.ReportDownloadBackendLocal, downloadURL.Backend)
+		assert.Equal(t, now.Add(10*time.Minute), downloadURL.ExpiresAt)
+		assert.Empty(t, logRepo.logs, "签发本地链接时还未下载")
+
+		link := parseIssuedLink(t, downloadURL.URL)
+		report, err := downloads.OpenSignedDownload(context.Background(), link, anonymous("10.0.0.2"))
+		require.NoError(t, err)
+		assert.Equal(t, "rpt-1", report.UUID)
+
+		require.Len(t, logRepo.logs, 1)
+		log := logRepo.logs[0]
+		assert.Equal(t, uint64(1), log.TenantID)
+		assert.Equal(t, uint64(11), log.ReportID)
+		assert.Equal(t, types.ReportDownloadChannelSignedURL, log.Channel)
+		require.NotNil(t, log.UserID)
+		assert.Equal(t, userID, *log.UserID)
+		assert.Equal(t, "10.0.0.2", log.ClientIP)
+		assert.Equal(t, now, log.CreatedAt)
+
+		logs, err := downloads.ListDownloadLogs(ctx, "rpt-1")
+		require.NoError(t, err)
+		assert.Len(t, logs, 1)
+	})
+
+	t.Run("篡改链接参数时签名校验失败", func(t *testing.T) {
+		downloads, _, logRepo, _ := newService(&ReportDownloadConfig{Secret: "secret"})
+		downloadURL, err := downloads.IssueDownloadURL(ctx, "rpt-1", requester)
+		require.NoError(t, err)
+
+		tampers := map[string]func(link *ReportDownloadLink){
+			"其他报表":  func(link *ReportDownloadLink) { link.ReportUUID = "rpt-2" },
+			"其他租户":  func(link *ReportDownloadLink) { link.TenantID = 2 },
+			"延长有效期": func(link *ReportDownloadLink) { link.ExpiresAt += 3600 },
+			"冒充用户":  func(link *ReportDownloadLink) { link.UserID = 1 },
+			"伪造签名":  func(link *ReportDownloadLink) { link.Signature = strings.Repeat("0", 64) },
+		}
+		for name, tamper := range tampers {
+			link := parseIssuedLink(t, downloadURL.URL)
+			tamper(link)
+			_, err := downloads.OpenSignedDownload(context.Background(), link, anonymous("10.0.0.1"))
+			assert.ErrorIs(t, err, types.ErrReportDownloadSignatureInvalid, name)
+		}
+
+		// 其他密钥签发的链接无效
+		other, _, _, _ := newService(&ReportDownloadConfig{Secret: "other"})
+		_, err = other.OpenSignedDownload(context.Background(), parseIssuedLink(t, downloadURL.URL), anonymous("10.0.0.1"))
+		assert.ErrorIs(t, err, types.ErrReportDownloadSignatureInvalid)
+		assert.Empty(t, logRepo.logs)
+	})
+
+	t.Run("过期的链接被拒绝", func(t *testing.T) {
+		downloads, _, _, _ := newService(&ReportDownloadConfig{Secret: "secret", URLExpiry: 5 * time.Minute})
+		downloadURL, err := downloads.IssueDownloadURL(ctx, "rpt-1", requester)
+		require.NoError(t, err)
+		link := parseIssuedLink(t, downloadURL.URL)
+
+		// 到期前一秒仍可下载，到期时刻起拒绝
+		later, _, logRepo, _ := newService(&ReportDownloadConfig{Secret: "secret"})
+		later.signer = NewReportURLSigner("secret", func() time.Time { return now.Add(5*time.Minute - time.Second) })
+		_, err = later.OpenSignedDownload(context.Background(), link, anonymous("10.0.0.1"))
+		require.NoError(t, err)
+
+		later.signer = NewReportURLSigner("secret", func() time.Time { return now.Add(5 * time.Minute) })
+		_, err = later.OpenSignedDownload(context.Background(), link, anonymous("10.0.0.1"))
+		assert.ErrorIs(t, err, types.ErrReportDownloadURLExpired)
+		assert.Len(t, logRepo.logs, 1, "过期链接不记录下载")
+	})
+
+	t.Run("绑定IP的链接只能在申请链接的IP使用", func(t *testing.T) {
+		downloads, _, _, _ := newService(&ReportDownloadConfig{Secret: "secret", BindIP: true})
+		downloadURL, err := downloads.IssueDownloadURL(ctx, "rpt-1", requester)
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.1", downloadURL.BoundIP)
+
+		link := parseIssuedLink(t, downloadURL.URL)
+		_, err = downloads.OpenSignedDownload(context.Background(), link, anonymous("10.0.0.2"))
+		assert.ErrorIs(t, err, types.ErrReportDownloadIPMismatch)
+
+		// 去掉绑定的IP后签名失效
+		link.IP = ""
+		_, err = downloads.OpenSignedDownload(context.Background(), link, anonymous("10.0.0.2"))
+		assert.ErrorIs(t, err, types.ErrReportDownloadSignatureInvalid)
+
+		_, err = downloads.OpenSignedDownload(context.Background(), parseIssuedLink(t, downloadURL.URL), anonymous("10.0.0.1"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("不能为其他租户的报表签发链接", func(t *testing.T) {
+		downloads, _, _, _ := newService(&ReportDownloadConfig{Secret: "secret"})
+		_, err := downloads.IssueDownloadURL(ctx, "rpt-2", requester)
+		assert.Error(t, err)
+	})
+
+	t.Run("对象存储交付时首次签发链接上传报表并记录签发", func(t *testing.T) {
+		downloads, reportRepo, logRepo, storage := newService(&ReportDownloadConfig{Backend: types.ReportDownloadBackendOSS, Secret: "secret", URLExpiry: time.Hour})
+
+		first, err := downloads.IssueDownloadURL(ctx, "rpt-1", requester)
+		require.NoError(t, err)
+		assert.Equal(t, types.ReportDownloadBackendOSS, first.Backend)
+		assert.Contains(t, first.URL, "reports/1/rpt-1/financial.xlsx")
+		assert.Equal(t, "reports/1/rpt-1/financial.xlsx", reportRepo.reports["rpt-1"].ObjectKey)
+
+		_, err = downloads.IssueDownloadURL(ctx, "rpt-1", requester)
+		require.NoError(t, err)
+		assert.Equal(t, 1, storage.uploads, "已上传的报表不重复上传")
+		assert.Equal(t, 1, reportRepo.updates)
+
+		require.Len(t, logRepo.logs, 2)
+		assert.Equal(t, types.ReportDownloadChannelObjectStorage, logRepo.logs[0].Channel)
+		assert.Equal(t, "test-agent", logRepo.logs[0].UserAgent)
+	})
+
+	t.Run("未配置签名密钥时拒绝签发和校验链接", func(t *testing.T) {
+		signed, _, _, _ := newService(&ReportDownloadConfig{Secret: "secret"})
+		downloadURL, err := signed.IssueDownloadURL(ctx, "rpt-1", requester)
+		require.NoError(t, err)
+
+		// 空密钥也能算出签名，必须在签名前拒绝
+		downloads, _, logRepo, _ := newService(&ReportDownloadConfig{})
+		_, err = downloads.IssueDownloadURL(ctx, "rpt-1", requester)
+		assert.ErrorIs(t, err, types.ErrReportDownloadSecretMissing)
+
+		link := parseIssuedLink(t, downloadURL.URL)
+		link.Signature = NewReportURLSigner("", clock).Sign(link)
+		_, err = downloads.OpenSignedDownload(context.Background(), link, anonymous("10.0.0.1"))
+		assert.ErrorIs(t, err, types.ErrReportDownloadSecretMissing)
+		assert.Empty(t, logRepo.logs)
+	})
+
+	t.Run("有效期限制在上限内", func(t *testing.T) {
+		config := (&ReportDownloadConfig{Backend: "ftp", URLExpiry: 30 * 24 * time.Hour}).normalize()
+		assert.Equal(t, types.ReportDownloadBackendLocal, config.Backend)
+		assert.Equal(t, maxReportDownloadURLExpiry, config.URLExpiry)
+		assert.Equal(t, defaultReportDownloadURLExpiry, (&ReportDownloadConfig{}).normalize().URLExpiry)
+	})
+}
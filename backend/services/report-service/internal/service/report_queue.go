@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 报表生成并发默认配置
+const (
+	defaultReportMaxConcurrent      = 4
+	defaultReportMaxQueuedPerTenant = 20
+)
+
+// ErrReportQueueFull 租户排队中的报表过多
+var ErrReportQueueFull = errors.New("排队中的报表过多，请稍后再试")
+
+// ReportQueueConfig 报表生成并发配置
+type ReportQueueConfig struct {
+	MaxConcurrent      int `json:"max_concurrent"`        // 全局同时生成的报表数
+	MaxPerTenant       int `json:"max_per_tenant"`        // 单个租户同时生成的报表数，默认为全局上限的一半
+	MaxQueuedPerTenant int `json:"max_queued_per_tenant"` // 单个租户排队的报表数，超过时拒绝
+}
+
+// reportGenerationJob 排队中的报表生成任务
+type reportGenerationJob struct {
+	tenantID   uint64
+	reportUUID string
+	run        func()
+}
+
+// ReportGenerationQueue 报表生成队列。
+// 限制同时生成的报表数，超出的请求按租户轮询排队，避免单个租户占满生成能力
+type ReportGenerationQueue struct {
+	mu                 sync.Mutex
+	maxConcurrent      int
+	maxPerTenant       int
+	maxQueuedPerTenant int
+
+	running       int
+	tenantRunning map[uint64]int
+	queues        map[uint64][]*reportGenerationJob
+	tenantOrder   []uint64 // 有排队任务的租户，按轮询顺序排列
+}
+
+var (
+	reportQueueOnce sync.Once
+	reportQueue     *ReportGenerationQueue
+)
+
+// DefaultReportGenerationQueue 获取进程内共享的报表生成队列，首次使用时从 report.generation 配置加载
+func DefaultReportGenerationQueue() *ReportGenerationQueue {
+	reportQueueOnce.Do(func() {
+		ctx := context.Background()
+		config := &ReportQueueConfig{}
+		if err := g.Cfg().MustGet(ctx, "report.generation").Scan(config); err != nil {
+			g.Log().Warning(ctx, "报表生成并发配置无效，使用默认值", "error", err)
+			config = &ReportQueueConfig{}
+		}
+		reportQueue = NewReportGenerationQueue(config)
+	})
+	return reportQueue
+}
+
+// NewReportGenerationQueue 创建报表生成队列，未配置的项使用默认值
+func NewReportGenerationQueue(config *ReportQueueConfig) *ReportGenerationQueue {
+	q := &ReportGenerationQueue{
+		maxConcurrent:      config.MaxConcurrent,
+		maxPerTenant:       config.MaxPerTenant,
+		maxQueuedPerTenant: config.MaxQueuedPerTenant,
+		tenantRunning:      make(map[uint64]int),
+		queues:             make(map[uint64][]*reportGenerationJob),
+	}
+	if q.maxConcurrent <= 0 {
+		q.maxConcurrent = defaultReportMaxConcurrent
+	}
+	if q.maxPerTenant <= 0 {
+		q.maxPerTenant = (q.maxConcurrent + 1) / 2
+	}
+	if q.maxPerTenant > q.maxConcurrent {
+		q.maxPerTenant = q.maxConcurrent
+	}
+	if q.maxQueuedPerTenant <= 0 {
+		q.maxQueuedPerTenant = defaultReportMaxQueuedPerTenant
+	}
+	return q
+}
+
+// Submit 提交报表生成任务。
+// 有空闲名额时立即开始生成并返回0，否则排队并返回预计排队位置（从1开始）
+func (q *ReportGenerationQueue) Submit(tenantID uint64, reportUUID string, run func()) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.queues[tenantID]) >= q.maxQueuedPerTenant {
+		return 0, ErrReportQueueFull
+	}
+
+	if len(q.queues[tenantID]) == 0 {
+		q.tenantOrder = append(q.tenantOrder, tenantID)
+	}
+	q.queues[tenantID] = append(q.queues[tenantID], &reportGenerationJob{
+		tenantID:   tenantID,
+		reportUUID: reportUUID,
+		run:        run,
+	})
+	q.dispatch()
+
+	return q.position(tenantID, reportUUID), nil
+}
+
+// Position 报表的预计排队位置（从1开始），未在排队时返回0
+func (q *ReportGenerationQueue) Position(tenantID uint64, reportUUID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.position(tenantID, reportUUID)
+}
+
+// Stats 生成中和排队中的报表数
+func (q *ReportGenerationQueue) Stats() (running int, queued int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, jobs := range q.queues {
+		queued += len(jobs)
+	}
+	return q.running, queued
+}
+
+// position 按轮询顺序估算排队位置：
+// 排在前面的有本租户更早的任务，以及其他租户在同一轮及之前轮次的任务
+func (q *ReportGenerationQueue) position(tenantID uint64, reportUUID string) int {
+	index := -1
+	for i, job := range q.queues[tenantID] {
+		if job.reportUUID == reportUUID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return 0
+	}
+
+	ahead := index
+	seenSelf := false
+	for _, other := range q.tenantOrder {
+		if other == tenantID {
+			seenSelf = true
+			continue
+		}
+		rounds := index
+		if !seenSelf {
+			// 轮询顺序在前的租户同一轮先出队
+			rounds++
+		}
+		ahead += min(len(q.queues[other]), rounds)
+	}
+	return ahead + 1
+}
+
+// dispatch 在有空闲名额时按租户轮询启动排队的任务，调用方需持有锁
+func (q *ReportGenerationQueue) dispatch() {
+	for q.running < q.maxConcurrent {
+		next := -1
+		for i, tenantID := range q.tenantOrder {
+			if q.tenantRunning[tenantID] < q.maxPerTenant {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return
+		}
+
+		tenantID := q.tenantOrder[next]
+		job := q.queues[tenantID][0]
+		q.queues[tenantID] = q.queues[tenantID][1:]
+
+		// 出队的租户移到轮询末尾，没有剩余任务时移出
+		q.tenantOrder = append(q.tenantOrder[:next], q.tenantOrder[next+1:]...)
+		if len(q.queues[tenantID]) > 0 {
+			q.tenantOrder = append(q.tenantOrder, tenantID)
+		} else {
+			delete(q.queues, tenantID)
+		}
+
+		q.running++
+		q.tenantRunning[tenantID]++
+		go q.execute(job)
+	}
+}
+
+// execute 执行任务，结束后释放名额并启动下一个排队任务
+func (q *ReportGenerationQueue) execute(job *reportGenerationJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.Log().Error(context.Background(), "报表生成异常", "report_uuid", job.reportUUID, "panic", r)
+		}
+
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.running--
+		if q.tenantRunning[job.tenantID]--; q.tenantRunning[job.tenantID] <= 0 {
+			delete(q.tenantRunning, job.tenantID)
+		}
+		q.dispatch()
+	}()
+
+	job.run()
+}
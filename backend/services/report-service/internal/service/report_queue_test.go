@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingJob 阻塞直到 release 关闭的生成任务，记录并发峰值
+type blockingJob struct {
+	release chan struct{}
+	running int32
+	peak    int32
+	order   []string
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+}
+
+func newBlockingJob() *blockingJob {
+	return &blockingJob{release: make(chan struct{})}
+}
+
+func (b *blockingJob) run(name string) func() {
+	b.wg.Add(1)
+	return func() {
+		defer b.wg.Done()
+		current := atomic.AddInt32(&b.running, 1)
+		for {
+			peak := atomic.LoadInt32(&b.peak)
+			if current <= peak || atomic.CompareAndSwapInt32(&b.peak, peak, current) {
+				break
+			}
+		}
+
+		b.mu.Lock()
+		b.order = append(b.order, name)
+		b.mu.Unlock()
+
+		<-b.release
+		atomic.AddInt32(&b.running, -1)
+	}
+}
+
+func TestReportGenerationQueue_BoundedConcurrency(t *testing.T) {
+	queue := NewReportGenerationQueue(&ReportQueueConfig{MaxConcurrent: 3, MaxPerTenant: 3, MaxQueuedPerTenant: 20})
+	jobs := newBlockingJob()
+
+	positions := make([]int, 0, 10)
+	for i := 0; i < 10; i++ {
+		uuid := fmt.Sprintf("r%d", i)
+		position, err := queue.Submit(1, uuid, jobs.run(uuid))
+		require.NoError(t, err)
+		positions = append(positions, position)
+	}
+
+	// 前3个立即开始，其余按提交顺序排队
+	assert.Equal(t, []int{0, 0, 0, 1, 2, 3, 4, 5, 6, 7}, positions)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&jobs.running) == 3 }, time.Second, 5*time.Millisecond)
+
+	running, queued := queue.Stats()
+	assert.Equal(t, 3, running)
+	assert.Equal(t, 7, queued)
+	assert.Equal(t, 1, queue.Position(1, "r3"))
+	assert.Equal(t, 0, queue.Position(1, "r0"))
+
+	close(jobs.release)
+	jobs.wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&jobs.peak), int32(3))
+	require.Eventually(t, func() bool {
+		running, queued := queue.Stats()
+		return running == 0 && queued == 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestReportGenerationQueue_TenantFairness(t *testing.T) {
+	queue := NewReportGenerationQueue(&ReportQueueConfig{MaxConcurrent: 2, MaxPerTenant: 1, MaxQueuedPerTenant: 2})
+	jobs := newBlockingJob()
+
+	// 租户1先提交一批，单租户最多占用1个名额
+	for i := 0; i < 3; i++ {
+		uuid := fmt.Sprintf("a%d", i)
+		_, err := queue.Submit(1, uuid, jobs.run(uuid))
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&jobs.running) == 1 }, time.Second, 5*time.Millisecond)
+
+	// 超过单租户排队上限时拒绝
+	_, err := queue.Submit(1, "a3", func() {})
+	assert.ErrorIs(t, err, ErrReportQueueFull)
+
+	// 租户2后提交也能立即使用空闲名额
+	position, err := queue.Submit(2, "b0", jobs.run("b0"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, position)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&jobs.running) == 2 }, time.Second, 5*time.Millisecond)
+
+	// 租户2的下一个报表与租户1轮流出队
+	position, err = queue.Submit(2, "b1", jobs.run("b1"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, position)
+	assert.Equal(t, 1, queue.Position(1, "a1"))
+	assert.Equal(t, 3, queue.Position(1, "a2"))
+
+	close(jobs.release)
+	jobs.wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&jobs.peak), int32(2))
+	assert.Equal(t, []string{"a0", "b0"}, jobs.order[:2])
+}
+
+func TestNewReportGenerationQueue_Defaults(t *testing.T) {
+	queue := NewReportGenerationQueue(&ReportQueueConfig{})
+	assert.Equal(t, defaultReportMaxConcurrent, queue.maxConcurrent)
+	assert.Equal(t, 2, queue.maxPerTenant)
+	assert.Equal(t, defaultReportMaxQueuedPerTenant, queue.maxQueuedPerTenant)
+
+	queue = NewReportGenerationQueue(&ReportQueueConfig{MaxConcurrent: 1, MaxPerTenant: 5})
+	assert.Equal(t, 1, queue.maxPerTenant)
+}
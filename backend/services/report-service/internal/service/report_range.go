@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// defaultReportMaxRangeDays 未配置时报表时间范围上限为1年
+const defaultReportMaxRangeDays = 365
+
+// ReportRangeLimits 报表时间范围上限（天）。
+// 优先级：套餐+报表类型 > 套餐默认 > 报表类型 > 全局默认 > 1年
+type ReportRangeLimits struct {
+	Default int                           `json:"default"`
+	Types   map[types.ReportType]int      `json:"types"`
+	Plans   map[string]*ReportRangeLimits `json:"plans"` // 套餐级配置，只使用其中的 default 和 types
+}
+
+// LoadReportRangeLimits 从 report.max_range_days 配置加载报表时间范围上限
+func LoadReportRangeLimits(ctx context.Context) *ReportRangeLimits {
+	limits := &ReportRangeLimits{}
+	if err := g.Cfg().MustGet(ctx, "report.max_range_days").Scan(limits); err != nil {
+		g.Log().Warning(ctx, "报表时间范围上限配置无效，使用默认值", "error", err)
+		return &ReportRangeLimits{}
+	}
+	return limits
+}
+
+// MaxRangeDays 获取套餐下报表类型允许的最大时间范围（天）
+func (l *ReportRangeLimits) MaxRangeDays(plan string, reportType types.ReportType) int {
+	if l == nil {
+		return defaultReportMaxRangeDays
+	}
+	if planLimits, exists := l.Plans[plan]; exists && planLimits != nil {
+		if days := planLimits.Types[reportType]; days > 0 {
+			return days
+		}
+		if planLimits.Default > 0 {
+			return planLimits.Default
+		}
+	}
+	if days := l.Types[reportType]; days > 0 {
+		return days
+	}
+	if l.Default > 0 {
+		return l.Default
+	}
+	return defaultReportMaxRangeDays
+}
+
+// Check 检查时间范围是否超过套餐下报表类型的上限，错误信息中说明允许的上限
+func (l *ReportRangeLimits) Check(plan string, reportType types.ReportType, startDate, endDate time.Time) error {
+	maxDays := l.MaxRangeDays(plan, reportType)
+	if endDate.Sub(startDate) <= time.Duration(maxDays)*24*time.Hour {
+		return nil
+	}
+
+	if plan == "" {
+		plan = "默认"
+	}
+	return fmt.Errorf("时间范围不能超过%s（%s套餐下%s报表的上限为%d天）", describeRangeDays(maxDays), plan, reportType, maxDays)
+}
+
+// describeRangeDays 将天数描述为整年或天数
+func describeRangeDays(days int) string {
+	if days%365 == 0 {
+		return fmt.Sprintf("%d年", days/365)
+	}
+	return fmt.Sprintf("%d天", days)
+}
+
+// TenantPlanProvider 按租户获取订阅套餐
+type TenantPlanProvider func(ctx context.Context, tenantID uint64) (string, error)
+
+// Plan 获取租户订阅套餐，未配置提供者或获取失败时按默认套餐处理
+func (p TenantPlanProvider) Plan(ctx context.Context, tenantID uint64) string {
+	if p == nil {
+		return ""
+	}
+	plan, err := p(ctx, tenantID)
+	if err != nil {
+		g.Log().Warning(ctx, "获取租户套餐失败，使用默认报表范围上限", "tenant_id", tenantID, "error", err)
+		return ""
+	}
+	return plan
+}
+
+// NewTenantPlanProvider 创建从租户配置读取订阅套餐的提供者
+func NewTenantPlanProvider(tenantRepo repository.ITenantRepository) TenantPlanProvider {
+	return func(ctx context.Context, tenantID uint64) (string, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return "", fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return "", nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return "", fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.Plan, nil
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRangeLimits() *ReportRangeLimits {
+	return &ReportRangeLimits{
+		Default: 365,
+		Types: map[types.ReportType]int{
+			types.ReportTypeCustomerAnalysis: 730,
+			types.ReportTypeFinancial:        180,
+		},
+		Plans: map[string]*ReportRangeLimits{
+			"premium": {
+				Default: 1095,
+				Types:   map[types.ReportType]int{types.ReportTypeFinancial: 730},
+			},
+			"basic": {
+				Default: 90,
+			},
+		},
+	}
+}
+
+func TestReportRangeLimits_MaxRangeDays(t *testing.T) {
+	limits := newTestRangeLimits()
+
+	tests := []struct {
+		name       string
+		plan       string
+		reportType types.ReportType
+		want       int
+	}{
+		{"报表类型配置", "", types.ReportTypeCustomerAnalysis, 730},
+		{"重型财务报表更短", "", types.ReportTypeFinancial, 180},
+		{"未配置类型使用全局默认", "", types.ReportTypeMerchantOperation, 365},
+		{"套餐+报表类型", "premium", types.ReportTypeFinancial, 730},
+		{"套餐默认", "premium", types.ReportTypeMerchantOperation, 1095},
+		{"套餐默认优先于报表类型", "basic", types.ReportTypeCustomerAnalysis, 90},
+		{"未知套餐按全局配置", "enterprise", types.ReportTypeCustomerAnalysis, 730},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, limits.MaxRangeDays(tt.plan, tt.reportType))
+		})
+	}
+
+	// 未配置时默认1年
+	assert.Equal(t, 365, (&ReportRangeLimits{}).MaxRangeDays("premium", types.ReportTypeFinancial))
+	var nilLimits *ReportRangeLimits
+	assert.Equal(t, 365, nilLimits.MaxRangeDays("", types.ReportTypeFinancial))
+}
+
+func TestReportGeneratorService_ValidateRange(t *testing.T) {
+	plans := map[uint64]string{1: "", 2: "premium", 3: "basic"}
+	generator := &ReportGeneratorService{
+		rangeLimits: newTestRangeLimits(),
+		planProvider: func(ctx context.Context, tenantID uint64) (string, error) {
+			plan, exists := plans[tenantID]
+			if !exists {
+				return "", errors.New("租户不存在")
+			}
+			return plan, nil
+		},
+	}
+	ctx := context.Background()
+	endDate := time.Now().AddDate(0, 0, -1)
+	request := func(reportType types.ReportType, days int) *types.ReportCreateRequest {
+		return &types.ReportCreateRequest{
+			ReportType: reportType,
+			PeriodType: types.PeriodTypeCustom,
+			StartDate:  endDate.AddDate(0, 0, -days),
+			EndDate:    endDate,
+			FileFormat: types.FileFormatExcel,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		tenantID uint64
+		req      *types.ReportCreateRequest
+		errMsg   string
+	}{
+		{"客户分析允许2年", 1, request(types.ReportTypeCustomerAnalysis, 700), ""},
+		{"客户分析超过2年", 1, request(types.ReportTypeCustomerAnalysis, 800), "时间范围不能超过2年（默认套餐下customer_analysis报表的上限为730天）"},
+		{"财务报表超过180天", 1, request(types.ReportTypeFinancial, 200), "时间范围不能超过180天"},
+		{"高级套餐财务报表允许2年", 2, request(types.ReportTypeFinancial, 700), ""},
+		{"高级套餐运营报表允许3年", 2, request(types.ReportTypeMerchantOperation, 1000), ""},
+		{"基础套餐客户分析超过90天", 3, request(types.ReportTypeCustomerAnalysis, 100), "时间范围不能超过90天（basic套餐下customer_analysis报表的上限为90天）"},
+		{"获取套餐失败按默认配置", 9, request(types.ReportTypeMerchantOperation, 400), "时间范围不能超过1年"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := generator.validateGenerateRequest(ctx, tt.tenantID, tt.req)
+			if tt.errMsg == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
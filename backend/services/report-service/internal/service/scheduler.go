@@ -3,13 +3,14 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/schedule"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
-	"github.com/gogf/gf/v2/os/gcron"
 	"github.com/gogf/gf/v2/os/gctx"
 )
 
@@ -19,6 +20,9 @@ type ISchedulerService interface {
 	Stop(ctx context.Context) error
 	ProcessPendingJobs(ctx context.Context) error
 	ScheduleTemplateJobs(ctx context.Context) error
+	ListJobRuns(ctx context.Context, req *types.ReportJobListRequest) ([]*types.ReportJob, error)
+	GetJobRun(ctx context.Context, jobID uint64) (*types.ReportJobDetail, error)
+	RetryJob(ctx context.Context, jobID uint64) (*types.ReportJob, error)
 }
 
 // SchedulerService 调度服务实现
@@ -26,7 +30,10 @@ type SchedulerService struct {
 	reportRepo      repository.IReportRepository
 	generatorService IReportGeneratorService
 	templateService  ITemplateService
-	cron            *gcron.Cron
+	notificationService INotificationService
+	retryPolicy     *ReportJobRetryPolicy
+	now             func() time.Time
+	jobs            *schedule.Scheduler // 多副本部署时只有领导者副本执行调度
 	isRunning       bool
 }
 
@@ -36,11 +43,27 @@ func NewSchedulerService() ISchedulerService {
 		reportRepo:      repository.NewReportRepository(),
 		generatorService: NewReportGeneratorService(),
 		templateService:  NewTemplateService(),
-		cron:            gcron.New(),
+		notificationService: NewNotificationService(),
+		retryPolicy:     LoadReportJobRetryPolicy(context.Background()),
+		now:             time.Now,
+		jobs:            schedule.New("report-service"),
 		isRunning:       false,
 	}
 }
 
+// NewSchedulerServiceForTest 创建测试用调度服务实例，可指定当前时间
+func NewSchedulerServiceForTest(reportRepo repository.IReportRepository, generatorService IReportGeneratorService, templateService ITemplateService, notificationService INotificationService, retryPolicy *ReportJobRetryPolicy, now func() time.Time) ISchedulerService {
+	return &SchedulerService{
+		reportRepo:          reportRepo,
+		generatorService:    generatorService,
+		templateService:     templateService,
+		notificationService: notificationService,
+		retryPolicy:         retryPolicy.withDefaults(),
+		now:                 now,
+		jobs:                schedule.New("report-service"),
+	}
+}
+
 // Start 启动调度服务
 func (s *SchedulerService) Start(ctx context.Context) error {
 	if s.isRunning {
@@ -50,27 +73,27 @@ func (s *SchedulerService) Start(ctx context.Context) error {
 	g.Log().Info(ctx, "启动报表调度服务")
 	
 	// 每分钟检查一次待执行的任务
-	_, err := s.cron.Add(ctx, "*/1 * * * *", func(ctx context.Context) {
-		if err := s.ProcessPendingJobs(ctx); err != nil {
-			g.Log().Error(ctx, "处理待执行任务失败", "error", err)
-		}
-	}, "ProcessPendingJobs")
-	if err != nil {
+	err := s.jobs.Register(schedule.Job{
+		Name:     "ProcessPendingJobs",
+		Schedule: schedule.Every(time.Minute),
+		Run:      s.ProcessPendingJobs,
+	})
+	if err != nil && !errors.Is(err, schedule.ErrJobExists) {
 		return fmt.Errorf("添加任务处理定时器失败: %v", err)
 	}
 	
 	// 每小时检查一次模板调度
-	_, err = s.cron.Add(ctx, "0 * * * *", func(ctx context.Context) {
-		if err := s.ScheduleTemplateJobs(ctx); err != nil {
-			g.Log().Error(ctx, "模板调度失败", "error", err)
-		}
-	}, "ScheduleTemplateJobs")
-	if err != nil {
+	err = s.jobs.Register(schedule.Job{
+		Name:     "ScheduleTemplateJobs",
+		Schedule: schedule.HourlyAt(0),
+		Run:      s.ScheduleTemplateJobs,
+	})
+	if err != nil && !errors.Is(err, schedule.ErrJobExists) {
 		return fmt.Errorf("添加模板调度定时器失败: %v", err)
 	}
 	
 	// 启动定时器
-	s.cron.Start()
+	s.jobs.Start(ctx)
 	s.isRunning = true
 	
 	g.Log().Info(ctx, "报表调度服务启动成功")
@@ -85,7 +108,7 @@ func (s *SchedulerService) Stop(ctx context.Context) error {
 	
 	g.Log().Info(ctx, "停止报表调度服务")
 	
-	s.cron.Stop()
+	s.jobs.Stop(ctx)
 	s.isRunning = false
 	
 	g.Log().Info(ctx, "报表调度服务停止成功")
@@ -157,8 +180,10 @@ func (s *SchedulerService) processJob(ctx context.Context, job *types.ReportJob)
 	
 	// 更新任务状态为运行中
 	job.Status = types.JobStatusRunning
-	startTime := time.Now()
+	startTime := s.now()
 	job.StartedAt = &startTime
+	job.CompletedAt = nil
+	job.AttemptCount++
 	
 	err := s.reportRepo.UpdateReportJob(ctx, job)
 	if err != nil {
@@ -169,24 +194,19 @@ func (s *SchedulerService) processJob(ctx context.Context, job *types.ReportJob)
 	var reportErr error
 	
 	defer func() {
-		// 更新任务完成状态
-		endTime := time.Now()
-		job.CompletedAt = &endTime
-		
-		if reportErr != nil {
-			job.Status = types.JobStatusFailed
-			job.ErrorMessage = reportErr.Error()
-			job.RetryCount++
-		} else {
-			job.Status = types.JobStatusCompleted
-		}
+		// 记录本次执行，临时错误按退避时间重新排队，超过重试次数后最终失败
+		s.recordJobAttempt(ctx, job, startTime, reportErr)
 		
 		if err := s.reportRepo.UpdateReportJob(ctx, job); err != nil {
 			g.Log().Error(ctx, "更新任务完成状态失败", "job_id", job.ID, "error", err)
 		}
 		
-		// 为模板任务调度下一次执行
-		if job.TemplateID != nil && reportErr == nil {
+		if job.Status == types.JobStatusFailed {
+			s.notifyJobFailed(ctx, job)
+		}
+		
+		// 为模板任务调度下一次执行（自动重试中的任务在重试结束后再调度）
+		if job.TemplateID != nil && job.Status != types.JobStatusPending {
 			if err := s.scheduleNextTemplateJob(ctx, *job.TemplateID); err != nil {
 				g.Log().Error(ctx, "调度下一次模板任务失败", "template_id", *job.TemplateID, "error", err)
 			}
@@ -199,7 +219,7 @@ func (s *SchedulerService) processJob(ctx context.Context, job *types.ReportJob)
 	} else if job.ReportID != nil {
 		reportErr = s.processReportJob(ctx, job)
 	} else {
-		reportErr = fmt.Errorf("任务没有关联的模板或报表")
+		reportErr = permanentJobError("任务没有关联的模板或报表")
 	}
 	
 	return reportErr
@@ -216,7 +236,7 @@ func (s *SchedulerService) processTemplateJob(ctx context.Context, job *types.Re
 	// 解析模板配置
 	var templateConfig map[string]interface{}
 	if err := json.Unmarshal(template.TemplateConfig, &templateConfig); err != nil {
-		return fmt.Errorf("解析模板配置失败: %v", err)
+		return permanentJobError("解析模板配置失败: %v", err)
 	}
 	
 	// 构建报表生成请求
@@ -264,7 +284,7 @@ func (s *SchedulerService) processReportJob(ctx context.Context, job *types.Repo
 	}
 	
 	if report.Status == types.ReportStatusFailed {
-		return fmt.Errorf("关联的报表生成失败")
+		return permanentJobError("关联的报表生成失败")
 	}
 	
 	// 报表还在生成中，继续等待
@@ -287,7 +307,7 @@ func (s *SchedulerService) scheduleTemplateIfNeeded(ctx context.Context, templat
 	}
 	
 	// 检查是否已有该模板的未来任务
-	now := time.Now()
+	now := s.now()
 	hasFutureJob := false
 	
 	for _, job := range pendingJobs {
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	defaultReportJobMaxAttempts = 3
+	defaultReportJobBaseBackoff = 5 * time.Minute
+	defaultReportJobMaxBackoff  = time.Hour
+)
+
+var (
+	// ErrReportJobPermanent 任务配置错误等重试也无法成功的失败，不自动重试
+	ErrReportJobPermanent = errors.New("报表任务无法重试")
+	// ErrReportJobNotFound 报表任务不存在
+	ErrReportJobNotFound = errors.New("报表任务不存在")
+	// ErrReportJobNotFailed 只有最终失败的任务可以手动重试
+	ErrReportJobNotFailed = errors.New("只有失败的报表任务可以重试")
+)
+
+// permanentJobError 标记为不可自动重试的失败
+func permanentJobError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrReportJobPermanent, fmt.Sprintf(format, args...))
+}
+
+// ReportJobRetryPolicy 定时报表失败自动重试策略
+type ReportJobRetryPolicy struct {
+	MaxAttempts int           `json:"max_attempts"` // 每轮最多执行次数（含首次执行）
+	BaseBackoff time.Duration `json:"base_backoff"` // 首次重试间隔，之后每次翻倍
+	MaxBackoff  time.Duration `json:"max_backoff"`  // 重试间隔上限
+}
+
+// LoadReportJobRetryPolicy 从 report.scheduled_retry 配置加载重试策略，未配置的项使用默认值
+func LoadReportJobRetryPolicy(ctx context.Context) *ReportJobRetryPolicy {
+	policy := &ReportJobRetryPolicy{}
+	if err := g.Cfg().MustGet(ctx, "report.scheduled_retry").Scan(policy); err != nil {
+		g.Log().Warning(ctx, "定时报表重试配置无效，使用默认值", "error", err)
+		policy = &ReportJobRetryPolicy{}
+	}
+	return policy.withDefaults()
+}
+
+func (p *ReportJobRetryPolicy) withDefaults() *ReportJobRetryPolicy {
+	result := *p
+	if result.MaxAttempts <= 0 {
+		result.MaxAttempts = defaultReportJobMaxAttempts
+	}
+	if result.BaseBackoff <= 0 {
+		result.BaseBackoff = defaultReportJobBaseBackoff
+	}
+	if result.MaxBackoff <= 0 {
+		result.MaxBackoff = defaultReportJobMaxBackoff
+	}
+	return &result
+}
+
+// Backoff 第 retry 次自动重试（从1开始）前的等待时间
+func (p *ReportJobRetryPolicy) Backoff(retry int) time.Duration {
+	backoff := p.BaseBackoff
+	for i := 1; i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff
+}
+
+// recordJobAttempt 记录一次执行结果，并决定任务是自动重试还是最终失败
+func (s *SchedulerService) recordJobAttempt(ctx context.Context, job *types.ReportJob, startedAt time.Time, jobErr error) {
+	completedAt := s.now()
+	retryable := jobErr != nil && !errors.Is(jobErr, ErrReportJobPermanent)
+
+	attempt := &types.ReportJobAttempt{
+		JobID:       job.ID,
+		Attempt:     job.AttemptCount,
+		Status:      types.JobStatusCompleted,
+		Retryable:   retryable,
+		StartedAt:   startedAt,
+		CompletedAt: completedAt,
+		DurationMs:  completedAt.Sub(startedAt).Milliseconds(),
+	}
+	if jobErr != nil {
+		attempt.Status = types.JobStatusFailed
+		attempt.ErrorMessage = jobErr.Error()
+	}
+	if err := s.reportRepo.CreateReportJobAttempt(ctx, attempt); err != nil {
+		g.Log().Error(ctx, "记录报表任务执行失败", "job_id", job.ID, "error", err)
+	}
+
+	job.CompletedAt = &completedAt
+	switch {
+	case jobErr == nil:
+		job.Status = types.JobStatusCompleted
+		job.ErrorMessage = ""
+	case retryable && job.RetryCount+1 < s.retryPolicy.MaxAttempts:
+		// 临时错误：按退避时间重新排队
+		job.RetryCount++
+		job.Status = types.JobStatusPending
+		job.ScheduledAt = completedAt.Add(s.retryPolicy.Backoff(job.RetryCount))
+		job.ErrorMessage = jobErr.Error()
+		g.Log().Warning(ctx, "报表任务失败，稍后自动重试",
+			"job_id", job.ID,
+			"retry", job.RetryCount,
+			"next_run", job.ScheduledAt,
+			"error", jobErr)
+	default:
+		job.Status = types.JobStatusFailed
+		job.ErrorMessage = jobErr.Error()
+	}
+}
+
+// notifyJobFailed 通知模板创建人定时报表最终失败
+func (s *SchedulerService) notifyJobFailed(ctx context.Context, job *types.ReportJob) {
+	if job.TemplateID == nil || s.notificationService == nil {
+		return
+	}
+
+	template, err := s.templateService.GetTemplate(ctx, *job.TemplateID)
+	if err != nil {
+		g.Log().Error(ctx, "获取报表模板失败，无法发送失败通知", "job_id", job.ID, "error", err)
+		return
+	}
+
+	err = s.notificationService.SendNotification(ctx, &NotificationRequest{
+		Type:       NotificationTypeInApp,
+		Recipients: []string{strconv.FormatUint(template.CreatedBy, 10)},
+		Subject:    fmt.Sprintf("定时报表「%s」生成失败", template.Name),
+		Content:    fmt.Sprintf("已执行 %d 次仍然失败：%s", job.RetryCount+1, job.ErrorMessage),
+		Priority:   NotificationPriorityHigh,
+		Metadata: map[string]interface{}{
+			"job_id":      job.ID,
+			"template_id": template.ID,
+		},
+	})
+	if err != nil {
+		g.Log().Error(ctx, "发送定时报表失败通知失败", "job_id", job.ID, "error", err)
+	}
+}
+
+// ListJobRuns 获取最近的定时报表执行任务
+func (s *SchedulerService) ListJobRuns(ctx context.Context, req *types.ReportJobListRequest) ([]*types.ReportJob, error) {
+	jobs, err := s.reportRepo.ListReportJobs(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("获取报表任务列表失败: %v", err)
+	}
+	return jobs, nil
+}
+
+// GetJobRun 获取报表任务及其每次执行记录
+func (s *SchedulerService) GetJobRun(ctx context.Context, jobID uint64) (*types.ReportJobDetail, error) {
+	job, err := s.reportRepo.GetReportJob(ctx, jobID)
+	if err != nil || job == nil || job.ID == 0 {
+		return nil, ErrReportJobNotFound
+	}
+
+	attempts, err := s.reportRepo.ListReportJobAttempts(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("获取报表任务执行记录失败: %v", err)
+	}
+
+	return &types.ReportJobDetail{Job: job, Attempts: attempts}, nil
+}
+
+// RetryJob 手动重试最终失败的任务，任务重新获得完整的自动重试次数
+func (s *SchedulerService) RetryJob(ctx context.Context, jobID uint64) (*types.ReportJob, error) {
+	job, err := s.reportRepo.GetReportJob(ctx, jobID)
+	if err != nil || job == nil || job.ID == 0 {
+		return nil, ErrReportJobNotFound
+	}
+	if job.Status != types.JobStatusFailed {
+		return nil, ErrReportJobNotFailed
+	}
+
+	job.Status = types.JobStatusPending
+	job.ScheduledAt = s.now()
+	job.RetryCount = 0
+	job.StartedAt = nil
+	job.CompletedAt = nil
+	if err := s.reportRepo.UpdateReportJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("更新报表任务失败: %v", err)
+	}
+
+	g.Log().Info(ctx, "手动重试报表任务", "job_id", job.ID, "template_id", job.TemplateID)
+	return job, nil
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jobReportRepository 内存报表任务仓储
+type jobReportRepository struct {
+	repository.IReportRepository
+	now      func() time.Time
+	jobs     map[uint64]*types.ReportJob
+	attempts []*types.ReportJobAttempt
+	nextID   uint64
+}
+
+func newJobReportRepository(now func() time.Time) *jobReportRepository {
+	return &jobReportRepository{now: now, jobs: map[uint64]*types.ReportJob{}}
+}
+
+func (f *jobReportRepository) CreateReportJob(ctx context.Context, job *types.ReportJob) error {
+	f.nextID++
+	job.ID = f.nextID
+	stored := *job
+	f.jobs[job.ID] = &stored
+	return nil
+}
+
+func (f *jobReportRepository) GetReportJob(ctx context.Context, id uint64) (*types.ReportJob, error) {
+	job, exists := f.jobs[id]
+	if !exists {
+		return nil, errors.New("not found")
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (f *jobReportRepository) UpdateReportJob(ctx context.Context, job *types.ReportJob) error {
+	stored := *job
+	f.jobs[job.ID] = &stored
+	return nil
+}
+
+func (f *jobReportRepository) ListPendingJobs(ctx context.Context) ([]*types.ReportJob, error) {
+	var jobs []*types.ReportJob
+	for id := uint64(1); id <= f.nextID; id++ {
+		job, exists := f.jobs[id]
+		if exists && job.Status == types.JobStatusPending && !job.ScheduledAt.After(f.now()) {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	return jobs, nil
+}
+
+func (f *jobReportRepository) CreateReportJobAttempt(ctx context.Context, attempt *types.ReportJobAttempt) error {
+	f.attempts = append(f.attempts, attempt)
+	return nil
+}
+
+func (f *jobReportRepository) ListReportJobAttempts(ctx context.Context, jobID uint64) ([]*types.ReportJobAttempt, error) {
+	var attempts []*types.ReportJobAttempt
+	for _, attempt := range f.attempts {
+		if attempt.JobID == jobID {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
+
+// flakyGenerator 前 failures 次生成失败的报表生成服务
+type flakyGenerator struct {
+	IReportGeneratorService
+	failures int
+	calls    int
+}
+
+func (f *flakyGenerator) GenerateReport(ctx context.Context, req *types.ReportCreateRequest) (*types.Report, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, errors.New("数据库连接超时")
+	}
+	return &types.Report{ID: uint64(100 + f.calls)}, nil
+}
+
+// staticTemplateService 返回固定模板的模板服务
+type staticTemplateService struct {
+	ITemplateService
+	template *types.ReportTemplate
+}
+
+func (f *staticTemplateService) GetTemplate(ctx context.Context, templateID uint64) (*types.ReportTemplate, error) {
+	return f.template, nil
+}
+
+// recordingNotificationService 记录发送的通知
+type recordingNotificationService struct {
+	INotificationService
+	sent []*NotificationRequest
+}
+
+func (f *recordingNotificationService) SendNotification(ctx context.Context, req *NotificationRequest) error {
+	f.sent = append(f.sent, req)
+	return nil
+}
+
+type schedulerRetryFixture struct {
+	scheduler ISchedulerService
+	repo      *jobReportRepository
+	generator *flakyGenerator
+	notifier  *recordingNotificationService
+	template  *types.ReportTemplate
+	now       time.Time
+}
+
+func newSchedulerRetryFixture(t *testing.T, failures int) *schedulerRetryFixture {
+	f := &schedulerRetryFixture{now: time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)}
+	f.repo = newJobReportRepository(func() time.Time { return f.now })
+	f.generator = &flakyGenerator{failures: failures}
+	f.notifier = &recordingNotificationService{}
+	f.template = &types.ReportTemplate{
+		ID:             7,
+		TenantID:       1,
+		Name:           "每日财务报表",
+		ReportType:     types.ReportTypeFinancial,
+		TemplateConfig: json.RawMessage(`{}`),
+		ScheduleConfig: json.RawMessage(`{"frequency":"daily","time":"08:00","timezone":"UTC"}`),
+		CreatedBy:      42,
+	}
+	f.scheduler = NewSchedulerServiceForTest(f.repo, f.generator, &staticTemplateService{template: f.template}, f.notifier,
+		&ReportJobRetryPolicy{MaxAttempts: 3, BaseBackoff: 5 * time.Minute, MaxBackoff: time.Hour},
+		func() time.Time { return f.now })
+
+	require.NoError(t, f.repo.CreateReportJob(context.Background(), &types.ReportJob{
+		TenantID:    1,
+		TemplateID:  &f.template.ID,
+		Status:      types.JobStatusPending,
+		ScheduledAt: f.now,
+	}))
+	return f
+}
+
+func TestScheduler_FailingThenSucceedingRun(t *testing.T) {
+	f := newSchedulerRetryFixture(t, 1)
+	ctx := context.Background()
+
+	// 首次执行失败，按退避时间重新排队
+	require.NoError(t, f.scheduler.ProcessPendingJobs(ctx))
+	job := f.repo.jobs[1]
+	assert.Equal(t, types.JobStatusPending, job.Status)
+	assert.Equal(t, 1, job.RetryCount)
+	assert.Equal(t, f.now.Add(5*time.Minute), job.ScheduledAt)
+	assert.Contains(t, job.ErrorMessage, "数据库连接超时")
+
+	// 未到重试时间不执行
+	f.now = f.now.Add(time.Minute)
+	require.NoError(t, f.scheduler.ProcessPendingJobs(ctx))
+	assert.Equal(t, 1, f.generator.calls)
+
+	// 到达重试时间后执行成功
+	f.now = f.now.Add(5 * time.Minute)
+	require.NoError(t, f.scheduler.ProcessPendingJobs(ctx))
+	job = f.repo.jobs[1]
+	assert.Equal(t, types.JobStatusCompleted, job.Status)
+	assert.Empty(t, job.ErrorMessage)
+	assert.Equal(t, 2, job.AttemptCount)
+	require.NotNil(t, job.ReportID)
+
+	detail, err := f.scheduler.GetJobRun(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, detail.Attempts, 2)
+	assert.Equal(t, 1, detail.Attempts[0].Attempt)
+	assert.Equal(t, types.JobStatusFailed, detail.Attempts[0].Status)
+	assert.True(t, detail.Attempts[0].Retryable)
+	assert.Equal(t, 2, detail.Attempts[1].Attempt)
+	assert.Equal(t, types.JobStatusCompleted, detail.Attempts[1].Status)
+
+	assert.Empty(t, f.notifier.sent)
+}
+
+func TestScheduler_PermanentFailureAfterRetries(t *testing.T) {
+	f := newSchedulerRetryFixture(t, 10)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, f.scheduler.ProcessPendingJobs(ctx))
+		f.now = f.now.Add(time.Hour)
+	}
+
+	job := f.repo.jobs[1]
+	assert.Equal(t, types.JobStatusFailed, job.Status)
+	assert.Equal(t, 3, f.generator.calls)
+	assert.Len(t, f.repo.attempts, 3)
+
+	// 最终失败时通知模板创建人
+	require.Len(t, f.notifier.sent, 1)
+	assert.Equal(t, []string{"42"}, f.notifier.sent[0].Recipients)
+
+	// 超过重试次数后不再自动执行
+	require.NoError(t, f.scheduler.ProcessPendingJobs(ctx))
+	assert.Equal(t, 3, f.generator.calls)
+
+	// 手动重试后重新执行
+	f.generator.failures = 0
+	retried, err := f.scheduler.RetryJob(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, types.JobStatusPending, retried.Status)
+	assert.Equal(t, 0, retried.RetryCount)
+
+	require.NoError(t, f.scheduler.ProcessPendingJobs(ctx))
+	assert.Equal(t, types.JobStatusCompleted, f.repo.jobs[1].Status)
+	assert.Equal(t, 4, f.repo.jobs[1].AttemptCount)
+
+	_, err = f.scheduler.RetryJob(ctx, 1)
+	assert.ErrorIs(t, err, ErrReportJobNotFailed)
+}
+
+func TestScheduler_ConfigErrorIsNotRetried(t *testing.T) {
+	f := newSchedulerRetryFixture(t, 0)
+	f.template.TemplateConfig = json.RawMessage(`not json`)
+
+	require.NoError(t, f.scheduler.ProcessPendingJobs(context.Background()))
+
+	job := f.repo.jobs[1]
+	assert.Equal(t, types.JobStatusFailed, job.Status)
+	require.Len(t, f.repo.attempts, 1)
+	assert.False(t, f.repo.attempts[0].Retryable)
+	assert.Len(t, f.notifier.sent, 1)
+	assert.Equal(t, 0, f.generator.calls)
+}
+
+func TestReportJobRetryPolicy_Backoff(t *testing.T) {
+	policy := (&ReportJobRetryPolicy{BaseBackoff: 5 * time.Minute, MaxBackoff: 30 * time.Minute}).withDefaults()
+
+	assert.Equal(t, 3, policy.MaxAttempts)
+	assert.Equal(t, 5*time.Minute, policy.Backoff(1))
+	assert.Equal(t, 10*time.Minute, policy.Backoff(2))
+	assert.Equal(t, 20*time.Minute, policy.Backoff(3))
+	assert.Equal(t, 30*time.Minute, policy.Backoff(4))
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// SettlementService 商户结算服务：按周期计算各商户的订单收入、退款、平台手续费和应付金额，
+// 生成待付款结算单，付款后登记付款凭证号
+type SettlementService struct {
+	repo         repository.IMerchantSettlementRepository
+	policy       repository.SettlementPolicyProvider
+	pdfGenerator IPDFGenerator
+	now          func() time.Time
+}
+
+// NewSettlementService 创建商户结算服务实例
+func NewSettlementService() *SettlementService {
+	return &SettlementService{
+		repo:         repository.NewMerchantSettlementRepository(),
+		policy:       repository.NewTenantSettlementPolicyProvider(repository.NewTenantRepository()),
+		pdfGenerator: NewPDFGenerator(),
+		now:          time.Now,
+	}
+}
+
+// NewSettlementServiceForTest 创建测试用商户结算服务，可指定当前时间
+func NewSettlementServiceForTest(repo repository.IMerchantSettlementRepository, policy repository.SettlementPolicyProvider, pdfGenerator IPDFGenerator, now func() time.Time) *SettlementService {
+	return &SettlementService{
+		repo:         repo,
+		policy:       policy,
+		pdfGenerator: pdfGenerator,
+		now:          now,
+	}
+}
+
+// GenerateSettlements 生成周期内的商户结算单，结束日期包含当天。
+// 周期内没有收入和退款的商户不生成结算单；任一商户在周期内已有结算单时全部不生成
+func (s *SettlementService) GenerateSettlements(ctx context.Context, req *types.SettlementGenerateRequest) (*types.SettlementStatement, error) {
+	tenantID := gconv.Uint64(ctx.Value("tenant_id"))
+	if tenantID == 0 {
+		return nil, fmt.Errorf("无效的租户上下文")
+	}
+	periodStart, periodEnd := settlementPeriod(req.StartDate, req.EndDate)
+	if periodEnd.Before(periodStart) {
+		return nil, fmt.Errorf("结束日期不能早于开始日期")
+	}
+
+	policy, err := s.policy(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取结算规则失败: %v", err)
+	}
+	figures, err := s.repo.GetSettlementFigures(ctx, tenantID, periodStart, periodEnd, req.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	operatorID := gconv.Uint64(ctx.Value("user_id"))
+	settlements := make([]*types.MerchantSettlement, 0, len(figures))
+	for _, f := range figures {
+		if f.GrossRevenue == 0 && f.RefundAmount == 0 {
+			continue
+		}
+		settlement := types.NewMerchantSettlement(f, policy, periodStart, periodEnd)
+		settlement.GeneratedBy = operatorID
+		settlements = append(settlements, settlement)
+	}
+	if err := s.repo.CreateSettlements(ctx, tenantID, settlements); err != nil {
+		return nil, err
+	}
+
+	generated := make([]types.MerchantSettlement, 0, len(settlements))
+	for _, settlement := range settlements {
+		generated = append(generated, *settlement)
+	}
+	statement := types.NewSettlementStatement(periodStart, periodEnd, generated)
+	if statement.MerchantCount > 0 {
+		audit.LogSettlementGenerated(ctx, tenantID, operatorID, statement)
+	}
+
+	g.Log().Info(ctx, "生成商户结算单完成",
+		"tenant_id", tenantID,
+		"period_start", periodStart,
+		"period_end", periodEnd,
+		"merchant_count", statement.MerchantCount,
+		"total_net_payable", statement.TotalNetPayable)
+	return statement, nil
+}
+
+// GetSettlement 获取结算单
+func (s *SettlementService) GetSettlement(ctx context.Context, id uint64) (*types.MerchantSettlement, error) {
+	settlement, err := s.repo.GetSettlement(ctx, gconv.Uint64(ctx.Value("tenant_id")), id)
+	if err != nil {
+		return nil, err
+	}
+	if settlement == nil {
+		return nil, types.ErrSettlementNotFound
+	}
+	return settlement, nil
+}
+
+// ListSettlements 分页获取结算单
+func (s *SettlementService) ListSettlements(ctx context.Context, query *types.SettlementQuery) ([]*types.MerchantSettlement, int64, error) {
+	return s.repo.ListSettlements(ctx, gconv.Uint64(ctx.Value("tenant_id")), query)
+}
+
+// MarkPaid 将待付款结算单标记为已付款并登记付款凭证号，已付款的结算单不能重复标记
+func (s *SettlementService) MarkPaid(ctx context.Context, id uint64, reference string) (*types.MerchantSettlement, error) {
+	settlement, err := s.GetSettlement(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if settlement.Status == types.SettlementStatusPaid {
+		return nil, types.ErrSettlementAlreadyPaid
+	}
+
+	paidBy := gconv.Uint64(ctx.Value("user_id"))
+	paidAt := s.now()
+	updated, err := s.repo.MarkPaid(ctx, settlement.TenantID, settlement.ID, reference, paidBy, paidAt)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, types.ErrSettlementAlreadyPaid
+	}
+
+	settlement.Status = types.SettlementStatusPaid
+	settlement.PaymentReference = reference
+	settlement.PaidAt = &paidAt
+	settlement.PaidBy = &paidBy
+	settlement.UpdatedAt = paidAt
+	audit.LogSettlementPaid(ctx, settlement)
+	return settlement, nil
+}
+
+// GenerateStatementPDF 生成单个商户的结算单PDF，金额格式和页眉页脚使用租户的报表设置
+func (s *SettlementService) GenerateStatementPDF(ctx context.Context, id uint64) (*PDFResult, error) {
+	settlement, err := s.GetSettlement(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &types.Report{
+		UUID:       fmt.Sprintf("%d_%d", settlement.MerchantID, settlement.ID),
+		TenantID:   settlement.TenantID,
+		ReportType: types.ReportTypeSettlement,
+		StartDate:  settlement.PeriodStart,
+		EndDate:    settlement.PeriodEnd,
+		FileFormat: types.FileFormatPDF,
+	}
+	statement := types.NewSettlementStatement(settlement.PeriodStart, settlement.PeriodEnd, []types.MerchantSettlement{*settlement})
+	return s.pdfGenerator.GeneratePDFReport(ctx, report, statement)
+}
+
+// settlementPeriod 结算周期从开始日期零点到结束日期当天结束
+func settlementPeriod(startDate, endDate time.Time) (time.Time, time.Time) {
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, startDate.Location())
+	end := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 23, 59, 59, 0, endDate.Location())
+	return start, end
+}
+
+// createSettlementHTMLTemplate 创建商户结算单HTML模板
+func (p *PDFGenerator) createSettlementHTMLTemplate(data *types.SettlementStatement, moneyFormat *types.MoneyFormatPolicy, branding *ReportBrandingAssets) (string, error) {
+	tmplStr := `
+<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+    <meta charset="UTF-8">
+    <title>商户结算单</title>
+    <style>
+        body { font-family: 'SimHei', sans-serif; margin: 20px; line-height: 1.6; }
+        .header { text-align: center; margin-bottom: 30px; border-bottom: 2px solid #333; padding-bottom: 10px; }
+        .brand .logo { max-height: 60px; }
+        .brand .company { font-size: 16px; font-weight: bold; }
+        .header .header-text { color: #666; font-size: 14px; }
+        .section-title { background-color: #2c3e50; color: white; padding: 10px; margin: 20px 0 10px 0; }
+        .settlement-table { width: 100%; border-collapse: collapse; margin-bottom: 20px; }
+        .settlement-table th, .settlement-table td { border: 1px solid #ddd; padding: 8px; text-align: right; }
+        .settlement-table th { background-color: #f8f9fa; text-align: left; }
+        .net-payable { font-weight: bold; color: #c0392b; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <div class="brand">
+            {{with .Branding.LogoDataURI}}<img class="logo" src="{{.}}" alt="logo">{{end}}
+            <div class="company">{{.Branding.CompanyName}}</div>
+        </div>
+        <h1>商户结算单</h1>
+        {{with .Branding.HeaderText}}<div class="header-text">{{.}}</div>{{end}}
+        <div class="date">结算周期: {{.PeriodStart}} 至 {{.PeriodEnd}}</div>
+        <div class="date">生成时间: {{.GeneratedAt}}</div>
+    </div>
+
+    {{range .Settlements}}
+    <div class="section-title">{{.MerchantName}}（商户ID: {{.MerchantID}}）</div>
+    <table class="settlement-table">
+        <tr><th>订单收入（{{.OrderCount}}笔）</th><td>{{formatMoney .GrossRevenue}}</td></tr>
+        <tr><th>退款（{{.RefundCount}}笔）</th><td>-{{formatMoney .RefundAmount}}</td></tr>
+        <tr><th>平台手续费（{{printf "%.2f" .FeeRatePercent}}%，每笔{{formatMoney .FixedFeePerOrder}}）</th><td>-{{formatMoney .PlatformFee}}</td></tr>
+        <tr><th>应付金额</th><td class="net-payable">{{formatMoney .NetPayable}}</td></tr>
+        <tr><th>结算状态</th><td>{{if eq .Status "paid"}}已付款（凭证号: {{.PaymentReference}}）{{else}}待付款{{end}}</td></tr>
+    </table>
+    {{end}}
+
+    <div class="footer">
+        <p>{{.Branding.FooterText}}</p>
+    </div>
+</body>
+</html>
+`
+
+	tmpl, err := template.New("settlement").Funcs(types.MoneyTemplateFuncs(moneyFormat)).Parse(tmplStr)
+	if err != nil {
+		return "", fmt.Errorf("解析商户结算单HTML模板失败: %v", err)
+	}
+
+	templateData := map[string]interface{}{
+		"PeriodStart": data.PeriodStart.Format("2006-01-02"),
+		"PeriodEnd":   data.PeriodEnd.Format("2006-01-02"),
+		"GeneratedAt": time.Now().Format("2006-01-02 15:04:05"),
+		"Settlements": data.Settlements,
+		"Branding":    branding,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData); err != nil {
+		return "", fmt.Errorf("渲染商户结算单HTML模板失败: %v", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySettlementRepository 内存结算单仓储，按状态条件标记付款
+type memorySettlementRepository struct {
+	figures     []types.MerchantSettlementFigures
+	settlements map[uint64]*types.MerchantSettlement
+	nextID      uint64
+}
+
+func newMemorySettlementRepository(figures []types.MerchantSettlementFigures) *memorySettlementRepository {
+	return &memorySettlementRepository{figures: figures, settlements: map[uint64]*types.MerchantSettlement{}}
+}
+
+func (m *memorySettlementRepository) GetSettlementFigures(ctx context.Context, tenantID uint64, startDate, endDate time.Time, merchantID *uint64) ([]types.MerchantSettlementFigures, error) {
+	return m.figures, nil
+}
+
+func (m *memorySettlementRepository) CreateSettlements(ctx context.Context, tenantID uint64, settlements []*types.MerchantSettlement) error {
+	for _, settlement := range settlements {
+		for _, existing := range m.settlements {
+			if existing.MerchantID == settlement.MerchantID &&
+				!existing.PeriodStart.After(settlement.PeriodEnd) && !existing.PeriodEnd.Before(settlement.PeriodStart) {
+				return fmt.Errorf("%w: 商户 %d", types.ErrSettlementPeriodOverlap, settlement.MerchantID)
+			}
+		}
+	}
+	for _, settlement := range settlements {
+		m.nextID++
+		settlement.ID = m.nextID
+		settlement.TenantID = tenantID
+		stored := *settlement
+		m.settlements[settlement.ID] = &stored
+	}
+	return nil
+}
+
+func (m *memorySettlementRepository) GetSettlement(ctx context.Context, tenantID, id uint64) (*types.MerchantSettlement, error) {
+	settlement, exists := m.settlements[id]
+	if !exists || settlement.TenantID != tenantID {
+		return nil, nil
+	}
+	copied := *settlement
+	return &copied, nil
+}
+
+func (m *memorySettlementRepository) ListSettlements(ctx context.Context, tenantID uint64, query *types.SettlementQuery) ([]*types.MerchantSettlement, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *memorySettlementRepository) MarkPaid(ctx context.Context, tenantID, id uint64, reference string, paidBy uint64, paidAt time.Time) (bool, error) {
+	settlement, exists := m.settlements[id]
+	if !exists || settlement.Status != types.SettlementStatusPending {
+		return false, nil
+	}
+	settlement.Status = types.SettlementStatusPaid
+	settlement.PaymentReference = reference
+	settlement.PaidAt = &paidAt
+	settlement.PaidBy = &paidBy
+	return true, nil
+}
+
+func newTestSettlementService(repo *memorySettlementRepository, now time.Time) *SettlementService {
+	policy := func(ctx context.Context, tenantID uint64) (*types.SettlementPolicy, error) {
+		return &types.SettlementPolicy{FeeRatePercent: 5, FixedFeePerOrder: 0.5}, nil
+	}
+	return NewSettlementServiceForTest(repo, policy, nil, func() time.Time { return now })
+}
+
+func TestGenerateSettlements(t *testing.T) {
+	repo := newMemorySettlementRepository([]types.MerchantSettlementFigures{
+		{MerchantID: 1, MerchantName: "有退款商户", GrossRevenue: 1000, OrderCount: 10, RefundAmount: 200, RefundCount: 2},
+		{MerchantID: 2, MerchantName: "只有退款商户", RefundAmount: 50, RefundCount: 1},
+		{MerchantID: 3, MerchantName: "无交易商户"},
+	})
+	settlementService := newTestSettlementService(repo, time.Now())
+	ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+	ctx = context.WithValue(ctx, "user_id", uint64(9))
+	req := &types.SettlementGenerateRequest{
+		StartDate: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC),
+	}
+
+	statement, err := settlementService.GenerateSettlements(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 2, statement.MerchantCount)
+	assert.Equal(t, time.Date(2026, 9, 30, 23, 59, 59, 0, time.UTC), statement.PeriodEnd)
+
+	first := statement.Settlements[0]
+	assert.Equal(t, 45.0, first.PlatformFee)
+	assert.Equal(t, 755.0, first.NetPayable)
+	assert.Equal(t, uint64(9), first.GeneratedBy)
+	assert.Equal(t, types.SettlementStatusPending, first.Status)
+
+	second := statement.Settlements[1]
+	assert.Equal(t, 0.0, second.PlatformFee)
+	assert.Equal(t, -50.0, second.NetPayable)
+	assert.Equal(t, 705.0, statement.TotalNetPayable)
+
+	_, err = settlementService.GenerateSettlements(ctx, &types.SettlementGenerateRequest{
+		StartDate: time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+	})
+	assert.ErrorIs(t, err, types.ErrSettlementPeriodOverlap)
+}
+
+func TestMarkSettlementPaid(t *testing.T) {
+	repo := newMemorySettlementRepository([]types.MerchantSettlementFigures{
+		{MerchantID: 1, MerchantName: "有退款商户", GrossRevenue: 1000, OrderCount: 10, RefundAmount: 200, RefundCount: 2},
+	})
+	paidAt := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	settlementService := newTestSettlementService(repo, paidAt)
+	ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+	ctx = context.WithValue(ctx, "user_id", uint64(9))
+
+	statement, err := settlementService.GenerateSettlements(ctx, &types.SettlementGenerateRequest{
+		StartDate: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	id := statement.Settlements[0].ID
+
+	settlement, err := settlementService.MarkPaid(ctx, id, "BANK-20261005-001")
+	require.NoError(t, err)
+	assert.Equal(t, types.SettlementStatusPaid, settlement.Status)
+	assert.Equal(t, "BANK-20261005-001", settlement.PaymentReference)
+	require.NotNil(t, settlement.PaidAt)
+	assert.Equal(t, paidAt, *settlement.PaidAt)
+	assert.Equal(t, types.SettlementStatusPaid, repo.settlements[id].Status)
+
+	_, err = settlementService.MarkPaid(ctx, id, "BANK-20261005-002")
+	assert.ErrorIs(t, err, types.ErrSettlementAlreadyPaid)
+	assert.Equal(t, "BANK-20261005-001", repo.settlements[id].PaymentReference)
+
+	_, err = settlementService.MarkPaid(ctx, id+100, "BANK-20261005-003")
+	assert.ErrorIs(t, err, types.ErrSettlementNotFound)
+
+	otherTenant := context.WithValue(context.Background(), "tenant_id", uint64(2))
+	_, err = settlementService.MarkPaid(otherTenant, id, "BANK-20261005-004")
+	assert.ErrorIs(t, err, types.ErrSettlementNotFound)
+}
@@ -90,6 +90,7 @@ func (e *TemplateEngine) GetSupportedVariables() map[string]string {
 		"{{.ActiveMerchantCount}}":  "活跃商户数",
 		"{{.ActiveCustomerCount}}":  "活跃客户数",
 		"{{.RightsConsumed}}":       "权益消耗",
+		"{{.TaxCollected}}":         "代收税额",
 		"{{.NetProfit}}":            "净利润",
 		"{{.ReportDate}}":           "报表日期",
 		"{{.ReportPeriod}}":         "报表周期",
@@ -105,6 +106,7 @@ func (e *TemplateEngine) renderFinancialTemplate(ctx context.Context, config map
 		"summary": map[string]interface{}{
 			"total_revenue":         e.formatMoney(data.TotalRevenue.Amount),
 			"total_expenditure":     e.formatMoney(data.TotalExpenditure.Amount),
+			"tax_collected":        e.formatMoney(data.TaxCollected.Amount),
 			"net_profit":           e.formatMoney(data.NetProfit.Amount),
 			"order_count":          data.OrderCount,
 			"merchant_count":       data.MerchantCount,
@@ -162,6 +164,11 @@ func (e *TemplateEngine) renderMerchantOperationTemplate(ctx context.Context, co
 		reportData["category_analysis"] = data.CategoryAnalysis
 	}
 	
+	// 商品评价满意度
+	if len(data.Satisfaction) > 0 {
+		reportData["satisfaction"] = data.Satisfaction
+	}
+	
 	// 增长指标
 	if data.GrowthMetrics != nil {
 		reportData["growth_metrics"] = data.GrowthMetrics
@@ -220,9 +227,9 @@ func (e *TemplateEngine) renderCustomerAnalysisTemplate(ctx context.Context, con
 func (e *TemplateEngine) registerDefaultFormatters() {
 	e.formatters["money"] = func(value interface{}) string {
 		if amount, ok := value.(float64); ok {
-			return fmt.Sprintf("¥%.2f", amount)
+			return types.DefaultMoneyFormatPolicy().Format(amount)
 		}
-		return "¥0.00"
+		return types.DefaultMoneyFormatPolicy().Format(0)
 	}
 	
 	e.formatters["number"] = func(value interface{}) string {
@@ -336,7 +343,7 @@ func (e *TemplateEngine) formatMoney(amount float64) string {
 	if formatter, exists := e.formatters["money"]; exists {
 		return formatter(amount)
 	}
-	return fmt.Sprintf("¥%.2f", amount)
+	return types.DefaultMoneyFormatPolicy().Format(amount)
 }
 
 // formatNumber 格式化数字
@@ -4,7 +4,12 @@ import (
 	"github.com/gofromzero/mer-sys/backend/services/report-service/internal/controller"
 	"github.com/gofromzero/mer-sys/backend/services/report-service/internal/service"
 	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/export"
+	"github.com/gofromzero/mer-sys/backend/shared/handlers"
+	"github.com/gofromzero/mer-sys/backend/shared/maintenance"
 	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/os/gctx"
@@ -17,15 +22,29 @@ func main() {
 	auth.NewJWTManager()
 	ctx := gctx.GetInitCtx()
 
+	// 数据驻留配置映射了未定义的数据库分组时拒绝启动，避免租户数据落入共享数据库
+	if err := repository.InitTenantDatasourceConfig(ctx); err != nil {
+		g.Log().Fatalf(ctx, "数据驻留配置校验失败: %v", err)
+	}
+
 	s := g.Server()
 
+	// 应用连接池配置并暴露连接池指标
+	handlers.RegisterDBPoolMetrics(s, "report-service")
+
 	// 创建中间件实例
 	authMiddleware := middleware.NewAuthMiddleware()
 
+	// 维护模式下拒绝写请求，状态在各服务间共享
+	maintenanceManager := maintenance.NewManager()
+	maintenanceGuard := middleware.MaintenanceMode(maintenanceManager)
+
 	// 创建控制器
 	reportController := controller.NewReportController()
 	templateController := controller.NewTemplateController()
 	scheduledTaskController := controller.NewScheduledTaskController()
+	settlementController := controller.NewSettlementController()
+	reportDownloadController := controller.NewReportDownloadController(service.NewReportDownloadService())
 
 	g.Log().Info(ctx, "报表服务控制器初始化完成")
 
@@ -35,11 +54,29 @@ func main() {
 		g.Log().Error(ctx, "启动调度服务失败", "error", err)
 	}
 
+	reportJobController := controller.NewReportJobController(schedulerService)
+
+	// 导出队列：按需导出的分析事实数据在后台生成
+	exportQueue := export.NewQueue()
+
+	// 启动分析数据定时导出
+	analyticsExportService := service.NewAnalyticsExportService(exportQueue)
+	if err := analyticsExportService.Start(ctx); err != nil {
+		g.Log().Error(ctx, "启动分析数据定时导出失败", "error", err)
+	}
+	analyticsExportController := controller.NewAnalyticsExportController(analyticsExportService)
+	exportQueue.Start(ctx)
+
 	// 注册路由
 	s.Group("/api/v1", func(group *ghttp.RouterGroup) {
+		// 记录访问日志，配置的路由按采样记录脱敏后的请求/响应体
+		group.Middleware(middleware.AccessLog(middleware.LoadAccessLogConfig(ctx)))
+		// 支持 fields 参数只返回指定的响应字段
+		group.Middleware(middleware.ResponseFields(middleware.LoadResponseFieldsConfig(ctx)))
+
 		// 报表管理路由（需要认证）
 		group.Group("/reports", func(reportGroup *ghttp.RouterGroup) {
-			reportGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation)
+			reportGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard)
 
 			// 报表生成和管理
 			reportGroup.POST("/generate", reportController.GenerateReport)
@@ -47,11 +84,20 @@ func main() {
 			reportGroup.GET("/:id", reportController.GetReport)
 			reportGroup.DELETE("/:id", reportController.DeleteReport)
 			reportGroup.GET("/:uuid/download", reportController.DownloadReport)
+
+			// 签名下载链接和下载记录
+			reportGroup.GET("/:uuid/download-url", reportDownloadController.IssueDownloadURL)
+			reportGroup.GET("/:uuid/download-logs", authMiddleware.RequirePermissions(types.PermissionReportView), reportDownloadController.ListDownloadLogs)
+		})
+
+		// 签名链接下载报表（不需要认证，由签名、有效期和绑定的IP控制访问）
+		group.Group("/report-downloads", func(downloadGroup *ghttp.RouterGroup) {
+			downloadGroup.GET("/:uuid", reportDownloadController.SignedDownload)
 		})
 
 		// 报表模板路由（需要认证）
 		group.Group("/report-templates", func(templateGroup *ghttp.RouterGroup) {
-			templateGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation)
+			templateGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard)
 			
 			// 模板管理
 			templateGroup.POST("/", templateController.CreateTemplate)
@@ -64,21 +110,52 @@ func main() {
 			templateGroup.POST("/schedule", templateController.ScheduleReport)
 		})
 
+		// 定时报表执行记录路由（需要认证）
+		group.Group("/report-jobs", func(jobGroup *ghttp.RouterGroup) {
+			jobGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard)
+
+			jobGroup.GET("/", reportJobController.ListJobs)
+			jobGroup.GET("/:id", reportJobController.GetJob)
+			jobGroup.POST("/:id/retry", reportJobController.RetryJob)
+		})
+
 		// 数据分析路由（需要认证）
 		group.Group("/analytics", func(analyticsGroup *ghttp.RouterGroup) {
-			analyticsGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation)
+			analyticsGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard)
 
 			// 基础分析数据
 			analyticsGroup.GET("/financial", reportController.GetFinancialAnalytics)
 			analyticsGroup.GET("/merchants", reportController.GetMerchantAnalytics)
 			analyticsGroup.GET("/customers", reportController.GetCustomerAnalytics)
+			analyticsGroup.GET("/merchant-summary", reportController.GetMerchantSummary)
 			
 			// 自定义查询和趋势数据
 			analyticsGroup.POST("/custom", reportController.CustomQuery)
 			analyticsGroup.GET("/trends/:metric", reportController.GetTrendData)
+
+			// 规范化事实数据导出，供BI工具加载（进入导出队列后台生成）
+			analyticsGroup.GET("/facts", analyticsExportController.ExportFacts)
+			analyticsGroup.GET("/facts/exports/:id", analyticsExportController.GetExport)
+			analyticsGroup.GET("/facts/exports/:id/download", analyticsExportController.DownloadExport)
 			
 			// 缓存管理
 			analyticsGroup.POST("/cache/clear", reportController.ClearCache)
+
+			// 分析查询校验（仅限运维）
+			analyticsGroup.GET("/diagnostics/query-checks",
+				authMiddleware.RequireAnyPermission(types.PermissionSystemConfig),
+				reportController.RunQueryChecks)
+		})
+
+		// 商户结算路由（需要认证，生成和付款需要资金管理权限）
+		group.Group("/settlements", func(settlementGroup *ghttp.RouterGroup) {
+			settlementGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation, maintenanceGuard)
+
+			settlementGroup.POST("/", authMiddleware.RequirePermissions(types.PermissionFundManage), settlementController.GenerateSettlements)
+			settlementGroup.GET("/", authMiddleware.RequirePermissions(types.PermissionReportView), settlementController.ListSettlements)
+			settlementGroup.GET("/:id", authMiddleware.RequirePermissions(types.PermissionReportView), settlementController.GetSettlement)
+			settlementGroup.POST("/:id/pay", authMiddleware.RequirePermissions(types.PermissionFundManage), settlementController.MarkPaid)
+			settlementGroup.GET("/:id/statement", authMiddleware.RequirePermissions(types.PermissionReportExport), settlementController.DownloadStatement)
 		})
 
 		// 定时任务路由
@@ -87,9 +164,11 @@ func main() {
 
 	// 健康检查端点
 	s.BindHandler("/health", func(r *ghttp.Request) {
+		maintenanceStatus, _ := maintenanceManager.Status(r.Context(), 0)
 		r.Response.WriteJsonExit(g.Map{
-			"status":  "healthy",
-			"service": "report-service",
+			"status":      "healthy",
+			"service":     "report-service",
+			"maintenance": maintenanceStatus,
 		})
 	})
 
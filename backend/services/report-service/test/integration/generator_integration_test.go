@@ -136,12 +136,13 @@ func TestPDFReportGeneration(t *testing.T) {
 	}
 	
 	ctx := context.Background()
-	filePath, err := pdfGenerator.GeneratePDFReport(ctx, report, financialData)
+	result, err := pdfGenerator.GeneratePDFReport(ctx, report, financialData)
 	
 	// PDF生成可能失败（如果系统没有安装转换器），但不应该崩溃
 	if err != nil {
 		t.Logf("PDF生成失败（可能是系统未安装转换器）: %v", err)
 	} else {
+		filePath := result.FilePath
 		assert.NotEmpty(t, filePath)
 		
 		// 检查文件是否存在
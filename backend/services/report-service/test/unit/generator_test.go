@@ -179,8 +179,8 @@ func TestTemplateEngine_RenderTemplate(t *testing.T) {
 	// 验证汇总数据
 	summary, ok := renderedData["summary"].(map[string]interface{})
 	require.True(t, ok)
-	assert.Equal(t, "¥100000.00", summary["total_revenue"])
-	assert.Equal(t, "¥40000.00", summary["net_profit"])
+	assert.Equal(t, "¥100,000.00", summary["total_revenue"])
+	assert.Equal(t, "¥40,000.00", summary["net_profit"])
 	assert.Equal(t, float64(500), summary["order_count"])
 }
 
@@ -275,8 +275,8 @@ func TestPDFGenerator_CreateHTMLTemplate(t *testing.T) {
 	assert.NotEmpty(t, htmlContent)
 	assert.Contains(t, htmlContent, "<!DOCTYPE html>")
 	assert.Contains(t, htmlContent, "财务报表")
-	assert.Contains(t, htmlContent, "50000.00")
-	assert.Contains(t, htmlContent, "20000.00")
+	assert.Contains(t, htmlContent, "¥50,000.00")
+	assert.Contains(t, htmlContent, "¥20,000.00")
 	
 	// 测试不支持的报表类型
 	_, err = generator.CreateHTMLTemplate("unsupported_type", nil)
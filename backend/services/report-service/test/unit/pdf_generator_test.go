@@ -34,8 +34,8 @@ func TestPDFGenerator_CreateHTMLTemplate(t *testing.T) {
 	assert.Contains(t, htmlContent, "财务报表")
 	assert.Contains(t, htmlContent, "总收入")
 	assert.Contains(t, htmlContent, "净利润")
-	assert.Contains(t, htmlContent, "120000.00") // 总收入金额
-	assert.Contains(t, htmlContent, "50000.00")  // 净利润金额
+	assert.Contains(t, htmlContent, "¥120,000.00") // 总收入金额
+	assert.Contains(t, htmlContent, "¥50,000.00")  // 净利润金额
 	
 	// 验证CSS样式存在
 	assert.Contains(t, htmlContent, "<style>")
@@ -96,8 +96,8 @@ func TestPDFGenerator_MerchantOperationHTMLTemplate(t *testing.T) {
 	assert.Contains(t, htmlContent, "商户业绩排行榜")
 	assert.Contains(t, htmlContent, "顶级商户")
 	assert.Contains(t, htmlContent, "优秀商户")
-	assert.Contains(t, htmlContent, "80000.00")
-	assert.Contains(t, htmlContent, "60000.00")
+	assert.Contains(t, htmlContent, "¥80,000.00")
+	assert.Contains(t, htmlContent, "¥60,000.00")
 	
 	// 验证增长率的颜色样式
 	assert.Contains(t, htmlContent, "growth-positive")
@@ -205,10 +205,11 @@ func TestPDFGenerator_GeneratePDFReport(t *testing.T) {
 	financialData := createTestFinancialData()
 	
 	// 生成PDF报表
-	filePath, err := generator.GeneratePDFReport(ctx, report, financialData)
+	result, err := generator.GeneratePDFReport(ctx, report, financialData)
 	
 	// 验证结果
 	require.NoError(t, err)
+	filePath := result.FilePath
 	assert.NotEmpty(t, filePath)
 	
 	// 检查文件是否存在
@@ -223,6 +224,7 @@ func TestPDFGenerator_GeneratePDFReport(t *testing.T) {
 	// 检查文件扩展名
 	ext := filepath.Ext(filePath)
 	assert.True(t, ext == ".pdf" || ext == ".html", "文件应该是PDF或HTML格式")
+	assert.Equal(t, "."+string(result.FileFormat), ext, "文件格式应与实际文件一致")
 	
 	if ext == ".html" {
 		t.Logf("PDF转换失败，生成了HTML文件: %s (大小: %d 字节)", filePath, fileInfo.Size())
@@ -316,9 +318,9 @@ func TestPDFGenerator_HTMLTemplateWithComplexData(t *testing.T) {
 	assert.Contains(t, htmlContent, "超级商户A")
 	assert.Contains(t, htmlContent, "优质商户B")
 	assert.Contains(t, htmlContent, "成长商户C")
-	assert.Contains(t, htmlContent, "150000.00")
-	assert.Contains(t, htmlContent, "100000.00")
-	assert.Contains(t, htmlContent, "75000.00")
+	assert.Contains(t, htmlContent, "¥150,000.00")
+	assert.Contains(t, htmlContent, "¥100,000.00")
+	assert.Contains(t, htmlContent, "¥75,000.00")
 	
 	// 验证月度趋势数据
 	assert.Contains(t, htmlContent, "月度趋势")
@@ -380,9 +382,9 @@ func TestPDFGenerator_HTMLTemplateEncoding(t *testing.T) {
 	assert.Contains(t, htmlContent, "测试商户")
 	
 	// 验证数字格式
-	assert.Contains(t, htmlContent, "88888.88")
-	assert.Contains(t, htmlContent, "66666.66")
-	assert.Contains(t, htmlContent, "12345.67")
+	assert.Contains(t, htmlContent, "¥88,888.88")
+	assert.Contains(t, htmlContent, "¥66,666.66")
+	assert.Contains(t, htmlContent, "¥12,345.67")
 	
 	t.Logf("编码测试通过，HTML长度: %d", len(htmlContent))
 }
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/services/tenant-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/maintenance"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// MaintenanceController 维护模式控制器，开启和关闭维护模式会记录审计日志
+type MaintenanceController struct {
+	tenantService service.ITenantService
+	manager       *maintenance.Manager
+}
+
+// NewMaintenanceController 创建维护模式控制器
+func NewMaintenanceController(manager *maintenance.Manager) *MaintenanceController {
+	return &MaintenanceController{
+		tenantService: service.NewTenantService(),
+		manager:       manager,
+	}
+}
+
+// GetGlobal handles GET /api/v1/maintenance - 查看平台维护模式
+func (c *MaintenanceController) GetGlobal(r *ghttp.Request) {
+	status, err := c.manager.Status(r.Context(), 0)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "获取维护模式状态失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "获取维护模式状态成功",
+		"data":    status,
+	})
+}
+
+// SetGlobal handles PUT /api/v1/maintenance - 开启或关闭平台维护模式
+func (c *MaintenanceController) SetGlobal(r *ghttp.Request) {
+	var req *types.SetMaintenanceModeRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数格式错误",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	state, err := c.manager.SetGlobal(r.Context(), req)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "设置维护模式失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "维护模式设置成功",
+		"data":    state,
+	})
+}
+
+// GetTenant handles GET /api/v1/tenants/:id/maintenance - 查看租户维护模式
+func (c *MaintenanceController) GetTenant(r *ghttp.Request) {
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "租户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	status, err := c.manager.Status(r.Context(), id)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "获取维护模式状态失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "获取维护模式状态成功",
+		"data":    status,
+	})
+}
+
+// SetTenant handles PUT /api/v1/tenants/:id/maintenance - 开启或关闭租户维护模式
+func (c *MaintenanceController) SetTenant(r *ghttp.Request) {
+	id, err := strconv.ParseUint(r.Get("id").String(), 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "租户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req *types.SetMaintenanceModeRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数格式错误",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	tenant, err := c.tenantService.GetTenantByID(r.Context(), id)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "获取租户信息失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if tenant == nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    404,
+			"message": "租户不存在",
+			"data":    nil,
+		})
+		return
+	}
+
+	state, err := c.manager.SetTenant(r.Context(), id, req)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "设置维护模式失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "维护模式设置成功",
+		"data":    state,
+	})
+}
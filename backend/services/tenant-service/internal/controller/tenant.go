@@ -1,10 +1,13 @@
 package controller
 
 import (
+	"encoding/json"
+	"errors"
 	"strconv"
 
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gofromzero/mer-sys/backend/shared/quota"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gofromzero/mer-sys/backend/services/tenant-service/internal/service"
 )
@@ -12,6 +15,7 @@ import (
 type TenantController struct {
 	tenantService service.ITenantService
 	securityService *service.TenantSecurityService
+	quotaGuard      *quota.Guard
 }
 
 func NewTenantController() *TenantController {
@@ -19,6 +23,7 @@ func NewTenantController() *TenantController {
 	return &TenantController{
 		tenantService:   tenantService,
 		securityService: service.NewTenantSecurityService(tenantService),
+		quotaGuard:      quota.NewGuard(),
 	}
 }
 
@@ -270,6 +275,37 @@ func (c *TenantController) GetConfig(r *ghttp.Request) {
 	})
 }
 
+// GetQuotaUsage handles GET /api/v1/tenants/{id}/quota-usage - 获取租户配额用量
+func (c *TenantController) GetQuotaUsage(r *ghttp.Request) {
+	idStr := r.Get("id").String()
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "租户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	report, err := c.quotaGuard.Report(r.Context(), id)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "获取租户配额用量失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "获取租户配额用量成功",
+		"data":    report,
+	})
+}
+
 // UpdateConfig handles PUT /api/v1/tenants/{id}/config - 更新租户配置
 func (c *TenantController) UpdateConfig(r *ghttp.Request) {
 	idStr := r.Get("id").String()
@@ -335,6 +371,149 @@ func (c *TenantController) UpdateConfig(r *ghttp.Request) {
 	})
 }
 
+// ExportConfig handles GET /api/v1/tenants/{id}/config/export - 导出租户配置包（密钥已脱敏）
+func (c *TenantController) ExportConfig(r *ghttp.Request) {
+	idStr := r.Get("id").String()
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "租户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	bundle, err := c.tenantService.ExportTenantConfig(r.Context(), id)
+	if errors.Is(err, service.ErrTenantNotFound) {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    404,
+			"message": "租户不存在",
+			"data":    nil,
+		})
+		return
+	}
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "导出租户配置失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "导出租户配置成功",
+		"data":    bundle,
+	})
+}
+
+// ImportConfig handles POST /api/v1/tenants/{id}/config/import - 校验并导入租户配置包，dry_run=true 时只返回校验结果和变更
+func (c *TenantController) ImportConfig(r *ghttp.Request) {
+	idStr := r.Get("id").String()
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "租户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	// 配置包按原始 JSON 解析，保留配置内容用于结构校验
+	var bundle *types.TenantConfigBundle
+	if err := json.Unmarshal(r.GetBody(), &bundle); err != nil || bundle == nil {
+		if err == nil {
+			err = errors.New("配置包不能为空")
+		}
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数格式错误",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	config, report, err := c.tenantService.PreviewTenantConfigImport(r.Context(), id, bundle)
+	if errors.Is(err, service.ErrTenantNotFound) {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    404,
+			"message": "租户不存在",
+			"data":    nil,
+		})
+		return
+	}
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "校验租户配置包失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if r.Get("dry_run").Bool() {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    0,
+			"message": "校验租户配置包完成",
+			"data":    report,
+		})
+		return
+	}
+
+	// 与更新配置相同的安全验证，配置包不兼容时由导入记录审计日志并返回问题
+	if report.Valid {
+		currentConfig, err := c.tenantService.GetTenantConfig(r.Context(), id)
+		if err != nil {
+			r.Response.WriteJsonExit(g.Map{
+				"code":    500,
+				"message": "获取当前租户配置失败",
+				"data":    nil,
+				"error":   err.Error(),
+			})
+			return
+		}
+		if err := c.securityService.ValidateTenantConfigChange(r.Context(), id, currentConfig, config); err != nil {
+			r.Response.WriteJsonExit(g.Map{
+				"code":    403,
+				"message": "权限验证失败",
+				"data":    nil,
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	report, err = c.tenantService.ImportTenantConfig(r.Context(), id, bundle)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "导入租户配置失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if !report.Applied {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "租户配置包与目标环境不兼容",
+			"data":    report,
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "导入租户配置成功",
+		"data":    report,
+	})
+}
+
 // GetConfigNotification handles GET /api/v1/tenants/{id}/config/notifications - 获取配置变更通知
 func (c *TenantController) GetConfigNotification(r *ghttp.Request) {
 	idStr := r.Get("id").String()
@@ -363,4 +542,54 @@ func (c *TenantController) GetConfigNotification(r *ghttp.Request) {
 		"message": "获取配置变更通知成功",
 		"data":    notification,
 	})
-}
\ No newline at end of file
+}
+
+// Provision handles POST /api/v1/tenants/{id}/provision - 重新开通租户，补齐缺失的默认项
+func (c *TenantController) Provision(r *ghttp.Request) {
+	idStr := r.Get("id").String()
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "租户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req *types.ProvisionTenantRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    400,
+			"message": "请求参数格式错误",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	result, err := c.tenantService.ProvisionTenant(r.Context(), id, req)
+	if errors.Is(err, service.ErrTenantNotFound) {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    404,
+			"message": "租户不存在",
+			"data":    nil,
+		})
+		return
+	}
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    500,
+			"message": "租户开通失败",
+			"data":    nil,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "租户开通成功",
+		"data":    result,
+	})
+}
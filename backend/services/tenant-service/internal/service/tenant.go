@@ -5,11 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 
-	"github.com/gogf/gf/v2/frame/g"
-	"github.com/gogf/gf/v2/os/gtime"
 	"github.com/gofromzero/mer-sys/backend/shared/audit"
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/util/gconv"
 )
 
 type ITenantService interface {
@@ -21,17 +22,37 @@ type ITenantService interface {
 	GetTenantConfig(ctx context.Context, id uint64) (*types.TenantConfig, error)
 	UpdateTenantConfig(ctx context.Context, id uint64, config *types.TenantConfig) error
 	GetConfigChangeNotification(ctx context.Context, id uint64) (map[string]interface{}, error)
+	ProvisionTenant(ctx context.Context, id uint64, req *types.ProvisionTenantRequest) (*types.TenantProvisionResult, error)
+	ExportTenantConfig(ctx context.Context, id uint64) (*types.TenantConfigBundle, error)
+	PreviewTenantConfigImport(ctx context.Context, id uint64, bundle *types.TenantConfigBundle) (*types.TenantConfig, *types.TenantConfigImportReport, error)
+	ImportTenantConfig(ctx context.Context, id uint64, bundle *types.TenantConfigBundle) (*types.TenantConfigImportReport, error)
 }
 
 type tenantService struct {
-	tenantRepo    repository.ITenantRepository
-	configCache   *TenantConfigCache
+	tenantRepo     repository.ITenantRepository
+	configCache    *TenantConfigCache
+	provisioner    ITenantProvisioningService
+	configWebhooks *TenantConfigWebhookPublisher
+	versionRepo    repository.ITenantConfigVersionRepository
 }
 
 func NewTenantService() ITenantService {
 	return &tenantService{
-		tenantRepo:  repository.NewTenantRepository(),
-		configCache: NewTenantConfigCache(),
+		tenantRepo:     repository.NewTenantRepository(),
+		configCache:    NewTenantConfigCache(),
+		provisioner:    NewTenantProvisioningService(),
+		configWebhooks: NewTenantConfigWebhookPublisher(),
+		versionRepo:    repository.NewTenantConfigVersionRepository(),
+	}
+}
+
+// NewTenantServiceForTest 创建测试用租户服务实例（用于租户配置的更新、导出和导入）
+func NewTenantServiceForTest(tenantRepo repository.ITenantRepository, versionRepo repository.ITenantConfigVersionRepository, configWebhooks *TenantConfigWebhookPublisher) ITenantService {
+	return &tenantService{
+		tenantRepo:     tenantRepo,
+		configCache:    NewTestTenantConfigCache(),
+		configWebhooks: configWebhooks,
+		versionRepo:    versionRepo,
 	}
 }
 
@@ -67,28 +88,33 @@ func (s *tenantService) CreateTenant(ctx context.Context, req *types.CreateTenan
 		Address:          req.Address,
 		RegistrationTime: &now.Time,
 		ActivationTime:   &now.Time,
-		Config:           `{"max_users": 100, "max_merchants": 50, "features": ["basic"], "settings": {}}`,
 		CreatedAt:        now.Time,
 		UpdatedAt:        now.Time,
 	}
 
-	// 保存到数据库
-	id, err := s.tenantRepo.Create(ctx, tenant)
+	// 在同一事务中保存租户并开通默认配置、默认超时配置和初始管理员
+	provisioning, err := s.provisioner.CreateAndProvision(ctx, tenant, &types.ProvisionTenantRequest{
+		AdminUsername: req.AdminUsername,
+		AdminEmail:    req.ContactEmail,
+		AdminPassword: req.AdminPassword,
+	})
 	if err != nil {
 		return nil, err
 	}
+	id := tenant.ID
 
 	// 记录租户创建审计日志
 	audit.LogTenantAccess(ctx, id, "tenant", "create", map[string]interface{}{
-		"tenant_name": tenant.Name,
-		"tenant_code": tenant.Code,
+		"tenant_name":   tenant.Name,
+		"tenant_code":   tenant.Code,
 		"business_type": tenant.BusinessType,
 		"contact_email": tenant.ContactEmail,
 	})
 
-	// 返回创建的租户信息
-	tenant.ID = id
-	return s.convertToResponse(tenant), nil
+	// 返回创建的租户信息，包含开通结果（初始管理员密码仅返回一次）
+	response := s.convertToResponse(tenant)
+	response.Provisioning = provisioning
+	return response, nil
 }
 
 func (s *tenantService) ListTenants(ctx context.Context, req *types.ListTenantsRequest) (*types.ListTenantsResponse, error) {
@@ -215,14 +241,14 @@ func (s *tenantService) UpdateTenantStatus(ctx context.Context, id uint64, req *
 
 	// 记录状态变更审计日志
 	audit.LogTenantAccess(ctx, id, "tenant", "status_change", map[string]interface{}{
-		"old_status": string(oldStatus),
-		"new_status": string(req.Status),
-		"reason": req.Reason,
+		"old_status":  string(oldStatus),
+		"new_status":  string(req.Status),
+		"reason":      req.Reason,
 		"tenant_name": tenant.Name,
 		"tenant_code": tenant.Code,
 	})
 
-	g.Log().Infof(ctx, "Tenant status changed: tenant_id=%d, old_status=%s, new_status=%s, reason=%s", 
+	g.Log().Infof(ctx, "Tenant status changed: tenant_id=%d, old_status=%s, new_status=%s, reason=%s",
 		id, oldStatus, req.Status, req.Reason)
 
 	return nil
@@ -268,10 +294,91 @@ func (s *tenantService) UpdateTenantConfig(ctx context.Context, id uint64, confi
 		return errors.New("租户不存在")
 	}
 
+	if err := validateTenantConfig(config); err != nil {
+		return err
+	}
+
+	_, err = s.applyTenantConfig(ctx, tenant, config, types.TenantConfigVersionSourceUpdate)
+	return err
+}
+
+// validateTenantConfig 校验配置中各策略的取值
+func validateTenantConfig(config *types.TenantConfig) error {
+	if config.PasswordPolicy != nil {
+		if err := config.PasswordPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+	if config.OrderReview != nil {
+		if err := config.OrderReview.Validate(); err != nil {
+			return err
+		}
+	}
+	if config.NotificationBranding != nil {
+		if err := config.NotificationBranding.Validate(); err != nil {
+			return err
+		}
+	}
+	if config.DataRetention != nil {
+		if err := config.DataRetention.Validate(); err != nil {
+			return err
+		}
+	}
+	if config.Tax != nil {
+		if err := config.Tax.Validate(); err != nil {
+			return err
+		}
+	}
+	if config.OrderStatusLabels != nil {
+		if err := config.OrderStatusLabels.Validate(); err != nil {
+			return err
+		}
+	}
+	if config.Invoice != nil {
+		if err := config.Invoice.Validate(); err != nil {
+			return err
+		}
+	}
+	if config.ReportBranding != nil {
+		if err := config.ReportBranding.Validate(); err != nil {
+			return err
+		}
+	}
+	if config.AnalyticsExport != nil {
+		if err := config.AnalyticsExport.Validate(); err != nil {
+			return err
+		}
+	}
+	if config.Quota != nil {
+		if err := config.Quota.Validate(); err != nil {
+			return err
+		}
+	}
+	if config.Settlement != nil {
+		if err := config.Settlement.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyTenantConfig 保存租户配置并生成新的配置版本，使缓存失效、推送变更并记录审计日志，返回新的版本号。
+// 保存版本失败不影响配置更新，此时返回的版本号为 0
+func (s *tenantService) applyTenantConfig(ctx context.Context, tenant *types.Tenant, config *types.TenantConfig, source string) (int, error) {
+	id := tenant.ID
+
+	// 保留旧配置用于计算变更推送的差异，旧配置无法解析时按空配置处理
+	var oldConfig types.TenantConfig
+	if tenant.Config != "" {
+		if err := json.Unmarshal([]byte(tenant.Config), &oldConfig); err != nil {
+			g.Log().Warningf(ctx, "Failed to parse previous tenant config: tenant_id=%d, error=%v", id, err)
+		}
+	}
+
 	// 序列化配置
 	configJSON, err := json.Marshal(config)
 	if err != nil {
-		return errors.New("配置序列化失败")
+		return 0, errors.New("配置序列化失败")
 	}
 
 	// 更新配置
@@ -281,7 +388,19 @@ func (s *tenantService) UpdateTenantConfig(ctx context.Context, id uint64, confi
 	// 更新数据库
 	err = s.tenantRepo.Update(ctx, tenant)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	// 保存配置版本
+	version := &types.TenantConfigVersion{
+		TenantID:  id,
+		Config:    tenant.Config,
+		Source:    source,
+		CreatedBy: gconv.Uint64(ctx.Value("user_id")),
+	}
+	if err := s.versionRepo.Create(ctx, version); err != nil {
+		g.Log().Errorf(ctx, "Failed to save tenant config version: tenant_id=%d, error=%v", id, err)
+		version.Version = 0
 	}
 
 	// 使配置缓存失效
@@ -297,22 +416,43 @@ func (s *tenantService) UpdateTenantConfig(ctx context.Context, id uint64, confi
 	}
 	s.configCache.SetConfigChangeNotification(ctx, id, changeInfo)
 
-	// 记录配置变更审计日志
-	audit.LogTenantAccess(ctx, id, "tenant", "config_update", map[string]interface{}{
-		"tenant_name": tenant.Name,
-		"tenant_code": tenant.Code,
-		"new_config":  config,
-	})
+	// 推送配置变更到订阅的 Webhook，写入推送任务失败不影响配置更新
+	if err := s.configWebhooks.PublishConfigChange(ctx, id, &oldConfig, config); err != nil {
+		g.Log().Errorf(ctx, "Failed to publish tenant config webhooks: tenant_id=%d, error=%v", id, err)
+	}
 
-	g.Log().Infof(ctx, "Tenant config updated: tenant_id=%d", id)
+	// 记录配置变更审计日志，导入配置的审计日志由导入记录
+	if source == types.TenantConfigVersionSourceUpdate {
+		audit.LogTenantAccess(ctx, id, "tenant", "config_update", map[string]interface{}{
+			"tenant_name": tenant.Name,
+			"tenant_code": tenant.Code,
+			"version":     version.Version,
+			"new_config":  config,
+		})
+	}
 
-	return nil
+	g.Log().Infof(ctx, "Tenant config updated: tenant_id=%d, version=%d, source=%s", id, version.Version, source)
+
+	return version.Version, nil
 }
 
 func (s *tenantService) GetConfigChangeNotification(ctx context.Context, id uint64) (map[string]interface{}, error) {
 	return s.configCache.GetConfigChangeNotification(ctx, id)
 }
 
+// ProvisionTenant 重新开通租户，补齐缺失的默认项
+func (s *tenantService) ProvisionTenant(ctx context.Context, id uint64, req *types.ProvisionTenantRequest) (*types.TenantProvisionResult, error) {
+	result, err := s.provisioner.Provision(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 开通可能写入了默认配置，使配置缓存失效
+	s.configCache.InvalidateConfig(ctx, id)
+
+	return result, nil
+}
+
 func (s *tenantService) convertToResponse(tenant *types.Tenant) *types.TenantResponse {
 	return &types.TenantResponse{
 		ID:               tenant.ID,
@@ -329,4 +469,4 @@ func (s *tenantService) convertToResponse(tenant *types.Tenant) *types.TenantRes
 		CreatedAt:        tenant.CreatedAt,
 		UpdatedAt:        tenant.UpdatedAt,
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// ExportTenantConfig 导出租户配置包，密钥配置的取值替换为占位符，包中记录导出时的配置版本
+func (s *tenantService) ExportTenantConfig(ctx context.Context, id uint64) (*types.TenantConfigBundle, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tenant == nil {
+		return nil, ErrTenantNotFound
+	}
+
+	var config types.TenantConfig
+	if tenant.Config != "" {
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, errors.New("租户配置格式错误")
+		}
+	}
+
+	// 配置版本只用于追溯来源，查询失败时不记录来源版本
+	sourceVersion := 0
+	latest, err := s.versionRepo.Latest(ctx, id)
+	if err != nil {
+		g.Log().Warningf(ctx, "Failed to get tenant config version: tenant_id=%d, error=%v", id, err)
+	} else if latest != nil {
+		sourceVersion = latest.Version
+	}
+
+	bundle, err := types.NewTenantConfigBundle(&config, tenant.Code, sourceVersion, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	audit.LogTenantAccess(ctx, id, "tenant", "config_export", map[string]interface{}{
+		"tenant_name":           tenant.Name,
+		"tenant_code":           tenant.Code,
+		"source_config_version": sourceVersion,
+		"redacted":              bundle.Redacted,
+	})
+
+	return bundle, nil
+}
+
+// PreviewTenantConfigImport 校验配置包并计算导入后相对当前配置的变更，不应用配置。
+// 配置包不兼容或配置取值无效时返回的配置为 nil，问题记录在报告中
+func (s *tenantService) PreviewTenantConfigImport(ctx context.Context, id uint64, bundle *types.TenantConfigBundle) (*types.TenantConfig, *types.TenantConfigImportReport, error) {
+	_, config, report, err := s.prepareConfigImport(ctx, id, bundle)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.DryRun = true
+	return config, report, nil
+}
+
+// ImportTenantConfig 校验并应用配置包，生成新的配置版本；配置包存在问题时不应用，返回包含问题的报告
+func (s *tenantService) ImportTenantConfig(ctx context.Context, id uint64, bundle *types.TenantConfigBundle) (*types.TenantConfigImportReport, error) {
+	tenant, config, report, err := s.prepareConfigImport(ctx, id, bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	if !report.Valid {
+		audit.LogTenantAccess(ctx, id, "tenant", "config_import_rejected", map[string]interface{}{
+			"tenant_name":        tenant.Name,
+			"tenant_code":        tenant.Code,
+			"source_tenant_code": bundle.SourceTenantCode,
+			"issues":             report.Issues,
+		})
+		return report, nil
+	}
+
+	version, err := s.applyTenantConfig(ctx, tenant, config, types.TenantConfigVersionSourceImport)
+	if err != nil {
+		return nil, err
+	}
+	report.Applied = true
+	report.Version = version
+
+	changedKeys := make([]string, 0, len(report.Changes))
+	for _, change := range report.Changes {
+		changedKeys = append(changedKeys, change.Key)
+	}
+	audit.LogTenantAccess(ctx, id, "tenant", "config_import", map[string]interface{}{
+		"tenant_name":           tenant.Name,
+		"tenant_code":           tenant.Code,
+		"version":               version,
+		"source_tenant_code":    bundle.SourceTenantCode,
+		"source_config_version": bundle.SourceConfigVersion,
+		"exported_at":           bundle.ExportedAt,
+		"changed_keys":          changedKeys,
+	})
+
+	return report, nil
+}
+
+// prepareConfigImport 按配置包结构和各策略的取值校验配置包，被脱敏的密钥沿用租户的当前取值
+func (s *tenantService) prepareConfigImport(ctx context.Context, id uint64, bundle *types.TenantConfigBundle) (*types.Tenant, *types.TenantConfig, *types.TenantConfigImportReport, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if tenant == nil {
+		return nil, nil, nil, ErrTenantNotFound
+	}
+
+	// 当前配置无法解析时按空配置处理，与更新配置一致
+	var current types.TenantConfig
+	if tenant.Config != "" {
+		if err := json.Unmarshal([]byte(tenant.Config), &current); err != nil {
+			g.Log().Warningf(ctx, "Failed to parse previous tenant config: tenant_id=%d, error=%v", id, err)
+		}
+	}
+
+	report := &types.TenantConfigImportReport{}
+	config, issues := bundle.Decode(&current)
+	if len(issues) == 0 {
+		if err := validateTenantConfig(config); err != nil {
+			issues = append(issues, types.TenantConfigImportIssue{Key: "config", Message: err.Error()})
+		}
+	}
+	if len(issues) > 0 {
+		report.Issues = issues
+		return tenant, nil, report, nil
+	}
+
+	changes, err := types.DiffTenantConfig(&current, config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	report.Valid = true
+	report.Changes = changes
+	return tenant, config, report, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+// TenantConfigWebhookPublisher 租户配置变更推送：按订阅了 tenant.config_changed 的 Webhook 写入发件箱推送任务，
+// 由订单服务的发件箱投递器复用订单 Webhook 的签名和重试策略推送
+type TenantConfigWebhookPublisher struct {
+	webhookRepo repository.IOrderWebhookRepository
+	outboxRepo  repository.IOutboxRepository
+}
+
+// NewTenantConfigWebhookPublisher 创建租户配置变更推送实例
+func NewTenantConfigWebhookPublisher() *TenantConfigWebhookPublisher {
+	return &TenantConfigWebhookPublisher{
+		webhookRepo: repository.NewOrderWebhookRepository(),
+		outboxRepo:  repository.NewOutboxRepository(),
+	}
+}
+
+// NewTenantConfigWebhookPublisherForTest 创建测试用租户配置变更推送实例
+func NewTenantConfigWebhookPublisherForTest(webhookRepo repository.IOrderWebhookRepository, outboxRepo repository.IOutboxRepository) *TenantConfigWebhookPublisher {
+	return &TenantConfigWebhookPublisher{
+		webhookRepo: webhookRepo,
+		outboxRepo:  outboxRepo,
+	}
+}
+
+// PublishConfigChange 计算配置差异，为每个订阅的启用 Webhook 写入一条推送任务。
+// 推送内容只包含 Webhook 订阅分区内的变更，没有相关变更的 Webhook 不推送；密钥类配置只推送键名
+func (p *TenantConfigWebhookPublisher) PublishConfigChange(ctx context.Context, tenantID uint64, oldConfig, newConfig *types.TenantConfig) error {
+	changes, err := types.DiffTenantConfig(oldConfig, newConfig)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// 平台管理员修改其他租户配置时，按被修改的租户查询 Webhook
+	ctx = context.WithValue(ctx, "tenant_id", tenantID)
+	webhooks, err := p.webhookRepo.ListEnabled(ctx)
+	if err != nil {
+		return err
+	}
+
+	eventID := guid.S()
+	occurredAt := time.Now()
+	var failures []string
+	for i := range webhooks {
+		webhook := &webhooks[i]
+		if !webhook.Subscribes(types.OrderWebhookEventTenantConfigChanged) {
+			continue
+		}
+
+		subscribed := types.FilterTenantConfigChanges(changes, webhook.ConfigSections)
+		if len(subscribed) == 0 {
+			continue
+		}
+
+		body, err := json.Marshal(types.NewTenantConfigWebhookPayload(eventID, tenantID, occurredAt, subscribed))
+		if err != nil {
+			return fmt.Errorf("序列化推送内容失败: %v", err)
+		}
+		task, err := json.Marshal(&types.OrderWebhookDeliveryEvent{
+			WebhookID: webhook.ID,
+			EventID:   eventID,
+			Event:     types.OrderWebhookEventTenantConfigChanged,
+			Body:      string(body),
+		})
+		if err != nil {
+			return fmt.Errorf("序列化推送任务失败: %v", err)
+		}
+
+		err = p.outboxRepo.Enqueue(ctx, &types.OutboxEvent{
+			TenantID:      tenantID,
+			AggregateType: "tenant",
+			AggregateID:   tenantID,
+			EventType:     types.OutboxEventOrderWebhook,
+			Payload:       string(task),
+		})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("Webhook%d: %v", webhook.ID, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("写入配置变更推送任务失败: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/crypto/gmd5"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// 系统生成的初始管理员密码长度
+const initialAdminPasswordLength = 16
+
+// ErrTenantNotFound 租户不存在
+var ErrTenantNotFound = errors.New("租户不存在")
+
+// ITenantProvisioningService 租户开通服务接口
+type ITenantProvisioningService interface {
+	// 创建租户并开通默认配置、默认超时配置和初始管理员
+	CreateAndProvision(ctx context.Context, tenant *types.Tenant, req *types.ProvisionTenantRequest) (*types.TenantProvisionResult, error)
+	// 重新开通已有租户，只补齐缺失的默认项
+	Provision(ctx context.Context, tenantID uint64, req *types.ProvisionTenantRequest) (*types.TenantProvisionResult, error)
+}
+
+// TenantProvisioningService 租户开通服务。
+// 所有默认项在同一事务中创建，任一步骤失败时整体回滚，不会留下开通一半的租户。
+// 系统内置角色对所有租户生效（tenant_id = 0），开通时只需为初始管理员分配租户管理员角色
+type TenantProvisioningService struct {
+	repo repository.ITenantProvisioningRepository
+}
+
+// NewTenantProvisioningService 创建租户开通服务实例
+func NewTenantProvisioningService() ITenantProvisioningService {
+	return &TenantProvisioningService{
+		repo: repository.NewTenantProvisioningRepository(),
+	}
+}
+
+// NewTenantProvisioningServiceForTest 创建测试用租户开通服务实例
+func NewTenantProvisioningServiceForTest(repo repository.ITenantProvisioningRepository) *TenantProvisioningService {
+	return &TenantProvisioningService{repo: repo}
+}
+
+// CreateAndProvision 在同一事务中创建租户并开通默认项
+func (s *TenantProvisioningService) CreateAndProvision(ctx context.Context, tenant *types.Tenant, req *types.ProvisionTenantRequest) (*types.TenantProvisionResult, error) {
+	if err := validateProvisionRequest(req); err != nil {
+		return nil, err
+	}
+
+	var result *types.TenantProvisionResult
+	err := s.repo.WithTransaction(ctx, 0, func(ctx context.Context, tx gdb.TX) error {
+		configCreated, err := applyDefaultTenantConfig(tenant)
+		if err != nil {
+			return err
+		}
+
+		id, err := s.repo.CreateTenant(ctx, tenant)
+		if err != nil {
+			return err
+		}
+		tenant.ID = id
+
+		result = &types.TenantProvisionResult{TenantID: id}
+		result.Record(types.TenantProvisionStepConfig, configCreated)
+		return s.provisionDefaults(ctx, tenant, req, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logProvisioned(ctx, tenant, result)
+	return result, nil
+}
+
+// Provision 重新开通已有租户，用于修复开通不完整的租户，可重复执行
+func (s *TenantProvisioningService) Provision(ctx context.Context, tenantID uint64, req *types.ProvisionTenantRequest) (*types.TenantProvisionResult, error) {
+	if err := validateProvisionRequest(req); err != nil {
+		return nil, err
+	}
+
+	var tenant *types.Tenant
+	var result *types.TenantProvisionResult
+	err := s.repo.WithTransaction(ctx, tenantID, func(ctx context.Context, tx gdb.TX) error {
+		var err error
+		tenant, err = s.repo.LockTenant(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+		if tenant == nil {
+			return ErrTenantNotFound
+		}
+
+		configCreated, err := applyDefaultTenantConfig(tenant)
+		if err != nil {
+			return err
+		}
+		if configCreated {
+			if err := s.repo.UpdateTenantConfig(ctx, tenant.ID, tenant.Config); err != nil {
+				return err
+			}
+		}
+
+		result = &types.TenantProvisionResult{TenantID: tenant.ID}
+		result.Record(types.TenantProvisionStepConfig, configCreated)
+		return s.provisionDefaults(ctx, tenant, req, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logProvisioned(ctx, tenant, result)
+	return result, nil
+}
+
+// provisionDefaults 补齐租户默认超时配置、初始管理员及其角色
+func (s *TenantProvisioningService) provisionDefaults(ctx context.Context, tenant *types.Tenant, req *types.ProvisionTenantRequest, result *types.TenantProvisionResult) error {
+	timeoutConfig, err := s.repo.GetDefaultTimeoutConfig(ctx, tenant.ID)
+	if err != nil {
+		return err
+	}
+	if timeoutConfig == nil {
+		if err := s.repo.CreateTimeoutConfig(ctx, types.DefaultTenantTimeoutConfig(tenant.ID)); err != nil {
+			return err
+		}
+	}
+	result.Record(types.TenantProvisionStepTimeoutConfig, timeoutConfig == nil)
+
+	admin, err := s.ensureAdminUser(ctx, tenant, req, result)
+	if err != nil {
+		return err
+	}
+
+	roles, err := s.repo.GetUserRoles(ctx, admin.ID, tenant.ID)
+	if err != nil {
+		return err
+	}
+	hasAdminRole := false
+	for _, role := range roles {
+		if role == types.RoleTenantAdmin {
+			hasAdminRole = true
+			break
+		}
+	}
+	if !hasAdminRole {
+		grantedBy := gconv.Uint64(ctx.Value("user_id"))
+		if err := s.repo.AssignRole(ctx, admin.ID, tenant.ID, types.RoleTenantAdmin, grantedBy); err != nil {
+			return err
+		}
+	}
+	result.Record(types.TenantProvisionStepAdminRole, !hasAdminRole)
+
+	return nil
+}
+
+// ensureAdminUser 获取初始管理员，不存在时创建
+func (s *TenantProvisioningService) ensureAdminUser(ctx context.Context, tenant *types.Tenant, req *types.ProvisionTenantRequest, result *types.TenantProvisionResult) (*types.User, error) {
+	username := req.AdminUsername
+	if username == "" {
+		username = types.DefaultTenantAdminUsername
+	}
+
+	admin, err := s.repo.GetUserByUsername(ctx, tenant.ID, username)
+	if err != nil {
+		return nil, err
+	}
+	if admin != nil {
+		result.Record(types.TenantProvisionStepAdminUser, false)
+		result.AdminUserID = admin.ID
+		result.AdminUsername = admin.Username
+		return admin, nil
+	}
+
+	password := req.AdminPassword
+	if password == "" {
+		if password, err = utils.GenerateRandomPassword(initialAdminPasswordLength); err != nil {
+			return nil, err
+		}
+		result.InitialPassword = password
+	}
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("管理员密码加密失败: %v", err)
+	}
+
+	email := req.AdminEmail
+	if email == "" {
+		email = tenant.ContactEmail
+	}
+
+	admin = &types.User{
+		UUID:         gmd5.MustEncryptString(fmt.Sprintf("%s-%d-%d", username, tenant.ID, time.Now().UnixNano())),
+		Username:     username,
+		Email:        email,
+		PasswordHash: passwordHash,
+		TenantID:     tenant.ID,
+		Status:       types.UserStatusActive,
+	}
+	if admin.ID, err = s.repo.CreateUser(ctx, admin); err != nil {
+		return nil, err
+	}
+
+	result.Record(types.TenantProvisionStepAdminUser, true)
+	result.AdminUserID = admin.ID
+	result.AdminUsername = admin.Username
+	return admin, nil
+}
+
+// logProvisioned 记录租户开通审计日志，初始密码不写入日志
+func (s *TenantProvisioningService) logProvisioned(ctx context.Context, tenant *types.Tenant, result *types.TenantProvisionResult) {
+	audit.LogTenantAccess(ctx, tenant.ID, "tenant", "provision", map[string]interface{}{
+		"tenant_code":    tenant.Code,
+		"created":        result.Created,
+		"existing":       result.Existing,
+		"admin_user_id":  result.AdminUserID,
+		"admin_username": result.AdminUsername,
+	})
+
+	g.Log().Infof(ctx, "Tenant provisioned: tenant_id=%d, created=%v", tenant.ID, result.Created)
+}
+
+// validateProvisionRequest 校验开通请求，在开启事务前拒绝无效的管理员密码
+func validateProvisionRequest(req *types.ProvisionTenantRequest) error {
+	if req.AdminPassword != "" {
+		if err := utils.IsValidPassword(req.AdminPassword); err != nil {
+			return fmt.Errorf("管理员密码无效: %v", err)
+		}
+	}
+	return nil
+}
+
+// applyDefaultTenantConfig 租户未设置配置时使用默认配置，返回是否写入了默认配置
+func applyDefaultTenantConfig(tenant *types.Tenant) (bool, error) {
+	config := strings.TrimSpace(tenant.Config)
+	if config != "" && config != "null" && config != "{}" {
+		return false, nil
+	}
+
+	configJSON, err := json.Marshal(types.DefaultTenantConfig())
+	if err != nil {
+		return false, fmt.Errorf("默认配置序列化失败: %v", err)
+	}
+	tenant.Config = string(configJSON)
+	return true, nil
+}
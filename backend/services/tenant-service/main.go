@@ -7,7 +7,11 @@ import (
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/os/gctx"
 	"github.com/gofromzero/mer-sys/backend/services/tenant-service/internal/controller"
+	"github.com/gofromzero/mer-sys/backend/shared/database"
+	"github.com/gofromzero/mer-sys/backend/shared/handlers"
+	"github.com/gofromzero/mer-sys/backend/shared/maintenance"
 	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
 
 	_ "github.com/gogf/gf/contrib/drivers/mysql/v2"
 )
@@ -15,12 +19,28 @@ import (
 func main() {
 	ctx := gctx.GetInitCtx()
 
+	// 按配置在启动时应用共享迁移，默认由部署流程执行 migrate 命令
+	if err := database.MigrateOnStartup(ctx, database.SharedMigrationSet, database.SharedMigrations()); err != nil {
+		g.Log().Fatal(ctx, err)
+	}
+
+	// 数据驻留配置映射了未定义的数据库分组时拒绝启动，避免租户数据落入共享数据库
+	if err := repository.InitTenantDatasourceConfig(ctx); err != nil {
+		g.Log().Fatalf(ctx, "数据驻留配置校验失败: %v", err)
+	}
+
 	// 创建HTTP服务器
 	s := g.Server()
 
+	// 应用连接池配置并暴露连接池指标
+	handlers.RegisterDBPoolMetrics(s, "tenant-service")
+
 	// 设置端口
 	s.SetPort(8081)
 
+	// 维护模式状态在各服务间共享
+	maintenanceManager := maintenance.NewManager()
+
 	// 注册中间件
 	s.Group("/api/v1", func(group *ghttp.RouterGroup) {
 		// 公开路由（不需要认证）
@@ -30,6 +50,8 @@ func main() {
 		group.Group("/", func(authGroup *ghttp.RouterGroup) {
 			// 基础认证中间件
 			authGroup.Middleware(middleware.NewAuthMiddleware())
+			// 维护模式下拒绝写请求
+			authGroup.Middleware(middleware.MaintenanceMode(maintenanceManager))
 
 			// 租户相关路由
 			tenantController := controller.NewTenantController()
@@ -64,23 +86,68 @@ func main() {
 				middleware.NewAuthMiddleware().RequirePermissions("tenant:view"),
 				tenantController.GetConfig)
 			
+			// 查看租户配额用量 - 需要查看权限
+			authGroup.GET("/tenants/:id/quota-usage", 
+				middleware.NewAuthMiddleware().RequirePermissions("tenant:view"),
+				tenantController.GetQuotaUsage)
+			
 			// 更新租户配置 - 需要管理权限（敏感操作）
 			authGroup.PUT("/tenants/:id/config", 
 				middleware.NewAuthMiddleware().RequirePermissions("tenant:manage"),
 				tenantController.UpdateConfig)
 			
+			// 导出租户配置包（密钥已脱敏）- 需要管理权限
+			authGroup.GET("/tenants/:id/config/export", 
+				middleware.NewAuthMiddleware().RequirePermissions("tenant:manage"),
+				tenantController.ExportConfig)
+			
+			// 导入租户配置包，生成新的配置版本 - 需要管理权限（敏感操作）
+			authGroup.POST("/tenants/:id/config/import", 
+				middleware.NewAuthMiddleware().RequirePermissions("tenant:manage"),
+				tenantController.ImportConfig)
+			
+			// 重新开通租户（修复开通不完整的租户）- 需要管理权限（敏感操作）
+			authGroup.POST("/tenants/:id/provision", 
+				middleware.NewAuthMiddleware().RequirePermissions("tenant:manage"),
+				tenantController.Provision)
+			
 			// 获取配置变更通知 - 需要查看权限
 			authGroup.GET("/tenants/:id/config/notifications", 
 				middleware.NewAuthMiddleware().RequirePermissions("tenant:view"),
 				tenantController.GetConfigNotification)
 		})
+
+		// 维护模式路由：不受维护模式限制，以便随时关闭维护模式
+		group.Group("/", func(maintenanceGroup *ghttp.RouterGroup) {
+			maintenanceGroup.Middleware(middleware.NewAuthMiddleware().JWTAuth)
+
+			maintenanceController := controller.NewMaintenanceController(maintenanceManager)
+
+			// 平台维护模式 - 需要系统配置权限
+			maintenanceGroup.GET("/maintenance",
+				middleware.NewAuthMiddleware().RequirePermissions("system:config"),
+				maintenanceController.GetGlobal)
+			maintenanceGroup.PUT("/maintenance",
+				middleware.NewAuthMiddleware().RequirePermissions("system:config"),
+				maintenanceController.SetGlobal)
+
+			// 租户维护模式 - 开启和关闭需要管理权限（敏感操作）
+			maintenanceGroup.GET("/tenants/:id/maintenance",
+				middleware.NewAuthMiddleware().RequirePermissions("tenant:view"),
+				maintenanceController.GetTenant)
+			maintenanceGroup.PUT("/tenants/:id/maintenance",
+				middleware.NewAuthMiddleware().RequirePermissions("tenant:manage"),
+				maintenanceController.SetTenant)
+		})
 	})
 
 	// 健康检查路由
 	s.BindHandler("/health", func(r *ghttp.Request) {
+		maintenanceStatus, _ := maintenanceManager.Status(r.Context(), 0)
 		r.Response.WriteJson(g.Map{
 			"status": "ok",
 			"service": "tenant-service",
+			"maintenance": maintenanceStatus,
 		})
 	})
 
@@ -0,0 +1,218 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/services/tenant-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryTenantRepository 内存租户仓储
+type memoryTenantRepository struct {
+	repository.ITenantRepository
+	tenants map[uint64]*types.Tenant
+}
+
+func (r *memoryTenantRepository) GetByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	tenant, exists := r.tenants[id]
+	if !exists {
+		return nil, nil
+	}
+	copied := *tenant
+	return &copied, nil
+}
+
+func (r *memoryTenantRepository) Update(ctx context.Context, tenant *types.Tenant) error {
+	copied := *tenant
+	r.tenants[tenant.ID] = &copied
+	return nil
+}
+
+// memoryConfigVersionRepository 内存租户配置版本仓储
+type memoryConfigVersionRepository struct {
+	versions []types.TenantConfigVersion
+}
+
+func (r *memoryConfigVersionRepository) Create(ctx context.Context, version *types.TenantConfigVersion) error {
+	latest, err := r.Latest(ctx, version.TenantID)
+	if err != nil {
+		return err
+	}
+	version.Version = 1
+	if latest != nil {
+		version.Version = latest.Version + 1
+	}
+	version.ID = uint64(len(r.versions) + 1)
+	r.versions = append(r.versions, *version)
+	return nil
+}
+
+func (r *memoryConfigVersionRepository) Latest(ctx context.Context, tenantID uint64) (*types.TenantConfigVersion, error) {
+	var latest *types.TenantConfigVersion
+	for i := range r.versions {
+		if r.versions[i].TenantID == tenantID && (latest == nil || r.versions[i].Version > latest.Version) {
+			latest = &r.versions[i]
+		}
+	}
+	return latest, nil
+}
+
+func TestTenantConfigBundle(t *testing.T) {
+	Convey("租户配置导出和导入测试", t, func() {
+		ctx := context.WithValue(context.Background(), "user_id", uint64(7))
+		stagingConfig := &types.TenantConfig{
+			MaxUsers:     50,
+			MaxMerchants: 10,
+			Features:     []string{"reports"},
+			Settings:     map[string]string{"currency": "CNY", "payment_secret": "sk-staging"},
+			Tax:          &types.TaxPolicy{Enabled: true, Rate: 0.06},
+		}
+		stagingJSON, _ := json.Marshal(stagingConfig)
+		productionJSON, _ := json.Marshal(&types.TenantConfig{
+			MaxUsers:     20,
+			MaxMerchants: 5,
+			Settings:     map[string]string{"currency": "CNY", "payment_secret": "sk-production"},
+		})
+
+		tenantRepo := &memoryTenantRepository{tenants: map[uint64]*types.Tenant{
+			1: {ID: 1, Code: "staging", Config: string(stagingJSON)},
+			2: {ID: 2, Code: "production", Config: string(productionJSON)},
+			3: {ID: 3, Code: "empty"},
+		}}
+		versionRepo := &memoryConfigVersionRepository{versions: []types.TenantConfigVersion{
+			{ID: 1, TenantID: 1, Version: 4, Config: string(stagingJSON), Source: types.TenantConfigVersionSourceUpdate},
+		}}
+		outbox := &configOutboxRepository{}
+		tenantService := service.NewTenantServiceForTest(tenantRepo, versionRepo,
+			service.NewTenantConfigWebhookPublisherForTest(&configWebhookRepository{}, outbox))
+
+		bundle, err := tenantService.ExportTenantConfig(ctx, 1)
+		So(err, ShouldBeNil)
+
+		Convey("导出的配置包记录来源版本，密钥取值被替换为占位符", func() {
+			So(bundle.Format, ShouldEqual, types.TenantConfigBundleFormat)
+			So(bundle.SchemaVersion, ShouldEqual, types.TenantConfigBundleSchemaVersion)
+			So(bundle.SourceTenantCode, ShouldEqual, "staging")
+			So(bundle.SourceConfigVersion, ShouldEqual, 4)
+			So(bundle.Redacted, ShouldResemble, []string{"settings.payment_secret"})
+			So(string(bundle.Config), ShouldNotContainSubstring, "sk-staging")
+			So(string(bundle.Config), ShouldContainSubstring, types.TenantConfigRedactedValue)
+		})
+
+		Convey("导出再导入到另一个租户，密钥沿用目标租户的取值并生成新的配置版本", func() {
+			data, err := json.Marshal(bundle)
+			So(err, ShouldBeNil)
+			var imported types.TenantConfigBundle
+			So(json.Unmarshal(data, &imported), ShouldBeNil)
+
+			report, err := tenantService.ImportTenantConfig(ctx, 2, &imported)
+			So(err, ShouldBeNil)
+			So(report.Valid, ShouldBeTrue)
+			So(report.Applied, ShouldBeTrue)
+			So(report.Version, ShouldEqual, 1)
+			So(report.Issues, ShouldBeEmpty)
+
+			changed := make(map[string]bool)
+			for _, change := range report.Changes {
+				changed[change.Key] = true
+			}
+			So(changed["max_users"], ShouldBeTrue)
+			So(changed["tax.rate"], ShouldBeTrue)
+			So(changed["settings.payment_secret"], ShouldBeFalse)
+
+			var applied types.TenantConfig
+			So(json.Unmarshal([]byte(tenantRepo.tenants[2].Config), &applied), ShouldBeNil)
+			So(applied.MaxUsers, ShouldEqual, 50)
+			So(applied.Features, ShouldResemble, []string{"reports"})
+			So(applied.Tax.Rate, ShouldEqual, 0.06)
+			So(applied.Settings["payment_secret"], ShouldEqual, "sk-production")
+
+			latest, _ := versionRepo.Latest(ctx, 2)
+			So(latest.Source, ShouldEqual, types.TenantConfigVersionSourceImport)
+			So(latest.CreatedBy, ShouldEqual, 7)
+			So(latest.Config, ShouldEqual, tenantRepo.tenants[2].Config)
+
+			Convey("重新导出得到相同的配置", func() {
+				exported, err := tenantService.ExportTenantConfig(ctx, 2)
+				So(err, ShouldBeNil)
+				So(exported.SourceConfigVersion, ShouldEqual, 1)
+				So(string(exported.Config), ShouldEqual, string(bundle.Config))
+			})
+		})
+
+		Convey("只校验时返回变更但不应用", func() {
+			config, report, err := tenantService.PreviewTenantConfigImport(ctx, 2, bundle)
+			So(err, ShouldBeNil)
+			So(report.DryRun, ShouldBeTrue)
+			So(report.Valid, ShouldBeTrue)
+			So(report.Applied, ShouldBeFalse)
+			So(report.Changes, ShouldNotBeEmpty)
+			So(config.Settings["payment_secret"], ShouldEqual, "sk-production")
+			So(tenantRepo.tenants[2].Config, ShouldEqual, string(productionJSON))
+		})
+
+		Convey("校验失败时不应用配置并返回全部问题", func() {
+			Convey("格式、结构版本不支持和未知配置分区", func() {
+				invalid := *bundle
+				invalid.Format = "other-system"
+				invalid.SchemaVersion = 99
+				invalid.Config = json.RawMessage(`{"max_users": 5, "loyalty": {"enabled": true}}`)
+
+				report, err := tenantService.ImportTenantConfig(ctx, 2, &invalid)
+				So(err, ShouldBeNil)
+				So(report.Valid, ShouldBeFalse)
+				So(report.Applied, ShouldBeFalse)
+
+				keys := make([]string, 0, len(report.Issues))
+				for _, issue := range report.Issues {
+					keys = append(keys, issue.Key)
+				}
+				So(keys, ShouldResemble, []string{"format", "schema_version", "loyalty"})
+				So(tenantRepo.tenants[2].Config, ShouldEqual, string(productionJSON))
+				So(outbox.events, ShouldBeEmpty)
+			})
+
+			Convey("目标租户没有被脱敏的密钥", func() {
+				report, err := tenantService.ImportTenantConfig(ctx, 3, bundle)
+				So(err, ShouldBeNil)
+				So(report.Applied, ShouldBeFalse)
+				So(report.Issues, ShouldHaveLength, 1)
+				So(report.Issues[0].Key, ShouldEqual, "settings.payment_secret")
+				So(tenantRepo.tenants[3].Config, ShouldBeEmpty)
+			})
+
+			Convey("配置字段类型不兼容", func() {
+				invalid := *bundle
+				invalid.Config = json.RawMessage(`{"max_users": "many"}`)
+
+				report, err := tenantService.ImportTenantConfig(ctx, 2, &invalid)
+				So(err, ShouldBeNil)
+				So(report.Applied, ShouldBeFalse)
+				So(report.Issues[0].Key, ShouldEqual, "config")
+			})
+
+			Convey("配置策略取值无效", func() {
+				invalid := *bundle
+				invalid.Config = json.RawMessage(`{"max_users": 5, "tax": {"enabled": true, "rate": 2}}`)
+
+				report, err := tenantService.ImportTenantConfig(ctx, 2, &invalid)
+				So(err, ShouldBeNil)
+				So(report.Applied, ShouldBeFalse)
+				So(report.Issues, ShouldHaveLength, 1)
+				So(tenantRepo.tenants[2].Config, ShouldEqual, string(productionJSON))
+			})
+		})
+
+		Convey("租户不存在", func() {
+			_, err := tenantService.ExportTenantConfig(ctx, 99)
+			So(err, ShouldEqual, service.ErrTenantNotFound)
+
+			_, err = tenantService.ImportTenantConfig(ctx, 99, bundle)
+			So(err, ShouldEqual, service.ErrTenantNotFound)
+		})
+	})
+}
@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/services/tenant-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// configWebhookRepository 按租户返回启用的 Webhook
+type configWebhookRepository struct {
+	repository.IOrderWebhookRepository
+	webhooks []types.OrderWebhook
+}
+
+func (r *configWebhookRepository) ListEnabled(ctx context.Context) ([]types.OrderWebhook, error) {
+	var result []types.OrderWebhook
+	for _, webhook := range r.webhooks {
+		if webhook.Enabled && webhook.TenantID == gconv.Uint64(ctx.Value("tenant_id")) {
+			result = append(result, webhook)
+		}
+	}
+	return result, nil
+}
+
+// configOutboxRepository 记录写入的发件箱事件
+type configOutboxRepository struct {
+	repository.IOutboxRepository
+	events []types.OutboxEvent
+}
+
+func (r *configOutboxRepository) Enqueue(ctx context.Context, event *types.OutboxEvent) error {
+	event.ID = uint64(len(r.events) + 1)
+	r.events = append(r.events, *event)
+	return nil
+}
+
+// decodeConfigWebhookTask 解析推送任务和推送内容
+func decodeConfigWebhookTask(event types.OutboxEvent) (*types.OrderWebhookDeliveryEvent, *types.TenantConfigWebhookPayload) {
+	var task types.OrderWebhookDeliveryEvent
+	So(json.Unmarshal([]byte(event.Payload), &task), ShouldBeNil)
+	var payload types.TenantConfigWebhookPayload
+	So(json.Unmarshal([]byte(task.Body), &payload), ShouldBeNil)
+	return &task, &payload
+}
+
+func TestTenantConfigWebhookPublisher(t *testing.T) {
+	Convey("租户配置变更推送测试", t, func() {
+		webhookRepo := &configWebhookRepository{webhooks: []types.OrderWebhook{
+			{ID: 1, TenantID: 1, Enabled: true, Events: types.StringArray{string(types.OrderWebhookEventTenantConfigChanged)}},
+			{ID: 2, TenantID: 1, Enabled: true, Events: types.StringArray{string(types.OrderWebhookEventTenantConfigChanged)}, ConfigSections: types.StringArray{"quiet_hours"}},
+			{ID: 3, TenantID: 1, Enabled: true, Events: types.StringArray{string(types.OrderWebhookEventPaid)}},
+			{ID: 4, TenantID: 1, Enabled: false, Events: types.StringArray{string(types.OrderWebhookEventTenantConfigChanged)}},
+			{ID: 5, TenantID: 2, Enabled: true, Events: types.StringArray{string(types.OrderWebhookEventTenantConfigChanged)}},
+		}}
+		outbox := &configOutboxRepository{}
+		publisher := service.NewTenantConfigWebhookPublisherForTest(webhookRepo, outbox)
+
+		// 平台管理员在自己的租户上下文中修改租户1的配置
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(99))
+		oldConfig := &types.TenantConfig{
+			MaxUsers: 10,
+			Settings: map[string]string{"currency": "CNY", "payment_secret": "sk-old-value"},
+		}
+		newConfig := &types.TenantConfig{
+			MaxUsers: 20,
+			Settings: map[string]string{"currency": "USD", "payment_secret": "sk-new-value"},
+		}
+
+		Convey("订阅的 Webhook 收到包含差异的推送任务，密钥只推送键名", func() {
+			So(publisher.PublishConfigChange(ctx, 1, oldConfig, newConfig), ShouldBeNil)
+			So(outbox.events, ShouldHaveLength, 1)
+
+			event := outbox.events[0]
+			So(event.TenantID, ShouldEqual, 1)
+			So(event.EventType, ShouldEqual, types.OutboxEventOrderWebhook)
+
+			task, payload := decodeConfigWebhookTask(event)
+			So(task.WebhookID, ShouldEqual, 1)
+			So(task.Event, ShouldEqual, types.OrderWebhookEventTenantConfigChanged)
+			So(task.EventID, ShouldEqual, payload.ID)
+			So(payload.TenantID, ShouldEqual, 1)
+			So(payload.Sections, ShouldResemble, []string{"max_users", "settings"})
+			So(payload.Changes, ShouldResemble, []types.TenantConfigChange{
+				{Key: "max_users", Section: "max_users", OldValue: float64(10), NewValue: float64(20)},
+				{Key: "settings.currency", Section: "settings", OldValue: "CNY", NewValue: "USD"},
+				{Key: "settings.payment_secret", Section: "settings", Redacted: true},
+			})
+			So(task.Body, ShouldNotContainSubstring, "sk-old-value")
+			So(task.Body, ShouldNotContainSubstring, "sk-new-value")
+		})
+
+		Convey("只订阅部分分区的 Webhook 只接收这些分区的变更", func() {
+			newConfig.QuietHours = &types.QuietHoursPolicy{Enabled: true, Start: "22:00", End: "08:00"}
+			So(publisher.PublishConfigChange(ctx, 1, oldConfig, newConfig), ShouldBeNil)
+			So(outbox.events, ShouldHaveLength, 2)
+
+			task, payload := decodeConfigWebhookTask(outbox.events[1])
+			So(task.WebhookID, ShouldEqual, 2)
+			So(payload.Sections, ShouldResemble, []string{"quiet_hours"})
+			for _, change := range payload.Changes {
+				So(change.Section, ShouldEqual, "quiet_hours")
+			}
+		})
+
+		Convey("配置没有变化时不推送", func() {
+			So(publisher.PublishConfigChange(ctx, 1, oldConfig, oldConfig), ShouldBeNil)
+			So(outbox.events, ShouldHaveLength, 0)
+		})
+	})
+}
@@ -0,0 +1,241 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/services/tenant-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/database/gdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// provisioningState 内存开通数据
+type provisioningState struct {
+	tenants        map[uint64]types.Tenant
+	timeoutConfigs map[uint64]types.OrderTimeoutConfig
+	users          map[uint64]types.User
+	userRoles      map[uint64][]types.RoleType
+	nextID         uint64
+}
+
+func (s *provisioningState) clone() *provisioningState {
+	c := &provisioningState{
+		tenants:        map[uint64]types.Tenant{},
+		timeoutConfigs: map[uint64]types.OrderTimeoutConfig{},
+		users:          map[uint64]types.User{},
+		userRoles:      map[uint64][]types.RoleType{},
+		nextID:         s.nextID,
+	}
+	for k, v := range s.tenants {
+		c.tenants[k] = v
+	}
+	for k, v := range s.timeoutConfigs {
+		c.timeoutConfigs[k] = v
+	}
+	for k, v := range s.users {
+		c.users[k] = v
+	}
+	for k, v := range s.userRoles {
+		c.userRoles[k] = append([]types.RoleType(nil), v...)
+	}
+	return c
+}
+
+// fakeProvisioningRepository 内存租户开通仓储，事务失败时恢复到事务开始前的数据
+type fakeProvisioningRepository struct {
+	*provisioningState
+	assignRoleErr error
+	// transactionTenants 每次开通事务所在数据源的租户
+	transactionTenants []uint64
+}
+
+func newFakeProvisioningRepository() *fakeProvisioningRepository {
+	return &fakeProvisioningRepository{provisioningState: (&provisioningState{}).clone()}
+}
+
+func (f *fakeProvisioningRepository) WithTransaction(ctx context.Context, tenantID uint64, fn func(ctx context.Context, tx gdb.TX) error) error {
+	f.transactionTenants = append(f.transactionTenants, tenantID)
+	snapshot := f.provisioningState.clone()
+	if err := fn(ctx, nil); err != nil {
+		f.provisioningState = snapshot
+		return err
+	}
+	return nil
+}
+
+func (f *fakeProvisioningRepository) CreateTenant(ctx context.Context, tenant *types.Tenant) (uint64, error) {
+	f.nextID++
+	tenant.ID = f.nextID
+	f.tenants[tenant.ID] = *tenant
+	return tenant.ID, nil
+}
+
+func (f *fakeProvisioningRepository) LockTenant(ctx context.Context, tenantID uint64) (*types.Tenant, error) {
+	tenant, exists := f.tenants[tenantID]
+	if !exists {
+		return nil, nil
+	}
+	return &tenant, nil
+}
+
+func (f *fakeProvisioningRepository) UpdateTenantConfig(ctx context.Context, tenantID uint64, config string) error {
+	tenant := f.tenants[tenantID]
+	tenant.Config = config
+	f.tenants[tenantID] = tenant
+	return nil
+}
+
+func (f *fakeProvisioningRepository) GetDefaultTimeoutConfig(ctx context.Context, tenantID uint64) (*types.OrderTimeoutConfig, error) {
+	config, exists := f.timeoutConfigs[tenantID]
+	if !exists {
+		return nil, nil
+	}
+	return &config, nil
+}
+
+func (f *fakeProvisioningRepository) CreateTimeoutConfig(ctx context.Context, config *types.OrderTimeoutConfig) error {
+	f.timeoutConfigs[config.TenantID] = *config
+	return nil
+}
+
+func (f *fakeProvisioningRepository) GetUserByUsername(ctx context.Context, tenantID uint64, username string) (*types.User, error) {
+	for _, user := range f.users {
+		if user.TenantID == tenantID && user.Username == username {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeProvisioningRepository) CreateUser(ctx context.Context, user *types.User) (uint64, error) {
+	f.nextID++
+	user.ID = f.nextID
+	f.users[user.ID] = *user
+	return user.ID, nil
+}
+
+func (f *fakeProvisioningRepository) GetUserRoles(ctx context.Context, userID, tenantID uint64) ([]types.RoleType, error) {
+	return f.userRoles[userID], nil
+}
+
+func (f *fakeProvisioningRepository) AssignRole(ctx context.Context, userID, tenantID uint64, roleType types.RoleType, grantedBy uint64) error {
+	if f.assignRoleErr != nil {
+		return f.assignRoleErr
+	}
+	f.userRoles[userID] = append(f.userRoles[userID], roleType)
+	return nil
+}
+
+func TestTenantProvisioningService(t *testing.T) {
+	Convey("租户开通服务测试", t, func() {
+		ctx := context.WithValue(context.Background(), "user_id", uint64(1))
+		repo := newFakeProvisioningRepository()
+		provisioner := service.NewTenantProvisioningServiceForTest(repo)
+
+		newTenant := func() *types.Tenant {
+			return &types.Tenant{Name: "开通测试租户", Code: "provision-test", ContactEmail: "owner@example.com", Status: types.TenantStatusActive}
+		}
+
+		Convey("创建租户时开通所有默认项", func() {
+			result, err := provisioner.CreateAndProvision(ctx, newTenant(), &types.ProvisionTenantRequest{})
+			So(err, ShouldBeNil)
+			So(result.Created, ShouldResemble, []types.TenantProvisionStep{
+				types.TenantProvisionStepConfig,
+				types.TenantProvisionStepTimeoutConfig,
+				types.TenantProvisionStepAdminUser,
+				types.TenantProvisionStepAdminRole,
+			})
+			So(result.Existing, ShouldBeEmpty)
+
+			// 默认配置
+			var config types.TenantConfig
+			So(json.Unmarshal([]byte(repo.tenants[result.TenantID].Config), &config), ShouldBeNil)
+			So(config.MaxUsers, ShouldEqual, 100)
+			So(config.Features, ShouldResemble, []string{"basic"})
+
+			// 默认超时配置
+			timeoutConfig := repo.timeoutConfigs[result.TenantID]
+			So(timeoutConfig.MerchantID, ShouldBeNil)
+			So(timeoutConfig.PaymentTimeoutMinutes, ShouldEqual, 30)
+			So(timeoutConfig.AutoCompleteAfterHours, ShouldEqual, types.DefaultAutoCompleteAfterHours)
+
+			// 初始管理员及租户管理员角色
+			admin := repo.users[result.AdminUserID]
+			So(admin.Username, ShouldEqual, types.DefaultTenantAdminUsername)
+			So(admin.Email, ShouldEqual, "owner@example.com")
+			So(admin.TenantID, ShouldEqual, result.TenantID)
+			So(admin.Status, ShouldEqual, types.UserStatusActive)
+			So(result.InitialPassword, ShouldNotBeEmpty)
+			So(utils.CheckPassword(result.InitialPassword, admin.PasswordHash), ShouldBeTrue)
+			So(repo.userRoles[admin.ID], ShouldResemble, []types.RoleType{types.RoleTenantAdmin})
+			So(repo.transactionTenants, ShouldResemble, []uint64{0})
+		})
+
+		Convey("指定管理员账号时使用指定的用户名和密码", func() {
+			result, err := provisioner.CreateAndProvision(ctx, newTenant(), &types.ProvisionTenantRequest{
+				AdminUsername: "owner",
+				AdminPassword: "owner-secret",
+			})
+			So(err, ShouldBeNil)
+			So(result.AdminUsername, ShouldEqual, "owner")
+			So(result.InitialPassword, ShouldBeEmpty)
+			So(utils.CheckPassword("owner-secret", repo.users[result.AdminUserID].PasswordHash), ShouldBeTrue)
+		})
+
+		Convey("重复开通不会重复创建", func() {
+			created, err := provisioner.CreateAndProvision(ctx, newTenant(), &types.ProvisionTenantRequest{})
+			So(err, ShouldBeNil)
+
+			result, err := provisioner.Provision(ctx, created.TenantID, &types.ProvisionTenantRequest{})
+			So(err, ShouldBeNil)
+			So(result.Created, ShouldBeEmpty)
+			So(len(result.Existing), ShouldEqual, 4)
+			So(result.AdminUserID, ShouldEqual, created.AdminUserID)
+			So(result.InitialPassword, ShouldBeEmpty)
+			So(len(repo.users), ShouldEqual, 1)
+			So(len(repo.userRoles[created.AdminUserID]), ShouldEqual, 1)
+		})
+
+		Convey("修复开通不完整的租户", func() {
+			repo.tenants[10] = types.Tenant{ID: 10, Code: "legacy", ContactEmail: "legacy@example.com", Config: `{"max_users": 5}`}
+			repo.users[11] = types.User{ID: 11, Username: "admin", TenantID: 10}
+
+			result, err := provisioner.Provision(ctx, 10, &types.ProvisionTenantRequest{})
+			So(err, ShouldBeNil)
+			So(result.Created, ShouldResemble, []types.TenantProvisionStep{
+				types.TenantProvisionStepTimeoutConfig,
+				types.TenantProvisionStepAdminRole,
+			})
+			So(result.Existing, ShouldResemble, []types.TenantProvisionStep{
+				types.TenantProvisionStepConfig,
+				types.TenantProvisionStepAdminUser,
+			})
+			So(repo.tenants[10].Config, ShouldEqual, `{"max_users": 5}`)
+			So(repo.userRoles[11], ShouldResemble, []types.RoleType{types.RoleTenantAdmin})
+			So(repo.transactionTenants, ShouldResemble, []uint64{10})
+		})
+
+		Convey("开通失败时整体回滚", func() {
+			repo.assignRoleErr = errors.New("角色分配失败")
+
+			_, err := provisioner.CreateAndProvision(ctx, newTenant(), &types.ProvisionTenantRequest{})
+			So(err, ShouldNotBeNil)
+			So(repo.tenants, ShouldBeEmpty)
+			So(repo.timeoutConfigs, ShouldBeEmpty)
+			So(repo.users, ShouldBeEmpty)
+		})
+
+		Convey("无效的请求被拒绝", func() {
+			_, err := provisioner.Provision(ctx, 99, &types.ProvisionTenantRequest{})
+			So(errors.Is(err, service.ErrTenantNotFound), ShouldBeTrue)
+
+			_, err = provisioner.CreateAndProvision(ctx, newTenant(), &types.ProvisionTenantRequest{AdminPassword: "123"})
+			So(err, ShouldNotBeNil)
+			So(repo.tenants, ShouldBeEmpty)
+		})
+	})
+}
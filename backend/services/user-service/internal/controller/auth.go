@@ -1,12 +1,18 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/gconv"
 	"github.com/gofromzero/mer-sys/backend/services/user-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
 	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/middleware"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
 
@@ -14,6 +20,8 @@ import (
 type AuthController struct {
 	authService *service.AuthService
 	jwtManager  *auth.JWTManager
+	loginGuard  *service.LoginGuard
+	stepUp      *auth.StepUpPolicy
 }
 
 // NewAuthController 创建认证控制器
@@ -21,6 +29,18 @@ func NewAuthController() *AuthController {
 	return &AuthController{
 		authService: service.NewAuthService(),
 		jwtManager:  auth.NewJWTManager(),
+		loginGuard:  service.NewLoginGuard(),
+		stepUp:      auth.LoadStepUpPolicy(context.Background()),
+	}
+}
+
+// NewAuthControllerForTest 创建测试用认证控制器实例
+func NewAuthControllerForTest(authService *service.AuthService, loginGuard *service.LoginGuard) *AuthController {
+	return &AuthController{
+		authService: authService,
+		jwtManager:  auth.NewJWTManagerForTest("test-secret", 24),
+		loginGuard:  loginGuard,
+		stepUp:      auth.NewStepUpPolicy(auth.DefaultStepUpFreshness, nil),
 	}
 }
 
@@ -29,6 +49,8 @@ type LoginRequest struct {
 	Username string `json:"username" v:"required|length:3,50#用户名不能为空|用户名长度为3-50字符"`
 	Password string `json:"password" v:"required|length:6,128#密码不能为空|密码长度为6-128字符"`
 	TenantID uint64 `json:"tenant_id" v:"required|min:1#租户ID不能为空|租户ID必须大于0"`
+
+	CaptchaToken string `json:"captcha_token,omitempty"` // 需要人机验证时提交的验证码令牌
 }
 
 // LoginResponse 登录响应结构
@@ -40,12 +62,41 @@ type LoginResponse struct {
 	User         *types.UserInfo `json:"user"`
 }
 
+// ChangePasswordRequest 修改密码请求结构，使用原密码验证身份，密码过期无法登录时也可使用
+type ChangePasswordRequest struct {
+	Username    string `json:"username" v:"required|length:3,50#用户名不能为空|用户名长度为3-50字符"`
+	TenantID    uint64 `json:"tenant_id" v:"required|min:1#租户ID不能为空|租户ID必须大于0"`
+	OldPassword string `json:"old_password" v:"required#原密码不能为空"`
+	NewPassword string `json:"new_password" v:"required#新密码不能为空"`
+
+	CaptchaToken string `json:"captcha_token,omitempty"` // 需要人机验证时提交的验证码令牌
+}
+
+// UpdateMarketingPreferenceRequest 更新营销信息订阅偏好请求
+type UpdateMarketingPreferenceRequest struct {
+	OptOut bool `json:"opt_out"` // true 表示退订营销信息
+}
+
 // LogoutRequest 登出请求结构
 type LogoutRequest struct {
 	Token        string `json:"token,omitempty"`         // 可选，从Header或Body获取
 	RefreshToken string `json:"refresh_token,omitempty"` // 可选，同时撤销刷新令牌
 }
 
+// ReauthenticateRequest 重新验证身份请求结构
+type ReauthenticateRequest struct {
+	Password  string `json:"password" v:"required#密码不能为空"`
+	Operation string `json:"operation,omitempty"` // 触发重新验证的敏感操作，仅用于审计
+
+	CaptchaToken string `json:"captcha_token,omitempty"` // 需要人机验证时提交的验证码令牌
+}
+
+// ReauthenticateResponse 重新验证身份响应结构
+type ReauthenticateResponse struct {
+	ReauthenticatedAt time.Time `json:"reauthenticated_at"`
+	ExpiresIn         int64     `json:"expires_in"` // 重新验证有效期（秒）
+}
+
 // Login 用户登录
 func (c *AuthController) Login(r *ghttp.Request) {
 	ctx := r.GetCtx()
@@ -60,9 +111,31 @@ func (c *AuthController) Login(r *ghttp.Request) {
 		return
 	}
 
+	// 失败次数过多时要求先完成人机验证
+	clientIP := middleware.ClientIP(r)
+	challenge, err := c.loginGuard.CheckChallenge(ctx, req.TenantID, req.Username, clientIP, req.CaptchaToken)
+	if err != nil {
+		g.Log().Errorf(ctx, "登录人机验证检查失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code": 500,
+			"msg":  "登录失败，请稍后重试",
+			"data": nil,
+		})
+		return
+	}
+	if challenge != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code": 428,
+			"msg":  "请完成人机验证后重新登录",
+			"data": challenge,
+		})
+		return
+	}
+
 	// 验证用户凭证
 	user, userPermissions, err := c.authService.ValidateUserCredentials(ctx, req.Username, req.Password, req.TenantID)
 	if err != nil {
+		c.loginGuard.RecordFailure(ctx, req.TenantID, req.Username, clientIP)
 		g.Log().Errorf(ctx, "登录验证失败 - 用户: %s, 租户: %d, 错误: %v", req.Username, req.TenantID, err)
 		r.Response.WriteJsonExit(g.Map{
 			"code": 401,
@@ -83,24 +156,25 @@ func (c *AuthController) Login(r *ghttp.Request) {
 		return
 	}
 
-	// 生成访问令牌
-	accessToken, err := c.jwtManager.GenerateTokenWithPermissions(ctx, user, userPermissions, "access")
-	if err != nil {
-		g.Log().Errorf(ctx, "生成访问令牌失败: %v", err)
+	// 密码超过租户策略的有效期时不签发令牌，需先修改密码
+	if c.authService.PasswordExpired(ctx, user) {
+		c.loginGuard.Reset(ctx, req.TenantID, req.Username)
+		g.Log().Infof(ctx, "用户密码已过期 - 用户: %s, 租户: %d", req.Username, req.TenantID)
 		r.Response.WriteJsonExit(g.Map{
-			"code": 500,
-			"msg":  "登录失败，请稍后重试",
-			"data": nil,
+			"code": 403,
+			"msg":  "密码已过期，请修改密码后重新登录",
+			"data": g.Map{
+				"password_expired":         true,
+				"password_change_required": true,
+			},
 		})
 		return
 	}
 
-	// 生成刷新令牌
-	refreshToken, err := c.jwtManager.GenerateTokenWithPermissions(ctx, user, userPermissions, "refresh")
+	// 生成访问令牌和刷新令牌（有效期按租户会话策略计算）
+	tokenPair, err := c.jwtManager.GenerateTokenPair(ctx, user, userPermissions)
 	if err != nil {
-		g.Log().Errorf(ctx, "生成刷新令牌失败: %v", err)
-		// 清理已生成的访问令牌
-		c.jwtManager.RevokeToken(ctx, accessToken)
+		g.Log().Errorf(ctx, "生成令牌失败: %v", err)
 		r.Response.WriteJsonExit(g.Map{
 			"code": 500,
 			"msg":  "登录失败，请稍后重试",
@@ -109,6 +183,8 @@ func (c *AuthController) Login(r *ghttp.Request) {
 		return
 	}
 
+	c.loginGuard.Reset(ctx, req.TenantID, req.Username)
+
 	// 更新用户最后登录时间
 	err = c.authService.UpdateLastLoginTime(ctx, user.ID)
 	if err != nil {
@@ -130,13 +206,10 @@ func (c *AuthController) Login(r *ghttp.Request) {
 		Profile:    user.Profile,
 	}
 
-	// 计算访问令牌过期时间（24小时）
-	expiresIn := int64(24 * 60 * 60) // 24小时，单位：秒
-
 	response := LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    expiresIn,
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		ExpiresIn:    tokenPair.ExpiresIn,
 		TokenType:    "Bearer",
 		User:         userInfo,
 	}
@@ -241,7 +314,7 @@ func (c *AuthController) RefreshToken(r *ghttp.Request) {
 	}
 
 	// 使用安全的令牌轮换刷新
-	newAccessToken, newRefreshToken, err := c.jwtManager.RefreshTokenWithRotation(ctx, req.RefreshToken)
+	tokenPair, err := c.jwtManager.RotateTokenPair(ctx, req.RefreshToken)
 	if err != nil {
 		g.Log().Errorf(ctx, "令牌刷新失败: %v", err)
 		r.Response.WriteJsonExit(g.Map{
@@ -252,13 +325,10 @@ func (c *AuthController) RefreshToken(r *ghttp.Request) {
 		return
 	}
 
-	// 计算新的过期时间
-	expiresIn := int64(24 * 60 * 60) // 24小时
-
 	response := g.Map{
-		"access_token":  newAccessToken,
-		"refresh_token": newRefreshToken,
-		"expires_in":    expiresIn,
+		"access_token":  tokenPair.AccessToken,
+		"refresh_token": tokenPair.RefreshToken,
+		"expires_in":    tokenPair.ExpiresIn,
 		"token_type":    "Bearer",
 	}
 
@@ -271,6 +341,174 @@ func (c *AuthController) RefreshToken(r *ghttp.Request) {
 	})
 }
 
+// ChangePassword 修改密码（公开）
+// 使用用户名和原密码验证身份后按租户密码策略设置新密码，不满足策略时返回具体的不满足项
+func (c *AuthController) ChangePassword(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var req ChangePasswordRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code": 400,
+			"msg":  fmt.Sprintf("请求参数错误: %v", err),
+			"data": nil,
+		})
+		return
+	}
+	// 修改密码是公开接口，没有经过租户中间件，按请求中的租户访问用户数据
+	ctx = context.WithValue(ctx, "tenant_id", req.TenantID)
+
+	// 与登录共用失败次数统计，防止借修改密码接口猜测密码
+	clientIP := middleware.ClientIP(r)
+	challenge, err := c.loginGuard.CheckChallenge(ctx, req.TenantID, req.Username, clientIP, req.CaptchaToken)
+	if err != nil {
+		g.Log().Errorf(ctx, "修改密码人机验证检查失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code": 500,
+			"msg":  "修改密码失败，请稍后重试",
+			"data": nil,
+		})
+		return
+	}
+	if challenge != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code": 428,
+			"msg":  "请完成人机验证后重新提交",
+			"data": challenge,
+		})
+		return
+	}
+
+	user, _, err := c.authService.ValidateUserCredentials(ctx, req.Username, req.OldPassword, req.TenantID)
+	if err != nil {
+		c.loginGuard.RecordFailure(ctx, req.TenantID, req.Username, clientIP)
+		g.Log().Warningf(ctx, "修改密码身份验证失败 - 用户: %s, 租户: %d, 错误: %v", req.Username, req.TenantID, err)
+		r.Response.WriteJsonExit(g.Map{
+			"code": 401,
+			"msg":  "用户名、密码或租户信息错误",
+			"data": nil,
+		})
+		return
+	}
+	c.loginGuard.Reset(ctx, req.TenantID, req.Username)
+
+	if err := c.authService.ChangePassword(ctx, user.ID, req.OldPassword, req.NewPassword); err != nil {
+		var policyErr *types.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			r.Response.WriteJsonExit(g.Map{
+				"code": 400,
+				"msg":  policyErr.Error(),
+				"data": g.Map{"violations": policyErr.Violations},
+			})
+			return
+		}
+		g.Log().Errorf(ctx, "修改密码失败 - 用户ID: %d, 错误: %v", user.ID, err)
+		r.Response.WriteJsonExit(g.Map{
+			"code": 500,
+			"msg":  "修改密码失败，请稍后重试",
+			"data": nil,
+		})
+		return
+	}
+
+	audit.LogOperation(ctx, "password", "change", map[string]interface{}{
+		"user_id":   user.ID,
+		"tenant_id": user.TenantID,
+	})
+
+	r.Response.WriteJsonExit(g.Map{
+		"code": 200,
+		"msg":  "密码修改成功，请使用新密码登录",
+		"data": nil,
+	})
+}
+
+// Reauthenticate 重新验证身份（需要认证）
+// 敏感操作要求会话近期重新验证过身份，验证密码后为当前会话记录新的验证时间
+func (c *AuthController) Reauthenticate(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var req ReauthenticateRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code": 400,
+			"msg":  fmt.Sprintf("请求参数错误: %v", err),
+			"data": nil,
+		})
+		return
+	}
+
+	claims, err := c.jwtManager.GetTokenInfo(ctx, r.GetCtxVar("token").String())
+	if err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code": 401,
+			"msg":  "未认证用户",
+			"data": nil,
+		})
+		return
+	}
+
+	// 与登录共用失败次数统计，防止借重新验证接口猜测密码
+	clientIP := middleware.ClientIP(r)
+	challenge, err := c.loginGuard.CheckChallenge(ctx, claims.TenantID, claims.Username, clientIP, req.CaptchaToken)
+	if err != nil {
+		g.Log().Errorf(ctx, "重新验证人机验证检查失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code": 500,
+			"msg":  "重新验证失败，请稍后重试",
+			"data": nil,
+		})
+		return
+	}
+	if challenge != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code": 428,
+			"msg":  "请完成人机验证后重新提交",
+			"data": challenge,
+		})
+		return
+	}
+
+	if err := c.authService.VerifyUserPassword(ctx, claims.UserID, claims.TenantID, req.Password); err != nil {
+		c.loginGuard.RecordFailure(ctx, claims.TenantID, claims.Username, clientIP)
+		g.Log().Warningf(ctx, "重新验证身份失败 - 用户ID: %d, 租户: %d, 错误: %v", claims.UserID, claims.TenantID, err)
+		audit.LogOperation(ctx, "step_up", "failed", map[string]interface{}{
+			"operation": req.Operation,
+		})
+		r.Response.WriteJsonExit(g.Map{
+			"code": 403,
+			"msg":  "密码错误，重新验证失败",
+			"data": nil,
+		})
+		return
+	}
+	c.loginGuard.Reset(ctx, claims.TenantID, claims.Username)
+
+	reauthenticatedAt, err := c.jwtManager.RecordReauthentication(ctx, claims)
+	if err != nil {
+		g.Log().Errorf(ctx, "记录重新验证时间失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code": 500,
+			"msg":  "重新验证失败，请稍后重试",
+			"data": nil,
+		})
+		return
+	}
+
+	audit.LogOperation(ctx, "step_up", "complete", map[string]interface{}{
+		"operation": req.Operation,
+	})
+
+	r.Response.WriteJsonExit(g.Map{
+		"code": 200,
+		"msg":  "重新验证成功",
+		"data": ReauthenticateResponse{
+			ReauthenticatedAt: reauthenticatedAt,
+			ExpiresIn:         int64(c.stepUp.Freshness / time.Second),
+		},
+	})
+}
+
 // GetUserInfo 获取当前用户信息（需要认证）
 func (c *AuthController) GetUserInfo(r *ghttp.Request) {
 	ctx := r.GetCtx()
@@ -328,3 +566,35 @@ func (c *AuthController) GetUserInfo(r *ghttp.Request) {
 		"data": userInfo,
 	})
 }
+
+// UpdateMarketingPreference 更新当前用户的营销信息订阅偏好（需要认证），退订后不会出现在客户分群营销导出中
+func (c *AuthController) UpdateMarketingPreference(r *ghttp.Request) {
+	ctx := r.GetCtx()
+
+	var req UpdateMarketingPreferenceRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code": 400,
+			"msg":  fmt.Sprintf("请求参数错误: %v", err),
+			"data": nil,
+		})
+		return
+	}
+
+	userID := gconv.Uint64(ctx.Value("user_id"))
+	if err := c.authService.UpdateMarketingPreference(ctx, userID, req.OptOut); err != nil {
+		g.Log().Errorf(ctx, "更新营销订阅偏好失败 - 用户ID: %d, 错误: %v", userID, err)
+		r.Response.WriteJsonExit(g.Map{
+			"code": 500,
+			"msg":  "更新营销订阅偏好失败，请稍后重试",
+			"data": nil,
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code": 200,
+		"msg":  "营销订阅偏好已更新",
+		"data": g.Map{"marketing_opt_out": req.OptOut},
+	})
+}
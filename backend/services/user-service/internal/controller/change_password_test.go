@@ -0,0 +1,256 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/services/user-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/guid"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// changePasswordStore 内存用户表：只有租户 1 的一个用户，记录密码更新语句
+type changePasswordStore struct {
+	mu           sync.Mutex
+	passwordHash string
+	updates      int
+}
+
+var testChangePasswordStore = &changePasswordStore{}
+
+func (s *changePasswordStore) reset(passwordHash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.passwordHash, s.updates = passwordHash, 0
+}
+
+// changePasswordConn 内存用户表连接
+type changePasswordConn struct{}
+
+func (c *changePasswordConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *changePasswordConn) Close() error { return nil }
+
+func (c *changePasswordConn) Begin() (driver.Tx, error) { return nil, errors.New("transaction not supported") }
+
+func (c *changePasswordConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	s := testChangePasswordStore
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !strings.HasPrefix(query, "UPDATE `users`") {
+		return nil, fmt.Errorf("unexpected statement: %s", query)
+	}
+	// UPDATE `users` SET `password_hash`=?,... WHERE ...
+	s.passwordHash = fmt.Sprint(args[0].Value)
+	s.updates++
+	return driver.RowsAffected(1), nil
+}
+
+func (c *changePasswordConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	s := testChangePasswordStore
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.Contains(query, "`user_roles`") {
+		return &changePasswordRows{columns: []string{"role_type"}}, nil
+	}
+	return &changePasswordRows{
+		columns: []string{"id", "tenant_id", "username", "password_hash", "status"},
+		rows:    [][]driver.Value{{int64(1), int64(1), "alice", s.passwordHash, string(types.UserStatusActive)}},
+	}, nil
+}
+
+// changePasswordRows 用户查询结果
+type changePasswordRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *changePasswordRows) Columns() []string { return r.columns }
+
+func (r *changePasswordRows) Close() error { return nil }
+
+func (r *changePasswordRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type changePasswordSQLDriver struct{}
+
+func (changePasswordSQLDriver) Open(name string) (driver.Conn, error) {
+	return &changePasswordConn{}, nil
+}
+
+// changePasswordDBDriver gdb 驱动，连接到内存用户表
+type changePasswordDBDriver struct {
+	*gdb.Core
+}
+
+func (d *changePasswordDBDriver) New(core *gdb.Core, node *gdb.ConfigNode) (gdb.DB, error) {
+	return &changePasswordDBDriver{Core: core}, nil
+}
+
+func (d *changePasswordDBDriver) Open(config *gdb.ConfigNode) (*sql.DB, error) {
+	return sql.Open("change_password", config.Name)
+}
+
+func (d *changePasswordDBDriver) GetChars() (string, string) { return "`", "`" }
+
+func (d *changePasswordDBDriver) Tables(ctx context.Context, schema ...string) ([]string, error) {
+	return []string{"users", "user_roles"}, nil
+}
+
+func (d *changePasswordDBDriver) TableFields(ctx context.Context, table string, schema ...string) (map[string]*gdb.TableField, error) {
+	if table == "user_roles" {
+		return map[string]*gdb.TableField{
+			"user_id":   {Index: 0, Name: "user_id", Type: "bigint unsigned"},
+			"tenant_id": {Index: 1, Name: "tenant_id", Type: "bigint unsigned"},
+			"role_type": {Index: 2, Name: "role_type", Type: "varchar(32)"},
+		}, nil
+	}
+	return map[string]*gdb.TableField{
+		"id":                  {Index: 0, Name: "id", Type: "bigint unsigned"},
+		"tenant_id":           {Index: 1, Name: "tenant_id", Type: "bigint unsigned"},
+		"username":            {Index: 2, Name: "username", Type: "varchar(50)"},
+		"password_hash":       {Index: 3, Name: "password_hash", Type: "varchar(255)"},
+		"status":              {Index: 4, Name: "status", Type: "varchar(32)"},
+		"password_changed_at": {Index: 5, Name: "password_changed_at", Type: "datetime"},
+		"updated_at":          {Index: 6, Name: "updated_at", Type: "datetime"},
+	}, nil
+}
+
+var registerChangePasswordDriverOnce sync.Once
+
+// fakeTenantRepository 只返回未配置验证码和密码策略的租户
+type fakeTenantRepository struct {
+	repository.ITenantRepository
+}
+
+func (f *fakeTenantRepository) GetByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	return &types.Tenant{ID: id}, nil
+}
+
+// fakePasswordHistoryRepository 空密码历史仓储
+type fakePasswordHistoryRepository struct{}
+
+func (f *fakePasswordHistoryRepository) Create(ctx context.Context, history *types.PasswordHistory) error {
+	return nil
+}
+
+func (f *fakePasswordHistoryRepository) ListRecent(ctx context.Context, userID uint64, limit int) ([]types.PasswordHistory, error) {
+	return nil, nil
+}
+
+func (f *fakePasswordHistoryRepository) Prune(ctx context.Context, userID uint64, keep int) error {
+	return nil
+}
+
+// startChangePasswordDatasource 注册内存用户表所在的数据库分组，并将租户 1 路由到该分组
+func startChangePasswordDatasource() {
+	registerChangePasswordDriverOnce.Do(func() {
+		sql.Register("change_password", changePasswordSQLDriver{})
+		if err := gdb.Register("change_password", &changePasswordDBDriver{}); err != nil {
+			panic(err)
+		}
+		if err := gdb.AddConfigNode("change_password", gdb.ConfigNode{Type: "change_password", Name: "change_password"}); err != nil {
+			panic(err)
+		}
+	})
+	repository.SetTenantDatasourceConfig(&repository.TenantDatasourceConfig{Tenants: map[uint64]string{1: "change_password"}})
+}
+
+// startChangePasswordServer 按 main.go 的公开路由挂载修改密码接口，租户 1 的用户数据路由到内存用户表
+func startChangePasswordServer() (*ghttp.Server, string) {
+	startChangePasswordDatasource()
+
+	tenantRepo := &fakeTenantRepository{}
+	authService := service.NewAuthServiceForTest(
+		repository.NewUserRepository(),
+		service.NewPasswordPolicyServiceForTest(tenantRepo, &fakePasswordHistoryRepository{}, time.Now),
+	)
+	controller := NewAuthControllerForTest(authService, service.NewLoginGuardForTest(tenantRepo, map[string]auth.CaptchaProvider{}))
+
+	s := g.Server(guid.S())
+	s.Group("/api/v1/auth", func(group *ghttp.RouterGroup) {
+		group.POST("/change-password", controller.ChangePassword)
+	})
+	s.SetDumpRouterMap(false)
+	if err := s.Start(); err != nil {
+		panic(err)
+	}
+	return s, fmt.Sprintf("http://127.0.0.1:%d/api/v1/auth/change-password", s.GetListenedPort())
+}
+
+// doChangePassword 不携带访问令牌提交修改密码请求
+func doChangePassword(url string, req ChangePasswordRequest) utils.APIResponse {
+	payload, err := json.Marshal(req)
+	So(err, ShouldBeNil)
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(payload))
+	So(err, ShouldBeNil)
+	defer resp.Body.Close()
+
+	var body utils.APIResponse
+	So(json.NewDecoder(resp.Body).Decode(&body), ShouldBeNil)
+	return body
+}
+
+func TestAuthController_ChangePassword(t *testing.T) {
+	Convey("修改密码接口测试", t, func() {
+		s, url := startChangePasswordServer()
+		defer s.Shutdown()
+		defer repository.SetTenantDatasourceConfig(nil)
+
+		oldHash, err := utils.HashPassword("OldPassw0rd!")
+		So(err, ShouldBeNil)
+		testChangePasswordStore.reset(oldHash)
+
+		Convey("没有访问令牌时按请求中的租户修改密码", func() {
+			body := doChangePassword(url, ChangePasswordRequest{
+				Username:    "alice",
+				TenantID:    1,
+				OldPassword: "OldPassw0rd!",
+				NewPassword: "NewPassw0rd!2024",
+			})
+			So(body.Code, ShouldEqual, 200)
+			So(testChangePasswordStore.updates, ShouldEqual, 1)
+			So(utils.CheckPassword("NewPassw0rd!2024", testChangePasswordStore.passwordHash), ShouldBeTrue)
+		})
+
+		Convey("原密码错误时拒绝修改", func() {
+			body := doChangePassword(url, ChangePasswordRequest{
+				Username:    "alice",
+				TenantID:    1,
+				OldPassword: "WrongPassw0rd!",
+				NewPassword: "NewPassw0rd!2024",
+			})
+			So(body.Code, ShouldEqual, 401)
+			So(testChangePasswordStore.updates, ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"github.com/gofromzero/mer-sys/backend/services/user-service/internal/service"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// CustomerAnonymizationController 客户个人信息匿名化控制器
+type CustomerAnonymizationController struct {
+	anonymizationService *service.CustomerAnonymizationService
+}
+
+// NewCustomerAnonymizationController 创建客户个人信息匿名化控制器
+func NewCustomerAnonymizationController(anonymizationService *service.CustomerAnonymizationService) *CustomerAnonymizationController {
+	return &CustomerAnonymizationController{
+		anonymizationService: anonymizationService,
+	}
+}
+
+// AnonymizeUser 匿名化客户个人信息，操作不可撤销
+func (c *CustomerAnonymizationController) AnonymizeUser(r *ghttp.Request) {
+	ctx := r.GetCtx()
+	userID := r.Get("id").Uint64()
+	if userID == 0 {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": "无效的用户ID",
+			"data":    nil,
+		})
+		return
+	}
+
+	result, err := c.anonymizationService.Anonymize(ctx, userID)
+	if err != nil {
+		g.Log().Errorf(ctx, "匿名化客户失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "客户个人信息已匿名化",
+		"data":    result,
+	})
+}
@@ -1,11 +1,15 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strconv"
 
+	"github.com/gofromzero/mer-sys/backend/services/user-service/internal/service"
 	"github.com/gofromzero/mer-sys/backend/shared/audit"
 	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/quota"
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gofromzero/mer-sys/backend/shared/utils"
@@ -17,13 +21,17 @@ import (
 
 // MerchantUserController 商户用户控制器
 type MerchantUserController struct {
-	userRepo *repository.UserRepository
+	userRepo       *repository.UserRepository
+	passwordPolicy *service.PasswordPolicyService
+	quotaGuard     *quota.Guard
 }
 
 // NewMerchantUserController 创建商户用户控制器
 func NewMerchantUserController() *MerchantUserController {
 	return &MerchantUserController{
-		userRepo: repository.NewUserRepository(),
+		userRepo:       repository.NewUserRepository(),
+		passwordPolicy: service.NewPasswordPolicyService(),
+		quotaGuard:     quota.NewGuard(),
 	}
 }
 
@@ -40,6 +48,22 @@ func (c *MerchantUserController) CreateMerchantUser(r *ghttp.Request) {
 	}
 
 	ctx := r.GetCtx()
+
+	// 校验租户密码策略，返回具体的不满足项
+	if err := c.passwordPolicy.Validate(ctx, repository.GetTenantIDFromContext(ctx), nil, req.Password); err != nil {
+		writePasswordPolicyError(r, err)
+		return
+	}
+
+	// 租户用户数达到配额时拒绝创建
+	if _, err := c.quotaGuard.Check(ctx, repository.GetTenantIDFromContext(ctx), types.TenantQuotaUsers, 1); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": quotaErrorMessage(ctx, err),
+			"data":    nil,
+		})
+		return
+	}
 	
 	// 生成UUID
 	uuid := grand.S(32)
@@ -80,6 +104,7 @@ func (c *MerchantUserController) CreateMerchantUser(r *ghttp.Request) {
 		})
 		return
 	}
+	c.passwordPolicy.Record(ctx, user.TenantID, user.ID, passwordHash)
 
 	// 记录审计日志
 	operatorUserID := uint64(0)
@@ -463,8 +488,8 @@ func (c *MerchantUserController) ResetMerchantUserPassword(r *ghttp.Request) {
 
 	ctx := r.GetCtx()
 
-	// 生成随机密码（8位）
-	newPassword, err := utils.GenerateRandomPassword(8)
+	// 生成满足租户密码策略的随机密码（至少8位）
+	newPassword, err := c.passwordPolicy.Generate(ctx, repository.GetTenantIDFromContext(ctx))
 	if err != nil {
 		g.Log().Errorf(ctx, "生成随机密码失败: %v", err)
 		r.Response.WriteJsonExit(g.Map{
@@ -510,6 +535,8 @@ func (c *MerchantUserController) ResetMerchantUserPassword(r *ghttp.Request) {
 		return
 	}
 
+	c.passwordPolicy.Record(ctx, targetUser.TenantID, userID, passwordHash)
+
 	// 记录敏感操作审计日志
 	operatorUserID := uint64(0)
 	tenantID := targetUser.TenantID
@@ -555,6 +582,27 @@ func (c *MerchantUserController) BatchCreateMerchantUsers(r *ghttp.Request) {
 	// 逐个创建用户
 	for i, userReq := range req.Users {
 		userReq.MerchantID = req.MerchantID
+
+		if err := c.passwordPolicy.Validate(ctx, repository.GetTenantIDFromContext(ctx), nil, userReq.Password); err != nil {
+			failedUsers = append(failedUsers, g.Map{
+				"index":      i + 1,
+				"username":   userReq.Username,
+				"email":      userReq.Email,
+				"error":      err.Error(),
+				"violations": passwordViolations(err),
+			})
+			continue
+		}
+
+		if _, err := c.quotaGuard.Check(ctx, repository.GetTenantIDFromContext(ctx), types.TenantQuotaUsers, 1); err != nil {
+			failedUsers = append(failedUsers, g.Map{
+				"index":    i + 1,
+				"username": userReq.Username,
+				"email":    userReq.Email,
+				"error":    quotaErrorMessage(ctx, err),
+			})
+			continue
+		}
 		
 		// 生成UUID
 		uuid := grand.S(32)
@@ -596,6 +644,7 @@ func (c *MerchantUserController) BatchCreateMerchantUsers(r *ghttp.Request) {
 				"error":    err.Error(),
 			})
 		} else {
+			c.passwordPolicy.Record(ctx, user.TenantID, user.ID, passwordHash)
 			successCount++
 		}
 	}
@@ -756,4 +805,31 @@ func (c *MerchantUserController) GetMerchantUserOperationHistory(r *ghttp.Reques
 			"total_pages": (total + pageSize - 1) / pageSize,
 		},
 	})
-}
\ No newline at end of file
+}
+
+// writePasswordPolicyError 返回密码策略校验失败的响应，不满足策略时附带具体的不满足项
+func writePasswordPolicyError(r *ghttp.Request, err error) {
+	r.Response.WriteJsonExit(g.Map{
+		"code":    1,
+		"message": err.Error(),
+		"data":    g.Map{"violations": passwordViolations(err)},
+	})
+}
+
+// passwordViolations 提取密码策略的不满足项，其他错误返回nil
+func passwordViolations(err error) []types.PasswordViolation {
+	var policyErr *types.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return policyErr.Violations
+	}
+	return nil
+}
+
+// quotaErrorMessage 配额检查失败时返回给调用方的提示，配额已用完时说明用量
+func quotaErrorMessage(ctx context.Context, err error) string {
+	if errors.Is(err, types.ErrTenantQuotaExceeded) {
+		return err.Error()
+	}
+	g.Log().Errorf(ctx, "检查租户配额失败: %v", err)
+	return "检查租户配额失败"
+}
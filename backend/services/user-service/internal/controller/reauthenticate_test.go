@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/services/user-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/guid"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// captchaTenantRepository 返回启用登录验证码策略的租户，失败两次后要求人机验证
+type captchaTenantRepository struct {
+	repository.ITenantRepository
+}
+
+func (f *captchaTenantRepository) GetByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	config, err := json.Marshal(types.TenantConfig{Captcha: &types.CaptchaPolicy{
+		Enabled:           true,
+		Provider:          types.CaptchaProviderLocal,
+		IPThreshold:       2,
+		UsernameThreshold: 2,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return &types.Tenant{ID: id, Config: string(config)}, nil
+}
+
+// startReauthenticateServer 挂载重新验证身份接口，用请求头中的访问令牌代替认证中间件，租户 1 的用户数据路由到内存用户表
+func startReauthenticateServer() (*ghttp.Server, string, *AuthController) {
+	startChangePasswordDatasource()
+
+	tenantRepo := &captchaTenantRepository{}
+	authService := service.NewAuthServiceForTest(
+		repository.NewUserRepository(),
+		service.NewPasswordPolicyServiceForTest(tenantRepo, &fakePasswordHistoryRepository{}, time.Now),
+	)
+	controller := NewAuthControllerForTest(authService, service.NewLoginGuardForTest(tenantRepo, map[string]auth.CaptchaProvider{
+		types.CaptchaProviderLocal: auth.NewLocalCaptchaProviderForTest(),
+	}))
+
+	s := g.Server(guid.S())
+	s.Group("/api/v1/auth", func(group *ghttp.RouterGroup) {
+		group.Middleware(func(r *ghttp.Request) {
+			r.SetCtxVar("tenant_id", uint64(1))
+			r.SetCtxVar("token", r.Header.Get("X-Test-Token"))
+			r.Middleware.Next()
+		})
+		group.POST("/reauthenticate", controller.Reauthenticate)
+	})
+	s.SetDumpRouterMap(false)
+	if err := s.Start(); err != nil {
+		panic(err)
+	}
+	return s, fmt.Sprintf("http://127.0.0.1:%d/api/v1/auth/reauthenticate", s.GetListenedPort()), controller
+}
+
+// doReauthenticate 携带访问令牌提交重新验证请求
+func doReauthenticate(url, token, password string) utils.APIResponse {
+	payload, err := json.Marshal(ReauthenticateRequest{Password: password})
+	So(err, ShouldBeNil)
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	So(err, ShouldBeNil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-Token", token)
+
+	resp, err := http.DefaultClient.Do(req)
+	So(err, ShouldBeNil)
+	defer resp.Body.Close()
+
+	var body utils.APIResponse
+	So(json.NewDecoder(resp.Body).Decode(&body), ShouldBeNil)
+	return body
+}
+
+func TestAuthController_Reauthenticate(t *testing.T) {
+	Convey("重新验证身份接口测试", t, func() {
+		s, url, controller := startReauthenticateServer()
+		defer s.Shutdown()
+		defer repository.SetTenantDatasourceConfig(nil)
+
+		passwordHash, err := utils.HashPassword("Passw0rd!")
+		So(err, ShouldBeNil)
+		testChangePasswordStore.reset(passwordHash)
+
+		user := &types.User{ID: 1, TenantID: 1, Username: "alice", Email: "alice@example.com"}
+		pair, err := controller.jwtManager.GenerateTokenPair(context.Background(), user, &types.UserPermissions{UserID: 1, TenantID: 1})
+		So(err, ShouldBeNil)
+
+		Convey("密码正确时重新验证成功", func() {
+			So(doReauthenticate(url, pair.AccessToken, "Passw0rd!").Code, ShouldEqual, 200)
+		})
+
+		Convey("密码错误次数达到阈值后要求人机验证，正确密码也不能绕过", func() {
+			So(doReauthenticate(url, pair.AccessToken, "WrongPassw0rd!").Code, ShouldEqual, 403)
+			So(doReauthenticate(url, pair.AccessToken, "WrongPassw0rd!").Code, ShouldEqual, 403)
+
+			body := doReauthenticate(url, pair.AccessToken, "Passw0rd!")
+			So(body.Code, ShouldEqual, 428)
+			So(body.Data, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"github.com/gofromzero/mer-sys/backend/services/user-service/internal/service"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// RoleController 角色权限管理控制器
+type RoleController struct {
+	roleService *service.RoleService
+}
+
+// NewRoleController 创建角色权限管理控制器
+func NewRoleController() *RoleController {
+	return &RoleController{
+		roleService: service.NewRoleService(),
+	}
+}
+
+// ListRoles 获取角色列表（包含系统内置角色）
+func (c *RoleController) ListRoles(r *ghttp.Request) {
+	ctx := r.GetCtx()
+	tenantID := r.GetCtxVar("tenant_id").Uint64()
+
+	roles, err := c.roleService.ListRoles(ctx, tenantID)
+	if err != nil {
+		g.Log().Errorf(ctx, "查询角色列表失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": "查询角色列表失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "查询成功",
+		"data":    roles,
+	})
+}
+
+// CreateRole 创建自定义角色
+func (c *RoleController) CreateRole(r *ghttp.Request) {
+	var req types.CreateRoleRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": "请求参数解析失败: " + err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	ctx := r.GetCtx()
+	tenantID := r.GetCtxVar("tenant_id").Uint64()
+
+	role, err := c.roleService.CreateRole(ctx, tenantID, &req)
+	if err != nil {
+		g.Log().Errorf(ctx, "创建角色失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "创建角色成功",
+		"data":    role,
+	})
+}
+
+// GrantPermissions 为角色授予权限
+func (c *RoleController) GrantPermissions(r *ghttp.Request) {
+	roleType := types.RoleType(r.Get("role").String())
+
+	var req types.RolePermissionsRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": "请求参数解析失败: " + err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	ctx := r.GetCtx()
+	tenantID := r.GetCtxVar("tenant_id").Uint64()
+
+	if err := c.roleService.GrantPermissions(ctx, tenantID, roleType, req.Permissions); err != nil {
+		g.Log().Errorf(ctx, "授予角色权限失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "授予权限成功",
+		"data":    nil,
+	})
+}
+
+// RevokePermissions 撤销角色权限
+func (c *RoleController) RevokePermissions(r *ghttp.Request) {
+	roleType := types.RoleType(r.Get("role").String())
+
+	var req types.RolePermissionsRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": "请求参数解析失败: " + err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	ctx := r.GetCtx()
+	tenantID := r.GetCtxVar("tenant_id").Uint64()
+
+	if err := c.roleService.RevokePermissions(ctx, tenantID, roleType, req.Permissions); err != nil {
+		g.Log().Errorf(ctx, "撤销角色权限失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "撤销权限成功",
+		"data":    nil,
+	})
+}
+
+// AssignUserRole 为用户分配角色
+func (c *RoleController) AssignUserRole(r *ghttp.Request) {
+	roleType := types.RoleType(r.Get("role").String())
+
+	var req types.AssignUserRoleRequest
+	if err := r.Parse(&req); err != nil {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": "请求参数解析失败: " + err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	ctx := r.GetCtx()
+	tenantID := r.GetCtxVar("tenant_id").Uint64()
+	operatorID := r.GetCtxVar("user_id").Uint64()
+
+	if err := c.roleService.AssignRoleToUser(ctx, tenantID, req.UserID, roleType, operatorID); err != nil {
+		g.Log().Errorf(ctx, "分配用户角色失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "分配角色成功",
+		"data":    nil,
+	})
+}
+
+// RevokeUserRole 撤销用户角色
+func (c *RoleController) RevokeUserRole(r *ghttp.Request) {
+	roleType := types.RoleType(r.Get("role").String())
+	userID := r.Get("user_id").Uint64()
+	if userID == 0 {
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": "用户ID不能为空",
+			"data":    nil,
+		})
+		return
+	}
+
+	ctx := r.GetCtx()
+	tenantID := r.GetCtxVar("tenant_id").Uint64()
+
+	if err := c.roleService.RevokeRoleFromUser(ctx, tenantID, userID, roleType); err != nil {
+		g.Log().Errorf(ctx, "撤销用户角色失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "撤销角色成功",
+		"data":    nil,
+	})
+}
+
+// GetCurrentUserPermissions 获取当前用户的有效权限
+func (c *RoleController) GetCurrentUserPermissions(r *ghttp.Request) {
+	ctx := r.GetCtx()
+	userID := r.GetCtxVar("user_id").Uint64()
+	tenantID := r.GetCtxVar("tenant_id").Uint64()
+
+	var merchantID *uint64
+	if id := r.GetCtxVar("merchant_id").Uint64(); id > 0 {
+		merchantID = &id
+	}
+
+	permissions, err := c.roleService.GetEffectivePermissions(ctx, userID, tenantID, merchantID)
+	if err != nil {
+		g.Log().Errorf(ctx, "获取用户权限失败: %v", err)
+		r.Response.WriteJsonExit(g.Map{
+			"code":    1,
+			"message": "获取用户权限失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	r.Response.WriteJsonExit(g.Map{
+		"code":    0,
+		"message": "查询成功",
+		"data":    permissions,
+	})
+}
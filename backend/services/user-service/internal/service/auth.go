@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/gofromzero/mer-sys/backend/shared/quota"
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/crypto/gmd5"
@@ -14,13 +15,25 @@ import (
 
 // AuthService 认证业务逻辑服务
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo       *repository.UserRepository
+	passwordPolicy *PasswordPolicyService
+	quotaGuard     *quota.Guard
 }
 
 // NewAuthService 创建认证服务
 func NewAuthService() *AuthService {
 	return &AuthService{
-		userRepo: repository.NewUserRepository(),
+		userRepo:       repository.NewUserRepository(),
+		passwordPolicy: NewPasswordPolicyService(),
+		quotaGuard:     quota.NewGuard(),
+	}
+}
+
+// NewAuthServiceForTest 创建测试用认证服务实例
+func NewAuthServiceForTest(userRepo *repository.UserRepository, passwordPolicy *PasswordPolicyService) *AuthService {
+	return &AuthService{
+		userRepo:       userRepo,
+		passwordPolicy: passwordPolicy,
 	}
 }
 
@@ -128,8 +141,35 @@ func (s *AuthService) GetUserByID(ctx context.Context, userID, tenantID uint64)
 	return s.userRepo.FindByIDAndTenant(ctx, userID, tenantID)
 }
 
+// VerifyUserPassword 重新验证当前用户的密码（敏感操作前的二次验证）
+func (s *AuthService) VerifyUserPassword(ctx context.Context, userID, tenantID uint64, password string) error {
+	user, err := s.userRepo.FindByIDAndTenant(ctx, userID, tenantID)
+	if err != nil {
+		return fmt.Errorf("用户不存在: %v", err)
+	}
+
+	if user.Status != types.UserStatusActive {
+		return fmt.Errorf("用户账户状态异常: %s", user.Status)
+	}
+
+	if !s.verifyPassword(password, user.PasswordHash) {
+		return fmt.Errorf("密码错误")
+	}
+	return nil
+}
+
 // CreateUser 创建用户（用于注册等场景）
 func (s *AuthService) CreateUser(ctx context.Context, user *types.User, password string) error {
+	// 校验租户密码策略
+	if err := s.passwordPolicy.Validate(ctx, user.TenantID, nil, password); err != nil {
+		return err
+	}
+
+	// 租户用户数达到配额时拒绝创建
+	if _, err := s.quotaGuard.Check(ctx, user.TenantID, types.TenantQuotaUsers, 1); err != nil {
+		return err
+	}
+
 	// 加密密码
 	hashedPassword, err := s.HashPassword(password)
 	if err != nil {
@@ -153,9 +193,14 @@ func (s *AuthService) CreateUser(ctx context.Context, user *types.User, password
 	now := gtime.Now().Time
 	user.CreatedAt = now
 	user.UpdatedAt = now
+	user.PasswordChangedAt = &now
 
 	// 保存用户
-	return s.userRepo.Create(ctx, user)
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return err
+	}
+	s.passwordPolicy.Record(ctx, user.TenantID, user.ID, hashedPassword)
+	return nil
 }
 
 // generateUserUUID 生成用户UUID
@@ -177,26 +222,42 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID uint64, oldPass
 		return fmt.Errorf("原密码错误")
 	}
 
-	// 加密新密码
-	newPasswordHash, err := s.HashPassword(newPassword)
+	return s.setPassword(ctx, user, newPassword)
+}
+
+// ResetPassword 重置密码（管理员功能）
+func (s *AuthService) ResetPassword(ctx context.Context, userID uint64, newPassword string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("新密码加密失败: %v", err)
+		return fmt.Errorf("用户不存在: %v", err)
 	}
 
-	// 更新密码
-	return s.userRepo.UpdatePassword(ctx, userID, newPasswordHash)
+	return s.setPassword(ctx, user, newPassword)
 }
 
-// ResetPassword 重置密码（管理员功能）
-func (s *AuthService) ResetPassword(ctx context.Context, userID uint64, newPassword string) error {
+// setPassword 按租户密码策略校验新密码（包括密码历史）后更新密码并记录密码历史
+func (s *AuthService) setPassword(ctx context.Context, user *types.User, newPassword string) error {
+	if err := s.passwordPolicy.Validate(ctx, user.TenantID, user, newPassword); err != nil {
+		return err
+	}
+
 	// 加密新密码
 	newPasswordHash, err := s.HashPassword(newPassword)
 	if err != nil {
-		return fmt.Errorf("密码加密失败: %v", err)
+		return fmt.Errorf("新密码加密失败: %v", err)
 	}
 
 	// 更新密码
-	return s.userRepo.UpdatePassword(ctx, userID, newPasswordHash)
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, newPasswordHash); err != nil {
+		return err
+	}
+	s.passwordPolicy.Record(ctx, user.TenantID, user.ID, newPasswordHash)
+	return nil
+}
+
+// PasswordExpired 用户密码是否已超过租户密码策略的最长有效天数
+func (s *AuthService) PasswordExpired(ctx context.Context, user *types.User) bool {
+	return s.passwordPolicy.Expired(ctx, user)
 }
 
 // DeactivateUser 停用用户
@@ -214,6 +275,11 @@ func (s *AuthService) SuspendUser(ctx context.Context, userID uint64) error {
 	return s.userRepo.UpdateStatus(ctx, userID, types.UserStatusSuspended)
 }
 
+// UpdateMarketingPreference 更新用户的营销信息订阅偏好，退订后不会出现在客户分群营销导出中
+func (s *AuthService) UpdateMarketingPreference(ctx context.Context, userID uint64, optOut bool) error {
+	return s.userRepo.UpdateMarketingOptOut(ctx, userID, optOut)
+}
+
 // ValidatePassword 验证密码强度
 func (s *AuthService) ValidatePassword(password string) error {
 	if len(password) < 6 {
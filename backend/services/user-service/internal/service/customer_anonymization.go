@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	// 默认的保留期检查频率
+	defaultRetentionSweepInterval = 24 * time.Hour
+	// 每个租户每次最多匿名化的客户数，未处理完的在下次检查时继续
+	retentionSweepBatchSize = 200
+	// 每次查询的租户数
+	retentionSweepTenantPageSize = 100
+)
+
+// CustomerAnonymizationService 客户个人信息匿名化服务：按需匿名化指定客户，
+// 并由定时任务按租户保留策略匿名化长期未活跃的客户。订单金额和数量保留，用于财务统计
+type CustomerAnonymizationService struct {
+	repo       repository.ICustomerAnonymizationRepository
+	tenantRepo repository.ITenantRepository
+	policies   repository.DataRetentionPolicyProvider
+	interval   time.Duration
+	now        func() time.Time
+	stopCh     chan struct{}
+	isRunning  bool
+}
+
+// NewCustomerAnonymizationService 创建客户个人信息匿名化服务实例
+func NewCustomerAnonymizationService() *CustomerAnonymizationService {
+	interval := g.Cfg().MustGet(context.Background(), "dataRetention.checkInterval", defaultRetentionSweepInterval).Duration()
+	if interval <= 0 {
+		interval = defaultRetentionSweepInterval
+	}
+	tenantRepo := repository.NewTenantRepository()
+	return &CustomerAnonymizationService{
+		repo:       repository.NewCustomerAnonymizationRepository(),
+		tenantRepo: tenantRepo,
+		policies:   repository.NewTenantDataRetentionPolicyProvider(tenantRepo),
+		interval:   interval,
+		now:        time.Now,
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// NewCustomerAnonymizationServiceForTest 创建测试用客户个人信息匿名化服务实例
+func NewCustomerAnonymizationServiceForTest(repo repository.ICustomerAnonymizationRepository, tenantRepo repository.ITenantRepository, now func() time.Time) *CustomerAnonymizationService {
+	return &CustomerAnonymizationService{
+		repo:       repo,
+		tenantRepo: tenantRepo,
+		policies:   repository.NewTenantDataRetentionPolicyProvider(tenantRepo),
+		interval:   defaultRetentionSweepInterval,
+		now:        now,
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// Anonymize 匿名化当前租户的客户账户，员工和商户用户不能通过此操作匿名化
+func (s *CustomerAnonymizationService) Anonymize(ctx context.Context, userID uint64) (*types.CustomerAnonymization, error) {
+	user, isCustomer, err := s.repo.GetCustomer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("用户不存在: %d", userID)
+	}
+	if user.AnonymizedAt != nil {
+		return nil, types.ErrCustomerAlreadyAnonymized
+	}
+	if !isCustomer {
+		return nil, types.ErrAnonymizeNonCustomer
+	}
+
+	return s.anonymize(ctx, user, "request")
+}
+
+// anonymize 生成随机令牌替换客户个人信息并记录审计日志，trigger 为 request（按需）或 retention（保留期到期）
+func (s *CustomerAnonymizationService) anonymize(ctx context.Context, user *types.User, trigger string) (*types.CustomerAnonymization, error) {
+	token, err := types.NewAnonymizationToken()
+	if err != nil {
+		return nil, err
+	}
+	anonymization := types.NewCustomerAnonymization(user, token, s.now())
+	if err := s.repo.Anonymize(ctx, anonymization); err != nil {
+		return nil, err
+	}
+
+	// 审计日志只记录用户ID和令牌，不记录原始个人信息
+	audit.LogOperation(ctx, "customer", "anonymize", map[string]interface{}{
+		"user_id":            anonymization.UserID,
+		"token":              anonymization.Token,
+		"trigger":            trigger,
+		"notes_scrubbed":     anonymization.NotesScrubbed,
+		"disputes_scrubbed":  anonymization.DisputesScrubbed,
+		"history_scrubbed":   anonymization.HistoryScrubbed,
+		"orders_scrubbed":    anonymization.OrdersScrubbed,
+		"addresses_scrubbed": anonymization.AddressesScrubbed,
+		"retained_orders":    anonymization.RetainedOrders,
+	})
+	return anonymization, nil
+}
+
+// Start 启动后台保留期检查
+func (s *CustomerAnonymizationService) Start(ctx context.Context) {
+	if s.isRunning {
+		return
+	}
+	s.isRunning = true
+	g.Log().Info(ctx, "启动客户个人信息保留期检查", "interval", s.interval)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+				if _, err := s.SweepExpired(ctx); err != nil {
+					g.Log().Error(ctx, "客户个人信息保留期检查失败", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止后台保留期检查
+func (s *CustomerAnonymizationService) Stop(ctx context.Context) {
+	if !s.isRunning {
+		return
+	}
+	s.isRunning = false
+	close(s.stopCh)
+	g.Log().Info(ctx, "客户个人信息保留期检查已停止")
+}
+
+// SweepExpired 按各活跃租户的保留策略匿名化超过保留期未活跃的客户，返回匿名化的客户数。
+// 单个租户处理失败不影响其他租户
+func (s *CustomerAnonymizationService) SweepExpired(ctx context.Context) (int, error) {
+	anonymized := 0
+	for page := 1; ; page++ {
+		tenants, total, err := s.tenantRepo.List(ctx, &types.ListTenantsRequest{
+			Page:     page,
+			PageSize: retentionSweepTenantPageSize,
+			Status:   types.TenantStatusActive,
+		})
+		if err != nil {
+			return anonymized, fmt.Errorf("查询租户失败: %v", err)
+		}
+
+		for _, tenant := range tenants {
+			count, err := s.sweepTenant(ctx, tenant.ID)
+			if err != nil {
+				g.Log().Error(ctx, "租户客户个人信息保留期检查失败", "tenant_id", tenant.ID, "error", err)
+			}
+			anonymized += count
+		}
+
+		if len(tenants) == 0 || page*retentionSweepTenantPageSize >= total {
+			return anonymized, nil
+		}
+	}
+}
+
+// sweepTenant 匿名化租户中超过保留期的客户，未配置保留策略的租户跳过
+func (s *CustomerAnonymizationService) sweepTenant(ctx context.Context, tenantID uint64) (int, error) {
+	policy, err := s.policies(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	cutoff, ok := policy.Cutoff(s.now())
+	if !ok {
+		return 0, nil
+	}
+
+	// 定时任务没有请求上下文，按租户设置租户上下文
+	tenantCtx := context.WithValue(ctx, "tenant_id", tenantID)
+	customers, err := s.repo.ListInactiveCustomers(tenantCtx, cutoff, retentionSweepBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	anonymized := 0
+	for i := range customers {
+		if _, err := s.anonymize(tenantCtx, &customers[i], "retention"); err != nil {
+			if err == types.ErrCustomerAlreadyAnonymized {
+				continue
+			}
+			g.Log().Error(ctx, "匿名化过期客户失败", "tenant_id", tenantID, "user_id", customers[i].ID, "error", err)
+			continue
+		}
+		anonymized++
+	}
+	if anonymized > 0 {
+		g.Log().Info(ctx, "已匿名化超过保留期的客户",
+			"tenant_id", tenantID,
+			"count", anonymized,
+			"retention_days", policy.CustomerRetentionDays)
+	}
+	return anonymized, nil
+}
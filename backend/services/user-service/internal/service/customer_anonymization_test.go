@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryAnonymizationRepository 内存客户匿名化仓储，按 SQL 实现的规则替换个人信息
+type memoryAnonymizationRepository struct {
+	users     map[uint64]*types.User
+	customers map[uint64]bool
+	orders    []types.Order
+	notes     []types.OrderNote
+	disputes  []types.OrderDispute
+}
+
+func (m *memoryAnonymizationRepository) GetCustomer(ctx context.Context, userID uint64) (*types.User, bool, error) {
+	user, ok := m.users[userID]
+	if !ok {
+		return nil, false, nil
+	}
+	copied := *user
+	return &copied, m.customers[userID] && user.MerchantID == nil, nil
+}
+
+func (m *memoryAnonymizationRepository) Anonymize(ctx context.Context, anonymization *types.CustomerAnonymization) error {
+	user := m.users[anonymization.UserID]
+	if user.AnonymizedAt != nil {
+		return types.ErrCustomerAlreadyAnonymized
+	}
+	anonymization.Apply(user)
+
+	for i := range m.notes {
+		if m.notes[i].AuthorID == user.ID {
+			m.notes[i].AuthorName = types.AnonymizedAuthorName
+			m.notes[i].Content = types.AnonymizedText
+			anonymization.NotesScrubbed++
+		}
+	}
+	for i := range m.disputes {
+		if m.disputes[i].CustomerID == user.ID {
+			m.disputes[i].Description = types.AnonymizedText
+			anonymization.DisputesScrubbed++
+		}
+	}
+	for _, order := range m.orders {
+		if order.CustomerID == user.ID {
+			anonymization.RetainedOrders++
+			anonymization.RetainedAmount += order.TotalAmount
+		}
+	}
+	return nil
+}
+
+func (m *memoryAnonymizationRepository) ListInactiveCustomers(ctx context.Context, cutoff time.Time, limit int) ([]types.User, error) {
+	var result []types.User
+	for id := uint64(1); id <= uint64(len(m.users)); id++ {
+		user, ok := m.users[id]
+		if !ok || !m.customers[id] || user.AnonymizedAt != nil || user.TenantID != ctx.Value("tenant_id") {
+			continue
+		}
+		lastActive := user.CreatedAt
+		if user.LastLoginAt != nil {
+			lastActive = *user.LastLoginAt
+		}
+		if !lastActive.Before(cutoff) {
+			continue
+		}
+		recentOrder := false
+		for _, order := range m.orders {
+			if order.CustomerID == id && !order.CreatedAt.Before(cutoff) {
+				recentOrder = true
+			}
+		}
+		if !recentOrder && len(result) < limit {
+			result = append(result, *user)
+		}
+	}
+	return result, nil
+}
+
+// retentionTenantRepository 按租户ID返回配置的租户仓储桩
+type retentionTenantRepository struct {
+	repository.ITenantRepository
+	tenants []types.Tenant
+}
+
+func (f *retentionTenantRepository) GetByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	for i := range f.tenants {
+		if f.tenants[i].ID == id {
+			return &f.tenants[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *retentionTenantRepository) List(ctx context.Context, req *types.ListTenantsRequest) ([]types.Tenant, int, error) {
+	return f.tenants, len(f.tenants), nil
+}
+
+func TestCustomerAnonymizationService(t *testing.T) {
+	Convey("客户个人信息匿名化测试", t, func() {
+		now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		longAgo := now.AddDate(-2, 0, 0)
+		recently := now.AddDate(0, -1, 0)
+		merchantID := uint64(9)
+
+		repo := &memoryAnonymizationRepository{
+			users: map[uint64]*types.User{
+				1: {ID: 1, TenantID: 1, Username: "zhangsan", Email: "zhangsan@example.com", Phone: "13800138000",
+					PasswordHash: "hash", Status: types.UserStatusActive, CreatedAt: longAgo, LastLoginAt: &longAgo,
+					Profile: &types.UserProfile{FirstName: "三", LastName: "张", Description: "上海市浦东新区世纪大道100号"}},
+				2: {ID: 2, TenantID: 1, Username: "lisi", Email: "lisi@example.com", Status: types.UserStatusActive, CreatedAt: longAgo, LastLoginAt: &recently},
+				3: {ID: 3, TenantID: 1, Username: "wangwu", Email: "wangwu@example.com", Status: types.UserStatusActive, CreatedAt: longAgo, LastLoginAt: &longAgo},
+				4: {ID: 4, TenantID: 1, Username: "operator", Email: "operator@example.com", Status: types.UserStatusActive, CreatedAt: longAgo, MerchantID: &merchantID},
+			},
+			customers: map[uint64]bool{1: true, 2: true, 3: true},
+			orders: []types.Order{
+				{ID: 1, CustomerID: 1, MerchantID: 9, TotalAmount: 120.50, CreatedAt: longAgo},
+				{ID: 2, CustomerID: 1, MerchantID: 9, TotalAmount: 79.50, CreatedAt: longAgo},
+				{ID: 3, CustomerID: 2, MerchantID: 9, TotalAmount: 35, CreatedAt: longAgo},
+				{ID: 4, CustomerID: 3, MerchantID: 9, TotalAmount: 60, CreatedAt: recently},
+			},
+			notes: []types.OrderNote{
+				{ID: 1, OrderID: 1, AuthorID: 1, AuthorName: "张三", Content: "请送到世纪大道100号，电话13800138000"},
+				{ID: 2, OrderID: 1, AuthorID: 4, AuthorName: "operator", Content: "已联系客户确认地址"},
+			},
+			disputes: []types.OrderDispute{
+				{ID: 1, OrderID: 2, CustomerID: 1, Description: "收件人张三未收到货"},
+			},
+		}
+		tenantRepo := &retentionTenantRepository{tenants: []types.Tenant{
+			{ID: 1, Config: `{"data_retention":{"customer_retention_days":365}}`},
+			{ID: 2},
+		}}
+		anonymizationService := NewCustomerAnonymizationServiceForTest(repo, tenantRepo, func() time.Time { return now })
+
+		Convey("匿名化客户后个人信息被替换，订单金额和数量保持不变", func() {
+			result, err := anonymizationService.Anonymize(ctx, 1)
+			So(err, ShouldBeNil)
+			So(result.Token, ShouldNotBeEmpty)
+			So(result.NotesScrubbed, ShouldEqual, 1)
+			So(result.DisputesScrubbed, ShouldEqual, 1)
+			So(result.RetainedOrders, ShouldEqual, 2)
+			So(result.RetainedAmount, ShouldAlmostEqual, 200)
+
+			user := repo.users[1]
+			So(user.Username, ShouldEqual, types.AnonymizedUsernamePrefix+result.Token)
+			So(user.Email, ShouldEqual, result.Token+"@"+types.AnonymizedEmailDomain)
+			So(user.Phone, ShouldBeEmpty)
+			So(user.Profile, ShouldBeNil)
+			So(user.PasswordHash, ShouldBeEmpty)
+			So(user.Status, ShouldEqual, types.UserStatusDeactivated)
+			So(user.AnonymizedAt, ShouldNotBeNil)
+
+			So(repo.notes[0].AuthorName, ShouldEqual, types.AnonymizedAuthorName)
+			So(repo.notes[0].Content, ShouldEqual, types.AnonymizedText)
+			So(repo.notes[1].Content, ShouldEqual, "已联系客户确认地址")
+			So(repo.disputes[0].Description, ShouldEqual, types.AnonymizedText)
+
+			So(len(repo.orders), ShouldEqual, 4)
+			total := 0.0
+			for _, order := range repo.orders {
+				if order.CustomerID == 1 {
+					total += order.TotalAmount
+				}
+			}
+			So(total, ShouldAlmostEqual, 200)
+			So(repo.orders[0].TotalAmount, ShouldEqual, 120.50)
+		})
+
+		Convey("每次匿名化使用不同的令牌", func() {
+			first, err := anonymizationService.Anonymize(ctx, 1)
+			So(err, ShouldBeNil)
+			second, err := anonymizationService.Anonymize(ctx, 2)
+			So(err, ShouldBeNil)
+			So(first.Token, ShouldNotEqual, second.Token)
+		})
+
+		Convey("已匿名化的客户不能重复匿名化", func() {
+			_, err := anonymizationService.Anonymize(ctx, 1)
+			So(err, ShouldBeNil)
+			_, err = anonymizationService.Anonymize(ctx, 1)
+			So(err, ShouldEqual, types.ErrCustomerAlreadyAnonymized)
+		})
+
+		Convey("商户用户不能匿名化", func() {
+			_, err := anonymizationService.Anonymize(ctx, 4)
+			So(err, ShouldEqual, types.ErrAnonymizeNonCustomer)
+			So(repo.users[4].Username, ShouldEqual, "operator")
+		})
+
+		Convey("用户不存在", func() {
+			_, err := anonymizationService.Anonymize(ctx, 99)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("保留期检查只匿名化超过保留期未登录且未下单的客户", func() {
+			count, err := anonymizationService.SweepExpired(context.Background())
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+			So(repo.users[1].AnonymizedAt, ShouldNotBeNil)
+			So(repo.users[2].AnonymizedAt, ShouldBeNil)
+			So(repo.users[3].AnonymizedAt, ShouldBeNil)
+			So(repo.users[4].AnonymizedAt, ShouldBeNil)
+
+			Convey("再次检查不会重复处理", func() {
+				count, err := anonymizationService.SweepExpired(context.Background())
+				So(err, ShouldBeNil)
+				So(count, ShouldEqual, 0)
+			})
+		})
+
+		Convey("未配置保留策略的租户不自动匿名化", func() {
+			tenantRepo.tenants[0].Config = `{"data_retention":{"customer_retention_days":0}}`
+			count, err := anonymizationService.SweepExpired(context.Background())
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 0)
+			So(repo.users[1].AnonymizedAt, ShouldBeNil)
+		})
+	})
+}
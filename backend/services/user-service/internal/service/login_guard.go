@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/cache"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	defaultCaptchaThreshold = 5
+	defaultCaptchaWindow    = 15 * 60 // 秒
+)
+
+// LoginGuard 登录防护服务：统计失败次数，超过阈值后要求完成验证码
+type LoginGuard struct {
+	cache      *cache.Cache
+	tenantRepo repository.ITenantRepository
+	providers  map[string]auth.CaptchaProvider
+}
+
+// NewLoginGuard 创建登录防护服务
+func NewLoginGuard() *LoginGuard {
+	ctx := context.Background()
+	providers := map[string]auth.CaptchaProvider{
+		types.CaptchaProviderLocal: auth.NewLocalCaptchaProvider(),
+	}
+
+	if secret := g.Cfg().MustGet(ctx, "captcha.hcaptcha.secret").String(); secret != "" {
+		siteKey := g.Cfg().MustGet(ctx, "captcha.hcaptcha.siteKey").String()
+		providers[types.CaptchaProviderHCaptcha] = auth.NewHCaptchaProvider(siteKey, secret)
+	}
+	if secret := g.Cfg().MustGet(ctx, "captcha.recaptcha.secret").String(); secret != "" {
+		siteKey := g.Cfg().MustGet(ctx, "captcha.recaptcha.siteKey").String()
+		providers[types.CaptchaProviderReCaptcha] = auth.NewReCaptchaProvider(siteKey, secret)
+	}
+
+	return &LoginGuard{
+		cache:      cache.NewCache("login_guard"),
+		tenantRepo: repository.NewTenantRepository(),
+		providers:  providers,
+	}
+}
+
+// NewLoginGuardForTest 创建测试用登录防护服务实例
+func NewLoginGuardForTest(tenantRepo repository.ITenantRepository, providers map[string]auth.CaptchaProvider) *LoginGuard {
+	return &LoginGuard{
+		cache:      cache.NewMockCache(),
+		tenantRepo: tenantRepo,
+		providers:  providers,
+	}
+}
+
+// CheckChallenge 检查本次登录是否需要人机验证。
+// 返回非空挑战表示需要客户端完成验证后重新提交登录
+func (s *LoginGuard) CheckChallenge(ctx context.Context, tenantID uint64, username, clientIP, captchaToken string) (*types.CaptchaChallenge, error) {
+	policy := s.getPolicy(ctx, tenantID)
+	if policy == nil || isTrustedIP(clientIP, policy.TrustedIPs) {
+		return nil, nil
+	}
+
+	if !s.challengeRequired(ctx, tenantID, username, clientIP, policy) {
+		return nil, nil
+	}
+
+	provider, exists := s.providers[policy.Provider]
+	if !exists {
+		return nil, fmt.Errorf("验证码提供者 %s 未配置", policy.Provider)
+	}
+
+	if captchaToken != "" {
+		passed, err := provider.Verify(ctx, captchaToken, clientIP)
+		if err != nil {
+			g.Log().Warningf(ctx, "验证码校验失败 - 租户: %d, 用户: %s, IP: %s, 错误: %v", tenantID, username, clientIP, err)
+		}
+		details := challengeLogDetails(username, clientIP, policy.Provider)
+		if passed {
+			audit.LogTenantAccess(ctx, tenantID, "login_captcha", "verify_passed", details)
+			return nil, nil
+		}
+		audit.LogSecurityViolation(ctx, tenantID, "captcha_failed", "登录验证码校验未通过", details)
+	}
+
+	challenge, err := provider.Issue(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("生成验证码挑战失败: %w", err)
+	}
+	audit.LogTenantAccess(ctx, tenantID, "login_captcha", "issue", challengeLogDetails(username, clientIP, policy.Provider))
+
+	return challenge, nil
+}
+
+// RecordFailure 记录一次登录失败
+func (s *LoginGuard) RecordFailure(ctx context.Context, tenantID uint64, username, clientIP string) {
+	policy := s.getPolicy(ctx, tenantID)
+	if policy == nil {
+		return
+	}
+
+	window := time.Duration(policy.WindowSeconds) * time.Second
+	for _, key := range []string{s.ipKey(tenantID, clientIP), s.usernameKey(tenantID, username)} {
+		count, err := s.cache.Increment(ctx, key, 1)
+		if err != nil {
+			g.Log().Warningf(ctx, "记录登录失败次数失败: %v", err)
+			continue
+		}
+		// 首次失败时开始计算统计窗口
+		if count == 1 {
+			s.cache.Expire(ctx, key, window)
+		}
+	}
+}
+
+// Reset 登录成功后清除用户名维度的失败计数（IP维度保留，防止撞库）
+func (s *LoginGuard) Reset(ctx context.Context, tenantID uint64, username string) {
+	if err := s.cache.Delete(ctx, s.usernameKey(tenantID, username)); err != nil {
+		g.Log().Warningf(ctx, "清除登录失败次数失败: %v", err)
+	}
+}
+
+// challengeRequired 判断IP或用户名的失败次数是否已达到阈值
+func (s *LoginGuard) challengeRequired(ctx context.Context, tenantID uint64, username, clientIP string, policy *types.CaptchaPolicy) bool {
+	ipFailures, _ := s.cache.GetInt(ctx, s.ipKey(tenantID, clientIP))
+	if ipFailures >= policy.IPThreshold {
+		return true
+	}
+
+	usernameFailures, _ := s.cache.GetInt(ctx, s.usernameKey(tenantID, username))
+	return usernameFailures >= policy.UsernameThreshold
+}
+
+// getPolicy 获取租户验证码策略，未启用时返回nil
+func (s *LoginGuard) getPolicy(ctx context.Context, tenantID uint64) *types.CaptchaPolicy {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil || tenant == nil || tenant.Config == "" {
+		return nil
+	}
+
+	var config types.TenantConfig
+	if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+		g.Log().Warningf(ctx, "解析租户配置失败 - 租户: %d, 错误: %v", tenantID, err)
+		return nil
+	}
+
+	if config.Captcha == nil || !config.Captcha.Enabled {
+		return nil
+	}
+
+	policy := *config.Captcha
+	if policy.Provider == "" {
+		policy.Provider = types.CaptchaProviderLocal
+	}
+	if policy.IPThreshold <= 0 {
+		policy.IPThreshold = defaultCaptchaThreshold
+	}
+	if policy.UsernameThreshold <= 0 {
+		policy.UsernameThreshold = defaultCaptchaThreshold
+	}
+	if policy.WindowSeconds <= 0 {
+		policy.WindowSeconds = defaultCaptchaWindow
+	}
+	return &policy
+}
+
+// challengeLogDetails 验证码下发与校验的审计详情
+func challengeLogDetails(username, clientIP, provider string) map[string]interface{} {
+	return map[string]interface{}{
+		"username":  username,
+		"client_ip": clientIP,
+		"provider":  provider,
+	}
+}
+
+func (s *LoginGuard) ipKey(tenantID uint64, clientIP string) string {
+	return fmt.Sprintf("fail:ip:%d:%s", tenantID, clientIP)
+}
+
+func (s *LoginGuard) usernameKey(tenantID uint64, username string) string {
+	return fmt.Sprintf("fail:user:%d:%s", tenantID, strings.ToLower(username))
+}
+
+// isTrustedIP 检查IP是否在受信任列表中，支持单个IP和CIDR网段
+func isTrustedIP(clientIP string, trusted []string) bool {
+	ip := net.ParseIP(clientIP)
+	for _, entry := range trusted {
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if entry == clientIP {
+			return true
+		}
+	}
+	return false
+}
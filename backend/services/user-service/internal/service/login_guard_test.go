@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// mockCaptchaProvider 只接受固定令牌的验证码提供者
+type mockCaptchaProvider struct {
+	validToken string
+	issued     int
+	verified   []string
+}
+
+func (m *mockCaptchaProvider) Issue(ctx context.Context) (*types.CaptchaChallenge, error) {
+	m.issued++
+	return &types.CaptchaChallenge{Required: true, Provider: types.CaptchaProviderHCaptcha, SiteKey: "site-key"}, nil
+}
+
+func (m *mockCaptchaProvider) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	m.verified = append(m.verified, token)
+	return token == m.validToken, nil
+}
+
+func TestLoginGuard(t *testing.T) {
+	Convey("登录验证码防护测试", t, func() {
+		ctx := context.Background()
+		provider := &mockCaptchaProvider{validToken: "solved"}
+		tenantRepo := &fakeTenantRepository{
+			config: `{"captcha":{"enabled":true,"provider":"hcaptcha","ip_threshold":5,"username_threshold":3,"trusted_ips":["10.0.0.0/8","192.168.1.10"]}}`,
+		}
+		guard := NewLoginGuardForTest(tenantRepo, map[string]auth.CaptchaProvider{
+			types.CaptchaProviderHCaptcha: provider,
+		})
+
+		Convey("失败次数未达阈值时不需要验证", func() {
+			guard.RecordFailure(ctx, 1, "alice", "1.2.3.4")
+			guard.RecordFailure(ctx, 1, "alice", "1.2.3.4")
+
+			challenge, err := guard.CheckChallenge(ctx, 1, "alice", "1.2.3.4", "")
+			So(err, ShouldBeNil)
+			So(challenge, ShouldBeNil)
+		})
+
+		Convey("同一用户名失败达到阈值后要求验证", func() {
+			for i := 0; i < 3; i++ {
+				guard.RecordFailure(ctx, 1, "alice", "1.2.3.4")
+			}
+
+			challenge, err := guard.CheckChallenge(ctx, 1, "Alice", "5.6.7.8", "")
+			So(err, ShouldBeNil)
+			So(challenge, ShouldNotBeNil)
+			So(challenge.Required, ShouldBeTrue)
+			So(challenge.SiteKey, ShouldEqual, "site-key")
+			So(provider.issued, ShouldEqual, 1)
+
+			Convey("提交错误令牌时重新下发挑战", func() {
+				challenge, err := guard.CheckChallenge(ctx, 1, "alice", "5.6.7.8", "wrong")
+				So(err, ShouldBeNil)
+				So(challenge, ShouldNotBeNil)
+				So(provider.verified, ShouldResemble, []string{"wrong"})
+			})
+
+			Convey("提交正确令牌时放行", func() {
+				challenge, err := guard.CheckChallenge(ctx, 1, "alice", "5.6.7.8", "solved")
+				So(err, ShouldBeNil)
+				So(challenge, ShouldBeNil)
+			})
+
+			Convey("登录成功后清除用户名失败计数", func() {
+				guard.Reset(ctx, 1, "alice")
+				challenge, err := guard.CheckChallenge(ctx, 1, "alice", "5.6.7.8", "")
+				So(err, ShouldBeNil)
+				So(challenge, ShouldBeNil)
+			})
+		})
+
+		Convey("同一IP失败达到阈值后对其他用户名也要求验证", func() {
+			for _, username := range []string{"u1", "u2", "u3", "u4", "u5"} {
+				guard.RecordFailure(ctx, 1, username, "1.2.3.4")
+			}
+
+			challenge, err := guard.CheckChallenge(ctx, 1, "bob", "1.2.3.4", "")
+			So(err, ShouldBeNil)
+			So(challenge, ShouldNotBeNil)
+		})
+
+		Convey("受信任IP跳过验证", func() {
+			for i := 0; i < 5; i++ {
+				guard.RecordFailure(ctx, 1, "alice", "10.1.2.3")
+			}
+
+			challenge, err := guard.CheckChallenge(ctx, 1, "alice", "10.1.2.3", "")
+			So(err, ShouldBeNil)
+			So(challenge, ShouldBeNil)
+
+			challenge, err = guard.CheckChallenge(ctx, 1, "alice", "192.168.1.10", "")
+			So(err, ShouldBeNil)
+			So(challenge, ShouldBeNil)
+		})
+
+		Convey("租户未启用验证码时不做限制", func() {
+			tenantRepo.config = `{"captcha":{"enabled":false}}`
+			for i := 0; i < 10; i++ {
+				guard.RecordFailure(ctx, 1, "alice", "1.2.3.4")
+			}
+
+			challenge, err := guard.CheckChallenge(ctx, 1, "alice", "1.2.3.4", "")
+			So(err, ShouldBeNil)
+			So(challenge, ShouldBeNil)
+		})
+	})
+}
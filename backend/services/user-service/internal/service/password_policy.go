@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 随机生成满足策略的密码时的最大尝试次数
+const maxGeneratePasswordAttempts = 20
+
+// PasswordPolicyService 租户密码策略服务：设置密码时校验策略和密码历史，登录时检查密码是否过期
+type PasswordPolicyService struct {
+	policies    repository.PasswordPolicyProvider
+	historyRepo repository.IPasswordHistoryRepository
+	now         func() time.Time
+}
+
+// NewPasswordPolicyService 创建密码策略服务
+func NewPasswordPolicyService() *PasswordPolicyService {
+	return &PasswordPolicyService{
+		policies:    repository.NewTenantPasswordPolicyProvider(repository.NewTenantRepository()),
+		historyRepo: repository.NewPasswordHistoryRepository(),
+		now:         time.Now,
+	}
+}
+
+// NewPasswordPolicyServiceForTest 创建测试用密码策略服务实例
+func NewPasswordPolicyServiceForTest(tenantRepo repository.ITenantRepository, historyRepo repository.IPasswordHistoryRepository, now func() time.Time) *PasswordPolicyService {
+	return &PasswordPolicyService{
+		policies:    repository.NewTenantPasswordPolicyProvider(tenantRepo),
+		historyRepo: historyRepo,
+		now:         now,
+	}
+}
+
+// Validate 校验新密码是否满足租户密码策略，不满足时返回 *types.PasswordPolicyError。
+// user 为已有用户时同时检查新密码是否与当前密码或最近使用过的密码重复
+func (s *PasswordPolicyService) Validate(ctx context.Context, tenantID uint64, user *types.User, password string) error {
+	policy := s.policies.Resolve(ctx, tenantID)
+	violations := policy.Check(password)
+
+	if user != nil && policy.HistoryCount > 0 {
+		reused, err := s.reused(ctx, user, password, policy.HistoryCount)
+		if err != nil {
+			return err
+		}
+		if reused {
+			violations = append(violations, types.ReusedPasswordViolation(policy.HistoryCount))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &types.PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// reused 新密码是否与当前密码或最近 historyCount 次设置的密码相同，当前密码计入次数
+func (s *PasswordPolicyService) reused(ctx context.Context, user *types.User, password string, historyCount int) (bool, error) {
+	if user.PasswordHash != "" && utils.CheckPassword(password, user.PasswordHash) {
+		return true, nil
+	}
+
+	histories, err := s.historyRepo.ListRecent(ctx, user.ID, historyCount)
+	if err != nil {
+		return false, err
+	}
+	for _, history := range histories {
+		if utils.CheckPassword(password, history.PasswordHash) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Record 记录用户新设置的密码哈希，只保留最近的密码历史；记录失败不影响密码设置
+func (s *PasswordPolicyService) Record(ctx context.Context, tenantID, userID uint64, passwordHash string) {
+	history := &types.PasswordHistory{
+		TenantID:     tenantID,
+		UserID:       userID,
+		PasswordHash: passwordHash,
+	}
+	if err := s.historyRepo.Create(ctx, history); err != nil {
+		g.Log().Warningf(ctx, "记录密码历史失败 - 用户ID: %d, 错误: %v", userID, err)
+		return
+	}
+	if err := s.historyRepo.Prune(ctx, userID, types.MaxPasswordHistoryCount); err != nil {
+		g.Log().Warningf(ctx, "清理密码历史失败 - 用户ID: %d, 错误: %v", userID, err)
+	}
+}
+
+// Expired 用户密码是否已超过租户策略的最长有效天数，从未修改过密码的按创建时间计算
+func (s *PasswordPolicyService) Expired(ctx context.Context, user *types.User) bool {
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return s.policies.Resolve(ctx, user.TenantID).Expired(changedAt, s.now())
+}
+
+// Generate 为重置密码生成满足租户密码策略的随机密码
+func (s *PasswordPolicyService) Generate(ctx context.Context, tenantID uint64) (string, error) {
+	policy := s.policies.Resolve(ctx, tenantID)
+	length := policy.EffectiveMinLength()
+	if length < 8 {
+		length = 8
+	}
+
+	for i := 0; i < maxGeneratePasswordAttempts; i++ {
+		password, err := utils.GenerateRandomPassword(length)
+		if err != nil {
+			return "", err
+		}
+		// 随机密码与历史密码重复的概率可以忽略，只检查长度、字符类别和常见弱密码
+		if len(policy.Check(password)) == 0 {
+			return password, nil
+		}
+	}
+	return "", fmt.Errorf("无法生成满足密码策略的随机密码")
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakePasswordHistoryRepository 内存密码历史仓储
+type fakePasswordHistoryRepository struct {
+	repository.IPasswordHistoryRepository
+	histories []types.PasswordHistory
+}
+
+func (f *fakePasswordHistoryRepository) Create(ctx context.Context, history *types.PasswordHistory) error {
+	history.ID = uint64(len(f.histories) + 1)
+	f.histories = append(f.histories, *history)
+	return nil
+}
+
+func (f *fakePasswordHistoryRepository) ListRecent(ctx context.Context, userID uint64, limit int) ([]types.PasswordHistory, error) {
+	var result []types.PasswordHistory
+	for i := len(f.histories) - 1; i >= 0 && len(result) < limit; i-- {
+		if f.histories[i].UserID == userID {
+			result = append(result, f.histories[i])
+		}
+	}
+	return result, nil
+}
+
+func (f *fakePasswordHistoryRepository) Prune(ctx context.Context, userID uint64, keep int) error {
+	recent, _ := f.ListRecent(ctx, userID, keep)
+	kept := make(map[uint64]bool, len(recent))
+	for _, history := range recent {
+		kept[history.ID] = true
+	}
+	var histories []types.PasswordHistory
+	for _, history := range f.histories {
+		if history.UserID != userID || kept[history.ID] {
+			histories = append(histories, history)
+		}
+	}
+	f.histories = histories
+	return nil
+}
+
+// passwordViolationCodes 提取密码策略错误中的不满足项
+func passwordViolationCodes(err error) []types.PasswordViolationCode {
+	var policyErr *types.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return nil
+	}
+	var codes []types.PasswordViolationCode
+	for _, violation := range policyErr.Violations {
+		codes = append(codes, violation.Code)
+	}
+	return codes
+}
+
+func mustHashPassword(password string) string {
+	hash, err := utils.HashPassword(password)
+	So(err, ShouldBeNil)
+	return hash
+}
+
+func TestPasswordPolicyService(t *testing.T) {
+	Convey("租户密码策略测试", t, func() {
+		ctx := context.Background()
+		now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		tenantRepo := &fakeTenantRepository{
+			config: `{"password_policy":{"min_length":10,"required_classes":["digit"],"min_classes":3,"disallow_common":true,"history_count":3,"max_age_days":90}}`,
+		}
+		historyRepo := &fakePasswordHistoryRepository{}
+		policyService := NewPasswordPolicyServiceForTest(tenantRepo, historyRepo, func() time.Time { return now })
+
+		Convey("新用户密码按租户策略返回具体的不满足项", func() {
+			err := policyService.Validate(ctx, 1, nil, "Password")
+			So(passwordViolationCodes(err), ShouldResemble, []types.PasswordViolationCode{
+				types.PasswordViolationTooShort,
+				types.PasswordViolationMissingClass,
+				types.PasswordViolationFewClasses,
+				types.PasswordViolationCommon,
+			})
+			So(err.Error(), ShouldContainSubstring, "密码长度不能少于10位")
+
+			So(policyService.Validate(ctx, 1, nil, "Summer-2024x"), ShouldBeNil)
+		})
+
+		Convey("未配置密码策略的租户只要求默认最小长度", func() {
+			tenantRepo.config = `{"features":["reports"]}`
+			So(policyService.Validate(ctx, 1, nil, "abcdef"), ShouldBeNil)
+			So(passwordViolationCodes(policyService.Validate(ctx, 1, nil, "abc")), ShouldResemble, []types.PasswordViolationCode{types.PasswordViolationTooShort})
+		})
+
+		Convey("禁止重复使用当前密码和最近使用过的密码", func() {
+			user := &types.User{ID: 7, TenantID: 1}
+			for _, password := range []string{"Oldest-2021x", "Older-2022xx", "Recent-2023x", "Current-2024x"} {
+				user.PasswordHash = mustHashPassword(password)
+				policyService.Record(ctx, 1, user.ID, user.PasswordHash)
+			}
+			policyService.Record(ctx, 1, 8, mustHashPassword("Other-2024xx"))
+
+			So(passwordViolationCodes(policyService.Validate(ctx, 1, user, "Current-2024x")), ShouldResemble, []types.PasswordViolationCode{types.PasswordViolationReused})
+			So(passwordViolationCodes(policyService.Validate(ctx, 1, user, "Recent-2023x")), ShouldResemble, []types.PasswordViolationCode{types.PasswordViolationReused})
+			So(passwordViolationCodes(policyService.Validate(ctx, 1, user, "Older-2022xx")), ShouldResemble, []types.PasswordViolationCode{types.PasswordViolationReused})
+
+			// 超出最近3次的密码和其他用户的密码可以使用
+			So(policyService.Validate(ctx, 1, user, "Oldest-2021x"), ShouldBeNil)
+			So(policyService.Validate(ctx, 1, user, "Other-2024xx"), ShouldBeNil)
+		})
+
+		Convey("未启用密码历史时允许重复使用", func() {
+			tenantRepo.config = `{"password_policy":{"min_length":8}}`
+			user := &types.User{ID: 7, TenantID: 1, PasswordHash: mustHashPassword("Current-2024x")}
+			So(policyService.Validate(ctx, 1, user, "Current-2024x"), ShouldBeNil)
+		})
+
+		Convey("只保留最近的密码历史", func() {
+			for i := 0; i < types.MaxPasswordHistoryCount+3; i++ {
+				policyService.Record(ctx, 1, 7, "hash")
+			}
+			So(historyRepo.histories, ShouldHaveLength, types.MaxPasswordHistoryCount)
+			So(historyRepo.histories[0].ID, ShouldEqual, 4)
+		})
+
+		Convey("超过有效天数的密码已过期", func() {
+			changedAt := now.AddDate(0, 0, -90)
+			So(policyService.Expired(ctx, &types.User{TenantID: 1, PasswordChangedAt: &changedAt}), ShouldBeTrue)
+
+			changedAt = now.AddDate(0, 0, -30)
+			So(policyService.Expired(ctx, &types.User{TenantID: 1, PasswordChangedAt: &changedAt}), ShouldBeFalse)
+
+			// 从未修改过密码时按创建时间计算
+			So(policyService.Expired(ctx, &types.User{TenantID: 1, CreatedAt: now.AddDate(-1, 0, 0)}), ShouldBeTrue)
+
+			tenantRepo.config = `{"password_policy":{"min_length":8}}`
+			So(policyService.Expired(ctx, &types.User{TenantID: 1, CreatedAt: now.AddDate(-1, 0, 0)}), ShouldBeFalse)
+		})
+
+		Convey("重置密码时生成满足策略的随机密码", func() {
+			password, err := policyService.Generate(ctx, 1)
+			So(err, ShouldBeNil)
+			So(len(password), ShouldBeGreaterThanOrEqualTo, 10)
+			So(policyService.Validate(ctx, 1, nil, password), ShouldBeNil)
+		})
+	})
+}
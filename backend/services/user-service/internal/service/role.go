@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// RoleService 角色与权限管理服务
+type RoleService struct {
+	roleRepo   repository.RoleRepository
+	tenantRepo repository.ITenantRepository
+}
+
+// NewRoleService 创建角色管理服务
+func NewRoleService() *RoleService {
+	return &RoleService{
+		roleRepo:   repository.NewRoleRepository(),
+		tenantRepo: repository.NewTenantRepository(),
+	}
+}
+
+// NewRoleServiceForTest 创建测试用角色管理服务实例
+func NewRoleServiceForTest(roleRepo repository.RoleRepository, tenantRepo repository.ITenantRepository) *RoleService {
+	return &RoleService{
+		roleRepo:   roleRepo,
+		tenantRepo: tenantRepo,
+	}
+}
+
+// ListRoles 获取租户可用角色列表
+func (s *RoleService) ListRoles(ctx context.Context, tenantID uint64) ([]types.Role, error) {
+	return s.roleRepo.ListRoles(ctx, tenantID)
+}
+
+// CreateRole 创建租户自定义角色
+func (s *RoleService) CreateRole(ctx context.Context, tenantID uint64, req *types.CreateRoleRequest) (*types.Role, error) {
+	role := &types.Role{
+		Type:        req.Type,
+		Name:        req.Name,
+		Description: req.Description,
+		IsSystem:    false,
+		Permissions: req.Permissions,
+	}
+
+	if err := s.roleRepo.CreateRole(ctx, tenantID, role); err != nil {
+		return nil, err
+	}
+
+	audit.LogOperation(ctx, "role", "create", map[string]interface{}{
+		"role_type":   role.Type,
+		"name":        role.Name,
+		"permissions": role.Permissions,
+	})
+
+	return role, nil
+}
+
+// GrantPermissions 为角色授予权限
+func (s *RoleService) GrantPermissions(ctx context.Context, tenantID uint64, roleType types.RoleType, permissions []types.Permission) error {
+	if len(permissions) == 0 {
+		return fmt.Errorf("权限列表不能为空")
+	}
+
+	if err := s.roleRepo.GrantRolePermissions(ctx, tenantID, roleType, permissions); err != nil {
+		return err
+	}
+
+	audit.LogOperation(ctx, "role", "grant_permissions", map[string]interface{}{
+		"role_type":   roleType,
+		"permissions": permissions,
+	})
+
+	return nil
+}
+
+// RevokePermissions 撤销角色权限
+func (s *RoleService) RevokePermissions(ctx context.Context, tenantID uint64, roleType types.RoleType, permissions []types.Permission) error {
+	if len(permissions) == 0 {
+		return fmt.Errorf("权限列表不能为空")
+	}
+
+	if err := s.roleRepo.RevokeRolePermissions(ctx, tenantID, roleType, permissions); err != nil {
+		return err
+	}
+
+	audit.LogOperation(ctx, "role", "revoke_permissions", map[string]interface{}{
+		"role_type":   roleType,
+		"permissions": permissions,
+	})
+
+	return nil
+}
+
+// AssignRoleToUser 为用户分配角色
+func (s *RoleService) AssignRoleToUser(ctx context.Context, tenantID, userID uint64, roleType types.RoleType, operatorID uint64) error {
+	definitions, err := s.roleRepo.GetRoleDefinitions(ctx, tenantID, []types.RoleType{roleType})
+	if err != nil {
+		return err
+	}
+
+	if _, exists := definitions[roleType]; !exists {
+		return fmt.Errorf("角色 %s 不存在", roleType)
+	}
+
+	if err := s.roleRepo.AssignRole(ctx, userID, tenantID, roleType, operatorID); err != nil {
+		return err
+	}
+
+	audit.LogOperation(ctx, "user_role", "assign", map[string]interface{}{
+		"user_id":   userID,
+		"role_type": roleType,
+	})
+
+	return nil
+}
+
+// RevokeRoleFromUser 撤销用户角色
+func (s *RoleService) RevokeRoleFromUser(ctx context.Context, tenantID, userID uint64, roleType types.RoleType) error {
+	if err := s.roleRepo.RevokeRole(ctx, userID, tenantID, roleType); err != nil {
+		return err
+	}
+
+	audit.LogOperation(ctx, "user_role", "revoke", map[string]interface{}{
+		"user_id":   userID,
+		"role_type": roleType,
+	})
+
+	return nil
+}
+
+// GetEffectivePermissions 获取用户的有效权限及租户功能开关
+func (s *RoleService) GetEffectivePermissions(ctx context.Context, userID, tenantID uint64, merchantID *uint64) (*types.EffectivePermissions, error) {
+	// 权限解析结果由角色仓储按用户缓存，角色变更时自动失效
+	userPermissions, err := s.roleRepo.GetUserPermissions(ctx, userID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户权限失败: %w", err)
+	}
+
+	permissions := userPermissions.Permissions
+	if merchantID != nil {
+		// 商户用户只能看到商户范围内的权限
+		permissions = types.ScopePermissionsToMerchant(permissions)
+	}
+
+	features, err := s.getTenantFeatures(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.EffectivePermissions{
+		UserID:      userID,
+		TenantID:    tenantID,
+		MerchantID:  merchantID,
+		Roles:       userPermissions.Roles,
+		Permissions: permissions,
+		Features:    features,
+	}, nil
+}
+
+// getTenantFeatures 获取租户启用的功能开关
+func (s *RoleService) getTenantFeatures(ctx context.Context, tenantID uint64) ([]string, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取租户信息失败: %w", err)
+	}
+
+	if tenant == nil || tenant.Config == "" {
+		return []string{}, nil
+	}
+
+	var config types.TenantConfig
+	if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+		return nil, fmt.Errorf("解析租户配置失败: %w", err)
+	}
+
+	if config.Features == nil {
+		return []string{}, nil
+	}
+
+	return config.Features, nil
+}
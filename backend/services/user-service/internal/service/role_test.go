@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeRoleRepository 按角色定义解析权限的角色仓储桩
+type fakeRoleRepository struct {
+	repository.RoleRepository
+	userRoles map[uint64][]types.RoleType
+}
+
+func (f *fakeRoleRepository) GetUserPermissions(ctx context.Context, userID, tenantID uint64) (*types.UserPermissions, error) {
+	roles := f.userRoles[userID]
+	return &types.UserPermissions{
+		UserID:      userID,
+		TenantID:    tenantID,
+		Roles:       roles,
+		Permissions: types.ResolvePermissions(roles, types.GetDefaultRoles()),
+	}, nil
+}
+
+// fakeTenantRepository 租户仓储桩
+type fakeTenantRepository struct {
+	repository.ITenantRepository
+	config string
+}
+
+func (f *fakeTenantRepository) GetByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	return &types.Tenant{ID: id, Config: f.config}, nil
+}
+
+func TestGetEffectivePermissions(t *testing.T) {
+	Convey("用户有效权限查询测试", t, func() {
+		ctx := context.Background()
+		roleRepo := &fakeRoleRepository{
+			userRoles: map[uint64][]types.RoleType{
+				1: {types.RoleTenantAdmin},
+				2: {types.RoleMerchantOperator},
+				3: {types.RoleMerchantOperator, types.RoleTenantAdmin},
+			},
+		}
+		tenantRepo := &fakeTenantRepository{config: `{"features":["reports","fund_management"]}`}
+		roleService := NewRoleServiceForTest(roleRepo, tenantRepo)
+
+		Convey("返回的权限与分配的角色一致", func() {
+			result, err := roleService.GetEffectivePermissions(ctx, 1, 1, nil)
+			So(err, ShouldBeNil)
+			So(result.Roles, ShouldResemble, []types.RoleType{types.RoleTenantAdmin})
+			So(result.Permissions, ShouldResemble, types.GetDefaultRoles()[types.RoleTenantAdmin].Permissions)
+			So(result.Features, ShouldResemble, []string{"reports", "fund_management"})
+		})
+
+		Convey("商户操作员只获得商户级权限", func() {
+			merchantID := uint64(10)
+			result, err := roleService.GetEffectivePermissions(ctx, 2, 1, &merchantID)
+			So(err, ShouldBeNil)
+			So(result.Permissions, ShouldResemble, types.GetDefaultRoles()[types.RoleMerchantOperator].Permissions)
+			So(*result.MerchantID, ShouldEqual, merchantID)
+		})
+
+		Convey("商户用户不会看到租户管理员权限", func() {
+			merchantID := uint64(10)
+			result, err := roleService.GetEffectivePermissions(ctx, 3, 1, &merchantID)
+			So(err, ShouldBeNil)
+			So(result.Permissions, ShouldContain, types.PermissionMerchantOrderView)
+			So(result.Permissions, ShouldNotContain, types.PermissionUserManage)
+			So(result.Permissions, ShouldNotContain, types.PermissionRoleManage)
+			So(result.Permissions, ShouldNotContain, types.PermissionSystemConfig)
+		})
+
+		Convey("租户未配置功能开关时返回空列表", func() {
+			tenantRepo.config = ""
+			result, err := roleService.GetEffectivePermissions(ctx, 1, 1, nil)
+			So(err, ShouldBeNil)
+			So(result.Features, ShouldBeEmpty)
+		})
+	})
+}
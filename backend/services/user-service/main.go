@@ -2,7 +2,12 @@ package main
 
 import (
 	"github.com/gofromzero/mer-sys/backend/services/user-service/internal/controller"
+	"github.com/gofromzero/mer-sys/backend/services/user-service/internal/service"
 	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/handlers"
+	"github.com/gofromzero/mer-sys/backend/shared/middleware"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/os/gctx"
@@ -15,19 +20,43 @@ func main() {
 	auth.NewJWTManager()
 	ctx := gctx.GetInitCtx()
 
+	// 数据驻留配置映射了未定义的数据库分组时拒绝启动，避免租户数据落入共享数据库
+	if err := repository.InitTenantDatasourceConfig(ctx); err != nil {
+		g.Log().Fatalf(ctx, "数据驻留配置校验失败: %v", err)
+	}
+
 	s := g.Server()
 
+	// 应用连接池配置并暴露连接池指标
+	handlers.RegisterDBPoolMetrics(s, "user-service")
+
+	// 创建中间件实例
+	authMiddleware := middleware.NewAuthMiddleware()
+
 	// 创建控制器
 	authController := controller.NewAuthController()
 	merchantUserController := controller.NewMerchantUserController()
+	roleController := controller.NewRoleController()
+	anonymizationService := service.NewCustomerAnonymizationService()
+	anonymizationController := controller.NewCustomerAnonymizationController(anonymizationService)
 
 	// 注册路由
 	s.Group("/api/v1", func(group *ghttp.RouterGroup) {
+		// 记录访问日志，配置的路由按采样记录脱敏后的请求/响应体
+		group.Middleware(middleware.AccessLog(middleware.LoadAccessLogConfig(ctx)))
+
 		// 认证路由（公开，不需要认证）
 		group.Group("/auth", func(authGroup *ghttp.RouterGroup) {
 			authGroup.POST("/login", authController.Login)
 			authGroup.POST("/logout", authController.Logout)
 			authGroup.POST("/refresh", authController.RefreshToken)
+			authGroup.POST("/change-password", authController.ChangePassword)
+
+			// 敏感操作前重新验证身份（需要认证）
+			authGroup.Group("/", func(reauthGroup *ghttp.RouterGroup) {
+				reauthGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation)
+				reauthGroup.POST("/reauthenticate", authController.Reauthenticate)
+			})
 		})
 
 		// 需要认证的路由
@@ -35,6 +64,13 @@ func main() {
 			// TODO: 添加认证中间件
 			// userGroup.Middleware(middleware.Auth)
 			userGroup.GET("/info", authController.GetUserInfo)
+
+			// 当前用户有效权限和营销订阅偏好（需要认证）
+			userGroup.Group("/", func(permissionGroup *ghttp.RouterGroup) {
+				permissionGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation)
+				permissionGroup.GET("/permissions", roleController.GetCurrentUserPermissions)
+				permissionGroup.PUT("/marketing-preference", authController.UpdateMarketingPreference)
+			})
 		})
 
 		// 商户用户路由（需要认证）
@@ -53,6 +89,50 @@ func main() {
 			merchantUserGroup.GET("/audit-logs", merchantUserController.GetMerchantUserAuditLogs)
 			merchantUserGroup.GET("/:user_id/operation-history", merchantUserController.GetMerchantUserOperationHistory)
 		})
+
+		// 角色权限管理路由（需要认证）
+		group.Group("/roles", func(roleGroup *ghttp.RouterGroup) {
+			roleGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation)
+
+			// 查看角色列表 - 需要角色查看权限
+			roleGroup.GET("/",
+				authMiddleware.RequirePermissions(types.PermissionRoleView),
+				roleController.ListRoles)
+
+			// 创建自定义角色 - 需要角色管理权限
+			roleGroup.POST("/",
+				authMiddleware.RequirePermissions(types.PermissionRoleManage),
+				roleController.CreateRole)
+
+			// 授予/撤销角色权限 - 需要角色管理权限
+			roleGroup.POST("/:role/permissions",
+				authMiddleware.RequirePermissions(types.PermissionRoleManage),
+				roleController.GrantPermissions)
+			roleGroup.DELETE("/:role/permissions",
+				authMiddleware.RequirePermissions(types.PermissionRoleManage),
+				roleController.RevokePermissions)
+
+			// 为用户分配/撤销角色 - 需要角色分配权限
+			roleGroup.POST("/:role/users",
+				authMiddleware.RequirePermissions(types.PermissionRoleAssign),
+				roleController.AssignUserRole)
+			roleGroup.DELETE("/:role/users/:user_id",
+				authMiddleware.RequirePermissions(types.PermissionRoleAssign),
+				roleController.RevokeUserRole)
+		})
+
+		// 用户管理路由（需要认证）
+		group.Group("/users", func(usersGroup *ghttp.RouterGroup) {
+			usersGroup.Middleware(authMiddleware.JWTAuth, authMiddleware.TenantIsolation)
+
+			// 匿名化客户个人信息 - 需要用户删除权限，且操作不可撤销需重新验证身份
+			usersGroup.Group("/", func(anonymizeGroup *ghttp.RouterGroup) {
+				anonymizeGroup.Middleware(
+					authMiddleware.RequirePermissions(types.PermissionUserDelete),
+					authMiddleware.RequireStepUp(auth.StepUpOperationCustomerAnonymize))
+				anonymizeGroup.POST("/:id/anonymize", anonymizationController.AnonymizeUser)
+			})
+		})
 	})
 
 	// 健康检查端点
@@ -63,6 +143,9 @@ func main() {
 		})
 	})
 
+	// 启动客户个人信息保留期检查
+	anonymizationService.Start(ctx)
+
 	// 启动服务器
 	g.Log().Info(ctx, "用户服务启动中...")
 	s.SetPort(8081) // 用户服务端口
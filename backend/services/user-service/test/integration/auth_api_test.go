@@ -18,6 +18,7 @@ import (
 	"github.com/gofromzero/mer-sys/backend/shared/auth"
 	"github.com/gofromzero/mer-sys/backend/shared/config"
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/test/fixtures"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -63,41 +64,10 @@ func setupTestServer() *ghttp.Server {
 	return s
 }
 
-// setupTestUser 创建测试用户
-func setupTestUser(ctx context.Context, tenantID uint64) (*types.User, string, error) {
-	userRepo := repository.NewUserRepository()
-	
-	// 创建测试用户
-	testUser := &types.User{
-		UUID:     fmt.Sprintf("test-user-%d-%d", tenantID, time.Now().UnixNano()),
-		Username: fmt.Sprintf("testuser%d", tenantID),
-		Email:    fmt.Sprintf("test%d@example.com", tenantID),
-		Phone:    "13800138000",
-		Status:   types.UserStatusActive,
-		TenantID: tenantID,
-	}
-
-	password := "testpassword123"
-	
-	// 使用AuthService创建用户（自动处理密码加密）
-	authService := service.NewAuthService()
-	if err := authService.CreateUser(ctx, testUser, password); err != nil {
-		return nil, "", fmt.Errorf("创建测试用户失败: %v", err)
-	}
-
-	// 查询创建的用户
-	createdUser, err := userRepo.FindByUsernameAndTenant(ctx, testUser.Username, tenantID)
-	if err != nil {
-		return nil, "", fmt.Errorf("查询创建的用户失败: %v", err)
-	}
-
-	return createdUser, password, nil
-}
-
-// cleanupTestUser 清理测试用户
-func cleanupTestUser(ctx context.Context, userID uint64) {
-	userRepo := repository.NewUserRepository()
-	userRepo.DeleteByID(ctx, userID)
+// setupTestUser 创建测试用户，返回用户、明文密码和清理函数
+func setupTestUser(t *testing.T, tenantID uint64) (*types.User, string, func()) {
+	testUser, cleanup := fixtures.New(t).NewTestUser(tenantID)
+	return testUser, fixtures.DefaultPassword, cleanup
 }
 
 // makeHTTPRequest 发送HTTP请求并返回响应
@@ -144,12 +114,11 @@ func TestAuthAPIIntegration(t *testing.T) {
 		tenantID := uint64(1)
 
 		// 创建测试用户
-		testUser, testPassword, err := setupTestUser(ctx, tenantID)
-		So(err, ShouldBeNil)
+		testUser, testPassword, cleanup := setupTestUser(t, tenantID)
 		So(testUser, ShouldNotBeNil)
 
 		// 测试完成后清理
-		defer cleanupTestUser(ctx, testUser.ID)
+		defer cleanup()
 
 		Convey("用户登录API测试", func() {
 			Convey("成功登录", func() {
@@ -669,13 +638,11 @@ func TestAuthAPIBoundaryConditions(t *testing.T) {
 func TestConcurrentAuthRequests(t *testing.T) {
 	Convey("并发认证请求测试", t, func() {
 		server := setupTestServer()
-		ctx := context.Background()
 		tenantID := uint64(3)
 
 		// 创建测试用户
-		testUser, testPassword, err := setupTestUser(ctx, tenantID)
-		So(err, ShouldBeNil)
-		defer cleanupTestUser(ctx, testUser.ID)
+		testUser, testPassword, cleanup := setupTestUser(t, tenantID)
+		defer cleanup()
 
 		Convey("并发登录请求", func() {
 			concurrency := 10
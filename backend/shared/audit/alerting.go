@@ -0,0 +1,335 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// alertQueueSize 待评估告警规则的事件队列长度，队列满时丢弃事件并记录警告
+const alertQueueSize = 1024
+
+// severityRank 严重程度由低到高的排序
+var severityRank = map[AuditSeverity]int{
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityError:    3,
+	SeverityCritical: 4,
+}
+
+// AlertRule 审计告警规则：窗口内匹配的事件数达到阈值时触发告警
+type AlertRule struct {
+	EventType   AuditEventType `json:"event_type,omitempty"`   // 为空时匹配所有事件类型
+	MinSeverity AuditSeverity  `json:"min_severity,omitempty"` // 为空时不限严重程度
+	Threshold   int            `json:"threshold"`              // 小于等于1时每条匹配事件都告警
+	Window      time.Duration  `json:"window"`
+}
+
+// matches 事件是否匹配规则
+func (r AlertRule) matches(event AuditEvent) bool {
+	if r.EventType != "" && r.EventType != event.EventType {
+		return false
+	}
+	return severityRank[event.Severity] >= severityRank[r.MinSeverity]
+}
+
+// key 规则标识，同一事件类型和最低严重程度的租户规则覆盖全局规则
+func (r AlertRule) key() string {
+	return string(r.EventType) + "|" + string(r.MinSeverity)
+}
+
+// AlertPolicy 生效的告警规则
+type AlertPolicy struct {
+	Rules []AlertRule
+}
+
+// AuditAlert 审计告警，Event 为使计数达到阈值的事件
+type AuditAlert struct {
+	TenantID    uint64     `json:"tenant_id"`
+	Rule        AlertRule  `json:"rule"`
+	Count       int        `json:"count"`
+	WindowStart time.Time  `json:"window_start"`
+	Event       AuditEvent `json:"event"`
+	Message     string     `json:"message"`
+}
+
+// AlertNotifier 发送审计告警
+type AlertNotifier func(ctx context.Context, alert AuditAlert) error
+
+// AlertPolicyProvider 按租户获取告警规则，返回nil时只使用全局规则
+type AlertPolicyProvider func(ctx context.Context, tenantID uint64) (*types.AuditAlertPolicy, error)
+
+// LoadAlertPolicy 读取全局告警规则配置（audit.alerts.rules）
+func LoadAlertPolicy(ctx context.Context) *AlertPolicy {
+	var rules []types.AuditAlertRule
+	if err := g.Cfg().MustGet(ctx, "audit.alerts.rules").Scan(&rules); err != nil {
+		g.Log().Warningf(ctx, "解析审计告警规则失败，不启用全局告警规则: %v", err)
+		return &AlertPolicy{}
+	}
+	return &AlertPolicy{Rules: toAlertRules(rules)}
+}
+
+// LoadAlertNotifier 读取告警通知配置（audit.alerts.webhook_url），未配置 webhook 时只记录日志
+func LoadAlertNotifier(ctx context.Context) AlertNotifier {
+	if url := g.Cfg().MustGet(ctx, "audit.alerts.webhook_url").String(); url != "" {
+		return NewWebhookAlertNotifier(url)
+	}
+	return logAlert
+}
+
+// NewWebhookAlertNotifier 创建记录日志后将告警以 JSON 推送到 webhook 的通知器
+func NewWebhookAlertNotifier(url string) AlertNotifier {
+	return func(ctx context.Context, alert AuditAlert) error {
+		logAlert(ctx, alert)
+
+		response, err := g.Client().Timeout(5*time.Second).ContentJson().Post(ctx, url, alert)
+		if err != nil {
+			return fmt.Errorf("推送审计告警失败: %v", err)
+		}
+		defer response.Close()
+
+		if response.StatusCode >= 300 {
+			return fmt.Errorf("推送审计告警失败: webhook 返回状态码 %d", response.StatusCode)
+		}
+		return nil
+	}
+}
+
+// logAlert 将审计告警写入监控日志
+func logAlert(ctx context.Context, alert AuditAlert) error {
+	g.Log().Critical(ctx, "AUDIT_ALERT: %s %+v", alert.Message, alert)
+	return nil
+}
+
+// SetGlobalAlertPolicy 设置全局告警规则，为空时不启用全局规则
+func SetGlobalAlertPolicy(policy *AlertPolicy) {
+	defaultAlerter.mutex.Lock()
+	defer defaultAlerter.mutex.Unlock()
+
+	if policy == nil {
+		policy = &AlertPolicy{}
+	}
+	defaultAlerter.global = policy
+	defaultAlerter.cache = make(map[uint64]cachedAlertPolicy)
+}
+
+// SetAlertPolicyProvider 设置租户告警规则提供者，为空时所有租户只使用全局规则
+func SetAlertPolicyProvider(provider AlertPolicyProvider) {
+	defaultAlerter.mutex.Lock()
+	defer defaultAlerter.mutex.Unlock()
+
+	defaultAlerter.provider = provider
+	defaultAlerter.cache = make(map[uint64]cachedAlertPolicy)
+}
+
+// SetAlertNotifier 设置告警通知器，为空时只记录日志
+func SetAlertNotifier(notifier AlertNotifier) {
+	defaultAlerter.mutex.Lock()
+	defer defaultAlerter.mutex.Unlock()
+
+	if notifier == nil {
+		notifier = logAlert
+	}
+	defaultAlerter.notifier = notifier
+}
+
+// alerter 告警评估器：事件写入后进入队列，由后台协程按全局和租户规则在时间窗口内计数
+type alerter struct {
+	mutex    sync.RWMutex
+	global   *AlertPolicy
+	provider AlertPolicyProvider
+	cache    map[uint64]cachedAlertPolicy
+	ttl      time.Duration
+	notifier AlertNotifier
+	windows  map[alertKey][]time.Time
+	queue    chan AuditEvent
+	start    sync.Once
+}
+
+type cachedAlertPolicy struct {
+	policy    *AlertPolicy
+	expiresAt time.Time
+}
+
+type alertKey struct {
+	tenantID uint64
+	rule     string
+}
+
+var defaultAlerter = newAlerter()
+
+func newAlerter() *alerter {
+	return &alerter{
+		global:   &AlertPolicy{},
+		cache:    make(map[uint64]cachedAlertPolicy),
+		ttl:      5 * time.Minute,
+		notifier: logAlert,
+		windows:  make(map[alertKey][]time.Time),
+		queue:    make(chan AuditEvent, alertQueueSize),
+	}
+}
+
+// observe 将已写入的事件放入评估队列，不阻塞审计日志的写入
+func (a *alerter) observe(ctx context.Context, event AuditEvent) {
+	a.start.Do(func() { go a.run() })
+
+	select {
+	case a.queue <- event:
+	default:
+		g.Log().Warningf(ctx, "审计告警队列已满，事件未参与告警评估 - 事件: %s, 类型: %s", event.EventID, event.EventType)
+	}
+}
+
+// run 逐个评估队列中的事件并发送触发的告警
+func (a *alerter) run() {
+	ctx := context.Background()
+	for event := range a.queue {
+		alerts := a.evaluate(ctx, event)
+
+		a.mutex.RLock()
+		notifier := a.notifier
+		a.mutex.RUnlock()
+
+		for _, alert := range alerts {
+			if err := notifier(ctx, alert); err != nil {
+				g.Log().Errorf(ctx, "发送审计告警失败 - 租户: %d, 事件类型: %s, 错误: %v", alert.TenantID, alert.Event.EventType, err)
+			}
+		}
+	}
+}
+
+// evaluate 按事件时间统计各规则窗口内的匹配事件，达到阈值时返回告警并重新开始计数
+func (a *alerter) evaluate(ctx context.Context, event AuditEvent) []AuditAlert {
+	policy := a.policyFor(ctx, event.TenantID)
+	at := event.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	var alerts []AuditAlert
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, rule := range policy.Rules {
+		if !rule.matches(event) {
+			continue
+		}
+
+		key := alertKey{tenantID: event.TenantID, rule: rule.key()}
+		window := a.windows[key]
+		// 丢弃窗口之外的事件
+		kept := window[:0]
+		for _, seen := range window {
+			if at.Sub(seen) < rule.Window {
+				kept = append(kept, seen)
+			}
+		}
+		kept = append(kept, at)
+
+		if len(kept) < rule.Threshold {
+			a.windows[key] = kept
+			continue
+		}
+
+		delete(a.windows, key)
+		message := fmt.Sprintf("租户%d发生%s事件", event.TenantID, describeRule(rule))
+		if rule.Threshold > 1 {
+			message = fmt.Sprintf("租户%d在%s内发生%d次%s事件", event.TenantID, rule.Window, len(kept), describeRule(rule))
+		}
+		alerts = append(alerts, AuditAlert{
+			TenantID:    event.TenantID,
+			Rule:        rule,
+			Count:       len(kept),
+			WindowStart: kept[0],
+			Event:       event,
+			Message:     message,
+		})
+	}
+	return alerts
+}
+
+// describeRule 告警消息中的事件描述
+func describeRule(rule AlertRule) string {
+	description := "审计"
+	if meta, ok := LookupEventType(rule.EventType); ok {
+		description = meta.Description
+	} else if rule.EventType != "" {
+		description = string(rule.EventType)
+	}
+	if rule.MinSeverity != "" {
+		description = fmt.Sprintf("%s及以上级别的%s", rule.MinSeverity, description)
+	}
+	return description
+}
+
+// policyFor 获取租户生效的告警规则
+func (a *alerter) policyFor(ctx context.Context, tenantID uint64) *AlertPolicy {
+	a.mutex.RLock()
+	global, provider := a.global, a.provider
+	cached, hit := a.cache[tenantID]
+	a.mutex.RUnlock()
+
+	if provider == nil || tenantID == 0 {
+		return global
+	}
+	if hit && time.Now().Before(cached.expiresAt) {
+		return cached.policy
+	}
+
+	policy := global
+	tenantPolicy, err := provider(ctx, tenantID)
+	if err != nil {
+		g.Log().Warningf(ctx, "获取租户审计告警规则失败，使用全局规则 - 租户: %d, 错误: %v", tenantID, err)
+	} else if tenantPolicy != nil {
+		policy = mergeAlertPolicy(global, tenantPolicy)
+	}
+
+	a.mutex.Lock()
+	a.cache[tenantID] = cachedAlertPolicy{policy: policy, expiresAt: time.Now().Add(a.ttl)}
+	a.mutex.Unlock()
+
+	return policy
+}
+
+// mergeAlertPolicy 将租户规则叠加到全局规则上，事件类型和最低严重程度相同的租户规则覆盖全局规则
+func mergeAlertPolicy(global *AlertPolicy, tenant *types.AuditAlertPolicy) *AlertPolicy {
+	tenantRules := toAlertRules(tenant.Rules)
+	overridden := make(map[string]bool, len(tenantRules))
+	for _, rule := range tenantRules {
+		overridden[rule.key()] = true
+	}
+
+	merged := &AlertPolicy{Rules: make([]AlertRule, 0, len(global.Rules)+len(tenantRules))}
+	for _, rule := range global.Rules {
+		if !overridden[rule.key()] {
+			merged.Rules = append(merged.Rules, rule)
+		}
+	}
+	merged.Rules = append(merged.Rules, tenantRules...)
+	return merged
+}
+
+// toAlertRules 转换配置中的告警规则，忽略严重程度无效或需要计数却没有统计窗口的规则
+func toAlertRules(configured []types.AuditAlertRule) []AlertRule {
+	rules := make([]AlertRule, 0, len(configured))
+	for _, rule := range configured {
+		severity := AuditSeverity(rule.MinSeverity)
+		if _, ok := severityRank[severity]; severity != "" && !ok {
+			continue
+		}
+		if rule.Threshold > 1 && rule.WindowSeconds <= 0 {
+			continue
+		}
+		rules = append(rules, AlertRule{
+			EventType:   AuditEventType(rule.EventType),
+			MinSeverity: severity,
+			Threshold:   rule.Threshold,
+			Window:      time.Duration(rule.WindowSeconds) * time.Second,
+		})
+	}
+	return rules
+}
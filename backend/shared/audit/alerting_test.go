@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// evaluateEvents 依次评估同类事件，返回触发的告警
+func evaluateEvents(a *alerter, tenantID uint64, eventType AuditEventType, severity AuditSeverity, times ...time.Time) []AuditAlert {
+	var alerts []AuditAlert
+	for _, at := range times {
+		event := AuditEvent{TenantID: tenantID, EventType: eventType, Severity: severity, Timestamp: at}
+		alerts = append(alerts, a.evaluate(context.Background(), event)...)
+	}
+	return alerts
+}
+
+func TestAlerting(t *testing.T) {
+	Convey("审计事件阈值告警测试", t, func() {
+		a := newAlerter()
+		a.global = &AlertPolicy{Rules: toAlertRules([]types.AuditAlertRule{
+			// 10分钟内超过2次冻结资金
+			{EventType: string(EventFundFreeze), MinSeverity: string(SeverityWarning), Threshold: 3, WindowSeconds: 600},
+			// 任何 error 级别的删除商户用户
+			{EventType: string(EventMerchantUserDelete), MinSeverity: string(SeverityError), Threshold: 1},
+		})}
+		base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+		Convey("窗口内事件数达到阈值时告警，之后重新计数", func() {
+			So(evaluateEvents(a, 1, EventFundFreeze, SeverityWarning, base, base.Add(time.Minute)), ShouldBeEmpty)
+
+			alerts := evaluateEvents(a, 1, EventFundFreeze, SeverityWarning, base.Add(2*time.Minute))
+			So(alerts, ShouldHaveLength, 1)
+			So(alerts[0].TenantID, ShouldEqual, 1)
+			So(alerts[0].Count, ShouldEqual, 3)
+			So(alerts[0].WindowStart, ShouldEqual, base)
+			So(alerts[0].Rule.EventType, ShouldEqual, EventFundFreeze)
+			So(alerts[0].Message, ShouldContainSubstring, "冻结资金")
+
+			So(evaluateEvents(a, 1, EventFundFreeze, SeverityWarning, base.Add(3*time.Minute)), ShouldBeEmpty)
+		})
+
+		Convey("超出窗口的事件不计入阈值", func() {
+			alerts := evaluateEvents(a, 1, EventFundFreeze, SeverityWarning,
+				base, base.Add(6*time.Minute), base.Add(12*time.Minute), base.Add(18*time.Minute))
+			So(alerts, ShouldBeEmpty)
+		})
+
+		Convey("各租户独立计数", func() {
+			So(evaluateEvents(a, 1, EventFundFreeze, SeverityWarning, base, base.Add(time.Minute)), ShouldBeEmpty)
+			So(evaluateEvents(a, 2, EventFundFreeze, SeverityWarning, base.Add(2*time.Minute)), ShouldBeEmpty)
+		})
+
+		Convey("严重程度低于规则要求的事件不告警", func() {
+			So(evaluateEvents(a, 1, EventFundFreeze, SeverityInfo, base, base, base, base), ShouldBeEmpty)
+			So(evaluateEvents(a, 1, EventMerchantUserDelete, SeverityWarning, base), ShouldBeEmpty)
+
+			alerts := evaluateEvents(a, 1, EventMerchantUserDelete, SeverityError, base)
+			So(alerts, ShouldHaveLength, 1)
+			So(alerts[0].Message, ShouldContainSubstring, "删除商户用户")
+		})
+
+		Convey("租户规则覆盖同一事件类型和严重程度的全局规则", func() {
+			a.provider = func(ctx context.Context, tenantID uint64) (*types.AuditAlertPolicy, error) {
+				if tenantID != 1 {
+					return nil, nil
+				}
+				return &types.AuditAlertPolicy{Rules: []types.AuditAlertRule{
+					{EventType: string(EventFundFreeze), MinSeverity: string(SeverityWarning), Threshold: 5, WindowSeconds: 600},
+					{EventType: string(EventFundBatchDeposit), Threshold: 1},
+				}}, nil
+			}
+
+			times := []time.Time{base, base, base, base}
+			So(evaluateEvents(a, 1, EventFundFreeze, SeverityWarning, times...), ShouldBeEmpty)
+			So(evaluateEvents(a, 1, EventFundFreeze, SeverityWarning, base), ShouldHaveLength, 1)
+			So(evaluateEvents(a, 1, EventFundBatchDeposit, SeverityWarning, base), ShouldHaveLength, 1)
+			So(evaluateEvents(a, 1, EventMerchantUserDelete, SeverityError, base), ShouldHaveLength, 1)
+
+			// 未配置租户规则的租户使用全局规则
+			So(evaluateEvents(a, 2, EventFundFreeze, SeverityWarning, times[:3]...), ShouldHaveLength, 1)
+			So(evaluateEvents(a, 2, EventFundBatchDeposit, SeverityWarning, base), ShouldBeEmpty)
+		})
+
+		Convey("需要计数但没有统计窗口或严重程度无效的规则被忽略", func() {
+			rules := toAlertRules([]types.AuditAlertRule{
+				{EventType: string(EventFundFreeze), Threshold: 3},
+				{EventType: string(EventFundFreeze), MinSeverity: "fatal", Threshold: 1},
+			})
+			So(rules, ShouldBeEmpty)
+		})
+
+		Convey("写入的事件进入队列后发送告警", func() {
+			fired := make(chan AuditAlert, 1)
+			a.notifier = func(ctx context.Context, alert AuditAlert) error {
+				fired <- alert
+				return nil
+			}
+
+			a.observe(context.Background(), AuditEvent{TenantID: 3, EventType: EventMerchantUserDelete, Severity: SeverityError, Timestamp: base})
+			select {
+			case alert := <-fired:
+				So(alert.TenantID, ShouldEqual, 3)
+			case <-time.After(time.Second):
+				So("未收到告警", ShouldBeEmpty)
+			}
+		})
+	})
+}
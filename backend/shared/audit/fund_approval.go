@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LogFundApprovalRequested 记录大额资金操作提交审批
+func (l *AuditLogger) LogFundApprovalRequested(ctx context.Context, tenantID, merchantID, operatorID uint64, approvalID uint64, action string, amount float64, requiredApprovals int) {
+	event := AuditEvent{
+		EventType:    EventFundApprovalRequested,
+		TenantID:     tenantID,
+		UserID:       operatorID,
+		MerchantID:   &merchantID,
+		ResourceType: "fund_approval",
+		ResourceID:   fmt.Sprintf("%d", approvalID),
+		Action:       action,
+		IPAddress:    l.getIPAddress(ctx),
+		UserAgent:    l.getUserAgent(ctx),
+		Message:      fmt.Sprintf("商户ID:%d的%s金额%.2f超过审批阈值，等待%d人审批", merchantID, action, amount, requiredApprovals),
+		Details: map[string]interface{}{
+			"amount":             amount,
+			"required_approvals": requiredApprovals,
+		},
+		Timestamp: time.Now(),
+	}
+
+	l.logEvent(ctx, event)
+}
+
+// LogFundApprovalDecision 记录审批人的审批意见，completed 表示审批已结束（入账或驳回）
+func (l *AuditLogger) LogFundApprovalDecision(ctx context.Context, tenantID, merchantID, approverID uint64, approvalID uint64, approved bool, approvalCount, requiredApprovals int, completed bool, comment string) {
+	eventType, action, message := EventFundApprovalApproved, "approve", "审批通过"
+	if !approved {
+		eventType, action, message = EventFundApprovalRejected, "reject", "审批驳回"
+	}
+	if approved && completed {
+		message = "审批通过，审批人数已满足，资金已入账"
+	}
+
+	event := AuditEvent{
+		EventType:    eventType,
+		TenantID:     tenantID,
+		UserID:       approverID,
+		MerchantID:   &merchantID,
+		ResourceType: "fund_approval",
+		ResourceID:   fmt.Sprintf("%d", approvalID),
+		Action:       action,
+		IPAddress:    l.getIPAddress(ctx),
+		UserAgent:    l.getUserAgent(ctx),
+		Message:      fmt.Sprintf("资金审批%d: %s（%d/%d）", approvalID, message, approvalCount, requiredApprovals),
+		Details: map[string]interface{}{
+			"approval_count":     approvalCount,
+			"required_approvals": requiredApprovals,
+			"completed":          completed,
+			"comment":            comment,
+		},
+		Timestamp: time.Now(),
+	}
+
+	l.logEvent(ctx, event)
+}
+
+// LogFundApprovalRequested 全局函数：记录大额资金操作提交审批
+func LogFundApprovalRequested(ctx context.Context, tenantID, merchantID, operatorID uint64, approvalID uint64, action string, amount float64, requiredApprovals int) {
+	defaultAuditLogger.LogFundApprovalRequested(ctx, tenantID, merchantID, operatorID, approvalID, action, amount, requiredApprovals)
+}
+
+// LogFundApprovalDecision 全局函数：记录审批人的审批意见
+func LogFundApprovalDecision(ctx context.Context, tenantID, merchantID, approverID uint64, approvalID uint64, approved bool, approvalCount, requiredApprovals int, completed bool, comment string) {
+	defaultAuditLogger.LogFundApprovalDecision(ctx, tenantID, merchantID, approverID, approvalID, approved, approvalCount, requiredApprovals, completed, comment)
+}
@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LogFundRightsRefund 记录订单退款后退还给商户的权益，operatorID 为 0 表示系统操作
+func (l *AuditLogger) LogFundRightsRefund(ctx context.Context, tenantID, merchantID, operatorID uint64, orderID, fundID uint64, amount, refundAmount float64, reason string) {
+	event := AuditEvent{
+		EventType:    EventFundRightsRefund,
+		TenantID:     tenantID,
+		UserID:       operatorID,
+		MerchantID:   &merchantID,
+		ResourceType: "fund",
+		ResourceID:   fmt.Sprintf("%d", fundID),
+		Action:       "rights_refund",
+		IPAddress:    l.getIPAddress(ctx),
+		UserAgent:    l.getUserAgent(ctx),
+		Message:      fmt.Sprintf("订单%d退款%.2f，退还商户ID:%d权益%.2f", orderID, refundAmount, merchantID, amount),
+		Details: map[string]interface{}{
+			"order_id":      orderID,
+			"amount":        amount,
+			"refund_amount": refundAmount,
+			"reason":        reason,
+		},
+		Timestamp: time.Now(),
+	}
+
+	l.logEvent(ctx, event)
+}
+
+// LogFundRightsRefund 全局函数：记录订单退款后退还给商户的权益
+func LogFundRightsRefund(ctx context.Context, tenantID, merchantID, operatorID uint64, orderID, fundID uint64, amount, refundAmount float64, reason string) {
+	defaultAuditLogger.LogFundRightsRefund(ctx, tenantID, merchantID, operatorID, orderID, fundID, amount, refundAmount, reason)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/guid"
 )
 
 // AuditEventType 审计事件类型
@@ -35,6 +36,14 @@ const (
 	EventFundUnfreeze          AuditEventType = "fund_unfreeze"
 	EventFundBalanceQuery      AuditEventType = "fund_balance_query"
 	EventFundTransactionQuery  AuditEventType = "fund_transaction_query"
+	EventFundApprovalRequested AuditEventType = "fund_approval_requested"
+	EventFundApprovalApproved  AuditEventType = "fund_approval_approved"
+	EventFundApprovalRejected  AuditEventType = "fund_approval_rejected"
+	EventFundRightsRefund      AuditEventType = "fund_rights_refund"
+	EventSettlementGenerated   AuditEventType = "settlement_generated"
+	EventSettlementPaid        AuditEventType = "settlement_paid"
+	// 运维相关事件
+	EventMaintenanceMode       AuditEventType = "maintenance_mode"
 )
 
 // AuditSeverity 审计事件严重程度
@@ -49,8 +58,10 @@ const (
 
 // AuditEvent 审计事件
 type AuditEvent struct {
+	EventID         string         `json:"event_id"` // 事件唯一标识，附件等证明材料通过它关联到事件
 	EventType       AuditEventType `json:"event_type"`
-	Severity        AuditSeverity  `json:"severity"`
+	Severity        AuditSeverity  `json:"severity"` // 未指定时取事件类型登记的默认严重程度
+	Category        AuditCategory  `json:"category,omitempty"`
 	TenantID        uint64         `json:"tenant_id"`
 	UserID          uint64         `json:"user_id,omitempty"`
 	MerchantID      *uint64        `json:"merchant_id,omitempty"`    // 商户ID
@@ -63,6 +74,7 @@ type AuditEvent struct {
 	UserAgent       string         `json:"user_agent,omitempty"`
 	Message         string         `json:"message"`
 	Details         interface{}    `json:"details,omitempty"`
+	SampleRate      int            `json:"sample_rate,omitempty"` // 采样记录时该事件代表的同类事件数
 	Timestamp       time.Time      `json:"timestamp"`
 }
 
@@ -79,7 +91,6 @@ func NewAuditLogger() *AuditLogger {
 func (l *AuditLogger) LogCrossTenantAttempt(ctx context.Context, userTenantID, requestedTenantID uint64, resourceType, action string, details interface{}) {
 	event := AuditEvent{
 		EventType:       EventCrossTenantAttempt,
-		Severity:        SeverityCritical,
 		TenantID:        userTenantID,
 		UserID:          l.getUserID(ctx),
 		ResourceType:    resourceType,
@@ -99,7 +110,6 @@ func (l *AuditLogger) LogCrossTenantAttempt(ctx context.Context, userTenantID, r
 func (l *AuditLogger) LogTenantAccess(ctx context.Context, tenantID uint64, resourceType, action string, details interface{}) {
 	event := AuditEvent{
 		EventType:    EventTenantAccess,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       l.getUserID(ctx),
 		ResourceType: resourceType,
@@ -118,7 +128,6 @@ func (l *AuditLogger) LogTenantAccess(ctx context.Context, tenantID uint64, reso
 func (l *AuditLogger) LogDataQuery(ctx context.Context, tenantID uint64, resourceType, query string, rowCount int) {
 	event := AuditEvent{
 		EventType:    EventDataQuery,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       l.getUserID(ctx),
 		ResourceType: resourceType,
@@ -138,7 +147,6 @@ func (l *AuditLogger) LogDataQuery(ctx context.Context, tenantID uint64, resourc
 func (l *AuditLogger) LogSecurityViolation(ctx context.Context, tenantID uint64, violationType, message string, details interface{}) {
 	event := AuditEvent{
 		EventType:    EventSecurityViolation,
-		Severity:     SeverityError,
 		TenantID:     tenantID,
 		UserID:       l.getUserID(ctx),
 		ResourceType: "security",
@@ -157,7 +165,6 @@ func (l *AuditLogger) LogSecurityViolation(ctx context.Context, tenantID uint64,
 func (l *AuditLogger) LogMerchantUserLogin(ctx context.Context, tenantID, merchantID, userID uint64, username, ipAddress string, details interface{}) {
 	event := AuditEvent{
 		EventType:    EventMerchantUserLogin,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       userID,
 		MerchantID:   &merchantID,
@@ -177,7 +184,6 @@ func (l *AuditLogger) LogMerchantUserLogin(ctx context.Context, tenantID, mercha
 func (l *AuditLogger) LogMerchantUserLogout(ctx context.Context, tenantID, merchantID, userID uint64, username string, details interface{}) {
 	event := AuditEvent{
 		EventType:    EventMerchantUserLogout,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       userID,
 		MerchantID:   &merchantID,
@@ -197,7 +203,6 @@ func (l *AuditLogger) LogMerchantUserLogout(ctx context.Context, tenantID, merch
 func (l *AuditLogger) LogMerchantUserCreate(ctx context.Context, tenantID, merchantID, operatorUserID, targetUserID uint64, targetUsername string, details interface{}) {
 	event := AuditEvent{
 		EventType:    EventMerchantUserCreate,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       operatorUserID,
 		MerchantID:   &merchantID,
@@ -219,7 +224,6 @@ func (l *AuditLogger) LogMerchantUserCreate(ctx context.Context, tenantID, merch
 func (l *AuditLogger) LogMerchantUserUpdate(ctx context.Context, tenantID, merchantID, operatorUserID, targetUserID uint64, targetUsername string, changes map[string]interface{}) {
 	event := AuditEvent{
 		EventType:    EventMerchantUserUpdate,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       operatorUserID,
 		MerchantID:   &merchantID,
@@ -258,7 +262,7 @@ func (l *AuditLogger) LogMerchantUserStatusChange(ctx context.Context, tenantID,
 
 	event := AuditEvent{
 		EventType:    eventType,
-		Severity:     SeverityWarning, // 状态变更为警告级别
+		Severity:     SeverityWarning, // 其他状态变更记为更新事件，但与启用、禁用一样按警告级别记录
 		TenantID:     tenantID,
 		UserID:       operatorUserID,
 		MerchantID:   &merchantID,
@@ -284,7 +288,6 @@ func (l *AuditLogger) LogMerchantUserStatusChange(ctx context.Context, tenantID,
 func (l *AuditLogger) LogMerchantUserPasswordReset(ctx context.Context, tenantID, merchantID, operatorUserID, targetUserID uint64, targetUsername string, resetMethod string) {
 	event := AuditEvent{
 		EventType:    EventMerchantUserPassword,
-		Severity:     SeverityWarning, // 密码重置为警告级别
 		TenantID:     tenantID,
 		UserID:       operatorUserID,
 		MerchantID:   &merchantID,
@@ -308,7 +311,6 @@ func (l *AuditLogger) LogMerchantUserPasswordReset(ctx context.Context, tenantID
 func (l *AuditLogger) LogMerchantUserDelete(ctx context.Context, tenantID, merchantID, operatorUserID, targetUserID uint64, targetUsername string, details interface{}) {
 	event := AuditEvent{
 		EventType:    EventMerchantUserDelete,
-		Severity:     SeverityError, // 删除操作为错误级别
 		TenantID:     tenantID,
 		UserID:       operatorUserID,
 		MerchantID:   &merchantID,
@@ -330,7 +332,6 @@ func (l *AuditLogger) LogMerchantUserDelete(ctx context.Context, tenantID, merch
 func (l *AuditLogger) LogMerchantOperation(ctx context.Context, tenantID, merchantID, userID uint64, resourceType, action, message string, details interface{}) {
 	event := AuditEvent{
 		EventType:    EventMerchantOperation,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       userID,
 		MerchantID:   &merchantID,
@@ -346,8 +347,25 @@ func (l *AuditLogger) LogMerchantOperation(ctx context.Context, tenantID, mercha
 	l.logEvent(ctx, event)
 }
 
-// logEvent 记录审计事件
+// logEvent 按采样策略记录审计事件
 func (l *AuditLogger) logEvent(ctx context.Context, event AuditEvent) {
+	applyEventDefaults(&event)
+	if !defaultSampler.admit(ctx, &event) {
+		return
+	}
+	l.writeEvent(ctx, event)
+}
+
+// writeEvent 写入审计事件
+func (l *AuditLogger) writeEvent(ctx context.Context, event AuditEvent) {
+	applyEventDefaults(&event)
+	if event.EventID == "" {
+		event.EventID = guid.S()
+	}
+
+	// 序列化前脱敏敏感字段
+	event = l.maskEvent(ctx, event)
+
 	// 序列化事件为JSON
 	eventJSON, err := json.Marshal(event)
 	if err != nil {
@@ -373,6 +391,15 @@ func (l *AuditLogger) logEvent(ctx context.Context, event AuditEvent) {
 	if event.Severity == SeverityCritical {
 		l.sendToMonitoring(ctx, event)
 	}
+
+	// 按告警规则异步评估，窗口内事件数达到阈值时发送告警
+	defaultAlerter.observe(ctx, event)
+}
+
+// maskEvent 按租户脱敏策略处理事件详情，IP、用户等定位信息保持原样以便追查
+func (l *AuditLogger) maskEvent(ctx context.Context, event AuditEvent) AuditEvent {
+	event.Details = MaskDetails(ctx, event.TenantID, event.Details)
+	return event
 }
 
 // getUserID 从上下文获取用户ID
@@ -465,7 +492,6 @@ func LogOperation(ctx context.Context, resourceType, action string, details inte
 
 	event := AuditEvent{
 		EventType:    EventTenantAccess,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       userID,
 		ResourceType: resourceType,
@@ -477,7 +503,8 @@ func LogOperation(ctx context.Context, resourceType, action string, details inte
 		Timestamp:    time.Now(),
 	}
 
-	defaultAuditLogger.logEvent(ctx, event)
+	// 业务操作记录用于追溯，不参与采样
+	defaultAuditLogger.writeEvent(ctx, event)
 }
 
 // GetOperationLogs 获取操作日志（模拟实现）
@@ -580,6 +607,8 @@ func GetMerchantUserAuditLogs(ctx context.Context, merchantID uint64, userID *ui
 		},
 	}
 
+	describeLogs(logs)
+
 	// 模拟分页
 	total := len(logs)
 	if page > 1 {
@@ -605,7 +634,6 @@ func GetMerchantUserAuditLogs(ctx context.Context, merchantID uint64, userID *ui
 func (l *AuditLogger) LogFundDeposit(ctx context.Context, tenantID, merchantID, operatorID uint64, amount float64, currency string, fundID uint64, details interface{}) {
 	event := AuditEvent{
 		EventType:    EventFundDeposit,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       operatorID,
 		MerchantID:   &merchantID,
@@ -627,23 +655,25 @@ func (l *AuditLogger) LogFundDeposit(ctx context.Context, tenantID, merchantID,
 	l.logEvent(ctx, event)
 }
 
-// LogFundBatchDeposit 记录批量资金充值操作
-func (l *AuditLogger) LogFundBatchDeposit(ctx context.Context, tenantID, operatorID uint64, batchCount int, totalAmount float64, fundIDs []uint64, details interface{}) {
+// LogFundBatchDeposit 记录批量资金充值操作，成功、失败笔数和总金额以实际入账为准
+func (l *AuditLogger) LogFundBatchDeposit(ctx context.Context, tenantID, operatorID uint64, mode string, succeeded, failed int, totalAmount float64, fundIDs []uint64, details interface{}) {
 	event := AuditEvent{
 		EventType:    EventFundBatchDeposit,
-		Severity:     SeverityWarning, // 批量操作使用警告级别
 		TenantID:     tenantID,
 		UserID:       operatorID,
 		ResourceType: "fund",
 		Action:       "batch_deposit",
 		IPAddress:    l.getIPAddress(ctx),
 		UserAgent:    l.getUserAgent(ctx),
-		Message:      fmt.Sprintf("批量充值操作：%d笔充值，总金额%.2f", batchCount, totalAmount),
+		Message:      fmt.Sprintf("批量充值操作(%s)：成功%d笔，失败%d笔，入账总金额%.2f", mode, succeeded, failed, totalAmount),
 		Details: map[string]interface{}{
-			"batch_count":  batchCount,
-			"total_amount": totalAmount,
-			"fund_ids":     fundIDs,
-			"details":      details,
+			"mode":            mode,
+			"batch_count":     succeeded + failed,
+			"succeeded_count": succeeded,
+			"failed_count":    failed,
+			"total_amount":    totalAmount,
+			"fund_ids":        fundIDs,
+			"details":         details,
 		},
 		Timestamp: time.Now(),
 	}
@@ -655,7 +685,6 @@ func (l *AuditLogger) LogFundBatchDeposit(ctx context.Context, tenantID, operato
 func (l *AuditLogger) LogFundAllocate(ctx context.Context, tenantID, merchantID, operatorID uint64, amount float64, fundID uint64, details interface{}) {
 	event := AuditEvent{
 		EventType:    EventFundAllocate,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       operatorID,
 		MerchantID:   &merchantID,
@@ -680,7 +709,6 @@ func (l *AuditLogger) LogFundAllocate(ctx context.Context, tenantID, merchantID,
 func (l *AuditLogger) LogFundFreeze(ctx context.Context, tenantID, merchantID, operatorID uint64, amount float64, reason string, details interface{}) {
 	event := AuditEvent{
 		EventType:    EventFundFreeze,
-		Severity:     SeverityWarning, // 冻结操作使用警告级别
 		TenantID:     tenantID,
 		UserID:       operatorID,
 		MerchantID:   &merchantID,
@@ -704,7 +732,6 @@ func (l *AuditLogger) LogFundFreeze(ctx context.Context, tenantID, merchantID, o
 func (l *AuditLogger) LogFundUnfreeze(ctx context.Context, tenantID, merchantID, operatorID uint64, amount float64, reason string, details interface{}) {
 	event := AuditEvent{
 		EventType:    EventFundUnfreeze,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       operatorID,
 		MerchantID:   &merchantID,
@@ -728,7 +755,6 @@ func (l *AuditLogger) LogFundUnfreeze(ctx context.Context, tenantID, merchantID,
 func (l *AuditLogger) LogFundBalanceQuery(ctx context.Context, tenantID, merchantID, operatorID uint64, balance *map[string]interface{}) {
 	event := AuditEvent{
 		EventType:    EventFundBalanceQuery,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       operatorID,
 		MerchantID:   &merchantID,
@@ -750,7 +776,6 @@ func (l *AuditLogger) LogFundBalanceQuery(ctx context.Context, tenantID, merchan
 func (l *AuditLogger) LogFundTransactionQuery(ctx context.Context, tenantID, operatorID uint64, query map[string]interface{}, resultCount int) {
 	event := AuditEvent{
 		EventType:    EventFundTransactionQuery,
-		Severity:     SeverityInfo,
 		TenantID:     tenantID,
 		UserID:       operatorID,
 		ResourceType: "fund",
@@ -776,8 +801,8 @@ func LogFundDeposit(ctx context.Context, tenantID, merchantID, operatorID uint64
 }
 
 // LogFundBatchDeposit 全局函数：记录批量资金充值操作
-func LogFundBatchDeposit(ctx context.Context, tenantID, operatorID uint64, batchCount int, totalAmount float64, fundIDs []uint64, details interface{}) {
-	defaultAuditLogger.LogFundBatchDeposit(ctx, tenantID, operatorID, batchCount, totalAmount, fundIDs, details)
+func LogFundBatchDeposit(ctx context.Context, tenantID, operatorID uint64, mode string, succeeded, failed int, totalAmount float64, fundIDs []uint64, details interface{}) {
+	defaultAuditLogger.LogFundBatchDeposit(ctx, tenantID, operatorID, mode, succeeded, failed, totalAmount, fundIDs, details)
 }
 
 // LogFundAllocate 全局函数：记录权益分配操作
@@ -877,6 +902,8 @@ func GetFundAuditLogs(ctx context.Context, tenantID uint64, merchantID *uint64,
 		logs = filteredLogs
 	}
 
+	describeLogs(logs)
+
 	// 模拟分页
 	total := len(logs)
 	if page > 1 {
@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// LogMaintenanceModeChange 记录维护模式的开启与关闭，previousEnabled 为变更前是否处于维护模式
+func (l *AuditLogger) LogMaintenanceModeChange(ctx context.Context, state *types.MaintenanceState, previousEnabled bool) {
+	action, message := "disable", "关闭维护模式"
+	if state.Enabled {
+		action, message = "enable", "开启维护模式，写操作将被拒绝"
+	}
+
+	resourceID := string(types.MaintenanceScopeGlobal)
+	if state.Scope == types.MaintenanceScopeTenant {
+		resourceID = fmt.Sprintf("tenant:%d", state.TenantID)
+	}
+
+	event := AuditEvent{
+		EventType:    EventMaintenanceMode,
+		TenantID:     state.TenantID,
+		UserID:       state.UpdatedBy,
+		ResourceType: "maintenance_mode",
+		ResourceID:   resourceID,
+		Action:       action,
+		IPAddress:    l.getIPAddress(ctx),
+		UserAgent:    l.getUserAgent(ctx),
+		Message:      fmt.Sprintf("%s（%s）", message, resourceID),
+		Details: map[string]interface{}{
+			"previous_enabled": previousEnabled,
+			"reason":           state.Reason,
+			"retry_after":      state.RetryAfter,
+		},
+		Timestamp: time.Now(),
+	}
+
+	// 维护模式变更用于追溯，不参与采样
+	l.writeEvent(ctx, event)
+}
+
+// LogMaintenanceModeChange 全局函数：记录维护模式的开启与关闭
+func LogMaintenanceModeChange(ctx context.Context, state *types.MaintenanceState, previousEnabled bool) {
+	defaultAuditLogger.LogMaintenanceModeChange(ctx, state, previousEnabled)
+}
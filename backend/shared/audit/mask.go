@@ -0,0 +1,283 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// MaskRule 脱敏规则
+type MaskRule string
+
+const (
+	MaskRuleNone   MaskRule = "none"   // 不脱敏
+	MaskRulePhone  MaskRule = "phone"  // 手机号保留前3后4位：138****8000
+	MaskRuleEmail  MaskRule = "email"  // 邮箱保留用户名首字符和域名：z***@example.com
+	MaskRuleAmount MaskRule = "amount" // 金额整体隐藏
+	MaskRuleFull   MaskRule = "full"   // 整体隐藏（密码、令牌等）
+)
+
+const maskedValue = "******"
+
+var (
+	phonePattern = regexp.MustCompile(`1[3-9]\d{9}`)
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// MaskingPolicy 生效的脱敏策略
+type MaskingPolicy struct {
+	Fields      map[string]MaskRule // 字段名（小写）-> 脱敏规则
+	MaskAmounts bool                // 是否脱敏金额类字段
+}
+
+// MaskingPolicyProvider 按租户获取脱敏策略，返回nil时使用全局策略
+type MaskingPolicyProvider func(ctx context.Context, tenantID uint64) (*types.DataMaskingPolicy, error)
+
+// DefaultMaskingPolicy 返回全局默认脱敏策略
+func DefaultMaskingPolicy() *MaskingPolicy {
+	return &MaskingPolicy{
+		Fields: map[string]MaskRule{
+			"phone":            MaskRulePhone,
+			"mobile":           MaskRulePhone,
+			"contact_phone":    MaskRulePhone,
+			"email":            MaskRuleEmail,
+			"contact_email":    MaskRuleEmail,
+			"password":         MaskRuleFull,
+			"old_password":     MaskRuleFull,
+			"new_password":     MaskRuleFull,
+			"token":            MaskRuleFull,
+			"access_token":     MaskRuleFull,
+			"refresh_token":    MaskRuleFull,
+			"secret":           MaskRuleFull,
+			"id_card":          MaskRuleFull,
+			"bank_account":     MaskRuleFull,
+			"balance":          MaskRuleAmount,
+			"amount":           MaskRuleAmount,
+			"total_amount":     MaskRuleAmount,
+			"frozen_amount":    MaskRuleAmount,
+			"available_amount": MaskRuleAmount,
+		},
+		MaskAmounts: false,
+	}
+}
+
+// masker 脱敏器：全局策略叠加租户策略，租户策略缓存一段时间避免每条日志都查询
+type masker struct {
+	mutex    sync.RWMutex
+	global   *MaskingPolicy
+	provider MaskingPolicyProvider
+	cache    map[uint64]cachedMaskingPolicy
+	ttl      time.Duration
+}
+
+type cachedMaskingPolicy struct {
+	policy    *MaskingPolicy
+	expiresAt time.Time
+}
+
+var defaultMasker = &masker{
+	global: DefaultMaskingPolicy(),
+	cache:  make(map[uint64]cachedMaskingPolicy),
+	ttl:    5 * time.Minute,
+}
+
+// SetGlobalMaskingPolicy 设置全局脱敏策略
+func SetGlobalMaskingPolicy(policy *MaskingPolicy) {
+	defaultMasker.mutex.Lock()
+	defer defaultMasker.mutex.Unlock()
+
+	defaultMasker.global = policy
+	defaultMasker.cache = make(map[uint64]cachedMaskingPolicy)
+}
+
+// SetMaskingPolicyProvider 设置租户脱敏策略提供者，为空时所有租户使用全局策略
+func SetMaskingPolicyProvider(provider MaskingPolicyProvider) {
+	defaultMasker.mutex.Lock()
+	defer defaultMasker.mutex.Unlock()
+
+	defaultMasker.provider = provider
+	defaultMasker.cache = make(map[uint64]cachedMaskingPolicy)
+}
+
+// Mask 按规则脱敏单个值
+func Mask(value interface{}, rule MaskRule) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	switch rule {
+	case MaskRuleNone:
+		return value
+	case MaskRulePhone:
+		return maskPhone(fmt.Sprint(value))
+	case MaskRuleEmail:
+		return maskEmail(fmt.Sprint(value))
+	case MaskRuleAmount, MaskRuleFull:
+		return maskedValue
+	default:
+		return maskedValue
+	}
+}
+
+// MaskText 脱敏自由文本中出现的手机号和邮箱（如查询语句、错误信息）
+func MaskText(text string) string {
+	text = emailPattern.ReplaceAllStringFunc(text, maskEmail)
+	return phonePattern.ReplaceAllStringFunc(text, maskPhone)
+}
+
+// MaskDetails 按租户策略脱敏审计详情，返回脱敏后的副本，不修改原值
+func MaskDetails(ctx context.Context, tenantID uint64, details interface{}) interface{} {
+	if details == nil {
+		return nil
+	}
+
+	// 统一转换为通用结构，便于按字段名处理结构体和map
+	data, err := json.Marshal(details)
+	if err != nil {
+		return maskedValue
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return maskedValue
+	}
+
+	return defaultMasker.policyFor(ctx, tenantID).apply(generic)
+}
+
+// MaskJSON 脱敏JSON字符串（如请求体），非JSON内容按自由文本处理
+func MaskJSON(ctx context.Context, tenantID uint64, body string) string {
+	var generic interface{}
+	if err := json.Unmarshal([]byte(body), &generic); err != nil {
+		return MaskText(body)
+	}
+
+	masked, err := json.Marshal(defaultMasker.policyFor(ctx, tenantID).apply(generic))
+	if err != nil {
+		return maskedValue
+	}
+	return string(masked)
+}
+
+// apply 递归脱敏通用结构
+func (p *MaskingPolicy) apply(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if rule, exists := p.ruleFor(key); exists {
+				if _, nested := item.(map[string]interface{}); !nested {
+					result[key] = Mask(item, rule)
+					continue
+				}
+			}
+			result[key] = p.apply(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = p.apply(item)
+		}
+		return result
+	case string:
+		return MaskText(v)
+	default:
+		return v
+	}
+}
+
+// ruleFor 获取字段的脱敏规则，金额规则仅在启用金额脱敏时生效
+func (p *MaskingPolicy) ruleFor(key string) (MaskRule, bool) {
+	rule, exists := p.Fields[strings.ToLower(key)]
+	if !exists {
+		return "", false
+	}
+	if rule == MaskRuleAmount && !p.MaskAmounts {
+		return MaskRuleNone, true
+	}
+	return rule, true
+}
+
+// policyFor 获取租户生效的脱敏策略
+func (m *masker) policyFor(ctx context.Context, tenantID uint64) *MaskingPolicy {
+	m.mutex.RLock()
+	global, provider := m.global, m.provider
+	cached, hit := m.cache[tenantID]
+	m.mutex.RUnlock()
+
+	if provider == nil || tenantID == 0 {
+		return global
+	}
+	if hit && time.Now().Before(cached.expiresAt) {
+		return cached.policy
+	}
+
+	policy := global
+	tenantPolicy, err := provider(ctx, tenantID)
+	if err != nil {
+		g.Log().Warningf(ctx, "获取租户脱敏策略失败，使用全局策略 - 租户: %d, 错误: %v", tenantID, err)
+	} else if tenantPolicy != nil {
+		var rejected []string
+		policy, rejected = mergeMaskingPolicy(global, tenantPolicy)
+		if len(rejected) > 0 {
+			g.Log().Warningf(ctx, "租户脱敏策略不能放宽凭证类字段的整体隐藏，已忽略 - 租户: %d, 字段: %v", tenantID, rejected)
+		}
+	}
+
+	m.mutex.Lock()
+	m.cache[tenantID] = cachedMaskingPolicy{policy: policy, expiresAt: time.Now().Add(m.ttl)}
+	m.mutex.Unlock()
+
+	return policy
+}
+
+// mergeMaskingPolicy 将租户策略叠加到全局策略上。全局策略整体隐藏的字段（密码、令牌、密钥等）
+// 不允许租户改为其他规则，这类覆盖被忽略并按字段名排序返回
+func mergeMaskingPolicy(global *MaskingPolicy, tenant *types.DataMaskingPolicy) (*MaskingPolicy, []string) {
+	merged := &MaskingPolicy{
+		Fields:      make(map[string]MaskRule, len(global.Fields)+len(tenant.Fields)),
+		MaskAmounts: global.MaskAmounts,
+	}
+	for key, rule := range global.Fields {
+		merged.Fields[key] = rule
+	}
+	var rejected []string
+	for key, rule := range tenant.Fields {
+		key = strings.ToLower(key)
+		if global.Fields[key] == MaskRuleFull && MaskRule(rule) != MaskRuleFull {
+			rejected = append(rejected, key)
+			continue
+		}
+		merged.Fields[key] = MaskRule(rule)
+	}
+	if tenant.MaskAmounts != nil {
+		merged.MaskAmounts = *tenant.MaskAmounts
+	}
+	sort.Strings(rejected)
+	return merged, rejected
+}
+
+// maskPhone 手机号保留前3后4位
+func maskPhone(phone string) string {
+	if len(phone) < 7 {
+		return maskedValue
+	}
+	return phone[:3] + strings.Repeat("*", len(phone)-7) + phone[len(phone)-4:]
+}
+
+// maskEmail 邮箱保留用户名首字符和完整域名
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return maskedValue
+	}
+	return email[:1] + "***" + email[at:]
+}
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMask(t *testing.T) {
+	Convey("脱敏规则测试", t, func() {
+		Convey("手机号保留前3后4位", func() {
+			So(Mask("13812348000", MaskRulePhone), ShouldEqual, "138****8000")
+			So(Mask("12345", MaskRulePhone), ShouldEqual, maskedValue)
+		})
+
+		Convey("邮箱保留首字符和域名", func() {
+			So(Mask("zhangsan@example.com", MaskRuleEmail), ShouldEqual, "z***@example.com")
+			So(Mask("invalid", MaskRuleEmail), ShouldEqual, maskedValue)
+		})
+
+		Convey("金额和敏感凭证整体隐藏", func() {
+			So(Mask(1024.5, MaskRuleAmount), ShouldEqual, maskedValue)
+			So(Mask("p@ssw0rd", MaskRuleFull), ShouldEqual, maskedValue)
+		})
+
+		Convey("none规则保持原值", func() {
+			So(Mask("13812348000", MaskRuleNone), ShouldEqual, "13812348000")
+		})
+
+		Convey("自由文本中的手机号和邮箱被脱敏", func() {
+			masked := MaskText("SELECT * FROM users WHERE phone = '13812348000' OR email = 'li@corp.cn'")
+			So(masked, ShouldContainSubstring, "138****8000")
+			So(masked, ShouldContainSubstring, "l***@corp.cn")
+			So(masked, ShouldNotContainSubstring, "13812348000")
+		})
+	})
+}
+
+func TestMaskDetails(t *testing.T) {
+	Convey("审计详情脱敏测试", t, func() {
+		ctx := context.Background()
+		defer SetMaskingPolicyProvider(nil)
+
+		details := map[string]interface{}{
+			"username": "alice",
+			"phone":    "13812348000",
+			"profile": map[string]interface{}{
+				"email":    "alice@example.com",
+				"password": "secret",
+			},
+			"balance": 1000.0,
+		}
+
+		Convey("全局策略脱敏嵌套字段，默认不脱敏金额", func() {
+			masked := MaskDetails(ctx, 1, details).(map[string]interface{})
+			So(masked["username"], ShouldEqual, "alice")
+			So(masked["phone"], ShouldEqual, "138****8000")
+			profile := masked["profile"].(map[string]interface{})
+			So(profile["email"], ShouldEqual, "a***@example.com")
+			So(profile["password"], ShouldEqual, maskedValue)
+			So(masked["balance"], ShouldEqual, 1000.0)
+
+			// 原始详情不被修改
+			So(details["phone"], ShouldEqual, "13812348000")
+		})
+
+		Convey("租户策略可开启金额脱敏并追加字段", func() {
+			maskAmounts := true
+			SetMaskingPolicyProvider(func(ctx context.Context, tenantID uint64) (*types.DataMaskingPolicy, error) {
+				return &types.DataMaskingPolicy{
+					MaskAmounts: &maskAmounts,
+					Fields:      map[string]string{"Username": "full", "phone": "none"},
+				}, nil
+			})
+
+			masked := MaskDetails(ctx, 2, details).(map[string]interface{})
+			So(masked["balance"], ShouldEqual, maskedValue)
+			So(masked["username"], ShouldEqual, maskedValue)
+			So(masked["phone"], ShouldEqual, "13812348000")
+
+			// 其他租户不受影响
+			global := MaskDetails(ctx, 0, details).(map[string]interface{})
+			So(global["balance"], ShouldEqual, 1000.0)
+		})
+
+		Convey("租户策略不能放宽密码、令牌等字段的整体隐藏", func() {
+			SetMaskingPolicyProvider(func(ctx context.Context, tenantID uint64) (*types.DataMaskingPolicy, error) {
+				return &types.DataMaskingPolicy{
+					Fields: map[string]string{"Password": "none", "access_token": "email", "secret": "none", "refresh_token": "full"},
+				}, nil
+			})
+
+			body := MaskJSON(ctx, 3, `{"password":"123456","access_token":"eyJhbGciOi","secret":"s3cr3t","refresh_token":"r1"}`)
+			var parsed map[string]interface{}
+			So(json.Unmarshal([]byte(body), &parsed), ShouldBeNil)
+			So(parsed["password"], ShouldEqual, maskedValue)
+			So(parsed["access_token"], ShouldEqual, maskedValue)
+			So(parsed["secret"], ShouldEqual, maskedValue)
+			So(parsed["refresh_token"], ShouldEqual, maskedValue)
+
+			_, rejected := mergeMaskingPolicy(DefaultMaskingPolicy(), &types.DataMaskingPolicy{
+				Fields: map[string]string{"Password": "none", "access_token": "email", "phone": "none", "token": "full"},
+			})
+			So(rejected, ShouldResemble, []string{"access_token", "password"})
+		})
+
+		Convey("请求体JSON按字段脱敏", func() {
+			body := MaskJSON(ctx, 1, `{"username":"bob","password":"123456","contact_phone":"13900001111"}`)
+			var parsed map[string]interface{}
+			So(json.Unmarshal([]byte(body), &parsed), ShouldBeNil)
+			So(parsed["password"], ShouldEqual, maskedValue)
+			So(parsed["contact_phone"], ShouldEqual, "139****1111")
+			So(parsed["username"], ShouldEqual, "bob")
+		})
+
+		Convey("安全事件脱敏后仍保留追查所需信息", func() {
+			logger := NewAuditLogger()
+			event := logger.maskEvent(ctx, AuditEvent{
+				EventType: EventSecurityViolation,
+				Severity:  SeverityCritical,
+				TenantID:  1,
+				UserID:    42,
+				IPAddress: "203.0.113.7",
+				Action:    "brute_force",
+				Details: map[string]interface{}{
+					"username":  "alice",
+					"client_ip": "203.0.113.7",
+					"phone":     "13812348000",
+					"attempts":  12,
+				},
+			})
+
+			So(event.IPAddress, ShouldEqual, "203.0.113.7")
+			So(event.UserID, ShouldEqual, 42)
+			masked := event.Details.(map[string]interface{})
+			So(masked["username"], ShouldEqual, "alice")
+			So(masked["client_ip"], ShouldEqual, "203.0.113.7")
+			So(masked["attempts"], ShouldEqual, 12)
+			// 手机号保留末4位，仍可用于核对当事人
+			So(masked["phone"], ShouldEqual, "138****8000")
+		})
+	})
+}
@@ -0,0 +1,118 @@
+package audit
+
+import "sort"
+
+// AuditCategory 审计事件分类
+type AuditCategory string
+
+const (
+	CategorySecurity     AuditCategory = "security"
+	CategoryAccess       AuditCategory = "access"
+	CategoryMerchantUser AuditCategory = "merchant_user"
+	CategoryMerchant     AuditCategory = "merchant"
+	CategoryFund         AuditCategory = "fund"
+	CategoryOperations   AuditCategory = "operations"
+)
+
+// AuditEventMeta 审计事件类型的元数据，Severity 为该类事件的默认严重程度
+type AuditEventMeta struct {
+	EventType   AuditEventType `json:"event_type"`
+	Severity    AuditSeverity  `json:"severity"`
+	Category    AuditCategory  `json:"category"`
+	Description string         `json:"description"`
+}
+
+// eventRegistry 审计事件类型登记表，统一各类事件的默认严重程度：
+// 普通访问和查询为 info；批量、冻结、状态和密码变更等影响面较大的操作为 warning；
+// 删除和安全违规为 error；跨租户访问为 critical
+var eventRegistry = map[AuditEventType]AuditEventMeta{
+	EventCrossTenantAttempt: {Severity: SeverityCritical, Category: CategorySecurity, Description: "跨租户访问尝试"},
+	EventTenantAccess:       {Severity: SeverityInfo, Category: CategoryAccess, Description: "租户数据访问"},
+	EventDataQuery:          {Severity: SeverityInfo, Category: CategoryAccess, Description: "数据查询"},
+	EventSecurityViolation:  {Severity: SeverityError, Category: CategorySecurity, Description: "安全违规"},
+
+	EventMerchantUserLogin:    {Severity: SeverityInfo, Category: CategoryMerchantUser, Description: "商户用户登录"},
+	EventMerchantUserLogout:   {Severity: SeverityInfo, Category: CategoryMerchantUser, Description: "商户用户登出"},
+	EventMerchantUserCreate:   {Severity: SeverityInfo, Category: CategoryMerchantUser, Description: "创建商户用户"},
+	EventMerchantUserUpdate:   {Severity: SeverityInfo, Category: CategoryMerchantUser, Description: "更新商户用户"},
+	EventMerchantUserDelete:   {Severity: SeverityError, Category: CategoryMerchantUser, Description: "删除商户用户"},
+	EventMerchantUserDisable:  {Severity: SeverityWarning, Category: CategoryMerchantUser, Description: "禁用商户用户"},
+	EventMerchantUserEnable:   {Severity: SeverityWarning, Category: CategoryMerchantUser, Description: "启用商户用户"},
+	EventMerchantUserPassword: {Severity: SeverityWarning, Category: CategoryMerchantUser, Description: "重置商户用户密码"},
+	EventMerchantOperation:    {Severity: SeverityInfo, Category: CategoryMerchant, Description: "商户业务操作"},
+
+	EventFundDeposit:           {Severity: SeverityInfo, Category: CategoryFund, Description: "资金充值"},
+	EventFundBatchDeposit:      {Severity: SeverityWarning, Category: CategoryFund, Description: "批量资金充值"},
+	EventFundAllocate:          {Severity: SeverityInfo, Category: CategoryFund, Description: "权益分配"},
+	EventFundFreeze:            {Severity: SeverityWarning, Category: CategoryFund, Description: "冻结资金"},
+	EventFundUnfreeze:          {Severity: SeverityInfo, Category: CategoryFund, Description: "解冻资金"},
+	EventFundBalanceQuery:      {Severity: SeverityInfo, Category: CategoryFund, Description: "查询资金余额"},
+	EventFundTransactionQuery:  {Severity: SeverityInfo, Category: CategoryFund, Description: "查询资金交易记录"},
+	EventFundApprovalRequested: {Severity: SeverityInfo, Category: CategoryFund, Description: "提交资金审批"},
+	EventFundApprovalApproved:  {Severity: SeverityInfo, Category: CategoryFund, Description: "资金审批通过"},
+	EventFundApprovalRejected:  {Severity: SeverityInfo, Category: CategoryFund, Description: "资金审批驳回"},
+	EventFundRightsRefund:      {Severity: SeverityInfo, Category: CategoryFund, Description: "订单退款退还权益"},
+	EventSettlementGenerated:   {Severity: SeverityInfo, Category: CategoryFund, Description: "生成商户结算单"},
+	EventSettlementPaid:        {Severity: SeverityWarning, Category: CategoryFund, Description: "商户结算单付款"},
+
+	EventMaintenanceMode: {Severity: SeverityWarning, Category: CategoryOperations, Description: "维护模式变更"},
+}
+
+// LookupEventType 查询事件类型的元数据，未登记的事件类型返回 false
+func LookupEventType(eventType AuditEventType) (AuditEventMeta, bool) {
+	meta, ok := eventRegistry[eventType]
+	if !ok {
+		return AuditEventMeta{}, false
+	}
+	meta.EventType = eventType
+	return meta, true
+}
+
+// RegisteredEventTypes 按分类和事件类型排序返回所有登记的事件类型，供审计日志查询界面展示
+func RegisteredEventTypes() []AuditEventMeta {
+	metas := make([]AuditEventMeta, 0, len(eventRegistry))
+	for eventType := range eventRegistry {
+		meta, _ := LookupEventType(eventType)
+		metas = append(metas, meta)
+	}
+	sort.Slice(metas, func(i, j int) bool {
+		if metas[i].Category != metas[j].Category {
+			return metas[i].Category < metas[j].Category
+		}
+		return metas[i].EventType < metas[j].EventType
+	})
+	return metas
+}
+
+// DefaultSeverity 事件类型的默认严重程度，未登记的事件类型为 info
+func DefaultSeverity(eventType AuditEventType) AuditSeverity {
+	if meta, ok := eventRegistry[eventType]; ok {
+		return meta.Severity
+	}
+	return SeverityInfo
+}
+
+// applyEventDefaults 按登记表补全事件的分类和严重程度，调用方显式指定的严重程度优先
+func applyEventDefaults(event *AuditEvent) {
+	meta, ok := eventRegistry[event.EventType]
+	if event.Severity == "" {
+		event.Severity = SeverityInfo
+		if ok {
+			event.Severity = meta.Severity
+		}
+	}
+	if event.Category == "" && ok {
+		event.Category = meta.Category
+	}
+}
+
+// describeLogs 为审计日志查询结果补充事件类型的分类和说明
+func describeLogs(logs []map[string]interface{}) {
+	for _, log := range logs {
+		eventType, _ := log["event_type"].(string)
+		if meta, ok := LookupEventType(AuditEventType(eventType)); ok {
+			log["category"] = meta.Category
+			log["event_description"] = meta.Description
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+
+	"github.com/gogf/gf/v2/frame/g"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// declaredEventTypes 解析 logger.go 中声明的全部 AuditEventType 常量，新增事件类型时无需同步修改测试
+func declaredEventTypes(t *testing.T) map[string]AuditEventType {
+	file, err := parser.ParseFile(token.NewFileSet(), "logger.go", nil, 0)
+	if err != nil {
+		t.Fatalf("解析 logger.go 失败: %v", err)
+	}
+
+	declared := make(map[string]AuditEventType)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			if ident, ok := value.Type.(*ast.Ident); !ok || ident.Name != "AuditEventType" {
+				continue
+			}
+			for i, name := range value.Names {
+				literal, err := strconv.Unquote(value.Values[i].(*ast.BasicLit).Value)
+				if err != nil {
+					t.Fatalf("解析事件类型 %s 失败: %v", name.Name, err)
+				}
+				declared[name.Name] = AuditEventType(literal)
+			}
+		}
+	}
+	return declared
+}
+
+func TestAuditEventRegistry(t *testing.T) {
+	Convey("审计事件类型登记表", t, func() {
+		validSeverities := map[AuditSeverity]bool{
+			SeverityInfo: true, SeverityWarning: true, SeverityError: true, SeverityCritical: true,
+		}
+
+		Convey("每个事件类型都登记了有效的严重程度、分类和说明", func() {
+			declared := declaredEventTypes(t)
+			So(len(declared), ShouldEqual, len(eventRegistry))
+			for name, eventType := range declared {
+				meta, ok := LookupEventType(eventType)
+				So(ok, ShouldBeTrue)
+				So(meta.EventType, ShouldEqual, eventType)
+				So(validSeverities[meta.Severity], ShouldBeTrue)
+				So(meta.Category, ShouldNotBeEmpty)
+				So(meta.Description, ShouldNotBeEmpty)
+				So(DefaultSeverity(eventType), ShouldEqual, meta.Severity)
+				So(name, ShouldStartWith, "Event")
+			}
+		})
+
+		Convey("统一严重程度策略", func() {
+			So(DefaultSeverity(EventFundBatchDeposit), ShouldEqual, SeverityWarning)
+			So(DefaultSeverity(EventFundDeposit), ShouldEqual, SeverityInfo)
+			So(DefaultSeverity(EventMerchantUserDelete), ShouldEqual, SeverityError)
+			So(DefaultSeverity(EventCrossTenantAttempt), ShouldEqual, SeverityCritical)
+			So(DefaultSeverity(AuditEventType("unknown_event")), ShouldEqual, SeverityInfo)
+		})
+
+		Convey("按登记表补全事件，显式指定的严重程度优先", func() {
+			event := AuditEvent{EventType: EventFundFreeze}
+			applyEventDefaults(&event)
+			So(event.Severity, ShouldEqual, SeverityWarning)
+			So(event.Category, ShouldEqual, CategoryFund)
+
+			event = AuditEvent{EventType: EventMerchantUserUpdate, Severity: SeverityWarning}
+			applyEventDefaults(&event)
+			So(event.Severity, ShouldEqual, SeverityWarning)
+			So(event.Category, ShouldEqual, CategoryMerchantUser)
+
+			event = AuditEvent{EventType: AuditEventType("unknown_event")}
+			applyEventDefaults(&event)
+			So(event.Severity, ShouldEqual, SeverityInfo)
+			So(event.Category, ShouldBeEmpty)
+		})
+
+		Convey("事件类型列表按分类排序", func() {
+			metas := RegisteredEventTypes()
+			So(len(metas), ShouldEqual, len(eventRegistry))
+			for i := 1; i < len(metas); i++ {
+				So(metas[i-1].Category <= metas[i].Category, ShouldBeTrue)
+			}
+		})
+
+		Convey("审计日志查询结果包含事件说明", func() {
+			logs := []g.Map{{"event_type": "fund_freeze"}, {"event_type": "unknown_event"}}
+			describeLogs(logs)
+			So(logs[0]["event_description"], ShouldEqual, "冻结资金")
+			So(logs[0]["category"], ShouldEqual, CategoryFund)
+			So(logs[1]["event_description"], ShouldBeNil)
+		})
+	})
+}
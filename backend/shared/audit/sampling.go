@@ -0,0 +1,227 @@
+package audit
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// protectedEventTypes 安全相关事件，无论如何配置都完整记录
+var protectedEventTypes = map[AuditEventType]bool{
+	EventCrossTenantAttempt:    true,
+	EventSecurityViolation:     true,
+	EventMerchantUserLogin:     true,
+	EventMerchantUserLogout:    true,
+	EventMerchantUserCreate:    true,
+	EventMerchantUserUpdate:    true,
+	EventMerchantUserDelete:    true,
+	EventMerchantUserDisable:   true,
+	EventMerchantUserEnable:    true,
+	EventMerchantUserPassword:  true,
+	EventFundDeposit:           true,
+	EventFundBatchDeposit:      true,
+	EventFundAllocate:          true,
+	EventFundFreeze:            true,
+	EventFundUnfreeze:          true,
+	EventFundApprovalRequested: true,
+	EventFundApprovalApproved:  true,
+	EventFundApprovalRejected:  true,
+	EventFundRightsRefund:      true,
+	EventSettlementGenerated:   true,
+	EventSettlementPaid:        true,
+}
+
+// IsSamplingProtected 事件类型是否不参与采样
+func IsSamplingProtected(eventType AuditEventType) bool {
+	return protectedEventTypes[eventType]
+}
+
+// SamplingPolicy 生效的采样策略
+type SamplingPolicy struct {
+	Rates map[AuditEventType]int // 事件类型 -> 采样率，N 表示每 N 条记录 1 条，未配置或小于等于1时全部记录
+}
+
+// SamplingPolicyProvider 按租户获取采样策略，返回nil时使用全局策略
+type SamplingPolicyProvider func(ctx context.Context, tenantID uint64) (*types.AuditSamplingPolicy, error)
+
+// SamplingStat 采样计数，Seen 为产生的事件总数，Recorded 为实际记录的数量
+type SamplingStat struct {
+	TenantID  uint64         `json:"tenant_id"`
+	EventType AuditEventType `json:"event_type"`
+	Seen      uint64         `json:"seen"`
+	Recorded  uint64         `json:"recorded"`
+}
+
+// Dropped 被采样丢弃的事件数
+func (s SamplingStat) Dropped() uint64 {
+	return s.Seen - s.Recorded
+}
+
+// LoadSamplingPolicy 读取全局采样配置（audit.sampling.rates），安全相关事件的配置会被忽略
+func LoadSamplingPolicy(ctx context.Context) *SamplingPolicy {
+	rates := g.Cfg().MustGet(ctx, "audit.sampling.rates").MapStrVar()
+	policy := &SamplingPolicy{Rates: make(map[AuditEventType]int, len(rates))}
+	for eventType, rate := range rates {
+		policy.Rates[AuditEventType(eventType)] = rate.Int()
+	}
+	return policy
+}
+
+// SetGlobalSamplingPolicy 设置全局采样策略，为空时全部记录
+func SetGlobalSamplingPolicy(policy *SamplingPolicy) {
+	defaultSampler.mutex.Lock()
+	defer defaultSampler.mutex.Unlock()
+
+	if policy == nil {
+		policy = &SamplingPolicy{}
+	}
+	defaultSampler.global = policy
+	defaultSampler.cache = make(map[uint64]cachedSamplingPolicy)
+}
+
+// SetSamplingPolicyProvider 设置租户采样策略提供者，为空时所有租户使用全局策略
+func SetSamplingPolicyProvider(provider SamplingPolicyProvider) {
+	defaultSampler.mutex.Lock()
+	defer defaultSampler.mutex.Unlock()
+
+	defaultSampler.provider = provider
+	defaultSampler.cache = make(map[uint64]cachedSamplingPolicy)
+}
+
+// SamplingStats 获取各租户各事件类型的采样计数，用于估算被采样事件的总量
+func SamplingStats() []SamplingStat {
+	defaultSampler.mutex.RLock()
+	defer defaultSampler.mutex.RUnlock()
+
+	stats := make([]SamplingStat, 0, len(defaultSampler.counters))
+	for key, counter := range defaultSampler.counters {
+		stats = append(stats, SamplingStat{
+			TenantID:  key.tenantID,
+			EventType: key.eventType,
+			Seen:      counter.seen,
+			Recorded:  counter.recorded,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TenantID != stats[j].TenantID {
+			return stats[i].TenantID < stats[j].TenantID
+		}
+		return stats[i].EventType < stats[j].EventType
+	})
+	return stats
+}
+
+// sampler 采样器：全局策略叠加租户策略，按租户和事件类型独立计数
+type sampler struct {
+	mutex    sync.RWMutex
+	global   *SamplingPolicy
+	provider SamplingPolicyProvider
+	cache    map[uint64]cachedSamplingPolicy
+	ttl      time.Duration
+	counters map[samplingKey]*samplingCounter
+}
+
+type cachedSamplingPolicy struct {
+	policy    *SamplingPolicy
+	expiresAt time.Time
+}
+
+type samplingKey struct {
+	tenantID  uint64
+	eventType AuditEventType
+}
+
+type samplingCounter struct {
+	seen     uint64
+	recorded uint64
+}
+
+var defaultSampler = newSampler()
+
+func newSampler() *sampler {
+	return &sampler{
+		global:   &SamplingPolicy{},
+		cache:    make(map[uint64]cachedSamplingPolicy),
+		ttl:      5 * time.Minute,
+		counters: make(map[samplingKey]*samplingCounter),
+	}
+}
+
+// admit 判断事件是否记录，记录的采样事件标注采样率；只有 info 级别的非安全事件参与采样
+func (s *sampler) admit(ctx context.Context, event *AuditEvent) bool {
+	if event.Severity != SeverityInfo || IsSamplingProtected(event.EventType) {
+		return true
+	}
+
+	rate := s.policyFor(ctx, event.TenantID).Rates[event.EventType]
+	if rate <= 1 {
+		return true
+	}
+
+	key := samplingKey{tenantID: event.TenantID, eventType: event.EventType}
+	s.mutex.Lock()
+	counter, exists := s.counters[key]
+	if !exists {
+		counter = &samplingCounter{}
+		s.counters[key] = counter
+	}
+	// 每 rate 条中记录第一条，记录的事件代表 rate 条同类事件
+	admitted := counter.seen%uint64(rate) == 0
+	counter.seen++
+	if admitted {
+		counter.recorded++
+	}
+	s.mutex.Unlock()
+
+	if admitted {
+		event.SampleRate = rate
+	}
+	return admitted
+}
+
+// policyFor 获取租户生效的采样策略
+func (s *sampler) policyFor(ctx context.Context, tenantID uint64) *SamplingPolicy {
+	s.mutex.RLock()
+	global, provider := s.global, s.provider
+	cached, hit := s.cache[tenantID]
+	s.mutex.RUnlock()
+
+	if provider == nil || tenantID == 0 {
+		return global
+	}
+	if hit && time.Now().Before(cached.expiresAt) {
+		return cached.policy
+	}
+
+	policy := global
+	tenantPolicy, err := provider(ctx, tenantID)
+	if err != nil {
+		g.Log().Warningf(ctx, "获取租户审计采样策略失败，使用全局策略 - 租户: %d, 错误: %v", tenantID, err)
+	} else if tenantPolicy != nil {
+		policy = mergeSamplingPolicy(global, tenantPolicy)
+	}
+
+	s.mutex.Lock()
+	s.cache[tenantID] = cachedSamplingPolicy{policy: policy, expiresAt: time.Now().Add(s.ttl)}
+	s.mutex.Unlock()
+
+	return policy
+}
+
+// mergeSamplingPolicy 将租户采样率叠加到全局策略上
+func mergeSamplingPolicy(global *SamplingPolicy, tenant *types.AuditSamplingPolicy) *SamplingPolicy {
+	merged := &SamplingPolicy{
+		Rates: make(map[AuditEventType]int, len(global.Rates)+len(tenant.Rates)),
+	}
+	for eventType, rate := range global.Rates {
+		merged.Rates[eventType] = rate
+	}
+	for eventType, rate := range tenant.Rates {
+		merged.Rates[AuditEventType(eventType)] = rate
+	}
+	return merged
+}
@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// admitCount 产生 total 条同类事件，返回被记录的数量
+func admitCount(s *sampler, tenantID uint64, eventType AuditEventType, severity AuditSeverity, total int) int {
+	ctx := context.Background()
+	recorded := 0
+	for i := 0; i < total; i++ {
+		event := &AuditEvent{TenantID: tenantID, EventType: eventType, Severity: severity}
+		if s.admit(ctx, event) {
+			recorded++
+		}
+	}
+	return recorded
+}
+
+func TestSampling(t *testing.T) {
+	Convey("审计事件采样测试", t, func() {
+		s := newSampler()
+		s.global = &SamplingPolicy{Rates: map[AuditEventType]int{
+			EventDataQuery:         10,
+			EventTenantAccess:      5,
+			EventSecurityViolation: 10,
+			EventMerchantUserLogin: 10,
+		}}
+
+		Convey("配置采样的 info 事件按采样率减少，并记录采样计数", func() {
+			So(admitCount(s, 1, EventDataQuery, SeverityInfo, 100), ShouldEqual, 10)
+			So(admitCount(s, 1, EventTenantAccess, SeverityInfo, 100), ShouldEqual, 20)
+
+			event := &AuditEvent{TenantID: 2, EventType: EventDataQuery, Severity: SeverityInfo}
+			So(s.admit(context.Background(), event), ShouldBeTrue)
+			So(event.SampleRate, ShouldEqual, 10)
+
+			counter := s.counters[samplingKey{tenantID: 1, eventType: EventDataQuery}]
+			So(counter.seen, ShouldEqual, 100)
+			So(counter.recorded, ShouldEqual, 10)
+		})
+
+		Convey("warning 及以上级别的事件不参与采样", func() {
+			So(admitCount(s, 1, EventDataQuery, SeverityWarning, 50), ShouldEqual, 50)
+			So(admitCount(s, 1, EventDataQuery, SeverityError, 50), ShouldEqual, 50)
+			So(admitCount(s, 1, EventDataQuery, SeverityCritical, 50), ShouldEqual, 50)
+		})
+
+		Convey("安全相关事件即使配置了采样率也完整记录", func() {
+			So(admitCount(s, 1, EventSecurityViolation, SeverityInfo, 100), ShouldEqual, 100)
+			So(admitCount(s, 1, EventMerchantUserLogin, SeverityInfo, 100), ShouldEqual, 100)
+			So(admitCount(s, 1, EventFundDeposit, SeverityInfo, 100), ShouldEqual, 100)
+		})
+
+		Convey("未配置采样率的事件类型完整记录", func() {
+			So(admitCount(s, 1, EventMerchantOperation, SeverityInfo, 30), ShouldEqual, 30)
+		})
+
+		Convey("租户配置覆盖全局采样率，只影响该租户", func() {
+			s.provider = func(ctx context.Context, tenantID uint64) (*types.AuditSamplingPolicy, error) {
+				if tenantID != 7 {
+					return nil, nil
+				}
+				return &types.AuditSamplingPolicy{Rates: map[string]int{
+					string(EventDataQuery):    1,
+					string(EventTenantAccess): 50,
+				}}, nil
+			}
+
+			So(admitCount(s, 7, EventDataQuery, SeverityInfo, 100), ShouldEqual, 100)
+			So(admitCount(s, 7, EventTenantAccess, SeverityInfo, 100), ShouldEqual, 2)
+			So(admitCount(s, 8, EventDataQuery, SeverityInfo, 100), ShouldEqual, 10)
+			So(admitCount(s, 8, EventTenantAccess, SeverityInfo, 100), ShouldEqual, 20)
+		})
+	})
+}
+
+func TestSamplingStats(t *testing.T) {
+	Convey("全局采样计数测试", t, func() {
+		SetGlobalSamplingPolicy(&SamplingPolicy{Rates: map[AuditEventType]int{EventDataQuery: 4}})
+		defer SetGlobalSamplingPolicy(nil)
+
+		admitCount(defaultSampler, 901, EventDataQuery, SeverityInfo, 20)
+
+		var stat *SamplingStat
+		for _, s := range SamplingStats() {
+			if s.TenantID == 901 && s.EventType == EventDataQuery {
+				stat = &s
+			}
+		}
+		So(stat, ShouldNotBeNil)
+		So(stat.Seen, ShouldEqual, 20)
+		So(stat.Recorded, ShouldEqual, 5)
+		So(stat.Dropped(), ShouldEqual, 15)
+	})
+}
@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// LogSettlementGenerated 记录一次结算单生成，列出每个商户结算单的金额
+func (l *AuditLogger) LogSettlementGenerated(ctx context.Context, tenantID, operatorID uint64, statement *types.SettlementStatement) {
+	settlements := make([]map[string]interface{}, 0, len(statement.Settlements))
+	for _, settlement := range statement.Settlements {
+		settlements = append(settlements, map[string]interface{}{
+			"settlement_id": settlement.ID,
+			"merchant_id":   settlement.MerchantID,
+			"gross_revenue": settlement.GrossRevenue,
+			"refund_amount": settlement.RefundAmount,
+			"platform_fee":  settlement.PlatformFee,
+			"net_payable":   settlement.NetPayable,
+		})
+	}
+
+	event := AuditEvent{
+		EventType:    EventSettlementGenerated,
+		TenantID:     tenantID,
+		UserID:       operatorID,
+		ResourceType: "settlement",
+		Action:       "generate",
+		IPAddress:    l.getIPAddress(ctx),
+		UserAgent:    l.getUserAgent(ctx),
+		Message: fmt.Sprintf("生成%s至%s的商户结算单%d份，应付合计%.2f",
+			statement.PeriodStart.Format("2006-01-02"), statement.PeriodEnd.Format("2006-01-02"),
+			statement.MerchantCount, statement.TotalNetPayable),
+		Details: map[string]interface{}{
+			"period_start":        statement.PeriodStart,
+			"period_end":          statement.PeriodEnd,
+			"total_gross_revenue": statement.TotalGrossRevenue,
+			"total_refunds":       statement.TotalRefunds,
+			"total_platform_fees": statement.TotalPlatformFees,
+			"total_net_payable":   statement.TotalNetPayable,
+			"settlements":         settlements,
+		},
+		Timestamp: time.Now(),
+	}
+
+	l.logEvent(ctx, event)
+}
+
+// LogSettlementPaid 记录结算单标记为已付款
+func (l *AuditLogger) LogSettlementPaid(ctx context.Context, settlement *types.MerchantSettlement) {
+	var operatorID uint64
+	if settlement.PaidBy != nil {
+		operatorID = *settlement.PaidBy
+	}
+	merchantID := settlement.MerchantID
+
+	event := AuditEvent{
+		EventType:    EventSettlementPaid,
+		TenantID:     settlement.TenantID,
+		UserID:       operatorID,
+		MerchantID:   &merchantID,
+		ResourceType: "settlement",
+		ResourceID:   fmt.Sprintf("%d", settlement.ID),
+		Action:       "pay",
+		IPAddress:    l.getIPAddress(ctx),
+		UserAgent:    l.getUserAgent(ctx),
+		Message:      fmt.Sprintf("商户ID:%d结算单%d已付款%.2f，付款凭证号%s", merchantID, settlement.ID, settlement.NetPayable, settlement.PaymentReference),
+		Details: map[string]interface{}{
+			"period_start":      settlement.PeriodStart,
+			"period_end":        settlement.PeriodEnd,
+			"net_payable":       settlement.NetPayable,
+			"payment_reference": settlement.PaymentReference,
+			"paid_at":           settlement.PaidAt,
+		},
+		Timestamp: time.Now(),
+	}
+
+	l.logEvent(ctx, event)
+}
+
+// LogSettlementGenerated 全局函数：记录一次结算单生成
+func LogSettlementGenerated(ctx context.Context, tenantID, operatorID uint64, statement *types.SettlementStatement) {
+	defaultAuditLogger.LogSettlementGenerated(ctx, tenantID, operatorID, statement)
+}
+
+// LogSettlementPaid 全局函数：记录结算单标记为已付款
+func LogSettlementPaid(ctx context.Context, settlement *types.MerchantSettlement) {
+	defaultAuditLogger.LogSettlementPaid(ctx, settlement)
+}
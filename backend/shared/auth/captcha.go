@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/cache"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/crypto/gmd5"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// CaptchaProvider 验证码提供者
+type CaptchaProvider interface {
+	// Issue 生成返回给客户端的挑战信息
+	Issue(ctx context.Context) (*types.CaptchaChallenge, error)
+	// Verify 校验客户端提交的验证码令牌
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
+
+const (
+	hCaptchaVerifyURL  = "https://hcaptcha.com/siteverify"
+	reCaptchaVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+)
+
+// SiteVerifyCaptchaProvider 基于 siteverify 接口的第三方验证码（hCaptcha/reCAPTCHA）
+type SiteVerifyCaptchaProvider struct {
+	name      string
+	verifyURL string
+	siteKey   string
+	secret    string
+}
+
+// NewHCaptchaProvider 创建hCaptcha验证码提供者
+func NewHCaptchaProvider(siteKey, secret string) *SiteVerifyCaptchaProvider {
+	return &SiteVerifyCaptchaProvider{
+		name:      types.CaptchaProviderHCaptcha,
+		verifyURL: hCaptchaVerifyURL,
+		siteKey:   siteKey,
+		secret:    secret,
+	}
+}
+
+// NewReCaptchaProvider 创建reCAPTCHA验证码提供者
+func NewReCaptchaProvider(siteKey, secret string) *SiteVerifyCaptchaProvider {
+	return &SiteVerifyCaptchaProvider{
+		name:      types.CaptchaProviderReCaptcha,
+		verifyURL: reCaptchaVerifyURL,
+		siteKey:   siteKey,
+		secret:    secret,
+	}
+}
+
+// Issue 第三方验证码由客户端组件完成，只需返回站点密钥
+func (p *SiteVerifyCaptchaProvider) Issue(ctx context.Context) (*types.CaptchaChallenge, error) {
+	return &types.CaptchaChallenge{
+		Required: true,
+		Provider: p.name,
+		SiteKey:  p.siteKey,
+	}, nil
+}
+
+// Verify 调用第三方 siteverify 接口校验令牌
+func (p *SiteVerifyCaptchaProvider) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	response, err := g.Client().Timeout(5*time.Second).Post(ctx, p.verifyURL, g.Map{
+		"secret":   p.secret,
+		"response": token,
+		"remoteip": remoteIP,
+	})
+	if err != nil {
+		return false, fmt.Errorf("调用验证码校验接口失败: %v", err)
+	}
+	defer response.Close()
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(response.ReadAll(), &result); err != nil {
+		return false, fmt.Errorf("解析验证码校验结果失败: %v", err)
+	}
+
+	return result.Success, nil
+}
+
+// LocalCaptchaProvider 本地图片验证码，答案保存在缓存中
+type LocalCaptchaProvider struct {
+	cache  *cache.Cache
+	length int
+	ttl    time.Duration
+}
+
+// NewLocalCaptchaProvider 创建本地图片验证码提供者
+func NewLocalCaptchaProvider() *LocalCaptchaProvider {
+	return &LocalCaptchaProvider{
+		cache:  cache.NewCache("captcha"),
+		length: 4,
+		ttl:    5 * time.Minute,
+	}
+}
+
+// NewLocalCaptchaProviderForTest 创建测试用本地验证码提供者（使用模拟缓存）
+func NewLocalCaptchaProviderForTest() *LocalCaptchaProvider {
+	return &LocalCaptchaProvider{
+		cache:  cache.NewMockCache(),
+		length: 4,
+		ttl:    5 * time.Minute,
+	}
+}
+
+// Issue 生成验证码图片，客户端提交 "挑战ID:答案" 作为令牌
+func (p *LocalCaptchaProvider) Issue(ctx context.Context) (*types.CaptchaChallenge, error) {
+	answer := make([]byte, p.length)
+	for i := range answer {
+		answer[i] = byte('0' + rand.Intn(10))
+	}
+
+	challengeID := gmd5.MustEncryptString(fmt.Sprintf("%s-%d-%d", answer, time.Now().UnixNano(), rand.Int63()))
+	if err := p.cache.Set(ctx, challengeID, string(answer), p.ttl); err != nil {
+		return nil, fmt.Errorf("存储验证码失败: %v", err)
+	}
+
+	imageData, err := renderCaptchaImage(string(answer))
+	if err != nil {
+		return nil, fmt.Errorf("生成验证码图片失败: %v", err)
+	}
+
+	return &types.CaptchaChallenge{
+		Required:    true,
+		Provider:    types.CaptchaProviderLocal,
+		ChallengeID: challengeID,
+		Image:       "data:image/png;base64," + base64.StdEncoding.EncodeToString(imageData),
+	}, nil
+}
+
+// Verify 校验验证码答案，挑战只能使用一次
+func (p *LocalCaptchaProvider) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	challengeID, answer, found := strings.Cut(token, ":")
+	if !found || challengeID == "" || answer == "" {
+		return false, nil
+	}
+
+	expected, err := p.cache.GetString(ctx, challengeID)
+	if err != nil || expected == "" {
+		return false, nil
+	}
+	p.cache.Delete(ctx, challengeID)
+
+	return strings.EqualFold(expected, strings.TrimSpace(answer)), nil
+}
+
+// captchaDigits 5x7 点阵数字字形
+var captchaDigits = [10][7]string{
+	{"01110", "10001", "10011", "10101", "11001", "10001", "01110"},
+	{"00100", "01100", "00100", "00100", "00100", "00100", "01110"},
+	{"01110", "10001", "00001", "00010", "00100", "01000", "11111"},
+	{"11110", "00001", "00001", "01110", "00001", "00001", "11110"},
+	{"00010", "00110", "01010", "10010", "11111", "00010", "00010"},
+	{"11111", "10000", "11110", "00001", "00001", "10001", "01110"},
+	{"00110", "01000", "10000", "11110", "10001", "10001", "01110"},
+	{"11111", "00001", "00010", "00100", "01000", "01000", "01000"},
+	{"01110", "10001", "10001", "01110", "10001", "10001", "01110"},
+	{"01110", "10001", "10001", "01111", "00001", "00010", "01100"},
+}
+
+// renderCaptchaImage 将数字答案渲染为带干扰点的PNG图片
+func renderCaptchaImage(answer string) ([]byte, error) {
+	const scale, padding = 4, 8
+	width := padding*2 + len(answer)*6*scale
+	height := padding*2 + 7*scale + scale
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.White)
+		}
+	}
+
+	for i, ch := range answer {
+		glyph := captchaDigits[ch-'0']
+		offsetX := padding + i*6*scale
+		offsetY := padding + rand.Intn(scale+1) // 字符上下随机偏移
+		ink := color.RGBA{R: uint8(rand.Intn(120)), G: uint8(rand.Intn(120)), B: uint8(rand.Intn(120)), A: 255}
+
+		for row, line := range glyph {
+			for col, bit := range line {
+				if bit != '1' {
+					continue
+				}
+				for dx := 0; dx < scale; dx++ {
+					for dy := 0; dy < scale; dy++ {
+						img.Set(offsetX+col*scale+dx, offsetY+row*scale+dy, ink)
+					}
+				}
+			}
+		}
+	}
+
+	// 干扰点
+	for i := 0; i < width*height/8; i++ {
+		noise := color.RGBA{R: uint8(rand.Intn(256)), G: uint8(rand.Intn(256)), B: uint8(rand.Intn(256)), A: 255}
+		img.Set(rand.Intn(width), rand.Intn(height), noise)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
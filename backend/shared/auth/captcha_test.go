@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLocalCaptchaProvider(t *testing.T) {
+	Convey("本地图片验证码测试", t, func() {
+		ctx := context.Background()
+		provider := NewLocalCaptchaProviderForTest()
+
+		challenge, err := provider.Issue(ctx)
+		So(err, ShouldBeNil)
+		So(challenge.Required, ShouldBeTrue)
+		So(challenge.ChallengeID, ShouldNotBeEmpty)
+		So(strings.HasPrefix(challenge.Image, "data:image/png;base64,"), ShouldBeTrue)
+
+		answer, err := provider.cache.GetString(ctx, challenge.ChallengeID)
+		So(err, ShouldBeNil)
+		So(len(answer), ShouldEqual, 4)
+
+		Convey("正确答案校验通过且挑战只能使用一次", func() {
+			passed, err := provider.Verify(ctx, challenge.ChallengeID+":"+answer, "")
+			So(err, ShouldBeNil)
+			So(passed, ShouldBeTrue)
+
+			passed, err = provider.Verify(ctx, challenge.ChallengeID+":"+answer, "")
+			So(err, ShouldBeNil)
+			So(passed, ShouldBeFalse)
+		})
+
+		Convey("错误答案校验失败", func() {
+			passed, err := provider.Verify(ctx, challenge.ChallengeID+":abcd", "")
+			So(err, ShouldBeNil)
+			So(passed, ShouldBeFalse)
+		})
+
+		Convey("格式错误的令牌校验失败", func() {
+			passed, err := provider.Verify(ctx, "no-separator", "")
+			So(err, ShouldBeNil)
+			So(passed, ShouldBeFalse)
+		})
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/gofromzero/mer-sys/backend/shared/cache"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/crypto/gmd5"
 	"github.com/gogf/gf/v2/frame/g"
@@ -15,9 +16,11 @@ import (
 
 // JWTManager JWT管理器
 type JWTManager struct {
-	cache      *cache.Cache
-	secret     string
-	expireTime time.Duration
+	cache          *cache.Cache
+	secret         string
+	expireTime     time.Duration
+	policyProvider SessionPolicyProvider // 租户会话策略，为空时使用默认策略
+	now            func() time.Time
 }
 
 // NewJWTManager 创建JWT管理器
@@ -26,9 +29,11 @@ func NewJWTManager() *JWTManager {
 	expire := g.Cfg().MustGet(context.Background(), "jwt.expire", 24).Int()
 
 	return &JWTManager{
-		cache:      cache.NewCache("jwt"),
-		secret:     secret,
-		expireTime: time.Duration(expire) * time.Hour,
+		cache:          cache.NewCache("jwt"),
+		secret:         secret,
+		expireTime:     time.Duration(expire) * time.Hour,
+		policyProvider: NewTenantSessionPolicyProvider(repository.NewTenantRepository()),
+		now:            time.Now,
 	}
 }
 
@@ -38,6 +43,7 @@ func NewJWTManagerForTest(secret string, expireHours int) *JWTManager {
 		cache:      cache.NewMockCache(), // 使用模拟缓存
 		secret:     secret,
 		expireTime: time.Duration(expireHours) * time.Hour,
+		now:        time.Now,
 	}
 }
 
@@ -53,6 +59,10 @@ type TokenClaims struct {
 	TokenType   string             `json:"token_type"` // access, refresh
 	IssuedAt    time.Time          `json:"issued_at"`
 	ExpiresAt   time.Time          `json:"expires_at"`
+
+	SessionID        string               `json:"session_id,omitempty"`         // 令牌所属会话的随机标识，不包含任何令牌
+	SessionStartedAt time.Time            `json:"session_started_at,omitempty"` // 会话开始（登录）时间
+	Session          *types.SessionPolicy `json:"session,omitempty"`            // 签发时生效的会话策略
 }
 
 // GenerateToken 生成JWT令牌（兼容旧接口）
@@ -69,10 +79,57 @@ func (j *JWTManager) GenerateToken(ctx context.Context, user *types.User, tokenT
 
 // GenerateTokenWithPermissions 生成包含角色和权限的JWT令牌
 func (j *JWTManager) GenerateTokenWithPermissions(ctx context.Context, user *types.User, userPermissions *types.UserPermissions, tokenType string) (string, error) {
-	now := time.Now()
-	expireTime := j.expireTime
+	policy := j.GetSessionPolicy(ctx, user.TenantID)
+	return j.issueToken(ctx, user, userPermissions, tokenType, policy, j.currentTime(), "")
+}
+
+// GenerateTokenPair 生成属于同一会话的访问令牌和刷新令牌，有效期按租户会话策略计算
+func (j *JWTManager) GenerateTokenPair(ctx context.Context, user *types.User, userPermissions *types.UserPermissions) (*TokenPair, error) {
+	policy := j.GetSessionPolicy(ctx, user.TenantID)
+	return j.issueTokenPair(ctx, user, userPermissions, policy, j.currentTime(), "")
+}
+
+// issueTokenPair 先签发刷新令牌，再签发属于同一会话的访问令牌。sessionID 为空时开始新会话
+func (j *JWTManager) issueTokenPair(ctx context.Context, user *types.User, userPermissions *types.UserPermissions, policy *types.SessionPolicy, sessionStartedAt time.Time, sessionID string) (*TokenPair, error) {
+	if sessionID == "" {
+		var err error
+		if sessionID, err = newSessionID(); err != nil {
+			return nil, err
+		}
+	}
+
+	refreshToken, err := j.issueToken(ctx, user, userPermissions, "refresh", policy, sessionStartedAt, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("生成刷新令牌失败: %v", err)
+	}
+
+	accessToken, err := j.issueToken(ctx, user, userPermissions, "access", policy, sessionStartedAt, sessionID)
+	if err != nil {
+		// 清理已生成的刷新令牌
+		j.RevokeToken(ctx, refreshToken)
+		return nil, fmt.Errorf("生成访问令牌失败: %v", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    policy.AccessTokenTTL,
+	}, nil
+}
+
+// issueToken 按会话策略签发令牌
+func (j *JWTManager) issueToken(ctx context.Context, user *types.User, userPermissions *types.UserPermissions, tokenType string, policy *types.SessionPolicy, sessionStartedAt time.Time, sessionID string) (string, error) {
+	now := j.currentTime()
+	ttl := policy.AccessTokenTTL
 	if tokenType == "refresh" {
-		expireTime = j.expireTime * 7 // 刷新令牌7倍时长
+		ttl = policy.RefreshTokenTTL
+	}
+
+	// 任何令牌都不能超过会话的绝对最长有效期
+	expiresAt := capSessionExpiry(now.Add(time.Duration(ttl)*time.Second), sessionStartedAt, policy)
+	expireTime := expiresAt.Sub(now)
+	if expireTime <= 0 {
+		return "", fmt.Errorf("会话已超过最长有效期")
 	}
 
 	claims := &TokenClaims{
@@ -85,7 +142,11 @@ func (j *JWTManager) GenerateTokenWithPermissions(ctx context.Context, user *typ
 		Permissions: userPermissions.Permissions,
 		TokenType:   tokenType,
 		IssuedAt:    now,
-		ExpiresAt:   now.Add(expireTime),
+		ExpiresAt:   expiresAt,
+
+		SessionID:        sessionID,
+		SessionStartedAt: sessionStartedAt,
+		Session:          policy,
 	}
 
 	// 生成令牌ID（使用用户ID、类型和时间戳）
@@ -103,6 +164,13 @@ func (j *JWTManager) GenerateTokenWithPermissions(ctx context.Context, user *typ
 		return "", fmt.Errorf("维护用户令牌列表失败: %v", err)
 	}
 
+	// 会话标识指向当前的刷新令牌，供滑动会话续期时查找
+	if tokenType == "refresh" && sessionID != "" {
+		if err := j.cache.Set(ctx, sessionRefreshKey(sessionID), tokenID, expireTime); err != nil {
+			return "", fmt.Errorf("存储会话失败: %v", err)
+		}
+	}
+
 	return tokenID, nil
 }
 
@@ -124,11 +192,16 @@ func (j *JWTManager) ValidateToken(ctx context.Context, token string) (*TokenCla
 	}
 
 	// 检查是否过期
-	if time.Now().After(claims.ExpiresAt) {
+	if j.currentTime().After(claims.ExpiresAt) {
 		j.cache.Delete(ctx, token) // 清理过期令牌
 		return nil, fmt.Errorf("令牌已过期")
 	}
 
+	// 访问令牌的使用视为会话活动
+	if claims.TokenType == "access" {
+		j.slideSession(ctx, &claims)
+	}
+
 	return &claims, nil
 }
 
@@ -144,77 +217,50 @@ func (j *JWTManager) RefreshToken(ctx context.Context, refreshToken string) (str
 		return "", fmt.Errorf("令牌类型错误")
 	}
 
-	// 创建新的访问令牌，保持原有的角色和权限
-	user := &types.User{
-		ID:       claims.UserID,
-		TenantID: claims.TenantID,
-		Username: claims.Username,
-		Email:    claims.Email,
-	}
-
-	userPermissions := &types.UserPermissions{
-		UserID:      claims.UserID,
-		TenantID:    claims.TenantID,
-		Roles:       claims.Roles,
-		Permissions: claims.Permissions,
-	}
+	// 创建新的访问令牌，保持原有的角色和权限及所属会话
+	user, userPermissions := claims.toUser()
+	policy := j.GetSessionPolicy(ctx, claims.TenantID)
 
-	return j.GenerateTokenWithPermissions(ctx, user, userPermissions, "access")
+	return j.issueToken(ctx, user, userPermissions, "access", policy, claims.sessionStartedAt(), claims.SessionID)
 }
 
 // RefreshTokenWithRotation 刷新令牌并轮换刷新令牌（安全增强版）
 func (j *JWTManager) RefreshTokenWithRotation(ctx context.Context, refreshToken string) (accessToken, newRefreshToken string, err error) {
+	pair, err := j.RotateTokenPair(ctx, refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+	return pair.AccessToken, pair.RefreshToken, nil
+}
+
+// RotateTokenPair 使用刷新令牌轮换出新的令牌对，新令牌沿用原会话的开始时间
+func (j *JWTManager) RotateTokenPair(ctx context.Context, refreshToken string) (*TokenPair, error) {
 	// 验证刷新令牌
 	claims, err := j.ValidateToken(ctx, refreshToken)
 	if err != nil {
-		return "", "", fmt.Errorf("刷新令牌无效: %v", err)
+		return nil, fmt.Errorf("刷新令牌无效: %v", err)
 	}
 
 	if claims.TokenType != "refresh" {
-		return "", "", fmt.Errorf("令牌类型错误")
+		return nil, fmt.Errorf("令牌类型错误")
 	}
 
 	// 检查刷新令牌是否接近过期（在过期前1小时内才允许刷新）
-	if time.Until(claims.ExpiresAt) > time.Hour {
-		return "", "", fmt.Errorf("刷新令牌尚未到刷新时间")
+	if claims.ExpiresAt.Sub(j.currentTime()) > time.Hour {
+		return nil, fmt.Errorf("刷新令牌尚未到刷新时间")
 	}
 
 	// 立即撤销旧的刷新令牌，防止重放攻击
 	err = j.RevokeToken(ctx, refreshToken)
 	if err != nil {
-		return "", "", fmt.Errorf("撤销旧刷新令牌失败: %v", err)
-	}
-
-	// 创建用户和权限信息
-	user := &types.User{
-		ID:       claims.UserID,
-		TenantID: claims.TenantID,
-		Username: claims.Username,
-		Email:    claims.Email,
-	}
-
-	userPermissions := &types.UserPermissions{
-		UserID:      claims.UserID,
-		TenantID:    claims.TenantID,
-		Roles:       claims.Roles,
-		Permissions: claims.Permissions,
-	}
-
-	// 生成新的访问令牌
-	accessToken, err = j.GenerateTokenWithPermissions(ctx, user, userPermissions, "access")
-	if err != nil {
-		return "", "", fmt.Errorf("生成访问令牌失败: %v", err)
+		return nil, fmt.Errorf("撤销旧刷新令牌失败: %v", err)
 	}
 
-	// 生成新的刷新令牌
-	newRefreshToken, err = j.GenerateTokenWithPermissions(ctx, user, userPermissions, "refresh")
-	if err != nil {
-		// 如果生成新刷新令牌失败，撤销刚生成的访问令牌
-		j.RevokeToken(ctx, accessToken)
-		return "", "", fmt.Errorf("生成新刷新令牌失败: %v", err)
-	}
+	// 按当前租户会话策略签发新的令牌对，沿用原会话标识
+	user, userPermissions := claims.toUser()
+	policy := j.GetSessionPolicy(ctx, claims.TenantID)
 
-	return accessToken, newRefreshToken, nil
+	return j.issueTokenPair(ctx, user, userPermissions, policy, claims.sessionStartedAt(), claims.SessionID)
 }
 
 // RevokeToken 撤销令牌
@@ -415,6 +461,26 @@ func (tc *TokenClaims) GetUserPermissions() *types.UserPermissions {
 	}
 }
 
+// toUser 从令牌还原用户及权限信息
+func (tc *TokenClaims) toUser() (*types.User, *types.UserPermissions) {
+	user := &types.User{
+		ID:         tc.UserID,
+		TenantID:   tc.TenantID,
+		MerchantID: tc.MerchantID,
+		Username:   tc.Username,
+		Email:      tc.Email,
+	}
+	return user, tc.GetUserPermissions()
+}
+
+// sessionStartedAt 获取会话开始时间，旧版令牌以签发时间为准
+func (tc *TokenClaims) sessionStartedAt() time.Time {
+	if tc.SessionStartedAt.IsZero() {
+		return tc.IssuedAt
+	}
+	return tc.SessionStartedAt
+}
+
 // IsMerchantUser 检查是否为商户用户
 func (tc *TokenClaims) IsMerchantUser() bool {
 	return tc.MerchantID != nil
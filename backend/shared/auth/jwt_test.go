@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -254,3 +255,124 @@ func TestTokenBlacklistMechanism(t *testing.T) {
 		})
 	})
 }
+
+func TestSessionPolicy(t *testing.T) {
+	Convey("会话策略测试", t, func() {
+		ctx := context.Background()
+		start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		current := start
+
+		jwtManager := NewJWTManagerForTest("test-secret", 24)
+		jwtManager.now = func() time.Time { return current }
+
+		user := &types.User{ID: 1, TenantID: 1, Username: "testuser", Email: "test@example.com"}
+		userPermissions := &types.UserPermissions{
+			UserID:      1,
+			TenantID:    1,
+			Roles:       []types.RoleType{types.RoleTenantAdmin},
+			Permissions: []types.Permission{types.PermissionUserView},
+		}
+
+		Convey("未配置租户策略时保持默认有效期", func() {
+			pair, err := jwtManager.GenerateTokenPair(ctx, user, userPermissions)
+			So(err, ShouldBeNil)
+			So(pair.ExpiresIn, ShouldEqual, 24*60*60)
+
+			refreshClaims, err := jwtManager.ValidateToken(ctx, pair.RefreshToken)
+			So(err, ShouldBeNil)
+			So(refreshClaims.ExpiresAt, ShouldEqual, start.Add(7*24*time.Hour))
+		})
+
+		Convey("租户自定义令牌有效期", func() {
+			jwtManager.SetSessionPolicyProvider(func(ctx context.Context, tenantID uint64) (*types.SessionPolicy, error) {
+				return &types.SessionPolicy{AccessTokenTTL: 600, RefreshTokenTTL: 3600}, nil
+			})
+
+			pair, err := jwtManager.GenerateTokenPair(ctx, user, userPermissions)
+			So(err, ShouldBeNil)
+			So(pair.ExpiresIn, ShouldEqual, 600)
+
+			accessClaims, err := jwtManager.ValidateToken(ctx, pair.AccessToken)
+			So(err, ShouldBeNil)
+			So(accessClaims.ExpiresAt, ShouldEqual, start.Add(10*time.Minute))
+			// 访问令牌只携带随机会话标识，不泄露刷新令牌
+			So(accessClaims.SessionID, ShouldNotBeEmpty)
+			So(accessClaims.SessionID, ShouldNotEqual, pair.RefreshToken)
+			So(accessClaims.SessionID, ShouldNotContainSubstring, pair.RefreshToken)
+
+			refreshClaims, err := jwtManager.ValidateToken(ctx, pair.RefreshToken)
+			So(err, ShouldBeNil)
+			So(refreshClaims.ExpiresAt, ShouldEqual, start.Add(time.Hour))
+			So(refreshClaims.SessionID, ShouldEqual, accessClaims.SessionID)
+
+			current = start.Add(601 * time.Second)
+			_, err = jwtManager.ValidateToken(ctx, pair.AccessToken)
+			So(err, ShouldNotBeNil)
+
+			Convey("刷新后的令牌使用配置的有效期", func() {
+				rotated, err := jwtManager.RotateTokenPair(ctx, pair.RefreshToken)
+				So(err, ShouldBeNil)
+				So(rotated.ExpiresIn, ShouldEqual, 600)
+
+				rotatedClaims, err := jwtManager.ValidateToken(ctx, rotated.AccessToken)
+				So(err, ShouldBeNil)
+				So(rotatedClaims.ExpiresAt, ShouldEqual, current.Add(10*time.Minute))
+				So(rotatedClaims.SessionID, ShouldEqual, accessClaims.SessionID)
+			})
+		})
+
+		Convey("滑动会话续期不超过绝对最长有效期", func() {
+			jwtManager.SetSessionPolicyProvider(func(ctx context.Context, tenantID uint64) (*types.SessionPolicy, error) {
+				return &types.SessionPolicy{
+					AccessTokenTTL:  3000,
+					RefreshTokenTTL: 3600,
+					SlidingEnabled:  true,
+					SlidingWindow:   1800,
+					AbsoluteMaxTTL:  5400,
+				}, nil
+			})
+
+			pair, err := jwtManager.GenerateTokenPair(ctx, user, userPermissions)
+			So(err, ShouldBeNil)
+
+			// 刷新令牌剩余有效期仍大于窗口，不续期
+			current = start.Add(1000 * time.Second)
+			_, err = jwtManager.ValidateToken(ctx, pair.AccessToken)
+			So(err, ShouldBeNil)
+			refreshClaims, err := jwtManager.ValidateToken(ctx, pair.RefreshToken)
+			So(err, ShouldBeNil)
+			So(refreshClaims.ExpiresAt, ShouldEqual, start.Add(3600*time.Second))
+
+			// 进入窗口后的活动续期，但被绝对最长有效期截断
+			current = start.Add(2000 * time.Second)
+			_, err = jwtManager.ValidateToken(ctx, pair.AccessToken)
+			So(err, ShouldBeNil)
+			refreshClaims, err = jwtManager.ValidateToken(ctx, pair.RefreshToken)
+			So(err, ShouldBeNil)
+			So(refreshClaims.ExpiresAt, ShouldEqual, start.Add(5400*time.Second))
+
+			// 轮换后的令牌同样受会话上限约束
+			current = start.Add(5000 * time.Second)
+			rotated, err := jwtManager.RotateTokenPair(ctx, pair.RefreshToken)
+			So(err, ShouldBeNil)
+			accessClaims, err := jwtManager.ValidateToken(ctx, rotated.AccessToken)
+			So(err, ShouldBeNil)
+			So(accessClaims.ExpiresAt, ShouldEqual, start.Add(5400*time.Second))
+
+			current = start.Add(5401 * time.Second)
+			_, err = jwtManager.ValidateToken(ctx, rotated.RefreshToken)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("启用滑动会话但未配置上限时使用默认上限", func() {
+			jwtManager.SetSessionPolicyProvider(func(ctx context.Context, tenantID uint64) (*types.SessionPolicy, error) {
+				return &types.SessionPolicy{RefreshTokenTTL: 3600, SlidingEnabled: true}, nil
+			})
+
+			policy := jwtManager.GetSessionPolicy(ctx, 1)
+			So(policy.AccessTokenTTL, ShouldEqual, 24*60*60)
+			So(policy.SlidingWindow, ShouldEqual, policy.AccessTokenTTL)
+			So(policy.AbsoluteMaxTTL, ShouldEqual, 4*3600)
+		})
+	})
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// SessionPolicyProvider 按租户获取会话策略
+type SessionPolicyProvider func(ctx context.Context, tenantID uint64) (*types.SessionPolicy, error)
+
+// TokenPair 同一会话的访问令牌与刷新令牌
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // 访问令牌有效期（秒）
+}
+
+// NewTenantSessionPolicyProvider 创建从租户配置读取会话策略的提供者
+func NewTenantSessionPolicyProvider(tenantRepo repository.ITenantRepository) SessionPolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.SessionPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.Session, nil
+	}
+}
+
+// SetSessionPolicyProvider 设置会话策略提供者，为空时所有租户使用默认策略
+func (j *JWTManager) SetSessionPolicyProvider(provider SessionPolicyProvider) {
+	j.policyProvider = provider
+}
+
+// DefaultSessionPolicy 默认会话策略：访问令牌使用配置的有效期，刷新令牌为其7倍，不启用滑动会话
+func (j *JWTManager) DefaultSessionPolicy() *types.SessionPolicy {
+	accessTTL := int64(j.expireTime / time.Second)
+	return &types.SessionPolicy{
+		AccessTokenTTL:  accessTTL,
+		RefreshTokenTTL: accessTTL * 7,
+	}
+}
+
+// GetSessionPolicy 获取租户生效的会话策略，未配置的字段使用默认值
+func (j *JWTManager) GetSessionPolicy(ctx context.Context, tenantID uint64) *types.SessionPolicy {
+	policy := j.DefaultSessionPolicy()
+	if j.policyProvider == nil {
+		return policy
+	}
+
+	custom, err := j.policyProvider(ctx, tenantID)
+	if err != nil {
+		// 读取失败时回退到默认策略，不影响登录
+		g.Log().Warningf(ctx, "获取租户会话策略失败，使用默认策略 - 租户: %d, 错误: %v", tenantID, err)
+		return policy
+	}
+	if custom == nil {
+		return policy
+	}
+
+	if custom.AccessTokenTTL > 0 {
+		policy.AccessTokenTTL = custom.AccessTokenTTL
+	}
+	if custom.RefreshTokenTTL > 0 {
+		policy.RefreshTokenTTL = custom.RefreshTokenTTL
+	}
+
+	policy.SlidingEnabled = custom.SlidingEnabled
+	policy.AbsoluteMaxTTL = custom.AbsoluteMaxTTL
+	if policy.SlidingEnabled {
+		policy.SlidingWindow = custom.SlidingWindow
+		if policy.SlidingWindow <= 0 {
+			policy.SlidingWindow = policy.AccessTokenTTL
+		}
+		// 滑动会话必须有上限，未配置时默认为刷新令牌有效期的4倍
+		if policy.AbsoluteMaxTTL <= 0 {
+			policy.AbsoluteMaxTTL = policy.RefreshTokenTTL * 4
+		}
+	}
+
+	return policy
+}
+
+// capSessionExpiry 将过期时间限制在会话绝对最长有效期内
+func capSessionExpiry(expiresAt, sessionStartedAt time.Time, policy *types.SessionPolicy) time.Time {
+	if policy.AbsoluteMaxTTL <= 0 || sessionStartedAt.IsZero() {
+		return expiresAt
+	}
+
+	deadline := sessionStartedAt.Add(time.Duration(policy.AbsoluteMaxTTL) * time.Second)
+	if expiresAt.After(deadline) {
+		return deadline
+	}
+	return expiresAt
+}
+
+// slideSession 滑动会话：刷新令牌剩余有效期进入窗口后，访问令牌的使用会自动延长刷新令牌有效期，
+// 但不会超过会话的绝对最长有效期
+func (j *JWTManager) slideSession(ctx context.Context, claims *TokenClaims) {
+	policy := claims.Session
+	if policy == nil || !policy.SlidingEnabled || claims.SessionID == "" {
+		return
+	}
+
+	refreshToken, err := j.cache.GetString(ctx, sessionRefreshKey(claims.SessionID))
+	if err != nil || refreshToken == "" {
+		// 会话已过期，不再续期
+		return
+	}
+
+	var refreshClaims TokenClaims
+	if err := j.cache.GetStruct(ctx, refreshToken, &refreshClaims); err != nil {
+		// 刷新令牌已过期或被撤销，会话不再续期
+		return
+	}
+
+	now := j.currentTime()
+	if refreshClaims.ExpiresAt.Sub(now) > time.Duration(policy.SlidingWindow)*time.Second {
+		return
+	}
+
+	expiresAt := now.Add(time.Duration(policy.RefreshTokenTTL) * time.Second)
+	expiresAt = capSessionExpiry(expiresAt, refreshClaims.SessionStartedAt, policy)
+	if !expiresAt.After(refreshClaims.ExpiresAt) {
+		return
+	}
+
+	refreshClaims.ExpiresAt = expiresAt
+	if err := j.cache.Set(ctx, refreshToken, &refreshClaims, expiresAt.Sub(now)); err != nil {
+		g.Log().Warningf(ctx, "延长会话有效期失败: %v", err)
+		return
+	}
+	if err := j.cache.Expire(ctx, sessionRefreshKey(claims.SessionID), expiresAt.Sub(now)); err != nil {
+		g.Log().Warningf(ctx, "延长会话有效期失败: %v", err)
+	}
+}
+
+// sessionRefreshKey 会话当前刷新令牌的缓存键
+func sessionRefreshKey(sessionID string) string {
+	return "session_refresh:" + sessionID
+}
+
+// newSessionID 生成随机会话标识。访问令牌的声明只携带该标识，不会泄露刷新令牌
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成会话标识失败: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// currentTime 获取当前时间（测试中可替换时钟）
+func (j *JWTManager) currentTime() time.Time {
+	if j.now != nil {
+		return j.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// StepUpOperation 需要重新验证身份才能执行的敏感操作
+type StepUpOperation string
+
+const (
+	StepUpOperationFundFreeze        StepUpOperation = "fund_freeze"        // 冻结商户资金
+	StepUpOperationMerchantDelete    StepUpOperation = "merchant_delete"    // 删除商户（预留，目前没有删除商户的接口）
+	StepUpOperationOrderRefund       StepUpOperation = "order_refund"       // 订单退款（取消已支付订单）
+	StepUpOperationCustomerAnonymize StepUpOperation = "customer_anonymize" // 匿名化客户个人信息（不可撤销）
+)
+
+// DefaultStepUpFreshness 默认的重新验证有效期
+const DefaultStepUpFreshness = 5 * time.Minute
+
+// DefaultStepUpOperations 默认需要重新验证身份的操作
+var DefaultStepUpOperations = []StepUpOperation{
+	StepUpOperationFundFreeze,
+	StepUpOperationMerchantDelete,
+	StepUpOperationOrderRefund,
+	StepUpOperationCustomerAnonymize,
+}
+
+// ErrStepUpRequired 操作需要近期重新验证身份
+var ErrStepUpRequired = errors.New("该操作需要重新验证身份")
+
+// StepUpRequiredError 会话未在有效期内重新验证身份，可通过 errors.Is 判断 ErrStepUpRequired
+type StepUpRequiredError struct {
+	Operation         StepUpOperation
+	Freshness         time.Duration
+	ReauthenticatedAt time.Time // 最近一次重新验证的时间，从未验证时为零值
+}
+
+func (e *StepUpRequiredError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrStepUpRequired, e.Operation)
+}
+
+func (e *StepUpRequiredError) Unwrap() error {
+	return ErrStepUpRequired
+}
+
+// StepUpPolicy 敏感操作重新验证策略
+type StepUpPolicy struct {
+	Freshness  time.Duration            // 重新验证后多长时间内可执行敏感操作
+	Operations map[StepUpOperation]bool // 需要重新验证的操作
+}
+
+// NewStepUpPolicy 创建重新验证策略，有效期不大于0时使用默认值
+func NewStepUpPolicy(freshness time.Duration, operations []StepUpOperation) *StepUpPolicy {
+	if freshness <= 0 {
+		freshness = DefaultStepUpFreshness
+	}
+
+	policy := &StepUpPolicy{
+		Freshness:  freshness,
+		Operations: make(map[StepUpOperation]bool, len(operations)),
+	}
+	for _, operation := range operations {
+		policy.Operations[operation] = true
+	}
+	return policy
+}
+
+// LoadStepUpPolicy 从配置读取重新验证策略，未配置操作列表时保护全部默认操作
+func LoadStepUpPolicy(ctx context.Context) *StepUpPolicy {
+	freshness := g.Cfg().MustGet(ctx, "security.stepUp.freshness", int(DefaultStepUpFreshness/time.Second)).Int()
+
+	operations := DefaultStepUpOperations
+	if configured := g.Cfg().MustGet(ctx, "security.stepUp.operations"); !configured.IsNil() {
+		operations = nil
+		for _, operation := range configured.Strings() {
+			operations = append(operations, StepUpOperation(operation))
+		}
+	}
+
+	return NewStepUpPolicy(time.Duration(freshness)*time.Second, operations)
+}
+
+// Requires 判断操作是否需要重新验证身份
+func (p *StepUpPolicy) Requires(operation StepUpOperation) bool {
+	return p != nil && p.Operations[operation]
+}
+
+// RecordReauthentication 记录会话在当前时间完成了重新验证，记录随会话刷新令牌一同过期
+func (j *JWTManager) RecordReauthentication(ctx context.Context, claims *TokenClaims) (time.Time, error) {
+	now := j.currentTime()
+
+	policy := claims.Session
+	if policy == nil {
+		policy = j.DefaultSessionPolicy()
+	}
+	ttl := time.Duration(policy.RefreshTokenTTL) * time.Second
+	if ttl <= 0 {
+		ttl = j.expireTime
+	}
+
+	if err := j.cache.Set(ctx, j.getStepUpKey(claims), now.Unix(), ttl); err != nil {
+		return time.Time{}, fmt.Errorf("记录重新验证时间失败: %v", err)
+	}
+	return now, nil
+}
+
+// LastReauthenticatedAt 获取会话最近一次重新验证的时间，从未验证时返回零值
+func (j *JWTManager) LastReauthenticatedAt(ctx context.Context, claims *TokenClaims) (time.Time, error) {
+	key := j.getStepUpKey(claims)
+	exists, err := j.cache.Exists(ctx, key)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("获取重新验证时间失败: %v", err)
+	}
+	if !exists {
+		return time.Time{}, nil
+	}
+
+	timestamp, err := j.cache.GetInt(ctx, key)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("获取重新验证时间失败: %v", err)
+	}
+	if timestamp == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(int64(timestamp), 0), nil
+}
+
+// IsReauthenticationFresh 判断会话是否在有效期内完成过重新验证
+func (j *JWTManager) IsReauthenticationFresh(ctx context.Context, claims *TokenClaims, freshness time.Duration) (bool, time.Time, error) {
+	reauthenticatedAt, err := j.LastReauthenticatedAt(ctx, claims)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	if reauthenticatedAt.IsZero() {
+		return false, reauthenticatedAt, nil
+	}
+	return j.currentTime().Sub(reauthenticatedAt) <= freshness, reauthenticatedAt, nil
+}
+
+// getStepUpKey 生成重新验证记录键，按用户和会话开始时间区分，令牌轮换后仍然有效
+func (j *JWTManager) getStepUpKey(claims *TokenClaims) string {
+	return fmt.Sprintf("step_up:%d:%d", claims.UserID, claims.sessionStartedAt().Unix())
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStepUpReauthentication(t *testing.T) {
+	Convey("敏感操作重新验证测试", t, func() {
+		ctx := context.Background()
+		start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		current := start
+
+		jwtManager := NewJWTManagerForTest("test-secret", 24)
+		jwtManager.now = func() time.Time { return current }
+
+		user := &types.User{ID: 1, TenantID: 1, Username: "testuser", Email: "test@example.com"}
+		userPermissions := &types.UserPermissions{
+			UserID:      1,
+			TenantID:    1,
+			Roles:       []types.RoleType{types.RoleTenantAdmin},
+			Permissions: []types.Permission{types.PermissionUserView},
+		}
+
+		pair, err := jwtManager.GenerateTokenPair(ctx, user, userPermissions)
+		So(err, ShouldBeNil)
+		claims, err := jwtManager.ValidateToken(ctx, pair.AccessToken)
+		So(err, ShouldBeNil)
+
+		Convey("从未重新验证时不满足要求", func() {
+			fresh, reauthenticatedAt, err := jwtManager.IsReauthenticationFresh(ctx, claims, DefaultStepUpFreshness)
+			So(err, ShouldBeNil)
+			So(fresh, ShouldBeFalse)
+			So(reauthenticatedAt.IsZero(), ShouldBeTrue)
+		})
+
+		Convey("有效期内的重新验证满足要求", func() {
+			recordedAt, err := jwtManager.RecordReauthentication(ctx, claims)
+			So(err, ShouldBeNil)
+			So(recordedAt, ShouldEqual, start)
+
+			current = start.Add(4 * time.Minute)
+			fresh, reauthenticatedAt, err := jwtManager.IsReauthenticationFresh(ctx, claims, DefaultStepUpFreshness)
+			So(err, ShouldBeNil)
+			So(fresh, ShouldBeTrue)
+			So(reauthenticatedAt.Equal(start), ShouldBeTrue)
+		})
+
+		Convey("超过有效期的重新验证需要再次验证", func() {
+			_, err := jwtManager.RecordReauthentication(ctx, claims)
+			So(err, ShouldBeNil)
+
+			current = start.Add(6 * time.Minute)
+			fresh, reauthenticatedAt, err := jwtManager.IsReauthenticationFresh(ctx, claims, DefaultStepUpFreshness)
+			So(err, ShouldBeNil)
+			So(fresh, ShouldBeFalse)
+			So(reauthenticatedAt.Equal(start), ShouldBeTrue)
+
+			// 再次验证后恢复
+			_, err = jwtManager.RecordReauthentication(ctx, claims)
+			So(err, ShouldBeNil)
+			fresh, _, err = jwtManager.IsReauthenticationFresh(ctx, claims, DefaultStepUpFreshness)
+			So(err, ShouldBeNil)
+			So(fresh, ShouldBeTrue)
+		})
+
+		Convey("重新验证记录属于会话，刷新令牌签发的访问令牌同样生效", func() {
+			_, err := jwtManager.RecordReauthentication(ctx, claims)
+			So(err, ShouldBeNil)
+
+			refreshClaims, err := jwtManager.ValidateToken(ctx, pair.RefreshToken)
+			So(err, ShouldBeNil)
+			fresh, _, err := jwtManager.IsReauthenticationFresh(ctx, refreshClaims, DefaultStepUpFreshness)
+			So(err, ShouldBeNil)
+			So(fresh, ShouldBeTrue)
+
+			// 新登录的会话需要单独验证
+			current = start.Add(time.Minute)
+			otherPair, err := jwtManager.GenerateTokenPair(ctx, user, userPermissions)
+			So(err, ShouldBeNil)
+			otherClaims, err := jwtManager.ValidateToken(ctx, otherPair.AccessToken)
+			So(err, ShouldBeNil)
+			fresh, _, err = jwtManager.IsReauthenticationFresh(ctx, otherClaims, DefaultStepUpFreshness)
+			So(err, ShouldBeNil)
+			So(fresh, ShouldBeFalse)
+		})
+	})
+}
+
+func TestStepUpPolicy(t *testing.T) {
+	Convey("重新验证策略测试", t, func() {
+		Convey("默认策略保护全部默认操作", func() {
+			policy := NewStepUpPolicy(0, DefaultStepUpOperations)
+			So(policy.Freshness, ShouldEqual, DefaultStepUpFreshness)
+			So(policy.Requires(StepUpOperationFundFreeze), ShouldBeTrue)
+			So(policy.Requires(StepUpOperationMerchantDelete), ShouldBeTrue)
+			So(policy.Requires(StepUpOperationOrderRefund), ShouldBeTrue)
+		})
+
+		Convey("只保护配置的操作", func() {
+			policy := NewStepUpPolicy(10*time.Minute, []StepUpOperation{StepUpOperationFundFreeze})
+			So(policy.Freshness, ShouldEqual, 10*time.Minute)
+			So(policy.Requires(StepUpOperationFundFreeze), ShouldBeTrue)
+			So(policy.Requires(StepUpOperationOrderRefund), ShouldBeFalse)
+		})
+
+		Convey("空策略不保护任何操作", func() {
+			var policy *StepUpPolicy
+			So(policy.Requires(StepUpOperationFundFreeze), ShouldBeFalse)
+		})
+	})
+}
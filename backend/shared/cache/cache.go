@@ -188,6 +188,11 @@ func (c *Cache) Exists(ctx context.Context, key string) (bool, error) {
 
 // Expire 设置缓存过期时间
 func (c *Cache) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	// 如果是测试模式，使用模拟缓存
+	if c.mock != nil {
+		return c.mock.Expire(ctx, c.buildKey(key), ttl)
+	}
+
 	redis := config.GetRedis()
 	fullKey := c.buildKey(key)
 
@@ -235,6 +240,11 @@ func (c *Cache) FlushDB(ctx context.Context) error {
 
 // Increment 递增计数器
 func (c *Cache) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	// 如果是测试模式，使用模拟缓存
+	if c.mock != nil {
+		return c.mock.Increment(ctx, c.buildKey(key), delta)
+	}
+
 	redis := config.GetRedis()
 	fullKey := c.buildKey(key)
 
@@ -471,3 +481,29 @@ func (m *MockCache) Exists(ctx context.Context, key string) (bool, error) {
 	_, exists := m.data[key]
 	return exists, nil
 }
+
+// Increment 模拟缓存递增计数器
+func (m *MockCache) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if expiry, exists := m.expiry[key]; exists && time.Now().After(expiry) {
+		delete(m.data, key)
+		delete(m.expiry, key)
+	}
+
+	value := gconv.Int64(m.data[key]) + delta
+	m.data[key] = value
+	return value, nil
+}
+
+// Expire 模拟缓存设置过期时间
+func (m *MockCache) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.data[key]; exists {
+		m.expiry[key] = time.Now().Add(ttl)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// migrate 管理数据库表结构版本
+//
+// 用法：
+//
+//	migrate [-set shared] [-dir 目录] up
+//	migrate [-set shared] [-dir 目录] down [-steps 1]
+//	migrate [-set shared] [-dir 目录] status
+//	migrate [-set shared] [-dir 目录] baseline <版本>
+//
+// 未指定 -dir 时使用嵌入的共享迁移集合；服务自己的迁移集合通过 -set 服务名 -dir 服务迁移目录指定。
+// 数据库连接读取 config.yaml 中的 database.default 配置
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/gofromzero/mer-sys/backend/shared/config"
+	"github.com/gofromzero/mer-sys/backend/shared/database"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+func main() {
+	set := flag.String("set", database.SharedMigrationSet, "迁移集合名称")
+	dir := flag.String("dir", "", "迁移脚本目录，为空时使用嵌入的共享迁移集合")
+	steps := flag.Int("steps", 1, "down 命令回滚的迁移数")
+	flag.Parse()
+
+	if err := run(context.Background(), *set, *dir, *steps, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, set, dir string, steps int, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("缺少命令：up、down、status 或 baseline")
+	}
+
+	var source fs.FS = database.SharedMigrations()
+	if dir != "" {
+		source = os.DirFS(dir)
+	}
+
+	if err := config.InitDatabase(); err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+	migrator := database.NewMigrator(g.DB(), set, source)
+
+	switch args[0] {
+	case "up":
+		versions, err := migrator.Up(ctx)
+		printVersions("已应用", versions)
+		return err
+	case "down":
+		if steps <= 0 {
+			return fmt.Errorf("回滚的迁移数必须大于0")
+		}
+		versions, err := migrator.Down(ctx, steps)
+		printVersions("已回滚", versions)
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "未应用"
+			if status.Applied {
+				state = "已应用 " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if !status.Reversible {
+				state += "（不可回滚）"
+			}
+			fmt.Printf("%s\t%s\n", status.Version, state)
+		}
+		return nil
+	case "baseline":
+		if len(args) < 2 {
+			return fmt.Errorf("baseline 需要指定版本，如 054_create_product_co_purchases")
+		}
+		versions, err := migrator.Baseline(ctx, args[1])
+		printVersions("已记为应用", versions)
+		return err
+	default:
+		return fmt.Errorf("未知命令 %s", args[0])
+	}
+}
+
+func printVersions(action string, versions []string) {
+	for _, version := range versions {
+		fmt.Printf("%s %s\n", action, version)
+	}
+	fmt.Printf("%s %d 个迁移\n", action, len(versions))
+}
@@ -2,7 +2,6 @@ package config
 
 import (
 	"context"
-	"time"
 	
 	"github.com/gogf/gf/v2/database/gdb"
 	"github.com/gogf/gf/v2/frame/g"
@@ -44,6 +43,7 @@ func GetDefaultDatabaseConfig() *DatabaseConfig {
 // InitDatabase 初始化数据库连接
 func InitDatabase() error {
 	config := GetDefaultDatabaseConfig()
+	poolConfig := LoadPoolConfig(context.Background())
 	
 	// 配置数据库连接
 	gdb.SetConfig(gdb.Config{
@@ -61,9 +61,9 @@ func InitDatabase() error {
 				Charset:  config.Charset,
 				Timezone: config.Timezone,
 				// 连接池配置
-				MaxIdleConnCount: 10,
-				MaxOpenConnCount: 100,
-				MaxConnLifeTime:  time.Second * 30,
+				MaxIdleConnCount: poolConfig.MaxIdle,
+				MaxOpenConnCount: poolConfig.MaxOpen,
+				MaxConnLifeTime:  poolConfig.MaxLifetime,
 			},
 		},
 	})
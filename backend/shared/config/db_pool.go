@@ -0,0 +1,203 @@
+package config
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// PoolConfig 数据库连接池配置（配置文件 dbPool 节点）
+type PoolConfig struct {
+	MaxOpen           int           `json:"max_open"`            // 最大打开连接数
+	MaxIdle           int           `json:"max_idle"`            // 最大空闲连接数
+	MaxLifetime       time.Duration `json:"max_lifetime"`        // 连接最长复用时间
+	WaitCountAlert    int64         `json:"wait_count_alert"`    // 单个采样周期内等待次数告警阈值
+	WaitDurationAlert time.Duration `json:"wait_duration_alert"` // 单个采样周期内累计等待时长告警阈值
+}
+
+// PoolStats 连接池统计
+type PoolStats struct {
+	MaxOpen           int           `json:"max_open"`
+	Open              int           `json:"open"`
+	InUse             int           `json:"in_use"`
+	Idle              int           `json:"idle"`
+	WaitCount         int64         `json:"wait_count"`
+	WaitDuration      time.Duration `json:"wait_duration"`
+	MaxIdleClosed     int64         `json:"max_idle_closed"`
+	MaxLifetimeClosed int64         `json:"max_lifetime_closed"`
+}
+
+// PoolMetrics 连接池指标：累计统计加上最近一个采样周期的增量
+type PoolMetrics struct {
+	PoolStats
+	WaitCountDelta    int64         `json:"wait_count_delta"`
+	WaitDurationDelta time.Duration `json:"wait_duration_delta"`
+	Saturated         bool          `json:"saturated"` // 最近采样周期等待次数或等待时长超过告警阈值
+}
+
+// DefaultPoolConfig 返回默认连接池配置
+func DefaultPoolConfig() *PoolConfig {
+	return &PoolConfig{
+		MaxOpen:           100,
+		MaxIdle:           10,
+		MaxLifetime:       30 * time.Second,
+		WaitCountAlert:    50,
+		WaitDurationAlert: time.Second,
+	}
+}
+
+// LoadPoolConfig 从配置文件读取连接池配置，未配置的项使用默认值
+func LoadPoolConfig(ctx context.Context) *PoolConfig {
+	config := DefaultPoolConfig()
+	if v, err := g.Cfg().Get(ctx, "dbPool.maxOpen"); err == nil && !v.IsEmpty() {
+		config.MaxOpen = v.Int()
+	}
+	if v, err := g.Cfg().Get(ctx, "dbPool.maxIdle"); err == nil && !v.IsEmpty() {
+		config.MaxIdle = v.Int()
+	}
+	if v, err := g.Cfg().Get(ctx, "dbPool.maxLifetime"); err == nil && !v.IsEmpty() {
+		config.MaxLifetime = v.Duration()
+	}
+	if v, err := g.Cfg().Get(ctx, "dbPool.waitCountAlert"); err == nil && !v.IsEmpty() {
+		config.WaitCountAlert = v.Int64()
+	}
+	if v, err := g.Cfg().Get(ctx, "dbPool.waitDurationAlert"); err == nil && !v.IsEmpty() {
+		config.WaitDurationAlert = v.Duration()
+	}
+	return config
+}
+
+// ApplyPoolConfig 将连接池配置应用到数据库实例
+func ApplyPoolConfig(ctx context.Context, db gdb.DB, config *PoolConfig) {
+	db.SetMaxOpenConnCount(config.MaxOpen)
+	db.SetMaxIdleConnCount(config.MaxIdle)
+	db.SetMaxConnLifeTime(config.MaxLifetime)
+
+	g.Log().Infof(ctx, "数据库连接池配置已应用 - maxOpen: %d, maxIdle: %d, maxLifetime: %s",
+		config.MaxOpen, config.MaxIdle, config.MaxLifetime)
+}
+
+// CollectPoolStats 汇总数据库实例各节点的连接池统计
+func CollectPoolStats(ctx context.Context, db gdb.DB) PoolStats {
+	var stats PoolStats
+	for _, item := range db.Stats(ctx) {
+		s := item.Stats()
+		stats.MaxOpen += s.MaxOpenConnections
+		stats.Open += s.OpenConnections
+		stats.InUse += s.InUse
+		stats.Idle += s.Idle
+		stats.WaitCount += s.WaitCount
+		stats.WaitDuration += s.WaitDuration
+		stats.MaxIdleClosed += s.MaxIdleClosed
+		stats.MaxLifetimeClosed += s.MaxLifetimeClosed
+	}
+	return stats
+}
+
+// PoolMonitor 连接池监控器：定期采样统计并在等待增加时告警
+type PoolMonitor struct {
+	config    *PoolConfig
+	collect   func(ctx context.Context) PoolStats
+	mutex     sync.RWMutex
+	last      PoolStats
+	metrics   PoolMetrics
+	sampled   bool
+	isRunning bool
+	stopCh    chan struct{}
+}
+
+// NewPoolMonitor 创建连接池监控器
+func NewPoolMonitor(db gdb.DB, config *PoolConfig) *PoolMonitor {
+	return &PoolMonitor{
+		config: config,
+		collect: func(ctx context.Context) PoolStats {
+			return CollectPoolStats(ctx, db)
+		},
+		stopCh: make(chan struct{}),
+	}
+}
+
+// NewPoolMonitorForTest 创建测试用连接池监控器
+func NewPoolMonitorForTest(config *PoolConfig, collect func(ctx context.Context) PoolStats) *PoolMonitor {
+	return &PoolMonitor{
+		config:  config,
+		collect: collect,
+		stopCh:  make(chan struct{}),
+	}
+}
+
+// Start 按采样间隔启动监控
+func (m *PoolMonitor) Start(ctx context.Context, interval time.Duration) {
+	m.mutex.Lock()
+	if m.isRunning {
+		m.mutex.Unlock()
+		return
+	}
+	m.isRunning = true
+	m.mutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			m.Observe(ctx)
+			select {
+			case <-m.stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop 停止监控
+func (m *PoolMonitor) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.isRunning {
+		return
+	}
+	m.isRunning = false
+	close(m.stopCh)
+}
+
+// Observe 采样一次连接池统计，计算与上次采样的增量并判断是否饱和
+func (m *PoolMonitor) Observe(ctx context.Context) PoolMetrics {
+	stats := m.collect(ctx)
+
+	m.mutex.Lock()
+	metrics := PoolMetrics{PoolStats: stats}
+	if m.sampled {
+		metrics.WaitCountDelta = stats.WaitCount - m.last.WaitCount
+		metrics.WaitDurationDelta = stats.WaitDuration - m.last.WaitDuration
+	}
+	metrics.Saturated = (m.config.WaitCountAlert > 0 && metrics.WaitCountDelta >= m.config.WaitCountAlert) ||
+		(m.config.WaitDurationAlert > 0 && metrics.WaitDurationDelta >= m.config.WaitDurationAlert)
+	wasSaturated := m.metrics.Saturated
+	m.last = stats
+	m.metrics = metrics
+	m.sampled = true
+	m.mutex.Unlock()
+
+	// 仅在状态变化时输出，便于日志告警规则匹配
+	if metrics.Saturated && !wasSaturated {
+		g.Log().Warningf(ctx, "DB_POOL_SATURATED 数据库连接池等待增加 - 等待次数: +%d, 等待时长: +%s, 使用中: %d/%d",
+			metrics.WaitCountDelta, metrics.WaitDurationDelta, stats.InUse, stats.MaxOpen)
+	} else if !metrics.Saturated && wasSaturated {
+		g.Log().Infof(ctx, "DB_POOL_RECOVERED 数据库连接池等待恢复正常 - 使用中: %d/%d", stats.InUse, stats.MaxOpen)
+	}
+
+	return metrics
+}
+
+// Metrics 获取最近一次采样的指标
+func (m *PoolMonitor) Metrics() PoolMetrics {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.metrics
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPoolMonitor(t *testing.T) {
+	Convey("数据库连接池监控测试", t, func() {
+		ctx := context.Background()
+		stats := PoolStats{MaxOpen: 20, Open: 5, InUse: 3, Idle: 2}
+		monitor := NewPoolMonitorForTest(&PoolConfig{
+			WaitCountAlert:    10,
+			WaitDurationAlert: time.Second,
+		}, func(ctx context.Context) PoolStats {
+			return stats
+		})
+
+		Convey("首次采样不计算增量", func() {
+			stats.WaitCount = 100
+			metrics := monitor.Observe(ctx)
+			So(metrics.WaitCountDelta, ShouldEqual, 0)
+			So(metrics.Saturated, ShouldBeFalse)
+			So(metrics.InUse, ShouldEqual, 3)
+		})
+
+		Convey("等待次数在采样周期内超过阈值时标记饱和", func() {
+			monitor.Observe(ctx)
+
+			stats.WaitCount = 5
+			metrics := monitor.Observe(ctx)
+			So(metrics.WaitCountDelta, ShouldEqual, 5)
+			So(metrics.Saturated, ShouldBeFalse)
+
+			stats.WaitCount = 20
+			metrics = monitor.Observe(ctx)
+			So(metrics.WaitCountDelta, ShouldEqual, 15)
+			So(metrics.Saturated, ShouldBeTrue)
+			So(monitor.Metrics().Saturated, ShouldBeTrue)
+
+			// 等待不再增加后恢复
+			metrics = monitor.Observe(ctx)
+			So(metrics.Saturated, ShouldBeFalse)
+		})
+
+		Convey("累计等待时长超过阈值时标记饱和", func() {
+			monitor.Observe(ctx)
+			stats.WaitCount = 2
+			stats.WaitDuration = 3 * time.Second
+			So(monitor.Observe(ctx).Saturated, ShouldBeTrue)
+		})
+	})
+}
@@ -15,6 +15,8 @@ import (
 )
 
 // Migration 数据库迁移管理器
+//
+// Deprecated: 只能按文件名执行未执行过的迁移，不支持回滚和多个迁移集合，使用 Migrator
 type Migration struct {
 	db gdb.DB
 }
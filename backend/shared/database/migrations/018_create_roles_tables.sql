@@ -0,0 +1,117 @@
+-- 018_create_roles_tables.sql
+-- 创建角色定义及角色权限关联表，支持运行时管理角色与权限
+-- tenant_id = 0 表示系统内置角色，对所有租户生效且不可修改
+
+-- 角色定义表
+CREATE TABLE `roles` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `tenant_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '租户ID（0表示系统内置角色）',
+    `role_type` VARCHAR(50) NOT NULL COMMENT '角色标识',
+    `name` VARCHAR(100) NOT NULL COMMENT '角色名称',
+    `description` VARCHAR(500) NULL COMMENT '角色描述',
+    `is_system` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否系统内置角色',
+    `status` ENUM('active', 'disabled') NOT NULL DEFAULT 'active' COMMENT '角色状态',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_tenant_role` (`tenant_id`, `role_type`),
+    KEY `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='角色定义表';
+
+-- 角色权限关联表
+CREATE TABLE `role_permissions` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `tenant_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '租户ID（0表示系统内置角色）',
+    `role_type` VARCHAR(50) NOT NULL COMMENT '角色标识',
+    `permission` VARCHAR(100) NOT NULL COMMENT '权限标识',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_tenant_role_permission` (`tenant_id`, `role_type`, `permission`),
+    KEY `idx_tenant_role` (`tenant_id`, `role_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='角色权限关联表';
+
+-- 初始化系统内置角色（与 types.GetDefaultRoles 保持一致）
+INSERT INTO `roles` (`tenant_id`, `role_type`, `name`, `description`, `is_system`, `status`) VALUES
+    (0, 'tenant_admin', '租户管理员', '拥有租户内所有权限', 1, 'active'),
+    (0, 'merchant', '商户', '管理自己的商品和订单', 1, 'active'),
+    (0, 'merchant_admin', '商户管理员', '管理商户内所有权限，包括用户管理', 1, 'active'),
+    (0, 'merchant_operator', '商户操作员', '商户日常运营权限，不包括用户管理', 1, 'active'),
+    (0, 'customer', '客户', '查看商品和管理自己的订单', 1, 'active');
+
+-- 初始化系统内置角色权限
+INSERT INTO `role_permissions` (`tenant_id`, `role_type`, `permission`) VALUES
+    (0, 'tenant_admin', 'user:manage'),
+    (0, 'tenant_admin', 'user:view'),
+    (0, 'tenant_admin', 'user:create'),
+    (0, 'tenant_admin', 'user:update'),
+    (0, 'tenant_admin', 'user:delete'),
+    (0, 'tenant_admin', 'merchant:manage'),
+    (0, 'tenant_admin', 'merchant:view'),
+    (0, 'tenant_admin', 'merchant:create'),
+    (0, 'tenant_admin', 'merchant:update'),
+    (0, 'tenant_admin', 'merchant:delete'),
+    (0, 'tenant_admin', 'order:manage'),
+    (0, 'tenant_admin', 'order:view'),
+    (0, 'tenant_admin', 'order:create'),
+    (0, 'tenant_admin', 'order:update'),
+    (0, 'tenant_admin', 'order:delete'),
+    (0, 'tenant_admin', 'product:manage'),
+    (0, 'tenant_admin', 'product:view'),
+    (0, 'tenant_admin', 'product:create'),
+    (0, 'tenant_admin', 'product:update'),
+    (0, 'tenant_admin', 'product:delete'),
+    (0, 'tenant_admin', 'tenant:view'),
+    (0, 'tenant_admin', 'tenant:update'),
+    (0, 'tenant_admin', 'report:view'),
+    (0, 'tenant_admin', 'report:export'),
+    (0, 'tenant_admin', 'report:create'),
+    (0, 'tenant_admin', 'report:delete'),
+    (0, 'tenant_admin', 'fund:view'),
+    (0, 'tenant_admin', 'fund:manage'),
+    (0, 'tenant_admin', 'fund:withdraw'),
+    (0, 'tenant_admin', 'fund:transfer'),
+    (0, 'tenant_admin', 'benefit:view'),
+    (0, 'tenant_admin', 'benefit:manage'),
+    (0, 'tenant_admin', 'benefit:create'),
+    (0, 'tenant_admin', 'benefit:update'),
+    (0, 'tenant_admin', 'benefit:delete'),
+    (0, 'tenant_admin', 'system:config'),
+    (0, 'tenant_admin', 'system:audit'),
+    (0, 'tenant_admin', 'system:log'),
+    (0, 'tenant_admin', 'role:view'),
+    (0, 'tenant_admin', 'role:manage'),
+    (0, 'tenant_admin', 'role:assign'),
+    (0, 'merchant', 'order:view'),
+    (0, 'merchant', 'order:create'),
+    (0, 'merchant', 'order:update'),
+    (0, 'merchant', 'product:manage'),
+    (0, 'merchant', 'product:view'),
+    (0, 'merchant', 'product:create'),
+    (0, 'merchant', 'product:update'),
+    (0, 'merchant', 'product:delete'),
+    (0, 'merchant', 'report:view'),
+    (0, 'merchant', 'fund:view'),
+    (0, 'merchant', 'fund:withdraw'),
+    (0, 'merchant', 'benefit:view'),
+    (0, 'merchant_admin', 'merchant:product:view'),
+    (0, 'merchant_admin', 'merchant:product:create'),
+    (0, 'merchant_admin', 'merchant:product:edit'),
+    (0, 'merchant_admin', 'merchant:product:delete'),
+    (0, 'merchant_admin', 'merchant:order:view'),
+    (0, 'merchant_admin', 'merchant:order:process'),
+    (0, 'merchant_admin', 'merchant:order:cancel'),
+    (0, 'merchant_admin', 'merchant:user:view'),
+    (0, 'merchant_admin', 'merchant:user:manage'),
+    (0, 'merchant_admin', 'merchant:report:view'),
+    (0, 'merchant_admin', 'merchant:report:export'),
+    (0, 'merchant_operator', 'merchant:product:view'),
+    (0, 'merchant_operator', 'merchant:product:create'),
+    (0, 'merchant_operator', 'merchant:product:edit'),
+    (0, 'merchant_operator', 'merchant:order:view'),
+    (0, 'merchant_operator', 'merchant:order:process'),
+    (0, 'merchant_operator', 'merchant:report:view'),
+    (0, 'customer', 'product:view'),
+    (0, 'customer', 'order:view'),
+    (0, 'customer', 'order:create');
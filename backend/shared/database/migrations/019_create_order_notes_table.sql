@@ -0,0 +1,17 @@
+-- 订单备注表（内部协作备注与客户可见备注）
+CREATE TABLE order_notes (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    author_id BIGINT UNSIGNED NOT NULL,
+    author_name VARCHAR(100) NOT NULL DEFAULT '',
+    visibility ENUM('internal', 'customer') NOT NULL DEFAULT 'internal',
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_tenant_id (tenant_id),
+    INDEX idx_order_id (order_id),
+    INDEX idx_merchant_id (merchant_id),
+    INDEX idx_author_id (author_id),
+    CONSTRAINT fk_order_notes_order FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- 订单自动完成等待时长（处理中订单最后一次状态变更后多久自动完成）
+ALTER TABLE order_timeout_configs
+ADD COLUMN auto_complete_after_hours INT NOT NULL DEFAULT 72 AFTER auto_complete_enabled;
@@ -0,0 +1,18 @@
+-- 发件箱事件表（事务性发件箱，保证跨服务事件至少投递一次）
+CREATE TABLE outbox_events (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id BIGINT UNSIGNED NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSON NOT NULL,
+    status ENUM('pending', 'processed') NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error VARCHAR(500) NOT NULL DEFAULT '',
+    available_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_status_available (status, available_at),
+    INDEX idx_tenant_id (tenant_id),
+    INDEX idx_aggregate (aggregate_type, aggregate_id)
+);
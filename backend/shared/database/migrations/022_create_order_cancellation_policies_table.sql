@@ -0,0 +1,16 @@
+-- 订单取消策略表（merchant_id 为空表示租户默认策略）
+CREATE TABLE order_cancellation_policies (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED,
+    customer_cancel_statuses JSON NOT NULL, -- 客户可取消的订单状态，如 [1]
+    merchant_cancel_statuses JSON NOT NULL, -- 商户可取消的订单状态，如 [1, 2]
+    refund_on_cancel BOOLEAN NOT NULL DEFAULT TRUE,
+    fee_type ENUM('none', 'fixed', 'percentage') NOT NULL DEFAULT 'none',
+    fee_value DECIMAL(10,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tenant_id (tenant_id),
+    INDEX idx_merchant_id (merchant_id),
+    UNIQUE KEY uk_tenant_merchant (tenant_id, merchant_id)
+);
@@ -0,0 +1,22 @@
+-- 商品评价表（每个订单商品只能评价一次）
+CREATE TABLE product_reviews (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    product_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    customer_id BIGINT UNSIGNED NOT NULL,
+    rating TINYINT UNSIGNED NOT NULL, -- 评分 1-5
+    comment VARCHAR(2000) NOT NULL DEFAULT '',
+    flagged BOOLEAN NOT NULL DEFAULT FALSE, -- 被标记为不当内容，不展示也不计入评分
+    flag_reason VARCHAR(255) NOT NULL DEFAULT '',
+    flagged_by BIGINT UNSIGNED,
+    flagged_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tenant_product (tenant_id, product_id, flagged),
+    INDEX idx_merchant_id (merchant_id),
+    INDEX idx_customer_id (customer_id),
+    UNIQUE KEY uk_order_product (order_id, product_id),
+    CONSTRAINT chk_rating CHECK (rating BETWEEN 1 AND 5)
+);
@@ -0,0 +1,3 @@
+-- 订单库存预留保留时长（分钟），0 表示与支付超时一致；可短于支付超时以尽早释放热门商品库存
+ALTER TABLE order_timeout_configs
+ADD COLUMN reservation_hold_minutes INT NOT NULL DEFAULT 0 AFTER payment_timeout_minutes;
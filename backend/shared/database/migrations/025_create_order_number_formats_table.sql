@@ -0,0 +1,20 @@
+-- 租户订单号格式表（未配置的租户使用默认格式：年月日时分秒 + 6位序号）
+CREATE TABLE order_number_formats (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    prefix VARCHAR(8) NOT NULL, -- 大写字母前缀，租户间唯一
+    date_pattern VARCHAR(20) NOT NULL, -- YYYYMMDDHHmmss、YYYYMMDD、YYMMDD、YYYYMM
+    sequence_width TINYINT UNSIGNED NOT NULL DEFAULT 6,
+    include_merchant_code BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_tenant_id (tenant_id),
+    UNIQUE KEY uk_prefix (prefix)
+);
+
+-- 订单号序号表，按订单号中序号之前的部分（前缀+日期+商户编码）分别递增
+CREATE TABLE order_number_sequences (
+    scope_key VARCHAR(100) NOT NULL PRIMARY KEY,
+    current_value BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
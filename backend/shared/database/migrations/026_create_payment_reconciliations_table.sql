@@ -0,0 +1,14 @@
+-- 支付对账记录表：记录对账任务向支付渠道查询待支付订单的结果
+CREATE TABLE payment_reconciliations (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    order_number VARCHAR(50) NOT NULL,
+    trade_status VARCHAR(32) NOT NULL DEFAULT '', -- 渠道交易状态
+    transaction_id VARCHAR(64) NOT NULL DEFAULT '',
+    outcome ENUM('recovered', 'unpaid', 'settled', 'failed') NOT NULL,
+    message VARCHAR(500) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_tenant_order (tenant_id, order_id),
+    INDEX idx_outcome_created (outcome, created_at)
+);
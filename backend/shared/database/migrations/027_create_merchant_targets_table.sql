@@ -0,0 +1,13 @@
+-- 商户月度销售目标表
+CREATE TABLE merchant_targets (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    period CHAR(7) NOT NULL, -- 自然月，格式 YYYY-MM
+    target_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    target_orders INT NOT NULL DEFAULT 0,
+    created_by BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_tenant_merchant_period (tenant_id, merchant_id, period)
+);
@@ -0,0 +1,22 @@
+-- 订单状态历史异步导出任务表：时间范围较大的导出在后台生成文件后下载
+CREATE TABLE order_status_history_exports (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid VARCHAR(64) NOT NULL,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    query JSON NOT NULL, -- 导出条件
+    format ENUM('csv', 'excel') NOT NULL,
+    status ENUM('generating', 'completed', 'failed') NOT NULL DEFAULT 'generating',
+    file_path VARCHAR(500) NOT NULL DEFAULT '',
+    row_count INT NOT NULL DEFAULT 0,
+    error_message VARCHAR(500) NOT NULL DEFAULT '',
+    requested_by BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_uuid (uuid),
+    INDEX idx_tenant_created (tenant_id, created_at)
+);
+
+-- 按时间范围导出状态历史
+CREATE INDEX idx_order_status_history_tenant_created ON order_status_history (tenant_id, created_at);
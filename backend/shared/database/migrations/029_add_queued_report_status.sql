@@ -0,0 +1,3 @@
+-- 报表生成并发受限后，超出并发上限的报表先以 queued 状态排队
+ALTER TABLE reports
+    MODIFY COLUMN status ENUM('queued', 'generating', 'completed', 'failed') NOT NULL DEFAULT 'generating';
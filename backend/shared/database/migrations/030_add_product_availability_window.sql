@@ -0,0 +1,9 @@
+-- 商品定时上下架：可售时间窗口，以及等待定时上架的 scheduled 状态
+ALTER TABLE `products`
+ADD COLUMN `available_from` TIMESTAMP NULL DEFAULT NULL COMMENT '可售开始时间，为空时不限制' AFTER `status`,
+ADD COLUMN `available_until` TIMESTAMP NULL DEFAULT NULL COMMENT '可售结束时间，为空时不限制' AFTER `available_from`,
+ADD INDEX `idx_status_available_from` (`status`, `available_from`),
+ADD INDEX `idx_status_available_until` (`status`, `available_until`);
+
+ALTER TABLE `products`
+MODIFY COLUMN `status` ENUM('draft', 'scheduled', 'active', 'inactive', 'deleted') NOT NULL DEFAULT 'draft' COMMENT '商品状态';
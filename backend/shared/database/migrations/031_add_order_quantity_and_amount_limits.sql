@@ -0,0 +1,7 @@
+-- 下单数量和金额限制：商品单笔订单最小/最大购买数量，商户最低起订金额，0 表示不限制
+ALTER TABLE `products`
+ADD COLUMN `min_order_quantity` INT NOT NULL DEFAULT 0 COMMENT '单笔订单最小购买数量，0表示不限制' AFTER `available_until`,
+ADD COLUMN `max_order_quantity` INT NOT NULL DEFAULT 0 COMMENT '单笔订单最大购买数量，0表示不限制' AFTER `min_order_quantity`;
+
+ALTER TABLE `merchants`
+ADD COLUMN `min_order_amount` DECIMAL(15,2) NOT NULL DEFAULT 0.00 COMMENT '最低起订金额，0表示不限制';
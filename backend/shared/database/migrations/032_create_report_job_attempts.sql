@@ -0,0 +1,19 @@
+-- 定时报表执行记录：每次执行（含自动重试和手动重试）记录状态、错误和耗时
+ALTER TABLE `report_jobs`
+ADD COLUMN `attempt_count` INT NOT NULL DEFAULT 0 COMMENT '累计执行次数' AFTER `retry_count`;
+
+CREATE TABLE IF NOT EXISTS `report_job_attempts` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `tenant_id` BIGINT UNSIGNED NOT NULL,
+    `job_id` BIGINT UNSIGNED NOT NULL,
+    `attempt` INT NOT NULL COMMENT '第几次执行',
+    `status` ENUM('completed', 'failed') NOT NULL,
+    `error_message` TEXT,
+    `retryable` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '失败是否可自动重试',
+    `started_at` TIMESTAMP NOT NULL,
+    `completed_at` TIMESTAMP NOT NULL,
+    `duration_ms` BIGINT NOT NULL DEFAULT 0,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX `idx_tenant_job` (`tenant_id`, `job_id`),
+    FOREIGN KEY (`job_id`) REFERENCES `report_jobs`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='报表任务执行记录表';
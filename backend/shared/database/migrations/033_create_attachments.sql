@@ -0,0 +1,19 @@
+-- 证明材料附件表（退款审批、敏感操作审计的凭证，文件本身存放在对象存储）
+CREATE TABLE attachments (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    target_type VARCHAR(32) NOT NULL DEFAULT '',
+    target_id VARCHAR(64) NOT NULL DEFAULT '',
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL DEFAULT 0,
+    checksum CHAR(64) NOT NULL,
+    object_key VARCHAR(512) NOT NULL,
+    uploaded_by BIGINT UNSIGNED NOT NULL,
+    linked_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_tenant_id (tenant_id),
+    INDEX idx_target (tenant_id, target_type, target_id),
+    INDEX idx_uploaded_by (uploaded_by)
+);
@@ -0,0 +1,6 @@
+-- 商品搜索同义词词典（每个租户一份，synonym_groups 为同义词组的 JSON 数组）
+CREATE TABLE product_search_synonyms (
+    tenant_id BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+    synonym_groups JSON NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
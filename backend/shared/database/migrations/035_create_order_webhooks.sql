@@ -0,0 +1,29 @@
+-- 订单事件 Webhook 配置表（租户自有系统接收订单生命周期事件）
+CREATE TABLE order_webhooks (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    url VARCHAR(512) NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    events JSON NOT NULL,
+    enabled TINYINT(1) NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tenant_enabled (tenant_id, enabled)
+);
+
+-- 订单 Webhook 推送记录表，每次推送尝试一条
+CREATE TABLE order_webhook_deliveries (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    webhook_id BIGINT UNSIGNED NOT NULL,
+    event_id VARCHAR(64) NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    attempt INT NOT NULL DEFAULT 1,
+    status_code INT NOT NULL DEFAULT 0,
+    success TINYINT(1) NOT NULL DEFAULT 0,
+    error VARCHAR(500) NOT NULL DEFAULT '',
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_webhook_created (tenant_id, webhook_id, created_at),
+    INDEX idx_event_id (event_id)
+);
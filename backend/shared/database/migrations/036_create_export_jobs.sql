@@ -0,0 +1,27 @@
+-- 统一导出任务表：所有导出进入导出队列，按租户限制并发数和每日用量
+CREATE TABLE export_jobs (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid VARCHAR(64) NOT NULL,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    kind VARCHAR(64) NOT NULL,
+    params JSON NOT NULL, -- 导出条件
+    format VARCHAR(16) NOT NULL,
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    status ENUM('queued', 'running', 'completed', 'failed') NOT NULL DEFAULT 'queued',
+    file_path VARCHAR(500) NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    row_count INT NOT NULL DEFAULT 0,
+    error_message VARCHAR(500) NOT NULL DEFAULT '',
+    requested_by BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    started_at TIMESTAMP NULL,
+    completed_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_uuid (uuid),
+    INDEX idx_status_id (status, id),
+    INDEX idx_tenant_created (tenant_id, created_at)
+);
+
+-- 订单状态历史导出改为通过导出队列生成
+DROP TABLE IF EXISTS order_status_history_exports;
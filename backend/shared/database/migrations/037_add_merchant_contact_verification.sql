@@ -0,0 +1,4 @@
+-- 商户联系方式验证：入驻时向联系邮箱/电话发送验证码，审批前至少验证一种联系方式
+ALTER TABLE `merchants`
+ADD COLUMN `contact_email_verified_at` TIMESTAMP NULL COMMENT '联系邮箱验证时间，邮箱变更后清空' AFTER `min_order_amount`,
+ADD COLUMN `contact_phone_verified_at` TIMESTAMP NULL COMMENT '联系电话验证时间，电话变更后清空' AFTER `contact_email_verified_at`;
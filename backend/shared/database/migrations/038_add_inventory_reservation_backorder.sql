@@ -0,0 +1,3 @@
+-- 库存预订：接受预订的商品缺货时允许可用库存在预订上限内为负，预留记录标记其中以预订方式接受的数量
+ALTER TABLE `inventory_reservations`
+ADD COLUMN `backordered_quantity` INT NOT NULL DEFAULT 0 COMMENT '超出可用库存、以预订方式接受的数量' AFTER `reserved_quantity`;
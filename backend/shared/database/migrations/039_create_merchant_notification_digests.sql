@@ -0,0 +1,26 @@
+-- 商户通知偏好表：开启汇总后非紧急通知按小时或每日汇总发送
+CREATE TABLE merchant_notification_preferences (
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    digest_frequency ENUM('immediate', 'hourly', 'daily') NOT NULL DEFAULT 'immediate',
+    digest_hour TINYINT UNSIGNED NOT NULL DEFAULT 9, -- 每日汇总的发送时刻
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, merchant_id)
+);
+
+-- 等待汇总发送的商户通知，汇总发送后记录发送时间
+CREATE TABLE merchant_notification_digest_entries (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    order_number VARCHAR(64) NOT NULL DEFAULT '',
+    summary VARCHAR(255) NOT NULL DEFAULT '',
+    link VARCHAR(512) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP NULL,
+    INDEX idx_pending (sent_at, id),
+    INDEX idx_recipient (tenant_id, merchant_id, user_id, sent_at)
+);
@@ -0,0 +1,13 @@
+-- 订单权益冻结表：下单时冻结商户权益，支付后转为已使用，取消或超时后退回
+CREATE TABLE order_rights_reservations (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    status ENUM('active', 'released', 'consumed') NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_order (tenant_id, order_id),
+    INDEX idx_merchant_status (tenant_id, merchant_id, status)
+);
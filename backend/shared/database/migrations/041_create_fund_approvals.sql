@@ -0,0 +1,32 @@
+-- 大额资金审批表：超过金额阈值的充值和权益分配需要多人审批后才入账
+CREATE TABLE fund_approvals (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    fund_id BIGINT UNSIGNED NOT NULL,
+    fund_type TINYINT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'CNY',
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    requested_by BIGINT UNSIGNED NOT NULL,
+    required_approvals INT UNSIGNED NOT NULL,
+    approval_count INT UNSIGNED NOT NULL DEFAULT 0,
+    status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending',
+    decided_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_fund (tenant_id, fund_id),
+    INDEX idx_pending (tenant_id, status, created_at)
+);
+
+-- 审批意见，同一审批人对同一笔资金操作只能审批一次
+CREATE TABLE fund_approval_decisions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    approval_id BIGINT UNSIGNED NOT NULL,
+    approver_id BIGINT UNSIGNED NOT NULL,
+    decision ENUM('approve', 'reject') NOT NULL,
+    comment VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_approver (tenant_id, approval_id, approver_id)
+);
@@ -0,0 +1,3 @@
+-- 商户启用的支付方式：JSON 数组，为空表示接受全部支付方式
+ALTER TABLE `merchants`
+ADD COLUMN `payment_methods` JSON NULL COMMENT '启用的支付方式，为空表示接受全部支付方式';
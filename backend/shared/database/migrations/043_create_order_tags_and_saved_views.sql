@@ -0,0 +1,24 @@
+-- 订单标签表：订单与标签的多对多关联，同一订单的标签不重复
+CREATE TABLE order_tags (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    tag VARCHAR(32) NOT NULL,
+    created_by BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_order_tag (tenant_id, order_id, tag),
+    INDEX idx_tenant_tag (tenant_id, tag),
+    CONSTRAINT fk_order_tags_order FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+-- 订单筛选视图表：用户保存的命名筛选条件，按租户和用户隔离
+CREATE TABLE order_saved_views (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    filters JSON NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_user_view_name (tenant_id, user_id, name)
+);
@@ -0,0 +1,27 @@
+-- 订单状态处理时限配置表（merchant_id 为空表示租户默认配置）
+CREATE TABLE order_sla_configs (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED,
+    statuses JSON NOT NULL, -- 各状态处理时限，如 [{"status": 2, "minutes": 1440}]
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tenant_id (tenant_id),
+    UNIQUE KEY uk_tenant_merchant (tenant_id, merchant_id)
+);
+
+-- 订单处理超时提醒记录表：同一订单在同一状态只提醒一次
+CREATE TABLE order_sla_alerts (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    status TINYINT NOT NULL,
+    entered_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_order_status_entered (order_id, status, entered_at),
+    INDEX idx_tenant_id (tenant_id),
+    CONSTRAINT fk_order_sla_alerts_order FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+-- 查询订单进入当前状态的时间
+CREATE INDEX idx_order_status_history_order_to_status ON order_status_history (order_id, to_status, created_at);
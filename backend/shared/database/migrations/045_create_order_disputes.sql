@@ -0,0 +1,21 @@
+-- 订单争议表：客户对错发、未收到等问题发起争议，由租户或商户员工处理。
+-- 同一订单同一时间只能有一条未结束（open、investigating）的争议，未结束的争议会暂停订单自动完成
+CREATE TABLE order_disputes (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    customer_id BIGINT UNSIGNED NOT NULL,
+    reason VARCHAR(32) NOT NULL, -- wrong_item, not_received, damaged, other
+    description TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, investigating, resolved, rejected
+    resolution_notes TEXT,
+    handler_id BIGINT UNSIGNED,
+    resolved_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tenant_status (tenant_id, status),
+    INDEX idx_order_status (order_id, status),
+    INDEX idx_tenant_merchant (tenant_id, merchant_id),
+    CONSTRAINT fk_order_disputes_order FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- Webhook 订阅租户配置变更：tenant.config_changed 事件只推送订阅分区内的配置变更
+ALTER TABLE `order_webhooks`
+ADD COLUMN `config_sections` JSON NULL COMMENT '订阅的租户配置分区，为空时推送全部分区' AFTER `events`;
@@ -0,0 +1,14 @@
+-- 租户密码策略：记录用户设置过的密码哈希用于禁止重复使用，记录最近一次设置密码的时间用于密码过期。
+-- 每个用户最多保留 24 条密码历史
+CREATE TABLE password_histories (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_tenant_user (tenant_id, user_id, id),
+    CONSTRAINT fk_password_histories_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+ALTER TABLE `users`
+ADD COLUMN `password_changed_at` TIMESTAMP NULL COMMENT '最近一次设置密码的时间，为空时按创建时间计算密码有效期' AFTER `last_login_at`;
@@ -0,0 +1,20 @@
+-- 订单风险审核表：下单时命中租户审核规则（大额、新客户、频繁下单等）的订单进入人工审核。
+-- 待审核（pending）的订单不能支付，也不计算支付超时；审核通过后从通过时起重新计算支付超时，审核拒绝时取消订单
+CREATE TABLE order_reviews (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    customer_id BIGINT UNSIGNED NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, approved, rejected
+    matched_rules JSON NOT NULL,
+    decision_notes TEXT,
+    reviewer_id BIGINT UNSIGNED,
+    decided_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tenant_status (tenant_id, status),
+    INDEX idx_order_status (order_id, status),
+    INDEX idx_tenant_merchant (tenant_id, merchant_id),
+    CONSTRAINT fk_order_reviews_order FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
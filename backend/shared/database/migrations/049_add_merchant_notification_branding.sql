@@ -0,0 +1,3 @@
+-- 商户客户订单通知品牌：JSON 对象（display_name、support_contact、signature），为空时使用租户默认品牌
+ALTER TABLE `merchants`
+ADD COLUMN `notification_branding` JSON NULL COMMENT '客户订单通知品牌，为空时使用租户默认品牌';
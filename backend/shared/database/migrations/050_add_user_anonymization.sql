@@ -0,0 +1,5 @@
+-- 客户个人信息匿名化：用户名、邮箱替换为随机令牌，手机号和资料清空，订单金额和数量保留。
+-- 租户可在 data_retention 配置中设置保留天数，超过保留期未活跃的客户由定时任务自动匿名化
+ALTER TABLE `users`
+ADD COLUMN `anonymized_at` TIMESTAMP NULL COMMENT '个人信息匿名化时间，为空表示未匿名化' AFTER `password_changed_at`,
+ADD INDEX `idx_tenant_anonymized` (`tenant_id`, `anonymized_at`);
@@ -0,0 +1,13 @@
+-- 购物车保留期与找回提醒：购物车按最后变更时间计算保留期，闲置超过配置时间且有商品的购物车发送一次找回提醒，
+-- 购物车再次变更后重新计算闲置期。客户可退订找回提醒
+ALTER TABLE `carts`
+ADD COLUMN `recovery_notified_at` TIMESTAMP NULL COMMENT '最近一次发送找回提醒的时间' AFTER `expires_at`,
+ADD INDEX `idx_updated_at` (`updated_at`);
+
+CREATE TABLE cart_recovery_preferences (
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    customer_id BIGINT UNSIGNED NOT NULL,
+    opted_out TINYINT(1) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, customer_id)
+);
@@ -0,0 +1,8 @@
+-- 订单税费：下单时按租户税费策略计算税额，订单金额为含税应付金额，subtotal_amount 为不含税商品金额，
+-- tax_breakdown 保存各商品的税率和税额。历史订单未计税，不含税商品金额即订单金额
+ALTER TABLE `orders`
+ADD COLUMN `subtotal_amount` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '不含税商品金额' AFTER `total_rights_cost`,
+ADD COLUMN `tax_amount` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '税额' AFTER `subtotal_amount`,
+ADD COLUMN `tax_breakdown` JSON NULL COMMENT '税费明细' AFTER `tax_amount`;
+
+UPDATE `orders` SET `subtotal_amount` = `total_amount`;
@@ -0,0 +1,48 @@
+-- 群发通知：租户向全部或筛选后的客户、商户用户发送同一条消息（政策变更、促销等）。
+-- 后台任务按用户ID顺序分批发送，cursor_user_id 记录已处理到的用户；每个接收人每个渠道记录一条发送结果，
+-- 已退订群发通知的用户只记录一条跳过（skipped）记录
+CREATE TABLE notification_broadcasts (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    subject VARCHAR(100) NOT NULL DEFAULT '',
+    content TEXT NOT NULL,
+    channels JSON NOT NULL,
+    filter JSON NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'sending', -- sending, completed
+    created_by BIGINT UNSIGNED NOT NULL,
+    total_recipients INT NOT NULL DEFAULT 0,
+    processed_count INT NOT NULL DEFAULT 0,
+    skipped_count INT NOT NULL DEFAULT 0,
+    sent_count INT NOT NULL DEFAULT 0,
+    failed_count INT NOT NULL DEFAULT 0,
+    cursor_user_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP NULL,
+    INDEX idx_tenant_created (tenant_id, created_at),
+    INDEX idx_status (status)
+);
+
+CREATE TABLE broadcast_deliveries (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    broadcast_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    channel VARCHAR(20) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL, -- sent, failed, skipped
+    error VARCHAR(500) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_broadcast_status (broadcast_id, status),
+    CONSTRAINT fk_broadcast_deliveries_broadcast FOREIGN KEY (broadcast_id) REFERENCES notification_broadcasts (id) ON DELETE CASCADE
+);
+
+CREATE TABLE broadcast_preferences (
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    opted_out TINYINT(1) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, user_id)
+);
+
+-- 发送群发通知的权限，默认授予租户管理员（与 types.GetDefaultRoles 保持一致）
+INSERT INTO `role_permissions` (`tenant_id`, `role_type`, `permission`) VALUES
+    (0, 'tenant_admin', 'notification:broadcast');
@@ -0,0 +1,12 @@
+-- 商品搭配推荐（经常一起购买）：后台任务定期按最近已完成订单统计同一商户下商品在同一订单中一起购买的次数，
+-- 每次计算后替换租户的全部记录。推荐时排除已下架或无库存的搭配商品
+CREATE TABLE product_co_purchases (
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    product_id BIGINT UNSIGNED NOT NULL,
+    related_product_id BIGINT UNSIGNED NOT NULL,
+    co_purchase_count INT NOT NULL,
+    computed_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (tenant_id, product_id, related_product_id),
+    INDEX idx_product_count (tenant_id, product_id, co_purchase_count)
+);
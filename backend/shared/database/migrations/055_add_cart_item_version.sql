@@ -0,0 +1,4 @@
+-- 购物车项版本：每次修改数量后递增。更新请求携带读取到的版本时，版本不一致表示已被其他设备修改，
+-- 不携带版本的更新以最后一次为准。同一购物车的修改在事务中锁定购物车行后串行执行
+ALTER TABLE `cart_items`
+ADD COLUMN `version` INT NOT NULL DEFAULT 1 COMMENT '版本号，每次修改数量后递增' AFTER `quantity`;
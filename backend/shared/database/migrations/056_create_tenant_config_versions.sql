@@ -0,0 +1,12 @@
+-- 租户配置版本：每次更新或导入租户配置后保存完整配置，版本按租户递增。
+-- 导出的配置包记录来源版本，便于追溯预发和生产环境之间迁移的配置
+CREATE TABLE tenant_config_versions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    version INT NOT NULL,
+    config JSON NOT NULL,
+    source VARCHAR(20) NOT NULL DEFAULT 'update', -- update, import
+    created_by BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_tenant_version (tenant_id, version)
+);
@@ -0,0 +1,21 @@
+-- 客户收货地址簿：每个客户可保存多个地址，最多一个默认地址。
+-- 下单时选择的地址以快照保存在 orders.shipping_address，之后修改或删除地址簿不影响已下单的订单
+CREATE TABLE customer_addresses (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    customer_id BIGINT UNSIGNED NOT NULL,
+    recipient_name VARCHAR(50) NOT NULL,
+    phone VARCHAR(20) NOT NULL,
+    province VARCHAR(50) NOT NULL,
+    city VARCHAR(50) NOT NULL,
+    district VARCHAR(50) NOT NULL DEFAULT '',
+    detail_address VARCHAR(200) NOT NULL,
+    postal_code VARCHAR(10) NOT NULL DEFAULT '',
+    is_default TINYINT(1) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tenant_customer (tenant_id, customer_id)
+);
+
+ALTER TABLE `orders`
+ADD COLUMN `shipping_address` JSON NULL COMMENT '收货地址快照' AFTER `tax_breakdown`;
@@ -0,0 +1,25 @@
+-- 订单发票：每个订单只开具一张发票，发票号在首次下载时分配并保存，之后重新下载沿用原发票号。
+-- 发票号序号按租户连续递增，与订单号序号相互独立
+CREATE TABLE order_invoices (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    customer_id BIGINT UNSIGNED NOT NULL,
+    invoice_number VARCHAR(40) NOT NULL,
+    total_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    subtotal_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    file_path VARCHAR(500) NOT NULL DEFAULT '',
+    issued_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_tenant_order (tenant_id, order_id),
+    UNIQUE KEY uk_tenant_invoice_number (tenant_id, invoice_number)
+);
+
+-- 发票号序号表，每个租户一行
+CREATE TABLE invoice_sequences (
+    tenant_id BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+    current_value BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
@@ -0,0 +1,6 @@
+-- 预警升级：活跃预警超过升级策略的时长仍未解决时逐级升级，escalation_level 为已升级次数，
+-- escalated_at 为最近一次升级时间，下一次升级从该时间开始计时
+ALTER TABLE `rights_alerts`
+ADD COLUMN `escalation_level` INT NOT NULL DEFAULT 0 COMMENT '已升级次数' AFTER `notified_channels`,
+ADD COLUMN `escalated_at` TIMESTAMP NULL COMMENT '最近一次升级时间' AFTER `escalation_level`,
+ADD INDEX `idx_status_type_id` (`status`, `alert_type`, `id`);
@@ -0,0 +1,24 @@
+-- 商品套餐：多个商品按固定数量组合，以套餐价格和套餐权益成本整体销售。
+-- 组成商品以 JSON 保存 [{"product_id":1,"quantity":2}]，下单时按组成商品分别校验和占用库存，
+-- 订单项中保存组成商品快照，之后修改套餐不影响已下单的订单
+CREATE TABLE product_bundles (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    components JSON NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    rights_cost DECIMAL(10,2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tenant_merchant_status (tenant_id, merchant_id, status)
+);
+
+-- 购物车中的套餐项 product_id 为空，bundle_id 指向套餐
+ALTER TABLE `cart_items`
+MODIFY COLUMN `product_id` BIGINT UNSIGNED NULL,
+ADD COLUMN `bundle_id` BIGINT UNSIGNED NULL COMMENT '套餐ID，套餐购物车项的商品ID为空' AFTER `product_id`,
+ADD INDEX `idx_bundle_id` (`bundle_id`),
+ADD CONSTRAINT `fk_cart_item_bundle` FOREIGN KEY (`bundle_id`) REFERENCES `product_bundles` (`id`) ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+-- 外部订单号：对接方在下单时传入自己系统中的订单号，之后可按外部订单号查询和对账，无需保存内部订单ID。
+-- 同一租户内唯一，未设置时为 NULL，不受唯一约束限制
+ALTER TABLE `orders`
+ADD COLUMN `external_reference` VARCHAR(64) NULL COMMENT '外部订单号' AFTER `order_number`,
+ADD UNIQUE KEY `uk_tenant_external_reference` (`tenant_id`, `external_reference`);
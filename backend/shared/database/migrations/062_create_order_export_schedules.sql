@@ -0,0 +1,42 @@
+-- 定时订单导出：按每天或每周的计划将新增订单导出给财务系统，通过邮件发送下载链接或上传到对象存储。
+-- last_order_id 为已投递的最大订单ID，每次执行只导出之后的订单，投递成功后才推进，失败时下次执行重新导出同一范围
+CREATE TABLE order_export_schedules (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    filter JSON NOT NULL,
+    format VARCHAR(20) NOT NULL DEFAULT 'csv',
+    frequency VARCHAR(20) NOT NULL,
+    weekday TINYINT NOT NULL DEFAULT 0,
+    hour TINYINT NOT NULL DEFAULT 0,
+    minute TINYINT NOT NULL DEFAULT 0,
+    destination VARCHAR(20) NOT NULL,
+    recipient VARCHAR(500) NOT NULL,
+    enabled TINYINT(1) NOT NULL DEFAULT 1,
+    last_order_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    next_run_at TIMESTAMP NOT NULL,
+    last_run_at TIMESTAMP NULL,
+    created_by BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tenant (tenant_id),
+    INDEX idx_enabled_next_run (enabled, next_run_at)
+);
+
+-- 定时订单导出执行记录
+CREATE TABLE order_export_runs (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    schedule_id BIGINT UNSIGNED NOT NULL,
+    export_job_id VARCHAR(64) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    from_order_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    through_order_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    row_count INT NOT NULL DEFAULT 0,
+    error_message VARCHAR(1000) NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP NULL,
+    INDEX idx_tenant_schedule (tenant_id, schedule_id, id),
+    INDEX idx_status (status),
+    CONSTRAINT fk_order_export_run_schedule FOREIGN KEY (schedule_id) REFERENCES order_export_schedules (id) ON DELETE CASCADE
+);
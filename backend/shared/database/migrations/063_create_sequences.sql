@@ -0,0 +1,14 @@
+-- 通用序号表：按租户和序号名称分别递增，tenant_id 为 0 的是跨租户唯一的全局序号。
+-- 订单号序号迁移到该表（名称为 order_number: + 作用域），继续从原序号之后分配
+CREATE TABLE sequences (
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    seq_key VARCHAR(150) NOT NULL,
+    current_value BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, seq_key)
+);
+
+INSERT INTO sequences (tenant_id, seq_key, current_value)
+SELECT 0, CONCAT('order_number:', scope_key), current_value FROM order_number_sequences;
+
+DROP TABLE order_number_sequences;
@@ -0,0 +1,16 @@
+-- 订单权益退还：已支付订单取消退款后，按退款比例将支付时扣减的权益退回商户可用余额。
+-- 一笔订单可以多次部分退款，累计退还的权益不超过 order_rights_reservations 中已扣减的权益
+CREATE TABLE order_rights_refunds (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    fund_id BIGINT UNSIGNED NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    refund_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    reason VARCHAR(500) NOT NULL DEFAULT '',
+    operator_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_tenant_order (tenant_id, order_id),
+    INDEX idx_merchant (tenant_id, merchant_id)
+);
@@ -0,0 +1,15 @@
+-- 商户权益余额预警级别：记录最近一次阈值检查时余额所处的级别（normal/warning/critical），
+-- 只在级别变得更严重时提醒商户管理员，避免每次检查重复提醒；级别不为 normal 时在商户仪表板生成待处理事项
+CREATE TABLE merchant_rights_thresholds (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    level ENUM('normal', 'warning', 'critical') NOT NULL DEFAULT 'normal',
+    threshold DECIMAL(15,2) NOT NULL DEFAULT 0,
+    available_balance DECIMAL(15,2) NOT NULL DEFAULT 0,
+    average_daily_usage DECIMAL(15,2) NOT NULL DEFAULT 0,
+    projected_depletion_at TIMESTAMP NULL,
+    crossed_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_tenant_merchant (tenant_id, merchant_id)
+);
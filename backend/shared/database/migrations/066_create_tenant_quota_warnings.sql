@@ -0,0 +1,13 @@
+-- 租户配额用量级别：记录最近一次检查时用户数、商户数所处的级别（normal/warning/exceeded），
+-- 只在级别变得更严重时提醒租户，避免每次创建重复提醒
+CREATE TABLE tenant_quota_warnings (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    resource VARCHAR(32) NOT NULL,
+    level ENUM('normal', 'warning', 'exceeded') NOT NULL DEFAULT 'normal',
+    used INT NOT NULL DEFAULT 0,
+    quota_limit INT NOT NULL DEFAULT 0,
+    crossed_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_tenant_resource (tenant_id, resource)
+);
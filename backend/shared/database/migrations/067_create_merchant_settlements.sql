@@ -0,0 +1,27 @@
+-- 商户结算单：按周期计算商户的订单收入、退款、平台手续费和应付金额，付款后记录付款凭证号
+CREATE TABLE merchant_settlements (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    merchant_id BIGINT UNSIGNED NOT NULL,
+    merchant_name VARCHAR(255) NOT NULL DEFAULT '',
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    gross_revenue DECIMAL(15,2) NOT NULL DEFAULT 0,
+    order_count INT NOT NULL DEFAULT 0,
+    refund_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    refund_count INT NOT NULL DEFAULT 0,
+    platform_fee DECIMAL(15,2) NOT NULL DEFAULT 0,
+    net_payable DECIMAL(15,2) NOT NULL DEFAULT 0,
+    fee_rate_percent DECIMAL(5,2) NOT NULL DEFAULT 0,
+    fixed_fee_per_order DECIMAL(15,2) NOT NULL DEFAULT 0,
+    status ENUM('pending', 'paid') NOT NULL DEFAULT 'pending',
+    payment_reference VARCHAR(100) NOT NULL DEFAULT '',
+    paid_at TIMESTAMP NULL,
+    paid_by BIGINT UNSIGNED NULL,
+    generated_by BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_merchant_period (tenant_id, merchant_id, period_start, period_end),
+    INDEX idx_tenant_status (tenant_id, status),
+    INDEX idx_tenant_period (tenant_id, period_start)
+);
@@ -0,0 +1,4 @@
+-- 营销信息订阅偏好：客户退订后不会出现在客户分群营销导出中，默认未退订
+ALTER TABLE `users`
+ADD COLUMN `marketing_opt_out` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否退订营销信息' AFTER `anonymized_at`,
+ADD COLUMN `marketing_preference_updated_at` TIMESTAMP NULL COMMENT '最近一次修改营销订阅偏好的时间' AFTER `marketing_opt_out`;
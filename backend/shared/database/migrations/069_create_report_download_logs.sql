@@ -0,0 +1,18 @@
+-- 报表对象存储：使用对象存储交付的报表首次下载时上传，记录对象键，之后直接签发对象存储签名链接
+ALTER TABLE `reports`
+ADD COLUMN `object_key` VARCHAR(500) NULL COMMENT '报表文件在对象存储中的对象键' AFTER `file_path`;
+
+-- 报表下载访问日志：记录谁在何时通过哪种方式下载了哪个报表。
+-- 对象存储签名链接由对象存储或CDN直接响应，记录的是签发链接的时间和请求者
+CREATE TABLE report_download_logs (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    report_id BIGINT UNSIGNED NOT NULL,
+    report_uuid VARCHAR(64) NOT NULL,
+    channel ENUM('direct', 'signed_url', 'object_storage') NOT NULL COMMENT '下载方式：认证后直接下载、本地签名链接、对象存储签名链接',
+    user_id BIGINT UNSIGNED NULL COMMENT '下载或签发链接的用户，签名链接下载时为签发链接的用户',
+    client_ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_tenant_report (tenant_id, report_id, created_at)
+);
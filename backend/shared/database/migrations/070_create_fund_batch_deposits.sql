@@ -0,0 +1,14 @@
+-- 批量充值记录：携带幂等键的批量充值先登记幂等键再入账，重复提交返回首次处理的结果，不重复入账
+CREATE TABLE fund_batch_deposits (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    idempotency_key VARCHAR(64) NOT NULL,
+    mode ENUM('best_effort', 'all_or_nothing') NOT NULL DEFAULT 'best_effort',
+    status ENUM('processing', 'completed', 'partial', 'failed') NOT NULL DEFAULT 'processing',
+    request_hash CHAR(64) NOT NULL,
+    operator_id BIGINT UNSIGNED NOT NULL,
+    result JSON NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_idempotency_key (tenant_id, idempotency_key)
+);
@@ -0,0 +1,16 @@
+-- 订单履约单：拆单后每个包裹独立发货、独立流转状态，金额和权益消耗按包裹内商品占比分摊
+CREATE TABLE order_fulfillments (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    status ENUM('pending', 'shipped', 'delivered', 'completed') NOT NULL DEFAULT 'pending',
+    items JSON NOT NULL,
+    amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    rights_cost DECIMAL(15,2) NOT NULL DEFAULT 0,
+    carrier VARCHAR(64) NOT NULL DEFAULT '',
+    tracking_number VARCHAR(64) NOT NULL DEFAULT '',
+    shipped_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tenant_order (tenant_id, order_id)
+);
@@ -0,0 +1,351 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	// SchemaMigrationsTable 记录已应用迁移版本的表
+	SchemaMigrationsTable = "schema_migrations"
+	// SharedMigrationSet 共享迁移集合（shared/database/migrations）的名称
+	SharedMigrationSet = "shared"
+)
+
+var (
+	// ErrIrreversibleMigration 迁移没有对应的 down 文件，无法回滚
+	ErrIrreversibleMigration = errors.New("迁移没有回滚脚本")
+	// ErrUnknownMigrationVersion 迁移版本不在迁移集合中
+	ErrUnknownMigrationVersion = errors.New("迁移版本不存在")
+)
+
+// MigrationDB 执行迁移所需的数据库操作，gdb.DB 满足该接口
+type MigrationDB interface {
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	GetAll(ctx context.Context, query string, args ...interface{}) (gdb.Result, error)
+}
+
+// MigrationFile 一个版本的迁移脚本。文件名为 <编号>_<名称>.sql 或 <编号>_<名称>.up.sql，
+// 回滚脚本为同名的 .down.sql，版本为去掉扩展名后的文件名，如 053_create_notification_broadcasts
+type MigrationFile struct {
+	Version string
+	Number  int
+	Up      string
+	Down    string // 为空时不能回滚
+}
+
+// Reversible 迁移是否可以回滚
+func (f *MigrationFile) Reversible() bool {
+	return strings.TrimSpace(f.Down) != ""
+}
+
+// MigrationStatus 迁移版本的应用状态
+type MigrationStatus struct {
+	Version    string     `json:"version"`
+	Applied    bool       `json:"applied"`
+	AppliedAt  *time.Time `json:"applied_at,omitempty"`
+	Reversible bool       `json:"reversible"`
+}
+
+// Migrator 版本化数据库迁移执行器。每个迁移集合（共享迁移或各服务自己的迁移）单独记录已应用的版本，
+// 迁移按编号顺序应用、按相反顺序回滚
+type Migrator struct {
+	db     MigrationDB
+	set    string
+	source fs.FS
+	now    func() time.Time
+}
+
+// NewMigrator 创建迁移执行器，source 为迁移集合的脚本目录（通常为 embed.FS）
+func NewMigrator(db MigrationDB, set string, source fs.FS) *Migrator {
+	return &Migrator{
+		db:     db,
+		set:    set,
+		source: source,
+		now:    time.Now,
+	}
+}
+
+// Migrations 读取迁移集合中的全部迁移，按编号和版本排序
+func (m *Migrator) Migrations() ([]MigrationFile, error) {
+	entries, err := fs.ReadDir(m.source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移目录失败: %v", err)
+	}
+
+	byVersion := make(map[string]*MigrationFile)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		version, down := strings.TrimSuffix(name, ".sql"), false
+		if strings.HasSuffix(version, ".down") {
+			version, down = strings.TrimSuffix(version, ".down"), true
+		} else {
+			version = strings.TrimSuffix(version, ".up")
+		}
+		number, err := migrationNumber(version)
+		if err != nil {
+			return nil, fmt.Errorf("迁移文件 %s 命名错误: %v", name, err)
+		}
+
+		content, err := fs.ReadFile(m.source, name)
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件 %s 失败: %v", name, err)
+		}
+
+		file, exists := byVersion[version]
+		if !exists {
+			file = &MigrationFile{Version: version, Number: number}
+			byVersion[version] = file
+		}
+		if down {
+			file.Down = string(content)
+		} else {
+			file.Up = string(content)
+		}
+	}
+
+	files := make([]MigrationFile, 0, len(byVersion))
+	for _, file := range byVersion {
+		if strings.TrimSpace(file.Up) == "" {
+			return nil, fmt.Errorf("迁移 %s 缺少执行脚本", file.Version)
+		}
+		files = append(files, *file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Number != files[j].Number {
+			return files[i].Number < files[j].Number
+		}
+		return files[i].Version < files[j].Version
+	})
+	return files, nil
+}
+
+// migrationNumber 解析版本开头的编号
+func migrationNumber(version string) (int, error) {
+	prefix, _, _ := strings.Cut(version, "_")
+	number, err := strconv.Atoi(prefix)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("版本应以正整数编号开头，如 001_create_tenants_table")
+	}
+	return number, nil
+}
+
+// Status 获取迁移集合中每个版本的应用状态
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	files, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(files))
+	for _, file := range files {
+		status := MigrationStatus{Version: file.Version, Reversible: file.Reversible()}
+		if appliedAt, ok := applied[file.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up 按顺序应用全部未应用的迁移，返回本次应用的版本。某个迁移失败时停止，之前的迁移保持已应用
+func (m *Migrator) Up(ctx context.Context) ([]string, error) {
+	files, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, file := range files {
+		if _, ok := applied[file.Version]; ok {
+			continue
+		}
+		if err := m.execScript(ctx, file.Up); err != nil {
+			return versions, fmt.Errorf("应用迁移 %s 失败: %w", file.Version, err)
+		}
+		if _, err := m.db.Exec(ctx,
+			"INSERT INTO "+SchemaMigrationsTable+" (migration_set, version, applied_at) VALUES (?, ?, ?)",
+			m.set, file.Version, m.now()); err != nil {
+			return versions, fmt.Errorf("记录迁移 %s 失败: %v", file.Version, err)
+		}
+		g.Log().Infof(ctx, "已应用迁移 %s/%s", m.set, file.Version)
+		versions = append(versions, file.Version)
+	}
+	return versions, nil
+}
+
+// Down 按相反顺序回滚最近应用的 steps 个迁移，返回本次回滚的版本
+func (m *Migrator) Down(ctx context.Context, steps int) ([]string, error) {
+	files, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(files))
+	for _, file := range files {
+		known[file.Version] = true
+	}
+	for version := range applied {
+		if !known[version] {
+			return nil, fmt.Errorf("%w: 已应用的 %s/%s 不在迁移集合中", ErrUnknownMigrationVersion, m.set, version)
+		}
+	}
+
+	var versions []string
+	for i := len(files) - 1; i >= 0 && len(versions) < steps; i-- {
+		file := files[i]
+		if _, ok := applied[file.Version]; !ok {
+			continue
+		}
+		if !file.Reversible() {
+			return versions, fmt.Errorf("%w: %s", ErrIrreversibleMigration, file.Version)
+		}
+		if err := m.execScript(ctx, file.Down); err != nil {
+			return versions, fmt.Errorf("回滚迁移 %s 失败: %w", file.Version, err)
+		}
+		if _, err := m.db.Exec(ctx,
+			"DELETE FROM "+SchemaMigrationsTable+" WHERE migration_set = ? AND version = ?",
+			m.set, file.Version); err != nil {
+			return versions, fmt.Errorf("删除迁移记录 %s 失败: %v", file.Version, err)
+		}
+		g.Log().Infof(ctx, "已回滚迁移 %s/%s", m.set, file.Version)
+		versions = append(versions, file.Version)
+	}
+	return versions, nil
+}
+
+// Baseline 把 version 及之前的迁移记为已应用但不执行，用于接入迁移管理前已手动建好表结构的数据库
+func (m *Migrator) Baseline(ctx context.Context, version string) ([]string, error) {
+	files, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	end := -1
+	for i, file := range files {
+		if file.Version == version {
+			end = i
+		}
+	}
+	if end < 0 {
+		return nil, fmt.Errorf("%w: %s/%s", ErrUnknownMigrationVersion, m.set, version)
+	}
+
+	var versions []string
+	for _, file := range files[:end+1] {
+		if _, ok := applied[file.Version]; ok {
+			continue
+		}
+		if _, err := m.db.Exec(ctx,
+			"INSERT INTO "+SchemaMigrationsTable+" (migration_set, version, applied_at) VALUES (?, ?, ?)",
+			m.set, file.Version, m.now()); err != nil {
+			return versions, fmt.Errorf("记录迁移 %s 失败: %v", file.Version, err)
+		}
+		versions = append(versions, file.Version)
+	}
+	return versions, nil
+}
+
+// appliedVersions 获取迁移集合已应用的版本及应用时间，记录表不存在时先创建
+func (m *Migrator) appliedVersions(ctx context.Context) (map[string]time.Time, error) {
+	if _, err := m.db.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+SchemaMigrationsTable+` (
+    migration_set VARCHAR(50) NOT NULL,
+    version VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (migration_set, version)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='已应用的数据库迁移版本'`); err != nil {
+		return nil, fmt.Errorf("创建迁移记录表失败: %v", err)
+	}
+
+	records, err := m.db.GetAll(ctx,
+		"SELECT version, applied_at FROM "+SchemaMigrationsTable+" WHERE migration_set = ?", m.set)
+	if err != nil {
+		return nil, fmt.Errorf("查询已应用的迁移失败: %v", err)
+	}
+
+	applied := make(map[string]time.Time, len(records))
+	for _, record := range records {
+		applied[record["version"].String()] = record["applied_at"].Time()
+	}
+	return applied, nil
+}
+
+// execScript 逐条执行脚本中的语句。MySQL 的 DDL 会隐式提交，迁移无法整体回滚，
+// 失败时需要修正脚本后重新执行，因此迁移脚本应尽量使用 IF NOT EXISTS 等可重复执行的写法
+func (m *Migrator) execScript(ctx context.Context, script string) error {
+	for _, statement := range SplitSQLStatements(script) {
+		if _, err := m.db.Exec(ctx, statement); err != nil {
+			return fmt.Errorf("执行SQL失败 [%s]: %v", statement, err)
+		}
+	}
+	return nil
+}
+
+// SplitSQLStatements 把迁移脚本拆分为单条语句：忽略整行注释，语句以行尾的分隔符结束，
+// 支持 mysql 客户端的 DELIMITER 指令，用于创建存储过程
+func SplitSQLStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		delimiter  = ";"
+	)
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(script, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		if fields := strings.Fields(trimmed); len(fields) == 2 && strings.EqualFold(fields[0], "DELIMITER") {
+			flush()
+			delimiter = fields[1]
+			continue
+		}
+
+		if strings.HasSuffix(trimmed, delimiter) {
+			current.WriteString(strings.TrimSuffix(strings.TrimRight(line, " \t"), delimiter))
+			flush()
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+	flush()
+	return statements
+}
@@ -0,0 +1,244 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/database/gdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	createTablePattern = regexp.MustCompile(`(?i)^CREATE TABLE (?:IF NOT EXISTS )?` + "`?" + `(\w+)`)
+	dropTablePattern   = regexp.MustCompile(`(?i)^DROP TABLE (?:IF EXISTS )?` + "`?" + `(\w+)`)
+)
+
+// memoryMigrationDB 内存迁移数据库：记录建表和删表语句，按集合保存已应用的版本
+type memoryMigrationDB struct {
+	tables     map[string]bool
+	applied    map[string]map[string]time.Time
+	statements []string
+	failOn     string // 执行包含该内容的语句时失败
+}
+
+func newMemoryMigrationDB() *memoryMigrationDB {
+	return &memoryMigrationDB{
+		tables:  make(map[string]bool),
+		applied: make(map[string]map[string]time.Time),
+	}
+}
+
+func (d *memoryMigrationDB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	switch {
+	case strings.HasPrefix(query, "INSERT INTO "+SchemaMigrationsTable):
+		set, version := args[0].(string), args[1].(string)
+		if d.applied[set] == nil {
+			d.applied[set] = make(map[string]time.Time)
+		}
+		d.applied[set][version] = args[2].(time.Time)
+		return nil, nil
+	case strings.HasPrefix(query, "DELETE FROM "+SchemaMigrationsTable):
+		delete(d.applied[args[0].(string)], args[1].(string))
+		return nil, nil
+	}
+
+	d.statements = append(d.statements, query)
+	if d.failOn != "" && strings.Contains(query, d.failOn) {
+		return nil, errors.New("syntax error")
+	}
+	if match := createTablePattern.FindStringSubmatch(query); match != nil {
+		d.tables[match[1]] = true
+	}
+	if match := dropTablePattern.FindStringSubmatch(query); match != nil {
+		delete(d.tables, match[1])
+	}
+	return nil, nil
+}
+
+func (d *memoryMigrationDB) GetAll(ctx context.Context, query string, args ...interface{}) (gdb.Result, error) {
+	var result gdb.Result
+	for version, appliedAt := range d.applied[args[0].(string)] {
+		result = append(result, gdb.Record{"version": gvar.New(version), "applied_at": gvar.New(appliedAt)})
+	}
+	return result, nil
+}
+
+// sampleMigrations 示例迁移集合：002 不可回滚，版本编号相同时按名称排序
+func sampleMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"001_create_widgets.up.sql":   {Data: []byte("-- 创建示例表\nCREATE TABLE IF NOT EXISTS `widgets` (\n    `id` BIGINT NOT NULL,\n    PRIMARY KEY (`id`)\n);\n\nINSERT INTO `widgets` (`id`) VALUES (1);\n")},
+		"001_create_widgets.down.sql": {Data: []byte("DROP TABLE IF EXISTS `widgets`;\n")},
+		"002_add_gadgets.sql":         {Data: []byte("CREATE TABLE `gadgets` (`id` BIGINT NOT NULL);\n")},
+		"002_add_sprockets.up.sql":    {Data: []byte("CREATE TABLE `sprockets` (`id` BIGINT NOT NULL);\n")},
+		"002_add_sprockets.down.sql":  {Data: []byte("DROP TABLE `sprockets`;\n")},
+		"README.md":                   {Data: []byte("迁移说明")},
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	Convey("版本化迁移测试", t, func() {
+		ctx := context.Background()
+		db := newMemoryMigrationDB()
+		source := sampleMigrations()
+		migrator := NewMigrator(db, "order-service", source)
+
+		Convey("按编号和名称排序读取迁移，.sql 和 .up.sql 都是执行脚本", func() {
+			files, err := migrator.Migrations()
+			So(err, ShouldBeNil)
+			So(files, ShouldHaveLength, 3)
+			So(files[0].Version, ShouldEqual, "001_create_widgets")
+			So(files[0].Reversible(), ShouldBeTrue)
+			So(files[1].Version, ShouldEqual, "002_add_gadgets")
+			So(files[1].Reversible(), ShouldBeFalse)
+			So(files[2].Version, ShouldEqual, "002_add_sprockets")
+		})
+
+		Convey("应用全部未应用的迁移并记录版本，重复执行不再应用", func() {
+			versions, err := migrator.Up(ctx)
+			So(err, ShouldBeNil)
+			So(versions, ShouldResemble, []string{"001_create_widgets", "002_add_gadgets", "002_add_sprockets"})
+			So(db.tables["widgets"], ShouldBeTrue)
+			So(db.tables["gadgets"], ShouldBeTrue)
+			So(db.tables[SchemaMigrationsTable], ShouldBeTrue)
+			So(db.applied["order-service"], ShouldHaveLength, 3)
+
+			versions, err = migrator.Up(ctx)
+			So(err, ShouldBeNil)
+			So(versions, ShouldBeEmpty)
+
+			statuses, err := migrator.Status(ctx)
+			So(err, ShouldBeNil)
+			So(statuses, ShouldHaveLength, 3)
+			for _, status := range statuses {
+				So(status.Applied, ShouldBeTrue)
+				So(status.AppliedAt, ShouldNotBeNil)
+			}
+
+			Convey("按相反顺序回滚", func() {
+				versions, err := migrator.Down(ctx, 1)
+				So(err, ShouldBeNil)
+				So(versions, ShouldResemble, []string{"002_add_sprockets"})
+				So(db.tables["sprockets"], ShouldBeFalse)
+				So(db.applied["order-service"], ShouldNotContainKey, "002_add_sprockets")
+
+				Convey("没有回滚脚本的迁移不能回滚", func() {
+					versions, err := migrator.Down(ctx, 2)
+					So(errors.Is(err, ErrIrreversibleMigration), ShouldBeTrue)
+					So(versions, ShouldBeEmpty)
+					So(db.applied["order-service"], ShouldContainKey, "002_add_gadgets")
+				})
+
+				Convey("回滚后可以重新应用", func() {
+					versions, err := migrator.Up(ctx)
+					So(err, ShouldBeNil)
+					So(versions, ShouldResemble, []string{"002_add_sprockets"})
+					So(db.tables["sprockets"], ShouldBeTrue)
+				})
+			})
+		})
+
+		Convey("各迁移集合分别记录已应用的版本", func() {
+			_, err := migrator.Up(ctx)
+			So(err, ShouldBeNil)
+
+			other := NewMigrator(db, "product-service", fstest.MapFS{
+				"001_create_widgets.sql": {Data: []byte("CREATE TABLE IF NOT EXISTS `product_widgets` (`id` BIGINT NOT NULL);")},
+			})
+			versions, err := other.Up(ctx)
+			So(err, ShouldBeNil)
+			So(versions, ShouldResemble, []string{"001_create_widgets"})
+			So(db.tables["product_widgets"], ShouldBeTrue)
+			So(db.applied["order-service"], ShouldHaveLength, 3)
+		})
+
+		Convey("迁移失败时停止，之前的迁移保持已应用", func() {
+			db.failOn = "`gadgets`"
+			versions, err := migrator.Up(ctx)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "002_add_gadgets")
+			So(versions, ShouldResemble, []string{"001_create_widgets"})
+			So(db.applied["order-service"], ShouldNotContainKey, "002_add_gadgets")
+			So(db.applied["order-service"], ShouldNotContainKey, "002_add_sprockets")
+		})
+
+		Convey("基线把已有表结构的版本记为已应用而不执行", func() {
+			versions, err := migrator.Baseline(ctx, "002_add_gadgets")
+			So(err, ShouldBeNil)
+			So(versions, ShouldResemble, []string{"001_create_widgets", "002_add_gadgets"})
+			So(db.tables["widgets"], ShouldBeFalse)
+
+			versions, err = migrator.Up(ctx)
+			So(err, ShouldBeNil)
+			So(versions, ShouldResemble, []string{"002_add_sprockets"})
+
+			_, err = migrator.Baseline(ctx, "999_missing")
+			So(errors.Is(err, ErrUnknownMigrationVersion), ShouldBeTrue)
+		})
+
+		Convey("已应用的版本不在迁移集合中时不能回滚", func() {
+			db.applied["order-service"] = map[string]time.Time{"003_removed": time.Now()}
+			_, err := migrator.Down(ctx, 1)
+			So(errors.Is(err, ErrUnknownMigrationVersion), ShouldBeTrue)
+		})
+
+		Convey("迁移文件必须以编号开头", func() {
+			source["create_things.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE things (id BIGINT);")}
+			_, err := migrator.Migrations()
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("只有回滚脚本的迁移无效", func() {
+			source["003_orphan.down.sql"] = &fstest.MapFile{Data: []byte("DROP TABLE orphans;")}
+			_, err := migrator.Migrations()
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestSplitSQLStatements(t *testing.T) {
+	Convey("拆分迁移脚本", t, func() {
+		Convey("忽略注释和空行，按行尾分号拆分", func() {
+			statements := SplitSQLStatements("-- 注释\nALTER TABLE `orders`\nADD COLUMN `a` INT COMMENT '说明；含中文分号',\nADD COLUMN `b` INT;\n\nUPDATE `orders` SET `a` = 1;\r\n")
+			So(statements, ShouldResemble, []string{
+				"ALTER TABLE `orders`\nADD COLUMN `a` INT COMMENT '说明；含中文分号',\nADD COLUMN `b` INT",
+				"UPDATE `orders` SET `a` = 1",
+			})
+		})
+
+		Convey("支持 DELIMITER 指令创建存储过程", func() {
+			statements := SplitSQLStatements("DELIMITER //\nCREATE PROCEDURE Backfill()\nBEGIN\n    UPDATE t SET a = 1;\nEND //\nDELIMITER ;\n\nCALL Backfill();\nDROP PROCEDURE IF EXISTS Backfill;\n")
+			So(statements, ShouldHaveLength, 3)
+			So(statements[0], ShouldEqual, "CREATE PROCEDURE Backfill()\nBEGIN\n    UPDATE t SET a = 1;\nEND")
+			So(statements[1], ShouldEqual, "CALL Backfill()")
+			So(statements[2], ShouldEqual, "DROP PROCEDURE IF EXISTS Backfill")
+		})
+	})
+}
+
+func TestSharedMigrations(t *testing.T) {
+	Convey("共享迁移集合可以解析", t, func() {
+		files, err := NewMigrator(newMemoryMigrationDB(), SharedMigrationSet, SharedMigrations()).Migrations()
+		So(err, ShouldBeNil)
+		So(len(files), ShouldBeGreaterThan, 0)
+		So(files[0].Version, ShouldEqual, "001_create_tenants_table")
+
+		// 同一编号的迁移按名称排序
+		versions := make([]string, 0, len(files))
+		for _, file := range files {
+			versions = append(versions, file.Version)
+		}
+		So(versions, ShouldContain, "005_create_products_table")
+		So(versions, ShouldContain, "005_extend_merchants_table")
+
+		for _, file := range files {
+			So(SplitSQLStatements(file.Up), ShouldNotBeEmpty)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+//go:embed migrations/*.sql
+var sharedMigrations embed.FS
+
+// SharedMigrations 共享迁移集合的脚本目录，随二进制文件一起发布。
+// 服务自己的迁移集合同样用 embed.FS 嵌入，并使用服务名作为集合名称
+func SharedMigrations() fs.FS {
+	source, err := fs.Sub(sharedMigrations, "migrations")
+	if err != nil {
+		panic(err)
+	}
+	return source
+}
+
+// MigrateOnStartup 配置 migration.onStartup 为 true 时在服务启动时应用迁移集合中未应用的迁移，
+// 默认关闭，由部署流程通过 migrate 命令执行
+func MigrateOnStartup(ctx context.Context, set string, source fs.FS) error {
+	if !g.Cfg().MustGet(ctx, "migration.onStartup", false).Bool() {
+		return nil
+	}
+
+	versions, err := NewMigrator(g.DB(), set, source).Up(ctx)
+	if err != nil {
+		return fmt.Errorf("启动时执行 %s 迁移失败: %w", set, err)
+	}
+	g.Log().Infof(ctx, "启动时已应用 %d 个 %s 迁移", len(versions), set)
+	return nil
+}
@@ -0,0 +1,417 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+const (
+	dispatchInterval  = 3 * time.Second
+	dispatchBatchSize = 100
+	// 导出文件保留时长
+	fileRetention = 7 * 24 * time.Hour
+	// 生成超过该时长仍未结束的任务视为已中断
+	jobTimeout = time.Hour
+
+	defaultMaxConcurrent = 2
+	defaultDailyJobs     = 50
+	defaultDailyBytes    = 1 << 30
+)
+
+var (
+	// ErrUnknownKind 没有注册对应生成器的导出类型
+	ErrUnknownKind = errors.New("不支持的导出类型")
+	// ErrDailyJobLimitExceeded 当天创建的导出任务数已达上限
+	ErrDailyJobLimitExceeded = errors.New("今日导出次数已达上限")
+	// ErrDailySizeLimitExceeded 当天生成的导出文件总大小已达上限
+	ErrDailySizeLimitExceeded = errors.New("今日导出文件大小已达上限")
+	// ErrJobNotFound 导出任务不存在
+	ErrJobNotFound = errors.New("导出任务不存在")
+	// ErrJobNotReady 导出文件尚未生成完成
+	ErrJobNotReady = errors.New("导出文件尚未生成完成")
+	// ErrJobExpired 导出文件已过期清理
+	ErrJobExpired = errors.New("导出文件不存在或已过期")
+)
+
+// Handler 导出生成器：按任务的导出条件将文件内容写入 w，返回导出的记录数
+type Handler func(ctx context.Context, job *types.ExportJob, w io.Writer) (int, error)
+
+// Request 导出请求
+type Request struct {
+	Kind     string
+	Format   string
+	FileName string      // 下载时的文件名
+	Params   interface{} // 导出条件，序列化后交给生成器
+}
+
+// Queue 导出队列：导出请求先持久化为排队任务，后台按租户并发数限制逐个生成文件，
+// 创建任务时检查租户当天的任务数和文件大小上限，生成过程中超出文件大小上限的任务失败
+type Queue struct {
+	repo       repository.IExportJobRepository
+	limits     types.ExportLimits
+	storageDir string
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	// 同一实例内串行调度，避免并发调度时超出租户并发数限制
+	dispatchMu sync.Mutex
+	running    sync.WaitGroup
+	wakeCh     chan struct{}
+	stopCh     chan struct{}
+	isRunning  bool
+}
+
+// NewQueue 创建导出队列
+func NewQueue() *Queue {
+	ctx := context.Background()
+	return NewQueueForTest(repository.NewExportJobRepository(), LoadLimits(ctx),
+		g.Cfg().MustGet(ctx, "export.storage_dir", "/tmp/exports").String())
+}
+
+// NewQueueForTest 创建测试用导出队列
+func NewQueueForTest(repo repository.IExportJobRepository, limits types.ExportLimits, storageDir string) *Queue {
+	return &Queue{
+		repo:       repo,
+		limits:     limits,
+		storageDir: storageDir,
+		handlers:   make(map[string]Handler),
+		wakeCh:     make(chan struct{}, 1),
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// LoadLimits 读取导出资源限制配置
+func LoadLimits(ctx context.Context) types.ExportLimits {
+	return types.ExportLimits{
+		MaxConcurrent: g.Cfg().MustGet(ctx, "export.max_concurrent_per_tenant", defaultMaxConcurrent).Int(),
+		DailyJobs:     g.Cfg().MustGet(ctx, "export.daily_jobs_per_tenant", defaultDailyJobs).Int(),
+		DailyBytes:    g.Cfg().MustGet(ctx, "export.daily_bytes_per_tenant", defaultDailyBytes).Int64(),
+	}
+}
+
+// Register 注册导出类型的生成器
+func (q *Queue) Register(kind string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = handler
+}
+
+// Enqueue 创建排队中的导出任务，超出租户当天用量上限时拒绝
+func (q *Queue) Enqueue(ctx context.Context, req *Request) (*types.ExportJob, error) {
+	if q.handler(req.Kind) == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKind, req.Kind)
+	}
+
+	usage, err := q.repo.UsageSince(ctx, startOfDay(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	if q.limits.DailyJobs > 0 && usage.Jobs >= q.limits.DailyJobs {
+		return nil, fmt.Errorf("%w（%d 个）", ErrDailyJobLimitExceeded, q.limits.DailyJobs)
+	}
+	if q.limits.DailyBytes > 0 && usage.Bytes >= q.limits.DailyBytes {
+		return nil, fmt.Errorf("%w（%d 字节）", ErrDailySizeLimitExceeded, q.limits.DailyBytes)
+	}
+
+	params, err := json.Marshal(req.Params)
+	if err != nil {
+		return nil, fmt.Errorf("序列化导出条件失败: %v", err)
+	}
+
+	job := &types.ExportJob{
+		UUID:        "exp_" + guid.S(),
+		Kind:        req.Kind,
+		Params:      string(params),
+		Format:      req.Format,
+		FileName:    req.FileName,
+		Status:      types.ExportJobStatusQueued,
+		RequestedBy: gconv.Uint64(ctx.Value("user_id")),
+	}
+	if err := q.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	q.wake()
+	return job, nil
+}
+
+// Get 获取导出任务，导出文件只对发起人可见
+func (q *Queue) Get(ctx context.Context, uuid string) (*types.ExportJob, error) {
+	job, err := q.repo.GetByUUID(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+	if userID := gconv.Uint64(ctx.Value("user_id")); userID > 0 && job.RequestedBy != userID {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// GetFile 获取已生成完成且未过期的导出任务，用于下载文件
+func (q *Queue) GetFile(ctx context.Context, uuid string) (*types.ExportJob, error) {
+	job, err := q.Get(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != types.ExportJobStatusCompleted {
+		return nil, fmt.Errorf("%w，当前状态: %s", ErrJobNotReady, job.Status)
+	}
+	if job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		return nil, ErrJobExpired
+	}
+	if _, err := os.Stat(job.FilePath); err != nil {
+		return nil, ErrJobExpired
+	}
+	return job, nil
+}
+
+// Start 启动后台调度循环，启动时会继续生成重启前排队的任务
+func (q *Queue) Start(ctx context.Context) {
+	if q.isRunning {
+		return
+	}
+	q.isRunning = true
+	g.Log().Info(ctx, "启动导出队列", "limits", q.limits)
+
+	go func() {
+		ticker := time.NewTicker(dispatchInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := q.DispatchPending(ctx); err != nil {
+				g.Log().Error(ctx, "调度导出任务失败", "error", err)
+			}
+
+			select {
+			case <-q.stopCh:
+				return
+			case <-ticker.C:
+			case <-q.wakeCh:
+			}
+		}
+	}()
+}
+
+// Stop 停止调度并等待生成中的任务结束
+func (q *Queue) Stop(ctx context.Context) {
+	if !q.isRunning {
+		return
+	}
+	q.isRunning = false
+	close(q.stopCh)
+	q.Wait()
+	g.Log().Info(ctx, "导出队列已停止")
+}
+
+// Wait 等待已开始生成的任务结束
+func (q *Queue) Wait() {
+	q.running.Wait()
+}
+
+// DispatchPending 按租户并发数限制开始生成排队中的任务，返回本次开始生成的任务数
+func (q *Queue) DispatchPending(ctx context.Context) (int, error) {
+	q.dispatchMu.Lock()
+	defer q.dispatchMu.Unlock()
+
+	if failed, err := q.repo.FailStale(ctx, time.Now().Add(-jobTimeout), "导出任务生成超时"); err != nil {
+		g.Log().Warning(ctx, "清理超时导出任务失败", "error", err)
+	} else if failed > 0 {
+		g.Log().Warning(ctx, "已将超时的导出任务标记为失败", "count", failed)
+	}
+
+	kinds := q.kinds()
+	if len(kinds) == 0 {
+		return 0, nil
+	}
+	jobs, err := q.repo.ListQueued(ctx, kinds, dispatchBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	started := 0
+	runningByTenant := make(map[uint64]int)
+	for i := range jobs {
+		job := &jobs[i]
+		// 后台生成没有请求上下文，按任务设置租户和发起人
+		jobCtx := context.WithValue(ctx, "tenant_id", job.TenantID)
+		jobCtx = context.WithValue(jobCtx, "user_id", job.RequestedBy)
+
+		running, counted := runningByTenant[job.TenantID]
+		if !counted {
+			if running, err = q.repo.CountByStatus(jobCtx, types.ExportJobStatusRunning); err != nil {
+				g.Log().Error(ctx, "统计生成中的导出任务失败", "tenant_id", job.TenantID, "error", err)
+				continue
+			}
+		}
+		if q.limits.MaxConcurrent > 0 && running >= q.limits.MaxConcurrent {
+			runningByTenant[job.TenantID] = running
+			continue
+		}
+
+		now := time.Now()
+		job.Status = types.ExportJobStatusRunning
+		job.StartedAt = &now
+		ok, err := q.repo.Transition(jobCtx, job, types.ExportJobStatusQueued)
+		if err != nil {
+			g.Log().Error(ctx, "开始生成导出任务失败", "job_id", job.UUID, "error", err)
+			continue
+		}
+		runningByTenant[job.TenantID] = running
+		if !ok {
+			// 已被其他实例开始生成
+			continue
+		}
+
+		runningByTenant[job.TenantID]++
+		started++
+		q.running.Add(1)
+		go q.run(jobCtx, job)
+	}
+
+	return started, nil
+}
+
+// run 生成导出文件并记录结果，结束后唤醒调度继续生成同租户排队的任务
+func (q *Queue) run(ctx context.Context, job *types.ExportJob) {
+	defer q.running.Done()
+	defer q.wake()
+
+	g.Log().Info(ctx, "开始生成导出文件", "job_id", job.UUID, "kind", job.Kind)
+
+	rowCount, size, filePath, err := q.generate(ctx, job)
+	now := time.Now()
+	job.CompletedAt = &now
+	job.RowCount = rowCount
+	job.SizeBytes = size
+	if err != nil {
+		g.Log().Error(ctx, "生成导出文件失败", "job_id", job.UUID, "kind", job.Kind, "error", err)
+		job.Status = types.ExportJobStatusFailed
+		job.ErrorMessage = err.Error()
+		// 失败任务不占用当天的文件大小额度
+		job.SizeBytes = 0
+		if filePath != "" {
+			os.Remove(filePath)
+		}
+	} else {
+		expiresAt := now.Add(fileRetention)
+		job.Status = types.ExportJobStatusCompleted
+		job.FilePath = filePath
+		job.ExpiresAt = &expiresAt
+	}
+
+	ok, err := q.repo.Transition(ctx, job, types.ExportJobStatusRunning)
+	if err != nil || !ok {
+		g.Log().Error(ctx, "记录导出任务结果失败", "job_id", job.UUID, "error", err)
+		if job.Status == types.ExportJobStatusCompleted {
+			os.Remove(filePath)
+		}
+	}
+}
+
+// generate 调用生成器将导出内容写入存储目录下的文件，写入内容超过租户当天剩余额度时中止
+func (q *Queue) generate(ctx context.Context, job *types.ExportJob) (rowCount int, size int64, filePath string, err error) {
+	handler := q.handler(job.Kind)
+	if handler == nil {
+		return 0, 0, "", fmt.Errorf("%w: %s", ErrUnknownKind, job.Kind)
+	}
+
+	remaining := int64(-1)
+	if q.limits.DailyBytes > 0 {
+		usage, err := q.repo.UsageSince(ctx, startOfDay(job.CreatedAt))
+		if err != nil {
+			return 0, 0, "", err
+		}
+		remaining = q.limits.DailyBytes - usage.Bytes
+	}
+
+	if err := os.MkdirAll(q.storageDir, 0755); err != nil {
+		return 0, 0, "", fmt.Errorf("创建导出目录失败: %v", err)
+	}
+	filePath = filepath.Join(q.storageDir, job.UUID+filepath.Ext(job.FileName))
+	file, err := os.Create(filePath)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("创建导出文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := &limitedWriter{w: file, remaining: remaining}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("生成导出文件异常: %v", r)
+		}
+		size = writer.written
+	}()
+
+	rowCount, err = handler(ctx, job, writer)
+	if writer.exceeded {
+		err = fmt.Errorf("%w（%d 字节）", ErrDailySizeLimitExceeded, q.limits.DailyBytes)
+	}
+	return rowCount, writer.written, filePath, err
+}
+
+// kinds 已注册生成器的导出类型，多个服务共用导出任务表时各自只生成自己注册的类型
+func (q *Queue) kinds() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	kinds := make([]string, 0, len(q.handlers))
+	for kind := range q.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func (q *Queue) handler(kind string) Handler {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.handlers[kind]
+}
+
+// wake 通知调度循环立即检查排队的任务
+func (q *Queue) wake() {
+	select {
+	case q.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// limitedWriter 限制写入总大小的 Writer，remaining 小于 0 表示不限制
+type limitedWriter struct {
+	w         io.Writer
+	remaining int64
+	written   int64
+	exceeded  bool
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.remaining >= 0 && l.written+int64(len(p)) > l.remaining {
+		l.exceeded = true
+		return 0, ErrDailySizeLimitExceeded
+	}
+	n, err := l.w.Write(p)
+	l.written += int64(n)
+	return n, err
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,297 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryExportJobRepository 内存导出任务仓储，生成在后台协程中进行，需要加锁
+type memoryExportJobRepository struct {
+	mu   sync.Mutex
+	jobs []*types.ExportJob
+}
+
+func (m *memoryExportJobRepository) Create(ctx context.Context, job *types.ExportJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job.ID = uint64(len(m.jobs) + 1)
+	job.TenantID = gconv.Uint64(ctx.Value("tenant_id"))
+	job.CreatedAt = time.Now()
+	saved := *job
+	m.jobs = append(m.jobs, &saved)
+	return nil
+}
+
+func (m *memoryExportJobRepository) Transition(ctx context.Context, job *types.ExportJob, from types.ExportJobStatus) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current := m.jobs[job.ID-1]
+	if current.Status != from {
+		return false, nil
+	}
+	if !from.CanTransitionTo(job.Status) {
+		return false, errors.New("非法的状态流转")
+	}
+	saved := *job
+	m.jobs[job.ID-1] = &saved
+	return true, nil
+}
+
+func (m *memoryExportJobRepository) GetByUUID(ctx context.Context, uuid string) (*types.ExportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		if job.UUID == uuid && job.TenantID == gconv.Uint64(ctx.Value("tenant_id")) {
+			found := *job
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryExportJobRepository) CountByStatus(ctx context.Context, status types.ExportJobStatus) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := 0
+	for _, job := range m.jobs {
+		if job.Status == status && job.TenantID == gconv.Uint64(ctx.Value("tenant_id")) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *memoryExportJobRepository) UsageSince(ctx context.Context, since time.Time) (*types.ExportUsage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	usage := &types.ExportUsage{}
+	for _, job := range m.jobs {
+		if job.TenantID == gconv.Uint64(ctx.Value("tenant_id")) && !job.CreatedAt.Before(since) {
+			usage.Jobs++
+			usage.Bytes += job.SizeBytes
+		}
+	}
+	return usage, nil
+}
+
+func (m *memoryExportJobRepository) ListQueued(ctx context.Context, kinds []string, limit int) ([]types.ExportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var queued []types.ExportJob
+	for _, job := range m.jobs {
+		if job.Status == types.ExportJobStatusQueued && slices.Contains(kinds, job.Kind) && len(queued) < limit {
+			queued = append(queued, *job)
+		}
+	}
+	return queued, nil
+}
+
+func (m *memoryExportJobRepository) FailStale(ctx context.Context, startedBefore time.Time, reason string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	failed := 0
+	for _, job := range m.jobs {
+		if job.Status == types.ExportJobStatusRunning && job.StartedAt.Before(startedBefore) {
+			job.Status = types.ExportJobStatusFailed
+			job.ErrorMessage = reason
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+func (m *memoryExportJobRepository) status(id uint64) types.ExportJobStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.jobs[id-1].Status
+}
+
+func TestExportQueue(t *testing.T) {
+	Convey("导出队列测试", t, func() {
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		ctx = context.WithValue(ctx, "user_id", uint64(7))
+
+		repo := &memoryExportJobRepository{}
+		limits := types.ExportLimits{MaxConcurrent: 1, DailyJobs: 3, DailyBytes: 100}
+		queue := NewQueueForTest(repo, limits, t.TempDir())
+
+		// release 关闭前生成器一直阻塞，用于模拟生成中的任务
+		release := make(chan struct{})
+		queue.Register("report", func(ctx context.Context, job *types.ExportJob, w io.Writer) (int, error) {
+			<-release
+			_, err := io.WriteString(w, job.Params)
+			return 1, err
+		})
+		queue.Register("oversized", func(ctx context.Context, job *types.ExportJob, w io.Writer) (int, error) {
+			_, err := io.WriteString(w, strings.Repeat("x", 150))
+			return 1, err
+		})
+
+		enqueue := func(kind string) *types.ExportJob {
+			job, err := queue.Enqueue(ctx, &Request{Kind: kind, Format: "csv", FileName: "report.csv", Params: map[string]string{"day": "2025-09-01"}})
+			So(err, ShouldBeNil)
+			return job
+		}
+
+		Convey("导出请求排队后由后台生成，完成后可下载", func() {
+			job := enqueue("report")
+			So(job.Status, ShouldEqual, types.ExportJobStatusQueued)
+			So(job.RequestedBy, ShouldEqual, 7)
+
+			_, err := queue.GetFile(ctx, job.UUID)
+			So(errors.Is(err, ErrJobNotReady), ShouldBeTrue)
+
+			close(release)
+			started, err := queue.DispatchPending(context.Background())
+			So(err, ShouldBeNil)
+			So(started, ShouldEqual, 1)
+			queue.Wait()
+
+			completed, err := queue.GetFile(ctx, job.UUID)
+			So(err, ShouldBeNil)
+			So(completed.Status, ShouldEqual, types.ExportJobStatusCompleted)
+			So(completed.RowCount, ShouldEqual, 1)
+			So(completed.SizeBytes, ShouldEqual, len(`{"day":"2025-09-01"}`))
+			So(completed.StartedAt, ShouldNotBeNil)
+			So(completed.ExpiresAt, ShouldNotBeNil)
+
+			data, err := os.ReadFile(completed.FilePath)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `{"day":"2025-09-01"}`)
+		})
+
+		Convey("同一租户超过并发数的任务继续排队", func() {
+			first := enqueue("report")
+			second := enqueue("report")
+
+			started, err := queue.DispatchPending(context.Background())
+			So(err, ShouldBeNil)
+			So(started, ShouldEqual, 1)
+			So(repo.status(first.ID), ShouldEqual, types.ExportJobStatusRunning)
+
+			started, _ = queue.DispatchPending(context.Background())
+			So(started, ShouldEqual, 0)
+			So(repo.status(second.ID), ShouldEqual, types.ExportJobStatusQueued)
+
+			close(release)
+			queue.Wait()
+			So(repo.status(first.ID), ShouldEqual, types.ExportJobStatusCompleted)
+
+			started, _ = queue.DispatchPending(context.Background())
+			So(started, ShouldEqual, 1)
+			queue.Wait()
+			So(repo.status(second.ID), ShouldEqual, types.ExportJobStatusCompleted)
+		})
+
+		Convey("不同租户的任务互不占用并发数", func() {
+			otherCtx := context.WithValue(context.Background(), "tenant_id", uint64(2))
+			enqueue("report")
+			_, err := queue.Enqueue(otherCtx, &Request{Kind: "report", Format: "csv", FileName: "report.csv"})
+			So(err, ShouldBeNil)
+
+			started, _ := queue.DispatchPending(context.Background())
+			So(started, ShouldEqual, 2)
+			close(release)
+			queue.Wait()
+		})
+
+		Convey("超过每日任务数上限时拒绝排队", func() {
+			close(release)
+			for i := 0; i < limits.DailyJobs; i++ {
+				enqueue("report")
+			}
+			_, err := queue.Enqueue(ctx, &Request{Kind: "report", Format: "csv", FileName: "report.csv"})
+			So(errors.Is(err, ErrDailyJobLimitExceeded), ShouldBeTrue)
+		})
+
+		Convey("生成内容超过每日文件大小上限时任务失败且不保留文件", func() {
+			job := enqueue("oversized")
+			queue.DispatchPending(context.Background())
+			queue.Wait()
+
+			failed, err := queue.Get(ctx, job.UUID)
+			So(err, ShouldBeNil)
+			So(failed.Status, ShouldEqual, types.ExportJobStatusFailed)
+			So(failed.ErrorMessage, ShouldContainSubstring, "今日导出文件大小已达上限")
+			So(failed.SizeBytes, ShouldEqual, 0)
+
+			entries, _ := os.ReadDir(queue.storageDir)
+			So(entries, ShouldBeEmpty)
+
+			_, err = queue.GetFile(ctx, job.UUID)
+			So(errors.Is(err, ErrJobNotReady), ShouldBeTrue)
+		})
+
+		Convey("当天文件大小用尽后拒绝新的导出", func() {
+			close(release)
+			queue.Register("full", func(ctx context.Context, job *types.ExportJob, w io.Writer) (int, error) {
+				_, err := io.WriteString(w, strings.Repeat("x", 100))
+				return 1, err
+			})
+			enqueue("full")
+			queue.DispatchPending(context.Background())
+			queue.Wait()
+
+			_, err := queue.Enqueue(ctx, &Request{Kind: "report", Format: "csv", FileName: "report.csv"})
+			So(errors.Is(err, ErrDailySizeLimitExceeded), ShouldBeTrue)
+		})
+
+		Convey("未注册的导出类型被拒绝", func() {
+			_, err := queue.Enqueue(ctx, &Request{Kind: "unknown"})
+			So(errors.Is(err, ErrUnknownKind), ShouldBeTrue)
+		})
+
+		Convey("其他服务的导出类型留给对应服务生成", func() {
+			close(release)
+			other := &types.ExportJob{UUID: "exp_other", Kind: "other_service", Status: types.ExportJobStatusQueued}
+			So(repo.Create(ctx, other), ShouldBeNil)
+			job := enqueue("report")
+
+			started, err := queue.DispatchPending(context.Background())
+			So(err, ShouldBeNil)
+			So(started, ShouldEqual, 1)
+			queue.Wait()
+			So(repo.status(job.ID), ShouldEqual, types.ExportJobStatusCompleted)
+			So(repo.status(other.ID), ShouldEqual, types.ExportJobStatusQueued)
+		})
+
+		Convey("导出任务只对发起人可见", func() {
+			job := enqueue("report")
+			otherUserCtx := context.WithValue(ctx, "user_id", uint64(8))
+			_, err := queue.Get(otherUserCtx, job.UUID)
+			So(err, ShouldEqual, ErrJobNotFound)
+
+			otherTenantCtx := context.WithValue(context.Background(), "tenant_id", uint64(2))
+			_, err = queue.Get(otherTenantCtx, job.UUID)
+			So(err, ShouldEqual, ErrJobNotFound)
+		})
+
+		Convey("超时未结束的任务标记为失败", func() {
+			job := enqueue("report")
+			queue.DispatchPending(context.Background())
+			repo.mu.Lock()
+			startedAt := time.Now().Add(-2 * time.Hour)
+			repo.jobs[job.ID-1].StartedAt = &startedAt
+			repo.mu.Unlock()
+
+			queue.DispatchPending(context.Background())
+			So(repo.status(job.ID), ShouldEqual, types.ExportJobStatusFailed)
+
+			// 中断的生成结束后不能覆盖失败状态
+			close(release)
+			queue.Wait()
+			So(repo.status(job.ID), ShouldEqual, types.ExportJobStatusFailed)
+		})
+	})
+}
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/xuri/excelize/v2"
+)
+
+// RowWriter 导出文件行写入器
+type RowWriter interface {
+	WriteRow(values []string) error
+	Close() error
+}
+
+// NewRowWriter 按导出格式创建行写入器
+func NewRowWriter(format types.OrderExportFormat, w io.Writer) (RowWriter, error) {
+	switch format {
+	case types.OrderExportFormatCSV:
+		// 写入 UTF-8 BOM，Excel 打开 CSV 时中文不乱码
+		if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return nil, err
+		}
+		return &csvRowWriter{writer: csv.NewWriter(w)}, nil
+	case types.OrderExportFormatExcel:
+		return newExcelRowWriter(w)
+	default:
+		return nil, fmt.Errorf("不支持的导出格式: %s", format)
+	}
+}
+
+// csvRowWriter CSV 行写入器
+type csvRowWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvRowWriter) WriteRow(values []string) error {
+	return c.writer.Write(values)
+}
+
+func (c *csvRowWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// excelRowWriter Excel 行写入器，使用流式写入避免大量数据占用内存
+type excelRowWriter struct {
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	output io.Writer
+	row    int
+}
+
+// newExcelRowWriter 创建 Excel 行写入器
+func newExcelRowWriter(w io.Writer) (*excelRowWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("创建Excel写入器失败: %v", err)
+	}
+	return &excelRowWriter{file: file, stream: stream, output: w}, nil
+}
+
+func (e *excelRowWriter) WriteRow(values []string) error {
+	e.row++
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		cells[i] = value
+	}
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	return e.stream.SetRow(cell, cells)
+}
+
+func (e *excelRowWriter) Close() error {
+	defer e.file.Close()
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.output)
+}
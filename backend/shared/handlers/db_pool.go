@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/config"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gctx"
+)
+
+// dbPoolSampleInterval 连接池采样间隔
+const dbPoolSampleInterval = 15 * time.Second
+
+// DBPoolHandler 数据库连接池指标处理器
+type DBPoolHandler struct {
+	serviceName string
+	monitor     *config.PoolMonitor
+}
+
+// NewDBPoolHandler 创建数据库连接池指标处理器
+func NewDBPoolHandler(serviceName string, monitor *config.PoolMonitor) *DBPoolHandler {
+	return &DBPoolHandler{
+		serviceName: serviceName,
+		monitor:     monitor,
+	}
+}
+
+// RegisterDBPoolMetrics 应用服务的连接池配置，启动连接池监控并注册 /metrics/db-pool 端点
+func RegisterDBPoolMetrics(s *ghttp.Server, serviceName string) *config.PoolMonitor {
+	ctx := gctx.GetInitCtx()
+	poolConfig := config.LoadPoolConfig(ctx)
+	config.ApplyPoolConfig(ctx, g.DB(), poolConfig)
+
+	monitor := config.NewPoolMonitor(g.DB(), poolConfig)
+	monitor.Start(ctx, dbPoolSampleInterval)
+
+	s.BindHandler("/metrics/db-pool", NewDBPoolHandler(serviceName, monitor).Metrics)
+	return monitor
+}
+
+// Metrics 以Prometheus文本格式输出连接池指标
+// @Summary 数据库连接池指标
+// @Description 输出连接池使用中、空闲、等待次数和等待时长等指标
+// @Tags Metrics
+// @Produce plain
+// @Router /metrics/db-pool [get]
+func (h *DBPoolHandler) Metrics(r *ghttp.Request) {
+	r.Response.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.Response.Write(FormatPoolMetrics(h.serviceName, h.monitor.Metrics()))
+}
+
+// FormatPoolMetrics 将连接池指标格式化为Prometheus文本格式
+func FormatPoolMetrics(serviceName string, metrics config.PoolMetrics) string {
+	var b strings.Builder
+	label := fmt.Sprintf(`{service="%s"}`, serviceName)
+
+	write := func(name, metricType, help string, value interface{}) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s%s %v\n", name, help, name, metricType, name, label, value)
+	}
+
+	saturated := 0
+	if metrics.Saturated {
+		saturated = 1
+	}
+
+	write("db_pool_max_open_connections", "gauge", "Maximum number of open connections.", metrics.MaxOpen)
+	write("db_pool_open_connections", "gauge", "Number of established connections.", metrics.Open)
+	write("db_pool_in_use_connections", "gauge", "Number of connections currently in use.", metrics.InUse)
+	write("db_pool_idle_connections", "gauge", "Number of idle connections.", metrics.Idle)
+	write("db_pool_wait_count_total", "counter", "Total number of connections waited for.", metrics.WaitCount)
+	write("db_pool_wait_duration_seconds_total", "counter", "Total time blocked waiting for a new connection.", metrics.WaitDuration.Seconds())
+	write("db_pool_max_idle_closed_total", "counter", "Total connections closed due to max idle.", metrics.MaxIdleClosed)
+	write("db_pool_max_lifetime_closed_total", "counter", "Total connections closed due to max lifetime.", metrics.MaxLifetimeClosed)
+	write("db_pool_wait_count_delta", "gauge", "Connections waited for during the last sample interval.", metrics.WaitCountDelta)
+	write("db_pool_saturated", "gauge", "Whether waits in the last sample interval exceeded the alert threshold.", saturated)
+
+	return b.String()
+}
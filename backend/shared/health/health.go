@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"github.com/gofromzero/mer-sys/backend/shared/config"
+	"github.com/gofromzero/mer-sys/backend/shared/maintenance"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
 )
 
 // HealthStatus 健康状态
@@ -37,6 +40,8 @@ type SystemHealth struct {
 	Uptime     string                     `json:"uptime"`
 	Components map[string]ComponentHealth `json:"components"`
 	System     SystemInfo                 `json:"system"`
+	// 维护模式期间服务仍可读，不影响健康与就绪状态
+	Maintenance *types.MaintenanceStatus `json:"maintenance,omitempty"`
 }
 
 // SystemInfo 系统信息
@@ -54,6 +59,7 @@ type HealthChecker struct {
 	startTime   time.Time
 	serviceName string
 	version     string
+	maintenance *maintenance.Manager
 }
 
 // NewHealthChecker 创建健康检查器
@@ -62,6 +68,7 @@ func NewHealthChecker(serviceName, version string) *HealthChecker {
 		startTime:   time.Now(),
 		serviceName: serviceName,
 		version:     version,
+		maintenance: maintenance.NewManager(),
 	}
 }
 
@@ -93,14 +100,29 @@ func (h *HealthChecker) CheckHealth(ctx context.Context) *SystemHealth {
 	components["system"] = systemHealth
 
 	return &SystemHealth{
-		Status:     overallStatus,
-		Version:    h.version,
-		Service:    h.serviceName,
-		Timestamp:  time.Now(),
-		Uptime:     time.Since(h.startTime).String(),
-		Components: components,
-		System:     h.getSystemInfo(),
+		Status:      overallStatus,
+		Version:     h.version,
+		Service:     h.serviceName,
+		Timestamp:   time.Now(),
+		Uptime:      time.Since(h.startTime).String(),
+		Components:  components,
+		System:      h.getSystemInfo(),
+		Maintenance: h.getMaintenanceStatus(ctx),
+	}
+}
+
+// getMaintenanceStatus 获取平台维护模式状态，读取失败时不返回
+func (h *HealthChecker) getMaintenanceStatus(ctx context.Context) *types.MaintenanceStatus {
+	if h.maintenance == nil {
+		return nil
+	}
+
+	status, err := h.maintenance.Status(ctx, 0)
+	if err != nil {
+		g.Log().Warningf(ctx, "读取维护模式状态失败: %v", err)
+		return nil
 	}
+	return status
 }
 
 // checkDatabase 检查数据库连接
@@ -0,0 +1,117 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/cache"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+const globalKey = "global"
+
+// Manager 维护模式管理器，状态保存在Redis中，所有服务实例共享
+type Manager struct {
+	cache *cache.Cache
+}
+
+// NewManager 创建维护模式管理器
+func NewManager() *Manager {
+	return &Manager{
+		cache: cache.NewCache("maintenance"),
+	}
+}
+
+// NewManagerForTest 创建测试用维护模式管理器（使用模拟缓存）
+func NewManagerForTest() *Manager {
+	return &Manager{
+		cache: cache.NewMockCache(),
+	}
+}
+
+// Status 获取全局与指定租户的维护模式状态，tenantID 为0时只返回全局状态
+func (m *Manager) Status(ctx context.Context, tenantID uint64) (*types.MaintenanceStatus, error) {
+	global, err := m.load(ctx, globalKey)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &types.MaintenanceStatus{Global: global}
+	if tenantID > 0 {
+		if status.Tenant, err = m.load(ctx, tenantKey(tenantID)); err != nil {
+			return nil, err
+		}
+	}
+	status.ReadOnly = status.Active() != nil
+	return status, nil
+}
+
+// SetGlobal 开启或关闭平台维护模式
+func (m *Manager) SetGlobal(ctx context.Context, req *types.SetMaintenanceModeRequest) (*types.MaintenanceState, error) {
+	return m.set(ctx, globalKey, &types.MaintenanceState{Scope: types.MaintenanceScopeGlobal}, req)
+}
+
+// SetTenant 开启或关闭租户维护模式
+func (m *Manager) SetTenant(ctx context.Context, tenantID uint64, req *types.SetMaintenanceModeRequest) (*types.MaintenanceState, error) {
+	if tenantID == 0 {
+		return nil, fmt.Errorf("租户ID不能为空")
+	}
+	return m.set(ctx, tenantKey(tenantID), &types.MaintenanceState{Scope: types.MaintenanceScopeTenant, TenantID: tenantID}, req)
+}
+
+// set 保存维护模式状态并记录审计日志，关闭时删除状态
+func (m *Manager) set(ctx context.Context, key string, state *types.MaintenanceState, req *types.SetMaintenanceModeRequest) (*types.MaintenanceState, error) {
+	if req.RetryAfter < 0 || req.RetryAfter > types.MaxMaintenanceRetryAfter {
+		return nil, fmt.Errorf("重试间隔必须在0到%d秒之间", types.MaxMaintenanceRetryAfter)
+	}
+
+	previous, err := m.load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	state.Enabled = req.Enabled
+	state.Reason = req.Reason
+	state.RetryAfter = req.RetryAfter
+	if state.RetryAfter == 0 {
+		state.RetryAfter = types.DefaultMaintenanceRetryAfter
+	}
+	state.UpdatedBy = gconv.Uint64(ctx.Value("user_id"))
+	state.UpdatedAt = time.Now()
+
+	if state.Enabled {
+		err = m.cache.Set(ctx, key, state, 0)
+	} else {
+		err = m.cache.Delete(ctx, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("保存维护模式状态失败: %v", err)
+	}
+
+	audit.LogMaintenanceModeChange(ctx, state, previous != nil)
+	return state, nil
+}
+
+// load 读取维护模式状态，未开启时返回nil
+func (m *Manager) load(ctx context.Context, key string) (*types.MaintenanceState, error) {
+	exists, err := m.cache.Exists(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("读取维护模式状态失败: %v", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	var state types.MaintenanceState
+	if err := m.cache.GetStruct(ctx, key, &state); err != nil {
+		return nil, fmt.Errorf("读取维护模式状态失败: %v", err)
+	}
+	return &state, nil
+}
+
+func tenantKey(tenantID uint64) string {
+	return fmt.Sprintf("tenant:%d", tenantID)
+}
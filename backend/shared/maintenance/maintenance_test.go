@@ -0,0 +1,68 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestManager(t *testing.T) {
+	Convey("维护模式状态测试", t, func() {
+		manager := NewManagerForTest()
+		ctx := context.WithValue(context.Background(), "user_id", uint64(9))
+
+		Convey("默认不处于维护模式", func() {
+			status, err := manager.Status(ctx, 1)
+			So(err, ShouldBeNil)
+			So(status.ReadOnly, ShouldBeFalse)
+			So(status.Active(), ShouldBeNil)
+		})
+
+		Convey("开启租户维护模式记录操作人并使用默认重试间隔", func() {
+			state, err := manager.SetTenant(ctx, 1, &types.SetMaintenanceModeRequest{Enabled: true, Reason: "数据迁移"})
+			So(err, ShouldBeNil)
+			So(state.Scope, ShouldEqual, types.MaintenanceScopeTenant)
+			So(state.UpdatedBy, ShouldEqual, 9)
+			So(state.RetryAfter, ShouldEqual, types.DefaultMaintenanceRetryAfter)
+
+			status, err := manager.Status(ctx, 1)
+			So(err, ShouldBeNil)
+			So(status.ReadOnly, ShouldBeTrue)
+			So(status.Tenant.Reason, ShouldEqual, "数据迁移")
+
+			status, err = manager.Status(ctx, 2)
+			So(err, ShouldBeNil)
+			So(status.ReadOnly, ShouldBeFalse)
+		})
+
+		Convey("全局维护模式优先于租户维护模式", func() {
+			_, err := manager.SetTenant(ctx, 1, &types.SetMaintenanceModeRequest{Enabled: true, RetryAfter: 60})
+			So(err, ShouldBeNil)
+			_, err = manager.SetGlobal(ctx, &types.SetMaintenanceModeRequest{Enabled: true, RetryAfter: 600})
+			So(err, ShouldBeNil)
+
+			status, err := manager.Status(ctx, 1)
+			So(err, ShouldBeNil)
+			So(status.Active().Scope, ShouldEqual, types.MaintenanceScopeGlobal)
+			So(status.Active().RetryAfter, ShouldEqual, 600)
+
+			_, err = manager.SetGlobal(ctx, &types.SetMaintenanceModeRequest{Enabled: false})
+			So(err, ShouldBeNil)
+			status, err = manager.Status(ctx, 1)
+			So(err, ShouldBeNil)
+			So(status.Global, ShouldBeNil)
+			So(status.Active().Scope, ShouldEqual, types.MaintenanceScopeTenant)
+		})
+
+		Convey("重试间隔超出范围时拒绝", func() {
+			_, err := manager.SetGlobal(ctx, &types.SetMaintenanceModeRequest{Enabled: true, RetryAfter: -1})
+			So(err, ShouldNotBeNil)
+
+			status, err := manager.Status(ctx, 0)
+			So(err, ShouldBeNil)
+			So(status.ReadOnly, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,261 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+const (
+	// defaultMaxAccessLogBodiesPerMinute 每分钟最多记录请求/响应体的请求数
+	defaultMaxAccessLogBodiesPerMinute = 60
+	// defaultMaxAccessLogBodySize 超过该大小的请求/响应体不记录内容
+	defaultMaxAccessLogBodySize = 4096
+	// redactedValue 凭证类字段的替换值
+	redactedValue = "******"
+)
+
+// credentialKeywords 字段名包含这些关键字时视为凭证，无论租户脱敏策略如何配置都不记录原值
+var credentialKeywords = []string{"password", "token", "secret", "authorization", "captcha", "credential"}
+
+// AccessLogConfig 访问日志配置
+type AccessLogConfig struct {
+	Enabled            bool     `json:"enabled"`            // 是否记录访问日志
+	BodyRoutes         []string `json:"bodyRoutes"`         // 记录请求/响应体的路由，格式为"方法 路径"，路径以 /* 结尾时匹配前缀，如 "POST /api/v1/orders"
+	BodySampleRate     int      `json:"bodySampleRate"`     // 采样率，N 表示匹配路由的请求每 N 条记录 1 条请求/响应体，小于等于1时全部记录
+	MaxBodiesPerMinute int      `json:"maxBodiesPerMinute"` // 每分钟最多记录请求/响应体的请求数
+	MaxBodySize        int      `json:"maxBodySize"`        // 超过该字节数的请求/响应体不记录内容
+}
+
+// AccessLogEntry 访问日志记录，不包含任何请求头
+type AccessLogEntry struct {
+	Timestamp    time.Time `json:"timestamp"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	Status       int       `json:"status"`
+	DurationMs   int64     `json:"duration_ms"`
+	TenantID     uint64    `json:"tenant_id,omitempty"`
+	UserID       uint64    `json:"user_id,omitempty"`
+	ClientIP     string    `json:"client_ip"`
+	RequestBody  string    `json:"request_body,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+}
+
+// AccessLogWriter 访问日志输出
+type AccessLogWriter func(ctx context.Context, entry *AccessLogEntry)
+
+// LoadAccessLogConfig 从 accessLog 配置加载访问日志配置，默认只记录访问行，不记录请求/响应体
+func LoadAccessLogConfig(ctx context.Context) *AccessLogConfig {
+	config := &AccessLogConfig{Enabled: true}
+	if err := g.Cfg().MustGet(ctx, "accessLog").Scan(config); err != nil {
+		g.Log().Warningf(ctx, "访问日志配置无效，使用默认配置: %v", err)
+		config = &AccessLogConfig{Enabled: true}
+	}
+	if config.MaxBodiesPerMinute <= 0 {
+		config.MaxBodiesPerMinute = defaultMaxAccessLogBodiesPerMinute
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultMaxAccessLogBodySize
+	}
+	return config
+}
+
+// AccessLog 访问日志中间件：记录方法、路径、状态码、耗时、租户和用户。
+// 请求/响应体只对配置的路由按采样率和每分钟上限记录，记录前去除凭证字段并按租户脱敏策略脱敏
+func AccessLog(config *AccessLogConfig) ghttp.HandlerFunc {
+	return NewAccessLogger(config, writeAccessLog).Handle
+}
+
+// AccessLogger 访问日志记录器，维护请求/响应体的采样计数和每分钟限额
+type AccessLogger struct {
+	config *AccessLogConfig
+	write  AccessLogWriter
+	now    func() time.Time
+
+	mutex       sync.Mutex
+	seen        uint64    // 匹配记录体路由的请求数
+	windowStart time.Time // 当前限额窗口的开始时间
+	windowCount int       // 当前限额窗口内已记录体的请求数
+}
+
+// NewAccessLogger 创建访问日志记录器
+func NewAccessLogger(config *AccessLogConfig, write AccessLogWriter) *AccessLogger {
+	if config == nil {
+		config = &AccessLogConfig{Enabled: true}
+	}
+	if config.MaxBodiesPerMinute <= 0 {
+		config.MaxBodiesPerMinute = defaultMaxAccessLogBodiesPerMinute
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultMaxAccessLogBodySize
+	}
+	return &AccessLogger{
+		config: config,
+		write:  write,
+		now:    time.Now,
+	}
+}
+
+// Handle 访问日志中间件处理函数。需放在JWT认证中间件之前，请求结束后从上下文读取认证写入的租户和用户
+func (l *AccessLogger) Handle(r *ghttp.Request) {
+	if !l.config.Enabled {
+		r.Middleware.Next()
+		return
+	}
+
+	startTime := l.now()
+	captureBody := l.matchBodyRoute(r.Method, r.URL.Path) && l.allowBody(startTime)
+	var requestBody string
+	if captureBody {
+		requestBody = r.GetBodyString()
+	}
+
+	r.Middleware.Next()
+
+	ctx := r.GetCtx()
+	entry := &AccessLogEntry{
+		Timestamp:  startTime,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Status:     r.Response.Status,
+		DurationMs: l.now().Sub(startTime).Milliseconds(),
+		TenantID:   gconv.Uint64(ctx.Value("tenant_id")),
+		UserID:     gconv.Uint64(ctx.Value("user_id")),
+		ClientIP:   r.GetClientIp(),
+	}
+	if captureBody {
+		entry.RequestBody = l.redactBody(ctx, entry.TenantID, r.Header.Get("Content-Type"), requestBody)
+		entry.ResponseBody = l.redactBody(ctx, entry.TenantID, r.Response.Header().Get("Content-Type"), r.Response.BufferString())
+	}
+	l.write(ctx, entry)
+}
+
+// matchBodyRoute 请求是否属于需要记录请求/响应体的路由
+func (l *AccessLogger) matchBodyRoute(method, path string) bool {
+	for _, route := range l.config.BodyRoutes {
+		routeMethod, routePath, found := strings.Cut(strings.TrimSpace(route), " ")
+		if !found || !strings.EqualFold(routeMethod, method) {
+			continue
+		}
+		routePath = strings.TrimSpace(routePath)
+		if prefix, wildcard := strings.CutSuffix(routePath, "/*"); wildcard {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if path == routePath {
+			return true
+		}
+	}
+	return false
+}
+
+// allowBody 按采样率和每分钟上限决定本次请求是否记录请求/响应体
+func (l *AccessLogger) allowBody(now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.seen++
+	if rate := uint64(l.config.BodySampleRate); rate > 1 && (l.seen-1)%rate != 0 {
+		return false
+	}
+
+	if now.Sub(l.windowStart) >= time.Minute {
+		l.windowStart = now
+		l.windowCount = 0
+	}
+	if l.windowCount >= l.config.MaxBodiesPerMinute {
+		return false
+	}
+	l.windowCount++
+	return true
+}
+
+// redactBody 去除凭证字段后按租户脱敏策略脱敏请求/响应体。
+// 只记录JSON和表单内容，其他内容及超过大小上限的内容只记录长度
+func (l *AccessLogger) redactBody(ctx context.Context, tenantID uint64, contentType, body string) string {
+	if body == "" {
+		return ""
+	}
+	if len(body) > l.config.MaxBodySize {
+		return fmt.Sprintf("[内容过大未记录，%d字节]", len(body))
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal([]byte(body), &generic); err == nil {
+		redacted, err := json.Marshal(redactCredentials(generic))
+		if err != nil {
+			return redactedValue
+		}
+		return audit.MaskJSON(ctx, tenantID, string(redacted))
+	}
+
+	if strings.Contains(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(body)
+		if err != nil {
+			return fmt.Sprintf("[表单解析失败未记录，%d字节]", len(body))
+		}
+		for key := range values {
+			if isCredentialField(key) {
+				values[key] = []string{redactedValue}
+			}
+		}
+		return audit.MaskText(values.Encode())
+	}
+	return fmt.Sprintf("[非JSON内容未记录，%d字节]", len(body))
+}
+
+// redactCredentials 递归替换凭证类字段的值
+func redactCredentials(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if isCredentialField(key) {
+				result[key] = redactedValue
+				continue
+			}
+			result[key] = redactCredentials(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = redactCredentials(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// isCredentialField 字段名是否为凭证类字段
+func isCredentialField(key string) bool {
+	key = strings.ToLower(key)
+	for _, keyword := range credentialKeywords {
+		if strings.Contains(key, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeAccessLog 写入访问日志
+func writeAccessLog(ctx context.Context, entry *AccessLogEntry) {
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		g.Log().Error(ctx, "访问日志序列化失败", err)
+		return
+	}
+	g.Log().Info(ctx, "ACCESS", string(entryJSON))
+}
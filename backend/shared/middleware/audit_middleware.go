@@ -3,10 +3,15 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/gconv"
 )
 
 // AuditEvent 审计事件结构
@@ -74,6 +79,9 @@ func AuditLog(resource string) ghttp.HandlerFunc {
 			event.ErrorMsg = r.Response.BufferString()
 		}
 
+		// 写入前脱敏请求体、变更内容和错误信息
+		maskAuditEvent(r.GetCtx(), event)
+
 		// 异步写入审计日志
 		go writeAuditLog(event)
 	}
@@ -117,6 +125,83 @@ func getActionFromMethod(method string) string {
 	}
 }
 
+// maskAuditEvent 按租户脱敏策略处理审计事件中的敏感内容
+func maskAuditEvent(ctx context.Context, event *AuditEvent) {
+	tenantID := gconv.Uint64(event.TenantID)
+	if event.RequestBody != "" {
+		event.RequestBody = audit.MaskJSON(ctx, tenantID, event.RequestBody)
+	}
+	if event.Changes != nil {
+		event.Changes = audit.MaskDetails(ctx, tenantID, event.Changes)
+	}
+	if event.ErrorMsg != "" {
+		event.ErrorMsg = audit.MaskJSON(ctx, tenantID, event.ErrorMsg)
+	}
+}
+
+// NewTenantMaskingPolicyProvider 创建从租户配置读取脱敏策略的提供者
+func NewTenantMaskingPolicyProvider(tenantRepo repository.ITenantRepository) audit.MaskingPolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.DataMaskingPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.Masking, nil
+	}
+}
+
+// NewTenantSamplingPolicyProvider 创建从租户配置读取审计采样策略的提供者
+func NewTenantSamplingPolicyProvider(tenantRepo repository.ITenantRepository) audit.SamplingPolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.AuditSamplingPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.AuditSampling, nil
+	}
+}
+
+// NewTenantAlertPolicyProvider 创建从租户配置读取审计告警规则的提供者
+func NewTenantAlertPolicyProvider(tenantRepo repository.ITenantRepository) audit.AlertPolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.AuditAlertPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.AuditAlerts, nil
+	}
+}
+
 // writeAuditLog 写入审计日志
 func writeAuditLog(event *AuditEvent) {
 	// 将审计事件序列化为JSON
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
 	"github.com/gofromzero/mer-sys/backend/shared/auth"
 	"github.com/gofromzero/mer-sys/backend/shared/repository"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
@@ -17,13 +18,25 @@ type AuthMiddleware struct {
 	roleRepository repository.RoleRepository
 	publicPaths    []string
 	skipPaths      []string
+	stepUpPolicy   *auth.StepUpPolicy // 敏感操作重新验证策略
 }
 
 // NewAuthMiddleware 创建认证中间件实例
 func NewAuthMiddleware() *AuthMiddleware {
+	// 审计日志按租户配置脱敏
+	audit.SetMaskingPolicyProvider(NewTenantMaskingPolicyProvider(repository.NewTenantRepository()))
+	// 高频 info 审计事件按全局配置和租户配置采样
+	audit.SetGlobalSamplingPolicy(audit.LoadSamplingPolicy(context.Background()))
+	audit.SetSamplingPolicyProvider(NewTenantSamplingPolicyProvider(repository.NewTenantRepository()))
+	// 审计事件按全局配置和租户配置的阈值告警
+	audit.SetGlobalAlertPolicy(audit.LoadAlertPolicy(context.Background()))
+	audit.SetAlertPolicyProvider(NewTenantAlertPolicyProvider(repository.NewTenantRepository()))
+	audit.SetAlertNotifier(audit.LoadAlertNotifier(context.Background()))
+
 	return &AuthMiddleware{
 		jwtManager:     auth.NewJWTManager(),
 		roleRepository: repository.NewRoleRepository(),
+		stepUpPolicy:   auth.LoadStepUpPolicy(context.Background()),
 		publicPaths: []string{
 			"/api/v1/auth/login",
 			"/api/v1/auth/register",
@@ -50,6 +63,7 @@ func NewAuthMiddlewareForTest(jwtManager *auth.JWTManager, roleRepo repository.R
 	return &AuthMiddleware{
 		jwtManager:     jwtManager,
 		roleRepository: roleRepo,
+		stepUpPolicy:   auth.NewStepUpPolicy(auth.DefaultStepUpFreshness, auth.DefaultStepUpOperations),
 		publicPaths: []string{
 			"/api/v1/auth/login",
 			"/api/v1/auth/register",
@@ -89,6 +103,12 @@ func (am *AuthMiddleware) SetSkipPaths(paths []string) *AuthMiddleware {
 	return am
 }
 
+// SetStepUpPolicy 设置敏感操作重新验证策略
+func (am *AuthMiddleware) SetStepUpPolicy(policy *auth.StepUpPolicy) *AuthMiddleware {
+	am.stepUpPolicy = policy
+	return am
+}
+
 // JWTAuth JWT认证中间件
 func (am *AuthMiddleware) JWTAuth(r *ghttp.Request) {
 	ctx := r.GetCtx()
@@ -141,6 +161,9 @@ func (am *AuthMiddleware) JWTAuth(r *ghttp.Request) {
 	ctx = context.WithValue(ctx, "permissions", claims.Permissions)
 	ctx = context.WithValue(ctx, "token", token)
 	ctx = context.WithValue(ctx, "token_type", claims.TokenType)
+	if claims.MerchantID != nil {
+		ctx = context.WithValue(ctx, "merchant_id", *claims.MerchantID)
+	}
 	
 	// 添加客户端信息用于审计
 	ctx = context.WithValue(ctx, "client_ip", r.GetClientIp())
@@ -175,14 +198,14 @@ func (am *AuthMiddleware) PermissionAuth(requiredPermission types.Permission) gh
 		}
 
 		// 检查用户是否拥有所需权限
-		hasPermission, err := am.roleRepository.HasPermission(ctx, userID, tenantID, requiredPermission)
+		userPermissions, err := am.effectivePermissions(ctx, userID, tenantID)
 		if err != nil {
 			g.Log().Errorf(ctx, "权限检查失败: %v", err)
 			am.respondWithError(r, 500, "权限检查失败")
 			return
 		}
 
-		if !hasPermission {
+		if !userPermissions.HasPermission(requiredPermission) {
 			am.respondWithError(r, 403, "权限不足")
 			return
 		}
@@ -293,16 +316,17 @@ func (am *AuthMiddleware) RequirePermissions(permissions ...types.Permission) gh
 			return
 		}
 
+		// 根据用户角色解析有效权限（结果由角色仓储按用户缓存），商户用户只保留商户范围内的权限
+		userPermissions, err := am.effectivePermissions(ctx, userID, tenantID)
+		if err != nil {
+			g.Log().Errorf(ctx, "权限检查失败: %v", err)
+			am.respondWithError(r, 500, "权限检查失败")
+			return
+		}
+
 		// 检查用户是否拥有所有所需权限
 		for _, permission := range permissions {
-			hasPermission, err := am.roleRepository.HasPermission(ctx, userID, tenantID, permission)
-			if err != nil {
-				g.Log().Errorf(ctx, "权限检查失败: %v", err)
-				am.respondWithError(r, 500, "权限检查失败")
-				return
-			}
-
-			if !hasPermission {
+			if !userPermissions.HasPermission(permission) {
 				am.respondWithError(r, 403, "权限不足：缺少"+string(permission))
 				return
 			}
@@ -334,15 +358,16 @@ func (am *AuthMiddleware) RequireAnyPermission(permissions ...types.Permission)
 			return
 		}
 
+		userPermissions, err := am.effectivePermissions(ctx, userID, tenantID)
+		if err != nil {
+			g.Log().Errorf(ctx, "权限检查失败: %v", err)
+			am.respondWithError(r, 500, "权限检查失败")
+			return
+		}
+
 		// 检查用户是否拥有任意一个所需权限
 		for _, permission := range permissions {
-			hasPermission, err := am.roleRepository.HasPermission(ctx, userID, tenantID, permission)
-			if err != nil {
-				g.Log().Errorf(ctx, "权限检查失败: %v", err)
-				continue
-			}
-
-			if hasPermission {
+			if userPermissions.HasPermission(permission) {
 				// 拥有其中一个权限即可通过
 				r.Middleware.Next()
 				return
@@ -354,6 +379,23 @@ func (am *AuthMiddleware) RequireAnyPermission(permissions ...types.Permission)
 	}
 }
 
+// effectivePermissions 获取用户的有效权限。令牌携带商户ID的商户用户即使被授予了租户级角色，
+// 也只保留商户范围内的权限，与权限查询接口返回的结果一致
+func (am *AuthMiddleware) effectivePermissions(ctx context.Context, userID, tenantID uint64) (*types.UserPermissions, error) {
+	userPermissions, err := am.roleRepository.GetUserPermissions(ctx, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if merchantID, ok := GetMerchantIDFromContext(ctx); !ok || merchantID == 0 {
+		return userPermissions, nil
+	}
+
+	// 角色仓储缓存的权限对象可能被其他请求共享，过滤结果写入副本
+	scoped := *userPermissions
+	scoped.Permissions = types.ScopePermissionsToMerchant(userPermissions.Permissions)
+	return &scoped, nil
+}
+
 // extractToken 从请求中提取Token
 func (am *AuthMiddleware) extractToken(r *ghttp.Request) string {
 	// 1. 从Authorization头提取
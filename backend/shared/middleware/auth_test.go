@@ -114,6 +114,26 @@ func (m *MockRoleRepository) GetTenantRoleStats(ctx context.Context, tenantID ui
 	return make(map[types.RoleType]int), nil
 }
 
+func (m *MockRoleRepository) CreateRole(ctx context.Context, tenantID uint64, role *types.Role) error {
+	return nil
+}
+
+func (m *MockRoleRepository) ListRoles(ctx context.Context, tenantID uint64) ([]types.Role, error) {
+	return []types.Role{}, nil
+}
+
+func (m *MockRoleRepository) GetRoleDefinitions(ctx context.Context, tenantID uint64, roleTypes []types.RoleType) (map[types.RoleType]types.Role, error) {
+	return make(map[types.RoleType]types.Role), nil
+}
+
+func (m *MockRoleRepository) GrantRolePermissions(ctx context.Context, tenantID uint64, roleType types.RoleType, permissions []types.Permission) error {
+	return nil
+}
+
+func (m *MockRoleRepository) RevokeRolePermissions(ctx context.Context, tenantID uint64, roleType types.RoleType, permissions []types.Permission) error {
+	return nil
+}
+
 // TestAuthMiddleware 认证中间件测试
 func TestAuthMiddleware(t *testing.T) {
 	Convey("认证中间件测试", t, func() {
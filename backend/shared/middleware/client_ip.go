@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// TrustedProxies 可信反向代理：只有直连地址属于可信代理时才采用 X-Forwarded-For 中的客户端地址
+type TrustedProxies struct {
+	networks []*net.IPNet
+}
+
+var (
+	defaultTrustedProxies     *TrustedProxies
+	defaultTrustedProxiesOnce sync.Once
+)
+
+// ParseTrustedProxies 解析可信代理列表，每项为 IP 或 CIDR
+func ParseTrustedProxies(entries []string) (*TrustedProxies, error) {
+	proxies := &TrustedProxies{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("无效的可信代理地址: %s", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies.networks = append(proxies.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("无效的可信代理网段: %s", entry)
+		}
+		proxies.networks = append(proxies.networks, network)
+	}
+	return proxies, nil
+}
+
+// LoadTrustedProxies 从 security.trustedProxies 配置加载可信代理，未配置或配置无效时不信任任何代理
+func LoadTrustedProxies(ctx context.Context) *TrustedProxies {
+	v, err := g.Cfg().Get(ctx, "security.trustedProxies")
+	if err != nil || v.IsEmpty() {
+		return &TrustedProxies{}
+	}
+	proxies, err := ParseTrustedProxies(v.Strings())
+	if err != nil {
+		g.Log().Warningf(ctx, "可信代理配置无效，不信任任何转发头: %v", err)
+		return &TrustedProxies{}
+	}
+	return proxies
+}
+
+// trusted 地址是否属于可信代理
+func (p *TrustedProxies) trusted(ip net.IP) bool {
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP 返回请求的客户端IP。直连地址不是可信代理时直接使用直连地址，客户端自行携带的转发头不可信；
+// 否则从右往左取 X-Forwarded-For 中第一个不属于可信代理的地址
+func (p *TrustedProxies) ClientIP(r *ghttp.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+
+	ip := net.ParseIP(remote)
+	if ip == nil || !p.trusted(ip) {
+		return remote
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		hopIP := net.ParseIP(hop)
+		if hopIP == nil {
+			// 转发头格式错误时不再继续向左信任
+			break
+		}
+		if !p.trusted(hopIP) {
+			return hop
+		}
+		remote = hop
+	}
+	return remote
+}
+
+// ClientIP 按 security.trustedProxies 配置返回请求的客户端IP，用于限流、人机验证和 IP 绑定等安全判断
+func ClientIP(r *ghttp.Request) string {
+	defaultTrustedProxiesOnce.Do(func() {
+		defaultTrustedProxies = LoadTrustedProxies(context.Background())
+	})
+	return defaultTrustedProxies.ClientIP(r)
+}
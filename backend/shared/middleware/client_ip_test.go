@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gogf/gf/v2/net/ghttp"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newClientIPRequest 构造指定直连地址和转发头的请求
+func newClientIPRequest(remoteAddr, forwardedFor string) *ghttp.Request {
+	req := httptest.NewRequest("POST", "/api/v1/auth/login", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	return &ghttp.Request{Request: req}
+}
+
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	Convey("客户端IP解析测试", t, func() {
+		proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.10"})
+		So(err, ShouldBeNil)
+
+		Convey("直连地址不是可信代理时忽略客户端携带的转发头", func() {
+			So(proxies.ClientIP(newClientIPRequest("203.0.113.7:51234", "1.2.3.4")), ShouldEqual, "203.0.113.7")
+		})
+
+		Convey("经可信代理转发时取最右侧的非代理地址", func() {
+			So(proxies.ClientIP(newClientIPRequest("10.0.0.5:443", "1.2.3.4, 203.0.113.7")), ShouldEqual, "203.0.113.7")
+			So(proxies.ClientIP(newClientIPRequest("192.168.1.10:443", "203.0.113.7, 10.0.0.6")), ShouldEqual, "203.0.113.7")
+		})
+
+		Convey("可信代理未携带转发头时使用直连地址", func() {
+			So(proxies.ClientIP(newClientIPRequest("10.0.0.5:443", "")), ShouldEqual, "10.0.0.5")
+		})
+
+		Convey("未配置可信代理时始终使用直连地址", func() {
+			none, err := ParseTrustedProxies(nil)
+			So(err, ShouldBeNil)
+			So(none.ClientIP(newClientIPRequest("10.0.0.5:443", "1.2.3.4")), ShouldEqual, "10.0.0.5")
+		})
+
+		Convey("无效的可信代理配置返回错误", func() {
+			_, err := ParseTrustedProxies([]string{"10.0.0.0/33"})
+			So(err, ShouldNotBeNil)
+			_, err = ParseTrustedProxies([]string{"proxy.internal"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -12,6 +12,7 @@ const (
 	FundPermissionAllocate = "fund:allocate"
 	FundPermissionView     = "fund:view"
 	FundPermissionFreeze   = "fund:freeze"
+	FundPermissionApprove  = "fund:approve"
 )
 
 // FundPermissionMiddleware 资金权限检查中间件
@@ -77,4 +78,9 @@ func RequireFundView(r *ghttp.Request) {
 // RequireFundFreeze 需要冻结权限
 func RequireFundFreeze(r *ghttp.Request) {
 	FundPermissionMiddleware(FundPermissionFreeze)(r)
-}
\ No newline at end of file
+}
+
+// RequireFundApprove 需要大额资金审批权限
+func RequireFundApprove(r *ghttp.Request) {
+	FundPermissionMiddleware(FundPermissionApprove)(r)
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gofromzero/mer-sys/backend/shared/maintenance"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// MaintenanceMode 维护模式中间件：平台或当前租户处于维护模式时以503拒绝写请求（POST/PUT/DELETE/PATCH），
+// 读请求不受影响；拥有系统配置权限的运维人员可绕过限制。需放在JWT认证中间件之后
+func MaintenanceMode(manager *maintenance.Manager) ghttp.HandlerFunc {
+	return func(r *ghttp.Request) {
+		if !isMutatingMethod(r.Method) {
+			r.Middleware.Next()
+			return
+		}
+
+		ctx := r.GetCtx()
+		status, err := manager.Status(ctx, gconv.Uint64(ctx.Value("tenant_id")))
+		if err != nil {
+			// 状态不可读时放行，避免Redis故障导致全平台只读
+			g.Log().Errorf(ctx, "读取维护模式状态失败: %v", err)
+			r.Middleware.Next()
+			return
+		}
+
+		state := status.Active()
+		if state == nil {
+			r.Middleware.Next()
+			return
+		}
+
+		if HasPermissionInContext(ctx, types.PermissionSystemConfig) {
+			g.Log().Infof(ctx, "运维人员绕过维护模式: user_id=%v %s %s", ctx.Value("user_id"), r.Method, r.URL.Path)
+			r.Middleware.Next()
+			return
+		}
+
+		message := "系统维护中，暂时只能查看数据，请稍后重试"
+		if state.Reason != "" {
+			message = "系统维护中，暂时只能查看数据：" + state.Reason
+		}
+
+		r.Response.Header().Set("Retry-After", strconv.Itoa(state.RetryAfter))
+		r.Response.Status = http.StatusServiceUnavailable
+		r.Response.WriteJsonExit(utils.APIResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: message,
+			Data: g.Map{
+				"scope":       state.Scope,
+				"retry_after": state.RetryAfter,
+			},
+		})
+	}
+}
+
+// isMutatingMethod 判断是否为会修改数据的请求方法
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/maintenance"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/guid"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeJWTAuth 模拟JWT认证中间件，从请求头写入租户与权限上下文
+func fakeJWTAuth(r *ghttp.Request) {
+	ctx := context.WithValue(r.GetCtx(), "tenant_id", r.Header.Get("X-Tenant-ID"))
+	ctx = context.WithValue(ctx, "user_id", uint64(1))
+	if r.Header.Get("X-Operator") != "" {
+		ctx = context.WithValue(ctx, "permissions", []types.Permission{types.PermissionSystemConfig})
+	}
+	r.SetCtx(ctx)
+	r.Middleware.Next()
+}
+
+// startMaintenanceServer 启动挂载维护模式中间件的测试服务
+func startMaintenanceServer(manager *maintenance.Manager) (*ghttp.Server, string) {
+	s := g.Server(guid.S())
+	s.Group("/api/v1/orders", func(group *ghttp.RouterGroup) {
+		group.Middleware(fakeJWTAuth, MaintenanceMode(manager))
+		handler := func(r *ghttp.Request) { utils.SuccessResponse(r, r.Method) }
+		group.GET("/", handler)
+		group.POST("/", handler)
+		group.PUT("/:id", handler)
+		group.PATCH("/:id", handler)
+		group.DELETE("/:id", handler)
+	})
+	s.SetDumpRouterMap(false)
+	if err := s.Start(); err != nil {
+		panic(err)
+	}
+	return s, fmt.Sprintf("http://127.0.0.1:%d/api/v1/orders", s.GetListenedPort())
+}
+
+// doMaintenanceRequest 发送测试请求，返回状态码、Retry-After 与响应体
+func doMaintenanceRequest(method, url, tenantID string, operator bool) (int, string, utils.APIResponse) {
+	req, err := http.NewRequest(method, url, nil)
+	So(err, ShouldBeNil)
+	req.Header.Set("X-Tenant-ID", tenantID)
+	if operator {
+		req.Header.Set("X-Operator", "1")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	So(err, ShouldBeNil)
+	defer resp.Body.Close()
+
+	var body utils.APIResponse
+	So(json.NewDecoder(resp.Body).Decode(&body), ShouldBeNil)
+	return resp.StatusCode, resp.Header.Get("Retry-After"), body
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	Convey("维护模式中间件测试", t, func() {
+		manager := maintenance.NewManagerForTest()
+		s, url := startMaintenanceServer(manager)
+		defer s.Shutdown()
+
+		ctx := context.WithValue(context.Background(), "user_id", uint64(9))
+
+		Convey("未开启维护模式时读写请求正常", func() {
+			status, _, body := doMaintenanceRequest(http.MethodPost, url+"/", "1", false)
+			So(status, ShouldEqual, http.StatusOK)
+			So(body.Code, ShouldEqual, 0)
+		})
+
+		Convey("平台维护模式下拒绝写请求，读请求正常", func() {
+			_, err := manager.SetGlobal(ctx, &types.SetMaintenanceModeRequest{Enabled: true, Reason: "数据库迁移", RetryAfter: 120})
+			So(err, ShouldBeNil)
+
+			for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+				target := url + "/10"
+				if method == http.MethodPost {
+					target = url + "/"
+				}
+				status, retryAfter, body := doMaintenanceRequest(method, target, "1", false)
+				So(status, ShouldEqual, http.StatusServiceUnavailable)
+				So(retryAfter, ShouldEqual, "120")
+				So(body.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(body.Message, ShouldContainSubstring, "数据库迁移")
+			}
+
+			status, _, body := doMaintenanceRequest(http.MethodGet, url+"/", "1", false)
+			So(status, ShouldEqual, http.StatusOK)
+			So(body.Data, ShouldEqual, http.MethodGet)
+
+			Convey("关闭后写请求恢复", func() {
+				_, err := manager.SetGlobal(ctx, &types.SetMaintenanceModeRequest{Enabled: false})
+				So(err, ShouldBeNil)
+
+				status, _, _ := doMaintenanceRequest(http.MethodPost, url+"/", "1", false)
+				So(status, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("租户维护模式只影响该租户", func() {
+			_, err := manager.SetTenant(ctx, 1, &types.SetMaintenanceModeRequest{Enabled: true})
+			So(err, ShouldBeNil)
+
+			status, retryAfter, _ := doMaintenanceRequest(http.MethodPut, url+"/10", "1", false)
+			So(status, ShouldEqual, http.StatusServiceUnavailable)
+			So(retryAfter, ShouldEqual, fmt.Sprint(types.DefaultMaintenanceRetryAfter))
+
+			status, _, _ = doMaintenanceRequest(http.MethodGet, url+"/", "1", false)
+			So(status, ShouldEqual, http.StatusOK)
+
+			status, _, _ = doMaintenanceRequest(http.MethodPut, url+"/10", "2", false)
+			So(status, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("拥有系统配置权限的运维人员可以绕过维护模式", func() {
+			_, err := manager.SetGlobal(ctx, &types.SetMaintenanceModeRequest{Enabled: true})
+			So(err, ShouldBeNil)
+
+			status, _, body := doMaintenanceRequest(http.MethodDelete, url+"/10", "1", true)
+			So(status, ShouldEqual, http.StatusOK)
+			So(body.Data, ShouldEqual, http.MethodDelete)
+		})
+	})
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/guid"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// startPermissionServer 启动挂载权限中间件的测试服务
+func startPermissionServer(am *AuthMiddleware) (*ghttp.Server, string) {
+	s := g.Server(guid.S())
+	s.Group("/api/v1/users", func(group *ghttp.RouterGroup) {
+		group.Middleware(am.JWTAuth)
+		handler := func(r *ghttp.Request) { utils.SuccessResponse(r, r.Method) }
+		group.Group("/all", func(allGroup *ghttp.RouterGroup) {
+			allGroup.Middleware(am.RequirePermissions(types.PermissionUserManage))
+			allGroup.PUT("/", handler)
+		})
+		group.Group("/any", func(anyGroup *ghttp.RouterGroup) {
+			anyGroup.Middleware(am.RequireAnyPermission(types.PermissionUserManage, types.PermissionMerchantOrderProcess))
+			anyGroup.PUT("/", handler)
+		})
+		group.Group("/single", func(singleGroup *ghttp.RouterGroup) {
+			singleGroup.Middleware(am.PermissionAuth(types.PermissionUserManage))
+			singleGroup.PUT("/", handler)
+		})
+	})
+	s.SetDumpRouterMap(false)
+	if err := s.Start(); err != nil {
+		panic(err)
+	}
+	return s, fmt.Sprintf("http://127.0.0.1:%d/api/v1/users", s.GetListenedPort())
+}
+
+func TestPermissionMiddlewareMerchantScope(t *testing.T) {
+	Convey("权限中间件商户范围测试", t, func() {
+		ctx := context.Background()
+		jwtManager := auth.NewJWTManagerForTest("test-secret", 24)
+		roleRepo := NewMockRoleRepository()
+		am := NewAuthMiddlewareForTest(jwtManager, roleRepo)
+
+		s, url := startPermissionServer(am)
+		defer s.Shutdown()
+
+		// 两个用户都被授予了租户级的用户管理权限
+		roleRepo.SetUserPermissions(1, 1, []types.Permission{types.PermissionUserManage})
+		roleRepo.SetUserPermissions(2, 1, []types.Permission{types.PermissionUserManage, types.PermissionMerchantOrderProcess})
+
+		tokenFor := func(userID uint64, merchantID *uint64) string {
+			user := &types.User{ID: userID, TenantID: 1, MerchantID: merchantID, Username: "user", Email: "user@example.com"}
+			pair, err := jwtManager.GenerateTokenPair(ctx, user, &types.UserPermissions{UserID: userID, TenantID: 1})
+			So(err, ShouldBeNil)
+			return pair.AccessToken
+		}
+		merchantID := uint64(9)
+
+		Convey("租户用户保留租户级权限", func() {
+			token := tokenFor(1, nil)
+			for _, path := range []string{"/all", "/any", "/single"} {
+				status, _ := doStepUpRequest(url+path, token)
+				So(status, ShouldEqual, http.StatusOK)
+			}
+		})
+
+		Convey("商户用户的租户级权限不生效", func() {
+			token := tokenFor(1, &merchantID)
+			for _, path := range []string{"/all", "/any", "/single"} {
+				status, body := doStepUpRequest(url+path, token)
+				So(status, ShouldEqual, http.StatusForbidden)
+				So(body.Code, ShouldEqual, 403)
+			}
+		})
+
+		Convey("商户用户仍可使用商户范围内的权限", func() {
+			status, _ := doStepUpRequest(url+"/any", tokenFor(2, &merchantID))
+			So(status, ShouldEqual, http.StatusOK)
+		})
+	})
+}
@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+const (
+	// ResponseFieldsParam 指定返回字段的查询参数
+	ResponseFieldsParam = "fields"
+	// defaultMaxResponseFields 一次请求最多指定的字段数
+	defaultMaxResponseFields = 50
+	// maxResponseFieldDepth 字段路径的最大层级
+	maxResponseFieldDepth = 5
+)
+
+// responseFieldSegmentPattern 字段路径每一级的名称
+var responseFieldSegmentPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// ResponseFieldsConfig 响应字段过滤配置
+type ResponseFieldsConfig struct {
+	Enabled   bool `json:"enabled"`   // 是否支持 fields 参数，关闭时忽略该参数
+	MaxFields int  `json:"maxFields"` // 一次请求最多指定的字段数
+}
+
+// LoadResponseFieldsConfig 从 response.fields 配置加载响应字段过滤配置，默认启用
+func LoadResponseFieldsConfig(ctx context.Context) *ResponseFieldsConfig {
+	config := &ResponseFieldsConfig{Enabled: true, MaxFields: defaultMaxResponseFields}
+	if err := g.Cfg().MustGet(ctx, "response.fields").Scan(config); err != nil {
+		g.Log().Warningf(ctx, "响应字段过滤配置无效，使用默认配置: %v", err)
+		return &ResponseFieldsConfig{Enabled: true, MaxFields: defaultMaxResponseFields}
+	}
+	if config.MaxFields <= 0 {
+		config.MaxFields = defaultMaxResponseFields
+	}
+	return config
+}
+
+// ResponseFields 响应字段过滤中间件：GET 请求携带 fields 参数（逗号分隔的字段路径）时，只返回 data 中指定的字段。
+// 路径相对于 data，用 . 访问下级字段，经过数组时作用于每个元素，如 fields=total,items.id,items.order_number。
+// 字段按实际响应校验，不存在的字段以400拒绝；错误响应和非JSON响应原样返回
+func ResponseFields(config *ResponseFieldsConfig) ghttp.HandlerFunc {
+	if config == nil {
+		config = &ResponseFieldsConfig{Enabled: true, MaxFields: defaultMaxResponseFields}
+	}
+	return func(r *ghttp.Request) {
+		raw := r.URL.Query().Get(ResponseFieldsParam)
+		if !config.Enabled || r.Method != http.MethodGet || strings.TrimSpace(raw) == "" {
+			r.Middleware.Next()
+			return
+		}
+
+		paths, err := ParseResponseFields(raw, config.MaxFields)
+		if err != nil {
+			r.Response.Status = http.StatusBadRequest
+			utils.ErrorResponse(r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		r.Middleware.Next()
+
+		if r.Response.Status != 0 && r.Response.Status != http.StatusOK {
+			return
+		}
+		if !strings.Contains(r.Response.Header().Get("Content-Type"), "json") {
+			return
+		}
+
+		var body map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(r.Response.Buffer()))
+		decoder.UseNumber()
+		if err := decoder.Decode(&body); err != nil {
+			return
+		}
+		if code, _ := body["code"].(json.Number); code.String() != "0" {
+			return
+		}
+		data, exists := body["data"]
+		if !exists || data == nil {
+			return
+		}
+
+		if unknown := unknownResponseFields(data, paths); len(unknown) > 0 {
+			r.Response.ClearBuffer()
+			r.Response.Status = http.StatusBadRequest
+			r.Response.WriteJson(utils.APIResponse{
+				Code:    http.StatusBadRequest,
+				Message: "未知的响应字段: " + strings.Join(unknown, ", "),
+			})
+			return
+		}
+
+		body["data"] = trimResponseFields(data, newResponseFieldTree(paths))
+		trimmed, err := json.Marshal(body)
+		if err != nil {
+			g.Log().Errorf(r.GetCtx(), "响应字段过滤失败: %v", err)
+			return
+		}
+		r.Response.ClearBuffer()
+		r.Response.Write(trimmed)
+	}
+}
+
+// ParseResponseFields 解析 fields 参数，返回去重后的字段路径
+func ParseResponseFields(raw string, maxFields int) ([][]string, error) {
+	seen := make(map[string]bool)
+	var paths [][]string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		seen[field] = true
+
+		segments := strings.Split(field, ".")
+		if len(segments) > maxResponseFieldDepth {
+			return nil, fmt.Errorf("字段 %s 超过%d级", field, maxResponseFieldDepth)
+		}
+		for _, segment := range segments {
+			if !responseFieldSegmentPattern.MatchString(segment) {
+				return nil, fmt.Errorf("字段 %s 格式错误，只能包含字母、数字和下划线，用 . 分隔层级", field)
+			}
+		}
+		paths = append(paths, segments)
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("fields 参数不能为空")
+	}
+	if maxFields > 0 && len(paths) > maxFields {
+		return nil, fmt.Errorf("最多指定%d个字段", maxFields)
+	}
+	return paths, nil
+}
+
+// unknownResponseFields 返回在响应中不存在的字段，按字母排序
+func unknownResponseFields(data interface{}, paths [][]string) []string {
+	var unknown []string
+	for _, path := range paths {
+		if !responseFieldExists(data, path) {
+			unknown = append(unknown, strings.Join(path, "."))
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// responseFieldExists 字段路径是否存在。数组中任一元素包含即视为存在；
+// 空数组和 null 无法判断下级字段，视为存在
+func responseFieldExists(value interface{}, path []string) bool {
+	if len(path) == 0 {
+		return true
+	}
+	switch v := value.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		if len(v) == 0 {
+			return true
+		}
+		for _, item := range v {
+			if item != nil && responseFieldExists(item, path) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		child, exists := v[path[0]]
+		return exists && responseFieldExists(child, path[1:])
+	default:
+		return false
+	}
+}
+
+// responseFieldTree 请求的字段路径树，leaf 表示返回该字段的全部内容
+type responseFieldTree struct {
+	leaf     bool
+	children map[string]*responseFieldTree
+}
+
+// newResponseFieldTree 将字段路径合并为路径树
+func newResponseFieldTree(paths [][]string) *responseFieldTree {
+	root := &responseFieldTree{children: make(map[string]*responseFieldTree)}
+	for _, path := range paths {
+		node := root
+		for _, segment := range path {
+			child, exists := node.children[segment]
+			if !exists {
+				child = &responseFieldTree{children: make(map[string]*responseFieldTree)}
+				node.children[segment] = child
+			}
+			node = child
+		}
+		node.leaf = true
+	}
+	return root
+}
+
+// trimResponseFields 按路径树裁剪响应数据，数组逐个元素裁剪
+func trimResponseFields(value interface{}, tree *responseFieldTree) interface{} {
+	if tree.leaf {
+		return value
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		trimmed := make([]interface{}, len(v))
+		for i, item := range v {
+			trimmed[i] = trimResponseFields(item, tree)
+		}
+		return trimmed
+	case map[string]interface{}:
+		trimmed := make(map[string]interface{}, len(tree.children))
+		for key, child := range tree.children {
+			if field, exists := v[key]; exists {
+				trimmed[key] = trimResponseFields(field, child)
+			}
+		}
+		return trimmed
+	default:
+		return value
+	}
+}
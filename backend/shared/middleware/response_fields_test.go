@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/guid"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// startResponseFieldsServer 启动挂载响应字段过滤中间件的测试服务
+func startResponseFieldsServer(config *ResponseFieldsConfig) (*ghttp.Server, string) {
+	s := g.Server(guid.S())
+	s.Group("/api/v1", func(group *ghttp.RouterGroup) {
+		group.Middleware(ResponseFields(config))
+		group.GET("/orders", func(r *ghttp.Request) {
+			utils.SuccessResponse(r, g.Map{
+				"total": 2,
+				"items": g.Slice{
+					g.Map{"id": 1, "order_number": "ORD001", "total_amount": 99.5, "status": "paid",
+						"items": g.Slice{g.Map{"product_id": 11, "quantity": 2, "name": "咖啡"}},
+						"payment_info": g.Map{"method": "alipay", "amount": 99.5}},
+					g.Map{"id": 2, "order_number": "ORD002", "total_amount": 10, "status": "pending",
+						"items": g.Slice{}, "payment_info": nil},
+				},
+			})
+		})
+		group.GET("/orders/empty", func(r *ghttp.Request) {
+			utils.SuccessResponse(r, g.Map{"total": 0, "items": g.Slice{}})
+		})
+		group.GET("/orders/:id", func(r *ghttp.Request) {
+			utils.SuccessResponse(r, g.Map{"id": 1, "order_number": "ORD001", "customer": g.Map{"id": 100, "phone": "13800000000"}})
+		})
+		group.GET("/orders/missing", func(r *ghttp.Request) {
+			utils.ErrorResponse(r, 404, "订单不存在")
+		})
+		group.POST("/orders", func(r *ghttp.Request) {
+			utils.SuccessResponse(r, g.Map{"id": 3, "order_number": "ORD003"})
+		})
+	})
+	s.SetDumpRouterMap(false)
+	if err := s.Start(); err != nil {
+		panic(err)
+	}
+	return s, fmt.Sprintf("http://127.0.0.1:%d/api/v1", s.GetListenedPort())
+}
+
+// doResponseFieldsRequest 发送测试请求，返回状态码与解析后的响应体
+func doResponseFieldsRequest(method, rawURL, fields string) (int, map[string]interface{}) {
+	if fields != "" {
+		rawURL += "?fields=" + url.QueryEscape(fields)
+	}
+	req, err := http.NewRequest(method, rawURL, nil)
+	So(err, ShouldBeNil)
+
+	resp, err := http.DefaultClient.Do(req)
+	So(err, ShouldBeNil)
+	defer resp.Body.Close()
+
+	var body map[string]interface{}
+	So(json.NewDecoder(resp.Body).Decode(&body), ShouldBeNil)
+	return resp.StatusCode, body
+}
+
+func TestResponseFields(t *testing.T) {
+	Convey("响应字段过滤中间件测试", t, func() {
+		s, baseURL := startResponseFieldsServer(&ResponseFieldsConfig{Enabled: true, MaxFields: 5})
+		defer s.Shutdown()
+
+		Convey("未指定字段时返回完整响应", func() {
+			status, body := doResponseFieldsRequest(http.MethodGet, baseURL+"/orders", "")
+			So(status, ShouldEqual, http.StatusOK)
+			order := body["data"].(map[string]interface{})["items"].([]interface{})[0].(map[string]interface{})
+			So(order, ShouldContainKey, "status")
+			So(order, ShouldContainKey, "payment_info")
+		})
+
+		Convey("列表响应按路径裁剪每个元素", func() {
+			status, body := doResponseFieldsRequest(http.MethodGet, baseURL+"/orders", "total, items.id,items.order_number")
+			So(status, ShouldEqual, http.StatusOK)
+			So(body["code"], ShouldEqual, 0)
+			So(body["message"], ShouldEqual, "success")
+			So(body["data"], ShouldResemble, map[string]interface{}{
+				"total": float64(2),
+				"items": []interface{}{
+					map[string]interface{}{"id": float64(1), "order_number": "ORD001"},
+					map[string]interface{}{"id": float64(2), "order_number": "ORD002"},
+				},
+			})
+		})
+
+		Convey("嵌套对象和嵌套数组按下级路径裁剪", func() {
+			_, body := doResponseFieldsRequest(http.MethodGet, baseURL+"/orders", "items.items.product_id,items.payment_info.method")
+			items := body["data"].(map[string]interface{})["items"].([]interface{})
+			So(items[0], ShouldResemble, map[string]interface{}{
+				"items":        []interface{}{map[string]interface{}{"product_id": float64(11)}},
+				"payment_info": map[string]interface{}{"method": "alipay"},
+			})
+			So(items[1], ShouldResemble, map[string]interface{}{"items": []interface{}{}, "payment_info": nil})
+		})
+
+		Convey("详情响应只返回指定字段，整体指定的对象完整返回", func() {
+			_, body := doResponseFieldsRequest(http.MethodGet, baseURL+"/orders/1", "order_number,customer")
+			So(body["data"], ShouldResemble, map[string]interface{}{
+				"order_number": "ORD001",
+				"customer":     map[string]interface{}{"id": float64(100), "phone": "13800000000"},
+			})
+		})
+
+		Convey("响应中不存在的字段被拒绝", func() {
+			status, body := doResponseFieldsRequest(http.MethodGet, baseURL+"/orders", "items.id,items.password,secret")
+			So(status, ShouldEqual, http.StatusBadRequest)
+			So(body["code"], ShouldEqual, http.StatusBadRequest)
+			So(body["message"], ShouldEqual, "未知的响应字段: items.password, secret")
+			So(body, ShouldNotContainKey, "data")
+
+			status, _ = doResponseFieldsRequest(http.MethodGet, baseURL+"/orders/1", "order_number.value")
+			So(status, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("空列表无法校验下级字段时不拒绝", func() {
+			status, body := doResponseFieldsRequest(http.MethodGet, baseURL+"/orders/empty", "items.id")
+			So(status, ShouldEqual, http.StatusOK)
+			So(body["data"], ShouldResemble, map[string]interface{}{"items": []interface{}{}})
+		})
+
+		Convey("字段格式错误或数量超限时拒绝", func() {
+			status, body := doResponseFieldsRequest(http.MethodGet, baseURL+"/orders", "items[0].id")
+			So(status, ShouldEqual, http.StatusBadRequest)
+			So(body["code"], ShouldEqual, http.StatusBadRequest)
+
+			status, _ = doResponseFieldsRequest(http.MethodGet, baseURL+"/orders", "a,b,c,d,e,f")
+			So(status, ShouldEqual, http.StatusBadRequest)
+
+			status, _ = doResponseFieldsRequest(http.MethodGet, baseURL+"/orders", " , ")
+			So(status, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("错误响应和非GET请求原样返回", func() {
+			_, body := doResponseFieldsRequest(http.MethodGet, baseURL+"/orders/missing", "id")
+			So(body["code"], ShouldEqual, 404)
+			So(body["message"], ShouldEqual, "订单不存在")
+
+			_, body = doResponseFieldsRequest(http.MethodPost, baseURL+"/orders", "id")
+			So(body["data"], ShouldResemble, map[string]interface{}{"id": float64(3), "order_number": "ORD003"})
+		})
+
+		Convey("关闭后忽略字段参数", func() {
+			disabled, disabledURL := startResponseFieldsServer(&ResponseFieldsConfig{Enabled: false})
+			defer disabled.Shutdown()
+
+			status, body := doResponseFieldsRequest(http.MethodGet, disabledURL+"/orders/1", "unknown")
+			So(status, ShouldEqual, http.StatusOK)
+			So(body["data"], ShouldContainKey, "customer")
+		})
+	})
+
+	Convey("解析字段参数", t, func() {
+		paths, err := ParseResponseFields("id, items.id,id,,items.product.name", 0)
+		So(err, ShouldBeNil)
+		So(paths, ShouldResemble, [][]string{{"id"}, {"items", "id"}, {"items", "product", "name"}})
+
+		_, err = ParseResponseFields("a.b.c.d.e.f", 0)
+		So(err, ShouldNotBeNil)
+		_, err = ParseResponseFields("items..id", 0)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// ReauthenticatePath 重新验证身份接口
+const ReauthenticatePath = "/api/v1/auth/reauthenticate"
+
+var (
+	errStepUpTokenMissing = errors.New("用户认证信息不完整")
+	errStepUpTokenInvalid = errors.New("令牌无效或已过期")
+)
+
+// RequireStepUp 敏感操作要求当前会话近期重新验证过身份（需在JWTAuth之后使用）
+// 未配置保护的操作直接放行；验证已过期时返回需要重新验证的响应并记录审计
+func (am *AuthMiddleware) RequireStepUp(operation auth.StepUpOperation) ghttp.HandlerFunc {
+	return func(r *ghttp.Request) {
+		err := am.VerifyStepUp(r.GetCtx(), operation)
+		var stepUpErr *auth.StepUpRequiredError
+		switch {
+		case err == nil:
+			r.Middleware.Next()
+		case errors.As(err, &stepUpErr):
+			WriteStepUpRequired(r, stepUpErr)
+		case errors.Is(err, errStepUpTokenMissing), errors.Is(err, errStepUpTokenInvalid):
+			am.respondWithError(r, 401, err.Error())
+		default:
+			g.Log().Errorf(r.GetCtx(), "重新验证检查失败: %v", err)
+			am.respondWithError(r, 500, "重新验证检查失败")
+		}
+	}
+}
+
+// VerifyStepUp 校验当前会话是否在有效期内重新验证过身份，用于只在部分情况下需要重新验证的操作（需在JWTAuth之后使用）。
+// 未配置保护的操作返回 nil，验证已过期时返回 *auth.StepUpRequiredError
+func (am *AuthMiddleware) VerifyStepUp(ctx context.Context, operation auth.StepUpOperation) error {
+	if !am.stepUpPolicy.Requires(operation) {
+		return nil
+	}
+
+	token := gconv.String(ctx.Value("token"))
+	if token == "" {
+		return errStepUpTokenMissing
+	}
+
+	claims, err := am.jwtManager.GetTokenInfo(ctx, token)
+	if err != nil {
+		return errStepUpTokenInvalid
+	}
+
+	fresh, reauthenticatedAt, err := am.jwtManager.IsReauthenticationFresh(ctx, claims, am.stepUpPolicy.Freshness)
+	if err != nil {
+		return err
+	}
+	if fresh {
+		return nil
+	}
+	return &auth.StepUpRequiredError{
+		Operation:         operation,
+		Freshness:         am.stepUpPolicy.Freshness,
+		ReauthenticatedAt: reauthenticatedAt,
+	}
+}
+
+// WriteStepUpRequired 记录审计并返回需要重新验证身份的响应
+func WriteStepUpRequired(r *ghttp.Request, err *auth.StepUpRequiredError) {
+	details := map[string]interface{}{
+		"operation": err.Operation,
+		"method":    r.Method,
+		"path":      r.URL.Path,
+	}
+	if !err.ReauthenticatedAt.IsZero() {
+		details["last_reauthenticated_at"] = err.ReauthenticatedAt.Format(time.RFC3339)
+	}
+	audit.LogOperation(r.GetCtx(), "step_up", "challenge", details)
+
+	r.Response.Status = 403
+	r.Response.WriteJson(g.Map{
+		"code": 403,
+		"msg":  "该操作需要重新验证身份",
+		"data": g.Map{
+			"reauth_required":   true,
+			"operation":         err.Operation,
+			"freshness_seconds": int64(err.Freshness / time.Second),
+			"reauth_path":       ReauthenticatePath,
+		},
+	})
+	r.ExitAll()
+}
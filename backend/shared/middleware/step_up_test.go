@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/guid"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// startStepUpServer 启动挂载重新验证中间件的测试服务
+func startStepUpServer(am *AuthMiddleware) (*ghttp.Server, string) {
+	s := g.Server(guid.S())
+	s.Group("/api/v1/funds", func(group *ghttp.RouterGroup) {
+		group.Middleware(am.JWTAuth)
+		handler := func(r *ghttp.Request) { utils.SuccessResponse(r, r.Method) }
+		group.Group("/freeze", func(freezeGroup *ghttp.RouterGroup) {
+			freezeGroup.Middleware(am.RequireStepUp(auth.StepUpOperationFundFreeze))
+			freezeGroup.PUT("/:merchant_id", handler)
+		})
+		group.Group("/refund", func(refundGroup *ghttp.RouterGroup) {
+			refundGroup.Middleware(am.RequireStepUp(auth.StepUpOperationOrderRefund))
+			refundGroup.PUT("/:order_id", handler)
+		})
+	})
+	s.SetDumpRouterMap(false)
+	if err := s.Start(); err != nil {
+		panic(err)
+	}
+	return s, fmt.Sprintf("http://127.0.0.1:%d/api/v1/funds", s.GetListenedPort())
+}
+
+// doStepUpRequest 携带访问令牌发送测试请求，返回状态码与响应体
+func doStepUpRequest(url, token string) (int, utils.APIResponse) {
+	req, err := http.NewRequest(http.MethodPut, url, nil)
+	So(err, ShouldBeNil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	So(err, ShouldBeNil)
+	defer resp.Body.Close()
+
+	var body utils.APIResponse
+	So(json.NewDecoder(resp.Body).Decode(&body), ShouldBeNil)
+	return resp.StatusCode, body
+}
+
+func TestRequireStepUp(t *testing.T) {
+	Convey("敏感操作重新验证中间件测试", t, func() {
+		ctx := context.Background()
+		jwtManager := auth.NewJWTManagerForTest("test-secret", 24)
+		am := NewAuthMiddlewareForTest(jwtManager, NewMockRoleRepository())
+		am.SetStepUpPolicy(auth.NewStepUpPolicy(auth.DefaultStepUpFreshness, []auth.StepUpOperation{auth.StepUpOperationFundFreeze}))
+
+		s, url := startStepUpServer(am)
+		defer s.Shutdown()
+
+		user := &types.User{ID: 1, TenantID: 1, Username: "admin", Email: "admin@example.com"}
+		pair, err := jwtManager.GenerateTokenPair(ctx, user, &types.UserPermissions{UserID: 1, TenantID: 1})
+		So(err, ShouldBeNil)
+		claims, err := jwtManager.ValidateToken(ctx, pair.AccessToken)
+		So(err, ShouldBeNil)
+
+		Convey("未重新验证时要求重新验证身份", func() {
+			status, body := doStepUpRequest(url+"/freeze/1", pair.AccessToken)
+			So(status, ShouldEqual, http.StatusForbidden)
+			So(body.Code, ShouldEqual, 403)
+
+			data, ok := body.Data.(map[string]interface{})
+			So(ok, ShouldBeTrue)
+			So(data["reauth_required"], ShouldEqual, true)
+			So(data["operation"], ShouldEqual, string(auth.StepUpOperationFundFreeze))
+			So(data["reauth_path"], ShouldEqual, ReauthenticatePath)
+		})
+
+		Convey("有效期内重新验证后放行", func() {
+			_, err := jwtManager.RecordReauthentication(ctx, claims)
+			So(err, ShouldBeNil)
+
+			status, body := doStepUpRequest(url+"/freeze/1", pair.AccessToken)
+			So(status, ShouldEqual, http.StatusOK)
+			So(body.Code, ShouldEqual, 0)
+		})
+
+		Convey("重新验证已过期时再次要求验证", func() {
+			_, err := jwtManager.RecordReauthentication(ctx, claims)
+			So(err, ShouldBeNil)
+
+			// 有效期为负数时任何历史验证都视为过期
+			am.SetStepUpPolicy(&auth.StepUpPolicy{
+				Freshness:  -time.Second,
+				Operations: map[auth.StepUpOperation]bool{auth.StepUpOperationFundFreeze: true},
+			})
+
+			status, body := doStepUpRequest(url+"/freeze/1", pair.AccessToken)
+			So(status, ShouldEqual, http.StatusForbidden)
+			So(body.Code, ShouldEqual, 403)
+		})
+
+		Convey("未配置保护的操作直接放行", func() {
+			status, body := doStepUpRequest(url+"/refund/1", pair.AccessToken)
+			So(status, ShouldEqual, http.StatusOK)
+			So(body.Code, ShouldEqual, 0)
+		})
+	})
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/gogf/gf/v2/net/gclient"
 	"github.com/gogf/gf/v2/os/gcache"
 
-	"mer-demo/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
 
 // NotificationChannel 通知渠道类型
@@ -39,6 +39,7 @@ type UploadFileInfo struct {
 	Size        int64  `json:"size"`
 	URL         string `json:"url"`
 	ETag        string `json:"etag"`
+	ObjectKey   string `json:"object_key"`
 }
 
 // UploadOptions 上传选项
@@ -46,6 +47,10 @@ type UploadOptions struct {
 	Directory string            // 目录路径，如 "products/images"
 	FileName  string            // 自定义文件名，如果为空则生成UUID
 	Metadata  map[string]string // 元数据
+	// AllowedTypes 允许的文件类型，为空时只允许图片
+	AllowedTypes []string
+	// MaxSize 文件大小上限（字节），为0时限制为5MB
+	MaxSize int64
 }
 
 // UploadFile 上传文件
@@ -83,13 +88,13 @@ func (s *OSSService) UploadFile(ctx context.Context, file multipart.File, header
 	}
 
 	// 验证文件类型
-	if !isAllowedFileType(contentType) {
+	if !options.isAllowedFileType(contentType) {
 		return nil, fmt.Errorf("unsupported file type: %s", contentType)
 	}
 
-	// 验证文件大小 (5MB限制)
-	if header.Size > 5*1024*1024 {
-		return nil, fmt.Errorf("file size exceeds 5MB limit")
+	// 验证文件大小 (默认5MB限制)
+	if maxSize := options.maxSize(); header.Size > maxSize {
+		return nil, fmt.Errorf("file size exceeds %dMB limit", maxSize/(1024*1024))
 	}
 
 	// TODO: 实际的OSS上传逻辑
@@ -104,6 +109,7 @@ func (s *OSSService) UploadFile(ctx context.Context, file multipart.File, header
 		Size:        header.Size,
 		URL:         url,
 		ETag:        generateETag(fileContent),
+		ObjectKey:   objectKey,
 	}, nil
 }
 
@@ -130,12 +136,12 @@ func (s *OSSService) UploadFromBytes(ctx context.Context, data []byte, fileName,
 	}
 
 	// 验证文件类型和大小
-	if !isAllowedFileType(contentType) {
+	if !options.isAllowedFileType(contentType) {
 		return nil, fmt.Errorf("unsupported file type: %s", contentType)
 	}
 
-	if len(data) > 5*1024*1024 {
-		return nil, fmt.Errorf("file size exceeds 5MB limit")
+	if maxSize := options.maxSize(); int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file size exceeds %dMB limit", maxSize/(1024*1024))
 	}
 
 	// 模拟上传
@@ -147,6 +153,7 @@ func (s *OSSService) UploadFromBytes(ctx context.Context, data []byte, fileName,
 		Size:        int64(len(data)),
 		URL:         url,
 		ETag:        generateETag(data),
+		ObjectKey:   objectKey,
 	}, nil
 }
 
@@ -214,6 +221,27 @@ func detectContentType(fileName string, content []byte) string {
 	}
 }
 
+// isAllowedFileType 检查是否为上传选项允许的文件类型
+func (o *UploadOptions) isAllowedFileType(contentType string) bool {
+	if len(o.AllowedTypes) == 0 {
+		return isAllowedFileType(contentType)
+	}
+	for _, allowed := range o.AllowedTypes {
+		if strings.HasPrefix(contentType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxSize 上传选项的文件大小上限
+func (o *UploadOptions) maxSize() int64 {
+	if o.MaxSize > 0 {
+		return o.MaxSize
+	}
+	return 5 * 1024 * 1024
+}
+
 // isAllowedFileType 检查是否为允许的文件类型
 func isAllowedFileType(contentType string) bool {
 	allowedTypes := []string{
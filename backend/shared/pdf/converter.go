@@ -0,0 +1,220 @@
+// Package pdf 将HTML文件转换为PDF，按顺序尝试多个外部转换器，供报表和发票等文档生成共用
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// ErrConversionFailed 所有PDF转换器均失败
+var ErrConversionFailed = errors.New("所有PDF转换器均失败")
+
+// DefaultConverterNames 默认转换器顺序
+var DefaultConverterNames = []string{"wkhtmltopdf", "chrome", "phantomjs"}
+
+// Converter 将HTML文件转换为PDF文件
+type Converter func(htmlPath, pdfPath string) error
+
+// chromePaths 常见的Chrome/Chromium命令和安装路径
+var chromePaths = []string{
+	"google-chrome",
+	"chromium-browser",
+	"chromium",
+	"chrome",
+	"/usr/bin/google-chrome",
+	"/usr/bin/chromium-browser",
+	"/usr/bin/chromium",
+}
+
+// Converters 内置的转换器，按名称索引
+func Converters() map[string]Converter {
+	return map[string]Converter{
+		"wkhtmltopdf": convertWithWkhtml,
+		"chrome":      convertWithChrome,
+		"phantomjs":   convertWithPhantom,
+	}
+}
+
+// Convert 按 names 顺序尝试转换器将HTML转换为PDF，每个转换器失败后重试 retries 次，
+// 返回生成PDF的转换器名称；全部失败时返回 ErrConversionFailed
+func Convert(ctx context.Context, converters map[string]Converter, names []string, retries int, htmlPath, pdfPath string) (string, error) {
+	g.Log().Debug(ctx, "尝试将HTML转换为PDF",
+		"html_path", htmlPath,
+		"pdf_path", pdfPath)
+
+	if len(names) == 0 {
+		names = DefaultConverterNames
+	}
+	if retries < 0 {
+		retries = 0
+	}
+
+	var lastErr error
+	for _, name := range names {
+		convert, exists := converters[name]
+		if !exists {
+			g.Log().Warning(ctx, "未知的PDF转换器", "converter", name)
+			continue
+		}
+
+		for attempt := 0; attempt <= retries; attempt++ {
+			g.Log().Debug(ctx, "尝试PDF转换器", "converter", name, "attempt", attempt+1)
+			err := convert(htmlPath, pdfPath)
+			if err == nil {
+				// 验证PDF文件是否生成成功
+				if _, statErr := os.Stat(pdfPath); statErr == nil {
+					g.Log().Info(ctx, "PDF转换成功",
+						"converter", name,
+						"pdf_path", pdfPath)
+					return name, nil
+				}
+				err = fmt.Errorf("%s未生成PDF文件", name)
+			}
+
+			lastErr = err
+			g.Log().Warning(ctx, "PDF转换器失败",
+				"converter", name,
+				"attempt", attempt+1,
+				"error", err)
+			// 清理失败时可能残留的不完整文件
+			os.Remove(pdfPath)
+		}
+	}
+
+	if lastErr == nil {
+		return "", fmt.Errorf("%w: 没有可用的转换器", ErrConversionFailed)
+	}
+	return "", fmt.Errorf("%w: %v", ErrConversionFailed, lastErr)
+}
+
+// SupportedConverters 当前环境已安装的内置转换器
+func SupportedConverters() []string {
+	var supported []string
+
+	if _, err := exec.LookPath("wkhtmltopdf"); err == nil {
+		supported = append(supported, "wkhtmltopdf")
+	}
+	if findChrome() != "" {
+		supported = append(supported, "chrome")
+	}
+	if _, err := exec.LookPath("phantomjs"); err == nil {
+		supported = append(supported, "phantomjs")
+	}
+
+	return supported
+}
+
+// findChrome 查找可用的Chrome/Chromium命令，未安装时返回空字符串
+func findChrome() string {
+	for _, path := range chromePaths {
+		if _, err := exec.LookPath(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// convertWithWkhtml 使用wkhtmltopdf转换
+func convertWithWkhtml(htmlPath, pdfPath string) error {
+	// 检查wkhtmltopdf是否可用
+	if _, err := exec.LookPath("wkhtmltopdf"); err != nil {
+		return fmt.Errorf("wkhtmltopdf未安装: %v", err)
+	}
+
+	// 执行转换命令
+	cmd := exec.Command("wkhtmltopdf",
+		"--page-size", "A4",
+		"--encoding", "UTF-8",
+		"--margin-top", "10mm",
+		"--margin-right", "10mm",
+		"--margin-bottom", "10mm",
+		"--margin-left", "10mm",
+		htmlPath, pdfPath)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("wkhtmltopdf执行失败: %v, stderr: %s", err, stderr.String())
+	}
+
+	return nil
+}
+
+// convertWithChrome 使用Chrome/Chromium转换
+func convertWithChrome(htmlPath, pdfPath string) error {
+	chromeCmd := findChrome()
+	if chromeCmd == "" {
+		return fmt.Errorf("未找到Chrome/Chromium浏览器")
+	}
+
+	// 执行Chrome转换命令
+	cmd := exec.Command(chromeCmd,
+		"--headless",
+		"--disable-gpu",
+		"--no-sandbox",
+		"--print-to-pdf="+pdfPath,
+		"file://"+htmlPath)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Chrome转换失败: %v, stderr: %s", err, stderr.String())
+	}
+
+	return nil
+}
+
+// convertWithPhantom 使用PhantomJS转换
+func convertWithPhantom(htmlPath, pdfPath string) error {
+	// 检查phantomjs是否可用
+	if _, err := exec.LookPath("phantomjs"); err != nil {
+		return fmt.Errorf("phantomjs未安装: %v", err)
+	}
+
+	// 创建临时的JS脚本
+	jsScript := fmt.Sprintf(`
+		var page = require('webpage').create();
+		page.paperSize = {
+			format: 'A4',
+			margin: {
+				top: '10mm',
+				left: '10mm',
+				right: '10mm',
+				bottom: '10mm'
+			}
+		};
+
+		page.open('%s', function() {
+			setTimeout(function() {
+				page.render('%s');
+				phantom.exit();
+			}, 1000);
+		});
+	`, "file://"+htmlPath, pdfPath)
+
+	// 同一目录下可能同时转换多个文件，脚本名按PDF文件区分
+	scriptPath := pdfPath + ".convert.js"
+	if err := os.WriteFile(scriptPath, []byte(jsScript), 0644); err != nil {
+		return fmt.Errorf("创建转换脚本失败: %v", err)
+	}
+	defer os.Remove(scriptPath)
+
+	// 执行PhantomJS转换
+	cmd := exec.Command("phantomjs", scriptPath)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("PhantomJS转换失败: %v, stderr: %s", err, stderr.String())
+	}
+
+	return nil
+}
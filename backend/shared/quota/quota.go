@@ -0,0 +1,188 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/notification"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// Notifier 租户配额提醒的邮件发送
+type Notifier interface {
+	SendEmail(ctx context.Context, to, subject, body string) error
+}
+
+// Guard 租户配额检查：创建用户、商户前检查租户配置的配额（max_users、max_merchants），
+// 达到配额时拒绝创建；用量达到软限制时仍允许创建，并在级别变得更严重时邮件提醒租户联系人一次
+type Guard struct {
+	tenantRepo repository.ITenantRepository
+	repo       repository.ITenantQuotaRepository
+	notifier   Notifier
+	now        func() time.Time
+}
+
+// NewGuard 创建租户配额检查
+func NewGuard() *Guard {
+	return &Guard{
+		tenantRepo: repository.NewTenantRepository(),
+		repo:       repository.NewTenantQuotaRepository(),
+		notifier:   notification.NewNotificationService(),
+		now:        time.Now,
+	}
+}
+
+// NewGuardForTest 创建测试用租户配额检查，可指定当前时间
+func NewGuardForTest(tenantRepo repository.ITenantRepository, repo repository.ITenantQuotaRepository, notifier Notifier, now func() time.Time) *Guard {
+	return &Guard{
+		tenantRepo: tenantRepo,
+		repo:       repo,
+		notifier:   notifier,
+		now:        now,
+	}
+}
+
+// Check 创建 count 个资源前检查配额，返回创建后的用量。配额不足时返回 types.ErrTenantQuotaExceeded；
+// 创建后达到软限制时仍允许创建并提醒租户。配额状态记录或提醒失败只记录日志，不影响创建
+func (q *Guard) Check(ctx context.Context, tenantID uint64, resource types.TenantQuotaResource, count int) (*types.TenantQuotaUsage, error) {
+	tenant, config, err := q.loadTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := q.usage(ctx, tenantID, config, resource)
+	if err != nil {
+		return nil, err
+	}
+	if current.Limit <= 0 {
+		return current, nil
+	}
+
+	if !current.Allows(count) {
+		q.track(ctx, tenant, current)
+		return current, fmt.Errorf("%w: %s", types.ErrTenantQuotaExceeded, current.Summary())
+	}
+
+	projected := types.NewTenantQuotaUsage(resource, current.Used+count, current.Limit, current.SoftLimitPercent)
+	q.track(ctx, tenant, projected)
+	return projected, nil
+}
+
+// Report 获取租户各资源的当前用量与配额，达到软限制的资源生成待处理事项
+func (q *Guard) Report(ctx context.Context, tenantID uint64) (*types.TenantQuotaReport, error) {
+	_, config, err := q.loadTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &types.TenantQuotaReport{
+		TenantID:     tenantID,
+		Usages:       make([]types.TenantQuotaUsage, 0, len(types.TenantQuotaResources)),
+		PendingTasks: []types.PendingTask{},
+	}
+	for _, resource := range types.TenantQuotaResources {
+		usage, err := q.usage(ctx, tenantID, config, resource)
+		if err != nil {
+			return nil, err
+		}
+		report.Usages = append(report.Usages, *usage)
+		if task := usage.PendingTask(); task != nil {
+			report.PendingTasks = append(report.PendingTasks, *task)
+		}
+	}
+	return report, nil
+}
+
+// loadTenant 获取租户及其配置，配置为空时按空配置处理（不限制）
+func (q *Guard) loadTenant(ctx context.Context, tenantID uint64) (*types.Tenant, *types.TenantConfig, error) {
+	tenant, err := q.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取租户信息失败: %w", err)
+	}
+	if tenant == nil {
+		return nil, nil, fmt.Errorf("租户不存在: %d", tenantID)
+	}
+
+	config := &types.TenantConfig{}
+	if tenant.Config != "" {
+		if err := json.Unmarshal([]byte(tenant.Config), config); err != nil {
+			return nil, nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+	}
+	return tenant, config, nil
+}
+
+// usage 统计资源当前用量并按租户配置计算级别
+func (q *Guard) usage(ctx context.Context, tenantID uint64, config *types.TenantConfig, resource types.TenantQuotaResource) (*types.TenantQuotaUsage, error) {
+	used, err := q.repo.CountUsage(ctx, tenantID, resource)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewTenantQuotaUsage(resource, used, config.QuotaLimit(resource), config.Quota.SoftLimitPercentFor(resource)), nil
+}
+
+// track 记录资源的用量级别，级别比上次检查更严重时提醒租户；用量回落时只降低级别，再次达到软限制时重新提醒
+func (q *Guard) track(ctx context.Context, tenant *types.Tenant, usage *types.TenantQuotaUsage) {
+	previous, err := q.repo.GetWarning(ctx, tenant.ID, usage.Resource)
+	if err != nil {
+		g.Log().Error(ctx, "获取租户配额用量级别失败", "tenant_id", tenant.ID, "resource", usage.Resource, "error", err)
+		return
+	}
+
+	now := q.now()
+	warning := &types.TenantQuotaWarningState{
+		TenantID:  tenant.ID,
+		Resource:  usage.Resource,
+		Level:     usage.Level,
+		Used:      usage.Used,
+		Limit:     usage.Limit,
+		CrossedAt: now,
+		UpdatedAt: now,
+	}
+	previousLevel := types.TenantQuotaNormal
+	if previous != nil {
+		previousLevel = previous.Level
+		if previous.Level == warning.Level {
+			warning.CrossedAt = previous.CrossedAt
+		}
+	}
+	if err := q.repo.SaveWarning(ctx, warning); err != nil {
+		g.Log().Error(ctx, "记录租户配额用量级别失败", "tenant_id", tenant.ID, "resource", usage.Resource, "error", err)
+		return
+	}
+	if usage.Level.Rank() <= previousLevel.Rank() {
+		return
+	}
+
+	g.Log().Warning(ctx, "租户配额用量达到提醒线",
+		"tenant_id", tenant.ID,
+		"resource", usage.Resource,
+		"level", usage.Level,
+		"used", usage.Used,
+		"limit", usage.Limit)
+	q.notify(ctx, tenant, usage)
+}
+
+// notify 邮件提醒租户联系人配额即将用完或已用完
+func (q *Guard) notify(ctx context.Context, tenant *types.Tenant, usage *types.TenantQuotaUsage) {
+	if q.notifier == nil {
+		return
+	}
+	if tenant.ContactEmail == "" {
+		g.Log().Warning(ctx, "租户未设置联系邮箱，无法发送配额提醒", "tenant_id", tenant.ID)
+		return
+	}
+
+	subject := fmt.Sprintf("%s配额即将用完提醒 - %s", usage.Resource.DisplayName(), tenant.Name)
+	if usage.Level == types.TenantQuotaExceeded {
+		subject = fmt.Sprintf("%s配额已用完提醒 - %s", usage.Resource.DisplayName(), tenant.Name)
+	}
+	body := fmt.Sprintf("%s，您好：\n\n%s。\n当前用量：%d，配额：%d，已使用 %.2f%%。\n达到配额后将无法继续创建，如需提升配额请联系平台。",
+		tenant.ContactPerson, usage.Summary(), usage.Used, usage.Limit, usage.Percentage)
+	if err := q.notifier.SendEmail(ctx, tenant.ContactEmail, subject, body); err != nil {
+		g.Log().Error(ctx, "发送租户配额提醒失败", "tenant_id", tenant.ID, "resource", usage.Resource, "error", err)
+	}
+}
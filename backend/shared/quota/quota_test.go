@@ -0,0 +1,188 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryTenantRepository 内存租户仓储
+type memoryTenantRepository struct {
+	repository.ITenantRepository
+	tenant *types.Tenant
+}
+
+func (r *memoryTenantRepository) GetByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	return r.tenant, nil
+}
+
+// memoryQuotaRepository 内存租户配额用量仓储
+type memoryQuotaRepository struct {
+	used     map[types.TenantQuotaResource]int
+	warnings map[types.TenantQuotaResource]*types.TenantQuotaWarningState
+}
+
+func (r *memoryQuotaRepository) CountUsage(ctx context.Context, tenantID uint64, resource types.TenantQuotaResource) (int, error) {
+	return r.used[resource], nil
+}
+
+func (r *memoryQuotaRepository) GetWarning(ctx context.Context, tenantID uint64, resource types.TenantQuotaResource) (*types.TenantQuotaWarningState, error) {
+	return r.warnings[resource], nil
+}
+
+func (r *memoryQuotaRepository) SaveWarning(ctx context.Context, warning *types.TenantQuotaWarningState) error {
+	r.warnings[warning.Resource] = warning
+	return nil
+}
+
+// recordingNotifier 记录发送的配额提醒
+type recordingNotifier struct {
+	subjects []string
+	bodies   []string
+}
+
+func (n *recordingNotifier) SendEmail(ctx context.Context, to, subject, body string) error {
+	n.subjects = append(n.subjects, subject)
+	n.bodies = append(n.bodies, body)
+	return nil
+}
+
+func TestGuard(t *testing.T) {
+	Convey("租户配额检查", t, func() {
+		ctx := context.Background()
+		now := time.Date(2026, 10, 15, 10, 0, 0, 0, time.Local)
+		tenant := &types.Tenant{ID: 1, Name: "星河贸易", ContactPerson: "张三", ContactEmail: "admin@example.com",
+			Config: `{"max_users":10,"max_merchants":5}`}
+		repo := &memoryQuotaRepository{
+			used:     map[types.TenantQuotaResource]int{types.TenantQuotaUsers: 6, types.TenantQuotaMerchants: 1},
+			warnings: map[types.TenantQuotaResource]*types.TenantQuotaWarningState{},
+		}
+		notifier := &recordingNotifier{}
+		guard := NewGuardForTest(&memoryTenantRepository{tenant: tenant}, repo, notifier, func() time.Time { return now })
+
+		// 创建一个用户：检查配额后用量加一
+		create := func() (*types.TenantQuotaUsage, error) {
+			usage, err := guard.Check(ctx, 1, types.TenantQuotaUsers, 1)
+			if err == nil {
+				repo.used[types.TenantQuotaUsers]++
+			}
+			return usage, err
+		}
+
+		Convey("低于软限制时允许创建且不提醒", func() {
+			usage, err := create()
+			So(err, ShouldBeNil)
+			So(usage.Used, ShouldEqual, 7)
+			So(usage.Level, ShouldEqual, types.TenantQuotaNormal)
+			So(notifier.subjects, ShouldBeEmpty)
+		})
+
+		Convey("达到软限制时仍允许创建并提醒一次", func() {
+			_, err := create()
+			So(err, ShouldBeNil)
+			usage, err := create()
+			So(err, ShouldBeNil)
+			So(usage.Used, ShouldEqual, 8)
+			So(usage.Level, ShouldEqual, types.TenantQuotaWarning)
+			So(usage.Percentage, ShouldEqual, 80)
+			So(usage.Remaining, ShouldEqual, 2)
+			So(notifier.subjects, ShouldResemble, []string{"用户数配额即将用完提醒 - 星河贸易"})
+			So(notifier.bodies[0], ShouldContainSubstring, "用户数已使用 8/10（80.00%），达到80%的提醒线，剩余 2")
+
+			// 停留在软限制级别时不重复提醒
+			_, err = create()
+			So(err, ShouldBeNil)
+			So(notifier.subjects, ShouldHaveLength, 1)
+			So(repo.warnings[types.TenantQuotaUsers].CrossedAt, ShouldEqual, now)
+		})
+
+		Convey("达到配额后拒绝创建", func() {
+			for i := 0; i < 4; i++ {
+				_, err := create()
+				So(err, ShouldBeNil)
+			}
+			So(repo.used[types.TenantQuotaUsers], ShouldEqual, 10)
+			So(notifier.subjects, ShouldResemble, []string{
+				"用户数配额即将用完提醒 - 星河贸易",
+				"用户数配额已用完提醒 - 星河贸易",
+			})
+
+			usage, err := create()
+			So(errors.Is(err, types.ErrTenantQuotaExceeded), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "用户数已达到配额上限 10")
+			So(usage.Used, ShouldEqual, 10)
+			So(usage.Remaining, ShouldEqual, 0)
+			So(repo.used[types.TenantQuotaUsers], ShouldEqual, 10)
+			So(notifier.subjects, ShouldHaveLength, 2)
+		})
+
+		Convey("批量创建超过剩余配额时整体拒绝", func() {
+			_, err := guard.Check(ctx, 1, types.TenantQuotaUsers, 5)
+			So(errors.Is(err, types.ErrTenantQuotaExceeded), ShouldBeTrue)
+			So(repo.warnings[types.TenantQuotaUsers].Level, ShouldEqual, types.TenantQuotaNormal)
+		})
+
+		Convey("用量回落后再次达到软限制时重新提醒", func() {
+			repo.used[types.TenantQuotaUsers] = 8
+			_, err := create()
+			So(err, ShouldBeNil)
+			So(notifier.subjects, ShouldHaveLength, 1)
+
+			repo.used[types.TenantQuotaUsers] = 3
+			_, err = create()
+			So(err, ShouldBeNil)
+			So(repo.warnings[types.TenantQuotaUsers].Level, ShouldEqual, types.TenantQuotaNormal)
+
+			repo.used[types.TenantQuotaUsers] = 8
+			_, err = create()
+			So(err, ShouldBeNil)
+			So(notifier.subjects, ShouldHaveLength, 2)
+		})
+
+		Convey("软限制百分比可按资源配置", func() {
+			tenant.Config = `{"max_users":10,"max_merchants":5,"quota":{"soft_limit_percent":60,"resources":{"merchants":50}}}`
+			usage, err := create()
+			So(err, ShouldBeNil)
+			So(usage.SoftLimitPercent, ShouldEqual, 60)
+			So(usage.SoftLimit, ShouldEqual, 6)
+			So(usage.Level, ShouldEqual, types.TenantQuotaWarning)
+
+			usage, err = guard.Check(ctx, 1, types.TenantQuotaMerchants, 2)
+			So(err, ShouldBeNil)
+			So(usage.SoftLimit, ShouldEqual, 3)
+			So(usage.Level, ShouldEqual, types.TenantQuotaWarning)
+			So(notifier.subjects, ShouldContain, "商户数配额即将用完提醒 - 星河贸易")
+		})
+
+		Convey("未配置配额时不限制", func() {
+			tenant.Config = `{}`
+			usage, err := guard.Check(ctx, 1, types.TenantQuotaUsers, 100)
+			So(err, ShouldBeNil)
+			So(usage.Limit, ShouldEqual, 0)
+			So(usage.Remaining, ShouldEqual, -1)
+			So(repo.warnings, ShouldBeEmpty)
+		})
+
+		Convey("配额用量包含百分比和待处理事项", func() {
+			repo.used[types.TenantQuotaUsers] = 9
+			repo.used[types.TenantQuotaMerchants] = 5
+			report, err := guard.Report(ctx, 1)
+			So(err, ShouldBeNil)
+			So(report.Usages, ShouldHaveLength, 2)
+			So(report.Usages[0].Resource, ShouldEqual, types.TenantQuotaUsers)
+			So(report.Usages[0].Percentage, ShouldEqual, 90)
+			So(report.Usages[1].Percentage, ShouldEqual, 100)
+
+			So(report.PendingTasks, ShouldHaveLength, 2)
+			So(report.PendingTasks[0].Type, ShouldEqual, types.TaskTypeQuotaWarning)
+			So(report.PendingTasks[0].Priority, ShouldEqual, types.PriorityHigh)
+			So(report.PendingTasks[1].Priority, ShouldEqual, types.PriorityUrgent)
+			So(report.PendingTasks[1].Description, ShouldContainSubstring, "商户数已达到配额上限 5")
+		})
+	})
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// AnalyticsQueryCheck 分析查询校验：执行一个报表查询并检查结果约束
+type AnalyticsQueryCheck struct {
+	Name string
+	// Run 执行查询并检查结果，返回的错误为查询本身的错误；返回 errAnalyticsCheckSkipped 表示条件不满足而跳过
+	Run func(ctx context.Context, repo IReportRepository, req *types.AnalyticsCheckRequest, v *types.AnalyticsInvariants) error
+}
+
+// errAnalyticsCheckSkipped 校验请求缺少检查所需的参数
+var errAnalyticsCheckSkipped = fmt.Errorf("缺少检查所需的参数")
+
+var (
+	analyticsQueryChecksMutex sync.RWMutex
+	analyticsQueryChecks      []AnalyticsQueryCheck
+)
+
+// RegisterAnalyticsQueryCheck 注册分析查询校验，同名检查会被替换
+func RegisterAnalyticsQueryCheck(check AnalyticsQueryCheck) {
+	analyticsQueryChecksMutex.Lock()
+	defer analyticsQueryChecksMutex.Unlock()
+
+	for i := range analyticsQueryChecks {
+		if analyticsQueryChecks[i].Name == check.Name {
+			analyticsQueryChecks[i] = check
+			return
+		}
+	}
+	analyticsQueryChecks = append(analyticsQueryChecks, check)
+}
+
+// AnalyticsQueryChecks 返回已注册的分析查询校验
+func AnalyticsQueryChecks() []AnalyticsQueryCheck {
+	analyticsQueryChecksMutex.RLock()
+	defer analyticsQueryChecksMutex.RUnlock()
+
+	return append([]AnalyticsQueryCheck(nil), analyticsQueryChecks...)
+}
+
+type queryDiagnosticsKey struct{}
+
+// queryDiagnostics 收集报表查询中被降级处理的子查询错误
+type queryDiagnostics struct {
+	mu     sync.Mutex
+	errors []types.AnalyticsQueryError
+}
+
+// withQueryDiagnostics 在上下文中挂载子查询错误收集器
+func withQueryDiagnostics(ctx context.Context) (context.Context, *queryDiagnostics) {
+	diagnostics := &queryDiagnostics{}
+	return context.WithValue(ctx, queryDiagnosticsKey{}, diagnostics), diagnostics
+}
+
+// recordSwallowedQueryError 记录报表中被降级处理的子查询错误：报表仍返回其余数据，
+// 但错误会写入日志，校验时会作为失败项返回
+func recordSwallowedQueryError(ctx context.Context, query string, err error) {
+	if err == nil || err == sql.ErrNoRows {
+		return
+	}
+	g.Log().Warning(ctx, "报表子查询失败，已跳过该部分数据", "query", query, "error", err)
+
+	if diagnostics, ok := ctx.Value(queryDiagnosticsKey{}).(*queryDiagnostics); ok {
+		diagnostics.mu.Lock()
+		defer diagnostics.mu.Unlock()
+		diagnostics.errors = append(diagnostics.errors, types.AnalyticsQueryError{Query: query, Error: err.Error()})
+	}
+}
+
+// RunAnalyticsQueryChecks 依次执行已注册的分析查询并检查结果，单个检查失败或 panic 不影响其他检查
+func RunAnalyticsQueryChecks(ctx context.Context, repo IReportRepository, req *types.AnalyticsCheckRequest) *types.AnalyticsCheckReport {
+	ctx = context.WithValue(ctx, "tenant_id", req.TenantID)
+	report := &types.AnalyticsCheckReport{
+		TenantID:  req.TenantID,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Passed:    true,
+		Results:   []types.AnalyticsCheckResult{},
+		CheckedAt: time.Now(),
+	}
+
+	for _, check := range AnalyticsQueryChecks() {
+		result := runAnalyticsQueryCheck(ctx, repo, req, check)
+		if !result.Passed && !result.Skipped {
+			report.Passed = false
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// runAnalyticsQueryCheck 执行单个分析查询校验
+func runAnalyticsQueryCheck(ctx context.Context, repo IReportRepository, req *types.AnalyticsCheckRequest, check AnalyticsQueryCheck) (result types.AnalyticsCheckResult) {
+	ctx, diagnostics := withQueryDiagnostics(ctx)
+	invariants := &types.AnalyticsInvariants{}
+	result.Name = check.Name
+	startTime := time.Now()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result.Error = fmt.Sprintf("panic: %v", recovered)
+		}
+		result.DurationMs = time.Since(startTime).Milliseconds()
+		result.QueryErrors = diagnostics.errors
+		result.Violations = invariants.Violations()
+		result.Passed = !result.Skipped && result.Error == "" && len(result.QueryErrors) == 0 && len(result.Violations) == 0
+	}()
+
+	if err := check.Run(ctx, repo, req, invariants); err != nil {
+		if err == errAnalyticsCheckSkipped {
+			result.Skipped = true
+			return result
+		}
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func init() {
+	RegisterAnalyticsQueryCheck(AnalyticsQueryCheck{Name: "financial_data", Run: checkFinancialData})
+	RegisterAnalyticsQueryCheck(AnalyticsQueryCheck{Name: "merchant_summary", Run: checkMerchantSummary})
+	RegisterAnalyticsQueryCheck(AnalyticsQueryCheck{Name: "reconciliation", Run: checkReconciliation})
+	RegisterAnalyticsQueryCheck(AnalyticsQueryCheck{Name: "merchant_operation", Run: checkMerchantOperation})
+	RegisterAnalyticsQueryCheck(AnalyticsQueryCheck{Name: "customer_analysis", Run: checkCustomerAnalysis})
+}
+
+func checkFinancialData(ctx context.Context, repo IReportRepository, req *types.AnalyticsCheckRequest, v *types.AnalyticsInvariants) error {
+	data, err := repo.GetFinancialData(ctx, req.TenantID, req.StartDate, req.EndDate, req.MerchantID)
+	if err != nil {
+		return err
+	}
+
+	v.NonNegative("total_revenue", data.TotalRevenue.Amount)
+	v.NonNegative("order_count", float64(data.OrderCount))
+	v.NonNegative("rights_consumed", float64(data.RightsConsumed))
+	v.NonNegative("merchant_count", float64(data.MerchantCount))
+	v.NonNegative("customer_count", float64(data.CustomerCount))
+	v.AtMost("active_merchant_count", float64(data.ActiveMerchantCount), "merchant_count", float64(data.MerchantCount))
+	v.AtMost("active_customer_count", float64(data.ActiveCustomerCount), "customer_count", float64(data.CustomerCount))
+	if !v.Required("breakdown", data.Breakdown != nil) {
+		return nil
+	}
+
+	merchantPercentages := make([]float64, 0, len(data.Breakdown.RevenueByMerchant))
+	for i, item := range data.Breakdown.RevenueByMerchant {
+		v.NonNegative(fmt.Sprintf("revenue_by_merchant[%d].revenue", i), item.Revenue.Amount)
+		v.NonNegative(fmt.Sprintf("revenue_by_merchant[%d].order_count", i), float64(item.OrderCount))
+		merchantPercentages = append(merchantPercentages, item.Percentage)
+	}
+	v.PercentTotal("revenue_by_merchant.percentage", merchantPercentages)
+
+	categoryPercentages := make([]float64, 0, len(data.Breakdown.RevenueByCategory))
+	for i, item := range data.Breakdown.RevenueByCategory {
+		v.NonNegative(fmt.Sprintf("revenue_by_category[%d].revenue", i), item.Revenue.Amount)
+		categoryPercentages = append(categoryPercentages, item.Percentage)
+	}
+	v.PercentTotal("revenue_by_category.percentage", categoryPercentages)
+
+	for i, item := range data.Breakdown.MonthlyTrend {
+		v.Required(fmt.Sprintf("monthly_trend[%d].month", i), item.Month != "")
+		v.NonNegative(fmt.Sprintf("monthly_trend[%d].revenue", i), item.Revenue.Amount)
+		v.NonNegative(fmt.Sprintf("monthly_trend[%d].order_count", i), float64(item.OrderCount))
+	}
+	return nil
+}
+
+func checkMerchantSummary(ctx context.Context, repo IReportRepository, req *types.AnalyticsCheckRequest, v *types.AnalyticsInvariants) error {
+	if req.MerchantID == nil {
+		return errAnalyticsCheckSkipped
+	}
+	figures, err := repo.GetMerchantSummaryFigures(ctx, req.TenantID, *req.MerchantID, req.StartDate, req.EndDate)
+	if err != nil {
+		return err
+	}
+
+	v.NonNegative("revenue", figures.Revenue)
+	v.NonNegative("order_count", float64(figures.OrderCount))
+	v.NonNegative("avg_order_value", figures.AvgOrderValue)
+	v.NonNegative("rights_consumed", float64(figures.RightsConsumed))
+	v.AtMost("customer_count", float64(figures.CustomerCount), "order_count", float64(figures.OrderCount))
+	if figures.OrderCount == 0 && figures.Revenue != 0 {
+		v.Violatef("revenue: 没有订单时收入应为0，实际为 %v", figures.Revenue)
+	}
+	return nil
+}
+
+func checkReconciliation(ctx context.Context, repo IReportRepository, req *types.AnalyticsCheckRequest, v *types.AnalyticsInvariants) error {
+	figures, err := repo.GetReconciliationFigures(ctx, req.TenantID, req.StartDate, req.EndDate, req.MerchantID)
+	if err != nil {
+		return err
+	}
+
+	for i, item := range figures {
+		field := fmt.Sprintf("figures[%d]", i)
+		v.Required(field+".merchant_id", item.MerchantID > 0)
+		v.NonNegative(field+".order_revenue", item.OrderRevenue)
+		v.NonNegative(field+".paid_order_count", float64(item.PaidOrderCount))
+		v.NonNegative(field+".deposits", item.Deposits)
+		v.NonNegative(field+".allocations", item.Allocations)
+		// 累计数据包含本周期数据
+		v.AtMost(field+".rights_consumed", item.RightsConsumed, "total_consumed", item.TotalConsumed)
+		v.AtMost(field+".deposits+allocations", item.Deposits+item.Allocations, "total_credited", item.TotalCredited)
+	}
+	return nil
+}
+
+func checkMerchantOperation(ctx context.Context, repo IReportRepository, req *types.AnalyticsCheckRequest, v *types.AnalyticsInvariants) error {
+	report, err := repo.GetMerchantOperationData(ctx, req.TenantID, req.StartDate, req.EndDate)
+	if err != nil {
+		return err
+	}
+
+	for i, ranking := range report.MerchantRankings {
+		field := fmt.Sprintf("merchant_rankings[%d]", i)
+		if ranking.Rank != i+1 {
+			v.Violatef("%s.rank: 应为 %d，实际为 %d", field, i+1, ranking.Rank)
+		}
+		v.NonNegative(field+".total_revenue", ranking.TotalRevenue.Amount)
+		v.NonNegative(field+".order_count", float64(ranking.OrderCount))
+		v.AtMost(field+".customer_count", float64(ranking.CustomerCount), "order_count", float64(ranking.OrderCount))
+	}
+
+	shares := make([]float64, 0, len(report.CategoryAnalysis))
+	for i, category := range report.CategoryAnalysis {
+		v.NonNegative(fmt.Sprintf("category_analysis[%d].revenue", i), category.Revenue.Amount)
+		shares = append(shares, category.MarketShare)
+	}
+	v.PercentTotal("category_analysis.market_share", shares)
+
+	for i, satisfaction := range report.Satisfaction {
+		field := fmt.Sprintf("satisfaction[%d]", i)
+		v.Range(field+".average_rating", satisfaction.AverageRating, 0, 5)
+		v.AtMost(field+".low_rating_count", float64(satisfaction.LowRatingCount), "review_count", float64(satisfaction.ReviewCount))
+	}
+	return nil
+}
+
+func checkCustomerAnalysis(ctx context.Context, repo IReportRepository, req *types.AnalyticsCheckRequest, v *types.AnalyticsInvariants) error {
+	report, err := repo.GetCustomerAnalysisData(ctx, req.TenantID, req.StartDate, req.EndDate)
+	if err != nil {
+		return err
+	}
+
+	cumulative := 0
+	for i, growth := range report.UserGrowth {
+		field := fmt.Sprintf("user_growth[%d]", i)
+		v.Required(field+".month", growth.Month != "")
+		v.NonNegative(field+".new_users", float64(growth.NewUsers))
+		v.Range(field+".retention_rate", growth.RetentionRate, 0, 100)
+		if growth.CumulativeUsers < cumulative {
+			v.Violatef("%s.cumulative_users: 累计用户数不能减少", field)
+		}
+		cumulative = growth.CumulativeUsers
+	}
+
+	if v.Required("activity_metrics", report.ActivityMetrics != nil) {
+		metrics := report.ActivityMetrics
+		v.NonNegative("activity_metrics.dau", float64(metrics.DAU))
+		v.AtMost("activity_metrics.dau", float64(metrics.DAU), "wau", float64(metrics.WAU))
+		v.AtMost("activity_metrics.wau", float64(metrics.WAU), "mau", float64(metrics.MAU))
+	}
+
+	if v.Required("consumption_behavior", report.ConsumptionBehavior != nil) {
+		behavior := report.ConsumptionBehavior
+		v.NonNegative("consumption_behavior.average_order_value", behavior.AverageOrderValue.Amount)
+		v.Range("consumption_behavior.repurchase_rate", behavior.RepurchaseRate, 0, 100)
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const analyticsSeedTenantID = 9
+
+// analyticsSeed 按语句片段匹配的种子数据，err 不为空时查询返回该错误
+type analyticsSeed struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+var (
+	analyticsSeedMutex sync.Mutex
+	analyticsSeeds     []analyticsSeed
+)
+
+// seedAnalyticsData 设置报表查询返回的数据，按顺序匹配第一个语句片段
+func seedAnalyticsData(seeds []analyticsSeed) {
+	analyticsSeedMutex.Lock()
+	defer analyticsSeedMutex.Unlock()
+	analyticsSeeds = seeds
+}
+
+// healthyAnalyticsSeeds 数据一致的报表种子数据
+func healthyAnalyticsSeeds() []analyticsSeed {
+	return []analyticsSeed{
+		{match: "as total_order_count",
+			columns: []string{"total_order_count", "paid_order_count", "merchant_count", "customer_count", "total_revenue", "avg_order_value", "rights_consumed", "active_merchant_count", "active_customer_count"},
+			rows:    [][]driver.Value{{int64(5), int64(4), int64(2), int64(3), 400.0, 100.0, int64(40), int64(2), int64(3)}}},
+		{match: "LIMIT 10",
+			columns: []string{"merchant_id", "merchant_name", "revenue", "order_count"},
+			rows:    [][]driver.Value{{int64(1), "商户A", 300.0, int64(3)}, {int64(2), "商户B", 100.0, int64(1)}}},
+		{match: "as merchant_count FROM orders o JOIN order_items",
+			columns: []string{"category_id", "category_name", "revenue", "order_count", "merchant_count"},
+			rows:    [][]driver.Value{{int64(10), "饮品", 250.0, int64(3), int64(2)}, {int64(11), "食品", 150.0, int64(2), int64(1)}}},
+		{match: "JOIN order_items",
+			columns: []string{"category_id", "category_name", "revenue", "order_count"},
+			rows:    [][]driver.Value{{int64(10), "饮品", 250.0, int64(3)}, {int64(11), "食品", 150.0, int64(2)}}},
+		{match: "as month, COALESCE(SUM(o.total_amount)",
+			columns: []string{"month", "revenue", "order_count", "rights_consumed"},
+			rows:    [][]driver.Value{{"2026-09", 400.0, int64(4), int64(40)}}},
+		{match: "AND o.merchant_id = ? AND o.created_at >= ?",
+			columns: []string{"order_count", "customer_count", "revenue", "avg_order_value", "rights_consumed"},
+			rows:    [][]driver.Value{{int64(3), int64(2), 300.0, 100.0, int64(30)}}},
+		{match: "as order_revenue",
+			columns: []string{"merchant_id", "merchant_name", "order_revenue", "paid_order_count", "rights_consumed", "total_consumed"},
+			rows:    [][]driver.Value{{int64(1), "商户A", 300.0, int64(3), 30.0, 50.0}}},
+		{match: "as total_credited",
+			columns: []string{"merchant_id", "merchant_name", "deposits", "allocations", "total_credited"},
+			rows:    [][]driver.Value{{int64(1), "商户A", 500.0, 0.0, 800.0}}},
+		{match: "HAVING total_revenue > 0",
+			columns: []string{"merchant_id", "merchant_name", "total_revenue", "order_count", "customer_count", "average_order_value"},
+			rows:    [][]driver.Value{{int64(1), "商户A", 300.0, int64(3), int64(2), 100.0}, {int64(2), "商户B", 100.0, int64(1), int64(1), 100.0}}},
+		{match: "FROM product_reviews",
+			columns: []string{"merchant_id", "merchant_name", "average_rating", "review_count", "low_rating_count"},
+			rows:    [][]driver.Value{{int64(1), "商户A", 4.5, int64(10), int64(1)}}},
+		{match: "as new_users",
+			columns: []string{"month", "new_users", "active_users"},
+			rows:    [][]driver.Value{{"2026-08", int64(10), int64(6)}, {"2026-09", int64(20), int64(15)}}},
+		{match: "as dau",
+			columns: []string{"dau", "wau", "mau"},
+			rows:    [][]driver.Value{{int64(5), int64(10), int64(20)}}},
+		{match: "as repurchase_rate",
+			columns: []string{"average_order_value", "repurchase_rate", "average_order_count"},
+			rows:    [][]driver.Value{{100.0, 40.0, 1.5}}},
+	}
+}
+
+// replaceAnalyticsSeed 替换匹配片段相同的种子数据
+func replaceAnalyticsSeed(seeds []analyticsSeed, seed analyticsSeed) []analyticsSeed {
+	for i := range seeds {
+		if seeds[i].match == seed.match {
+			seeds[i] = seed
+		}
+	}
+	return seeds
+}
+
+// analyticsConn 按种子数据响应报表查询的连接
+type analyticsConn struct{}
+
+func (c *analyticsConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *analyticsConn) Close() error { return nil }
+
+func (c *analyticsConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+func (c *analyticsConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	normalized := strings.Join(strings.Fields(query), " ")
+	if strings.Contains(normalized, "%!") {
+		return nil, errors.New("You have an error in your SQL syntax near: " + normalized)
+	}
+
+	analyticsSeedMutex.Lock()
+	defer analyticsSeedMutex.Unlock()
+	for _, seed := range analyticsSeeds {
+		if !strings.Contains(normalized, seed.match) {
+			continue
+		}
+		if seed.err != nil {
+			return nil, seed.err
+		}
+		return &analyticsRows{columns: seed.columns, rows: append([][]driver.Value(nil), seed.rows...)}, nil
+	}
+	return nil, errors.New("no seed data for query: " + normalized)
+}
+
+type analyticsRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *analyticsRows) Columns() []string { return r.columns }
+
+func (r *analyticsRows) Close() error { return nil }
+
+func (r *analyticsRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type analyticsSQLDriver struct{}
+
+func (analyticsSQLDriver) Open(name string) (driver.Conn, error) {
+	return &analyticsConn{}, nil
+}
+
+// analyticsDBDriver gdb 驱动，连接到报表种子数据
+type analyticsDBDriver struct {
+	*gdb.Core
+}
+
+func (d *analyticsDBDriver) New(core *gdb.Core, node *gdb.ConfigNode) (gdb.DB, error) {
+	return &analyticsDBDriver{Core: core}, nil
+}
+
+func (d *analyticsDBDriver) Open(config *gdb.ConfigNode) (*sql.DB, error) {
+	return sql.Open("analytics_seed", config.Name)
+}
+
+func (d *analyticsDBDriver) GetChars() (string, string) { return "`", "`" }
+
+func (d *analyticsDBDriver) Tables(ctx context.Context, schema ...string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (d *analyticsDBDriver) TableFields(ctx context.Context, table string, schema ...string) (map[string]*gdb.TableField, error) {
+	return map[string]*gdb.TableField{}, nil
+}
+
+var registerAnalyticsDriverOnce sync.Once
+
+// setupAnalyticsDatasource 注册报表种子数据源，并将测试租户路由到该数据源
+func setupAnalyticsDatasource() {
+	setupResidencyDatasources()
+	registerAnalyticsDriverOnce.Do(func() {
+		sql.Register("analytics_seed", analyticsSQLDriver{})
+		if err := gdb.Register("analytics_seed", &analyticsDBDriver{}); err != nil {
+			panic(err)
+		}
+		if err := gdb.AddConfigNode("analytics_seed", gdb.ConfigNode{Type: "analytics_seed", Name: "analytics_seed"}); err != nil {
+			panic(err)
+		}
+	})
+	SetTenantDatasourceConfig(&TenantDatasourceConfig{
+		Tenants: map[uint64]string{analyticsSeedTenantID: "analytics_seed"},
+	})
+}
+
+// analyticsCheckResult 按名称查找检查结果
+func analyticsCheckResult(report *types.AnalyticsCheckReport, name string) types.AnalyticsCheckResult {
+	for _, result := range report.Results {
+		if result.Name == name {
+			return result
+		}
+	}
+	return types.AnalyticsCheckResult{}
+}
+
+func TestAnalyticsQueryChecks(t *testing.T) {
+	Convey("分析查询校验测试", t, func() {
+		setupAnalyticsDatasource()
+		defer SetTenantDatasourceConfig(nil)
+
+		merchantID := uint64(1)
+		req := &types.AnalyticsCheckRequest{
+			TenantID:   analyticsSeedTenantID,
+			StartDate:  time.Date(2026, 8, 1, 0, 0, 0, 0, time.Local),
+			EndDate:    time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local),
+			MerchantID: &merchantID,
+		}
+		repo := NewReportRepository()
+		seeds := healthyAnalyticsSeeds()
+
+		Convey("所有已注册的查询都被执行", func() {
+			seedAnalyticsData(seeds)
+			report := RunAnalyticsQueryChecks(context.Background(), repo, req)
+
+			So(report.Passed, ShouldBeTrue)
+			So(report.Failed(), ShouldBeEmpty)
+			names := make([]string, 0, len(report.Results))
+			for _, result := range report.Results {
+				names = append(names, result.Name)
+			}
+			So(names, ShouldResemble, []string{"financial_data", "merchant_summary", "reconciliation", "merchant_operation", "customer_analysis"})
+		})
+
+		Convey("数据一致时分解数据和百分比完整", func() {
+			seedAnalyticsData(seeds)
+			data, err := repo.GetFinancialData(context.WithValue(context.Background(), "tenant_id", uint64(analyticsSeedTenantID)),
+				req.TenantID, req.StartDate, req.EndDate, nil)
+
+			So(err, ShouldBeNil)
+			So(data.Breakdown, ShouldNotBeNil)
+			So(data.Breakdown.RevenueByMerchant, ShouldHaveLength, 2)
+			So(data.Breakdown.RevenueByMerchant[0].Percentage, ShouldEqual, 75)
+			So(data.Breakdown.RevenueByCategory[1].Percentage, ShouldEqual, 37.5)
+		})
+
+		Convey("未指定商户时跳过商户级检查", func() {
+			seedAnalyticsData(seeds)
+			report := RunAnalyticsQueryChecks(context.Background(), repo, &types.AnalyticsCheckRequest{
+				TenantID: req.TenantID, StartDate: req.StartDate, EndDate: req.EndDate,
+			})
+
+			So(report.Passed, ShouldBeTrue)
+			So(analyticsCheckResult(report, "merchant_summary").Skipped, ShouldBeTrue)
+		})
+
+		Convey("被降级处理的子查询错误作为失败返回", func() {
+			seedAnalyticsData(replaceAnalyticsSeed(seeds, analyticsSeed{
+				match: "FROM product_reviews",
+				err:   errors.New("Unknown column 'pr.flagged' in 'where clause'"),
+			}))
+			report := RunAnalyticsQueryChecks(context.Background(), repo, req)
+
+			So(report.Passed, ShouldBeFalse)
+			result := analyticsCheckResult(report, "merchant_operation")
+			So(result.Passed, ShouldBeFalse)
+			So(result.Error, ShouldBeEmpty)
+			So(result.QueryErrors, ShouldHaveLength, 1)
+			So(result.QueryErrors[0].Query, ShouldEqual, "merchant_operation.satisfaction")
+			So(result.QueryErrors[0].Error, ShouldContainSubstring, "pr.flagged")
+			So(analyticsCheckResult(report, "financial_data").Passed, ShouldBeTrue)
+		})
+
+		Convey("主查询失败时记录错误，不影响其他检查", func() {
+			seedAnalyticsData(replaceAnalyticsSeed(seeds, analyticsSeed{
+				match: "as total_order_count",
+				err:   errors.New("Table 'orders' doesn't exist"),
+			}))
+			report := RunAnalyticsQueryChecks(context.Background(), repo, req)
+
+			result := analyticsCheckResult(report, "financial_data")
+			So(result.Passed, ShouldBeFalse)
+			So(result.Error, ShouldContainSubstring, "doesn't exist")
+			So(analyticsCheckResult(report, "customer_analysis").Passed, ShouldBeTrue)
+		})
+
+		Convey("结果不满足约束时列出违反项", func() {
+			seeds = replaceAnalyticsSeed(seeds, analyticsSeed{
+				match:   "as dau",
+				columns: []string{"dau", "wau", "mau"},
+				rows:    [][]driver.Value{{int64(30), int64(10), int64(20)}},
+			})
+			seeds = replaceAnalyticsSeed(seeds, analyticsSeed{
+				match:   "FROM product_reviews",
+				columns: []string{"merchant_id", "merchant_name", "average_rating", "review_count", "low_rating_count"},
+				rows:    [][]driver.Value{{int64(1), "商户A", 6.0, int64(2), int64(3)}},
+			})
+			seeds = replaceAnalyticsSeed(seeds, analyticsSeed{
+				match:   "as order_revenue",
+				columns: []string{"merchant_id", "merchant_name", "order_revenue", "paid_order_count", "rights_consumed", "total_consumed"},
+				rows:    [][]driver.Value{{int64(1), "商户A", -300.0, int64(3), 30.0, 10.0}},
+			})
+			seedAnalyticsData(seeds)
+			report := RunAnalyticsQueryChecks(context.Background(), repo, req)
+
+			So(report.Passed, ShouldBeFalse)
+			So(analyticsCheckResult(report, "customer_analysis").Violations, ShouldHaveLength, 1)
+			So(analyticsCheckResult(report, "merchant_operation").Violations, ShouldHaveLength, 2)
+			So(analyticsCheckResult(report, "reconciliation").Violations, ShouldHaveLength, 2)
+			So(analyticsCheckResult(report, "financial_data").Passed, ShouldBeTrue)
+		})
+
+		Convey("检查 panic 时记录为失败", func() {
+			seedAnalyticsData(seeds)
+			RegisterAnalyticsQueryCheck(AnalyticsQueryCheck{
+				Name: "panicking_check",
+				Run: func(ctx context.Context, repo IReportRepository, req *types.AnalyticsCheckRequest, v *types.AnalyticsInvariants) error {
+					var data *types.FinancialReportData
+					v.NonNegative("order_count", float64(data.OrderCount))
+					return nil
+				},
+			})
+			defer unregisterAnalyticsQueryCheck("panicking_check")
+
+			report := RunAnalyticsQueryChecks(context.Background(), repo, req)
+			result := analyticsCheckResult(report, "panicking_check")
+			So(result.Passed, ShouldBeFalse)
+			So(result.Error, ShouldStartWith, "panic:")
+		})
+	})
+}
+
+// unregisterAnalyticsQueryCheck 移除测试注册的检查
+func unregisterAnalyticsQueryCheck(name string) {
+	analyticsQueryChecksMutex.Lock()
+	defer analyticsQueryChecksMutex.Unlock()
+
+	checks := analyticsQueryChecks[:0]
+	for _, check := range analyticsQueryChecks {
+		if check.Name != name {
+			checks = append(checks, check)
+		}
+	}
+	analyticsQueryChecks = checks
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IAttachmentRepository 附件仓储接口
+type IAttachmentRepository interface {
+	Create(ctx context.Context, attachment *types.Attachment) error
+	GetByID(ctx context.Context, id uint64) (*types.Attachment, error)
+	Link(ctx context.Context, id uint64, targetType types.AttachmentTargetType, targetID string) error
+	ListByTarget(ctx context.Context, targetType types.AttachmentTargetType, targetID string) ([]types.Attachment, error)
+}
+
+// AttachmentRepository 附件仓储实现
+type AttachmentRepository struct {
+	*BaseRepository
+}
+
+// NewAttachmentRepository 创建附件仓储实例
+func NewAttachmentRepository() IAttachmentRepository {
+	return &AttachmentRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 创建附件记录
+func (r *AttachmentRepository) Create(ctx context.Context, attachment *types.Attachment) error {
+	attachment.TenantID = r.GetTenantID(ctx)
+	attachment.CreatedAt = gtime.Now().Time
+
+	id, err := TenantDB(ctx).Model("attachments").Ctx(ctx).Data(attachment).OmitEmpty().InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("创建附件记录失败: %v", err)
+	}
+
+	attachment.ID = uint64(id)
+	return nil
+}
+
+// GetByID 获取当前租户的附件，不存在时返回 nil
+func (r *AttachmentRepository) GetByID(ctx context.Context, id uint64) (*types.Attachment, error) {
+	var attachment *types.Attachment
+	err := TenantDB(ctx).Model("attachments").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", id, r.GetTenantID(ctx)).
+		Scan(&attachment)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取附件失败: %v", err)
+	}
+
+	return attachment, nil
+}
+
+// Link 将尚未关联的附件关联到业务记录
+func (r *AttachmentRepository) Link(ctx context.Context, id uint64, targetType types.AttachmentTargetType, targetID string) error {
+	result, err := TenantDB(ctx).Model("attachments").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ? AND target_id = ''", id, r.GetTenantID(ctx)).
+		Data(g.Map{
+			"target_type": targetType,
+			"target_id":   targetID,
+			"linked_at":   gtime.Now(),
+		}).
+		Update()
+	if err != nil {
+		return fmt.Errorf("关联附件失败: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("关联附件失败: %v", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("附件不存在或已关联")
+	}
+	return nil
+}
+
+// ListByTarget 获取业务记录关联的附件
+func (r *AttachmentRepository) ListByTarget(ctx context.Context, targetType types.AttachmentTargetType, targetID string) ([]types.Attachment, error) {
+	var attachments []types.Attachment
+	err := TenantDB(ctx).Model("attachments").
+		Ctx(ctx).
+		Where("tenant_id = ? AND target_type = ? AND target_id = ?", r.GetTenantID(ctx), targetType, targetID).
+		OrderAsc("created_at").
+		Scan(&attachments)
+	if err != nil {
+		return nil, fmt.Errorf("获取附件列表失败: %v", err)
+	}
+
+	return attachments, nil
+}
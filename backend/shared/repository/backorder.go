@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IBackorderRepository 预订订单补货释放仓储接口
+type IBackorderRepository interface {
+	// 按下单先后将商品补货后的库存释放给待补货的预订订单，全部预订已释放的已支付订单转为处理中
+	ReleaseBackorders(ctx context.Context, productID uint64, reason string) (*types.BackorderReleaseResult, error)
+}
+
+// BackorderRepository 预订订单补货释放仓储
+type BackorderRepository struct {
+	*BaseRepository
+	orderRepo IOrderRepository
+}
+
+// NewBackorderRepository 创建预订订单补货释放仓储实例
+func NewBackorderRepository() IBackorderRepository {
+	return &BackorderRepository{
+		BaseRepository: NewBaseRepository(),
+		orderRepo:      NewOrderRepository(),
+	}
+}
+
+// ReleaseBackorders 在一个事务内锁定商品库存行和待补货订单后分配补货库存，订单项的预订数量、
+// 订单状态变更及其状态历史和通知事件一起提交或回滚。商品行锁保证同一商品的补货释放串行执行
+func (r *BackorderRepository) ReleaseBackorders(ctx context.Context, productID uint64, reason string) (*types.BackorderReleaseResult, error) {
+	tenantID := r.GetTenantID(ctx)
+	merchantID := r.GetMerchantID(ctx)
+	if tenantID == 0 || merchantID == 0 {
+		return nil, fmt.Errorf("missing tenant_id or merchant_id in context")
+	}
+
+	result := &types.BackorderReleaseResult{ProductID: productID}
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		var product types.Product
+		err := tx.Model("products").Ctx(ctx).
+			Where("id = ? AND tenant_id = ? AND merchant_id = ?", productID, tenantID, merchantID).
+			LockUpdate().
+			Scan(&product)
+		if err != nil {
+			return err
+		}
+		if product.ID == 0 {
+			return fmt.Errorf("product not found")
+		}
+		if product.InventoryInfo == nil {
+			return nil
+		}
+
+		orders, err := r.lockBackorderedOrders(ctx, tx, tenantID, merchantID, productID)
+		if err != nil {
+			return err
+		}
+		for _, order := range orders {
+			result.Outstanding += order.BackorderedQuantityOf(productID)
+		}
+
+		releasable := product.InventoryInfo.ReleasableBackorders(result.Outstanding)
+		result.Releases = types.AllocateBackorders(productID, releasable, orders)
+
+		statuses := make(map[uint64]types.OrderStatus, len(orders))
+		itemsByOrder := make(map[uint64][]types.OrderItem, len(orders))
+		for _, order := range orders {
+			statuses[order.ID] = order.Status
+			itemsByOrder[order.ID] = order.Items
+		}
+
+		for _, release := range result.Releases {
+			itemsJSON, err := json.Marshal(itemsByOrder[release.OrderID])
+			if err != nil {
+				return fmt.Errorf("序列化订单项失败: %v", err)
+			}
+			_, err = tx.Model("orders").Ctx(ctx).
+				Where("id = ? AND tenant_id = ?", release.OrderID, tenantID).
+				Update(gdb.Map{
+					"items":      string(itemsJSON),
+					"updated_at": gtime.Now(),
+				})
+			if err != nil {
+				return fmt.Errorf("更新订单预订数量失败: %v", err)
+			}
+			result.Released += release.Quantity
+
+			// 待支付订单只释放预订数量，支付后按正常流程处理
+			if !release.Fulfilled || statuses[release.OrderID] != types.OrderStatusPaid {
+				continue
+			}
+			err = r.orderRepo.UpdateStatusWithHistory(ctx, release.OrderID, types.OrderStatusIntProcessing, reason,
+				types.OrderStatusOperatorTypeSystem, nil, map[string]interface{}{
+					"product_id":        productID,
+					"released_quantity": release.Quantity,
+				})
+			if err != nil {
+				return err
+			}
+			result.ProcessingOrderIDs = append(result.ProcessingOrderIDs, release.OrderID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("释放预订订单失败: %w", err)
+	}
+	return result, nil
+}
+
+// lockBackorderedOrders 锁定包含该商品待补货预订数量的待支付和已支付订单，按下单时间升序返回
+func (r *BackorderRepository) lockBackorderedOrders(ctx context.Context, tx gdb.TX, tenantID, merchantID, productID uint64) ([]*types.Order, error) {
+	var rows []orderRow
+	err := tx.Model("orders").Ctx(ctx).
+		Where("tenant_id = ? AND merchant_id = ?", tenantID, merchantID).
+		WhereIn("status", []types.OrderStatus{types.OrderStatusPending, types.OrderStatusPaid}).
+		Where("JSON_CONTAINS(items, JSON_OBJECT('product_id', ?))", productID).
+		OrderAsc("created_at").
+		OrderAsc("id").
+		LockUpdate().
+		Scan(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("查询预订订单失败: %v", err)
+	}
+
+	decoded, err := decodeOrderRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]*types.Order, 0, len(decoded))
+	for _, order := range decoded {
+		if order.BackorderedQuantityOf(productID) > 0 {
+			orders = append(orders, order)
+		}
+	}
+	return orders, nil
+}
@@ -24,11 +24,23 @@ func NewBaseRepository() *BaseRepository {
 	}
 }
 
-// GetDB 获取数据库实例
+// GetDB 获取共享数据库实例
 func (r *BaseRepository) GetDB() gdb.DB {
 	return r.db
 }
 
+// GetTenantDB 获取上下文租户数据所在的数据库，未配置数据驻留的租户使用共享数据库
+func (r *BaseRepository) GetTenantDB(ctx context.Context) (gdb.DB, error) {
+	group, err := resolveDatasource(ctx, r.GetTenantID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if r.db != nil && group == r.db.GetGroup() {
+		return r.db, nil
+	}
+	return g.DB(group), nil
+}
+
 // GetTableName 获取表名
 func (r *BaseRepository) GetTableName() string {
 	return r.tableName
@@ -109,15 +121,20 @@ func (r *BaseRepository) Model(ctx context.Context) (*gdb.Model, error) {
 		return nil, fmt.Errorf("missing tenant_id in context")
 	}
 	
+	db, err := r.GetTenantDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	
 	// 记录正常的租户数据访问
 	audit.LogTenantAccess(ctx, tenantID, r.tableName, "query", nil)
 	
-	return r.db.Model(r.tableName).Where("tenant_id", tenantID), nil
+	return r.Guard(ctx, db.Model(r.tableName)).Where("tenant_id", tenantID), nil
 }
 
-// ModelWithoutTenant 获取不带租户隔离的模型（慎用）
+// ModelWithoutTenant 获取不带租户隔离的模型（慎用），只访问共享数据库，不会跨数据源查询
 func (r *BaseRepository) ModelWithoutTenant() *gdb.Model {
-	return r.db.Model(r.tableName)
+	return r.db.Model(r.tableName).Hook(queryGuardHook(r.tableName))
 }
 
 // Insert 插入数据（自动添加租户ID）
@@ -127,11 +144,16 @@ func (r *BaseRepository) Insert(ctx context.Context, data interface{}) (sql.Resu
 		return nil, fmt.Errorf("missing tenant_id in context")
 	}
 	
+	db, err := r.GetTenantDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	
 	// 确保数据包含租户ID
 	dataMap := gconv.Map(data)
 	dataMap["tenant_id"] = tenantID
 	
-	return r.db.Model(r.tableName).Insert(dataMap)
+	return r.Guard(ctx, db.Model(r.tableName)).Insert(dataMap)
 }
 
 // InsertAndGetId 插入数据并返回ID
@@ -141,10 +163,15 @@ func (r *BaseRepository) InsertAndGetId(ctx context.Context, data interface{}) (
 		return 0, fmt.Errorf("missing tenant_id in context")
 	}
 	
+	db, err := r.GetTenantDB(ctx)
+	if err != nil {
+		return 0, err
+	}
+	
 	dataMap := gconv.Map(data)
 	dataMap["tenant_id"] = tenantID
 	
-	return r.db.Model(r.tableName).InsertAndGetId(dataMap)
+	return r.Guard(ctx, db.Model(r.tableName)).InsertAndGetId(dataMap)
 }
 
 // Update 更新数据（自动添加租户隔离）
@@ -154,7 +181,12 @@ func (r *BaseRepository) Update(ctx context.Context, data interface{}, condition
 		return nil, fmt.Errorf("missing tenant_id in context")
 	}
 	
-	model := r.db.Model(r.tableName).Where("tenant_id", tenantID)
+	db, err := r.GetTenantDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	
+	model := r.Guard(ctx, db.Model(r.tableName)).Where("tenant_id", tenantID)
 	if condition != nil {
 		model = model.Where(condition, args...)
 	}
@@ -169,7 +201,12 @@ func (r *BaseRepository) Delete(ctx context.Context, condition interface{}, args
 		return nil, fmt.Errorf("missing tenant_id in context")
 	}
 	
-	model := r.db.Model(r.tableName).Where("tenant_id", tenantID)
+	db, err := r.GetTenantDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	
+	model := r.Guard(ctx, db.Model(r.tableName)).Where("tenant_id", tenantID)
 	if condition != nil {
 		model = model.Where(condition, args...)
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/database/gdb"
@@ -14,24 +15,35 @@ import (
 // ICartRepository 购物车仓储接口
 type ICartRepository interface {
 	GetOrCreate(ctx context.Context, customerID uint64) (*types.Cart, error)
-	AddItem(ctx context.Context, cartID uint64, productID uint64, quantity int) error
-	UpdateItemQuantity(ctx context.Context, itemID uint64, quantity int) error
+	AddItem(ctx context.Context, cartID uint64, productID uint64, quantity int, validate CartItemValidator) error
+	AddBundle(ctx context.Context, cartID uint64, bundleID uint64, quantity int, validate CartItemValidator) error
+	UpdateItemQuantity(ctx context.Context, itemID uint64, quantity int, version int, validate CartItemValidator) error
 	RemoveItem(ctx context.Context, itemID uint64) error
 	ClearCart(ctx context.Context, cartID uint64) error
 	GetCartItems(ctx context.Context, cartID uint64) ([]types.CartItem, error)
 	GetItemByProductID(ctx context.Context, cartID uint64, productID uint64) (*types.CartItem, error)
-	CleanExpiredCarts(ctx context.Context) error
+	// 跨租户删除最后变更时间早于 untouchedSince 的购物车，返回删除的购物车数，仅供后台定时任务使用
+	CleanExpiredCarts(ctx context.Context, untouchedSince time.Time) (int, error)
 }
 
+// CartItemValidator 在锁定购物车期间校验修改后的购物车项，item 的 Quantity 为修改后购物车中该商品或套餐的总数量
+type CartItemValidator func(ctx context.Context, item types.CartItem) error
+
 // CartRepository 购物车仓储实现
 type CartRepository struct {
 	*BaseRepository
+	ttl time.Duration // 购物车最后变更后的保留时间
 }
 
 // NewCartRepository 创建购物车仓储实例
 func NewCartRepository() ICartRepository {
+	ttl := g.Cfg().MustGet(context.Background(), "cart.ttl", types.DefaultCartTTL).Duration()
+	if ttl <= 0 {
+		ttl = types.DefaultCartTTL
+	}
 	return &CartRepository{
 		BaseRepository: NewBaseRepository(),
+		ttl:            ttl,
 	}
 }
 
@@ -41,7 +53,7 @@ func (r *CartRepository) GetOrCreate(ctx context.Context, customerID uint64) (*t
 	
 	// 先尝试获取现有购物车
 	var cart types.Cart
-	err := g.DB().Model("carts").Ctx(ctx).
+	err := TenantDB(ctx).Model("carts").Ctx(ctx).
 		Where("tenant_id = ? AND customer_id = ?", tenantID, customerID).
 		Scan(&cart)
 	
@@ -51,8 +63,8 @@ func (r *CartRepository) GetOrCreate(ctx context.Context, customerID uint64) (*t
 	
 	// 如果购物车不存在，创建新的
 	if err == sql.ErrNoRows {
-		expiresAt := gtime.Now().Add(7 * 24 * 3600) // 7天后过期
-		result, err := g.DB().Model("carts").Ctx(ctx).Insert(gdb.Map{
+		expiresAt := gtime.Now().Add(r.ttl) // 最后变更后超过保留时间未变更的购物车会被清空
+		result, err := TenantDB(ctx).Model("carts").Ctx(ctx).Insert(gdb.Map{
 			"tenant_id":   tenantID,
 			"customer_id": customerID,
 			"created_at":  gtime.Now(),
@@ -89,90 +101,258 @@ func (r *CartRepository) GetOrCreate(ctx context.Context, customerID uint64) (*t
 	return &cart, nil
 }
 
-// AddItem 添加商品到购物车
-func (r *CartRepository) AddItem(ctx context.Context, cartID uint64, productID uint64, quantity int) error {
+// AddItem 添加商品到购物车。在事务中锁定购物车后读取已有数量，并发添加同一商品时数量累加，
+// validate 不为空时在锁定期间按添加后的总数量校验
+func (r *CartRepository) AddItem(ctx context.Context, cartID uint64, productID uint64, quantity int, validate CartItemValidator) error {
 	tenantID := r.GetTenantID(ctx)
 	
-	// 检查商品是否已在购物车中
-	existingItem, err := r.GetItemByProductID(ctx, cartID, productID)
-	if err != nil && err.Error() != "购物车项不存在" {
-		return fmt.Errorf("检查购物车项失败: %v", err)
-	}
-	
-	if existingItem != nil {
-		// 如果已存在，更新数量
-		return r.UpdateItemQuantity(ctx, existingItem.ID, existingItem.Quantity+quantity)
-	}
-	
-	// 添加新项
-	_, err = g.DB().Model("cart_items").Ctx(ctx).Insert(gdb.Map{
-		"tenant_id":  tenantID,
-		"cart_id":    cartID,
-		"product_id": productID,
-		"quantity":   quantity,
-		"added_at":   gtime.Now(),
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if err := lockCart(ctx, tx, tenantID, cartID); err != nil {
+			return err
+		}
+		
+		// 检查商品是否已在购物车中
+		var existingItem *types.CartItem
+		err := tx.Model("cart_items").Ctx(ctx).
+			Where("cart_id = ? AND product_id = ? AND tenant_id = ?", cartID, productID, tenantID).
+			Scan(&existingItem)
+		if err != nil {
+			return fmt.Errorf("检查购物车项失败: %v", err)
+		}
+		
+		total := quantity
+		if existingItem != nil {
+			total += existingItem.Quantity
+		}
+		if validate != nil {
+			if err := validate(ctx, types.CartItem{ProductID: productID, Quantity: total}); err != nil {
+				return err
+			}
+		}
+		
+		if existingItem != nil {
+			// 如果已存在，更新数量
+			_, err = tx.Model("cart_items").Ctx(ctx).
+				Where("id = ? AND tenant_id = ?", existingItem.ID, tenantID).
+				Update(gdb.Map{
+					"quantity": total,
+					"version":  gdb.Raw("version + 1"),
+				})
+			if err != nil {
+				return fmt.Errorf("更新购物车项数量失败: %v", err)
+			}
+		} else {
+			// 添加新项
+			_, err = tx.Model("cart_items").Ctx(ctx).Insert(gdb.Map{
+				"tenant_id":  tenantID,
+				"cart_id":    cartID,
+				"product_id": productID,
+				"quantity":   quantity,
+				"version":    1,
+				"added_at":   gtime.Now(),
+			})
+			if err != nil {
+				return fmt.Errorf("添加购物车项失败: %v", err)
+			}
+		}
+		
+		return r.touch(ctx, tx, tenantID, cartID)
 	})
-	
-	if err != nil {
-		return fmt.Errorf("添加购物车项失败: %v", err)
-	}
-	
-	// 更新购物车的更新时间
-	_, err = g.DB().Model("carts").Ctx(ctx).
-		Where("id = ? AND tenant_id = ?", cartID, tenantID).
-		Update(gdb.Map{"updated_at": gtime.Now()})
-	
-	return err
 }
 
-// UpdateItemQuantity 更新购物车项数量
-func (r *CartRepository) UpdateItemQuantity(ctx context.Context, itemID uint64, quantity int) error {
+// AddBundle 添加套餐到购物车，同一套餐合并为一项，锁定和校验方式与 AddItem 相同
+func (r *CartRepository) AddBundle(ctx context.Context, cartID uint64, bundleID uint64, quantity int, validate CartItemValidator) error {
+	tenantID := r.GetTenantID(ctx)
+
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if err := lockCart(ctx, tx, tenantID, cartID); err != nil {
+			return err
+		}
+
+		var existingItem *types.CartItem
+		err := tx.Model("cart_items").Ctx(ctx).
+			Where("cart_id = ? AND bundle_id = ? AND tenant_id = ?", cartID, bundleID, tenantID).
+			Scan(&existingItem)
+		if err != nil {
+			return fmt.Errorf("检查购物车项失败: %v", err)
+		}
+
+		total := quantity
+		if existingItem != nil {
+			total += existingItem.Quantity
+		}
+		if validate != nil {
+			if err := validate(ctx, types.CartItem{BundleID: bundleID, Quantity: total}); err != nil {
+				return err
+			}
+		}
+
+		if existingItem != nil {
+			_, err = tx.Model("cart_items").Ctx(ctx).
+				Where("id = ? AND tenant_id = ?", existingItem.ID, tenantID).
+				Update(gdb.Map{
+					"quantity": total,
+					"version":  gdb.Raw("version + 1"),
+				})
+			if err != nil {
+				return fmt.Errorf("更新购物车项数量失败: %v", err)
+			}
+		} else {
+			_, err = tx.Model("cart_items").Ctx(ctx).Insert(gdb.Map{
+				"tenant_id": tenantID,
+				"cart_id":   cartID,
+				"bundle_id": bundleID,
+				"quantity":  quantity,
+				"version":   1,
+				"added_at":  gtime.Now(),
+			})
+			if err != nil {
+				return fmt.Errorf("添加购物车项失败: %v", err)
+			}
+		}
+
+		return r.touch(ctx, tx, tenantID, cartID)
+	})
+}
+
+// UpdateItemQuantity 更新购物车项数量。version 大于 0 时要求与当前版本一致，否则返回
+// types.ErrCartItemVersionConflict；为 0 时以最后一次更新为准。validate 不为空时在锁定期间校验新数量
+func (r *CartRepository) UpdateItemQuantity(ctx context.Context, itemID uint64, quantity int, version int, validate CartItemValidator) error {
 	tenantID := r.GetTenantID(ctx)
 	
-	_, err := g.DB().Model("cart_items").Ctx(ctx).
-		Where("id = ? AND tenant_id = ?", itemID, tenantID).
-		Update(gdb.Map{"quantity": quantity})
-	
-	if err != nil {
-		return fmt.Errorf("更新购物车项数量失败: %v", err)
-	}
-	
-	return nil
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		cartID, err := lockItemCart(ctx, tx, tenantID, itemID)
+		if err != nil {
+			return err
+		}
+		
+		var item *types.CartItem
+		if cartID > 0 {
+			err = tx.Model("cart_items").Ctx(ctx).
+				Where("id = ? AND tenant_id = ?", itemID, tenantID).
+				Scan(&item)
+			if err != nil {
+				return fmt.Errorf("查询购物车项失败: %v", err)
+			}
+		}
+		if item == nil {
+			return fmt.Errorf("购物车项不存在")
+		}
+		
+		if version > 0 && item.Version != version {
+			return fmt.Errorf("%w: 当前版本 %d", types.ErrCartItemVersionConflict, item.Version)
+		}
+		if validate != nil {
+			target := *item
+			target.Quantity = quantity
+			if err := validate(ctx, target); err != nil {
+				return err
+			}
+		}
+		
+		_, err = tx.Model("cart_items").Ctx(ctx).
+			Where("id = ? AND tenant_id = ?", itemID, tenantID).
+			Update(gdb.Map{
+				"quantity": quantity,
+				"version":  gdb.Raw("version + 1"),
+			})
+		if err != nil {
+			return fmt.Errorf("更新购物车项数量失败: %v", err)
+		}
+		
+		return r.touch(ctx, tx, tenantID, cartID)
+	})
 }
 
 // RemoveItem 从购物车中移除商品
 func (r *CartRepository) RemoveItem(ctx context.Context, itemID uint64) error {
 	tenantID := r.GetTenantID(ctx)
 	
-	_, err := g.DB().Model("cart_items").Ctx(ctx).
-		Where("id = ? AND tenant_id = ?", itemID, tenantID).
-		Delete()
-	
-	if err != nil {
-		return fmt.Errorf("删除购物车项失败: %v", err)
-	}
-	
-	return nil
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		cartID, err := lockItemCart(ctx, tx, tenantID, itemID)
+		if err != nil || cartID == 0 {
+			return err
+		}
+		
+		_, err = tx.Model("cart_items").Ctx(ctx).
+			Where("id = ? AND tenant_id = ?", itemID, tenantID).
+			Delete()
+		if err != nil {
+			return fmt.Errorf("删除购物车项失败: %v", err)
+		}
+		
+		return r.touch(ctx, tx, tenantID, cartID)
+	})
 }
 
 // ClearCart 清空购物车
 func (r *CartRepository) ClearCart(ctx context.Context, cartID uint64) error {
 	tenantID := r.GetTenantID(ctx)
 	
-	_, err := g.DB().Model("cart_items").Ctx(ctx).
-		Where("cart_id = ? AND tenant_id = ?", cartID, tenantID).
-		Delete()
-	
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if err := lockCart(ctx, tx, tenantID, cartID); err != nil {
+			return err
+		}
+		
+		_, err := tx.Model("cart_items").Ctx(ctx).
+			Where("cart_id = ? AND tenant_id = ?", cartID, tenantID).
+			Delete()
+		if err != nil {
+			return fmt.Errorf("清空购物车失败: %v", err)
+		}
+		
+		return r.touch(ctx, tx, tenantID, cartID)
+	})
+}
+
+// lockCart 在事务中锁定购物车行，同一购物车的商品修改（多个标签页或设备）串行执行
+func lockCart(ctx context.Context, tx gdb.TX, tenantID, cartID uint64) error {
+	id, err := tx.Model("carts").Ctx(ctx).
+		Fields("id").
+		Where("id = ? AND tenant_id = ?", cartID, tenantID).
+		LockUpdate().
+		Value()
 	if err != nil {
-		return fmt.Errorf("清空购物车失败: %v", err)
+		return fmt.Errorf("锁定购物车失败: %v", err)
 	}
-	
-	// 更新购物车的更新时间
-	_, err = g.DB().Model("carts").Ctx(ctx).
+	if id.IsEmpty() {
+		return fmt.Errorf("购物车不存在")
+	}
+	return nil
+}
+
+// lockItemCart 锁定购物车项所属的购物车，返回购物车ID，购物车项不存在时返回 0。
+// 先锁购物车再读写购物车项，与添加商品的加锁顺序一致，避免死锁
+func lockItemCart(ctx context.Context, tx gdb.TX, tenantID, itemID uint64) (uint64, error) {
+	cartID, err := tx.Model("cart_items").Ctx(ctx).
+		Fields("cart_id").
+		Where("id = ? AND tenant_id = ?", itemID, tenantID).
+		Value()
+	if err != nil {
+		return 0, fmt.Errorf("查询购物车项失败: %v", err)
+	}
+	if cartID.IsEmpty() {
+		return 0, nil
+	}
+	if err := lockCart(ctx, tx, tenantID, cartID.Uint64()); err != nil {
+		return 0, err
+	}
+	return cartID.Uint64(), nil
+}
+
+// touch 购物车变更后更新最后变更时间并顺延过期时间，找回提醒按新的闲置期重新计算
+func (r *CartRepository) touch(ctx context.Context, tx gdb.TX, tenantID, cartID uint64) error {
+	now := gtime.Now()
+	_, err := tx.Model("carts").Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", cartID, tenantID).
-		Update(gdb.Map{"updated_at": gtime.Now()})
-	
-	return err
+		Update(gdb.Map{
+			"updated_at": now,
+			"expires_at": now.Add(r.ttl),
+		})
+	if err != nil {
+		return fmt.Errorf("更新购物车时间失败: %v", err)
+	}
+	return nil
 }
 
 // GetCartItems 获取购物车项列表
@@ -180,7 +360,7 @@ func (r *CartRepository) GetCartItems(ctx context.Context, cartID uint64) ([]typ
 	tenantID := r.GetTenantID(ctx)
 	
 	var items []types.CartItem
-	err := g.DB().Model("cart_items").Ctx(ctx).
+	err := TenantDB(ctx).Model("cart_items").Ctx(ctx).
 		Where("cart_id = ? AND tenant_id = ?", cartID, tenantID).
 		Order("added_at ASC").
 		Scan(&items)
@@ -197,7 +377,7 @@ func (r *CartRepository) GetItemByProductID(ctx context.Context, cartID uint64,
 	tenantID := r.GetTenantID(ctx)
 	
 	var item types.CartItem
-	err := g.DB().Model("cart_items").Ctx(ctx).
+	err := TenantDB(ctx).Model("cart_items").Ctx(ctx).
 		Where("cart_id = ? AND product_id = ? AND tenant_id = ?", cartID, productID, tenantID).
 		Scan(&item)
 	
@@ -211,25 +391,26 @@ func (r *CartRepository) GetItemByProductID(ctx context.Context, cartID uint64,
 	return &item, nil
 }
 
-// CleanExpiredCarts 清理过期的购物车
-func (r *CartRepository) CleanExpiredCarts(ctx context.Context) error {
+// CleanExpiredCarts 跨租户删除超过保留时间未变更的购物车及其商品
+func (r *CartRepository) CleanExpiredCarts(ctx context.Context, untouchedSince time.Time) (int, error) {
 	// 删除过期的购物车项
-	_, err := g.DB().Model("cart_items").Ctx(ctx).
-		Where("cart_id IN (SELECT id FROM carts WHERE expires_at < ?)", gtime.Now()).
+	_, err := TenantDB(ctx).Model("cart_items").Ctx(ctx).
+		Where("cart_id IN (SELECT id FROM carts WHERE updated_at < ?)", untouchedSince).
 		Delete()
 	
 	if err != nil {
-		return fmt.Errorf("删除过期购物车项失败: %v", err)
+		return 0, fmt.Errorf("删除过期购物车项失败: %v", err)
 	}
 	
 	// 删除过期的购物车
-	_, err = g.DB().Model("carts").Ctx(ctx).
-		Where("expires_at < ?", gtime.Now()).
+	result, err := TenantDB(ctx).Model("carts").Ctx(ctx).
+		Where("updated_at < ?", untouchedSince).
 		Delete()
 	
 	if err != nil {
-		return fmt.Errorf("删除过期购物车失败: %v", err)
+		return 0, fmt.Errorf("删除过期购物车失败: %v", err)
 	}
 	
-	return nil
-}
\ No newline at end of file
+	deleted, _ := result.RowsAffected()
+	return int(deleted), nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// ICartRecoveryRepository 购物车找回提醒仓储接口
+type ICartRecoveryRepository interface {
+	// 跨租户列出有商品、最后变更时间早于 idleSince 且本次闲置期间未发送找回提醒的购物车，不包含购物车项，仅供后台定时任务使用
+	ListAbandonedCarts(ctx context.Context, idleSince time.Time, limit int) ([]types.Cart, error)
+	// 购物车自 cart.UpdatedAt 起未变更且本次闲置期间未提醒时记录提醒时间，返回是否记录；
+	// 多个实例同时处理同一购物车时只有一个能记录成功
+	MarkRecoveryNotified(ctx context.Context, cart *types.Cart, notifiedAt time.Time) (bool, error)
+	// 获取客户的找回提醒偏好，未设置时返回 nil
+	GetPreference(ctx context.Context, customerID uint64) (*types.CartRecoveryPreference, error)
+	SavePreference(ctx context.Context, preference *types.CartRecoveryPreference) error
+}
+
+// CartRecoveryRepository 购物车找回提醒仓储实现
+type CartRecoveryRepository struct {
+	*BaseRepository
+}
+
+// NewCartRecoveryRepository 创建购物车找回提醒仓储实例
+func NewCartRecoveryRepository() ICartRecoveryRepository {
+	return &CartRecoveryRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// ListAbandonedCarts 按最后变更时间从早到晚列出待发送找回提醒的购物车
+func (r *CartRecoveryRepository) ListAbandonedCarts(ctx context.Context, idleSince time.Time, limit int) ([]types.Cart, error) {
+	var carts []types.Cart
+	err := TenantDB(ctx).Model("carts c").
+		Ctx(ctx).
+		Fields("c.*").
+		Where("c.updated_at < ?", idleSince).
+		Where("(c.recovery_notified_at IS NULL OR c.recovery_notified_at < c.updated_at)").
+		Where("EXISTS (SELECT 1 FROM cart_items i WHERE i.cart_id = c.id)").
+		OrderAsc("c.updated_at").
+		Limit(limit).
+		Scan(&carts)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询闲置购物车失败: %v", err)
+	}
+	return carts, nil
+}
+
+// MarkRecoveryNotified 记录找回提醒时间，显式保留更新时间避免被自动更新
+func (r *CartRecoveryRepository) MarkRecoveryNotified(ctx context.Context, cart *types.Cart, notifiedAt time.Time) (bool, error) {
+	result, err := TenantDB(ctx).Model("carts").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ? AND updated_at = ?", cart.ID, r.GetTenantID(ctx), cart.UpdatedAt).
+		Where("(recovery_notified_at IS NULL OR recovery_notified_at < updated_at)").
+		Update(gdb.Map{
+			"recovery_notified_at": notifiedAt,
+			"updated_at":           cart.UpdatedAt,
+		})
+	if err != nil {
+		return false, fmt.Errorf("记录购物车找回提醒失败: %v", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// GetPreference 获取当前租户客户的找回提醒偏好
+func (r *CartRecoveryRepository) GetPreference(ctx context.Context, customerID uint64) (*types.CartRecoveryPreference, error) {
+	var preference *types.CartRecoveryPreference
+	err := TenantDB(ctx).Model("cart_recovery_preferences").
+		Ctx(ctx).
+		Where("tenant_id = ? AND customer_id = ?", r.GetTenantID(ctx), customerID).
+		Scan(&preference)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取购物车找回提醒偏好失败: %v", err)
+	}
+	return preference, nil
+}
+
+// SavePreference 保存客户的找回提醒偏好
+func (r *CartRecoveryRepository) SavePreference(ctx context.Context, preference *types.CartRecoveryPreference) error {
+	preference.TenantID = r.GetTenantID(ctx)
+	preference.UpdatedAt = gtime.Now().Time
+
+	_, err := TenantDB(ctx).Model("cart_recovery_preferences").
+		Ctx(ctx).
+		Data(gdb.Map{
+			"tenant_id":   preference.TenantID,
+			"customer_id": preference.CustomerID,
+			"opted_out":   preference.OptedOut,
+			"updated_at":  preference.UpdatedAt,
+		}).
+		Save()
+	if err != nil {
+		return fmt.Errorf("保存购物车找回提醒偏好失败: %v", err)
+	}
+	return nil
+}
@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"github.com/gofromzero/mer-sys/backend/shared/types"
-	"github.com/gogf/gf/v2/frame/g"
 )
 
 // CategoryRepository 分类数据访问层
@@ -49,7 +48,7 @@ func (r *CategoryRepository) Create(ctx context.Context, category *types.Product
 		category.Path = category.Name
 	}
 	
-	result, err := g.DB().Model("product_categories").Ctx(ctx).Insert(category)
+	result, err := TenantDB(ctx).Model("product_categories").Ctx(ctx).Insert(category)
 	if err != nil {
 		return err
 	}
@@ -71,7 +70,7 @@ func (r *CategoryRepository) GetByID(ctx context.Context, id uint64) (*types.Pro
 	}
 	
 	var category types.ProductCategory
-	err := g.DB().Model("product_categories").
+	err := TenantDB(ctx).Model("product_categories").
 		Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", id, tenantID).
 		Scan(&category)
@@ -156,7 +155,7 @@ func (r *CategoryRepository) Update(ctx context.Context, id uint64, updates map[
 		}
 	}
 	
-	result, err := g.DB().Model("product_categories").
+	result, err := TenantDB(ctx).Model("product_categories").
 		Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", id, tenantID).
 		Update(updates)
@@ -181,7 +180,7 @@ func (r *CategoryRepository) Delete(ctx context.Context, id uint64) error {
 	}
 	
 	// 检查是否有子分类
-	count, err := g.DB().Model("product_categories").
+	count, err := TenantDB(ctx).Model("product_categories").
 		Ctx(ctx).
 		Where("parent_id = ? AND tenant_id = ?", id, tenantID).
 		Count()
@@ -194,7 +193,7 @@ func (r *CategoryRepository) Delete(ctx context.Context, id uint64) error {
 	}
 	
 	// 检查是否有商品使用此分类
-	productCount, err := g.DB().Model("products").
+	productCount, err := TenantDB(ctx).Model("products").
 		Ctx(ctx).
 		Where("category_id = ? AND tenant_id = ?", id, tenantID).
 		Where("status != ?", types.ProductStatusDeleted).
@@ -208,7 +207,7 @@ func (r *CategoryRepository) Delete(ctx context.Context, id uint64) error {
 	}
 	
 	// 删除分类
-	result, err := g.DB().Model("product_categories").
+	result, err := TenantDB(ctx).Model("product_categories").
 		Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", id, tenantID).
 		Delete()
@@ -233,7 +232,7 @@ func (r *CategoryRepository) GetTree(ctx context.Context) ([]types.CategoryTreeR
 	}
 	
 	var categories []types.ProductCategory
-	err := g.DB().Model("product_categories").
+	err := TenantDB(ctx).Model("product_categories").
 		Ctx(ctx).
 		Where("tenant_id = ?", tenantID).
 		Where("status = ?", types.CategoryStatusActive).
@@ -255,7 +254,7 @@ func (r *CategoryRepository) GetChildren(ctx context.Context, parentID uint64) (
 	}
 	
 	var children []types.ProductCategory
-	err := g.DB().Model("product_categories").
+	err := TenantDB(ctx).Model("product_categories").
 		Ctx(ctx).
 		Where("parent_id = ? AND tenant_id = ?", parentID, tenantID).
 		Where("status = ?", types.CategoryStatusActive).
@@ -289,7 +288,7 @@ func (r *CategoryRepository) GetPath(ctx context.Context, categoryID uint64) ([]
 		}
 		
 		var cat types.ProductCategory
-		err := g.DB().Model("product_categories").
+		err := TenantDB(ctx).Model("product_categories").
 			Ctx(ctx).
 			Where("path = ? AND tenant_id = ?", currentPath, category.TenantID).
 			Scan(&cat)
@@ -355,7 +354,7 @@ func (r *CategoryRepository) wouldCreateCycle(ctx context.Context, categoryID ui
 // updateChildrenPaths 更新所有子分类的路径
 func (r *CategoryRepository) updateChildrenPaths(ctx context.Context, parentID uint64, oldPath, newPath string) error {
 	var children []types.ProductCategory
-	err := g.DB().Model("product_categories").
+	err := TenantDB(ctx).Model("product_categories").
 		Ctx(ctx).
 		Where("parent_id = ?", parentID).
 		Scan(&children)
@@ -368,7 +367,7 @@ func (r *CategoryRepository) updateChildrenPaths(ctx context.Context, parentID u
 		// 替换路径前缀
 		childNewPath := strings.Replace(child.Path, oldPath, newPath, 1)
 		
-		_, err := g.DB().Model("product_categories").
+		_, err := TenantDB(ctx).Model("product_categories").
 			Ctx(ctx).
 			Where("id = ?", child.ID).
 			Update(map[string]interface{}{
@@ -397,7 +396,7 @@ func (r *CategoryRepository) GetFlatList(ctx context.Context) ([]types.ProductCa
 	}
 	
 	var categories []types.ProductCategory
-	err := g.DB().Model("product_categories").
+	err := TenantDB(ctx).Model("product_categories").
 		Ctx(ctx).
 		Where("tenant_id = ?", tenantID).
 		Where("status = ?", types.CategoryStatusActive).
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+)
+
+// ICategoryBatchRepository 分类批量操作仓储接口
+type ICategoryBatchRepository interface {
+	// 获取当前租户的全部分类（含禁用分类），用于计算分类树变更
+	ListAllCategories(ctx context.Context) ([]types.ProductCategory, error)
+	// 统计分类下未删除的商品数量，没有商品的分类不返回
+	CountProductsByCategory(ctx context.Context, categoryIDs []uint64) (map[uint64]int, error)
+	// 更新分类启用状态
+	UpdateStatus(ctx context.Context, id uint64, status types.CategoryStatus) error
+	// 在同一事务中转移商品、上移子分类并删除分类，返回转移的商品数量
+	ApplyDeletion(ctx context.Context, deletion *types.CategoryDeletion) (int, error)
+}
+
+// NewCategoryBatchRepository 创建分类批量操作仓储实例
+func NewCategoryBatchRepository() ICategoryBatchRepository {
+	return NewCategoryRepository()
+}
+
+// ListAllCategories 获取当前租户的全部分类
+func (r *CategoryRepository) ListAllCategories(ctx context.Context) ([]types.ProductCategory, error) {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return nil, fmt.Errorf("missing tenant_id in context")
+	}
+
+	var categories []types.ProductCategory
+	err := TenantDB(ctx).Model("product_categories").
+		Ctx(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("level ASC, sort_order ASC, id ASC").
+		Scan(&categories)
+	if err != nil {
+		return nil, fmt.Errorf("获取分类列表失败: %v", err)
+	}
+
+	return categories, nil
+}
+
+// CountProductsByCategory 统计分类下未删除的商品数量
+func (r *CategoryRepository) CountProductsByCategory(ctx context.Context, categoryIDs []uint64) (map[uint64]int, error) {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return nil, fmt.Errorf("missing tenant_id in context")
+	}
+
+	counts := make(map[uint64]int)
+	if len(categoryIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		CategoryID uint64 `json:"category_id"`
+		Total      int    `json:"total"`
+	}
+	err := TenantDB(ctx).Model("products").
+		Ctx(ctx).
+		Fields("category_id, COUNT(*) AS total").
+		Where("tenant_id = ?", tenantID).
+		WhereIn("category_id", categoryIDs).
+		Where("status != ?", types.ProductStatusDeleted).
+		Group("category_id").
+		Scan(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("统计分类商品数量失败: %v", err)
+	}
+
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Total
+	}
+	return counts, nil
+}
+
+// UpdateStatus 更新分类启用状态
+func (r *CategoryRepository) UpdateStatus(ctx context.Context, id uint64, status types.CategoryStatus) error {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return fmt.Errorf("missing tenant_id in context")
+	}
+
+	_, err := TenantDB(ctx).Model("product_categories").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Update(gdb.Map{"status": status})
+	if err != nil {
+		return fmt.Errorf("更新分类状态失败: %v", err)
+	}
+	return nil
+}
+
+// ApplyDeletion 在同一事务中转移商品、上移子分类并删除分类
+func (r *CategoryRepository) ApplyDeletion(ctx context.Context, deletion *types.CategoryDeletion) (int, error) {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return 0, fmt.Errorf("missing tenant_id in context")
+	}
+
+	reassigned := 0
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if deletion.ReassignTo != nil {
+			result, err := tx.Model("products").Ctx(ctx).
+				Where("tenant_id = ?", tenantID).
+				WhereIn("category_id", deletion.DeleteIDs).
+				Where("status != ?", types.ProductStatusDeleted).
+				Update(gdb.Map{
+					"category_id":   deletion.ReassignTo.ID,
+					"category_path": deletion.ReassignTo.Path,
+				})
+			if err != nil {
+				return err
+			}
+			affected, _ := result.RowsAffected()
+			reassigned = int(affected)
+		}
+
+		for _, move := range deletion.Moves {
+			_, err := tx.Model("product_categories").Ctx(ctx).
+				Where("id = ? AND tenant_id = ?", move.CategoryID, tenantID).
+				Update(gdb.Map{
+					"parent_id": move.ParentID,
+					"level":     move.Level,
+					"path":      move.Path,
+				})
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Model("product_categories").Ctx(ctx).
+			Where("tenant_id = ?", tenantID).
+			WhereIn("id", deletion.DeleteIDs).
+			Delete()
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("删除分类失败: %v", err)
+	}
+
+	return reassigned, nil
+}
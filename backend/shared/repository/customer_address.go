@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// ICustomerAddressRepository 客户收货地址仓储接口，所有操作限定在当前租户的指定客户
+type ICustomerAddressRepository interface {
+	Create(ctx context.Context, address *types.CustomerAddress) error
+	GetByID(ctx context.Context, customerID, addressID uint64) (*types.CustomerAddress, error)
+	ListByCustomer(ctx context.Context, customerID uint64) ([]types.CustomerAddress, error)
+	Update(ctx context.Context, address *types.CustomerAddress) error
+	SetDefault(ctx context.Context, customerID, addressID uint64) error
+	Delete(ctx context.Context, customerID, addressID uint64) error
+}
+
+// CustomerAddressRepository 客户收货地址仓储实现
+type CustomerAddressRepository struct {
+	*BaseRepository
+}
+
+// NewCustomerAddressRepository 创建客户收货地址仓储实例
+func NewCustomerAddressRepository() ICustomerAddressRepository {
+	return &CustomerAddressRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 保存收货地址，地址为默认地址时在同一事务中取消客户其他地址的默认标记
+func (r *CustomerAddressRepository) Create(ctx context.Context, address *types.CustomerAddress) error {
+	address.TenantID = r.GetTenantID(ctx)
+	now := gtime.Now().Time
+	address.CreatedAt = now
+	address.UpdatedAt = now
+
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if address.IsDefault {
+			if err := clearDefaultAddress(ctx, tx, address.TenantID, address.CustomerID); err != nil {
+				return err
+			}
+		}
+
+		id, err := tx.Model("customer_addresses").Ctx(ctx).Data(g.Map{
+			"tenant_id":      address.TenantID,
+			"customer_id":    address.CustomerID,
+			"recipient_name": address.RecipientName,
+			"phone":          address.Phone,
+			"province":       address.Province,
+			"city":           address.City,
+			"district":       address.District,
+			"detail_address": address.DetailAddress,
+			"postal_code":    address.PostalCode,
+			"is_default":     address.IsDefault,
+			"created_at":     address.CreatedAt,
+			"updated_at":     address.UpdatedAt,
+		}).InsertAndGetId()
+		if err != nil {
+			return fmt.Errorf("保存收货地址失败: %v", err)
+		}
+
+		address.ID = uint64(id)
+		return nil
+	})
+}
+
+// GetByID 获取客户的收货地址，不存在或不属于该客户时返回nil
+func (r *CustomerAddressRepository) GetByID(ctx context.Context, customerID, addressID uint64) (*types.CustomerAddress, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	var address *types.CustomerAddress
+	err := TenantDB(ctx).Model("customer_addresses").
+		Ctx(ctx).
+		Where("tenant_id = ? AND customer_id = ? AND id = ?", tenantID, customerID, addressID).
+		Scan(&address)
+	if err != nil {
+		return nil, fmt.Errorf("获取收货地址失败: %v", err)
+	}
+	return address, nil
+}
+
+// ListByCustomer 获取客户的全部收货地址，默认地址在前，其余按最近修改排序
+func (r *CustomerAddressRepository) ListByCustomer(ctx context.Context, customerID uint64) ([]types.CustomerAddress, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	var addresses []types.CustomerAddress
+	err := TenantDB(ctx).Model("customer_addresses").
+		Ctx(ctx).
+		Where("tenant_id = ? AND customer_id = ?", tenantID, customerID).
+		OrderDesc("is_default").
+		OrderDesc("updated_at").
+		OrderDesc("id").
+		Scan(&addresses)
+	if err != nil {
+		return nil, fmt.Errorf("获取收货地址列表失败: %v", err)
+	}
+	return addresses, nil
+}
+
+// Update 修改收货地址，地址为默认地址时在同一事务中取消客户其他地址的默认标记
+func (r *CustomerAddressRepository) Update(ctx context.Context, address *types.CustomerAddress) error {
+	tenantID := r.GetTenantID(ctx)
+	address.UpdatedAt = gtime.Now().Time
+
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if address.IsDefault {
+			if err := clearDefaultAddress(ctx, tx, tenantID, address.CustomerID); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Model("customer_addresses").Ctx(ctx).
+			Where("tenant_id = ? AND customer_id = ? AND id = ?", tenantID, address.CustomerID, address.ID).
+			Update(g.Map{
+				"recipient_name": address.RecipientName,
+				"phone":          address.Phone,
+				"province":       address.Province,
+				"city":           address.City,
+				"district":       address.District,
+				"detail_address": address.DetailAddress,
+				"postal_code":    address.PostalCode,
+				"is_default":     address.IsDefault,
+				"updated_at":     address.UpdatedAt,
+			})
+		if err != nil {
+			return fmt.Errorf("更新收货地址失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// SetDefault 将客户的指定地址设为默认地址，其他地址取消默认
+func (r *CustomerAddressRepository) SetDefault(ctx context.Context, customerID, addressID uint64) error {
+	tenantID := r.GetTenantID(ctx)
+
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if err := clearDefaultAddress(ctx, tx, tenantID, customerID); err != nil {
+			return err
+		}
+
+		_, err := tx.Model("customer_addresses").Ctx(ctx).
+			Where("tenant_id = ? AND customer_id = ? AND id = ?", tenantID, customerID, addressID).
+			Update(g.Map{"is_default": true})
+		if err != nil {
+			return fmt.Errorf("设置默认收货地址失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// Delete 删除客户的收货地址
+func (r *CustomerAddressRepository) Delete(ctx context.Context, customerID, addressID uint64) error {
+	tenantID := r.GetTenantID(ctx)
+
+	_, err := TenantDB(ctx).Model("customer_addresses").
+		Ctx(ctx).
+		Where("tenant_id = ? AND customer_id = ? AND id = ?", tenantID, customerID, addressID).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("删除收货地址失败: %v", err)
+	}
+	return nil
+}
+
+// clearDefaultAddress 取消客户全部地址的默认标记
+func clearDefaultAddress(ctx context.Context, tx gdb.TX, tenantID, customerID uint64) error {
+	_, err := tx.Model("customer_addresses").Ctx(ctx).
+		Where("tenant_id = ? AND customer_id = ? AND is_default = ?", tenantID, customerID, true).
+		Update(g.Map{"is_default": false})
+	if err != nil {
+		return fmt.Errorf("取消默认收货地址失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+)
+
+// ICustomerAnonymizationRepository 客户个人信息匿名化仓储接口
+type ICustomerAnonymizationRepository interface {
+	// 获取当前租户的用户及是否为客户账户，用户不存在时返回 nil
+	GetCustomer(ctx context.Context, userID uint64) (*types.User, bool, error)
+	// 在同一事务中替换用户及其地址簿、订单收货地址快照、订单备注、争议描述和操作原因中的个人信息，订单金额和数量保持不变；
+	// 执行结果写回 anonymization，用户已匿名化时返回 types.ErrCustomerAlreadyAnonymized
+	Anonymize(ctx context.Context, anonymization *types.CustomerAnonymization) error
+	// 列出当前租户最后登录和最后下单均早于 cutoff、且没有未结束争议的未匿名化客户
+	ListInactiveCustomers(ctx context.Context, cutoff time.Time, limit int) ([]types.User, error)
+}
+
+// CustomerAnonymizationRepository 客户个人信息匿名化仓储实现
+type CustomerAnonymizationRepository struct {
+	*BaseRepository
+}
+
+// NewCustomerAnonymizationRepository 创建客户个人信息匿名化仓储实例
+func NewCustomerAnonymizationRepository() ICustomerAnonymizationRepository {
+	return &CustomerAnonymizationRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// GetCustomer 获取当前租户的用户，并按是否分配客户角色且不属于商户判断是否为客户账户
+func (r *CustomerAnonymizationRepository) GetCustomer(ctx context.Context, userID uint64) (*types.User, bool, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	var user *types.User
+	err := TenantDB(ctx).Model("users").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", userID, tenantID).
+		Scan(&user)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, false, fmt.Errorf("获取用户失败: %v", err)
+	}
+	if user == nil {
+		return nil, false, nil
+	}
+	if user.MerchantID != nil {
+		return user, false, nil
+	}
+
+	count, err := TenantDB(ctx).Model("user_roles").
+		Ctx(ctx).
+		Where("user_id = ? AND tenant_id = ? AND role_type = ?", userID, tenantID, types.RoleCustomer).
+		Count()
+	if err != nil {
+		return nil, false, fmt.Errorf("获取用户角色失败: %v", err)
+	}
+	return user, count > 0, nil
+}
+
+// Anonymize 在同一事务中匿名化客户，订单只替换收货地址快照，金额和订单项不做修改
+func (r *CustomerAnonymizationRepository) Anonymize(ctx context.Context, anonymization *types.CustomerAnonymization) error {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return fmt.Errorf("missing tenant_id in context")
+	}
+	userID := anonymization.UserID
+
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		result, err := tx.Model("users").Ctx(ctx).
+			Where("id = ? AND tenant_id = ? AND anonymized_at IS NULL", userID, tenantID).
+			Update(gdb.Map{
+				"username":      anonymization.Username,
+				"email":         anonymization.Email,
+				"phone":         "",
+				"profile":       nil,
+				"password_hash": "",
+				"status":        types.UserStatusDeactivated,
+				"anonymized_at": anonymization.AnonymizedAt,
+				"updated_at":    anonymization.AnonymizedAt,
+			})
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return types.ErrCustomerAlreadyAnonymized
+		}
+
+		// 客户填写的订单备注
+		result, err = tx.Model("order_notes").Ctx(ctx).
+			Where("tenant_id = ? AND author_id = ?", tenantID, userID).
+			Update(gdb.Map{
+				"author_name": types.AnonymizedAuthorName,
+				"content":     types.AnonymizedText,
+			})
+		if err != nil {
+			return err
+		}
+		anonymization.NotesScrubbed, _ = result.RowsAffected()
+
+		// 客户提交的争议描述，商户的处理意见保留
+		result, err = tx.Model("order_disputes").Ctx(ctx).
+			Where("tenant_id = ? AND customer_id = ?", tenantID, userID).
+			Update(gdb.Map{"description": types.AnonymizedText})
+		if err != nil {
+			return err
+		}
+		anonymization.DisputesScrubbed, _ = result.RowsAffected()
+
+		// 客户取消订单等操作时填写的原因，状态流转记录保留
+		result, err = tx.Model("order_status_history").Ctx(ctx).
+			Where("tenant_id = ? AND operator_id = ? AND operator_type = ?", tenantID, userID, types.OrderStatusOperatorTypeCustomer).
+			Update(gdb.Map{"reason": types.AnonymizedText})
+		if err != nil {
+			return err
+		}
+		anonymization.HistoryScrubbed, _ = result.RowsAffected()
+
+		// 地址簿中的收货地址，省市区保留
+		result, err = tx.Model("customer_addresses").Ctx(ctx).
+			Where("tenant_id = ? AND customer_id = ?", tenantID, userID).
+			Update(gdb.Map{
+				"recipient_name": types.AnonymizedAuthorName,
+				"phone":          "",
+				"detail_address": types.AnonymizedText,
+				"postal_code":    "",
+			})
+		if err != nil {
+			return err
+		}
+		anonymization.AddressesScrubbed, _ = result.RowsAffected()
+
+		// 历史订单的收货地址快照，省市区保留用于地区统计
+		var snapshots []struct {
+			ID                  uint64 `orm:"id"`
+			ShippingAddressJSON string `orm:"shipping_address"`
+		}
+		err = tx.Model("orders").Ctx(ctx).
+			Fields("id, shipping_address").
+			Where("tenant_id = ? AND customer_id = ? AND shipping_address IS NOT NULL", tenantID, userID).
+			Scan(&snapshots)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		for _, snapshot := range snapshots {
+			if snapshot.ShippingAddressJSON == "" || snapshot.ShippingAddressJSON == "null" {
+				continue
+			}
+			var address types.ShippingAddress
+			if err := json.Unmarshal([]byte(snapshot.ShippingAddressJSON), &address); err != nil {
+				return fmt.Errorf("反序列化订单 %d 收货地址失败: %v", snapshot.ID, err)
+			}
+			address.Anonymize()
+			addressJSON, err := json.Marshal(address)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Model("orders").Ctx(ctx).
+				Where("id = ? AND tenant_id = ?", snapshot.ID, tenantID).
+				Update(gdb.Map{"shipping_address": string(addressJSON)})
+			if err != nil {
+				return err
+			}
+			anonymization.OrdersScrubbed++
+		}
+
+		var retained struct {
+			Orders int64   `json:"orders"`
+			Amount float64 `json:"amount"`
+		}
+		err = tx.Model("orders").Ctx(ctx).
+			Fields("COUNT(*) AS orders, COALESCE(SUM(total_amount), 0) AS amount").
+			Where("tenant_id = ? AND customer_id = ?", tenantID, userID).
+			Scan(&retained)
+		if err != nil {
+			return err
+		}
+		anonymization.RetainedOrders = retained.Orders
+		anonymization.RetainedAmount = retained.Amount
+		return nil
+	})
+	if err == types.ErrCustomerAlreadyAnonymized {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("匿名化客户失败: %v", err)
+	}
+	return nil
+}
+
+// ListInactiveCustomers 列出超过保留期未活跃的客户，按用户ID排序
+func (r *CustomerAnonymizationRepository) ListInactiveCustomers(ctx context.Context, cutoff time.Time, limit int) ([]types.User, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	var users []types.User
+	err := TenantDB(ctx).Model("users u").
+		Ctx(ctx).
+		InnerJoin("user_roles ur", "ur.user_id = u.id AND ur.tenant_id = u.tenant_id").
+		Fields("u.*").
+		Where("u.tenant_id = ? AND u.merchant_id IS NULL AND u.anonymized_at IS NULL", tenantID).
+		Where("ur.role_type = ?", types.RoleCustomer).
+		Where("COALESCE(u.last_login_at, u.created_at) < ?", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM orders o WHERE o.tenant_id = u.tenant_id AND o.customer_id = u.id AND o.created_at >= ?)", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM order_disputes d WHERE d.tenant_id = u.tenant_id AND d.customer_id = u.id AND d.status IN (?))",
+			[]types.OrderDisputeStatus{types.OrderDisputeStatusOpen, types.OrderDisputeStatusInvestigating}).
+		Group("u.id").
+		OrderAsc("u.id").
+		Limit(limit).
+		Scan(&users)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询待匿名化客户失败: %v", err)
+	}
+	return users, nil
+}
@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// anonymizationRow 内存表中的一行
+type anonymizationRow map[string]driver.Value
+
+// anonymizationStore 内存数据库：按表保存行，只支持 Anonymize 用到的等值和 IS NULL 条件，
+// 记录事务外执行的写入
+type anonymizationStore struct {
+	mu            sync.Mutex
+	tables        map[string][]anonymizationRow
+	writesOutside int
+}
+
+var testAnonymizationStore = &anonymizationStore{}
+
+// anonymizationTableFields 各内存表的字段
+var anonymizationTableFields = map[string][]string{
+	"users":                {"id", "tenant_id", "username", "email", "phone", "profile", "password_hash", "status", "anonymized_at", "updated_at"},
+	"order_notes":          {"id", "tenant_id", "author_id", "author_name", "content"},
+	"order_disputes":       {"id", "tenant_id", "customer_id", "description"},
+	"order_status_history": {"id", "tenant_id", "operator_id", "operator_type", "reason"},
+	"orders":               {"id", "tenant_id", "customer_id", "total_amount", "shipping_address"},
+	"customer_addresses":   {"id", "tenant_id", "customer_id", "recipient_name", "phone", "detail_address", "postal_code"},
+}
+
+func (s *anonymizationStore) reset(tables map[string][]anonymizationRow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables, s.writesOutside = tables, 0
+}
+
+// row 返回表中指定ID的行
+func (s *anonymizationStore) row(table string, id int64) anonymizationRow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, row := range s.tables[table] {
+		if row["id"] == id {
+			return row
+		}
+	}
+	return nil
+}
+
+var anonymizationConditionPattern = regexp.MustCompile(`^(\w+) (= \?|IS NULL|IS NOT NULL)$`)
+
+// match 按 WHERE 条件筛选行，args 为 WHERE 条件的参数
+func (s *anonymizationStore) match(table, where string, args []driver.NamedValue) ([]anonymizationRow, error) {
+	where = strings.NewReplacer("(", "", ")", "", "`", "").Replace(where)
+	var conditions []string
+	if where != "" {
+		conditions = strings.Split(where, " AND ")
+	}
+
+	var matched []anonymizationRow
+	for _, row := range s.tables[table] {
+		ok, argIndex := true, 0
+		for _, condition := range conditions {
+			parts := anonymizationConditionPattern.FindStringSubmatch(strings.TrimSpace(condition))
+			if parts == nil {
+				return nil, fmt.Errorf("unsupported condition: %s", condition)
+			}
+			switch parts[2] {
+			case "= ?":
+				ok = ok && fmt.Sprint(row[parts[1]]) == fmt.Sprint(args[argIndex].Value)
+				argIndex++
+			case "IS NULL":
+				ok = ok && row[parts[1]] == nil
+			case "IS NOT NULL":
+				ok = ok && row[parts[1]] != nil
+			}
+		}
+		if ok {
+			matched = append(matched, row)
+		}
+	}
+	return matched, nil
+}
+
+// anonymizationConn 内存数据库连接
+type anonymizationConn struct {
+	inTx bool
+}
+
+func (c *anonymizationConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *anonymizationConn) Close() error { return nil }
+
+func (c *anonymizationConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	return c, nil
+}
+
+func (c *anonymizationConn) Commit() error {
+	c.inTx = false
+	return nil
+}
+
+func (c *anonymizationConn) Rollback() error {
+	c.inTx = false
+	return nil
+}
+
+func (c *anonymizationConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	s := testAnonymizationStore
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !strings.HasPrefix(query, "UPDATE ") {
+		return nil, fmt.Errorf("unexpected statement: %s", query)
+	}
+	if !c.inTx {
+		s.writesOutside++
+	}
+
+	// UPDATE `table` SET `a`=?,`b`=NULL WHERE ...
+	table := strings.Trim(strings.Fields(query)[1], "`")
+	setClause := query[strings.Index(query, " SET ")+5 : strings.Index(query, " WHERE ")]
+	values := map[string]driver.Value{}
+	argIndex := 0
+	for _, assignment := range strings.Split(setClause, ",") {
+		column, value, _ := strings.Cut(assignment, "=")
+		column = strings.Trim(column, "`")
+		if value == "?" {
+			values[column] = args[argIndex].Value
+			argIndex++
+		} else {
+			values[column] = nil
+		}
+	}
+
+	rows, err := s.match(table, query[strings.Index(query, " WHERE ")+7:], args[argIndex:])
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		for column, value := range values {
+			row[column] = value
+		}
+	}
+	return driver.RowsAffected(len(rows)), nil
+}
+
+func (c *anonymizationConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	s := testAnonymizationStore
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// SELECT fields FROM `table` WHERE ... [LIMIT 1]
+	query = strings.TrimSuffix(query, " LIMIT 1")
+	table := strings.Trim(strings.Fields(query[strings.Index(query, " FROM ")+6:])[0], "`")
+	rows, err := s.match(table, query[strings.Index(query, " WHERE ")+7:], args)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Contains(query, "COUNT(*)") {
+		var amount float64
+		for _, row := range rows {
+			amount += row["total_amount"].(float64)
+		}
+		return &anonymizationRows{columns: []string{"orders", "amount"}, rows: [][]driver.Value{{int64(len(rows)), amount}}}, nil
+	}
+
+	fields := strings.Split(strings.ReplaceAll(query[len("SELECT "):strings.Index(query, " FROM ")], "`", ""), ",")
+	result := &anonymizationRows{}
+	for _, field := range fields {
+		result.columns = append(result.columns, strings.TrimSpace(field))
+	}
+	for _, row := range rows {
+		values := make([]driver.Value, len(result.columns))
+		for i, column := range result.columns {
+			values[i] = row[column]
+		}
+		result.rows = append(result.rows, values)
+	}
+	return result, nil
+}
+
+// anonymizationRows 查询结果
+type anonymizationRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *anonymizationRows) Columns() []string { return r.columns }
+
+func (r *anonymizationRows) Close() error { return nil }
+
+func (r *anonymizationRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type anonymizationSQLDriver struct{}
+
+func (anonymizationSQLDriver) Open(name string) (driver.Conn, error) {
+	return &anonymizationConn{}, nil
+}
+
+// anonymizationDBDriver gdb 驱动，连接到内存数据库
+type anonymizationDBDriver struct {
+	*gdb.Core
+}
+
+func (d *anonymizationDBDriver) New(core *gdb.Core, node *gdb.ConfigNode) (gdb.DB, error) {
+	return &anonymizationDBDriver{Core: core}, nil
+}
+
+func (d *anonymizationDBDriver) Open(config *gdb.ConfigNode) (*sql.DB, error) {
+	return sql.Open("customer_anonymization", config.Name)
+}
+
+func (d *anonymizationDBDriver) GetChars() (string, string) { return "`", "`" }
+
+func (d *anonymizationDBDriver) Tables(ctx context.Context, schema ...string) ([]string, error) {
+	tables := make([]string, 0, len(anonymizationTableFields))
+	for table := range anonymizationTableFields {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+func (d *anonymizationDBDriver) TableFields(ctx context.Context, table string, schema ...string) (map[string]*gdb.TableField, error) {
+	fields := map[string]*gdb.TableField{}
+	for i, name := range anonymizationTableFields[table] {
+		fields[name] = &gdb.TableField{Index: i, Name: name, Type: "varchar(255)"}
+	}
+	return fields, nil
+}
+
+var registerAnonymizationDriverOnce sync.Once
+
+// setupAnonymizationDatasource 注册内存数据库所在的数据库分组，并将租户 1 路由到该分组
+func setupAnonymizationDatasource(tables map[string][]anonymizationRow) {
+	registerAnonymizationDriverOnce.Do(func() {
+		sql.Register("customer_anonymization", anonymizationSQLDriver{})
+		if err := gdb.Register("customer_anonymization", &anonymizationDBDriver{}); err != nil {
+			panic(err)
+		}
+		if err := gdb.AddConfigNode("customer_anonymization", gdb.ConfigNode{Type: "customer_anonymization", Name: "customer_anonymization"}); err != nil {
+			panic(err)
+		}
+	})
+	SetTenantDatasourceConfig(&TenantDatasourceConfig{Tenants: map[uint64]string{1: "customer_anonymization"}})
+	testAnonymizationStore.reset(tables)
+}
+
+// shippingAddressJSON 订单收货地址快照
+func shippingAddressJSON(recipient, phone, detail string) string {
+	data, _ := json.Marshal(types.ShippingAddress{
+		AddressID:     1,
+		RecipientName: recipient,
+		Phone:         phone,
+		Province:      "浙江省",
+		City:          "杭州市",
+		District:      "西湖区",
+		DetailAddress: detail,
+		PostalCode:    "310000",
+	})
+	return string(data)
+}
+
+// decodeShippingAddress 解析内存订单表中的收货地址快照
+func decodeShippingAddress(row anonymizationRow) types.ShippingAddress {
+	var address types.ShippingAddress
+	So(json.Unmarshal([]byte(fmt.Sprint(row["shipping_address"])), &address), ShouldBeNil)
+	return address
+}
+
+func TestCustomerAnonymizationRepository_Anonymize(t *testing.T) {
+	Convey("客户匿名化仓储测试", t, func() {
+		// 租户 1 的客户 7 和另一位客户 8
+		setupAnonymizationDatasource(map[string][]anonymizationRow{
+			"users": {
+				{"id": int64(7), "tenant_id": int64(1), "username": "alice", "email": "alice@example.com", "phone": "13800000000", "anonymized_at": nil},
+				{"id": int64(8), "tenant_id": int64(1), "username": "bob", "email": "bob@example.com", "phone": "13900000000", "anonymized_at": nil},
+			},
+			"orders": {
+				{"id": int64(1), "tenant_id": int64(1), "customer_id": int64(7), "total_amount": 120.0, "shipping_address": shippingAddressJSON("张三", "13800000000", "文三路 1 号")},
+				{"id": int64(2), "tenant_id": int64(1), "customer_id": int64(7), "total_amount": 80.0, "shipping_address": nil},
+				{"id": int64(3), "tenant_id": int64(1), "customer_id": int64(8), "total_amount": 50.0, "shipping_address": shippingAddressJSON("李四", "13900000000", "文二路 2 号")},
+			},
+			"customer_addresses": {
+				{"id": int64(1), "tenant_id": int64(1), "customer_id": int64(7), "recipient_name": "张三", "phone": "13800000000", "detail_address": "文三路 1 号", "postal_code": "310000"},
+				{"id": int64(2), "tenant_id": int64(1), "customer_id": int64(8), "recipient_name": "李四", "phone": "13900000000", "detail_address": "文二路 2 号", "postal_code": "310000"},
+			},
+		})
+		defer SetTenantDatasourceConfig(nil)
+
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		repo := &CustomerAnonymizationRepository{BaseRepository: &BaseRepository{}}
+		anonymization := types.NewCustomerAnonymization(&types.User{ID: 7, TenantID: 1}, "token7", time.Now())
+
+		Convey("在同一事务中替换用户、地址簿和订单收货地址快照中的个人信息，保留订单金额", func() {
+			So(repo.Anonymize(ctx, anonymization), ShouldBeNil)
+			So(testAnonymizationStore.writesOutside, ShouldEqual, 0)
+
+			user := testAnonymizationStore.row("users", 7)
+			So(user["username"], ShouldEqual, anonymization.Username)
+			So(user["email"], ShouldEqual, anonymization.Email)
+			So(user["phone"], ShouldEqual, "")
+
+			So(anonymization.AddressesScrubbed, ShouldEqual, 1)
+			saved := testAnonymizationStore.row("customer_addresses", 1)
+			So(saved["recipient_name"], ShouldEqual, types.AnonymizedAuthorName)
+			So(saved["phone"], ShouldEqual, "")
+			So(saved["detail_address"], ShouldEqual, types.AnonymizedText)
+			So(saved["postal_code"], ShouldEqual, "")
+
+			So(anonymization.OrdersScrubbed, ShouldEqual, 1)
+			address := decodeShippingAddress(testAnonymizationStore.row("orders", 1))
+			So(address.RecipientName, ShouldEqual, types.AnonymizedAuthorName)
+			So(address.Phone, ShouldBeEmpty)
+			So(address.DetailAddress, ShouldEqual, types.AnonymizedText)
+			So(address.PostalCode, ShouldBeEmpty)
+			So(address.Province+address.City+address.District, ShouldEqual, "浙江省杭州市西湖区")
+			So(testAnonymizationStore.row("orders", 2)["shipping_address"], ShouldBeNil)
+
+			So(anonymization.RetainedOrders, ShouldEqual, 2)
+			So(anonymization.RetainedAmount, ShouldAlmostEqual, 200)
+			So(testAnonymizationStore.row("orders", 1)["total_amount"], ShouldEqual, 120.0)
+		})
+
+		Convey("不修改其他客户的信息", func() {
+			So(repo.Anonymize(ctx, anonymization), ShouldBeNil)
+
+			So(testAnonymizationStore.row("users", 8)["username"], ShouldEqual, "bob")
+			So(testAnonymizationStore.row("customer_addresses", 2)["recipient_name"], ShouldEqual, "李四")
+			address := decodeShippingAddress(testAnonymizationStore.row("orders", 3))
+			So(address.RecipientName, ShouldEqual, "李四")
+			So(address.Phone, ShouldEqual, "13900000000")
+		})
+
+		Convey("已匿名化的客户不能重复匿名化", func() {
+			So(repo.Anonymize(ctx, anonymization), ShouldBeNil)
+			err := repo.Anonymize(ctx, anonymization)
+			So(err, ShouldEqual, types.ErrCustomerAlreadyAnonymized)
+		})
+	})
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// CustomerSegmentRepository 客户分群数据访问层
+type CustomerSegmentRepository struct {
+	*BaseRepository
+}
+
+// NewCustomerSegmentRepository 创建客户分群仓库实例
+func NewCustomerSegmentRepository() *CustomerSegmentRepository {
+	return &CustomerSegmentRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// ListSegmentMembers 按导出条件分批获取分群内的客户及其联系方式，按客户ID升序返回 afterID 之后的客户。
+// 分群按统计周期内已支付或已完成的订单数计算，与客户分析报表一致；退订和匿名化标记一并返回，由调用方排除
+func (r *CustomerSegmentRepository) ListSegmentMembers(ctx context.Context, query *types.CustomerSegmentExportQuery, afterID uint64, limit int) ([]types.CustomerSegmentExportRow, error) {
+	criteria, ok := query.Segment.Criteria()
+	if !ok {
+		return nil, types.ErrInvalidCustomerSegment
+	}
+	tenantID := r.GetTenantID(ctx)
+
+	having := "HAVING COUNT(o.id) >= ?"
+	havingArgs := []interface{}{criteria.MinOrders}
+	if criteria.MaxOrders > 0 {
+		having += " AND COUNT(o.id) <= ?"
+		havingArgs = append(havingArgs, criteria.MaxOrders)
+	}
+
+	sql := fmt.Sprintf(`
+		SELECT
+			u.id as customer_id,
+			u.username,
+			u.email,
+			COALESCE(u.phone, '') as phone,
+			stats.order_count,
+			stats.total_spent,
+			stats.last_order_at,
+			u.marketing_opt_out,
+			u.anonymized_at IS NOT NULL as anonymized
+		FROM (
+			SELECT
+				o.customer_id,
+				COUNT(o.id) as order_count,
+				SUM(o.total_amount) as total_spent,
+				MAX(o.created_at) as last_order_at
+			FROM orders o
+			WHERE o.tenant_id = ? AND o.customer_id > ?
+				AND o.created_at >= ? AND o.created_at < ?
+				AND o.status IN ('completed', 'paid')
+			GROUP BY o.customer_id
+			%s
+		) stats
+		INNER JOIN users u ON u.id = stats.customer_id AND u.tenant_id = ?
+		ORDER BY stats.customer_id
+		LIMIT ?
+	`, having)
+
+	args := []interface{}{tenantID, afterID, query.StartDate, query.EndDate}
+	args = append(args, havingArgs...)
+	args = append(args, tenantID, limit)
+
+	var rows []types.CustomerSegmentExportRow
+	if err := r.HeavyRaw(ctx, sql, args...).Scan(&rows); err != nil {
+		return nil, fmt.Errorf("获取分群客户失败: %v", err)
+	}
+	return rows, nil
+}
@@ -7,9 +7,7 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/gogf/gf/v2/database/gdb"
 	"github.com/gogf/gf/v2/errors/gerror"
-	"github.com/gogf/gf/v2/frame/g"
 
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
@@ -25,8 +23,11 @@ type DashboardRepository interface {
 	// 获取权益使用历史趋势
 	GetRightsUsageTrend(ctx context.Context, tenantID, merchantID uint64, days int) ([]types.RightsUsagePoint, error)
 	
-	// 获取待处理事项
-	GetPendingTasks(ctx context.Context, tenantID, merchantID uint64) ([]types.PendingTask, error)
+	// 获取待处理事项统计数据，由服务层按触发条件生成待处理事项
+	GetPendingTaskStats(ctx context.Context, tenantID, merchantID uint64, criteria *PendingTaskCriteria) (*PendingTaskStats, error)
+	
+	// 获取商户最近订单，statuses 为空时不过滤状态
+	GetRecentOrders(ctx context.Context, tenantID, merchantID uint64, limit int, statuses []types.OrderStatusInt) ([]types.OrderSummary, error)
 	
 	// 获取系统通知和公告
 	GetMerchantNotifications(ctx context.Context, tenantID, merchantID uint64, limit int) ([]types.Notification, []types.Announcement, error)
@@ -43,14 +44,12 @@ type DashboardRepository interface {
 // dashboardRepositoryImpl 仪表板数据访问实现
 type dashboardRepositoryImpl struct {
 	*BaseRepository
-	db gdb.DB
 }
 
 // NewDashboardRepository 创建仪表板数据访问实例
 func NewDashboardRepository() DashboardRepository {
 	return &dashboardRepositoryImpl{
 		BaseRepository: NewBaseRepository(),
-		db:             g.DB(),
 	}
 }
 
@@ -61,6 +60,26 @@ type MerchantBusinessStats struct {
 	TotalCustomers int     `json:"total_customers"`
 }
 
+// PendingTaskCriteria 待处理事项统计条件
+type PendingTaskCriteria struct {
+	OrderStatuses        []types.OrderStatusInt // 视为待处理的订单状态
+	StaleBefore          time.Time              // 早于该时间未更新的商品视为需要更新
+	TrackOrderSLA        bool                   // 统计超过状态处理时限的订单
+	TrackRightsThreshold bool                   // 获取权益余额预警级别
+}
+
+// PendingTaskStats 待处理事项统计 (用于服务层生成待处理事项)
+type PendingTaskStats struct {
+	PendingOrders             int
+	OldestPendingOrderAt      *time.Time
+	PendingVerifications      int
+	OldestPendingVerification *time.Time
+	RightsBalance             *types.RightsBalance
+	ProductsNeedingUpdate     int
+	SLABreachedOrders         int
+	RightsThreshold           *types.MerchantRightsThreshold // 最近一次阈值检查的权益余额预警级别
+}
+
 // GetMerchantDashboardData 获取商户仪表板数据
 func (r *dashboardRepositoryImpl) GetMerchantDashboardData(ctx context.Context, tenantID, merchantID uint64, period types.TimePeriod) (*types.MerchantDashboardData, error) {
 	// 获取商户权益余额
@@ -87,18 +106,19 @@ func (r *dashboardRepositoryImpl) GetMerchantDashboardData(ctx context.Context,
 		return nil, gerror.Wrap(err, "获取权益预警失败")
 	}
 
-	// 获取待处理事项
-	pendingTasks, err := r.GetPendingTasks(ctx, tenantID, merchantID)
-	if err != nil {
-		return nil, gerror.Wrap(err, "获取待处理事项失败")
-	}
-
 	// 获取通知和公告
 	notifications, announcements, err := r.GetMerchantNotifications(ctx, tenantID, merchantID, 10)
 	if err != nil {
 		return nil, gerror.Wrap(err, "获取通知公告失败")
 	}
 
+	// 普通订单可使用的权益（扣除保留权益）
+	var consumableRights *float64
+	if rightsBalance != nil {
+		consumable := rightsBalance.GetConsumableBalance()
+		consumableRights = &consumable
+	}
+
 	// 计算预测耗尽天数
 	var predictedDepletionDays *int
 	if rightsBalance != nil && rightsBalance.AvailableBalance > 0 {
@@ -116,12 +136,12 @@ func (r *dashboardRepositoryImpl) GetMerchantDashboardData(ctx context.Context,
 		TotalOrders:            businessStats.TotalOrders,
 		TotalCustomers:         businessStats.TotalCustomers,
 		RightsBalance:          rightsBalance,
+		ConsumableRights:       consumableRights,
 		RightsUsageTrend:       usageTrend,
 		RightsAlerts:           rightsAlerts,
 		PredictedDepletionDays: predictedDepletionDays,
 		PendingOrders:          r.countPendingOrders(ctx, tenantID, merchantID),
 		PendingVerifications:   r.countPendingVerifications(ctx, tenantID, merchantID),
-		PendingTasks:           pendingTasks,
 		Announcements:          announcements,
 		Notifications:          notifications,
 		LastUpdated:            time.Now(),
@@ -157,7 +177,7 @@ func (r *dashboardRepositoryImpl) GetMerchantBusinessStats(ctx context.Context,
 	`
 
 	stats := &MerchantBusinessStats{}
-	err := r.db.Ctx(ctx).Raw(query, tenantID, merchantID, startTime).Scan(stats)
+	err := TenantDBFor(ctx, tenantID).Ctx(ctx).Raw(query, tenantID, merchantID, startTime).Scan(stats)
 	if err != nil {
 		return nil, gerror.Wrap(err, "查询业务统计失败")
 	}
@@ -183,7 +203,7 @@ func (r *dashboardRepositoryImpl) GetRightsUsageTrend(ctx context.Context, tenan
 		ORDER BY stat_date ASC
 	`
 
-	results, err := r.db.GetAll(ctx, query, tenantID, merchantID, startDate)
+	results, err := TenantDBFor(ctx, tenantID).GetAll(ctx, query, tenantID, merchantID, startDate)
 	if err != nil {
 		return nil, gerror.Wrap(err, "查询权益趋势失败")
 	}
@@ -202,54 +222,107 @@ func (r *dashboardRepositoryImpl) GetRightsUsageTrend(ctx context.Context, tenan
 	return trends, nil
 }
 
-// GetPendingTasks 获取待处理事项
-func (r *dashboardRepositoryImpl) GetPendingTasks(ctx context.Context, tenantID, merchantID uint64) ([]types.PendingTask, error) {
-	var tasks []types.PendingTask
+// GetPendingTaskStats 获取待处理事项统计数据
+func (r *dashboardRepositoryImpl) GetPendingTaskStats(ctx context.Context, tenantID, merchantID uint64, criteria *PendingTaskCriteria) (*PendingTaskStats, error) {
+	stats := &PendingTaskStats{}
 
 	// 待处理订单
-	pendingOrders := r.countPendingOrders(ctx, tenantID, merchantID)
-	if pendingOrders > 0 {
-		tasks = append(tasks, types.PendingTask{
-			ID:          "pending_orders",
-			Type:        types.TaskTypeOrderProcessing,
-			Description: fmt.Sprintf("有 %d 个订单待处理", pendingOrders),
-			Priority:    types.PriorityNormal,
-			Count:       pendingOrders,
-		})
+	if len(criteria.OrderStatuses) > 0 {
+		record, err := TenantDBFor(ctx, tenantID).Model("orders").Ctx(ctx).
+			Fields("COUNT(*) AS count, MIN(created_at) AS oldest").
+			Where("tenant_id = ? AND merchant_id = ?", tenantID, merchantID).
+			WhereIn("status", criteria.OrderStatuses).
+			One()
+		if err != nil {
+			return nil, gerror.Wrap(err, "统计待处理订单失败")
+		}
+		stats.PendingOrders = record["count"].Int()
+		if !record["oldest"].IsNil() {
+			oldest := record["oldest"].Time()
+			stats.OldestPendingOrderAt = &oldest
+		}
 	}
 
 	// 待核销订单
-	pendingVerifications := r.countPendingVerifications(ctx, tenantID, merchantID)
-	if pendingVerifications > 0 {
-		tasks = append(tasks, types.PendingTask{
-			ID:          "pending_verifications",
-			Type:        types.TaskTypeVerificationPending,
-			Description: fmt.Sprintf("有 %d 个订单待核销", pendingVerifications),
-			Priority:    types.PriorityHigh,
-			Count:       pendingVerifications,
-		})
+	record, err := TenantDBFor(ctx, tenantID).Model("orders").Ctx(ctx).
+		Fields("COUNT(*) AS count, MIN(created_at) AS oldest").
+		Where("tenant_id = ? AND merchant_id = ? AND status = ?", tenantID, merchantID, types.OrderStatusIntPaid).
+		Where("verification_info IS NULL OR JSON_EXTRACT(verification_info, '$.verified_at') IS NULL").
+		One()
+	if err != nil {
+		return nil, gerror.Wrap(err, "统计待核销订单失败")
+	}
+	stats.PendingVerifications = record["count"].Int()
+	if !record["oldest"].IsNil() {
+		oldest := record["oldest"].Time()
+		stats.OldestPendingVerification = &oldest
+	}
+
+	// 权益余额
+	stats.RightsBalance, err = r.getMerchantRightsBalance(ctx, tenantID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 需要更新的商品：库存低于预警值或长期未更新
+	count, err := TenantDBFor(ctx, tenantID).Model("products").Ctx(ctx).
+		Where("tenant_id = ? AND merchant_id = ?", tenantID, merchantID).
+		Where("status != ?", types.ProductStatusDeleted).
+		Where("(JSON_EXTRACT(inventory_info, '$.track_inventory') = true AND "+
+			"JSON_EXTRACT(inventory_info, '$.stock_quantity') - JSON_EXTRACT(inventory_info, '$.reserved_quantity') <= COALESCE(JSON_EXTRACT(inventory_info, '$.low_stock_threshold'), 0)) "+
+			"OR updated_at < ?", criteria.StaleBefore).
+		Count()
+	if err != nil {
+		return nil, gerror.Wrap(err, "统计需更新商品失败")
 	}
+	stats.ProductsNeedingUpdate = count
 
-	// 权益余额预警
-	rightsBalance, _ := r.getMerchantRightsBalance(ctx, tenantID, merchantID)
-	if rightsBalance != nil && rightsBalance.WarningThreshold != nil && 
-	   rightsBalance.AvailableBalance < *rightsBalance.WarningThreshold {
-		priority := types.PriorityHigh
-		if rightsBalance.CriticalThreshold != nil && 
-		   rightsBalance.AvailableBalance < *rightsBalance.CriticalThreshold {
-			priority = types.PriorityUrgent
+	// 处理超时订单：按商户有效的处理时限配置统计
+	if criteria.TrackOrderSLA {
+		config, err := getEffectiveOrderSLAConfig(ctx, tenantID, merchantID)
+		if err != nil {
+			return nil, err
 		}
-		
-		tasks = append(tasks, types.PendingTask{
-			ID:          "low_balance_warning",
-			Type:        types.TaskTypeLowBalanceWarning,
-			Description: fmt.Sprintf("权益余额不足，当前可用: %.2f", rightsBalance.AvailableBalance),
-			Priority:    priority,
-			Count:       1,
+		entries, err := listOrderSLABreachEntries(ctx, &OrderSLAEntryFilter{
+			TenantID:   tenantID,
+			MerchantID: &merchantID,
+			Limits:     config.Limits(),
+			Now:        time.Now(),
 		})
+		if err != nil {
+			return nil, err
+		}
+		stats.SLABreachedOrders = len(entries)
+	}
+
+	// 权益余额预警级别：由订单服务的阈值检查任务记录
+	if criteria.TrackRightsThreshold {
+		stats.RightsThreshold, err = getMerchantRightsThreshold(ctx, tenantID, merchantID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return stats, nil
+}
+
+// GetRecentOrders 获取商户最近订单
+func (r *dashboardRepositoryImpl) GetRecentOrders(ctx context.Context, tenantID, merchantID uint64, limit int, statuses []types.OrderStatusInt) ([]types.OrderSummary, error) {
+	query := TenantDBFor(ctx, tenantID).Model("orders o").Ctx(ctx).
+		LeftJoin("users u", "o.customer_id = u.id AND u.tenant_id = o.tenant_id").
+		Fields("o.id, o.order_number, o.status, o.total_amount, o.created_at, o.updated_at, " +
+			"JSON_LENGTH(o.items) as item_count, u.username as customer_name").
+		Where("o.tenant_id = ? AND o.merchant_id = ?", tenantID, merchantID)
+	if len(statuses) > 0 {
+		query = query.WhereIn("o.status", statuses)
 	}
 
-	return tasks, nil
+	var summaries []types.OrderSummary
+	if err := query.OrderDesc("o.created_at").Limit(limit).Scan(&summaries); err != nil {
+		return nil, gerror.Wrap(err, "查询最近订单失败")
+	}
+
+	return summaries, nil
 }
 
 // GetMerchantNotifications 获取系统通知和公告
@@ -272,7 +345,7 @@ func (r *dashboardRepositoryImpl) GetMerchantNotifications(ctx context.Context,
 		LIMIT ?
 	`
 
-	results, err := r.db.GetAll(ctx, announcementQuery, merchantID, tenantID, fmt.Sprintf(`"%d"`, merchantID), limit)
+	results, err := TenantDBFor(ctx, tenantID).GetAll(ctx, announcementQuery, merchantID, tenantID, fmt.Sprintf(`"%d"`, merchantID), limit)
 	if err != nil {
 		return nil, nil, gerror.Wrap(err, "查询公告失败")
 	}
@@ -307,7 +380,7 @@ func (r *dashboardRepositoryImpl) GetDashboardConfig(ctx context.Context, tenant
 		WHERE tenant_id = ? AND merchant_id = ?
 	`
 
-	result, err := r.db.GetOne(ctx, query, tenantID, merchantID)
+	result, err := TenantDBFor(ctx, tenantID).GetOne(ctx, query, tenantID, merchantID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// 返回默认配置
@@ -364,7 +437,7 @@ func (r *dashboardRepositoryImpl) SaveDashboardConfig(ctx context.Context, tenan
 		updated_at = NOW()
 	`
 
-	_, err := r.db.Exec(ctx, query, tenantID, merchantID, string(layoutConfigJSON), 
+	_, err := TenantDBFor(ctx, tenantID).Exec(ctx, query, tenantID, merchantID, string(layoutConfigJSON), 
 		string(preferencesJSON), config.RefreshInterval, string(mobileLayoutJSON))
 	if err != nil {
 		return gerror.Wrap(err, "保存仪表板配置失败")
@@ -386,7 +459,7 @@ func (r *dashboardRepositoryImpl) MarkAnnouncementAsRead(ctx context.Context, te
 		ON DUPLICATE KEY UPDATE read_at = NOW()
 	`
 
-	_, err := r.db.Exec(ctx, query, tenantID, announcementID, merchantID)
+	_, err := TenantDBFor(ctx, tenantID).Exec(ctx, query, tenantID, announcementID, merchantID)
 	if err != nil {
 		return gerror.Wrap(err, "标记公告已读失败")
 	}
@@ -398,40 +471,23 @@ func (r *dashboardRepositoryImpl) MarkAnnouncementAsRead(ctx context.Context, te
 
 // getMerchantRightsBalance 获取商户权益余额
 func (r *dashboardRepositoryImpl) getMerchantRightsBalance(ctx context.Context, tenantID, merchantID uint64) (*types.RightsBalance, error) {
-	query := `
-		SELECT total_balance, used_balance, frozen_balance, available_balance,
-			   last_updated, warning_threshold, critical_threshold, trend_coefficient
-		FROM merchants 
-		WHERE tenant_id = ? AND id = ?
-	`
-
-	result, err := r.db.GetOne(ctx, query, tenantID, merchantID)
+	value, err := TenantDBFor(ctx, tenantID).Model("merchants").Ctx(ctx).
+		Fields("rights_balance").
+		Where("tenant_id = ? AND id = ?", tenantID, merchantID).
+		Value()
 	if err != nil {
 		return nil, gerror.Wrap(err, "查询权益余额失败")
 	}
-
-	balance := &types.RightsBalance{
-		TotalBalance:      result["total_balance"].Float64(),
-		UsedBalance:       result["used_balance"].Float64(),
-		FrozenBalance:     result["frozen_balance"].Float64(),
-		AvailableBalance:  result["available_balance"].Float64(),
-		LastUpdated:       result["last_updated"].Time(),
+	if value.IsNil() || value.IsEmpty() {
+		return nil, nil
 	}
 
-	if !result["warning_threshold"].IsNil() {
-		threshold := result["warning_threshold"].Float64()
-		balance.WarningThreshold = &threshold
-	}
-	if !result["critical_threshold"].IsNil() {
-		threshold := result["critical_threshold"].Float64()
-		balance.CriticalThreshold = &threshold
-	}
-	if !result["trend_coefficient"].IsNil() {
-		coefficient := result["trend_coefficient"].Float64()
-		balance.TrendCoefficient = &coefficient
+	var balance types.RightsBalance
+	if err := json.Unmarshal(value.Bytes(), &balance); err != nil {
+		return nil, gerror.Wrap(err, "解析权益余额失败")
 	}
 
-	return balance, nil
+	return &balance, nil
 }
 
 // getRightsAlerts 获取权益预警
@@ -445,7 +501,7 @@ func (r *dashboardRepositoryImpl) getRightsAlerts(ctx context.Context, tenantID,
 		LIMIT 5
 	`
 
-	results, err := r.db.GetAll(ctx, query, tenantID, merchantID, types.AlertStatusActive)
+	results, err := TenantDBFor(ctx, tenantID).GetAll(ctx, query, tenantID, merchantID, types.AlertStatusActive)
 	if err != nil {
 		return nil, gerror.Wrap(err, "查询权益预警失败")
 	}
@@ -488,10 +544,10 @@ func (r *dashboardRepositoryImpl) countPendingOrders(ctx context.Context, tenant
 		SELECT COUNT(*) as count
 		FROM orders 
 		WHERE tenant_id = ? AND merchant_id = ? 
-		AND status IN ('paid', 'processing')
+		AND status IN (?, ?)
 	`
 
-	result, err := r.db.GetValue(ctx, query, tenantID, merchantID)
+	result, err := TenantDBFor(ctx, tenantID).GetValue(ctx, query, tenantID, merchantID, types.OrderStatusIntPaid, types.OrderStatusIntProcessing)
 	if err != nil {
 		return 0
 	}
@@ -505,11 +561,11 @@ func (r *dashboardRepositoryImpl) countPendingVerifications(ctx context.Context,
 		SELECT COUNT(*) as count
 		FROM orders 
 		WHERE tenant_id = ? AND merchant_id = ? 
-		AND status = 'paid' 
+		AND status = ? 
 		AND (verification_info IS NULL OR JSON_EXTRACT(verification_info, '$.verified_at') IS NULL)
 	`
 
-	result, err := r.db.GetValue(ctx, query, tenantID, merchantID)
+	result, err := TenantDBFor(ctx, tenantID).GetValue(ctx, query, tenantID, merchantID, types.OrderStatusIntPaid)
 	if err != nil {
 		return 0
 	}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+)
+
+// IExportJobRepository 导出任务仓储接口
+type IExportJobRepository interface {
+	Create(ctx context.Context, job *types.ExportJob) error
+	// 仅当任务仍处于 from 状态时写入 job 的新状态和结果，任务已被其他实例处理时返回 false
+	Transition(ctx context.Context, job *types.ExportJob, from types.ExportJobStatus) (bool, error)
+	// 获取当前租户的导出任务，不存在时返回 nil
+	GetByUUID(ctx context.Context, uuid string) (*types.ExportJob, error)
+	// 统计当前租户处于该状态的任务数
+	CountByStatus(ctx context.Context, status types.ExportJobStatus) (int, error)
+	// 统计当前租户 since 之后创建的任务数和已生成的文件总大小
+	UsageSince(ctx context.Context, since time.Time) (*types.ExportUsage, error)
+	// 获取排队中的指定类型任务（跨租户，仅供导出队列使用），按创建顺序
+	ListQueued(ctx context.Context, kinds []string, limit int) ([]types.ExportJob, error)
+	// 将 startedBefore 之前开始且仍在生成中的任务标记为失败（跨租户，用于清理进程退出时中断的任务）
+	FailStale(ctx context.Context, startedBefore time.Time, reason string) (int, error)
+}
+
+// ExportJobRepository 导出任务仓储实现
+type ExportJobRepository struct {
+	*BaseRepository
+}
+
+// NewExportJobRepository 创建导出任务仓储实例
+func NewExportJobRepository() IExportJobRepository {
+	return &ExportJobRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 创建导出任务
+func (r *ExportJobRepository) Create(ctx context.Context, job *types.ExportJob) error {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return fmt.Errorf("missing tenant_id in context")
+	}
+
+	now := time.Now()
+	job.TenantID = tenantID
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	id, err := TenantDB(ctx).Model("export_jobs").Ctx(ctx).Data(gdb.Map{
+		"uuid":         job.UUID,
+		"tenant_id":    tenantID,
+		"kind":         job.Kind,
+		"params":       job.Params,
+		"format":       job.Format,
+		"file_name":    job.FileName,
+		"status":       job.Status,
+		"requested_by": job.RequestedBy,
+		"created_at":   now,
+		"updated_at":   now,
+	}).InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("创建导出任务失败: %v", err)
+	}
+
+	job.ID = uint64(id)
+	return nil
+}
+
+// Transition 按状态条件更新任务，保证同一任务只会被一个实例开始生成
+func (r *ExportJobRepository) Transition(ctx context.Context, job *types.ExportJob, from types.ExportJobStatus) (bool, error) {
+	errorMessage := job.ErrorMessage
+	if len(errorMessage) > 500 {
+		errorMessage = errorMessage[:500]
+	}
+
+	job.UpdatedAt = time.Now()
+	result, err := TenantDB(ctx).Model("export_jobs").
+		Ctx(ctx).
+		Where("id = ? AND status = ?", job.ID, from).
+		Data(gdb.Map{
+			"status":        job.Status,
+			"file_path":     job.FilePath,
+			"size_bytes":    job.SizeBytes,
+			"row_count":     job.RowCount,
+			"error_message": errorMessage,
+			"started_at":    job.StartedAt,
+			"completed_at":  job.CompletedAt,
+			"expires_at":    job.ExpiresAt,
+			"updated_at":    job.UpdatedAt,
+		}).
+		Update()
+	if err != nil {
+		return false, fmt.Errorf("更新导出任务失败: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("更新导出任务失败: %v", err)
+	}
+	return affected > 0, nil
+}
+
+// GetByUUID 获取当前租户的导出任务
+func (r *ExportJobRepository) GetByUUID(ctx context.Context, uuid string) (*types.ExportJob, error) {
+	var job *types.ExportJob
+	err := TenantDB(ctx).Model("export_jobs").
+		Ctx(ctx).
+		Where("tenant_id = ? AND uuid = ?", r.GetTenantID(ctx), uuid).
+		Scan(&job)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取导出任务失败: %v", err)
+	}
+	return job, nil
+}
+
+// CountByStatus 统计当前租户处于该状态的任务数
+func (r *ExportJobRepository) CountByStatus(ctx context.Context, status types.ExportJobStatus) (int, error) {
+	count, err := TenantDB(ctx).Model("export_jobs").
+		Ctx(ctx).
+		Where("tenant_id = ? AND status = ?", r.GetTenantID(ctx), status).
+		Count()
+	if err != nil {
+		return 0, fmt.Errorf("统计导出任务失败: %v", err)
+	}
+	return count, nil
+}
+
+// UsageSince 统计当前租户的导出用量，失败任务计入任务数但不计入文件大小
+func (r *ExportJobRepository) UsageSince(ctx context.Context, since time.Time) (*types.ExportUsage, error) {
+	var usage types.ExportUsage
+	err := TenantDB(ctx).Model("export_jobs").
+		Ctx(ctx).
+		Fields("COUNT(*) AS jobs, COALESCE(SUM(size_bytes), 0) AS bytes").
+		Where("tenant_id = ? AND created_at >= ?", r.GetTenantID(ctx), since).
+		Scan(&usage)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("统计导出用量失败: %v", err)
+	}
+	return &usage, nil
+}
+
+// ListQueued 获取排队中的任务，只返回调用方能生成的类型，其他服务的任务留给对应服务的导出队列
+func (r *ExportJobRepository) ListQueued(ctx context.Context, kinds []string, limit int) ([]types.ExportJob, error) {
+	var jobs []types.ExportJob
+	err := TenantDB(ctx).Model("export_jobs").
+		Ctx(ctx).
+		Where("status = ?", types.ExportJobStatusQueued).
+		WhereIn("kind", kinds).
+		OrderAsc("id").
+		Limit(limit).
+		Scan(&jobs)
+	if err != nil {
+		return nil, fmt.Errorf("获取排队中的导出任务失败: %v", err)
+	}
+	return jobs, nil
+}
+
+// FailStale 将超时未完成的任务标记为失败
+func (r *ExportJobRepository) FailStale(ctx context.Context, startedBefore time.Time, reason string) (int, error) {
+	now := time.Now()
+	result, err := TenantDB(ctx).Model("export_jobs").
+		Ctx(ctx).
+		Where("status = ? AND started_at < ?", types.ExportJobStatusRunning, startedBefore).
+		Data(gdb.Map{
+			"status":        types.ExportJobStatusFailed,
+			"error_message": reason,
+			"completed_at":  now,
+			"updated_at":    now,
+		}).
+		Update()
+	if err != nil {
+		return 0, fmt.Errorf("清理超时导出任务失败: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("清理超时导出任务失败: %v", err)
+	}
+	return int(affected), nil
+}
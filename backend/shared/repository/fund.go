@@ -24,6 +24,8 @@ type FundRepository interface {
 	
 	// 权益余额相关操作
 	GetMerchantBalance(ctx context.Context, tenantID, merchantID uint64) (*types.RightsBalance, error)
+	// 获取商户权益余额并加行锁，需在事务中调用
+	GetMerchantBalanceForUpdate(ctx context.Context, tenantID, merchantID uint64) (*types.RightsBalance, error)
 	UpdateMerchantBalance(ctx context.Context, tenantID, merchantID uint64, balance *types.RightsBalance) error
 	
 	// 统计相关操作
@@ -34,15 +36,11 @@ type FundRepository interface {
 }
 
 // fundRepository 资金仓储实现
-type fundRepository struct {
-	db gdb.DB
-}
+type fundRepository struct{}
 
 // NewFundRepository 创建资金仓储实例
 func NewFundRepository() FundRepository {
-	return &fundRepository{
-		db: g.DB(),
-	}
+	return &fundRepository{}
 }
 
 // CreateFund 创建资金记录
@@ -59,7 +57,7 @@ func (r *fundRepository) CreateFund(ctx context.Context, fund *types.Fund) error
 	}
 	fund.TenantID = tenantID
 	
-	result, err := r.db.Model("funds").Ctx(ctx).Insert(fund)
+	result, err := TenantDB(ctx).Model("funds").Ctx(ctx).Insert(fund)
 	if err != nil {
 		return fmt.Errorf("创建资金记录失败: %v", err)
 	}
@@ -80,7 +78,7 @@ func (r *fundRepository) GetFundByID(ctx context.Context, tenantID, fundID uint6
 	}
 	
 	var fund types.Fund
-	err := r.db.Model("funds").Ctx(ctx).
+	err := TenantDBFor(ctx, tenantID).Model("funds").Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", fundID, tenantID).
 		Scan(&fund)
 	
@@ -100,7 +98,7 @@ func (r *fundRepository) UpdateFundStatus(ctx context.Context, tenantID, fundID
 		return fmt.Errorf("无效的参数")
 	}
 	
-	result, err := r.db.Model("funds").Ctx(ctx).
+	result, err := TenantDBFor(ctx, tenantID).Model("funds").Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", fundID, tenantID).
 		Update(g.Map{"status": status})
 	
@@ -143,7 +141,7 @@ func (r *fundRepository) ListFunds(ctx context.Context, tenantID, merchantID uin
 	}
 	
 	// 查询总数
-	count, err := r.db.Model("funds").Ctx(ctx).
+	count, err := TenantDBFor(ctx, tenantID).Model("funds").Ctx(ctx).
 		Where(whereCondition, whereArgs...).
 		Count()
 	if err != nil {
@@ -152,7 +150,7 @@ func (r *fundRepository) ListFunds(ctx context.Context, tenantID, merchantID uin
 	
 	// 查询列表
 	var funds []*types.Fund
-	err = r.db.Model("funds").Ctx(ctx).
+	err = TenantDBFor(ctx, tenantID).Model("funds").Ctx(ctx).
 		Where(whereCondition, whereArgs...).
 		OrderDesc("created_at").
 		Limit((page-1)*pageSize, pageSize).
@@ -179,7 +177,7 @@ func (r *fundRepository) CreateFundTransaction(ctx context.Context, transaction
 	}
 	transaction.TenantID = tenantID
 	
-	result, err := r.db.Model("fund_transactions").Ctx(ctx).Insert(transaction)
+	result, err := TenantDB(ctx).Model("fund_transactions").Ctx(ctx).Insert(transaction)
 	if err != nil {
 		return fmt.Errorf("创建资金流转记录失败: %v", err)
 	}
@@ -210,7 +208,7 @@ func (r *fundRepository) ListFundTransactions(ctx context.Context, query *types.
 	}
 	
 	// 构建查询条件
-	model := r.db.Model("fund_transactions").Ctx(ctx).Where("tenant_id = ?", tenantID)
+	model := TenantDB(ctx).Model("fund_transactions").Ctx(ctx).Where("tenant_id = ?", tenantID)
 	
 	if query.MerchantID > 0 {
 		model = model.Where("merchant_id = ?", query.MerchantID)
@@ -257,9 +255,32 @@ func (r *fundRepository) GetMerchantBalance(ctx context.Context, tenantID, merch
 	}
 	
 	var balance types.RightsBalance
-	err := r.db.Model("merchants").Ctx(ctx).
+	err := TenantDBFor(ctx, tenantID).Model("merchants").Ctx(ctx).
+		Fields("rights_balance").
+		Where("id = ? AND tenant_id = ?", merchantID, tenantID).
+		Scan(&balance)
+	
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("商户不存在")
+		}
+		return nil, fmt.Errorf("查询商户权益余额失败: %v", err)
+	}
+	
+	return &balance, nil
+}
+
+// GetMerchantBalanceForUpdate 获取商户权益余额并加行锁，避免并发入账时余额被覆盖
+func (r *fundRepository) GetMerchantBalanceForUpdate(ctx context.Context, tenantID, merchantID uint64) (*types.RightsBalance, error) {
+	if tenantID == 0 || merchantID == 0 {
+		return nil, fmt.Errorf("无效的参数")
+	}
+	
+	var balance types.RightsBalance
+	err := TenantDBFor(ctx, tenantID).Model("merchants").Ctx(ctx).
 		Fields("rights_balance").
 		Where("id = ? AND tenant_id = ?", merchantID, tenantID).
+		LockUpdate().
 		Scan(&balance)
 	
 	if err != nil {
@@ -281,7 +302,7 @@ func (r *fundRepository) UpdateMerchantBalance(ctx context.Context, tenantID, me
 	// 更新可用余额
 	balance.UpdateAvailableBalance()
 	
-	result, err := r.db.Model("merchants").Ctx(ctx).
+	result, err := TenantDBFor(ctx, tenantID).Model("merchants").Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", merchantID, tenantID).
 		Update(g.Map{"rights_balance": balance})
 	
@@ -321,7 +342,7 @@ func (r *fundRepository) GetFundSummary(ctx context.Context, tenantID uint64, me
 	
 	// 充值总额
 	var totalDeposits sql.NullFloat64
-	err := r.db.Model("funds").Ctx(ctx).
+	err := TenantDBFor(ctx, tenantID).Model("funds").Ctx(ctx).
 		Fields("COALESCE(SUM(amount), 0) as total").
 		Where(whereCondition+" AND fund_type = ?", append(whereArgs, types.FundTypeDeposit)...).
 		Scan(&totalDeposits)
@@ -332,7 +353,7 @@ func (r *fundRepository) GetFundSummary(ctx context.Context, tenantID uint64, me
 	
 	// 分配总额
 	var totalAllocations sql.NullFloat64
-	err = r.db.Model("funds").Ctx(ctx).
+	err = TenantDBFor(ctx, tenantID).Model("funds").Ctx(ctx).
 		Fields("COALESCE(SUM(amount), 0) as total").
 		Where(whereCondition+" AND fund_type = ?", append(whereArgs, types.FundTypeAllocation)...).
 		Scan(&totalAllocations)
@@ -343,7 +364,7 @@ func (r *fundRepository) GetFundSummary(ctx context.Context, tenantID uint64, me
 	
 	// 消费总额
 	var totalConsumption sql.NullFloat64
-	err = r.db.Model("funds").Ctx(ctx).
+	err = TenantDBFor(ctx, tenantID).Model("funds").Ctx(ctx).
 		Fields("COALESCE(SUM(amount), 0) as total").
 		Where(whereCondition+" AND fund_type = ?", append(whereArgs, types.FundTypeConsumption)...).
 		Scan(&totalConsumption)
@@ -354,7 +375,7 @@ func (r *fundRepository) GetFundSummary(ctx context.Context, tenantID uint64, me
 	
 	// 退款总额
 	var totalRefunds sql.NullFloat64
-	err = r.db.Model("funds").Ctx(ctx).
+	err = TenantDBFor(ctx, tenantID).Model("funds").Ctx(ctx).
 		Fields("COALESCE(SUM(amount), 0) as total").
 		Where(whereCondition+" AND fund_type = ?", append(whereArgs, types.FundTypeRefund)...).
 		Scan(&totalRefunds)
@@ -371,7 +392,7 @@ func (r *fundRepository) GetFundSummary(ctx context.Context, tenantID uint64, me
 
 // WithTransaction 在事务中执行操作
 func (r *fundRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context, tx gdb.TX) error) error {
-	return r.db.Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
 		return fn(ctx, tx)
 	})
 }
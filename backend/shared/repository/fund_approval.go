@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"mer-demo/shared/types"
+)
+
+// FundApprovalRepository 大额资金操作审批仓储接口
+type FundApprovalRepository interface {
+	CreateApproval(ctx context.Context, approval *types.FundApproval) error
+	// 获取审批记录并加行锁，需在事务中调用；不存在时返回 nil
+	GetApprovalForUpdate(ctx context.Context, tenantID, approvalID uint64) (*types.FundApproval, error)
+	ListPendingApprovals(ctx context.Context, tenantID uint64, query *types.FundApprovalQuery) ([]*types.FundApproval, int64, error)
+	UpdateApprovalProgress(ctx context.Context, approval *types.FundApproval) error
+
+	CreateDecision(ctx context.Context, decision *types.FundApprovalDecision) error
+	HasDecision(ctx context.Context, tenantID, approvalID, approverID uint64) (bool, error)
+}
+
+// fundApprovalRepository 大额资金操作审批仓储实现
+type fundApprovalRepository struct{}
+
+// NewFundApprovalRepository 创建大额资金操作审批仓储实例
+func NewFundApprovalRepository() FundApprovalRepository {
+	return &fundApprovalRepository{}
+}
+
+// CreateApproval 创建审批记录
+func (r *fundApprovalRepository) CreateApproval(ctx context.Context, approval *types.FundApproval) error {
+	tenantID := GetTenantIDFromContext(ctx)
+	if tenantID == 0 {
+		return fmt.Errorf("无效的租户上下文")
+	}
+	approval.TenantID = tenantID
+	approval.CreatedAt = time.Now()
+	approval.UpdatedAt = approval.CreatedAt
+
+	id, err := TenantDB(ctx).Model("fund_approvals").Ctx(ctx).InsertAndGetId(approval)
+	if err != nil {
+		return fmt.Errorf("创建资金审批记录失败: %v", err)
+	}
+	approval.ID = uint64(id)
+	return nil
+}
+
+// GetApprovalForUpdate 获取审批记录并加行锁，避免并发审批重复入账
+func (r *fundApprovalRepository) GetApprovalForUpdate(ctx context.Context, tenantID, approvalID uint64) (*types.FundApproval, error) {
+	if tenantID == 0 || approvalID == 0 {
+		return nil, fmt.Errorf("无效的参数")
+	}
+
+	var approval *types.FundApproval
+	err := TenantDBFor(ctx, tenantID).Model("fund_approvals").Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", approvalID, tenantID).
+		LockUpdate().
+		Scan(&approval)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询资金审批记录失败: %v", err)
+	}
+	return approval, nil
+}
+
+// ListPendingApprovals 分页获取待审批的资金操作，按提交时间排序
+func (r *fundApprovalRepository) ListPendingApprovals(ctx context.Context, tenantID uint64, query *types.FundApprovalQuery) ([]*types.FundApproval, int64, error) {
+	if tenantID == 0 {
+		return nil, 0, fmt.Errorf("无效的租户ID")
+	}
+
+	page, pageSize := query.Page, query.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	model := TenantDBFor(ctx, tenantID).Model("fund_approvals").Ctx(ctx).
+		Where("tenant_id = ? AND status = ?", tenantID, types.FundApprovalStatusPending)
+	if query.MerchantID > 0 {
+		model = model.Where("merchant_id = ?", query.MerchantID)
+	}
+
+	count, err := model.Clone().Count()
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询待审批资金操作总数失败: %v", err)
+	}
+
+	var approvals []*types.FundApproval
+	err = model.OrderAsc("created_at").
+		Limit((page-1)*pageSize, pageSize).
+		Scan(&approvals)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询待审批资金操作失败: %v", err)
+	}
+
+	return approvals, int64(count), nil
+}
+
+// UpdateApprovalProgress 更新审批人数和审批状态
+func (r *fundApprovalRepository) UpdateApprovalProgress(ctx context.Context, approval *types.FundApproval) error {
+	approval.UpdatedAt = time.Now()
+	_, err := TenantDB(ctx).Model("fund_approvals").Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", approval.ID, approval.TenantID).
+		Update(g.Map{
+			"approval_count": approval.ApprovalCount,
+			"status":         approval.Status,
+			"decided_at":     approval.DecidedAt,
+			"updated_at":     approval.UpdatedAt,
+		})
+	if err != nil {
+		return fmt.Errorf("更新资金审批记录失败: %v", err)
+	}
+	return nil
+}
+
+// CreateDecision 记录审批意见
+func (r *fundApprovalRepository) CreateDecision(ctx context.Context, decision *types.FundApprovalDecision) error {
+	decision.CreatedAt = time.Now()
+	id, err := TenantDB(ctx).Model("fund_approval_decisions").Ctx(ctx).InsertAndGetId(decision)
+	if err != nil {
+		return fmt.Errorf("记录审批意见失败: %v", err)
+	}
+	decision.ID = uint64(id)
+	return nil
+}
+
+// HasDecision 审批人是否已审批过该资金操作
+func (r *fundApprovalRepository) HasDecision(ctx context.Context, tenantID, approvalID, approverID uint64) (bool, error) {
+	count, err := TenantDBFor(ctx, tenantID).Model("fund_approval_decisions").Ctx(ctx).
+		Where("tenant_id = ? AND approval_id = ? AND approver_id = ?", tenantID, approvalID, approverID).
+		Count()
+	if err != nil {
+		return false, fmt.Errorf("查询审批意见失败: %v", err)
+	}
+	return count > 0, nil
+}
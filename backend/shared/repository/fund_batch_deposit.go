@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"mer-demo/shared/types"
+)
+
+// FundBatchDepositRepository 批量充值幂等记录仓储接口
+type FundBatchDepositRepository interface {
+	// 登记批量充值的幂等键，幂等键已存在时返回 false
+	CreateBatch(ctx context.Context, batch *types.FundBatchDeposit) (bool, error)
+	// 按幂等键获取批量充值记录，不存在时返回 nil
+	GetBatchByKey(ctx context.Context, tenantID uint64, idempotencyKey string) (*types.FundBatchDeposit, error)
+	// 记录批量充值的处理结果
+	CompleteBatch(ctx context.Context, tenantID, batchID uint64, status types.BatchDepositStatus, resultJSON string) error
+}
+
+// fundBatchDepositRepository 批量充值幂等记录仓储实现
+type fundBatchDepositRepository struct{}
+
+// NewFundBatchDepositRepository 创建批量充值幂等记录仓储实例
+func NewFundBatchDepositRepository() FundBatchDepositRepository {
+	return &fundBatchDepositRepository{}
+}
+
+// CreateBatch 登记幂等键，依赖 (tenant_id, idempotency_key) 唯一约束保证并发提交时只有一个请求入账
+func (r *fundBatchDepositRepository) CreateBatch(ctx context.Context, batch *types.FundBatchDeposit) (bool, error) {
+	batch.CreatedAt = time.Now()
+	batch.UpdatedAt = batch.CreatedAt
+
+	// 处理结果在完成后写入，登记时省略空字段
+	id, err := TenantDBFor(ctx, batch.TenantID).Model("fund_batch_deposits").Ctx(ctx).
+		Data(batch).OmitEmpty().InsertAndGetId()
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("登记批量充值失败: %v", err)
+	}
+	batch.ID = uint64(id)
+	return true, nil
+}
+
+// GetBatchByKey 按幂等键获取批量充值记录
+func (r *fundBatchDepositRepository) GetBatchByKey(ctx context.Context, tenantID uint64, idempotencyKey string) (*types.FundBatchDeposit, error) {
+	var batch *types.FundBatchDeposit
+	err := TenantDBFor(ctx, tenantID).Model("fund_batch_deposits").Ctx(ctx).
+		Where("tenant_id = ? AND idempotency_key = ?", tenantID, idempotencyKey).
+		Scan(&batch)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询批量充值记录失败: %v", err)
+	}
+	return batch, nil
+}
+
+// CompleteBatch 记录批量充值的处理结果
+func (r *fundBatchDepositRepository) CompleteBatch(ctx context.Context, tenantID, batchID uint64, status types.BatchDepositStatus, resultJSON string) error {
+	_, err := TenantDBFor(ctx, tenantID).Model("fund_batch_deposits").Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", batchID, tenantID).
+		Update(g.Map{
+			"status":     status,
+			"result":     resultJSON,
+			"updated_at": time.Now(),
+		})
+	if err != nil {
+		return fmt.Errorf("记录批量充值结果失败: %v", err)
+	}
+	return nil
+}
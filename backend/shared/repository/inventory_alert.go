@@ -70,7 +70,7 @@ func (r *inventoryAlertRepository) Create(ctx context.Context, alert *types.Inve
 		"updated_at":             alert.UpdatedAt,
 	}
 
-	result, err := g.DB().Model(r.tableName).Ctx(ctx).Insert(data)
+	result, err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).Insert(data)
 	if err != nil {
 		g.Log().Errorf(ctx, "创建库存预警规则失败: %v", err)
 		return err
@@ -94,7 +94,7 @@ func (r *inventoryAlertRepository) GetByID(ctx context.Context, tenantID, alertI
 	var alert types.InventoryAlert
 	var channelsJSON string
 
-	err := g.DB().Model(r.tableName).Ctx(ctx).
+	err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Fields("*, notification_channels as channels_json").
 		Where("tenant_id = ? AND id = ?", tenantID, alertID).
 		Scan(&alert)
@@ -123,7 +123,7 @@ func (r *inventoryAlertRepository) GetByProductID(ctx context.Context, tenantID,
 	var alerts []types.InventoryAlert
 	var results []g.Map
 
-	err := g.DB().Model(r.tableName).Ctx(ctx).
+	err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND product_id = ?", tenantID, productID).
 		Order("created_at DESC").
 		Scan(&results)
@@ -156,7 +156,7 @@ func (r *inventoryAlertRepository) GetActiveAlerts(ctx context.Context, tenantID
 	var alerts []types.InventoryAlert
 	var results []g.Map
 
-	err := g.DB().Model(r.tableName).Ctx(ctx).
+	err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND is_active = ?", tenantID, true).
 		Order("created_at DESC").
 		Scan(&results)
@@ -207,7 +207,7 @@ func (r *inventoryAlertRepository) Update(ctx context.Context, tenantID uint64,
 		"updated_at":             alert.UpdatedAt,
 	}
 
-	result, err := g.DB().Model(r.tableName).Ctx(ctx).
+	result, err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, alert.ID).
 		Update(data)
 	if err != nil {
@@ -232,7 +232,7 @@ func (r *inventoryAlertRepository) UpdateLastTriggered(ctx context.Context, tena
 		return errors.New("预警ID不能为空")
 	}
 
-	_, err := g.DB().Model(r.tableName).Ctx(ctx).
+	_, err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, alertID).
 		Update(g.Map{
 			"last_triggered_at": time.Now(),
@@ -252,7 +252,7 @@ func (r *inventoryAlertRepository) Delete(ctx context.Context, tenantID, alertID
 		return errors.New("预警ID不能为空")
 	}
 
-	result, err := g.DB().Model(r.tableName).Ctx(ctx).
+	result, err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, alertID).
 		Delete()
 	if err != nil {
@@ -277,7 +277,7 @@ func (r *inventoryAlertRepository) ToggleStatus(ctx context.Context, tenantID, a
 		return errors.New("预警ID不能为空")
 	}
 
-	result, err := g.DB().Model(r.tableName).Ctx(ctx).
+	result, err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, alertID).
 		Update(g.Map{
 			"is_active":  isActive,
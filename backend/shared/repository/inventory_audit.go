@@ -72,7 +72,7 @@ func (r *inventoryAuditRepository) Create(ctx context.Context, auditLog *types.I
 		"created_at":      auditLog.CreatedAt,
 	}
 
-	result, err := g.DB().Model(r.tableName).Ctx(ctx).Insert(data)
+	result, err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).Insert(data)
 	if err != nil {
 		g.Log().Errorf(ctx, "创建审计日志失败: %v", err)
 		return err
@@ -99,7 +99,7 @@ func (r *inventoryAuditRepository) Query(ctx context.Context, req *types.AuditLo
 	}
 
 	// 构建查询条件
-	db := g.DB().Model(r.tableName).Ctx(ctx).Where("tenant_id = ?", tenantID)
+	db := TenantDB(ctx).Model(r.tableName).Ctx(ctx).Where("tenant_id = ?", tenantID)
 
 	if req.AuditType != nil {
 		db = db.Where("audit_type = ?", string(*req.AuditType))
@@ -170,7 +170,7 @@ func (r *inventoryAuditRepository) GetByResourceID(ctx context.Context, resource
 	}
 
 	var logs []types.InventoryAuditLog
-	err := g.DB().Model(r.tableName).Ctx(ctx).
+	err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND resource_type = ? AND resource_id = ?", tenantID, resourceType, resourceID).
 		Order("created_at DESC").
 		Limit(limit).
@@ -192,7 +192,7 @@ func (r *inventoryAuditRepository) GetRecentLogs(ctx context.Context, limit int)
 	}
 
 	var logs []types.InventoryAuditLog
-	err := g.DB().Model(r.tableName).Ctx(ctx).
+	err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ?", tenantID).
 		Order("created_at DESC").
 		Limit(limit).
@@ -216,7 +216,7 @@ func (r *inventoryAuditRepository) GetStatsByTimeRange(ctx context.Context, star
 	stats := make(map[string]interface{})
 
 	// 总日志数
-	totalCount, err := g.DB().Model(r.tableName).Ctx(ctx).
+	totalCount, err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND created_at BETWEEN ? AND ?", tenantID, startTime, endTime).
 		Count()
 	if err != nil {
@@ -226,7 +226,7 @@ func (r *inventoryAuditRepository) GetStatsByTimeRange(ctx context.Context, star
 
 	// 按审计类型统计
 	var typeStats []g.Map
-	err = g.DB().Model(r.tableName).Ctx(ctx).
+	err = TenantDB(ctx).Model(r.tableName).Ctx(ctx).
 		Fields("audit_type, COUNT(*) as count").
 		Where("tenant_id = ? AND created_at BETWEEN ? AND ?", tenantID, startTime, endTime).
 		Group("audit_type").
@@ -238,7 +238,7 @@ func (r *inventoryAuditRepository) GetStatsByTimeRange(ctx context.Context, star
 
 	// 按级别统计
 	var levelStats []g.Map
-	err = g.DB().Model(r.tableName).Ctx(ctx).
+	err = TenantDB(ctx).Model(r.tableName).Ctx(ctx).
 		Fields("level, COUNT(*) as count").
 		Where("tenant_id = ? AND created_at BETWEEN ? AND ?", tenantID, startTime, endTime).
 		Group("level").
@@ -250,7 +250,7 @@ func (r *inventoryAuditRepository) GetStatsByTimeRange(ctx context.Context, star
 
 	// 按操作类型统计
 	var operationStats []g.Map
-	err = g.DB().Model(r.tableName).Ctx(ctx).
+	err = TenantDB(ctx).Model(r.tableName).Ctx(ctx).
 		Fields("operation_type, COUNT(*) as count").
 		Where("tenant_id = ? AND created_at BETWEEN ? AND ?", tenantID, startTime, endTime).
 		Group("operation_type").
@@ -270,7 +270,7 @@ func (r *inventoryAuditRepository) DeleteOldLogs(ctx context.Context, beforeDate
 		return 0, errors.New("租户ID不能为空")
 	}
 
-	result, err := g.DB().Model(r.tableName).Ctx(ctx).
+	result, err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND created_at < ?", tenantID, beforeDate).
 		Delete()
 
@@ -42,7 +42,7 @@ func (r *inventoryRecordRepository) Create(ctx context.Context, record *types.In
 	}
 	record.TenantID = tenantID
 
-	_, err := g.DB().Model(r.tableName).Ctx(ctx).Insert(record)
+	_, err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).Insert(record)
 	if err != nil {
 		g.Log().Errorf(ctx, "创建库存记录失败: %v", err)
 		return err
@@ -60,7 +60,7 @@ func (r *inventoryRecordRepository) GetByProductID(ctx context.Context, tenantID
 	var records []types.InventoryRecord
 	offset := (page - 1) * pageSize
 
-	db := g.DB().Model(r.tableName).Ctx(ctx).
+	db := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND product_id = ?", tenantID, productID).
 		Order("created_at DESC").
 		Limit(pageSize).
@@ -73,7 +73,7 @@ func (r *inventoryRecordRepository) GetByProductID(ctx context.Context, tenantID
 	}
 
 	// 获取总数
-	count, err := g.DB().Model(r.tableName).Ctx(ctx).
+	count, err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND product_id = ?", tenantID, productID).
 		Count()
 	if err != nil {
@@ -91,7 +91,7 @@ func (r *inventoryRecordRepository) GetByReferenceID(ctx context.Context, tenant
 	}
 
 	var records []types.InventoryRecord
-	err := g.DB().Model(r.tableName).Ctx(ctx).
+	err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND reference_id = ?", tenantID, referenceID).
 		Order("created_at DESC").
 		Scan(&records)
@@ -106,7 +106,7 @@ func (r *inventoryRecordRepository) GetByReferenceID(ctx context.Context, tenant
 // GetRecentRecords 获取最近的库存记录
 func (r *inventoryRecordRepository) GetRecentRecords(ctx context.Context, tenantID uint64, limit int) ([]types.InventoryRecord, error) {
 	var records []types.InventoryRecord
-	err := g.DB().Model(r.tableName).Ctx(ctx).
+	err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ?", tenantID).
 		Order("created_at DESC").
 		Limit(limit).
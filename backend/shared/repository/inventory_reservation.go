@@ -18,6 +18,7 @@ type IInventoryReservationRepository interface {
 	GetActiveReservations(ctx context.Context, tenantID, productID uint64) ([]types.InventoryReservation, error)
 	UpdateStatus(ctx context.Context, tenantID, reservationID uint64, status types.ReservationStatus) error
 	GetExpiredReservations(ctx context.Context, tenantID uint64) ([]types.InventoryReservation, error)
+	GetExpiredByReferenceType(ctx context.Context, referenceType string, limit int) ([]types.InventoryReservation, error)
 	GetTotalReservedQuantity(ctx context.Context, tenantID, productID uint64) (int, error)
 	Delete(ctx context.Context, tenantID, reservationID uint64) error
 }
@@ -50,12 +51,13 @@ func (r *inventoryReservationRepository) Create(ctx context.Context, reservation
 	reservation.CreatedAt = time.Now()
 	reservation.UpdatedAt = time.Now()
 
-	_, err := g.DB().Model(r.tableName).Ctx(ctx).Insert(reservation)
+	id, err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).InsertAndGetId(reservation)
 	if err != nil {
 		g.Log().Errorf(ctx, "创建库存预留记录失败: %v", err)
 		return err
 	}
 
+	reservation.ID = uint64(id)
 	return nil
 }
 
@@ -66,7 +68,7 @@ func (r *inventoryReservationRepository) GetByID(ctx context.Context, tenantID,
 	}
 
 	var reservation types.InventoryReservation
-	err := g.DB().Model(r.tableName).Ctx(ctx).
+	err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, reservationID).
 		Scan(&reservation)
 	if err != nil {
@@ -87,7 +89,7 @@ func (r *inventoryReservationRepository) GetByReference(ctx context.Context, ten
 	}
 
 	var reservations []types.InventoryReservation
-	err := g.DB().Model(r.tableName).Ctx(ctx).
+	err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND reference_type = ? AND reference_id = ?", tenantID, referenceType, referenceID).
 		Order("created_at DESC").
 		Scan(&reservations)
@@ -106,7 +108,7 @@ func (r *inventoryReservationRepository) GetActiveReservations(ctx context.Conte
 	}
 
 	var reservations []types.InventoryReservation
-	err := g.DB().Model(r.tableName).Ctx(ctx).
+	err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND product_id = ? AND status = ?", tenantID, productID, types.ReservationStatusActive).
 		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
 		Order("created_at ASC").
@@ -125,7 +127,7 @@ func (r *inventoryReservationRepository) UpdateStatus(ctx context.Context, tenan
 		return errors.New("预留ID不能为空")
 	}
 
-	result, err := g.DB().Model(r.tableName).Ctx(ctx).
+	result, err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, reservationID).
 		Update(g.Map{
 			"status":     status,
@@ -150,7 +152,7 @@ func (r *inventoryReservationRepository) UpdateStatus(ctx context.Context, tenan
 // GetExpiredReservations 获取过期的预留记录
 func (r *inventoryReservationRepository) GetExpiredReservations(ctx context.Context, tenantID uint64) ([]types.InventoryReservation, error) {
 	var reservations []types.InventoryReservation
-	err := g.DB().Model(r.tableName).Ctx(ctx).
+	err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND status = ? AND expires_at <= ?", tenantID, types.ReservationStatusActive, time.Now()).
 		Scan(&reservations)
 	if err != nil {
@@ -161,13 +163,34 @@ func (r *inventoryReservationRepository) GetExpiredReservations(ctx context.Cont
 	return reservations, nil
 }
 
+// GetExpiredByReferenceType 获取指定关联类型的过期预留记录（跨租户，仅供系统定时任务使用）。
+// 逐个扫描租户数据所在的数据源，每个数据源最多返回 limit 条
+func (r *inventoryReservationRepository) GetExpiredByReferenceType(ctx context.Context, referenceType string, limit int) ([]types.InventoryReservation, error) {
+	var reservations []types.InventoryReservation
+	for _, group := range TenantDatasources() {
+		var expired []types.InventoryReservation
+		err := g.DB(group).Model(r.tableName).Ctx(ctx).
+			Where("reference_type = ? AND status = ? AND expires_at <= ?", referenceType, types.ReservationStatusActive, time.Now()).
+			Order("expires_at ASC").
+			Limit(limit).
+			Scan(&expired)
+		if err != nil {
+			g.Log().Errorf(ctx, "查询过期库存预留记录失败 - 数据源: %s, 错误: %v", group, err)
+			return nil, err
+		}
+		reservations = append(reservations, expired...)
+	}
+
+	return reservations, nil
+}
+
 // GetTotalReservedQuantity 获取商品总预留数量
 func (r *inventoryReservationRepository) GetTotalReservedQuantity(ctx context.Context, tenantID, productID uint64) (int, error) {
 	if productID == 0 {
 		return 0, errors.New("商品ID不能为空")
 	}
 
-	result, err := g.DB().Model(r.tableName).Ctx(ctx).
+	result, err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND product_id = ? AND status = ?", tenantID, productID, types.ReservationStatusActive).
 		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
 		Sum("reserved_quantity")
@@ -187,7 +210,7 @@ func (r *inventoryReservationRepository) Delete(ctx context.Context, tenantID, r
 		return errors.New("预留ID不能为空")
 	}
 
-	result, err := g.DB().Model(r.tableName).Ctx(ctx).
+	result, err := TenantDBFor(ctx, tenantID).Model(r.tableName).Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, reservationID).
 		Delete()
 	if err != nil {
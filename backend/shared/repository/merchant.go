@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gogf/gf/v2/database/gdb"
@@ -20,11 +21,17 @@ type MerchantRepository interface {
 	Update(ctx context.Context, merchant *types.Merchant) error
 	UpdateStatus(ctx context.Context, id uint64, status types.MerchantStatus) error
 	UpdateApproval(ctx context.Context, id uint64, status types.MerchantStatus, approvedBy uint64) error
+	// 记录联系方式验证时间，verifiedAt 为 nil 时清除验证状态
+	UpdateContactVerification(ctx context.Context, id uint64, channel types.MerchantContactChannel, verifiedAt *time.Time) error
+	// 在行锁下设置商户保留权益，返回更新后的权益余额；商户未开通权益余额时返回错误
+	UpdateRightsReserve(ctx context.Context, id uint64, req *types.MerchantRightsReserveRequest) (*types.RightsBalance, error)
 	Delete(ctx context.Context, id uint64) error
 	FindPage(ctx context.Context, page, pageSize int, condition interface{}, args ...interface{}) ([]*types.Merchant, int, error)
 	FindPageWithFilter(ctx context.Context, query *types.MerchantListQuery) ([]*types.Merchant, int, error)
 	Count(ctx context.Context) (int, error)
 	CountByStatus(ctx context.Context, status types.MerchantStatus) (int, error)
+	// 按ID顺序分批读取当前租户的商户（含当前权益余额），用于导出
+	ListForExport(ctx context.Context, query *types.MerchantExportQuery, afterID uint64, limit int) ([]*types.Merchant, error)
 }
 
 // merchantRepository 商户仓储实现
@@ -182,6 +189,47 @@ func (r *merchantRepository) UpdateApproval(ctx context.Context, id uint64, stat
 	return err
 }
 
+// UpdateContactVerification 更新商户联系方式验证时间
+func (r *merchantRepository) UpdateContactVerification(ctx context.Context, id uint64, channel types.MerchantContactChannel, verifiedAt *time.Time) error {
+	column := "contact_email_verified_at"
+	if channel == types.MerchantContactChannelPhone {
+		column = "contact_phone_verified_at"
+	}
+	_, err := r.BaseRepository.Update(ctx, gdb.Map{column: verifiedAt}, "id", id)
+	return err
+}
+
+// UpdateRightsReserve 设置商户保留权益，与订单冻结权益使用同一行锁，避免覆盖并发冻结的余额
+func (r *merchantRepository) UpdateRightsReserve(ctx context.Context, id uint64, req *types.MerchantRightsReserveRequest) (*types.RightsBalance, error) {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return nil, fmt.Errorf("missing tenant_id in context")
+	}
+
+	var updated *types.RightsBalance
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		balance, err := lockMerchantRights(ctx, tx, tenantID, id)
+		if err != nil {
+			return err
+		}
+		if balance == nil {
+			return fmt.Errorf("商户 %d 未开通权益余额", id)
+		}
+
+		balance.ReserveAmount = req.ReserveAmount
+		balance.ReservePercent = req.ReservePercent
+		if err := saveMerchantRights(ctx, tx, tenantID, id, balance); err != nil {
+			return err
+		}
+		updated = balance
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
 // CountByStatus 根据状态统计商户数量
 func (r *merchantRepository) CountByStatus(ctx context.Context, status types.MerchantStatus) (int, error) {
 	return r.BaseRepository.Count(ctx, "status = ?", status)
@@ -219,4 +267,42 @@ func (r *merchantRepository) FindPageWithFilter(ctx context.Context, query *type
 	}
 	
 	return r.FindPage(ctx, query.Page, query.PageSize, whereClause, args...)
-}
\ No newline at end of file
+}
+
+// ListForExport 按ID顺序读取当前租户 afterID 之后的商户，权益余额随商户记录一并读取
+func (r *merchantRepository) ListForExport(ctx context.Context, query *types.MerchantExportQuery, afterID uint64, limit int) ([]*types.Merchant, error) {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return nil, fmt.Errorf("missing tenant_id in context")
+	}
+
+	condition, args := buildMerchantExportCondition(tenantID, query, afterID)
+	var merchants []*types.Merchant
+	err := TenantDB(ctx).Model("merchants").
+		Ctx(ctx).
+		Where(condition, args...).
+		OrderAsc("id").
+		Limit(limit).
+		Scan(&merchants)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询导出商户失败: %v", err)
+	}
+	return merchants, nil
+}
+
+// buildMerchantExportCondition 构建导出查询条件，始终限定在当前租户内
+func buildMerchantExportCondition(tenantID uint64, query *types.MerchantExportQuery, afterID uint64) (string, []interface{}) {
+	conditions := []string{"tenant_id = ?", "id > ?"}
+	args := []interface{}{tenantID, afterID}
+
+	if query.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, query.Status)
+	}
+	if query.MerchantID != nil {
+		conditions = append(conditions, "id = ?")
+		args = append(args, *query.MerchantID)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMerchantExportCondition(t *testing.T) {
+	Convey("商户导出查询条件", t, func() {
+		query := &types.MerchantExportQuery{Format: types.OrderExportFormatCSV}
+
+		Convey("始终限定当前租户并按ID分批", func() {
+			clause, args := buildMerchantExportCondition(7, query, 100)
+			So(clause, ShouldEqual, "tenant_id = ? AND id > ?")
+			So(args, ShouldResemble, []interface{}{uint64(7), uint64(100)})
+		})
+
+		Convey("按状态和商户过滤", func() {
+			merchantID := uint64(12)
+			query.Status = types.MerchantStatusActive
+			query.MerchantID = &merchantID
+
+			clause, args := buildMerchantExportCondition(7, query, 0)
+			So(clause, ShouldStartWith, "tenant_id = ?")
+			So(clause, ShouldContainSubstring, "status = ?")
+			So(clause, ShouldContainSubstring, "id = ?")
+			So(args, ShouldResemble, []interface{}{uint64(7), uint64(0), types.MerchantStatusActive, uint64(12)})
+			So(strings.Count(clause, "?"), ShouldEqual, len(args))
+		})
+
+		Convey("缺少租户上下文时拒绝导出", func() {
+			repo := &merchantRepository{BaseRepository: &BaseRepository{}}
+			merchants, err := repo.ListForExport(context.Background(), query, 0, 10)
+			So(err, ShouldNotBeNil)
+			So(merchants, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IMerchantNotificationDigestRepository 商户通知偏好及待汇总通知仓储接口
+type IMerchantNotificationDigestRepository interface {
+	// 获取当前租户商户的通知偏好，未设置时返回 nil
+	GetPreference(ctx context.Context, merchantID uint64) (*types.MerchantNotificationPreference, error)
+	SavePreference(ctx context.Context, preference *types.MerchantNotificationPreference) error
+	AddEntry(ctx context.Context, entry *types.MerchantNotificationDigestEntry) error
+	// 获取有待汇总通知的接收人（跨租户，供定时任务使用），按最早通知时间排序
+	ListPendingRecipients(ctx context.Context, limit int) ([]types.MerchantDigestRecipient, error)
+	// 获取接收人全部待汇总通知，按产生顺序排列
+	ListPendingEntries(ctx context.Context, recipient *types.MerchantDigestRecipient) ([]types.MerchantNotificationDigestEntry, error)
+	MarkSent(ctx context.Context, tenantID uint64, ids []uint64, sentAt time.Time) error
+}
+
+// MerchantNotificationDigestRepository 商户通知汇总仓储实现
+type MerchantNotificationDigestRepository struct {
+	*BaseRepository
+}
+
+// NewMerchantNotificationDigestRepository 创建商户通知汇总仓储实例
+func NewMerchantNotificationDigestRepository() IMerchantNotificationDigestRepository {
+	return &MerchantNotificationDigestRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// GetPreference 获取商户通知偏好
+func (r *MerchantNotificationDigestRepository) GetPreference(ctx context.Context, merchantID uint64) (*types.MerchantNotificationPreference, error) {
+	var preference *types.MerchantNotificationPreference
+	err := TenantDB(ctx).Model("merchant_notification_preferences").
+		Ctx(ctx).
+		Where("tenant_id = ? AND merchant_id = ?", r.GetTenantID(ctx), merchantID).
+		Scan(&preference)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取商户通知偏好失败: %v", err)
+	}
+	return preference, nil
+}
+
+// SavePreference 保存商户通知偏好，已存在时覆盖
+func (r *MerchantNotificationDigestRepository) SavePreference(ctx context.Context, preference *types.MerchantNotificationPreference) error {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return fmt.Errorf("missing tenant_id in context")
+	}
+
+	preference.TenantID = tenantID
+	preference.UpdatedAt = gtime.Now().Time
+	_, err := TenantDB(ctx).Model("merchant_notification_preferences").Ctx(ctx).Data(g.Map{
+		"tenant_id":        tenantID,
+		"merchant_id":      preference.MerchantID,
+		"digest_frequency": preference.DigestFrequency,
+		"digest_hour":      preference.DigestHour,
+		"updated_at":       preference.UpdatedAt,
+	}).Save()
+	if err != nil {
+		return fmt.Errorf("保存商户通知偏好失败: %v", err)
+	}
+	return nil
+}
+
+// AddEntry 记录待汇总的通知
+func (r *MerchantNotificationDigestRepository) AddEntry(ctx context.Context, entry *types.MerchantNotificationDigestEntry) error {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return fmt.Errorf("missing tenant_id in context")
+	}
+
+	entry.TenantID = tenantID
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = gtime.Now().Time
+	}
+	id, err := TenantDB(ctx).Model("merchant_notification_digest_entries").Ctx(ctx).Data(g.Map{
+		"tenant_id":    tenantID,
+		"merchant_id":  entry.MerchantID,
+		"user_id":      entry.UserID,
+		"event_type":   entry.EventType,
+		"order_id":     entry.OrderID,
+		"order_number": entry.OrderNumber,
+		"summary":      entry.Summary,
+		"link":         entry.Link,
+		"created_at":   entry.CreatedAt,
+	}).InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("记录待汇总通知失败: %v", err)
+	}
+
+	entry.ID = uint64(id)
+	return nil
+}
+
+// ListPendingRecipients 获取有待汇总通知的接收人
+func (r *MerchantNotificationDigestRepository) ListPendingRecipients(ctx context.Context, limit int) ([]types.MerchantDigestRecipient, error) {
+	var recipients []types.MerchantDigestRecipient
+	err := TenantDB(ctx).Model("merchant_notification_digest_entries").
+		Ctx(ctx).
+		Fields("tenant_id, merchant_id, user_id, MIN(created_at) AS first_at").
+		Where("sent_at IS NULL").
+		Group("tenant_id, merchant_id, user_id").
+		Order("first_at ASC").
+		Limit(limit).
+		Scan(&recipients)
+	if err != nil {
+		return nil, fmt.Errorf("获取待汇总通知接收人失败: %v", err)
+	}
+	return recipients, nil
+}
+
+// ListPendingEntries 获取接收人的待汇总通知
+func (r *MerchantNotificationDigestRepository) ListPendingEntries(ctx context.Context, recipient *types.MerchantDigestRecipient) ([]types.MerchantNotificationDigestEntry, error) {
+	var entries []types.MerchantNotificationDigestEntry
+	err := TenantDB(ctx).Model("merchant_notification_digest_entries").
+		Ctx(ctx).
+		Where("tenant_id = ? AND merchant_id = ? AND user_id = ? AND sent_at IS NULL",
+			recipient.TenantID, recipient.MerchantID, recipient.UserID).
+		OrderAsc("id").
+		Scan(&entries)
+	if err != nil {
+		return nil, fmt.Errorf("获取待汇总通知失败: %v", err)
+	}
+	return entries, nil
+}
+
+// MarkSent 标记通知已随汇总发送
+func (r *MerchantNotificationDigestRepository) MarkSent(ctx context.Context, tenantID uint64, ids []uint64, sentAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := TenantDBFor(ctx, tenantID).Model("merchant_notification_digest_entries").
+		Ctx(ctx).
+		Where("tenant_id = ?", tenantID).
+		WhereIn("id", ids).
+		Data(g.Map{"sent_at": sentAt}).
+		Update()
+	if err != nil {
+		return fmt.Errorf("标记汇总通知已发送失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// IMerchantSettlementRepository 商户结算单仓储接口
+type IMerchantSettlementRepository interface {
+	// 按商户汇总周期内的已支付订单收入和退款
+	GetSettlementFigures(ctx context.Context, tenantID uint64, startDate, endDate time.Time, merchantID *uint64) ([]types.MerchantSettlementFigures, error)
+	// 在同一事务中保存结算单，任一商户在周期内已有结算单时返回 types.ErrSettlementPeriodOverlap
+	CreateSettlements(ctx context.Context, tenantID uint64, settlements []*types.MerchantSettlement) error
+	// 获取结算单，不存在时返回 nil
+	GetSettlement(ctx context.Context, tenantID, id uint64) (*types.MerchantSettlement, error)
+	ListSettlements(ctx context.Context, tenantID uint64, query *types.SettlementQuery) ([]*types.MerchantSettlement, int64, error)
+	// 将待付款结算单标记为已付款，结算单不是待付款状态时返回 false
+	MarkPaid(ctx context.Context, tenantID, id uint64, reference string, paidBy uint64, paidAt time.Time) (bool, error)
+}
+
+// MerchantSettlementRepository 商户结算单数据访问层
+type MerchantSettlementRepository struct {
+	*BaseRepository
+}
+
+// NewMerchantSettlementRepository 创建商户结算单仓库实例
+func NewMerchantSettlementRepository() IMerchantSettlementRepository {
+	return &MerchantSettlementRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// GetSettlementFigures 按商户汇总结算数据：订单收入按订单进入已支付状态的时间统计，
+// 退款取订单取消时记录的退款金额，按取消时间统计
+func (r *MerchantSettlementRepository) GetSettlementFigures(ctx context.Context, tenantID uint64, startDate, endDate time.Time, merchantID *uint64) ([]types.MerchantSettlementFigures, error) {
+	merchantCondition := ""
+	merchantArgs := []interface{}{}
+	if merchantID != nil {
+		merchantCondition = "AND o.merchant_id = ?"
+		merchantArgs = append(merchantArgs, *merchantID)
+	}
+
+	revenueQuery := fmt.Sprintf(`
+		SELECT
+			o.merchant_id,
+			m.name as merchant_name,
+			COALESCE(SUM(o.total_amount), 0) as gross_revenue,
+			COUNT(*) as order_count
+		FROM orders o
+		INNER JOIN (
+			SELECT DISTINCT order_id FROM order_status_history
+			WHERE tenant_id = ? AND to_status = ? AND created_at BETWEEN ? AND ?
+		) paid ON paid.order_id = o.id
+		LEFT JOIN merchants m ON o.merchant_id = m.id
+		WHERE o.tenant_id = ? %s
+		GROUP BY o.merchant_id, m.name
+	`, merchantCondition)
+
+	revenueArgs := []interface{}{tenantID, types.OrderStatusIntPaid, startDate, endDate, tenantID}
+	revenueArgs = append(revenueArgs, merchantArgs...)
+
+	var revenueFigures []types.MerchantSettlementFigures
+	if err := r.HeavyRaw(ctx, revenueQuery, revenueArgs...).Scan(&revenueFigures); err != nil {
+		return nil, fmt.Errorf("查询商户结算订单收入失败: %v", err)
+	}
+
+	refundQuery := fmt.Sprintf(`
+		SELECT
+			o.merchant_id,
+			m.name as merchant_name,
+			COALESCE(SUM(CAST(JSON_UNQUOTE(JSON_EXTRACT(h.metadata, '$.refund_amount')) AS DECIMAL(15,2))), 0) as refund_amount,
+			COUNT(DISTINCT h.order_id) as refund_count
+		FROM order_status_history h
+		INNER JOIN orders o ON o.id = h.order_id
+		LEFT JOIN merchants m ON o.merchant_id = m.id
+		WHERE h.tenant_id = ? AND h.to_status = ? AND h.created_at BETWEEN ? AND ?
+			AND CAST(JSON_UNQUOTE(JSON_EXTRACT(h.metadata, '$.refund_amount')) AS DECIMAL(15,2)) > 0 %s
+		GROUP BY o.merchant_id, m.name
+	`, merchantCondition)
+
+	refundArgs := []interface{}{tenantID, types.OrderStatusIntCancelled, startDate, endDate}
+	refundArgs = append(refundArgs, merchantArgs...)
+
+	var refundFigures []types.MerchantSettlementFigures
+	if err := r.HeavyRaw(ctx, refundQuery, refundArgs...).Scan(&refundFigures); err != nil {
+		return nil, fmt.Errorf("查询商户结算退款失败: %v", err)
+	}
+
+	// 合并收入和退款，周期内只有退款的商户也要结算
+	merged := make(map[uint64]*types.MerchantSettlementFigures, len(revenueFigures))
+	for i := range revenueFigures {
+		merged[revenueFigures[i].MerchantID] = &revenueFigures[i]
+	}
+	for _, refund := range refundFigures {
+		figures, exists := merged[refund.MerchantID]
+		if !exists {
+			refund := refund
+			merged[refund.MerchantID] = &refund
+			continue
+		}
+		figures.RefundAmount = refund.RefundAmount
+		figures.RefundCount = refund.RefundCount
+	}
+
+	result := make([]types.MerchantSettlementFigures, 0, len(merged))
+	for _, figures := range merged {
+		result = append(result, *figures)
+	}
+	return result, nil
+}
+
+// CreateSettlements 保存结算单。先检查商户在周期内是否已有结算单，避免同一笔收入重复结算
+func (r *MerchantSettlementRepository) CreateSettlements(ctx context.Context, tenantID uint64, settlements []*types.MerchantSettlement) error {
+	if len(settlements) == 0 {
+		return nil
+	}
+
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		now := time.Now()
+		for _, settlement := range settlements {
+			count, err := tx.Model("merchant_settlements").Ctx(ctx).
+				Where("tenant_id = ? AND merchant_id = ? AND period_start <= ? AND period_end >= ?",
+					tenantID, settlement.MerchantID, settlement.PeriodEnd, settlement.PeriodStart).
+				LockUpdate().
+				Count()
+			if err != nil {
+				return fmt.Errorf("查询商户已有结算单失败: %v", err)
+			}
+			if count > 0 {
+				return fmt.Errorf("%w: 商户 %d", types.ErrSettlementPeriodOverlap, settlement.MerchantID)
+			}
+
+			settlement.TenantID = tenantID
+			settlement.CreatedAt = now
+			settlement.UpdatedAt = now
+			id, err := tx.Model("merchant_settlements").Ctx(ctx).InsertAndGetId(settlement)
+			if err != nil {
+				return fmt.Errorf("创建商户结算单失败: %v", err)
+			}
+			settlement.ID = uint64(id)
+		}
+		return nil
+	})
+}
+
+// GetSettlement 获取结算单
+func (r *MerchantSettlementRepository) GetSettlement(ctx context.Context, tenantID, id uint64) (*types.MerchantSettlement, error) {
+	var settlement *types.MerchantSettlement
+	err := TenantDBFor(ctx, tenantID).Model("merchant_settlements").Ctx(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Scan(&settlement)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询商户结算单失败: %v", err)
+	}
+	return settlement, nil
+}
+
+// ListSettlements 分页获取结算单，按周期倒序
+func (r *MerchantSettlementRepository) ListSettlements(ctx context.Context, tenantID uint64, query *types.SettlementQuery) ([]*types.MerchantSettlement, int64, error) {
+	page, pageSize := query.Page, query.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	model := TenantDBFor(ctx, tenantID).Model("merchant_settlements").Ctx(ctx).
+		Where("tenant_id = ?", tenantID)
+	if query.MerchantID > 0 {
+		model = model.Where("merchant_id = ?", query.MerchantID)
+	}
+	if query.Status != "" {
+		model = model.Where("status = ?", query.Status)
+	}
+
+	count, err := model.Clone().Count()
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询商户结算单总数失败: %v", err)
+	}
+
+	var settlements []*types.MerchantSettlement
+	err = model.OrderDesc("period_start").OrderAsc("merchant_id").
+		Limit((page-1)*pageSize, pageSize).
+		Scan(&settlements)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询商户结算单失败: %v", err)
+	}
+	return settlements, int64(count), nil
+}
+
+// MarkPaid 将待付款结算单标记为已付款，按状态条件更新，并发标记时只有一次生效
+func (r *MerchantSettlementRepository) MarkPaid(ctx context.Context, tenantID, id uint64, reference string, paidBy uint64, paidAt time.Time) (bool, error) {
+	result, err := TenantDBFor(ctx, tenantID).Model("merchant_settlements").Ctx(ctx).
+		Where("tenant_id = ? AND id = ? AND status = ?", tenantID, id, types.SettlementStatusPending).
+		Update(g.Map{
+			"status":            types.SettlementStatusPaid,
+			"payment_reference": reference,
+			"paid_at":           paidAt,
+			"paid_by":           paidBy,
+			"updated_at":        paidAt,
+		})
+	if err != nil {
+		return false, fmt.Errorf("标记商户结算单已付款失败: %v", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("标记商户结算单已付款失败: %v", err)
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// IMerchantTargetRepository 商户销售目标仓储接口
+type IMerchantTargetRepository interface {
+	// 获取商户指定周期的销售目标，未设置时返回 nil
+	GetByPeriod(ctx context.Context, tenantID, merchantID uint64, period string) (*types.MerchantTarget, error)
+	// 保存商户周期销售目标，同一周期重复设置时覆盖
+	Save(ctx context.Context, target *types.MerchantTarget) error
+	// 统计商户在 [start, end) 内的实际销售额和订单数
+	GetPeriodActuals(ctx context.Context, tenantID, merchantID uint64, start, end time.Time) (*types.MerchantTargetActuals, error)
+}
+
+// MerchantTargetRepository 商户销售目标数据访问层
+type MerchantTargetRepository struct {
+	*BaseRepository
+}
+
+// NewMerchantTargetRepository 创建商户销售目标仓库实例
+func NewMerchantTargetRepository() IMerchantTargetRepository {
+	return &MerchantTargetRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// GetByPeriod 获取商户指定周期的销售目标
+func (r *MerchantTargetRepository) GetByPeriod(ctx context.Context, tenantID, merchantID uint64, period string) (*types.MerchantTarget, error) {
+	var target types.MerchantTarget
+	err := TenantDBFor(ctx, tenantID).Model("merchant_targets").
+		Ctx(ctx).
+		Where("tenant_id = ? AND merchant_id = ? AND period = ?", tenantID, merchantID, period).
+		Scan(&target)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("获取销售目标失败: %v", err)
+	}
+
+	return &target, nil
+}
+
+// Save 保存商户周期销售目标
+func (r *MerchantTargetRepository) Save(ctx context.Context, target *types.MerchantTarget) error {
+	_, err := TenantDB(ctx).Model("merchant_targets").
+		Ctx(ctx).
+		Data(g.Map{
+			"tenant_id":     target.TenantID,
+			"merchant_id":   target.MerchantID,
+			"period":        target.Period,
+			"target_amount": target.TargetAmount,
+			"target_orders": target.TargetOrders,
+			"created_by":    target.CreatedBy,
+		}).
+		OnDuplicate("target_amount", "target_orders", "created_by").
+		Save()
+	if err != nil {
+		return fmt.Errorf("保存销售目标失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetPeriodActuals 统计周期内已支付订单的销售额和订单数，统计口径与仪表板业务统计一致
+func (r *MerchantTargetRepository) GetPeriodActuals(ctx context.Context, tenantID, merchantID uint64, start, end time.Time) (*types.MerchantTargetActuals, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(total_amount), 0) as amount,
+			COUNT(*) as orders
+		FROM orders
+		WHERE tenant_id = ? AND merchant_id = ?
+		AND status IN ('paid', 'processing', 'completed')
+		AND created_at >= ? AND created_at < ?
+	`
+
+	actuals := &types.MerchantTargetActuals{}
+	if err := TenantDBFor(ctx, tenantID).Ctx(ctx).Raw(query, tenantID, merchantID, start, end).Scan(actuals); err != nil {
+		return nil, fmt.Errorf("统计周期销售数据失败: %v", err)
+	}
+
+	return actuals, nil
+}
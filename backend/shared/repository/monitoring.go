@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,7 +12,7 @@ import (
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gtime"
 
-	"mer-demo/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
 
 var ErrTenantRequired = errors.New("tenant_id is required")
@@ -24,6 +25,10 @@ type MonitoringRepository interface {
 	ListAlerts(ctx context.Context, query *types.AlertListQuery) ([]*types.RightsAlert, int, error)
 	UpdateAlert(ctx context.Context, alert *types.RightsAlert) error
 	ResolveAlert(ctx context.Context, id uint64, resolution string) error
+	// 跨租户列出指定类型的活跃预警，按ID升序返回 afterID 之后的最多 limit 个，仅供预警升级定时任务使用
+	ListActiveAlertsForEscalation(ctx context.Context, alertTypes []types.AlertType, afterID uint64, limit int) ([]*types.RightsAlert, error)
+	// 保存预警升级，仅当预警仍为活跃且升级次数仍为 previousLevel 时更新，返回是否更新
+	EscalateAlert(ctx context.Context, alert *types.RightsAlert, previousLevel int) (bool, error)
 	GetActiveAlertsCount(ctx context.Context, merchantID *uint64) (int, error)
 	GetCriticalAlertsCount(ctx context.Context, merchantID *uint64) (int, error)
 
@@ -64,7 +69,7 @@ func (r *monitoringRepository) CreateAlert(ctx context.Context, alert *types.Rig
 	alert.CreatedAt = time.Now()
 	alert.UpdatedAt = time.Now()
 
-	_, err := g.DB().Model("rights_alerts").Ctx(ctx).Insert(alert)
+	_, err := TenantDB(ctx).Model("rights_alerts").Ctx(ctx).Insert(alert)
 	return err
 }
 
@@ -76,7 +81,7 @@ func (r *monitoringRepository) GetAlert(ctx context.Context, id uint64) (*types.
 	}
 
 	var alert types.RightsAlert
-	err := g.DB().Model("rights_alerts").
+	err := TenantDB(ctx).Model("rights_alerts").
 		Ctx(ctx).
 		Where("id", id).
 		Where("tenant_id", tenantID).
@@ -98,7 +103,7 @@ func (r *monitoringRepository) ListAlerts(ctx context.Context, query *types.Aler
 		return nil, 0, fmt.Errorf("missing tenant_id in context")
 	}
 
-	model := g.DB().Model("rights_alerts").
+	model := TenantDB(ctx).Model("rights_alerts").
 		Ctx(ctx).
 		Where("tenant_id", tenantID).
 		OrderDesc("triggered_at")
@@ -148,7 +153,7 @@ func (r *monitoringRepository) UpdateAlert(ctx context.Context, alert *types.Rig
 	}
 
 	alert.UpdatedAt = time.Now()
-	_, err := g.DB().Model("rights_alerts").
+	_, err := TenantDB(ctx).Model("rights_alerts").
 		Ctx(ctx).
 		Where("id", alert.ID).
 		Where("tenant_id", tenantID).
@@ -165,7 +170,7 @@ func (r *monitoringRepository) ResolveAlert(ctx context.Context, id uint64, reso
 	}
 
 	now := time.Now()
-	_, err := g.DB().Model("rights_alerts").
+	_, err := TenantDB(ctx).Model("rights_alerts").
 		Ctx(ctx).
 		Where("id", id).
 		Where("tenant_id", tenantID).
@@ -180,6 +185,49 @@ func (r *monitoringRepository) ResolveAlert(ctx context.Context, id uint64, reso
 	return err
 }
 
+// ListActiveAlertsForEscalation 跨租户分批列出待检查升级的活跃预警
+func (r *monitoringRepository) ListActiveAlertsForEscalation(ctx context.Context, alertTypes []types.AlertType, afterID uint64, limit int) ([]*types.RightsAlert, error) {
+	if len(alertTypes) == 0 {
+		return nil, nil
+	}
+
+	var alerts []*types.RightsAlert
+	err := TenantDB(ctx).Model("rights_alerts").
+		Ctx(ctx).
+		Where("status", types.AlertStatusActive).
+		WhereIn("alert_type", alertTypes).
+		WhereGT("id", afterID).
+		OrderAsc("id").
+		Limit(limit).
+		Scan(&alerts)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询待升级预警失败: %v", err)
+	}
+	return alerts, nil
+}
+
+// EscalateAlert 保存预警升级后的严重程度和升级次数，预警已解决或已被其他任务升级时不更新
+func (r *monitoringRepository) EscalateAlert(ctx context.Context, alert *types.RightsAlert, previousLevel int) (bool, error) {
+	alert.UpdatedAt = time.Now()
+	result, err := TenantDBFor(ctx, alert.TenantID).Model("rights_alerts").
+		Ctx(ctx).
+		Where("id", alert.ID).
+		Where("tenant_id", alert.TenantID).
+		Where("status", types.AlertStatusActive).
+		Where("escalation_level", previousLevel).
+		Update(g.Map{
+			"severity":         alert.Severity,
+			"escalation_level": alert.EscalationLevel,
+			"escalated_at":     alert.EscalatedAt,
+			"updated_at":       alert.UpdatedAt,
+		})
+	if err != nil {
+		return false, fmt.Errorf("保存预警升级失败: %v", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
 // GetActiveAlertsCount 获取活跃预警数量
 func (r *monitoringRepository) GetActiveAlertsCount(ctx context.Context, merchantID *uint64) (int, error) {
 	tenantID := r.GetTenantID(ctx)
@@ -187,7 +235,7 @@ func (r *monitoringRepository) GetActiveAlertsCount(ctx context.Context, merchan
 		return 0, ErrTenantRequired
 	}
 
-	model := g.DB().Model("rights_alerts").
+	model := TenantDB(ctx).Model("rights_alerts").
 		Ctx(ctx).
 		Where("tenant_id", tenantID).
 		Where("status", types.AlertStatusActive)
@@ -207,7 +255,7 @@ func (r *monitoringRepository) GetCriticalAlertsCount(ctx context.Context, merch
 		return 0, ErrTenantRequired
 	}
 
-	model := g.DB().Model("rights_alerts").
+	model := TenantDB(ctx).Model("rights_alerts").
 		Ctx(ctx).
 		Where("tenant_id", tenantID).
 		Where("status", types.AlertStatusActive).
@@ -231,7 +279,7 @@ func (r *monitoringRepository) CreateUsageStats(ctx context.Context, stats *type
 	stats.TenantID = tenantID
 	stats.CreatedAt = time.Now()
 
-	_, err := g.DB().Model("rights_usage_stats").Ctx(ctx).Insert(stats)
+	_, err := TenantDB(ctx).Model("rights_usage_stats").Ctx(ctx).Insert(stats)
 	return err
 }
 
@@ -242,7 +290,7 @@ func (r *monitoringRepository) GetUsageStats(ctx context.Context, query *types.R
 		return nil, ErrTenantRequired
 	}
 
-	model := g.DB().Model("rights_usage_stats").
+	model := TenantDB(ctx).Model("rights_usage_stats").
 		Ctx(ctx).
 		Where("tenant_id", tenantID).
 		OrderDesc("stat_date")
@@ -277,7 +325,7 @@ func (r *monitoringRepository) GetUsageTrends(ctx context.Context, query *types.
 		days = *query.Days
 	}
 
-	model := g.DB().Model("rights_usage_stats").
+	model := TenantDB(ctx).Model("rights_usage_stats").
 		Ctx(ctx).
 		Where("tenant_id", tenantID).
 		Where("stat_date >= ?", gtime.Now().AddDate(0, 0, -days)).
@@ -317,7 +365,7 @@ func (r *monitoringRepository) GetMerchantUsageInfo(ctx context.Context, limit i
 		LIMIT ?`
 
 	var results []*types.MerchantUsageInfo
-	err := g.DB().Ctx(ctx).Raw(sql, tenantID, limit).Scan(&results)
+	err := TenantDB(ctx).Ctx(ctx).Raw(sql, tenantID, limit).Scan(&results)
 	return results, err
 }
 
@@ -347,7 +395,7 @@ func (r *monitoringRepository) GetDailyUsageTrends(ctx context.Context, days int
 	sql += " GROUP BY stat_date ORDER BY stat_date ASC"
 
 	var trends []*types.DailyUsageTrend
-	err := g.DB().Ctx(ctx).Raw(sql, params...).Scan(&trends)
+	err := TenantDB(ctx).Ctx(ctx).Raw(sql, params...).Scan(&trends)
 	return trends, err
 }
 
@@ -361,7 +409,7 @@ func (r *monitoringRepository) GetDashboardData(ctx context.Context, merchantID
 	data := &types.MonitoringDashboardData{}
 
 	// 获取商户总数
-	merchantCount, err := g.DB().Model("merchants").
+	merchantCount, err := TenantDB(ctx).Model("merchants").
 		Ctx(ctx).
 		Where("tenant_id", tenantID).
 		Where("status", "active").
@@ -393,9 +441,9 @@ func (r *monitoringRepository) GetDashboardData(ctx context.Context, merchantID
 		WHERE tenant_id = ? AND status = 'active'`
 	if merchantID != nil {
 		balanceSQL += " AND id = ?"
-		err = g.DB().Ctx(ctx).Raw(balanceSQL, tenantID, *merchantID).Scan(&totalBalance)
+		err = TenantDB(ctx).Ctx(ctx).Raw(balanceSQL, tenantID, *merchantID).Scan(&totalBalance)
 	} else {
-		err = g.DB().Ctx(ctx).Raw(balanceSQL, tenantID).Scan(&totalBalance)
+		err = TenantDB(ctx).Ctx(ctx).Raw(balanceSQL, tenantID).Scan(&totalBalance)
 	}
 	if err != nil {
 		return nil, err
@@ -412,9 +460,9 @@ func (r *monitoringRepository) GetDashboardData(ctx context.Context, merchantID
 	
 	if merchantID != nil {
 		avgUsageSQL += " AND merchant_id = ?"
-		err = g.DB().Ctx(ctx).Raw(avgUsageSQL, tenantID, types.TimePeriodDaily, *merchantID).Scan(&data.AvgDailyUsage)
+		err = TenantDB(ctx).Ctx(ctx).Raw(avgUsageSQL, tenantID, types.TimePeriodDaily, *merchantID).Scan(&data.AvgDailyUsage)
 	} else {
-		err = g.DB().Ctx(ctx).Raw(avgUsageSQL, tenantID, types.TimePeriodDaily).Scan(&data.AvgDailyUsage)
+		err = TenantDB(ctx).Ctx(ctx).Raw(avgUsageSQL, tenantID, types.TimePeriodDaily).Scan(&data.AvgDailyUsage)
 	}
 	if err != nil {
 		return nil, err
@@ -477,7 +525,7 @@ func (r *monitoringRepository) UpdateMerchantThresholds(ctx context.Context, mer
 	if warningThreshold != nil || criticalThreshold != nil {
 		// 先获取当前的rights_balance
 		var currentBalance string
-		err := g.DB().Model("merchants").
+		err := TenantDB(ctx).Model("merchants").
 			Ctx(ctx).
 			Where("id", merchantID).
 			Where("tenant_id", tenantID).
@@ -511,7 +559,7 @@ func (r *monitoringRepository) UpdateMerchantThresholds(ctx context.Context, mer
 		updateData["rights_balance"] = string(newBalanceJSON)
 	}
 
-	_, err := g.DB().Model("merchants").
+	_, err := TenantDB(ctx).Model("merchants").
 		Ctx(ctx).
 		Where("id", merchantID).
 		Where("tenant_id", tenantID).
@@ -528,7 +576,7 @@ func (r *monitoringRepository) GetMerchantThresholds(ctx context.Context, mercha
 	}
 
 	var balanceJSON string
-	err = g.DB().Model("merchants").
+	err = TenantDB(ctx).Model("merchants").
 		Ctx(ctx).
 		Where("id", merchantID).
 		Where("tenant_id", tenantID).
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// NotificationBrandingProvider 按订单所属商户获取客户通知品牌，返回已合并租户默认品牌的结果
+type NotificationBrandingProvider func(ctx context.Context, tenantID, merchantID uint64) (*types.NotificationBranding, error)
+
+// NewNotificationBrandingProvider 创建从商户配置和租户配置读取通知品牌的提供者，商户未配置的字段使用租户默认品牌
+func NewNotificationBrandingProvider(tenantRepo ITenantRepository, merchantRepo MerchantRepository) NotificationBrandingProvider {
+	return func(ctx context.Context, tenantID, merchantID uint64) (*types.NotificationBranding, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		var tenantBranding *types.NotificationBranding
+		if tenant != nil && tenant.Config != "" {
+			var config types.TenantConfig
+			if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+				return nil, fmt.Errorf("解析租户配置失败: %w", err)
+			}
+			tenantBranding = config.NotificationBranding
+		}
+
+		if merchantID == 0 {
+			return types.ResolveNotificationBranding(tenantBranding), nil
+		}
+
+		// 通知可能在后台任务中发送，商户查询需要租户上下文
+		merchant, err := merchantRepo.GetByID(context.WithValue(ctx, "tenant_id", tenantID), merchantID)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				g.Log().Warningf(ctx, "获取商户 %d 通知品牌失败，使用租户默认品牌: %v", merchantID, err)
+			}
+			return types.ResolveNotificationBranding(tenantBranding), nil
+		}
+		if merchant == nil {
+			return types.ResolveNotificationBranding(tenantBranding), nil
+		}
+		return types.ResolveNotificationBranding(tenantBranding, merchant.NotificationBranding), nil
+	}
+}
+
+// Resolve 获取商户生效的通知品牌，提供者为空或读取失败时使用默认品牌
+func (p NotificationBrandingProvider) Resolve(ctx context.Context, tenantID, merchantID uint64) *types.NotificationBranding {
+	if p == nil {
+		return types.DefaultNotificationBranding()
+	}
+
+	branding, err := p(ctx, tenantID, merchantID)
+	if err != nil {
+		g.Log().Warningf(ctx, "获取商户 %d 通知品牌失败，使用默认品牌: %v", merchantID, err)
+		return types.DefaultNotificationBranding()
+	}
+	if branding == nil {
+		return types.DefaultNotificationBranding()
+	}
+	return branding
+}
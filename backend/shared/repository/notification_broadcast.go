@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// INotificationBroadcastRepository 群发通知仓储接口
+type INotificationBroadcastRepository interface {
+	Create(ctx context.Context, broadcast *types.NotificationBroadcast) error
+	// 获取当前租户的群发通知，不存在时返回 nil
+	GetByID(ctx context.Context, id uint64) (*types.NotificationBroadcast, error)
+	// 获取当前租户的群发通知，按创建时间倒序
+	List(ctx context.Context, limit int) ([]types.NotificationBroadcast, error)
+	// 统计当前租户在 since 之后创建的群发通知数
+	CountSince(ctx context.Context, since time.Time) (int, error)
+	// 统计 until 之前注册且符合条件的接收人数及其中已退订的人数
+	CountRecipients(ctx context.Context, filter *types.BroadcastAudienceFilter, until time.Time) (total int, optedOut int, err error)
+	// 按用户ID顺序列出 until 之前注册、用户ID大于 afterUserID 且符合条件的接收人，包含退订状态
+	ListRecipients(ctx context.Context, filter *types.BroadcastAudienceFilter, until time.Time, afterUserID uint64, limit int) ([]types.BroadcastRecipient, error)
+	// 跨租户列出发送中的群发通知，仅供后台定时任务使用
+	ListSending(ctx context.Context, limit int) ([]types.NotificationBroadcast, error)
+	// 群发通知仍处于发送中且已处理到 fromUserID 时将进度推进到 toUserID，返回是否推进；
+	// 多个实例同时处理同一群发通知时只有一个能推进成功并发送这批接收人
+	ClaimBatch(ctx context.Context, broadcastID uint64, fromUserID, toUserID uint64) (bool, error)
+	// 在同一事务中保存一批发送记录并累加发送统计，completedAt 不为空时标记为已完成
+	RecordBatch(ctx context.Context, broadcastID uint64, deliveries []types.BroadcastDelivery, progress types.BroadcastProgress, completedAt *time.Time) error
+	// 获取群发通知的发送记录，status 不为空时只返回该状态的记录
+	ListDeliveries(ctx context.Context, broadcastID uint64, status types.BroadcastDeliveryStatus, limit int) ([]types.BroadcastDelivery, error)
+	// 获取用户的群发通知偏好，未设置时返回 nil
+	GetPreference(ctx context.Context, userID uint64) (*types.BroadcastPreference, error)
+	SavePreference(ctx context.Context, preference *types.BroadcastPreference) error
+}
+
+// NotificationBroadcastRepository 群发通知仓储实现
+type NotificationBroadcastRepository struct {
+	*BaseRepository
+}
+
+// NewNotificationBroadcastRepository 创建群发通知仓储实例
+func NewNotificationBroadcastRepository() INotificationBroadcastRepository {
+	return &NotificationBroadcastRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 创建群发通知
+func (r *NotificationBroadcastRepository) Create(ctx context.Context, broadcast *types.NotificationBroadcast) error {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return fmt.Errorf("missing tenant_id in context")
+	}
+
+	broadcast.TenantID = tenantID
+	if broadcast.CreatedAt.IsZero() {
+		broadcast.CreatedAt = gtime.Now().Time
+	}
+	id, err := TenantDB(ctx).Model("notification_broadcasts").Ctx(ctx).Data(gdb.Map{
+		"tenant_id":        tenantID,
+		"subject":          broadcast.Subject,
+		"content":          broadcast.Content,
+		"channels":         broadcast.Channels,
+		"filter":           broadcast.Filter,
+		"status":           broadcast.Status,
+		"created_by":       broadcast.CreatedBy,
+		"total_recipients": broadcast.TotalRecipients,
+		"created_at":       broadcast.CreatedAt,
+	}).InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("创建群发通知失败: %v", err)
+	}
+
+	broadcast.ID = uint64(id)
+	return nil
+}
+
+// GetByID 获取当前租户的群发通知
+func (r *NotificationBroadcastRepository) GetByID(ctx context.Context, id uint64) (*types.NotificationBroadcast, error) {
+	var broadcast *types.NotificationBroadcast
+	err := TenantDB(ctx).Model("notification_broadcasts").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", id, r.GetTenantID(ctx)).
+		Scan(&broadcast)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取群发通知失败: %v", err)
+	}
+	return broadcast, nil
+}
+
+// List 获取当前租户的群发通知
+func (r *NotificationBroadcastRepository) List(ctx context.Context, limit int) ([]types.NotificationBroadcast, error) {
+	var broadcasts []types.NotificationBroadcast
+	err := TenantDB(ctx).Model("notification_broadcasts").
+		Ctx(ctx).
+		Where("tenant_id = ?", r.GetTenantID(ctx)).
+		OrderDesc("id").
+		Limit(limit).
+		Scan(&broadcasts)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取群发通知列表失败: %v", err)
+	}
+	return broadcasts, nil
+}
+
+// CountSince 统计当前租户在 since 之后创建的群发通知数
+func (r *NotificationBroadcastRepository) CountSince(ctx context.Context, since time.Time) (int, error) {
+	count, err := TenantDB(ctx).Model("notification_broadcasts").
+		Ctx(ctx).
+		Where("tenant_id = ? AND created_at >= ?", r.GetTenantID(ctx), since).
+		Count()
+	if err != nil {
+		return 0, fmt.Errorf("统计群发通知失败: %v", err)
+	}
+	return count, nil
+}
+
+// CountRecipients 统计符合条件的接收人数及其中已退订的人数
+func (r *NotificationBroadcastRepository) CountRecipients(ctx context.Context, filter *types.BroadcastAudienceFilter, until time.Time) (int, int, error) {
+	total, err := r.recipientQuery(ctx, filter, until).Count()
+	if err != nil {
+		return 0, 0, fmt.Errorf("统计群发通知接收人失败: %v", err)
+	}
+	optedOut, err := r.recipientQuery(ctx, filter, until).Where("bp.opted_out = ?", true).Count()
+	if err != nil {
+		return 0, 0, fmt.Errorf("统计群发通知接收人失败: %v", err)
+	}
+	return total, optedOut, nil
+}
+
+// ListRecipients 按用户ID顺序列出符合条件的接收人
+func (r *NotificationBroadcastRepository) ListRecipients(ctx context.Context, filter *types.BroadcastAudienceFilter, until time.Time, afterUserID uint64, limit int) ([]types.BroadcastRecipient, error) {
+	var recipients []types.BroadcastRecipient
+	err := r.recipientQuery(ctx, filter, until).
+		Fields("u.id, u.username, COALESCE(bp.opted_out, 0) AS opted_out").
+		Where("u.id > ?", afterUserID).
+		OrderAsc("u.id").
+		Limit(limit).
+		Scan(&recipients)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询群发通知接收人失败: %v", err)
+	}
+	return recipients, nil
+}
+
+// recipientQuery 按接收对象和筛选条件查询当前租户的有效用户，并关联用户的群发通知偏好。
+// 客户为分配了客户角色且不属于商户、未匿名化的用户；商户为属于商户的用户。
+// 只包含 until 之前注册的用户，发送过程中新注册的用户不会收到该通知
+func (r *NotificationBroadcastRepository) recipientQuery(ctx context.Context, filter *types.BroadcastAudienceFilter, until time.Time) *gdb.Model {
+	tenantID := r.GetTenantID(ctx)
+	query := TenantDB(ctx).Model("users u").
+		Ctx(ctx).
+		LeftJoin("broadcast_preferences bp", "bp.tenant_id = u.tenant_id AND bp.user_id = u.id").
+		Where("u.tenant_id = ? AND u.status = ? AND u.created_at <= ?", tenantID, types.UserStatusActive, until)
+
+	if filter.Audience == types.BroadcastAudienceMerchants {
+		query = query.Where("u.merchant_id IS NOT NULL")
+		if len(filter.MerchantIDs) > 0 {
+			query = query.WhereIn("u.merchant_id", filter.MerchantIDs)
+		}
+	} else {
+		query = query.
+			Where("u.merchant_id IS NULL AND u.anonymized_at IS NULL").
+			Where("EXISTS (SELECT 1 FROM user_roles ur WHERE ur.tenant_id = u.tenant_id AND ur.user_id = u.id AND ur.role_type = ?)", types.RoleCustomer)
+		if len(filter.MerchantIDs) > 0 || filter.OrderedSince != nil {
+			orders := "SELECT 1 FROM orders o WHERE o.tenant_id = u.tenant_id AND o.customer_id = u.id"
+			var args []interface{}
+			if len(filter.MerchantIDs) > 0 {
+				orders += " AND o.merchant_id IN (?)"
+				args = append(args, filter.MerchantIDs)
+			}
+			if filter.OrderedSince != nil {
+				orders += " AND o.created_at >= ?"
+				args = append(args, *filter.OrderedSince)
+			}
+			query = query.Where("EXISTS ("+orders+")", args...)
+		}
+	}
+
+	if filter.RegisteredAfter != nil {
+		query = query.Where("u.created_at >= ?", *filter.RegisteredAfter)
+	}
+	if filter.RegisteredBefore != nil {
+		query = query.Where("u.created_at < ?", *filter.RegisteredBefore)
+	}
+	return query
+}
+
+// ListSending 按创建时间从早到晚列出发送中的群发通知
+func (r *NotificationBroadcastRepository) ListSending(ctx context.Context, limit int) ([]types.NotificationBroadcast, error) {
+	var broadcasts []types.NotificationBroadcast
+	err := TenantDB(ctx).Model("notification_broadcasts").
+		Ctx(ctx).
+		Where("status = ?", types.BroadcastStatusSending).
+		OrderAsc("id").
+		Limit(limit).
+		Scan(&broadcasts)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询发送中的群发通知失败: %v", err)
+	}
+	return broadcasts, nil
+}
+
+// ClaimBatch 推进群发通知的发送进度
+func (r *NotificationBroadcastRepository) ClaimBatch(ctx context.Context, broadcastID uint64, fromUserID, toUserID uint64) (bool, error) {
+	result, err := TenantDB(ctx).Model("notification_broadcasts").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ? AND status = ? AND cursor_user_id = ?",
+			broadcastID, r.GetTenantID(ctx), types.BroadcastStatusSending, fromUserID).
+		Update(gdb.Map{"cursor_user_id": toUserID})
+	if err != nil {
+		return false, fmt.Errorf("更新群发通知进度失败: %v", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// RecordBatch 保存一批发送记录并累加发送统计
+func (r *NotificationBroadcastRepository) RecordBatch(ctx context.Context, broadcastID uint64, deliveries []types.BroadcastDelivery, progress types.BroadcastProgress, completedAt *time.Time) error {
+	tenantID := r.GetTenantID(ctx)
+	now := gtime.Now().Time
+
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if len(deliveries) > 0 {
+			rows := make(gdb.List, 0, len(deliveries))
+			for i := range deliveries {
+				deliveries[i].TenantID = tenantID
+				deliveries[i].BroadcastID = broadcastID
+				deliveries[i].CreatedAt = now
+				rows = append(rows, gdb.Map{
+					"tenant_id":    tenantID,
+					"broadcast_id": broadcastID,
+					"user_id":      deliveries[i].UserID,
+					"channel":      deliveries[i].Channel,
+					"status":       deliveries[i].Status,
+					"error":        deliveries[i].Error,
+					"created_at":   now,
+				})
+			}
+			if _, err := tx.Model("broadcast_deliveries").Ctx(ctx).Data(rows).Insert(); err != nil {
+				return err
+			}
+		}
+
+		data := gdb.Map{
+			"processed_count": gdb.Raw(fmt.Sprintf("processed_count + %d", progress.Processed)),
+			"skipped_count":   gdb.Raw(fmt.Sprintf("skipped_count + %d", progress.Skipped)),
+			"sent_count":      gdb.Raw(fmt.Sprintf("sent_count + %d", progress.Sent)),
+			"failed_count":    gdb.Raw(fmt.Sprintf("failed_count + %d", progress.Failed)),
+		}
+		if completedAt != nil {
+			data["status"] = types.BroadcastStatusCompleted
+			data["completed_at"] = *completedAt
+		}
+		_, err := tx.Model("notification_broadcasts").Ctx(ctx).
+			Where("id = ? AND tenant_id = ?", broadcastID, tenantID).
+			Update(data)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("保存群发通知发送记录失败: %v", err)
+	}
+	return nil
+}
+
+// ListDeliveries 获取群发通知的发送记录，按接收人顺序
+func (r *NotificationBroadcastRepository) ListDeliveries(ctx context.Context, broadcastID uint64, status types.BroadcastDeliveryStatus, limit int) ([]types.BroadcastDelivery, error) {
+	query := TenantDB(ctx).Model("broadcast_deliveries").
+		Ctx(ctx).
+		Where("tenant_id = ? AND broadcast_id = ?", r.GetTenantID(ctx), broadcastID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []types.BroadcastDelivery
+	if err := query.OrderAsc("id").Limit(limit).Scan(&deliveries); err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取群发通知发送记录失败: %v", err)
+	}
+	return deliveries, nil
+}
+
+// GetPreference 获取当前租户用户的群发通知偏好
+func (r *NotificationBroadcastRepository) GetPreference(ctx context.Context, userID uint64) (*types.BroadcastPreference, error) {
+	var preference *types.BroadcastPreference
+	err := TenantDB(ctx).Model("broadcast_preferences").
+		Ctx(ctx).
+		Where("tenant_id = ? AND user_id = ?", r.GetTenantID(ctx), userID).
+		Scan(&preference)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取群发通知偏好失败: %v", err)
+	}
+	return preference, nil
+}
+
+// SavePreference 保存用户的群发通知偏好
+func (r *NotificationBroadcastRepository) SavePreference(ctx context.Context, preference *types.BroadcastPreference) error {
+	preference.TenantID = r.GetTenantID(ctx)
+	preference.UpdatedAt = gtime.Now().Time
+
+	_, err := TenantDB(ctx).Model("broadcast_preferences").
+		Ctx(ctx).
+		Data(gdb.Map{
+			"tenant_id":  preference.TenantID,
+			"user_id":    preference.UserID,
+			"opted_out":  preference.OptedOut,
+			"updated_at": preference.UpdatedAt,
+		}).
+		Save()
+	if err != nil {
+		return fmt.Errorf("保存群发通知偏好失败: %v", err)
+	}
+	return nil
+}
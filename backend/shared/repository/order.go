@@ -10,7 +10,6 @@ import (
 
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/database/gdb"
-	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gtime"
 )
 
@@ -20,29 +19,36 @@ type IOrderRepository interface {
 	GetByID(ctx context.Context, id uint64) (*types.Order, error)
 	GetByIDWithHistory(ctx context.Context, id uint64) (*types.Order, error)
 	GetByOrderNumber(ctx context.Context, orderNumber string) (*types.Order, error)
+	GetByExternalReference(ctx context.Context, reference string) (*types.Order, error)
 	List(ctx context.Context, customerID uint64, status types.OrderStatus, page, limit int) ([]*types.Order, int, error)
 	QueryList(ctx context.Context, req *types.OrderQueryRequest) (*types.OrderListResponse, error)
 	Update(ctx context.Context, order *types.Order) error
 	UpdateStatus(ctx context.Context, id uint64, status types.OrderStatus) error
 	UpdateStatusWithHistory(ctx context.Context, id uint64, status types.OrderStatusInt, reason string, operatorType types.OrderStatusOperatorType, operatorID *uint64, metadata interface{}) error
 	BatchUpdateStatus(ctx context.Context, req *types.BatchUpdateOrderStatusRequest, operatorID *uint64) (*types.BatchUpdateOrderStatusResponse, error)
-	GenerateOrderNumber(ctx context.Context) (string, error)
-	GetTimeoutOrders(ctx context.Context, timeoutConfig *types.OrderTimeoutConfig) ([]*types.Order, error)
+	GenerateOrderNumber(ctx context.Context, merchantID uint64) (string, error)
+	GetTimeoutOrders(ctx context.Context, status types.OrderStatus, deadline time.Time, afterID uint64, limit int) ([]*types.Order, error)
+	GetAutoCompleteCandidates(ctx context.Context, timeoutConfig *types.OrderTimeoutConfig, limit int) ([]*types.Order, error)
+	GetPendingPaymentOrders(ctx context.Context, updatedBefore, createdAfter time.Time, limit int) ([]*types.Order, error)
+	ListForRecompute(ctx context.Context, req *types.OrderRecomputeRequest, afterID uint64, limit int) ([]*types.Order, error)
+	UpdateDerivedFields(ctx context.Context, order *types.Order) error
 }
 
 // OrderRepository 订单仓储实现
 type OrderRepository struct {
 	*BaseRepository
+	sequences SequenceGenerator
 }
 
 // NewOrderRepository 创建订单仓储实例
 func NewOrderRepository() IOrderRepository {
 	return &OrderRepository{
 		BaseRepository: NewBaseRepository(),
+		sequences:      NewSequenceGenerator(),
 	}
 }
 
-// Create 创建订单
+// Create 创建订单，外部订单号与同一租户的其他订单重复时返回 types.ErrDuplicateExternalReference
 func (r *OrderRepository) Create(ctx context.Context, order *types.Order) error {
 	tenantID := r.GetTenantID(ctx)
 	
@@ -65,22 +71,54 @@ func (r *OrderRepository) Create(ctx context.Context, order *types.Order) error
 		verificationInfoJSON = string(verificationBytes)
 	}
 	
-	result, err := g.DB().Model("orders").Ctx(ctx).Insert(gdb.Map{
+	taxBreakdownJSON := "null"
+	if order.Tax != nil {
+		taxBytes, err := json.Marshal(order.Tax)
+		if err != nil {
+			return fmt.Errorf("序列化税费明细失败: %v", err)
+		}
+		taxBreakdownJSON = string(taxBytes)
+	}
+	
+	shippingAddressJSON := "null"
+	if order.ShippingAddress != nil {
+		addressBytes, err := json.Marshal(order.ShippingAddress)
+		if err != nil {
+			return fmt.Errorf("序列化收货地址失败: %v", err)
+		}
+		shippingAddressJSON = string(addressBytes)
+	}
+	
+	// 未设置外部订单号时保存为 NULL，不参与唯一约束
+	var externalReference interface{}
+	if order.ExternalReference != "" {
+		externalReference = order.ExternalReference
+	}
+	
+	result, err := TenantDB(ctx).Model("orders").Ctx(ctx).Insert(gdb.Map{
 		"tenant_id":           tenantID,
 		"merchant_id":         order.MerchantID,
 		"customer_id":         order.CustomerID,
 		"order_number":        order.OrderNumber,
+		"external_reference":  externalReference,
 		"status":              order.Status,
 		"items":               string(itemsJSON),
 		"payment_info":        string(paymentInfoJSON),
 		"verification_info":   verificationInfoJSON,
 		"total_amount":        order.TotalAmount,
 		"total_rights_cost":   order.TotalRightsCost,
+		"subtotal_amount":     order.SubtotalAmount,
+		"tax_amount":          order.TaxAmount,
+		"tax_breakdown":       taxBreakdownJSON,
+		"shipping_address":    shippingAddressJSON,
 		"created_at":          gtime.Now(),
 		"updated_at":          gtime.Now(),
 	})
 	
 	if err != nil {
+		if order.ExternalReference != "" && isDuplicateKeyError(err) {
+			return fmt.Errorf("%w: %s", types.ErrDuplicateExternalReference, order.ExternalReference)
+		}
 		return fmt.Errorf("创建订单失败: %v", err)
 	}
 	
@@ -99,12 +137,14 @@ func (r *OrderRepository) GetByID(ctx context.Context, id uint64) (*types.Order,
 	
 	var orderData struct {
 		types.Order
-		ItemsJSON           string `db:"items"`
-		PaymentInfoJSON     string `db:"payment_info"`
-		VerificationInfoJSON string `db:"verification_info"`
+		ItemsJSON           string `orm:"items"`
+		PaymentInfoJSON     string `orm:"payment_info"`
+		VerificationInfoJSON string `orm:"verification_info"`
+		TaxBreakdownJSON    string `orm:"tax_breakdown"`
+		ShippingAddressJSON string `orm:"shipping_address"`
 	}
 	
-	err := g.DB().Model("orders").Ctx(ctx).
+	err := TenantDB(ctx).Model("orders").Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", id, tenantID).
 		Scan(&orderData)
 	
@@ -134,6 +174,20 @@ func (r *OrderRepository) GetByID(ctx context.Context, id uint64) (*types.Order,
 		}
 	}
 	
+	// 反序列化税费明细
+	if orderData.TaxBreakdownJSON != "" && orderData.TaxBreakdownJSON != "null" {
+		if err := json.Unmarshal([]byte(orderData.TaxBreakdownJSON), &orderData.Order.Tax); err != nil {
+			return nil, fmt.Errorf("反序列化税费明细失败: %v", err)
+		}
+	}
+	
+	// 反序列化收货地址
+	if orderData.ShippingAddressJSON != "" && orderData.ShippingAddressJSON != "null" {
+		if err := json.Unmarshal([]byte(orderData.ShippingAddressJSON), &orderData.Order.ShippingAddress); err != nil {
+			return nil, fmt.Errorf("反序列化收货地址失败: %v", err)
+		}
+	}
+	
 	return &orderData.Order, nil
 }
 
@@ -143,18 +197,20 @@ func (r *OrderRepository) GetByOrderNumber(ctx context.Context, orderNumber stri
 	
 	var orderData struct {
 		types.Order
-		ItemsJSON           string `db:"items"`
-		PaymentInfoJSON     string `db:"payment_info"`
-		VerificationInfoJSON string `db:"verification_info"`
+		ItemsJSON           string `orm:"items"`
+		PaymentInfoJSON     string `orm:"payment_info"`
+		VerificationInfoJSON string `orm:"verification_info"`
+		TaxBreakdownJSON    string `orm:"tax_breakdown"`
+		ShippingAddressJSON string `orm:"shipping_address"`
 	}
 	
-	err := g.DB().Model("orders").Ctx(ctx).
+	err := TenantDB(ctx).Model("orders").Ctx(ctx).
 		Where("order_number = ? AND tenant_id = ?", orderNumber, tenantID).
 		Scan(&orderData)
 	
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("订单不存在")
+			return nil, fmt.Errorf("%w: 订单号 %s", types.ErrOrderReferenceNotFound, orderNumber)
 		}
 		return nil, fmt.Errorf("查询订单失败: %v", err)
 	}
@@ -178,14 +234,49 @@ func (r *OrderRepository) GetByOrderNumber(ctx context.Context, orderNumber stri
 		}
 	}
 	
+	// 反序列化税费明细
+	if orderData.TaxBreakdownJSON != "" && orderData.TaxBreakdownJSON != "null" {
+		if err := json.Unmarshal([]byte(orderData.TaxBreakdownJSON), &orderData.Order.Tax); err != nil {
+			return nil, fmt.Errorf("反序列化税费明细失败: %v", err)
+		}
+	}
+	
+	// 反序列化收货地址
+	if orderData.ShippingAddressJSON != "" && orderData.ShippingAddressJSON != "null" {
+		if err := json.Unmarshal([]byte(orderData.ShippingAddressJSON), &orderData.Order.ShippingAddress); err != nil {
+			return nil, fmt.Errorf("反序列化收货地址失败: %v", err)
+		}
+	}
+	
 	return &orderData.Order, nil
 }
 
+// GetByExternalReference 根据外部订单号获取订单
+func (r *OrderRepository) GetByExternalReference(ctx context.Context, reference string) (*types.Order, error) {
+	tenantID := r.GetTenantID(ctx)
+	
+	value, err := TenantDB(ctx).Model("orders").Ctx(ctx).
+		Where("tenant_id = ? AND external_reference = ?", tenantID, reference).
+		Value("id")
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %v", err)
+	}
+	if value.IsEmpty() {
+		return nil, fmt.Errorf("%w: 外部订单号 %s", types.ErrOrderReferenceNotFound, reference)
+	}
+	return r.GetByID(ctx, value.Uint64())
+}
+
+// isDuplicateKeyError 是否为违反唯一约束的写入错误
+func isDuplicateKeyError(err error) bool {
+	return strings.Contains(err.Error(), "Duplicate entry")
+}
+
 // List 获取订单列表
 func (r *OrderRepository) List(ctx context.Context, customerID uint64, status types.OrderStatus, page, limit int) ([]*types.Order, int, error) {
 	tenantID := r.GetTenantID(ctx)
 	
-	query := g.DB().Model("orders").Ctx(ctx).Where("tenant_id = ?", tenantID)
+	query := TenantDB(ctx).Model("orders").Ctx(ctx).Where("tenant_id = ?", tenantID)
 	
 	if customerID > 0 {
 		query = query.Where("customer_id = ?", customerID)
@@ -207,6 +298,8 @@ func (r *OrderRepository) List(ctx context.Context, customerID uint64, status ty
 		ItemsJSON           string `db:"items"`
 		PaymentInfoJSON     string `db:"payment_info"`
 		VerificationInfoJSON string `db:"verification_info"`
+		TaxBreakdownJSON    string `db:"tax_breakdown"`
+		ShippingAddressJSON string `db:"shipping_address"`
 	}
 	
 	err = query.Order("created_at DESC").
@@ -240,6 +333,20 @@ func (r *OrderRepository) List(ctx context.Context, customerID uint64, status ty
 			}
 		}
 		
+		// 反序列化税费明细
+		if orderData.TaxBreakdownJSON != "" && orderData.TaxBreakdownJSON != "null" {
+			if err := json.Unmarshal([]byte(orderData.TaxBreakdownJSON), &orderData.Order.Tax); err != nil {
+				return nil, 0, fmt.Errorf("反序列化税费明细失败: %v", err)
+			}
+		}
+		
+		// 反序列化收货地址
+		if orderData.ShippingAddressJSON != "" && orderData.ShippingAddressJSON != "null" {
+			if err := json.Unmarshal([]byte(orderData.ShippingAddressJSON), &orderData.Order.ShippingAddress); err != nil {
+				return nil, 0, fmt.Errorf("反序列化收货地址失败: %v", err)
+			}
+		}
+		
 		orders = append(orders, &orderData.Order)
 	}
 	
@@ -269,7 +376,25 @@ func (r *OrderRepository) Update(ctx context.Context, order *types.Order) error
 		verificationInfoJSON = string(verificationBytes)
 	}
 	
-	_, err = g.DB().Model("orders").Ctx(ctx).
+	taxBreakdownJSON := "null"
+	if order.Tax != nil {
+		taxBytes, err := json.Marshal(order.Tax)
+		if err != nil {
+			return fmt.Errorf("序列化税费明细失败: %v", err)
+		}
+		taxBreakdownJSON = string(taxBytes)
+	}
+	
+	shippingAddressJSON := "null"
+	if order.ShippingAddress != nil {
+		addressBytes, err := json.Marshal(order.ShippingAddress)
+		if err != nil {
+			return fmt.Errorf("序列化收货地址失败: %v", err)
+		}
+		shippingAddressJSON = string(addressBytes)
+	}
+	
+	_, err = TenantDB(ctx).Model("orders").Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", order.ID, tenantID).
 		Update(gdb.Map{
 			"merchant_id":         order.MerchantID,
@@ -281,6 +406,10 @@ func (r *OrderRepository) Update(ctx context.Context, order *types.Order) error
 			"verification_info":   verificationInfoJSON,
 			"total_amount":        order.TotalAmount,
 			"total_rights_cost":   order.TotalRightsCost,
+			"subtotal_amount":     order.SubtotalAmount,
+			"tax_amount":          order.TaxAmount,
+			"tax_breakdown":       taxBreakdownJSON,
+			"shipping_address":    shippingAddressJSON,
 			"updated_at":          gtime.Now(),
 		})
 	
@@ -295,7 +424,7 @@ func (r *OrderRepository) Update(ctx context.Context, order *types.Order) error
 func (r *OrderRepository) UpdateStatus(ctx context.Context, id uint64, status types.OrderStatus) error {
 	tenantID := r.GetTenantID(ctx)
 	
-	_, err := g.DB().Model("orders").Ctx(ctx).
+	_, err := TenantDB(ctx).Model("orders").Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", id, tenantID).
 		Update(gdb.Map{
 			"status":     status,
@@ -309,22 +438,33 @@ func (r *OrderRepository) UpdateStatus(ctx context.Context, id uint64, status ty
 	return nil
 }
 
-// GenerateOrderNumber 生成订单号
-func (r *OrderRepository) GenerateOrderNumber(ctx context.Context) (string, error) {
-	// 订单号格式：年月日时分秒 + 6位随机数
-	now := gtime.Now()
-	timePrefix := now.Format("20060102150405")
-	
-	// 使用数据库的自增特性来确保唯一性
-	count, err := g.DB().Model("orders").Ctx(ctx).
-		Where("created_at >= ?", now.Format("2006-01-02 00:00:00")).
-		Count()
-	
+// GenerateOrderNumber 按租户配置的订单号格式生成订单号，未配置格式时使用默认格式。
+// 序号按订单号中序号之前的部分分配全局序号，保证不同租户生成的订单号也不会重复
+func (r *OrderRepository) GenerateOrderNumber(ctx context.Context, merchantID uint64) (string, error) {
+	formatRepo := NewOrderNumberFormatRepository()
+	format, err := formatRepo.GetEffectiveFormat(ctx)
 	if err != nil {
-		return "", fmt.Errorf("查询订单计数失败: %v", err)
+		return "", err
 	}
-	
-	return fmt.Sprintf("%s%06d", timePrefix, count+1), nil
+
+	var merchantCode string
+	if format.IncludeMerchantCode {
+		code, err := TenantDB(ctx).Model("merchants").Ctx(ctx).
+			Where("id = ? AND tenant_id = ?", merchantID, r.GetTenantID(ctx)).
+			Value("code")
+		if err != nil {
+			return "", fmt.Errorf("查询商户编码失败: %v", err)
+		}
+		merchantCode = code.String()
+	}
+
+	now := time.Now()
+	sequence, err := r.sequences.Next(ctx, 0, OrderNumberSequenceKey(format.SequenceScope(now, merchantCode)))
+	if err != nil {
+		return "", fmt.Errorf("分配订单号序号失败: %v", err)
+	}
+
+	return format.Render(now, merchantCode, sequence)
 }
 
 // GetByIDWithHistory 根据ID获取订单（包含状态历史）
@@ -350,7 +490,7 @@ func (r *OrderRepository) QueryList(ctx context.Context, req *types.OrderQueryRe
 	tenantID := r.GetTenantID(ctx)
 	
 	// 构建查询条件
-	query := g.DB().Model("orders o").Ctx(ctx).Where("o.tenant_id = ?", tenantID)
+	query := TenantDB(ctx).Model("orders o").Ctx(ctx).Where("o.tenant_id = ?", tenantID)
 	
 	if req.MerchantID != nil {
 		query = query.Where("o.merchant_id = ?", *req.MerchantID)
@@ -381,6 +521,14 @@ func (r *OrderRepository) QueryList(ctx context.Context, req *types.OrderQueryRe
 		query = query.Where("(o.order_number LIKE ? OR JSON_UNQUOTE(JSON_EXTRACT(o.items, '$[*].product_name')) LIKE ?)", keyword, keyword)
 	}
 	
+	if req.ExternalReference != nil && *req.ExternalReference != "" {
+		query = query.Where("o.external_reference = ?", *req.ExternalReference)
+	}
+	
+	if len(req.Tags) > 0 {
+		query = query.Where(orderTagCondition(len(req.Tags)), orderTagParams(tenantID, req.Tags)...)
+	}
+	
 	// 获取总数
 	total, err := query.Count()
 	if err != nil {
@@ -390,12 +538,12 @@ func (r *OrderRepository) QueryList(ctx context.Context, req *types.OrderQueryRe
 	// 构建完整查询（包含用户和商户名称）
 	// 构建查询参数
 	queryParams := []interface{}{tenantID}
-	queryParams = append(queryParams, r.buildWhereParams(req)...)
+	queryParams = append(queryParams, r.buildWhereParams(tenantID, req)...)
 	queryParams = append(queryParams, req.PageSize, (req.Page-1)*req.PageSize)
 	
-	fullQuery := g.DB().Ctx(ctx).Raw(`
+	fullQuery := TenantDB(ctx).Ctx(ctx).Raw(`
 		SELECT 
-			o.id, o.order_number, o.status, o.total_amount, o.created_at, o.updated_at,
+			o.id, o.order_number, o.external_reference, o.status, o.total_amount, o.created_at, o.updated_at,
 			COUNT(JSON_EXTRACT(o.items, '$[*]')) as item_count,
 			u.username as customer_name,
 			m.name as merchant_name
@@ -425,11 +573,20 @@ func (r *OrderRepository) QueryList(ctx context.Context, req *types.OrderQueryRe
 		return nil, fmt.Errorf("获取订单状态历史失败: %v", err)
 	}
 	
-	// 填充状态历史
+	tagMap, err := NewOrderTagRepository().ListByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, fmt.Errorf("获取订单标签失败: %v", err)
+	}
+	
+	// 填充状态历史和标签
 	for i := range summaries {
 		if history, exists := historyMap[summaries[i].ID]; exists {
 			summaries[i].LatestStatusChange = history
 		}
+		summaries[i].Tags = tagMap[summaries[i].ID]
+		if summaries[i].Tags == nil {
+			summaries[i].Tags = []string{}
+		}
 	}
 	
 	return &types.OrderListResponse{
@@ -471,11 +628,19 @@ func (r *OrderRepository) buildWhereClause(req *types.OrderQueryRequest) string
 		conditions = append(conditions, "AND (o.order_number LIKE ? OR JSON_UNQUOTE(JSON_EXTRACT(o.items, '$[*].product_name')) LIKE ?)")
 	}
 	
+	if req.ExternalReference != nil && *req.ExternalReference != "" {
+		conditions = append(conditions, "AND o.external_reference = ?")
+	}
+	
+	if len(req.Tags) > 0 {
+		conditions = append(conditions, "AND "+orderTagCondition(len(req.Tags)))
+	}
+	
 	return strings.Join(conditions, " ")
 }
 
 // buildWhereParams 构建WHERE参数
-func (r *OrderRepository) buildWhereParams(req *types.OrderQueryRequest) []interface{} {
+func (r *OrderRepository) buildWhereParams(tenantID uint64, req *types.OrderQueryRequest) []interface{} {
 	params := []interface{}{}
 	
 	if req.MerchantID != nil {
@@ -505,10 +670,34 @@ func (r *OrderRepository) buildWhereParams(req *types.OrderQueryRequest) []inter
 		params = append(params, keyword, keyword)
 	}
 	
+	if req.ExternalReference != nil && *req.ExternalReference != "" {
+		params = append(params, *req.ExternalReference)
+	}
+	
+	if len(req.Tags) > 0 {
+		params = append(params, orderTagParams(tenantID, req.Tags)...)
+	}
+	
 	return params
 }
 
-// UpdateStatusWithHistory 更新订单状态并记录历史
+// orderTagCondition 订单同时带有全部指定标签的查询条件
+func orderTagCondition(tagCount int) string {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", tagCount), ",")
+	return "o.id IN (SELECT order_id FROM order_tags WHERE tenant_id = ? AND tag IN (" + placeholders + ") GROUP BY order_id HAVING COUNT(DISTINCT tag) = ?)"
+}
+
+// orderTagParams 标签查询条件的参数
+func orderTagParams(tenantID uint64, tags []string) []interface{} {
+	params := []interface{}{tenantID}
+	for _, tag := range tags {
+		params = append(params, tag)
+	}
+	return append(params, len(tags))
+}
+
+// UpdateStatusWithHistory 更新订单状态并记录历史。按校验时读取的状态条件更新，
+// 校验后状态已被其他请求修改时不写入历史，返回 types.ErrOrderStatusConflict
 func (r *OrderRepository) UpdateStatusWithHistory(ctx context.Context, id uint64, status types.OrderStatusInt, reason string, operatorType types.OrderStatusOperatorType, operatorID *uint64, metadata interface{}) error {
 	// 获取当前订单状态
 	currentOrder, err := r.GetByID(ctx, id)
@@ -524,53 +713,59 @@ func (r *OrderRepository) UpdateStatusWithHistory(ctx context.Context, id uint64
 		return fmt.Errorf("不允许从状态 %s 转换到 %s", currentStatusInt.String(), status.String())
 	}
 	
-	// 开启事务
-	tx, err := g.DB().Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("开启事务失败: %v", err)
+	// 预订商品补货释放前订单保持在当前状态，补货后由库存释放转为处理中
+	if status == types.OrderStatusIntProcessing && currentOrder.HasBackorderedItems() {
+		return fmt.Errorf("%w: 订单 %d 补货前不能转为处理中", types.ErrOrderOnBackorderHold, id)
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
-	}()
 	
-	// 更新订单状态
-	newStatus := status.ToOrderStatus()
 	tenantID := r.GetTenantID(ctx)
-	
-	_, err = tx.Model("orders").Ctx(ctx).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Update(gdb.Map{
-			"status":            newStatus,
-			"status_updated_at": gtime.Now(),
-			"updated_at":       gtime.Now(),
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		// 只有状态仍为校验时的状态才更新，并发的状态变更只有一个生效
+		result, err := tx.Model("orders").Ctx(ctx).
+			Where("id = ? AND tenant_id = ? AND status = ?", id, tenantID, currentOrder.Status).
+			Update(gdb.Map{
+				"status":            status.ToOrderStatus(),
+				"status_updated_at": gtime.Now(),
+				"updated_at":        gtime.Now(),
+			})
+		if err != nil {
+			return fmt.Errorf("更新订单状态失败: %v", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("更新订单状态失败: %v", err)
+		}
+		if affected == 0 {
+			return fmt.Errorf("%w: 订单 %d 已不是 %s 状态", types.ErrOrderStatusConflict, id, currentStatusInt.String())
+		}
+		
+		// 创建状态历史记录
+		history := &types.OrderStatusHistory{
+			TenantID:     tenantID,
+			OrderID:      id,
+			FromStatus:   currentStatusInt,
+			ToStatus:     status,
+			Reason:       reason,
+			OperatorID:   operatorID,
+			OperatorType: operatorType,
+			Metadata:     metadata,
+			CreatedAt:    time.Now(),
+		}
+		
+		if _, err := tx.Model("order_status_history").Ctx(ctx).Data(history).Insert(); err != nil {
+			return fmt.Errorf("创建状态历史记录失败: %v", err)
+		}
+		
+		// 在同一事务中写入状态变更事件，由发件箱投递器异步发送通知
+		return insertOutboxEvent(ctx, tx, tenantID, "order", id, types.OutboxEventOrderStatusChanged, &types.OrderStatusChangedEvent{
+			OrderID:      id,
+			FromStatus:   currentStatusInt,
+			ToStatus:     status,
+			Reason:       reason,
+			OperatorID:   operatorID,
+			OperatorType: operatorType,
 		})
-	if err != nil {
-		return fmt.Errorf("更新订单状态失败: %v", err)
-	}
-	
-	// 创建状态历史记录
-	history := &types.OrderStatusHistory{
-		TenantID:     tenantID,
-		OrderID:      id,
-		FromStatus:   currentStatusInt,
-		ToStatus:     status,
-		Reason:       reason,
-		OperatorID:   operatorID,
-		OperatorType: operatorType,
-		Metadata:     metadata,
-		CreatedAt:    time.Now(),
-	}
-	
-	_, err = tx.Model("order_status_history").Ctx(ctx).Data(history).Insert()
-	if err != nil {
-		return fmt.Errorf("创建状态历史记录失败: %v", err)
-	}
-	
-	return nil
+	})
 }
 
 // orderStatusToInt 将字符串状态转换为数字状态
@@ -598,7 +793,7 @@ func (r *OrderRepository) BatchUpdateStatus(ctx context.Context, req *types.Batc
 	}
 	
 	// 开启事务
-	tx, err := g.DB().Begin(ctx)
+	tx, err := TenantDB(ctx).Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("开启事务失败: %v", err)
 	}
@@ -635,42 +830,153 @@ func (r *OrderRepository) BatchUpdateStatus(ctx context.Context, req *types.Batc
 	return response, nil
 }
 
-// GetTimeoutOrders 获取超时的订单
-func (r *OrderRepository) GetTimeoutOrders(ctx context.Context, timeoutConfig *types.OrderTimeoutConfig) ([]*types.Order, error) {
-	tenantID := r.GetTenantID(ctx)
+// GetTimeoutOrders 跨租户获取超时订单，供超时处理任务分批处理。按订单ID升序返回 afterID 之后的最多 limit 个订单，
+// 先创建的订单先处理；待支付订单按创建时间早于 deadline 判断，处理中订单按最后一次状态变更早于 deadline 判断
+func (r *OrderRepository) GetTimeoutOrders(ctx context.Context, status types.OrderStatus, deadline time.Time, afterID uint64, limit int) ([]*types.Order, error) {
+	query := TenantDB(ctx).Model("orders").Ctx(ctx).
+		Where("status = ?", status).
+		Where("id > ?", afterID)
 	
-	// 计算超时时间点
-	now := time.Now()
-	paymentTimeout := now.Add(-time.Duration(timeoutConfig.PaymentTimeoutMinutes) * time.Minute)
-	processingTimeout := now.Add(-time.Duration(timeoutConfig.ProcessingTimeoutHours) * time.Hour)
+	switch status {
+	case types.OrderStatusPending:
+		query = query.Where("created_at < ?", deadline.Format("2006-01-02 15:04:05")).
+			// 风险审核中的订单不计算支付超时，审核通过的订单从通过时起重新计算
+			Where("id NOT IN (SELECT order_id FROM order_reviews WHERE status = ? OR (status = ? AND decided_at >= ?))",
+				types.OrderReviewStatusPending, types.OrderReviewStatusApproved, deadline.Format("2006-01-02 15:04:05"))
+	case types.OrderStatusProcessing:
+		query = query.Where("status_updated_at < ?", deadline.Format("2006-01-02 15:04:05"))
+	default:
+		return nil, fmt.Errorf("不支持的订单状态超时检查: %s", status)
+	}
 	
-	var orderDataList []struct {
-		types.Order
-		ItemsJSON           string `db:"items"`
-		PaymentInfoJSON     string `db:"payment_info"`
-		VerificationInfoJSON string `db:"verification_info"`
+	var orderDataList []orderRow
+	err := query.OrderAsc("id").Limit(limit).Scan(&orderDataList)
+	if err != nil {
+		return nil, fmt.Errorf("查询超时订单失败: %v", err)
 	}
 	
-	// 查找超时的订单
-	query := g.DB().Model("orders").Ctx(ctx).Where("tenant_id = ?", tenantID)
+	return decodeOrderRows(orderDataList)
+}
+
+// GetAutoCompleteCandidates 获取可自动完成的订单：处理中、最后一次状态变更早于自动完成等待时长、不在等待核销且没有未结束的争议。
+// 租户默认配置不覆盖已有商户级配置的商户。待核销订单在查询中排除，避免占满一批候选后其他订单一直无法自动完成
+func (r *OrderRepository) GetAutoCompleteCandidates(ctx context.Context, timeoutConfig *types.OrderTimeoutConfig, limit int) ([]*types.Order, error) {
+	deadline := time.Now().Add(-time.Duration(timeoutConfig.AutoCompleteAfterHours) * time.Hour)
+	
+	query := TenantDB(ctx).Model("orders").Ctx(ctx).
+		Where("tenant_id = ?", timeoutConfig.TenantID).
+		Where("status = ?", types.OrderStatusProcessing).
+		Where("status_updated_at < ?", deadline.Format("2006-01-02 15:04:05")).
+		Where("id NOT IN (SELECT order_id FROM order_disputes WHERE tenant_id = ? AND status IN (?,?))",
+			timeoutConfig.TenantID, types.OrderDisputeStatusOpen, types.OrderDisputeStatusInvestigating).
+		// 与 Order.IsAwaitingVerification 一致：有核销信息但尚未核销的订单需由商户核销后完成
+		Where("(verification_info IS NULL OR JSON_TYPE(verification_info) != 'OBJECT' OR JSON_EXTRACT(verification_info, '$.verified_at') IS NOT NULL)")
 	
 	if timeoutConfig.MerchantID != nil {
 		query = query.Where("merchant_id = ?", *timeoutConfig.MerchantID)
+	} else {
+		query = query.Where("merchant_id NOT IN (SELECT merchant_id FROM order_timeout_configs WHERE tenant_id = ? AND merchant_id IS NOT NULL)",
+			timeoutConfig.TenantID)
 	}
 	
-	// 查找待支付超时的订单或处理中超时的订单
-	query = query.Where("(status = ? AND status_updated_at < ?) OR (status = ? AND status_updated_at < ?)",
-		types.OrderStatusIntPending, paymentTimeout.Format("2006-01-02 15:04:05"),
-		types.OrderStatusIntProcessing, processingTimeout.Format("2006-01-02 15:04:05"))
+	var orderDataList []orderRow
+	err := query.OrderAsc("status_updated_at").Limit(limit).Scan(&orderDataList)
+	if err != nil {
+		return nil, fmt.Errorf("查询待自动完成订单失败: %v", err)
+	}
 	
-	err := query.Scan(&orderDataList)
+	return decodeOrderRows(orderDataList)
+}
+
+// GetPendingPaymentOrders 跨租户获取已发起支付但仍待支付的订单，供支付对账使用。
+// updatedBefore 排除刚发起支付、回调可能仍在途中的订单，createdAfter 限制回溯范围
+func (r *OrderRepository) GetPendingPaymentOrders(ctx context.Context, updatedBefore, createdAfter time.Time, limit int) ([]*types.Order, error) {
+	var orderDataList []orderRow
+	err := TenantDB(ctx).Model("orders").Ctx(ctx).
+		Where("status = ?", types.OrderStatusPending).
+		Where("payment_info IS NOT NULL AND payment_info != 'null'").
+		Where("updated_at < ?", updatedBefore.Format("2006-01-02 15:04:05")).
+		Where("created_at > ?", createdAfter.Format("2006-01-02 15:04:05")).
+		OrderAsc("updated_at").
+		Limit(limit).
+		Scan(&orderDataList)
 	if err != nil {
-		return nil, fmt.Errorf("查询超时订单失败: %v", err)
+		return nil, fmt.Errorf("查询待对账订单失败: %v", err)
 	}
 	
-	// 构造返回结果
+	return decodeOrderRows(orderDataList)
+}
+
+// ListForRecompute 按下单时间范围和状态获取当前租户的订单，供重算派生字段分批处理。
+// 按订单ID升序返回 afterID 之后的最多 limit 个订单
+func (r *OrderRepository) ListForRecompute(ctx context.Context, req *types.OrderRecomputeRequest, afterID uint64, limit int) ([]*types.Order, error) {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return nil, fmt.Errorf("missing tenant_id in context")
+	}
+	
+	query := TenantDB(ctx).Model("orders").Ctx(ctx).
+		Where("tenant_id = ?", tenantID).
+		Where("id > ?", afterID).
+		Where("created_at >= ?", req.StartDate.Format("2006-01-02 15:04:05")).
+		Where("created_at < ?", req.EndDate.Format("2006-01-02 15:04:05"))
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	
+	var orderDataList []orderRow
+	if err := query.OrderAsc("id").Limit(limit).Scan(&orderDataList); err != nil {
+		return nil, fmt.Errorf("查询待重算订单失败: %v", err)
+	}
+	
+	return decodeOrderRows(orderDataList)
+}
+
+// UpdateDerivedFields 只保存订单金额、权益和税费等派生字段，不修改订单项、状态等源数据
+func (r *OrderRepository) UpdateDerivedFields(ctx context.Context, order *types.Order) error {
+	tenantID := r.GetTenantID(ctx)
+	
+	taxBreakdownJSON := "null"
+	if order.Tax != nil {
+		taxBytes, err := json.Marshal(order.Tax)
+		if err != nil {
+			return fmt.Errorf("序列化税费明细失败: %v", err)
+		}
+		taxBreakdownJSON = string(taxBytes)
+	}
+	
+	_, err := TenantDB(ctx).Model("orders").Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", order.ID, tenantID).
+		Update(gdb.Map{
+			"total_amount":      order.TotalAmount,
+			"total_rights_cost": order.TotalRightsCost,
+			"subtotal_amount":   order.SubtotalAmount,
+			"tax_amount":        order.TaxAmount,
+			"tax_breakdown":     taxBreakdownJSON,
+			"updated_at":        gtime.Now(),
+		})
+	if err != nil {
+		return fmt.Errorf("更新订单派生字段失败: %v", err)
+	}
+	return nil
+}
+
+// orderRow 订单表原始行（JSON字段未解析）
+type orderRow struct {
+	types.Order
+	ItemsJSON            string `db:"items"`
+	PaymentInfoJSON      string `db:"payment_info"`
+	VerificationInfoJSON string `db:"verification_info"`
+	TaxBreakdownJSON     string `db:"tax_breakdown"`
+	ShippingAddressJSON  string `db:"shipping_address"`
+}
+
+// decodeOrderRows 解析订单行中的JSON字段
+func decodeOrderRows(orderDataList []orderRow) ([]*types.Order, error) {
 	orders := make([]*types.Order, 0, len(orderDataList))
-	for _, orderData := range orderDataList {
+	for i := range orderDataList {
+		orderData := &orderDataList[i]
+		
 		// 反序列化订单项
 		if err := json.Unmarshal([]byte(orderData.ItemsJSON), &orderData.Order.Items); err != nil {
 			return nil, fmt.Errorf("反序列化订单项失败: %v", err)
@@ -690,6 +996,20 @@ func (r *OrderRepository) GetTimeoutOrders(ctx context.Context, timeoutConfig *t
 			}
 		}
 		
+		// 反序列化税费明细
+		if orderData.TaxBreakdownJSON != "" && orderData.TaxBreakdownJSON != "null" {
+			if err := json.Unmarshal([]byte(orderData.TaxBreakdownJSON), &orderData.Order.Tax); err != nil {
+				return nil, fmt.Errorf("反序列化税费明细失败: %v", err)
+			}
+		}
+		
+		// 反序列化收货地址
+		if orderData.ShippingAddressJSON != "" && orderData.ShippingAddressJSON != "null" {
+			if err := json.Unmarshal([]byte(orderData.ShippingAddressJSON), &orderData.Order.ShippingAddress); err != nil {
+				return nil, fmt.Errorf("反序列化收货地址失败: %v", err)
+			}
+		}
+		
 		orders = append(orders, &orderData.Order)
 	}
 	
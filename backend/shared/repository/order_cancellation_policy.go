@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// IOrderCancellationPolicyRepository 订单取消策略仓储接口
+type IOrderCancellationPolicyRepository interface {
+	GetEffectivePolicy(ctx context.Context, merchantID uint64) (*types.OrderCancellationPolicy, error)
+	Save(ctx context.Context, policy *types.OrderCancellationPolicy) error
+}
+
+// OrderCancellationPolicyRepository 订单取消策略数据访问层
+type OrderCancellationPolicyRepository struct {
+	*BaseRepository
+}
+
+// NewOrderCancellationPolicyRepository 创建订单取消策略仓库实例
+func NewOrderCancellationPolicyRepository() IOrderCancellationPolicyRepository {
+	return &OrderCancellationPolicyRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// GetEffectivePolicy 获取有效的取消策略（优先商户级策略，其次租户默认策略，都未配置时使用系统默认值）
+func (r *OrderCancellationPolicyRepository) GetEffectivePolicy(ctx context.Context, merchantID uint64) (*types.OrderCancellationPolicy, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	var policy types.OrderCancellationPolicy
+	err := TenantDB(ctx).Model("order_cancellation_policies").
+		Ctx(ctx).
+		Where("tenant_id = ?", tenantID).
+		Where("merchant_id = ? OR merchant_id IS NULL", merchantID).
+		OrderDesc("merchant_id").
+		Limit(1).
+		Scan(&policy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.DefaultOrderCancellationPolicy(tenantID), nil
+		}
+		return nil, fmt.Errorf("获取订单取消策略失败: %v", err)
+	}
+
+	return &policy, nil
+}
+
+// Save 保存取消策略，已存在同一租户/商户的策略时覆盖
+func (r *OrderCancellationPolicyRepository) Save(ctx context.Context, policy *types.OrderCancellationPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	policy.TenantID = r.GetTenantID(ctx)
+
+	_, err := TenantDB(ctx).Model("order_cancellation_policies").
+		Ctx(ctx).
+		Data(g.Map{
+			"tenant_id":                policy.TenantID,
+			"merchant_id":              policy.MerchantID,
+			"customer_cancel_statuses": policy.CustomerCancelStatuses,
+			"merchant_cancel_statuses": policy.MerchantCancelStatuses,
+			"refund_on_cancel":         policy.RefundOnCancel,
+			"fee_type":                 policy.FeeType,
+			"fee_value":                policy.FeeValue,
+		}).
+		Save()
+	if err != nil {
+		return fmt.Errorf("保存订单取消策略失败: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IOrderDisputeRepository 订单争议仓储接口
+type IOrderDisputeRepository interface {
+	Create(ctx context.Context, dispute *types.OrderDispute) error
+	GetByID(ctx context.Context, id uint64) (*types.OrderDispute, error)
+	ListByOrderID(ctx context.Context, orderID uint64) ([]types.OrderDispute, error)
+	List(ctx context.Context, req *types.OrderDisputeListRequest) ([]types.OrderDispute, error)
+	UpdateStatus(ctx context.Context, dispute *types.OrderDispute) error
+	// 返回指定订单中存在未结束争议的订单ID
+	ActiveOrderIDs(ctx context.Context, orderIDs []uint64) (map[uint64]bool, error)
+}
+
+// OrderDisputeRepository 订单争议仓储实现
+type OrderDisputeRepository struct {
+	*BaseRepository
+}
+
+// NewOrderDisputeRepository 创建订单争议仓储实例
+func NewOrderDisputeRepository() IOrderDisputeRepository {
+	return &OrderDisputeRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 创建订单争议
+func (r *OrderDisputeRepository) Create(ctx context.Context, dispute *types.OrderDispute) error {
+	dispute.TenantID = r.GetTenantID(ctx)
+	dispute.CreatedAt = gtime.Now().Time
+	dispute.UpdatedAt = dispute.CreatedAt
+
+	id, err := TenantDB(ctx).Model("order_disputes").Ctx(ctx).Data(dispute).OmitEmpty().InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("创建订单争议失败: %v", err)
+	}
+
+	dispute.ID = uint64(id)
+	return nil
+}
+
+// GetByID 获取当前租户的订单争议，不存在时返回 nil
+func (r *OrderDisputeRepository) GetByID(ctx context.Context, id uint64) (*types.OrderDispute, error) {
+	var dispute *types.OrderDispute
+	err := TenantDB(ctx).Model("order_disputes").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", id, r.GetTenantID(ctx)).
+		Scan(&dispute)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取订单争议失败: %v", err)
+	}
+	return dispute, nil
+}
+
+// ListByOrderID 获取订单的全部争议，按提交时间排序
+func (r *OrderDisputeRepository) ListByOrderID(ctx context.Context, orderID uint64) ([]types.OrderDispute, error) {
+	var disputes []types.OrderDispute
+	err := TenantDB(ctx).Model("order_disputes").
+		Ctx(ctx).
+		Where("tenant_id = ? AND order_id = ?", r.GetTenantID(ctx), orderID).
+		OrderAsc("id").
+		Scan(&disputes)
+	if err != nil {
+		return nil, fmt.Errorf("获取订单争议失败: %v", err)
+	}
+	return disputes, nil
+}
+
+// List 按状态和商户筛选当前租户的订单争议，最新提交的在前
+func (r *OrderDisputeRepository) List(ctx context.Context, req *types.OrderDisputeListRequest) ([]types.OrderDispute, error) {
+	query := TenantDB(ctx).Model("order_disputes").
+		Ctx(ctx).
+		Where("tenant_id = ?", r.GetTenantID(ctx))
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.MerchantID != nil {
+		query = query.Where("merchant_id = ?", *req.MerchantID)
+	}
+
+	var disputes []types.OrderDispute
+	if err := query.OrderDesc("id").Limit(req.Limit).Scan(&disputes); err != nil {
+		return nil, fmt.Errorf("获取订单争议列表失败: %v", err)
+	}
+	return disputes, nil
+}
+
+// UpdateStatus 更新争议状态和处理结果
+func (r *OrderDisputeRepository) UpdateStatus(ctx context.Context, dispute *types.OrderDispute) error {
+	dispute.UpdatedAt = gtime.Now().Time
+	_, err := TenantDB(ctx).Model("order_disputes").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", dispute.ID, r.GetTenantID(ctx)).
+		Data(g.Map{
+			"status":           dispute.Status,
+			"resolution_notes": dispute.ResolutionNotes,
+			"handler_id":       dispute.HandlerID,
+			"resolved_at":      dispute.ResolvedAt,
+			"updated_at":       dispute.UpdatedAt,
+		}).
+		Update()
+	if err != nil {
+		return fmt.Errorf("更新订单争议失败: %v", err)
+	}
+	return nil
+}
+
+// ActiveOrderIDs 返回指定订单中存在未结束争议的订单ID
+func (r *OrderDisputeRepository) ActiveOrderIDs(ctx context.Context, orderIDs []uint64) (map[uint64]bool, error) {
+	active := make(map[uint64]bool)
+	if len(orderIDs) == 0 {
+		return active, nil
+	}
+
+	values, err := TenantDB(ctx).Model("order_disputes").
+		Ctx(ctx).
+		Fields("DISTINCT order_id").
+		Where("tenant_id = ?", r.GetTenantID(ctx)).
+		WhereIn("order_id", orderIDs).
+		WhereIn("status", types.ActiveOrderDisputeStatuses).
+		Array()
+	if err != nil {
+		return nil, fmt.Errorf("查询订单未结束争议失败: %v", err)
+	}
+
+	for _, value := range values {
+		active[value.Uint64()] = true
+	}
+	return active, nil
+}
@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IOrderExportScheduleRepository 定时订单导出仓储接口
+type IOrderExportScheduleRepository interface {
+	Create(ctx context.Context, schedule *types.OrderExportSchedule) error
+	// 获取当前租户的定时导出，不存在时返回 nil
+	GetByID(ctx context.Context, id uint64) (*types.OrderExportSchedule, error)
+	List(ctx context.Context) ([]types.OrderExportSchedule, error)
+	// 启用或停用定时导出，启用时同时设置下一次执行时间
+	SetEnabled(ctx context.Context, id uint64, enabled bool, nextRunAt time.Time) error
+	// 跨租户列出已启用且到期的定时导出，仅供后台定时任务使用
+	ListDue(ctx context.Context, now time.Time, limit int) ([]types.OrderExportSchedule, error)
+	// 定时导出的下一次执行时间仍为 schedule.NextRunAt 时推进到 nextRunAt，返回是否推进；
+	// 多个实例同时执行时只有一个能推进成功并创建本次导出
+	ClaimRun(ctx context.Context, schedule *types.OrderExportSchedule, nextRunAt time.Time, now time.Time) (bool, error)
+	// 定时导出是否有生成中的执行
+	HasGeneratingRun(ctx context.Context, scheduleID uint64) (bool, error)
+	CreateRun(ctx context.Context, run *types.OrderExportRun) error
+	// 获取定时导出的执行记录，按执行时间倒序
+	ListRuns(ctx context.Context, scheduleID uint64, limit int) ([]types.OrderExportRun, error)
+	// 跨租户列出生成中的执行，仅供后台定时任务使用
+	ListGeneratingRuns(ctx context.Context, limit int) ([]types.OrderExportRun, error)
+	// 记录执行结果，投递成功时在同一事务中将定时导出的导出位置推进到本次导出的上界
+	CompleteRun(ctx context.Context, run *types.OrderExportRun) error
+	// 当前租户最大的订单ID，作为本次导出的上界
+	LatestOrderID(ctx context.Context) (uint64, error)
+	// 按订单ID顺序列出 (afterID, throughID] 范围内符合筛选条件的订单
+	ListOrders(ctx context.Context, filter *types.OrderExportFilter, afterID, throughID uint64, limit int) ([]types.OrderExtractRow, error)
+}
+
+// OrderExportScheduleRepository 定时订单导出仓储实现
+type OrderExportScheduleRepository struct {
+	*BaseRepository
+}
+
+// NewOrderExportScheduleRepository 创建定时订单导出仓储实例
+func NewOrderExportScheduleRepository() IOrderExportScheduleRepository {
+	return &OrderExportScheduleRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 创建定时导出
+func (r *OrderExportScheduleRepository) Create(ctx context.Context, schedule *types.OrderExportSchedule) error {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return fmt.Errorf("missing tenant_id in context")
+	}
+
+	filter, err := schedule.Filter.Value()
+	if err != nil {
+		return fmt.Errorf("序列化导出条件失败: %v", err)
+	}
+
+	schedule.TenantID = tenantID
+	schedule.CreatedAt = gtime.Now().Time
+	schedule.UpdatedAt = schedule.CreatedAt
+	id, err := TenantDB(ctx).Model("order_export_schedules").Ctx(ctx).Data(gdb.Map{
+		"tenant_id":     tenantID,
+		"name":          schedule.Name,
+		"filter":        filter,
+		"format":        schedule.Format,
+		"frequency":     schedule.Frequency,
+		"weekday":       schedule.Weekday,
+		"hour":          schedule.Hour,
+		"minute":        schedule.Minute,
+		"destination":   schedule.Destination,
+		"recipient":     schedule.Recipient,
+		"enabled":       schedule.Enabled,
+		"last_order_id": schedule.LastOrderID,
+		"next_run_at":   schedule.NextRunAt,
+		"created_by":    schedule.CreatedBy,
+		"created_at":    schedule.CreatedAt,
+		"updated_at":    schedule.UpdatedAt,
+	}).InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("创建定时订单导出失败: %v", err)
+	}
+
+	schedule.ID = uint64(id)
+	return nil
+}
+
+// GetByID 获取当前租户的定时导出
+func (r *OrderExportScheduleRepository) GetByID(ctx context.Context, id uint64) (*types.OrderExportSchedule, error) {
+	var schedule *types.OrderExportSchedule
+	err := TenantDB(ctx).Model("order_export_schedules").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", id, r.GetTenantID(ctx)).
+		Scan(&schedule)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取定时订单导出失败: %v", err)
+	}
+	return schedule, nil
+}
+
+// List 获取当前租户的定时导出
+func (r *OrderExportScheduleRepository) List(ctx context.Context) ([]types.OrderExportSchedule, error) {
+	var schedules []types.OrderExportSchedule
+	err := TenantDB(ctx).Model("order_export_schedules").
+		Ctx(ctx).
+		Where("tenant_id = ?", r.GetTenantID(ctx)).
+		OrderAsc("id").
+		Scan(&schedules)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取定时订单导出列表失败: %v", err)
+	}
+	return schedules, nil
+}
+
+// SetEnabled 启用或停用定时导出
+func (r *OrderExportScheduleRepository) SetEnabled(ctx context.Context, id uint64, enabled bool, nextRunAt time.Time) error {
+	data := gdb.Map{"enabled": enabled}
+	if enabled {
+		data["next_run_at"] = nextRunAt
+	}
+	_, err := TenantDB(ctx).Model("order_export_schedules").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", id, r.GetTenantID(ctx)).
+		Update(data)
+	if err != nil {
+		return fmt.Errorf("更新定时订单导出失败: %v", err)
+	}
+	return nil
+}
+
+// ListDue 按下一次执行时间从早到晚列出到期的定时导出
+func (r *OrderExportScheduleRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]types.OrderExportSchedule, error) {
+	var schedules []types.OrderExportSchedule
+	err := TenantDB(ctx).Model("order_export_schedules").
+		Ctx(ctx).
+		Where("enabled = ? AND next_run_at <= ?", true, now).
+		OrderAsc("next_run_at").
+		Limit(limit).
+		Scan(&schedules)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询到期的定时订单导出失败: %v", err)
+	}
+	return schedules, nil
+}
+
+// ClaimRun 推进定时导出的下一次执行时间
+func (r *OrderExportScheduleRepository) ClaimRun(ctx context.Context, schedule *types.OrderExportSchedule, nextRunAt time.Time, now time.Time) (bool, error) {
+	result, err := TenantDB(ctx).Model("order_export_schedules").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ? AND enabled = ? AND next_run_at = ?",
+			schedule.ID, r.GetTenantID(ctx), true, schedule.NextRunAt).
+		Update(gdb.Map{"next_run_at": nextRunAt, "last_run_at": now})
+	if err != nil {
+		return false, fmt.Errorf("更新定时订单导出执行时间失败: %v", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// HasGeneratingRun 定时导出是否有生成中的执行
+func (r *OrderExportScheduleRepository) HasGeneratingRun(ctx context.Context, scheduleID uint64) (bool, error) {
+	count, err := TenantDB(ctx).Model("order_export_runs").
+		Ctx(ctx).
+		Where("tenant_id = ? AND schedule_id = ? AND status = ?", r.GetTenantID(ctx), scheduleID, types.OrderExportRunStatusGenerating).
+		Count()
+	if err != nil {
+		return false, fmt.Errorf("查询定时订单导出执行失败: %v", err)
+	}
+	return count > 0, nil
+}
+
+// CreateRun 创建执行记录
+func (r *OrderExportScheduleRepository) CreateRun(ctx context.Context, run *types.OrderExportRun) error {
+	tenantID := r.GetTenantID(ctx)
+	run.TenantID = tenantID
+	id, err := TenantDB(ctx).Model("order_export_runs").Ctx(ctx).Data(gdb.Map{
+		"tenant_id":        tenantID,
+		"schedule_id":      run.ScheduleID,
+		"export_job_id":    run.ExportJobID,
+		"status":           run.Status,
+		"from_order_id":    run.FromOrderID,
+		"through_order_id": run.ThroughOrderID,
+		"row_count":        run.RowCount,
+		"error_message":    run.ErrorMessage,
+		"started_at":       run.StartedAt,
+		"completed_at":     run.CompletedAt,
+	}).InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("创建定时订单导出执行记录失败: %v", err)
+	}
+
+	run.ID = uint64(id)
+	return nil
+}
+
+// ListRuns 获取定时导出的执行记录
+func (r *OrderExportScheduleRepository) ListRuns(ctx context.Context, scheduleID uint64, limit int) ([]types.OrderExportRun, error) {
+	var runs []types.OrderExportRun
+	err := TenantDB(ctx).Model("order_export_runs").
+		Ctx(ctx).
+		Where("tenant_id = ? AND schedule_id = ?", r.GetTenantID(ctx), scheduleID).
+		OrderDesc("id").
+		Limit(limit).
+		Scan(&runs)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取定时订单导出执行记录失败: %v", err)
+	}
+	return runs, nil
+}
+
+// ListGeneratingRuns 按创建顺序列出生成中的执行
+func (r *OrderExportScheduleRepository) ListGeneratingRuns(ctx context.Context, limit int) ([]types.OrderExportRun, error) {
+	var runs []types.OrderExportRun
+	err := TenantDB(ctx).Model("order_export_runs").
+		Ctx(ctx).
+		Where("status = ?", types.OrderExportRunStatusGenerating).
+		OrderAsc("id").
+		Limit(limit).
+		Scan(&runs)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询生成中的定时订单导出失败: %v", err)
+	}
+	return runs, nil
+}
+
+// CompleteRun 记录执行结果，只有生成中的执行可以结束
+func (r *OrderExportScheduleRepository) CompleteRun(ctx context.Context, run *types.OrderExportRun) error {
+	tenantID := r.GetTenantID(ctx)
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		result, err := tx.Model("order_export_runs").Ctx(ctx).
+			Where("id = ? AND tenant_id = ? AND status = ?", run.ID, tenantID, types.OrderExportRunStatusGenerating).
+			Update(gdb.Map{
+				"status":        run.Status,
+				"row_count":     run.RowCount,
+				"error_message": run.ErrorMessage,
+				"completed_at":  run.CompletedAt,
+			})
+		if err != nil {
+			return fmt.Errorf("更新定时订单导出执行记录失败: %v", err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 || run.Status != types.OrderExportRunStatusDelivered {
+			return nil
+		}
+
+		// 只从本次导出的起点推进，避免覆盖更新的导出位置
+		_, err = tx.Model("order_export_schedules").Ctx(ctx).
+			Where("id = ? AND tenant_id = ? AND last_order_id = ?", run.ScheduleID, tenantID, run.FromOrderID).
+			Update(gdb.Map{"last_order_id": run.ThroughOrderID})
+		if err != nil {
+			return fmt.Errorf("更新定时订单导出位置失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// LatestOrderID 当前租户最大的订单ID
+func (r *OrderExportScheduleRepository) LatestOrderID(ctx context.Context) (uint64, error) {
+	value, err := TenantDB(ctx).Model("orders").
+		Ctx(ctx).
+		Where("tenant_id = ?", r.GetTenantID(ctx)).
+		Max("id")
+	if err != nil {
+		return 0, fmt.Errorf("查询最新订单失败: %v", err)
+	}
+	return uint64(value), nil
+}
+
+// ListOrders 按订单ID顺序列出导出范围内的订单
+func (r *OrderExportScheduleRepository) ListOrders(ctx context.Context, filter *types.OrderExportFilter, afterID, throughID uint64, limit int) ([]types.OrderExtractRow, error) {
+	query := TenantDB(ctx).Model("orders").
+		Ctx(ctx).
+		Fields("id, order_number, COALESCE(external_reference, '') AS external_reference, merchant_id, customer_id, status, subtotal_amount, tax_amount, total_amount, total_rights_cost, created_at").
+		Where("tenant_id = ? AND id > ? AND id <= ?", r.GetTenantID(ctx), afterID, throughID)
+	if filter.MerchantID != nil {
+		query = query.Where("merchant_id = ?", *filter.MerchantID)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.WhereIn("status", filter.Statuses)
+	}
+
+	var rows []types.OrderExtractRow
+	err := query.OrderAsc("id").Limit(limit).Scan(&rows)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询导出订单失败: %v", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IOrderFulfillmentRepository 订单履约单仓储接口
+type IOrderFulfillmentRepository interface {
+	// 创建订单的全部履约单，订单已拆分过时返回 types.ErrOrderFulfillmentsExist
+	CreateBatch(ctx context.Context, orderID uint64, fulfillments []types.OrderFulfillment) error
+	ListByOrderID(ctx context.Context, orderID uint64) ([]types.OrderFulfillment, error)
+	// 更新履约单状态，返回变更前的状态和变更后订单的全部履约单
+	UpdateStatus(ctx context.Context, orderID, fulfillmentID uint64, req *types.UpdateFulfillmentStatusRequest) (*types.FulfillmentStatusChange, error)
+}
+
+// OrderFulfillmentRepository 订单履约单仓储实现
+type OrderFulfillmentRepository struct {
+	*BaseRepository
+}
+
+// NewOrderFulfillmentRepository 创建订单履约单仓储实例
+func NewOrderFulfillmentRepository() IOrderFulfillmentRepository {
+	return &OrderFulfillmentRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// CreateBatch 在一个事务内锁定订单行后创建履约单，订单行锁保证同一订单不会被并发重复拆分
+func (r *OrderFulfillmentRepository) CreateBatch(ctx context.Context, orderID uint64, fulfillments []types.OrderFulfillment) error {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return fmt.Errorf("missing tenant_id in context")
+	}
+
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		locked, err := tx.Model("orders").Ctx(ctx).
+			Fields("id").
+			Where("id = ? AND tenant_id = ?", orderID, tenantID).
+			LockUpdate().
+			Value()
+		if err != nil {
+			return err
+		}
+		if locked.IsEmpty() {
+			return fmt.Errorf("订单不存在")
+		}
+
+		count, err := tx.Model("order_fulfillments").Ctx(ctx).
+			Where("tenant_id = ? AND order_id = ?", tenantID, orderID).
+			Count()
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return types.ErrOrderFulfillmentsExist
+		}
+
+		now := gtime.Now().Time
+		for i := range fulfillments {
+			itemsJSON, err := json.Marshal(fulfillments[i].Items)
+			if err != nil {
+				return fmt.Errorf("序列化履约单商品失败: %v", err)
+			}
+			fulfillments[i].TenantID = tenantID
+			fulfillments[i].OrderID = orderID
+			fulfillments[i].Status = types.FulfillmentStatusPending
+			fulfillments[i].UpdatedAt = now
+
+			id, err := tx.Model("order_fulfillments").Ctx(ctx).
+				Data(gdb.Map{
+					"tenant_id":   tenantID,
+					"order_id":    orderID,
+					"status":      fulfillments[i].Status,
+					"items":       string(itemsJSON),
+					"amount":      fulfillments[i].Amount,
+					"rights_cost": fulfillments[i].RightsCost,
+					"created_at":  now,
+					"updated_at":  now,
+				}).
+				InsertAndGetId()
+			if err != nil {
+				return err
+			}
+			fulfillments[i].ID = uint64(id)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("创建订单履约单失败: %w", err)
+	}
+	return nil
+}
+
+// ListByOrderID 获取订单的全部履约单，按创建顺序排序
+func (r *OrderFulfillmentRepository) ListByOrderID(ctx context.Context, orderID uint64) ([]types.OrderFulfillment, error) {
+	var fulfillments []types.OrderFulfillment
+	err := TenantDB(ctx).Model("order_fulfillments").
+		Ctx(ctx).
+		Where("tenant_id = ? AND order_id = ?", r.GetTenantID(ctx), orderID).
+		OrderAsc("id").
+		Scan(&fulfillments)
+	if err != nil {
+		return nil, fmt.Errorf("获取订单履约单失败: %v", err)
+	}
+	return fulfillments, nil
+}
+
+// UpdateStatus 在一个事务内锁定订单的全部履约单后更新其中一个的状态。
+// 同一订单的包裹状态变更串行执行，调用方据此判断本次变更是否使订单首次全部完成交付
+func (r *OrderFulfillmentRepository) UpdateStatus(ctx context.Context, orderID, fulfillmentID uint64, req *types.UpdateFulfillmentStatusRequest) (*types.FulfillmentStatusChange, error) {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return nil, fmt.Errorf("missing tenant_id in context")
+	}
+
+	var change *types.FulfillmentStatusChange
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		var fulfillments []types.OrderFulfillment
+		err := tx.Model("order_fulfillments").Ctx(ctx).
+			Where("tenant_id = ? AND order_id = ?", tenantID, orderID).
+			OrderAsc("id").
+			LockUpdate().
+			Scan(&fulfillments)
+		if err != nil {
+			return err
+		}
+
+		var fulfillment *types.OrderFulfillment
+		for i := range fulfillments {
+			if fulfillments[i].ID == fulfillmentID {
+				fulfillment = &fulfillments[i]
+				break
+			}
+		}
+		if fulfillment == nil {
+			return types.ErrOrderFulfillmentNotFound
+		}
+		if !fulfillment.Status.CanTransitionTo(req.Status) {
+			return fmt.Errorf("%w: %s -> %s", types.ErrFulfillmentStatusTransition, fulfillment.Status, req.Status)
+		}
+
+		previous := fulfillment.Status
+		now := gtime.Now().Time
+		data := gdb.Map{
+			"status":     req.Status,
+			"updated_at": now,
+		}
+		if req.Status == types.FulfillmentStatusShipped {
+			fulfillment.Carrier = req.Carrier
+			fulfillment.TrackingNumber = req.TrackingNumber
+			fulfillment.ShippedAt = &now
+			data["carrier"] = req.Carrier
+			data["tracking_number"] = req.TrackingNumber
+			data["shipped_at"] = now
+		}
+		_, err = tx.Model("order_fulfillments").Ctx(ctx).
+			Where("id = ? AND tenant_id = ?", fulfillment.ID, tenantID).
+			Update(data)
+		if err != nil {
+			return err
+		}
+
+		fulfillment.Status = req.Status
+		fulfillment.UpdatedAt = now
+		change = &types.FulfillmentStatusChange{
+			Fulfillment:    fulfillment,
+			PreviousStatus: previous,
+			Fulfillments:   fulfillments,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("更新履约单状态失败: %w", err)
+	}
+	return change, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IOrderInvoiceRepository 订单发票仓储接口
+type IOrderInvoiceRepository interface {
+	NextSequence(ctx context.Context) (uint64, error)
+	Create(ctx context.Context, invoice *types.OrderInvoice) error
+	GetByOrderID(ctx context.Context, orderID uint64) (*types.OrderInvoice, error)
+	UpdateFilePath(ctx context.Context, invoiceID uint64, filePath string) error
+}
+
+// OrderInvoiceRepository 订单发票仓储实现
+type OrderInvoiceRepository struct {
+	*BaseRepository
+}
+
+// NewOrderInvoiceRepository 创建订单发票仓储实例
+func NewOrderInvoiceRepository() IOrderInvoiceRepository {
+	return &OrderInvoiceRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// NextSequence 原子地获取当前租户的下一个发票号序号
+func (r *OrderInvoiceRepository) NextSequence(ctx context.Context) (uint64, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	// LAST_INSERT_ID(expr) 使递增后的值通过本条语句的结果返回，无需额外加锁查询
+	result, err := TenantDB(ctx).Exec(ctx,
+		"INSERT INTO invoice_sequences (tenant_id, current_value) VALUES (?, LAST_INSERT_ID(1)) "+
+			"ON DUPLICATE KEY UPDATE current_value = LAST_INSERT_ID(current_value + 1)",
+		tenantID)
+	if err != nil {
+		return 0, fmt.Errorf("分配发票号序号失败: %v", err)
+	}
+
+	sequence, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("获取发票号序号失败: %v", err)
+	}
+	return uint64(sequence), nil
+}
+
+// Create 保存发票，同一订单已有发票或发票号重复时返回错误
+func (r *OrderInvoiceRepository) Create(ctx context.Context, invoice *types.OrderInvoice) error {
+	invoice.TenantID = r.GetTenantID(ctx)
+	invoice.CreatedAt = gtime.Now().Time
+
+	id, err := TenantDB(ctx).Model("order_invoices").Ctx(ctx).Data(g.Map{
+		"tenant_id":       invoice.TenantID,
+		"order_id":        invoice.OrderID,
+		"merchant_id":     invoice.MerchantID,
+		"customer_id":     invoice.CustomerID,
+		"invoice_number":  invoice.InvoiceNumber,
+		"total_amount":    invoice.TotalAmount,
+		"subtotal_amount": invoice.SubtotalAmount,
+		"tax_amount":      invoice.TaxAmount,
+		"file_path":       invoice.FilePath,
+		"issued_at":       invoice.IssuedAt,
+		"created_at":      invoice.CreatedAt,
+	}).InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("保存发票失败: %v", err)
+	}
+
+	invoice.ID = uint64(id)
+	return nil
+}
+
+// GetByOrderID 获取订单的发票，未开具时返回nil
+func (r *OrderInvoiceRepository) GetByOrderID(ctx context.Context, orderID uint64) (*types.OrderInvoice, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	var invoice *types.OrderInvoice
+	err := TenantDB(ctx).Model("order_invoices").
+		Ctx(ctx).
+		Where("tenant_id = ? AND order_id = ?", tenantID, orderID).
+		Scan(&invoice)
+	if err != nil {
+		return nil, fmt.Errorf("获取发票失败: %v", err)
+	}
+	return invoice, nil
+}
+
+// UpdateFilePath 记录已生成的发票PDF文件
+func (r *OrderInvoiceRepository) UpdateFilePath(ctx context.Context, invoiceID uint64, filePath string) error {
+	tenantID := r.GetTenantID(ctx)
+
+	_, err := TenantDB(ctx).Model("order_invoices").
+		Ctx(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, invoiceID).
+		Update(g.Map{"file_path": filePath})
+	if err != nil {
+		return fmt.Errorf("更新发票文件失败: %v", err)
+	}
+	return nil
+}
+
+// InvoicePolicyProvider 按租户获取发票策略，未配置时返回 nil
+type InvoicePolicyProvider func(ctx context.Context, tenantID uint64) (*types.InvoicePolicy, error)
+
+// NewTenantInvoicePolicyProvider 创建从租户配置读取发票策略的提供者
+func NewTenantInvoicePolicyProvider(tenantRepo ITenantRepository) InvoicePolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.InvoicePolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.Invoice, nil
+	}
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IOrderNoteRepository 订单备注仓储接口
+type IOrderNoteRepository interface {
+	Create(ctx context.Context, note *types.OrderNote) error
+	ListByOrderID(ctx context.Context, orderID uint64, includeInternal bool) ([]types.OrderNote, error)
+}
+
+// OrderNoteRepository 订单备注仓储实现
+type OrderNoteRepository struct {
+	*BaseRepository
+}
+
+// NewOrderNoteRepository 创建订单备注仓储实例
+func NewOrderNoteRepository() IOrderNoteRepository {
+	return &OrderNoteRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 创建订单备注
+func (r *OrderNoteRepository) Create(ctx context.Context, note *types.OrderNote) error {
+	note.TenantID = r.GetTenantID(ctx)
+	note.CreatedAt = gtime.Now().Time
+
+	id, err := TenantDB(ctx).Model("order_notes").Ctx(ctx).Data(note).OmitEmpty().InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("创建订单备注失败: %v", err)
+	}
+
+	note.ID = uint64(id)
+	return nil
+}
+
+// ListByOrderID 获取订单备注列表，includeInternal 为 false 时只返回客户可见备注
+func (r *OrderNoteRepository) ListByOrderID(ctx context.Context, orderID uint64, includeInternal bool) ([]types.OrderNote, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	model := TenantDB(ctx).Model("order_notes").
+		Ctx(ctx).
+		Where("tenant_id = ? AND order_id = ?", tenantID, orderID)
+	if !includeInternal {
+		model = model.Where("visibility", types.OrderNoteVisibilityCustomer)
+	}
+
+	var notes []types.OrderNote
+	if err := model.OrderAsc("created_at").Scan(&notes); err != nil {
+		return nil, fmt.Errorf("获取订单备注失败: %v", err)
+	}
+
+	return notes, nil
+}
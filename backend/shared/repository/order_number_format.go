@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// IOrderNumberFormatRepository 订单号格式仓储接口
+type IOrderNumberFormatRepository interface {
+	GetEffectiveFormat(ctx context.Context) (*types.OrderNumberFormat, error)
+	Save(ctx context.Context, format *types.OrderNumberFormat) error
+	Delete(ctx context.Context) error
+}
+
+// OrderNumberSequenceKey 订单号序号在序号源中的名称，作用域是订单号中序号之前的部分
+func OrderNumberSequenceKey(scope string) string {
+	return "order_number:" + scope
+}
+
+// OrderNumberFormatRepository 订单号格式数据访问层
+type OrderNumberFormatRepository struct {
+	*BaseRepository
+}
+
+// NewOrderNumberFormatRepository 创建订单号格式仓库实例
+func NewOrderNumberFormatRepository() IOrderNumberFormatRepository {
+	return &OrderNumberFormatRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// GetEffectiveFormat 获取当前租户的订单号格式，未配置时使用默认格式
+func (r *OrderNumberFormatRepository) GetEffectiveFormat(ctx context.Context) (*types.OrderNumberFormat, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	var format types.OrderNumberFormat
+	err := TenantDB(ctx).Model("order_number_formats").
+		Ctx(ctx).
+		Where("tenant_id = ?", tenantID).
+		Scan(&format)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.DefaultOrderNumberFormat(tenantID), nil
+		}
+		return nil, fmt.Errorf("获取订单号格式失败: %v", err)
+	}
+
+	return &format, nil
+}
+
+// Save 保存当前租户的订单号格式，前缀不能与其他租户重复
+func (r *OrderNumberFormatRepository) Save(ctx context.Context, format *types.OrderNumberFormat) error {
+	if err := format.Validate(); err != nil {
+		return err
+	}
+	format.TenantID = r.GetTenantID(ctx)
+
+	count, err := TenantDB(ctx).Model("order_number_formats").
+		Ctx(ctx).
+		Where("prefix = ? AND tenant_id != ?", format.Prefix, format.TenantID).
+		Count()
+	if err != nil {
+		return fmt.Errorf("检查订单号前缀失败: %v", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("订单号前缀 %s 已被其他租户使用", format.Prefix)
+	}
+
+	_, err = TenantDB(ctx).Model("order_number_formats").
+		Ctx(ctx).
+		Data(g.Map{
+			"tenant_id":             format.TenantID,
+			"prefix":                format.Prefix,
+			"date_pattern":          format.DatePattern,
+			"sequence_width":        format.SequenceWidth,
+			"include_merchant_code": format.IncludeMerchantCode,
+		}).
+		Save()
+	if err != nil {
+		return fmt.Errorf("保存订单号格式失败: %v", err)
+	}
+
+	return nil
+}
+
+// Delete 删除当前租户的订单号格式，恢复默认格式
+func (r *OrderNumberFormatRepository) Delete(ctx context.Context) error {
+	_, err := TenantDB(ctx).Model("order_number_formats").
+		Ctx(ctx).
+		Where("tenant_id = ?", r.GetTenantID(ctx)).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("删除订单号格式失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IOrderReviewRepository 订单风险审核仓储接口
+type IOrderReviewRepository interface {
+	Create(ctx context.Context, review *types.OrderReview) error
+	GetByID(ctx context.Context, id uint64) (*types.OrderReview, error)
+	// 获取订单最近一次审核记录，没有时返回 nil
+	GetLatestByOrderID(ctx context.Context, orderID uint64) (*types.OrderReview, error)
+	List(ctx context.Context, req *types.OrderReviewListRequest) ([]types.OrderReview, error)
+	UpdateDecision(ctx context.Context, review *types.OrderReview) error
+	// 统计客户的订单数，since 不为空时只统计该时间之后创建的订单
+	CountCustomerOrders(ctx context.Context, customerID uint64, since *time.Time) (int, error)
+}
+
+// OrderReviewRepository 订单风险审核仓储实现
+type OrderReviewRepository struct {
+	*BaseRepository
+}
+
+// NewOrderReviewRepository 创建订单风险审核仓储实例
+func NewOrderReviewRepository() IOrderReviewRepository {
+	return &OrderReviewRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 创建订单审核记录
+func (r *OrderReviewRepository) Create(ctx context.Context, review *types.OrderReview) error {
+	review.TenantID = r.GetTenantID(ctx)
+	review.CreatedAt = gtime.Now().Time
+	review.UpdatedAt = review.CreatedAt
+
+	id, err := TenantDB(ctx).Model("order_reviews").Ctx(ctx).Data(review).OmitEmpty().InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("创建订单审核记录失败: %v", err)
+	}
+
+	review.ID = uint64(id)
+	return nil
+}
+
+// GetByID 获取当前租户的订单审核记录，不存在时返回 nil
+func (r *OrderReviewRepository) GetByID(ctx context.Context, id uint64) (*types.OrderReview, error) {
+	var review *types.OrderReview
+	err := TenantDB(ctx).Model("order_reviews").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", id, r.GetTenantID(ctx)).
+		Scan(&review)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取订单审核记录失败: %v", err)
+	}
+	return review, nil
+}
+
+// GetLatestByOrderID 获取订单最近一次审核记录，没有时返回 nil
+func (r *OrderReviewRepository) GetLatestByOrderID(ctx context.Context, orderID uint64) (*types.OrderReview, error) {
+	var review *types.OrderReview
+	err := TenantDB(ctx).Model("order_reviews").
+		Ctx(ctx).
+		Where("tenant_id = ? AND order_id = ?", r.GetTenantID(ctx), orderID).
+		OrderDesc("id").
+		Limit(1).
+		Scan(&review)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取订单审核记录失败: %v", err)
+	}
+	return review, nil
+}
+
+// List 按状态和商户筛选当前租户的审核记录，最早进入队列的在前
+func (r *OrderReviewRepository) List(ctx context.Context, req *types.OrderReviewListRequest) ([]types.OrderReview, error) {
+	query := TenantDB(ctx).Model("order_reviews").
+		Ctx(ctx).
+		Where("tenant_id = ? AND status = ?", r.GetTenantID(ctx), req.Status)
+	if req.MerchantID != nil {
+		query = query.Where("merchant_id = ?", *req.MerchantID)
+	}
+
+	var reviews []types.OrderReview
+	if err := query.OrderAsc("id").Limit(req.Limit).Scan(&reviews); err != nil {
+		return nil, fmt.Errorf("获取订单审核队列失败: %v", err)
+	}
+	return reviews, nil
+}
+
+// UpdateDecision 记录审核决定，只更新仍待审核的记录，避免重复处理
+func (r *OrderReviewRepository) UpdateDecision(ctx context.Context, review *types.OrderReview) error {
+	review.UpdatedAt = gtime.Now().Time
+	result, err := TenantDB(ctx).Model("order_reviews").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ? AND status = ?", review.ID, r.GetTenantID(ctx), types.OrderReviewStatusPending).
+		Data(g.Map{
+			"status":         review.Status,
+			"decision_notes": review.DecisionNotes,
+			"reviewer_id":    review.ReviewerID,
+			"decided_at":     review.DecidedAt,
+			"updated_at":     review.UpdatedAt,
+		}).
+		Update()
+	if err != nil {
+		return fmt.Errorf("更新订单审核记录失败: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("订单审核记录已处理")
+	}
+	return nil
+}
+
+// CountCustomerOrders 统计客户的订单数
+func (r *OrderReviewRepository) CountCustomerOrders(ctx context.Context, customerID uint64, since *time.Time) (int, error) {
+	query := TenantDB(ctx).Model("orders").
+		Ctx(ctx).
+		Where("tenant_id = ? AND customer_id = ?", r.GetTenantID(ctx), customerID)
+	if since != nil {
+		query = query.Where("created_at >= ?", since.Format("2006-01-02 15:04:05"))
+	}
+
+	count, err := query.Count()
+	if err != nil {
+		return 0, fmt.Errorf("统计客户订单数失败: %v", err)
+	}
+	return count, nil
+}
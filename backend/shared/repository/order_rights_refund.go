@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+)
+
+// Restore 退还订单已扣减的权益。先锁定订单已转为已使用的冻结记录，保证同一订单的多次退款串行执行，
+// 余额、退款资金、流转记录和退还记录在同一事务中提交
+func (r *OrderRightsReservationRepository) Restore(ctx context.Context, refund *types.OrderRightsRefund) (*types.OrderRightsRefund, error) {
+	if refund.Amount <= 0 {
+		return nil, nil
+	}
+
+	var restored *types.OrderRightsRefund
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		var reservation *types.OrderRightsReservation
+		err := tx.Model("order_rights_reservations").Ctx(ctx).
+			Where("tenant_id = ? AND order_id = ? AND status = ?", refund.TenantID, refund.OrderID, types.OrderRightsReservationConsumed).
+			LockUpdate().
+			Scan(&reservation)
+		if err != nil {
+			return fmt.Errorf("查询订单权益扣减记录失败: %v", err)
+		}
+		if reservation == nil {
+			return nil
+		}
+
+		refunded, err := tx.Model("order_rights_refunds").Ctx(ctx).
+			Where("tenant_id = ? AND order_id = ?", refund.TenantID, refund.OrderID).
+			Sum("amount")
+		if err != nil {
+			return fmt.Errorf("查询订单已退还权益失败: %v", err)
+		}
+		remaining := math.Round((reservation.Amount-refunded)*100) / 100
+		amount := math.Min(refund.Amount, remaining)
+		if amount <= 0 {
+			return nil
+		}
+
+		balance, err := lockMerchantRights(ctx, tx, refund.TenantID, reservation.MerchantID)
+		if err != nil || balance == nil {
+			return err
+		}
+		balanceBefore := balance.GetAvailableBalance()
+		if amount = balance.RestoreUsed(amount); amount <= 0 {
+			return nil
+		}
+		if err := saveMerchantRights(ctx, tx, refund.TenantID, reservation.MerchantID, balance); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		fund := &types.Fund{
+			TenantID:   refund.TenantID,
+			MerchantID: reservation.MerchantID,
+			FundType:   types.FundTypeRefund,
+			Amount:     amount,
+			Currency:   "CNY",
+			Status:     types.FundStatusConfirmed,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		fundID, err := tx.Model("funds").Ctx(ctx).InsertAndGetId(fund)
+		if err != nil {
+			return fmt.Errorf("创建权益退还资金记录失败: %v", err)
+		}
+
+		_, err = tx.Model("fund_transactions").Ctx(ctx).Insert(&types.FundTransaction{
+			TenantID:        refund.TenantID,
+			MerchantID:      reservation.MerchantID,
+			FundID:          uint64(fundID),
+			TransactionType: types.TransactionTypeCredit,
+			Amount:          amount,
+			BalanceBefore:   balanceBefore,
+			BalanceAfter:    balance.GetAvailableBalance(),
+			OperatorID:      refund.OperatorID,
+			Description:     fmt.Sprintf("订单 %d 退款退还权益: %s", refund.OrderID, refund.Reason),
+			CreatedAt:       now,
+		})
+		if err != nil {
+			return fmt.Errorf("创建权益退还流转记录失败: %v", err)
+		}
+
+		record := *refund
+		record.MerchantID = reservation.MerchantID
+		record.FundID = uint64(fundID)
+		record.Amount = amount
+		record.CreatedAt = now
+		id, err := tx.Model("order_rights_refunds").Ctx(ctx).InsertAndGetId(&record)
+		if err != nil {
+			return fmt.Errorf("创建订单权益退还记录失败: %v", err)
+		}
+		record.ID = uint64(id)
+		restored = &record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
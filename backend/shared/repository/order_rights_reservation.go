@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// IOrderRightsReservationRepository 订单权益冻结仓储接口
+type IOrderRightsReservationRepository interface {
+	// 锁定商户权益余额并冻结订单所需权益，返回冻结前的余额；
+	// 可用余额不足时返回 types.ErrRightsBalanceInsufficient，商户未开通权益余额时不冻结并返回 nil 余额
+	Freeze(ctx context.Context, reservation *types.OrderRightsReservation) (*types.RightsBalance, error)
+	// 结清订单仍冻结的权益：released 退回可用余额，consumed 转为已使用；没有冻结记录时返回 nil
+	Settle(ctx context.Context, tenantID, orderID uint64, status types.OrderRightsReservationStatus) (*types.OrderRightsReservation, error)
+	// 订单退款后将已扣减的权益退回商户可用余额，并记录退款资金和流转；累计退还不超过订单已扣减的权益，
+	// 返回实际退还的记录，订单没有扣减权益或已全部退还时返回 nil
+	Restore(ctx context.Context, refund *types.OrderRightsRefund) (*types.OrderRightsRefund, error)
+}
+
+// OrderRightsReservationRepository 订单权益冻结仓储实现
+type OrderRightsReservationRepository struct {
+	*BaseRepository
+}
+
+// NewOrderRightsReservationRepository 创建订单权益冻结仓储实例
+func NewOrderRightsReservationRepository() IOrderRightsReservationRepository {
+	return &OrderRightsReservationRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// merchantRightsRecord 商户权益余额行
+type merchantRightsRecord struct {
+	RightsBalance *types.RightsBalance `json:"rights_balance"`
+}
+
+// lockMerchantRights 在事务中锁定并读取商户权益余额
+func lockMerchantRights(ctx context.Context, tx gdb.TX, tenantID, merchantID uint64) (*types.RightsBalance, error) {
+	var record *merchantRightsRecord
+	err := tx.Model("merchants").Ctx(ctx).
+		Fields("rights_balance").
+		Where("id = ? AND tenant_id = ?", merchantID, tenantID).
+		LockUpdate().
+		Scan(&record)
+	if err != nil {
+		return nil, fmt.Errorf("查询商户权益余额失败: %v", err)
+	}
+	if record == nil {
+		return nil, fmt.Errorf("商户 %d 不存在", merchantID)
+	}
+	return record.RightsBalance, nil
+}
+
+// saveMerchantRights 在事务中保存商户权益余额
+func saveMerchantRights(ctx context.Context, tx gdb.TX, tenantID, merchantID uint64, balance *types.RightsBalance) error {
+	_, err := tx.Model("merchants").Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", merchantID, tenantID).
+		Update(g.Map{"rights_balance": balance})
+	if err != nil {
+		return fmt.Errorf("更新商户权益余额失败: %v", err)
+	}
+	return nil
+}
+
+// Freeze 冻结订单权益，余额更新与冻结记录在同一事务中提交
+func (r *OrderRightsReservationRepository) Freeze(ctx context.Context, reservation *types.OrderRightsReservation) (*types.RightsBalance, error) {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return nil, fmt.Errorf("missing tenant_id in context")
+	}
+
+	var before *types.RightsBalance
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		balance, err := lockMerchantRights(ctx, tx, tenantID, reservation.MerchantID)
+		if err != nil || balance == nil {
+			return err
+		}
+		snapshot := *balance
+		before = &snapshot
+
+		if err := balance.Freeze(reservation.Amount); err != nil {
+			return err
+		}
+		if err := saveMerchantRights(ctx, tx, tenantID, reservation.MerchantID, balance); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		reservation.TenantID = tenantID
+		reservation.Status = types.OrderRightsReservationActive
+		reservation.CreatedAt = now
+		reservation.UpdatedAt = now
+		id, err := tx.Model("order_rights_reservations").Ctx(ctx).InsertAndGetId(reservation)
+		if err != nil {
+			return fmt.Errorf("创建订单权益冻结记录失败: %v", err)
+		}
+		reservation.ID = uint64(id)
+		return nil
+	})
+	return before, err
+}
+
+// Settle 结清订单冻结的权益，冻结记录状态先于余额更新，已结清的记录不会重复处理
+func (r *OrderRightsReservationRepository) Settle(ctx context.Context, tenantID, orderID uint64, status types.OrderRightsReservationStatus) (*types.OrderRightsReservation, error) {
+	if status != types.OrderRightsReservationReleased && status != types.OrderRightsReservationConsumed {
+		return nil, fmt.Errorf("无效的权益冻结结清状态: %s", status)
+	}
+
+	var settled *types.OrderRightsReservation
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		var reservation *types.OrderRightsReservation
+		err := tx.Model("order_rights_reservations").Ctx(ctx).
+			Where("tenant_id = ? AND order_id = ? AND status = ?", tenantID, orderID, types.OrderRightsReservationActive).
+			LockUpdate().
+			Scan(&reservation)
+		if err != nil {
+			return fmt.Errorf("查询订单权益冻结记录失败: %v", err)
+		}
+		if reservation == nil {
+			return nil
+		}
+
+		_, err = tx.Model("order_rights_reservations").Ctx(ctx).
+			Where("id = ?", reservation.ID).
+			Update(g.Map{"status": status, "updated_at": time.Now()})
+		if err != nil {
+			return fmt.Errorf("更新订单权益冻结状态失败: %v", err)
+		}
+
+		balance, err := lockMerchantRights(ctx, tx, tenantID, reservation.MerchantID)
+		if err != nil {
+			return err
+		}
+		if balance != nil {
+			if status == types.OrderRightsReservationConsumed {
+				balance.ConsumeFrozen(reservation.Amount)
+			} else {
+				balance.Unfreeze(reservation.Amount)
+			}
+			if err := saveMerchantRights(ctx, tx, tenantID, reservation.MerchantID, balance); err != nil {
+				return err
+			}
+		}
+
+		reservation.Status = status
+		settled = reservation
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return settled, nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// OrderSLAEntryFilter 超时订单查询条件
+type OrderSLAEntryFilter struct {
+	TenantID   uint64                                 // 为0时查询所有租户（仅用于后台任务）
+	MerchantID *uint64                                // 为空时查询租户下所有商户
+	Limits     map[types.OrderStatusInt]time.Duration // 各状态处理时限，只查询停留超过时限的订单
+	Now        time.Time
+	Limit      int
+}
+
+// IOrderSLARepository 订单处理时限仓储接口
+type IOrderSLARepository interface {
+	GetEffectiveConfig(ctx context.Context, merchantID uint64) (*types.OrderSLAConfig, error)
+	ListConfigs(ctx context.Context, tenantID uint64) ([]types.OrderSLAConfig, error)
+	Save(ctx context.Context, config *types.OrderSLAConfig) error
+	ListBreachEntries(ctx context.Context, filter *OrderSLAEntryFilter) ([]types.OrderStatusEntry, error)
+	MarkAlerted(ctx context.Context, entry *types.OrderStatusEntry) (bool, error)
+}
+
+// OrderSLARepository 订单处理时限数据访问层
+type OrderSLARepository struct {
+	*BaseRepository
+}
+
+// NewOrderSLARepository 创建订单处理时限仓库实例
+func NewOrderSLARepository() IOrderSLARepository {
+	return &OrderSLARepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// GetEffectiveConfig 获取有效的处理时限配置（优先商户级配置，其次租户默认配置，都未配置时使用系统默认值）
+func (r *OrderSLARepository) GetEffectiveConfig(ctx context.Context, merchantID uint64) (*types.OrderSLAConfig, error) {
+	return getEffectiveOrderSLAConfig(ctx, r.GetTenantID(ctx), merchantID)
+}
+
+// getEffectiveOrderSLAConfig 按指定租户获取商户有效的处理时限配置
+func getEffectiveOrderSLAConfig(ctx context.Context, tenantID, merchantID uint64) (*types.OrderSLAConfig, error) {
+	var config types.OrderSLAConfig
+	err := TenantDBFor(ctx, tenantID).Model("order_sla_configs").
+		Ctx(ctx).
+		Where("tenant_id = ?", tenantID).
+		Where("merchant_id = ? OR merchant_id IS NULL", merchantID).
+		OrderDesc("merchant_id").
+		Limit(1).
+		Scan(&config)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.DefaultOrderSLAConfig(tenantID), nil
+		}
+		return nil, fmt.Errorf("获取订单处理时限配置失败: %v", err)
+	}
+
+	return &config, nil
+}
+
+// ListConfigs 获取租户已保存的处理时限配置，tenantID 为0时获取所有租户的配置
+func (r *OrderSLARepository) ListConfigs(ctx context.Context, tenantID uint64) ([]types.OrderSLAConfig, error) {
+	model := TenantDBFor(ctx, tenantID).Model("order_sla_configs").Ctx(ctx)
+	if tenantID > 0 {
+		model = model.Where("tenant_id = ?", tenantID)
+	}
+
+	var configs []types.OrderSLAConfig
+	if err := model.OrderAsc("id").Scan(&configs); err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取订单处理时限配置失败: %v", err)
+	}
+	return configs, nil
+}
+
+// Save 保存处理时限配置，已存在同一租户/商户的配置时覆盖
+func (r *OrderSLARepository) Save(ctx context.Context, config *types.OrderSLAConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	config.TenantID = r.GetTenantID(ctx)
+
+	_, err := TenantDB(ctx).Model("order_sla_configs").
+		Ctx(ctx).
+		Data(g.Map{
+			"tenant_id":   config.TenantID,
+			"merchant_id": config.MerchantID,
+			"statuses":    config.Statuses,
+		}).
+		Save()
+	if err != nil {
+		return fmt.Errorf("保存订单处理时限配置失败: %v", err)
+	}
+
+	return nil
+}
+
+// ListBreachEntries 查询在当前状态停留超过时限的订单，按进入当前状态的时间从早到晚排序
+func (r *OrderSLARepository) ListBreachEntries(ctx context.Context, filter *OrderSLAEntryFilter) ([]types.OrderStatusEntry, error) {
+	return listOrderSLABreachEntries(ctx, filter)
+}
+
+// listOrderSLABreachEntries 查询超时订单。进入当前状态的时间取最近一条流转到该状态的历史，
+// 没有历史时（如刚创建的订单）取订单创建时间
+func listOrderSLABreachEntries(ctx context.Context, filter *OrderSLAEntryFilter) ([]types.OrderStatusEntry, error) {
+	if len(filter.Limits) == 0 {
+		return []types.OrderStatusEntry{}, nil
+	}
+
+	statuses := make([]types.OrderStatusInt, 0, len(filter.Limits))
+	for status := range filter.Limits {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i] < statuses[j] })
+
+	conditions := make([]string, 0, len(statuses))
+	args := make([]interface{}, 0, len(statuses)*2)
+	for _, status := range statuses {
+		conditions = append(conditions, "(o.status = ? AND entered_at < ?)")
+		args = append(args, status, filter.Now.Add(-filter.Limits[status]))
+	}
+
+	model := TenantDBFor(ctx, filter.TenantID).Model("orders o").
+		Ctx(ctx).
+		LeftJoin("order_status_history h", "h.order_id = o.id AND h.to_status = o.status").
+		Fields("o.tenant_id, o.id AS order_id, o.order_number, o.merchant_id, o.status, "+
+			"COALESCE(MAX(h.created_at), o.created_at) AS entered_at").
+		WhereIn("o.status", statuses)
+	if filter.TenantID > 0 {
+		model = model.Where("o.tenant_id = ?", filter.TenantID)
+	}
+	if filter.MerchantID != nil {
+		model = model.Where("o.merchant_id = ?", *filter.MerchantID)
+	}
+	if filter.Limit > 0 {
+		model = model.Limit(filter.Limit)
+	}
+
+	var entries []types.OrderStatusEntry
+	err := model.Group("o.id").
+		Having(strings.Join(conditions, " OR "), args...).
+		OrderAsc("entered_at").
+		Scan(&entries)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询处理超时订单失败: %v", err)
+	}
+	if entries == nil {
+		entries = []types.OrderStatusEntry{}
+	}
+	return entries, nil
+}
+
+// MarkAlerted 记录订单处理超时提醒，订单本次进入该状态后已提醒过时返回false
+func (r *OrderSLARepository) MarkAlerted(ctx context.Context, entry *types.OrderStatusEntry) (bool, error) {
+	result, err := TenantDBFor(ctx, entry.TenantID).Model("order_sla_alerts").
+		Ctx(ctx).
+		Data(g.Map{
+			"tenant_id":  entry.TenantID,
+			"order_id":   entry.OrderID,
+			"status":     entry.Status,
+			"entered_at": entry.EnteredAt,
+		}).
+		InsertIgnore()
+	if err != nil {
+		return false, fmt.Errorf("记录订单处理超时提醒失败: %v", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("记录订单处理超时提醒失败: %v", err)
+	}
+	return affected > 0, nil
+}
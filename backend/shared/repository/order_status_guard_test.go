@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// orderStatusGuardStore 内存订单表：只有一笔订单，按 UPDATE 的 WHERE 条件比较当前状态，
+// 事务内的写入在提交后才生效
+type orderStatusGuardStore struct {
+	mu     sync.Mutex
+	status string
+	// afterRead 读取订单后调用，用于模拟其他请求在校验后修改了订单状态
+	afterRead func()
+
+	pendingStatus string
+	pending       []string
+	committed     []string
+	rollbacks     int
+}
+
+var testOrderStatusGuardStore = &orderStatusGuardStore{}
+
+func (s *orderStatusGuardStore) reset(status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.afterRead = status, nil
+	s.pendingStatus, s.pending, s.committed, s.rollbacks = "", nil, nil, 0
+}
+
+func (s *orderStatusGuardStore) setStatus(status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func (s *orderStatusGuardStore) currentStatus() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// committedTables 已提交写入的表
+func (s *orderStatusGuardStore) committedTables() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tables := make([]string, len(s.committed))
+	for i, query := range s.committed {
+		// INSERT INTO `table`(...) 或 UPDATE `table` SET ...
+		if strings.HasPrefix(query, "INSERT") {
+			tables[i] = strings.Trim(strings.Split(strings.Fields(query)[2], "(")[0], "`")
+		} else {
+			tables[i] = strings.Trim(strings.Fields(query)[1], "`")
+		}
+	}
+	return tables
+}
+
+// orderStatusGuardConn 内存订单表连接
+type orderStatusGuardConn struct{}
+
+func (c *orderStatusGuardConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *orderStatusGuardConn) Close() error { return nil }
+
+func (c *orderStatusGuardConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *orderStatusGuardConn) Commit() error {
+	s := testOrderStatusGuardStore
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pendingStatus != "" {
+		s.status = s.pendingStatus
+	}
+	s.committed = append(s.committed, s.pending...)
+	s.pendingStatus, s.pending = "", nil
+	return nil
+}
+
+func (c *orderStatusGuardConn) Rollback() error {
+	s := testOrderStatusGuardStore
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pendingStatus, s.pending = "", nil
+	s.rollbacks++
+	return nil
+}
+
+func (c *orderStatusGuardConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	s := testOrderStatusGuardStore
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.HasPrefix(query, "UPDATE `orders`") {
+		// UPDATE `orders` SET `status`=?,... WHERE id = ? AND tenant_id = ? AND status = ?
+		where := query[strings.Index(query, " WHERE"):]
+		if strings.Contains(where, "status") && fmt.Sprint(args[len(args)-1].Value) != s.status {
+			return driver.RowsAffected(0), nil
+		}
+		for i, column := range strings.Split(query[strings.Index(query, "SET")+4:len(query)-len(where)], ",") {
+			if strings.HasPrefix(column, "`status`=") {
+				s.pendingStatus = fmt.Sprint(args[i].Value)
+			}
+		}
+	}
+	s.pending = append(s.pending, query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *orderStatusGuardConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	s := testOrderStatusGuardStore
+	s.mu.Lock()
+	rows := &orderStatusGuardRows{row: []driver.Value{int64(1), int64(1), s.status, "[]"}}
+	afterRead := s.afterRead
+	s.mu.Unlock()
+
+	if afterRead != nil {
+		afterRead()
+	}
+	return rows, nil
+}
+
+// orderStatusGuardRows 订单查询结果
+type orderStatusGuardRows struct {
+	row []driver.Value
+}
+
+func (r *orderStatusGuardRows) Columns() []string {
+	return []string{"id", "tenant_id", "status", "items"}
+}
+
+func (r *orderStatusGuardRows) Close() error { return nil }
+
+func (r *orderStatusGuardRows) Next(dest []driver.Value) error {
+	if r.row == nil {
+		return io.EOF
+	}
+	copy(dest, r.row)
+	r.row = nil
+	return nil
+}
+
+type orderStatusGuardSQLDriver struct{}
+
+func (orderStatusGuardSQLDriver) Open(name string) (driver.Conn, error) {
+	return &orderStatusGuardConn{}, nil
+}
+
+// orderStatusGuardDBDriver gdb 驱动，连接到内存订单表
+type orderStatusGuardDBDriver struct {
+	*gdb.Core
+}
+
+func (d *orderStatusGuardDBDriver) New(core *gdb.Core, node *gdb.ConfigNode) (gdb.DB, error) {
+	return &orderStatusGuardDBDriver{Core: core}, nil
+}
+
+func (d *orderStatusGuardDBDriver) Open(config *gdb.ConfigNode) (*sql.DB, error) {
+	return sql.Open("order_status_guard", config.Name)
+}
+
+func (d *orderStatusGuardDBDriver) GetChars() (string, string) { return "`", "`" }
+
+func (d *orderStatusGuardDBDriver) Tables(ctx context.Context, schema ...string) ([]string, error) {
+	return []string{"orders", "order_status_history", "outbox_events"}, nil
+}
+
+func (d *orderStatusGuardDBDriver) TableFields(ctx context.Context, table string, schema ...string) (map[string]*gdb.TableField, error) {
+	return map[string]*gdb.TableField{
+		"id":        {Index: 0, Name: "id", Type: "bigint unsigned"},
+		"tenant_id": {Index: 1, Name: "tenant_id", Type: "bigint unsigned"},
+		"status":    {Index: 2, Name: "status", Type: "varchar(32)"},
+	}, nil
+}
+
+var registerOrderStatusGuardDriverOnce sync.Once
+
+// setupOrderStatusGuardDatasource 注册内存订单表所在的数据库分组，并将租户 1 路由到该分组
+func setupOrderStatusGuardDatasource(status types.OrderStatus) {
+	registerOrderStatusGuardDriverOnce.Do(func() {
+		sql.Register("order_status_guard", orderStatusGuardSQLDriver{})
+		if err := gdb.Register("order_status_guard", &orderStatusGuardDBDriver{}); err != nil {
+			panic(err)
+		}
+		if err := gdb.AddConfigNode("order_status_guard", gdb.ConfigNode{Type: "order_status_guard", Name: "order_status_guard"}); err != nil {
+			panic(err)
+		}
+	})
+	SetTenantDatasourceConfig(&TenantDatasourceConfig{Tenants: map[uint64]string{1: "order_status_guard"}})
+	testOrderStatusGuardStore.reset(string(status))
+}
+
+func TestOrderRepository_UpdateStatusWithHistory(t *testing.T) {
+	Convey("订单状态条件更新测试", t, func() {
+		setupOrderStatusGuardDatasource(types.OrderStatusPending)
+		defer SetTenantDatasourceConfig(nil)
+
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		// 订单读写都走租户数据源，不需要默认数据库分组
+		repo := &OrderRepository{BaseRepository: &BaseRepository{}}
+
+		Convey("状态仍为校验时的状态时更新并在同一事务中写入历史和事件", func() {
+			err := repo.UpdateStatusWithHistory(ctx, 1, types.OrderStatusIntPaid, "支付成功", types.OrderStatusOperatorTypeSystem, nil, nil)
+			So(err, ShouldBeNil)
+			So(testOrderStatusGuardStore.currentStatus(), ShouldEqual, string(types.OrderStatusPaid))
+			So(testOrderStatusGuardStore.committedTables(), ShouldResemble, []string{"orders", "order_status_history", "outbox_events"})
+		})
+
+		Convey("校验后状态已被其他请求修改时返回冲突，不写入历史", func() {
+			testOrderStatusGuardStore.afterRead = func() {
+				testOrderStatusGuardStore.setStatus(string(types.OrderStatusCancelled))
+			}
+
+			err := repo.UpdateStatusWithHistory(ctx, 1, types.OrderStatusIntPaid, "支付成功", types.OrderStatusOperatorTypeSystem, nil, nil)
+			So(errors.Is(err, types.ErrOrderStatusConflict), ShouldBeTrue)
+			So(testOrderStatusGuardStore.currentStatus(), ShouldEqual, string(types.OrderStatusCancelled))
+			So(testOrderStatusGuardStore.committedTables(), ShouldBeEmpty)
+			So(testOrderStatusGuardStore.rollbacks, ShouldEqual, 1)
+		})
+
+		Convey("不允许的状态转换在更新前拒绝", func() {
+			testOrderStatusGuardStore.setStatus(string(types.OrderStatusCompleted))
+
+			err := repo.UpdateStatusWithHistory(ctx, 1, types.OrderStatusIntPaid, "支付成功", types.OrderStatusOperatorTypeSystem, nil, nil)
+			So(err, ShouldNotBeNil)
+			So(errors.Is(err, types.ErrOrderStatusConflict), ShouldBeFalse)
+			So(testOrderStatusGuardStore.committedTables(), ShouldBeEmpty)
+		})
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
 
@@ -26,7 +25,7 @@ func (r *OrderStatusHistoryRepository) Create(ctx context.Context, history *type
 	
 	history.TenantID = tenantID
 	
-	_, err := g.DB().Model("order_status_history").Ctx(ctx).Data(history).Insert()
+	_, err := TenantDB(ctx).Model("order_status_history").Ctx(ctx).Data(history).Insert()
 	if err != nil {
 		return fmt.Errorf("创建订单状态历史记录失败: %v", err)
 	}
@@ -39,7 +38,7 @@ func (r *OrderStatusHistoryRepository) GetByOrderID(ctx context.Context, orderID
 	tenantID := r.GetTenantID(ctx)
 	
 	var histories []types.OrderStatusHistory
-	err := g.DB().Model("order_status_history").
+	err := TenantDB(ctx).Model("order_status_history").
 		Ctx(ctx).
 		Where("tenant_id = ? AND order_id = ?", tenantID, orderID).
 		OrderAsc("created_at").
@@ -56,7 +55,7 @@ func (r *OrderStatusHistoryRepository) GetLatestByOrderID(ctx context.Context, o
 	tenantID := r.GetTenantID(ctx)
 	
 	var history types.OrderStatusHistory
-	err := g.DB().Model("order_status_history").
+	err := TenantDB(ctx).Model("order_status_history").
 		Ctx(ctx).
 		Where("tenant_id = ? AND order_id = ?", tenantID, orderID).
 		OrderDesc("created_at").
@@ -93,7 +92,7 @@ func (r *OrderStatusHistoryRepository) GetByOrderIDs(ctx context.Context, orderI
 	`
 	
 	var histories []types.OrderStatusHistory
-	err := g.DB().Raw(query, tenantID, orderIDs).Scan(&histories)
+	err := TenantDB(ctx).Raw(query, tenantID, orderIDs).Scan(&histories)
 	if err != nil {
 		return nil, fmt.Errorf("批量获取订单状态历史失败: %v", err)
 	}
@@ -111,7 +110,7 @@ func (r *OrderStatusHistoryRepository) GetByOrderIDs(ctx context.Context, orderI
 func (r *OrderStatusHistoryRepository) DeleteByOrderID(ctx context.Context, orderID uint64) error {
 	tenantID := r.GetTenantID(ctx)
 	
-	_, err := g.DB().Model("order_status_history").
+	_, err := TenantDB(ctx).Model("order_status_history").
 		Ctx(ctx).
 		Where("tenant_id = ? AND order_id = ?", tenantID, orderID).
 		Delete()
@@ -126,7 +125,7 @@ func (r *OrderStatusHistoryRepository) DeleteByOrderID(ctx context.Context, orde
 func (r *OrderStatusHistoryRepository) CountByStatus(ctx context.Context, status types.OrderStatusInt, startDate, endDate *string) (int64, error) {
 	tenantID := r.GetTenantID(ctx)
 	
-	query := g.DB().Model("order_status_history").
+	query := TenantDB(ctx).Model("order_status_history").
 		Ctx(ctx).
 		Where("tenant_id = ? AND to_status = ?", tenantID, status)
 	
@@ -143,4 +142,30 @@ func (r *OrderStatusHistoryRepository) CountByStatus(ctx context.Context, status
 	}
 	
 	return int64(count), nil
-}
\ No newline at end of file
+}
+// ListForExport 按导出条件分批获取状态历史，按记录ID升序返回 afterID 之后的记录，
+// 调用方以最后一条记录ID继续获取下一批，避免大范围导出一次加载全部数据
+func (r *OrderStatusHistoryRepository) ListForExport(ctx context.Context, query *types.OrderStatusHistoryExportQuery, afterID uint64, limit int) ([]types.OrderStatusHistoryExportRow, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	model := TenantDB(ctx).Model("order_status_history h").
+		Ctx(ctx).
+		InnerJoin("orders o", "o.id = h.order_id").
+		Fields("h.id, h.order_id, o.order_number, o.merchant_id, o.customer_id, o.total_amount, h.from_status, h.to_status, h.operator_type, h.operator_id, h.reason, h.created_at").
+		Where("h.tenant_id = ? AND h.id > ?", tenantID, afterID).
+		Where("h.created_at >= ? AND h.created_at < ?", query.StartDate, query.EndDate)
+
+	if query.MerchantID != nil {
+		model = model.Where("o.merchant_id = ?", *query.MerchantID)
+	}
+	if query.Status != nil {
+		model = model.Where("h.to_status = ?", *query.Status)
+	}
+
+	var rows []types.OrderStatusHistoryExportRow
+	if err := model.OrderAsc("h.id").Limit(limit).Scan(&rows); err != nil {
+		return nil, fmt.Errorf("获取导出状态历史失败: %v", err)
+	}
+
+	return rows, nil
+}
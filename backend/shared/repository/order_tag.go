@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IOrderTagRepository 订单标签仓储接口
+type IOrderTagRepository interface {
+	AddTags(ctx context.Context, orderID uint64, tags []string, createdBy uint64) error
+	RemoveTag(ctx context.Context, orderID uint64, tag string) error
+	ListByOrderIDs(ctx context.Context, orderIDs []uint64) (map[uint64][]string, error)
+}
+
+// OrderTagRepository 订单标签仓储实现
+type OrderTagRepository struct {
+	*BaseRepository
+}
+
+// NewOrderTagRepository 创建订单标签仓储实例
+func NewOrderTagRepository() IOrderTagRepository {
+	return &OrderTagRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// AddTags 为订单添加标签，订单已有的标签忽略
+func (r *OrderTagRepository) AddTags(ctx context.Context, orderID uint64, tags []string, createdBy uint64) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	tenantID := r.GetTenantID(ctx)
+	now := gtime.Now().Time
+	data := make(g.List, 0, len(tags))
+	for _, tag := range tags {
+		data = append(data, g.Map{
+			"tenant_id":  tenantID,
+			"order_id":   orderID,
+			"tag":        tag,
+			"created_by": createdBy,
+			"created_at": now,
+		})
+	}
+
+	if _, err := TenantDB(ctx).Model("order_tags").Ctx(ctx).Data(data).InsertIgnore(); err != nil {
+		return fmt.Errorf("添加订单标签失败: %v", err)
+	}
+	return nil
+}
+
+// RemoveTag 移除订单标签
+func (r *OrderTagRepository) RemoveTag(ctx context.Context, orderID uint64, tag string) error {
+	tenantID := r.GetTenantID(ctx)
+
+	_, err := TenantDB(ctx).Model("order_tags").
+		Ctx(ctx).
+		Where("tenant_id = ? AND order_id = ? AND tag = ?", tenantID, orderID, tag).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("移除订单标签失败: %v", err)
+	}
+	return nil
+}
+
+// ListByOrderIDs 批量获取订单标签，按添加顺序返回
+func (r *OrderTagRepository) ListByOrderIDs(ctx context.Context, orderIDs []uint64) (map[uint64][]string, error) {
+	result := make(map[uint64][]string, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return result, nil
+	}
+
+	tenantID := r.GetTenantID(ctx)
+
+	var tags []types.OrderTag
+	err := TenantDB(ctx).Model("order_tags").
+		Ctx(ctx).
+		Where("tenant_id = ? AND order_id IN (?)", tenantID, orderIDs).
+		OrderAsc("id").
+		Scan(&tags)
+	if err != nil {
+		return nil, fmt.Errorf("获取订单标签失败: %v", err)
+	}
+
+	for _, tag := range tags {
+		result[tag.OrderID] = append(result[tag.OrderID], tag.Tag)
+	}
+	return result, nil
+}
+
+// IOrderSavedViewRepository 订单筛选视图仓储接口
+type IOrderSavedViewRepository interface {
+	Create(ctx context.Context, view *types.OrderSavedView) error
+	GetByID(ctx context.Context, userID, viewID uint64) (*types.OrderSavedView, error)
+	ListByUser(ctx context.Context, userID uint64) ([]types.OrderSavedView, error)
+	Delete(ctx context.Context, userID, viewID uint64) error
+}
+
+// OrderSavedViewRepository 订单筛选视图仓储实现
+type OrderSavedViewRepository struct {
+	*BaseRepository
+}
+
+// NewOrderSavedViewRepository 创建订单筛选视图仓储实例
+func NewOrderSavedViewRepository() IOrderSavedViewRepository {
+	return &OrderSavedViewRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 创建订单筛选视图，同一用户的视图名称不能重复
+func (r *OrderSavedViewRepository) Create(ctx context.Context, view *types.OrderSavedView) error {
+	view.TenantID = r.GetTenantID(ctx)
+	now := gtime.Now().Time
+	view.CreatedAt = now
+	view.UpdatedAt = now
+
+	id, err := TenantDB(ctx).Model("order_saved_views").Ctx(ctx).Data(g.Map{
+		"tenant_id":  view.TenantID,
+		"user_id":    view.UserID,
+		"name":       view.Name,
+		"filters":    view.Filters,
+		"created_at": view.CreatedAt,
+		"updated_at": view.UpdatedAt,
+	}).InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("保存订单视图失败: %v", err)
+	}
+
+	view.ID = uint64(id)
+	return nil
+}
+
+// GetByID 获取用户的订单筛选视图，不存在时返回nil
+func (r *OrderSavedViewRepository) GetByID(ctx context.Context, userID, viewID uint64) (*types.OrderSavedView, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	var view *types.OrderSavedView
+	err := TenantDB(ctx).Model("order_saved_views").
+		Ctx(ctx).
+		Where("tenant_id = ? AND user_id = ? AND id = ?", tenantID, userID, viewID).
+		Scan(&view)
+	if err != nil {
+		return nil, fmt.Errorf("获取订单视图失败: %v", err)
+	}
+	return view, nil
+}
+
+// ListByUser 获取用户的全部订单筛选视图
+func (r *OrderSavedViewRepository) ListByUser(ctx context.Context, userID uint64) ([]types.OrderSavedView, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	var views []types.OrderSavedView
+	err := TenantDB(ctx).Model("order_saved_views").
+		Ctx(ctx).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		OrderAsc("name").
+		Scan(&views)
+	if err != nil {
+		return nil, fmt.Errorf("获取订单视图列表失败: %v", err)
+	}
+	return views, nil
+}
+
+// Delete 删除用户的订单筛选视图
+func (r *OrderSavedViewRepository) Delete(ctx context.Context, userID, viewID uint64) error {
+	tenantID := r.GetTenantID(ctx)
+
+	_, err := TenantDB(ctx).Model("order_saved_views").
+		Ctx(ctx).
+		Where("tenant_id = ? AND user_id = ? AND id = ?", tenantID, userID, viewID).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("删除订单视图失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOrderTagQueryCondition(t *testing.T) {
+	Convey("订单列表按标签筛选", t, func() {
+		repo := &OrderRepository{}
+		keyword := "ORD"
+		req := &types.OrderQueryRequest{
+			SearchKeyword: &keyword,
+			Tags:          []string{"VIP", "priority"},
+		}
+
+		clause := repo.buildWhereClause(req)
+		params := repo.buildWhereParams(7, req)
+
+		Convey("要求订单同时带有全部标签并限定租户", func() {
+			So(clause, ShouldContainSubstring, "FROM order_tags WHERE tenant_id = ? AND tag IN (?,?)")
+			So(clause, ShouldContainSubstring, "HAVING COUNT(DISTINCT tag) = ?")
+			So(params[2:], ShouldResemble, []interface{}{uint64(7), "VIP", "priority", 2})
+		})
+
+		Convey("占位符数量与参数数量一致", func() {
+			So(strings.Count(clause, "?"), ShouldEqual, len(params))
+		})
+
+		Convey("未指定标签时不添加标签条件", func() {
+			req.Tags = nil
+			So(repo.buildWhereClause(req), ShouldNotContainSubstring, "order_tags")
+			So(len(repo.buildWhereParams(7, req)), ShouldEqual, 2)
+		})
+	})
+}
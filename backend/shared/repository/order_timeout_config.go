@@ -26,7 +26,7 @@ func (r *OrderTimeoutConfigRepository) Create(ctx context.Context, config *types
 	
 	config.TenantID = tenantID
 	
-	_, err := g.DB().Model("order_timeout_configs").Ctx(ctx).Data(config).Insert()
+	_, err := TenantDB(ctx).Model("order_timeout_configs").Ctx(ctx).Data(config).Insert()
 	if err != nil {
 		return fmt.Errorf("创建订单超时配置失败: %v", err)
 	}
@@ -39,7 +39,7 @@ func (r *OrderTimeoutConfigRepository) GetByMerchantID(ctx context.Context, merc
 	tenantID := r.GetTenantID(ctx)
 	
 	var config types.OrderTimeoutConfig
-	err := g.DB().Model("order_timeout_configs").
+	err := TenantDB(ctx).Model("order_timeout_configs").
 		Ctx(ctx).
 		Where("tenant_id = ? AND merchant_id = ?", tenantID, merchantID).
 		Scan(&config)
@@ -58,20 +58,14 @@ func (r *OrderTimeoutConfigRepository) GetDefaultConfig(ctx context.Context) (*t
 	tenantID := r.GetTenantID(ctx)
 	
 	var config types.OrderTimeoutConfig
-	err := g.DB().Model("order_timeout_configs").
+	err := TenantDB(ctx).Model("order_timeout_configs").
 		Ctx(ctx).
 		Where("tenant_id = ? AND merchant_id IS NULL", tenantID).
 		Scan(&config)
 	if err != nil {
 		if err.Error() == "sql: no rows in result set" {
 			// 返回默认配置值
-			return &types.OrderTimeoutConfig{
-				TenantID:               tenantID,
-				MerchantID:            nil,
-				PaymentTimeoutMinutes: 30,
-				ProcessingTimeoutHours: 24,
-				AutoCompleteEnabled:   false,
-			}, nil
+			return types.DefaultTenantTimeoutConfig(tenantID), nil
 		}
 		return nil, fmt.Errorf("获取默认超时配置失败: %v", err)
 	}
@@ -107,7 +101,7 @@ func (r *OrderTimeoutConfigRepository) Update(ctx context.Context, config *types
 		"id":       config.ID,
 	}
 	
-	_, err := g.DB().Model("order_timeout_configs").
+	_, err := TenantDB(ctx).Model("order_timeout_configs").
 		Ctx(ctx).
 		Where(whereCondition).
 		Data(config).
@@ -123,7 +117,7 @@ func (r *OrderTimeoutConfigRepository) Update(ctx context.Context, config *types
 func (r *OrderTimeoutConfigRepository) Delete(ctx context.Context, id uint64) error {
 	tenantID := r.GetTenantID(ctx)
 	
-	_, err := g.DB().Model("order_timeout_configs").
+	_, err := TenantDB(ctx).Model("order_timeout_configs").
 		Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, id).
 		Delete()
@@ -139,7 +133,7 @@ func (r *OrderTimeoutConfigRepository) ListByTenant(ctx context.Context) ([]type
 	tenantID := r.GetTenantID(ctx)
 	
 	var configs []types.OrderTimeoutConfig
-	err := g.DB().Model("order_timeout_configs").
+	err := TenantDB(ctx).Model("order_timeout_configs").
 		Ctx(ctx).
 		Where("tenant_id = ?", tenantID).
 		OrderAsc("merchant_id").
@@ -149,4 +143,22 @@ func (r *OrderTimeoutConfigRepository) ListByTenant(ctx context.Context) ([]type
 	}
 	
 	return configs, nil
-}
\ No newline at end of file
+}
+
+// ListAutoCompleteEnabled 获取所有启用自动完成的超时配置（跨租户，仅供系统定时任务使用），逐个扫描租户数据所在的数据源
+func (r *OrderTimeoutConfigRepository) ListAutoCompleteEnabled(ctx context.Context) ([]types.OrderTimeoutConfig, error) {
+	var configs []types.OrderTimeoutConfig
+	for _, group := range TenantDatasources() {
+		var enabled []types.OrderTimeoutConfig
+		err := g.DB(group).Model("order_timeout_configs").
+			Ctx(ctx).
+			Where("auto_complete_enabled = ?", true).
+			Scan(&enabled)
+		if err != nil {
+			return nil, fmt.Errorf("获取数据源 %s 的自动完成配置失败: %v", group, err)
+		}
+		configs = append(configs, enabled...)
+	}
+	
+	return configs, nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IOrderWebhookRepository 订单 Webhook 配置及推送记录仓储接口
+type IOrderWebhookRepository interface {
+	Create(ctx context.Context, webhook *types.OrderWebhook) error
+	Update(ctx context.Context, webhook *types.OrderWebhook) error
+	Delete(ctx context.Context, id uint64) error
+	// 获取当前租户的 Webhook，不存在时返回 nil
+	GetByID(ctx context.Context, id uint64) (*types.OrderWebhook, error)
+	List(ctx context.Context) ([]types.OrderWebhook, error)
+	// 获取当前租户启用的 Webhook
+	ListEnabled(ctx context.Context) ([]types.OrderWebhook, error)
+	CreateDelivery(ctx context.Context, delivery *types.OrderWebhookDelivery) error
+	// 获取 Webhook 最近的推送记录，按时间倒序
+	ListDeliveries(ctx context.Context, webhookID uint64, limit int) ([]types.OrderWebhookDelivery, error)
+}
+
+// OrderWebhookRepository 订单 Webhook 仓储实现
+type OrderWebhookRepository struct {
+	*BaseRepository
+}
+
+// NewOrderWebhookRepository 创建订单 Webhook 仓储实例
+func NewOrderWebhookRepository() IOrderWebhookRepository {
+	return &OrderWebhookRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 创建 Webhook 配置
+func (r *OrderWebhookRepository) Create(ctx context.Context, webhook *types.OrderWebhook) error {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return fmt.Errorf("missing tenant_id in context")
+	}
+
+	now := gtime.Now().Time
+	webhook.TenantID = tenantID
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	id, err := TenantDB(ctx).Model("order_webhooks").Ctx(ctx).Data(g.Map{
+		"tenant_id":       tenantID,
+		"url":             webhook.URL,
+		"secret":          webhook.Secret,
+		"events":          webhook.Events,
+		"config_sections": webhook.ConfigSections,
+		"enabled":         webhook.Enabled,
+		"created_at":      now,
+		"updated_at":      now,
+	}).InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("创建Webhook失败: %v", err)
+	}
+
+	webhook.ID = uint64(id)
+	return nil
+}
+
+// Update 更新 Webhook 配置
+func (r *OrderWebhookRepository) Update(ctx context.Context, webhook *types.OrderWebhook) error {
+	webhook.UpdatedAt = gtime.Now().Time
+	_, err := TenantDB(ctx).Model("order_webhooks").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", webhook.ID, r.GetTenantID(ctx)).
+		Data(g.Map{
+			"url":             webhook.URL,
+			"secret":          webhook.Secret,
+			"events":          webhook.Events,
+			"config_sections": webhook.ConfigSections,
+			"enabled":         webhook.Enabled,
+			"updated_at":      webhook.UpdatedAt,
+		}).
+		Update()
+	if err != nil {
+		return fmt.Errorf("更新Webhook失败: %v", err)
+	}
+	return nil
+}
+
+// Delete 删除 Webhook 配置，推送记录保留用于排查
+func (r *OrderWebhookRepository) Delete(ctx context.Context, id uint64) error {
+	_, err := TenantDB(ctx).Model("order_webhooks").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", id, r.GetTenantID(ctx)).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("删除Webhook失败: %v", err)
+	}
+	return nil
+}
+
+// GetByID 获取当前租户的 Webhook 配置
+func (r *OrderWebhookRepository) GetByID(ctx context.Context, id uint64) (*types.OrderWebhook, error) {
+	var webhook *types.OrderWebhook
+	err := TenantDB(ctx).Model("order_webhooks").
+		Ctx(ctx).
+		Where("id = ? AND tenant_id = ?", id, r.GetTenantID(ctx)).
+		Scan(&webhook)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取Webhook失败: %v", err)
+	}
+	return webhook, nil
+}
+
+// List 获取当前租户的全部 Webhook 配置
+func (r *OrderWebhookRepository) List(ctx context.Context) ([]types.OrderWebhook, error) {
+	return r.list(ctx, false)
+}
+
+// ListEnabled 获取当前租户启用的 Webhook 配置
+func (r *OrderWebhookRepository) ListEnabled(ctx context.Context) ([]types.OrderWebhook, error) {
+	return r.list(ctx, true)
+}
+
+func (r *OrderWebhookRepository) list(ctx context.Context, enabledOnly bool) ([]types.OrderWebhook, error) {
+	query := TenantDB(ctx).Model("order_webhooks").
+		Ctx(ctx).
+		Where("tenant_id = ?", r.GetTenantID(ctx))
+	if enabledOnly {
+		query = query.Where("enabled = ?", true)
+	}
+
+	var webhooks []types.OrderWebhook
+	if err := query.OrderAsc("id").Scan(&webhooks); err != nil {
+		return nil, fmt.Errorf("获取Webhook列表失败: %v", err)
+	}
+	return webhooks, nil
+}
+
+// CreateDelivery 记录一次推送尝试
+func (r *OrderWebhookRepository) CreateDelivery(ctx context.Context, delivery *types.OrderWebhookDelivery) error {
+	delivery.TenantID = r.GetTenantID(ctx)
+	delivery.CreatedAt = gtime.Now().Time
+	if len(delivery.Error) > 500 {
+		delivery.Error = delivery.Error[:500]
+	}
+
+	id, err := TenantDB(ctx).Model("order_webhook_deliveries").Ctx(ctx).Data(delivery).OmitEmpty().InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("记录Webhook推送失败: %v", err)
+	}
+
+	delivery.ID = uint64(id)
+	return nil
+}
+
+// ListDeliveries 获取 Webhook 最近的推送记录
+func (r *OrderWebhookRepository) ListDeliveries(ctx context.Context, webhookID uint64, limit int) ([]types.OrderWebhookDelivery, error) {
+	var deliveries []types.OrderWebhookDelivery
+	err := TenantDB(ctx).Model("order_webhook_deliveries").
+		Ctx(ctx).
+		Where("tenant_id = ? AND webhook_id = ?", r.GetTenantID(ctx), webhookID).
+		OrderDesc("id").
+		Limit(limit).
+		Scan(&deliveries)
+	if err != nil {
+		return nil, fmt.Errorf("获取Webhook推送记录失败: %v", err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// IOutboxRepository 发件箱事件仓储接口
+type IOutboxRepository interface {
+	FetchPending(ctx context.Context, limit int) ([]types.OutboxEvent, error)
+	// 事件ID只在所在数据源内唯一，按事件所属租户定位数据源
+	MarkProcessed(ctx context.Context, tenantID, id uint64) error
+	MarkFailed(ctx context.Context, tenantID, id uint64, reason string, retryAt time.Time) error
+	// 写入独立于业务事务的事件，在 availableAt 之后才会被投递
+	Enqueue(ctx context.Context, event *types.OutboxEvent) error
+}
+
+// OutboxRepository 发件箱事件仓储实现
+type OutboxRepository struct {
+	*BaseRepository
+}
+
+// NewOutboxRepository 创建发件箱事件仓储实例
+func NewOutboxRepository() IOutboxRepository {
+	return &OutboxRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// FetchPending 获取已到投递时间的待投递事件（跨租户，仅供后台投递器使用）。
+// 逐个扫描租户数据所在的数据源，每个数据源最多返回 limit 条
+func (r *OutboxRepository) FetchPending(ctx context.Context, limit int) ([]types.OutboxEvent, error) {
+	var events []types.OutboxEvent
+	for _, group := range TenantDatasources() {
+		var pending []types.OutboxEvent
+		err := g.DB(group).Model("outbox_events").
+			Ctx(ctx).
+			Where("status = ? AND available_at <= ?", types.OutboxEventStatusPending, time.Now()).
+			OrderAsc("id").
+			Limit(limit).
+			Scan(&pending)
+		if err != nil {
+			return nil, fmt.Errorf("获取数据源 %s 的待投递事件失败: %v", group, err)
+		}
+		events = append(events, pending...)
+	}
+
+	return events, nil
+}
+
+// MarkProcessed 标记事件已投递
+func (r *OutboxRepository) MarkProcessed(ctx context.Context, tenantID, id uint64) error {
+	_, err := TenantDBFor(ctx, tenantID).Model("outbox_events").
+		Ctx(ctx).
+		Where("id = ?", id).
+		Update(gdb.Map{
+			"status":       types.OutboxEventStatusProcessed,
+			"processed_at": time.Now(),
+		})
+	if err != nil {
+		return fmt.Errorf("标记事件已投递失败: %v", err)
+	}
+
+	return nil
+}
+
+// MarkFailed 记录投递失败，事件保持待投递状态并在 retryAt 之后重试
+func (r *OutboxRepository) MarkFailed(ctx context.Context, tenantID, id uint64, reason string, retryAt time.Time) error {
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+
+	_, err := TenantDBFor(ctx, tenantID).Model("outbox_events").
+		Ctx(ctx).
+		Where("id = ?", id).
+		Update(gdb.Map{
+			"attempts":     gdb.Raw("attempts + 1"),
+			"last_error":   reason,
+			"available_at": retryAt,
+		})
+	if err != nil {
+		return fmt.Errorf("记录事件投递失败: %v", err)
+	}
+
+	return nil
+}
+
+// Enqueue 写入一条待投递事件，用于延迟执行等无需与业务数据同事务的场景
+func (r *OutboxRepository) Enqueue(ctx context.Context, event *types.OutboxEvent) error {
+	now := time.Now()
+	availableAt := event.AvailableAt
+	if availableAt.IsZero() {
+		availableAt = now
+	}
+
+	id, err := TenantDB(ctx).Model("outbox_events").Ctx(ctx).Data(gdb.Map{
+		"tenant_id":      event.TenantID,
+		"aggregate_type": event.AggregateType,
+		"aggregate_id":   event.AggregateID,
+		"event_type":     event.EventType,
+		"payload":        event.Payload,
+		"status":         types.OutboxEventStatusPending,
+		"available_at":   availableAt,
+		"created_at":     now,
+	}).InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("写入发件箱事件失败: %v", err)
+	}
+
+	event.ID = uint64(id)
+	event.Status = types.OutboxEventStatusPending
+	event.AvailableAt = availableAt
+	event.CreatedAt = now
+	return nil
+}
+
+// insertOutboxEvent 在调用方事务中写入发件箱事件
+func insertOutboxEvent(ctx context.Context, tx gdb.TX, tenantID uint64, aggregateType string, aggregateID uint64, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化事件载荷失败: %v", err)
+	}
+
+	now := time.Now()
+	_, err = tx.Model("outbox_events").Ctx(ctx).Data(gdb.Map{
+		"tenant_id":      tenantID,
+		"aggregate_type": aggregateType,
+		"aggregate_id":   aggregateID,
+		"event_type":     eventType,
+		"payload":        string(data),
+		"status":         types.OutboxEventStatusPending,
+		"available_at":   now,
+		"created_at":     now,
+	}).Insert()
+	if err != nil {
+		return fmt.Errorf("写入发件箱事件失败: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IPasswordHistoryRepository 密码历史仓储接口
+type IPasswordHistoryRepository interface {
+	Create(ctx context.Context, history *types.PasswordHistory) error
+	ListRecent(ctx context.Context, userID uint64, limit int) ([]types.PasswordHistory, error)
+	Prune(ctx context.Context, userID uint64, keep int) error
+}
+
+// PasswordHistoryRepository 密码历史仓储实现
+type PasswordHistoryRepository struct {
+	*BaseRepository
+}
+
+// NewPasswordHistoryRepository 创建密码历史仓储实例
+func NewPasswordHistoryRepository() IPasswordHistoryRepository {
+	return &PasswordHistoryRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 记录用户设置的密码哈希
+func (r *PasswordHistoryRepository) Create(ctx context.Context, history *types.PasswordHistory) error {
+	if history.TenantID == 0 {
+		history.TenantID = r.GetTenantID(ctx)
+	}
+	history.CreatedAt = gtime.Now().Time
+
+	id, err := TenantDB(ctx).Model("password_histories").Ctx(ctx).Data(history).OmitEmpty().InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("记录密码历史失败: %v", err)
+	}
+
+	history.ID = uint64(id)
+	return nil
+}
+
+// ListRecent 获取用户最近设置的密码，按设置时间倒序
+func (r *PasswordHistoryRepository) ListRecent(ctx context.Context, userID uint64, limit int) ([]types.PasswordHistory, error) {
+	var histories []types.PasswordHistory
+	err := TenantDB(ctx).Model("password_histories").
+		Ctx(ctx).
+		Where("tenant_id = ? AND user_id = ?", r.GetTenantID(ctx), userID).
+		OrderDesc("id").
+		Limit(limit).
+		Scan(&histories)
+	if err != nil {
+		return nil, fmt.Errorf("获取密码历史失败: %v", err)
+	}
+
+	return histories, nil
+}
+
+// Prune 只保留用户最近 keep 条密码历史
+func (r *PasswordHistoryRepository) Prune(ctx context.Context, userID uint64, keep int) error {
+	recent, err := r.ListRecent(ctx, userID, keep)
+	if err != nil {
+		return err
+	}
+	if len(recent) < keep {
+		return nil
+	}
+
+	_, err = TenantDB(ctx).Model("password_histories").
+		Ctx(ctx).
+		Where("tenant_id = ? AND user_id = ?", r.GetTenantID(ctx), userID).
+		WhereLT("id", recent[len(recent)-1].ID).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("清理密码历史失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// IPaymentReconciliationRepository 支付对账记录仓储接口
+type IPaymentReconciliationRepository interface {
+	Create(ctx context.Context, record *types.PaymentReconciliation) error
+	ListByOrderID(ctx context.Context, orderID uint64) ([]types.PaymentReconciliation, error)
+}
+
+// PaymentReconciliationRepository 支付对账记录数据访问层
+type PaymentReconciliationRepository struct {
+	*BaseRepository
+}
+
+// NewPaymentReconciliationRepository 创建支付对账记录仓库实例
+func NewPaymentReconciliationRepository() IPaymentReconciliationRepository {
+	return &PaymentReconciliationRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 记录对账结果，租户取自记录本身（对账任务跨租户运行）
+func (r *PaymentReconciliationRepository) Create(ctx context.Context, record *types.PaymentReconciliation) error {
+	id, err := TenantDB(ctx).Model("payment_reconciliations").
+		Ctx(ctx).
+		Data(g.Map{
+			"tenant_id":      record.TenantID,
+			"order_id":       record.OrderID,
+			"order_number":   record.OrderNumber,
+			"trade_status":   record.TradeStatus,
+			"transaction_id": record.TransactionID,
+			"outcome":        record.Outcome,
+			"message":        record.Message,
+		}).
+		InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("记录支付对账结果失败: %v", err)
+	}
+
+	record.ID = uint64(id)
+	return nil
+}
+
+// ListByOrderID 获取订单的对账记录，最新的在前
+func (r *PaymentReconciliationRepository) ListByOrderID(ctx context.Context, orderID uint64) ([]types.PaymentReconciliation, error) {
+	var records []types.PaymentReconciliation
+	err := TenantDB(ctx).Model("payment_reconciliations").
+		Ctx(ctx).
+		Where("tenant_id = ? AND order_id = ?", r.GetTenantID(ctx), orderID).
+		OrderDesc("id").
+		Scan(&records)
+	if err != nil {
+		return nil, fmt.Errorf("获取支付对账记录失败: %v", err)
+	}
+	return records, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gofromzero/mer-sys/backend/shared/types"
 	"github.com/gogf/gf/v2/database/gdb"
@@ -24,7 +25,7 @@ func NewProductRepository() *ProductRepository {
 
 // Create 创建商品
 func (r *ProductRepository) Create(ctx context.Context, product *types.Product) error {
-	db := g.DB()
+	db := TenantDB(ctx)
 	
 	// 自动注入租户和商户信息
 	tenantID := r.GetTenantID(ctx)
@@ -59,7 +60,7 @@ func (r *ProductRepository) GetByID(ctx context.Context, id uint64) (*types.Prod
 	}
 	
 	var product types.Product
-	err := g.DB().Model("products").
+	err := TenantDB(ctx).Model("products").
 		Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", id, tenantID).
 		Scan(&product)
@@ -93,7 +94,7 @@ func (r *ProductRepository) GetByIDWithCategory(ctx context.Context, id uint64)
 	// 获取分类信息
 	if product.CategoryID != nil {
 		var category types.ProductCategory
-		err := g.DB().Model("product_categories").
+		err := TenantDB(ctx).Model("product_categories").
 			Ctx(ctx).
 			Where("id = ? AND tenant_id = ?", *product.CategoryID, tenantID).
 			Scan(&category)
@@ -118,7 +119,7 @@ func (r *ProductRepository) Update(ctx context.Context, id uint64, updates map[s
 	if _, exists := updates["version"]; !exists {
 		// 获取当前版本号并加1
 		var currentVersion int
-		err := g.DB().Model("products").
+		err := TenantDB(ctx).Model("products").
 			Ctx(ctx).
 			Where("id = ? AND tenant_id = ? AND merchant_id = ?", id, tenantID, merchantID).
 			Fields("version").
@@ -129,7 +130,7 @@ func (r *ProductRepository) Update(ctx context.Context, id uint64, updates map[s
 		updates["version"] = currentVersion + 1
 	}
 	
-	result, err := g.DB().Model("products").
+	result, err := TenantDB(ctx).Model("products").
 		Ctx(ctx).
 		Where("id = ? AND tenant_id = ? AND merchant_id = ?", id, tenantID, merchantID).
 		Update(updates)
@@ -166,7 +167,7 @@ func (r *ProductRepository) List(ctx context.Context, req *types.ProductListRequ
 		return nil, fmt.Errorf("missing tenant_id or merchant_id in context")
 	}
 	
-	db := g.DB().Model("products p").
+	db := TenantDB(ctx).Model("products p").
 		LeftJoin("product_categories c", "p.category_id = c.id").
 		Where("p.tenant_id = ? AND p.merchant_id = ?", tenantID, merchantID).
 		Where("p.status != ?", types.ProductStatusDeleted)
@@ -180,6 +181,12 @@ func (r *ProductRepository) List(ctx context.Context, req *types.ProductListRequ
 		db = db.Where("p.status = ?", req.Status)
 	}
 	
+	// 默认只返回处于可售时间窗口内的商品
+	if !req.IncludeUnavailable {
+		now := time.Now()
+		db = db.Where("(p.available_from IS NULL OR p.available_from <= ?) AND (p.available_until IS NULL OR p.available_until > ?)", now, now)
+	}
+	
 	if req.Keyword != "" {
 		keyword := "%" + req.Keyword + "%"
 		db = db.Where("(p.name LIKE ? OR p.description LIKE ?)", keyword, keyword)
@@ -273,7 +280,7 @@ func (r *ProductRepository) BatchUpdateStatus(ctx context.Context, productIDs []
 	}
 	args = append(args, tenantID, merchantID, status)
 	
-	result, err := g.DB().Exec(ctx, 
+	result, err := TenantDB(ctx).Exec(ctx, 
 		fmt.Sprintf("UPDATE products SET status = ?, version = version + 1 WHERE id IN (%s) AND tenant_id = ? AND merchant_id = ?", placeholders),
 		append([]interface{}{status}, args[:len(productIDs)+2]...)...,
 	)
@@ -297,7 +304,7 @@ func (r *ProductRepository) GetMerchantProducts(ctx context.Context, merchantID
 		return nil, fmt.Errorf("missing tenant_id in context")
 	}
 	
-	db := g.DB().Model("products").
+	db := TenantDB(ctx).Model("products").
 		Where("tenant_id = ? AND merchant_id = ?", tenantID, merchantID)
 	
 	if status != "" {
@@ -380,7 +387,7 @@ func (r *ProductRepository) AdjustInventory(ctx context.Context, productID uint6
 	}
 	
 	// 在事务中执行库存调整
-	tx, err := g.DB().Begin(ctx)
+	tx, err := TenantDB(ctx).Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -410,8 +417,8 @@ func (r *ProductRepository) AdjustInventory(ctx context.Context, productID uint6
 	oldQuantity := product.InventoryInfo.StockQuantity
 	newQuantity := oldQuantity + adjustment
 	
-	// 检查库存不能为负数
-	if newQuantity < 0 {
+	// 检查库存不能低于下限（接受预订的商品允许在预订上限内为负）
+	if newQuantity < product.InventoryInfo.MinStockQuantity() {
 		return nil, fmt.Errorf("insufficient inventory: current=%d, adjustment=%d", oldQuantity, adjustment)
 	}
 	
@@ -438,15 +445,16 @@ func (r *ProductRepository) AdjustInventory(ctx context.Context, productID uint6
 }
 
 // ReserveInventory 预留库存
-func (r *ProductRepository) ReserveInventory(ctx context.Context, productID uint64, quantity int) error {
+func (r *ProductRepository) ReserveInventory(ctx context.Context, productID uint64, quantity int) (int, error) {
 	tenantID := r.GetTenantID(ctx)
 	merchantID := r.GetMerchantID(ctx)
 	if tenantID == 0 || merchantID == 0 {
-		return fmt.Errorf("missing tenant_id or merchant_id in context")
+		return 0, fmt.Errorf("missing tenant_id or merchant_id in context")
 	}
 	
 	// 在事务中执行库存预留
-	return g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+	backordered := 0
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
 		// 获取当前库存信息（行级锁）
 		var product types.Product
 		err := tx.Model("products").
@@ -461,10 +469,10 @@ func (r *ProductRepository) ReserveInventory(ctx context.Context, productID uint
 			return fmt.Errorf("product not found")
 		}
 		
-		// 检查可用库存
-		availableStock := product.InventoryInfo.StockQuantity - product.InventoryInfo.ReservedQuantity
-		if availableStock < quantity {
-			return fmt.Errorf("insufficient available inventory: available=%d, requested=%d", availableStock, quantity)
+		// 检查可用库存，接受预订的商品在预订上限内允许超出
+		backordered, err = product.InventoryInfo.CheckReserve(quantity)
+		if err != nil {
+			return fmt.Errorf("%w: available=%d, requested=%d", err, product.InventoryInfo.AvailableStock(), quantity)
 		}
 		
 		// 增加预留数量
@@ -480,6 +488,7 @@ func (r *ProductRepository) ReserveInventory(ctx context.Context, productID uint
 		
 		return err
 	})
+	return backordered, err
 }
 
 // ReleaseInventory 释放预留库存
@@ -491,7 +500,7 @@ func (r *ProductRepository) ReleaseInventory(ctx context.Context, productID uint
 	}
 	
 	// 在事务中执行库存释放
-	return g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+	return TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
 		// 获取当前库存信息（行级锁）
 		var product types.Product
 		err := tx.Model("products").
@@ -535,7 +544,7 @@ func (r *ProductRepository) GetLowStockProducts(ctx context.Context) ([]types.Pr
 	}
 	
 	var products []types.Product
-	err := g.DB().Model("products").
+	err := TenantDB(ctx).Model("products").
 		Ctx(ctx).
 		Where("tenant_id = ? AND merchant_id = ?", tenantID, merchantID).
 		Where("status != ?", types.ProductStatusDeleted).
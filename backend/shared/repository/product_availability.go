@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+)
+
+// IProductAvailabilityRepository 商品可售时间窗口仓储接口
+type IProductAvailabilityRepository interface {
+	// 获取当前租户下的商品，用于下单时校验可售时间窗口
+	GetByIDs(ctx context.Context, ids []uint64) ([]types.Product, error)
+	// 跨租户获取到达上架或下架时间的商品，仅供后台定时任务使用
+	ListDueForTransition(ctx context.Context, now time.Time, limit int) ([]types.Product, error)
+	// 商品仍处于 from 状态时更新为 to 并记录状态变更历史，返回是否更新
+	TransitionStatus(ctx context.Context, product *types.Product, from, to types.ProductStatus) (bool, error)
+}
+
+// NewProductAvailabilityRepository 创建商品可售时间窗口仓储实例
+func NewProductAvailabilityRepository() IProductAvailabilityRepository {
+	return NewProductRepository()
+}
+
+// GetByIDs 批量获取当前租户下的商品，不存在的商品不返回
+func (r *ProductRepository) GetByIDs(ctx context.Context, ids []uint64) ([]types.Product, error) {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return nil, fmt.Errorf("missing tenant_id in context")
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var products []types.Product
+	err := TenantDB(ctx).Model("products").
+		Ctx(ctx).
+		Where("tenant_id = ?", tenantID).
+		WhereIn("id", ids).
+		Scan(&products)
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// ListDueForTransition 获取已到开始时间的待上架商品和已到结束时间的在售商品
+func (r *ProductRepository) ListDueForTransition(ctx context.Context, now time.Time, limit int) ([]types.Product, error) {
+	var products []types.Product
+	err := TenantDB(ctx).Model("products").
+		Ctx(ctx).
+		Where("(status = ? AND (available_from IS NULL OR available_from <= ?)) OR (status = ? AND available_until <= ?)",
+			types.ProductStatusScheduled, now, types.ProductStatusActive, now).
+		OrderAsc("id").
+		Limit(limit).
+		Scan(&products)
+	if err != nil {
+		return nil, fmt.Errorf("获取待定时上下架商品失败: %v", err)
+	}
+
+	return products, nil
+}
+
+// TransitionStatus 以商品当前状态为条件更新状态，避免覆盖商户在此期间的手动操作；
+// 状态变更历史在同一事务中记录，操作人为系统（0）
+func (r *ProductRepository) TransitionStatus(ctx context.Context, product *types.Product, from, to types.ProductStatus) (bool, error) {
+	updated := false
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		result, err := tx.Model("products").Ctx(ctx).
+			Where("id = ? AND tenant_id = ? AND status = ?", product.ID, product.TenantID, from).
+			Update(gdb.Map{
+				"status":  to,
+				"version": gdb.Raw("version + 1"),
+			})
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return nil
+		}
+
+		oldValue, _ := json.Marshal(from)
+		newValue, _ := json.Marshal(to)
+		_, err = tx.Model("product_histories").Ctx(ctx).Insert(&types.ProductHistory{
+			TenantID:  product.TenantID,
+			ProductID: product.ID,
+			Version:   product.Version + 1,
+			FieldName: "status",
+			OldValue:  string(oldValue),
+			NewValue:  string(newValue),
+			Operation: types.ChangeOperationStatusChange,
+			ChangedBy: 0,
+		})
+		if err != nil {
+			return err
+		}
+
+		updated = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("更新商品状态失败: %v", err)
+	}
+
+	return updated, nil
+}
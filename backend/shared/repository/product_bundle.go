@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IProductBundleRepository 商品套餐仓储接口，所有操作限定在当前租户
+type IProductBundleRepository interface {
+	Create(ctx context.Context, bundle *types.ProductBundle) error
+	GetByID(ctx context.Context, id uint64) (*types.ProductBundle, error)
+	// 批量获取套餐，不存在的套餐不返回
+	GetByIDs(ctx context.Context, ids []uint64) ([]types.ProductBundle, error)
+	// 按商户获取套餐，status 为空时不限状态
+	ListByMerchant(ctx context.Context, merchantID uint64, status types.ProductBundleStatus) ([]types.ProductBundle, error)
+	UpdateStatus(ctx context.Context, id uint64, status types.ProductBundleStatus) error
+}
+
+// ProductBundleRepository 商品套餐仓储实现
+type ProductBundleRepository struct {
+	*BaseRepository
+}
+
+// NewProductBundleRepository 创建商品套餐仓储实例
+func NewProductBundleRepository() IProductBundleRepository {
+	return &ProductBundleRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 保存套餐
+func (r *ProductBundleRepository) Create(ctx context.Context, bundle *types.ProductBundle) error {
+	bundle.TenantID = r.GetTenantID(ctx)
+	now := gtime.Now().Time
+	bundle.CreatedAt = now
+	bundle.UpdatedAt = now
+
+	id, err := TenantDB(ctx).Model("product_bundles").Ctx(ctx).Data(g.Map{
+		"tenant_id":   bundle.TenantID,
+		"merchant_id": bundle.MerchantID,
+		"name":        bundle.Name,
+		"description": bundle.Description,
+		"components":  bundle.Components,
+		"price":       bundle.Price,
+		"rights_cost": bundle.RightsCost,
+		"status":      bundle.Status,
+		"created_at":  bundle.CreatedAt,
+		"updated_at":  bundle.UpdatedAt,
+	}).InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("保存套餐失败: %v", err)
+	}
+
+	bundle.ID = uint64(id)
+	return nil
+}
+
+// GetByID 获取套餐，不存在时返回nil
+func (r *ProductBundleRepository) GetByID(ctx context.Context, id uint64) (*types.ProductBundle, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	var bundle *types.ProductBundle
+	err := TenantDB(ctx).Model("product_bundles").
+		Ctx(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Scan(&bundle)
+	if err != nil {
+		return nil, fmt.Errorf("获取套餐失败: %v", err)
+	}
+	return bundle, nil
+}
+
+// GetByIDs 批量获取当前租户下的套餐
+func (r *ProductBundleRepository) GetByIDs(ctx context.Context, ids []uint64) ([]types.ProductBundle, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	tenantID := r.GetTenantID(ctx)
+
+	var bundles []types.ProductBundle
+	err := TenantDB(ctx).Model("product_bundles").
+		Ctx(ctx).
+		Where("tenant_id = ? AND id IN (?)", tenantID, ids).
+		Scan(&bundles)
+	if err != nil {
+		return nil, fmt.Errorf("获取套餐失败: %v", err)
+	}
+	return bundles, nil
+}
+
+// ListByMerchant 获取商户的套餐，最新创建的在前
+func (r *ProductBundleRepository) ListByMerchant(ctx context.Context, merchantID uint64, status types.ProductBundleStatus) ([]types.ProductBundle, error) {
+	tenantID := r.GetTenantID(ctx)
+
+	model := TenantDB(ctx).Model("product_bundles").
+		Ctx(ctx).
+		Where("tenant_id = ? AND merchant_id = ?", tenantID, merchantID)
+	if status != "" {
+		model = model.Where("status = ?", status)
+	}
+
+	var bundles []types.ProductBundle
+	if err := model.OrderDesc("id").Scan(&bundles); err != nil {
+		return nil, fmt.Errorf("获取套餐列表失败: %v", err)
+	}
+	return bundles, nil
+}
+
+// UpdateStatus 更新套餐状态
+func (r *ProductBundleRepository) UpdateStatus(ctx context.Context, id uint64, status types.ProductBundleStatus) error {
+	tenantID := r.GetTenantID(ctx)
+
+	_, err := TenantDB(ctx).Model("product_bundles").
+		Ctx(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Update(g.Map{
+			"status":     status,
+			"updated_at": gtime.Now().Time,
+		})
+	if err != nil {
+		return fmt.Errorf("更新套餐状态失败: %v", err)
+	}
+	return nil
+}
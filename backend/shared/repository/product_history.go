@@ -31,7 +31,7 @@ func (r *ProductHistoryRepository) RecordChange(ctx context.Context, productID u
 	
 	// 获取当前商品版本
 	var version int
-	err := g.DB().Model("products").
+	err := TenantDB(ctx).Model("products").
 		Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", productID, tenantID).
 		Fields("version").
@@ -57,7 +57,7 @@ func (r *ProductHistoryRepository) RecordChange(ctx context.Context, productID u
 		ChangedBy: userID,
 	}
 	
-	_, err = g.DB().Model("product_histories").Ctx(ctx).Insert(history)
+	_, err = TenantDB(ctx).Model("product_histories").Ctx(ctx).Insert(history)
 	return err
 }
 
@@ -71,7 +71,7 @@ func (r *ProductHistoryRepository) RecordFieldChange(ctx context.Context, produc
 	
 	// 获取当前商品版本
 	var version int
-	err := g.DB().Model("products").
+	err := TenantDB(ctx).Model("products").
 		Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", productID, tenantID).
 		Fields("version").
@@ -107,7 +107,7 @@ func (r *ProductHistoryRepository) RecordFieldChange(ctx context.Context, produc
 		ChangedBy: userID,
 	}
 	
-	_, err = g.DB().Model("product_histories").Ctx(ctx).Insert(history)
+	_, err = TenantDB(ctx).Model("product_histories").Ctx(ctx).Insert(history)
 	return err
 }
 
@@ -127,7 +127,7 @@ func (r *ProductHistoryRepository) GetProductHistory(ctx context.Context, produc
 	}
 	
 	var histories []types.ProductHistory
-	err := g.DB().Model("product_histories ph").
+	err := TenantDB(ctx).Model("product_histories ph").
 		LeftJoin("users u", "ph.changed_by = u.id").
 		Fields("ph.*, u.username as changed_by_name").
 		Where("ph.product_id = ? AND ph.tenant_id = ?", productID, tenantID).
@@ -150,7 +150,7 @@ func (r *ProductHistoryRepository) GetVersionHistory(ctx context.Context, produc
 	}
 	
 	var histories []types.ProductHistory
-	err := g.DB().Model("product_histories").
+	err := TenantDB(ctx).Model("product_histories").
 		Ctx(ctx).
 		Where("product_id = ? AND tenant_id = ? AND version = ?", productID, tenantID, version).
 		Order("changed_at DESC").
@@ -178,7 +178,7 @@ func (r *ProductHistoryRepository) GetUserChanges(ctx context.Context, userID ui
 	}
 	
 	var histories []types.ProductHistory
-	err := g.DB().Model("product_histories ph").
+	err := TenantDB(ctx).Model("product_histories ph").
 		LeftJoin("products p", "ph.product_id = p.id").
 		Fields("ph.*, p.name as product_name").
 		Where("ph.changed_by = ? AND ph.tenant_id = ?", userID, tenantID).
@@ -200,7 +200,7 @@ func (r *ProductHistoryRepository) CleanupOldHistories(ctx context.Context, keep
 		keepDays = 90 // 默认保留90天
 	}
 	
-	result, err := g.DB().Exec(ctx, 
+	result, err := TenantDB(ctx).Exec(ctx, 
 		"DELETE FROM product_histories WHERE changed_at < DATE_SUB(NOW(), INTERVAL ? DAY)",
 		keepDays,
 	)
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+)
+
+// productCoPurchaseInsertBatch 每条插入语句写入的搭配记录数
+const productCoPurchaseInsertBatch = 500
+
+// IProductRecommendationRepository 商品搭配推荐仓储接口
+type IProductRecommendationRepository interface {
+	// 跨租户列出 since 之后有已完成订单的租户，仅供后台定时任务使用
+	ListTenantsWithCompletedOrders(ctx context.Context, since time.Time) ([]uint64, error)
+	// 按订单ID顺序列出当前租户 since 之后创建、ID大于 afterOrderID 的已完成订单，只包含商户和订单项
+	ListCompletedOrders(ctx context.Context, since time.Time, afterOrderID uint64, limit int) ([]types.Order, error)
+	// 在同一事务中用新的计算结果替换当前租户的全部搭配记录
+	ReplaceCoPurchases(ctx context.Context, coPurchases []types.ProductCoPurchase) error
+	// 获取当前租户中商品的搭配记录，按一起购买次数从多到少排序
+	ListCoPurchases(ctx context.Context, productID uint64, limit int) ([]types.ProductCoPurchase, error)
+}
+
+// ProductRecommendationRepository 商品搭配推荐仓储实现
+type ProductRecommendationRepository struct {
+	*BaseRepository
+}
+
+// NewProductRecommendationRepository 创建商品搭配推荐仓储实例
+func NewProductRecommendationRepository() IProductRecommendationRepository {
+	return &ProductRecommendationRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// ListTenantsWithCompletedOrders 列出有已完成订单的租户
+func (r *ProductRecommendationRepository) ListTenantsWithCompletedOrders(ctx context.Context, since time.Time) ([]uint64, error) {
+	values, err := TenantDB(ctx).Model("orders").
+		Ctx(ctx).
+		Fields("DISTINCT tenant_id").
+		Where("status = ? AND created_at >= ?", types.OrderStatusCompleted, since).
+		Array()
+	if err != nil {
+		return nil, fmt.Errorf("查询有已完成订单的租户失败: %v", err)
+	}
+
+	tenantIDs := make([]uint64, 0, len(values))
+	for _, value := range values {
+		tenantIDs = append(tenantIDs, value.Uint64())
+	}
+	return tenantIDs, nil
+}
+
+// ListCompletedOrders 按订单ID顺序列出已完成订单
+func (r *ProductRecommendationRepository) ListCompletedOrders(ctx context.Context, since time.Time, afterOrderID uint64, limit int) ([]types.Order, error) {
+	var rows []struct {
+		ID         uint64 `db:"id"`
+		MerchantID uint64 `db:"merchant_id"`
+		ItemsJSON  string `db:"items"`
+	}
+	err := TenantDB(ctx).Model("orders").
+		Ctx(ctx).
+		Fields("id, merchant_id, items").
+		Where("tenant_id = ? AND status = ? AND created_at >= ? AND id > ?",
+			r.GetTenantID(ctx), types.OrderStatusCompleted, since, afterOrderID).
+		OrderAsc("id").
+		Limit(limit).
+		Scan(&rows)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询已完成订单失败: %v", err)
+	}
+
+	orders := make([]types.Order, 0, len(rows))
+	for _, row := range rows {
+		order := types.Order{ID: row.ID, MerchantID: row.MerchantID}
+		if err := json.Unmarshal([]byte(row.ItemsJSON), &order.Items); err != nil {
+			return nil, fmt.Errorf("反序列化订单项失败: %v", err)
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// ReplaceCoPurchases 替换当前租户的搭配记录
+func (r *ProductRecommendationRepository) ReplaceCoPurchases(ctx context.Context, coPurchases []types.ProductCoPurchase) error {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return fmt.Errorf("missing tenant_id in context")
+	}
+
+	err := TenantTransaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if _, err := tx.Model("product_co_purchases").Ctx(ctx).Where("tenant_id = ?", tenantID).Delete(); err != nil {
+			return err
+		}
+
+		for start := 0; start < len(coPurchases); start += productCoPurchaseInsertBatch {
+			end := start + productCoPurchaseInsertBatch
+			if end > len(coPurchases) {
+				end = len(coPurchases)
+			}
+			rows := make(gdb.List, 0, end-start)
+			for _, coPurchase := range coPurchases[start:end] {
+				rows = append(rows, gdb.Map{
+					"tenant_id":          tenantID,
+					"merchant_id":        coPurchase.MerchantID,
+					"product_id":         coPurchase.ProductID,
+					"related_product_id": coPurchase.RelatedProductID,
+					"co_purchase_count":  coPurchase.CoPurchaseCount,
+					"computed_at":        coPurchase.ComputedAt,
+				})
+			}
+			if _, err := tx.Model("product_co_purchases").Ctx(ctx).Data(rows).Insert(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("保存商品搭配数据失败: %v", err)
+	}
+	return nil
+}
+
+// ListCoPurchases 获取商品的搭配记录
+func (r *ProductRecommendationRepository) ListCoPurchases(ctx context.Context, productID uint64, limit int) ([]types.ProductCoPurchase, error) {
+	var coPurchases []types.ProductCoPurchase
+	err := TenantDB(ctx).Model("product_co_purchases").
+		Ctx(ctx).
+		Where("tenant_id = ? AND product_id = ?", r.GetTenantID(ctx), productID).
+		OrderDesc("co_purchase_count").
+		OrderAsc("related_product_id").
+		Limit(limit).
+		Scan(&coPurchases)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取商品搭配数据失败: %v", err)
+	}
+	return coPurchases, nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IProductReviewRepository 商品评价仓储接口
+type IProductReviewRepository interface {
+	Create(ctx context.Context, review *types.ProductReview) error
+	GetByID(ctx context.Context, id uint64) (*types.ProductReview, error)
+	ExistsForOrderItem(ctx context.Context, orderID, productID uint64) (bool, error)
+	ListByProduct(ctx context.Context, productID uint64, includeFlagged bool, page, pageSize int) ([]types.ProductReview, int, error)
+	SetFlag(ctx context.Context, id uint64, flagged bool, reason string, operatorID uint64) error
+	GetRatingSummary(ctx context.Context, productID uint64) (*types.ProductRatingSummary, error)
+}
+
+// ProductReviewRepository 商品评价仓储实现
+type ProductReviewRepository struct {
+	*BaseRepository
+}
+
+// NewProductReviewRepository 创建商品评价仓储实例
+func NewProductReviewRepository() IProductReviewRepository {
+	return &ProductReviewRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 创建商品评价
+func (r *ProductReviewRepository) Create(ctx context.Context, review *types.ProductReview) error {
+	review.TenantID = r.GetTenantID(ctx)
+	review.CreatedAt = gtime.Now().Time
+	review.UpdatedAt = review.CreatedAt
+
+	id, err := TenantDB(ctx).Model("product_reviews").Ctx(ctx).Data(review).OmitEmpty().InsertAndGetId()
+	if err != nil {
+		return fmt.Errorf("创建商品评价失败: %v", err)
+	}
+
+	review.ID = uint64(id)
+	return nil
+}
+
+// GetByID 根据ID获取商品评价
+func (r *ProductReviewRepository) GetByID(ctx context.Context, id uint64) (*types.ProductReview, error) {
+	var review types.ProductReview
+	err := TenantDB(ctx).Model("product_reviews").
+		Ctx(ctx).
+		Where("tenant_id = ? AND id = ?", r.GetTenantID(ctx), id).
+		Scan(&review)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("商品评价不存在")
+		}
+		return nil, fmt.Errorf("获取商品评价失败: %v", err)
+	}
+
+	return &review, nil
+}
+
+// ExistsForOrderItem 检查订单中的商品是否已评价
+func (r *ProductReviewRepository) ExistsForOrderItem(ctx context.Context, orderID, productID uint64) (bool, error) {
+	count, err := TenantDB(ctx).Model("product_reviews").
+		Ctx(ctx).
+		Where("tenant_id = ? AND order_id = ? AND product_id = ?", r.GetTenantID(ctx), orderID, productID).
+		Count()
+	if err != nil {
+		return false, fmt.Errorf("检查商品评价失败: %v", err)
+	}
+
+	return count > 0, nil
+}
+
+// ListByProduct 分页获取商品评价，includeFlagged 为 false 时不返回被标记的评价
+func (r *ProductReviewRepository) ListByProduct(ctx context.Context, productID uint64, includeFlagged bool, page, pageSize int) ([]types.ProductReview, int, error) {
+	model := TenantDB(ctx).Model("product_reviews").
+		Ctx(ctx).
+		Where("tenant_id = ? AND product_id = ?", r.GetTenantID(ctx), productID)
+	if !includeFlagged {
+		model = model.Where("flagged", false)
+	}
+
+	total, err := model.Count()
+	if err != nil {
+		return nil, 0, fmt.Errorf("统计商品评价失败: %v", err)
+	}
+
+	var reviews []types.ProductReview
+	if err := model.OrderDesc("created_at").Page(page, pageSize).Scan(&reviews); err != nil && err != sql.ErrNoRows {
+		return nil, 0, fmt.Errorf("获取商品评价失败: %v", err)
+	}
+
+	return reviews, total, nil
+}
+
+// SetFlag 标记或取消标记评价
+func (r *ProductReviewRepository) SetFlag(ctx context.Context, id uint64, flagged bool, reason string, operatorID uint64) error {
+	data := g.Map{
+		"flagged":     flagged,
+		"flag_reason": reason,
+		"flagged_by":  nil,
+		"flagged_at":  nil,
+	}
+	if flagged {
+		data["flagged_by"] = operatorID
+		data["flagged_at"] = gtime.Now()
+	}
+
+	result, err := TenantDB(ctx).Model("product_reviews").
+		Ctx(ctx).
+		Where("tenant_id = ? AND id = ?", r.GetTenantID(ctx), id).
+		Data(data).
+		Update()
+	if err != nil {
+		return fmt.Errorf("标记商品评价失败: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("商品评价不存在")
+	}
+
+	return nil
+}
+
+// GetRatingSummary 获取商品评分汇总，不计入被标记的评价
+func (r *ProductReviewRepository) GetRatingSummary(ctx context.Context, productID uint64) (*types.ProductRatingSummary, error) {
+	var rows []struct {
+		Rating int `json:"rating"`
+		Count  int `json:"count"`
+	}
+	err := TenantDB(ctx).Model("product_reviews").
+		Ctx(ctx).
+		Fields("rating, COUNT(*) AS count").
+		Where("tenant_id = ? AND product_id = ? AND flagged = ?", r.GetTenantID(ctx), productID, false).
+		Group("rating").
+		Scan(&rows)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取商品评分失败: %v", err)
+	}
+
+	ratingCounts := make(map[int]int, len(rows))
+	for _, row := range rows {
+		ratingCounts[row.Rating] = row.Count
+	}
+	return types.NewProductRatingSummary(productID, ratingCounts), nil
+}
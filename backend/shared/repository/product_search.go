@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// IProductSearchRepository 商品搜索候选集仓储接口
+type IProductSearchRepository interface {
+	// 获取任一查询词命中名称、描述、标签或分类名称的商品，最多返回 limit 个
+	ListSearchCandidates(ctx context.Context, terms []string, categoryID *uint64, limit int) ([]types.ProductSearchDocument, error)
+}
+
+// NewProductSearchRepository 创建商品搜索候选集仓储实例
+func NewProductSearchRepository() IProductSearchRepository {
+	return NewProductRepository()
+}
+
+// ListSearchCandidates 按关键词模糊匹配获取当前租户（商户用户为本商户）可售的候选商品
+func (r *ProductRepository) ListSearchCandidates(ctx context.Context, terms []string, categoryID *uint64, limit int) ([]types.ProductSearchDocument, error) {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return nil, fmt.Errorf("missing tenant_id in context")
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	db := TenantDB(ctx).Model("products p").
+		Ctx(ctx).
+		LeftJoin("product_categories c", "p.category_id = c.id").
+		Where("p.tenant_id = ?", tenantID).
+		Where("p.status != ?", types.ProductStatusDeleted).
+		Where("(p.available_from IS NULL OR p.available_from <= ?) AND (p.available_until IS NULL OR p.available_until > ?)", now, now)
+	if merchantID := r.GetMerchantID(ctx); merchantID > 0 {
+		db = db.Where("p.merchant_id = ?", merchantID)
+	}
+	if categoryID != nil {
+		db = db.Where("p.category_id = ?", *categoryID)
+	}
+
+	conditions := make([]string, 0, len(terms))
+	args := make([]interface{}, 0, len(terms)*4)
+	for _, term := range terms {
+		like := "%" + term + "%"
+		conditions = append(conditions, "p.name LIKE ? OR p.description LIKE ? OR p.tags LIKE ? OR c.name LIKE ?")
+		args = append(args, like, like, like, like)
+	}
+	db = db.Where("("+strings.Join(conditions, " OR ")+")", args...)
+
+	var results []struct {
+		types.Product
+		CategoryName *string `json:"category_name"`
+	}
+	err := db.Fields("p.*, c.name as category_name").
+		Order("p.created_at DESC").
+		Limit(limit).
+		Scan(&results)
+	if err != nil {
+		return nil, fmt.Errorf("搜索商品失败: %v", err)
+	}
+
+	docs := make([]types.ProductSearchDocument, len(results))
+	for i, result := range results {
+		docs[i] = types.ProductSearchDocument{Product: result.Product}
+		if result.CategoryName != nil {
+			docs[i].CategoryName = *result.CategoryName
+		}
+	}
+	return docs, nil
+}
+
+// IProductSynonymRepository 商品搜索同义词词典仓储接口
+type IProductSynonymRepository interface {
+	// 获取当前租户的同义词词典，未配置时返回空词典
+	GetDictionary(ctx context.Context) (*types.ProductSynonymDictionary, error)
+	SaveDictionary(ctx context.Context, dictionary *types.ProductSynonymDictionary) error
+}
+
+// ProductSynonymRepository 商品搜索同义词词典仓储实现
+type ProductSynonymRepository struct {
+	*BaseRepository
+}
+
+// NewProductSynonymRepository 创建商品搜索同义词词典仓储实例
+func NewProductSynonymRepository() IProductSynonymRepository {
+	return &ProductSynonymRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// GetDictionary 获取当前租户的同义词词典
+func (r *ProductSynonymRepository) GetDictionary(ctx context.Context) (*types.ProductSynonymDictionary, error) {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return nil, fmt.Errorf("missing tenant_id in context")
+	}
+
+	var row struct {
+		SynonymGroups string    `json:"synonym_groups"`
+		UpdatedAt     time.Time `json:"updated_at"`
+	}
+	err := TenantDB(ctx).Model("product_search_synonyms").
+		Ctx(ctx).
+		Where("tenant_id = ?", tenantID).
+		Scan(&row)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("获取同义词词典失败: %v", err)
+	}
+
+	dictionary := &types.ProductSynonymDictionary{TenantID: tenantID, Groups: [][]string{}, UpdatedAt: row.UpdatedAt}
+	if row.SynonymGroups != "" {
+		if err := json.Unmarshal([]byte(row.SynonymGroups), &dictionary.Groups); err != nil {
+			return nil, fmt.Errorf("解析同义词词典失败: %v", err)
+		}
+	}
+	return dictionary, nil
+}
+
+// SaveDictionary 保存当前租户的同义词词典（整体替换）
+func (r *ProductSynonymRepository) SaveDictionary(ctx context.Context, dictionary *types.ProductSynonymDictionary) error {
+	tenantID := r.GetTenantID(ctx)
+	if tenantID == 0 {
+		return fmt.Errorf("missing tenant_id in context")
+	}
+
+	groups, err := json.Marshal(dictionary.Groups)
+	if err != nil {
+		return fmt.Errorf("序列化同义词词典失败: %v", err)
+	}
+
+	dictionary.TenantID = tenantID
+	dictionary.UpdatedAt = gtime.Now().Time
+	_, err = TenantDB(ctx).Model("product_search_synonyms").
+		Ctx(ctx).
+		Data(g.Map{
+			"tenant_id":      tenantID,
+			"synonym_groups": string(groups),
+			"updated_at":     dictionary.UpdatedAt,
+		}).
+		Save()
+	if err != nil {
+		return fmt.Errorf("保存同义词词典失败: %v", err)
+	}
+	return nil
+}
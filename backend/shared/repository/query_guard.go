@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// QueryGuardConfig 查询超时与慢查询配置
+type QueryGuardConfig struct {
+	Timeout       time.Duration // 默认单条查询超时
+	HeavyTimeout  time.Duration // 报表等已知重查询的超时
+	SlowThreshold time.Duration // 慢查询阈值
+}
+
+// SlowQueryRecord 慢查询记录
+type SlowQueryRecord struct {
+	TenantID  uint64
+	Table     string
+	Statement string // 已脱敏的语句（参数不内联）
+	Duration  time.Duration
+	RowCount  int
+	Err       error
+}
+
+type queryTimeoutKey struct{}
+
+const maxStatementLength = 1000
+
+var (
+	queryGuardMutex  sync.RWMutex
+	queryGuardConfig *QueryGuardConfig
+	queryGuardOnce   sync.Once
+
+	// slowQueryRecorder 慢查询记录器，测试中可替换
+	slowQueryRecorder = recordSlowQuery
+
+	whitespacePattern    = regexp.MustCompile(`\s+`)
+	stringLiteralPattern = regexp.MustCompile(`'(?:[^'\\]|\\.)*'`)
+)
+
+// DefaultQueryGuardConfig 返回默认查询超时配置
+func DefaultQueryGuardConfig() *QueryGuardConfig {
+	return &QueryGuardConfig{
+		Timeout:       10 * time.Second,
+		HeavyTimeout:  120 * time.Second,
+		SlowThreshold: 500 * time.Millisecond,
+	}
+}
+
+// SetQueryGuardConfig 设置查询超时配置
+func SetQueryGuardConfig(config *QueryGuardConfig) {
+	queryGuardMutex.Lock()
+	defer queryGuardMutex.Unlock()
+
+	queryGuardConfig = config
+}
+
+// getQueryGuardConfig 获取查询超时配置，首次使用时从配置文件 query 节点加载
+func getQueryGuardConfig() *QueryGuardConfig {
+	queryGuardOnce.Do(func() {
+		queryGuardMutex.Lock()
+		defer queryGuardMutex.Unlock()
+		if queryGuardConfig != nil {
+			return
+		}
+
+		config := DefaultQueryGuardConfig()
+		ctx := context.Background()
+		if v, err := g.Cfg().Get(ctx, "query.timeout"); err == nil && !v.IsEmpty() {
+			config.Timeout = v.Duration()
+		}
+		if v, err := g.Cfg().Get(ctx, "query.heavyTimeout"); err == nil && !v.IsEmpty() {
+			config.HeavyTimeout = v.Duration()
+		}
+		if v, err := g.Cfg().Get(ctx, "query.slowThreshold"); err == nil && !v.IsEmpty() {
+			config.SlowThreshold = v.Duration()
+		}
+		queryGuardConfig = config
+	})
+
+	queryGuardMutex.RLock()
+	defer queryGuardMutex.RUnlock()
+	return queryGuardConfig
+}
+
+// WithQueryTimeout 为上下文中的后续查询指定超时，覆盖默认值
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// WithHeavyQuery 标记后续查询为重查询（报表、统计），使用较长的超时
+func WithHeavyQuery(ctx context.Context) context.Context {
+	return WithQueryTimeout(ctx, getQueryGuardConfig().HeavyTimeout)
+}
+
+// Guard 为模型绑定上下文并启用查询超时与慢查询记录
+func (r *BaseRepository) Guard(ctx context.Context, model *gdb.Model) *gdb.Model {
+	return model.Ctx(ctx).Hook(queryGuardHook(r.tableName))
+}
+
+// HeavyRaw 执行已知的重查询（报表、统计），使用重查询超时
+func (r *BaseRepository) HeavyRaw(ctx context.Context, rawSQL string, args ...interface{}) *gdb.Model {
+	return r.Guard(WithHeavyQuery(ctx), TenantDB(ctx).Raw(rawSQL, args...))
+}
+
+// queryGuardHook 查询钩子：在实际执行的上下文上施加超时，并记录慢查询
+func queryGuardHook(table string) gdb.HookHandler {
+	return gdb.HookHandler{
+		Select: func(ctx context.Context, in *gdb.HookSelectInput) (result gdb.Result, err error) {
+			err = guardQuery(ctx, tableOrDefault(in.Table, table), in.Sql, func(ctx context.Context) (int, error) {
+				result, err = in.Next(ctx)
+				return len(result), err
+			})
+			return result, err
+		},
+		Insert: func(ctx context.Context, in *gdb.HookInsertInput) (result sql.Result, err error) {
+			statement := fmt.Sprintf("INSERT INTO %s (%d rows)", in.Table, len(in.Data))
+			err = guardQuery(ctx, tableOrDefault(in.Table, table), statement, func(ctx context.Context) (int, error) {
+				result, err = in.Next(ctx)
+				return rowsAffected(result), err
+			})
+			return result, err
+		},
+		Update: func(ctx context.Context, in *gdb.HookUpdateInput) (result sql.Result, err error) {
+			statement := fmt.Sprintf("UPDATE %s WHERE %s", in.Table, in.Condition)
+			err = guardQuery(ctx, tableOrDefault(in.Table, table), statement, func(ctx context.Context) (int, error) {
+				result, err = in.Next(ctx)
+				return rowsAffected(result), err
+			})
+			return result, err
+		},
+		Delete: func(ctx context.Context, in *gdb.HookDeleteInput) (result sql.Result, err error) {
+			statement := fmt.Sprintf("DELETE FROM %s WHERE %s", in.Table, in.Condition)
+			err = guardQuery(ctx, tableOrDefault(in.Table, table), statement, func(ctx context.Context) (int, error) {
+				result, err = in.Next(ctx)
+				return rowsAffected(result), err
+			})
+			return result, err
+		},
+	}
+}
+
+// guardQuery 在带超时的上下文中执行查询，超过慢查询阈值时记录。
+// 调用方上下文已有更早的截止时间时保留原截止时间
+func guardQuery(ctx context.Context, table, statement string, run func(ctx context.Context) (int, error)) error {
+	config := getQueryGuardConfig()
+
+	timeout := config.Timeout
+	if override, ok := ctx.Value(queryTimeoutKey{}).(time.Duration); ok && override > 0 {
+		timeout = override
+	}
+
+	queryCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		queryCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	startTime := time.Now()
+	rowCount, err := run(queryCtx)
+	duration := time.Since(startTime)
+
+	if err != nil && queryCtx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("查询超时（%s）已取消: %w", timeout, context.DeadlineExceeded)
+	}
+
+	if config.SlowThreshold > 0 && duration >= config.SlowThreshold {
+		slowQueryRecorder(ctx, &SlowQueryRecord{
+			TenantID:  gconv.Uint64(ctx.Value("tenant_id")),
+			Table:     table,
+			Statement: sanitizeStatement(statement),
+			Duration:  duration,
+			RowCount:  rowCount,
+			Err:       err,
+		})
+	}
+
+	return err
+}
+
+// recordSlowQuery 记录慢查询日志并写入数据查询审计
+func recordSlowQuery(ctx context.Context, record *SlowQueryRecord) {
+	g.Log().Warningf(ctx, "慢查询 - 表: %s, 耗时: %s, 行数: %d, 错误: %v, 语句: %s",
+		record.Table, record.Duration, record.RowCount, record.Err, record.Statement)
+
+	audit.LogDataQuery(ctx, record.TenantID, record.Table,
+		fmt.Sprintf("[slow %dms] %s", record.Duration.Milliseconds(), record.Statement), record.RowCount)
+}
+
+// sanitizeStatement 压缩空白、去除字符串字面量并截断语句，参数值不写入日志
+func sanitizeStatement(statement string) string {
+	statement = whitespacePattern.ReplaceAllString(strings.TrimSpace(statement), " ")
+	statement = stringLiteralPattern.ReplaceAllString(statement, "'?'")
+	statement = audit.MaskText(statement)
+	if len(statement) > maxStatementLength {
+		statement = statement[:maxStatementLength] + "..."
+	}
+	return statement
+}
+
+func tableOrDefault(table, fallback string) string {
+	if table != "" {
+		return table
+	}
+	return fallback
+}
+
+func rowsAffected(result sql.Result) int {
+	if result == nil {
+		return 0
+	}
+	affected, _ := result.RowsAffected()
+	return int(affected)
+}
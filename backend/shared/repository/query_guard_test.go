@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// slowQuery 模拟 SELECT SLEEP(5)：直到完成或上下文被取消
+func slowQuery(duration time.Duration) func(ctx context.Context) (int, error) {
+	return func(ctx context.Context) (int, error) {
+		select {
+		case <-time.After(duration):
+			return 1, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func TestQueryGuard(t *testing.T) {
+	Convey("查询超时与慢查询测试", t, func() {
+		SetQueryGuardConfig(&QueryGuardConfig{
+			Timeout:       50 * time.Millisecond,
+			HeavyTimeout:  time.Second,
+			SlowThreshold: 20 * time.Millisecond,
+		})
+		defer SetQueryGuardConfig(DefaultQueryGuardConfig())
+
+		var records []*SlowQueryRecord
+		slowQueryRecorder = func(ctx context.Context, record *SlowQueryRecord) {
+			records = append(records, record)
+		}
+		defer func() { slowQueryRecorder = recordSlowQuery }()
+
+		ctx := context.WithValue(context.Background(), "tenant_id", uint64(3))
+
+		Convey("超过默认超时的查询被取消", func() {
+			startTime := time.Now()
+			err := guardQuery(ctx, "orders", "SELECT SLEEP(5)", slowQuery(5*time.Second))
+
+			So(err, ShouldNotBeNil)
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			So(time.Since(startTime), ShouldBeLessThan, time.Second)
+
+			// 被取消的查询同样记录为慢查询
+			So(len(records), ShouldEqual, 1)
+			So(records[0].Table, ShouldEqual, "orders")
+			So(records[0].TenantID, ShouldEqual, 3)
+			So(records[0].Err, ShouldNotBeNil)
+		})
+
+		Convey("重查询使用较长的超时", func() {
+			err := guardQuery(WithHeavyQuery(ctx), "orders", "SELECT SLEEP(0.1)", slowQuery(100*time.Millisecond))
+			So(err, ShouldBeNil)
+			So(len(records), ShouldEqual, 1)
+			So(records[0].Duration, ShouldBeGreaterThanOrEqualTo, 100*time.Millisecond)
+			So(records[0].RowCount, ShouldEqual, 1)
+		})
+
+		Convey("调用方取消时查询立即中止", func() {
+			cancelCtx, cancel := context.WithCancel(ctx)
+			cancel()
+			err := guardQuery(cancelCtx, "orders", "SELECT 1", slowQuery(time.Second))
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+		})
+
+		Convey("快速查询不记录慢查询", func() {
+			err := guardQuery(ctx, "orders", "SELECT 1", slowQuery(0))
+			So(err, ShouldBeNil)
+			So(len(records), ShouldEqual, 0)
+		})
+
+		Convey("慢查询语句被清理", func() {
+			statement := sanitizeStatement("SELECT *\n\tFROM users  WHERE phone = '13812348000' AND name = 'O\\'Brien'")
+			So(statement, ShouldEqual, "SELECT * FROM users WHERE phone = '?' AND name = '?'")
+
+			statement = sanitizeStatement("SELECT * FROM users WHERE phone = 13812348000")
+			So(statement, ShouldContainSubstring, "138****8000")
+		})
+	})
+}
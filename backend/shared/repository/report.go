@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/gofromzero/mer-sys/backend/shared/types"
-	"github.com/gogf/gf/v2/frame/g"
 )
 
 // IReportRepository 报表仓储接口
@@ -31,6 +30,9 @@ type IReportRepository interface {
 	GetReportJob(ctx context.Context, id uint64) (*types.ReportJob, error)
 	UpdateReportJob(ctx context.Context, job *types.ReportJob) error
 	ListPendingJobs(ctx context.Context) ([]*types.ReportJob, error)
+	ListReportJobs(ctx context.Context, req *types.ReportJobListRequest) ([]*types.ReportJob, error)
+	CreateReportJobAttempt(ctx context.Context, attempt *types.ReportJobAttempt) error
+	ListReportJobAttempts(ctx context.Context, jobID uint64) ([]*types.ReportJobAttempt, error)
 	
 	// 分析缓存管理
 	GetAnalyticsCache(ctx context.Context, cacheKey string) (*types.AnalyticsCache, error)
@@ -41,6 +43,8 @@ type IReportRepository interface {
 	GetFinancialData(ctx context.Context, tenantID uint64, startDate, endDate time.Time, merchantID *uint64) (*types.FinancialReportData, error)
 	GetMerchantOperationData(ctx context.Context, tenantID uint64, startDate, endDate time.Time) (*types.MerchantOperationReport, error)
 	GetCustomerAnalysisData(ctx context.Context, tenantID uint64, startDate, endDate time.Time) (*types.CustomerAnalysisReport, error)
+	GetMerchantSummaryFigures(ctx context.Context, tenantID, merchantID uint64, startDate, endDate time.Time) (*types.MerchantSummaryFigures, error)
+	GetReconciliationFigures(ctx context.Context, tenantID uint64, startDate, endDate time.Time, merchantID *uint64) ([]types.MerchantReconciliationFigures, error)
 }
 
 // ReportRepository 报表仓储实现
@@ -67,7 +71,7 @@ func (r *ReportRepository) CreateReport(ctx context.Context, report *types.Repor
 		report.UUID = fmt.Sprintf("rpt_%d_%d", time.Now().Unix(), time.Now().Nanosecond())
 	}
 	
-	_, err := g.DB().Model("reports").Ctx(ctx).Insert(report)
+	_, err := TenantDB(ctx).Model("reports").Ctx(ctx).Insert(report)
 	return err
 }
 
@@ -75,7 +79,7 @@ func (r *ReportRepository) CreateReport(ctx context.Context, report *types.Repor
 func (r *ReportRepository) GetReportByID(ctx context.Context, id uint64) (*types.Report, error) {
 	tenantID := r.GetTenantID(ctx)
 	var report types.Report
-	err := g.DB().Model("reports").
+	err := TenantDB(ctx).Model("reports").
 		Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, id).
 		Scan(&report)
@@ -89,7 +93,7 @@ func (r *ReportRepository) GetReportByID(ctx context.Context, id uint64) (*types
 func (r *ReportRepository) GetReportByUUID(ctx context.Context, uuid string) (*types.Report, error) {
 	tenantID := r.GetTenantID(ctx)
 	var report types.Report
-	err := g.DB().Model("reports").
+	err := TenantDB(ctx).Model("reports").
 		Ctx(ctx).
 		Where("tenant_id = ? AND uuid = ?", tenantID, uuid).
 		Scan(&report)
@@ -102,7 +106,7 @@ func (r *ReportRepository) GetReportByUUID(ctx context.Context, uuid string) (*t
 // UpdateReport 更新报表
 func (r *ReportRepository) UpdateReport(ctx context.Context, report *types.Report) error {
 	tenantID := r.GetTenantID(ctx)
-	_, err := g.DB().Model("reports").
+	_, err := TenantDB(ctx).Model("reports").
 		Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, report.ID).
 		Update(report)
@@ -112,7 +116,7 @@ func (r *ReportRepository) UpdateReport(ctx context.Context, report *types.Repor
 // DeleteReport 删除报表
 func (r *ReportRepository) DeleteReport(ctx context.Context, id uint64) error {
 	tenantID := r.GetTenantID(ctx)
-	_, err := g.DB().Model("reports").
+	_, err := TenantDB(ctx).Model("reports").
 		Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, id).
 		Delete()
@@ -123,7 +127,7 @@ func (r *ReportRepository) DeleteReport(ctx context.Context, id uint64) error {
 func (r *ReportRepository) ListReports(ctx context.Context, req *types.ReportListRequest) ([]*types.Report, int, error) {
 	tenantID := r.GetTenantID(ctx)
 	
-	query := g.DB().Model("reports").Ctx(ctx).Where("tenant_id = ?", tenantID)
+	query := TenantDB(ctx).Model("reports").Ctx(ctx).Where("tenant_id = ?", tenantID)
 	
 	// 添加筛选条件
 	if req.ReportType != nil {
@@ -161,7 +165,7 @@ func (r *ReportRepository) CreateReportTemplate(ctx context.Context, template *t
 	tenantID := r.GetTenantID(ctx)
 	template.TenantID = tenantID
 	
-	_, err := g.DB().Model("report_templates").Ctx(ctx).Insert(template)
+	_, err := TenantDB(ctx).Model("report_templates").Ctx(ctx).Insert(template)
 	return err
 }
 
@@ -169,7 +173,7 @@ func (r *ReportRepository) CreateReportTemplate(ctx context.Context, template *t
 func (r *ReportRepository) GetReportTemplate(ctx context.Context, id uint64) (*types.ReportTemplate, error) {
 	tenantID := r.GetTenantID(ctx)
 	var template types.ReportTemplate
-	err := g.DB().Model("report_templates").
+	err := TenantDB(ctx).Model("report_templates").
 		Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, id).
 		Scan(&template)
@@ -182,7 +186,7 @@ func (r *ReportRepository) GetReportTemplate(ctx context.Context, id uint64) (*t
 // UpdateReportTemplate 更新报表模板
 func (r *ReportRepository) UpdateReportTemplate(ctx context.Context, template *types.ReportTemplate) error {
 	tenantID := r.GetTenantID(ctx)
-	_, err := g.DB().Model("report_templates").
+	_, err := TenantDB(ctx).Model("report_templates").
 		Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, template.ID).
 		Update(template)
@@ -192,7 +196,7 @@ func (r *ReportRepository) UpdateReportTemplate(ctx context.Context, template *t
 // DeleteReportTemplate 删除报表模板
 func (r *ReportRepository) DeleteReportTemplate(ctx context.Context, id uint64) error {
 	tenantID := r.GetTenantID(ctx)
-	_, err := g.DB().Model("report_templates").
+	_, err := TenantDB(ctx).Model("report_templates").
 		Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, id).
 		Delete()
@@ -203,7 +207,7 @@ func (r *ReportRepository) DeleteReportTemplate(ctx context.Context, id uint64)
 func (r *ReportRepository) ListReportTemplates(ctx context.Context, reportType *types.ReportType) ([]*types.ReportTemplate, error) {
 	tenantID := r.GetTenantID(ctx)
 	
-	query := g.DB().Model("report_templates").
+	query := TenantDB(ctx).Model("report_templates").
 		Ctx(ctx).
 		Where("tenant_id = ? AND enabled = ?", tenantID, true)
 	
@@ -221,7 +225,7 @@ func (r *ReportRepository) CreateReportJob(ctx context.Context, job *types.Repor
 	tenantID := r.GetTenantID(ctx)
 	job.TenantID = tenantID
 	
-	_, err := g.DB().Model("report_jobs").Ctx(ctx).Insert(job)
+	_, err := TenantDB(ctx).Model("report_jobs").Ctx(ctx).Insert(job)
 	return err
 }
 
@@ -229,7 +233,7 @@ func (r *ReportRepository) CreateReportJob(ctx context.Context, job *types.Repor
 func (r *ReportRepository) GetReportJob(ctx context.Context, id uint64) (*types.ReportJob, error) {
 	tenantID := r.GetTenantID(ctx)
 	var job types.ReportJob
-	err := g.DB().Model("report_jobs").
+	err := TenantDB(ctx).Model("report_jobs").
 		Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, id).
 		Scan(&job)
@@ -242,7 +246,7 @@ func (r *ReportRepository) GetReportJob(ctx context.Context, id uint64) (*types.
 // UpdateReportJob 更新报表任务
 func (r *ReportRepository) UpdateReportJob(ctx context.Context, job *types.ReportJob) error {
 	tenantID := r.GetTenantID(ctx)
-	_, err := g.DB().Model("report_jobs").
+	_, err := TenantDB(ctx).Model("report_jobs").
 		Ctx(ctx).
 		Where("tenant_id = ? AND id = ?", tenantID, job.ID).
 		Update(job)
@@ -254,7 +258,7 @@ func (r *ReportRepository) ListPendingJobs(ctx context.Context) ([]*types.Report
 	now := time.Now()
 	var jobs []*types.ReportJob
 	
-	err := g.DB().Model("report_jobs").
+	err := TenantDB(ctx).Model("report_jobs").
 		Ctx(ctx).
 		Where("status = ? AND scheduled_at <= ?", types.JobStatusPending, now).
 		OrderAsc("scheduled_at").
@@ -263,10 +267,62 @@ func (r *ReportRepository) ListPendingJobs(ctx context.Context) ([]*types.Report
 	return jobs, err
 }
 
+// ListReportJobs 获取最近的报表任务，可按模板和状态筛选
+func (r *ReportRepository) ListReportJobs(ctx context.Context, req *types.ReportJobListRequest) ([]*types.ReportJob, error) {
+	tenantID := r.GetTenantID(ctx)
+	query := TenantDB(ctx).Model("report_jobs").
+		Ctx(ctx).
+		Where("tenant_id = ?", tenantID)
+	
+	if req.TemplateID != nil {
+		query = query.Where("template_id = ?", *req.TemplateID)
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	
+	var jobs []*types.ReportJob
+	err := query.OrderDesc("scheduled_at").Limit(limit).Scan(&jobs)
+	return jobs, err
+}
+
+// CreateReportJobAttempt 记录报表任务的一次执行
+func (r *ReportRepository) CreateReportJobAttempt(ctx context.Context, attempt *types.ReportJobAttempt) error {
+	attempt.TenantID = r.GetTenantID(ctx)
+	
+	result, err := TenantDB(ctx).Model("report_job_attempts").Ctx(ctx).Insert(attempt)
+	if err != nil {
+		return err
+	}
+	
+	id, err := result.LastInsertId()
+	if err == nil {
+		attempt.ID = uint64(id)
+	}
+	return nil
+}
+
+// ListReportJobAttempts 获取报表任务的执行记录，按执行顺序排列
+func (r *ReportRepository) ListReportJobAttempts(ctx context.Context, jobID uint64) ([]*types.ReportJobAttempt, error) {
+	tenantID := r.GetTenantID(ctx)
+	var attempts []*types.ReportJobAttempt
+	err := TenantDB(ctx).Model("report_job_attempts").
+		Ctx(ctx).
+		Where("tenant_id = ? AND job_id = ?", tenantID, jobID).
+		OrderAsc("attempt").
+		Scan(&attempts)
+	return attempts, err
+}
+
 // GetAnalyticsCache 获取分析缓存
 func (r *ReportRepository) GetAnalyticsCache(ctx context.Context, cacheKey string) (*types.AnalyticsCache, error) {
 	var cache types.AnalyticsCache
-	err := g.DB().Model("analytics_cache").
+	err := TenantDB(ctx).Model("analytics_cache").
 		Ctx(ctx).
 		Where("cache_key = ? AND expires_at > NOW()", cacheKey).
 		Scan(&cache)
@@ -278,7 +334,7 @@ func (r *ReportRepository) GetAnalyticsCache(ctx context.Context, cacheKey strin
 
 // SetAnalyticsCache 设置分析缓存
 func (r *ReportRepository) SetAnalyticsCache(ctx context.Context, cache *types.AnalyticsCache) error {
-	_, err := g.DB().Model("analytics_cache").
+	_, err := TenantDB(ctx).Model("analytics_cache").
 		Ctx(ctx).
 		Replace(cache)
 	return err
@@ -286,7 +342,7 @@ func (r *ReportRepository) SetAnalyticsCache(ctx context.Context, cache *types.A
 
 // DeleteExpiredCache 删除过期缓存
 func (r *ReportRepository) DeleteExpiredCache(ctx context.Context) error {
-	_, err := g.DB().Model("analytics_cache").
+	_, err := TenantDB(ctx).Model("analytics_cache").
 		Ctx(ctx).
 		Where("expires_at <= NOW()").
 		Delete()
@@ -327,6 +383,7 @@ func (r *ReportRepository) GetFinancialData(ctx context.Context, tenantID uint64
 			-- 收入统计
 			COALESCE(SUM(CASE WHEN o.status IN ('completed', 'paid') THEN o.total_amount ELSE 0 END), 0) as total_revenue,
 			COALESCE(AVG(CASE WHEN o.status IN ('completed', 'paid') THEN o.total_amount END), 0) as avg_order_value,
+			COALESCE(SUM(CASE WHEN o.status IN ('completed', 'paid') THEN o.tax_amount ELSE 0 END), 0) as tax_collected,
 			
 			-- 权益统计
 			COALESCE(SUM(CASE WHEN o.status IN ('completed', 'paid') THEN o.total_rights_cost ELSE 0 END), 0) as rights_consumed,
@@ -344,6 +401,7 @@ func (r *ReportRepository) GetFinancialData(ctx context.Context, tenantID uint64
 		CustomerCount        int     `json:"customer_count"`
 		TotalRevenue         float64 `json:"total_revenue"`
 		AvgOrderValue        float64 `json:"avg_order_value"`
+		TaxCollected         float64 `json:"tax_collected"`
 		RightsConsumed       int64   `json:"rights_consumed"`
 		ActiveMerchantCount  int     `json:"active_merchant_count"`
 		ActiveCustomerCount  int     `json:"active_customer_count"`
@@ -352,7 +410,7 @@ func (r *ReportRepository) GetFinancialData(ctx context.Context, tenantID uint64
 	// 为活跃度统计添加额外的参数
 	queryArgs := append(whereArgs, endDate, endDate)
 	
-	err := g.DB().Raw(financialQuery, queryArgs...).Scan(&financialResult)
+	err := r.HeavyRaw(ctx, financialQuery, queryArgs...).Scan(&financialResult)
 	if err != nil {
 		return nil, fmt.Errorf("查询财务数据失败: %v", err)
 	}
@@ -361,6 +419,8 @@ func (r *ReportRepository) GetFinancialData(ctx context.Context, tenantID uint64
 	data.OrderCount = financialResult.PaidOrderCount // 使用已支付订单数
 	data.TotalRevenue = types.Money{Amount: financialResult.TotalRevenue}
 	data.OrderAmount = types.Money{Amount: financialResult.TotalRevenue}
+	data.TaxCollected = types.Money{Amount: financialResult.TaxCollected}
+	data.NetRevenue = types.Money{Amount: financialResult.TotalRevenue - financialResult.TaxCollected}
 	data.RightsConsumed = financialResult.RightsConsumed
 	data.MerchantCount = financialResult.MerchantCount
 	data.CustomerCount = financialResult.CustomerCount
@@ -371,19 +431,47 @@ func (r *ReportRepository) GetFinancialData(ctx context.Context, tenantID uint64
 	data.RightsDistributed = data.RightsConsumed // 简化，实际应该查询发放记录
 	data.RightsBalance = data.RightsDistributed - data.RightsConsumed
 	
-	// 计算净利润（这里简化，实际需要考虑成本）
-	data.NetProfit = data.TotalRevenue
+	// 计算净利润（这里简化，实际需要考虑成本），代收税额不计入利润
+	data.NetProfit = data.NetRevenue
 	data.TotalExpenditure = types.Money{Amount: 0} // 简化处理
 	
 	// 获取详细分解数据
 	breakdown, err := r.getFinancialBreakdown(ctx, tenantID, startDate, endDate, merchantID)
 	if err == nil {
 		data.Breakdown = breakdown
+	} else {
+		recordSwallowedQueryError(ctx, "financial_breakdown", err)
 	}
 	
 	return data, nil
 }
 
+// GetMerchantSummaryFigures 获取单个商户在 [startDate, endDate) 内的关键指标，
+// 统计口径与 GetFinancialData 一致，但不查询分解数据
+func (r *ReportRepository) GetMerchantSummaryFigures(ctx context.Context, tenantID, merchantID uint64, startDate, endDate time.Time) (*types.MerchantSummaryFigures, error) {
+	query := `
+		SELECT 
+			COUNT(*) as order_count,
+			COUNT(DISTINCT o.customer_id) as customer_count,
+			COALESCE(SUM(o.total_amount), 0) as revenue,
+			COALESCE(AVG(o.total_amount), 0) as avg_order_value,
+			COALESCE(SUM(o.total_rights_cost), 0) as rights_consumed
+		FROM orders o
+		WHERE o.tenant_id = ? 
+			AND o.merchant_id = ?
+			AND o.created_at >= ? AND o.created_at < ?
+			AND o.status IN ('completed', 'paid')
+	`
+	
+	var figures types.MerchantSummaryFigures
+	err := r.HeavyRaw(ctx, query, tenantID, merchantID, startDate, endDate).Scan(&figures)
+	if err != nil {
+		return nil, fmt.Errorf("查询商户汇总数据失败: %v", err)
+	}
+	
+	return &figures, nil
+}
+
 // getFinancialBreakdown 获取财务分解数据
 func (r *ReportRepository) getFinancialBreakdown(ctx context.Context, tenantID uint64, startDate, endDate time.Time, merchantID *uint64) (*types.FinancialBreakdown, error) {
 	breakdown := &types.FinancialBreakdown{}
@@ -400,7 +488,7 @@ func (r *ReportRepository) getFinancialBreakdown(ctx context.Context, tenantID u
 	whereConditions = append(whereConditions, "o.created_at BETWEEN ? AND ?")
 	whereArgs = append(whereArgs, startDate, endDate)
 	
-	whereClause := fmt.Sprintf("WHERE %s", fmt.Sprintf(whereConditions[0], whereArgs[0]))
+	whereClause := "WHERE " + whereConditions[0]
 	for i := 1; i < len(whereConditions); i++ {
 		whereClause += fmt.Sprintf(" AND %s", whereConditions[i])
 	}
@@ -420,9 +508,25 @@ func (r *ReportRepository) getFinancialBreakdown(ctx context.Context, tenantID u
 		LIMIT 10
 	`, whereClause)
 	
-	var merchantRevenues []types.MerchantRevenue
-	err := g.DB().Raw(merchantRevenueQuery, whereArgs...).Scan(&merchantRevenues)
+	// 聚合金额为数值列，先扫描为数值再转换为金额
+	var merchantRows []struct {
+		MerchantID   uint64  `json:"merchant_id"`
+		MerchantName string  `json:"merchant_name"`
+		Revenue      float64 `json:"revenue"`
+		OrderCount   int     `json:"order_count"`
+	}
+	err := r.HeavyRaw(ctx, merchantRevenueQuery, whereArgs...).Scan(&merchantRows)
 	if err == nil {
+		merchantRevenues := make([]types.MerchantRevenue, 0, len(merchantRows))
+		for _, row := range merchantRows {
+			merchantRevenues = append(merchantRevenues, types.MerchantRevenue{
+				MerchantID:   row.MerchantID,
+				MerchantName: row.MerchantName,
+				Revenue:      types.Money{Amount: row.Revenue},
+				OrderCount:   row.OrderCount,
+			})
+		}
+		
 		// 计算百分比
 		totalRevenue := 0.0
 		for _, mr := range merchantRevenues {
@@ -436,6 +540,8 @@ func (r *ReportRepository) getFinancialBreakdown(ctx context.Context, tenantID u
 		}
 		
 		breakdown.RevenueByMerchant = merchantRevenues
+	} else {
+		recordSwallowedQueryError(ctx, "financial_breakdown.revenue_by_merchant", err)
 	}
 	
 	// 按类别收入统计（需要关联商品类别表）
@@ -454,9 +560,24 @@ func (r *ReportRepository) getFinancialBreakdown(ctx context.Context, tenantID u
 		ORDER BY revenue DESC
 	`, whereClause)
 	
-	var categoryRevenues []types.CategoryRevenue
-	err = g.DB().Raw(categoryRevenueQuery, whereArgs...).Scan(&categoryRevenues)
+	var categoryRows []struct {
+		CategoryID   uint64  `json:"category_id"`
+		CategoryName string  `json:"category_name"`
+		Revenue      float64 `json:"revenue"`
+		OrderCount   int     `json:"order_count"`
+	}
+	err = r.HeavyRaw(ctx, categoryRevenueQuery, whereArgs...).Scan(&categoryRows)
 	if err == nil {
+		categoryRevenues := make([]types.CategoryRevenue, 0, len(categoryRows))
+		for _, row := range categoryRows {
+			categoryRevenues = append(categoryRevenues, types.CategoryRevenue{
+				CategoryID:   row.CategoryID,
+				CategoryName: row.CategoryName,
+				Revenue:      types.Money{Amount: row.Revenue},
+				OrderCount:   row.OrderCount,
+			})
+		}
+		
 		// 计算百分比
 		totalRevenue := 0.0
 		for _, cr := range categoryRevenues {
@@ -470,6 +591,8 @@ func (r *ReportRepository) getFinancialBreakdown(ctx context.Context, tenantID u
 		}
 		
 		breakdown.RevenueByCategory = categoryRevenues
+	} else {
+		recordSwallowedQueryError(ctx, "financial_breakdown.revenue_by_category", err)
 	}
 	
 	// 月度趋势数据
@@ -485,20 +608,114 @@ func (r *ReportRepository) getFinancialBreakdown(ctx context.Context, tenantID u
 		ORDER BY month ASC
 	`, whereClause)
 	
-	var monthlyTrends []types.MonthlyFinancial
-	err = g.DB().Raw(monthlyTrendQuery, whereArgs...).Scan(&monthlyTrends)
+	var monthlyRows []struct {
+		Month          string  `json:"month"`
+		Revenue        float64 `json:"revenue"`
+		OrderCount     int     `json:"order_count"`
+		RightsConsumed int64   `json:"rights_consumed"`
+	}
+	err = r.HeavyRaw(ctx, monthlyTrendQuery, whereArgs...).Scan(&monthlyRows)
 	if err == nil {
 		// 计算净利润（简化处理）
-		for i := range monthlyTrends {
-			monthlyTrends[i].NetProfit = monthlyTrends[i].Revenue
-			monthlyTrends[i].Expenditure = types.Money{Amount: 0}
+		monthlyTrends := make([]types.MonthlyFinancial, 0, len(monthlyRows))
+		for _, row := range monthlyRows {
+			monthlyTrends = append(monthlyTrends, types.MonthlyFinancial{
+				Month:          row.Month,
+				Revenue:        types.Money{Amount: row.Revenue},
+				Expenditure:    types.Money{Amount: 0},
+				NetProfit:      types.Money{Amount: row.Revenue},
+				OrderCount:     row.OrderCount,
+				RightsConsumed: row.RightsConsumed,
+			})
 		}
 		breakdown.MonthlyTrend = monthlyTrends
+	} else {
+		recordSwallowedQueryError(ctx, "financial_breakdown.monthly_trend", err)
 	}
 	
 	return breakdown, nil
 }
 
+// GetReconciliationFigures 按商户汇总对账数据：订单收入和权益消耗来自已支付和已完成订单，
+// 充值和分配来自已确认的资金记录。累计数据统计截至 endDate 的全部记录
+func (r *ReportRepository) GetReconciliationFigures(ctx context.Context, tenantID uint64, startDate, endDate time.Time, merchantID *uint64) ([]types.MerchantReconciliationFigures, error) {
+	merchantCondition := ""
+	merchantArgs := []interface{}{}
+	if merchantID != nil {
+		merchantCondition = "AND merchant_id = ?"
+		merchantArgs = append(merchantArgs, *merchantID)
+	}
+	
+	orderQuery := fmt.Sprintf(`
+		SELECT 
+			o.merchant_id,
+			m.name as merchant_name,
+			COALESCE(SUM(CASE WHEN o.created_at BETWEEN ? AND ? THEN o.total_amount ELSE 0 END), 0) as order_revenue,
+			COUNT(CASE WHEN o.created_at BETWEEN ? AND ? THEN 1 END) as paid_order_count,
+			COALESCE(SUM(CASE WHEN o.created_at BETWEEN ? AND ? THEN o.total_rights_cost ELSE 0 END), 0) as rights_consumed,
+			COALESCE(SUM(o.total_rights_cost), 0) as total_consumed
+		FROM (SELECT * FROM orders WHERE tenant_id = ? %s) o
+		LEFT JOIN merchants m ON o.merchant_id = m.id
+		WHERE o.created_at <= ? AND o.status IN ('completed', 'paid')
+		GROUP BY o.merchant_id, m.name
+	`, merchantCondition)
+	
+	orderArgs := []interface{}{startDate, endDate, startDate, endDate, startDate, endDate, tenantID}
+	orderArgs = append(orderArgs, merchantArgs...)
+	orderArgs = append(orderArgs, endDate)
+	
+	var orderFigures []types.MerchantReconciliationFigures
+	if err := r.HeavyRaw(ctx, orderQuery, orderArgs...).Scan(&orderFigures); err != nil {
+		return nil, fmt.Errorf("查询订单对账数据失败: %v", err)
+	}
+	
+	fundQuery := fmt.Sprintf(`
+		SELECT 
+			f.merchant_id,
+			m.name as merchant_name,
+			COALESCE(SUM(CASE WHEN f.fund_type = ? AND f.created_at BETWEEN ? AND ? THEN f.amount ELSE 0 END), 0) as deposits,
+			COALESCE(SUM(CASE WHEN f.fund_type = ? AND f.created_at BETWEEN ? AND ? THEN f.amount ELSE 0 END), 0) as allocations,
+			COALESCE(SUM(f.amount), 0) as total_credited
+		FROM (SELECT * FROM funds WHERE tenant_id = ? %s) f
+		LEFT JOIN merchants m ON f.merchant_id = m.id
+		WHERE f.created_at <= ? AND f.status = ? AND f.fund_type IN (?, ?)
+		GROUP BY f.merchant_id, m.name
+	`, merchantCondition)
+	
+	fundArgs := []interface{}{types.FundTypeDeposit, startDate, endDate, types.FundTypeAllocation, startDate, endDate, tenantID}
+	fundArgs = append(fundArgs, merchantArgs...)
+	fundArgs = append(fundArgs, endDate, types.FundStatusConfirmed, types.FundTypeDeposit, types.FundTypeAllocation)
+	
+	var fundFigures []types.MerchantReconciliationFigures
+	if err := r.HeavyRaw(ctx, fundQuery, fundArgs...).Scan(&fundFigures); err != nil {
+		return nil, fmt.Errorf("查询资金对账数据失败: %v", err)
+	}
+	
+	// 合并订单和资金数据，只有其中一侧有记录的商户也要保留
+	merged := make(map[uint64]*types.MerchantReconciliationFigures, len(orderFigures))
+	for i := range orderFigures {
+		merged[orderFigures[i].MerchantID] = &orderFigures[i]
+	}
+	for _, fund := range fundFigures {
+		figures, exists := merged[fund.MerchantID]
+		if !exists {
+			fund := fund
+			merged[fund.MerchantID] = &fund
+			continue
+		}
+		figures.Deposits = fund.Deposits
+		figures.Allocations = fund.Allocations
+		figures.TotalCredited = fund.TotalCredited
+	}
+	
+	result := make([]types.MerchantReconciliationFigures, 0, len(merged))
+	for _, figures := range merged {
+		result = append(result, *figures)
+	}
+	
+	return result, nil
+}
+
 // GetMerchantOperationData 获取商户运营数据
 func (r *ReportRepository) GetMerchantOperationData(ctx context.Context, tenantID uint64, startDate, endDate time.Time) (*types.MerchantOperationReport, error) {
 	report := &types.MerchantOperationReport{}
@@ -523,16 +740,32 @@ func (r *ReportRepository) GetMerchantOperationData(ctx context.Context, tenantI
 		LIMIT 20
 	`
 	
-	var rankings []types.MerchantRanking
-	err := g.DB().Raw(rankingQuery, tenantID, startDate, endDate, tenantID).Scan(&rankings)
+	var rankingRows []struct {
+		MerchantID        uint64  `json:"merchant_id"`
+		MerchantName      string  `json:"merchant_name"`
+		TotalRevenue      float64 `json:"total_revenue"`
+		OrderCount        int     `json:"order_count"`
+		CustomerCount     int     `json:"customer_count"`
+		AverageOrderValue float64 `json:"average_order_value"`
+	}
+	err := r.HeavyRaw(ctx, rankingQuery, tenantID, startDate, endDate, tenantID).Scan(&rankingRows)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query merchant rankings: %v", err)
 	}
 	
 	// 添加排名和增长率（这里简化处理）
-	for i := range rankings {
-		rankings[i].Rank = i + 1
-		rankings[i].GrowthRate = 0.0 // 简化处理，实际需要对比历史数据
+	rankings := make([]types.MerchantRanking, 0, len(rankingRows))
+	for i, row := range rankingRows {
+		rankings = append(rankings, types.MerchantRanking{
+			Rank:              i + 1,
+			MerchantID:        row.MerchantID,
+			MerchantName:      row.MerchantName,
+			TotalRevenue:      types.Money{Amount: row.TotalRevenue},
+			OrderCount:        row.OrderCount,
+			CustomerCount:     row.CustomerCount,
+			AverageOrderValue: types.Money{Amount: row.AverageOrderValue},
+			GrowthRate:        0.0, // 简化处理，实际需要对比历史数据
+		})
 	}
 	
 	report.MerchantRankings = rankings
@@ -554,9 +787,26 @@ func (r *ReportRepository) GetMerchantOperationData(ctx context.Context, tenantI
 		ORDER BY revenue DESC
 	`
 	
-	var categories []types.CategoryAnalysis
-	err = g.DB().Raw(categoryQuery, tenantID, startDate, endDate).Scan(&categories)
+	var categoryRows []struct {
+		CategoryID    uint64  `json:"category_id"`
+		CategoryName  string  `json:"category_name"`
+		Revenue       float64 `json:"revenue"`
+		OrderCount    int     `json:"order_count"`
+		MerchantCount int     `json:"merchant_count"`
+	}
+	err = r.HeavyRaw(ctx, categoryQuery, tenantID, startDate, endDate).Scan(&categoryRows)
 	if err == nil {
+		categories := make([]types.CategoryAnalysis, 0, len(categoryRows))
+		for _, row := range categoryRows {
+			categories = append(categories, types.CategoryAnalysis{
+				CategoryID:    row.CategoryID,
+				CategoryName:  row.CategoryName,
+				Revenue:       types.Money{Amount: row.Revenue},
+				OrderCount:    row.OrderCount,
+				MerchantCount: row.MerchantCount,
+			})
+		}
+		
 		// 计算市场份额
 		totalRevenue := 0.0
 		for _, cat := range categories {
@@ -571,6 +821,33 @@ func (r *ReportRepository) GetMerchantOperationData(ctx context.Context, tenantI
 		}
 		
 		report.CategoryAnalysis = categories
+	} else {
+		recordSwallowedQueryError(ctx, "merchant_operation.category_analysis", err)
+	}
+	
+	// 商品评价满意度（不计入被标记的评价）
+	satisfactionQuery := `
+		SELECT 
+			m.id as merchant_id,
+			m.name as merchant_name,
+			ROUND(AVG(pr.rating), 1) as average_rating,
+			COUNT(pr.id) as review_count,
+			COUNT(CASE WHEN pr.rating <= 2 THEN 1 END) as low_rating_count
+		FROM product_reviews pr
+		JOIN merchants m ON pr.merchant_id = m.id
+		WHERE pr.tenant_id = ? 
+			AND pr.flagged = FALSE
+			AND pr.created_at BETWEEN ? AND ?
+		GROUP BY m.id, m.name
+		ORDER BY average_rating DESC, review_count DESC
+	`
+	
+	var satisfaction []types.MerchantSatisfaction
+	err = r.HeavyRaw(ctx, satisfactionQuery, tenantID, startDate, endDate).Scan(&satisfaction)
+	if err == nil {
+		report.Satisfaction = satisfaction
+	} else {
+		recordSwallowedQueryError(ctx, "merchant_operation.satisfaction", err)
 	}
 	
 	// 增长指标（简化实现）
@@ -603,7 +880,7 @@ func (r *ReportRepository) GetCustomerAnalysisData(ctx context.Context, tenantID
 	`
 	
 	var userGrowth []types.UserGrowthData
-	err := g.DB().Raw(userGrowthQuery, tenantID, startDate, endDate).Scan(&userGrowth)
+	err := r.HeavyRaw(ctx, userGrowthQuery, tenantID, startDate, endDate).Scan(&userGrowth)
 	if err == nil {
 		// 计算累计用户数和留存率（简化处理）
 		cumulativeUsers := 0
@@ -617,6 +894,8 @@ func (r *ReportRepository) GetCustomerAnalysisData(ctx context.Context, tenantID
 		}
 		
 		report.UserGrowth = userGrowth
+	} else {
+		recordSwallowedQueryError(ctx, "customer_analysis.user_growth", err)
 	}
 	
 	// 活跃度指标（简化实现）
@@ -630,11 +909,13 @@ func (r *ReportRepository) GetCustomerAnalysisData(ctx context.Context, tenantID
 	`
 	
 	var activityMetrics types.ActivityMetrics
-	err = g.DB().Raw(activityQuery, tenantID).Scan(&activityMetrics)
+	err = r.HeavyRaw(ctx, activityQuery, tenantID).Scan(&activityMetrics)
 	if err == nil {
 		activityMetrics.AverageSessionTime = 15.5 // 简化数据
 		activityMetrics.AverageOrderFreq = 2.3    // 简化数据
 		report.ActivityMetrics = &activityMetrics
+	} else {
+		recordSwallowedQueryError(ctx, "customer_analysis.activity_metrics", err)
 	}
 	
 	// 消费行为分析
@@ -660,13 +941,23 @@ func (r *ReportRepository) GetCustomerAnalysisData(ctx context.Context, tenantID
 		WHERE o.tenant_id = ? AND o.created_at BETWEEN ? AND ?
 	`
 	
-	var consumptionBehavior types.ConsumptionBehavior
-	err = g.DB().Raw(consumptionQuery, 
+	var consumptionResult struct {
+		AverageOrderValue float64 `json:"average_order_value"`
+		RepurchaseRate    float64 `json:"repurchase_rate"`
+		AverageOrderCount float64 `json:"average_order_count"`
+	}
+	err = r.HeavyRaw(ctx, consumptionQuery, 
 		tenantID, startDate, endDate, 
 		tenantID, startDate, endDate,
-		tenantID, startDate, endDate).Scan(&consumptionBehavior)
+		tenantID, startDate, endDate).Scan(&consumptionResult)
 	if err == nil {
-		report.ConsumptionBehavior = &consumptionBehavior
+		report.ConsumptionBehavior = &types.ConsumptionBehavior{
+			AverageOrderValue: types.Money{Amount: consumptionResult.AverageOrderValue},
+			RepurchaseRate:    consumptionResult.RepurchaseRate,
+			AverageOrderCount: consumptionResult.AverageOrderCount,
+		}
+	} else {
+		recordSwallowedQueryError(ctx, "customer_analysis.consumption_behavior", err)
 	}
 	
 	// 留存分析（简化实现）
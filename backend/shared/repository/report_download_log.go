@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// IReportDownloadLogRepository 报表下载访问日志仓储接口
+type IReportDownloadLogRepository interface {
+	Create(ctx context.Context, log *types.ReportDownloadLog) error
+	// ListByReport 按时间倒序获取报表最近的下载记录
+	ListByReport(ctx context.Context, reportID uint64, limit int) ([]types.ReportDownloadLog, error)
+}
+
+// ReportDownloadLogRepository 报表下载访问日志仓储
+type ReportDownloadLogRepository struct {
+	*BaseRepository
+}
+
+// NewReportDownloadLogRepository 创建报表下载访问日志仓储实例
+func NewReportDownloadLogRepository() IReportDownloadLogRepository {
+	return &ReportDownloadLogRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// Create 记录一次报表下载
+func (r *ReportDownloadLogRepository) Create(ctx context.Context, log *types.ReportDownloadLog) error {
+	id, err := TenantDBFor(ctx, log.TenantID).Model("report_download_logs").Ctx(ctx).InsertAndGetId(log)
+	if err != nil {
+		return fmt.Errorf("记录报表下载失败: %v", err)
+	}
+	log.ID = uint64(id)
+	return nil
+}
+
+// ListByReport 按时间倒序获取当前租户下报表最近的下载记录
+func (r *ReportDownloadLogRepository) ListByReport(ctx context.Context, reportID uint64, limit int) ([]types.ReportDownloadLog, error) {
+	var logs []types.ReportDownloadLog
+	err := TenantDB(ctx).Model("report_download_logs").Ctx(ctx).
+		Where("tenant_id = ? AND report_id = ?", r.GetTenantID(ctx), reportID).
+		OrderDesc("created_at").
+		OrderDesc("id").
+		Limit(limit).
+		Scan(&logs)
+	if err != nil {
+		return nil, fmt.Errorf("获取报表下载记录失败: %v", err)
+	}
+	return logs, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// IRightsThresholdRepository 商户权益余额阈值检查仓储接口
+type IRightsThresholdRepository interface {
+	// 列出配置了预警阈值的活跃商户及最近 days 天的日均权益消耗，tenantID 为0时查询所有租户（仅用于后台任务）
+	ListMerchantUsage(ctx context.Context, tenantID uint64, days int, limit int) ([]types.MerchantRightsUsage, error)
+	// 获取商户最近一次检查时的预警级别，从未检查过时返回 nil
+	GetThreshold(ctx context.Context, tenantID, merchantID uint64) (*types.MerchantRightsThreshold, error)
+	SaveThreshold(ctx context.Context, threshold *types.MerchantRightsThreshold) error
+}
+
+// RightsThresholdRepository 商户权益余额阈值检查数据访问层
+type RightsThresholdRepository struct {
+	*BaseRepository
+}
+
+// NewRightsThresholdRepository 创建商户权益余额阈值检查仓库实例
+func NewRightsThresholdRepository() IRightsThresholdRepository {
+	return &RightsThresholdRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// ListMerchantUsage 列出配置了预警阈值的活跃商户，日均消耗按最近 days 天转为已使用的订单权益计算
+func (r *RightsThresholdRepository) ListMerchantUsage(ctx context.Context, tenantID uint64, days int, limit int) ([]types.MerchantRightsUsage, error) {
+	model := TenantDBFor(ctx, tenantID).Model("merchants").
+		Ctx(ctx).
+		Fields("tenant_id, id AS merchant_id, name AS merchant_name, rights_balance").
+		Where("status = ?", types.MerchantStatusActive).
+		Where("JSON_EXTRACT(rights_balance, '$.warning_threshold') IS NOT NULL OR " +
+			"JSON_EXTRACT(rights_balance, '$.critical_threshold') IS NOT NULL")
+	if tenantID > 0 {
+		model = model.Where("tenant_id = ?", tenantID)
+	}
+	if limit > 0 {
+		model = model.Limit(limit)
+	}
+
+	var usages []types.MerchantRightsUsage
+	if err := model.OrderAsc("id").Scan(&usages); err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询商户权益余额失败: %v", err)
+	}
+	if len(usages) == 0 || days <= 0 {
+		return usages, nil
+	}
+
+	merchantIDs := make([]uint64, 0, len(usages))
+	for _, usage := range usages {
+		merchantIDs = append(merchantIDs, usage.MerchantID)
+	}
+	consumed := TenantDBFor(ctx, tenantID).Model("order_rights_reservations").
+		Ctx(ctx).
+		Fields("tenant_id, merchant_id, SUM(amount) AS amount").
+		Where("status = ? AND updated_at >= ?", types.OrderRightsReservationConsumed, time.Now().AddDate(0, 0, -days)).
+		WhereIn("merchant_id", merchantIDs)
+	if tenantID > 0 {
+		consumed = consumed.Where("tenant_id = ?", tenantID)
+	}
+	result, err := consumed.Group("tenant_id, merchant_id").All()
+	if err != nil {
+		return nil, fmt.Errorf("查询商户权益消耗失败: %v", err)
+	}
+
+	amounts := make(map[[2]uint64]float64, len(result))
+	for _, record := range result {
+		amounts[[2]uint64{record["tenant_id"].Uint64(), record["merchant_id"].Uint64()}] = record["amount"].Float64()
+	}
+	for i := range usages {
+		usages[i].AverageDailyUsage = amounts[[2]uint64{usages[i].TenantID, usages[i].MerchantID}] / float64(days)
+	}
+	return usages, nil
+}
+
+// GetThreshold 获取商户最近一次检查时的预警级别
+func (r *RightsThresholdRepository) GetThreshold(ctx context.Context, tenantID, merchantID uint64) (*types.MerchantRightsThreshold, error) {
+	return getMerchantRightsThreshold(ctx, tenantID, merchantID)
+}
+
+// getMerchantRightsThreshold 按指定租户获取商户最近一次检查时的预警级别
+func getMerchantRightsThreshold(ctx context.Context, tenantID, merchantID uint64) (*types.MerchantRightsThreshold, error) {
+	var threshold *types.MerchantRightsThreshold
+	err := TenantDBFor(ctx, tenantID).Model("merchant_rights_thresholds").
+		Ctx(ctx).
+		Where("tenant_id = ? AND merchant_id = ?", tenantID, merchantID).
+		Scan(&threshold)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询商户权益预警级别失败: %v", err)
+	}
+	return threshold, nil
+}
+
+// SaveThreshold 保存商户本次检查的预警级别，已存在时覆盖
+func (r *RightsThresholdRepository) SaveThreshold(ctx context.Context, threshold *types.MerchantRightsThreshold) error {
+	_, err := TenantDBFor(ctx, threshold.TenantID).Model("merchant_rights_thresholds").
+		Ctx(ctx).
+		Data(g.Map{
+			"tenant_id":              threshold.TenantID,
+			"merchant_id":            threshold.MerchantID,
+			"level":                  threshold.Level,
+			"threshold":              threshold.Threshold,
+			"available_balance":      threshold.AvailableBalance,
+			"average_daily_usage":    threshold.AverageDailyUsage,
+			"projected_depletion_at": threshold.ProjectedDepletionAt,
+			"crossed_at":             threshold.CrossedAt,
+			"updated_at":             threshold.UpdatedAt,
+		}).
+		Save()
+	if err != nil {
+		return fmt.Errorf("保存商户权益预警级别失败: %v", err)
+	}
+	return nil
+}
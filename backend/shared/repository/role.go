@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/gogf/gf/v2/database/gdb"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gtime"
 	"github.com/gofromzero/mer-sys/backend/shared/types"
@@ -39,6 +40,31 @@ type UserPermissionsCache struct {
 	UpdatedAt       *gtime.Time `json:"updated_at" db:"updated_at"`
 }
 
+// RoleRecord 角色定义数据库实体
+type RoleRecord struct {
+	ID          uint64         `json:"id" db:"id"`
+	TenantID    uint64         `json:"tenant_id" db:"tenant_id"`
+	RoleType    types.RoleType `json:"role_type" db:"role_type"`
+	Name        string         `json:"name" db:"name"`
+	Description string         `json:"description" db:"description"`
+	IsSystem    bool           `json:"is_system" db:"is_system"`
+	Status      string         `json:"status" db:"status"`
+	CreatedAt   *gtime.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt   *gtime.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// RolePermission 角色权限关联数据库实体
+type RolePermission struct {
+	ID         uint64           `json:"id" db:"id"`
+	TenantID   uint64           `json:"tenant_id" db:"tenant_id"`
+	RoleType   types.RoleType   `json:"role_type" db:"role_type"`
+	Permission types.Permission `json:"permission" db:"permission"`
+	CreatedAt  *gtime.Time      `json:"created_at" db:"created_at"`
+}
+
+// systemRoleTenantID 系统内置角色使用的租户ID
+const systemRoleTenantID uint64 = 0
+
 // RoleRepository 角色管理仓储接口
 type RoleRepository interface {
 	// 角色分配
@@ -60,6 +86,13 @@ type RoleRepository interface {
 	// 批量操作
 	GetRoleUsers(ctx context.Context, tenantID uint64, roleType types.RoleType) ([]uint64, error)
 	GetTenantRoleStats(ctx context.Context, tenantID uint64) (map[types.RoleType]int, error)
+	
+	// 角色定义管理
+	CreateRole(ctx context.Context, tenantID uint64, role *types.Role) error
+	ListRoles(ctx context.Context, tenantID uint64) ([]types.Role, error)
+	GetRoleDefinitions(ctx context.Context, tenantID uint64, roleTypes []types.RoleType) (map[types.RoleType]types.Role, error)
+	GrantRolePermissions(ctx context.Context, tenantID uint64, roleType types.RoleType, permissions []types.Permission) error
+	RevokeRolePermissions(ctx context.Context, tenantID uint64, roleType types.RoleType, permissions []types.Permission) error
 }
 
 // roleRepository 角色管理仓储实现
@@ -84,7 +117,7 @@ func (r *roleRepository) AssignRole(ctx context.Context, userID, tenantID uint64
 	tenantCtx := r.WithTenant(ctx, tenantID)
 	
 	// 检查是否已经存在该角色
-	count, err := g.DB().Model("user_roles").Ctx(tenantCtx).
+	count, err := TenantDBFor(ctx, tenantID).Model("user_roles").Ctx(tenantCtx).
 		Where("user_id = ? AND tenant_id = ? AND role_type = ? AND status = 'active'", userID, tenantID, roleType).
 		Count()
 	if err != nil {
@@ -96,7 +129,7 @@ func (r *roleRepository) AssignRole(ctx context.Context, userID, tenantID uint64
 	}
 	
 	// 插入新角色
-	_, err = g.DB().Model("user_roles").Ctx(tenantCtx).Insert(g.Map{
+	_, err = TenantDBFor(ctx, tenantID).Model("user_roles").Ctx(tenantCtx).Insert(g.Map{
 		"user_id":    userID,
 		"tenant_id":  tenantID,
 		"role_type":  roleType,
@@ -120,7 +153,7 @@ func (r *roleRepository) AssignRole(ctx context.Context, userID, tenantID uint64
 func (r *roleRepository) RevokeRole(ctx context.Context, userID, tenantID uint64, roleType types.RoleType) error {
 	tenantCtx := r.WithTenant(ctx, tenantID)
 	
-	_, err := g.DB().Model("user_roles").Ctx(tenantCtx).
+	_, err := TenantDBFor(ctx, tenantID).Model("user_roles").Ctx(tenantCtx).
 		Where("user_id = ? AND tenant_id = ? AND role_type = ?", userID, tenantID, roleType).
 		Update(g.Map{
 			"status":     "suspended",
@@ -142,7 +175,7 @@ func (r *roleRepository) GetUserRoles(ctx context.Context, userID, tenantID uint
 	tenantCtx := r.WithTenant(ctx, tenantID)
 	
 	var roles []string
-	err := g.DB().Model("user_roles").Ctx(tenantCtx).
+	err := TenantDBFor(ctx, tenantID).Model("user_roles").Ctx(tenantCtx).
 		Fields("role_type").
 		Where("user_id = ? AND tenant_id = ? AND status = 'active'", userID, tenantID).
 		Where("expires_at IS NULL OR expires_at > ?", gtime.Now()).
@@ -174,24 +207,17 @@ func (r *roleRepository) GetUserPermissions(ctx context.Context, userID, tenantI
 		return nil, fmt.Errorf("获取用户角色失败: %w", err)
 	}
 	
-	// 计算所有权限
-	allPermissions := make([]types.Permission, 0)
-	defaultRoles := types.GetDefaultRoles()
-	
-	for _, roleType := range roles {
-		if role, exists := defaultRoles[roleType]; exists {
-			allPermissions = append(allPermissions, role.Permissions...)
-		}
+	// 从角色定义表解析有效权限
+	definitions, err := r.GetRoleDefinitions(ctx, tenantID, roles)
+	if err != nil {
+		return nil, fmt.Errorf("获取角色定义失败: %w", err)
 	}
 	
-	// 去重权限
-	uniquePermissions := r.deduplicatePermissions(allPermissions)
-	
 	userPermissions := &types.UserPermissions{
 		UserID:      userID,
 		TenantID:    tenantID,
 		Roles:       roles,
-		Permissions: uniquePermissions,
+		Permissions: types.ResolvePermissions(roles, definitions),
 	}
 	
 	// 更新缓存
@@ -237,7 +263,7 @@ func (r *roleRepository) RefreshPermissionsCache(ctx context.Context, userID, te
 func (r *roleRepository) ClearPermissionsCache(ctx context.Context, userID, tenantID uint64) error {
 	tenantCtx := r.WithTenant(ctx, tenantID)
 	
-	_, err := g.DB().Model("user_permissions_cache").Ctx(tenantCtx).
+	_, err := TenantDBFor(ctx, tenantID).Model("user_permissions_cache").Ctx(tenantCtx).
 		Where("user_id = ? AND tenant_id = ?", userID, tenantID).
 		Delete()
 	
@@ -249,7 +275,7 @@ func (r *roleRepository) GetRoleUsers(ctx context.Context, tenantID uint64, role
 	tenantCtx := r.WithTenant(ctx, tenantID)
 	
 	var userIDs []uint64
-	err := g.DB().Model("user_roles").Ctx(tenantCtx).
+	err := TenantDBFor(ctx, tenantID).Model("user_roles").Ctx(tenantCtx).
 		Fields("user_id").
 		Where("tenant_id = ? AND role_type = ? AND status = 'active'", tenantID, roleType).
 		Where("expires_at IS NULL OR expires_at > ?", gtime.Now()).
@@ -272,7 +298,7 @@ func (r *roleRepository) GetTenantRoleStats(ctx context.Context, tenantID uint64
 	}
 	
 	var stats []RoleCount
-	err := g.DB().Model("user_roles").Ctx(tenantCtx).
+	err := TenantDBFor(ctx, tenantID).Model("user_roles").Ctx(tenantCtx).
 		Fields("role_type, COUNT(*) as count").
 		Where("tenant_id = ? AND status = 'active'", tenantID).
 		Where("expires_at IS NULL OR expires_at > ?", gtime.Now()).
@@ -291,12 +317,239 @@ func (r *roleRepository) GetTenantRoleStats(ctx context.Context, tenantID uint64
 	return result, nil
 }
 
+// CreateRole 创建租户自定义角色
+func (r *roleRepository) CreateRole(ctx context.Context, tenantID uint64, role *types.Role) error {
+	if types.IsSystemRole(role.Type) {
+		return fmt.Errorf("角色标识 %s 与系统内置角色冲突", role.Type)
+	}
+	
+	tenantCtx := r.WithTenant(ctx, tenantID)
+	
+	count, err := TenantDBFor(ctx, tenantID).Model("roles").Ctx(tenantCtx).
+		Where("tenant_id = ? AND role_type = ?", tenantID, role.Type).
+		Count()
+	if err != nil {
+		return fmt.Errorf("检查角色是否存在失败: %w", err)
+	}
+	
+	if count > 0 {
+		return fmt.Errorf("角色 %s 已存在", role.Type)
+	}
+	
+	return TenantTransaction(tenantCtx, func(ctx context.Context, tx gdb.TX) error {
+		_, err := tx.Model("roles").Ctx(ctx).Insert(g.Map{
+			"tenant_id":   tenantID,
+			"role_type":   role.Type,
+			"name":        role.Name,
+			"description": role.Description,
+			"is_system":   false,
+			"status":      "active",
+			"created_at":  gtime.Now(),
+			"updated_at":  gtime.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("创建角色失败: %w", err)
+		}
+		
+		return r.insertRolePermissions(ctx, tx, tenantID, role.Type, role.Permissions)
+	})
+}
+
+// ListRoles 获取租户可用的角色列表（系统内置角色 + 租户自定义角色）
+func (r *roleRepository) ListRoles(ctx context.Context, tenantID uint64) ([]types.Role, error) {
+	tenantCtx := r.WithTenant(ctx, tenantID)
+	
+	var records []RoleRecord
+	err := TenantDBFor(ctx, tenantID).Model("roles").Ctx(tenantCtx).
+		Where("tenant_id IN (?) AND status = 'active'", []uint64{systemRoleTenantID, tenantID}).
+		OrderDesc("is_system").
+		OrderAsc("id").
+		Scan(&records)
+	if err != nil {
+		return nil, fmt.Errorf("获取角色列表失败: %w", err)
+	}
+	
+	roleTypes := make([]types.RoleType, len(records))
+	for i, record := range records {
+		roleTypes[i] = record.RoleType
+	}
+	
+	definitions, err := r.GetRoleDefinitions(ctx, tenantID, roleTypes)
+	if err != nil {
+		return nil, err
+	}
+	
+	roles := make([]types.Role, 0, len(records))
+	for _, record := range records {
+		roles = append(roles, definitions[record.RoleType])
+	}
+	
+	return roles, nil
+}
+
+// GetRoleDefinitions 获取指定角色的定义及权限
+func (r *roleRepository) GetRoleDefinitions(ctx context.Context, tenantID uint64, roleTypes []types.RoleType) (map[types.RoleType]types.Role, error) {
+	definitions := make(map[types.RoleType]types.Role)
+	if len(roleTypes) == 0 {
+		return definitions, nil
+	}
+	
+	tenantCtx := r.WithTenant(ctx, tenantID)
+	tenantIDs := []uint64{systemRoleTenantID, tenantID}
+	
+	var records []RoleRecord
+	err := TenantDBFor(ctx, tenantID).Model("roles").Ctx(tenantCtx).
+		Where("tenant_id IN (?) AND role_type IN (?) AND status = 'active'", tenantIDs, roleTypes).
+		Scan(&records)
+	if err != nil {
+		return nil, fmt.Errorf("查询角色定义失败: %w", err)
+	}
+	
+	for _, record := range records {
+		definitions[record.RoleType] = types.Role{
+			Type:        record.RoleType,
+			Name:        record.Name,
+			Description: record.Description,
+			IsSystem:    record.IsSystem,
+			Permissions: make([]types.Permission, 0),
+		}
+	}
+	
+	var rolePermissions []RolePermission
+	err = TenantDBFor(ctx, tenantID).Model("role_permissions").Ctx(tenantCtx).
+		Where("tenant_id IN (?) AND role_type IN (?)", tenantIDs, roleTypes).
+		OrderAsc("id").
+		Scan(&rolePermissions)
+	if err != nil {
+		return nil, fmt.Errorf("查询角色权限失败: %w", err)
+	}
+	
+	for _, rp := range rolePermissions {
+		if role, exists := definitions[rp.RoleType]; exists {
+			role.Permissions = append(role.Permissions, rp.Permission)
+			definitions[rp.RoleType] = role
+		}
+	}
+	
+	return definitions, nil
+}
+
+// GrantRolePermissions 为租户自定义角色授予权限
+func (r *roleRepository) GrantRolePermissions(ctx context.Context, tenantID uint64, roleType types.RoleType, permissions []types.Permission) error {
+	if err := r.checkRoleEditable(ctx, tenantID, roleType); err != nil {
+		return err
+	}
+	
+	tenantCtx := r.WithTenant(ctx, tenantID)
+	
+	existing, err := r.GetRoleDefinitions(ctx, tenantID, []types.RoleType{roleType})
+	if err != nil {
+		return err
+	}
+	
+	toGrant := make([]types.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		if !existing[roleType].HasPermission(permission) {
+			toGrant = append(toGrant, permission)
+		}
+	}
+	
+	err = TenantTransaction(tenantCtx, func(ctx context.Context, tx gdb.TX) error {
+		return r.insertRolePermissions(ctx, tx, tenantID, roleType, toGrant)
+	})
+	if err != nil {
+		return err
+	}
+	
+	// 角色权限变化影响所有持有该角色的用户，清除租户权限缓存
+	return r.clearTenantPermissionsCache(ctx, tenantID)
+}
+
+// RevokeRolePermissions 撤销租户自定义角色的权限
+func (r *roleRepository) RevokeRolePermissions(ctx context.Context, tenantID uint64, roleType types.RoleType, permissions []types.Permission) error {
+	if err := r.checkRoleEditable(ctx, tenantID, roleType); err != nil {
+		return err
+	}
+	
+	if len(permissions) == 0 {
+		return nil
+	}
+	
+	tenantCtx := r.WithTenant(ctx, tenantID)
+	
+	_, err := TenantDBFor(ctx, tenantID).Model("role_permissions").Ctx(tenantCtx).
+		Where("tenant_id = ? AND role_type = ? AND permission IN (?)", tenantID, roleType, permissions).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("撤销角色权限失败: %w", err)
+	}
+	
+	return r.clearTenantPermissionsCache(ctx, tenantID)
+}
+
+// checkRoleEditable 检查角色是否存在且允许修改（系统内置角色不可修改）
+func (r *roleRepository) checkRoleEditable(ctx context.Context, tenantID uint64, roleType types.RoleType) error {
+	if types.IsSystemRole(roleType) {
+		return fmt.Errorf("系统内置角色 %s 不可修改", roleType)
+	}
+	
+	tenantCtx := r.WithTenant(ctx, tenantID)
+	
+	count, err := TenantDBFor(ctx, tenantID).Model("roles").Ctx(tenantCtx).
+		Where("tenant_id = ? AND role_type = ? AND status = 'active'", tenantID, roleType).
+		Count()
+	if err != nil {
+		return fmt.Errorf("检查角色失败: %w", err)
+	}
+	
+	if count == 0 {
+		return fmt.Errorf("角色 %s 不存在", roleType)
+	}
+	
+	return nil
+}
+
+// insertRolePermissions 批量写入角色权限
+func (r *roleRepository) insertRolePermissions(ctx context.Context, tx gdb.TX, tenantID uint64, roleType types.RoleType, permissions []types.Permission) error {
+	permissions = r.deduplicatePermissions(permissions)
+	if len(permissions) == 0 {
+		return nil
+	}
+	
+	data := make(g.List, 0, len(permissions))
+	for _, permission := range permissions {
+		data = append(data, g.Map{
+			"tenant_id":  tenantID,
+			"role_type":  roleType,
+			"permission": permission,
+			"created_at": gtime.Now(),
+		})
+	}
+	
+	if _, err := tx.Model("role_permissions").Ctx(ctx).Insert(data); err != nil {
+		return fmt.Errorf("写入角色权限失败: %w", err)
+	}
+	
+	return nil
+}
+
+// clearTenantPermissionsCache 清除租户下所有用户的权限缓存
+func (r *roleRepository) clearTenantPermissionsCache(ctx context.Context, tenantID uint64) error {
+	tenantCtx := r.WithTenant(ctx, tenantID)
+	
+	_, err := TenantDBFor(ctx, tenantID).Model("user_permissions_cache").Ctx(tenantCtx).
+		Where("tenant_id = ?", tenantID).
+		Delete()
+	
+	return err
+}
+
 // getPermissionsFromCache 从缓存获取权限信息
 func (r *roleRepository) getPermissionsFromCache(ctx context.Context, userID, tenantID uint64) (*types.UserPermissions, error) {
 	tenantCtx := r.WithTenant(ctx, tenantID)
 	
 	var cache UserPermissionsCache
-	err := g.DB().Model("user_permissions_cache").Ctx(tenantCtx).
+	err := TenantDBFor(ctx, tenantID).Model("user_permissions_cache").Ctx(tenantCtx).
 		Where("user_id = ? AND tenant_id = ? AND expires_at > ?", userID, tenantID, gtime.Now()).
 		Scan(&cache)
 	
@@ -343,7 +596,7 @@ func (r *roleRepository) updatePermissionsCache(ctx context.Context, userPermiss
 	expiresAt := gtime.Now().Add(time.Hour)
 	
 	// 更新或插入缓存
-	_, err = g.DB().Model("user_permissions_cache").Ctx(tenantCtx).
+	_, err = TenantDB(tenantCtx).Model("user_permissions_cache").Ctx(tenantCtx).
 		Replace(g.Map{
 			"user_id":          userPermissions.UserID,
 			"tenant_id":        userPermissions.TenantID,
@@ -6,7 +6,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gogf/gf/v2/database/gdb"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/util/gconv"
 
@@ -62,7 +61,7 @@ func (r *scheduledTaskRepository) Create(ctx context.Context, task *types.Schedu
 		"updated_at":        time.Now(),
 	}
 
-	lastInsertID, err := r.db.Model(r.tableName).Ctx(ctx).Data(data).InsertAndGetId()
+	lastInsertID, err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).Data(data).InsertAndGetId()
 	if err != nil {
 		return nil, fmt.Errorf("创建定时任务失败: %w", err)
 	}
@@ -102,7 +101,7 @@ func (r *scheduledTaskRepository) Update(ctx context.Context, task *types.Schedu
 	// IsEnabled 使用指针类型检查是否需要更新
 	data["is_enabled"] = task.IsEnabled
 
-	_, err := r.db.Model(r.tableName).Ctx(ctx).
+	_, err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", task.ID, tenantID).
 		Data(data).
 		Update()
@@ -118,7 +117,7 @@ func (r *scheduledTaskRepository) Update(ctx context.Context, task *types.Schedu
 func (r *scheduledTaskRepository) Delete(ctx context.Context, taskID int64) error {
 	tenantID := r.GetTenantID(ctx)
 
-	_, err := r.db.Model(r.tableName).Ctx(ctx).
+	_, err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", taskID, tenantID).
 		Delete()
 
@@ -134,7 +133,7 @@ func (r *scheduledTaskRepository) GetByID(ctx context.Context, taskID int64) (*t
 	tenantID := r.GetTenantID(ctx)
 
 	var task *types.ScheduledTask
-	err := r.db.Model(r.tableName).Ctx(ctx).
+	err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", taskID, tenantID).
 		Scan(&task)
 
@@ -158,7 +157,7 @@ func (r *scheduledTaskRepository) GetByID(ctx context.Context, taskID int64) (*t
 func (r *scheduledTaskRepository) List(ctx context.Context, req *types.ScheduledTaskListRequest) ([]*types.ScheduledTask, int, error) {
 	tenantID := r.GetTenantID(ctx)
 
-	query := r.db.Model(r.tableName).Ctx(ctx).Where("tenant_id = ?", tenantID)
+	query := TenantDB(ctx).Model(r.tableName).Ctx(ctx).Where("tenant_id = ?", tenantID)
 
 	// 构建查询条件
 	if req.TaskName != "" {
@@ -225,7 +224,7 @@ func (r *scheduledTaskRepository) UpdateLastExecution(ctx context.Context, taskI
 	if status == types.TaskStatusCompleted {
 		// 获取任务的cron表达式
 		var cronExpression string
-		err := r.db.Model(r.tableName).Ctx(ctx).
+		err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).
 			Fields("cron_expression").
 			Where("id = ? AND tenant_id = ?", taskID, tenantID).
 			Scan(&cronExpression)
@@ -235,7 +234,7 @@ func (r *scheduledTaskRepository) UpdateLastExecution(ctx context.Context, taskI
 		}
 	}
 
-	_, err := r.db.Model(r.tableName).Ctx(ctx).
+	_, err := TenantDB(ctx).Model(r.tableName).Ctx(ctx).
 		Where("id = ? AND tenant_id = ?", taskID, tenantID).
 		Data(data).
 		Update()
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gofromzero/mer-sys/backend/shared/config"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 序号源后端
+const (
+	SequenceBackendDatabase = "database"
+	SequenceBackendRedis    = "redis"
+)
+
+// ErrInvalidSequenceBatch 批量分配的序号数必须大于 0
+var ErrInvalidSequenceBatch = errors.New("批量分配的序号数必须大于0")
+
+// SequenceGenerator 序号源：按租户和序号名称分别递增，并发调用时分配的序号不会重复。
+// tenantID 为 0 表示全局序号，用于需要跨租户唯一的编号（如订单号）
+type SequenceGenerator interface {
+	// Next 分配下一个序号，序号从 1 开始
+	Next(ctx context.Context, tenantID uint64, key string) (uint64, error)
+	// NextBatch 一次分配 n 个连续的序号，返回其中第一个
+	NextBatch(ctx context.Context, tenantID uint64, key string, n int) (uint64, error)
+}
+
+// NewSequenceGenerator 按配置（sequence.backend）创建序号源，默认使用数据库
+func NewSequenceGenerator() SequenceGenerator {
+	backend := g.Cfg().MustGet(context.Background(), "sequence.backend", SequenceBackendDatabase).String()
+	if backend == SequenceBackendRedis {
+		return NewRedisSequenceGenerator()
+	}
+	return NewDBSequenceGenerator()
+}
+
+// dbSequenceGenerator 基于数据库的序号源，每次分配是一条原子的 upsert，不需要额外加锁
+type dbSequenceGenerator struct{}
+
+// NewDBSequenceGenerator 创建基于数据库的序号源
+func NewDBSequenceGenerator() SequenceGenerator {
+	return &dbSequenceGenerator{}
+}
+
+// Next 分配下一个序号
+func (s *dbSequenceGenerator) Next(ctx context.Context, tenantID uint64, key string) (uint64, error) {
+	return s.NextBatch(ctx, tenantID, key, 1)
+}
+
+// NextBatch 将序号增加 n，返回本次分配的第一个序号
+func (s *dbSequenceGenerator) NextBatch(ctx context.Context, tenantID uint64, key string, n int) (uint64, error) {
+	if n <= 0 {
+		return 0, ErrInvalidSequenceBatch
+	}
+
+	// LAST_INSERT_ID(expr) 使递增后的值通过本条语句的结果返回，无需额外加锁查询
+	result, err := sequenceDB(ctx, tenantID).Exec(ctx,
+		"INSERT INTO sequences (tenant_id, seq_key, current_value) VALUES (?, ?, LAST_INSERT_ID(?)) "+
+			"ON DUPLICATE KEY UPDATE current_value = LAST_INSERT_ID(current_value + ?)",
+		tenantID, key, n, n)
+	if err != nil {
+		return 0, fmt.Errorf("分配序号失败: %v", err)
+	}
+
+	last, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("获取序号失败: %v", err)
+	}
+	return uint64(last) - uint64(n) + 1, nil
+}
+
+// sequenceDB 序号所在的数据库：租户序号随租户数据驻留；全局序号保存在共享数据库，
+// 配置数据驻留后仍跨数据源唯一，也不受调用方所在租户事务的数据源限制
+func sequenceDB(ctx context.Context, tenantID uint64) gdb.DB {
+	if tenantID == 0 {
+		return g.DB()
+	}
+	return TenantDBFor(ctx, tenantID)
+}
+
+// redisSequenceGenerator 基于 Redis INCRBY 的序号源，适合分配频繁的序号；
+// 序号只保存在 Redis 中，需开启持久化，否则 Redis 数据丢失后会重新从 1 开始
+type redisSequenceGenerator struct{}
+
+// NewRedisSequenceGenerator 创建基于 Redis 的序号源
+func NewRedisSequenceGenerator() SequenceGenerator {
+	return &redisSequenceGenerator{}
+}
+
+// Next 分配下一个序号
+func (s *redisSequenceGenerator) Next(ctx context.Context, tenantID uint64, key string) (uint64, error) {
+	return s.NextBatch(ctx, tenantID, key, 1)
+}
+
+// NextBatch 将序号增加 n，返回本次分配的第一个序号
+func (s *redisSequenceGenerator) NextBatch(ctx context.Context, tenantID uint64, key string, n int) (uint64, error) {
+	if n <= 0 {
+		return 0, ErrInvalidSequenceBatch
+	}
+
+	result, err := config.GetRedis().Do(ctx, "INCRBY", fmt.Sprintf("sequence:%d:%s", tenantID, key), n)
+	if err != nil {
+		return 0, fmt.Errorf("分配序号失败: %v", err)
+	}
+	return result.Uint64() - uint64(n) + 1, nil
+}
+
+// memorySequenceGenerator 进程内序号源，用于测试
+type memorySequenceGenerator struct {
+	mu     sync.Mutex
+	values map[memorySequenceKey]uint64
+}
+
+type memorySequenceKey struct {
+	tenantID uint64
+	key      string
+}
+
+// NewMemorySequenceGenerator 创建进程内序号源
+func NewMemorySequenceGenerator() SequenceGenerator {
+	return &memorySequenceGenerator{values: make(map[memorySequenceKey]uint64)}
+}
+
+// Next 分配下一个序号
+func (s *memorySequenceGenerator) Next(ctx context.Context, tenantID uint64, key string) (uint64, error) {
+	return s.NextBatch(ctx, tenantID, key, 1)
+}
+
+// NextBatch 将序号增加 n，返回本次分配的第一个序号
+func (s *memorySequenceGenerator) NextBatch(ctx context.Context, tenantID uint64, key string, n int) (uint64, error) {
+	if n <= 0 {
+		return 0, ErrInvalidSequenceBatch
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := memorySequenceKey{tenantID: tenantID, key: key}
+	s.values[k] += uint64(n)
+	return s.values[k] - uint64(n) + 1, nil
+}
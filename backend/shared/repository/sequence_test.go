@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSequenceGenerator(t *testing.T) {
+	Convey("序号源测试", t, func() {
+		ctx := context.Background()
+		generator := NewMemorySequenceGenerator()
+
+		Convey("并发分配的序号严格递增且没有空缺", func() {
+			const workers, perWorker = 50, 200
+
+			var wg sync.WaitGroup
+			allocated := make([][]uint64, workers)
+			errs := make(chan error, workers)
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < perWorker; i++ {
+						value, err := generator.Next(ctx, 1, "order_number:ORD20250901")
+						if err != nil {
+							errs <- err
+							return
+						}
+						allocated[w] = append(allocated[w], value)
+					}
+				}(w)
+			}
+			wg.Wait()
+			close(errs)
+			So(<-errs, ShouldBeNil)
+
+			var all []uint64
+			for _, values := range allocated {
+				// 同一调用方先后分配的序号严格递增
+				for i := 1; i < len(values); i++ {
+					So(values[i], ShouldBeGreaterThan, values[i-1])
+				}
+				all = append(all, values...)
+			}
+			sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+			So(len(all), ShouldEqual, workers*perWorker)
+			for i, value := range all {
+				So(value, ShouldEqual, uint64(i+1))
+			}
+		})
+
+		Convey("并发批量分配的序号连续，与单个分配的序号合起来没有空缺和重复", func() {
+			const workers, rounds, batch = 20, 50, 5
+
+			var mu sync.Mutex
+			seen := make(map[uint64]int)
+			errs := make(chan error, workers)
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < rounds; i++ {
+						var values []uint64
+						if w%2 == 0 {
+							first, err := generator.NextBatch(ctx, 1, "invoice", batch)
+							if err != nil {
+								errs <- err
+								return
+							}
+							for k := 0; k < batch; k++ {
+								values = append(values, first+uint64(k))
+							}
+						} else {
+							value, err := generator.Next(ctx, 1, "invoice")
+							if err != nil {
+								errs <- err
+								return
+							}
+							values = append(values, value)
+						}
+
+						mu.Lock()
+						for _, value := range values {
+							seen[value]++
+						}
+						mu.Unlock()
+					}
+				}(w)
+			}
+			wg.Wait()
+			close(errs)
+			So(<-errs, ShouldBeNil)
+
+			total := workers/2*rounds*batch + workers/2*rounds
+			So(len(seen), ShouldEqual, total)
+			for value := uint64(1); value <= uint64(total); value++ {
+				So(seen[value], ShouldEqual, 1)
+			}
+		})
+
+		Convey("租户和序号名称分别递增", func() {
+			first, err := generator.Next(ctx, 1, "refund")
+			So(err, ShouldBeNil)
+			So(first, ShouldEqual, 1)
+
+			other, err := generator.Next(ctx, 2, "refund")
+			So(err, ShouldBeNil)
+			So(other, ShouldEqual, 1)
+
+			global, err := generator.Next(ctx, 0, "refund")
+			So(err, ShouldBeNil)
+			So(global, ShouldEqual, 1)
+
+			second, err := generator.NextBatch(ctx, 1, "refund", 3)
+			So(err, ShouldBeNil)
+			So(second, ShouldEqual, 2)
+
+			next, err := generator.Next(ctx, 1, "refund")
+			So(err, ShouldBeNil)
+			So(next, ShouldEqual, 5)
+		})
+
+		Convey("批量分配的序号数必须大于0", func() {
+			_, err := generator.NextBatch(ctx, 1, "refund", 0)
+			So(errors.Is(err, ErrInvalidSequenceBatch), ShouldBeTrue)
+		})
+
+		Convey("按前缀和位数格式化序号", func() {
+			format := types.SequenceFormat{Prefix: "RF", Width: 4}
+			number, err := format.Render(12)
+			So(err, ShouldBeNil)
+			So(number, ShouldEqual, "RF0012")
+
+			_, err = format.Render(10000)
+			So(errors.Is(err, types.ErrSequenceExhausted), ShouldBeTrue)
+
+			number, err = types.SequenceFormat{Prefix: "N"}.Render(123456)
+			So(err, ShouldBeNil)
+			So(number, ShouldEqual, "N123456")
+		})
+	})
+}
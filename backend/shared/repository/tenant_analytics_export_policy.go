@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// AnalyticsExportPolicyProvider 按租户获取分析数据定时导出配置，未配置时返回 nil
+type AnalyticsExportPolicyProvider func(ctx context.Context, tenantID uint64) (*types.AnalyticsExportPolicy, error)
+
+// NewTenantAnalyticsExportPolicyProvider 创建从租户配置读取分析数据导出配置的提供者
+func NewTenantAnalyticsExportPolicyProvider(tenantRepo ITenantRepository) AnalyticsExportPolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.AnalyticsExportPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.AnalyticsExport, nil
+	}
+}
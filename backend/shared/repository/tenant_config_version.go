@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// ITenantConfigVersionRepository 租户配置版本仓储接口。租户配置由平台管理员跨租户维护，按 tenantID 参数查询
+type ITenantConfigVersionRepository interface {
+	// Create 保存配置的新版本，版本号为租户最新版本加一
+	Create(ctx context.Context, version *types.TenantConfigVersion) error
+	// Latest 获取租户的最新配置版本，没有版本时返回 nil
+	Latest(ctx context.Context, tenantID uint64) (*types.TenantConfigVersion, error)
+}
+
+// tenantConfigVersionRepository 租户配置版本仓储实现
+type tenantConfigVersionRepository struct{}
+
+// NewTenantConfigVersionRepository 创建租户配置版本仓储实例
+func NewTenantConfigVersionRepository() ITenantConfigVersionRepository {
+	return &tenantConfigVersionRepository{}
+}
+
+// Create 保存配置的新版本，并发保存同一租户时由唯一索引保证版本号不重复
+func (r *tenantConfigVersionRepository) Create(ctx context.Context, version *types.TenantConfigVersion) error {
+	latest, err := g.DB().Model("tenant_config_versions").Ctx(ctx).
+		Where("tenant_id = ?", version.TenantID).
+		Max("version")
+	if err != nil {
+		return fmt.Errorf("查询租户配置版本失败: %v", err)
+	}
+
+	version.Version = int(latest) + 1
+	version.CreatedAt = time.Now()
+	id, err := g.DB().Model("tenant_config_versions").Ctx(ctx).InsertAndGetId(version)
+	if err != nil {
+		return fmt.Errorf("保存租户配置版本失败: %v", err)
+	}
+	version.ID = uint64(id)
+	return nil
+}
+
+// Latest 获取租户的最新配置版本
+func (r *tenantConfigVersionRepository) Latest(ctx context.Context, tenantID uint64) (*types.TenantConfigVersion, error) {
+	var version *types.TenantConfigVersion
+	err := g.DB().Model("tenant_config_versions").Ctx(ctx).
+		Where("tenant_id = ?", tenantID).
+		OrderDesc("version").
+		Limit(1).
+		Scan(&version)
+	if err != nil {
+		return nil, fmt.Errorf("查询租户配置版本失败: %v", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// DataRetentionPolicyProvider 按租户获取客户个人信息保留策略
+type DataRetentionPolicyProvider func(ctx context.Context, tenantID uint64) (*types.DataRetentionPolicy, error)
+
+// NewTenantDataRetentionPolicyProvider 创建从租户配置读取客户个人信息保留策略的提供者，未配置时返回 nil
+func NewTenantDataRetentionPolicyProvider(tenantRepo ITenantRepository) DataRetentionPolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.DataRetentionPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.DataRetention, nil
+	}
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gofromzero/mer-sys/backend/shared/audit"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// ErrCrossDatasourceQuery 同一事务中访问了其他数据源的租户数据
+var ErrCrossDatasourceQuery = errors.New("不能在同一事务中访问其他数据源的租户数据")
+
+// ErrTenantDatasourceNotConfigured 数据驻留配置中租户映射的数据库分组未定义
+var ErrTenantDatasourceNotConfigured = errors.New("租户映射的数据库分组未定义")
+
+// TenantDatasourceConfig 数据驻留配置：租户ID到数据库分组的映射，
+// 未映射的租户（以及没有租户上下文的跨租户任务）使用共享的 default 分组
+type TenantDatasourceConfig struct {
+	Tenants map[uint64]string
+}
+
+// Validate 校验映射的数据库分组均已在数据库配置中定义
+func (c *TenantDatasourceConfig) Validate() error {
+	for tenantID, group := range c.Tenants {
+		if gdb.GetConfig(group) == nil {
+			return fmt.Errorf("%w: 租户 %d 的数据库分组 %s", ErrTenantDatasourceNotConfigured, tenantID, group)
+		}
+	}
+	return nil
+}
+
+type datasourceKey struct{}
+
+var (
+	tenantDatasourceMutex  sync.RWMutex
+	tenantDatasourceConfig *TenantDatasourceConfig
+	// tenantDatasourceErr 首次使用时加载配置失败的原因，非空时拒绝所有租户数据访问
+	tenantDatasourceErr  error
+	tenantDatasourceOnce sync.Once
+)
+
+// SetTenantDatasourceConfig 设置租户数据源路由配置
+func SetTenantDatasourceConfig(config *TenantDatasourceConfig) {
+	tenantDatasourceMutex.Lock()
+	defer tenantDatasourceMutex.Unlock()
+
+	tenantDatasourceConfig = config
+	tenantDatasourceErr = nil
+}
+
+// LoadTenantDatasourceConfig 从配置文件 dataResidency.tenants 节点加载租户数据源路由配置并校验数据库分组
+func LoadTenantDatasourceConfig(ctx context.Context) (*TenantDatasourceConfig, error) {
+	config := &TenantDatasourceConfig{Tenants: make(map[uint64]string)}
+	// 没有配置文件或未配置数据驻留时所有租户使用 default 分组
+	v, err := g.Cfg().Get(ctx, "dataResidency.tenants")
+	if err != nil || v.IsEmpty() {
+		return config, nil
+	}
+	for tenant, group := range v.MapStrStr() {
+		tenantID := gconv.Uint64(tenant)
+		if tenantID == 0 || group == "" {
+			continue
+		}
+		config.Tenants[tenantID] = group
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// InitTenantDatasourceConfig 服务启动时加载租户数据源路由配置，映射了未定义的数据库分组时返回错误，
+// 由调用方拒绝启动，避免租户数据静默落入共享数据库
+func InitTenantDatasourceConfig(ctx context.Context) error {
+	config, err := LoadTenantDatasourceConfig(ctx)
+	if err != nil {
+		return err
+	}
+	SetTenantDatasourceConfig(config)
+	return nil
+}
+
+// getTenantDatasourceConfig 获取租户数据源路由配置，启动时未加载的在首次使用时加载；
+// 加载失败时返回错误，租户数据访问全部失败而不是落入共享数据库
+func getTenantDatasourceConfig() (*TenantDatasourceConfig, error) {
+	tenantDatasourceOnce.Do(func() {
+		tenantDatasourceMutex.Lock()
+		defer tenantDatasourceMutex.Unlock()
+		if tenantDatasourceConfig != nil {
+			return
+		}
+
+		ctx := context.Background()
+		config, err := LoadTenantDatasourceConfig(ctx)
+		if err != nil {
+			g.Log().Errorf(ctx, "加载数据驻留配置失败: %v", err)
+			tenantDatasourceErr = err
+			return
+		}
+		tenantDatasourceConfig = config
+	})
+
+	tenantDatasourceMutex.RLock()
+	defer tenantDatasourceMutex.RUnlock()
+	return tenantDatasourceConfig, tenantDatasourceErr
+}
+
+// TenantDatasource 返回租户数据所在的数据库分组，未映射时为 default 分组
+func TenantDatasource(tenantID uint64) string {
+	if config, _ := getTenantDatasourceConfig(); config != nil && tenantID > 0 {
+		if group, exists := config.Tenants[tenantID]; exists {
+			return group
+		}
+	}
+	return gdb.DefaultGroupName
+}
+
+// TenantDatasources 返回租户数据所在的全部数据库分组：default 分组在前，其余按名称排序。
+// 跨租户的系统任务需要逐个扫描，只查询 default 分组会遗漏数据驻留租户的数据
+func TenantDatasources() []string {
+	groups := []string{gdb.DefaultGroupName}
+	config, _ := getTenantDatasourceConfig()
+	if config == nil {
+		return groups
+	}
+
+	seen := map[string]bool{gdb.DefaultGroupName: true}
+	var residency []string
+	for _, group := range config.Tenants {
+		if !seen[group] {
+			seen[group] = true
+			residency = append(residency, group)
+		}
+	}
+	sort.Strings(residency)
+	return append(groups, residency...)
+}
+
+// TenantDB 返回上下文中租户数据所在的数据库；在 TenantTransaction 事务内访问其他数据源的租户时，
+// 返回的数据库不执行任何语句，所有操作返回 ErrCrossDatasourceQuery，防止同一事务的读写被静默拆分到多个数据源
+func TenantDB(ctx context.Context) gdb.DB {
+	return TenantDBFor(ctx, gconv.Uint64(ctx.Value("tenant_id")))
+}
+
+// TenantDBFor 返回指定租户数据所在的数据库，用于按参数而非上下文传入租户ID的查询
+func TenantDBFor(ctx context.Context, tenantID uint64) gdb.DB {
+	group, err := resolveDatasource(ctx, tenantID)
+	if err != nil {
+		return &crossDatasourceDB{DB: g.DB(TenantDatasource(tenantID)), err: err}
+	}
+	return g.DB(group)
+}
+
+// TenantTransaction 在上下文租户的数据源上开启事务，事务内的仓储操作只能访问同一数据源
+func TenantTransaction(ctx context.Context, fn func(ctx context.Context, tx gdb.TX) error) error {
+	group, err := resolveDatasource(ctx, gconv.Uint64(ctx.Value("tenant_id")))
+	if err != nil {
+		return err
+	}
+
+	ctx = context.WithValue(ctx, datasourceKey{}, group)
+	return g.DB(group).Transaction(ctx, fn)
+}
+
+// resolveDatasource 解析租户的数据源，并校验与事务绑定的数据源一致
+func resolveDatasource(ctx context.Context, tenantID uint64) (string, error) {
+	if _, err := getTenantDatasourceConfig(); err != nil {
+		return "", err
+	}
+	group := TenantDatasource(tenantID)
+
+	pinned, ok := ctx.Value(datasourceKey{}).(string)
+	if ok && pinned != group {
+		audit.LogSecurityViolation(ctx, tenantID, "cross_datasource_query",
+			"事务内访问其他数据源的租户数据", map[string]interface{}{
+				"transaction_datasource": pinned,
+				"tenant_datasource":      group,
+			})
+		return "", fmt.Errorf("%w: 事务数据源 %s，租户 %d 数据源 %s", ErrCrossDatasourceQuery, pinned, tenantID, group)
+	}
+	return group, nil
+}
+
+// crossDatasourceDB 事务内跨数据源访问时返回的数据库，保留链式调用，执行时返回解析数据源的错误
+type crossDatasourceDB struct {
+	gdb.DB
+	err error
+}
+
+// hook 模型执行查询和写入时直接返回错误，不访问数据库
+func (d *crossDatasourceDB) hook(model *gdb.Model) *gdb.Model {
+	return model.Hook(gdb.HookHandler{
+		Select: func(ctx context.Context, in *gdb.HookSelectInput) (gdb.Result, error) { return nil, d.err },
+		Insert: func(ctx context.Context, in *gdb.HookInsertInput) (sql.Result, error) { return nil, d.err },
+		Update: func(ctx context.Context, in *gdb.HookUpdateInput) (sql.Result, error) { return nil, d.err },
+		Delete: func(ctx context.Context, in *gdb.HookDeleteInput) (sql.Result, error) { return nil, d.err },
+	})
+}
+
+func (d *crossDatasourceDB) Model(tableNameOrStruct ...interface{}) *gdb.Model {
+	return d.hook(d.DB.Model(tableNameOrStruct...))
+}
+
+func (d *crossDatasourceDB) Raw(rawSql string, args ...interface{}) *gdb.Model {
+	return d.hook(d.DB.Raw(rawSql, args...))
+}
+
+func (d *crossDatasourceDB) With(objects ...interface{}) *gdb.Model {
+	return d.hook(d.DB.With(objects...))
+}
+
+func (d *crossDatasourceDB) Union(unions ...*gdb.Model) *gdb.Model {
+	return d.hook(d.DB.Union(unions...))
+}
+
+func (d *crossDatasourceDB) UnionAll(unions ...*gdb.Model) *gdb.Model {
+	return d.hook(d.DB.UnionAll(unions...))
+}
+
+func (d *crossDatasourceDB) Ctx(ctx context.Context) gdb.DB {
+	return &crossDatasourceDB{DB: d.DB.Ctx(ctx), err: d.err}
+}
+
+func (d *crossDatasourceDB) Query(ctx context.Context, sql string, args ...interface{}) (gdb.Result, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) Exec(ctx context.Context, sql string, args ...interface{}) (sql.Result, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) Insert(ctx context.Context, table string, data interface{}, batch ...int) (sql.Result, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) InsertIgnore(ctx context.Context, table string, data interface{}, batch ...int) (sql.Result, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) InsertAndGetId(ctx context.Context, table string, data interface{}, batch ...int) (int64, error) {
+	return 0, d.err
+}
+
+func (d *crossDatasourceDB) Replace(ctx context.Context, table string, data interface{}, batch ...int) (sql.Result, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) Save(ctx context.Context, table string, data interface{}, batch ...int) (sql.Result, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) Update(ctx context.Context, table string, data interface{}, condition interface{}, args ...interface{}) (sql.Result, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) Delete(ctx context.Context, table string, condition interface{}, args ...interface{}) (sql.Result, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) GetAll(ctx context.Context, sql string, args ...interface{}) (gdb.Result, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) GetOne(ctx context.Context, sql string, args ...interface{}) (gdb.Record, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) GetValue(ctx context.Context, sql string, args ...interface{}) (gdb.Value, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) GetArray(ctx context.Context, sql string, args ...interface{}) ([]gdb.Value, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) GetCount(ctx context.Context, sql string, args ...interface{}) (int, error) {
+	return 0, d.err
+}
+
+func (d *crossDatasourceDB) GetScan(ctx context.Context, objPointer interface{}, sql string, args ...interface{}) error {
+	return d.err
+}
+
+func (d *crossDatasourceDB) Begin(ctx context.Context) (gdb.TX, error) {
+	return nil, d.err
+}
+
+func (d *crossDatasourceDB) Transaction(ctx context.Context, f func(ctx context.Context, tx gdb.TX) error) error {
+	return d.err
+}
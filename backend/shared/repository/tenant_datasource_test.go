@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// residencyStore 内存数据源，按数据库分组记录写入的行与执行的语句
+type residencyStore struct {
+	mu         sync.Mutex
+	rows       map[string][]map[string]interface{}
+	statements map[string][]string
+}
+
+var testResidencyStore = &residencyStore{
+	rows:       make(map[string][]map[string]interface{}),
+	statements: make(map[string][]string),
+}
+
+func (s *residencyStore) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows = make(map[string][]map[string]interface{})
+	s.statements = make(map[string][]string)
+}
+
+func (s *residencyStore) record(group, query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statements[group] = append(s.statements[group], query)
+}
+
+func (s *residencyStore) tenantRows(group string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.rows[group]...)
+}
+
+// residencyConn 内存数据源连接：INSERT 写入当前分组，SELECT 只返回当前分组的数据
+type residencyConn struct {
+	group string
+}
+
+func (c *residencyConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *residencyConn) Close() error { return nil }
+
+func (c *residencyConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *residencyConn) Commit() error { return nil }
+
+func (c *residencyConn) Rollback() error { return nil }
+
+func (c *residencyConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	testResidencyStore.record(c.group, query)
+	if !strings.HasPrefix(query, "INSERT") {
+		return driver.RowsAffected(0), nil
+	}
+
+	// INSERT INTO `orders`(`name`,`tenant_id`) VALUES(?,?)
+	columns := strings.Split(query[strings.Index(query, "(")+1:strings.Index(query, ")")], ",")
+	row := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		row[strings.Trim(column, "`")] = args[i].Value
+	}
+
+	testResidencyStore.mu.Lock()
+	defer testResidencyStore.mu.Unlock()
+	testResidencyStore.rows[c.group] = append(testResidencyStore.rows[c.group], row)
+	return residencyResult(len(testResidencyStore.rows[c.group])), nil
+}
+
+// residencyResult 写入结果，自增ID为当前分组的行数
+type residencyResult int64
+
+func (r residencyResult) LastInsertId() (int64, error) { return int64(r), nil }
+
+func (r residencyResult) RowsAffected() (int64, error) { return 1, nil }
+
+func (c *residencyConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	testResidencyStore.record(c.group, query)
+	return &residencyRows{rows: testResidencyStore.tenantRows(c.group)}, nil
+}
+
+type residencyRows struct {
+	rows []map[string]interface{}
+}
+
+func (r *residencyRows) Columns() []string { return []string{"tenant_id", "name"} }
+
+func (r *residencyRows) Close() error { return nil }
+
+func (r *residencyRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	dest[0], dest[1] = r.rows[0]["tenant_id"], r.rows[0]["name"]
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type residencySQLDriver struct{}
+
+func (residencySQLDriver) Open(name string) (driver.Conn, error) {
+	return &residencyConn{group: name}, nil
+}
+
+// residencyDBDriver gdb 驱动，连接到以分组名命名的内存数据源
+type residencyDBDriver struct {
+	*gdb.Core
+}
+
+func (d *residencyDBDriver) New(core *gdb.Core, node *gdb.ConfigNode) (gdb.DB, error) {
+	return &residencyDBDriver{Core: core}, nil
+}
+
+func (d *residencyDBDriver) Open(config *gdb.ConfigNode) (*sql.DB, error) {
+	return sql.Open("residency_test", config.Name)
+}
+
+func (d *residencyDBDriver) GetChars() (string, string) { return "`", "`" }
+
+func (d *residencyDBDriver) Tables(ctx context.Context, schema ...string) ([]string, error) {
+	return []string{"orders"}, nil
+}
+
+func (d *residencyDBDriver) TableFields(ctx context.Context, table string, schema ...string) (map[string]*gdb.TableField, error) {
+	return map[string]*gdb.TableField{
+		"tenant_id": {Index: 0, Name: "tenant_id", Type: "bigint unsigned"},
+		"name":      {Index: 1, Name: "name", Type: "varchar(64)"},
+	}, nil
+}
+
+var registerResidencyDriverOnce sync.Once
+
+// setupResidencyDatasources 注册共享数据库与两个驻留数据库分组
+func setupResidencyDatasources() {
+	registerResidencyDriverOnce.Do(func() {
+		sql.Register("residency_test", residencySQLDriver{})
+		if err := gdb.Register("residency_test", &residencyDBDriver{}); err != nil {
+			panic(err)
+		}
+		for _, group := range []string{gdb.DefaultGroupName, "residency_a", "residency_b"} {
+			if err := gdb.AddConfigNode(group, gdb.ConfigNode{Type: "residency_test", Name: group}); err != nil {
+				panic(err)
+			}
+		}
+	})
+	testResidencyStore.reset()
+}
+
+func TestTenantDatasource(t *testing.T) {
+	Convey("租户数据源路由测试", t, func() {
+		setupResidencyDatasources()
+		SetTenantDatasourceConfig(&TenantDatasourceConfig{
+			Tenants: map[uint64]string{1: "residency_a", 2: "residency_b"},
+		})
+		defer SetTenantDatasourceConfig(nil)
+
+		tenant1 := context.WithValue(context.Background(), "tenant_id", uint64(1))
+		tenant2 := context.WithValue(context.Background(), "tenant_id", uint64(2))
+		tenant3 := context.WithValue(context.Background(), "tenant_id", uint64(3))
+		repo := &BaseRepository{db: TenantDB(context.Background()), tableName: "orders"}
+
+		Convey("映射的租户使用配置的数据源，未映射的租户使用共享数据库", func() {
+			So(TenantDB(tenant1).GetGroup(), ShouldEqual, "residency_a")
+			So(TenantDB(tenant2).GetGroup(), ShouldEqual, "residency_b")
+			So(TenantDB(tenant3).GetGroup(), ShouldEqual, gdb.DefaultGroupName)
+			So(TenantDB(context.Background()).GetGroup(), ShouldEqual, gdb.DefaultGroupName)
+			So(TenantDBFor(context.Background(), 2).GetGroup(), ShouldEqual, "residency_b")
+		})
+
+		Convey("两个租户的读写分别落在各自的数据源", func() {
+			_, err := repo.Insert(tenant1, map[string]interface{}{"name": "订单A"})
+			So(err, ShouldBeNil)
+			_, err = repo.Insert(tenant2, map[string]interface{}{"name": "订单B"})
+			So(err, ShouldBeNil)
+
+			So(testResidencyStore.tenantRows("residency_a"), ShouldHaveLength, 1)
+			So(testResidencyStore.tenantRows("residency_a")[0]["tenant_id"], ShouldEqual, 1)
+			So(testResidencyStore.tenantRows("residency_b"), ShouldHaveLength, 1)
+			So(testResidencyStore.tenantRows("residency_b")[0]["tenant_id"], ShouldEqual, 2)
+			So(testResidencyStore.tenantRows(gdb.DefaultGroupName), ShouldBeEmpty)
+
+			result, err := repo.FindAll(tenant1, nil)
+			So(err, ShouldBeNil)
+			So(result, ShouldHaveLength, 1)
+			So(result[0]["name"].String(), ShouldEqual, "订单A")
+
+			result, err = repo.FindAll(tenant2, nil)
+			So(err, ShouldBeNil)
+			So(result, ShouldHaveLength, 1)
+			So(result[0]["name"].String(), ShouldEqual, "订单B")
+
+			_, err = repo.Insert(tenant3, map[string]interface{}{"name": "订单C"})
+			So(err, ShouldBeNil)
+			So(testResidencyStore.tenantRows(gdb.DefaultGroupName), ShouldHaveLength, 1)
+		})
+
+		Convey("事务内不能访问其他数据源的租户数据", func() {
+			err := TenantTransaction(tenant1, func(ctx context.Context, tx gdb.TX) error {
+				if _, err := repo.Insert(ctx, map[string]interface{}{"name": "订单A"}); err != nil {
+					return err
+				}
+
+				crossCtx := context.WithValue(ctx, "tenant_id", uint64(2))
+				_, err := repo.Insert(crossCtx, map[string]interface{}{"name": "订单B"})
+				So(errors.Is(err, ErrCrossDatasourceQuery), ShouldBeTrue)
+				_, err = repo.FindAll(crossCtx, nil)
+				So(errors.Is(err, ErrCrossDatasourceQuery), ShouldBeTrue)
+				_, err = TenantDB(crossCtx).Model("orders").Ctx(crossCtx).Insert(map[string]interface{}{"name": "订单B"})
+				So(errors.Is(err, ErrCrossDatasourceQuery), ShouldBeTrue)
+				_, err = TenantDB(crossCtx).Ctx(crossCtx).Raw("SELECT * FROM orders").All()
+				So(errors.Is(err, ErrCrossDatasourceQuery), ShouldBeTrue)
+				_, err = TenantDBFor(ctx, 2).Exec(ctx, "DELETE FROM orders")
+				So(errors.Is(err, ErrCrossDatasourceQuery), ShouldBeTrue)
+				return nil
+			})
+			So(err, ShouldBeNil)
+
+			So(testResidencyStore.tenantRows("residency_a"), ShouldHaveLength, 1)
+			So(testResidencyStore.tenantRows("residency_b"), ShouldBeEmpty)
+			So(testResidencyStore.statements["residency_b"], ShouldBeEmpty)
+		})
+
+		Convey("全局序号保存在共享数据库，租户序号随租户数据驻留", func() {
+			sequences := NewDBSequenceGenerator()
+			err := TenantTransaction(tenant1, func(ctx context.Context, tx gdb.TX) error {
+				_, err := sequences.Next(ctx, 0, "order_number")
+				So(err, ShouldBeNil)
+				_, err = sequences.Next(ctx, 1, "refund_number")
+				So(err, ShouldBeNil)
+				return nil
+			})
+			So(err, ShouldBeNil)
+
+			_, err = sequences.Next(tenant2, 0, "order_number")
+			So(err, ShouldBeNil)
+
+			So(testResidencyStore.statements[gdb.DefaultGroupName], ShouldHaveLength, 2)
+			So(testResidencyStore.statements["residency_a"], ShouldHaveLength, 1)
+			So(testResidencyStore.statements["residency_b"], ShouldBeEmpty)
+		})
+
+		Convey("跨租户的系统任务扫描所有数据源", func() {
+			So(TenantDatasources(), ShouldResemble, []string{gdb.DefaultGroupName, "residency_a", "residency_b"})
+
+			_, err := repo.Insert(tenant2, map[string]interface{}{"name": "订单B"})
+			So(err, ShouldBeNil)
+
+			events, err := NewOutboxRepository().FetchPending(context.Background(), 10)
+			So(err, ShouldBeNil)
+			So(events, ShouldHaveLength, 1)
+			So(events[0].TenantID, ShouldEqual, 2)
+			for _, group := range TenantDatasources() {
+				So(strings.Join(testResidencyStore.statements[group], "\n"), ShouldContainSubstring, "outbox_events")
+			}
+		})
+
+		Convey("映射到未定义数据库分组的配置校验失败", func() {
+			config := &TenantDatasourceConfig{Tenants: map[uint64]string{1: "residency_a", 3: "residency_missing"}}
+			So(errors.Is(config.Validate(), ErrTenantDatasourceNotConfigured), ShouldBeTrue)
+		})
+
+		Convey("配置加载失败时拒绝租户数据访问而不是使用共享数据库", func() {
+			tenantDatasourceMutex.Lock()
+			tenantDatasourceErr = ErrTenantDatasourceNotConfigured
+			tenantDatasourceMutex.Unlock()
+
+			_, err := repo.Insert(tenant3, map[string]interface{}{"name": "订单C"})
+			So(errors.Is(err, ErrTenantDatasourceNotConfigured), ShouldBeTrue)
+			So(testResidencyStore.statements[gdb.DefaultGroupName], ShouldBeEmpty)
+		})
+
+		Convey("租户开通的读写都在租户的数据源事务中", func() {
+			provisioning := NewTenantProvisioningRepository()
+			err := provisioning.WithTransaction(tenant1, 2, func(ctx context.Context, tx gdb.TX) error {
+				tenant, err := provisioning.LockTenant(ctx, 2)
+				So(err, ShouldBeNil)
+				So(tenant, ShouldBeNil)
+				So(provisioning.AssignRole(ctx, 11, 2, types.RoleTenantAdmin, 1), ShouldBeNil)
+				_, err = provisioning.CreateUser(ctx, &types.User{Username: "admin", TenantID: 2})
+				So(err, ShouldBeNil)
+				So(provisioning.CreateTimeoutConfig(ctx, types.DefaultTenantTimeoutConfig(2)), ShouldBeNil)
+
+				// 其他数据源的租户不能混入同一开通事务
+				_, err = provisioning.CreateUser(ctx, &types.User{Username: "admin", TenantID: 1})
+				So(errors.Is(err, ErrCrossDatasourceQuery), ShouldBeTrue)
+				return nil
+			})
+			So(err, ShouldBeNil)
+
+			So(testResidencyStore.statements["residency_b"], ShouldNotBeEmpty)
+			So(testResidencyStore.statements["residency_a"], ShouldBeEmpty)
+			So(testResidencyStore.statements[gdb.DefaultGroupName], ShouldBeEmpty)
+		})
+	})
+}
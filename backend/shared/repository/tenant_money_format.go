@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// MoneyFormatProvider 按租户获取金额显示格式
+type MoneyFormatProvider func(ctx context.Context, tenantID uint64) (*types.MoneyFormatPolicy, error)
+
+// NewTenantMoneyFormatProvider 创建从租户配置读取金额显示格式的提供者
+func NewTenantMoneyFormatProvider(tenantRepo ITenantRepository) MoneyFormatProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.MoneyFormatPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.MoneyFormat, nil
+	}
+}
+
+// Resolve 获取租户生效的金额显示格式，提供者为空、未配置或读取失败时使用默认格式
+func (p MoneyFormatProvider) Resolve(ctx context.Context, tenantID uint64) *types.MoneyFormatPolicy {
+	if p == nil {
+		return types.DefaultMoneyFormatPolicy()
+	}
+
+	policy, err := p(ctx, tenantID)
+	if err != nil {
+		g.Log().Warningf(ctx, "获取租户 %d 金额显示格式失败，使用默认格式: %v", tenantID, err)
+		return types.DefaultMoneyFormatPolicy()
+	}
+	if policy == nil {
+		return types.DefaultMoneyFormatPolicy()
+	}
+	return policy
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// OrderReviewPolicyProvider 按租户获取下单风险审核规则，未配置时返回 nil
+type OrderReviewPolicyProvider func(ctx context.Context, tenantID uint64) (*types.OrderReviewPolicy, error)
+
+// NewTenantOrderReviewPolicyProvider 创建从租户配置读取下单风险审核规则的提供者
+func NewTenantOrderReviewPolicyProvider(tenantRepo ITenantRepository) OrderReviewPolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.OrderReviewPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.OrderReview, nil
+	}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// OrderStatusLabelProvider 按租户获取面向客户的订单状态显示配置，未配置时返回 nil
+type OrderStatusLabelProvider func(ctx context.Context, tenantID uint64) (*types.OrderStatusLabelPolicy, error)
+
+// NewTenantOrderStatusLabelProvider 创建从租户配置读取订单状态显示配置的提供者
+func NewTenantOrderStatusLabelProvider(tenantRepo ITenantRepository) OrderStatusLabelProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.OrderStatusLabelPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.OrderStatusLabels, nil
+	}
+}
+
+// Resolve 获取租户的订单状态显示配置，提供者为空、未配置或读取失败时返回 nil（使用内置名称）
+func (p OrderStatusLabelProvider) Resolve(ctx context.Context, tenantID uint64) *types.OrderStatusLabelPolicy {
+	if p == nil {
+		return nil
+	}
+
+	policy, err := p(ctx, tenantID)
+	if err != nil {
+		g.Log().Warningf(ctx, "获取租户 %d 订单状态显示配置失败，使用内置名称: %v", tenantID, err)
+		return nil
+	}
+	return policy
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// PasswordPolicyProvider 按租户获取密码策略
+type PasswordPolicyProvider func(ctx context.Context, tenantID uint64) (*types.PasswordPolicy, error)
+
+// NewTenantPasswordPolicyProvider 创建从租户配置读取密码策略的提供者
+func NewTenantPasswordPolicyProvider(tenantRepo ITenantRepository) PasswordPolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.PasswordPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.PasswordPolicy, nil
+	}
+}
+
+// Resolve 获取租户生效的密码策略，提供者为空、未配置或读取失败时使用默认策略
+func (p PasswordPolicyProvider) Resolve(ctx context.Context, tenantID uint64) *types.PasswordPolicy {
+	if p == nil {
+		return types.DefaultPasswordPolicy()
+	}
+
+	policy, err := p(ctx, tenantID)
+	if err != nil {
+		g.Log().Warningf(ctx, "获取租户 %d 密码策略失败，使用默认策略: %v", tenantID, err)
+		return types.DefaultPasswordPolicy()
+	}
+	if policy == nil {
+		return types.DefaultPasswordPolicy()
+	}
+	return policy
+}
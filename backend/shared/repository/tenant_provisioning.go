@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// ITenantProvisioningRepository 租户开通仓储接口。
+// 各方法使用 ctx 中的事务，在 WithTransaction 内调用时随事务一起提交或回滚
+type ITenantProvisioningRepository interface {
+	// 在租户数据所在的数据源上执行开通事务，新建租户尚未分配ID时传 0，使用共享数据库
+	WithTransaction(ctx context.Context, tenantID uint64, fn func(ctx context.Context, tx gdb.TX) error) error
+	CreateTenant(ctx context.Context, tenant *types.Tenant) (uint64, error)
+	// 锁定租户记录，避免并发开通同一租户
+	LockTenant(ctx context.Context, tenantID uint64) (*types.Tenant, error)
+	UpdateTenantConfig(ctx context.Context, tenantID uint64, config string) error
+	GetDefaultTimeoutConfig(ctx context.Context, tenantID uint64) (*types.OrderTimeoutConfig, error)
+	CreateTimeoutConfig(ctx context.Context, config *types.OrderTimeoutConfig) error
+	GetUserByUsername(ctx context.Context, tenantID uint64, username string) (*types.User, error)
+	CreateUser(ctx context.Context, user *types.User) (uint64, error)
+	GetUserRoles(ctx context.Context, userID, tenantID uint64) ([]types.RoleType, error)
+	AssignRole(ctx context.Context, userID, tenantID uint64, roleType types.RoleType, grantedBy uint64) error
+}
+
+// tenantProvisioningRepository 租户开通仓储实现
+type tenantProvisioningRepository struct {
+	roleRepo RoleRepository
+}
+
+// NewTenantProvisioningRepository 创建租户开通仓储实例
+func NewTenantProvisioningRepository() ITenantProvisioningRepository {
+	return &tenantProvisioningRepository{
+		roleRepo: NewRoleRepository(),
+	}
+}
+
+// WithTransaction 在租户的数据源上开启事务执行开通操作，租户、用户、超时配置和角色写入同一数据源，
+// 事务内访问其他数据源时返回 ErrCrossDatasourceQuery 并整体回滚
+func (r *tenantProvisioningRepository) WithTransaction(ctx context.Context, tenantID uint64, fn func(ctx context.Context, tx gdb.TX) error) error {
+	return TenantTransaction(context.WithValue(ctx, "tenant_id", tenantID), fn)
+}
+
+// CreateTenant 创建租户记录，新租户尚未分配ID，写入共享数据库
+func (r *tenantProvisioningRepository) CreateTenant(ctx context.Context, tenant *types.Tenant) (uint64, error) {
+	id, err := TenantDBFor(ctx, tenant.ID).Model("tenants").Ctx(ctx).InsertAndGetId(tenant)
+	if err != nil {
+		return 0, fmt.Errorf("创建租户失败: %w", err)
+	}
+	return uint64(id), nil
+}
+
+// LockTenant 加锁读取租户记录，不存在时返回 nil
+func (r *tenantProvisioningRepository) LockTenant(ctx context.Context, tenantID uint64) (*types.Tenant, error) {
+	var tenant *types.Tenant
+	err := TenantDBFor(ctx, tenantID).Model("tenants").Ctx(ctx).
+		Where("id", tenantID).
+		LockUpdate().
+		Scan(&tenant)
+	if err != nil {
+		return nil, fmt.Errorf("查询租户失败: %w", err)
+	}
+	return tenant, nil
+}
+
+// UpdateTenantConfig 更新租户配置
+func (r *tenantProvisioningRepository) UpdateTenantConfig(ctx context.Context, tenantID uint64, config string) error {
+	_, err := TenantDBFor(ctx, tenantID).Model("tenants").Ctx(ctx).
+		Where("id", tenantID).
+		Update(g.Map{
+			"config":     config,
+			"updated_at": gtime.Now(),
+		})
+	if err != nil {
+		return fmt.Errorf("更新租户配置失败: %w", err)
+	}
+	return nil
+}
+
+// GetDefaultTimeoutConfig 获取租户级（非商户级）订单超时配置，不存在时返回 nil
+func (r *tenantProvisioningRepository) GetDefaultTimeoutConfig(ctx context.Context, tenantID uint64) (*types.OrderTimeoutConfig, error) {
+	var config *types.OrderTimeoutConfig
+	err := TenantDBFor(ctx, tenantID).Model("order_timeout_configs").Ctx(ctx).
+		Where("tenant_id = ? AND merchant_id IS NULL", tenantID).
+		Scan(&config)
+	if err != nil {
+		return nil, fmt.Errorf("查询租户超时配置失败: %w", err)
+	}
+	return config, nil
+}
+
+// CreateTimeoutConfig 创建订单超时配置
+func (r *tenantProvisioningRepository) CreateTimeoutConfig(ctx context.Context, config *types.OrderTimeoutConfig) error {
+	_, err := TenantDBFor(ctx, config.TenantID).Model("order_timeout_configs").Ctx(ctx).Insert(g.Map{
+		"tenant_id":                 config.TenantID,
+		"merchant_id":               config.MerchantID,
+		"payment_timeout_minutes":   config.PaymentTimeoutMinutes,
+		"processing_timeout_hours":  config.ProcessingTimeoutHours,
+		"auto_complete_enabled":     config.AutoCompleteEnabled,
+		"auto_complete_after_hours": config.AutoCompleteAfterHours,
+		"reservation_hold_minutes":  config.ReservationHoldMinutes,
+		"created_at":                gtime.Now(),
+		"updated_at":                gtime.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("创建租户超时配置失败: %w", err)
+	}
+	return nil
+}
+
+// GetUserByUsername 按用户名查找租户下的用户，不存在时返回 nil
+func (r *tenantProvisioningRepository) GetUserByUsername(ctx context.Context, tenantID uint64, username string) (*types.User, error) {
+	var user *types.User
+	err := TenantDBFor(ctx, tenantID).Model("users").Ctx(ctx).
+		Where("tenant_id = ? AND username = ?", tenantID, username).
+		Scan(&user)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	return user, nil
+}
+
+// CreateUser 创建用户
+func (r *tenantProvisioningRepository) CreateUser(ctx context.Context, user *types.User) (uint64, error) {
+	id, err := TenantDBFor(ctx, user.TenantID).Model("users").Ctx(ctx).InsertAndGetId(g.Map{
+		"uuid":          user.UUID,
+		"username":      user.Username,
+		"email":         user.Email,
+		"password_hash": user.PasswordHash,
+		"tenant_id":     user.TenantID,
+		"status":        user.Status,
+		"created_at":    gtime.Now(),
+		"updated_at":    gtime.Now(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("创建用户失败: %w", err)
+	}
+	return uint64(id), nil
+}
+
+// GetUserRoles 获取用户在租户下的有效角色
+func (r *tenantProvisioningRepository) GetUserRoles(ctx context.Context, userID, tenantID uint64) ([]types.RoleType, error) {
+	return r.roleRepo.GetUserRoles(ctx, userID, tenantID)
+}
+
+// AssignRole 为用户分配角色
+func (r *tenantProvisioningRepository) AssignRole(ctx context.Context, userID, tenantID uint64, roleType types.RoleType, grantedBy uint64) error {
+	return r.roleRepo.AssignRole(ctx, userID, tenantID, roleType, grantedBy)
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// ITenantQuotaRepository 租户配额用量仓储接口
+type ITenantQuotaRepository interface {
+	// 统计租户当前占用配额的资源数，已停用的用户和商户不占用配额
+	CountUsage(ctx context.Context, tenantID uint64, resource types.TenantQuotaResource) (int, error)
+	// 获取资源最近一次检查时的用量级别，从未检查过时返回 nil
+	GetWarning(ctx context.Context, tenantID uint64, resource types.TenantQuotaResource) (*types.TenantQuotaWarningState, error)
+	SaveWarning(ctx context.Context, warning *types.TenantQuotaWarningState) error
+}
+
+// TenantQuotaRepository 租户配额用量数据访问层
+type TenantQuotaRepository struct {
+	*BaseRepository
+}
+
+// NewTenantQuotaRepository 创建租户配额用量仓库实例
+func NewTenantQuotaRepository() ITenantQuotaRepository {
+	return &TenantQuotaRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// CountUsage 统计租户当前占用配额的资源数
+func (r *TenantQuotaRepository) CountUsage(ctx context.Context, tenantID uint64, resource types.TenantQuotaResource) (int, error) {
+	var table, deactivated string
+	switch resource {
+	case types.TenantQuotaUsers:
+		table, deactivated = "users", string(types.UserStatusDeactivated)
+	case types.TenantQuotaMerchants:
+		table, deactivated = "merchants", string(types.MerchantStatusDeactivated)
+	default:
+		return 0, fmt.Errorf("不支持的配额资源: %s", resource)
+	}
+
+	count, err := TenantDBFor(ctx, tenantID).Model(table).
+		Ctx(ctx).
+		Where("tenant_id = ? AND status != ?", tenantID, deactivated).
+		Count()
+	if err != nil {
+		return 0, fmt.Errorf("统计租户%s失败: %v", resource.DisplayName(), err)
+	}
+	return count, nil
+}
+
+// GetWarning 获取资源最近一次检查时的用量级别
+func (r *TenantQuotaRepository) GetWarning(ctx context.Context, tenantID uint64, resource types.TenantQuotaResource) (*types.TenantQuotaWarningState, error) {
+	var warning *types.TenantQuotaWarningState
+	err := TenantDBFor(ctx, tenantID).Model("tenant_quota_warnings").
+		Ctx(ctx).
+		Where("tenant_id = ? AND resource = ?", tenantID, resource).
+		Scan(&warning)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询租户配额用量级别失败: %v", err)
+	}
+	return warning, nil
+}
+
+// SaveWarning 保存资源本次检查的用量级别，已存在时覆盖
+func (r *TenantQuotaRepository) SaveWarning(ctx context.Context, warning *types.TenantQuotaWarningState) error {
+	_, err := TenantDBFor(ctx, warning.TenantID).Model("tenant_quota_warnings").
+		Ctx(ctx).
+		Data(g.Map{
+			"tenant_id":   warning.TenantID,
+			"resource":    warning.Resource,
+			"level":       warning.Level,
+			"used":        warning.Used,
+			"quota_limit": warning.Limit,
+			"crossed_at":  warning.CrossedAt,
+			"updated_at":  warning.UpdatedAt,
+		}).
+		Save()
+	if err != nil {
+		return fmt.Errorf("保存租户配额用量级别失败: %v", err)
+	}
+	return nil
+}
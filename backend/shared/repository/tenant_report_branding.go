@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// ReportBrandingProvider 按租户获取报表品牌配置
+type ReportBrandingProvider func(ctx context.Context, tenantID uint64) (*types.ReportBranding, error)
+
+// NewTenantReportBrandingProvider 创建从租户配置读取报表品牌的提供者
+func NewTenantReportBrandingProvider(tenantRepo ITenantRepository) ReportBrandingProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.ReportBranding, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.ReportBranding, nil
+	}
+}
+
+// Resolve 获取租户生效的报表品牌，未配置的字段使用默认品牌，提供者为空或读取失败时使用默认品牌
+func (p ReportBrandingProvider) Resolve(ctx context.Context, tenantID uint64) *types.ReportBranding {
+	if p == nil {
+		return types.DefaultReportBranding()
+	}
+
+	branding, err := p(ctx, tenantID)
+	if err != nil {
+		g.Log().Warningf(ctx, "获取租户 %d 报表品牌失败，使用默认品牌: %v", tenantID, err)
+		return types.DefaultReportBranding()
+	}
+	return types.ResolveReportBranding(branding)
+}
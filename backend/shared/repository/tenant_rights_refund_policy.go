@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// RightsRefundPolicyProvider 按租户获取订单退款时的权益退还策略，未配置时返回 nil
+type RightsRefundPolicyProvider func(ctx context.Context, tenantID uint64) (*types.OrderRightsRefundPolicy, error)
+
+// NewTenantRightsRefundPolicyProvider 创建从租户配置读取权益退还策略的提供者
+func NewTenantRightsRefundPolicyProvider(tenantRepo ITenantRepository) RightsRefundPolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.OrderRightsRefundPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.RightsRefund, nil
+	}
+}
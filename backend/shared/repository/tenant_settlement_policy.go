@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// SettlementPolicyProvider 按租户获取商户结算的手续费规则，未配置时返回 nil
+type SettlementPolicyProvider func(ctx context.Context, tenantID uint64) (*types.SettlementPolicy, error)
+
+// NewTenantSettlementPolicyProvider 创建从租户配置读取结算规则的提供者
+func NewTenantSettlementPolicyProvider(tenantRepo ITenantRepository) SettlementPolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.SettlementPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.Settlement, nil
+	}
+}
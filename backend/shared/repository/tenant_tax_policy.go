@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+)
+
+// TaxPolicyProvider 按租户获取订单税费策略，未配置时返回 nil
+type TaxPolicyProvider func(ctx context.Context, tenantID uint64) (*types.TaxPolicy, error)
+
+// NewTenantTaxPolicyProvider 创建从租户配置读取订单税费策略的提供者
+func NewTenantTaxPolicyProvider(tenantRepo ITenantRepository) TaxPolicyProvider {
+	return func(ctx context.Context, tenantID uint64) (*types.TaxPolicy, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("获取租户信息失败: %w", err)
+		}
+
+		if tenant == nil || tenant.Config == "" {
+			return nil, nil
+		}
+
+		var config types.TenantConfig
+		if err := json.Unmarshal([]byte(tenant.Config), &config); err != nil {
+			return nil, fmt.Errorf("解析租户配置失败: %w", err)
+		}
+
+		return config.Tax, nil
+	}
+}
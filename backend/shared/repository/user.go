@@ -17,8 +17,11 @@ type UserRepository struct {
 
 // NewUserRepository 创建用户仓储实例
 func NewUserRepository() *UserRepository {
+	baseRepo := NewBaseRepository()
+	baseRepo.tableName = "users"
+
 	return &UserRepository{
-		BaseRepository: NewBaseRepository(),
+		BaseRepository: baseRepo,
 	}
 }
 
@@ -132,8 +135,12 @@ func (r *UserRepository) Create(ctx context.Context, user *types.User) error {
 	user.TenantID = tenantID
 
 	// 插入用户数据
-	_, err = r.Insert(ctx, user)
-	return err
+	id, err := r.InsertAndGetId(ctx, user)
+	if err != nil {
+		return err
+	}
+	user.ID = uint64(id)
+	return nil
 }
 
 // UpdateByID 根据ID更新用户（自动添加租户隔离）
@@ -284,9 +291,22 @@ func (r *UserRepository) UpdateLastLoginTime(ctx context.Context, userID uint64,
 
 // UpdatePassword 更新用户密码
 func (r *UserRepository) UpdatePassword(ctx context.Context, userID uint64, passwordHash string) error {
+	now := gtime.Now()
 	_, err := r.Update(ctx, gdb.Map{
-		"password_hash": passwordHash,
-		"updated_at":    gtime.Now(),
+		"password_hash":       passwordHash,
+		"password_changed_at": now,
+		"updated_at":          now,
+	}, "id", userID)
+	return err
+}
+
+// UpdateMarketingOptOut 更新用户是否退订营销信息
+func (r *UserRepository) UpdateMarketingOptOut(ctx context.Context, userID uint64, optOut bool) error {
+	now := gtime.Now()
+	_, err := r.Update(ctx, gdb.Map{
+		"marketing_opt_out":               optOut,
+		"marketing_preference_updated_at": now,
+		"updated_at":                      now,
 	}, "id", userID)
 	return err
 }
@@ -294,7 +314,7 @@ func (r *UserRepository) UpdatePassword(ctx context.Context, userID uint64, pass
 // GetUserRoles 获取用户角色列表
 func (r *UserRepository) GetUserRoles(ctx context.Context, userID, tenantID uint64) ([]types.RoleType, error) {
 	// 从user_roles表查询用户角色
-	records, err := g.DB().Model("user_roles").
+	records, err := TenantDBFor(ctx, tenantID).Model("user_roles").
 		Where("user_id = ? AND tenant_id = ?", userID, tenantID).
 		Fields("role_type").
 		All()
@@ -316,7 +336,7 @@ func (r *UserRepository) GetUserRoles(ctx context.Context, userID, tenantID uint
 // AssignRole 为用户分配角色
 func (r *UserRepository) AssignRole(ctx context.Context, userID, tenantID uint64, roleType types.RoleType) error {
 	// 检查角色是否已存在
-	exists, err := g.DB().Model("user_roles").
+	exists, err := TenantDBFor(ctx, tenantID).Model("user_roles").
 		Where("user_id = ? AND tenant_id = ? AND role_type = ?", userID, tenantID, roleType).
 		Count()
 
@@ -329,7 +349,7 @@ func (r *UserRepository) AssignRole(ctx context.Context, userID, tenantID uint64
 	}
 
 	// 插入用户角色记录
-	_, err = g.DB().Model("user_roles").Insert(gdb.Map{
+	_, err = TenantDBFor(ctx, tenantID).Model("user_roles").Insert(gdb.Map{
 		"user_id":    userID,
 		"tenant_id":  tenantID,
 		"role_type":  roleType,
@@ -342,7 +362,7 @@ func (r *UserRepository) AssignRole(ctx context.Context, userID, tenantID uint64
 
 // RemoveRole 移除用户角色
 func (r *UserRepository) RemoveRole(ctx context.Context, userID, tenantID uint64, roleType types.RoleType) error {
-	_, err := g.DB().Model("user_roles").
+	_, err := TenantDBFor(ctx, tenantID).Model("user_roles").
 		Where("user_id = ? AND tenant_id = ? AND role_type = ?", userID, tenantID, roleType).
 		Delete()
 
@@ -389,7 +409,7 @@ func (r *UserRepository) CreateMerchantUser(ctx context.Context, user *types.Use
 	user.TenantID = tenantID
 
 	// 开始事务
-	tx, err := g.DB().Begin(ctx)
+	tx, err := TenantDB(ctx).Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -411,6 +431,7 @@ func (r *UserRepository) CreateMerchantUser(ctx context.Context, user *types.Use
 	if err != nil {
 		return err
 	}
+	user.ID = uint64(userID)
 
 	// 分配商户角色
 	_, err = tx.Model("user_roles").Insert(gdb.Map{
@@ -530,10 +551,12 @@ func (r *UserRepository) ResetMerchantUserPassword(ctx context.Context, userID,
 		return err
 	}
 
+	now := gtime.Now()
 	_, err = model.Where("id = ? AND merchant_id = ?", userID, merchantID).
 		Update(gdb.Map{
-			"password_hash": newPasswordHash,
-			"updated_at":    gtime.Now(),
+			"password_hash":       newPasswordHash,
+			"password_changed_at": now,
+			"updated_at":          now,
 		})
 
 	return err
@@ -562,7 +585,7 @@ func (r *UserRepository) GetMerchantUserRoles(ctx context.Context, userID, merch
 	}
 
 	// 从user_roles表查询商户用户角色
-	records, err := g.DB().Model("user_roles").
+	records, err := TenantDB(ctx).Model("user_roles").
 		Where("user_id = ? AND tenant_id = ? AND resource_id = ?", userID, tenantID, merchantID).
 		Fields("role_type").
 		All()
@@ -589,7 +612,7 @@ func (r *UserRepository) AssignMerchantUserRole(ctx context.Context, userID, mer
 	}
 
 	// 检查角色是否已存在
-	exists, err := g.DB().Model("user_roles").
+	exists, err := TenantDB(ctx).Model("user_roles").
 		Where("user_id = ? AND tenant_id = ? AND resource_id = ? AND role_type = ?", 
 			userID, tenantID, merchantID, roleType).
 		Count()
@@ -603,7 +626,7 @@ func (r *UserRepository) AssignMerchantUserRole(ctx context.Context, userID, mer
 	}
 
 	// 插入用户角色记录
-	_, err = g.DB().Model("user_roles").Insert(gdb.Map{
+	_, err = TenantDB(ctx).Model("user_roles").Insert(gdb.Map{
 		"user_id":     userID,
 		"tenant_id":   tenantID,
 		"resource_id": merchantID,
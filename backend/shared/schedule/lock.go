@@ -0,0 +1,115 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/config"
+)
+
+// LeaderLock 领导者租约锁：同一时刻只有一个持有者，持有者需在租约到期前续期，否则由其他实例接管
+type LeaderLock interface {
+	// Acquire 尝试获取租约，租约被其他持有者占用时返回 false
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Renew 续期自己持有的租约，租约已过期或已被其他持有者获取时返回 false
+	Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Release 释放自己持有的租约，不会释放其他持有者的租约
+	Release(ctx context.Context, key, owner string) error
+}
+
+// 续期和释放需要先比较持有者再操作，使用脚本保证原子性
+const (
+	renewScript   = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`
+	releaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+)
+
+// redisLeaderLock 基于 Redis 的租约锁，租约到期由 Redis 自动删除键
+type redisLeaderLock struct{}
+
+// NewRedisLeaderLock 创建基于 Redis 的租约锁
+func NewRedisLeaderLock() LeaderLock {
+	return &redisLeaderLock{}
+}
+
+// Acquire 使用 SET NX PX 获取租约
+func (l *redisLeaderLock) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	result, err := config.GetRedis().Do(ctx, "SET", key, owner, "NX", "PX", ttl.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("获取调度租约失败: %v", err)
+	}
+	return result.String() == "OK", nil
+}
+
+// Renew 持有者一致时延长租约
+func (l *redisLeaderLock) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	result, err := config.GetRedis().Do(ctx, "EVAL", renewScript, 1, key, owner, ttl.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("续期调度租约失败: %v", err)
+	}
+	return result.Int() == 1, nil
+}
+
+// Release 持有者一致时删除租约
+func (l *redisLeaderLock) Release(ctx context.Context, key, owner string) error {
+	if _, err := config.GetRedis().Do(ctx, "EVAL", releaseScript, 1, key, owner); err != nil {
+		return fmt.Errorf("释放调度租约失败: %v", err)
+	}
+	return nil
+}
+
+// memoryLeaderLock 进程内租约锁，用于测试多个实例共享同一把锁
+type memoryLeaderLock struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	leases map[string]memoryLease
+}
+
+type memoryLease struct {
+	owner     string
+	expiresAt time.Time
+}
+
+// NewMemoryLeaderLock 创建进程内租约锁，now 为判断租约是否过期使用的当前时间
+func NewMemoryLeaderLock(now func() time.Time) LeaderLock {
+	return &memoryLeaderLock{now: now, leases: make(map[string]memoryLease)}
+}
+
+// Acquire 租约不存在或已过期时获取
+func (l *memoryLeaderLock) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if lease, exists := l.leases[key]; exists && now.Before(lease.expiresAt) {
+		return false, nil
+	}
+	l.leases[key] = memoryLease{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Renew 租约仍由 owner 持有且未过期时延长
+func (l *memoryLeaderLock) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	lease, exists := l.leases[key]
+	if !exists || lease.owner != owner || !now.Before(lease.expiresAt) {
+		return false, nil
+	}
+	l.leases[key] = memoryLease{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Release 租约由 owner 持有时删除
+func (l *memoryLeaderLock) Release(ctx context.Context, key, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lease, exists := l.leases[key]; exists && lease.owner == owner {
+		delete(l.leases, key)
+	}
+	return nil
+}
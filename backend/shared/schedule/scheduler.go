@@ -0,0 +1,322 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+const (
+	// tickInterval 检查到期任务的间隔，任务的执行时间精度不高于该间隔
+	tickInterval = time.Second
+	// defaultLeaseTTL 领导者租约时长，领导者异常退出后最长经过该时长由其他实例接管
+	defaultLeaseTTL = 30 * time.Second
+	leaderKeyPrefix = "scheduler:leader:"
+)
+
+// ErrJobExists 同名定时任务已注册
+var ErrJobExists = errors.New("定时任务已注册")
+
+// Schedule 定时任务的执行计划
+type Schedule interface {
+	// Next 返回 after 之后的下一次执行时间
+	Next(after time.Time) time.Time
+}
+
+// every 固定间隔执行
+type every time.Duration
+
+// Every 每隔 interval 执行一次，成为领导者后经过一个间隔首次执行
+func Every(interval time.Duration) Schedule {
+	return every(interval)
+}
+
+func (e every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+// hourlyAt 每小时的指定分钟执行
+type hourlyAt struct {
+	minute int
+}
+
+// HourlyAt 每小时的第 minute 分钟执行
+func HourlyAt(minute int) Schedule {
+	return hourlyAt{minute: minute}
+}
+
+func (h hourlyAt) Next(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), h.minute, 0, 0, after.Location())
+	if !next.After(after) {
+		next = next.Add(time.Hour)
+	}
+	return next
+}
+
+// dailyAt 每天的指定时刻执行
+type dailyAt struct {
+	hour   int
+	minute int
+}
+
+// DailyAt 每天 hour:minute 执行，按 after 所在时区计算
+func DailyAt(hour, minute int) Schedule {
+	return dailyAt{hour: hour, minute: minute}
+}
+
+func (d dailyAt) Next(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), d.hour, d.minute, 0, 0, after.Location())
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Job 定时任务
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+}
+
+// jobState 任务在当前实例上的调度状态
+type jobState struct {
+	job     Job
+	nextRun time.Time
+	running bool // 上一次执行尚未结束时跳过本次执行
+}
+
+// Scheduler 多副本部署下的定时任务调度器：同名调度器的各实例通过租约锁选举领导者，
+// 只有领导者执行到期的任务。领导者按租约时长的三分之一续期，续期失败时立即停止调度并取消执行中的任务，
+// 领导者退出或租约过期后由其他实例接管，接管后按执行计划重新计算各任务的下一次执行时间
+type Scheduler struct {
+	name       string
+	instanceID string
+	lock       LeaderLock
+	leaseTTL   time.Duration
+	now        func() time.Time
+
+	mu           sync.Mutex
+	jobs         []*jobState
+	leader       bool
+	electAt      time.Time // 下一次获取或续期租约的时间
+	cancelLeader context.CancelFunc
+	leaderCtx    context.Context
+
+	running   sync.WaitGroup
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	isRunning bool
+}
+
+// New 创建使用 Redis 租约锁的调度器，name 相同的调度器在各副本间只有一个执行任务
+func New(name string) *Scheduler {
+	ctx := context.Background()
+	leaseTTL := g.Cfg().MustGet(ctx, "scheduler.leaseTTL", defaultLeaseTTL).Duration()
+	return NewForTest(name, newInstanceID(), NewRedisLeaderLock(), leaseTTL, time.Now)
+}
+
+// NewForTest 创建测试用调度器，可指定实例标识、租约锁、租约时长和当前时间
+func NewForTest(name, instanceID string, lock LeaderLock, leaseTTL time.Duration, now func() time.Time) *Scheduler {
+	if leaseTTL <= 0 {
+		leaseTTL = defaultLeaseTTL
+	}
+	return &Scheduler{
+		name:       name,
+		instanceID: instanceID,
+		lock:       lock,
+		leaseTTL:   leaseTTL,
+		now:        now,
+	}
+}
+
+// newInstanceID 生成实例标识，包含主机名和进程号便于排查当前的领导者
+func newInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), guid.S())
+}
+
+// Register 注册定时任务
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Schedule == nil || job.Run == nil {
+		return errors.New("定时任务的名称、执行计划和执行函数不能为空")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, state := range s.jobs {
+		if state.job.Name == job.Name {
+			return fmt.Errorf("%w: %s", ErrJobExists, job.Name)
+		}
+	}
+	state := &jobState{job: job}
+	if s.leader {
+		state.nextRun = job.Schedule.Next(s.now())
+	}
+	s.jobs = append(s.jobs, state)
+	return nil
+}
+
+// IsLeader 当前实例是否为领导者
+func (s *Scheduler) IsLeader() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leader
+}
+
+// Start 启动调度循环，启动时立即尝试成为领导者
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isRunning {
+		return
+	}
+	s.isRunning = true
+	s.stopCh = make(chan struct{})
+	s.doneCh = make(chan struct{})
+	g.Log().Info(ctx, "启动定时任务调度器", "scheduler", s.name, "instance", s.instanceID)
+
+	go func(stopCh, doneCh chan struct{}) {
+		defer close(doneCh)
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		for {
+			s.Tick(ctx)
+
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}(s.stopCh, s.doneCh)
+}
+
+// Stop 停止调度循环，取消执行中的任务并等待其结束，领导者释放租约，其他实例无需等待租约过期即可接管
+func (s *Scheduler) Stop(ctx context.Context) {
+	s.mu.Lock()
+	if !s.isRunning {
+		s.mu.Unlock()
+		return
+	}
+	s.isRunning = false
+	close(s.stopCh)
+	doneCh := s.doneCh
+	s.mu.Unlock()
+
+	<-doneCh
+
+	s.mu.Lock()
+	wasLeader := s.leader
+	s.stepDown()
+	s.electAt = time.Time{}
+	s.mu.Unlock()
+
+	s.running.Wait()
+	if wasLeader {
+		if err := s.lock.Release(ctx, s.leaderKey(), s.instanceID); err != nil {
+			g.Log().Warning(ctx, "释放调度租约失败", "scheduler", s.name, "error", err)
+		}
+	}
+	g.Log().Info(ctx, "定时任务调度器已停止", "scheduler", s.name)
+}
+
+// Tick 到达选举时间时获取或续期租约，当前实例为领导者时在后台执行到期的任务
+func (s *Scheduler) Tick(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !now.Before(s.electAt) {
+		s.elect(ctx, now)
+	}
+	if !s.leader {
+		return
+	}
+
+	for _, state := range s.jobs {
+		if state.running || now.Before(state.nextRun) {
+			continue
+		}
+		state.running = true
+		state.nextRun = state.job.Schedule.Next(now)
+		s.running.Add(1)
+		go s.run(s.leaderCtx, state)
+	}
+}
+
+// elect 领导者续期租约，续期失败时退出领导者；其他实例尝试获取租约
+func (s *Scheduler) elect(ctx context.Context, now time.Time) {
+	s.electAt = now.Add(s.leaseTTL / 3)
+	key := s.leaderKey()
+
+	if s.leader {
+		renewed, err := s.lock.Renew(ctx, key, s.instanceID, s.leaseTTL)
+		if err != nil {
+			g.Log().Warning(ctx, "续期调度租约失败", "scheduler", s.name, "error", err)
+		}
+		if !renewed {
+			// 无法确认仍持有租约时停止调度，避免与接管的实例重复执行
+			g.Log().Warning(ctx, "调度租约已失效，停止执行定时任务", "scheduler", s.name, "instance", s.instanceID)
+			s.stepDown()
+		}
+		return
+	}
+
+	acquired, err := s.lock.Acquire(ctx, key, s.instanceID, s.leaseTTL)
+	if err != nil {
+		g.Log().Warning(ctx, "获取调度租约失败", "scheduler", s.name, "error", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	s.leader = true
+	s.leaderCtx, s.cancelLeader = context.WithCancel(ctx)
+	for _, state := range s.jobs {
+		state.nextRun = state.job.Schedule.Next(now)
+	}
+	g.Log().Info(ctx, "成为定时任务领导者", "scheduler", s.name, "instance", s.instanceID)
+}
+
+// stepDown 退出领导者并取消执行中的任务，调用方需持有 s.mu
+func (s *Scheduler) stepDown() {
+	if !s.leader {
+		return
+	}
+	s.leader = false
+	s.cancelLeader()
+	s.leaderCtx, s.cancelLeader = nil, nil
+}
+
+// run 执行任务，任务失败或异常只记录日志，不影响后续调度
+func (s *Scheduler) run(ctx context.Context, state *jobState) {
+	defer s.running.Done()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			g.Log().Error(ctx, "定时任务执行异常", "scheduler", s.name, "job", state.job.Name, "panic", recovered)
+		}
+		s.mu.Lock()
+		state.running = false
+		s.mu.Unlock()
+	}()
+
+	if err := state.job.Run(ctx); err != nil {
+		g.Log().Error(ctx, "定时任务执行失败", "scheduler", s.name, "job", state.job.Name, "error", err)
+	}
+}
+
+func (s *Scheduler) leaderKey() string {
+	return leaderKeyPrefix + s.name
+}
@@ -0,0 +1,183 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// testClock 测试用时钟，多个实例共享同一时间
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// jobCounter 按实例记录任务的执行次数
+type jobCounter struct {
+	mu   sync.Mutex
+	runs map[string]int
+}
+
+func (c *jobCounter) job(instance string) Job {
+	return Job{
+		Name:     "process",
+		Schedule: Every(time.Minute),
+		Run: func(ctx context.Context) error {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.runs[instance]++
+			return nil
+		},
+	}
+}
+
+func (c *jobCounter) count(instance string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runs[instance]
+}
+
+// tick 触发一次调度并等待本次触发的任务执行结束
+func tick(ctx context.Context, s *Scheduler) {
+	s.Tick(ctx)
+	s.running.Wait()
+}
+
+// runFor 时钟前进 d，期间每 5 秒依次触发各实例的调度，与调度循环的行为一致
+func runFor(ctx context.Context, clock *testClock, d time.Duration, schedulers ...*Scheduler) {
+	for elapsed := time.Duration(0); elapsed < d; elapsed += 5 * time.Second {
+		clock.Advance(5 * time.Second)
+		for _, s := range schedulers {
+			tick(ctx, s)
+		}
+	}
+}
+
+func TestSchedulerLeaderElection(t *testing.T) {
+	Convey("多实例定时任务调度测试", t, func() {
+		ctx := context.Background()
+		clock := &testClock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)}
+		lock := NewMemoryLeaderLock(clock.Now)
+		counter := &jobCounter{runs: make(map[string]int)}
+
+		first := NewForTest("report", "instance-a", lock, 30*time.Second, clock.Now)
+		second := NewForTest("report", "instance-b", lock, 30*time.Second, clock.Now)
+		So(first.Register(counter.job("instance-a")), ShouldBeNil)
+		So(second.Register(counter.job("instance-b")), ShouldBeNil)
+
+		tick(ctx, first)
+		tick(ctx, second)
+		So(first.IsLeader(), ShouldBeTrue)
+		So(second.IsLeader(), ShouldBeFalse)
+
+		Convey("同一次到期只有领导者执行任务", func() {
+			runFor(ctx, clock, 5*time.Minute, first, second)
+			So(first.IsLeader(), ShouldBeTrue)
+			So(counter.count("instance-a"), ShouldEqual, 5)
+			So(counter.count("instance-b"), ShouldEqual, 0)
+		})
+
+		Convey("领导者停止时释放租约，其他实例在下一次选举时接管", func() {
+			first.Start(ctx)
+			first.Stop(ctx)
+			So(first.IsLeader(), ShouldBeFalse)
+
+			runFor(ctx, clock, 10*time.Second, second)
+			So(second.IsLeader(), ShouldBeTrue)
+
+			runFor(ctx, clock, time.Minute, first, second)
+			So(first.IsLeader(), ShouldBeFalse)
+			So(counter.count("instance-a"), ShouldEqual, 0)
+			So(counter.count("instance-b"), ShouldEqual, 1)
+		})
+
+		Convey("领导者未续期时租约过期后由其他实例接管", func() {
+			clock.Advance(20 * time.Second)
+			tick(ctx, second)
+			So(second.IsLeader(), ShouldBeFalse)
+
+			clock.Advance(11 * time.Second)
+			tick(ctx, second)
+			So(second.IsLeader(), ShouldBeTrue)
+
+			Convey("原领导者续期失败后退出，不再执行任务", func() {
+				runFor(ctx, clock, time.Minute, first, second)
+				So(first.IsLeader(), ShouldBeFalse)
+				So(counter.count("instance-a"), ShouldEqual, 0)
+				So(counter.count("instance-b"), ShouldEqual, 1)
+			})
+		})
+
+		Convey("上一次执行未结束时跳过本次执行", func() {
+			release := make(chan struct{})
+			started := make(chan struct{}, 10)
+			So(first.Register(Job{
+				Name:     "slow",
+				Schedule: Every(time.Minute),
+				Run: func(ctx context.Context) error {
+					started <- struct{}{}
+					<-release
+					return nil
+				},
+			}), ShouldBeNil)
+
+			for elapsed := time.Duration(0); elapsed < 2*time.Minute; elapsed += 5 * time.Second {
+				clock.Advance(5 * time.Second)
+				first.Tick(ctx)
+			}
+			<-started
+			close(release)
+			first.running.Wait()
+			So(started, ShouldHaveLength, 0)
+		})
+
+		Convey("任务失败不影响后续调度", func() {
+			attempts := 0
+			So(first.Register(Job{
+				Name:     "failing",
+				Schedule: Every(time.Minute),
+				Run: func(ctx context.Context) error {
+					attempts++
+					return errors.New("数据库不可用")
+				},
+			}), ShouldBeNil)
+
+			runFor(ctx, clock, 3*time.Minute, first)
+			So(attempts, ShouldEqual, 3)
+		})
+
+		Convey("同名任务不能重复注册", func() {
+			err := first.Register(counter.job("instance-a"))
+			So(errors.Is(err, ErrJobExists), ShouldBeTrue)
+		})
+	})
+}
+
+func TestSchedules(t *testing.T) {
+	Convey("执行计划测试", t, func() {
+		now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+
+		So(Every(5*time.Minute).Next(now), ShouldEqual, now.Add(5*time.Minute))
+		So(HourlyAt(1).Next(now), ShouldEqual, time.Date(2024, 5, 1, 11, 1, 0, 0, time.Local))
+		So(HourlyAt(45).Next(now), ShouldEqual, time.Date(2024, 5, 1, 10, 45, 0, 0, time.Local))
+		So(HourlyAt(30).Next(now), ShouldEqual, time.Date(2024, 5, 1, 11, 30, 0, 0, time.Local))
+		So(DailyAt(2, 0).Next(now), ShouldEqual, time.Date(2024, 5, 2, 2, 0, 0, 0, time.Local))
+		So(DailyAt(23, 0).Next(now), ShouldEqual, time.Date(2024, 5, 1, 23, 0, 0, 0, time.Local))
+	})
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/test/fixtures"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// TestAnalyticsQueryChecksAgainstSchema 在真实数据库上执行所有已注册的分析查询，
+// 表结构变更导致查询失败或结果不满足约束时测试失败
+func TestAnalyticsQueryChecksAgainstSchema(t *testing.T) {
+	if testing.Short() {
+		t.Skip("跳过集成测试")
+	}
+	if os.Getenv("INTEGRATION_TEST") == "" {
+		t.Skip("需要设置 INTEGRATION_TEST 环境变量来运行集成测试")
+	}
+
+	Convey("分析查询与数据库表结构一致", t, func() {
+		f := fixtures.New(t, fixtures.WithTransaction())
+		tenant, _ := f.NewTestTenant()
+		merchant, _ := f.NewTestMerchant(tenant.ID)
+		customer, _ := f.NewTestUser(tenant.ID)
+		product, _ := f.NewTestProduct(tenant.ID, merchant.ID)
+
+		items := []types.OrderItem{{ProductID: product.ID, Quantity: 2, Price: 50, RightsCost: 5}}
+		for _, status := range []types.OrderStatus{types.OrderStatusPaid, types.OrderStatusCompleted, types.OrderStatusPending} {
+			status := status
+			f.NewTestOrder(tenant.ID, merchant.ID, customer.ID, items, func(o *types.Order) { o.Status = status })
+		}
+
+		report := repository.RunAnalyticsQueryChecks(f.Ctx(), repository.NewReportRepository(), &types.AnalyticsCheckRequest{
+			TenantID:   tenant.ID,
+			StartDate:  time.Now().AddDate(0, 0, -1),
+			EndDate:    time.Now().Add(time.Hour),
+			MerchantID: &merchant.ID,
+		})
+
+		for _, result := range report.Failed() {
+			t.Logf("%s: error=%q query_errors=%v violations=%v", result.Name, result.Error, result.QueryErrors, result.Violations)
+		}
+		So(report.Failed(), ShouldBeEmpty)
+		So(report.Passed, ShouldBeTrue)
+	})
+}
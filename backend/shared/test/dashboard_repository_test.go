@@ -98,21 +98,21 @@ func TestDashboardRepository(t *testing.T) {
 			}
 		})
 
-		Convey("GetPendingTasks should return task list", func() {
-			tasks, err := dashboardRepo.GetPendingTasks(ctx, tenantID, merchantID)
+		Convey("GetPendingTaskStats should return pending counts", func() {
+			stats, err := dashboardRepo.GetPendingTaskStats(ctx, tenantID, merchantID, &repository.PendingTaskCriteria{
+				OrderStatuses: []types.OrderStatusInt{types.OrderStatusIntPending, types.OrderStatusIntProcessing},
+				StaleBefore:   time.Now().AddDate(0, 0, -90),
+			})
 			
 			// 基本验证
 			if err != nil {
 				So(err, ShouldNotBeNil)
-				So(tasks, ShouldBeNil)
+				So(stats, ShouldBeNil)
 			} else {
-				So(tasks, ShouldNotBeNil)
-				// 验证任务数据结构
-				for _, task := range tasks {
-					So(task.ID, ShouldNotBeEmpty)
-					So(task.Description, ShouldNotBeEmpty)
-					So(task.Count, ShouldBeGreaterThanOrEqualTo, 0)
-				}
+				So(stats, ShouldNotBeNil)
+				So(stats.PendingOrders, ShouldBeGreaterThanOrEqualTo, 0)
+				So(stats.PendingVerifications, ShouldBeGreaterThanOrEqualTo, 0)
+				So(stats.ProductsNeedingUpdate, ShouldBeGreaterThanOrEqualTo, 0)
 			}
 		})
 
@@ -0,0 +1,327 @@
+// Package fixtures 提供集成测试数据构造工具：按租户插入测试记录、签发测试令牌，
+// 并在测试结束时清理数据（逐条删除或回滚事务）。
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/utils"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// DefaultPassword 测试用户的默认密码
+const DefaultPassword = "testpassword123"
+
+var (
+	// runID 区分不同测试进程，避免残留数据导致唯一键冲突
+	runID = time.Now().UnixNano() % 1_000_000
+	// sequence 保证同一进程内生成的编码、用户名唯一
+	sequence uint64
+)
+
+// Option 测试数据构造器选项
+type Option func(*Fixtures)
+
+// WithTransaction 在事务中插入所有测试数据，测试结束时回滚而不是逐条删除。
+// 被测代码需要使用 Fixtures.Ctx() 返回的上下文才能看到事务内的数据。
+func WithTransaction() Option {
+	return func(f *Fixtures) {
+		f.transactional = true
+	}
+}
+
+// WithJWTManager 指定签发测试令牌使用的JWT管理器，默认使用 auth.NewJWTManager()
+func WithJWTManager(jwtManager *auth.JWTManager) Option {
+	return func(f *Fixtures) {
+		f.jwtManager = jwtManager
+	}
+}
+
+// WithDB 指定数据库连接，默认使用 g.DB()
+func WithDB(db gdb.DB) Option {
+	return func(f *Fixtures) {
+		f.db = db
+	}
+}
+
+// Fixtures 集成测试数据构造器
+type Fixtures struct {
+	t             testing.TB
+	ctx           context.Context
+	db            gdb.DB
+	tx            gdb.TX
+	transactional bool
+	jwtManager    *auth.JWTManager
+
+	mu       sync.Mutex
+	cleanups []func()
+	closed   bool
+}
+
+// New 创建测试数据构造器，测试结束时自动清理插入的数据
+func New(t testing.TB, opts ...Option) *Fixtures {
+	t.Helper()
+
+	f := &Fixtures{t: t, ctx: context.Background()}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if f.transactional {
+		tx, err := f.database().Begin(f.ctx)
+		if err != nil {
+			t.Fatalf("开启测试事务失败: %v", err)
+		}
+		f.tx = tx
+		f.ctx = gdb.WithTX(f.ctx, tx)
+	}
+
+	t.Cleanup(f.Cleanup)
+	return f
+}
+
+// Ctx 返回测试上下文，事务模式下携带测试事务
+func (f *Fixtures) Ctx() context.Context {
+	return f.ctx
+}
+
+// TenantCtx 返回携带租户（以及可选商户、用户）信息的测试上下文，与认证中间件写入的键一致
+func (f *Fixtures) TenantCtx(tenantID uint64, merchantID, userID uint64) context.Context {
+	ctx := context.WithValue(f.ctx, "tenant_id", tenantID)
+	if merchantID > 0 {
+		ctx = context.WithValue(ctx, "merchant_id", merchantID)
+	}
+	if userID > 0 {
+		ctx = context.WithValue(ctx, "user_id", userID)
+	}
+	return ctx
+}
+
+// Cleanup 按创建的逆序清理测试数据，事务模式下直接回滚。可重复调用
+func (f *Fixtures) Cleanup() {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return
+	}
+	f.closed = true
+	cleanups := f.cleanups
+	f.cleanups = nil
+	f.mu.Unlock()
+
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+	if f.tx != nil {
+		if err := f.tx.Rollback(); err != nil {
+			f.t.Logf("回滚测试事务失败: %v", err)
+		}
+	}
+}
+
+// NewTestTenant 创建激活状态的测试租户
+func (f *Fixtures) NewTestTenant(overrides ...func(*types.Tenant)) (*types.Tenant, func()) {
+	f.t.Helper()
+
+	seq := nextSequence()
+	now := time.Now()
+	tenant := &types.Tenant{
+		Name:             fmt.Sprintf("测试租户%s", seq),
+		Code:             fmt.Sprintf("test-tenant-%s", seq),
+		Status:           types.TenantStatusActive,
+		Config:           "{}",
+		BusinessType:     "test",
+		ContactPerson:    "测试联系人",
+		ContactEmail:     fmt.Sprintf("tenant%s@example.com", seq),
+		RegistrationTime: &now,
+		ActivationTime:   &now,
+	}
+	for _, override := range overrides {
+		override(tenant)
+	}
+
+	tenant.ID = f.insert("tenants", tenant)
+	return tenant, f.deleteFunc("tenants", tenant.ID)
+}
+
+// NewTestMerchant 在租户下创建激活状态的测试商户
+func (f *Fixtures) NewTestMerchant(tenantID uint64, overrides ...func(*types.Merchant)) (*types.Merchant, func()) {
+	f.t.Helper()
+
+	seq := nextSequence()
+	now := time.Now()
+	merchant := &types.Merchant{
+		TenantID:         tenantID,
+		Name:             fmt.Sprintf("测试商户%s", seq),
+		Code:             fmt.Sprintf("test-merchant-%s", seq),
+		Status:           types.MerchantStatusActive,
+		BusinessInfo:     &types.BusinessInfo{},
+		RightsBalance:    &types.RightsBalance{},
+		RegistrationTime: &now,
+		ApprovalTime:     &now,
+	}
+	for _, override := range overrides {
+		override(merchant)
+	}
+
+	merchant.ID = f.insert("merchants", merchant)
+	return merchant, f.deleteFunc("merchants", merchant.ID)
+}
+
+// NewTestUser 在租户下创建激活状态的测试用户，密码为 DefaultPassword
+func (f *Fixtures) NewTestUser(tenantID uint64, overrides ...func(*types.User)) (*types.User, func()) {
+	f.t.Helper()
+
+	passwordHash, err := utils.NewPasswordManager().HashPassword(DefaultPassword)
+	if err != nil {
+		f.t.Fatalf("生成测试用户密码失败: %v", err)
+	}
+
+	seq := nextSequence()
+	user := &types.User{
+		UUID:         fmt.Sprintf("test-user-%d-%s", tenantID, seq),
+		Username:     fmt.Sprintf("testuser%s", seq),
+		Email:        fmt.Sprintf("test%s@example.com", seq),
+		Phone:        "13800138000",
+		PasswordHash: passwordHash,
+		TenantID:     tenantID,
+		Status:       types.UserStatusActive,
+	}
+	for _, override := range overrides {
+		override(user)
+	}
+
+	user.ID = f.insert("users", user)
+	return user, f.deleteFunc("users", user.ID)
+}
+
+// NewTestProduct 在商户下创建上架状态的测试商品，默认库存100
+func (f *Fixtures) NewTestProduct(tenantID, merchantID uint64, overrides ...func(*types.Product)) (*types.Product, func()) {
+	f.t.Helper()
+
+	seq := nextSequence()
+	product := &types.Product{
+		TenantID:      tenantID,
+		MerchantID:    merchantID,
+		Name:          fmt.Sprintf("测试商品%s", seq),
+		Tags:          types.StringArray{},
+		PriceAmount:   99.9,
+		PriceCurrency: "CNY",
+		InventoryInfo: &types.InventoryInfo{StockQuantity: 100, TrackInventory: true},
+		Images:        types.ProductImages{},
+		Status:        types.ProductStatusActive,
+		Version:       1,
+	}
+	for _, override := range overrides {
+		override(product)
+	}
+
+	product.ID = f.insert("products", product)
+	return product, f.deleteFunc("products", product.ID)
+}
+
+// NewTestOrder 创建待支付的测试订单，总金额和权益成本按订单项计算
+func (f *Fixtures) NewTestOrder(tenantID, merchantID, customerID uint64, items []types.OrderItem, overrides ...func(*types.Order)) (*types.Order, func()) {
+	f.t.Helper()
+
+	now := time.Now()
+	order := &types.Order{
+		TenantID:        tenantID,
+		MerchantID:      merchantID,
+		CustomerID:      customerID,
+		OrderNumber:     fmt.Sprintf("TEST%d%s", now.Unix(), nextSequence()),
+		Status:          types.OrderStatusPending,
+		Items:           items,
+		StatusUpdatedAt: now,
+	}
+	for _, item := range items {
+		order.TotalAmount += item.Price * float64(item.Quantity)
+		order.TotalRightsCost += item.RightsCost * float64(item.Quantity)
+	}
+	for _, override := range overrides {
+		override(order)
+	}
+
+	order.ID = f.insert("orders", order)
+	return order, f.deleteFunc("orders", order.ID)
+}
+
+// MintToken 为用户签发可通过认证中间件校验的访问令牌
+func (f *Fixtures) MintToken(user *types.User, permissions ...types.Permission) string {
+	f.t.Helper()
+
+	if f.jwtManager == nil {
+		f.jwtManager = auth.NewJWTManager()
+	}
+	userPermissions := &types.UserPermissions{
+		UserID:      user.ID,
+		TenantID:    user.TenantID,
+		Roles:       []types.RoleType{},
+		Permissions: permissions,
+	}
+	token, err := f.jwtManager.GenerateTokenWithPermissions(f.ctx, user, userPermissions, "access")
+	if err != nil {
+		f.t.Fatalf("签发测试令牌失败: %v", err)
+	}
+
+	f.addCleanup(func() {
+		f.jwtManager.RevokeToken(context.Background(), token)
+	})
+	return token
+}
+
+// insert 插入记录并返回自增ID，失败时终止测试
+func (f *Fixtures) insert(table string, data interface{}) uint64 {
+	f.t.Helper()
+
+	id, err := f.database().Model(table).Ctx(f.ctx).Data(data).OmitEmpty().InsertAndGetId()
+	if err != nil {
+		f.t.Fatalf("插入测试数据失败 (%s): %v", table, err)
+	}
+	return uint64(id)
+}
+
+// deleteFunc 注册删除记录的清理函数并返回，返回的函数可提前调用且只执行一次
+func (f *Fixtures) deleteFunc(table string, id uint64) func() {
+	var once sync.Once
+	cleanup := func() {
+		once.Do(func() {
+			// 事务模式下数据随回滚清理
+			if f.tx != nil {
+				return
+			}
+			if _, err := f.database().Model(table).Ctx(context.Background()).Where("id", id).Delete(); err != nil {
+				f.t.Logf("清理测试数据失败 (%s #%d): %v", table, id, err)
+			}
+		})
+	}
+	f.addCleanup(cleanup)
+	return cleanup
+}
+
+// database 获取数据库连接，未指定时在首次使用时取默认连接
+func (f *Fixtures) database() gdb.DB {
+	if f.db == nil {
+		f.db = g.DB()
+	}
+	return f.db
+}
+
+// addCleanup 登记清理函数
+func (f *Fixtures) addCleanup(cleanup func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cleanups = append(f.cleanups, cleanup)
+}
+
+// nextSequence 获取下一个序号，格式为 <进程标识><自增序号>
+func nextSequence() string {
+	return fmt.Sprintf("%d%04d", runID, atomic.AddUint64(&sequence, 1))
+}
@@ -0,0 +1,62 @@
+package fixtures
+
+import (
+	"testing"
+
+	"github.com/gofromzero/mer-sys/backend/shared/auth"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFixtures(t *testing.T) {
+	Convey("测试数据构造器", t, func() {
+		jwtManager := auth.NewJWTManagerForTest("fixtures-secret", 1)
+		f := New(t, WithJWTManager(jwtManager))
+
+		Convey("签发的令牌可通过校验并携带权限", func() {
+			user := &types.User{ID: 7, TenantID: 3, Username: "fixture"}
+			token := f.MintToken(user, types.PermissionOrderView)
+
+			claims, err := jwtManager.ValidateToken(f.Ctx(), token)
+			So(err, ShouldBeNil)
+			So(claims.UserID, ShouldEqual, 7)
+			So(claims.TenantID, ShouldEqual, 3)
+			So(claims.Permissions, ShouldResemble, []types.Permission{types.PermissionOrderView})
+
+			Convey("清理后令牌失效", func() {
+				f.Cleanup()
+				_, err := jwtManager.ValidateToken(f.Ctx(), token)
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("清理函数按登记的逆序执行且只执行一次", func() {
+			var order []int
+			f.addCleanup(func() { order = append(order, 1) })
+			f.addCleanup(func() { order = append(order, 2) })
+
+			f.Cleanup()
+			f.Cleanup()
+			So(order, ShouldResemble, []int{2, 1})
+		})
+
+		Convey("租户上下文与认证中间件写入的键一致", func() {
+			ctx := f.TenantCtx(1, 2, 3)
+			So(ctx.Value("tenant_id"), ShouldEqual, uint64(1))
+			So(ctx.Value("merchant_id"), ShouldEqual, uint64(2))
+			So(ctx.Value("user_id"), ShouldEqual, uint64(3))
+
+			ctx = f.TenantCtx(1, 0, 0)
+			So(ctx.Value("merchant_id"), ShouldBeNil)
+		})
+
+		Convey("生成的序号不重复", func() {
+			seen := make(map[string]bool)
+			for i := 0; i < 100; i++ {
+				seq := nextSequence()
+				So(seen[seq], ShouldBeFalse)
+				seen[seq] = true
+			}
+		})
+	})
+}
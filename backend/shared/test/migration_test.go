@@ -0,0 +1,71 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofromzero/mer-sys/backend/shared/config"
+	"github.com/gofromzero/mer-sys/backend/shared/database"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+// TestMigrationApplyAndRollback 测试在测试数据库上应用和回滚示例迁移
+func TestMigrationApplyAndRollback(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		// 初始化数据库连接
+		err := config.InitDatabase()
+		t.AssertNil(err)
+
+		ctx := context.Background()
+		db := g.DB()
+		set := "migration-test"
+		defer db.Exec(ctx, "DELETE FROM "+database.SchemaMigrationsTable+" WHERE migration_set = ?", set)
+		defer db.Exec(ctx, "DROP TABLE IF EXISTS `migration_test_widgets`")
+
+		migrator := database.NewMigrator(db, set, fstest.MapFS{
+			"001_create_migration_test_widgets.up.sql": {Data: []byte(`CREATE TABLE IF NOT EXISTS migration_test_widgets (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    name VARCHAR(50) NOT NULL,
+    PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO migration_test_widgets (name) VALUES ('示例');
+`)},
+			"001_create_migration_test_widgets.down.sql": {Data: []byte("DROP TABLE IF EXISTS migration_test_widgets;\n")},
+		})
+
+		// 应用迁移
+		versions, err := migrator.Up(ctx)
+		t.AssertNil(err)
+		t.Assert(versions, []string{"001_create_migration_test_widgets"})
+
+		count, err := db.GetValue(ctx, "SELECT COUNT(*) FROM migration_test_widgets")
+		t.AssertNil(err)
+		t.Assert(count.Int(), 1)
+
+		statuses, err := migrator.Status(ctx)
+		t.AssertNil(err)
+		t.Assert(len(statuses), 1)
+		t.Assert(statuses[0].Applied, true)
+
+		// 重复执行不再应用
+		versions, err = migrator.Up(ctx)
+		t.AssertNil(err)
+		t.Assert(len(versions), 0)
+
+		// 回滚迁移
+		versions, err = migrator.Down(ctx, 1)
+		t.AssertNil(err)
+		t.Assert(versions, []string{"001_create_migration_test_widgets"})
+
+		tables, err := db.GetAll(ctx, "SHOW TABLES LIKE 'migration_test_widgets'")
+		t.AssertNil(err)
+		t.Assert(len(tables), 0)
+
+		statuses, err = migrator.Status(ctx)
+		t.AssertNil(err)
+		t.Assert(statuses[0].Applied, false)
+	})
+}
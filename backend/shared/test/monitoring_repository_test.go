@@ -7,8 +7,8 @@ import (
 
 	"github.com/gogf/gf/v2/test/gtest"
 
-	"mer-demo/shared/repository"
-	"mer-demo/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/repository"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
 
 // TestMonitoringRepository_TenantIsolation 测试监控Repository的租户隔离
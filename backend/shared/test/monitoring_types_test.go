@@ -4,7 +4,7 @@ import (
 	"testing"
 
 	"github.com/gogf/gf/v2/test/gtest"
-	"mer-demo/shared/types"
+	"github.com/gofromzero/mer-sys/backend/shared/types"
 )
 
 // TestMonitoringTypes 测试监控相关类型定义
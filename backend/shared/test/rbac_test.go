@@ -445,4 +445,69 @@ func TestRBACSecurityModel(t *testing.T) {
 			}
 		})
 	})
-}
\ No newline at end of file
+}
+// TestRolePermissionResolution 角色权限解析测试（授予/撤销效果）
+func TestRolePermissionResolution(t *testing.T) {
+	Convey("角色权限解析测试", t, func() {
+		customRole := types.RoleType("store_auditor")
+		definitions := types.GetDefaultRoles()
+		definitions[customRole] = types.Role{
+			Type:        customRole,
+			Name:        "门店审计员",
+			Permissions: []types.Permission{types.PermissionReportView},
+		}
+		
+		Convey("系统内置角色识别", func() {
+			So(types.IsSystemRole(types.RoleTenantAdmin), ShouldBeTrue)
+			So(types.IsSystemRole(types.RoleMerchantOperator), ShouldBeTrue)
+			So(types.IsSystemRole(customRole), ShouldBeFalse)
+		})
+		
+		Convey("多角色权限合并去重", func() {
+			permissions := types.ResolvePermissions([]types.RoleType{types.RoleMerchant, types.RoleCustomer}, definitions)
+			
+			seen := make(map[types.Permission]int)
+			for _, perm := range permissions {
+				seen[perm]++
+			}
+			So(seen[types.PermissionProductView], ShouldEqual, 1)
+			So(seen[types.PermissionOrderCreate], ShouldEqual, 1)
+			So(seen[types.PermissionProductManage], ShouldEqual, 1)
+		})
+		
+		Convey("未定义的角色不产生权限", func() {
+			permissions := types.ResolvePermissions([]types.RoleType{"unknown_role"}, definitions)
+			So(len(permissions), ShouldEqual, 0)
+		})
+		
+		Convey("授予权限后用户获得新权限", func() {
+			userPermissions := types.UserPermissions{
+				Roles:       []types.RoleType{customRole},
+				Permissions: types.ResolvePermissions([]types.RoleType{customRole}, definitions),
+			}
+			So(userPermissions.HasPermission(types.PermissionReportView), ShouldBeTrue)
+			So(userPermissions.HasPermission(types.PermissionReportExport), ShouldBeFalse)
+			
+			role := definitions[customRole]
+			role.Permissions = append(role.Permissions, types.PermissionReportExport)
+			definitions[customRole] = role
+			
+			userPermissions.Permissions = types.ResolvePermissions(userPermissions.Roles, definitions)
+			So(userPermissions.HasPermission(types.PermissionReportExport), ShouldBeTrue)
+		})
+		
+		Convey("撤销权限后用户失去该权限", func() {
+			role := definitions[customRole]
+			role.Permissions = []types.Permission{}
+			definitions[customRole] = role
+			
+			userPermissions := types.UserPermissions{
+				Roles:       []types.RoleType{customRole, types.RoleCustomer},
+				Permissions: types.ResolvePermissions([]types.RoleType{customRole, types.RoleCustomer}, definitions),
+			}
+			So(userPermissions.HasPermission(types.PermissionReportView), ShouldBeFalse)
+			// 其他角色授予的权限不受影响
+			So(userPermissions.HasPermission(types.PermissionProductView), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,37 @@
+package types
+
+import "time"
+
+// AlertEscalatedWebhookPayload 预警升级推送内容
+type AlertEscalatedWebhookPayload struct {
+	ID              string            `json:"id"`
+	Event           OrderWebhookEvent `json:"event"`
+	TenantID        uint64            `json:"tenant_id"`
+	OccurredAt      time.Time         `json:"occurred_at"`
+	AlertID         uint64            `json:"alert_id"`
+	MerchantID      uint64            `json:"merchant_id"`
+	AlertType       string            `json:"alert_type"`
+	Severity        string            `json:"severity"`
+	EscalationLevel int               `json:"escalation_level"`
+	MaxLevel        int               `json:"max_level"`
+	Message         string            `json:"message"`
+	TriggeredAt     time.Time         `json:"triggered_at"`
+}
+
+// NewAlertEscalatedWebhookPayload 按升级后的预警生成推送内容
+func NewAlertEscalatedWebhookPayload(id string, alert *RightsAlert, maxLevel int, occurredAt time.Time) *AlertEscalatedWebhookPayload {
+	return &AlertEscalatedWebhookPayload{
+		ID:              id,
+		Event:           OrderWebhookEventAlertEscalated,
+		TenantID:        alert.TenantID,
+		OccurredAt:      occurredAt,
+		AlertID:         alert.ID,
+		MerchantID:      alert.MerchantID,
+		AlertType:       alert.AlertType.String(),
+		Severity:        alert.Severity.String(),
+		EscalationLevel: alert.EscalationLevel,
+		MaxLevel:        maxLevel,
+		Message:         alert.Message,
+		TriggeredAt:     alert.TriggeredAt,
+	}
+}
@@ -0,0 +1,115 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// 百分比合计允许的误差（四舍五入导致）
+const AnalyticsPercentTolerance = 0.5
+
+// AnalyticsCheckRequest 分析查询校验请求
+type AnalyticsCheckRequest struct {
+	TenantID   uint64    `json:"tenant_id"`
+	StartDate  time.Time `json:"start_date"`
+	EndDate    time.Time `json:"end_date"`
+	MerchantID *uint64   `json:"merchant_id,omitempty"` // 商户级查询使用的商户，为空时跳过商户级检查
+}
+
+// AnalyticsQueryError 分析查询中被降级处理（未向调用方返回）的子查询错误
+type AnalyticsQueryError struct {
+	Query string `json:"query"`
+	Error string `json:"error"`
+}
+
+// AnalyticsCheckResult 单个分析查询的校验结果
+type AnalyticsCheckResult struct {
+	Name        string                `json:"name"`
+	Passed      bool                  `json:"passed"`
+	Skipped     bool                  `json:"skipped,omitempty"`
+	DurationMs  int64                 `json:"duration_ms"`
+	Error       string                `json:"error,omitempty"`        // 查询返回的错误
+	QueryErrors []AnalyticsQueryError `json:"query_errors,omitempty"` // 被降级处理的子查询错误
+	Violations  []string              `json:"violations,omitempty"`   // 不满足的结果约束
+}
+
+// AnalyticsCheckReport 分析查询校验报告
+type AnalyticsCheckReport struct {
+	TenantID  uint64                 `json:"tenant_id"`
+	StartDate time.Time              `json:"start_date"`
+	EndDate   time.Time              `json:"end_date"`
+	Passed    bool                   `json:"passed"`
+	Results   []AnalyticsCheckResult `json:"results"`
+	CheckedAt time.Time              `json:"checked_at"`
+}
+
+// Failed 返回未通过的检查
+func (r *AnalyticsCheckReport) Failed() []AnalyticsCheckResult {
+	failed := make([]AnalyticsCheckResult, 0)
+	for _, result := range r.Results {
+		if !result.Passed && !result.Skipped {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// AnalyticsInvariants 分析查询结果约束检查器，记录所有不满足的约束
+type AnalyticsInvariants struct {
+	violations []string
+}
+
+// Violations 返回不满足的约束
+func (v *AnalyticsInvariants) Violations() []string {
+	return v.violations
+}
+
+// Violatef 记录一条不满足的约束
+func (v *AnalyticsInvariants) Violatef(format string, args ...interface{}) {
+	v.violations = append(v.violations, fmt.Sprintf(format, args...))
+}
+
+// Required 检查必需字段已返回
+func (v *AnalyticsInvariants) Required(field string, present bool) bool {
+	if !present {
+		v.Violatef("%s: 缺少必需字段", field)
+	}
+	return present
+}
+
+// NonNegative 检查计数、金额不为负数
+func (v *AnalyticsInvariants) NonNegative(field string, value float64) {
+	if value < 0 || math.IsNaN(value) {
+		v.Violatef("%s: 不能为负数，实际为 %v", field, value)
+	}
+}
+
+// Range 检查取值在 [min, max] 范围内
+func (v *AnalyticsInvariants) Range(field string, value, min, max float64) {
+	if value < min || value > max || math.IsNaN(value) {
+		v.Violatef("%s: 应在 %v 到 %v 之间，实际为 %v", field, min, max, value)
+	}
+}
+
+// AtMost 检查 value 不超过 limit（如活跃数不超过总数）
+func (v *AnalyticsInvariants) AtMost(field string, value float64, limitField string, limit float64) {
+	if value > limit {
+		v.Violatef("%s: 不能超过 %s（%v），实际为 %v", field, limitField, limit, value)
+	}
+}
+
+// PercentTotal 检查各项百分比之和约为100，所有项都为0时（如没有收入）不检查
+func (v *AnalyticsInvariants) PercentTotal(field string, percentages []float64) {
+	total := 0.0
+	for i, percentage := range percentages {
+		v.Range(fmt.Sprintf("%s[%d]", field, i), percentage, 0, 100)
+		total += percentage
+	}
+	if total == 0 {
+		return
+	}
+	if math.Abs(total-100) > AnalyticsPercentTolerance {
+		v.Violatef("%s: 百分比合计应约为100，实际为 %.2f", field, total)
+	}
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnalyticsInvariants(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(v *AnalyticsInvariants)
+		want  int
+	}{
+		{name: "非负数", check: func(v *AnalyticsInvariants) { v.NonNegative("order_count", 0) }},
+		{name: "负数", check: func(v *AnalyticsInvariants) { v.NonNegative("order_count", -1) }, want: 1},
+		{name: "NaN", check: func(v *AnalyticsInvariants) { v.NonNegative("revenue", math.NaN()) }, want: 1},
+		{name: "缺少必需字段", check: func(v *AnalyticsInvariants) { v.Required("breakdown", false) }, want: 1},
+		{name: "超出范围", check: func(v *AnalyticsInvariants) { v.Range("average_rating", 5.5, 0, 5) }, want: 1},
+		{name: "活跃数超过总数", check: func(v *AnalyticsInvariants) { v.AtMost("active_customer_count", 11, "customer_count", 10) }, want: 1},
+		{name: "百分比合计为100", check: func(v *AnalyticsInvariants) { v.PercentTotal("percentage", []float64{33.33, 33.33, 33.34}) }},
+		{name: "百分比合计在误差内", check: func(v *AnalyticsInvariants) { v.PercentTotal("percentage", []float64{66.7, 33.4}) }},
+		{name: "百分比全为0", check: func(v *AnalyticsInvariants) { v.PercentTotal("percentage", []float64{0, 0}) }},
+		{name: "空列表", check: func(v *AnalyticsInvariants) { v.PercentTotal("percentage", nil) }},
+		{name: "百分比合计不足100", check: func(v *AnalyticsInvariants) { v.PercentTotal("percentage", []float64{50, 30}) }, want: 1},
+		{name: "单项超过100", check: func(v *AnalyticsInvariants) { v.PercentTotal("percentage", []float64{150, -50}) }, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invariants := &AnalyticsInvariants{}
+			tt.check(invariants)
+			if got := len(invariants.Violations()); got != tt.want {
+				t.Errorf("Violations() = %v, want %d violations", invariants.Violations(), tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyticsCheckReportFailed(t *testing.T) {
+	report := &AnalyticsCheckReport{Results: []AnalyticsCheckResult{
+		{Name: "financial_data", Passed: true},
+		{Name: "merchant_summary", Skipped: true},
+		{Name: "customer_analysis", Violations: []string{"activity_metrics: 缺少必需字段"}},
+	}}
+
+	failed := report.Failed()
+	if len(failed) != 1 || failed[0].Name != "customer_analysis" {
+		t.Errorf("Failed() = %v, want only customer_analysis", failed)
+	}
+}
@@ -0,0 +1,148 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ExportKindAnalyticsFacts 分析事实数据导出
+const ExportKindAnalyticsFacts = "analytics_facts"
+
+// AnalyticsFactFormat 分析事实数据的文件格式
+type AnalyticsFactFormat string
+
+const (
+	AnalyticsFactFormatCSV   AnalyticsFactFormat = "csv"   // 带表头的CSV，UTF-8编码
+	AnalyticsFactFormatJSONL AnalyticsFactFormat = "jsonl" // 每行一个JSON对象
+)
+
+// IsValid 是否为支持的格式
+func (f AnalyticsFactFormat) IsValid() bool {
+	return f == AnalyticsFactFormatCSV || f == AnalyticsFactFormatJSONL
+}
+
+// ContentType 文件的MIME类型
+func (f AnalyticsFactFormat) ContentType() string {
+	if f == AnalyticsFactFormatJSONL {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// AnalyticsFactGrain 事实行的时间粒度
+type AnalyticsFactGrain string
+
+const (
+	AnalyticsFactGrainPeriod AnalyticsFactGrain = "period" // 整个统计区间的汇总，日期为区间开始日期
+	AnalyticsFactGrainMonth  AnalyticsFactGrain = "month"  // 月度数据，日期为当月1日
+)
+
+// 事实值的单位
+const (
+	AnalyticsFactUnitCount  = "count"  // 数量
+	AnalyticsFactUnitRights = "rights" // 权益点数
+	AnalyticsFactUnitRate   = "rate"   // 比率或百分比，与分析接口返回的值一致
+	AnalyticsFactUnitScore  = "score"  // 评分
+	AnalyticsFactUnitMinute = "minute" // 分钟
+)
+
+// AnalyticsFactColumns 事实数据的列，CSV表头按此顺序输出
+var AnalyticsFactColumns = []string{
+	"dataset", "date", "grain", "merchant_id", "merchant_name",
+	"category_id", "category_name", "metric", "value", "unit",
+}
+
+// AnalyticsFact 分析数据的规范化事实行：每行只有一个指标值，日期、商户、类别作为维度，
+// 便于BI工具直接加载。没有商户或类别维度的行对应的ID为0、名称为空。
+// 金额的单位为币种代码，如 CNY
+type AnalyticsFact struct {
+	Dataset      ReportType         `json:"dataset"`       // financial, merchant_operation, customer_analysis
+	Date         string             `json:"date"`          // YYYY-MM-DD
+	Grain        AnalyticsFactGrain `json:"grain"`         // period 或 month
+	MerchantID   uint64             `json:"merchant_id"`   // 商户维度，0 表示全部商户
+	MerchantName string             `json:"merchant_name"` // 商户名称
+	CategoryID   uint64             `json:"category_id"`   // 类别维度，0 表示全部类别
+	CategoryName string             `json:"category_name"` // 类别名称，支出类型、支付方式等非商品类别也记在此列
+	Metric       string             `json:"metric"`        // 指标名称，如 revenue、order_count
+	Value        float64            `json:"value"`         // 指标值
+	Unit         string             `json:"unit"`          // 单位
+}
+
+// Record CSV中的一行，顺序与 AnalyticsFactColumns 一致
+func (f *AnalyticsFact) Record() []string {
+	return []string{
+		string(f.Dataset),
+		f.Date,
+		string(f.Grain),
+		strconv.FormatUint(f.MerchantID, 10),
+		f.MerchantName,
+		strconv.FormatUint(f.CategoryID, 10),
+		f.CategoryName,
+		f.Metric,
+		strconv.FormatFloat(f.Value, 'f', -1, 64),
+		f.Unit,
+	}
+}
+
+// AnalyticsFactsExportQuery 分析事实数据导出条件，文件由导出队列在后台生成
+type AnalyticsFactsExportQuery struct {
+	Dataset   ReportType          `json:"dataset"`
+	Format    AnalyticsFactFormat `json:"format"`
+	StartDate time.Time           `json:"start_date"`
+	EndDate   time.Time           `json:"end_date"`
+}
+
+// AnalyticsExportDatasets 支持导出为事实数据的分析数据集
+var AnalyticsExportDatasets = []ReportType{
+	ReportTypeFinancial,
+	ReportTypeMerchantOperation,
+	ReportTypeCustomerAnalysis,
+}
+
+// AnalyticsExportPolicy 租户分析数据定时导出配置：每天导出前一天的分析数据到对象存储，
+// 按 analytics/{租户ID}/{数据集}/dt={日期}/ 分区存放，供BI工具加载
+type AnalyticsExportPolicy struct {
+	Enabled  bool                `json:"enabled"`
+	Datasets []ReportType        `json:"datasets"` // 导出的数据集，为空时导出全部支持的数据集
+	Format   AnalyticsFactFormat `json:"format"`   // csv 或 jsonl，为空时为 csv
+}
+
+// Validate 校验分析数据导出配置
+func (p *AnalyticsExportPolicy) Validate() error {
+	if p.Format != "" && !p.Format.IsValid() {
+		return fmt.Errorf("不支持的分析数据导出格式: %s，仅支持 csv、jsonl", p.Format)
+	}
+	for _, dataset := range p.Datasets {
+		if !isAnalyticsExportDataset(dataset) {
+			return fmt.Errorf("不支持导出的分析数据集: %s", dataset)
+		}
+	}
+	return nil
+}
+
+// ExportDatasets 实际导出的数据集
+func (p *AnalyticsExportPolicy) ExportDatasets() []ReportType {
+	if len(p.Datasets) == 0 {
+		return AnalyticsExportDatasets
+	}
+	return p.Datasets
+}
+
+// ExportFormat 实际导出的文件格式
+func (p *AnalyticsExportPolicy) ExportFormat() AnalyticsFactFormat {
+	if p.Format == "" {
+		return AnalyticsFactFormatCSV
+	}
+	return p.Format
+}
+
+// isAnalyticsExportDataset 数据集是否支持导出
+func isAnalyticsExportDataset(dataset ReportType) bool {
+	for _, supported := range AnalyticsExportDatasets {
+		if dataset == supported {
+			return true
+		}
+	}
+	return false
+}
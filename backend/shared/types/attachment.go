@@ -0,0 +1,76 @@
+package types
+
+import "time"
+
+// AttachmentTargetType 附件关联的业务记录类型
+type AttachmentTargetType string
+
+const (
+	AttachmentTargetAuditEvent AttachmentTargetType = "audit_event" // 审计事件，目标ID为审计事件的 event_id
+	AttachmentTargetRefund     AttachmentTargetType = "refund"      // 订单退款，目标ID为退款订单ID
+)
+
+// IsValid 检查关联类型是否有效
+func (t AttachmentTargetType) IsValid() bool {
+	return t == AttachmentTargetAuditEvent || t == AttachmentTargetRefund
+}
+
+// RequiredPermissions 访问该类型记录的附件所需的权限（满足其一即可）
+func (t AttachmentTargetType) RequiredPermissions() []Permission {
+	switch t {
+	case AttachmentTargetAuditEvent:
+		return []Permission{PermissionSystemAudit}
+	case AttachmentTargetRefund:
+		return []Permission{PermissionOrderUpdate, PermissionMerchantOrderProcess}
+	default:
+		return nil
+	}
+}
+
+// Attachment 证明材料附件：文件本身存放在对象存储，这里只记录元数据和存储位置
+type Attachment struct {
+	ID          uint64               `json:"id" db:"id"`
+	TenantID    uint64               `json:"tenant_id" db:"tenant_id"`
+	MerchantID  uint64               `json:"merchant_id" db:"merchant_id"` // 上传者所属商户，租户员工上传时为0
+	TargetType  AttachmentTargetType `json:"target_type,omitempty" db:"target_type"`
+	TargetID    string               `json:"target_id,omitempty" db:"target_id"`
+	FileName    string               `json:"file_name" db:"file_name"`
+	ContentType string               `json:"content_type" db:"content_type"`
+	Size        int64                `json:"size" db:"size"`
+	Checksum    string               `json:"checksum" db:"checksum"` // 文件内容的 SHA-256
+	ObjectKey   string               `json:"-" db:"object_key"`
+	UploadedBy  uint64               `json:"uploaded_by" db:"uploaded_by"`
+	LinkedAt    *time.Time           `json:"linked_at,omitempty" db:"linked_at"`
+	CreatedAt   time.Time            `json:"created_at" db:"created_at"`
+}
+
+// IsLinked 附件是否已关联到业务记录
+func (a *Attachment) IsLinked() bool {
+	return a.TargetType != "" && a.TargetID != ""
+}
+
+// Reference 附件引用，审计事件详情中只记录引用，不记录文件内容
+func (a *Attachment) Reference() AttachmentReference {
+	return AttachmentReference{
+		ID:          a.ID,
+		FileName:    a.FileName,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Checksum:    a.Checksum,
+	}
+}
+
+// AttachmentReference 附件引用
+type AttachmentReference struct {
+	ID          uint64 `json:"id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+}
+
+// LinkAttachmentRequest 关联附件请求
+type LinkAttachmentRequest struct {
+	TargetType AttachmentTargetType `json:"target_type" v:"required#关联类型不能为空"`
+	TargetID   string               `json:"target_id" v:"required|length:1,64#关联记录ID不能为空|关联记录ID长度为1-64个字符"`
+}
@@ -79,6 +79,9 @@ const (
 	PermissionSystemAudit  Permission = "system:audit"
 	PermissionSystemLog    Permission = "system:log"
 
+	// 通知权限
+	PermissionNotificationBroadcast Permission = "notification:broadcast"
+
 	// 角色管理权限
 	PermissionRoleView   Permission = "role:view"
 	PermissionRoleManage Permission = "role:manage"
@@ -109,16 +112,36 @@ type Role struct {
 	Type        RoleType     `json:"type"`
 	Name        string       `json:"name"`
 	Description string       `json:"description"`
+	IsSystem    bool         `json:"is_system"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// CreateRoleRequest 创建角色请求
+type CreateRoleRequest struct {
+	Type        RoleType     `json:"type" v:"required|length:2,50#角色标识不能为空|角色标识长度为2-50字符"`
+	Name        string       `json:"name" v:"required|length:1,100#角色名称不能为空|角色名称长度为1-100字符"`
+	Description string       `json:"description"`
 	Permissions []Permission `json:"permissions"`
 }
 
-// GetDefaultRoles 获取默认角色配置
+// RolePermissionsRequest 角色权限授予/撤销请求
+type RolePermissionsRequest struct {
+	Permissions []Permission `json:"permissions" v:"required#权限列表不能为空"`
+}
+
+// AssignUserRoleRequest 为用户分配角色请求
+type AssignUserRoleRequest struct {
+	UserID uint64 `json:"user_id" v:"required|min:1#用户ID不能为空|用户ID必须大于0"`
+}
+
+// GetDefaultRoles 获取默认角色配置（系统内置角色，作为种子数据写入roles/role_permissions表）
 func GetDefaultRoles() map[RoleType]Role {
 	return map[RoleType]Role{
 		RoleTenantAdmin: {
 			Type:        RoleTenantAdmin,
 			Name:        "租户管理员",
 			Description: "拥有租户内所有权限",
+			IsSystem:    true,
 			Permissions: []Permission{
 				// 用户管理
 				PermissionUserManage, PermissionUserView, PermissionUserCreate, PermissionUserUpdate, PermissionUserDelete,
@@ -138,6 +161,8 @@ func GetDefaultRoles() map[RoleType]Role {
 				PermissionBenefitView, PermissionBenefitManage, PermissionBenefitCreate, PermissionBenefitUpdate, PermissionBenefitDelete,
 				// 系统管理
 				PermissionSystemConfig, PermissionSystemAudit, PermissionSystemLog,
+				// 通知管理
+				PermissionNotificationBroadcast,
 				// 角色管理
 				PermissionRoleView, PermissionRoleManage, PermissionRoleAssign,
 			},
@@ -146,6 +171,7 @@ func GetDefaultRoles() map[RoleType]Role {
 			Type:        RoleMerchant,
 			Name:        "商户",
 			Description: "管理自己的商品和订单",
+			IsSystem:    true,
 			Permissions: []Permission{
 				// 订单管理
 				PermissionOrderView, PermissionOrderCreate, PermissionOrderUpdate,
@@ -163,6 +189,7 @@ func GetDefaultRoles() map[RoleType]Role {
 			Type:        RoleMerchantAdmin,
 			Name:        "商户管理员",
 			Description: "管理商户内所有权限，包括用户管理",
+			IsSystem:    true,
 			Permissions: []Permission{
 				// 商户级商品管理
 				PermissionMerchantProductView, PermissionMerchantProductCreate, PermissionMerchantProductEdit, PermissionMerchantProductDelete,
@@ -178,6 +205,7 @@ func GetDefaultRoles() map[RoleType]Role {
 			Type:        RoleMerchantOperator,
 			Name:        "商户操作员",
 			Description: "商户日常运营权限，不包括用户管理",
+			IsSystem:    true,
 			Permissions: []Permission{
 				// 商户级商品管理（不包括删除）
 				PermissionMerchantProductView, PermissionMerchantProductCreate, PermissionMerchantProductEdit,
@@ -191,6 +219,7 @@ func GetDefaultRoles() map[RoleType]Role {
 			Type:        RoleCustomer,
 			Name:        "客户",
 			Description: "查看商品和管理自己的订单",
+			IsSystem:    true,
 			Permissions: []Permission{
 				PermissionProductView,
 				PermissionOrderView, PermissionOrderCreate,
@@ -209,6 +238,33 @@ func (r Role) HasPermission(permission Permission) bool {
 	return false
 }
 
+// IsSystemRole 检查角色标识是否为系统内置角色
+func IsSystemRole(roleType RoleType) bool {
+	_, exists := GetDefaultRoles()[roleType]
+	return exists
+}
+
+// ResolvePermissions 根据角色定义解析用户的有效权限（去重，保持授予顺序）
+func ResolvePermissions(roles []RoleType, definitions map[RoleType]Role) []Permission {
+	seen := make(map[Permission]bool)
+	result := make([]Permission, 0)
+
+	for _, roleType := range roles {
+		role, exists := definitions[roleType]
+		if !exists {
+			continue
+		}
+		for _, permission := range role.Permissions {
+			if !seen[permission] {
+				seen[permission] = true
+				result = append(result, permission)
+			}
+		}
+	}
+
+	return result
+}
+
 // UserPermissions 用户权限信息
 type UserPermissions struct {
 	UserID      uint64       `json:"user_id"`
@@ -236,4 +292,62 @@ func (up UserPermissions) HasRole(role RoleType) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+// EffectivePermissions 当前用户的有效权限（供前端控制功能可见性）
+type EffectivePermissions struct {
+	UserID      uint64       `json:"user_id"`
+	TenantID    uint64       `json:"tenant_id"`
+	MerchantID  *uint64      `json:"merchant_id,omitempty"`
+	Roles       []RoleType   `json:"roles"`
+	Permissions []Permission `json:"permissions"`
+	Features    []string     `json:"features"`
+}
+
+// tenantOnlyPermissions 获取仅属于租户级管理的权限（租户管理员拥有，但任何商户级内置角色都不拥有）
+func tenantOnlyPermissions() map[Permission]bool {
+	defaultRoles := GetDefaultRoles()
+
+	merchantScoped := make(map[Permission]bool)
+	for _, roleType := range []RoleType{RoleMerchant, RoleMerchantAdmin, RoleMerchantOperator, RoleCustomer} {
+		for _, permission := range defaultRoles[roleType].Permissions {
+			merchantScoped[permission] = true
+		}
+	}
+
+	result := make(map[Permission]bool)
+	for _, permission := range defaultRoles[RoleTenantAdmin].Permissions {
+		if !merchantScoped[permission] {
+			result[permission] = true
+		}
+	}
+	return result
+}
+
+// ScopePermissionsToMerchant 过滤掉商户用户不应持有的租户级权限
+func ScopePermissionsToMerchant(permissions []Permission) []Permission {
+	tenantOnly := tenantOnlyPermissions()
+
+	result := make([]Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		if !tenantOnly[permission] {
+			result = append(result, permission)
+		}
+	}
+	return result
+}
+
+// 验证码提供者类型
+const (
+	CaptchaProviderHCaptcha  = "hcaptcha"
+	CaptchaProviderReCaptcha = "recaptcha"
+	CaptchaProviderLocal     = "local"
+)
+
+// CaptchaChallenge 登录人机验证挑战
+type CaptchaChallenge struct {
+	Required    bool   `json:"challenge_required"`
+	Provider    string `json:"provider"`
+	SiteKey     string `json:"site_key,omitempty"`     // 第三方验证码的站点密钥
+	ChallengeID string `json:"challenge_id,omitempty"` // 本地验证码挑战ID
+	Image       string `json:"image,omitempty"`        // 本地验证码图片（data URI）
+}
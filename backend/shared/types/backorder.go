@@ -0,0 +1,179 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInsufficientStock 可用库存不足且商品不接受预订
+	ErrInsufficientStock = errors.New("可用库存不足")
+	// ErrBackorderLimitExceeded 预订数量超过商品允许的预订上限
+	ErrBackorderLimitExceeded = errors.New("超出商品可预订数量")
+	// ErrOrderOnBackorderHold 订单仍有待补货的预订商品，补货释放前不能转为处理中
+	ErrOrderOnBackorderHold = errors.New("订单包含待补货的预订商品")
+)
+
+// ValidateBackorderLimit 校验预订上限，不接受预订的商品不能设置上限
+func ValidateBackorderLimit(inventory *InventoryInfo) error {
+	if inventory == nil {
+		return nil
+	}
+	if inventory.BackorderLimit < 0 {
+		return errors.New("可预订数量不能为负数")
+	}
+	if !inventory.AllowBackorder && inventory.BackorderLimit > 0 {
+		return errors.New("未开启预订的商品不能设置可预订数量")
+	}
+	return nil
+}
+
+// MinStockQuantity 库存允许达到的最小值，接受预订的商品可以为负
+func (ii *InventoryInfo) MinStockQuantity() int {
+	if ii.AllowBackorder {
+		return -ii.BackorderLimit
+	}
+	return 0
+}
+
+// CheckReserve 校验在当前预留基础上再预留 quantity 件是否可行，返回其中超出可用库存、以预订方式接受的数量
+func (ii *InventoryInfo) CheckReserve(quantity int) (int, error) {
+	if !ii.TrackInventory {
+		return 0, nil
+	}
+
+	available := ii.AvailableStock()
+	if quantity <= available {
+		return 0, nil
+	}
+	if !ii.AllowBackorder {
+		return 0, ErrInsufficientStock
+	}
+	if available-quantity < ii.MinStockQuantity() {
+		return 0, ErrBackorderLimitExceeded
+	}
+
+	// 可用库存已为负时，本次数量全部为预订
+	if available < 0 {
+		available = 0
+	}
+	return quantity - available, nil
+}
+
+// IsBackordered 订单项是否包含预订数量
+func (oi *OrderItem) IsBackordered() bool {
+	return oi.BackorderedQuantity > 0
+}
+
+// HasBackorderedItems 订单是否包含预订商品
+func (o *Order) HasBackorderedItems() bool {
+	for i := range o.Items {
+		if o.Items[i].IsBackordered() {
+			return true
+		}
+	}
+	return false
+}
+
+// BackorderRestockAt 订单中预订商品最晚的预计补货日期；
+// 没有预订商品时返回 nil 和 true，有预订商品但未提供补货日期时返回 nil 和 false
+func (o *Order) BackorderRestockAt() (*time.Time, bool) {
+	var latest *time.Time
+	for i := range o.Items {
+		item := &o.Items[i]
+		if !item.IsBackordered() {
+			continue
+		}
+		if item.EstimatedRestockAt == nil {
+			return nil, false
+		}
+		if latest == nil || item.EstimatedRestockAt.After(*latest) {
+			latest = item.EstimatedRestockAt
+		}
+	}
+	return latest, true
+}
+
+// BackorderedQuantityOf 订单中指定商品待补货的预订数量
+func (o *Order) BackorderedQuantityOf(productID uint64) int {
+	quantity := 0
+	for i := range o.Items {
+		if o.Items[i].ProductID == productID {
+			quantity += o.Items[i].BackorderedQuantity
+		}
+	}
+	return quantity
+}
+
+// ReleasableBackorders 补货后可释放的预订数量。预订数量已计入预留或已从库存扣减，
+// 可用库存为负的部分是尚未到货的预订，outstanding 件待补货预订中超出这部分的已有库存可以发货
+func (ii *InventoryInfo) ReleasableBackorders(outstanding int) int {
+	uncovered := 0
+	if available := ii.AvailableStock(); available < 0 {
+		uncovered = -available
+	}
+	if outstanding <= uncovered {
+		return 0
+	}
+	return outstanding - uncovered
+}
+
+// BackorderRelease 补货后一个订单释放的预订数量
+type BackorderRelease struct {
+	OrderID     uint64 `json:"order_id"`
+	OrderNumber string `json:"order_number"`
+	Quantity    int    `json:"quantity"`  // 本次释放的数量
+	Remaining   int    `json:"remaining"` // 该商品仍待补货的数量
+	// 订单全部预订商品已释放，已支付的订单随即转为处理中
+	Fulfilled bool `json:"fulfilled"`
+}
+
+// BackorderReleaseResult 一次补货释放的结果
+type BackorderReleaseResult struct {
+	ProductID   uint64             `json:"product_id"`
+	Outstanding int                `json:"outstanding"` // 释放前待补货的预订数量
+	Released    int                `json:"released"`
+	Releases    []BackorderRelease `json:"releases"`
+	// 转为处理中的订单
+	ProcessingOrderIDs []uint64 `json:"processing_order_ids"`
+}
+
+// AllocateBackorders 按订单先后（orders 须按下单时间升序）将 available 件库存分配给订单中该商品的预订数量，
+// 先下单的订单全部满足后才分配给后面的订单。直接扣减订单项的预订数量，返回各订单本次释放的数量
+func AllocateBackorders(productID uint64, available int, orders []*Order) []BackorderRelease {
+	var releases []BackorderRelease
+	for _, order := range orders {
+		if available <= 0 {
+			break
+		}
+
+		released := 0
+		for i := range order.Items {
+			item := &order.Items[i]
+			if item.ProductID != productID || !item.IsBackordered() {
+				continue
+			}
+			quantity := item.BackorderedQuantity
+			if quantity > available {
+				quantity = available
+			}
+			item.BackorderedQuantity -= quantity
+			available -= quantity
+			released += quantity
+			if available == 0 {
+				break
+			}
+		}
+
+		if released > 0 {
+			releases = append(releases, BackorderRelease{
+				OrderID:     order.ID,
+				OrderNumber: order.OrderNumber,
+				Quantity:    released,
+				Remaining:   order.BackorderedQuantityOf(productID),
+				Fulfilled:   !order.HasBackorderedItems(),
+			})
+		}
+	}
+	return releases
+}
@@ -0,0 +1,144 @@
+package types
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInventoryInfoCheckReserve(t *testing.T) {
+	tests := []struct {
+		name            string
+		inventory       InventoryInfo
+		quantity        int
+		wantBackordered int
+		wantErr         error
+	}{
+		{name: "不跟踪库存", inventory: InventoryInfo{StockQuantity: 0}, quantity: 10},
+		{name: "库存充足", inventory: InventoryInfo{StockQuantity: 10, ReservedQuantity: 4, TrackInventory: true}, quantity: 6},
+		{name: "不接受预订时库存不足", inventory: InventoryInfo{StockQuantity: 10, ReservedQuantity: 4, TrackInventory: true}, quantity: 7, wantErr: ErrInsufficientStock},
+		{name: "部分预订", inventory: InventoryInfo{StockQuantity: 5, TrackInventory: true, AllowBackorder: true, BackorderLimit: 3}, quantity: 8, wantBackordered: 3},
+		{name: "超出预订上限", inventory: InventoryInfo{StockQuantity: 5, TrackInventory: true, AllowBackorder: true, BackorderLimit: 3}, quantity: 9, wantErr: ErrBackorderLimitExceeded},
+		{name: "可用库存已为负时全部为预订", inventory: InventoryInfo{StockQuantity: 0, ReservedQuantity: 2, TrackInventory: true, AllowBackorder: true, BackorderLimit: 5}, quantity: 3, wantBackordered: 3},
+		{name: "预订额度已用完", inventory: InventoryInfo{StockQuantity: 0, ReservedQuantity: 5, TrackInventory: true, AllowBackorder: true, BackorderLimit: 5}, quantity: 1, wantErr: ErrBackorderLimitExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backordered, err := tt.inventory.CheckReserve(tt.quantity)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckReserve() error = %v, want %v", err, tt.wantErr)
+			}
+			if backordered != tt.wantBackordered {
+				t.Errorf("CheckReserve() backordered = %d, want %d", backordered, tt.wantBackordered)
+			}
+		})
+	}
+}
+
+func TestOrderBackorderRestockAt(t *testing.T) {
+	early := time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC)
+	late := early.AddDate(0, 0, 5)
+
+	tests := []struct {
+		name      string
+		items     []OrderItem
+		want      *time.Time
+		wantKnown bool
+	}{
+		{name: "没有预订商品", items: []OrderItem{{ProductID: 1, Quantity: 2}}, wantKnown: true},
+		{name: "取最晚的补货日期", items: []OrderItem{
+			{ProductID: 1, BackorderedQuantity: 1, EstimatedRestockAt: &late},
+			{ProductID: 2, BackorderedQuantity: 2, EstimatedRestockAt: &early},
+		}, want: &late, wantKnown: true},
+		{name: "预订商品未提供补货日期", items: []OrderItem{
+			{ProductID: 1, BackorderedQuantity: 1, EstimatedRestockAt: &early},
+			{ProductID: 2, BackorderedQuantity: 1},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &Order{Items: tt.items}
+			got, known := order.BackorderRestockAt()
+			if known != tt.wantKnown {
+				t.Fatalf("BackorderRestockAt() known = %v, want %v", known, tt.wantKnown)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("BackorderRestockAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryInfoReleasableBackorders(t *testing.T) {
+	tests := []struct {
+		name        string
+		inventory   InventoryInfo
+		outstanding int
+		want        int
+	}{
+		{name: "没有待补货预订", inventory: InventoryInfo{StockQuantity: 10}, want: 0},
+		{name: "尚未补货", inventory: InventoryInfo{StockQuantity: -5}, outstanding: 5, want: 0},
+		{name: "部分补货", inventory: InventoryInfo{StockQuantity: -1}, outstanding: 5, want: 4},
+		{name: "全部补货", inventory: InventoryInfo{StockQuantity: 3}, outstanding: 5, want: 5},
+		{name: "未支付订单的预订计入预留", inventory: InventoryInfo{StockQuantity: 2, ReservedQuantity: 4}, outstanding: 3, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.inventory.ReleasableBackorders(tt.outstanding); got != tt.want {
+				t.Errorf("ReleasableBackorders() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateBackorders(t *testing.T) {
+	newOrders := func() []*Order {
+		return []*Order{
+			{ID: 1, Items: []OrderItem{{ProductID: 7, Quantity: 3, BackorderedQuantity: 3}}},
+			{ID: 2, Items: []OrderItem{{ProductID: 7, Quantity: 2, BackorderedQuantity: 2}, {ProductID: 8, Quantity: 1, BackorderedQuantity: 1}}},
+			{ID: 3, Items: []OrderItem{{ProductID: 7, Quantity: 4, BackorderedQuantity: 1}}},
+		}
+	}
+
+	t.Run("部分满足时先下单的订单优先", func(t *testing.T) {
+		orders := newOrders()
+		releases := AllocateBackorders(7, 4, orders)
+		if len(releases) != 2 {
+			t.Fatalf("releases = %+v, want 2", releases)
+		}
+		if releases[0].OrderID != 1 || releases[0].Quantity != 3 || !releases[0].Fulfilled {
+			t.Errorf("第一个订单应全部释放, got %+v", releases[0])
+		}
+		if releases[1].OrderID != 2 || releases[1].Quantity != 1 || releases[1].Remaining != 1 || releases[1].Fulfilled {
+			t.Errorf("第二个订单应释放1件, got %+v", releases[1])
+		}
+		if orders[2].BackorderedQuantityOf(7) != 1 {
+			t.Error("后下单的订单不应释放")
+		}
+	})
+
+	t.Run("全部满足", func(t *testing.T) {
+		orders := newOrders()
+		releases := AllocateBackorders(7, 10, orders)
+		released := 0
+		for _, release := range releases {
+			released += release.Quantity
+		}
+		if released != 6 {
+			t.Errorf("released = %d, want 6", released)
+		}
+		// 订单2还有其他商品待补货，不能转为处理中
+		if !releases[0].Fulfilled || releases[1].Fulfilled || !releases[2].Fulfilled {
+			t.Errorf("releases = %+v", releases)
+		}
+	})
+
+	t.Run("没有可释放库存", func(t *testing.T) {
+		if releases := AllocateBackorders(7, 0, newOrders()); len(releases) != 0 {
+			t.Errorf("releases = %+v, want none", releases)
+		}
+	})
+}
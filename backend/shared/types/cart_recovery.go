@@ -0,0 +1,86 @@
+package types
+
+import "time"
+
+const (
+	// DefaultCartTTL 购物车最后一次变更后的默认保留时间，超过后由定时任务清空
+	DefaultCartTTL = 7 * 24 * time.Hour
+	// DefaultCartAbandonedAfter 购物车闲置多久视为放弃，发送找回提醒
+	DefaultCartAbandonedAfter = 24 * time.Hour
+)
+
+// CartRecoveryPreference 客户购物车找回提醒偏好，未设置时默认接收
+type CartRecoveryPreference struct {
+	TenantID   uint64    `json:"tenant_id" db:"tenant_id"`
+	CustomerID uint64    `json:"customer_id" db:"customer_id"`
+	OptedOut   bool      `json:"opted_out" db:"opted_out"` // 是否退订购物车找回提醒
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// UpdateCartRecoveryPreferenceRequest 更新购物车找回提醒偏好请求
+type UpdateCartRecoveryPreferenceRequest struct {
+	OptedOut bool `json:"opted_out"`
+}
+
+// CartRecoveryItem 找回提醒中列出的购物车商品，价格为当前售价
+type CartRecoveryItem struct {
+	ProductID uint64  `json:"product_id"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// CartRecovery 发送找回提醒时重新校验后的购物车内容
+type CartRecovery struct {
+	Cart        *Cart              `json:"cart"`
+	Items       []CartRecoveryItem `json:"items"`
+	Unavailable []uint64           `json:"unavailable,omitempty"` // 已下架、不在可售时间内或无库存的商品
+	TotalAmount float64            `json:"total_amount"`
+}
+
+// RecoveryDue 购物车有商品、闲置超过 abandonedAfter，且本次闲置期间尚未发送找回提醒。
+// 购物车变更后更新时间晚于上次提醒时间，视为新的闲置期
+func (c *Cart) RecoveryDue(now time.Time, abandonedAfter time.Duration) bool {
+	if len(c.Items) == 0 || now.Sub(c.UpdatedAt) < abandonedAfter {
+		return false
+	}
+	return c.RecoveryNotifiedAt == nil || c.RecoveryNotifiedAt.Before(c.UpdatedAt)
+}
+
+// BuildCartRecovery 按商品当前状态重新校验购物车商品：已下架、不在可售时间内或无可用库存的商品不再提醒，
+// 库存不足的按可购买数量提醒。products 中不存在的商品视为已下架
+func BuildCartRecovery(cart *Cart, products map[uint64]Product, now time.Time) *CartRecovery {
+	recovery := &CartRecovery{Cart: cart, Items: []CartRecoveryItem{}}
+	for _, item := range cart.Items {
+		// 找回提醒只按商品重新校验和展示，套餐购物车项保留在购物车中但不展示
+		if item.BundleID != 0 {
+			continue
+		}
+		product, exists := products[item.ProductID]
+		if !exists || product.Status != ProductStatusActive || !product.InAvailabilityWindow(now) {
+			recovery.Unavailable = append(recovery.Unavailable, item.ProductID)
+			continue
+		}
+
+		quantity := item.Quantity
+		if inventory := product.InventoryInfo; inventory != nil && inventory.TrackInventory {
+			purchasable := inventory.AvailableStock() - inventory.MinStockQuantity()
+			if purchasable <= 0 {
+				recovery.Unavailable = append(recovery.Unavailable, item.ProductID)
+				continue
+			}
+			if quantity > purchasable {
+				quantity = purchasable
+			}
+		}
+
+		recovery.Items = append(recovery.Items, CartRecoveryItem{
+			ProductID: item.ProductID,
+			Name:      product.Name,
+			Quantity:  quantity,
+			UnitPrice: product.PriceAmount,
+		})
+		recovery.TotalAmount += product.PriceAmount * float64(quantity)
+	}
+	return recovery
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCartRecoveryDue(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	idleSince := now.Add(-48 * time.Hour)
+	beforeIdle := idleSince.Add(-time.Hour)
+	afterIdle := idleSince.Add(time.Hour)
+	items := []CartItem{{ProductID: 1, Quantity: 1}}
+
+	tests := []struct {
+		name string
+		cart Cart
+		want bool
+	}{
+		{name: "闲置超过时间且未提醒", cart: Cart{Items: items, UpdatedAt: idleSince}, want: true},
+		{name: "闲置时间不足", cart: Cart{Items: items, UpdatedAt: now.Add(-time.Hour)}},
+		{name: "购物车为空", cart: Cart{UpdatedAt: idleSince}},
+		{name: "本次闲置期已提醒", cart: Cart{Items: items, UpdatedAt: idleSince, RecoveryNotifiedAt: &afterIdle}},
+		{name: "提醒后购物车又有变更", cart: Cart{Items: items, UpdatedAt: idleSince, RecoveryNotifiedAt: &beforeIdle}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cart.RecoveryDue(now, 24*time.Hour); got != tt.want {
+				t.Errorf("RecoveryDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCartRecovery(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	ended := now.Add(-time.Hour)
+	cart := &Cart{ID: 1, Items: []CartItem{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 1},
+		{ProductID: 3, Quantity: 1},
+		{ProductID: 4, Quantity: 5},
+		{ProductID: 5, Quantity: 1},
+		{ProductID: 6, Quantity: 1},
+	}}
+	products := map[uint64]Product{
+		1: {ID: 1, Name: "手冲咖啡豆", PriceAmount: 68, Status: ProductStatusActive},
+		2: {ID: 2, Name: "挂耳咖啡", PriceAmount: 39, Status: ProductStatusInactive},
+		3: {ID: 3, Name: "限定礼盒", PriceAmount: 199, Status: ProductStatusActive, AvailableUntil: &ended},
+		4: {ID: 4, Name: "滤纸", PriceAmount: 10, Status: ProductStatusActive,
+			InventoryInfo: &InventoryInfo{TrackInventory: true, StockQuantity: 3}},
+		5: {ID: 5, Name: "磨豆机", PriceAmount: 299, Status: ProductStatusActive,
+			InventoryInfo: &InventoryInfo{TrackInventory: true, StockQuantity: 0}},
+	}
+
+	recovery := BuildCartRecovery(cart, products, now)
+
+	wantItems := []CartRecoveryItem{
+		{ProductID: 1, Name: "手冲咖啡豆", Quantity: 2, UnitPrice: 68},
+		{ProductID: 4, Name: "滤纸", Quantity: 3, UnitPrice: 10},
+	}
+	if !reflect.DeepEqual(recovery.Items, wantItems) {
+		t.Errorf("Items = %+v, want %+v", recovery.Items, wantItems)
+	}
+	if want := []uint64{2, 3, 5, 6}; !reflect.DeepEqual(recovery.Unavailable, want) {
+		t.Errorf("Unavailable = %v, want %v", recovery.Unavailable, want)
+	}
+	if recovery.TotalAmount != 166 {
+		t.Errorf("TotalAmount = %v, want 166", recovery.TotalAmount)
+	}
+}
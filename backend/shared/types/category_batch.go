@@ -0,0 +1,76 @@
+package types
+
+// CategoryBatchAction 分类批量操作类型
+type CategoryBatchAction string
+
+const (
+	CategoryBatchEnable  CategoryBatchAction = "enable"
+	CategoryBatchDisable CategoryBatchAction = "disable"
+	CategoryBatchDelete  CategoryBatchAction = "delete"
+)
+
+// IsValid 检查批量操作类型是否有效
+func (a CategoryBatchAction) IsValid() bool {
+	return a == CategoryBatchEnable || a == CategoryBatchDisable || a == CategoryBatchDelete
+}
+
+// CategoryChildPolicy 删除分类时子分类的处理策略
+type CategoryChildPolicy string
+
+const (
+	CategoryChildPolicyBlock    CategoryChildPolicy = "block"    // 存在子分类时拒绝删除
+	CategoryChildPolicyCascade  CategoryChildPolicy = "cascade"  // 连同全部子孙分类一起删除
+	CategoryChildPolicyReparent CategoryChildPolicy = "reparent" // 子分类上移到被删分类的父分类下
+)
+
+// IsValid 检查子分类处理策略是否有效
+func (p CategoryChildPolicy) IsValid() bool {
+	return p == CategoryChildPolicyBlock || p == CategoryChildPolicyCascade || p == CategoryChildPolicyReparent
+}
+
+// CategoryBatchRequest 分类批量操作请求
+type CategoryBatchRequest struct {
+	CategoryIDs []uint64            `json:"category_ids" validate:"required,min=1"`
+	Action      CategoryBatchAction `json:"action" validate:"required,oneof=enable disable delete"`
+	// 删除时子分类的处理策略，为空时使用租户配置的默认策略
+	ChildPolicy CategoryChildPolicy `json:"child_policy,omitempty"`
+	// 删除时被删分类下的商品转移到该分类，为空时存在商品的分类拒绝删除
+	ReassignCategoryID *uint64 `json:"reassign_category_id,omitempty"`
+}
+
+// CategoryBatchItemResult 单个分类的批量操作结果
+type CategoryBatchItemResult struct {
+	CategoryID uint64 `json:"category_id"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	// 随该分类一起删除的子孙分类
+	CascadedIDs []uint64 `json:"cascaded_ids,omitempty"`
+	// 上移到父分类下的子分类
+	ReparentedIDs []uint64 `json:"reparented_ids,omitempty"`
+	// 转移到其他分类的商品数量
+	ReassignedProducts int `json:"reassigned_products,omitempty"`
+}
+
+// CategoryBatchResult 分类批量操作结果
+type CategoryBatchResult struct {
+	Action       CategoryBatchAction       `json:"action"`
+	Results      []CategoryBatchItemResult `json:"results"`
+	SuccessCount int                       `json:"success_count"`
+	FailureCount int                       `json:"failure_count"`
+}
+
+// CategoryMove 分类在树中的新位置
+type CategoryMove struct {
+	CategoryID uint64  `json:"category_id"`
+	ParentID   *uint64 `json:"parent_id,omitempty"`
+	Level      int     `json:"level"`
+	Path       string  `json:"path"`
+}
+
+// CategoryDeletion 一次分类删除需要在同一事务中完成的变更
+type CategoryDeletion struct {
+	DeleteIDs []uint64       // 要删除的分类（含级联删除的子孙分类）
+	Moves     []CategoryMove // 上移的子分类及其子孙分类的新位置
+	// 商品转移的目标分类，为空时不转移商品
+	ReassignTo *ProductCategory
+}
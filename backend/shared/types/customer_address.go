@@ -0,0 +1,162 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxCustomerAddresses 每个客户最多保存的收货地址数
+	MaxCustomerAddresses = 20
+	// MaxRecipientNameLength 收件人姓名最大长度（字符数）
+	MaxRecipientNameLength = 50
+	// MaxAddressRegionLength 省、市、区县名称最大长度（字符数）
+	MaxAddressRegionLength = 50
+	// MaxDetailAddressLength 详细地址最大长度（字符数）
+	MaxDetailAddressLength = 200
+)
+
+var (
+	// ErrCustomerAddressNotFound 收货地址不存在或不属于当前客户
+	ErrCustomerAddressNotFound = errors.New("收货地址不存在")
+	// ErrCustomerAddressLimit 客户的收货地址数已达上限
+	ErrCustomerAddressLimit = fmt.Errorf("最多保存%d个收货地址", MaxCustomerAddresses)
+	// ErrInvalidCustomerAddress 收货地址字段校验失败
+	ErrInvalidCustomerAddress = errors.New("收货地址无效")
+)
+
+var (
+	// addressPhonePattern 手机号或带区号的固定电话
+	addressPhonePattern = regexp.MustCompile(`^(1[3-9]\d{9}|0\d{2,3}-?\d{7,8})$`)
+	postalCodePattern   = regexp.MustCompile(`^\d{6}$`)
+)
+
+// CustomerAddress 客户地址簿中的收货地址，按租户和客户隔离
+type CustomerAddress struct {
+	ID            uint64    `json:"id" db:"id"`
+	TenantID      uint64    `json:"tenant_id" db:"tenant_id"`
+	CustomerID    uint64    `json:"customer_id" db:"customer_id"`
+	RecipientName string    `json:"recipient_name" db:"recipient_name"`
+	Phone         string    `json:"phone" db:"phone"`
+	Province      string    `json:"province" db:"province"`
+	City          string    `json:"city" db:"city"`
+	District      string    `json:"district" db:"district"`
+	DetailAddress string    `json:"detail_address" db:"detail_address"`
+	PostalCode    string    `json:"postal_code" db:"postal_code"`
+	IsDefault     bool      `json:"is_default" db:"is_default"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Snapshot 生成订单收货地址快照
+func (a *CustomerAddress) Snapshot() *ShippingAddress {
+	return &ShippingAddress{
+		AddressID:     a.ID,
+		RecipientName: a.RecipientName,
+		Phone:         a.Phone,
+		Province:      a.Province,
+		City:          a.City,
+		District:      a.District,
+		DetailAddress: a.DetailAddress,
+		PostalCode:    a.PostalCode,
+	}
+}
+
+// ShippingAddress 订单收货地址快照，下单后修改或删除地址簿中的地址不影响订单
+type ShippingAddress struct {
+	AddressID     uint64 `json:"address_id"` // 来源地址簿地址ID，地址可能已被删除
+	RecipientName string `json:"recipient_name"`
+	Phone         string `json:"phone"`
+	Province      string `json:"province"`
+	City          string `json:"city"`
+	District      string `json:"district,omitempty"`
+	DetailAddress string `json:"detail_address"`
+	PostalCode    string `json:"postal_code,omitempty"`
+}
+
+// FullAddress 省市区和详细地址拼接的完整地址
+func (a *ShippingAddress) FullAddress() string {
+	return a.Province + a.City + a.District + a.DetailAddress
+}
+
+// Anonymize 匿名化客户时替换收件人、电话和详细地址，保留省市区用于地区统计
+func (a *ShippingAddress) Anonymize() {
+	a.RecipientName = AnonymizedAuthorName
+	a.Phone = ""
+	a.DetailAddress = AnonymizedText
+	a.PostalCode = ""
+}
+
+// String 用于通知和发货单的收货信息：完整地址 收件人 电话
+func (a *ShippingAddress) String() string {
+	return fmt.Sprintf("%s %s %s", a.FullAddress(), a.RecipientName, a.Phone)
+}
+
+// CustomerAddressRequest 新增或修改收货地址请求
+type CustomerAddressRequest struct {
+	RecipientName string `json:"recipient_name" v:"required#收件人不能为空"`
+	Phone         string `json:"phone" v:"required#联系电话不能为空"`
+	Province      string `json:"province" v:"required#省份不能为空"`
+	City          string `json:"city" v:"required#城市不能为空"`
+	District      string `json:"district"`
+	DetailAddress string `json:"detail_address" v:"required#详细地址不能为空"`
+	PostalCode    string `json:"postal_code"`
+	IsDefault     bool   `json:"is_default"` // 设为默认地址，客户的第一个地址始终为默认地址
+}
+
+// Normalize 去除各字段首尾空白
+func (r *CustomerAddressRequest) Normalize() {
+	r.RecipientName = strings.TrimSpace(r.RecipientName)
+	r.Phone = strings.TrimSpace(r.Phone)
+	r.Province = strings.TrimSpace(r.Province)
+	r.City = strings.TrimSpace(r.City)
+	r.District = strings.TrimSpace(r.District)
+	r.DetailAddress = strings.TrimSpace(r.DetailAddress)
+	r.PostalCode = strings.TrimSpace(r.PostalCode)
+}
+
+// Validate 验证收货地址，调用前应先 Normalize
+func (r *CustomerAddressRequest) Validate() error {
+	if r.RecipientName == "" {
+		return errors.New("收件人不能为空")
+	}
+	if utf8.RuneCountInString(r.RecipientName) > MaxRecipientNameLength {
+		return fmt.Errorf("收件人不能超过%d个字符", MaxRecipientNameLength)
+	}
+	if !addressPhonePattern.MatchString(r.Phone) {
+		return errors.New("联系电话格式不正确")
+	}
+	if r.Province == "" || r.City == "" {
+		return errors.New("省份和城市不能为空")
+	}
+	for _, region := range []string{r.Province, r.City, r.District} {
+		if utf8.RuneCountInString(region) > MaxAddressRegionLength {
+			return fmt.Errorf("省、市、区县名称不能超过%d个字符", MaxAddressRegionLength)
+		}
+	}
+	if r.DetailAddress == "" {
+		return errors.New("详细地址不能为空")
+	}
+	if utf8.RuneCountInString(r.DetailAddress) > MaxDetailAddressLength {
+		return fmt.Errorf("详细地址不能超过%d个字符", MaxDetailAddressLength)
+	}
+	if r.PostalCode != "" && !postalCodePattern.MatchString(r.PostalCode) {
+		return errors.New("邮政编码应为6位数字")
+	}
+	return nil
+}
+
+// Apply 将请求中的地址字段写入地址簿地址，不修改默认标记
+func (r *CustomerAddressRequest) Apply(address *CustomerAddress) {
+	address.RecipientName = r.RecipientName
+	address.Phone = r.Phone
+	address.Province = r.Province
+	address.City = r.City
+	address.District = r.District
+	address.DetailAddress = r.DetailAddress
+	address.PostalCode = r.PostalCode
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// MinCustomerRetentionDays 租户可配置的最短保留天数，避免误配置导致活跃客户被匿名化
+	MinCustomerRetentionDays = 30
+	// MaxCustomerRetentionDays 租户可配置的最长保留天数
+	MaxCustomerRetentionDays = 3650
+
+	// AnonymizedUsernamePrefix 匿名化后用户名的前缀
+	AnonymizedUsernamePrefix = "anon_"
+	// AnonymizedEmailDomain 匿名化后邮箱使用的保留域名，不会投递
+	AnonymizedEmailDomain = "anonymized.invalid"
+	// AnonymizedAuthorName 匿名化客户在订单备注中显示的作者名
+	AnonymizedAuthorName = "已匿名客户"
+	// AnonymizedText 替换客户填写的备注、争议描述等自由文本
+	AnonymizedText = "[已匿名化]"
+
+	// anonymizationTokenBytes 匿名化令牌的随机字节数
+	anonymizationTokenBytes = 12
+)
+
+var (
+	// ErrCustomerAlreadyAnonymized 客户个人信息已匿名化
+	ErrCustomerAlreadyAnonymized = errors.New("客户个人信息已匿名化")
+	// ErrAnonymizeNonCustomer 只有客户账户可以匿名化，员工和商户用户需按账户管理流程处理
+	ErrAnonymizeNonCustomer = errors.New("只能匿名化客户账户")
+)
+
+// DataRetentionPolicy 租户客户个人信息保留策略，超过保留期未活跃的客户由定时任务自动匿名化
+type DataRetentionPolicy struct {
+	CustomerRetentionDays int `json:"customer_retention_days"` // 客户最后一次登录或下单后保留个人信息的天数，0 不自动匿名化
+}
+
+// Validate 校验保留策略
+func (p *DataRetentionPolicy) Validate() error {
+	if p.CustomerRetentionDays == 0 {
+		return nil
+	}
+	if p.CustomerRetentionDays < MinCustomerRetentionDays || p.CustomerRetentionDays > MaxCustomerRetentionDays {
+		return fmt.Errorf("客户个人信息保留天数必须为0或在%d-%d之间", MinCustomerRetentionDays, MaxCustomerRetentionDays)
+	}
+	return nil
+}
+
+// Cutoff 最后活跃时间早于返回值的客户需要匿名化，未启用自动匿名化时返回 false
+func (p *DataRetentionPolicy) Cutoff(now time.Time) (time.Time, bool) {
+	if p == nil || p.CustomerRetentionDays <= 0 {
+		return time.Time{}, false
+	}
+	return now.AddDate(0, 0, -p.CustomerRetentionDays), true
+}
+
+// CustomerAnonymization 一次客户匿名化的替换值和结果。
+// 令牌随机生成且不保存与原始信息的对应关系，匿名化后无法还原；订单金额、数量等财务数据保持不变
+type CustomerAnonymization struct {
+	TenantID     uint64    `json:"tenant_id"`
+	UserID       uint64    `json:"user_id"`
+	Token        string    `json:"token"`
+	Username     string    `json:"username"`
+	Email        string    `json:"email"`
+	AnonymizedAt time.Time `json:"anonymized_at"`
+	// 以下为执行结果
+	NotesScrubbed     int64   `json:"notes_scrubbed"`     // 替换的客户备注数
+	DisputesScrubbed  int64   `json:"disputes_scrubbed"`  // 替换的争议描述数
+	HistoryScrubbed   int64   `json:"history_scrubbed"`   // 替换的客户操作原因数
+	OrdersScrubbed    int64   `json:"orders_scrubbed"`    // 替换收货地址快照的订单数
+	AddressesScrubbed int64   `json:"addresses_scrubbed"` // 替换的地址簿地址数
+	RetainedOrders    int64   `json:"retained_orders"`    // 保留的订单数
+	RetainedAmount    float64 `json:"retained_amount"`    // 保留的订单总金额
+}
+
+// NewAnonymizationToken 生成随机匿名化令牌
+func NewAnonymizationToken() (string, error) {
+	buf := make([]byte, anonymizationTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成匿名化令牌失败: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// NewCustomerAnonymization 按令牌生成客户的替换用户名和邮箱
+func NewCustomerAnonymization(user *User, token string, at time.Time) *CustomerAnonymization {
+	return &CustomerAnonymization{
+		TenantID:     user.TenantID,
+		UserID:       user.ID,
+		Token:        token,
+		Username:     AnonymizedUsernamePrefix + token,
+		Email:        token + "@" + AnonymizedEmailDomain,
+		AnonymizedAt: at,
+	}
+}
+
+// Apply 将匿名化结果写入用户：清除手机号、资料和密码，停用账户
+func (a *CustomerAnonymization) Apply(user *User) {
+	anonymizedAt := a.AnonymizedAt
+	user.Username = a.Username
+	user.Email = a.Email
+	user.Phone = ""
+	user.Profile = nil
+	user.PasswordHash = ""
+	user.Status = UserStatusDeactivated
+	user.AnonymizedAt = &anonymizedAt
+	user.UpdatedAt = anonymizedAt
+}
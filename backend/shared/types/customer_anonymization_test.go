@@ -0,0 +1,111 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDataRetentionPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		days    int
+		wantErr bool
+	}{
+		{name: "0 表示不自动匿名化", days: 0},
+		{name: "最短保留天数", days: MinCustomerRetentionDays},
+		{name: "最长保留天数", days: MaxCustomerRetentionDays},
+		{name: "低于最短保留天数", days: MinCustomerRetentionDays - 1, wantErr: true},
+		{name: "超过最长保留天数", days: MaxCustomerRetentionDays + 1, wantErr: true},
+		{name: "负数", days: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&DataRetentionPolicy{CustomerRetentionDays: tt.days}).Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDataRetentionPolicyCutoff(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		policy *DataRetentionPolicy
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "未配置策略", policy: nil},
+		{name: "未启用自动匿名化", policy: &DataRetentionPolicy{}},
+		{name: "按天数向前推算", policy: &DataRetentionPolicy{CustomerRetentionDays: 365}, want: time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC), wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.policy.Cutoff(now)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("Cutoff() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCustomerAnonymizationApply(t *testing.T) {
+	lastLogin := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	user := &User{
+		ID:           7,
+		TenantID:     1,
+		Username:     "zhangsan",
+		Email:        "zhangsan@example.com",
+		Phone:        "13800138000",
+		PasswordHash: "$2a$12$hash",
+		Status:       UserStatusActive,
+		Profile:      &UserProfile{FirstName: "三", LastName: "张", Description: "上海市浦东新区"},
+		LastLoginAt:  &lastLogin,
+	}
+	at := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	anonymization := NewCustomerAnonymization(user, "a1b2c3", at)
+	anonymization.Apply(user)
+
+	if user.Username != "anon_a1b2c3" {
+		t.Errorf("Username = %q", user.Username)
+	}
+	if user.Email != "a1b2c3@"+AnonymizedEmailDomain {
+		t.Errorf("Email = %q", user.Email)
+	}
+	if user.Phone != "" || user.Profile != nil || user.PasswordHash != "" {
+		t.Errorf("个人信息未清除: phone=%q profile=%+v password=%q", user.Phone, user.Profile, user.PasswordHash)
+	}
+	if user.Status != UserStatusDeactivated {
+		t.Errorf("Status = %s, want %s", user.Status, UserStatusDeactivated)
+	}
+	if user.AnonymizedAt == nil || !user.AnonymizedAt.Equal(at) {
+		t.Errorf("AnonymizedAt = %v, want %v", user.AnonymizedAt, at)
+	}
+	if user.ID != 7 || user.TenantID != 1 || user.LastLoginAt != &lastLogin {
+		t.Errorf("非个人信息字段不应变化: %+v", user)
+	}
+}
+
+func TestNewAnonymizationToken(t *testing.T) {
+	first, err := NewAnonymizationToken()
+	if err != nil {
+		t.Fatalf("NewAnonymizationToken() error = %v", err)
+	}
+	second, err := NewAnonymizationToken()
+	if err != nil {
+		t.Fatalf("NewAnonymizationToken() error = %v", err)
+	}
+
+	if len(first) != anonymizationTokenBytes*2 || strings.Trim(first, "0123456789abcdef") != "" {
+		t.Errorf("令牌格式不正确: %q", first)
+	}
+	if first == second {
+		t.Errorf("两次生成的令牌相同: %q", first)
+	}
+}
@@ -0,0 +1,145 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExportKindCustomerSegment 客户分群营销导出
+const ExportKindCustomerSegment = "customer_segment"
+
+// MaxCustomerSegmentExportDays 客户分群导出的最长统计周期
+const MaxCustomerSegmentExportDays = 366
+
+// ErrInvalidCustomerSegment 无效的客户分群
+var ErrInvalidCustomerSegment = errors.New("无效的客户分群，可选值: new/ordinary/loyal/vip")
+
+// CustomerSegment 客户分群，按统计周期内已支付或已完成的订单数划分，与客户分析报表的分群一致
+type CustomerSegment string
+
+const (
+	CustomerSegmentNew      CustomerSegment = "new"      // 新客户
+	CustomerSegmentOrdinary CustomerSegment = "ordinary" // 普通客户
+	CustomerSegmentLoyal    CustomerSegment = "loyal"    // 忠诚客户
+	CustomerSegmentVIP      CustomerSegment = "vip"      // VIP客户
+)
+
+// CustomerSegmentCriteria 客户分群条件
+type CustomerSegmentCriteria struct {
+	Segment   CustomerSegment `json:"segment"`
+	Label     string          `json:"label"`
+	MinOrders int             `json:"min_orders"`
+	MaxOrders int             `json:"max_orders"` // 0 表示不限
+}
+
+// CustomerSegmentDefinitions 客户分群条件，按订单数从少到多排列
+var CustomerSegmentDefinitions = []CustomerSegmentCriteria{
+	{Segment: CustomerSegmentNew, Label: "新客户", MinOrders: 1, MaxOrders: 1},
+	{Segment: CustomerSegmentOrdinary, Label: "普通客户", MinOrders: 2, MaxOrders: 5},
+	{Segment: CustomerSegmentLoyal, Label: "忠诚客户", MinOrders: 6, MaxOrders: 10},
+	{Segment: CustomerSegmentVIP, Label: "VIP客户", MinOrders: 11},
+}
+
+// Criteria 客户分群的条件，无效分群返回 false
+func (s CustomerSegment) Criteria() (CustomerSegmentCriteria, bool) {
+	for _, criteria := range CustomerSegmentDefinitions {
+		if criteria.Segment == s {
+			return criteria, true
+		}
+	}
+	return CustomerSegmentCriteria{}, false
+}
+
+// CustomerSegmentForOrderCount 按订单数确定客户分群，没有订单时返回空
+func CustomerSegmentForOrderCount(orderCount int) CustomerSegment {
+	for _, criteria := range CustomerSegmentDefinitions {
+		if criteria.Matches(orderCount) {
+			return criteria.Segment
+		}
+	}
+	return ""
+}
+
+// Matches 订单数是否满足分群条件
+func (c CustomerSegmentCriteria) Matches(orderCount int) bool {
+	return orderCount >= c.MinOrders && (c.MaxOrders == 0 || orderCount <= c.MaxOrders)
+}
+
+// Description 分群条件说明，写入导出文件
+func (c CustomerSegmentCriteria) Description() string {
+	switch {
+	case c.MaxOrders == 0:
+		return fmt.Sprintf("统计周期内已支付或已完成订单不少于%d单", c.MinOrders)
+	case c.MinOrders == c.MaxOrders:
+		return fmt.Sprintf("统计周期内已支付或已完成订单%d单", c.MinOrders)
+	default:
+		return fmt.Sprintf("统计周期内已支付或已完成订单%d-%d单", c.MinOrders, c.MaxOrders)
+	}
+}
+
+// CustomerSegmentSQL 按订单数字段计算分群名称的 SQL 表达式，供客户分析报表和分群导出共用
+func CustomerSegmentSQL(orderCountColumn string) string {
+	var builder strings.Builder
+	builder.WriteString("CASE")
+	for _, criteria := range CustomerSegmentDefinitions {
+		switch {
+		case criteria.MaxOrders == 0:
+			builder.WriteString(fmt.Sprintf(" WHEN %s >= %d", orderCountColumn, criteria.MinOrders))
+		case criteria.MinOrders == criteria.MaxOrders:
+			builder.WriteString(fmt.Sprintf(" WHEN %s = %d", orderCountColumn, criteria.MinOrders))
+		default:
+			builder.WriteString(fmt.Sprintf(" WHEN %s BETWEEN %d AND %d", orderCountColumn, criteria.MinOrders, criteria.MaxOrders))
+		}
+		builder.WriteString(fmt.Sprintf(" THEN '%s'", criteria.Label))
+	}
+	builder.WriteString(" END")
+	return builder.String()
+}
+
+// CustomerSegmentExportQuery 客户分群营销导出条件
+type CustomerSegmentExportQuery struct {
+	Segment   CustomerSegment   `json:"segment"`
+	StartDate time.Time         `json:"start_date"`
+	EndDate   time.Time         `json:"end_date"` // 不含，统计到结束日期前一天
+	Format    OrderExportFormat `json:"format"`
+}
+
+// Validate 校验导出条件
+func (q *CustomerSegmentExportQuery) Validate() error {
+	if _, ok := q.Segment.Criteria(); !ok {
+		return ErrInvalidCustomerSegment
+	}
+	if q.StartDate.IsZero() || q.EndDate.IsZero() {
+		return fmt.Errorf("统计周期不能为空")
+	}
+	if !q.EndDate.After(q.StartDate) {
+		return fmt.Errorf("结束日期不能早于开始日期")
+	}
+	if q.EndDate.Sub(q.StartDate) > MaxCustomerSegmentExportDays*24*time.Hour {
+		return fmt.Errorf("统计周期不能超过%d天", MaxCustomerSegmentExportDays)
+	}
+	if !q.Format.IsValid() {
+		return fmt.Errorf("不支持的导出格式: %s", q.Format)
+	}
+	return nil
+}
+
+// CustomerSegmentExportRow 客户分群导出的客户及其周期内的订单统计
+type CustomerSegmentExportRow struct {
+	CustomerID      uint64    `json:"customer_id" db:"customer_id"`
+	Username        string    `json:"username" db:"username"`
+	Email           string    `json:"email" db:"email"`
+	Phone           string    `json:"phone" db:"phone"`
+	OrderCount      int       `json:"order_count" db:"order_count"`
+	TotalSpent      float64   `json:"total_spent" db:"total_spent"`
+	LastOrderAt     time.Time `json:"last_order_at" db:"last_order_at"`
+	MarketingOptOut bool      `json:"marketing_opt_out" db:"marketing_opt_out"`
+	Anonymized      bool      `json:"anonymized" db:"anonymized"`
+}
+
+// Contactable 客户可以接收营销信息：未退订且个人信息未匿名化
+func (r *CustomerSegmentExportRow) Contactable() bool {
+	return !r.MarketingOptOut && !r.Anonymized
+}
@@ -0,0 +1,91 @@
+package types
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCustomerSegmentForOrderCount(t *testing.T) {
+	tests := []struct {
+		orderCount int
+		want       CustomerSegment
+	}{
+		{orderCount: 0, want: ""},
+		{orderCount: 1, want: CustomerSegmentNew},
+		{orderCount: 2, want: CustomerSegmentOrdinary},
+		{orderCount: 5, want: CustomerSegmentOrdinary},
+		{orderCount: 6, want: CustomerSegmentLoyal},
+		{orderCount: 10, want: CustomerSegmentLoyal},
+		{orderCount: 11, want: CustomerSegmentVIP},
+		{orderCount: 200, want: CustomerSegmentVIP},
+	}
+
+	for _, tt := range tests {
+		if got := CustomerSegmentForOrderCount(tt.orderCount); got != tt.want {
+			t.Errorf("CustomerSegmentForOrderCount(%d) = %q, want %q", tt.orderCount, got, tt.want)
+		}
+		if tt.want == "" {
+			continue
+		}
+		criteria, ok := tt.want.Criteria()
+		if !ok || !criteria.Matches(tt.orderCount) {
+			t.Errorf("%s 的分群条件应包含 %d 单", tt.want, tt.orderCount)
+		}
+	}
+
+	if _, ok := CustomerSegment("gold").Criteria(); ok {
+		t.Error("未知分群不应有分群条件")
+	}
+}
+
+func TestCustomerSegmentSQL(t *testing.T) {
+	want := "CASE WHEN order_count = 1 THEN '新客户' WHEN order_count BETWEEN 2 AND 5 THEN '普通客户'" +
+		" WHEN order_count BETWEEN 6 AND 10 THEN '忠诚客户' WHEN order_count >= 11 THEN 'VIP客户' END"
+	if got := CustomerSegmentSQL("order_count"); got != want {
+		t.Errorf("CustomerSegmentSQL() = %q, want %q", got, want)
+	}
+}
+
+func TestCustomerSegmentExportQueryValidate(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		query   CustomerSegmentExportQuery
+		wantErr bool
+	}{
+		{name: "有效条件", query: CustomerSegmentExportQuery{Segment: CustomerSegmentVIP, StartDate: start, EndDate: start.AddDate(0, 1, 0), Format: OrderExportFormatCSV}},
+		{name: "无效分群", query: CustomerSegmentExportQuery{Segment: "gold", StartDate: start, EndDate: start.AddDate(0, 1, 0), Format: OrderExportFormatCSV}, wantErr: true},
+		{name: "缺少统计周期", query: CustomerSegmentExportQuery{Segment: CustomerSegmentVIP, Format: OrderExportFormatCSV}, wantErr: true},
+		{name: "结束日期早于开始日期", query: CustomerSegmentExportQuery{Segment: CustomerSegmentVIP, StartDate: start, EndDate: start.AddDate(0, 0, -1), Format: OrderExportFormatCSV}, wantErr: true},
+		{name: "统计周期过长", query: CustomerSegmentExportQuery{Segment: CustomerSegmentVIP, StartDate: start, EndDate: start.AddDate(0, 0, MaxCustomerSegmentExportDays+1), Format: OrderExportFormatCSV}, wantErr: true},
+		{name: "不支持的格式", query: CustomerSegmentExportQuery{Segment: CustomerSegmentVIP, StartDate: start, EndDate: start.AddDate(0, 1, 0), Format: "pdf"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	invalid := CustomerSegmentExportQuery{Segment: "gold", StartDate: start, EndDate: start.AddDate(0, 1, 0), Format: OrderExportFormatCSV}
+	if err := invalid.Validate(); !errors.Is(err, ErrInvalidCustomerSegment) {
+		t.Errorf("无效分群应返回 ErrInvalidCustomerSegment, got %v", err)
+	}
+}
+
+func TestCustomerSegmentExportRowContactable(t *testing.T) {
+	if !(&CustomerSegmentExportRow{}).Contactable() {
+		t.Error("未退订的客户应可联系")
+	}
+	if (&CustomerSegmentExportRow{MarketingOptOut: true}).Contactable() {
+		t.Error("退订营销信息的客户不应可联系")
+	}
+	if (&CustomerSegmentExportRow{Anonymized: true}).Contactable() {
+		t.Error("已匿名化的客户不应可联系")
+	}
+}
@@ -0,0 +1,71 @@
+package types
+
+import "time"
+
+// ExportJobStatus 导出任务状态
+type ExportJobStatus string
+
+const (
+	ExportJobStatusQueued    ExportJobStatus = "queued"    // 排队中
+	ExportJobStatusRunning   ExportJobStatus = "running"   // 生成中
+	ExportJobStatusCompleted ExportJobStatus = "completed" // 已完成，可下载
+	ExportJobStatusFailed    ExportJobStatus = "failed"    // 失败
+)
+
+// IsTerminal 任务是否已结束
+func (s ExportJobStatus) IsTerminal() bool {
+	return s == ExportJobStatusCompleted || s == ExportJobStatusFailed
+}
+
+// CanTransitionTo 状态流转：排队中 → 生成中 → 已完成/失败，排队中的任务也可以直接失败（如超时清理）
+func (s ExportJobStatus) CanTransitionTo(next ExportJobStatus) bool {
+	switch s {
+	case ExportJobStatusQueued:
+		return next == ExportJobStatusRunning || next == ExportJobStatusFailed
+	case ExportJobStatusRunning:
+		return next == ExportJobStatusCompleted || next == ExportJobStatusFailed
+	default:
+		return false
+	}
+}
+
+// 导出任务类型，每种导出在导出队列中注册对应的生成器
+const (
+	ExportKindOrderStatusHistory = "order_status_history"
+	ExportKindOrderExtract       = "order_extract" // 定时订单导出
+)
+
+// ExportJob 导出任务：所有导出统一进入导出队列，在后台生成文件后通过 /exports/:id 查询和下载
+type ExportJob struct {
+	ID           uint64          `json:"-" db:"id"`
+	UUID         string          `json:"id" db:"uuid"`
+	TenantID     uint64          `json:"tenant_id" db:"tenant_id"`
+	Kind         string          `json:"kind" db:"kind"`
+	Params       string          `json:"-" db:"params"` // 导出条件JSON，由对应的生成器解析
+	Format       string          `json:"format" db:"format"`
+	FileName     string          `json:"file_name" db:"file_name"`
+	Status       ExportJobStatus `json:"status" db:"status"`
+	FilePath     string          `json:"-" db:"file_path"`
+	SizeBytes    int64           `json:"size_bytes" db:"size_bytes"`
+	RowCount     int             `json:"row_count" db:"row_count"`
+	ErrorMessage string          `json:"error_message,omitempty" db:"error_message"`
+	RequestedBy  uint64          `json:"requested_by" db:"requested_by"`
+	StartedAt    *time.Time      `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt  *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
+	ExpiresAt    *time.Time      `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// ExportLimits 导出资源限制，均按租户计算，0 表示不限制
+type ExportLimits struct {
+	MaxConcurrent int   `json:"max_concurrent"` // 同时生成的任务数
+	DailyJobs     int   `json:"daily_jobs"`     // 每天可创建的任务数
+	DailyBytes    int64 `json:"daily_bytes"`    // 每天可生成的文件总大小
+}
+
+// ExportUsage 租户当天的导出用量
+type ExportUsage struct {
+	Jobs  int   `json:"jobs" db:"jobs"`
+	Bytes int64 `json:"bytes" db:"bytes"`
+}
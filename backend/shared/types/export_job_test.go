@@ -0,0 +1,42 @@
+package types
+
+import "testing"
+
+func TestExportJobStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		name string
+		from ExportJobStatus
+		to   ExportJobStatus
+		want bool
+	}{
+		{name: "排队中开始生成", from: ExportJobStatusQueued, to: ExportJobStatusRunning, want: true},
+		{name: "排队中直接失败", from: ExportJobStatusQueued, to: ExportJobStatusFailed, want: true},
+		{name: "排队中不能直接完成", from: ExportJobStatusQueued, to: ExportJobStatusCompleted, want: false},
+		{name: "生成中完成", from: ExportJobStatusRunning, to: ExportJobStatusCompleted, want: true},
+		{name: "生成中失败", from: ExportJobStatusRunning, to: ExportJobStatusFailed, want: true},
+		{name: "生成中不能回到排队", from: ExportJobStatusRunning, to: ExportJobStatusQueued, want: false},
+		{name: "已完成不能再变更", from: ExportJobStatusCompleted, to: ExportJobStatusRunning, want: false},
+		{name: "失败不能重新生成", from: ExportJobStatusFailed, to: ExportJobStatusRunning, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+				t.Errorf("%s → %s = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportJobStatus_IsTerminal(t *testing.T) {
+	for status, want := range map[ExportJobStatus]bool{
+		ExportJobStatusQueued:    false,
+		ExportJobStatusRunning:   false,
+		ExportJobStatusCompleted: true,
+		ExportJobStatusFailed:    true,
+	} {
+		if got := status.IsTerminal(); got != want {
+			t.Errorf("%s.IsTerminal() = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// reconciliationTolerance 金额比较容差，低于一分钱的差异视为舍入误差
+const reconciliationTolerance = 0.005
+
+// ReconciliationAnomalyType 对账异常类型
+type ReconciliationAnomalyType string
+
+const (
+	ReconciliationAnomalyRightsOverConsumed   ReconciliationAnomalyType = "rights_over_consumed"   // 权益消耗超过已分配
+	ReconciliationAnomalyRevenueWithoutRights ReconciliationAnomalyType = "revenue_without_rights" // 有订单收入但没有权益消耗记录
+	ReconciliationAnomalyRevenueWithoutFunds  ReconciliationAnomalyType = "revenue_without_funds"  // 有订单收入但没有充值或分配记录
+)
+
+// MerchantReconciliationFigures 商户对账原始数据。周期内数据按订单/资金记录创建时间统计，
+// 累计数据统计截至周期结束的全部记录，用于判断权益是否超额消耗
+type MerchantReconciliationFigures struct {
+	MerchantID     uint64  `json:"merchant_id"`
+	MerchantName   string  `json:"merchant_name"`
+	OrderRevenue   float64 `json:"order_revenue"`    // 周期内已支付和已完成订单收入
+	PaidOrderCount int     `json:"paid_order_count"` // 周期内已支付和已完成订单数
+	RightsConsumed float64 `json:"rights_consumed"`  // 周期内订单消耗的权益
+	Deposits       float64 `json:"deposits"`         // 周期内已确认的充值
+	Allocations    float64 `json:"allocations"`      // 周期内已确认的权益分配
+	TotalCredited  float64 `json:"total_credited"`   // 截至周期结束累计确认的充值和分配
+	TotalConsumed  float64 `json:"total_consumed"`   // 截至周期结束累计消耗的权益
+}
+
+// ReconciliationAnomaly 对账异常
+type ReconciliationAnomaly struct {
+	Type    ReconciliationAnomalyType `json:"type"`
+	Message string                    `json:"message"`
+}
+
+// MerchantReconciliation 单个商户的对账结果
+type MerchantReconciliation struct {
+	MerchantReconciliationFigures
+	RightsBalance float64                 `json:"rights_balance"` // 累计分配减累计消耗
+	Anomalies     []ReconciliationAnomaly `json:"anomalies,omitempty"`
+}
+
+// Balanced 商户收入、权益消耗和资金流水是否一致
+func (m *MerchantReconciliation) Balanced() bool {
+	return len(m.Anomalies) == 0
+}
+
+// ReconciliationReportData 财务对账报表数据，将订单收入、权益消耗与资金充值、分配逐商户对齐
+type ReconciliationReportData struct {
+	StartDate           time.Time                `json:"start_date"`
+	EndDate             time.Time                `json:"end_date"`
+	TotalRevenue        float64                  `json:"total_revenue"`
+	TotalRightsConsumed float64                  `json:"total_rights_consumed"`
+	TotalDeposits       float64                  `json:"total_deposits"`
+	TotalAllocations    float64                  `json:"total_allocations"`
+	MerchantCount       int                      `json:"merchant_count"`
+	AnomalyMerchants    int                      `json:"anomaly_merchants"` // 存在异常的商户数
+	Merchants           []MerchantReconciliation `json:"merchants"`
+}
+
+// NewReconciliationReport 汇总各商户对账数据并标记异常，商户按ID排序
+func NewReconciliationReport(startDate, endDate time.Time, figures []MerchantReconciliationFigures) *ReconciliationReportData {
+	report := &ReconciliationReportData{
+		StartDate:     startDate,
+		EndDate:       endDate,
+		MerchantCount: len(figures),
+		Merchants:     make([]MerchantReconciliation, 0, len(figures)),
+	}
+
+	for _, f := range figures {
+		merchant := MerchantReconciliation{
+			MerchantReconciliationFigures: f,
+			RightsBalance:                 f.TotalCredited - f.TotalConsumed,
+			Anomalies:                     reconciliationAnomalies(f),
+		}
+		if !merchant.Balanced() {
+			report.AnomalyMerchants++
+		}
+
+		report.TotalRevenue += f.OrderRevenue
+		report.TotalRightsConsumed += f.RightsConsumed
+		report.TotalDeposits += f.Deposits
+		report.TotalAllocations += f.Allocations
+		report.Merchants = append(report.Merchants, merchant)
+	}
+
+	sort.Slice(report.Merchants, func(i, j int) bool {
+		return report.Merchants[i].MerchantID < report.Merchants[j].MerchantID
+	})
+	return report
+}
+
+// reconciliationAnomalies 检查单个商户的对账异常
+func reconciliationAnomalies(f MerchantReconciliationFigures) []ReconciliationAnomaly {
+	var anomalies []ReconciliationAnomaly
+
+	if f.TotalConsumed-f.TotalCredited > reconciliationTolerance {
+		anomalies = append(anomalies, ReconciliationAnomaly{
+			Type:    ReconciliationAnomalyRightsOverConsumed,
+			Message: fmt.Sprintf("累计消耗权益%.2f超过累计分配%.2f，差额%.2f", f.TotalConsumed, f.TotalCredited, f.TotalConsumed-f.TotalCredited),
+		})
+	}
+	if f.OrderRevenue > reconciliationTolerance && f.RightsConsumed <= reconciliationTolerance {
+		anomalies = append(anomalies, ReconciliationAnomaly{
+			Type:    ReconciliationAnomalyRevenueWithoutRights,
+			Message: fmt.Sprintf("订单收入%.2f没有对应的权益消耗记录", f.OrderRevenue),
+		})
+	}
+	if f.OrderRevenue > reconciliationTolerance && f.TotalCredited <= reconciliationTolerance {
+		anomalies = append(anomalies, ReconciliationAnomaly{
+			Type:    ReconciliationAnomalyRevenueWithoutFunds,
+			Message: fmt.Sprintf("订单收入%.2f没有对应的充值或权益分配记录", f.OrderRevenue),
+		})
+	}
+
+	return anomalies
+}
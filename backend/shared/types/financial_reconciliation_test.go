@@ -0,0 +1,70 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewReconciliationReport(t *testing.T) {
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 9, 30, 23, 59, 59, 0, time.UTC)
+
+	report := NewReconciliationReport(start, end, []MerchantReconciliationFigures{
+		{MerchantID: 3, OrderRevenue: 500, PaidOrderCount: 5, TotalConsumed: 0},
+		{MerchantID: 1, OrderRevenue: 1000, PaidOrderCount: 10, RightsConsumed: 100, Deposits: 200, TotalCredited: 300, TotalConsumed: 150},
+		{MerchantID: 2, OrderRevenue: 800, PaidOrderCount: 8, RightsConsumed: 120, Allocations: 50, TotalCredited: 100, TotalConsumed: 180},
+	})
+
+	if report.MerchantCount != 3 || report.AnomalyMerchants != 2 {
+		t.Fatalf("merchant count = %d, anomaly merchants = %d", report.MerchantCount, report.AnomalyMerchants)
+	}
+	if report.TotalRevenue != 2300 || report.TotalRightsConsumed != 220 || report.TotalDeposits != 200 || report.TotalAllocations != 50 {
+		t.Errorf("totals = %+v", report)
+	}
+	for i, merchant := range report.Merchants {
+		if merchant.MerchantID != uint64(i+1) {
+			t.Fatalf("商户未按ID排序: %v", report.Merchants)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		merchant    MerchantReconciliation
+		wantBalance float64
+		wantTypes   []ReconciliationAnomalyType
+	}{
+		{name: "收支一致", merchant: report.Merchants[0], wantBalance: 150},
+		{name: "权益消耗超过分配", merchant: report.Merchants[1], wantBalance: -80,
+			wantTypes: []ReconciliationAnomalyType{ReconciliationAnomalyRightsOverConsumed}},
+		{name: "收入没有对应记录", merchant: report.Merchants[2], wantBalance: 0,
+			wantTypes: []ReconciliationAnomalyType{ReconciliationAnomalyRevenueWithoutRights, ReconciliationAnomalyRevenueWithoutFunds}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.merchant.RightsBalance != tt.wantBalance {
+				t.Errorf("RightsBalance = %v, want %v", tt.merchant.RightsBalance, tt.wantBalance)
+			}
+			if tt.merchant.Balanced() != (len(tt.wantTypes) == 0) {
+				t.Errorf("Balanced() = %v, anomalies = %v", tt.merchant.Balanced(), tt.merchant.Anomalies)
+			}
+			if len(tt.merchant.Anomalies) != len(tt.wantTypes) {
+				t.Fatalf("anomalies = %v, want %v", tt.merchant.Anomalies, tt.wantTypes)
+			}
+			for i, anomaly := range tt.merchant.Anomalies {
+				if anomaly.Type != tt.wantTypes[i] {
+					t.Errorf("anomaly[%d] = %v, want %v", i, anomaly.Type, tt.wantTypes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReconciliationTolerance(t *testing.T) {
+	report := NewReconciliationReport(time.Time{}, time.Time{}, []MerchantReconciliationFigures{
+		{MerchantID: 1, OrderRevenue: 100, RightsConsumed: 10, TotalCredited: 10, TotalConsumed: 10.004},
+	})
+	if report.AnomalyMerchants != 0 {
+		t.Errorf("舍入误差不应标记为异常: %v", report.Merchants[0].Anomalies)
+	}
+}
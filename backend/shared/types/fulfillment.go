@@ -0,0 +1,167 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrOrderFulfillmentsExist 订单已经拆分过履约单
+	ErrOrderFulfillmentsExist = errors.New("该订单已拆分履约单")
+	// ErrOrderFulfillmentNotFound 订单下不存在该履约单
+	ErrOrderFulfillmentNotFound = errors.New("履约单不存在")
+	// ErrFulfillmentStatusTransition 履约单状态不能按请求流转
+	ErrFulfillmentStatusTransition = errors.New("履约单状态不允许变更")
+)
+
+// FulfillmentStatus 履约单状态
+type FulfillmentStatus string
+
+const (
+	FulfillmentStatusPending   FulfillmentStatus = "pending"   // 待发货
+	FulfillmentStatusShipped   FulfillmentStatus = "shipped"   // 已发货
+	FulfillmentStatusDelivered FulfillmentStatus = "delivered" // 已送达
+	FulfillmentStatusCompleted FulfillmentStatus = "completed" // 已完成
+)
+
+// IsFinished 履约单是否已完成交付
+func (s FulfillmentStatus) IsFinished() bool {
+	return s == FulfillmentStatusDelivered || s == FulfillmentStatusCompleted
+}
+
+// IsValid 检查履约单状态是否有效
+func (s FulfillmentStatus) IsValid() bool {
+	switch s {
+	case FulfillmentStatusPending, FulfillmentStatusShipped, FulfillmentStatusDelivered, FulfillmentStatusCompleted:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo 检查履约单状态流转是否合法：待发货 → 已发货 → 已送达 → 已完成，只能逐级向前
+func (s FulfillmentStatus) CanTransitionTo(next FulfillmentStatus) bool {
+	switch s {
+	case FulfillmentStatusPending:
+		return next == FulfillmentStatusShipped
+	case FulfillmentStatusShipped:
+		return next == FulfillmentStatusDelivered
+	case FulfillmentStatusDelivered:
+		return next == FulfillmentStatusCompleted
+	}
+	return false
+}
+
+// OrderFulfillment 订单履约单：拆单后每个包裹独立发货、独立流转状态
+type OrderFulfillment struct {
+	ID             uint64            `json:"id" db:"id"`
+	TenantID       uint64            `json:"tenant_id" db:"tenant_id"`
+	OrderID        uint64            `json:"order_id" db:"order_id"`
+	Status         FulfillmentStatus `json:"status" db:"status"`
+	Items          []FulfillmentItem `json:"items" db:"items"`
+	Amount         float64           `json:"amount" db:"amount"`           // 分摊的订单金额
+	RightsCost     float64           `json:"rights_cost" db:"rights_cost"` // 分摊的权益消耗
+	Carrier        string            `json:"carrier,omitempty" db:"carrier"`
+	TrackingNumber string            `json:"tracking_number,omitempty" db:"tracking_number"`
+	ShippedAt      *time.Time        `json:"shipped_at,omitempty" db:"shipped_at"`
+	UpdatedAt      time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// FulfillmentItem 履约单包含的订单商品
+type FulfillmentItem struct {
+	ProductID   uint64 `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+// AllFulfillmentsFinished 订单的全部履约单是否都已完成交付
+func AllFulfillmentsFinished(fulfillments []OrderFulfillment) bool {
+	if len(fulfillments) == 0 {
+		return false
+	}
+	for _, fulfillment := range fulfillments {
+		if !fulfillment.Status.IsFinished() {
+			return false
+		}
+	}
+	return true
+}
+
+// FulfillmentStatusChange 履约单状态变更结果，Fulfillments 为变更后订单的全部履约单
+type FulfillmentStatusChange struct {
+	Fulfillment    *OrderFulfillment
+	PreviousStatus FulfillmentStatus
+	Fulfillments   []OrderFulfillment
+}
+
+// CompletesOrder 本次变更是否使订单的全部履约单首次都完成交付。
+// 变更前本履约单未完成交付、变更后全部完成交付时才成立，已送达包裹后续转为已完成不会再次成立
+func (c *FulfillmentStatusChange) CompletesOrder() bool {
+	return !c.PreviousStatus.IsFinished() && c.Fulfillment.Status.IsFinished() && AllFulfillmentsFinished(c.Fulfillments)
+}
+
+// CanSplitFulfillments 订单是否可以拆分履约单：已支付或处理中的订单才能发货
+func CanSplitFulfillments(status OrderStatus) bool {
+	return status == OrderStatusPaid || status == OrderStatusProcessing
+}
+
+// CreateOrderFulfillmentsRequest 拆单请求：把订单商品分到若干包裹，各包裹商品数量之和必须与订单一致
+type CreateOrderFulfillmentsRequest struct {
+	Packages []CreateFulfillmentPackage `json:"packages" v:"required"`
+}
+
+// CreateFulfillmentPackage 拆单请求中的一个包裹
+type CreateFulfillmentPackage struct {
+	Items []FulfillmentItem `json:"items"`
+}
+
+// Validate 校验拆单请求的商品数量与订单一致
+func (r *CreateOrderFulfillmentsRequest) Validate(order *Order) error {
+	if len(r.Packages) == 0 {
+		return fmt.Errorf("至少需要一个包裹")
+	}
+
+	remaining := make(map[uint64]int, len(order.Items))
+	for _, item := range order.Items {
+		remaining[item.ProductID] += item.Quantity
+	}
+	for i, pkg := range r.Packages {
+		if len(pkg.Items) == 0 {
+			return fmt.Errorf("第 %d 个包裹没有商品", i+1)
+		}
+		for _, item := range pkg.Items {
+			if item.Quantity <= 0 {
+				return fmt.Errorf("第 %d 个包裹的商品数量必须大于0", i+1)
+			}
+			if _, ok := remaining[item.ProductID]; !ok {
+				return fmt.Errorf("商品 %d 不在订单中", item.ProductID)
+			}
+			remaining[item.ProductID] -= item.Quantity
+		}
+	}
+	for productID, quantity := range remaining {
+		if quantity != 0 {
+			return fmt.Errorf("商品 %d 的包裹数量之和与订单数量不一致", productID)
+		}
+	}
+	return nil
+}
+
+// UpdateFulfillmentStatusRequest 更新履约单状态请求，发货时可填写承运商和运单号
+type UpdateFulfillmentStatusRequest struct {
+	Status         FulfillmentStatus `json:"status" v:"required"`
+	Carrier        string            `json:"carrier"`
+	TrackingNumber string            `json:"tracking_number"`
+}
+
+// Validate 校验更新履约单状态请求
+func (r *UpdateFulfillmentStatusRequest) Validate() error {
+	if !r.Status.IsValid() {
+		return fmt.Errorf("无效的履约单状态: %s", r.Status)
+	}
+	if r.Status == FulfillmentStatusShipped && strings.TrimSpace(r.TrackingNumber) == "" {
+		return fmt.Errorf("发货时必须填写运单号")
+	}
+	return nil
+}
@@ -123,7 +123,9 @@ type DepositRequest struct {
 
 // BatchDepositRequest 批量充值请求
 type BatchDepositRequest struct {
-	Deposits []DepositRequest `json:"deposits" binding:"required,min=1,max=100"`
+	Deposits       []DepositRequest `json:"deposits" binding:"required,min=1,max=100"`
+	Mode           BatchDepositMode `json:"mode,omitempty"`            // 未指定时为尽力模式
+	IdempotencyKey string           `json:"idempotency_key,omitempty"` // 相同幂等键重复提交返回首次处理的结果，不重复入账
 }
 
 // AllocateRequest 权益分配请求
@@ -244,6 +246,9 @@ func (bdr *BatchDepositRequest) Validate() error {
 	if len(bdr.Deposits) > 100 {
 		return fmt.Errorf("单次批量充值不能超过100笔")
 	}
+	if err := bdr.validateBatchOptions(); err != nil {
+		return err
+	}
 	
 	totalAmount := 0.0
 	for i, deposit := range bdr.Deposits {
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// FundApprovalStatus 大额资金操作审批状态
+type FundApprovalStatus string
+
+const (
+	FundApprovalStatusPending  FundApprovalStatus = "pending"  // 等待审批，余额尚未入账
+	FundApprovalStatusApproved FundApprovalStatus = "approved" // 审批人数已满足，余额已入账
+	FundApprovalStatusRejected FundApprovalStatus = "rejected" // 任一审批人驳回，资金记录已取消
+)
+
+// FundApprovalDecisionType 审批意见
+type FundApprovalDecisionType string
+
+const (
+	FundApprovalDecisionApprove FundApprovalDecisionType = "approve"
+	FundApprovalDecisionReject  FundApprovalDecisionType = "reject"
+)
+
+// FundApprovalPolicy 大额资金操作审批策略
+type FundApprovalPolicy struct {
+	Threshold         float64 `json:"threshold"`          // 金额超过该值需要审批，0 表示不启用审批
+	RequiredApprovers int     `json:"required_approvers"` // 入账前需要的不同审批人数
+}
+
+// RequiresApproval 资金操作金额是否需要审批，等于阈值时直接入账
+func (p *FundApprovalPolicy) RequiresApproval(amount float64) bool {
+	return p != nil && p.Threshold > 0 && amount > p.Threshold
+}
+
+// Approvers 需要的审批人数，至少 1 人
+func (p *FundApprovalPolicy) Approvers() int {
+	if p.RequiredApprovers < 1 {
+		return 1
+	}
+	return p.RequiredApprovers
+}
+
+// FundApproval 等待审批的大额充值或权益分配，审批通过前不修改商户余额
+type FundApproval struct {
+	ID                uint64             `json:"id" db:"id"`
+	TenantID          uint64             `json:"tenant_id" db:"tenant_id"`
+	MerchantID        uint64             `json:"merchant_id" db:"merchant_id"`
+	FundID            uint64             `json:"fund_id" db:"fund_id"`
+	FundType          FundType           `json:"fund_type" db:"fund_type"`
+	Amount            float64            `json:"amount" db:"amount"`
+	Currency          string             `json:"currency" db:"currency"`
+	Description       string             `json:"description" db:"description"`
+	RequestedBy       uint64             `json:"requested_by" db:"requested_by"`
+	RequiredApprovals int                `json:"required_approvals" db:"required_approvals"`
+	ApprovalCount     int                `json:"approval_count" db:"approval_count"`
+	Status            FundApprovalStatus `json:"status" db:"status"`
+	DecidedAt         *time.Time         `json:"decided_at" db:"decided_at"` // 审批完成或驳回时间
+	CreatedAt         time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// IsPending 是否仍在等待审批
+func (a *FundApproval) IsPending() bool {
+	return a.Status == FundApprovalStatusPending
+}
+
+// FundApprovalDecision 审批人对大额资金操作的审批意见，同一审批人只能审批一次
+type FundApprovalDecision struct {
+	ID         uint64                   `json:"id" db:"id"`
+	TenantID   uint64                   `json:"tenant_id" db:"tenant_id"`
+	ApprovalID uint64                   `json:"approval_id" db:"approval_id"`
+	ApproverID uint64                   `json:"approver_id" db:"approver_id"`
+	Decision   FundApprovalDecisionType `json:"decision" db:"decision"`
+	Comment    string                   `json:"comment" db:"comment"`
+	CreatedAt  time.Time                `json:"created_at" db:"created_at"`
+}
+
+// FundApprovalDecisionRequest 审批或驳回请求
+type FundApprovalDecisionRequest struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// FundApprovalQuery 待审批资金操作查询参数
+type FundApprovalQuery struct {
+	MerchantID uint64 `json:"merchant_id,omitempty" form:"merchant_id"`
+	Page       int    `json:"page" form:"page"`
+	PageSize   int    `json:"page_size" form:"page_size"`
+}
+
+// Validate 校验审批策略
+func (p *FundApprovalPolicy) Validate() error {
+	if p.Threshold < 0 {
+		return fmt.Errorf("审批金额阈值不能为负数")
+	}
+	if p.RequiredApprovers < 1 {
+		return fmt.Errorf("审批人数至少为1")
+	}
+	return nil
+}
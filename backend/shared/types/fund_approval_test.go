@@ -0,0 +1,35 @@
+package types
+
+import "testing"
+
+func TestFundApprovalPolicyRequiresApproval(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *FundApprovalPolicy
+		amount float64
+		want   bool
+	}{
+		{name: "低于阈值直接入账", policy: &FundApprovalPolicy{Threshold: 10000, RequiredApprovers: 2}, amount: 9999.99, want: false},
+		{name: "等于阈值直接入账", policy: &FundApprovalPolicy{Threshold: 10000, RequiredApprovers: 2}, amount: 10000, want: false},
+		{name: "超过阈值需要审批", policy: &FundApprovalPolicy{Threshold: 10000, RequiredApprovers: 2}, amount: 10000.01, want: true},
+		{name: "阈值为0不启用审批", policy: &FundApprovalPolicy{RequiredApprovers: 2}, amount: 1000000, want: false},
+		{name: "未配置策略不启用审批", policy: nil, amount: 1000000, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.RequiresApproval(tt.amount); got != tt.want {
+				t.Errorf("RequiresApproval(%v) = %v, want %v", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFundApprovalPolicyApprovers(t *testing.T) {
+	if got := (&FundApprovalPolicy{RequiredApprovers: 3}).Approvers(); got != 3 {
+		t.Errorf("Approvers() = %d, want 3", got)
+	}
+	if got := (&FundApprovalPolicy{}).Approvers(); got != 1 {
+		t.Errorf("Approvers() with zero = %d, want 1", got)
+	}
+}
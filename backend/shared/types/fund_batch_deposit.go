@@ -0,0 +1,142 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrBatchDepositInProgress 相同幂等键的批量充值正在处理
+	ErrBatchDepositInProgress = errors.New("相同幂等键的批量充值正在处理")
+	// ErrBatchDepositKeyReused 幂等键已用于内容不同的批量充值
+	ErrBatchDepositKeyReused = errors.New("幂等键已用于内容不同的批量充值")
+)
+
+// BatchDepositMode 批量充值模式
+type BatchDepositMode string
+
+const (
+	BatchDepositModeBestEffort   BatchDepositMode = "best_effort"    // 逐笔独立入账，失败的充值不影响其他充值
+	BatchDepositModeAllOrNothing BatchDepositMode = "all_or_nothing" // 所有充值在同一事务中入账，任一笔失败整批回滚
+)
+
+// BatchDepositStatus 批量充值处理结果
+type BatchDepositStatus string
+
+const (
+	BatchDepositStatusProcessing BatchDepositStatus = "processing" // 正在处理，相同幂等键的请求被拒绝
+	BatchDepositStatusCompleted  BatchDepositStatus = "completed"  // 全部充值成功
+	BatchDepositStatusPartial    BatchDepositStatus = "partial"    // 部分充值成功，仅尽力模式
+	BatchDepositStatusFailed     BatchDepositStatus = "failed"     // 没有充值入账
+)
+
+// maxIdempotencyKeyLength 幂等键最大长度，与批量充值记录字段长度一致
+const maxIdempotencyKeyLength = 64
+
+// DepositMode 批量充值模式，未指定时为尽力模式，与原有行为兼容
+func (bdr *BatchDepositRequest) DepositMode() BatchDepositMode {
+	if bdr.Mode == "" {
+		return BatchDepositModeBestEffort
+	}
+	return bdr.Mode
+}
+
+// Fingerprint 批量充值内容摘要，用于识别幂等键被不同内容的请求复用
+func (bdr *BatchDepositRequest) Fingerprint() string {
+	payload, _ := json.Marshal(struct {
+		Mode     BatchDepositMode `json:"mode"`
+		Deposits []DepositRequest `json:"deposits"`
+	}{bdr.DepositMode(), bdr.Deposits})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// validateBatchOptions 验证批量充值模式和幂等键
+func (bdr *BatchDepositRequest) validateBatchOptions() error {
+	switch bdr.DepositMode() {
+	case BatchDepositModeBestEffort, BatchDepositModeAllOrNothing:
+	default:
+		return fmt.Errorf("无效的批量充值模式: %s", bdr.Mode)
+	}
+	if len(bdr.IdempotencyKey) > maxIdempotencyKeyLength {
+		return fmt.Errorf("幂等键不能超过%d个字符", maxIdempotencyKeyLength)
+	}
+	return nil
+}
+
+// BatchDepositItemResult 批量充值中单笔充值的处理结果
+type BatchDepositItemResult struct {
+	Index      int        `json:"index"` // 在请求中的序号，从 1 开始
+	MerchantID uint64     `json:"merchant_id"`
+	Amount     float64    `json:"amount"`
+	Currency   string     `json:"currency"`
+	Success    bool       `json:"success"`
+	FundID     uint64     `json:"fund_id,omitempty"`
+	FundStatus FundStatus `json:"fund_status,omitempty"` // 超过审批阈值的充值为待处理，审批通过后入账
+	RolledBack bool       `json:"rolled_back,omitempty"` // 全部或全不模式下因其他充值失败而未入账
+	Error      string     `json:"error,omitempty"`
+}
+
+// BatchDepositResult 批量充值结果
+type BatchDepositResult struct {
+	BatchID        uint64                   `json:"batch_id,omitempty"` // 携带幂等键时的批量充值记录ID
+	IdempotencyKey string                   `json:"idempotency_key,omitempty"`
+	Mode           BatchDepositMode         `json:"mode"`
+	Status         BatchDepositStatus       `json:"status"`
+	SucceededCount int                      `json:"succeeded_count"`
+	FailedCount    int                      `json:"failed_count"`
+	AppliedAmount  float64                  `json:"applied_amount"` // 成功充值的总金额，含等待审批的金额
+	Items          []BatchDepositItemResult `json:"items"`
+	Replayed       bool                     `json:"replayed"` // 幂等键重复提交，返回首次处理的结果
+}
+
+// Summarize 按各笔充值结果统计成功、失败笔数和金额，并确定批量充值状态
+func (r *BatchDepositResult) Summarize() {
+	r.SucceededCount, r.FailedCount, r.AppliedAmount = 0, 0, 0
+	for _, item := range r.Items {
+		if item.Success {
+			r.SucceededCount++
+			r.AppliedAmount += item.Amount
+		} else {
+			r.FailedCount++
+		}
+	}
+
+	switch {
+	case r.FailedCount == 0:
+		r.Status = BatchDepositStatusCompleted
+	case r.SucceededCount == 0:
+		r.Status = BatchDepositStatusFailed
+	default:
+		r.Status = BatchDepositStatusPartial
+	}
+}
+
+// FundIDs 成功充值的资金记录ID
+func (r *BatchDepositResult) FundIDs() []uint64 {
+	fundIDs := make([]uint64, 0, r.SucceededCount)
+	for _, item := range r.Items {
+		if item.Success {
+			fundIDs = append(fundIDs, item.FundID)
+		}
+	}
+	return fundIDs
+}
+
+// FundBatchDeposit 携带幂等键的批量充值记录，同一租户下幂等键唯一，重复提交返回首次处理的结果
+type FundBatchDeposit struct {
+	ID             uint64             `json:"id" db:"id"`
+	TenantID       uint64             `json:"tenant_id" db:"tenant_id"`
+	IdempotencyKey string             `json:"idempotency_key" db:"idempotency_key"`
+	Mode           BatchDepositMode   `json:"mode" db:"mode"`
+	Status         BatchDepositStatus `json:"status" db:"status"`
+	RequestHash    string             `json:"-" db:"request_hash"`
+	OperatorID     uint64             `json:"operator_id" db:"operator_id"`
+	ResultJSON     string             `json:"-" db:"result"` // 处理完成后的 BatchDepositResult
+	CreatedAt      time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" db:"updated_at"`
+}
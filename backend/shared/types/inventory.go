@@ -13,6 +13,9 @@ type ExtendedInventoryInfo struct {
 	ReorderPoint       *int    `json:"reorder_point"`         // 补货点
 	ReorderQuantity    *int    `json:"reorder_quantity"`      // 补货数量
 	CostPerUnit        *Money  `json:"cost_per_unit"`         // 单位成本
+	AllowBackorder     bool    `json:"allow_backorder"`       // 缺货时是否接受预订
+	BackorderLimit     int     `json:"backorder_limit"`       // 可用库存最多允许为负的数量
+	EstimatedRestockAt *time.Time `json:"estimated_restock_at,omitempty"` // 预计补货日期
 }
 
 // AvailableQuantity 计算可用库存数量
@@ -76,18 +79,22 @@ const (
 	ReservationStatusExpired   ReservationStatus = "expired"   // 过期释放
 )
 
+// ReservationReferenceOrder 订单库存预留的关联类型，到期由订单超时监控处理
+const ReservationReferenceOrder = "order"
+
 // InventoryReservation 库存锁定记录实体
 type InventoryReservation struct {
-	ID               uint64            `json:"id" gorm:"primaryKey"`
-	TenantID         uint64            `json:"tenant_id" gorm:"not null;index:idx_tenant_product"`
-	ProductID        uint64            `json:"product_id" gorm:"not null;index:idx_tenant_product"`
-	ReservedQuantity int               `json:"reserved_quantity" gorm:"not null"`
-	ReferenceType    string            `json:"reference_type" gorm:"size:50;not null;index:idx_reference"`
-	ReferenceID      string            `json:"reference_id" gorm:"size:100;not null;index:idx_reference"`
-	Status           ReservationStatus `json:"status" gorm:"size:20;not null;default:'active';index:idx_status_expires"`
-	ExpiresAt        *time.Time        `json:"expires_at" gorm:"index:idx_status_expires"`
-	CreatedAt        time.Time         `json:"created_at"`
-	UpdatedAt        time.Time         `json:"updated_at"`
+	ID                  uint64            `json:"id" gorm:"primaryKey"`
+	TenantID            uint64            `json:"tenant_id" gorm:"not null;index:idx_tenant_product"`
+	ProductID           uint64            `json:"product_id" gorm:"not null;index:idx_tenant_product"`
+	ReservedQuantity    int               `json:"reserved_quantity" gorm:"not null"`
+	BackorderedQuantity int               `json:"backordered_quantity" gorm:"not null;default:0"` // 超出可用库存、以预订方式接受的数量
+	ReferenceType       string            `json:"reference_type" gorm:"size:50;not null;index:idx_reference"`
+	ReferenceID         string            `json:"reference_id" gorm:"size:100;not null;index:idx_reference"`
+	Status              ReservationStatus `json:"status" gorm:"size:20;not null;default:'active';index:idx_status_expires"`
+	ExpiresAt           *time.Time        `json:"expires_at" gorm:"index:idx_status_expires"`
+	CreatedAt           time.Time         `json:"created_at"`
+	UpdatedAt           time.Time         `json:"updated_at"`
 }
 
 // TableName 设置表名
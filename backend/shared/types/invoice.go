@@ -0,0 +1,136 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultInvoicePrefix 未配置发票策略时的发票号前缀
+	DefaultInvoicePrefix = "INV"
+	// DefaultInvoiceSequenceWidth 发票号序号的默认位数
+	DefaultInvoiceSequenceWidth = 8
+	// MinInvoiceSequenceWidth 发票号序号最少位数
+	MinInvoiceSequenceWidth = 4
+	// MaxInvoiceSequenceWidth 发票号序号最多位数
+	MaxInvoiceSequenceWidth = 12
+	// MaxInvoiceIssuerNameLength 开票方名称最大长度（字符数）
+	MaxInvoiceIssuerNameLength = 100
+	// MaxInvoiceFooterLength 发票备注最大长度（字符数）
+	MaxInvoiceFooterLength = 500
+)
+
+var (
+	// ErrOrderNotInvoiceable 订单未支付或已取消，不能开具发票
+	ErrOrderNotInvoiceable = errors.New("订单未支付或已取消，不能开具发票")
+	// ErrInvoiceAccessDenied 只有下单客户和订单所属商户可以获取发票
+	ErrInvoiceAccessDenied = errors.New("无权获取该订单的发票")
+	// ErrInvoiceDisabled 租户未启用发票
+	ErrInvoiceDisabled = errors.New("租户未启用发票")
+)
+
+var invoicePrefixPattern = regexp.MustCompile(`^[A-Z]{1,8}$`)
+
+// InvoicePolicy 租户发票策略，未配置时启用发票并使用默认发票号格式。
+// 发票号格式为 前缀 + 开票年份 + 序号，序号按租户连续递增、跨年不清零
+type InvoicePolicy struct {
+	Disabled      bool   `json:"disabled,omitempty"`       // 停用发票，已开具的发票仍可下载
+	Prefix        string `json:"prefix,omitempty"`         // 大写字母前缀，为空时为 INV
+	SequenceWidth int    `json:"sequence_width,omitempty"` // 序号位数，为 0 时为 8 位
+	IssuerName    string `json:"issuer_name,omitempty"`    // 开票方名称，为空时使用商户名称
+	Footer        string `json:"footer,omitempty"`         // 发票底部备注
+}
+
+// Validate 校验发票策略
+func (p *InvoicePolicy) Validate() error {
+	if p.Prefix != "" && !invoicePrefixPattern.MatchString(p.Prefix) {
+		return errors.New("发票号前缀必须为1-8个大写字母")
+	}
+	if p.SequenceWidth != 0 && (p.SequenceWidth < MinInvoiceSequenceWidth || p.SequenceWidth > MaxInvoiceSequenceWidth) {
+		return fmt.Errorf("发票号序号位数必须在%d-%d之间", MinInvoiceSequenceWidth, MaxInvoiceSequenceWidth)
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(p.IssuerName)) > MaxInvoiceIssuerNameLength {
+		return fmt.Errorf("开票方名称不能超过%d个字符", MaxInvoiceIssuerNameLength)
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(p.Footer)) > MaxInvoiceFooterLength {
+		return fmt.Errorf("发票备注不能超过%d个字符", MaxInvoiceFooterLength)
+	}
+	return nil
+}
+
+// Enabled 是否可以开具新发票，未配置策略时启用
+func (p *InvoicePolicy) Enabled() bool {
+	return p == nil || !p.Disabled
+}
+
+// InvoiceNumber 按策略生成发票号，序号超出位数时按实际位数显示
+func (p *InvoicePolicy) InvoiceNumber(issuedAt time.Time, sequence uint64) string {
+	prefix := DefaultInvoicePrefix
+	width := DefaultInvoiceSequenceWidth
+	if p != nil && p.Prefix != "" {
+		prefix = p.Prefix
+	}
+	if p != nil && p.SequenceWidth > 0 {
+		width = p.SequenceWidth
+	}
+	return fmt.Sprintf("%s%s%0*d", prefix, issuedAt.Format("2006"), width, sequence)
+}
+
+// CanInvoiceOrder 检查订单状态是否允许开具发票：已支付、处理中和已完成的订单
+func CanInvoiceOrder(status OrderStatus) bool {
+	return status == OrderStatusPaid || status == OrderStatusProcessing || status == OrderStatusCompleted
+}
+
+// OrderInvoice 订单发票，每个订单只开具一张，之后重新下载沿用已分配的发票号
+type OrderInvoice struct {
+	ID             uint64    `json:"id" db:"id"`
+	TenantID       uint64    `json:"tenant_id" db:"tenant_id"`
+	OrderID        uint64    `json:"order_id" db:"order_id"`
+	MerchantID     uint64    `json:"merchant_id" db:"merchant_id"`
+	CustomerID     uint64    `json:"customer_id" db:"customer_id"`
+	InvoiceNumber  string    `json:"invoice_number" db:"invoice_number"`
+	TotalAmount    float64   `json:"total_amount" db:"total_amount"`       // 含税金额
+	SubtotalAmount float64   `json:"subtotal_amount" db:"subtotal_amount"` // 不含税金额
+	TaxAmount      float64   `json:"tax_amount" db:"tax_amount"`
+	FilePath       string    `json:"-" db:"file_path"` // 已生成的PDF文件，为空或文件丢失时重新生成
+	IssuedAt       time.Time `json:"issued_at" db:"issued_at"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// InvoiceParty 发票上的开票方或购买方信息
+type InvoiceParty struct {
+	Name    string
+	TaxID   string // 纳税人识别号（营业执照号）
+	Address string
+	Phone   string
+}
+
+// InvoiceLine 发票明细行
+type InvoiceLine struct {
+	Description string
+	Quantity    int
+	UnitPrice   float64
+	Amount      float64 // 商品金额，价格含税时为含税金额
+	TaxRate     string  // 显示的税率，如 13%、免税
+	TaxAmount   float64
+}
+
+// InvoiceDocument 生成发票PDF使用的数据
+type InvoiceDocument struct {
+	InvoiceNumber  string
+	IssuedAt       string
+	OrderNumber    string
+	OrderDate      string
+	Issuer         InvoiceParty
+	Buyer          InvoiceParty
+	Lines          []InvoiceLine
+	TaxInclusive   bool // 商品价格是否含税
+	SubtotalAmount float64
+	TaxAmount      float64
+	TotalAmount    float64
+	Footer         string
+}
@@ -0,0 +1,59 @@
+package types
+
+import "time"
+
+// MaintenanceScope 维护模式范围
+type MaintenanceScope string
+
+const (
+	// MaintenanceScopeGlobal 平台维护，所有租户只读
+	MaintenanceScopeGlobal MaintenanceScope = "global"
+	// MaintenanceScopeTenant 租户维护，仅该租户只读
+	MaintenanceScopeTenant MaintenanceScope = "tenant"
+)
+
+const (
+	// DefaultMaintenanceRetryAfter 未指定时建议客户端重试的间隔（秒）
+	DefaultMaintenanceRetryAfter = 300
+	// MaxMaintenanceRetryAfter 建议重试间隔的上限（秒）
+	MaxMaintenanceRetryAfter = 86400
+)
+
+// MaintenanceState 维护模式状态，开启期间拒绝写请求，读请求不受影响
+type MaintenanceState struct {
+	Scope      MaintenanceScope `json:"scope"`
+	TenantID   uint64           `json:"tenant_id,omitempty"`
+	Enabled    bool             `json:"enabled"`
+	Reason     string           `json:"reason,omitempty"`
+	RetryAfter int              `json:"retry_after"` // 建议客户端重试的间隔（秒）
+	UpdatedBy  uint64           `json:"updated_by"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// MaintenanceStatus 当前生效的维护模式，全局维护与租户维护任一开启即为只读
+type MaintenanceStatus struct {
+	ReadOnly bool              `json:"read_only"`
+	Global   *MaintenanceState `json:"global,omitempty"`
+	Tenant   *MaintenanceState `json:"tenant,omitempty"`
+}
+
+// Active 返回生效的维护状态，全局维护优先；未处于维护模式时返回nil
+func (s *MaintenanceStatus) Active() *MaintenanceState {
+	if s == nil {
+		return nil
+	}
+	if s.Global != nil && s.Global.Enabled {
+		return s.Global
+	}
+	if s.Tenant != nil && s.Tenant.Enabled {
+		return s.Tenant
+	}
+	return nil
+}
+
+// SetMaintenanceModeRequest 开启或关闭维护模式请求
+type SetMaintenanceModeRequest struct {
+	Enabled    bool   `json:"enabled"`
+	Reason     string `json:"reason" v:"max-length:255#维护原因不能超过255个字符"`
+	RetryAfter int    `json:"retry_after" v:"min:0|max:86400#重试间隔不能为负数|重试间隔不能超过86400秒"`
+}
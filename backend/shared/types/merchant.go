@@ -18,16 +18,17 @@ const (
 
 // BusinessInfo 商户业务信息
 type BusinessInfo struct {
-	Type         string `json:"type"`          // 商户类型: retail, wholesale, service
-	Category     string `json:"category"`      // 业务分类
-	License      string `json:"license"`       // 营业执照号
-	LegalName    string `json:"legal_name"`    // 法人名称
-	ContactName  string `json:"contact_name"`  // 联系人姓名
-	ContactPhone string `json:"contact_phone"` // 联系电话
-	ContactEmail string `json:"contact_email"` // 联系邮箱
-	Address      string `json:"address"`       // 经营地址
-	Scope        string `json:"scope"`         // 经营范围
-	Description  string `json:"description"`   // 商户描述
+	Type         string `json:"type"`             // 商户类型: retail, wholesale, service
+	Category     string `json:"category"`         // 业务分类
+	License      string `json:"license"`          // 营业执照号
+	LegalName    string `json:"legal_name"`       // 法人名称
+	ContactName  string `json:"contact_name"`     // 联系人姓名
+	ContactPhone string `json:"contact_phone"`    // 联系电话
+	ContactEmail string `json:"contact_email"`    // 联系邮箱
+	Address      string `json:"address"`          // 经营地址
+	Region       string `json:"region,omitempty"` // 经营地区代码，用于匹配租户的地区税率
+	Scope        string `json:"scope"`            // 经营范围
+	Description  string `json:"description"`      // 商户描述
 }
 
 // RightsBalance 权益余额信息
@@ -40,6 +41,8 @@ type RightsBalance struct {
 	WarningThreshold  *float64  `json:"warning_threshold"`  // 预警阈值
 	CriticalThreshold *float64  `json:"critical_threshold"` // 紧急阈值
 	TrendCoefficient  *float64  `json:"trend_coefficient"`  // 趋势系数
+	ReserveAmount     *float64  `json:"reserve_amount,omitempty"`  // 保留权益，普通订单不能占用
+	ReservePercent    *float64  `json:"reserve_percent,omitempty"` // 按总余额比例保留的权益（0-100），与保留权益同时配置时取较大者
 }
 
 // UpdateAvailableBalance 更新可用余额
@@ -53,6 +56,29 @@ func (rb *RightsBalance) GetAvailableBalance() float64 {
 	return rb.TotalBalance - rb.UsedBalance - rb.FrozenBalance
 }
 
+// ReserveFloor 获取保留权益下限，未配置时为 0
+func (rb *RightsBalance) ReserveFloor() float64 {
+	floor := 0.0
+	if rb.ReserveAmount != nil {
+		floor = *rb.ReserveAmount
+	}
+	if rb.ReservePercent != nil {
+		if byPercent := rb.TotalBalance * *rb.ReservePercent / 100; byPercent > floor {
+			floor = byPercent
+		}
+	}
+	return floor
+}
+
+// GetConsumableBalance 获取普通订单可使用的权益，即可用余额扣除保留权益，不小于 0
+func (rb *RightsBalance) GetConsumableBalance() float64 {
+	consumable := rb.GetAvailableBalance() - rb.ReserveFloor()
+	if consumable < 0 {
+		return 0
+	}
+	return consumable
+}
+
 // Merchant 商户实体
 type Merchant struct {
 	ID             uint64         `json:"id" db:"id"`
@@ -65,6 +91,11 @@ type Merchant struct {
 	RegistrationTime *time.Time   `json:"registration_time" db:"registration_time"` // 注册申请时间
 	ApprovalTime     *time.Time   `json:"approval_time" db:"approval_time"`         // 审批时间
 	ApprovedBy       *uint64      `json:"approved_by" db:"approved_by"`             // 审批人ID
+	MinOrderAmount   float64      `json:"min_order_amount" db:"min_order_amount"`   // 最低起订金额，0 表示不限制
+	PaymentMethods   PaymentMethodList `json:"payment_methods" db:"payment_methods"` // 启用的支付方式，为空表示接受全部支付方式
+	ContactEmailVerifiedAt *time.Time `json:"contact_email_verified_at" db:"contact_email_verified_at"` // 联系邮箱验证时间，邮箱变更后清空
+	ContactPhoneVerifiedAt *time.Time `json:"contact_phone_verified_at" db:"contact_phone_verified_at"` // 联系电话验证时间，电话变更后清空
+	NotificationBranding *NotificationBranding `json:"notification_branding" db:"notification_branding"` // 客户订单通知品牌，为空时使用租户默认品牌
 	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
 }
@@ -113,9 +144,12 @@ type Money struct {
 
 // InventoryInfo 库存信息
 type InventoryInfo struct {
-	StockQuantity    int  `json:"stock_quantity"`
-	ReservedQuantity int  `json:"reserved_quantity"`
-	TrackInventory   bool `json:"track_inventory"`
+	StockQuantity      int        `json:"stock_quantity"`
+	ReservedQuantity   int        `json:"reserved_quantity"`
+	TrackInventory     bool       `json:"track_inventory"`
+	AllowBackorder     bool       `json:"allow_backorder"`                // 缺货时是否接受预订
+	BackorderLimit     int        `json:"backorder_limit"`                // 可用库存最多允许为负的数量
+	EstimatedRestockAt *time.Time `json:"estimated_restock_at,omitempty"` // 预计补货日期
 }
 
 // AvailableStock 计算可用库存
@@ -138,6 +172,12 @@ type Product struct {
 	InventoryInfo *InventoryInfo `json:"inventory_info" db:"inventory_info"`
 	Images       ProductImages  `json:"images" db:"images"`
 	Status       ProductStatus  `json:"status" db:"status"`
+	// 可售时间窗口，为空的一端不限制
+	AvailableFrom  *time.Time   `json:"available_from,omitempty" db:"available_from"`
+	AvailableUntil *time.Time   `json:"available_until,omitempty" db:"available_until"`
+	// 单笔订单购买数量限制，0 表示不限制
+	MinOrderQuantity int        `json:"min_order_quantity" db:"min_order_quantity"`
+	MaxOrderQuantity int        `json:"max_order_quantity" db:"max_order_quantity"`
 	Version      int           `json:"version" db:"version"`
 	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at" db:"updated_at"`
@@ -165,10 +205,22 @@ const (
 
 // OrderItem 订单项目
 type OrderItem struct {
-	ProductID uint64  `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+	ProductID  uint64  `json:"product_id"`
+	Quantity   int     `json:"quantity"`
+	Price      float64 `json:"price"`
 	RightsCost float64 `json:"rights_cost"`
+	// 下单时库存不足、以预订方式接受的数量
+	BackorderedQuantity int        `json:"backordered_quantity,omitempty"`
+	EstimatedRestockAt  *time.Time `json:"estimated_restock_at,omitempty"`
+	// 套餐订单项的 ProductID 为 0，价格和权益成本为每份套餐的价格，组成商品为下单时的快照
+	BundleID         uint64                  `json:"bundle_id,omitempty"`
+	BundleName       string                  `json:"bundle_name,omitempty"`
+	BundleComponents ProductBundleComponents `json:"bundle_components,omitempty"`
+}
+
+// IsBundle 是否为套餐订单项
+func (i *OrderItem) IsBundle() bool {
+	return i.BundleID != 0
 }
 
 // PaymentInfo 支付信息
@@ -235,10 +287,38 @@ type OrderTimeoutConfig struct {
 	PaymentTimeoutMinutes   int    `json:"payment_timeout_minutes" db:"payment_timeout_minutes"`
 	ProcessingTimeoutHours  int    `json:"processing_timeout_hours" db:"processing_timeout_hours"`
 	AutoCompleteEnabled     bool   `json:"auto_complete_enabled" db:"auto_complete_enabled"`
+	AutoCompleteAfterHours  int    `json:"auto_complete_after_hours" db:"auto_complete_after_hours"` // 处理中订单无变更多久后自动完成
+	ReservationHoldMinutes  int    `json:"reservation_hold_minutes" db:"reservation_hold_minutes"`   // 库存预留保留时长，0表示与支付超时一致
 	CreatedAt               time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt               time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// DefaultAutoCompleteAfterHours 默认自动完成等待时长（小时）
+const DefaultAutoCompleteAfterHours = 72
+
+// ReservationHold 获取订单库存预留保留时长，未配置或超过支付超时时与支付超时一致
+func (c *OrderTimeoutConfig) ReservationHold() time.Duration {
+	paymentTimeout := time.Duration(c.PaymentTimeoutMinutes) * time.Minute
+	if c.ReservationHoldMinutes <= 0 {
+		return paymentTimeout
+	}
+	hold := time.Duration(c.ReservationHoldMinutes) * time.Minute
+	if paymentTimeout > 0 && hold > paymentTimeout {
+		return paymentTimeout
+	}
+	return hold
+}
+
+// ReservationExpiresAt 计算订单库存预留的到期时间
+func (c *OrderTimeoutConfig) ReservationExpiresAt(orderCreatedAt time.Time) time.Time {
+	return orderCreatedAt.Add(c.ReservationHold())
+}
+
+// IsAwaitingVerification 订单是否仍在等待核销
+func (o *Order) IsAwaitingVerification() bool {
+	return o.VerificationInfo != nil && o.VerificationInfo.VerifiedAt == nil
+}
+
 // OrderTimeoutStatistics 订单超时统计信息
 type OrderTimeoutStatistics struct {
 	PendingTimeoutCount       int     `json:"pending_timeout_count"`       // 待支付超时订单数量
@@ -258,6 +338,8 @@ type OrderQueryRequest struct {
 	StartDate     *time.Time       `json:"start_date,omitempty"`
 	EndDate       *time.Time       `json:"end_date,omitempty"`
 	SearchKeyword *string          `json:"search_keyword,omitempty"`
+	ExternalReference *string      `json:"external_reference,omitempty"` // 按外部订单号精确匹配
+	Tags          []string         `json:"tags,omitempty"` // 同时带有全部标签的订单
 	Page          int              `json:"page" v:"required|min:1"`
 	PageSize      int              `json:"page_size" v:"required|min:1|max:100"`
 	SortBy        string           `json:"sort_by" v:"in:created_at,updated_at,total_amount"`
@@ -277,6 +359,7 @@ type OrderListResponse struct {
 type OrderSummary struct {
 	ID                  uint64               `json:"id"`
 	OrderNumber         string               `json:"order_number"`
+	ExternalReference   string               `json:"external_reference,omitempty"`
 	Status              OrderStatusInt       `json:"status"`
 	CustomerName        string               `json:"customer_name"`
 	MerchantName        string               `json:"merchant_name"`
@@ -285,6 +368,7 @@ type OrderSummary struct {
 	CreatedAt           time.Time            `json:"created_at"`
 	UpdatedAt           time.Time            `json:"updated_at"`
 	LatestStatusChange  *OrderStatusHistory  `json:"latest_status_change,omitempty"`
+	Tags                []string             `json:"tags"`
 }
 
 // UpdateOrderStatusRequest 更新订单状态请求
@@ -326,17 +410,29 @@ type Order struct {
 	MerchantID       uint64            `json:"merchant_id" db:"merchant_id"`
 	CustomerID       uint64            `json:"customer_id" db:"customer_id"`
 	OrderNumber      string            `json:"order_number" db:"order_number"`
+	// 对接方系统中的订单号，同一租户内唯一，未设置时为空
+	ExternalReference string           `json:"external_reference,omitempty" db:"external_reference"`
 	Status           OrderStatus       `json:"status" db:"status"`
 	Items            []OrderItem       `json:"items" db:"items"`
 	PaymentInfo      *PaymentInfo      `json:"payment_info" db:"payment_info"`
 	VerificationInfo *VerificationInfo `json:"verification_info" db:"verification_info"`
 	TotalAmount      float64           `json:"total_amount" db:"total_amount"`
 	TotalRightsCost  float64           `json:"total_rights_cost" db:"total_rights_cost"`
+	SubtotalAmount   float64           `json:"subtotal_amount" db:"subtotal_amount"` // 不含税商品金额
+	TaxAmount        float64           `json:"tax_amount" db:"tax_amount"`
+	Tax              *OrderTax         `json:"tax,omitempty" db:"tax_breakdown"` // 税费明细，未计税时为空
+	ShippingAddress  *ShippingAddress  `json:"shipping_address,omitempty" db:"shipping_address"` // 收货地址快照，无需配送的订单为空
 	StatusUpdatedAt  time.Time         `json:"status_updated_at" db:"status_updated_at"`
 	CreatedAt        time.Time         `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time         `json:"updated_at" db:"updated_at"`
 	// 状态历史（查询时可选填充）
 	StatusHistory    []OrderStatusHistory `json:"status_history,omitempty" db:"-"`
+	// 订单备注（查询时按查看者权限填充）
+	Notes            []OrderNote          `json:"notes,omitempty" db:"-"`
+	// 风险审核（下单命中审核规则时填充）
+	Review           *OrderReview         `json:"review,omitempty" db:"-"`
+	// 面向客户的状态显示（客户查询订单时按租户配置填充，status 始终为内部状态）
+	StatusDisplay    *OrderStatusLabel    `json:"status_display,omitempty" db:"-"`
 }
 
 // MerchantRegistrationRequest 商户注册请求
@@ -354,8 +450,28 @@ type MerchantApprovalRequest struct {
 
 // MerchantUpdateRequest 商户信息更新请求
 type MerchantUpdateRequest struct {
-	Name         *string       `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
-	BusinessInfo *BusinessInfo `json:"business_info,omitempty"`
+	Name           *string       `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	BusinessInfo   *BusinessInfo `json:"business_info,omitempty"`
+	MinOrderAmount *float64      `json:"min_order_amount,omitempty" binding:"omitempty,min=0"`
+	PaymentMethods *PaymentMethodList `json:"payment_methods,omitempty"`
+	NotificationBranding *NotificationBranding `json:"notification_branding,omitempty"`
+}
+
+// MerchantRightsReserveRequest 商户保留权益设置请求，两项均为空时取消保留
+type MerchantRightsReserveRequest struct {
+	ReserveAmount  *float64 `json:"reserve_amount,omitempty"`
+	ReservePercent *float64 `json:"reserve_percent,omitempty"`
+}
+
+// Validate 校验保留权益设置
+func (req *MerchantRightsReserveRequest) Validate() error {
+	if req.ReserveAmount != nil && *req.ReserveAmount < 0 {
+		return errors.New("保留权益不能为负数")
+	}
+	if req.ReservePercent != nil && (*req.ReservePercent < 0 || *req.ReservePercent > 100) {
+		return errors.New("保留权益比例必须在0-100之间")
+	}
+	return nil
 }
 
 // MerchantStatusUpdateRequest 商户状态更新请求
@@ -389,6 +505,8 @@ const (
 	TaskTypeVerificationPending  TaskType = "verification_pending"
 	TaskTypeLowBalanceWarning     TaskType = "low_balance_warning"
 	TaskTypeProductUpdateNeeded   TaskType = "product_update_needed"
+	TaskTypeOrderSLABreach        TaskType = "order_sla_breach"
+	TaskTypeRightsThreshold       TaskType = "rights_threshold"
 )
 
 // 优先级
@@ -411,6 +529,68 @@ type PendingTask struct {
 	Count       int        `json:"count"`
 }
 
+// Weight 优先级权重，数值越大越优先
+func (p Priority) Weight() int {
+	switch p {
+	case PriorityUrgent:
+		return 4
+	case PriorityHigh:
+		return 3
+	case PriorityNormal:
+		return 2
+	case PriorityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// PendingTaskTrigger 待处理事项触发条件
+type PendingTaskTrigger struct {
+	Enabled   bool             `json:"enabled"`
+	MinCount  int              `json:"min_count,omitempty"`  // 数量达到该值才生成事项
+	DueHours  int              `json:"due_hours,omitempty"`  // 处理时限（小时），超时后提升优先级
+	Statuses  []OrderStatusInt `json:"statuses,omitempty"`   // 订单处理：视为待处理的订单状态
+	Threshold float64          `json:"threshold,omitempty"`  // 低余额预警：余额阈值，为0时使用商户预警阈值
+	StaleDays int              `json:"stale_days,omitempty"` // 商品更新：超过该天数未更新视为需要更新
+}
+
+// DefaultPendingTaskTriggers 默认待处理事项触发条件
+func DefaultPendingTaskTriggers() map[TaskType]PendingTaskTrigger {
+	return map[TaskType]PendingTaskTrigger{
+		TaskTypeOrderProcessing: {
+			Enabled:  true,
+			MinCount: 1,
+			DueHours: 24,
+			Statuses: []OrderStatusInt{OrderStatusIntPending, OrderStatusIntProcessing},
+		},
+		TaskTypeVerificationPending: {
+			Enabled:  true,
+			MinCount: 1,
+			DueHours: 48,
+		},
+		TaskTypeLowBalanceWarning: {
+			Enabled:  true,
+			DueHours: 24,
+		},
+		TaskTypeProductUpdateNeeded: {
+			Enabled:   true,
+			MinCount:  1,
+			DueHours:  72,
+			StaleDays: 90,
+		},
+		TaskTypeOrderSLABreach: {
+			Enabled:  true,
+			MinCount: 1,
+			DueHours: 4,
+		},
+		TaskTypeRightsThreshold: {
+			Enabled:  true,
+			DueHours: 24,
+		},
+	}
+}
+
 // 公告信息
 type Announcement struct {
 	ID          uint64     `json:"id"`
@@ -444,6 +624,7 @@ type MerchantDashboardData struct {
 	TotalOrders    int            `json:"total_orders"`     // 总订单数
 	TotalCustomers int            `json:"total_customers"`  // 总客户数
 	RightsBalance  *RightsBalance `json:"rights_balance"`   // 权益余额
+	ConsumableRights *float64   `json:"consumable_rights,omitempty"` // 普通订单可使用的权益（可用余额扣除保留权益）
 	
 	// 权益使用情况 (AC: 2) 
 	RightsUsageTrend       []RightsUsagePoint `json:"rights_usage_trend"`
@@ -485,8 +666,21 @@ const (
 	WidgetTypeRecentOrders   WidgetType = "recent_orders"
 	WidgetTypeAnnouncements  WidgetType = "announcements"
 	WidgetTypeQuickActions   WidgetType = "quick_actions"
+	WidgetTypeSalesTarget    WidgetType = "sales_target"
 )
 
+// 最近订单组件默认及最大展示条数
+const (
+	DefaultRecentOrdersLimit = 10
+	MaxRecentOrdersLimit     = 50
+)
+
+// RecentOrdersWidgetConfig 最近订单组件配置，对应组件 config 中的 limit 和 status_filter
+type RecentOrdersWidgetConfig struct {
+	Limit        int              `json:"limit"`
+	StatusFilter []OrderStatusInt `json:"status_filter"`
+}
+
 // 仪表板组件
 type DashboardWidget struct {
 	ID       string                 `json:"id"`
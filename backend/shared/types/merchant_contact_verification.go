@@ -0,0 +1,88 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// MerchantContactChannel 商户联系方式验证渠道
+type MerchantContactChannel string
+
+const (
+	MerchantContactChannelEmail MerchantContactChannel = "email"
+	MerchantContactChannelPhone MerchantContactChannel = "phone"
+)
+
+// IsValid 验证渠道是否有效
+func (c MerchantContactChannel) IsValid() bool {
+	return c == MerchantContactChannelEmail || c == MerchantContactChannelPhone
+}
+
+// ContactTarget 商户在该渠道登记的联系方式，未登记时返回空
+func (m *Merchant) ContactTarget(channel MerchantContactChannel) string {
+	if m.BusinessInfo == nil {
+		return ""
+	}
+	switch channel {
+	case MerchantContactChannelEmail:
+		return strings.TrimSpace(m.BusinessInfo.ContactEmail)
+	case MerchantContactChannelPhone:
+		return strings.TrimSpace(m.BusinessInfo.ContactPhone)
+	default:
+		return ""
+	}
+}
+
+// IsContactVerified 该渠道的联系方式是否已验证
+func (m *Merchant) IsContactVerified(channel MerchantContactChannel) bool {
+	switch channel {
+	case MerchantContactChannelEmail:
+		return m.ContactEmailVerifiedAt != nil
+	case MerchantContactChannelPhone:
+		return m.ContactPhoneVerifiedAt != nil
+	default:
+		return false
+	}
+}
+
+// HasVerifiedContact 是否至少验证了一种联系方式
+func (m *Merchant) HasVerifiedContact() bool {
+	return m.IsContactVerified(MerchantContactChannelEmail) || m.IsContactVerified(MerchantContactChannelPhone)
+}
+
+// MerchantContactVerificationPolicy 商户联系方式验证策略
+type MerchantContactVerificationPolicy struct {
+	RequiredForApproval bool          `json:"required_for_approval"` // 审批前是否必须至少验证一种联系方式
+	CodeTTL             time.Duration `json:"code_ttl"`              // 验证码有效期
+	ResendInterval      time.Duration `json:"resend_interval"`       // 重新发送验证码的最短间隔
+	MaxAttempts         int           `json:"max_attempts"`          // 每个验证码允许的错误次数
+}
+
+// DefaultMerchantContactVerificationPolicy 默认验证策略
+func DefaultMerchantContactVerificationPolicy() MerchantContactVerificationPolicy {
+	return MerchantContactVerificationPolicy{
+		RequiredForApproval: true,
+		CodeTTL:             10 * time.Minute,
+		ResendInterval:      time.Minute,
+		MaxAttempts:         5,
+	}
+}
+
+// MerchantContactVerificationRequest 发送联系方式验证码请求
+type MerchantContactVerificationRequest struct {
+	Channel MerchantContactChannel `json:"channel" v:"required|in:email,phone#验证渠道不能为空|验证渠道只能是email或phone"`
+}
+
+// MerchantContactConfirmRequest 确认联系方式验证码请求
+type MerchantContactConfirmRequest struct {
+	Channel MerchantContactChannel `json:"channel" v:"required|in:email,phone#验证渠道不能为空|验证渠道只能是email或phone"`
+	Code    string                 `json:"code" v:"required#验证码不能为空"`
+}
+
+// MerchantContactVerificationIssued 验证码发送结果，联系方式已脱敏
+type MerchantContactVerificationIssued struct {
+	Channel     MerchantContactChannel `json:"channel"`
+	Target      string                 `json:"target"`
+	ExpiresAt   time.Time              `json:"expires_at"`
+	ResendAfter time.Time              `json:"resend_after"`
+}
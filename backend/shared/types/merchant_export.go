@@ -0,0 +1,71 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MerchantExportHeader 商户列表导出表头
+var MerchantExportHeader = []string{
+	"商户名称", "商户代码", "状态", "联系人", "联系电话", "联系邮箱",
+	"权益总额", "已使用权益", "冻结权益", "可用权益", "注册时间", "审批时间",
+}
+
+// MerchantExportQuery 商户列表导出条件
+type MerchantExportQuery struct {
+	Format     OrderExportFormat `json:"format"`                // 导出格式，与订单导出一致：csv/excel
+	Status     MerchantStatus    `json:"status,omitempty"`      // 按商户状态过滤，为空时导出全部
+	MerchantID *uint64           `json:"merchant_id,omitempty"` // 商户用户只能导出本商户
+}
+
+// Validate 验证导出条件
+func (q *MerchantExportQuery) Validate() error {
+	if !q.Format.IsValid() {
+		return fmt.Errorf("不支持的导出格式: %s", q.Format)
+	}
+	switch q.Status {
+	case "", MerchantStatusPending, MerchantStatusActive, MerchantStatusSuspended, MerchantStatusDeactivated:
+		return nil
+	default:
+		return fmt.Errorf("无效的商户状态: %s", q.Status)
+	}
+}
+
+// MerchantExportRecord 将商户转换为导出行，可用权益按当前余额计算
+func MerchantExportRecord(merchant *Merchant) []string {
+	record := make([]string, len(MerchantExportHeader))
+	record[0] = merchant.Name
+	record[1] = merchant.Code
+	record[2] = string(merchant.Status)
+
+	if info := merchant.BusinessInfo; info != nil {
+		record[3] = info.ContactName
+		record[4] = info.ContactPhone
+		record[5] = info.ContactEmail
+	}
+
+	balance := merchant.RightsBalance
+	if balance == nil {
+		balance = &RightsBalance{}
+	}
+	record[6] = formatExportAmount(balance.TotalBalance)
+	record[7] = formatExportAmount(balance.UsedBalance)
+	record[8] = formatExportAmount(balance.FrozenBalance)
+	record[9] = formatExportAmount(balance.GetAvailableBalance())
+
+	record[10] = formatExportTime(merchant.RegistrationTime)
+	record[11] = formatExportTime(merchant.ApprovalTime)
+	return record
+}
+
+func formatExportAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,77 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerchantExportRecord(t *testing.T) {
+	registeredAt := time.Date(2026, 3, 1, 9, 30, 0, 0, time.Local)
+	approvedAt := time.Date(2026, 3, 2, 14, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		merchant *Merchant
+		want     []string
+	}{
+		{
+			name: "包含联系人和权益余额",
+			merchant: &Merchant{
+				Name:   "测试商户",
+				Code:   "M001",
+				Status: MerchantStatusActive,
+				BusinessInfo: &BusinessInfo{
+					ContactName:  "张三",
+					ContactPhone: "13800138000",
+					ContactEmail: "zhangsan@example.com",
+				},
+				RightsBalance:    &RightsBalance{TotalBalance: 1000, UsedBalance: 250.5, FrozenBalance: 100},
+				RegistrationTime: &registeredAt,
+				ApprovalTime:     &approvedAt,
+			},
+			want: []string{
+				"测试商户", "M001", "active", "张三", "13800138000", "zhangsan@example.com",
+				"1000.00", "250.50", "100.00", "649.50", "2026-03-01 09:30:00", "2026-03-02 14:00:00",
+			},
+		},
+		{
+			name:     "待审核商户没有余额和审批时间",
+			merchant: &Merchant{Name: "新商户", Code: "M002", Status: MerchantStatusPending},
+			want:     []string{"新商户", "M002", "pending", "", "", "", "0.00", "0.00", "0.00", "0.00", "", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MerchantExportRecord(tt.merchant)
+			if len(got) != len(MerchantExportHeader) {
+				t.Fatalf("导出列数 = %d, want %d", len(got), len(MerchantExportHeader))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MerchantExportRecord() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerchantExportQueryValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   MerchantExportQuery
+		wantErr bool
+	}{
+		{name: "CSV导出全部商户", query: MerchantExportQuery{Format: OrderExportFormatCSV}},
+		{name: "Excel按状态导出", query: MerchantExportQuery{Format: OrderExportFormatExcel, Status: MerchantStatusSuspended}},
+		{name: "不支持的格式", query: MerchantExportQuery{Format: "pdf"}, wantErr: true},
+		{name: "无效的商户状态", query: MerchantExportQuery{Format: OrderExportFormatCSV, Status: "closed"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.query.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MerchantDigestFrequency 商户通知汇总频率
+type MerchantDigestFrequency string
+
+const (
+	MerchantDigestImmediate MerchantDigestFrequency = "immediate" // 逐条发送（默认）
+	MerchantDigestHourly    MerchantDigestFrequency = "hourly"    // 每小时汇总发送
+	MerchantDigestDaily     MerchantDigestFrequency = "daily"     // 每日汇总发送
+)
+
+// maxDigestLinksPerGroup 汇总中每类事件最多列出的订单链接数
+const maxDigestLinksPerGroup = 10
+
+// IsValid 是否为支持的汇总频率
+func (f MerchantDigestFrequency) IsValid() bool {
+	switch f {
+	case MerchantDigestImmediate, MerchantDigestHourly, MerchantDigestDaily:
+		return true
+	}
+	return false
+}
+
+// MerchantNotificationPreference 商户通知偏好
+type MerchantNotificationPreference struct {
+	TenantID        uint64                  `json:"tenant_id" db:"tenant_id"`
+	MerchantID      uint64                  `json:"merchant_id" db:"merchant_id"`
+	DigestFrequency MerchantDigestFrequency `json:"digest_frequency" db:"digest_frequency"`
+	DigestHour      int                     `json:"digest_hour" db:"digest_hour"` // 每日汇总的发送时刻（0-23点）
+	UpdatedAt       time.Time               `json:"updated_at" db:"updated_at"`
+}
+
+// DigestEnabled 是否开启通知汇总
+func (p *MerchantNotificationPreference) DigestEnabled() bool {
+	return p != nil && (p.DigestFrequency == MerchantDigestHourly || p.DigestFrequency == MerchantDigestDaily)
+}
+
+// DigestDueAt 最早一条待汇总通知在 firstAt 产生时，汇总的发送时间：
+// 按小时汇总在下一个整点发送，按日汇总在下一个 DigestHour 点发送
+func (p *MerchantNotificationPreference) DigestDueAt(firstAt time.Time) time.Time {
+	switch p.DigestFrequency {
+	case MerchantDigestHourly:
+		return firstAt.Truncate(time.Hour).Add(time.Hour)
+	case MerchantDigestDaily:
+		due := time.Date(firstAt.Year(), firstAt.Month(), firstAt.Day(), p.DigestHour, 0, 0, 0, firstAt.Location())
+		if !due.After(firstAt) {
+			due = due.AddDate(0, 0, 1)
+		}
+		return due
+	default:
+		return firstAt
+	}
+}
+
+// Validate 校验通知偏好
+func (p *MerchantNotificationPreference) Validate() error {
+	if !p.DigestFrequency.IsValid() {
+		return fmt.Errorf("不支持的汇总频率: %s", p.DigestFrequency)
+	}
+	if p.DigestHour < 0 || p.DigestHour > 23 {
+		return fmt.Errorf("每日汇总发送时刻必须在0-23之间")
+	}
+	return nil
+}
+
+// MerchantNotificationDigestEntry 等待汇总发送的商户通知
+type MerchantNotificationDigestEntry struct {
+	ID          uint64     `json:"id" db:"id"`
+	TenantID    uint64     `json:"tenant_id" db:"tenant_id"`
+	MerchantID  uint64     `json:"merchant_id" db:"merchant_id"`
+	UserID      uint64     `json:"user_id" db:"user_id"` // 接收通知的商户用户
+	EventType   string     `json:"event_type" db:"event_type"`
+	OrderID     uint64     `json:"order_id" db:"order_id"`
+	OrderNumber string     `json:"order_number" db:"order_number"`
+	Summary     string     `json:"summary" db:"summary"`
+	Link        string     `json:"link" db:"link"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	SentAt      *time.Time `json:"sent_at" db:"sent_at"`
+}
+
+// MerchantDigestRecipient 有待汇总通知的接收人
+type MerchantDigestRecipient struct {
+	TenantID   uint64    `json:"tenant_id" db:"tenant_id"`
+	MerchantID uint64    `json:"merchant_id" db:"merchant_id"`
+	UserID     uint64    `json:"user_id" db:"user_id"`
+	FirstAt    time.Time `json:"first_at" db:"first_at"` // 最早一条待汇总通知的产生时间
+}
+
+// MerchantDigestGroup 汇总中同一类事件的统计
+type MerchantDigestGroup struct {
+	EventType string   `json:"event_type"`
+	Count     int      `json:"count"`
+	Links     []string `json:"links"` // 最多列出 10 个订单链接
+}
+
+// MerchantNotificationDigest 发送给商户用户的通知汇总
+type MerchantNotificationDigest struct {
+	TenantID   uint64                `json:"tenant_id"`
+	MerchantID uint64                `json:"merchant_id"`
+	UserID     uint64                `json:"user_id"`
+	Since      time.Time             `json:"since"`
+	Until      time.Time             `json:"until"`
+	Total      int                   `json:"total"`
+	Groups     []MerchantDigestGroup `json:"groups"`
+}
+
+// BuildMerchantNotificationDigest 按事件类型汇总同一接收人的待发送通知，数量多的事件类型排在前面
+func BuildMerchantNotificationDigest(entries []MerchantNotificationDigestEntry) *MerchantNotificationDigest {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	digest := &MerchantNotificationDigest{
+		TenantID:   entries[0].TenantID,
+		MerchantID: entries[0].MerchantID,
+		UserID:     entries[0].UserID,
+		Since:      entries[0].CreatedAt,
+		Until:      entries[0].CreatedAt,
+		Total:      len(entries),
+	}
+
+	groups := make(map[string]*MerchantDigestGroup)
+	var order []string
+	for _, entry := range entries {
+		if entry.CreatedAt.Before(digest.Since) {
+			digest.Since = entry.CreatedAt
+		}
+		if entry.CreatedAt.After(digest.Until) {
+			digest.Until = entry.CreatedAt
+		}
+
+		group, exists := groups[entry.EventType]
+		if !exists {
+			group = &MerchantDigestGroup{EventType: entry.EventType}
+			groups[entry.EventType] = group
+			order = append(order, entry.EventType)
+		}
+		group.Count++
+		if entry.Link != "" && len(group.Links) < maxDigestLinksPerGroup {
+			group.Links = append(group.Links, entry.Link)
+		}
+	}
+
+	digest.Groups = make([]MerchantDigestGroup, 0, len(order))
+	for _, eventType := range order {
+		digest.Groups = append(digest.Groups, *groups[eventType])
+	}
+	sort.SliceStable(digest.Groups, func(i, j int) bool {
+		return digest.Groups[i].Count > digest.Groups[j].Count
+	})
+	return digest
+}
+
+// UpdateMerchantNotificationPreferenceRequest 更新商户通知偏好请求
+type UpdateMerchantNotificationPreferenceRequest struct {
+	DigestFrequency MerchantDigestFrequency `json:"digest_frequency" v:"required#汇总频率不能为空"`
+	DigestHour      int                     `json:"digest_hour"`
+}
@@ -0,0 +1,55 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMerchantNotificationPreferenceDigestDueAt(t *testing.T) {
+	firstAt := time.Date(2026, 10, 15, 10, 20, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		preference MerchantNotificationPreference
+		want       time.Time
+	}{
+		{name: "按小时汇总在下一个整点发送", preference: MerchantNotificationPreference{DigestFrequency: MerchantDigestHourly}, want: time.Date(2026, 10, 15, 11, 0, 0, 0, time.UTC)},
+		{name: "按日汇总在当天设置的时刻发送", preference: MerchantNotificationPreference{DigestFrequency: MerchantDigestDaily, DigestHour: 18}, want: time.Date(2026, 10, 15, 18, 0, 0, 0, time.UTC)},
+		{name: "已过当天时刻时顺延到次日", preference: MerchantNotificationPreference{DigestFrequency: MerchantDigestDaily, DigestHour: 9}, want: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+		{name: "逐条发送立即到期", preference: MerchantNotificationPreference{DigestFrequency: MerchantDigestImmediate}, want: firstAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.preference.DigestDueAt(firstAt); !got.Equal(tt.want) {
+				t.Errorf("DigestDueAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMerchantNotificationDigest(t *testing.T) {
+	base := time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC)
+	var entries []MerchantNotificationDigestEntry
+	for i := 0; i < 12; i++ {
+		entries = append(entries, MerchantNotificationDigestEntry{EventType: "payment_success", Link: "/orders/1", CreatedAt: base.Add(time.Duration(i) * time.Minute)})
+	}
+	entries = append(entries, MerchantNotificationDigestEntry{EventType: "order_processing", Link: "/orders/2", CreatedAt: base.Add(-time.Minute)})
+
+	digest := BuildMerchantNotificationDigest(entries)
+	if digest.Total != 13 {
+		t.Fatalf("Total = %d, want 13", digest.Total)
+	}
+	if !digest.Since.Equal(base.Add(-time.Minute)) || !digest.Until.Equal(base.Add(11*time.Minute)) {
+		t.Errorf("Since/Until = %v/%v", digest.Since, digest.Until)
+	}
+	if len(digest.Groups) != 2 || digest.Groups[0].EventType != "payment_success" || digest.Groups[0].Count != 12 || digest.Groups[1].Count != 1 {
+		t.Fatalf("Groups = %+v", digest.Groups)
+	}
+	if len(digest.Groups[0].Links) != maxDigestLinksPerGroup {
+		t.Errorf("Links = %d, want %d", len(digest.Groups[0].Links), maxDigestLinksPerGroup)
+	}
+	if BuildMerchantNotificationDigest(nil) != nil {
+		t.Error("BuildMerchantNotificationDigest(nil) should be nil")
+	}
+}
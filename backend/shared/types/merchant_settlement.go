@@ -0,0 +1,177 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrSettlementNotFound 结算单不存在
+	ErrSettlementNotFound = errors.New("结算单不存在")
+	// ErrSettlementAlreadyPaid 结算单已标记为已付款
+	ErrSettlementAlreadyPaid = errors.New("结算单已付款，不能重复标记")
+	// ErrSettlementPeriodOverlap 商户在该周期内已有结算单，不能重复结算
+	ErrSettlementPeriodOverlap = errors.New("商户在该周期内已生成结算单")
+)
+
+// ReportTypeSettlement 商户结算单，仅用于生成结算单PDF，不在报表生成接口中提供
+const ReportTypeSettlement ReportType = "settlement"
+
+// SettlementStatus 结算状态
+type SettlementStatus string
+
+const (
+	SettlementStatusPending SettlementStatus = "pending" // 待付款
+	SettlementStatusPaid    SettlementStatus = "paid"    // 已付款
+)
+
+// SettlementPolicy 商户结算规则：平台按商户周期内的净销售额（订单收入减退款）收取比例手续费，
+// 另按订单收取固定手续费。为空时不收取手续费
+type SettlementPolicy struct {
+	FeeRatePercent   float64 `json:"fee_rate_percent"`    // 比例手续费，按净销售额的百分比收取，如 5 表示 5%
+	FixedFeePerOrder float64 `json:"fixed_fee_per_order"` // 每笔已支付订单的固定手续费
+}
+
+// Validate 校验结算规则
+func (p *SettlementPolicy) Validate() error {
+	if p.FeeRatePercent < 0 || p.FeeRatePercent > 100 {
+		return fmt.Errorf("结算手续费比例必须在0到100之间")
+	}
+	if p.FixedFeePerOrder < 0 {
+		return fmt.Errorf("每笔订单固定手续费不能为负数")
+	}
+	return nil
+}
+
+// PlatformFee 按规则计算平台手续费，保留两位小数。净销售额为负时不收取比例手续费
+func (p *SettlementPolicy) PlatformFee(grossRevenue, refundAmount float64, orderCount int) float64 {
+	if p == nil {
+		return 0
+	}
+	netSales := math.Max(grossRevenue-refundAmount, 0)
+	return roundSettlementAmount(netSales*p.FeeRatePercent/100 + p.FixedFeePerOrder*float64(orderCount))
+}
+
+// MerchantSettlementFigures 商户结算原始数据。订单收入按订单进入已支付状态的时间统计，
+// 之后被取消的订单仍计入收入，其退款按退款时间计入退款
+type MerchantSettlementFigures struct {
+	MerchantID   uint64  `json:"merchant_id"`
+	MerchantName string  `json:"merchant_name"`
+	GrossRevenue float64 `json:"gross_revenue"` // 周期内已支付订单收入
+	OrderCount   int     `json:"order_count"`   // 周期内已支付订单数
+	RefundAmount float64 `json:"refund_amount"` // 周期内退款金额
+	RefundCount  int     `json:"refund_count"`  // 周期内退款订单数
+}
+
+// MerchantSettlement 商户结算单
+type MerchantSettlement struct {
+	ID               uint64           `json:"id" db:"id"`
+	TenantID         uint64           `json:"tenant_id" db:"tenant_id"`
+	MerchantID       uint64           `json:"merchant_id" db:"merchant_id"`
+	MerchantName     string           `json:"merchant_name" db:"merchant_name"`
+	PeriodStart      time.Time        `json:"period_start" db:"period_start"`
+	PeriodEnd        time.Time        `json:"period_end" db:"period_end"`
+	GrossRevenue     float64          `json:"gross_revenue" db:"gross_revenue"`
+	OrderCount       int              `json:"order_count" db:"order_count"`
+	RefundAmount     float64          `json:"refund_amount" db:"refund_amount"`
+	RefundCount      int              `json:"refund_count" db:"refund_count"`
+	PlatformFee      float64          `json:"platform_fee" db:"platform_fee"`
+	NetPayable       float64          `json:"net_payable" db:"net_payable"`                 // 订单收入减退款和手续费，为负时商户应向平台补缴
+	FeeRatePercent   float64          `json:"fee_rate_percent" db:"fee_rate_percent"`       // 生成时使用的比例手续费
+	FixedFeePerOrder float64          `json:"fixed_fee_per_order" db:"fixed_fee_per_order"` // 生成时使用的每笔订单固定手续费
+	Status           SettlementStatus `json:"status" db:"status"`
+	PaymentReference string           `json:"payment_reference,omitempty" db:"payment_reference"` // 付款凭证号，如银行流水号
+	PaidAt           *time.Time       `json:"paid_at,omitempty" db:"paid_at"`
+	PaidBy           *uint64          `json:"paid_by,omitempty" db:"paid_by"`
+	GeneratedBy      uint64           `json:"generated_by" db:"generated_by"`
+	CreatedAt        time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// NewMerchantSettlement 按结算规则计算商户的手续费和应付金额，生成待付款结算单
+func NewMerchantSettlement(figures MerchantSettlementFigures, policy *SettlementPolicy, periodStart, periodEnd time.Time) *MerchantSettlement {
+	settlement := &MerchantSettlement{
+		MerchantID:   figures.MerchantID,
+		MerchantName: figures.MerchantName,
+		PeriodStart:  periodStart,
+		PeriodEnd:    periodEnd,
+		GrossRevenue: roundSettlementAmount(figures.GrossRevenue),
+		OrderCount:   figures.OrderCount,
+		RefundAmount: roundSettlementAmount(figures.RefundAmount),
+		RefundCount:  figures.RefundCount,
+		Status:       SettlementStatusPending,
+	}
+	if policy != nil {
+		settlement.FeeRatePercent = policy.FeeRatePercent
+		settlement.FixedFeePerOrder = policy.FixedFeePerOrder
+	}
+	settlement.PlatformFee = policy.PlatformFee(settlement.GrossRevenue, settlement.RefundAmount, settlement.OrderCount)
+	settlement.NetPayable = roundSettlementAmount(settlement.GrossRevenue - settlement.RefundAmount - settlement.PlatformFee)
+	return settlement
+}
+
+// SettlementGenerateRequest 生成结算单请求
+type SettlementGenerateRequest struct {
+	StartDate  time.Time `json:"start_date" binding:"required"`
+	EndDate    time.Time `json:"end_date" binding:"required"`
+	MerchantID *uint64   `json:"merchant_id,omitempty"` // 为空时结算全部商户
+}
+
+// SettlementPayRequest 标记结算单已付款请求
+type SettlementPayRequest struct {
+	PaymentReference string `json:"payment_reference" v:"required|max-length:100#付款凭证号不能为空|付款凭证号不能超过100个字符"`
+}
+
+// SettlementQuery 结算单查询条件
+type SettlementQuery struct {
+	MerchantID uint64           `json:"merchant_id"`
+	Status     SettlementStatus `json:"status"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+}
+
+// SettlementStatement 结算单汇总，商户按ID排序
+type SettlementStatement struct {
+	PeriodStart       time.Time            `json:"period_start"`
+	PeriodEnd         time.Time            `json:"period_end"`
+	TotalGrossRevenue float64              `json:"total_gross_revenue"`
+	TotalRefunds      float64              `json:"total_refunds"`
+	TotalPlatformFees float64              `json:"total_platform_fees"`
+	TotalNetPayable   float64              `json:"total_net_payable"`
+	MerchantCount     int                  `json:"merchant_count"`
+	Settlements       []MerchantSettlement `json:"settlements"`
+}
+
+// NewSettlementStatement 汇总各商户结算单
+func NewSettlementStatement(periodStart, periodEnd time.Time, settlements []MerchantSettlement) *SettlementStatement {
+	statement := &SettlementStatement{
+		PeriodStart:   periodStart,
+		PeriodEnd:     periodEnd,
+		MerchantCount: len(settlements),
+		Settlements:   make([]MerchantSettlement, len(settlements)),
+	}
+	copy(statement.Settlements, settlements)
+	sort.Slice(statement.Settlements, func(i, j int) bool {
+		return statement.Settlements[i].MerchantID < statement.Settlements[j].MerchantID
+	})
+
+	for _, settlement := range statement.Settlements {
+		statement.TotalGrossRevenue += settlement.GrossRevenue
+		statement.TotalRefunds += settlement.RefundAmount
+		statement.TotalPlatformFees += settlement.PlatformFee
+		statement.TotalNetPayable += settlement.NetPayable
+	}
+	statement.TotalGrossRevenue = roundSettlementAmount(statement.TotalGrossRevenue)
+	statement.TotalRefunds = roundSettlementAmount(statement.TotalRefunds)
+	statement.TotalPlatformFees = roundSettlementAmount(statement.TotalPlatformFees)
+	statement.TotalNetPayable = roundSettlementAmount(statement.TotalNetPayable)
+	return statement
+}
+
+// roundSettlementAmount 结算金额保留两位小数
+func roundSettlementAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,91 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewMerchantSettlement(t *testing.T) {
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 9, 30, 23, 59, 59, 0, time.UTC)
+	policy := &SettlementPolicy{FeeRatePercent: 5, FixedFeePerOrder: 0.5}
+
+	tests := []struct {
+		name        string
+		figures     MerchantSettlementFigures
+		policy      *SettlementPolicy
+		wantFee     float64
+		wantPayable float64
+	}{
+		{name: "有退款时按净销售额收取手续费",
+			figures: MerchantSettlementFigures{MerchantID: 1, GrossRevenue: 1000, OrderCount: 10, RefundAmount: 200, RefundCount: 2},
+			policy:  policy, wantFee: 45, wantPayable: 755},
+		{name: "退款超过收入时只收取固定手续费，应付为负",
+			figures: MerchantSettlementFigures{MerchantID: 2, GrossRevenue: 100, OrderCount: 1, RefundAmount: 300, RefundCount: 3},
+			policy:  policy, wantFee: 0.5, wantPayable: -200.5},
+		{name: "金额保留两位小数",
+			figures: MerchantSettlementFigures{MerchantID: 3, GrossRevenue: 33.33, OrderCount: 1},
+			policy:  &SettlementPolicy{FeeRatePercent: 3}, wantFee: 1, wantPayable: 32.33},
+		{name: "未配置结算规则时不收取手续费",
+			figures: MerchantSettlementFigures{MerchantID: 4, GrossRevenue: 500, OrderCount: 5},
+			policy:  nil, wantFee: 0, wantPayable: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settlement := NewMerchantSettlement(tt.figures, tt.policy, start, end)
+			if settlement.PlatformFee != tt.wantFee {
+				t.Errorf("platform fee = %v, want %v", settlement.PlatformFee, tt.wantFee)
+			}
+			if settlement.NetPayable != tt.wantPayable {
+				t.Errorf("net payable = %v, want %v", settlement.NetPayable, tt.wantPayable)
+			}
+			if settlement.Status != SettlementStatusPending {
+				t.Errorf("status = %s, want pending", settlement.Status)
+			}
+			if !settlement.PeriodStart.Equal(start) || !settlement.PeriodEnd.Equal(end) {
+				t.Errorf("period = %v - %v", settlement.PeriodStart, settlement.PeriodEnd)
+			}
+		})
+	}
+}
+
+func TestSettlementPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  SettlementPolicy
+		wantErr bool
+	}{
+		{name: "有效规则", policy: SettlementPolicy{FeeRatePercent: 5, FixedFeePerOrder: 0.5}},
+		{name: "比例超过100", policy: SettlementPolicy{FeeRatePercent: 101}, wantErr: true},
+		{name: "比例为负", policy: SettlementPolicy{FeeRatePercent: -1}, wantErr: true},
+		{name: "固定手续费为负", policy: SettlementPolicy{FixedFeePerOrder: -0.1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSettlementStatement(t *testing.T) {
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 9, 30, 23, 59, 59, 0, time.UTC)
+	policy := &SettlementPolicy{FeeRatePercent: 5, FixedFeePerOrder: 0.5}
+
+	statement := NewSettlementStatement(start, end, []MerchantSettlement{
+		*NewMerchantSettlement(MerchantSettlementFigures{MerchantID: 2, GrossRevenue: 100, OrderCount: 1, RefundAmount: 300}, policy, start, end),
+		*NewMerchantSettlement(MerchantSettlementFigures{MerchantID: 1, GrossRevenue: 1000, OrderCount: 10, RefundAmount: 200}, policy, start, end),
+	})
+
+	if statement.MerchantCount != 2 || statement.Settlements[0].MerchantID != 1 {
+		t.Fatalf("商户未按ID排序: %+v", statement.Settlements)
+	}
+	if statement.TotalGrossRevenue != 1100 || statement.TotalRefunds != 500 ||
+		statement.TotalPlatformFees != 45.5 || statement.TotalNetPayable != 554.5 {
+		t.Errorf("totals = %+v", statement)
+	}
+}
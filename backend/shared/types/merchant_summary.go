@@ -0,0 +1,71 @@
+package types
+
+import (
+	"math"
+	"time"
+)
+
+// MerchantSummaryFigures 商户在一个时间段内的关键指标（仅统计已支付和已完成订单）
+type MerchantSummaryFigures struct {
+	Revenue        float64 `json:"revenue"`         // 收入
+	OrderCount     int     `json:"order_count"`     // 订单数
+	CustomerCount  int     `json:"customer_count"`  // 下单客户数
+	AvgOrderValue  float64 `json:"avg_order_value"` // 客单价
+	RightsConsumed int64   `json:"rights_consumed"` // 权益消耗
+}
+
+// MerchantSummaryGrowth 与上一周期相比的增长率（百分比），上一周期为 0 时为 nil
+type MerchantSummaryGrowth struct {
+	Revenue        *float64 `json:"revenue"`
+	OrderCount     *float64 `json:"order_count"`
+	CustomerCount  *float64 `json:"customer_count"`
+	AvgOrderValue  *float64 `json:"avg_order_value"`
+	RightsConsumed *float64 `json:"rights_consumed"`
+}
+
+// MerchantSummary 商户仪表盘汇总数据
+type MerchantSummary struct {
+	MerchantID        uint64                 `json:"merchant_id"`
+	StartDate         time.Time              `json:"start_date"`
+	EndDate           time.Time              `json:"end_date"`
+	PreviousStartDate time.Time              `json:"previous_start_date"`
+	PreviousEndDate   time.Time              `json:"previous_end_date"`
+	Current           MerchantSummaryFigures `json:"current"`
+	Previous          MerchantSummaryFigures `json:"previous"`
+	Growth            MerchantSummaryGrowth  `json:"growth"`
+}
+
+// PreviousPeriod 返回紧邻 [startDate, endDate) 之前、长度相同的上一周期
+func PreviousPeriod(startDate, endDate time.Time) (time.Time, time.Time) {
+	return startDate.Add(-endDate.Sub(startDate)), startDate
+}
+
+// NewMerchantSummary 根据本期和上一周期指标生成商户汇总数据
+func NewMerchantSummary(merchantID uint64, startDate, endDate time.Time, current, previous MerchantSummaryFigures) *MerchantSummary {
+	previousStart, previousEnd := PreviousPeriod(startDate, endDate)
+	return &MerchantSummary{
+		MerchantID:        merchantID,
+		StartDate:         startDate,
+		EndDate:           endDate,
+		PreviousStartDate: previousStart,
+		PreviousEndDate:   previousEnd,
+		Current:           current,
+		Previous:          previous,
+		Growth: MerchantSummaryGrowth{
+			Revenue:        growthRate(current.Revenue, previous.Revenue),
+			OrderCount:     growthRate(float64(current.OrderCount), float64(previous.OrderCount)),
+			CustomerCount:  growthRate(float64(current.CustomerCount), float64(previous.CustomerCount)),
+			AvgOrderValue:  growthRate(current.AvgOrderValue, previous.AvgOrderValue),
+			RightsConsumed: growthRate(float64(current.RightsConsumed), float64(previous.RightsConsumed)),
+		},
+	}
+}
+
+// growthRate 计算增长率百分比，保留两位小数
+func growthRate(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	rate := math.Round((current-previous)/previous*10000) / 100
+	return &rate
+}
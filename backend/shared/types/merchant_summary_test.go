@@ -0,0 +1,47 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewMerchantSummary(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 10, 8, 0, 0, 0, 0, time.UTC)
+
+	summary := NewMerchantSummary(7, start, end,
+		MerchantSummaryFigures{Revenue: 1500, OrderCount: 15, CustomerCount: 10, AvgOrderValue: 100, RightsConsumed: 30},
+		MerchantSummaryFigures{Revenue: 1000, OrderCount: 10, CustomerCount: 0, AvgOrderValue: 100, RightsConsumed: 40},
+	)
+
+	if !summary.PreviousStartDate.Equal(time.Date(2026, 9, 24, 0, 0, 0, 0, time.UTC)) || !summary.PreviousEndDate.Equal(start) {
+		t.Fatalf("上一周期 = [%v, %v)", summary.PreviousStartDate, summary.PreviousEndDate)
+	}
+
+	tests := []struct {
+		name string
+		got  *float64
+		want *float64
+	}{
+		{name: "收入增长", got: summary.Growth.Revenue, want: ptrFloat(50)},
+		{name: "订单数增长", got: summary.Growth.OrderCount, want: ptrFloat(50)},
+		{name: "上一周期为0时没有增长率", got: summary.Growth.CustomerCount, want: nil},
+		{name: "客单价持平", got: summary.Growth.AvgOrderValue, want: ptrFloat(0)},
+		{name: "权益消耗下降", got: summary.Growth.RightsConsumed, want: ptrFloat(-25)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.got == nil) != (tt.want == nil) {
+				t.Fatalf("增长率 = %v, want %v", tt.got, tt.want)
+			}
+			if tt.want != nil && *tt.got != *tt.want {
+				t.Errorf("增长率 = %v, want %v", *tt.got, *tt.want)
+			}
+		})
+	}
+}
+
+func ptrFloat(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,176 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 销售目标进度判定参数
+const (
+	// TargetPaceThreshold 预计达成率低于该值视为进度落后
+	TargetPaceThreshold = 0.9
+	// TargetPaceMinElapsed 周期已过比例达到该值后才判定进度，避免周期初期数据过少误报
+	TargetPaceMinElapsed = 0.2
+)
+
+// targetPeriodLayout 销售目标周期格式（自然月）
+const targetPeriodLayout = "2006-01"
+
+// ErrInvalidTargetPeriod 销售目标周期格式无效
+var ErrInvalidTargetPeriod = errors.New("目标周期格式无效，应为 YYYY-MM")
+
+// MerchantTarget 商户月度销售目标
+type MerchantTarget struct {
+	ID           uint64    `json:"id" db:"id"`
+	TenantID     uint64    `json:"tenant_id" db:"tenant_id"`
+	MerchantID   uint64    `json:"merchant_id" db:"merchant_id"`
+	Period       string    `json:"period" db:"period"` // 自然月，格式 YYYY-MM
+	TargetAmount float64   `json:"target_amount" db:"target_amount"`
+	TargetOrders int       `json:"target_orders" db:"target_orders"`
+	CreatedBy    uint64    `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SetMerchantTargetRequest 设置销售目标请求
+type SetMerchantTargetRequest struct {
+	Period       string  `json:"period" v:"required#目标周期不能为空"`
+	TargetAmount float64 `json:"target_amount" v:"min:0#目标金额不能为负数"`
+	TargetOrders int     `json:"target_orders" v:"min:0#目标订单数不能为负数"`
+}
+
+// Validate 校验销售目标请求
+func (r *SetMerchantTargetRequest) Validate() error {
+	if _, _, err := ParseTargetPeriod(r.Period); err != nil {
+		return err
+	}
+	if r.TargetAmount < 0 || r.TargetOrders < 0 {
+		return errors.New("目标金额和订单数不能为负数")
+	}
+	if r.TargetAmount == 0 && r.TargetOrders == 0 {
+		return errors.New("目标金额和目标订单数至少设置一项")
+	}
+	return nil
+}
+
+// MerchantTargetActuals 周期内实际销售数据
+type MerchantTargetActuals struct {
+	Amount float64 `json:"amount"`
+	Orders int     `json:"orders"`
+}
+
+// MerchantTargetProgress 销售目标进度，仪表板销售目标组件数据
+type MerchantTargetProgress struct {
+	Period      string    `json:"period"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Elapsed     float64   `json:"elapsed"` // 周期已过比例，0-1
+
+	TargetAmount     float64 `json:"target_amount"`
+	ActualAmount     float64 `json:"actual_amount"`
+	ProjectedAmount  float64 `json:"projected_amount"` // 按当前速度预计周期末金额
+	AmountAttainment float64 `json:"amount_attainment"`
+	// 预计周期末金额达成率
+	ProjectedAmountAttainment float64 `json:"projected_amount_attainment"`
+
+	TargetOrders     int     `json:"target_orders"`
+	ActualOrders     int     `json:"actual_orders"`
+	ProjectedOrders  float64 `json:"projected_orders"`
+	OrdersAttainment float64 `json:"orders_attainment"`
+	// 预计周期末订单数达成率
+	ProjectedOrdersAttainment float64 `json:"projected_orders_attainment"`
+
+	BehindPace bool `json:"behind_pace"` // 预计无法达成目标
+}
+
+// ParseTargetPeriod 解析目标周期，返回周期起始时间（含）和结束时间（不含）
+func ParseTargetPeriod(period string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation(targetPeriodLayout, period, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidTargetPeriod
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// TargetPeriodOf 获取时间所在的目标周期
+func TargetPeriodOf(t time.Time) string {
+	return t.Format(targetPeriodLayout)
+}
+
+// CalculateTargetProgress 计算销售目标进度。
+// 预计值按周期已过比例线性外推；周期结束后预计值即为实际值
+func CalculateTargetProgress(target *MerchantTarget, actuals *MerchantTargetActuals, now time.Time) (*MerchantTargetProgress, error) {
+	start, end, err := ParseTargetPeriod(target.Period)
+	if err != nil {
+		return nil, err
+	}
+
+	elapsed := 0.0
+	switch {
+	case !now.After(start):
+		elapsed = 0
+	case !now.Before(end):
+		elapsed = 1
+	default:
+		elapsed = float64(now.Sub(start)) / float64(end.Sub(start))
+	}
+
+	progress := &MerchantTargetProgress{
+		Period:       target.Period,
+		PeriodStart:  start,
+		PeriodEnd:    end,
+		Elapsed:      elapsed,
+		TargetAmount: target.TargetAmount,
+		ActualAmount: actuals.Amount,
+		TargetOrders: target.TargetOrders,
+		ActualOrders: actuals.Orders,
+	}
+
+	if elapsed > 0 {
+		progress.ProjectedAmount = actuals.Amount / elapsed
+		progress.ProjectedOrders = float64(actuals.Orders) / elapsed
+	}
+	progress.AmountAttainment = attainment(actuals.Amount, target.TargetAmount)
+	progress.ProjectedAmountAttainment = attainment(progress.ProjectedAmount, target.TargetAmount)
+	progress.OrdersAttainment = attainment(float64(actuals.Orders), float64(target.TargetOrders))
+	progress.ProjectedOrdersAttainment = attainment(progress.ProjectedOrders, float64(target.TargetOrders))
+
+	if elapsed >= TargetPaceMinElapsed {
+		progress.BehindPace = (target.TargetAmount > 0 && progress.ProjectedAmountAttainment < TargetPaceThreshold) ||
+			(target.TargetOrders > 0 && progress.ProjectedOrdersAttainment < TargetPaceThreshold)
+	}
+
+	return progress, nil
+}
+
+// amountBehind 销售额目标是否落后，金额和订单数都落后时以金额为准
+func (p *MerchantTargetProgress) amountBehind() bool {
+	return p.TargetAmount > 0 && p.ProjectedAmountAttainment < TargetPaceThreshold
+}
+
+// PaceAttainment 落后目标的预计达成率
+func (p *MerchantTargetProgress) PaceAttainment() float64 {
+	if p.amountBehind() {
+		return p.ProjectedAmountAttainment
+	}
+	return p.ProjectedOrdersAttainment
+}
+
+// PaceSummary 进度落后说明，用于预警消息
+func (p *MerchantTargetProgress) PaceSummary() string {
+	if p.amountBehind() {
+		return fmt.Sprintf("%s 销售额目标 %.2f，当前 %.2f，预计周期末达成 %.0f%%",
+			p.Period, p.TargetAmount, p.ActualAmount, p.ProjectedAmountAttainment*100)
+	}
+	return fmt.Sprintf("%s 订单数目标 %d，当前 %d，预计周期末达成 %.0f%%",
+		p.Period, p.TargetOrders, p.ActualOrders, p.ProjectedOrdersAttainment*100)
+}
+
+// attainment 达成率，未设置目标时为0
+func attainment(actual, target float64) float64 {
+	if target <= 0 {
+		return 0
+	}
+	return actual / target
+}
@@ -0,0 +1,148 @@
+package types
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalculateTargetProgress(t *testing.T) {
+	// 2025年9月共30天
+	target := &MerchantTarget{Period: "2025-09", TargetAmount: 30000, TargetOrders: 300}
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 9, day, hour, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name                string
+		now                 time.Time
+		actuals             MerchantTargetActuals
+		wantElapsed         float64
+		wantProjectedAmount float64
+		wantProjectedOrders float64
+		wantBehindPace      bool
+	}{
+		{
+			name:                "周期过半按速度达成",
+			now:                 at(16, 0),
+			actuals:             MerchantTargetActuals{Amount: 15000, Orders: 150},
+			wantElapsed:         0.5,
+			wantProjectedAmount: 30000,
+			wantProjectedOrders: 300,
+		},
+		{
+			name:                "周期过三分之一金额落后",
+			now:                 at(11, 0),
+			actuals:             MerchantTargetActuals{Amount: 8000, Orders: 100},
+			wantElapsed:         1.0 / 3,
+			wantProjectedAmount: 24000,
+			wantProjectedOrders: 300,
+			wantBehindPace:      true,
+		},
+		{
+			name:                "按小时计算已过比例",
+			now:                 at(1, 12),
+			actuals:             MerchantTargetActuals{Amount: 500, Orders: 5},
+			wantElapsed:         1.0 / 60,
+			wantProjectedAmount: 30000,
+			wantProjectedOrders: 300,
+		},
+		{
+			name:                "周期初期不判定落后",
+			now:                 at(4, 0),
+			actuals:             MerchantTargetActuals{Amount: 100, Orders: 1},
+			wantElapsed:         0.1,
+			wantProjectedAmount: 1000,
+			wantProjectedOrders: 10,
+		},
+		{
+			name:    "周期未开始",
+			now:     time.Date(2025, 8, 20, 0, 0, 0, 0, time.Local),
+			actuals: MerchantTargetActuals{},
+		},
+		{
+			name:                "周期结束后预计值即实际值",
+			now:                 time.Date(2025, 10, 3, 0, 0, 0, 0, time.Local),
+			actuals:             MerchantTargetActuals{Amount: 31000, Orders: 250},
+			wantElapsed:         1,
+			wantProjectedAmount: 31000,
+			wantProjectedOrders: 250,
+			wantBehindPace:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress, err := CalculateTargetProgress(target, &tt.actuals, tt.now)
+			if err != nil {
+				t.Fatalf("CalculateTargetProgress() error = %v", err)
+			}
+			if !approxEqual(progress.Elapsed, tt.wantElapsed) {
+				t.Errorf("Elapsed = %v, want %v", progress.Elapsed, tt.wantElapsed)
+			}
+			if !approxEqual(progress.ProjectedAmount, tt.wantProjectedAmount) {
+				t.Errorf("ProjectedAmount = %v, want %v", progress.ProjectedAmount, tt.wantProjectedAmount)
+			}
+			if !approxEqual(progress.ProjectedOrders, tt.wantProjectedOrders) {
+				t.Errorf("ProjectedOrders = %v, want %v", progress.ProjectedOrders, tt.wantProjectedOrders)
+			}
+			if progress.BehindPace != tt.wantBehindPace {
+				t.Errorf("BehindPace = %v, want %v", progress.BehindPace, tt.wantBehindPace)
+			}
+			if !approxEqual(progress.AmountAttainment, tt.actuals.Amount/target.TargetAmount) {
+				t.Errorf("AmountAttainment = %v", progress.AmountAttainment)
+			}
+		})
+	}
+}
+
+func TestCalculateTargetProgressPartialTargets(t *testing.T) {
+	now := time.Date(2025, 2, 15, 0, 0, 0, 0, time.Local) // 2月共28天，已过一半
+
+	// 只设置订单数目标时，金额不参与落后判定
+	ordersOnly := &MerchantTarget{Period: "2025-02", TargetOrders: 100}
+	progress, err := CalculateTargetProgress(ordersOnly, &MerchantTargetActuals{Amount: 10, Orders: 50}, now)
+	if err != nil {
+		t.Fatalf("CalculateTargetProgress() error = %v", err)
+	}
+	if progress.BehindPace {
+		t.Error("订单数按速度达成时不应判定落后")
+	}
+	if progress.ProjectedAmountAttainment != 0 {
+		t.Errorf("未设置金额目标时达成率应为0，得到 %v", progress.ProjectedAmountAttainment)
+	}
+
+	progress, _ = CalculateTargetProgress(ordersOnly, &MerchantTargetActuals{Orders: 40}, now)
+	if !progress.BehindPace {
+		t.Error("预计订单数达成80%时应判定落后")
+	}
+	if !approxEqual(progress.ProjectedOrdersAttainment, 0.8) {
+		t.Errorf("ProjectedOrdersAttainment = %v, want 0.8", progress.ProjectedOrdersAttainment)
+	}
+}
+
+func TestSetMerchantTargetRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     SetMerchantTargetRequest
+		wantErr bool
+	}{
+		{"有效目标", SetMerchantTargetRequest{Period: "2025-09", TargetAmount: 1000}, false},
+		{"只设置订单数", SetMerchantTargetRequest{Period: "2025-09", TargetOrders: 10}, false},
+		{"周期格式错误", SetMerchantTargetRequest{Period: "2025-9-1", TargetAmount: 1000}, true},
+		{"未设置目标", SetMerchantTargetRequest{Period: "2025-09"}, true},
+		{"负数目标", SetMerchantTargetRequest{Period: "2025-09", TargetAmount: -1, TargetOrders: 10}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
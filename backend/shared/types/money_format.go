@@ -0,0 +1,121 @@
+package types
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 未配置金额显示格式时使用人民币、简体中文格式
+const (
+	DefaultMoneyCurrency = "CNY"
+	DefaultMoneyLocale   = "zh-CN"
+)
+
+// MoneyFormatPolicy represents per-tenant money display settings for emails, SMS and PDF reports.
+// Currency decides the symbol and decimals; Locale decides symbol placement and separators.
+type MoneyFormatPolicy struct {
+	Currency string `json:"currency"` // ISO 4217 货币代码，如 CNY、USD
+	Locale   string `json:"locale"`   // 区域设置，如 zh-CN、en-US、de-DE
+}
+
+// currencyFormat 货币符号和小数位数
+type currencyFormat struct {
+	symbol   string
+	decimals int
+}
+
+// localeFormat 区域的千分位、小数分隔符和符号位置
+type localeFormat struct {
+	group        string
+	decimal      string
+	symbolSuffix bool // 符号放在金额之后，如 1.234,56 €
+}
+
+var currencyFormats = map[string]currencyFormat{
+	"CNY": {symbol: "¥", decimals: 2},
+	"USD": {symbol: "$", decimals: 2},
+	"EUR": {symbol: "€", decimals: 2},
+	"GBP": {symbol: "£", decimals: 2},
+	"HKD": {symbol: "HK$", decimals: 2},
+	"JPY": {symbol: "JP¥", decimals: 0},
+	"KRW": {symbol: "₩", decimals: 0},
+}
+
+var localeFormats = map[string]localeFormat{
+	"zh-CN": {group: ",", decimal: "."},
+	"zh-HK": {group: ",", decimal: "."},
+	"en-US": {group: ",", decimal: "."},
+	"en-GB": {group: ",", decimal: "."},
+	"ja-JP": {group: ",", decimal: "."},
+	"ko-KR": {group: ",", decimal: "."},
+	"de-DE": {group: ".", decimal: ",", symbolSuffix: true},
+	"fr-FR": {group: " ", decimal: ",", symbolSuffix: true},
+}
+
+// DefaultMoneyFormatPolicy 默认金额显示格式
+func DefaultMoneyFormatPolicy() *MoneyFormatPolicy {
+	return &MoneyFormatPolicy{Currency: DefaultMoneyCurrency, Locale: DefaultMoneyLocale}
+}
+
+// Format 按货币和区域格式化金额，如 ¥1,234.56、$1,234.56、1.234,56 €。
+// 未配置的字段使用默认值，未知货币以货币代码作为符号，未知区域使用简体中文格式
+func (p *MoneyFormatPolicy) Format(amount float64) string {
+	code, locale := DefaultMoneyCurrency, DefaultMoneyLocale
+	if p != nil && p.Currency != "" {
+		code = strings.ToUpper(p.Currency)
+	}
+	if p != nil && p.Locale != "" {
+		locale = p.Locale
+	}
+
+	currency, ok := currencyFormats[code]
+	if !ok {
+		currency = currencyFormat{symbol: code + " ", decimals: 2}
+	}
+	format, ok := localeFormats[locale]
+	if !ok {
+		format = localeFormats[DefaultMoneyLocale]
+	}
+
+	number := formatGroupedNumber(math.Abs(amount), currency.decimals, format)
+	sign := ""
+	if amount < 0 && strings.Trim(number, "0.,  ") != "" {
+		sign = "-"
+	}
+	if format.symbolSuffix {
+		return sign + number + " " + strings.TrimSpace(currency.symbol)
+	}
+	return sign + currency.symbol + number
+}
+
+// formatGroupedNumber 按小数位数四舍五入，并插入千分位分隔符
+func formatGroupedNumber(amount float64, decimals int, format localeFormat) string {
+	scale := math.Pow10(decimals)
+	formatted := strconv.FormatFloat(math.Round(amount*scale)/scale, 'f', decimals, 64)
+	integer, fraction := formatted, ""
+	if i := strings.IndexByte(formatted, '.'); i >= 0 {
+		integer, fraction = formatted[:i], formatted[i+1:]
+	}
+
+	var b strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(format.group)
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString(format.decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// MoneyTemplateFuncs 返回模板中使用的金额格式化函数，可直接传给 text/template 和 html/template 的 Funcs：
+// {{formatMoney .TotalAmount}}
+func MoneyTemplateFuncs(policy *MoneyFormatPolicy) map[string]interface{} {
+	return map[string]interface{}{
+		"formatMoney": policy.Format,
+	}
+}
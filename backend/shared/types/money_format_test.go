@@ -0,0 +1,72 @@
+package types
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestMoneyFormatPolicyFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *MoneyFormatPolicy
+		amount float64
+		want   string
+	}{
+		{name: "未配置使用人民币", policy: nil, amount: 1234.5, want: "¥1,234.50"},
+		{name: "人民币", policy: &MoneyFormatPolicy{Currency: "CNY", Locale: "zh-CN"}, amount: 1234567.891, want: "¥1,234,567.89"},
+		{name: "美元", policy: &MoneyFormatPolicy{Currency: "USD", Locale: "en-US"}, amount: 1234.5, want: "$1,234.50"},
+		{name: "小写货币代码", policy: &MoneyFormatPolicy{Currency: "usd", Locale: "en-US"}, amount: 99, want: "$99.00"},
+		{name: "欧元德语格式", policy: &MoneyFormatPolicy{Currency: "EUR", Locale: "de-DE"}, amount: 1234.56, want: "1.234,56 €"},
+		{name: "日元无小数", policy: &MoneyFormatPolicy{Currency: "JPY", Locale: "ja-JP"}, amount: 1234.5, want: "JP¥1,235"},
+		{name: "未知货币", policy: &MoneyFormatPolicy{Currency: "CHF", Locale: "en-US"}, amount: 1000, want: "CHF 1,000.00"},
+		{name: "未知区域", policy: &MoneyFormatPolicy{Currency: "USD", Locale: "xx-XX"}, amount: 1000, want: "$1,000.00"},
+		{name: "负数", policy: &MoneyFormatPolicy{Currency: "USD", Locale: "en-US"}, amount: -1234.5, want: "-$1,234.50"},
+		{name: "四舍五入后为0不显示负号", policy: nil, amount: -0.001, want: "¥0.00"},
+		{name: "不足千位", policy: nil, amount: 999.99, want: "¥999.99"},
+		{name: "零", policy: nil, amount: 0, want: "¥0.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Format(tt.amount); got != tt.want {
+				t.Errorf("Format(%v) = %q, want %q", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyTemplateFuncs(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *MoneyFormatPolicy
+		want   string
+	}{
+		{name: "人民币", policy: &MoneyFormatPolicy{Currency: "CNY", Locale: "zh-CN"}, want: "订单金额：¥12,345.60"},
+		{name: "美元", policy: &MoneyFormatPolicy{Currency: "USD", Locale: "en-US"}, want: "订单金额：$12,345.60"},
+	}
+	data := map[string]interface{}{"TotalAmount": 12345.6}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var text strings.Builder
+			tmpl := template.Must(template.New("text").Funcs(MoneyTemplateFuncs(tt.policy)).Parse("订单金额：{{formatMoney .TotalAmount}}"))
+			if err := tmpl.Execute(&text, data); err != nil {
+				t.Fatalf("渲染文本模板失败: %v", err)
+			}
+			if text.String() != tt.want {
+				t.Errorf("text/template = %q, want %q", text.String(), tt.want)
+			}
+
+			var html strings.Builder
+			htmlTmpl := htmltemplate.Must(htmltemplate.New("html").Funcs(MoneyTemplateFuncs(tt.policy)).Parse("<td>订单金额：{{formatMoney .TotalAmount}}</td>"))
+			if err := htmlTmpl.Execute(&html, data); err != nil {
+				t.Fatalf("渲染HTML模板失败: %v", err)
+			}
+			if html.String() != "<td>"+tt.want+"</td>" {
+				t.Errorf("html/template = %q, want %q", html.String(), "<td>"+tt.want+"</td>")
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@ const (
 	AlertTypeBalanceCritical
 	AlertTypeUsageSpike
 	AlertTypePredictedDepletion
+	AlertTypeSalesTargetBehind
 )
 
 // String returns the string representation of AlertType
@@ -27,11 +28,23 @@ func (at AlertType) String() string {
 		return "usage_spike"
 	case AlertTypePredictedDepletion:
 		return "predicted_depletion"
+	case AlertTypeSalesTargetBehind:
+		return "sales_target_behind"
 	default:
 		return "unknown"
 	}
 }
 
+// ParseAlertType 按字符串解析预警类型
+func ParseAlertType(value string) (AlertType, bool) {
+	for at := AlertTypeBalanceLow; at <= AlertTypeSalesTargetBehind; at++ {
+		if at.String() == value {
+			return at, true
+		}
+	}
+	return 0, false
+}
+
 // AlertSeverity 预警严重程度
 type AlertSeverity int
 
@@ -55,6 +68,14 @@ func (as AlertSeverity) String() string {
 	}
 }
 
+// Escalate 升级后的严重程度，已是严重时保持不变
+func (as AlertSeverity) Escalate() AlertSeverity {
+	if as >= AlertSeverityCritical {
+		return AlertSeverityCritical
+	}
+	return as + 1
+}
+
 // AlertStatus 预警状态
 type AlertStatus int
 
@@ -138,6 +159,8 @@ type RightsAlert struct {
 	TriggeredAt      time.Time      `json:"triggered_at" db:"triggered_at"`
 	ResolvedAt       *time.Time     `json:"resolved_at,omitempty" db:"resolved_at"`
 	NotifiedChannels []string       `json:"notified_channels" db:"notified_channels"`
+	EscalationLevel  int            `json:"escalation_level" db:"escalation_level"` // 已升级次数，0 表示未升级
+	EscalatedAt      *time.Time     `json:"escalated_at,omitempty" db:"escalated_at"`
 	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at" db:"updated_at"`
 }
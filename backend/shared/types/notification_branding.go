@@ -0,0 +1,80 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultNotificationSignature 商户和租户都未配置通知品牌时使用的署名
+	DefaultNotificationSignature = "商户系统"
+	// MaxNotificationDisplayNameLength 通知展示名称的最大长度
+	MaxNotificationDisplayNameLength = 50
+	// MaxNotificationSupportContactLength 客服联系方式的最大长度
+	MaxNotificationSupportContactLength = 100
+	// MaxNotificationSignatureLength 通知署名的最大长度
+	MaxNotificationSignatureLength = 200
+)
+
+// NotificationBranding represents how a merchant's customer order notifications are
+// branded. Tenants configure a default in their config; merchants override it field
+// by field. An empty Signature falls back to the DisplayName at the same level.
+type NotificationBranding struct {
+	DisplayName    string `json:"display_name"`    // 展示名称，短信以【展示名称】开头
+	SupportContact string `json:"support_contact"` // 客服联系方式，如电话或邮箱
+	Signature      string `json:"signature"`       // 邮件落款，可以多行
+}
+
+// DefaultNotificationBranding 未配置通知品牌时使用的默认品牌
+func DefaultNotificationBranding() *NotificationBranding {
+	return &NotificationBranding{
+		DisplayName: DefaultNotificationSignature,
+		Signature:   DefaultNotificationSignature,
+	}
+}
+
+// Validate 校验通知品牌配置
+func (b *NotificationBranding) Validate() error {
+	if utf8.RuneCountInString(strings.TrimSpace(b.DisplayName)) > MaxNotificationDisplayNameLength {
+		return fmt.Errorf("通知展示名称不能超过%d个字符", MaxNotificationDisplayNameLength)
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(b.SupportContact)) > MaxNotificationSupportContactLength {
+		return fmt.Errorf("客服联系方式不能超过%d个字符", MaxNotificationSupportContactLength)
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(b.Signature)) > MaxNotificationSignatureLength {
+		return fmt.Errorf("通知署名不能超过%d个字符", MaxNotificationSignatureLength)
+	}
+	return nil
+}
+
+// ResolveNotificationBranding 从默认品牌开始依次用各级配置中非空的字段覆盖，
+// 通常按租户、商户的顺序传入，未配置的级别传 nil
+func ResolveNotificationBranding(levels ...*NotificationBranding) *NotificationBranding {
+	resolved := DefaultNotificationBranding()
+	for _, level := range levels {
+		if level == nil {
+			continue
+		}
+		if name := strings.TrimSpace(level.DisplayName); name != "" {
+			resolved.DisplayName = name
+			// 只配置了展示名称时以展示名称落款，避免沿用上一级的署名
+			resolved.Signature = name
+		}
+		if contact := strings.TrimSpace(level.SupportContact); contact != "" {
+			resolved.SupportContact = contact
+		}
+		if signature := strings.TrimSpace(level.Signature); signature != "" {
+			resolved.Signature = signature
+		}
+	}
+	return resolved
+}
+
+// SupportLine 通知中的客服提示语，未配置客服联系方式时提示联系客服
+func (b *NotificationBranding) SupportLine() string {
+	if b == nil || b.SupportContact == "" {
+		return "如有疑问，请联系我们的客服。"
+	}
+	return fmt.Sprintf("如有疑问，请联系客服：%s", b.SupportContact)
+}
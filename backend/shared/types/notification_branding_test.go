@@ -0,0 +1,85 @@
+package types
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveNotificationBranding(t *testing.T) {
+	tenant := &NotificationBranding{DisplayName: "云集商城", SupportContact: "400-800-1234", Signature: "云集商城运营团队"}
+
+	tests := []struct {
+		name     string
+		tenant   *NotificationBranding
+		merchant *NotificationBranding
+		want     NotificationBranding
+	}{
+		{name: "都未配置时使用默认品牌", want: NotificationBranding{DisplayName: DefaultNotificationSignature, Signature: DefaultNotificationSignature}},
+		{name: "商户未配置时使用租户品牌", tenant: tenant, want: *tenant},
+		{
+			name:     "商户配置覆盖租户品牌",
+			tenant:   tenant,
+			merchant: &NotificationBranding{DisplayName: "山野咖啡", SupportContact: "service@shanye.example", Signature: "山野咖啡 客户服务部"},
+			want:     NotificationBranding{DisplayName: "山野咖啡", SupportContact: "service@shanye.example", Signature: "山野咖啡 客户服务部"},
+		},
+		{
+			name:     "商户只配置展示名称时以展示名称落款，客服沿用租户配置",
+			tenant:   tenant,
+			merchant: &NotificationBranding{DisplayName: "茶语小铺"},
+			want:     NotificationBranding{DisplayName: "茶语小铺", SupportContact: "400-800-1234", Signature: "茶语小铺"},
+		},
+		{
+			name:     "商户只配置客服联系方式",
+			tenant:   tenant,
+			merchant: &NotificationBranding{SupportContact: " 021-5555-6666 "},
+			want:     NotificationBranding{DisplayName: "云集商城", SupportContact: "021-5555-6666", Signature: "云集商城运营团队"},
+		},
+		{
+			name:     "空白字段视为未配置",
+			merchant: &NotificationBranding{DisplayName: "  ", Signature: "\n"},
+			want:     NotificationBranding{DisplayName: DefaultNotificationSignature, Signature: DefaultNotificationSignature},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResolveNotificationBranding(tt.tenant, tt.merchant)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ResolveNotificationBranding() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotificationBrandingValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		branding NotificationBranding
+		wantErr  bool
+	}{
+		{name: "有效配置", branding: NotificationBranding{DisplayName: "山野咖啡", SupportContact: "400-600-7788", Signature: "山野咖啡\n客户服务部"}},
+		{name: "空配置", branding: NotificationBranding{}},
+		{name: "展示名称按字符计算长度", branding: NotificationBranding{DisplayName: strings.Repeat("咖", MaxNotificationDisplayNameLength)}},
+		{name: "展示名称过长", branding: NotificationBranding{DisplayName: strings.Repeat("咖", MaxNotificationDisplayNameLength+1)}, wantErr: true},
+		{name: "客服联系方式过长", branding: NotificationBranding{SupportContact: strings.Repeat("1", MaxNotificationSupportContactLength+1)}, wantErr: true},
+		{name: "署名过长", branding: NotificationBranding{Signature: strings.Repeat("a", MaxNotificationSignatureLength+1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.branding.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNotificationBrandingSupportLine(t *testing.T) {
+	if got := (&NotificationBranding{SupportContact: "400-600-7788"}).SupportLine(); got != "如有疑问，请联系客服：400-600-7788" {
+		t.Errorf("SupportLine() = %s", got)
+	}
+	if got := DefaultNotificationBranding().SupportLine(); got != "如有疑问，请联系我们的客服。" {
+		t.Errorf("未配置客服时 SupportLine() = %s", got)
+	}
+}
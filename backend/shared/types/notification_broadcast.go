@@ -0,0 +1,266 @@
+package types
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ErrBroadcastRateLimited 租户在统计窗口内创建的群发通知已达上限
+var ErrBroadcastRateLimited = errors.New("群发通知次数已达上限，请稍后再试")
+
+const (
+	// MaxBroadcastSubjectLength 群发通知标题最大长度
+	MaxBroadcastSubjectLength = 100
+	// MaxBroadcastContentLength 群发通知内容模板最大长度
+	MaxBroadcastContentLength = 2000
+	// DefaultBroadcastListLimit 群发通知及发送记录列表默认返回条数
+	DefaultBroadcastListLimit = 50
+	// MaxBroadcastListLimit 群发通知及发送记录列表最大返回条数
+	MaxBroadcastListLimit = 500
+)
+
+// BroadcastAudience 群发通知的接收对象
+type BroadcastAudience string
+
+const (
+	BroadcastAudienceCustomers BroadcastAudience = "customers" // 租户下的客户
+	BroadcastAudienceMerchants BroadcastAudience = "merchants" // 商户的用户
+)
+
+// IsValid 检查接收对象是否有效
+func (a BroadcastAudience) IsValid() bool {
+	return a == BroadcastAudienceCustomers || a == BroadcastAudienceMerchants
+}
+
+// BroadcastStatus 群发通知状态
+type BroadcastStatus string
+
+const (
+	BroadcastStatusSending   BroadcastStatus = "sending"   // 发送中，后台任务按批发送
+	BroadcastStatusCompleted BroadcastStatus = "completed" // 已发送给全部接收人
+)
+
+// BroadcastDeliveryStatus 群发通知对单个接收人单个渠道的发送结果
+type BroadcastDeliveryStatus string
+
+const (
+	BroadcastDeliverySent    BroadcastDeliveryStatus = "sent"    // 已发送（免打扰时段内延迟发送的也视为已发送）
+	BroadcastDeliveryFailed  BroadcastDeliveryStatus = "failed"  // 发送失败
+	BroadcastDeliverySkipped BroadcastDeliveryStatus = "skipped" // 接收人已退订群发通知
+)
+
+// BroadcastAudienceFilter 群发通知的接收人筛选条件，条件之间为且的关系，均为空时发送给该类接收对象的全部用户
+type BroadcastAudienceFilter struct {
+	Audience         BroadcastAudience `json:"audience"`
+	MerchantIDs      []uint64          `json:"merchant_ids,omitempty"`      // 客户：在这些商户下过单；商户：这些商户的用户
+	OrderedSince     *time.Time        `json:"ordered_since,omitempty"`     // 仅客户：该时间之后下过单
+	RegisteredAfter  *time.Time        `json:"registered_after,omitempty"`  // 该时间之后注册
+	RegisteredBefore *time.Time        `json:"registered_before,omitempty"` // 该时间之前注册
+}
+
+// Validate 校验接收人筛选条件
+func (f *BroadcastAudienceFilter) Validate() error {
+	if !f.Audience.IsValid() {
+		return fmt.Errorf("群发通知接收对象无效: %s", f.Audience)
+	}
+	if f.OrderedSince != nil && f.Audience != BroadcastAudienceCustomers {
+		return fmt.Errorf("仅发送给客户时可以按下单时间筛选")
+	}
+	if f.RegisteredAfter != nil && f.RegisteredBefore != nil && !f.RegisteredAfter.Before(*f.RegisteredBefore) {
+		return fmt.Errorf("注册时间范围无效")
+	}
+	return nil
+}
+
+// Value 实现 driver.Valuer 接口
+func (f BroadcastAudienceFilter) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (f *BroadcastAudienceFilter) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	}
+	return nil
+}
+
+// NotificationBroadcast 群发通知：按筛选条件向客户或商户用户发送同一条消息，
+// 后台任务按用户ID顺序分批发送，发送进度和各渠道的发送结果记录在通知上
+type NotificationBroadcast struct {
+	ID              uint64                  `json:"id" db:"id"`
+	TenantID        uint64                  `json:"tenant_id" db:"tenant_id"`
+	Subject         string                  `json:"subject" db:"subject"` // 邮件主题
+	Content         string                  `json:"content" db:"content"` // 内容模板，可使用 {{.Username}}
+	Channels        StringArray             `json:"channels" db:"channels"`
+	Filter          BroadcastAudienceFilter `json:"filter" db:"filter"`
+	Status          BroadcastStatus         `json:"status" db:"status"`
+	CreatedBy       uint64                  `json:"created_by" db:"created_by"`
+	TotalRecipients int                     `json:"total_recipients" db:"total_recipients"` // 创建时符合条件的接收人数
+	ProcessedCount  int                     `json:"processed_count" db:"processed_count"`   // 已处理的接收人数
+	SkippedCount    int                     `json:"skipped_count" db:"skipped_count"`       // 已退订而未发送的接收人数
+	SentCount       int                     `json:"sent_count" db:"sent_count"`             // 发送成功的消息数（接收人 × 渠道）
+	FailedCount     int                     `json:"failed_count" db:"failed_count"`         // 发送失败的消息数（接收人 × 渠道）
+	CursorUserID    uint64                  `json:"-" db:"cursor_user_id"`                  // 已处理到的用户ID
+	CreatedAt       time.Time               `json:"created_at" db:"created_at"`
+	CompletedAt     *time.Time              `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// BroadcastRecipient 群发通知的接收人
+type BroadcastRecipient struct {
+	UserID   uint64 `json:"user_id" db:"id"`
+	Username string `json:"username" db:"username"`
+	OptedOut bool   `json:"opted_out" db:"opted_out"` // 是否已退订群发通知
+}
+
+// BroadcastDelivery 群发通知对单个接收人单个渠道的发送记录，已退订的接收人只记录一条跳过记录
+type BroadcastDelivery struct {
+	ID          uint64                  `json:"id" db:"id"`
+	TenantID    uint64                  `json:"tenant_id" db:"tenant_id"`
+	BroadcastID uint64                  `json:"broadcast_id" db:"broadcast_id"`
+	UserID      uint64                  `json:"user_id" db:"user_id"`
+	Channel     NotificationChannel     `json:"channel,omitempty" db:"channel"`
+	Status      BroadcastDeliveryStatus `json:"status" db:"status"`
+	Error       string                  `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time               `json:"created_at" db:"created_at"`
+}
+
+// BroadcastProgress 一批接收人的发送统计，累加到群发通知上
+type BroadcastProgress struct {
+	Processed int
+	Skipped   int
+	Sent      int
+	Failed    int
+}
+
+// Record 按发送记录累加统计，每个接收人调用一次
+func (p *BroadcastProgress) Record(deliveries []BroadcastDelivery) {
+	p.Processed++
+	for _, delivery := range deliveries {
+		switch delivery.Status {
+		case BroadcastDeliverySent:
+			p.Sent++
+		case BroadcastDeliveryFailed:
+			p.Failed++
+		case BroadcastDeliverySkipped:
+			p.Skipped++
+		}
+	}
+}
+
+// Apply 将一批的统计计入群发通知
+func (b *NotificationBroadcast) Apply(progress BroadcastProgress) {
+	b.ProcessedCount += progress.Processed
+	b.SkippedCount += progress.Skipped
+	b.SentCount += progress.Sent
+	b.FailedCount += progress.Failed
+}
+
+// Render 按接收人渲染通知内容
+func (b *NotificationBroadcast) Render(recipient *BroadcastRecipient) (string, error) {
+	tmpl, err := template.New("broadcast").Option("missingkey=error").Parse(b.Content)
+	if err != nil {
+		return "", fmt.Errorf("解析群发通知内容失败: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, recipient); err != nil {
+		return "", fmt.Errorf("渲染群发通知内容失败: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// BroadcastPreview 群发通知接收人预览
+type BroadcastPreview struct {
+	Recipients  int `json:"recipients"`  // 符合条件的接收人数
+	OptedOut    int `json:"opted_out"`   // 其中已退订的接收人数
+	Deliverable int `json:"deliverable"` // 实际发送的接收人数
+}
+
+// CreateBroadcastRequest 创建群发通知请求
+type CreateBroadcastRequest struct {
+	Subject  string                  `json:"subject"`
+	Content  string                  `json:"content" v:"required#通知内容不能为空"`
+	Channels []NotificationChannel   `json:"channels" v:"required#发送渠道不能为空"`
+	Filter   BroadcastAudienceFilter `json:"filter"`
+}
+
+// Validate 校验群发通知，并用示例接收人检查内容模板
+func (r *CreateBroadcastRequest) Validate() error {
+	if strings.TrimSpace(r.Content) == "" {
+		return fmt.Errorf("通知内容不能为空")
+	}
+	if len([]rune(r.Content)) > MaxBroadcastContentLength {
+		return fmt.Errorf("通知内容不能超过%d个字符", MaxBroadcastContentLength)
+	}
+	if len([]rune(r.Subject)) > MaxBroadcastSubjectLength {
+		return fmt.Errorf("通知标题不能超过%d个字符", MaxBroadcastSubjectLength)
+	}
+	if len(r.Channels) == 0 {
+		return fmt.Errorf("发送渠道不能为空")
+	}
+	seen := make(map[NotificationChannel]bool, len(r.Channels))
+	for _, channel := range r.Channels {
+		if channel != NotificationChannelSMS && channel != NotificationChannelEmail {
+			return fmt.Errorf("发送渠道无效: %s", channel)
+		}
+		if seen[channel] {
+			return fmt.Errorf("发送渠道重复: %s", channel)
+		}
+		seen[channel] = true
+	}
+	if seen[NotificationChannelEmail] && strings.TrimSpace(r.Subject) == "" {
+		return fmt.Errorf("通过邮件发送时通知标题不能为空")
+	}
+	if err := r.Filter.Validate(); err != nil {
+		return err
+	}
+
+	broadcast := &NotificationBroadcast{Content: r.Content}
+	if _, err := broadcast.Render(&BroadcastRecipient{Username: "preview"}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// BroadcastListRequest 群发通知及发送记录列表请求
+type BroadcastListRequest struct {
+	Limit int `json:"limit"`
+}
+
+// EffectiveLimit 返回生效的条数限制
+func (r *BroadcastListRequest) EffectiveLimit() int {
+	if r == nil || r.Limit <= 0 {
+		return DefaultBroadcastListLimit
+	}
+	if r.Limit > MaxBroadcastListLimit {
+		return MaxBroadcastListLimit
+	}
+	return r.Limit
+}
+
+// BroadcastPreference 用户群发通知偏好，未设置时默认接收。退订只影响群发通知，不影响订单通知
+type BroadcastPreference struct {
+	TenantID  uint64    `json:"tenant_id" db:"tenant_id"`
+	UserID    uint64    `json:"user_id" db:"user_id"`
+	OptedOut  bool      `json:"opted_out" db:"opted_out"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// UpdateBroadcastPreferenceRequest 更新群发通知偏好请求
+type UpdateBroadcastPreferenceRequest struct {
+	OptedOut bool `json:"opted_out"`
+}
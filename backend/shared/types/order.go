@@ -1,6 +1,9 @@
 package types
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -33,6 +36,16 @@ func (os OrderStatusInt) ToOrderStatus() OrderStatus {
 	}
 }
 
+// ToOrderStatusInt 转换为数字形式的订单状态，无法识别时返回false
+func (os OrderStatus) ToOrderStatusInt() (OrderStatusInt, bool) {
+	for status := OrderStatusIntPending; status <= OrderStatusIntCancelled; status++ {
+		if status.ToOrderStatus() == os {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
 // String 返回订单状态的字符串表示
 func (os OrderStatusInt) String() string {
 	switch os {
@@ -75,6 +88,9 @@ func (os OrderStatusInt) IsValidTransition(toStatus OrderStatusInt) bool {
 	return false
 }
 
+// ErrOrderStatusConflict 订单状态在校验后已被其他请求修改，本次状态变更未生效
+var ErrOrderStatusConflict = errors.New("订单状态已被其他操作修改，请刷新后重试")
+
 // ExtendedOrderItem 扩展的订单项（包含更多字段）
 type ExtendedOrderItem struct {
 	ID                 uint64  `json:"id"`
@@ -108,6 +124,64 @@ const (
 	PaymentMethodBalance PaymentMethod = "balance"
 )
 
+// AllPaymentMethods 系统支持的全部支付方式
+var AllPaymentMethods = []PaymentMethod{PaymentMethodAlipay, PaymentMethodWechat, PaymentMethodBalance}
+
+// IsValid 检查支付方式是否有效
+func (m PaymentMethod) IsValid() bool {
+	for _, method := range AllPaymentMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// PaymentMethodList 商户启用的支付方式，为空表示接受全部支付方式
+type PaymentMethodList []PaymentMethod
+
+// Effective 返回实际可用的支付方式，未配置时为全部支付方式
+func (l PaymentMethodList) Effective() []PaymentMethod {
+	if len(l) == 0 {
+		return append([]PaymentMethod(nil), AllPaymentMethods...)
+	}
+	return append([]PaymentMethod(nil), l...)
+}
+
+// Allows 检查是否接受指定支付方式
+func (l PaymentMethodList) Allows(method PaymentMethod) bool {
+	for _, allowed := range l.Effective() {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Value 实现 driver.Valuer 接口
+func (l PaymentMethodList) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return json.Marshal([]PaymentMethod{})
+	}
+	return json.Marshal(l)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (l *PaymentMethodList) Scan(value interface{}) error {
+	if value == nil {
+		*l = PaymentMethodList{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	}
+	return nil
+}
+
 // PaymentStatus 支付状态枚举
 type PaymentStatus int
 
@@ -147,6 +221,8 @@ type Cart struct {
 	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
 	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	// RecoveryNotifiedAt 最近一次发送找回提醒的时间，早于更新时间表示购物车之后又有变更
+	RecoveryNotifiedAt *time.Time `json:"recovery_notified_at,omitempty" db:"recovery_notified_at"`
 }
 
 // CartItem 购物车项
@@ -155,10 +231,15 @@ type CartItem struct {
 	TenantID  uint64    `json:"tenant_id" db:"tenant_id"`
 	CartID    uint64    `json:"cart_id" db:"cart_id"`
 	ProductID uint64    `json:"product_id" db:"product_id"`
+	BundleID  uint64    `json:"bundle_id,omitempty" db:"bundle_id"` // 套餐购物车项的 ProductID 为 0
 	Quantity  int       `json:"quantity" db:"quantity"`
+	Version   int       `json:"version" db:"version"` // 每次修改数量后递增，用于检测并发修改
 	AddedAt   time.Time `json:"added_at" db:"added_at"`
 }
 
+// ErrCartItemVersionConflict 购物车项已被其他请求修改，更新请求携带的版本已过期
+var ErrCartItemVersionConflict = errors.New("购物车项已被修改，请刷新后重试")
+
 // PaymentRecord 支付记录
 type PaymentRecord struct {
 	ID            uint64        `json:"id" db:"id"`
@@ -182,7 +263,21 @@ type CreateOrderRequest struct {
 	Items      []struct {
 		ProductID uint64 `json:"product_id" v:"required#商品ID不能为空"`
 		Quantity  int    `json:"quantity" v:"required|min:1#数量不能为空|数量必须大于0"`
-	} `json:"items" v:"required|length:1,50#订单项不能为空|订单项不能超过50个"`
+	} `json:"items" v:"required-without:Bundles|length:1,50#订单项不能为空|订单项不能超过50个"`
+	// Bundles 按套餐价格购买的套餐，下单时按组成商品校验和占用库存
+	Bundles []OrderBundleRequest `json:"bundles,omitempty"`
+	// ShippingAddressID 收货地址簿中的地址ID，下单时保存地址快照；为 0 时订单不含收货地址
+	ShippingAddressID uint64 `json:"shipping_address_id,omitempty"`
+	// ExternalReference 对接方系统中的订单号，同一租户内不能重复，可用于查询订单
+	ExternalReference string `json:"external_reference,omitempty"`
+}
+
+// AddItem 追加订单项，用于在服务端组装下单请求（如再来一单）
+func (r *CreateOrderRequest) AddItem(productID uint64, quantity int) {
+	r.Items = append(r.Items, struct {
+		ProductID uint64 `json:"product_id" v:"required#商品ID不能为空"`
+		Quantity  int    `json:"quantity" v:"required|min:1#数量不能为空|数量必须大于0"`
+	}{ProductID: productID, Quantity: quantity})
 }
 
 // AddCartItemRequest 添加购物车项请求
@@ -194,12 +289,42 @@ type AddCartItemRequest struct {
 // UpdateCartItemRequest 更新购物车项请求
 type UpdateCartItemRequest struct {
 	Quantity int `json:"quantity" v:"required|min:1#数量不能为空|数量必须大于0"`
+	// Version 客户端读取到的购物车项版本，为 0 时不检查版本，以最后一次更新为准
+	Version int `json:"version" v:"min:0#版本不能为负数"`
 }
 
 // InitiatePaymentRequest 发起支付请求
 type InitiatePaymentRequest struct {
 	PaymentMethod PaymentMethod `json:"payment_method" v:"required#支付方式不能为空"`
 	ReturnURL     string        `json:"return_url,omitempty"`
+	// Sandbox 沙箱模式下指定模拟支付结果，非沙箱模式下传入会被拒绝
+	Sandbox *SandboxPaymentOutcome `json:"sandbox,omitempty"`
+}
+
+// SandboxOutcome 沙箱支付模拟结果
+type SandboxOutcome string
+
+const (
+	SandboxOutcomeSuccess SandboxOutcome = "success" // 支付成功
+	SandboxOutcomeFail    SandboxOutcome = "fail"    // 支付失败（交易关闭）
+	SandboxOutcomeDelay   SandboxOutcome = "delay"   // 网关延迟响应后成功，超过网关超时则失败
+	// SandboxOutcomeCallbackLost 网关支付成功但回调丢失，订单需由支付对账恢复
+	SandboxOutcomeCallbackLost SandboxOutcome = "callback_lost"
+)
+
+// IsValid 检查模拟结果是否有效
+func (o SandboxOutcome) IsValid() bool {
+	switch o {
+	case SandboxOutcomeSuccess, SandboxOutcomeFail, SandboxOutcomeDelay, SandboxOutcomeCallbackLost:
+		return true
+	}
+	return false
+}
+
+// SandboxPaymentOutcome 沙箱支付模拟设置
+type SandboxPaymentOutcome struct {
+	Outcome SandboxOutcome `json:"outcome" v:"required#模拟结果不能为空"`
+	DelayMs int            `json:"delay_ms,omitempty"` // 网关模拟延迟（毫秒），仅 delay 结果生效
 }
 
 // OrderConfirmation 订单确认信息
@@ -207,9 +332,15 @@ type OrderConfirmation struct {
 	Items           []OrderConfirmationItem `json:"items"`
 	TotalAmount     float64                 `json:"total_amount"`
 	TotalRightsCost float64                 `json:"total_rights_cost"`
-	AvailableRights float64                 `json:"available_rights"`
-	CanCreate       bool                    `json:"can_create"`
-	ErrorMessage    string                  `json:"error_message,omitempty"`
+	AvailableRights float64                 `json:"available_rights"` // 商户可使用的权益，已扣除保留权益
+	// 不含税商品金额、税额及税费明细，未计税时税费明细为空
+	SubtotalAmount float64   `json:"subtotal_amount"`
+	TaxAmount      float64   `json:"tax_amount"`
+	Tax            *OrderTax `json:"tax,omitempty"`
+	// AvailablePaymentMethods 商户接受的支付方式
+	AvailablePaymentMethods []PaymentMethod `json:"available_payment_methods"`
+	CanCreate               bool            `json:"can_create"`
+	ErrorMessage            string          `json:"error_message,omitempty"`
 }
 
 // OrderConfirmationItem 订单确认项
@@ -223,4 +354,42 @@ type OrderConfirmationItem struct {
 	SubtotalRightsCost float64 `json:"subtotal_rights_cost"`
 	StockAvailable     int     `json:"stock_available"`
 	StockSufficient    bool    `json:"stock_sufficient"`
-}
\ No newline at end of file
+	// 超出可用库存、以预订方式接受的数量及预计补货日期
+	BackorderedQuantity int        `json:"backordered_quantity,omitempty"`
+	EstimatedRestockAt  *time.Time `json:"estimated_restock_at,omitempty"`
+	// 套餐确认项的 ProductID 为 0，StockAvailable 为按组成商品库存计算的可售份数
+	BundleID         uint64                  `json:"bundle_id,omitempty"`
+	BundleComponents ProductBundleComponents `json:"bundle_components,omitempty"`
+}
+
+// OrderNoteVisibility 订单备注可见范围
+type OrderNoteVisibility string
+
+const (
+	OrderNoteVisibilityInternal OrderNoteVisibility = "internal" // 仅内部员工可见
+	OrderNoteVisibilityCustomer OrderNoteVisibility = "customer" // 客户可见
+)
+
+// IsValid 检查可见范围是否有效
+func (v OrderNoteVisibility) IsValid() bool {
+	return v == OrderNoteVisibilityInternal || v == OrderNoteVisibilityCustomer
+}
+
+// OrderNote 订单备注（供客服和履约人员协作使用）
+type OrderNote struct {
+	ID         uint64              `json:"id" db:"id"`
+	TenantID   uint64              `json:"tenant_id" db:"tenant_id"`
+	OrderID    uint64              `json:"order_id" db:"order_id"`
+	MerchantID uint64              `json:"merchant_id" db:"merchant_id"`
+	AuthorID   uint64              `json:"author_id" db:"author_id"`
+	AuthorName string              `json:"author_name" db:"author_name"`
+	Visibility OrderNoteVisibility `json:"visibility" db:"visibility"`
+	Content    string              `json:"content" db:"content"`
+	CreatedAt  time.Time           `json:"created_at" db:"created_at"`
+}
+
+// CreateOrderNoteRequest 添加订单备注请求
+type CreateOrderNoteRequest struct {
+	Content    string              `json:"content" v:"required|length:1,2000#备注内容不能为空|备注内容长度为1-2000个字符"`
+	Visibility OrderNoteVisibility `json:"visibility"` // 默认仅内部可见
+}
@@ -0,0 +1,31 @@
+package types
+
+// MaxBatchCreateOrders 单次批量下单最多包含的订单数
+const MaxBatchCreateOrders = 20
+
+// BatchCreateOrderRequest 批量创建订单请求
+type BatchCreateOrderRequest struct {
+	// Orders 订单数量由 MaxBatchCreateOrders 限制，length 规则校验的是字符串长度，不能用于数组
+	Orders []CreateOrderRequest `json:"orders" v:"required#订单不能为空"`
+	// AllOrNothing 为 true 时任一订单失败整批失败，已创建的订单取消并释放库存预留
+	AllOrNothing bool `json:"all_or_nothing"`
+}
+
+// BatchCreateOrderFailure 批量下单中失败的订单
+type BatchCreateOrderFailure struct {
+	Index   int    `json:"index"`              // 订单在请求中的位置，从0开始
+	OrderID uint64 `json:"order_id,omitempty"` // 订单已创建后失败时为被取消的订单ID
+	Error   string `json:"error"`
+}
+
+// BatchCreateOrderResult 批量创建订单结果
+type BatchCreateOrderResult struct {
+	Orders               []*Order                  `json:"orders"`
+	Failures             []BatchCreateOrderFailure `json:"failures"`
+	ReleasedReservations int                       `json:"released_reservations"` // 失败订单释放的库存预留数
+}
+
+// HasFailures 是否有订单创建失败
+func (r *BatchCreateOrderResult) HasFailures() bool {
+	return len(r.Failures) > 0
+}
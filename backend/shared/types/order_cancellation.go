@@ -0,0 +1,117 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// CancellationFeeType 取消手续费类型
+type CancellationFeeType string
+
+const (
+	CancellationFeeNone       CancellationFeeType = "none"       // 不收取
+	CancellationFeeFixed      CancellationFeeType = "fixed"      // 固定金额
+	CancellationFeePercentage CancellationFeeType = "percentage" // 按支付金额百分比
+)
+
+// IsValid 验证手续费类型是否有效
+func (t CancellationFeeType) IsValid() bool {
+	switch t {
+	case CancellationFeeNone, CancellationFeeFixed, CancellationFeePercentage:
+		return true
+	default:
+		return false
+	}
+}
+
+// OrderCancellationPolicy 订单取消策略，商户级配置优先于租户默认配置
+type OrderCancellationPolicy struct {
+	ID                     uint64              `json:"id" db:"id"`
+	TenantID               uint64              `json:"tenant_id" db:"tenant_id"`
+	MerchantID             *uint64             `json:"merchant_id,omitempty" db:"merchant_id"`
+	CustomerCancelStatuses []OrderStatusInt    `json:"customer_cancel_statuses" db:"customer_cancel_statuses"` // 客户可取消的订单状态
+	MerchantCancelStatuses []OrderStatusInt    `json:"merchant_cancel_statuses" db:"merchant_cancel_statuses"` // 商户可取消的订单状态
+	RefundOnCancel         bool                `json:"refund_on_cancel" db:"refund_on_cancel"`                 // 已支付订单取消时自动退款
+	FeeType                CancellationFeeType `json:"fee_type" db:"fee_type"`
+	FeeValue               float64             `json:"fee_value" db:"fee_value"` // 固定金额或百分比(0-100)
+	CreatedAt              time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt              time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// DefaultOrderCancellationPolicy 默认取消策略：客户只能取消待支付订单，商户可取消待支付和已支付订单，已支付订单自动全额退款
+func DefaultOrderCancellationPolicy(tenantID uint64) *OrderCancellationPolicy {
+	return &OrderCancellationPolicy{
+		TenantID:               tenantID,
+		CustomerCancelStatuses: []OrderStatusInt{OrderStatusIntPending},
+		MerchantCancelStatuses: []OrderStatusInt{OrderStatusIntPending, OrderStatusIntPaid},
+		RefundOnCancel:         true,
+		FeeType:                CancellationFeeNone,
+	}
+}
+
+// AllowedStatuses 获取操作员可取消的订单状态，客户以外的操作员按商户规则处理
+func (p *OrderCancellationPolicy) AllowedStatuses(operatorType OrderStatusOperatorType) []OrderStatusInt {
+	if operatorType == OrderStatusOperatorTypeCustomer {
+		return p.CustomerCancelStatuses
+	}
+	return p.MerchantCancelStatuses
+}
+
+// CanCancel 检查操作员能否取消指定状态的订单
+func (p *OrderCancellationPolicy) CanCancel(operatorType OrderStatusOperatorType, status OrderStatusInt) bool {
+	for _, allowed := range p.AllowedStatuses(operatorType) {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CancellationFee 计算取消手续费，手续费不超过支付金额
+func (p *OrderCancellationPolicy) CancellationFee(paidAmount float64) float64 {
+	var fee float64
+	switch p.FeeType {
+	case CancellationFeeFixed:
+		fee = p.FeeValue
+	case CancellationFeePercentage:
+		fee = math.Round(paidAmount*p.FeeValue) / 100
+	}
+	return math.Min(math.Max(fee, 0), paidAmount)
+}
+
+// Validate 验证取消策略
+func (p *OrderCancellationPolicy) Validate() error {
+	for _, statuses := range [][]OrderStatusInt{p.CustomerCancelStatuses, p.MerchantCancelStatuses} {
+		for _, status := range statuses {
+			if status != OrderStatusIntPending && status != OrderStatusIntPaid && status != OrderStatusIntProcessing {
+				return errors.New("只能配置待支付、已支付、处理中状态的订单可取消")
+			}
+		}
+	}
+	if !p.FeeType.IsValid() {
+		return errors.New("无效的取消手续费类型")
+	}
+	if p.FeeValue < 0 {
+		return errors.New("取消手续费不能为负数")
+	}
+	if p.FeeType == CancellationFeePercentage && p.FeeValue > 100 {
+		return errors.New("取消手续费比例不能超过100")
+	}
+	return nil
+}
+
+// CancelOrderRequest 取消订单请求
+type CancelOrderRequest struct {
+	Reason       string                  `json:"reason"`
+	OperatorType OrderStatusOperatorType `json:"operator_type"` // 为空时视为客户取消
+}
+
+// OrderCancellationResult 订单取消结果
+type OrderCancellationResult struct {
+	OrderID         uint64  `json:"order_id"`
+	Refunded        bool    `json:"refunded"`
+	RefundAmount    float64 `json:"refund_amount"`
+	CancellationFee float64 `json:"cancellation_fee"`
+	RightsRefunded  float64 `json:"rights_refunded"` // 按退款比例退还给商户的权益
+}
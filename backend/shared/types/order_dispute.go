@@ -0,0 +1,139 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrOrderDisputeActive 订单已有未结束的争议
+var ErrOrderDisputeActive = errors.New("该订单已有处理中的争议")
+
+const (
+	// DefaultOrderDisputeListLimit 争议列表默认返回条数
+	DefaultOrderDisputeListLimit = 100
+	// MaxOrderDisputeListLimit 争议列表最大返回条数
+	MaxOrderDisputeListLimit = 500
+)
+
+// OrderDisputeReason 订单争议原因
+type OrderDisputeReason string
+
+const (
+	OrderDisputeReasonWrongItem   OrderDisputeReason = "wrong_item"   // 商品错发
+	OrderDisputeReasonNotReceived OrderDisputeReason = "not_received" // 未收到商品
+	OrderDisputeReasonDamaged     OrderDisputeReason = "damaged"      // 商品损坏
+	OrderDisputeReasonOther       OrderDisputeReason = "other"        // 其他
+)
+
+// IsValid 检查争议原因是否有效
+func (r OrderDisputeReason) IsValid() bool {
+	switch r {
+	case OrderDisputeReasonWrongItem, OrderDisputeReasonNotReceived, OrderDisputeReasonDamaged, OrderDisputeReasonOther:
+		return true
+	}
+	return false
+}
+
+// OrderDisputeStatus 订单争议状态
+type OrderDisputeStatus string
+
+const (
+	OrderDisputeStatusOpen          OrderDisputeStatus = "open"          // 已提交，待处理
+	OrderDisputeStatusInvestigating OrderDisputeStatus = "investigating" // 调查中
+	OrderDisputeStatusResolved      OrderDisputeStatus = "resolved"      // 已解决
+	OrderDisputeStatusRejected      OrderDisputeStatus = "rejected"      // 已驳回
+)
+
+// IsValid 检查争议状态是否有效
+func (s OrderDisputeStatus) IsValid() bool {
+	return s == OrderDisputeStatusOpen || s == OrderDisputeStatusInvestigating || s.IsClosed()
+}
+
+// IsActive 争议是否未结束，未结束的争议会暂停订单自动完成
+func (s OrderDisputeStatus) IsActive() bool {
+	return s == OrderDisputeStatusOpen || s == OrderDisputeStatusInvestigating
+}
+
+// IsClosed 争议是否已结束（已解决或已驳回）
+func (s OrderDisputeStatus) IsClosed() bool {
+	return s == OrderDisputeStatusResolved || s == OrderDisputeStatusRejected
+}
+
+// CanTransitionTo 检查争议状态流转是否合法：待处理可转为调查中或直接结束，调查中只能结束，已结束的争议不能再变更
+func (s OrderDisputeStatus) CanTransitionTo(next OrderDisputeStatus) bool {
+	switch s {
+	case OrderDisputeStatusOpen:
+		return next == OrderDisputeStatusInvestigating || next.IsClosed()
+	case OrderDisputeStatusInvestigating:
+		return next.IsClosed()
+	}
+	return false
+}
+
+// ActiveOrderDisputeStatuses 未结束的争议状态
+var ActiveOrderDisputeStatuses = []OrderDisputeStatus{OrderDisputeStatusOpen, OrderDisputeStatusInvestigating}
+
+// OrderDispute 订单争议（客户对错发、未收到等问题提出的投诉及处理结果）
+type OrderDispute struct {
+	ID              uint64             `json:"id" db:"id"`
+	TenantID        uint64             `json:"tenant_id" db:"tenant_id"`
+	OrderID         uint64             `json:"order_id" db:"order_id"`
+	MerchantID      uint64             `json:"merchant_id" db:"merchant_id"`
+	CustomerID      uint64             `json:"customer_id" db:"customer_id"`
+	Reason          OrderDisputeReason `json:"reason" db:"reason"`
+	Description     string             `json:"description" db:"description"`
+	Status          OrderDisputeStatus `json:"status" db:"status"`
+	ResolutionNotes string             `json:"resolution_notes,omitempty" db:"resolution_notes"`
+	HandlerID       *uint64            `json:"handler_id,omitempty" db:"handler_id"` // 最后处理争议的员工
+	ResolvedAt      *time.Time         `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt       time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// CanDisputeOrder 检查订单状态是否允许发起争议：已支付后的订单才可能出现错发或未收到的问题
+func CanDisputeOrder(status OrderStatus) bool {
+	return status == OrderStatusPaid || status == OrderStatusProcessing || status == OrderStatusCompleted
+}
+
+// CreateOrderDisputeRequest 发起订单争议请求
+type CreateOrderDisputeRequest struct {
+	Reason      OrderDisputeReason `json:"reason" v:"required#争议原因不能为空"`
+	Description string             `json:"description" v:"required|length:1,2000#问题描述不能为空|问题描述长度为1-2000个字符"`
+}
+
+// Validate 验证发起争议请求
+func (r *CreateOrderDisputeRequest) Validate() error {
+	if !r.Reason.IsValid() {
+		return fmt.Errorf("无效的争议原因: %s", r.Reason)
+	}
+	if strings.TrimSpace(r.Description) == "" {
+		return errors.New("问题描述不能为空")
+	}
+	return nil
+}
+
+// UpdateOrderDisputeStatusRequest 更新订单争议状态请求
+type UpdateOrderDisputeStatusRequest struct {
+	Status          OrderDisputeStatus `json:"status" v:"required#争议状态不能为空"`
+	ResolutionNotes string             `json:"resolution_notes" v:"length:0,2000#处理说明长度不能超过2000个字符"` // 结束争议时必填
+}
+
+// Validate 验证更新争议状态请求，结束争议时必须填写处理说明
+func (r *UpdateOrderDisputeStatusRequest) Validate() error {
+	if !r.Status.IsValid() {
+		return fmt.Errorf("无效的争议状态: %s", r.Status)
+	}
+	if r.Status.IsClosed() && strings.TrimSpace(r.ResolutionNotes) == "" {
+		return errors.New("结束争议时必须填写处理说明")
+	}
+	return nil
+}
+
+// OrderDisputeListRequest 订单争议列表查询请求
+type OrderDisputeListRequest struct {
+	Status     OrderDisputeStatus `json:"status"`      // 为空时返回全部状态
+	MerchantID *uint64            `json:"merchant_id"` // 商户用户固定为本商户
+	Limit      int                `json:"limit"`
+}
@@ -0,0 +1,49 @@
+package types
+
+import "testing"
+
+func TestOrderDisputeStatusTransition(t *testing.T) {
+	tests := []struct {
+		from OrderDisputeStatus
+		to   OrderDisputeStatus
+		want bool
+	}{
+		{OrderDisputeStatusOpen, OrderDisputeStatusInvestigating, true},
+		{OrderDisputeStatusOpen, OrderDisputeStatusResolved, true},
+		{OrderDisputeStatusOpen, OrderDisputeStatusRejected, true},
+		{OrderDisputeStatusOpen, OrderDisputeStatusOpen, false},
+		{OrderDisputeStatusInvestigating, OrderDisputeStatusResolved, true},
+		{OrderDisputeStatusInvestigating, OrderDisputeStatusRejected, true},
+		{OrderDisputeStatusInvestigating, OrderDisputeStatusOpen, false},
+		{OrderDisputeStatusResolved, OrderDisputeStatusInvestigating, false},
+		{OrderDisputeStatusRejected, OrderDisputeStatusOpen, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s -> %s = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateOrderDisputeStatusRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     UpdateOrderDisputeStatusRequest
+		wantErr bool
+	}{
+		{name: "转为调查中无需处理说明", req: UpdateOrderDisputeStatusRequest{Status: OrderDisputeStatusInvestigating}},
+		{name: "解决争议需要处理说明", req: UpdateOrderDisputeStatusRequest{Status: OrderDisputeStatusResolved}, wantErr: true},
+		{name: "驳回争议需要处理说明", req: UpdateOrderDisputeStatusRequest{Status: OrderDisputeStatusRejected, ResolutionNotes: "  "}, wantErr: true},
+		{name: "填写处理说明后解决", req: UpdateOrderDisputeStatusRequest{Status: OrderDisputeStatusResolved, ResolutionNotes: "已退款"}},
+		{name: "无效状态", req: UpdateOrderDisputeStatusRequest{Status: "closed", ResolutionNotes: "x"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
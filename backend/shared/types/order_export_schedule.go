@@ -0,0 +1,229 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// OrderExportFrequency 定时订单导出频率
+type OrderExportFrequency string
+
+const (
+	OrderExportFrequencyDaily  OrderExportFrequency = "daily"
+	OrderExportFrequencyWeekly OrderExportFrequency = "weekly"
+)
+
+// OrderExportDestination 定时订单导出文件的投递方式
+type OrderExportDestination string
+
+const (
+	OrderExportDestinationEmail   OrderExportDestination = "email"   // 发送下载链接到邮箱
+	OrderExportDestinationStorage OrderExportDestination = "storage" // 上传到对象存储的指定目录
+)
+
+// OrderExportRunStatus 定时订单导出执行状态
+type OrderExportRunStatus string
+
+const (
+	OrderExportRunStatusGenerating OrderExportRunStatus = "generating" // 导出文件生成中
+	OrderExportRunStatusDelivered  OrderExportRunStatus = "delivered"  // 已投递
+	OrderExportRunStatusFailed     OrderExportRunStatus = "failed"     // 生成或投递失败，下次执行重新导出同一范围
+)
+
+// ErrOrderExportScheduleNotFound 定时订单导出不存在或不属于当前租户
+var ErrOrderExportScheduleNotFound = errors.New("定时订单导出不存在")
+
+// OrderExportFilter 定时订单导出的筛选条件
+type OrderExportFilter struct {
+	MerchantID *uint64       `json:"merchant_id,omitempty"`
+	Statuses   []OrderStatus `json:"statuses,omitempty"` // 为空时导出所有状态的订单
+}
+
+// Value 实现 driver.Valuer 接口
+func (f OrderExportFilter) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (f *OrderExportFilter) Scan(value interface{}) error {
+	if value == nil {
+		*f = OrderExportFilter{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	}
+	return nil
+}
+
+// OrderExportSchedule 定时订单导出：按计划将新增订单导出给财务系统。
+// 每次执行导出订单ID大于 LastOrderID 的订单，投递成功后 LastOrderID 推进到本次导出的上界
+type OrderExportSchedule struct {
+	ID          uint64                 `json:"id" db:"id"`
+	TenantID    uint64                 `json:"tenant_id" db:"tenant_id"`
+	Name        string                 `json:"name" db:"name"`
+	Filter      OrderExportFilter      `json:"filter" db:"filter"`
+	Format      OrderExportFormat      `json:"format" db:"format"`
+	Frequency   OrderExportFrequency   `json:"frequency" db:"frequency"`
+	Weekday     int                    `json:"weekday" db:"weekday"` // 每周执行时的星期，0 为星期日
+	Hour        int                    `json:"hour" db:"hour"`
+	Minute      int                    `json:"minute" db:"minute"`
+	Destination OrderExportDestination `json:"destination" db:"destination"`
+	Recipient   string                 `json:"recipient" db:"recipient"` // 邮箱地址（多个用逗号分隔）或对象存储目录
+	Enabled     bool                   `json:"enabled" db:"enabled"`
+	LastOrderID uint64                 `json:"last_order_id" db:"last_order_id"` // 已投递的最大订单ID
+	NextRunAt   time.Time              `json:"next_run_at" db:"next_run_at"`
+	LastRunAt   *time.Time             `json:"last_run_at,omitempty" db:"last_run_at"`
+	CreatedBy   uint64                 `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// NextRunAfter 计算 after 之后的下一次执行时间，按 after 所在时区计算
+func (s *OrderExportSchedule) NextRunAfter(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), s.Hour, s.Minute, 0, 0, after.Location())
+	if s.Frequency == OrderExportFrequencyWeekly {
+		next = next.AddDate(0, 0, (s.Weekday-int(next.Weekday())+7)%7)
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next
+	}
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Recipients 邮件投递的收件人列表
+func (s *OrderExportSchedule) Recipients() []string {
+	var recipients []string
+	for _, recipient := range strings.Split(s.Recipient, ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			recipients = append(recipients, recipient)
+		}
+	}
+	return recipients
+}
+
+// CreateOrderExportScheduleRequest 创建定时订单导出请求
+type CreateOrderExportScheduleRequest struct {
+	Name        string                 `json:"name" v:"required|length:1,100#导出名称不能为空|导出名称不能超过100个字符"`
+	Filter      OrderExportFilter      `json:"filter"`
+	Format      OrderExportFormat      `json:"format"`
+	Frequency   OrderExportFrequency   `json:"frequency"`
+	Weekday     int                    `json:"weekday"`
+	Hour        int                    `json:"hour"`
+	Minute      int                    `json:"minute"`
+	Destination OrderExportDestination `json:"destination"`
+	Recipient   string                 `json:"recipient"`
+	// 首次执行从该订单ID之后开始导出，为空时从当前最新订单之后开始，只导出创建定时导出后的新订单
+	StartAfterOrderID *uint64 `json:"start_after_order_id,omitempty"`
+}
+
+// Validate 校验定时订单导出配置，未指定导出格式时使用 CSV
+func (r *CreateOrderExportScheduleRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("导出名称不能为空")
+	}
+	if r.Format == "" {
+		r.Format = OrderExportFormatCSV
+	}
+	if !r.Format.IsValid() {
+		return errors.New("不支持的导出格式，支持: csv, excel")
+	}
+	switch r.Frequency {
+	case OrderExportFrequencyDaily:
+	case OrderExportFrequencyWeekly:
+		if r.Weekday < 0 || r.Weekday > 6 {
+			return errors.New("每周执行的星期必须在0到6之间")
+		}
+	default:
+		return errors.New("不支持的导出频率，支持: daily, weekly")
+	}
+	if r.Hour < 0 || r.Hour > 23 || r.Minute < 0 || r.Minute > 59 {
+		return errors.New("执行时间无效")
+	}
+	for _, status := range r.Filter.Statuses {
+		if !status.IsValid() {
+			return fmt.Errorf("无效的订单状态: %s", status)
+		}
+	}
+	return r.validateDestination()
+}
+
+// validateDestination 校验投递方式和收件人或存储目录
+func (r *CreateOrderExportScheduleRequest) validateDestination() error {
+	r.Recipient = strings.TrimSpace(r.Recipient)
+	if r.Recipient == "" {
+		return errors.New("收件人或存储目录不能为空")
+	}
+
+	switch r.Destination {
+	case OrderExportDestinationEmail:
+		schedule := OrderExportSchedule{Recipient: r.Recipient}
+		for _, recipient := range schedule.Recipients() {
+			if _, err := mail.ParseAddress(recipient); err != nil {
+				return fmt.Errorf("无效的邮箱地址: %s", recipient)
+			}
+		}
+	case OrderExportDestinationStorage:
+		r.Recipient = strings.Trim(r.Recipient, "/")
+		for _, segment := range strings.Split(r.Recipient, "/") {
+			if segment == "" || segment == "." || segment == ".." {
+				return errors.New("存储目录无效")
+			}
+		}
+	default:
+		return errors.New("不支持的投递方式，支持: email, storage")
+	}
+	return nil
+}
+
+// OrderExportRun 定时订单导出的一次执行，导出订单ID在 (FromOrderID, ThroughOrderID] 范围内的订单
+type OrderExportRun struct {
+	ID             uint64               `json:"id" db:"id"`
+	TenantID       uint64               `json:"tenant_id" db:"tenant_id"`
+	ScheduleID     uint64               `json:"schedule_id" db:"schedule_id"`
+	ExportJobID    string               `json:"export_job_id" db:"export_job_id"` // 导出队列任务ID，可通过 /exports/:id 下载
+	Status         OrderExportRunStatus `json:"status" db:"status"`
+	FromOrderID    uint64               `json:"from_order_id" db:"from_order_id"`
+	ThroughOrderID uint64               `json:"through_order_id" db:"through_order_id"`
+	RowCount       int                  `json:"row_count" db:"row_count"`
+	ErrorMessage   string               `json:"error_message,omitempty" db:"error_message"`
+	StartedAt      time.Time            `json:"started_at" db:"started_at"`
+	CompletedAt    *time.Time           `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// OrderExtractParams 定时订单导出在导出队列中的导出条件
+type OrderExtractParams struct {
+	ScheduleID     uint64            `json:"schedule_id"`
+	Filter         OrderExportFilter `json:"filter"`
+	Format         OrderExportFormat `json:"format"`
+	AfterOrderID   uint64            `json:"after_order_id"`
+	ThroughOrderID uint64            `json:"through_order_id"`
+}
+
+// OrderExtractRow 定时订单导出行
+type OrderExtractRow struct {
+	ID                uint64      `json:"id" db:"id"`
+	OrderNumber       string      `json:"order_number" db:"order_number"`
+	ExternalReference string      `json:"external_reference" db:"external_reference"`
+	MerchantID        uint64      `json:"merchant_id" db:"merchant_id"`
+	CustomerID        uint64      `json:"customer_id" db:"customer_id"`
+	Status            OrderStatus `json:"status" db:"status"`
+	SubtotalAmount    float64     `json:"subtotal_amount" db:"subtotal_amount"`
+	TaxAmount         float64     `json:"tax_amount" db:"tax_amount"`
+	TotalAmount       float64     `json:"total_amount" db:"total_amount"`
+	TotalRightsCost   float64     `json:"total_rights_cost" db:"total_rights_cost"`
+	CreatedAt         time.Time   `json:"created_at" db:"created_at"`
+}
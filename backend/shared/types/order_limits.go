@@ -0,0 +1,17 @@
+package types
+
+import "errors"
+
+// ErrInvalidOrderQuantityLimits 商品购买数量限制无效
+var ErrInvalidOrderQuantityLimits = errors.New("最大购买数量不能小于最小购买数量")
+
+// ValidateOrderQuantityLimits 校验商品单笔订单购买数量限制，0 表示不限制
+func ValidateOrderQuantityLimits(min, max int) error {
+	if min < 0 || max < 0 {
+		return errors.New("购买数量限制不能为负数")
+	}
+	if min > 0 && max > 0 && max < min {
+		return ErrInvalidOrderQuantityLimits
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateOrderQuantityLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		min     int
+		max     int
+		wantErr error
+		invalid bool
+	}{
+		{name: "不限制", min: 0, max: 0},
+		{name: "仅最小数量", min: 2, max: 0},
+		{name: "仅最大数量", min: 0, max: 10},
+		{name: "最大数量等于最小数量", min: 3, max: 3},
+		{name: "最大数量小于最小数量", min: 5, max: 2, wantErr: ErrInvalidOrderQuantityLimits, invalid: true},
+		{name: "负数", min: -1, max: 0, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOrderQuantityLimits(tt.min, tt.max)
+			if (err != nil) != tt.invalid {
+				t.Fatalf("ValidateOrderQuantityLimits() error = %v, invalid %v", err, tt.invalid)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateOrderQuantityLimits() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
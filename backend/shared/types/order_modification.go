@@ -0,0 +1,28 @@
+package types
+
+// OrderItemChange 订单商品数量变更，Quantity 为修改后的数量，0 表示移除该商品
+type OrderItemChange struct {
+	ProductID uint64 `json:"product_id" v:"required#商品ID不能为空"`
+	Quantity  int    `json:"quantity" v:"min:0#商品数量不能为负数"`
+}
+
+// UpdateOrderItemsRequest 修改待支付订单商品请求
+type UpdateOrderItemsRequest struct {
+	Changes []OrderItemChange `json:"changes" v:"required#修改内容不能为空"`
+}
+
+// OrderItemAdjustment 单个商品的数量调整记录
+type OrderItemAdjustment struct {
+	ProductID    uint64 `json:"product_id"`
+	FromQuantity int    `json:"from_quantity"`
+	ToQuantity   int    `json:"to_quantity"`
+}
+
+// OrderItemsModificationResult 修改订单商品结果，商品全部移除时订单被取消
+type OrderItemsModificationResult struct {
+	Order              *Order                `json:"order"`
+	Adjustments        []OrderItemAdjustment `json:"adjustments"`
+	PreviousAmount     float64               `json:"previous_amount"`
+	PreviousRightsCost float64               `json:"previous_rights_cost"`
+	Cancelled          bool                  `json:"cancelled"`
+}
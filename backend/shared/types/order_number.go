@@ -0,0 +1,98 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// OrderNumberMaxLength 订单号最大长度，与 orders.order_number 字段一致
+	OrderNumberMaxLength = 50
+	// OrderNumberMerchantSeparator 商户编码段与序号之间的分隔符，商户编码只含字母数字，保证可以无歧义地切分
+	OrderNumberMerchantSeparator = "-"
+)
+
+// orderNumberDatePatterns 支持的日期格式
+var orderNumberDatePatterns = map[string]string{
+	"YYYYMMDDHHmmss": "20060102150405",
+	"YYYYMMDD":       "20060102",
+	"YYMMDD":         "060102",
+	"YYYYMM":         "200601",
+}
+
+// orderNumberPrefixPattern 前缀只允许大写字母，紧跟的日期段以数字开头，因此不同租户的前缀不会互相混淆
+var orderNumberPrefixPattern = regexp.MustCompile(`^[A-Z]{1,8}$`)
+
+// ErrOrderNumberSequenceExhausted 序号超出配置的位数
+var ErrOrderNumberSequenceExhausted = errors.New("订单号序号已用尽，请增加序号位数")
+
+// OrderNumberFormat 租户订单号格式：前缀 + 日期 + [商户编码-] + 定长序号。
+// 序号由序号源按“序号之前的全部内容”分别递增，因此同一格式内不会重复。
+type OrderNumberFormat struct {
+	ID                  uint64    `json:"id" db:"id"`
+	TenantID            uint64    `json:"tenant_id" db:"tenant_id"`
+	Prefix              string    `json:"prefix" db:"prefix"`
+	DatePattern         string    `json:"date_pattern" db:"date_pattern"`     // YYYYMMDDHHmmss、YYYYMMDD、YYMMDD、YYYYMM
+	SequenceWidth       int       `json:"sequence_width" db:"sequence_width"` // 序号位数，不足补零
+	IncludeMerchantCode bool      `json:"include_merchant_code" db:"include_merchant_code"`
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DefaultOrderNumberFormat 默认订单号格式：年月日时分秒 + 6位序号，未配置格式的租户使用
+func DefaultOrderNumberFormat(tenantID uint64) *OrderNumberFormat {
+	return &OrderNumberFormat{
+		TenantID:      tenantID,
+		DatePattern:   "YYYYMMDDHHmmss",
+		SequenceWidth: 6,
+	}
+}
+
+// Validate 验证租户自定义的订单号格式。
+// 自定义格式必须带字母前缀（默认格式以数字开头），前缀在租户间唯一由仓储保存时校验
+func (f *OrderNumberFormat) Validate() error {
+	if !orderNumberPrefixPattern.MatchString(f.Prefix) {
+		return errors.New("订单号前缀必须为1-8位大写字母")
+	}
+	if _, ok := orderNumberDatePatterns[f.DatePattern]; !ok {
+		return errors.New("不支持的日期格式，可选值: YYYYMMDDHHmmss、YYYYMMDD、YYMMDD、YYYYMM")
+	}
+	if f.SequenceWidth < 4 || f.SequenceWidth > 12 {
+		return errors.New("序号位数必须在4-12之间")
+	}
+	if len(f.Prefix)+len(f.DatePattern)+f.SequenceWidth > OrderNumberMaxLength {
+		return fmt.Errorf("订单号长度不能超过%d位", OrderNumberMaxLength)
+	}
+	return nil
+}
+
+// SequenceScope 序号作用域，即订单号中序号之前的部分。日期段变化时序号自然从1开始
+func (f *OrderNumberFormat) SequenceScope(now time.Time, merchantCode string) string {
+	var builder strings.Builder
+	builder.WriteString(f.Prefix)
+	builder.WriteString(now.Format(orderNumberDatePatterns[f.DatePattern]))
+	if f.IncludeMerchantCode {
+		builder.WriteString(merchantCode)
+		builder.WriteString(OrderNumberMerchantSeparator)
+	}
+	return builder.String()
+}
+
+// Render 用序号源分配的序号生成订单号，序号超出位数时返回错误而不是加宽，避免与其他作用域的订单号重叠
+func (f *OrderNumberFormat) Render(now time.Time, merchantCode string, sequence uint64) (string, error) {
+	if f.IncludeMerchantCode && merchantCode == "" {
+		return "", errors.New("订单号格式包含商户编码，但商户编码为空")
+	}
+
+	number, err := SequenceFormat{Prefix: f.SequenceScope(now, merchantCode), Width: f.SequenceWidth}.Render(sequence)
+	if err != nil {
+		return "", ErrOrderNumberSequenceExhausted
+	}
+	if len(number) > OrderNumberMaxLength {
+		return "", fmt.Errorf("订单号长度超过%d位，请缩短前缀或商户编码", OrderNumberMaxLength)
+	}
+	return number, nil
+}
@@ -0,0 +1,149 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrderNumberFormatRender(t *testing.T) {
+	now := time.Date(2025, 8, 28, 14, 30, 5, 0, time.Local)
+
+	tests := []struct {
+		name         string
+		format       OrderNumberFormat
+		merchantCode string
+		sequence     uint64
+		want         string
+	}{
+		{
+			name:     "default format",
+			format:   *DefaultOrderNumberFormat(1),
+			sequence: 1,
+			want:     "20250828143005000001",
+		},
+		{
+			name:     "prefix with daily sequence",
+			format:   OrderNumberFormat{Prefix: "ORD", DatePattern: "YYYYMMDD", SequenceWidth: 6},
+			sequence: 42,
+			want:     "ORD20250828000042",
+		},
+		{
+			name:     "short date and narrow sequence",
+			format:   OrderNumberFormat{Prefix: "SO", DatePattern: "YYMMDD", SequenceWidth: 4},
+			sequence: 9999,
+			want:     "SO2508289999",
+		},
+		{
+			name:     "monthly sequence",
+			format:   OrderNumberFormat{Prefix: "M", DatePattern: "YYYYMM", SequenceWidth: 8},
+			sequence: 123,
+			want:     "M20250800000123",
+		},
+		{
+			name:         "embedded merchant code",
+			format:       OrderNumberFormat{Prefix: "ORD", DatePattern: "YYYYMMDD", SequenceWidth: 5, IncludeMerchantCode: true},
+			merchantCode: "SHOP01",
+			sequence:     7,
+			want:         "ORD20250828SHOP01-00007",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.format.Render(now, tt.merchantCode, tt.sequence)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %s, want %s", got, tt.want)
+			}
+			if !strings.HasPrefix(got, tt.format.SequenceScope(now, tt.merchantCode)) {
+				t.Errorf("Render() = %s, should start with sequence scope", got)
+			}
+		})
+	}
+}
+
+func TestOrderNumberFormatRenderErrors(t *testing.T) {
+	now := time.Date(2025, 8, 28, 14, 30, 5, 0, time.Local)
+
+	format := OrderNumberFormat{Prefix: "SO", DatePattern: "YYMMDD", SequenceWidth: 4}
+	if _, err := format.Render(now, "", 10000); err != ErrOrderNumberSequenceExhausted {
+		t.Errorf("Render() error = %v, want %v", err, ErrOrderNumberSequenceExhausted)
+	}
+
+	format.IncludeMerchantCode = true
+	if _, err := format.Render(now, "", 1); err == nil {
+		t.Error("Render() should fail without merchant code")
+	}
+
+	if _, err := format.Render(now, strings.Repeat("A", 40), 1); err == nil {
+		t.Error("Render() should fail when order number exceeds max length")
+	}
+}
+
+func TestOrderNumberFormatCollisionFree(t *testing.T) {
+	now := time.Date(2025, 8, 28, 14, 30, 5, 0, time.Local)
+
+	// 模拟序号源：按作用域分别递增
+	sequences := make(map[string]uint64)
+	nextSequence := func(scope string) uint64 {
+		sequences[scope]++
+		return sequences[scope]
+	}
+
+	formats := []OrderNumberFormat{
+		*DefaultOrderNumberFormat(1),
+		*DefaultOrderNumberFormat(2),
+		{Prefix: "A", DatePattern: "YYYYMMDD", SequenceWidth: 4, IncludeMerchantCode: true},
+		{Prefix: "AB", DatePattern: "YYYYMMDD", SequenceWidth: 6},
+		{Prefix: "ABC", DatePattern: "YYMMDD", SequenceWidth: 4},
+	}
+	merchantCodes := []string{"M1", "M12", "M123"}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		for _, format := range formats {
+			for _, merchantCode := range merchantCodes {
+				scope := format.SequenceScope(now, merchantCode)
+				number, err := format.Render(now, merchantCode, nextSequence(scope))
+				if err != nil {
+					t.Fatalf("Render() error = %v", err)
+				}
+				if seen[number] {
+					t.Fatalf("duplicate order number %s", number)
+				}
+				seen[number] = true
+			}
+		}
+	}
+}
+
+func TestOrderNumberFormatValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  OrderNumberFormat
+		wantErr bool
+	}{
+		{"valid", OrderNumberFormat{Prefix: "ORD", DatePattern: "YYYYMMDD", SequenceWidth: 6}, false},
+		{"valid with merchant code", OrderNumberFormat{Prefix: "ORD", DatePattern: "YYMMDD", SequenceWidth: 4, IncludeMerchantCode: true}, false},
+		{"missing prefix", OrderNumberFormat{DatePattern: "YYYYMMDD", SequenceWidth: 6}, true},
+		{"lowercase prefix", OrderNumberFormat{Prefix: "ord", DatePattern: "YYYYMMDD", SequenceWidth: 6}, true},
+		{"digit in prefix", OrderNumberFormat{Prefix: "O1", DatePattern: "YYYYMMDD", SequenceWidth: 6}, true},
+		{"prefix too long", OrderNumberFormat{Prefix: "ABCDEFGHI", DatePattern: "YYYYMMDD", SequenceWidth: 6}, true},
+		{"unknown date pattern", OrderNumberFormat{Prefix: "ORD", DatePattern: "DDMMYYYY", SequenceWidth: 6}, true},
+		{"missing date pattern", OrderNumberFormat{Prefix: "ORD", SequenceWidth: 6}, true},
+		{"sequence too narrow", OrderNumberFormat{Prefix: "ORD", DatePattern: "YYYYMMDD", SequenceWidth: 3}, true},
+		{"sequence too wide", OrderNumberFormat{Prefix: "ORD", DatePattern: "YYYYMMDD", SequenceWidth: 13}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.format.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// OrderRecomputeBatchSize 重算订单时每批读取的订单数
+	OrderRecomputeBatchSize = 100
+	// MaxOrderRecomputeOrders 单次重算最多处理的订单数，超出的订单需缩小时间范围后再次重算
+	MaxOrderRecomputeOrders = 10000
+	// MaxOrderRecomputeReported 重算结果中最多列出的有变更订单数
+	MaxOrderRecomputeReported = 200
+	// MaxOrderRecomputeRange 重算时间范围上限
+	MaxOrderRecomputeRange = 366 * 24 * time.Hour
+)
+
+// 可重算的订单派生字段
+const (
+	OrderDerivedFieldTotalAmount     = "total_amount"
+	OrderDerivedFieldTotalRightsCost = "total_rights_cost"
+	OrderDerivedFieldSubtotalAmount  = "subtotal_amount"
+	OrderDerivedFieldTaxAmount       = "tax_amount"
+	OrderDerivedFieldTaxBreakdown    = "tax_breakdown"
+)
+
+// OrderRecomputeRequest 重算订单派生字段请求，按下单时间范围和状态筛选当前租户的订单
+type OrderRecomputeRequest struct {
+	StartDate time.Time    `json:"start_date"`
+	EndDate   time.Time    `json:"end_date"`
+	Status    *OrderStatus `json:"status,omitempty"`
+	DryRun    bool         `json:"dry_run"` // 只报告将发生的变更，不保存
+}
+
+// Validate 校验重算条件
+func (req *OrderRecomputeRequest) Validate() error {
+	if req.StartDate.IsZero() || req.EndDate.IsZero() {
+		return errors.New("重算时间范围不能为空")
+	}
+	if !req.EndDate.After(req.StartDate) {
+		return errors.New("结束时间必须晚于开始时间")
+	}
+	if req.EndDate.Sub(req.StartDate) > MaxOrderRecomputeRange {
+		return errors.New("重算时间范围不能超过366天")
+	}
+	if req.Status != nil && !req.Status.IsValid() {
+		return errors.New("无效的订单状态")
+	}
+	return nil
+}
+
+// OrderFieldChange 订单派生字段的变更
+type OrderFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// OrderRecomputeChange 一个订单重算后的变更
+type OrderRecomputeChange struct {
+	OrderID     uint64             `json:"order_id"`
+	OrderNumber string             `json:"order_number"`
+	Changes     []OrderFieldChange `json:"changes"`
+}
+
+// OrderRecomputeResult 重算结果
+type OrderRecomputeResult struct {
+	DryRun    bool                   `json:"dry_run"`
+	Scanned   int                    `json:"scanned"`   // 检查的订单数
+	Changed   int                    `json:"changed"`   // 派生字段与重算结果不一致的订单数
+	Updated   int                    `json:"updated"`   // 已保存的订单数，试运行时为 0
+	Failed    []uint64               `json:"failed"`    // 保存失败的订单
+	Truncated bool                   `json:"truncated"` // 达到单次重算上限，仍有订单未检查
+	Orders    []OrderRecomputeChange `json:"orders"`    // 有变更的订单，最多列出 MaxOrderRecomputeReported 个
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxExternalReferenceLength 外部订单号最大长度
+const MaxExternalReferenceLength = 64
+
+var (
+	// ErrDuplicateExternalReference 同一租户下外部订单号已被其他订单使用
+	ErrDuplicateExternalReference = errors.New("外部订单号已存在")
+	// ErrInvalidExternalReference 外部订单号格式无效
+	ErrInvalidExternalReference = fmt.Errorf("外部订单号只能包含字母、数字和 . _ - : /，且不超过%d个字符", MaxExternalReferenceLength)
+	// ErrOrderReferenceNotFound 按订单号或外部订单号未找到订单
+	ErrOrderReferenceNotFound = errors.New("订单不存在")
+)
+
+// externalReferencePattern 外部订单号以字母或数字开头，可包含常见的分隔符
+var externalReferencePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/-]*$`)
+
+// NormalizeExternalReference 去除首尾空白后校验外部订单号，为空表示未设置
+func NormalizeExternalReference(reference string) (string, error) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		return "", nil
+	}
+	if len(reference) > MaxExternalReferenceLength || !externalReferencePattern.MatchString(reference) {
+		return "", ErrInvalidExternalReference
+	}
+	return reference, nil
+}
+
+// OrderLookupRequest 按订单号或外部订单号查询订单，两者只能指定一个；
+// 只指定 Reference 时先按订单号查找，未找到再按外部订单号查找
+type OrderLookupRequest struct {
+	OrderNumber       string `json:"order_number"`
+	ExternalReference string `json:"external_reference"`
+	Reference         string `json:"reference"`
+}
+
+// Validate 校验只指定了一种查询方式
+func (r *OrderLookupRequest) Validate() error {
+	specified := 0
+	for _, value := range []string{r.OrderNumber, r.ExternalReference, r.Reference} {
+		if strings.TrimSpace(value) != "" {
+			specified++
+		}
+	}
+	if specified != 1 {
+		return errors.New("请指定订单号、外部订单号或查询编号中的一个")
+	}
+	return nil
+}
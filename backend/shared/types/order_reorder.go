@@ -0,0 +1,56 @@
+package types
+
+import "fmt"
+
+// ReorderMode 再来一单的方式
+type ReorderMode string
+
+const (
+	ReorderModeOrder ReorderMode = "order" // 直接创建新订单
+	ReorderModeCart  ReorderMode = "cart"  // 将商品加入购物车，由客户确认后下单
+)
+
+// ReorderIssueReason 原订单商品无法按原样再次购买的原因
+type ReorderIssueReason string
+
+const (
+	ReorderIssueUnavailable     ReorderIssueReason = "unavailable"      // 商品已下架、删除或不在可售时间内，不会加入新订单
+	ReorderIssueOutOfStock      ReorderIssueReason = "out_of_stock"     // 商品已无可用库存，不会加入新订单
+	ReorderIssueQuantityReduced ReorderIssueReason = "quantity_reduced" // 可用库存不足原数量，按可用库存购买
+	ReorderIssuePriceChanged    ReorderIssueReason = "price_changed"    // 商品价格与原订单不同，按当前价格购买
+)
+
+// ReorderRequest 再来一单请求，Mode 为空时直接创建新订单
+type ReorderRequest struct {
+	Mode ReorderMode `json:"mode"`
+}
+
+// Validate 校验再来一单方式
+func (r *ReorderRequest) Validate() error {
+	switch r.Mode {
+	case "", ReorderModeOrder, ReorderModeCart:
+		return nil
+	default:
+		return fmt.Errorf("不支持的再来一单方式: %s", r.Mode)
+	}
+}
+
+// ReorderItemIssue 原订单商品的变化情况
+type ReorderItemIssue struct {
+	ProductID         uint64             `json:"product_id"`
+	Reason            ReorderIssueReason `json:"reason"`
+	Message           string             `json:"message"`
+	PreviousQuantity  int                `json:"previous_quantity"`
+	Quantity          int                `json:"quantity"` // 实际购买数量，商品不可购买时为0
+	PreviousUnitPrice float64            `json:"previous_unit_price"`
+	UnitPrice         float64            `json:"unit_price,omitempty"`
+}
+
+// ReorderResult 再来一单结果：Mode 为 order 时 Order 为新创建的订单，为 cart 时 CartItems 为加入购物车的商品
+type ReorderResult struct {
+	SourceOrderID uint64             `json:"source_order_id"`
+	Mode          ReorderMode        `json:"mode"`
+	Order         *Order             `json:"order,omitempty"`
+	CartItems     []OrderItem        `json:"cart_items,omitempty"`
+	Issues        []ReorderItemIssue `json:"issues"`
+}
@@ -0,0 +1,290 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrOrderUnderReview 订单正在人工审核，审核通过前不能支付
+var ErrOrderUnderReview = errors.New("订单正在审核中，审核通过后才能支付")
+
+const (
+	// DefaultOrderReviewListLimit 审核队列默认返回条数
+	DefaultOrderReviewListLimit = 100
+	// MaxOrderReviewListLimit 审核队列最大返回条数
+	MaxOrderReviewListLimit = 500
+	// DefaultOrderReviewVelocityWindow 下单频率规则未配置统计窗口时的默认窗口（分钟）
+	DefaultOrderReviewVelocityWindow = 60
+)
+
+// OrderReviewCondition 订单审核规则的条件
+type OrderReviewCondition string
+
+const (
+	OrderReviewConditionAmountAtLeast   OrderReviewCondition = "amount_at_least"   // 订单金额不低于阈值
+	OrderReviewConditionQuantityAtLeast OrderReviewCondition = "quantity_at_least" // 订单商品总数量不低于阈值
+	OrderReviewConditionNewCustomer     OrderReviewCondition = "new_customer"      // 客户此前的订单数少于阈值（阈值为空时为首单）
+	OrderReviewConditionOrderVelocity   OrderReviewCondition = "order_velocity"    // 客户在统计窗口内的下单数（含本单）不低于阈值
+)
+
+// IsValid 检查审核条件是否有效
+func (c OrderReviewCondition) IsValid() bool {
+	switch c {
+	case OrderReviewConditionAmountAtLeast, OrderReviewConditionQuantityAtLeast,
+		OrderReviewConditionNewCustomer, OrderReviewConditionOrderVelocity:
+		return true
+	}
+	return false
+}
+
+// OrderReviewPolicy represents per-tenant fraud review rules evaluated at order creation.
+// An order matching any rule is held for manual review before it can be paid.
+type OrderReviewPolicy struct {
+	Enabled bool              `json:"enabled"`
+	Rules   []OrderReviewRule `json:"rules"`
+}
+
+// OrderReviewRule 订单审核规则，MerchantIDs 为空时对租户下全部商户生效
+type OrderReviewRule struct {
+	Name          string               `json:"name"`                     // 规则名称，展示在审核队列中
+	Condition     OrderReviewCondition `json:"condition"`                // 条件
+	Threshold     float64              `json:"threshold"`                // 阈值
+	WindowMinutes int                  `json:"window_minutes,omitempty"` // 统计窗口（分钟），仅 order_velocity 使用
+	MerchantIDs   []uint64             `json:"merchant_ids,omitempty"`   // 生效的商户
+}
+
+// Validate 校验订单审核策略
+func (p *OrderReviewPolicy) Validate() error {
+	for i, rule := range p.Rules {
+		if strings.TrimSpace(rule.Name) == "" {
+			return fmt.Errorf("第%d条审核规则缺少名称", i+1)
+		}
+		if !rule.Condition.IsValid() {
+			return fmt.Errorf("审核规则 %s 的条件无效: %s", rule.Name, rule.Condition)
+		}
+		if rule.Threshold < 0 {
+			return fmt.Errorf("审核规则 %s 的阈值不能为负数", rule.Name)
+		}
+		if rule.Condition != OrderReviewConditionNewCustomer && rule.Threshold == 0 {
+			return fmt.Errorf("审核规则 %s 缺少阈值", rule.Name)
+		}
+		if rule.WindowMinutes < 0 {
+			return fmt.Errorf("审核规则 %s 的统计窗口不能为负数", rule.Name)
+		}
+	}
+	return nil
+}
+
+// OrderReviewCustomerStats 评估客户相关规则所需的下单统计
+type OrderReviewCustomerStats struct {
+	PreviousOrders int // 本单之前的订单数
+	RecentOrders   int // 统计窗口内的订单数（含本单）
+}
+
+// OrderReviewMatch 订单命中的审核规则
+type OrderReviewMatch struct {
+	Rule      string               `json:"rule"`
+	Condition OrderReviewCondition `json:"condition"`
+	Threshold float64              `json:"threshold"`
+	Actual    float64              `json:"actual"`
+	Message   string               `json:"message"`
+}
+
+// OrderReviewMatchList 命中规则列表（JSON存储）
+type OrderReviewMatchList []OrderReviewMatch
+
+// Value 实现 driver.Valuer 接口
+func (l OrderReviewMatchList) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return json.Marshal([]OrderReviewMatch{})
+	}
+	return json.Marshal([]OrderReviewMatch(l))
+}
+
+// Scan 实现 sql.Scanner 接口
+func (l *OrderReviewMatchList) Scan(value interface{}) error {
+	if value == nil {
+		*l = OrderReviewMatchList{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	}
+	return nil
+}
+
+// RulesFor 返回对商户生效的规则
+func (p *OrderReviewPolicy) RulesFor(merchantID uint64) []OrderReviewRule {
+	if p == nil || !p.Enabled {
+		return nil
+	}
+
+	var rules []OrderReviewRule
+	for _, rule := range p.Rules {
+		if len(rule.MerchantIDs) == 0 {
+			rules = append(rules, rule)
+			continue
+		}
+		for _, id := range rule.MerchantIDs {
+			if id == merchantID {
+				rules = append(rules, rule)
+				break
+			}
+		}
+	}
+	return rules
+}
+
+// NeedsCustomerStats 规则中是否有需要客户下单统计的条件
+func NeedsCustomerStats(rules []OrderReviewRule) bool {
+	for _, rule := range rules {
+		if rule.Condition == OrderReviewConditionNewCustomer || rule.Condition == OrderReviewConditionOrderVelocity {
+			return true
+		}
+	}
+	return false
+}
+
+// VelocityWindow 下单频率规则的统计窗口，规则中有多个窗口时取最长的
+func VelocityWindow(rules []OrderReviewRule) time.Duration {
+	minutes := 0
+	for _, rule := range rules {
+		if rule.Condition != OrderReviewConditionOrderVelocity {
+			continue
+		}
+		window := rule.WindowMinutes
+		if window <= 0 {
+			window = DefaultOrderReviewVelocityWindow
+		}
+		if window > minutes {
+			minutes = window
+		}
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// EvaluateOrderReviewRules 按规则评估订单，返回全部命中的规则
+func EvaluateOrderReviewRules(rules []OrderReviewRule, order *Order, stats OrderReviewCustomerStats) []OrderReviewMatch {
+	quantity := 0
+	for _, item := range order.Items {
+		quantity += item.Quantity
+	}
+
+	var matches []OrderReviewMatch
+	for _, rule := range rules {
+		match := OrderReviewMatch{Rule: rule.Name, Condition: rule.Condition, Threshold: rule.Threshold}
+		switch rule.Condition {
+		case OrderReviewConditionAmountAtLeast:
+			match.Actual = order.TotalAmount
+			if order.TotalAmount < rule.Threshold {
+				continue
+			}
+			match.Message = fmt.Sprintf("订单金额%.2f达到审核阈值%.2f", order.TotalAmount, rule.Threshold)
+		case OrderReviewConditionQuantityAtLeast:
+			match.Actual = float64(quantity)
+			if float64(quantity) < rule.Threshold {
+				continue
+			}
+			match.Message = fmt.Sprintf("订单商品数量%d达到审核阈值%.0f", quantity, rule.Threshold)
+		case OrderReviewConditionNewCustomer:
+			threshold := rule.Threshold
+			if threshold <= 0 {
+				threshold = 1
+			}
+			match.Threshold = threshold
+			match.Actual = float64(stats.PreviousOrders)
+			if float64(stats.PreviousOrders) >= threshold {
+				continue
+			}
+			match.Message = fmt.Sprintf("新客户，此前仅有%d个订单", stats.PreviousOrders)
+		case OrderReviewConditionOrderVelocity:
+			match.Actual = float64(stats.RecentOrders)
+			if float64(stats.RecentOrders) < rule.Threshold {
+				continue
+			}
+			window := rule.WindowMinutes
+			if window <= 0 {
+				window = DefaultOrderReviewVelocityWindow
+			}
+			match.Message = fmt.Sprintf("客户%d分钟内下单%d次", window, stats.RecentOrders)
+		default:
+			continue
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// OrderReviewStatus 订单审核状态
+type OrderReviewStatus string
+
+const (
+	OrderReviewStatusPending  OrderReviewStatus = "pending"  // 待审核，订单暂停支付且不计算支付超时
+	OrderReviewStatusApproved OrderReviewStatus = "approved" // 审核通过，订单从通过时起重新计算支付超时
+	OrderReviewStatusRejected OrderReviewStatus = "rejected" // 审核拒绝，订单已取消
+)
+
+// IsValid 检查审核状态是否有效
+func (s OrderReviewStatus) IsValid() bool {
+	return s == OrderReviewStatusPending || s == OrderReviewStatusApproved || s == OrderReviewStatusRejected
+}
+
+// OrderReview 订单风险审核记录：下单时命中审核规则的订单在审核结束前保持待审核
+type OrderReview struct {
+	ID            uint64               `json:"id" db:"id"`
+	TenantID      uint64               `json:"tenant_id" db:"tenant_id"`
+	OrderID       uint64               `json:"order_id" db:"order_id"`
+	MerchantID    uint64               `json:"merchant_id" db:"merchant_id"`
+	CustomerID    uint64               `json:"customer_id" db:"customer_id"`
+	Status        OrderReviewStatus    `json:"status" db:"status"`
+	MatchedRules  OrderReviewMatchList `json:"matched_rules" db:"matched_rules"`
+	DecisionNotes string               `json:"decision_notes,omitempty" db:"decision_notes"`
+	ReviewerID    *uint64              `json:"reviewer_id,omitempty" db:"reviewer_id"`
+	DecidedAt     *time.Time           `json:"decided_at,omitempty" db:"decided_at"`
+	CreatedAt     time.Time            `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at" db:"updated_at"`
+}
+
+// OrderReviewDecision 审核决定
+type OrderReviewDecision string
+
+const (
+	OrderReviewDecisionApprove OrderReviewDecision = "approve" // 通过，客户可以继续支付
+	OrderReviewDecisionReject  OrderReviewDecision = "reject"  // 拒绝，取消订单
+)
+
+// OrderReviewDecisionRequest 审核决定请求
+type OrderReviewDecisionRequest struct {
+	Decision OrderReviewDecision `json:"decision" v:"required#审核决定不能为空"`
+	Notes    string              `json:"notes" v:"length:0,2000#审核说明长度不能超过2000个字符"` // 拒绝时必填
+}
+
+// Validate 验证审核决定请求，拒绝时必须填写审核说明
+func (r *OrderReviewDecisionRequest) Validate() error {
+	switch r.Decision {
+	case OrderReviewDecisionApprove:
+		return nil
+	case OrderReviewDecisionReject:
+		if strings.TrimSpace(r.Notes) == "" {
+			return errors.New("拒绝订单时必须填写审核说明")
+		}
+		return nil
+	default:
+		return fmt.Errorf("无效的审核决定: %s", r.Decision)
+	}
+}
+
+// OrderReviewListRequest 审核队列查询请求
+type OrderReviewListRequest struct {
+	Status     OrderReviewStatus `json:"status"`      // 为空时返回待审核的记录
+	MerchantID *uint64           `json:"merchant_id"` // 商户用户固定为本商户
+	Limit      int               `json:"limit"`
+}
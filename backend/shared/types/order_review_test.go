@@ -0,0 +1,137 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEvaluateOrderReviewRules(t *testing.T) {
+	order := &Order{MerchantID: 10, TotalAmount: 3000, Items: []OrderItem{{Quantity: 4}, {Quantity: 6}}}
+
+	tests := []struct {
+		name  string
+		rules []OrderReviewRule
+		stats OrderReviewCustomerStats
+		want  []string
+	}{
+		{name: "达到金额阈值", rules: []OrderReviewRule{{Name: "大额", Condition: OrderReviewConditionAmountAtLeast, Threshold: 3000}}, want: []string{"大额"}},
+		{name: "未达到金额阈值", rules: []OrderReviewRule{{Name: "大额", Condition: OrderReviewConditionAmountAtLeast, Threshold: 3000.01}}},
+		{name: "商品数量按订单项合计", rules: []OrderReviewRule{{Name: "多件", Condition: OrderReviewConditionQuantityAtLeast, Threshold: 10}}, want: []string{"多件"}},
+		{name: "阈值为空时首单视为新客户", rules: []OrderReviewRule{{Name: "新客", Condition: OrderReviewConditionNewCustomer}}, want: []string{"新客"}},
+		{name: "有历史订单的客户不是新客户", rules: []OrderReviewRule{{Name: "新客", Condition: OrderReviewConditionNewCustomer}}, stats: OrderReviewCustomerStats{PreviousOrders: 1}},
+		{name: "窗口内下单次数达到阈值", rules: []OrderReviewRule{{Name: "频繁", Condition: OrderReviewConditionOrderVelocity, Threshold: 3}}, stats: OrderReviewCustomerStats{RecentOrders: 3}, want: []string{"频繁"}},
+		{name: "窗口内下单次数未达到阈值", rules: []OrderReviewRule{{Name: "频繁", Condition: OrderReviewConditionOrderVelocity, Threshold: 3}}, stats: OrderReviewCustomerStats{RecentOrders: 2}},
+		{
+			name: "返回全部命中的规则",
+			rules: []OrderReviewRule{
+				{Name: "大额", Condition: OrderReviewConditionAmountAtLeast, Threshold: 1000},
+				{Name: "多件", Condition: OrderReviewConditionQuantityAtLeast, Threshold: 20},
+				{Name: "新客", Condition: OrderReviewConditionNewCustomer, Threshold: 3},
+			},
+			stats: OrderReviewCustomerStats{PreviousOrders: 2},
+			want:  []string{"大额", "新客"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, match := range EvaluateOrderReviewRules(tt.rules, order, tt.stats) {
+				got = append(got, match.Rule)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EvaluateOrderReviewRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderReviewPolicyRulesFor(t *testing.T) {
+	policy := &OrderReviewPolicy{
+		Enabled: true,
+		Rules: []OrderReviewRule{
+			{Name: "全部商户", Condition: OrderReviewConditionAmountAtLeast, Threshold: 1000},
+			{Name: "指定商户", Condition: OrderReviewConditionNewCustomer, MerchantIDs: []uint64{20}},
+		},
+	}
+
+	if got := policy.RulesFor(10); len(got) != 1 || got[0].Name != "全部商户" {
+		t.Errorf("RulesFor(10) = %v", got)
+	}
+	if got := policy.RulesFor(20); len(got) != 2 {
+		t.Errorf("RulesFor(20) = %v", got)
+	}
+
+	policy.Enabled = false
+	if got := policy.RulesFor(20); len(got) != 0 {
+		t.Errorf("未启用时 RulesFor() = %v, want empty", got)
+	}
+	var empty *OrderReviewPolicy
+	if got := empty.RulesFor(20); len(got) != 0 {
+		t.Errorf("未配置时 RulesFor() = %v, want empty", got)
+	}
+}
+
+func TestOrderReviewVelocityWindow(t *testing.T) {
+	rules := []OrderReviewRule{
+		{Name: "大额", Condition: OrderReviewConditionAmountAtLeast, Threshold: 1000, WindowMinutes: 600},
+		{Name: "频繁", Condition: OrderReviewConditionOrderVelocity, Threshold: 3},
+		{Name: "短时频繁", Condition: OrderReviewConditionOrderVelocity, Threshold: 2, WindowMinutes: 10},
+	}
+	if got := VelocityWindow(rules); got != DefaultOrderReviewVelocityWindow*time.Minute {
+		t.Errorf("VelocityWindow() = %v, want %v", got, DefaultOrderReviewVelocityWindow*time.Minute)
+	}
+	if got := VelocityWindow(rules[:1]); got != 0 {
+		t.Errorf("没有频率规则时 VelocityWindow() = %v, want 0", got)
+	}
+	if !NeedsCustomerStats(rules) || NeedsCustomerStats(rules[:1]) {
+		t.Errorf("NeedsCustomerStats() 结果错误")
+	}
+}
+
+func TestOrderReviewPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    OrderReviewRule
+		wantErr bool
+	}{
+		{name: "有效规则", rule: OrderReviewRule{Name: "大额", Condition: OrderReviewConditionAmountAtLeast, Threshold: 1000}},
+		{name: "新客户规则可以不设阈值", rule: OrderReviewRule{Name: "新客", Condition: OrderReviewConditionNewCustomer}},
+		{name: "缺少名称", rule: OrderReviewRule{Condition: OrderReviewConditionAmountAtLeast, Threshold: 1000}, wantErr: true},
+		{name: "无效条件", rule: OrderReviewRule{Name: "未知", Condition: "ip_blacklist", Threshold: 1}, wantErr: true},
+		{name: "缺少阈值", rule: OrderReviewRule{Name: "大额", Condition: OrderReviewConditionAmountAtLeast}, wantErr: true},
+		{name: "负数阈值", rule: OrderReviewRule{Name: "新客", Condition: OrderReviewConditionNewCustomer, Threshold: -1}, wantErr: true},
+		{name: "负数统计窗口", rule: OrderReviewRule{Name: "频繁", Condition: OrderReviewConditionOrderVelocity, Threshold: 3, WindowMinutes: -5}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &OrderReviewPolicy{Enabled: true, Rules: []OrderReviewRule{tt.rule}}
+			if err := policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOrderReviewDecisionRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     OrderReviewDecisionRequest
+		wantErr bool
+	}{
+		{name: "通过无需说明", req: OrderReviewDecisionRequest{Decision: OrderReviewDecisionApprove}},
+		{name: "拒绝须填写说明", req: OrderReviewDecisionRequest{Decision: OrderReviewDecisionReject, Notes: "  "}, wantErr: true},
+		{name: "拒绝并填写说明", req: OrderReviewDecisionRequest{Decision: OrderReviewDecisionReject, Notes: "疑似盗刷"}},
+		{name: "无效的审核决定", req: OrderReviewDecisionRequest{Decision: "hold"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrRightsBalanceInsufficient 商户可用权益余额不足
+	ErrRightsBalanceInsufficient = errors.New("商户可用权益余额不足")
+	// ErrRightsReserveBreached 冻结后可用余额将低于商户保留权益
+	ErrRightsReserveBreached = errors.New("商户可使用权益不足，剩余权益为保留权益")
+)
+
+// OrderRightsReservationStatus 订单权益冻结状态
+type OrderRightsReservationStatus string
+
+const (
+	OrderRightsReservationActive   OrderRightsReservationStatus = "active"   // 已冻结，等待支付
+	OrderRightsReservationReleased OrderRightsReservationStatus = "released" // 订单取消或超时，冻结权益已退回
+	OrderRightsReservationConsumed OrderRightsReservationStatus = "consumed" // 订单已支付，冻结权益转为已使用
+)
+
+// OrderRightsReservation 订单创建时冻结的商户权益，支付后转为已使用，取消后退回可用余额
+type OrderRightsReservation struct {
+	ID         uint64                       `json:"id" db:"id"`
+	TenantID   uint64                       `json:"tenant_id" db:"tenant_id"`
+	MerchantID uint64                       `json:"merchant_id" db:"merchant_id"`
+	OrderID    uint64                       `json:"order_id" db:"order_id"`
+	Amount     float64                      `json:"amount" db:"amount"`
+	Status     OrderRightsReservationStatus `json:"status" db:"status"`
+	CreatedAt  time.Time                    `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time                    `json:"updated_at" db:"updated_at"`
+}
+
+// Freeze 冻结权益，可用余额不足时返回 ErrRightsBalanceInsufficient，
+// 冻结后可用余额低于保留权益时返回 ErrRightsReserveBreached，出错时不修改余额
+func (rb *RightsBalance) Freeze(amount float64) error {
+	if available := rb.GetAvailableBalance(); amount > available {
+		return fmt.Errorf("%w: 需要权益 %.2f，可用权益 %.2f", ErrRightsBalanceInsufficient, amount, available)
+	}
+	if consumable := rb.GetConsumableBalance(); amount > consumable {
+		return fmt.Errorf("%w: 需要权益 %.2f，可使用权益 %.2f，保留权益 %.2f", ErrRightsReserveBreached, amount, consumable, rb.ReserveFloor())
+	}
+	rb.FrozenBalance += amount
+	rb.UpdateAvailableBalance()
+	return nil
+}
+
+// Unfreeze 解冻权益，退回可用余额
+func (rb *RightsBalance) Unfreeze(amount float64) {
+	rb.FrozenBalance -= amount
+	if rb.FrozenBalance < 0 {
+		rb.FrozenBalance = 0
+	}
+	rb.UpdateAvailableBalance()
+}
+
+// ConsumeFrozen 将冻结的权益转为已使用
+func (rb *RightsBalance) ConsumeFrozen(amount float64) {
+	rb.Unfreeze(amount)
+	rb.UsedBalance += amount
+	rb.UpdateAvailableBalance()
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"math"
+	"time"
+)
+
+// OrderRightsRefundPolicy 订单取消退款时是否退还已消耗的商户权益，为空时退还
+type OrderRightsRefundPolicy struct {
+	Disabled bool `json:"disabled,omitempty"` // 不退还权益，订单退款后已消耗的权益仍计为已使用
+}
+
+// Enabled 是否退还权益，未配置策略时退还
+func (p *OrderRightsRefundPolicy) Enabled() bool {
+	return p == nil || !p.Disabled
+}
+
+// OrderRightsRefund 订单退款时退还给商户的权益。一笔订单可以多次部分退款，
+// 累计退还的权益不超过订单支付时实际扣减的权益
+type OrderRightsRefund struct {
+	ID           uint64    `json:"id" db:"id"`
+	TenantID     uint64    `json:"tenant_id" db:"tenant_id"`
+	MerchantID   uint64    `json:"merchant_id" db:"merchant_id"`
+	OrderID      uint64    `json:"order_id" db:"order_id"`
+	FundID       uint64    `json:"fund_id" db:"fund_id"`             // 对应的退款资金记录
+	Amount       float64   `json:"amount" db:"amount"`               // 退还的权益
+	RefundAmount float64   `json:"refund_amount" db:"refund_amount"` // 本次退款的订单金额
+	Reason       string    `json:"reason" db:"reason"`
+	OperatorID   uint64    `json:"operator_id" db:"operator_id"` // 系统操作时为 0
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// RightsRefundAmount 按退款金额占支付金额的比例计算应退还的权益，保留两位小数。
+// 全额退款或支付金额未知时退还全部权益
+func RightsRefundAmount(totalRightsCost, paidAmount, refundAmount float64) float64 {
+	if totalRightsCost <= 0 || refundAmount <= 0 {
+		return 0
+	}
+	if paidAmount <= 0 || refundAmount >= paidAmount {
+		return totalRightsCost
+	}
+	return math.Round(totalRightsCost*refundAmount/paidAmount*100) / 100
+}
+
+// RestoreUsed 将已使用的权益退回可用余额，退还数量不超过已使用余额，返回实际退还的数量
+func (rb *RightsBalance) RestoreUsed(amount float64) float64 {
+	if amount > rb.UsedBalance {
+		amount = rb.UsedBalance
+	}
+	if amount <= 0 {
+		return 0
+	}
+	rb.UsedBalance -= amount
+	rb.UpdateAvailableBalance()
+	return amount
+}
@@ -0,0 +1,104 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRightsBalanceFreeze(t *testing.T) {
+	tests := []struct {
+		name       string
+		amount     float64
+		wantErr    bool
+		wantFrozen float64
+	}{
+		{name: "可用余额充足时冻结", amount: 50, wantFrozen: 70},
+		{name: "恰好用完可用余额", amount: 100, wantFrozen: 120},
+		{name: "超出可用余额时拒绝且不修改余额", amount: 100.5, wantErr: true, wantFrozen: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balance := &RightsBalance{TotalBalance: 200, UsedBalance: 80, FrozenBalance: 20}
+			err := balance.Freeze(tt.amount)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Freeze() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrRightsBalanceInsufficient) {
+				t.Errorf("Freeze() error = %v, want ErrRightsBalanceInsufficient", err)
+			}
+			if balance.FrozenBalance != tt.wantFrozen {
+				t.Errorf("FrozenBalance = %v, want %v", balance.FrozenBalance, tt.wantFrozen)
+			}
+		})
+	}
+}
+
+func TestRightsBalanceUnfreezeAndConsume(t *testing.T) {
+	balance := &RightsBalance{TotalBalance: 200, UsedBalance: 80, FrozenBalance: 50}
+
+	balance.ConsumeFrozen(30)
+	if balance.FrozenBalance != 20 || balance.UsedBalance != 110 || balance.AvailableBalance != 70 {
+		t.Errorf("ConsumeFrozen() = %+v", balance)
+	}
+
+	balance.Unfreeze(20)
+	if balance.FrozenBalance != 0 || balance.AvailableBalance != 90 {
+		t.Errorf("Unfreeze() = %+v", balance)
+	}
+
+	// 冻结余额不会被解冻为负数
+	balance.Unfreeze(10)
+	if balance.FrozenBalance != 0 || balance.AvailableBalance != 90 {
+		t.Errorf("Unfreeze() beyond frozen = %+v", balance)
+	}
+}
+
+func TestRightsBalanceFreezeWithReserve(t *testing.T) {
+	amount := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name           string
+		reserveAmount  *float64
+		reservePercent *float64
+		amount         float64
+		wantErr        error
+		wantConsumable float64
+	}{
+		// 可用余额 100，保留 30，可使用 70
+		{name: "低于保留权益下限时冻结", reserveAmount: amount(30), amount: 50, wantConsumable: 70},
+		{name: "冻结后恰好剩余保留权益", reserveAmount: amount(30), amount: 70, wantConsumable: 70},
+		{name: "占用保留权益时拒绝", reserveAmount: amount(30), amount: 70.5, wantErr: ErrRightsReserveBreached, wantConsumable: 70},
+		{name: "超出可用余额时仍返回余额不足", reserveAmount: amount(30), amount: 120, wantErr: ErrRightsBalanceInsufficient, wantConsumable: 70},
+		// 按总余额 200 的 10% 保留 20，与固定保留 15 取较大者
+		{name: "按比例保留取较大者", reserveAmount: amount(15), reservePercent: amount(10), amount: 81, wantErr: ErrRightsReserveBreached, wantConsumable: 80},
+		{name: "保留权益超过可用余额时不可使用", reserveAmount: amount(150), amount: 1, wantErr: ErrRightsReserveBreached, wantConsumable: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balance := &RightsBalance{TotalBalance: 200, UsedBalance: 80, FrozenBalance: 20,
+				ReserveAmount: tt.reserveAmount, ReservePercent: tt.reservePercent}
+			if consumable := balance.GetConsumableBalance(); consumable != tt.wantConsumable {
+				t.Errorf("GetConsumableBalance() = %v, want %v", consumable, tt.wantConsumable)
+			}
+
+			err := balance.Freeze(tt.amount)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Freeze() error = %v", err)
+				}
+				if balance.FrozenBalance != 20+tt.amount {
+					t.Errorf("FrozenBalance = %v, want %v", balance.FrozenBalance, 20+tt.amount)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Freeze() error = %v, want %v", err, tt.wantErr)
+			}
+			if balance.FrozenBalance != 20 {
+				t.Errorf("FrozenBalance = %v, want 20", balance.FrozenBalance)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultOrderSLAListLimit SLA超时订单列表默认返回条数
+const DefaultOrderSLAListLimit = 100
+
+// MaxOrderSLAListLimit SLA超时订单列表最大返回条数
+const MaxOrderSLAListLimit = 500
+
+// OrderStatusSLA 单个订单状态的处理时限
+type OrderStatusSLA struct {
+	Status  OrderStatusInt `json:"status"`
+	Minutes int            `json:"minutes"` // 订单在该状态停留超过该分钟数视为超时
+}
+
+// OrderSLAConfig 订单状态处理时限配置，商户级配置优先于租户默认配置。
+// 与超时配置不同，超过时限只提醒人工处理，不会自动取消订单
+type OrderSLAConfig struct {
+	ID         uint64           `json:"id" db:"id"`
+	TenantID   uint64           `json:"tenant_id" db:"tenant_id"`
+	MerchantID *uint64          `json:"merchant_id,omitempty" db:"merchant_id"`
+	Statuses   []OrderStatusSLA `json:"statuses" db:"statuses"`
+	CreatedAt  time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// DefaultOrderSLAConfig 默认处理时限：已支付订单24小时内开始处理，处理中订单48小时内完成。
+// 待支付订单由超时配置自动取消，默认不跟踪
+func DefaultOrderSLAConfig(tenantID uint64) *OrderSLAConfig {
+	return &OrderSLAConfig{
+		TenantID: tenantID,
+		Statuses: []OrderStatusSLA{
+			{Status: OrderStatusIntPaid, Minutes: 24 * 60},
+			{Status: OrderStatusIntProcessing, Minutes: 48 * 60},
+		},
+	}
+}
+
+// Limit 获取指定状态的处理时限，未配置时返回false
+func (c *OrderSLAConfig) Limit(status OrderStatusInt) (time.Duration, bool) {
+	for _, sla := range c.Statuses {
+		if sla.Status == status {
+			return time.Duration(sla.Minutes) * time.Minute, true
+		}
+	}
+	return 0, false
+}
+
+// Limits 获取全部状态的处理时限
+func (c *OrderSLAConfig) Limits() map[OrderStatusInt]time.Duration {
+	limits := make(map[OrderStatusInt]time.Duration, len(c.Statuses))
+	for _, sla := range c.Statuses {
+		limits[sla.Status] = time.Duration(sla.Minutes) * time.Minute
+	}
+	return limits
+}
+
+// Validate 验证处理时限配置，只能为未结束的订单状态配置时限
+func (c *OrderSLAConfig) Validate() error {
+	seen := make(map[OrderStatusInt]bool, len(c.Statuses))
+	for _, sla := range c.Statuses {
+		if sla.Status != OrderStatusIntPending && sla.Status != OrderStatusIntPaid && sla.Status != OrderStatusIntProcessing {
+			return errors.New("只能为待支付、已支付、处理中状态配置处理时限")
+		}
+		if seen[sla.Status] {
+			return fmt.Errorf("订单状态 %s 的处理时限重复配置", sla.Status)
+		}
+		seen[sla.Status] = true
+		if sla.Minutes <= 0 {
+			return errors.New("处理时限必须大于0分钟")
+		}
+	}
+	return nil
+}
+
+// OrderStatusEntry 订单当前状态及进入该状态的时间
+type OrderStatusEntry struct {
+	TenantID    uint64         `json:"tenant_id" db:"tenant_id"`
+	OrderID     uint64         `json:"order_id" db:"order_id"`
+	OrderNumber string         `json:"order_number" db:"order_number"`
+	MerchantID  uint64         `json:"merchant_id" db:"merchant_id"`
+	Status      OrderStatusInt `json:"status" db:"status"`
+	EnteredAt   time.Time      `json:"entered_at" db:"entered_at"`
+}
+
+// OrderSLABreach 超过当前状态处理时限的订单
+type OrderSLABreach struct {
+	TenantID            uint64         `json:"tenant_id"`
+	OrderID             uint64         `json:"order_id"`
+	OrderNumber         string         `json:"order_number"`
+	MerchantID          uint64         `json:"merchant_id"`
+	Status              OrderStatusInt `json:"status"`
+	EnteredAt           time.Time      `json:"entered_at"`
+	TimeInStatusSeconds int64          `json:"time_in_status_seconds"`
+	SLASeconds          int64          `json:"sla_seconds"`
+	OverdueSeconds      int64          `json:"overdue_seconds"`
+}
+
+// EvaluateSLA 按处理时限检查订单当前状态，超时时返回超时信息，未超时或未配置时限时返回nil
+func (e *OrderStatusEntry) EvaluateSLA(config *OrderSLAConfig, now time.Time) *OrderSLABreach {
+	limit, ok := config.Limit(e.Status)
+	if !ok {
+		return nil
+	}
+	elapsed := now.Sub(e.EnteredAt)
+	if elapsed <= limit {
+		return nil
+	}
+	return &OrderSLABreach{
+		TenantID:            e.TenantID,
+		OrderID:             e.OrderID,
+		OrderNumber:         e.OrderNumber,
+		MerchantID:          e.MerchantID,
+		Status:              e.Status,
+		EnteredAt:           e.EnteredAt,
+		TimeInStatusSeconds: int64(elapsed / time.Second),
+		SLASeconds:          int64(limit / time.Second),
+		OverdueSeconds:      int64((elapsed - limit) / time.Second),
+	}
+}
+
+// OrderStatusDuration 订单在某一状态累计停留的时间
+type OrderStatusDuration struct {
+	Status     OrderStatusInt `json:"status"`
+	Seconds    int64          `json:"seconds"`
+	SLASeconds int64          `json:"sla_seconds,omitempty"` // 未配置处理时限时为0
+	Breached   bool           `json:"breached"`
+}
+
+// OrderSLAStatus 订单各状态停留时间及当前状态的超时情况
+type OrderSLAStatus struct {
+	OrderID       uint64                `json:"order_id"`
+	CurrentStatus OrderStatusInt        `json:"current_status"`
+	EnteredAt     time.Time             `json:"entered_at"`
+	Durations     []OrderStatusDuration `json:"durations"`
+	Breach        *OrderSLABreach       `json:"breach,omitempty"`
+}
+
+// ComputeOrderStatusDurations 根据状态历史计算订单在各状态累计停留的时间。
+// 订单从创建时间起处于第一条历史的起始状态，没有历史时一直处于当前状态；
+// 返回按状态排序的停留时间及进入最新状态的时间
+func ComputeOrderStatusDurations(createdAt time.Time, current OrderStatusInt, history []OrderStatusHistory, now time.Time) ([]OrderStatusDuration, time.Time) {
+	sorted := make([]OrderStatusHistory, len(history))
+	copy(sorted, history)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	status := current
+	if len(sorted) > 0 {
+		status = sorted[0].FromStatus
+	}
+	since := createdAt
+
+	totals := make(map[OrderStatusInt]time.Duration)
+	for _, h := range sorted {
+		if h.CreatedAt.After(since) {
+			totals[status] += h.CreatedAt.Sub(since)
+		}
+		status, since = h.ToStatus, h.CreatedAt
+	}
+	elapsed := now.Sub(since)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	totals[status] += elapsed
+
+	durations := make([]OrderStatusDuration, 0, len(totals))
+	for s, d := range totals {
+		durations = append(durations, OrderStatusDuration{Status: s, Seconds: int64(d / time.Second)})
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i].Status < durations[j].Status })
+	return durations, since
+}
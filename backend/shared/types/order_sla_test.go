@@ -0,0 +1,119 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOrderSLAConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []OrderStatusSLA
+		wantErr  bool
+	}{
+		{name: "默认配置", statuses: DefaultOrderSLAConfig(1).Statuses},
+		{name: "空配置", statuses: nil},
+		{name: "已完成状态", statuses: []OrderStatusSLA{{Status: OrderStatusIntCompleted, Minutes: 60}}, wantErr: true},
+		{name: "时限为0", statuses: []OrderStatusSLA{{Status: OrderStatusIntPaid, Minutes: 0}}, wantErr: true},
+		{name: "重复状态", statuses: []OrderStatusSLA{{Status: OrderStatusIntPaid, Minutes: 60}, {Status: OrderStatusIntPaid, Minutes: 30}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &OrderSLAConfig{Statuses: tt.statuses}
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOrderStatusEntryEvaluateSLA(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	config := DefaultOrderSLAConfig(1)
+
+	tests := []struct {
+		name        string
+		status      OrderStatusInt
+		enteredAt   time.Time
+		wantOverdue int64
+		wantBreach  bool
+	}{
+		{name: "已支付超过24小时", status: OrderStatusIntPaid, enteredAt: now.Add(-25 * time.Hour), wantBreach: true, wantOverdue: 3600},
+		{name: "已支付未超时", status: OrderStatusIntPaid, enteredAt: now.Add(-23 * time.Hour)},
+		{name: "恰好到达时限不算超时", status: OrderStatusIntPaid, enteredAt: now.Add(-24 * time.Hour)},
+		{name: "未配置时限的状态", status: OrderStatusIntPending, enteredAt: now.Add(-100 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &OrderStatusEntry{OrderID: 1, Status: tt.status, EnteredAt: tt.enteredAt}
+			breach := entry.EvaluateSLA(config, now)
+			if (breach != nil) != tt.wantBreach {
+				t.Fatalf("EvaluateSLA() = %v, wantBreach %v", breach, tt.wantBreach)
+			}
+			if breach != nil && breach.OverdueSeconds != tt.wantOverdue {
+				t.Errorf("OverdueSeconds = %d, want %d", breach.OverdueSeconds, tt.wantOverdue)
+			}
+		})
+	}
+}
+
+func TestComputeOrderStatusDurations(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	hoursAgo := func(hours int) time.Time { return now.Add(-time.Duration(hours) * time.Hour) }
+
+	tests := []struct {
+		name          string
+		current       OrderStatusInt
+		history       []OrderStatusHistory
+		want          []OrderStatusDuration
+		wantEnteredAt time.Time
+	}{
+		{
+			name:          "没有状态历史",
+			current:       OrderStatusIntPending,
+			want:          []OrderStatusDuration{{Status: OrderStatusIntPending, Seconds: 10 * 3600}},
+			wantEnteredAt: hoursAgo(10),
+		},
+		{
+			name:    "多次流转",
+			current: OrderStatusIntProcessing,
+			history: []OrderStatusHistory{
+				{FromStatus: OrderStatusIntPending, ToStatus: OrderStatusIntPaid, CreatedAt: hoursAgo(9)},
+				{FromStatus: OrderStatusIntPaid, ToStatus: OrderStatusIntProcessing, CreatedAt: hoursAgo(4)},
+			},
+			want: []OrderStatusDuration{
+				{Status: OrderStatusIntPending, Seconds: 3600},
+				{Status: OrderStatusIntPaid, Seconds: 5 * 3600},
+				{Status: OrderStatusIntProcessing, Seconds: 4 * 3600},
+			},
+			wantEnteredAt: hoursAgo(4),
+		},
+		{
+			name:    "已结束的订单",
+			current: OrderStatusIntCancelled,
+			history: []OrderStatusHistory{
+				{FromStatus: OrderStatusIntPending, ToStatus: OrderStatusIntCancelled, CreatedAt: hoursAgo(2)},
+			},
+			want: []OrderStatusDuration{
+				{Status: OrderStatusIntPending, Seconds: 8 * 3600},
+				{Status: OrderStatusIntCancelled, Seconds: 2 * 3600},
+			},
+			wantEnteredAt: hoursAgo(2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, enteredAt := ComputeOrderStatusDurations(hoursAgo(10), tt.current, tt.history, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ComputeOrderStatusDurations() = %v, want %v", got, tt.want)
+			}
+			if !enteredAt.Equal(tt.wantEnteredAt) {
+				t.Errorf("enteredAt = %v, want %v", enteredAt, tt.wantEnteredAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OrderExportFormat 订单数据导出文件格式
+type OrderExportFormat string
+
+const (
+	OrderExportFormatCSV   OrderExportFormat = "csv"
+	OrderExportFormatExcel OrderExportFormat = "excel"
+)
+
+// IsValid 验证导出格式是否有效
+func (f OrderExportFormat) IsValid() bool {
+	return f == OrderExportFormatCSV || f == OrderExportFormatExcel
+}
+
+// Extension 导出文件扩展名
+func (f OrderExportFormat) Extension() string {
+	if f == OrderExportFormatExcel {
+		return "xlsx"
+	}
+	return "csv"
+}
+
+// OrderExportField 订单导出字段
+type OrderExportField string
+
+const (
+	OrderExportFieldOrderNumber  OrderExportField = "order_number"
+	OrderExportFieldOrderID      OrderExportField = "order_id"
+	OrderExportFieldMerchantID   OrderExportField = "merchant_id"
+	OrderExportFieldCustomerID   OrderExportField = "customer_id"
+	OrderExportFieldTotalAmount  OrderExportField = "total_amount"
+	OrderExportFieldFromStatus   OrderExportField = "from_status"
+	OrderExportFieldToStatus     OrderExportField = "to_status"
+	OrderExportFieldOperatorType OrderExportField = "operator_type"
+	OrderExportFieldOperatorID   OrderExportField = "operator_id"
+	OrderExportFieldReason       OrderExportField = "reason"
+	OrderExportFieldCreatedAt    OrderExportField = "created_at"
+)
+
+// orderExportFieldLabels 导出字段表头
+var orderExportFieldLabels = map[OrderExportField]string{
+	OrderExportFieldOrderNumber:  "订单号",
+	OrderExportFieldOrderID:      "订单ID",
+	OrderExportFieldMerchantID:   "商户ID",
+	OrderExportFieldCustomerID:   "客户ID",
+	OrderExportFieldTotalAmount:  "订单金额",
+	OrderExportFieldFromStatus:   "原状态",
+	OrderExportFieldToStatus:     "新状态",
+	OrderExportFieldOperatorType: "操作人类型",
+	OrderExportFieldOperatorID:   "操作人ID",
+	OrderExportFieldReason:       "变更原因",
+	OrderExportFieldCreatedAt:    "变更时间",
+}
+
+// ExportableOrderFields 可导出字段白名单
+var ExportableOrderFields = []OrderExportField{
+	OrderExportFieldOrderNumber,
+	OrderExportFieldOrderID,
+	OrderExportFieldMerchantID,
+	OrderExportFieldCustomerID,
+	OrderExportFieldTotalAmount,
+	OrderExportFieldFromStatus,
+	OrderExportFieldToStatus,
+	OrderExportFieldOperatorType,
+	OrderExportFieldOperatorID,
+	OrderExportFieldReason,
+	OrderExportFieldCreatedAt,
+}
+
+// DefaultOrderExportFields 未指定导出字段时的标准列及顺序
+var DefaultOrderExportFields = []OrderExportField{
+	OrderExportFieldOrderNumber,
+	OrderExportFieldOrderID,
+	OrderExportFieldMerchantID,
+	OrderExportFieldFromStatus,
+	OrderExportFieldToStatus,
+	OrderExportFieldOperatorType,
+	OrderExportFieldOperatorID,
+	OrderExportFieldReason,
+	OrderExportFieldCreatedAt,
+}
+
+// IsValid 验证是否为可导出字段
+func (f OrderExportField) IsValid() bool {
+	_, ok := orderExportFieldLabels[f]
+	return ok
+}
+
+// Label 导出字段的表头
+func (f OrderExportField) Label() string {
+	return orderExportFieldLabels[f]
+}
+
+// ParseOrderExportFields 解析逗号分隔的导出字段，保留指定的顺序，空字符串返回nil
+func ParseOrderExportFields(value string) []OrderExportField {
+	var fields []OrderExportField
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			fields = append(fields, OrderExportField(name))
+		}
+	}
+	return fields
+}
+
+// validateOrderExportFields 校验导出字段均在白名单中且不重复
+func validateOrderExportFields(fields []OrderExportField) error {
+	seen := make(map[OrderExportField]bool, len(fields))
+	for _, field := range fields {
+		if !field.IsValid() {
+			allowed := make([]string, 0, len(ExportableOrderFields))
+			for _, candidate := range ExportableOrderFields {
+				allowed = append(allowed, string(candidate))
+			}
+			return fmt.Errorf("不支持的导出字段: %s，支持: %s", field, strings.Join(allowed, ", "))
+		}
+		if seen[field] {
+			return fmt.Errorf("导出字段重复: %s", field)
+		}
+		seen[field] = true
+	}
+	return nil
+}
+
+// OrderStatusHistoryExportQuery 订单状态历史导出条件，时间范围为 [StartDate, EndDate)
+type OrderStatusHistoryExportQuery struct {
+	StartDate  time.Time          `json:"start_date"`
+	EndDate    time.Time          `json:"end_date"`
+	MerchantID *uint64            `json:"merchant_id,omitempty"`
+	Status     *OrderStatusInt    `json:"status,omitempty"` // 按变更后的状态过滤
+	Format     OrderExportFormat  `json:"format"`
+	Fields     []OrderExportField `json:"fields,omitempty"` // 导出列及顺序，为空时使用标准列
+}
+
+// Validate 校验导出条件
+func (q *OrderStatusHistoryExportQuery) Validate() error {
+	if q.StartDate.IsZero() || q.EndDate.IsZero() {
+		return errors.New("导出时间范围不能为空")
+	}
+	if !q.EndDate.After(q.StartDate) {
+		return errors.New("结束时间必须晚于开始时间")
+	}
+	if q.Status != nil && (*q.Status < OrderStatusIntPending || *q.Status > OrderStatusIntCancelled) {
+		return errors.New("无效的订单状态")
+	}
+	if !q.Format.IsValid() {
+		return errors.New("不支持的导出格式，支持: csv, excel")
+	}
+	return validateOrderExportFields(q.Fields)
+}
+
+// OrderStatusHistoryExportRow 订单状态历史导出行
+type OrderStatusHistoryExportRow struct {
+	ID           uint64                  `json:"id" db:"id"`
+	OrderID      uint64                  `json:"order_id" db:"order_id"`
+	OrderNumber  string                  `json:"order_number" db:"order_number"`
+	MerchantID   uint64                  `json:"merchant_id" db:"merchant_id"`
+	CustomerID   uint64                  `json:"customer_id" db:"customer_id"`
+	TotalAmount  float64                 `json:"total_amount" db:"total_amount"`
+	FromStatus   OrderStatusInt          `json:"from_status" db:"from_status"`
+	ToStatus     OrderStatusInt          `json:"to_status" db:"to_status"`
+	OperatorType OrderStatusOperatorType `json:"operator_type" db:"operator_type"`
+	OperatorID   *uint64                 `json:"operator_id,omitempty" db:"operator_id"`
+	Reason       string                  `json:"reason" db:"reason"`
+	CreatedAt    time.Time               `json:"created_at" db:"created_at"`
+}
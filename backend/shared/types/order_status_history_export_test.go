@@ -0,0 +1,50 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseOrderExportFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []OrderExportField
+	}{
+		{name: "保留指定顺序", value: "total_amount,order_number", want: []OrderExportField{OrderExportFieldTotalAmount, OrderExportFieldOrderNumber}},
+		{name: "去除空白和空项", value: " created_at ,, reason", want: []OrderExportField{OrderExportFieldCreatedAt, OrderExportFieldReason}},
+		{name: "空字符串", value: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseOrderExportFields(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseOrderExportFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderStatusHistoryExportQueryValidateFields(t *testing.T) {
+	start := time.Date(2025, 9, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name    string
+		fields  []OrderExportField
+		wantErr bool
+	}{
+		{name: "未指定字段", fields: nil},
+		{name: "白名单字段", fields: []OrderExportField{OrderExportFieldCustomerID, OrderExportFieldTotalAmount}},
+		{name: "未知字段", fields: []OrderExportField{"password"}, wantErr: true},
+		{name: "重复字段", fields: []OrderExportField{OrderExportFieldReason, OrderExportFieldReason}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := &OrderStatusHistoryExportQuery{StartDate: start, EndDate: start.AddDate(0, 0, 1), Format: OrderExportFormatCSV, Fields: tt.fields}
+			if err := query.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxOrderStatusLabelLength 订单状态显示名称的最大长度
+	MaxOrderStatusLabelLength = 20
+	// MaxOrderStatusMessageLength 订单状态客户说明的最大长度
+	MaxOrderStatusMessageLength = 200
+)
+
+// OrderStatusLabel 面向客户的订单状态显示名称和说明
+type OrderStatusLabel struct {
+	Label   string `json:"label"`             // 显示名称，如「已发货」
+	Message string `json:"message,omitempty"` // 客户说明，如「包裹已在路上，请留意物流信息」
+}
+
+// OrderStatusLabelPolicy represents per-tenant customer-facing labels for order statuses.
+// Labels apply to every locale; Locales overrides them for a specific locale such as en-US.
+// Statuses without a configured label fall back to the built-in Chinese names, and the
+// canonical status value itself is never changed.
+type OrderStatusLabelPolicy struct {
+	Labels  map[OrderStatus]OrderStatusLabel            `json:"labels,omitempty"`
+	Locales map[string]map[OrderStatus]OrderStatusLabel `json:"locales,omitempty"` // 区域 -> 该区域的状态显示
+}
+
+// Validate 校验订单状态显示配置，只能配置已有的订单状态
+func (p *OrderStatusLabelPolicy) Validate() error {
+	if err := validateOrderStatusLabels("", p.Labels); err != nil {
+		return err
+	}
+	for locale, labels := range p.Locales {
+		if strings.TrimSpace(locale) == "" {
+			return fmt.Errorf("订单状态显示缺少区域设置")
+		}
+		if err := validateOrderStatusLabels(locale, labels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateOrderStatusLabels 校验一组订单状态显示
+func validateOrderStatusLabels(locale string, labels map[OrderStatus]OrderStatusLabel) error {
+	prefix := ""
+	if locale != "" {
+		prefix = locale + " "
+	}
+	for status, label := range labels {
+		if !status.IsValid() {
+			return fmt.Errorf("%s订单状态 %s 无效", prefix, status)
+		}
+		if utf8.RuneCountInString(strings.TrimSpace(label.Label)) > MaxOrderStatusLabelLength {
+			return fmt.Errorf("%s订单状态 %s 的显示名称不能超过%d个字符", prefix, status, MaxOrderStatusLabelLength)
+		}
+		if utf8.RuneCountInString(strings.TrimSpace(label.Message)) > MaxOrderStatusMessageLength {
+			return fmt.Errorf("%s订单状态 %s 的客户说明不能超过%d个字符", prefix, status, MaxOrderStatusMessageLength)
+		}
+	}
+	return nil
+}
+
+// Lookup 查找订单状态在区域下的自定义显示，区域未单独配置时使用通用配置。
+// 显示名称为空的配置项只提供客户说明
+func (p *OrderStatusLabelPolicy) Lookup(status OrderStatus, locale string) (OrderStatusLabel, bool) {
+	if p == nil {
+		return OrderStatusLabel{}, false
+	}
+	if label, ok := p.Locales[locale][status]; ok {
+		return label, true
+	}
+	label, ok := p.Labels[status]
+	return label, ok
+}
+
+// IsValid 检查订单状态是否为已有的状态
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusProcessing,
+		OrderStatusCompleted, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxOrderTagLength 单个订单标签的最大字符数
+	MaxOrderTagLength = 32
+	// MaxOrderTagsPerOrder 单个订单最多可添加的标签数
+	MaxOrderTagsPerOrder = 20
+)
+
+// OrderTag 订单标签（订单与标签的多对多关联，同一订单的标签不重复）
+type OrderTag struct {
+	ID        uint64    `json:"id" db:"id"`
+	TenantID  uint64    `json:"tenant_id" db:"tenant_id"`
+	OrderID   uint64    `json:"order_id" db:"order_id"`
+	Tag       string    `json:"tag" db:"tag"`
+	CreatedBy uint64    `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// AddOrderTagsRequest 添加订单标签请求
+type AddOrderTagsRequest struct {
+	Tags []string `json:"tags" v:"required#标签不能为空"`
+}
+
+// NormalizeOrderTag 去除标签首尾空白并校验长度
+func NormalizeOrderTag(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", fmt.Errorf("标签不能为空")
+	}
+	if utf8.RuneCountInString(tag) > MaxOrderTagLength {
+		return "", fmt.Errorf("标签长度不能超过%d个字符: %s", MaxOrderTagLength, tag)
+	}
+	return tag, nil
+}
+
+// NormalizeOrderTags 规范化标签列表并去重，保持原有顺序
+func NormalizeOrderTags(tags []string) ([]string, error) {
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		normalized, err := NormalizeOrderTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := seen[normalized]; exists {
+			continue
+		}
+		seen[normalized] = struct{}{}
+		result = append(result, normalized)
+	}
+	return result, nil
+}
+
+// OrderViewFilters 保存视图中的订单筛选条件，与 OrderQueryRequest 的筛选字段对应
+type OrderViewFilters struct {
+	MerchantID    *uint64          `json:"merchant_id,omitempty"`
+	CustomerID    *uint64          `json:"customer_id,omitempty"`
+	Status        []OrderStatusInt `json:"status,omitempty"`
+	StartDate     *time.Time       `json:"start_date,omitempty"`
+	EndDate       *time.Time       `json:"end_date,omitempty"`
+	SearchKeyword *string          `json:"search_keyword,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	SortBy        string           `json:"sort_by,omitempty"`
+	SortOrder     string           `json:"sort_order,omitempty"`
+}
+
+// ToQueryRequest 将筛选条件转换为订单查询请求，未保存排序时按创建时间倒序
+func (f *OrderViewFilters) ToQueryRequest(page, pageSize int) *OrderQueryRequest {
+	req := &OrderQueryRequest{
+		MerchantID:    f.MerchantID,
+		CustomerID:    f.CustomerID,
+		Status:        f.Status,
+		StartDate:     f.StartDate,
+		EndDate:       f.EndDate,
+		SearchKeyword: f.SearchKeyword,
+		Tags:          f.Tags,
+		Page:          page,
+		PageSize:      pageSize,
+		SortBy:        f.SortBy,
+		SortOrder:     f.SortOrder,
+	}
+	if req.SortBy == "" {
+		req.SortBy = "created_at"
+	}
+	if req.SortOrder == "" {
+		req.SortOrder = "desc"
+	}
+	return req
+}
+
+// Value 实现 driver.Valuer 接口
+func (f OrderViewFilters) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (f *OrderViewFilters) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	}
+	return nil
+}
+
+// OrderSavedView 用户保存的订单筛选视图，按租户和用户隔离
+type OrderSavedView struct {
+	ID        uint64           `json:"id" db:"id"`
+	TenantID  uint64           `json:"tenant_id" db:"tenant_id"`
+	UserID    uint64           `json:"user_id" db:"user_id"`
+	Name      string           `json:"name" db:"name"`
+	Filters   OrderViewFilters `json:"filters" db:"filters"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// SaveOrderViewRequest 保存订单筛选视图请求
+type SaveOrderViewRequest struct {
+	Name    string           `json:"name" v:"required|length:1,50#视图名称不能为空|视图名称长度为1-50个字符"`
+	Filters OrderViewFilters `json:"filters"`
+}
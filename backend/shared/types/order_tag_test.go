@@ -0,0 +1,65 @@
+package types
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeOrderTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "去除空白并去重", tags: []string{" VIP", "dispute", "VIP "}, want: []string{"VIP", "dispute"}},
+		{name: "空列表", tags: nil, want: []string{}},
+		{name: "空白标签", tags: []string{"VIP", " "}, wantErr: true},
+		{name: "超长标签", tags: []string{strings.Repeat("标", MaxOrderTagLength+1)}, wantErr: true},
+		{name: "最大长度", tags: []string{strings.Repeat("标", MaxOrderTagLength)}, want: []string{strings.Repeat("标", MaxOrderTagLength)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeOrderTags(tt.tags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeOrderTags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeOrderTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderViewFiltersToQueryRequest(t *testing.T) {
+	merchantID := uint64(10)
+	filters := &OrderViewFilters{
+		MerchantID: &merchantID,
+		Status:     []OrderStatusInt{OrderStatusIntPaid},
+		Tags:       []string{"VIP"},
+	}
+
+	req := filters.ToQueryRequest(2, 50)
+	if req.Page != 2 || req.PageSize != 50 || req.SortBy != "created_at" || req.SortOrder != "desc" {
+		t.Errorf("paging/sort = %+v", req)
+	}
+	if *req.MerchantID != 10 || !reflect.DeepEqual(req.Tags, []string{"VIP"}) || !reflect.DeepEqual(req.Status, filters.Status) {
+		t.Errorf("filters not applied: %+v", req)
+	}
+
+	filters.SortBy, filters.SortOrder = "total_amount", "asc"
+	if req := filters.ToQueryRequest(1, 10); req.SortBy != "total_amount" || req.SortOrder != "asc" {
+		t.Errorf("saved sort not applied: %+v", req)
+	}
+
+	var scanned OrderViewFilters
+	value, err := filters.Value()
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	if err := scanned.Scan(value); err != nil || !reflect.DeepEqual(scanned.Tags, filters.Tags) || *scanned.MerchantID != 10 {
+		t.Errorf("Scan() = %+v, %v", scanned, err)
+	}
+}
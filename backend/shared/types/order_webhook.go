@@ -0,0 +1,187 @@
+package types
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// OrderWebhookEvent 订单 Webhook 事件类型
+type OrderWebhookEvent string
+
+const (
+	OrderWebhookEventCreated   OrderWebhookEvent = "order.created"
+	OrderWebhookEventPaid      OrderWebhookEvent = "order.paid"
+	OrderWebhookEventCompleted OrderWebhookEvent = "order.completed"
+	OrderWebhookEventCancelled OrderWebhookEvent = "order.cancelled"
+	OrderWebhookEventRefunded  OrderWebhookEvent = "order.refunded"
+	// OrderWebhookEventTenantConfigChanged 租户配置变更，可通过 ConfigSections 只订阅部分配置分区
+	OrderWebhookEventTenantConfigChanged OrderWebhookEvent = "tenant.config_changed"
+	// OrderWebhookEventAlertEscalated 监控预警持续未解决而升级
+	OrderWebhookEventAlertEscalated OrderWebhookEvent = "monitoring.alert_escalated"
+	// OrderWebhookEventTest 测试推送，不需要订阅
+	OrderWebhookEventTest OrderWebhookEvent = "webhook.test"
+)
+
+// OrderWebhookEvents 可订阅的事件
+var OrderWebhookEvents = []OrderWebhookEvent{
+	OrderWebhookEventCreated,
+	OrderWebhookEventPaid,
+	OrderWebhookEventCompleted,
+	OrderWebhookEventCancelled,
+	OrderWebhookEventRefunded,
+	OrderWebhookEventTenantConfigChanged,
+	OrderWebhookEventAlertEscalated,
+}
+
+// IsValid 是否为可订阅的事件
+func (e OrderWebhookEvent) IsValid() bool {
+	for _, event := range OrderWebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderWebhookEventForStatus 订单状态变更对应的 Webhook 事件，没有对应事件时返回空
+func OrderWebhookEventForStatus(status OrderStatusInt) OrderWebhookEvent {
+	switch status {
+	case OrderStatusIntPaid:
+		return OrderWebhookEventPaid
+	case OrderStatusIntCompleted:
+		return OrderWebhookEventCompleted
+	case OrderStatusIntCancelled:
+		return OrderWebhookEventCancelled
+	default:
+		return ""
+	}
+}
+
+// OrderWebhook 租户配置的订单事件推送地址
+type OrderWebhook struct {
+	ID             uint64      `json:"id" db:"id"`
+	TenantID       uint64      `json:"tenant_id" db:"tenant_id"`
+	URL            string      `json:"url" db:"url"`
+	Secret         string      `json:"-" db:"secret"`
+	Events         StringArray `json:"events" db:"events"`
+	ConfigSections StringArray `json:"config_sections" db:"config_sections"` // 订阅的租户配置分区，为空时订阅全部分区
+	Enabled        bool        `json:"enabled" db:"enabled"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// Subscribes 是否订阅了该事件
+func (w *OrderWebhook) Subscribes(event OrderWebhookEvent) bool {
+	for _, subscribed := range w.Events {
+		if OrderWebhookEvent(subscribed) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderWebhookRequest 创建或更新订单 Webhook 请求，更新时 Secret 为空表示保留原密钥
+type OrderWebhookRequest struct {
+	URL            string              `json:"url" v:"required#推送地址不能为空"`
+	Secret         string              `json:"secret"`
+	Events         []OrderWebhookEvent `json:"events" v:"required#订阅事件不能为空"`
+	ConfigSections []string            `json:"config_sections"` // 订阅 tenant.config_changed 时只推送这些配置分区的变更
+	Enabled        *bool               `json:"enabled"`
+}
+
+// Validate 校验推送地址和订阅事件
+func (r *OrderWebhookRequest) Validate() error {
+	parsed, err := url.Parse(r.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("推送地址必须是有效的 http 或 https 地址")
+	}
+	if len(r.Events) == 0 {
+		return fmt.Errorf("订阅事件不能为空")
+	}
+	for _, event := range r.Events {
+		if !event.IsValid() {
+			return fmt.Errorf("无效的订阅事件: %s", event)
+		}
+	}
+	for _, section := range r.ConfigSections {
+		if !IsTenantConfigSection(section) {
+			return fmt.Errorf("无效的配置分区: %s", section)
+		}
+	}
+	if r.Secret != "" && len(r.Secret) < 16 {
+		return fmt.Errorf("签名密钥至少16个字符")
+	}
+	return nil
+}
+
+// OrderWebhookCreated 创建 Webhook 的响应，签名密钥只在创建时返回一次
+type OrderWebhookCreated struct {
+	OrderWebhook
+	Secret string `json:"secret"`
+}
+
+// OrderWebhookOrderSummary 推送载荷中的订单摘要
+type OrderWebhookOrderSummary struct {
+	ID              uint64       `json:"id"`
+	OrderNumber     string       `json:"order_number"`
+	MerchantID      uint64       `json:"merchant_id"`
+	CustomerID      uint64       `json:"customer_id"`
+	Status          OrderStatus  `json:"status"`
+	Items           []OrderItem  `json:"items"`
+	TotalAmount     float64      `json:"total_amount"`
+	TotalRightsCost float64      `json:"total_rights_cost"`
+	PaymentInfo     *PaymentInfo `json:"payment_info,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+// NewOrderWebhookOrderSummary 从订单生成推送摘要
+func NewOrderWebhookOrderSummary(order *Order) *OrderWebhookOrderSummary {
+	return &OrderWebhookOrderSummary{
+		ID:              order.ID,
+		OrderNumber:     order.OrderNumber,
+		MerchantID:      order.MerchantID,
+		CustomerID:      order.CustomerID,
+		Status:          order.Status,
+		Items:           order.Items,
+		TotalAmount:     order.TotalAmount,
+		TotalRightsCost: order.TotalRightsCost,
+		PaymentInfo:     order.PaymentInfo,
+		CreatedAt:       order.CreatedAt,
+		UpdatedAt:       order.UpdatedAt,
+	}
+}
+
+// OrderWebhookPayload 推送给租户的事件内容，ID 在重试时保持不变，可用于接收方去重
+type OrderWebhookPayload struct {
+	ID         string                    `json:"id"`
+	Event      OrderWebhookEvent         `json:"event"`
+	TenantID   uint64                    `json:"tenant_id"`
+	OccurredAt time.Time                 `json:"occurred_at"`
+	Order      *OrderWebhookOrderSummary `json:"order"`
+	Data       map[string]interface{}    `json:"data,omitempty"` // 事件附加信息，如退款金额
+}
+
+// OrderWebhookDeliveryEvent 单个 Webhook 的推送任务（发件箱事件载荷），Body 为事件发生时生成的载荷快照
+type OrderWebhookDeliveryEvent struct {
+	WebhookID uint64            `json:"webhook_id"`
+	EventID   string            `json:"event_id"`
+	Event     OrderWebhookEvent `json:"event"`
+	Body      string            `json:"body"`
+}
+
+// OrderWebhookDelivery 推送记录，每次尝试一条
+type OrderWebhookDelivery struct {
+	ID         uint64            `json:"id" db:"id"`
+	TenantID   uint64            `json:"tenant_id" db:"tenant_id"`
+	WebhookID  uint64            `json:"webhook_id" db:"webhook_id"`
+	EventID    string            `json:"event_id" db:"event_id"`
+	Event      OrderWebhookEvent `json:"event" db:"event"`
+	Attempt    int               `json:"attempt" db:"attempt"`
+	StatusCode int               `json:"status_code" db:"status_code"`
+	Success    bool              `json:"success" db:"success"`
+	Error      string            `json:"error,omitempty" db:"error"`
+	DurationMs int64             `json:"duration_ms" db:"duration_ms"`
+	CreatedAt  time.Time         `json:"created_at" db:"created_at"`
+}
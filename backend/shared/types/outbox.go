@@ -0,0 +1,62 @@
+package types
+
+import "time"
+
+// OutboxEventStatus 发件箱事件状态
+type OutboxEventStatus string
+
+const (
+	OutboxEventStatusPending   OutboxEventStatus = "pending"   // 待投递
+	OutboxEventStatusProcessed OutboxEventStatus = "processed" // 已投递
+)
+
+// 发件箱事件类型
+const (
+	OutboxEventOrderStatusChanged   = "order.status_changed"
+	OutboxEventNotificationDeferred = "notification.deferred"
+	OutboxEventOrderWebhook         = "order.webhook"
+)
+
+// OutboxEvent 发件箱事件：与业务数据在同一事务中写入，由后台投递器至少投递一次
+type OutboxEvent struct {
+	ID            uint64            `json:"id" db:"id"`
+	TenantID      uint64            `json:"tenant_id" db:"tenant_id"`
+	AggregateType string            `json:"aggregate_type" db:"aggregate_type"`
+	AggregateID   uint64            `json:"aggregate_id" db:"aggregate_id"`
+	EventType     string            `json:"event_type" db:"event_type"`
+	Payload       string            `json:"payload" db:"payload"`
+	Status        OutboxEventStatus `json:"status" db:"status"`
+	Attempts      int               `json:"attempts" db:"attempts"`
+	LastError     string            `json:"last_error,omitempty" db:"last_error"`
+	AvailableAt   time.Time         `json:"available_at" db:"available_at"`
+	ProcessedAt   *time.Time        `json:"processed_at,omitempty" db:"processed_at"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+}
+
+// OrderStatusChangedEvent 订单状态变更事件载荷
+type OrderStatusChangedEvent struct {
+	OrderID      uint64                  `json:"order_id"`
+	FromStatus   OrderStatusInt          `json:"from_status"`
+	ToStatus     OrderStatusInt          `json:"to_status"`
+	Reason       string                  `json:"reason"`
+	OperatorID   *uint64                 `json:"operator_id,omitempty"`
+	OperatorType OrderStatusOperatorType `json:"operator_type"`
+}
+
+// NotificationChannel 通知渠道
+type NotificationChannel string
+
+const (
+	NotificationChannelSMS   NotificationChannel = "sms"
+	NotificationChannelEmail NotificationChannel = "email"
+)
+
+// DeferredNotification 免打扰时段内延迟发送的通知载荷，到达允许发送时间后由投递器发送
+type DeferredNotification struct {
+	Channel      NotificationChannel `json:"channel"`
+	UserID       uint64              `json:"user_id"`
+	TemplateCode string              `json:"template_code,omitempty"` // 短信模板代码
+	Subject      string              `json:"subject,omitempty"`       // 邮件主题
+	Content      string              `json:"content"`
+	DeferredAt   time.Time           `json:"deferred_at"`
+}
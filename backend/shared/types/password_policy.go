@@ -0,0 +1,224 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 密码字符类别
+const (
+	PasswordClassLower  = "lower"  // 小写字母
+	PasswordClassUpper  = "upper"  // 大写字母
+	PasswordClassDigit  = "digit"  // 数字
+	PasswordClassSymbol = "symbol" // 特殊字符
+)
+
+// PasswordClasses 全部密码字符类别
+var PasswordClasses = []string{PasswordClassLower, PasswordClassUpper, PasswordClassDigit, PasswordClassSymbol}
+
+const (
+	DefaultPasswordMinLength = 6   // 未配置密码策略时的最小长度
+	PasswordMaxLength        = 128 // 密码最大长度
+	// PasswordMinLengthLimit 租户可配置的最小长度上限，与随机密码生成的最大长度一致
+	PasswordMinLengthLimit = 32
+	// MaxPasswordHistoryCount 保留的密码历史条数上限，也是租户可配置的禁止重复次数上限
+	MaxPasswordHistoryCount = 24
+)
+
+// PasswordViolationCode 密码不满足策略的原因
+type PasswordViolationCode string
+
+const (
+	PasswordViolationTooShort     PasswordViolationCode = "too_short"     // 长度不足
+	PasswordViolationTooLong      PasswordViolationCode = "too_long"      // 超过最大长度
+	PasswordViolationMissingClass PasswordViolationCode = "missing_class" // 缺少必须包含的字符类别
+	PasswordViolationFewClasses   PasswordViolationCode = "few_classes"   // 包含的字符类别数量不足
+	PasswordViolationCommon       PasswordViolationCode = "common"        // 属于常见弱密码
+	PasswordViolationReused       PasswordViolationCode = "reused"        // 与最近使用过的密码重复
+)
+
+// PasswordViolation 密码不满足策略的具体项
+type PasswordViolation struct {
+	Code    PasswordViolationCode `json:"code"`
+	Class   string                `json:"class,omitempty"` // 缺少的字符类别，仅 missing_class 时有值
+	Message string                `json:"message"`
+}
+
+// PasswordPolicyError 密码不满足租户密码策略
+type PasswordPolicyError struct {
+	Violations []PasswordViolation `json:"violations"`
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return "密码不符合安全策略: " + strings.Join(messages, "；")
+}
+
+// PasswordPolicy represents per-tenant password rules applied when a password is set,
+// plus password rotation. Zero values disable the corresponding rule except MinLength,
+// which falls back to DefaultPasswordMinLength.
+type PasswordPolicy struct {
+	MinLength       int      `json:"min_length"`       // 最小长度
+	RequiredClasses []string `json:"required_classes"` // 必须包含的字符类别：lower, upper, digit, symbol
+	MinClasses      int      `json:"min_classes"`      // 至少包含的字符类别数量
+	DisallowCommon  bool     `json:"disallow_common"`  // 是否禁止常见弱密码
+	HistoryCount    int      `json:"history_count"`    // 禁止重复使用最近几次的密码，0 不限制
+	MaxAgeDays      int      `json:"max_age_days"`     // 密码最长有效天数，过期后需修改密码才能登录，0 不过期
+}
+
+// commonPasswords 常见弱密码（小写），比较时忽略大小写
+var commonPasswords = map[string]bool{
+	"123456": true, "1234567": true, "12345678": true, "123456789": true, "1234567890": true,
+	"111111": true, "000000": true, "888888": true, "666666": true, "123123": true,
+	"654321": true, "112233": true, "121212": true, "abc123": true, "abcd1234": true,
+	"a123456": true, "a1234567": true, "aa123456": true, "qwerty": true, "qwerty123": true,
+	"qwertyuiop": true, "1qaz2wsx": true, "1q2w3e4r": true, "asdfgh": true, "zxcvbnm": true,
+	"password": true, "password1": true, "password123": true, "p@ssw0rd": true, "passw0rd": true,
+	"admin": true, "admin123": true, "admin@123": true, "root123": true, "iloveyou": true,
+	"welcome": true, "welcome1": true, "letmein": true, "monkey": true, "dragon": true,
+	"woaini": true, "woaini1314": true, "5201314": true, "changeme": true, "test123": true,
+}
+
+// DefaultPasswordPolicy 未配置密码策略时使用的默认策略
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{MinLength: DefaultPasswordMinLength}
+}
+
+// Validate 校验密码策略配置
+func (p *PasswordPolicy) Validate() error {
+	// 0 表示使用默认最小长度；登录请求要求密码至少为默认最小长度，配置值不能更低
+	if p.MinLength != 0 && (p.MinLength < DefaultPasswordMinLength || p.MinLength > PasswordMinLengthLimit) {
+		return fmt.Errorf("密码最小长度须在%d-%d之间", DefaultPasswordMinLength, PasswordMinLengthLimit)
+	}
+	for _, class := range p.RequiredClasses {
+		if !isPasswordClass(class) {
+			return fmt.Errorf("不支持的密码字符类别: %s", class)
+		}
+	}
+	if p.MinClasses < 0 || p.MinClasses > len(PasswordClasses) {
+		return fmt.Errorf("密码字符类别数量须在0-%d之间", len(PasswordClasses))
+	}
+	if p.HistoryCount < 0 || p.HistoryCount > MaxPasswordHistoryCount {
+		return fmt.Errorf("禁止重复使用的密码次数须在0-%d之间", MaxPasswordHistoryCount)
+	}
+	if p.MaxAgeDays < 0 {
+		return fmt.Errorf("密码有效天数不能为负数")
+	}
+	return nil
+}
+
+// EffectiveMinLength 生效的最小长度，未配置时使用默认最小长度
+func (p *PasswordPolicy) EffectiveMinLength() int {
+	if p == nil || p.MinLength <= 0 {
+		return DefaultPasswordMinLength
+	}
+	return p.MinLength
+}
+
+// Check 按策略检查密码的长度、字符类别和常见弱密码，返回全部不满足的项；
+// 密码历史需要比对哈希，由调用方检查
+func (p *PasswordPolicy) Check(password string) []PasswordViolation {
+	if p == nil {
+		p = DefaultPasswordPolicy()
+	}
+
+	var violations []PasswordViolation
+	length := len([]rune(password))
+	if minLength := p.EffectiveMinLength(); length < minLength {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordViolationTooShort,
+			Message: fmt.Sprintf("密码长度不能少于%d位", minLength),
+		})
+	}
+	if length > PasswordMaxLength {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordViolationTooLong,
+			Message: fmt.Sprintf("密码长度不能超过%d位", PasswordMaxLength),
+		})
+	}
+
+	present := passwordClassesOf(password)
+	for _, class := range p.RequiredClasses {
+		if !present[class] {
+			violations = append(violations, PasswordViolation{
+				Code:    PasswordViolationMissingClass,
+				Class:   class,
+				Message: fmt.Sprintf("密码必须包含%s", passwordClassNames[class]),
+			})
+		}
+	}
+	if p.MinClasses > 0 && len(present) < p.MinClasses {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordViolationFewClasses,
+			Message: fmt.Sprintf("密码须至少包含大写字母、小写字母、数字、特殊字符中的%d类", p.MinClasses),
+		})
+	}
+
+	if p.DisallowCommon && commonPasswords[strings.ToLower(password)] {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordViolationCommon,
+			Message: "密码过于常见，请更换",
+		})
+	}
+	return violations
+}
+
+// Expired 判断在 changedAt 设置的密码在 now 时是否已超过最长有效天数
+func (p *PasswordPolicy) Expired(changedAt, now time.Time) bool {
+	if p == nil || p.MaxAgeDays <= 0 || changedAt.IsZero() {
+		return false
+	}
+	return !now.Before(changedAt.AddDate(0, 0, p.MaxAgeDays))
+}
+
+// ReusedPasswordViolation 密码与最近使用过的密码重复
+func ReusedPasswordViolation(historyCount int) PasswordViolation {
+	return PasswordViolation{
+		Code:    PasswordViolationReused,
+		Message: fmt.Sprintf("不能使用最近%d次使用过的密码", historyCount),
+	}
+}
+
+var passwordClassNames = map[string]string{
+	PasswordClassLower:  "小写字母",
+	PasswordClassUpper:  "大写字母",
+	PasswordClassDigit:  "数字",
+	PasswordClassSymbol: "特殊字符",
+}
+
+func isPasswordClass(class string) bool {
+	_, exists := passwordClassNames[class]
+	return exists
+}
+
+// passwordClassesOf 密码中包含的字符类别
+func passwordClassesOf(password string) map[string]bool {
+	present := make(map[string]bool, len(PasswordClasses))
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			present[PasswordClassLower] = true
+		case unicode.IsUpper(r):
+			present[PasswordClassUpper] = true
+		case unicode.IsDigit(r):
+			present[PasswordClassDigit] = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			present[PasswordClassSymbol] = true
+		}
+	}
+	return present
+}
+
+// PasswordHistory 用户设置过的密码哈希，用于禁止重复使用最近的密码
+type PasswordHistory struct {
+	ID           uint64    `json:"id" db:"id"`
+	TenantID     uint64    `json:"tenant_id" db:"tenant_id"`
+	UserID       uint64    `json:"user_id" db:"user_id"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
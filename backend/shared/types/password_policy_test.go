@@ -0,0 +1,107 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *PasswordPolicy
+		password string
+		want     []PasswordViolationCode
+	}{
+		{name: "未配置策略时使用默认最小长度", policy: nil, password: "12345", want: []PasswordViolationCode{PasswordViolationTooShort}},
+		{name: "满足默认策略", policy: nil, password: "123456"},
+		{name: "长度不足", policy: &PasswordPolicy{MinLength: 10}, password: "Abc12345!", want: []PasswordViolationCode{PasswordViolationTooShort}},
+		{name: "超过最大长度", policy: &PasswordPolicy{}, password: string(make([]byte, 129)), want: []PasswordViolationCode{PasswordViolationTooLong}},
+		{
+			name:     "缺少多个必须包含的字符类别",
+			policy:   &PasswordPolicy{RequiredClasses: []string{PasswordClassUpper, PasswordClassDigit, PasswordClassSymbol}},
+			password: "abcdefgh",
+			want:     []PasswordViolationCode{PasswordViolationMissingClass, PasswordViolationMissingClass, PasswordViolationMissingClass},
+		},
+		{name: "包含必须的字符类别", policy: &PasswordPolicy{RequiredClasses: []string{PasswordClassUpper, PasswordClassSymbol}}, password: "Abcdef#1"},
+		{name: "字符类别数量不足", policy: &PasswordPolicy{MinClasses: 3}, password: "abcdef12", want: []PasswordViolationCode{PasswordViolationFewClasses}},
+		{name: "字符类别数量满足", policy: &PasswordPolicy{MinClasses: 3}, password: "abcdef1!"},
+		{name: "常见弱密码忽略大小写", policy: &PasswordPolicy{DisallowCommon: true}, password: "PassWord123", want: []PasswordViolationCode{PasswordViolationCommon}},
+		{name: "未禁止常见弱密码", policy: &PasswordPolicy{}, password: "password123"},
+		{
+			name:     "返回全部不满足项",
+			policy:   &PasswordPolicy{MinLength: 12, MinClasses: 2, DisallowCommon: true},
+			password: "12345678",
+			want:     []PasswordViolationCode{PasswordViolationTooShort, PasswordViolationFewClasses, PasswordViolationCommon},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []PasswordViolationCode
+			for _, violation := range tt.policy.Check(tt.password) {
+				got = append(got, violation.Code)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyMissingClassDetail(t *testing.T) {
+	policy := &PasswordPolicy{RequiredClasses: []string{PasswordClassLower, PasswordClassSymbol}}
+	violations := policy.Check("abcdefgh")
+	if len(violations) != 1 || violations[0].Class != PasswordClassSymbol || violations[0].Message != "密码必须包含特殊字符" {
+		t.Errorf("Check() = %+v, want missing symbol", violations)
+	}
+}
+
+func TestPasswordPolicyExpired(t *testing.T) {
+	changedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		policy *PasswordPolicy
+		now    time.Time
+		want   bool
+	}{
+		{name: "未配置有效期", policy: &PasswordPolicy{}, now: changedAt.AddDate(5, 0, 0), want: false},
+		{name: "有效期内", policy: &PasswordPolicy{MaxAgeDays: 90}, now: changedAt.AddDate(0, 0, 89), want: false},
+		{name: "达到有效期", policy: &PasswordPolicy{MaxAgeDays: 90}, now: changedAt.AddDate(0, 0, 90), want: true},
+		{name: "策略为空", policy: nil, now: changedAt.AddDate(5, 0, 0), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Expired(changedAt, tt.now); got != tt.want {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  PasswordPolicy
+		wantErr bool
+	}{
+		{name: "有效策略", policy: PasswordPolicy{MinLength: 12, RequiredClasses: []string{PasswordClassUpper}, MinClasses: 3, HistoryCount: 5, MaxAgeDays: 90}},
+		{name: "使用默认最小长度", policy: PasswordPolicy{}},
+		{name: "最小长度低于默认值", policy: PasswordPolicy{MinLength: 4}, wantErr: true},
+		{name: "最小长度超过上限", policy: PasswordPolicy{MinLength: 64}, wantErr: true},
+		{name: "无效字符类别", policy: PasswordPolicy{RequiredClasses: []string{"emoji"}}, wantErr: true},
+		{name: "字符类别数量超过上限", policy: PasswordPolicy{MinClasses: 5}, wantErr: true},
+		{name: "密码历史次数超过上限", policy: PasswordPolicy{HistoryCount: MaxPasswordHistoryCount + 1}, wantErr: true},
+		{name: "有效天数为负数", policy: PasswordPolicy{MaxAgeDays: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
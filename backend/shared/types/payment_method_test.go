@@ -0,0 +1,47 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPaymentMethodList(t *testing.T) {
+	tests := []struct {
+		name      string
+		list      PaymentMethodList
+		method    PaymentMethod
+		allows    bool
+		effective []PaymentMethod
+	}{
+		{name: "未配置接受全部", list: nil, method: PaymentMethodBalance, allows: true, effective: AllPaymentMethods},
+		{name: "已启用", list: PaymentMethodList{PaymentMethodAlipay}, method: PaymentMethodAlipay, allows: true, effective: []PaymentMethod{PaymentMethodAlipay}},
+		{name: "未启用", list: PaymentMethodList{PaymentMethodAlipay}, method: PaymentMethodWechat, allows: false, effective: []PaymentMethod{PaymentMethodAlipay}},
+		{name: "无效支付方式", list: nil, method: "cash", allows: false, effective: AllPaymentMethods},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.list.Allows(tt.method); got != tt.allows {
+				t.Errorf("Allows(%s) = %v, want %v", tt.method, got, tt.allows)
+			}
+			if got := tt.list.Effective(); !reflect.DeepEqual(got, tt.effective) {
+				t.Errorf("Effective() = %v, want %v", got, tt.effective)
+			}
+		})
+	}
+}
+
+func TestPaymentMethodListScan(t *testing.T) {
+	var list PaymentMethodList
+	if err := list.Scan([]byte(`["wechat","balance"]`)); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if !reflect.DeepEqual(list, PaymentMethodList{PaymentMethodWechat, PaymentMethodBalance}) {
+		t.Errorf("Scan() = %v", list)
+	}
+
+	value, err := PaymentMethodList(nil).Value()
+	if err != nil || string(value.([]byte)) != "[]" {
+		t.Errorf("Value() = %s, %v", value, err)
+	}
+}
@@ -0,0 +1,63 @@
+package types
+
+import "time"
+
+// PaymentQueryResult 支付渠道交易查询结果
+type PaymentQueryResult struct {
+	TradeStatus   string     `json:"trade_status"` // 渠道交易状态，如 WAIT_BUYER_PAY、TRADE_SUCCESS、TRADE_CLOSED
+	TransactionID string     `json:"transaction_id"`
+	Amount        float64    `json:"amount"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+}
+
+// IsPaid 渠道侧是否已支付成功
+func (r *PaymentQueryResult) IsPaid() bool {
+	return r.TradeStatus == "TRADE_SUCCESS" || r.TradeStatus == "TRADE_FINISHED"
+}
+
+// PaymentReconciliationOutcome 支付对账结果
+type PaymentReconciliationOutcome string
+
+const (
+	PaymentReconciliationRecovered PaymentReconciliationOutcome = "recovered" // 渠道已支付，订单已补记为已支付
+	PaymentReconciliationUnpaid    PaymentReconciliationOutcome = "unpaid"    // 渠道未支付，订单保持待支付
+	PaymentReconciliationSettled   PaymentReconciliationOutcome = "settled"   // 订单已由回调等其他途径处理，无需补记
+	PaymentReconciliationFailed    PaymentReconciliationOutcome = "failed"    // 查询渠道或更新订单失败
+)
+
+// PaymentReconciliation 支付对账记录
+type PaymentReconciliation struct {
+	ID            uint64                       `json:"id" db:"id"`
+	TenantID      uint64                       `json:"tenant_id" db:"tenant_id"`
+	OrderID       uint64                       `json:"order_id" db:"order_id"`
+	OrderNumber   string                       `json:"order_number" db:"order_number"`
+	TradeStatus   string                       `json:"trade_status" db:"trade_status"`
+	TransactionID string                       `json:"transaction_id" db:"transaction_id"`
+	Outcome       PaymentReconciliationOutcome `json:"outcome" db:"outcome"`
+	Message       string                       `json:"message" db:"message"`
+	CreatedAt     time.Time                    `json:"created_at" db:"created_at"`
+}
+
+// PaymentReconciliationSummary 一轮支付对账的汇总
+type PaymentReconciliationSummary struct {
+	Scanned   int `json:"scanned"`
+	Recovered int `json:"recovered"`
+	Unpaid    int `json:"unpaid"`
+	Settled   int `json:"settled"`
+	Failed    int `json:"failed"`
+}
+
+// Add 累计单个订单的对账结果
+func (s *PaymentReconciliationSummary) Add(outcome PaymentReconciliationOutcome) {
+	s.Scanned++
+	switch outcome {
+	case PaymentReconciliationRecovered:
+		s.Recovered++
+	case PaymentReconciliationUnpaid:
+		s.Unpaid++
+	case PaymentReconciliationSettled:
+		s.Settled++
+	case PaymentReconciliationFailed:
+		s.Failed++
+	}
+}
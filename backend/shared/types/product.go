@@ -6,9 +6,10 @@ import (
 	"time"
 )
 
-// 扩展已有的ProductStatus枚举，添加DELETED状态
+// 扩展已有的ProductStatus枚举，添加DELETED和SCHEDULED状态
 const (
-	ProductStatusDeleted ProductStatus = "deleted" // 已删除
+	ProductStatusDeleted   ProductStatus = "deleted"   // 已删除
+	ProductStatusScheduled ProductStatus = "scheduled" // 待定时上架，到达可售开始时间后自动上架
 )
 
 // CategoryStatus 分类状态枚举
@@ -215,6 +216,12 @@ type CreateProductRequest struct {
 	Price        Money         `json:"price" validate:"required"`
 	RightsCost   int64         `json:"rights_cost" validate:"min=0"`
 	Inventory    InventoryInfo `json:"inventory" validate:"required"`
+	// 可售时间窗口，用于限时抢购和季节性商品
+	AvailableFrom  *time.Time  `json:"available_from,omitempty"`
+	AvailableUntil *time.Time  `json:"available_until,omitempty"`
+	// 单笔订单购买数量限制，0 表示不限制
+	MinOrderQuantity int       `json:"min_order_quantity,omitempty" validate:"min=0"`
+	MaxOrderQuantity int       `json:"max_order_quantity,omitempty" validate:"min=0"`
 }
 
 // UpdateProductRequest 更新商品请求
@@ -226,11 +233,18 @@ type UpdateProductRequest struct {
 	Price       *Money        `json:"price,omitempty"`
 	RightsCost  *int64        `json:"rights_cost,omitempty" validate:"min=0"`
 	Inventory   *InventoryInfo `json:"inventory,omitempty"`
+	AvailableFrom  *time.Time  `json:"available_from,omitempty"`
+	AvailableUntil *time.Time  `json:"available_until,omitempty"`
+	// 清除可售时间窗口，商品不再受时间限制
+	ClearAvailability bool     `json:"clear_availability,omitempty"`
+	// 单笔订单购买数量限制，设置为 0 时取消限制
+	MinOrderQuantity *int      `json:"min_order_quantity,omitempty" validate:"omitempty,min=0"`
+	MaxOrderQuantity *int      `json:"max_order_quantity,omitempty" validate:"omitempty,min=0"`
 }
 
 // UpdateProductStatusRequest 更新商品状态请求
 type UpdateProductStatusRequest struct {
-	Status ProductStatus `json:"status" validate:"required,oneof=draft active inactive deleted"`
+	Status ProductStatus `json:"status" validate:"required,oneof=draft scheduled active inactive deleted"`
 }
 
 // ProductListRequest 商品列表查询请求
@@ -242,6 +256,8 @@ type ProductListRequest struct {
 	Keyword    string        `json:"keyword,omitempty"`
 	SortBy     string        `json:"sort_by,omitempty" validate:"oneof=created_at updated_at name price"`
 	SortOrder  string        `json:"sort_order,omitempty" validate:"oneof=asc desc"`
+	// 包含不在可售时间窗口内的商品，供商户管理未上架或已过期的商品
+	IncludeUnavailable bool  `json:"include_unavailable,omitempty"`
 }
 
 // ProductBatchOperationRequest 批量操作请求
@@ -275,7 +291,8 @@ type UploadImageRequest struct {
 // ProductResponse 商品响应
 type ProductResponse struct {
 	Product
-	Category *ProductCategory `json:"category,omitempty"`
+	Category *ProductCategory      `json:"category,omitempty"`
+	Rating   *ProductRatingSummary `json:"rating,omitempty"`
 }
 
 // ProductListResponse 商品列表响应
@@ -0,0 +1,51 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidAvailabilityWindow 可售结束时间不晚于开始时间
+var ErrInvalidAvailabilityWindow = errors.New("可售结束时间必须晚于开始时间")
+
+// ValidateAvailabilityWindow 校验可售时间窗口，为空的一端不限制
+func ValidateAvailabilityWindow(from, until *time.Time) error {
+	if from != nil && until != nil && !until.After(*from) {
+		return ErrInvalidAvailabilityWindow
+	}
+	return nil
+}
+
+// InAvailabilityWindow 判断 at 时刻是否处于商品可售时间窗口内，窗口包含开始时间、不包含结束时间
+func (p *Product) InAvailabilityWindow(at time.Time) bool {
+	if p.AvailableFrom != nil && at.Before(*p.AvailableFrom) {
+		return false
+	}
+	if p.AvailableUntil != nil && !at.Before(*p.AvailableUntil) {
+		return false
+	}
+	return true
+}
+
+// ScheduledStatusAt 返回定时任务在 at 时刻应将商品流转到的状态：
+// 待上架商品到达开始时间后上架（已过结束时间则直接下架），在售商品到达结束时间后下架。
+// 不需要流转时返回当前状态
+func (p *Product) ScheduledStatusAt(at time.Time) ProductStatus {
+	expired := p.AvailableUntil != nil && !at.Before(*p.AvailableUntil)
+
+	switch p.Status {
+	case ProductStatusScheduled:
+		if p.AvailableFrom != nil && at.Before(*p.AvailableFrom) {
+			return p.Status
+		}
+		if expired {
+			return ProductStatusInactive
+		}
+		return ProductStatusActive
+	case ProductStatusActive:
+		if expired {
+			return ProductStatusInactive
+		}
+	}
+	return p.Status
+}
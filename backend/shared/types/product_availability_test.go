@@ -0,0 +1,87 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProduct_ScheduledStatusAt(t *testing.T) {
+	now := time.Date(2026, 10, 15, 10, 0, 0, 0, time.Local)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		name          string
+		product       Product
+		wantStatus    ProductStatus
+		wantAvailable bool
+	}{
+		{
+			name:          "未设置时间窗口",
+			product:       Product{Status: ProductStatusActive},
+			wantStatus:    ProductStatusActive,
+			wantAvailable: true,
+		},
+		{
+			name:       "待上架商品未到开始时间",
+			product:    Product{Status: ProductStatusScheduled, AvailableFrom: at(time.Hour)},
+			wantStatus: ProductStatusScheduled,
+		},
+		{
+			name:          "待上架商品到达开始时间后上架",
+			product:       Product{Status: ProductStatusScheduled, AvailableFrom: at(0), AvailableUntil: at(time.Hour)},
+			wantStatus:    ProductStatusActive,
+			wantAvailable: true,
+		},
+		{
+			name:       "待上架商品已过结束时间直接下架",
+			product:    Product{Status: ProductStatusScheduled, AvailableFrom: at(-2 * time.Hour), AvailableUntil: at(-time.Hour)},
+			wantStatus: ProductStatusInactive,
+		},
+		{
+			name:       "在售商品到达结束时间后下架",
+			product:    Product{Status: ProductStatusActive, AvailableUntil: at(0)},
+			wantStatus: ProductStatusInactive,
+		},
+		{
+			name:       "在售商品未到开始时间不改变状态",
+			product:    Product{Status: ProductStatusActive, AvailableFrom: at(time.Hour)},
+			wantStatus: ProductStatusActive,
+		},
+		{
+			// 时间窗口内但已被商户手动下架
+			name:          "已下架商品不自动上架",
+			product:       Product{Status: ProductStatusInactive, AvailableFrom: at(-time.Hour)},
+			wantStatus:    ProductStatusInactive,
+			wantAvailable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.product.ScheduledStatusAt(now); got != tt.wantStatus {
+				t.Errorf("ScheduledStatusAt() = %v, want %v", got, tt.wantStatus)
+			}
+			if got := tt.product.InAvailabilityWindow(now); got != tt.wantAvailable {
+				t.Errorf("InAvailabilityWindow() = %v, want %v", got, tt.wantAvailable)
+			}
+		})
+	}
+}
+
+func TestValidateAvailabilityWindow(t *testing.T) {
+	from := time.Date(2026, 11, 11, 0, 0, 0, 0, time.Local)
+	until := from.Add(24 * time.Hour)
+
+	if err := ValidateAvailabilityWindow(&from, &until); err != nil {
+		t.Errorf("ValidateAvailabilityWindow() error = %v", err)
+	}
+	if err := ValidateAvailabilityWindow(&from, nil); err != nil {
+		t.Errorf("ValidateAvailabilityWindow() error = %v", err)
+	}
+	if err := ValidateAvailabilityWindow(&until, &from); err != ErrInvalidAvailabilityWindow {
+		t.Errorf("ValidateAvailabilityWindow() error = %v, want %v", err, ErrInvalidAvailabilityWindow)
+	}
+}
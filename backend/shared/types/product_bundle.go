@@ -0,0 +1,215 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxProductBundleComponents 套餐最多包含的组成商品数
+	MaxProductBundleComponents = 10
+	// MaxProductBundleNameLength 套餐名称最大长度（字符数）
+	MaxProductBundleNameLength = 100
+)
+
+var (
+	// ErrProductBundleNotFound 套餐不存在
+	ErrProductBundleNotFound = errors.New("套餐不存在")
+	// ErrProductBundleUnavailable 套餐已停售或组成商品库存不足
+	ErrProductBundleUnavailable = errors.New("套餐不可售")
+	// ErrInvalidProductBundle 套餐配置校验失败
+	ErrInvalidProductBundle = errors.New("套餐配置无效")
+)
+
+// ProductBundleStatus 套餐状态
+type ProductBundleStatus string
+
+const (
+	ProductBundleStatusActive   ProductBundleStatus = "active"   // 在售
+	ProductBundleStatusInactive ProductBundleStatus = "inactive" // 停售
+)
+
+// IsValid 套餐状态是否有效
+func (s ProductBundleStatus) IsValid() bool {
+	return s == ProductBundleStatusActive || s == ProductBundleStatusInactive
+}
+
+// ProductBundleComponent 套餐组成商品及每份套餐包含的数量
+type ProductBundleComponent struct {
+	ProductID uint64 `json:"product_id" v:"required#商品ID不能为空"`
+	Quantity  int    `json:"quantity" v:"required|min:1#数量不能为空|数量必须大于0"`
+}
+
+// ProductBundleComponents 套餐组成商品列表，实现数据库序列化
+type ProductBundleComponents []ProductBundleComponent
+
+// Value 实现 driver.Valuer 接口
+func (c ProductBundleComponents) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return json.Marshal([]ProductBundleComponent{})
+	}
+	return json.Marshal(c)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (c *ProductBundleComponents) Scan(value interface{}) error {
+	if value == nil {
+		*c = ProductBundleComponents{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return nil
+}
+
+// Quantities 购买 count 份套餐需要的各组成商品数量
+func (c ProductBundleComponents) Quantities(count int) map[uint64]int {
+	quantities := make(map[uint64]int, len(c))
+	for _, component := range c {
+		quantities[component.ProductID] += component.Quantity * count
+	}
+	return quantities
+}
+
+// ProductBundle 商品套餐：多个商品按固定数量组合，以套餐价格和套餐权益成本整体销售，
+// 下单时按组成商品分别占用库存
+type ProductBundle struct {
+	ID          uint64                  `json:"id" db:"id"`
+	TenantID    uint64                  `json:"tenant_id" db:"tenant_id"`
+	MerchantID  uint64                  `json:"merchant_id" db:"merchant_id"`
+	Name        string                  `json:"name" db:"name"`
+	Description string                  `json:"description" db:"description"`
+	Components  ProductBundleComponents `json:"components" db:"components"`
+	Price       float64                 `json:"price" db:"price"`             // 套餐价格，不是组成商品价格之和
+	RightsCost  float64                 `json:"rights_cost" db:"rights_cost"` // 每份套餐消耗的权益
+	Status      ProductBundleStatus     `json:"status" db:"status"`
+	CreatedAt   time.Time               `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at" db:"updated_at"`
+}
+
+// IsActive 套餐是否在售
+func (b *ProductBundle) IsActive() bool {
+	return b.Status == ProductBundleStatusActive
+}
+
+// ProductBundleAvailability 套餐可售情况
+type ProductBundleAvailability struct {
+	InStock   bool `json:"in_stock"`
+	Quantity  int  `json:"quantity"`  // 按组成商品可用库存计算的可售份数，Unlimited 时为 0
+	Unlimited bool `json:"unlimited"` // 组成商品均不跟踪库存，可售份数不受限制
+	// UnavailableProductID 导致套餐不可售的组成商品
+	UnavailableProductID uint64 `json:"unavailable_product_id,omitempty"`
+}
+
+// Availability 按组成商品的状态和可用库存计算套餐可售份数，used 为同一订单中已占用的商品数量，可为空。
+// 任一组成商品不存在、未上架或可用库存不足一份套餐时套餐不可售；套餐不接受预订，
+// 不跟踪库存的组成商品不限制可售份数
+func (b *ProductBundle) Availability(products map[uint64]*Product, used map[uint64]int) ProductBundleAvailability {
+	availability := ProductBundleAvailability{InStock: true, Unlimited: true}
+	for _, component := range b.Components {
+		product, exists := products[component.ProductID]
+		if !exists || product.Status != ProductStatusActive {
+			return ProductBundleAvailability{UnavailableProductID: component.ProductID}
+		}
+
+		inventory := product.InventoryInfo
+		if inventory == nil || !inventory.TrackInventory {
+			continue
+		}
+		count := (inventory.AvailableStock() - used[component.ProductID]) / component.Quantity
+		if count <= 0 {
+			return ProductBundleAvailability{UnavailableProductID: component.ProductID}
+		}
+		if availability.Unlimited || count < availability.Quantity {
+			availability.Quantity = count
+		}
+		availability.Unlimited = false
+	}
+	return availability
+}
+
+// Allows 是否可以购买 quantity 份套餐
+func (a ProductBundleAvailability) Allows(quantity int) bool {
+	return a.InStock && (a.Unlimited || quantity <= a.Quantity)
+}
+
+// CreateProductBundleRequest 创建套餐请求
+type CreateProductBundleRequest struct {
+	MerchantID  uint64                   `json:"merchant_id" v:"required#商户ID不能为空"`
+	Name        string                   `json:"name" v:"required#套餐名称不能为空"`
+	Description string                   `json:"description"`
+	Components  []ProductBundleComponent `json:"components" v:"required#套餐商品不能为空"`
+	Price       float64                  `json:"price" v:"required|min:0.01#套餐价格不能为空|套餐价格必须大于0"`
+	RightsCost  float64                  `json:"rights_cost" v:"min:0#权益成本不能为负数"`
+}
+
+// Validate 校验套餐名称、价格和组成商品，同一商品只能出现一次
+func (r *CreateProductBundleRequest) Validate() error {
+	name := strings.TrimSpace(r.Name)
+	if name == "" || utf8.RuneCountInString(name) > MaxProductBundleNameLength {
+		return fmt.Errorf("%w: 套餐名称不能为空且不超过%d个字符", ErrInvalidProductBundle, MaxProductBundleNameLength)
+	}
+	if r.Price <= 0 {
+		return fmt.Errorf("%w: 套餐价格必须大于0", ErrInvalidProductBundle)
+	}
+	if r.RightsCost < 0 {
+		return fmt.Errorf("%w: 权益成本不能为负数", ErrInvalidProductBundle)
+	}
+	if len(r.Components) == 0 || len(r.Components) > MaxProductBundleComponents {
+		return fmt.Errorf("%w: 套餐需包含1到%d个商品", ErrInvalidProductBundle, MaxProductBundleComponents)
+	}
+
+	seen := make(map[uint64]bool, len(r.Components))
+	for _, component := range r.Components {
+		if component.ProductID == 0 || component.Quantity <= 0 {
+			return fmt.Errorf("%w: 套餐商品和数量必须大于0", ErrInvalidProductBundle)
+		}
+		if seen[component.ProductID] {
+			return fmt.Errorf("%w: 商品%d重复", ErrInvalidProductBundle, component.ProductID)
+		}
+		seen[component.ProductID] = true
+	}
+	return nil
+}
+
+// ProductIDs 套餐组成商品ID
+func (r *CreateProductBundleRequest) ProductIDs() []uint64 {
+	ids := make([]uint64, 0, len(r.Components))
+	for _, component := range r.Components {
+		ids = append(ids, component.ProductID)
+	}
+	return ids
+}
+
+// UpdateProductBundleStatusRequest 套餐上下架请求
+type UpdateProductBundleStatusRequest struct {
+	Status ProductBundleStatus `json:"status" v:"required|in:active,inactive#状态不能为空|状态无效"`
+}
+
+// ProductBundleDetail 套餐及其当前可售情况
+type ProductBundleDetail struct {
+	*ProductBundle
+	Availability ProductBundleAvailability `json:"availability"`
+}
+
+// OrderBundleRequest 下单请求中的套餐
+type OrderBundleRequest struct {
+	BundleID uint64 `json:"bundle_id" v:"required#套餐ID不能为空"`
+	Quantity int    `json:"quantity" v:"required|min:1#数量不能为空|数量必须大于0"`
+}
+
+// AddCartBundleRequest 添加套餐到购物车请求
+type AddCartBundleRequest struct {
+	BundleID uint64 `json:"bundle_id" v:"required#套餐ID不能为空"`
+	Quantity int    `json:"quantity" v:"required|min:1#数量不能为空|数量必须大于0"`
+}
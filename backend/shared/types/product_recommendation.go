@@ -0,0 +1,168 @@
+package types
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultProductRecommendationLimit 搭配推荐默认返回的商品数
+	DefaultProductRecommendationLimit = 10
+	// MaxProductRecommendationLimit 搭配推荐最多返回的商品数
+	MaxProductRecommendationLimit = 50
+)
+
+// ProductRecommendationConfig 搭配推荐（经常一起购买）的计算配置
+type ProductRecommendationConfig struct {
+	LookbackDays   int // 统计最近多少天内完成的订单
+	MinCoPurchases int // 至少在多少个订单中一起购买才推荐
+	MaxPerProduct  int // 每个商品最多保存的搭配商品数
+}
+
+// DefaultProductRecommendationConfig 默认的搭配推荐计算配置
+func DefaultProductRecommendationConfig() *ProductRecommendationConfig {
+	return &ProductRecommendationConfig{
+		LookbackDays:   90,
+		MinCoPurchases: 2,
+		MaxPerProduct:  MaxProductRecommendationLimit,
+	}
+}
+
+// WithDefaults 未配置或配置无效的项使用默认值
+func (c *ProductRecommendationConfig) WithDefaults() *ProductRecommendationConfig {
+	defaults := DefaultProductRecommendationConfig()
+	config := *c
+	if config.LookbackDays <= 0 {
+		config.LookbackDays = defaults.LookbackDays
+	}
+	if config.MinCoPurchases <= 0 {
+		config.MinCoPurchases = defaults.MinCoPurchases
+	}
+	if config.MaxPerProduct <= 0 {
+		config.MaxPerProduct = defaults.MaxPerProduct
+	}
+	return &config
+}
+
+// ProductCoPurchase 商品与搭配商品在同一订单中一起购买的次数，按商户统计
+type ProductCoPurchase struct {
+	TenantID         uint64    `json:"tenant_id" db:"tenant_id"`
+	MerchantID       uint64    `json:"merchant_id" db:"merchant_id"`
+	ProductID        uint64    `json:"product_id" db:"product_id"`
+	RelatedProductID uint64    `json:"related_product_id" db:"related_product_id"`
+	CoPurchaseCount  int       `json:"co_purchase_count" db:"co_purchase_count"`
+	ComputedAt       time.Time `json:"computed_at" db:"computed_at"`
+}
+
+// ProductRecommendation 搭配推荐的商品
+type ProductRecommendation struct {
+	ProductID       uint64 `json:"product_id"`
+	CoPurchaseCount int    `json:"co_purchase_count"` // 与当前商品一起购买的订单数
+}
+
+// ProductRecommendationResponse 商品的搭配推荐
+type ProductRecommendationResponse struct {
+	ProductID       uint64                  `json:"product_id"`
+	Recommendations []ProductRecommendation `json:"recommendations"`
+	ComputedAt      *time.Time              `json:"computed_at,omitempty"` // 推荐数据的计算时间，尚未计算时为空
+}
+
+// coPurchaseKey 同一商户下的商品对
+type coPurchaseKey struct {
+	merchantID uint64
+	productID  uint64
+	relatedID  uint64
+}
+
+// CoPurchaseCounter 统计订单中商品一起购买的次数，同一订单中重复的商品只计一次
+type CoPurchaseCounter struct {
+	counts map[coPurchaseKey]int
+}
+
+// NewCoPurchaseCounter 创建商品一起购买次数统计
+func NewCoPurchaseCounter() *CoPurchaseCounter {
+	return &CoPurchaseCounter{counts: make(map[coPurchaseKey]int)}
+}
+
+// Add 统计一个订单中的商品
+func (c *CoPurchaseCounter) Add(order *Order) {
+	seen := make(map[uint64]bool, len(order.Items))
+	productIDs := make([]uint64, 0, len(order.Items))
+	for _, item := range order.Items {
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+
+	for _, productID := range productIDs {
+		for _, relatedID := range productIDs {
+			if productID != relatedID {
+				c.counts[coPurchaseKey{merchantID: order.MerchantID, productID: productID, relatedID: relatedID}]++
+			}
+		}
+	}
+}
+
+// Results 返回一起购买次数不少于 MinCoPurchases 的商品对，每个商品按次数从多到少（次数相同时按商品ID）
+// 保留前 MaxPerProduct 个搭配商品
+func (c *CoPurchaseCounter) Results(config *ProductRecommendationConfig, tenantID uint64, computedAt time.Time) []ProductCoPurchase {
+	byProduct := make(map[coPurchaseKey][]ProductCoPurchase)
+	for key, count := range c.counts {
+		if count < config.MinCoPurchases {
+			continue
+		}
+		productKey := coPurchaseKey{merchantID: key.merchantID, productID: key.productID}
+		byProduct[productKey] = append(byProduct[productKey], ProductCoPurchase{
+			TenantID:         tenantID,
+			MerchantID:       key.merchantID,
+			ProductID:        key.productID,
+			RelatedProductID: key.relatedID,
+			CoPurchaseCount:  count,
+			ComputedAt:       computedAt,
+		})
+	}
+
+	productKeys := make([]coPurchaseKey, 0, len(byProduct))
+	for key := range byProduct {
+		productKeys = append(productKeys, key)
+	}
+	sort.Slice(productKeys, func(i, j int) bool {
+		if productKeys[i].merchantID != productKeys[j].merchantID {
+			return productKeys[i].merchantID < productKeys[j].merchantID
+		}
+		return productKeys[i].productID < productKeys[j].productID
+	})
+
+	var results []ProductCoPurchase
+	for _, key := range productKeys {
+		related := byProduct[key]
+		SortCoPurchases(related)
+		if len(related) > config.MaxPerProduct {
+			related = related[:config.MaxPerProduct]
+		}
+		results = append(results, related...)
+	}
+	return results
+}
+
+// SortCoPurchases 按一起购买次数从多到少排序，次数相同时按商品ID从小到大
+func SortCoPurchases(coPurchases []ProductCoPurchase) {
+	sort.Slice(coPurchases, func(i, j int) bool {
+		if coPurchases[i].CoPurchaseCount != coPurchases[j].CoPurchaseCount {
+			return coPurchases[i].CoPurchaseCount > coPurchases[j].CoPurchaseCount
+		}
+		return coPurchases[i].RelatedProductID < coPurchases[j].RelatedProductID
+	})
+}
+
+// Recommendable 商品当前可以被推荐：在售、处于可售时间窗口内且有可用库存（不跟踪库存的商品视为有货）
+func (p *Product) Recommendable(at time.Time) bool {
+	if p.Status != ProductStatusActive || !p.InAvailabilityWindow(at) {
+		return false
+	}
+	if inventory := p.InventoryInfo; inventory != nil && inventory.TrackInventory && inventory.AvailableStock() <= 0 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MinProductRating        = 1   // 最低评分
+	MaxProductRating        = 5   // 最高评分
+	MaxProductReviewComment = 500 // 评价内容最大字数
+)
+
+// ProductReview 商品评价，关联订单以保证只有真实购买者可以评价
+type ProductReview struct {
+	ID         uint64     `json:"id" db:"id"`
+	TenantID   uint64     `json:"tenant_id" db:"tenant_id"`
+	MerchantID uint64     `json:"merchant_id" db:"merchant_id"`
+	ProductID  uint64     `json:"product_id" db:"product_id"`
+	OrderID    uint64     `json:"order_id" db:"order_id"`
+	CustomerID uint64     `json:"customer_id" db:"customer_id"`
+	Rating     int        `json:"rating" db:"rating"`
+	Comment    string     `json:"comment" db:"comment"`
+	Flagged    bool       `json:"flagged" db:"flagged"`                   // 被标记为不当内容，不对外展示也不计入评分
+	FlagReason string     `json:"flag_reason,omitempty" db:"flag_reason"` // 标记原因
+	FlaggedBy  *uint64    `json:"flagged_by,omitempty" db:"flagged_by"`   // 标记人
+	FlaggedAt  *time.Time `json:"flagged_at,omitempty" db:"flagged_at"`   // 标记时间
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreateProductReviewRequest 提交商品评价请求
+type CreateProductReviewRequest struct {
+	OrderID uint64 `json:"order_id" v:"required#订单ID不能为空"`
+	Rating  int    `json:"rating" v:"required|between:1,5#评分不能为空|评分必须在1-5之间"`
+	Comment string `json:"comment"`
+}
+
+// Validate 验证评价请求
+func (r *CreateProductReviewRequest) Validate() error {
+	if r.OrderID == 0 {
+		return errors.New("订单ID不能为空")
+	}
+	if r.Rating < MinProductRating || r.Rating > MaxProductRating {
+		return errors.New("评分必须在1-5之间")
+	}
+	if utf8.RuneCountInString(r.Comment) > MaxProductReviewComment {
+		return errors.New("评价内容不能超过500字")
+	}
+	return nil
+}
+
+// FlagProductReviewRequest 标记评价请求，Flagged 为 false 时取消标记
+type FlagProductReviewRequest struct {
+	Flagged bool   `json:"flagged"`
+	Reason  string `json:"reason"`
+}
+
+// ProductReviewListResponse 商品评价列表响应
+type ProductReviewListResponse struct {
+	Reviews  []ProductReview       `json:"reviews"`
+	Summary  *ProductRatingSummary `json:"summary"`
+	Total    int                   `json:"total"`
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"page_size"`
+}
+
+// ProductRatingSummary 商品评分汇总，不含被标记的评价
+type ProductRatingSummary struct {
+	ProductID     uint64      `json:"product_id"`
+	AverageRating float64     `json:"average_rating"` // 平均评分，保留一位小数
+	ReviewCount   int         `json:"review_count"`
+	Distribution  map[int]int `json:"distribution"` // 各评分的评价数量
+}
+
+// NewProductRatingSummary 根据各评分的评价数量计算评分汇总，超出范围的评分被忽略
+func NewProductRatingSummary(productID uint64, ratingCounts map[int]int) *ProductRatingSummary {
+	summary := &ProductRatingSummary{ProductID: productID, Distribution: make(map[int]int)}
+
+	total := 0
+	for rating := MinProductRating; rating <= MaxProductRating; rating++ {
+		count := ratingCounts[rating]
+		if count <= 0 {
+			continue
+		}
+		summary.Distribution[rating] = count
+		summary.ReviewCount += count
+		total += rating * count
+	}
+	if summary.ReviewCount > 0 {
+		summary.AverageRating = math.Round(float64(total)/float64(summary.ReviewCount)*10) / 10
+	}
+
+	return summary
+}
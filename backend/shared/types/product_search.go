@@ -0,0 +1,264 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxSynonymGroups     = 200
+	maxSynonymTermLength = 50
+)
+
+// ProductSearchMatch 关键词与字段的匹配程度，数值越大越相关
+type ProductSearchMatch int
+
+const (
+	ProductSearchMatchNone     ProductSearchMatch = 0
+	ProductSearchMatchContains ProductSearchMatch = 1 // 字段包含关键词
+	ProductSearchMatchPrefix   ProductSearchMatch = 2 // 字段以关键词开头
+	ProductSearchMatchExact    ProductSearchMatch = 3 // 字段与关键词完全相同
+)
+
+// MatchSearchTerm 判断字段与关键词的匹配程度，忽略大小写和首尾空白
+func MatchSearchTerm(field, term string) ProductSearchMatch {
+	field = strings.ToLower(strings.TrimSpace(field))
+	term = strings.ToLower(strings.TrimSpace(term))
+	switch {
+	case field == "" || term == "":
+		return ProductSearchMatchNone
+	case field == term:
+		return ProductSearchMatchExact
+	case strings.HasPrefix(field, term):
+		return ProductSearchMatchPrefix
+	case strings.Contains(field, term):
+		return ProductSearchMatchContains
+	default:
+		return ProductSearchMatchNone
+	}
+}
+
+// ProductSearchRanking 商品搜索相关度权重
+type ProductSearchRanking struct {
+	NameWeight        float64 `json:"name_weight"`
+	TagWeight         float64 `json:"tag_weight"`
+	CategoryWeight    float64 `json:"category_weight"`
+	DescriptionWeight float64 `json:"description_weight"`
+	// 同义词命中的得分系数，使原词命中排在同义词命中之前
+	SynonymFactor float64 `json:"synonym_factor"`
+}
+
+// DefaultProductSearchRanking 默认权重：名称 > 标签 > 分类 > 描述
+func DefaultProductSearchRanking() *ProductSearchRanking {
+	return &ProductSearchRanking{
+		NameWeight:        10,
+		TagWeight:         6,
+		CategoryWeight:    4,
+		DescriptionWeight: 1,
+		SynonymFactor:     0.8,
+	}
+}
+
+// WithDefaults 未配置（非正数）的权重使用默认值
+func (r *ProductSearchRanking) WithDefaults() *ProductSearchRanking {
+	defaults := DefaultProductSearchRanking()
+	result := *r
+	if result.NameWeight <= 0 {
+		result.NameWeight = defaults.NameWeight
+	}
+	if result.TagWeight <= 0 {
+		result.TagWeight = defaults.TagWeight
+	}
+	if result.CategoryWeight <= 0 {
+		result.CategoryWeight = defaults.CategoryWeight
+	}
+	if result.DescriptionWeight <= 0 {
+		result.DescriptionWeight = defaults.DescriptionWeight
+	}
+	if result.SynonymFactor <= 0 || result.SynonymFactor > 1 {
+		result.SynonymFactor = defaults.SynonymFactor
+	}
+	return &result
+}
+
+// Score 计算商品与查询词的相关度：每个查询词按字段匹配程度乘以字段权重累加，取得分最高的查询词
+func (r *ProductSearchRanking) Score(doc *ProductSearchDocument, terms []ProductSearchTerm) (float64, []string) {
+	var best float64
+	var matched []string
+	for _, term := range terms {
+		score := float64(MatchSearchTerm(doc.Product.Name, term.Text))*r.NameWeight +
+			float64(MatchSearchTerm(doc.CategoryName, term.Text))*r.CategoryWeight +
+			float64(MatchSearchTerm(doc.Product.Description, term.Text))*r.DescriptionWeight
+		var tagMatch ProductSearchMatch
+		for _, tag := range doc.Product.Tags {
+			if match := MatchSearchTerm(tag, term.Text); match > tagMatch {
+				tagMatch = match
+			}
+		}
+		score += float64(tagMatch) * r.TagWeight
+
+		if score == 0 {
+			continue
+		}
+		if term.Synonym {
+			score *= r.SynonymFactor
+		}
+		matched = append(matched, term.Text)
+		if score > best {
+			best = score
+		}
+	}
+	return best, matched
+}
+
+// ProductSearchTerm 查询词，Synonym 表示由同义词扩展得到
+type ProductSearchTerm struct {
+	Text    string `json:"text"`
+	Synonym bool   `json:"synonym"`
+}
+
+// ProductSearchDocument 参与排序的商品及其分类名称
+type ProductSearchDocument struct {
+	Product      Product
+	CategoryName string
+}
+
+// ProductSearchRequest 商品搜索请求
+type ProductSearchRequest struct {
+	Keyword    string  `json:"keyword"`
+	CategoryID *uint64 `json:"category_id,omitempty"`
+	Page       int     `json:"page"`
+	PageSize   int     `json:"page_size"`
+}
+
+// ProductSearchQuery 交给搜索引擎执行的查询（关键词已按同义词扩展）
+type ProductSearchQuery struct {
+	Terms      []ProductSearchTerm
+	CategoryID *uint64
+	Page       int
+	PageSize   int
+}
+
+// ProductSearchHit 搜索结果中的单个商品
+type ProductSearchHit struct {
+	ProductResponse
+	Score        float64  `json:"score"`
+	MatchedTerms []string `json:"matched_terms"`
+}
+
+// ProductSearchResponse 商品搜索结果，按相关度从高到低排序
+type ProductSearchResponse struct {
+	Hits     []ProductSearchHit  `json:"hits"`
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Terms    []ProductSearchTerm `json:"terms"`
+}
+
+// ProductSynonymDictionary 租户的商品搜索同义词词典，每组内的词互为同义词
+type ProductSynonymDictionary struct {
+	TenantID  uint64     `json:"tenant_id" db:"tenant_id"`
+	Groups    [][]string `json:"groups" db:"synonym_groups"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ErrInvalidSynonymGroup 同义词组无效
+var ErrInvalidSynonymGroup = errors.New("同义词组无效")
+
+// Normalize 去除空白和重复词并校验词典，每组至少包含两个不同的词
+func (d *ProductSynonymDictionary) Normalize() error {
+	if len(d.Groups) > maxSynonymGroups {
+		return fmt.Errorf("%w: 同义词组不能超过 %d 个", ErrInvalidSynonymGroup, maxSynonymGroups)
+	}
+
+	groups := make([][]string, 0, len(d.Groups))
+	for i, group := range d.Groups {
+		seen := make(map[string]bool, len(group))
+		var terms []string
+		for _, term := range group {
+			term = strings.TrimSpace(term)
+			key := strings.ToLower(term)
+			if term == "" || seen[key] {
+				continue
+			}
+			if len([]rune(term)) > maxSynonymTermLength {
+				return fmt.Errorf("%w: 第 %d 组的词 %q 超过 %d 个字符", ErrInvalidSynonymGroup, i+1, term, maxSynonymTermLength)
+			}
+			seen[key] = true
+			terms = append(terms, term)
+		}
+		if len(terms) < 2 {
+			return fmt.Errorf("%w: 第 %d 组至少需要两个不同的词", ErrInvalidSynonymGroup, i+1)
+		}
+		groups = append(groups, terms)
+	}
+
+	d.Groups = groups
+	return nil
+}
+
+// Expand 将关键词按同义词扩展为查询词，原词在前，同义词按词典顺序排列
+func (d *ProductSynonymDictionary) Expand(keyword string) []ProductSearchTerm {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return nil
+	}
+
+	terms := []ProductSearchTerm{{Text: keyword}}
+	if d == nil {
+		return terms
+	}
+
+	seen := map[string]bool{strings.ToLower(keyword): true}
+	for _, group := range d.Groups {
+		inGroup := false
+		for _, term := range group {
+			if strings.EqualFold(term, keyword) {
+				inGroup = true
+				break
+			}
+		}
+		if !inGroup {
+			continue
+		}
+		for _, term := range group {
+			if key := strings.ToLower(term); !seen[key] {
+				seen[key] = true
+				terms = append(terms, ProductSearchTerm{Text: term, Synonym: true})
+			}
+		}
+	}
+	return terms
+}
+
+// RankProductSearchHits 计算相关度并按得分从高到低排序，未命中的商品不返回；
+// 得分相同时较新的商品在前
+func RankProductSearchHits(docs []ProductSearchDocument, terms []ProductSearchTerm, ranking *ProductSearchRanking) []ProductSearchHit {
+	hits := make([]ProductSearchHit, 0, len(docs))
+	for i := range docs {
+		score, matched := ranking.Score(&docs[i], terms)
+		if score == 0 {
+			continue
+		}
+		hit := ProductSearchHit{
+			ProductResponse: ProductResponse{Product: docs[i].Product},
+			Score:           score,
+			MatchedTerms:    matched,
+		}
+		if docs[i].Product.CategoryID != nil && docs[i].CategoryName != "" {
+			hit.Category = &ProductCategory{ID: *docs[i].Product.CategoryID, Name: docs[i].CategoryName}
+		}
+		hits = append(hits, hit)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].CreatedAt.After(hits[j].CreatedAt)
+	})
+	return hits
+}
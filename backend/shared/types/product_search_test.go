@@ -0,0 +1,116 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMatchSearchTerm(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		term  string
+		want  ProductSearchMatch
+	}{
+		{name: "完全相同", field: "Apple", term: "apple", want: ProductSearchMatchExact},
+		{name: "前缀匹配", field: "Apple Juice", term: "apple", want: ProductSearchMatchPrefix},
+		{name: "包含匹配", field: "Green Apple", term: "apple", want: ProductSearchMatchContains},
+		{name: "中文包含", field: "红富士苹果", term: "苹果", want: ProductSearchMatchContains},
+		{name: "不匹配", field: "Banana", term: "apple", want: ProductSearchMatchNone},
+		{name: "空字段", field: "", term: "apple", want: ProductSearchMatchNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchSearchTerm(tt.field, tt.term); got != tt.want {
+				t.Errorf("MatchSearchTerm(%q, %q) = %d, want %d", tt.field, tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankProductSearchHits(t *testing.T) {
+	now := time.Now()
+	docs := []ProductSearchDocument{
+		{Product: Product{ID: 1, Name: "Green Apple", CreatedAt: now}},
+		{Product: Product{ID: 2, Name: "Apple", CreatedAt: now.Add(-time.Hour)}},
+		{Product: Product{ID: 3, Name: "Apple Juice", CreatedAt: now}},
+		{Product: Product{ID: 4, Name: "Banana", Description: "apple flavour", CreatedAt: now}},
+		{Product: Product{ID: 5, Name: "Orange", CreatedAt: now}},
+		{Product: Product{ID: 6, Name: "Cider", Tags: StringArray{"apple"}, CreatedAt: now}},
+	}
+
+	hits := RankProductSearchHits(docs, []ProductSearchTerm{{Text: "apple"}}, DefaultProductSearchRanking())
+	var ids []uint64
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+
+	// 名称完全匹配 > 名称前缀 > 标签完全匹配 > 名称包含 > 描述前缀，未命中的商品不返回
+	want := []uint64{2, 3, 6, 1, 4}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("ranked ids = %v, want %v", ids, want)
+	}
+}
+
+func TestRankProductSearchHits_SynonymBelowOriginal(t *testing.T) {
+	docs := []ProductSearchDocument{
+		{Product: Product{ID: 1, Name: "Apple"}},
+		{Product: Product{ID: 2, Name: "苹果"}},
+	}
+	terms := []ProductSearchTerm{{Text: "苹果"}, {Text: "Apple", Synonym: true}}
+
+	hits := RankProductSearchHits(docs, terms, DefaultProductSearchRanking())
+	if len(hits) != 2 || hits[0].ID != 2 || hits[1].ID != 1 {
+		t.Fatalf("unexpected hits: %+v", hits)
+	}
+	if !reflect.DeepEqual(hits[1].MatchedTerms, []string{"Apple"}) {
+		t.Errorf("matched terms = %v, want [Apple]", hits[1].MatchedTerms)
+	}
+}
+
+func TestProductSynonymDictionary_Expand(t *testing.T) {
+	dictionary := &ProductSynonymDictionary{Groups: [][]string{
+		{"苹果", "Apple", "平果"},
+		{"手机", "电话"},
+	}}
+
+	tests := []struct {
+		name    string
+		keyword string
+		want    []ProductSearchTerm
+	}{
+		{
+			name:    "命中同义词组",
+			keyword: "apple",
+			want:    []ProductSearchTerm{{Text: "apple"}, {Text: "苹果", Synonym: true}, {Text: "平果", Synonym: true}},
+		},
+		{name: "未命中同义词组", keyword: "香蕉", want: []ProductSearchTerm{{Text: "香蕉"}}},
+		{name: "空关键词", keyword: "  ", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dictionary.Expand(tt.keyword); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expand(%q) = %v, want %v", tt.keyword, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductSynonymDictionary_Normalize(t *testing.T) {
+	dictionary := &ProductSynonymDictionary{Groups: [][]string{{" 苹果 ", "Apple", "apple", ""}}}
+	if err := dictionary.Normalize(); err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if want := [][]string{{"苹果", "Apple"}}; !reflect.DeepEqual(dictionary.Groups, want) {
+		t.Errorf("groups = %v, want %v", dictionary.Groups, want)
+	}
+
+	invalid := &ProductSynonymDictionary{Groups: [][]string{{"苹果", " 苹果"}}}
+	if err := invalid.Normalize(); !errors.Is(err, ErrInvalidSynonymGroup) {
+		t.Errorf("Normalize() error = %v, want ErrInvalidSynonymGroup", err)
+	}
+}
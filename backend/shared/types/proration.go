@@ -0,0 +1,101 @@
+package types
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultProrationDecimals 金额分摊默认保留的小数位数（分）
+const DefaultProrationDecimals = 2
+
+// ProrateAmount 按权重把 total 分摊为若干份，结果保留 decimals 位小数。
+// 使用最大余数法：先按比例向下取整到最小单位，再把剩余的最小单位依次分给余数最大的份额，
+// 保证各份额之和与 total（按 decimals 取整后）完全相等。权重全为 0 时平均分摊
+func ProrateAmount(total float64, weights []float64, decimals int) []float64 {
+	parts := make([]float64, len(weights))
+	if len(weights) == 0 {
+		return parts
+	}
+	if decimals < 0 {
+		decimals = 0
+	}
+
+	scale := math.Pow10(decimals)
+	totalUnits := int64(math.Round(math.Abs(total) * scale))
+	sign := 1.0
+	if total < 0 {
+		sign = -1
+	}
+
+	var weightSum float64
+	for _, weight := range weights {
+		if weight > 0 {
+			weightSum += weight
+		}
+	}
+
+	type share struct {
+		index     int
+		remainder float64
+	}
+	units := make([]int64, len(weights))
+	shares := make([]share, len(weights))
+	var allocated int64
+	for i, weight := range weights {
+		var exact float64
+		switch {
+		case weightSum == 0:
+			exact = float64(totalUnits) / float64(len(weights))
+		case weight > 0:
+			exact = float64(totalUnits) * weight / weightSum
+		}
+		// 容忍浮点误差，避免本应整除的份额被向下取整少一个单位
+		units[i] = int64(math.Floor(exact + 1e-9))
+		shares[i] = share{index: i, remainder: exact - float64(units[i])}
+		allocated += units[i]
+	}
+
+	// 余数相同时优先分给靠前的份额，保证结果稳定
+	sort.SliceStable(shares, func(a, b int) bool {
+		return shares[a].remainder > shares[b].remainder
+	})
+	for i := int64(0); i < totalUnits-allocated; i++ {
+		units[shares[i%int64(len(shares))].index]++
+	}
+
+	for i, unit := range units {
+		parts[i] = sign * float64(unit) / scale
+	}
+	return parts
+}
+
+// ProrateOrderFulfillments 把订单总金额和总权益消耗分摊到各履约单，
+// 按履约单内商品的金额（权益消耗）占比分摊，各履约单之和与订单总额完全相等
+func ProrateOrderFulfillments(order *Order, fulfillments []OrderFulfillment, decimals int) {
+	if order == nil || len(fulfillments) == 0 {
+		return
+	}
+
+	unitPrices := make(map[uint64]float64, len(order.Items))
+	unitRightsCosts := make(map[uint64]float64, len(order.Items))
+	for _, item := range order.Items {
+		unitPrices[item.ProductID] = item.Price
+		unitRightsCosts[item.ProductID] = item.RightsCost
+	}
+
+	amountWeights := make([]float64, len(fulfillments))
+	rightsWeights := make([]float64, len(fulfillments))
+	for i, fulfillment := range fulfillments {
+		for _, item := range fulfillment.Items {
+			amountWeights[i] += unitPrices[item.ProductID] * float64(item.Quantity)
+			rightsWeights[i] += unitRightsCosts[item.ProductID] * float64(item.Quantity)
+		}
+	}
+
+	amounts := ProrateAmount(order.TotalAmount, amountWeights, decimals)
+	rightsCosts := ProrateAmount(order.TotalRightsCost, rightsWeights, decimals)
+	for i := range fulfillments {
+		fulfillments[i].Amount = amounts[i]
+		fulfillments[i].RightsCost = rightsCosts[i]
+	}
+}
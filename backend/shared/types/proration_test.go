@@ -0,0 +1,107 @@
+package types
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// sumUnits 按最小单位求和，避免浮点累加误差影响断言
+func sumUnits(parts []float64, decimals int) int64 {
+	scale := math.Pow10(decimals)
+	var sum int64
+	for _, part := range parts {
+		sum += int64(math.Round(part * scale))
+	}
+	return sum
+}
+
+func TestProrateAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    float64
+		weights  []float64
+		decimals int
+		want     []float64
+	}{
+		{name: "100 平均分给 3 份", total: 100, weights: []float64{1, 1, 1}, decimals: 2, want: []float64{33.34, 33.33, 33.33}},
+		{name: "100 按整数分给 3 份", total: 100, weights: []float64{1, 1, 1}, decimals: 0, want: []float64{34, 33, 33}},
+		{name: "余数分给余数最大的份额", total: 10, weights: []float64{1, 2, 4}, decimals: 2, want: []float64{1.43, 2.86, 5.71}},
+		{name: "可以整除", total: 90, weights: []float64{1, 2}, decimals: 2, want: []float64{30, 60}},
+		{name: "权重全为0时平均分摊", total: 0.05, weights: []float64{0, 0}, decimals: 2, want: []float64{0.03, 0.02}},
+		{name: "权重为0的份额不分摊", total: 99.99, weights: []float64{3, 0, 3}, decimals: 2, want: []float64{50, 0, 49.99}},
+		{name: "负数金额", total: -100, weights: []float64{1, 1, 1}, decimals: 2, want: []float64{-33.34, -33.33, -33.33}},
+		{name: "没有份额", total: 100, weights: nil, decimals: 2, want: []float64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ProrateAmount(tt.total, tt.weights, tt.decimals)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ProrateAmount() = %v, want %v", got, tt.want)
+			}
+			if len(got) > 0 && sumUnits(got, tt.decimals) != int64(math.Round(tt.total*math.Pow10(tt.decimals))) {
+				t.Errorf("ProrateAmount() parts %v do not sum to %v", got, tt.total)
+			}
+		})
+	}
+}
+
+func TestProrateAmountReconstructsTotal(t *testing.T) {
+	totals := []float64{100, 0.01, 1, 333.33, 1999.99, 12345.67}
+	weightSets := [][]float64{
+		{1, 1, 1},
+		{1, 1, 1, 1, 1, 1, 1},
+		{3, 7, 11},
+		{0.1, 0.2, 0.3},
+		{19.9, 0.01, 5},
+	}
+
+	for _, total := range totals {
+		for _, weights := range weightSets {
+			for _, decimals := range []int{0, 2, 4} {
+				parts := ProrateAmount(total, weights, decimals)
+				want := int64(math.Round(total * math.Pow10(decimals)))
+				if got := sumUnits(parts, decimals); got != want {
+					t.Errorf("ProrateAmount(%v, %v, %d) = %v, sum %d want %d", total, weights, decimals, parts, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestProrateOrderFulfillments(t *testing.T) {
+	order := &Order{
+		Items: []OrderItem{
+			{ProductID: 1, Quantity: 1, Price: 40, RightsCost: 10},
+			{ProductID: 2, Quantity: 1, Price: 40, RightsCost: 10},
+			{ProductID: 3, Quantity: 1, Price: 40, RightsCost: 10},
+		},
+		// 使用优惠后订单金额不再等于商品金额之和
+		TotalAmount:     100,
+		TotalRightsCost: 10,
+	}
+	fulfillments := []OrderFulfillment{
+		{Items: []FulfillmentItem{{ProductID: 1, Quantity: 1}}},
+		{Items: []FulfillmentItem{{ProductID: 2, Quantity: 1}}},
+		{Items: []FulfillmentItem{{ProductID: 3, Quantity: 1}}},
+	}
+
+	ProrateOrderFulfillments(order, fulfillments, DefaultProrationDecimals)
+
+	amounts := make([]float64, len(fulfillments))
+	rightsCosts := make([]float64, len(fulfillments))
+	for i, fulfillment := range fulfillments {
+		amounts[i] = fulfillment.Amount
+		rightsCosts[i] = fulfillment.RightsCost
+	}
+	if want := []float64{33.34, 33.33, 33.33}; !reflect.DeepEqual(amounts, want) {
+		t.Errorf("amounts = %v, want %v", amounts, want)
+	}
+	if want := []float64{3.34, 3.33, 3.33}; !reflect.DeepEqual(rightsCosts, want) {
+		t.Errorf("rights costs = %v, want %v", rightsCosts, want)
+	}
+	if sumUnits(amounts, 2) != 10000 || sumUnits(rightsCosts, 2) != 1000 {
+		t.Errorf("prorated parts do not reconstruct order totals: %v %v", amounts, rightsCosts)
+	}
+}
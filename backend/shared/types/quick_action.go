@@ -0,0 +1,35 @@
+package types
+
+// QuickActionType 仪表板快捷操作类型
+type QuickActionType string
+
+const (
+	QuickActionProcessPendingOrders QuickActionType = "process_pending_orders" // 批量开始处理已支付订单
+	QuickActionViewLowStock         QuickActionType = "view_low_stock"         // 查看低库存商品
+	QuickActionRechargeRights       QuickActionType = "recharge_rights"        // 跳转权益充值
+)
+
+// QuickAction 快捷操作定义
+type QuickAction struct {
+	Type        QuickActionType `json:"type"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Route       string          `json:"route,omitempty"`  // 前端跳转路径
+	Params      []string        `json:"params,omitempty"` // 支持的参数
+	// Permissions 满足其中任一权限即可执行
+	Permissions []Permission `json:"-"`
+}
+
+// ExecuteQuickActionRequest 执行快捷操作请求
+type ExecuteQuickActionRequest struct {
+	Action QuickActionType        `json:"action" v:"required#快捷操作不能为空"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// QuickActionResult 快捷操作执行结果
+type QuickActionResult struct {
+	Action  QuickActionType `json:"action"`
+	Message string          `json:"message"`
+	Route   string          `json:"route,omitempty"`
+	Data    interface{}     `json:"data,omitempty"`
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// parseClockMinutes 解析 HH:MM 格式的时间，返回当天零点起的分钟数
+func parseClockMinutes(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("时间格式无效，应为 HH:MM: %s", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate 校验免打扰时段配置
+func (p *QuietHoursPolicy) Validate() error {
+	if _, err := parseClockMinutes(p.Start); err != nil {
+		return err
+	}
+	if _, err := parseClockMinutes(p.End); err != nil {
+		return err
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return fmt.Errorf("时区无效: %s", p.Timezone)
+	}
+	return nil
+}
+
+// NextAllowedTime 返回不早于 now 的最早允许发送时间，不在免打扰时段内时直接返回 now。
+// timezone 为接收人时区，为空时使用策略配置的时区
+func (p *QuietHoursPolicy) NextAllowedTime(now time.Time, timezone string) (time.Time, error) {
+	if p == nil || !p.Enabled {
+		return now, nil
+	}
+	if err := p.Validate(); err != nil {
+		return now, err
+	}
+	if timezone == "" {
+		timezone = p.Timezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return now, fmt.Errorf("时区无效: %s", timezone)
+	}
+
+	start, _ := parseClockMinutes(p.Start)
+	end, _ := parseClockMinutes(p.End)
+	if start == end {
+		return now, nil
+	}
+
+	local := now.In(loc)
+	minutes := local.Hour()*60 + local.Minute()
+	quiet := minutes >= start && minutes < end
+	if start > end {
+		// 跨零点的时段，如 22:00-08:00
+		quiet = minutes >= start || minutes < end
+	}
+	if !quiet {
+		return now, nil
+	}
+
+	next := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, loc)
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next, nil
+}
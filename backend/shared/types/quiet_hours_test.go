@@ -0,0 +1,79 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHoursPolicy_NextAllowedTime(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("缺少时区数据: %v", err)
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 9, day, hour, minute, 0, 0, shanghai)
+	}
+	overnight := &QuietHoursPolicy{Enabled: true, Start: "22:00", End: "08:00", Timezone: "Asia/Shanghai"}
+
+	tests := []struct {
+		name     string
+		policy   *QuietHoursPolicy
+		now      time.Time
+		timezone string
+		want     time.Time
+	}{
+		{name: "未配置时不限制", policy: nil, now: at(10, 3, 0), want: at(10, 3, 0)},
+		{name: "未启用时不限制", policy: &QuietHoursPolicy{Start: "22:00", End: "08:00"}, now: at(10, 3, 0), want: at(10, 3, 0)},
+		{name: "白天可以发送", policy: overnight, now: at(10, 15, 0), want: at(10, 15, 0)},
+		{name: "凌晨延迟到当天结束时间", policy: overnight, now: at(10, 3, 0), want: at(10, 8, 0)},
+		{name: "深夜延迟到次日结束时间", policy: overnight, now: at(10, 23, 30), want: at(11, 8, 0)},
+		{name: "开始时间已进入免打扰", policy: overnight, now: at(10, 22, 0), want: at(11, 8, 0)},
+		{name: "结束时间已可以发送", policy: overnight, now: at(10, 8, 0), want: at(10, 8, 0)},
+		{
+			name:   "不跨零点的时段",
+			policy: &QuietHoursPolicy{Enabled: true, Start: "12:00", End: "14:00", Timezone: "Asia/Shanghai"},
+			now:    at(10, 13, 15),
+			want:   at(10, 14, 0),
+		},
+		{
+			name:     "按用户时区判断",
+			policy:   overnight,
+			now:      time.Date(2025, 9, 10, 15, 0, 0, 0, shanghai), // UTC 07:00
+			timezone: "UTC",
+			want:     time.Date(2025, 9, 10, 8, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.NextAllowedTime(tt.now, tt.timezone)
+			if err != nil {
+				t.Fatalf("NextAllowedTime() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("NextAllowedTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietHoursPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  QuietHoursPolicy
+		wantErr bool
+	}{
+		{name: "有效配置", policy: QuietHoursPolicy{Start: "22:00", End: "08:00", Timezone: "UTC"}},
+		{name: "开始时间无效", policy: QuietHoursPolicy{Start: "25:00", End: "08:00"}, wantErr: true},
+		{name: "结束时间无效", policy: QuietHoursPolicy{Start: "22:00", End: "8点"}, wantErr: true},
+		{name: "时区无效", policy: QuietHoursPolicy{Start: "22:00", End: "08:00", Timezone: "Mars/Base"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ const (
 	ReportTypeFinancial         ReportType = "financial"          // 财务报表
 	ReportTypeMerchantOperation ReportType = "merchant_operation" // 商户运营报表
 	ReportTypeCustomerAnalysis  ReportType = "customer_analysis"  // 客户分析报表
+	ReportTypeReconciliation    ReportType = "reconciliation"     // 财务对账报表
 )
 
 // PeriodType 时间周期类型
@@ -30,6 +31,7 @@ const (
 type ReportStatus string
 
 const (
+	ReportStatusQueued     ReportStatus = "queued"     // 排队中
 	ReportStatusGenerating ReportStatus = "generating" // 生成中
 	ReportStatusCompleted  ReportStatus = "completed"  // 已完成
 	ReportStatusFailed     ReportStatus = "failed"     // 失败
@@ -42,8 +44,25 @@ const (
 	FileFormatExcel FileFormat = "excel" // Excel格式
 	FileFormatPDF   FileFormat = "pdf"   // PDF格式
 	FileFormatJSON  FileFormat = "json"  // JSON格式
+	FileFormatHTML  FileFormat = "html"  // HTML格式，仅在PDF转换失败并按HTML交付时使用
 )
 
+// ContentType 文件格式对应的下载内容类型
+func (f FileFormat) ContentType() string {
+	switch f {
+	case FileFormatExcel:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FileFormatPDF:
+		return "application/pdf"
+	case FileFormatJSON:
+		return "application/json"
+	case FileFormatHTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
 // Report 报表实体
 type Report struct {
 	ID          uint64          `gorm:"primary_key;auto_increment" json:"id"`
@@ -55,6 +74,7 @@ type Report struct {
 	EndDate     time.Time       `gorm:"not null;index" json:"end_date"`
 	Status      ReportStatus    `gorm:"not null;index;default:'generating'" json:"status"`
 	FilePath    string          `gorm:"type:varchar(500)" json:"file_path,omitempty"`
+	ObjectKey   string          `gorm:"type:varchar(500)" json:"object_key,omitempty"` // 对象存储交付时报表文件的对象键
 	FileFormat  FileFormat      `gorm:"not null" json:"file_format"`
 	GeneratedBy uint64          `gorm:"not null" json:"generated_by"`
 	GeneratedAt time.Time       `gorm:"default:CURRENT_TIMESTAMP" json:"generated_at"`
@@ -62,6 +82,9 @@ type Report struct {
 	DataSummary json.RawMessage `gorm:"type:json" json:"data_summary,omitempty"`
 	CreatedAt   time.Time       `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// QueuePosition 排队中报表的预计排队位置，不落库
+	QueuePosition int `gorm:"-" json:"queue_position,omitempty"`
 }
 
 // ReportTemplate 报表模板
@@ -91,7 +114,8 @@ type ReportJob struct {
 	StartedAt    *time.Time   `json:"started_at,omitempty"`
 	CompletedAt  *time.Time   `json:"completed_at,omitempty"`
 	ErrorMessage string       `gorm:"type:text" json:"error_message,omitempty"`
-	RetryCount   int          `gorm:"default:0" json:"retry_count"`
+	RetryCount   int          `gorm:"default:0" json:"retry_count"`   // 本轮自动重试次数，手动重试时清零
+	AttemptCount int          `gorm:"default:0" json:"attempt_count"` // 累计执行次数
 	CreatedAt    time.Time    `gorm:"autoCreateTime" json:"created_at"`
 }
 
@@ -131,6 +155,8 @@ type FinancialReportData struct {
 	ActiveCustomerCount  int                      `json:"active_customer_count"`  // 活跃客户数
 	OrderCount           int                      `json:"order_count"`            // 订单总数
 	OrderAmount          Money                    `json:"order_amount"`           // 订单总金额
+	TaxCollected         Money                    `json:"tax_collected"`          // 代收税额
+	NetRevenue           Money                    `json:"net_revenue"`            // 不含税收入
 	Breakdown            *FinancialBreakdown      `json:"breakdown,omitempty"`    // 详细分解数据
 }
 
@@ -192,10 +218,20 @@ type MonthlyFinancial struct {
 
 // MerchantOperationReport 商户运营报表数据
 type MerchantOperationReport struct {
-	MerchantRankings   []MerchantRanking    `json:"merchant_rankings"`   // 商户排名
-	PerformanceTrends  []MerchantTrend      `json:"performance_trends"`  // 业绩趋势
-	CategoryAnalysis   []CategoryAnalysis   `json:"category_analysis"`   // 类别分析
-	GrowthMetrics      *GrowthMetrics       `json:"growth_metrics"`      // 增长指标
+	MerchantRankings   []MerchantRanking      `json:"merchant_rankings"`   // 商户排名
+	PerformanceTrends  []MerchantTrend        `json:"performance_trends"`  // 业绩趋势
+	CategoryAnalysis   []CategoryAnalysis     `json:"category_analysis"`   // 类别分析
+	GrowthMetrics      *GrowthMetrics         `json:"growth_metrics"`      // 增长指标
+	Satisfaction       []MerchantSatisfaction `json:"satisfaction"`        // 商品评价满意度
+}
+
+// MerchantSatisfaction 商户商品评价满意度（不含被标记的评价）
+type MerchantSatisfaction struct {
+	MerchantID     uint64  `json:"merchant_id"`
+	MerchantName   string  `json:"merchant_name"`
+	AverageRating  float64 `json:"average_rating"`   // 平均评分
+	ReviewCount    int     `json:"review_count"`     // 评价数量
+	LowRatingCount int     `json:"low_rating_count"` // 差评数量（2分及以下）
 }
 
 // MerchantRanking 商户排名数据
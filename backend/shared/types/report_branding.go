@@ -0,0 +1,90 @@
+package types
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultReportCompanyName 租户未配置报表品牌时报表署名的公司名称
+	DefaultReportCompanyName = "MER系统"
+	// MaxReportCompanyNameLength 报表公司名称的最大长度
+	MaxReportCompanyNameLength = 50
+	// MaxReportHeaderTextLength 报表页眉文字的最大长度
+	MaxReportHeaderTextLength = 100
+	// MaxReportFooterTextLength 报表页脚声明的最大长度
+	MaxReportFooterTextLength = 100
+	// MaxReportLogoURLLength 报表Logo地址的最大长度
+	MaxReportLogoURLLength = 500
+)
+
+// ReportBranding represents how a tenant's generated reports are branded. The logo is
+// embedded into PDF/HTML reports and the Excel cover sheet; unset fields fall back to
+// the default branding. An empty FooterText is derived from the CompanyName.
+type ReportBranding struct {
+	LogoURL     string `json:"logo_url"`     // Logo图片地址，仅支持 http(s) 的 PNG 或 JPEG 图片
+	CompanyName string `json:"company_name"` // 公司名称，显示在报表页眉
+	HeaderText  string `json:"header_text"`  // 页眉文字，如部门或报表说明
+	FooterText  string `json:"footer_text"`  // 页脚声明
+}
+
+// DefaultReportBranding 未配置报表品牌时使用的默认品牌
+func DefaultReportBranding() *ReportBranding {
+	return &ReportBranding{
+		CompanyName: DefaultReportCompanyName,
+		FooterText:  defaultReportFooterText(DefaultReportCompanyName),
+	}
+}
+
+// defaultReportFooterText 未配置页脚声明时按公司名称生成的页脚
+func defaultReportFooterText(companyName string) string {
+	return fmt.Sprintf("本报表由%s自动生成 | 数据仅供内部使用", companyName)
+}
+
+// Validate 校验报表品牌配置
+func (b *ReportBranding) Validate() error {
+	if logoURL := strings.TrimSpace(b.LogoURL); logoURL != "" {
+		if len(logoURL) > MaxReportLogoURLLength {
+			return fmt.Errorf("报表Logo地址不能超过%d个字符", MaxReportLogoURLLength)
+		}
+		parsed, err := url.Parse(logoURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("报表Logo地址必须是 http 或 https 地址")
+		}
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(b.CompanyName)) > MaxReportCompanyNameLength {
+		return fmt.Errorf("报表公司名称不能超过%d个字符", MaxReportCompanyNameLength)
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(b.HeaderText)) > MaxReportHeaderTextLength {
+		return fmt.Errorf("报表页眉文字不能超过%d个字符", MaxReportHeaderTextLength)
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(b.FooterText)) > MaxReportFooterTextLength {
+		return fmt.Errorf("报表页脚声明不能超过%d个字符", MaxReportFooterTextLength)
+	}
+	return nil
+}
+
+// ResolveReportBranding 用租户配置中非空的字段覆盖默认品牌，未配置时传 nil
+func ResolveReportBranding(branding *ReportBranding) *ReportBranding {
+	resolved := DefaultReportBranding()
+	if branding == nil {
+		return resolved
+	}
+	if logoURL := strings.TrimSpace(branding.LogoURL); logoURL != "" {
+		resolved.LogoURL = logoURL
+	}
+	if name := strings.TrimSpace(branding.CompanyName); name != "" {
+		resolved.CompanyName = name
+		// 只配置了公司名称时以公司名称署名，避免页脚仍显示默认名称
+		resolved.FooterText = defaultReportFooterText(name)
+	}
+	if header := strings.TrimSpace(branding.HeaderText); header != "" {
+		resolved.HeaderText = header
+	}
+	if footer := strings.TrimSpace(branding.FooterText); footer != "" {
+		resolved.FooterText = footer
+	}
+	return resolved
+}
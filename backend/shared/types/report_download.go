@@ -0,0 +1,55 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrReportDownloadSignatureInvalid 报表下载链接签名无效
+	ErrReportDownloadSignatureInvalid = errors.New("下载链接签名无效")
+	// ErrReportDownloadURLExpired 报表下载链接已过期
+	ErrReportDownloadURLExpired = errors.New("下载链接已过期")
+	// ErrReportDownloadIPMismatch 下载请求的IP与链接绑定的IP不一致
+	ErrReportDownloadIPMismatch = errors.New("下载链接不能在当前网络使用")
+	// ErrReportDownloadSecretMissing 未配置报表下载链接签名密钥
+	ErrReportDownloadSecretMissing = errors.New("未配置报表下载链接签名密钥")
+)
+
+// ReportDownloadBackend 报表下载链接的交付方式
+type ReportDownloadBackend string
+
+const (
+	ReportDownloadBackendLocal ReportDownloadBackend = "local" // 由报表服务校验签名后返回文件
+	ReportDownloadBackendOSS   ReportDownloadBackend = "oss"   // 上传到对象存储，由对象存储或CDN直接响应
+)
+
+// ReportDownloadChannel 报表下载方式
+type ReportDownloadChannel string
+
+const (
+	ReportDownloadChannelDirect        ReportDownloadChannel = "direct"         // 认证后直接下载
+	ReportDownloadChannelSignedURL     ReportDownloadChannel = "signed_url"     // 通过本地签名链接下载
+	ReportDownloadChannelObjectStorage ReportDownloadChannel = "object_storage" // 签发对象存储签名链接
+)
+
+// ReportDownloadURL 报表签名下载链接
+type ReportDownloadURL struct {
+	URL       string                `json:"url"`
+	Backend   ReportDownloadBackend `json:"backend"`
+	ExpiresAt time.Time             `json:"expires_at"`
+	BoundIP   string                `json:"bound_ip,omitempty"` // 链接只能在该IP使用，未绑定时为空
+}
+
+// ReportDownloadLog 报表下载访问日志
+type ReportDownloadLog struct {
+	ID         uint64                `json:"id" db:"id"`
+	TenantID   uint64                `json:"tenant_id" db:"tenant_id"`
+	ReportID   uint64                `json:"report_id" db:"report_id"`
+	ReportUUID string                `json:"report_uuid" db:"report_uuid"`
+	Channel    ReportDownloadChannel `json:"channel" db:"channel"`
+	UserID     *uint64               `json:"user_id,omitempty" db:"user_id"`
+	ClientIP   string                `json:"client_ip" db:"client_ip"`
+	UserAgent  string                `json:"user_agent" db:"user_agent"`
+	CreatedAt  time.Time             `json:"created_at" db:"created_at"`
+}
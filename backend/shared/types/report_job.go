@@ -0,0 +1,31 @@
+package types
+
+import "time"
+
+// ReportJobAttempt 报表任务的一次执行记录
+type ReportJobAttempt struct {
+	ID           uint64    `json:"id"`
+	TenantID     uint64    `json:"tenant_id"`
+	JobID        uint64    `json:"job_id"`
+	Attempt      int       `json:"attempt"` // 第几次执行，对应任务的累计执行次数
+	Status       JobStatus `json:"status"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	Retryable    bool      `json:"retryable"` // 失败是否为可自动重试的临时错误
+	StartedAt    time.Time `json:"started_at"`
+	CompletedAt  time.Time `json:"completed_at"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ReportJobListRequest 报表任务执行记录列表请求
+type ReportJobListRequest struct {
+	TemplateID *uint64    `json:"template_id,omitempty"`
+	Status     *JobStatus `json:"status,omitempty"`
+	Limit      int        `json:"limit"`
+}
+
+// ReportJobDetail 报表任务及其每次执行记录
+type ReportJobDetail struct {
+	Job      *ReportJob          `json:"job"`
+	Attempts []*ReportJobAttempt `json:"attempts"`
+}
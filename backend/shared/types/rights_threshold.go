@@ -0,0 +1,135 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// RightsDepletionWindowDays 预计耗尽时间按最近多少天的日均消耗计算
+const RightsDepletionWindowDays = 30
+
+// RightsThresholdLevel 商户权益余额所处的预警级别
+type RightsThresholdLevel string
+
+const (
+	RightsThresholdNormal   RightsThresholdLevel = "normal"   // 高于预警阈值
+	RightsThresholdWarning  RightsThresholdLevel = "warning"  // 不高于预警阈值
+	RightsThresholdCritical RightsThresholdLevel = "critical" // 不高于紧急阈值
+)
+
+// Rank 级别的严重程度，数值越大越严重
+func (l RightsThresholdLevel) Rank() int {
+	switch l {
+	case RightsThresholdCritical:
+		return 2
+	case RightsThresholdWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// DisplayName 级别的显示名称
+func (l RightsThresholdLevel) DisplayName() string {
+	switch l {
+	case RightsThresholdCritical:
+		return "紧急阈值"
+	case RightsThresholdWarning:
+		return "预警阈值"
+	default:
+		return "正常"
+	}
+}
+
+// ThresholdLevel 按可用余额判断当前预警级别及对应的阈值，未配置阈值时为正常
+func (rb *RightsBalance) ThresholdLevel() (RightsThresholdLevel, float64) {
+	available := rb.GetAvailableBalance()
+	if rb.CriticalThreshold != nil && available <= *rb.CriticalThreshold {
+		return RightsThresholdCritical, *rb.CriticalThreshold
+	}
+	if rb.WarningThreshold != nil && available <= *rb.WarningThreshold {
+		return RightsThresholdWarning, *rb.WarningThreshold
+	}
+	return RightsThresholdNormal, 0
+}
+
+// ProjectRightsDepletion 按日均消耗预计可用余额耗尽的时间，没有消耗时返回 nil，余额已耗尽时返回 now
+func ProjectRightsDepletion(available, averageDailyUsage float64, now time.Time) *time.Time {
+	if available <= 0 {
+		return &now
+	}
+	if averageDailyUsage <= 0 {
+		return nil
+	}
+	depletion := now.AddDate(0, 0, int(math.Ceil(available/averageDailyUsage)))
+	return &depletion
+}
+
+// MerchantRightsUsage 商户权益余额及最近的日均消耗，用于阈值检查
+type MerchantRightsUsage struct {
+	TenantID          uint64         `json:"tenant_id" db:"tenant_id"`
+	MerchantID        uint64         `json:"merchant_id" db:"merchant_id"`
+	MerchantName      string         `json:"merchant_name" db:"merchant_name"`
+	RightsBalance     *RightsBalance `json:"rights_balance" db:"rights_balance"`
+	AverageDailyUsage float64        `json:"average_daily_usage" db:"average_daily_usage"`
+}
+
+// MerchantRightsThreshold 商户权益余额最近一次检查时所处的预警级别。
+// 只在级别变得更严重（越过阈值）时提醒，余额回升后级别随之降低，再次越过阈值时重新提醒
+type MerchantRightsThreshold struct {
+	TenantID             uint64               `json:"tenant_id" db:"tenant_id"`
+	MerchantID           uint64               `json:"merchant_id" db:"merchant_id"`
+	MerchantName         string               `json:"merchant_name" db:"-"`
+	Level                RightsThresholdLevel `json:"level" db:"level"`
+	Threshold            float64              `json:"threshold" db:"threshold"` // 越过的阈值，正常时为 0
+	AvailableBalance     float64              `json:"available_balance" db:"available_balance"`
+	AverageDailyUsage    float64              `json:"average_daily_usage" db:"average_daily_usage"`
+	ProjectedDepletionAt *time.Time           `json:"projected_depletion_at" db:"projected_depletion_at"`
+	CrossedAt            time.Time            `json:"crossed_at" db:"crossed_at"` // 进入当前级别的时间
+	UpdatedAt            time.Time            `json:"updated_at" db:"updated_at"`
+}
+
+// NewMerchantRightsThreshold 按商户当前余额和日均消耗计算预警级别及预计耗尽时间，未配置余额时返回 nil
+func NewMerchantRightsThreshold(usage *MerchantRightsUsage, now time.Time) *MerchantRightsThreshold {
+	if usage.RightsBalance == nil {
+		return nil
+	}
+	level, threshold := usage.RightsBalance.ThresholdLevel()
+	available := usage.RightsBalance.GetAvailableBalance()
+	return &MerchantRightsThreshold{
+		TenantID:             usage.TenantID,
+		MerchantID:           usage.MerchantID,
+		MerchantName:         usage.MerchantName,
+		Level:                level,
+		Threshold:            threshold,
+		AvailableBalance:     available,
+		AverageDailyUsage:    usage.AverageDailyUsage,
+		ProjectedDepletionAt: ProjectRightsDepletion(available, usage.AverageDailyUsage, now),
+		CrossedAt:            now,
+		UpdatedAt:            now,
+	}
+}
+
+// Crossed 与上次检查的级别相比是否越过了更严重的阈值
+func (t *MerchantRightsThreshold) Crossed(previous RightsThresholdLevel) bool {
+	return t.Level.Rank() > previous.Rank()
+}
+
+// DepletionSummary 预计耗尽时间说明
+func (t *MerchantRightsThreshold) DepletionSummary() string {
+	if t.AvailableBalance <= 0 {
+		return "可用余额已耗尽"
+	}
+	if t.ProjectedDepletionAt == nil {
+		return fmt.Sprintf("最近%d天无权益消耗，暂无法预计耗尽时间", RightsDepletionWindowDays)
+	}
+	days := int(math.Ceil(t.AvailableBalance / t.AverageDailyUsage))
+	return fmt.Sprintf("按最近%d天日均消耗 %.2f 计算，预计 %s 耗尽（约 %d 天）",
+		RightsDepletionWindowDays, t.AverageDailyUsage, t.ProjectedDepletionAt.Format("2006-01-02"), days)
+}
+
+// Summary 越过阈值的提醒内容
+func (t *MerchantRightsThreshold) Summary() string {
+	return fmt.Sprintf("权益可用余额 %.2f 已低于%s %.2f，%s", t.AvailableBalance, t.Level.DisplayName(), t.Threshold, t.DepletionSummary())
+}
@@ -0,0 +1,24 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSequenceExhausted 序号超出格式配置的位数
+var ErrSequenceExhausted = errors.New("序号已超出配置的位数")
+
+// SequenceFormat 序号格式：前缀 + 定长序号，位数为 0 时不补零
+type SequenceFormat struct {
+	Prefix string `json:"prefix"`
+	Width  int    `json:"width"`
+}
+
+// Render 生成带前缀的序号，序号超出位数时返回 ErrSequenceExhausted 而不是加宽，避免与其他前缀的编号重叠
+func (f SequenceFormat) Render(value uint64) (string, error) {
+	digits := fmt.Sprintf("%0*d", f.Width, value)
+	if f.Width > 0 && len(digits) > f.Width {
+		return "", fmt.Errorf("%w（%d 位）", ErrSequenceExhausted, f.Width)
+	}
+	return f.Prefix + digits, nil
+}
@@ -0,0 +1,267 @@
+package types
+
+import (
+	"fmt"
+	"math"
+)
+
+// TaxDecimals 税额保留的小数位数（分）
+const TaxDecimals = 2
+
+// MaxTaxRate 税率上限，税率按小数表示，如 0.13 表示 13%
+const MaxTaxRate = 1
+
+// TaxRoundingMode 税额舍入方式
+type TaxRoundingMode string
+
+const (
+	TaxRoundingHalfUp   TaxRoundingMode = "half_up"   // 四舍五入（默认）
+	TaxRoundingHalfEven TaxRoundingMode = "half_even" // 四舍六入五成双
+	TaxRoundingUp       TaxRoundingMode = "up"        // 向上取整
+	TaxRoundingDown     TaxRoundingMode = "down"      // 向下取整
+)
+
+// IsValid 检查舍入方式是否有效，为空时使用四舍五入
+func (m TaxRoundingMode) IsValid() bool {
+	switch m {
+	case "", TaxRoundingHalfUp, TaxRoundingHalfEven, TaxRoundingUp, TaxRoundingDown:
+		return true
+	}
+	return false
+}
+
+// Round 按舍入方式把金额保留 decimals 位小数
+func (m TaxRoundingMode) Round(amount float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+	// 先消除浮点误差，避免 0.125 这类金额按 0.12499999 舍入
+	scaled := math.Round(amount*scale*1e4) / 1e4
+
+	switch m {
+	case TaxRoundingHalfEven:
+		scaled = math.RoundToEven(scaled)
+	case TaxRoundingUp:
+		scaled = math.Ceil(scaled)
+	case TaxRoundingDown:
+		scaled = math.Floor(scaled)
+	default:
+		scaled = math.Round(scaled)
+	}
+	return scaled / scale
+}
+
+// TaxPolicy represents per-tenant order tax settings applied when an order is created.
+// Prices are either tax-inclusive or tax-exclusive; rates may be overridden per product
+// category and per merchant region.
+type TaxPolicy struct {
+	Enabled           bool                  `json:"enabled"`
+	Rate              float64               `json:"rate"`                          // 默认税率，如 0.13
+	Inclusive         bool                  `json:"inclusive"`                     // 商品价格是否含税
+	CategoryRates     map[uint64]float64    `json:"category_rates,omitempty"`      // 商品分类 -> 税率
+	Regions           map[string]*TaxRegion `json:"regions,omitempty"`             // 商户经营地区 -> 地区税率
+	ExemptCustomerIDs []uint64              `json:"exempt_customer_ids,omitempty"` // 免税客户
+	ExemptProductIDs  []uint64              `json:"exempt_product_ids,omitempty"`  // 免税商品
+	RoundingMode      TaxRoundingMode       `json:"rounding_mode,omitempty"`       // 税额舍入方式，为空时四舍五入
+}
+
+// TaxRegion 地区税率，配置后该地区商户的订单不再使用租户默认税率和分类税率
+type TaxRegion struct {
+	Rate          float64            `json:"rate"`
+	CategoryRates map[uint64]float64 `json:"category_rates,omitempty"`
+}
+
+// Validate 校验税费策略
+func (p *TaxPolicy) Validate() error {
+	if err := validateTaxRates("默认", p.Rate, p.CategoryRates); err != nil {
+		return err
+	}
+	for region, rates := range p.Regions {
+		if region == "" {
+			return fmt.Errorf("地区税率缺少地区代码")
+		}
+		if rates == nil {
+			return fmt.Errorf("地区 %s 缺少税率配置", region)
+		}
+		if err := validateTaxRates("地区 "+region, rates.Rate, rates.CategoryRates); err != nil {
+			return err
+		}
+	}
+	if !p.RoundingMode.IsValid() {
+		return fmt.Errorf("税额舍入方式无效: %s", p.RoundingMode)
+	}
+	return nil
+}
+
+// validateTaxRates 校验税率在 [0, MaxTaxRate] 范围内
+func validateTaxRates(scope string, rate float64, categoryRates map[uint64]float64) error {
+	if rate < 0 || rate > MaxTaxRate {
+		return fmt.Errorf("%s税率必须在0-%d之间", scope, MaxTaxRate)
+	}
+	for categoryID, categoryRate := range categoryRates {
+		if categoryRate < 0 || categoryRate > MaxTaxRate {
+			return fmt.Errorf("%s分类%d的税率必须在0-%d之间", scope, categoryID, MaxTaxRate)
+		}
+	}
+	return nil
+}
+
+// TaxItem 计税的订单商品，金额为单价乘以数量，按策略口径含税或不含税
+type TaxItem struct {
+	ProductID  uint64
+	CategoryID *uint64
+	Amount     float64
+}
+
+// OrderTax 订单税费明细，下单时按租户税费策略计算并保存在订单上
+type OrderTax struct {
+	Inclusive      bool            `json:"inclusive"`
+	RoundingMode   TaxRoundingMode `json:"rounding_mode"`
+	Region         string          `json:"region,omitempty"`
+	CustomerExempt bool            `json:"customer_exempt,omitempty"`
+	Lines          []OrderTaxLine  `json:"lines"`
+	NetAmount      float64         `json:"net_amount"`   // 不含税商品金额
+	TaxAmount      float64         `json:"tax_amount"`   // 税额
+	GrossAmount    float64         `json:"gross_amount"` // 含税金额，即订单应付金额
+}
+
+// OrderTaxLine 订单商品的税费
+type OrderTaxLine struct {
+	ProductID  uint64  `json:"product_id"`
+	CategoryID *uint64 `json:"category_id,omitempty"`
+	Rate       float64 `json:"rate"`
+	Exempt     bool    `json:"exempt,omitempty"`
+	NetAmount  float64 `json:"net_amount"`
+	TaxAmount  float64 `json:"tax_amount"`
+}
+
+// Calculate 按策略计算订单税费，逐个商品计算税额并按舍入方式取整。
+// 策略未启用时返回 nil；免税客户的订单和免税商品税率为 0
+func (p *TaxPolicy) Calculate(region string, customerID uint64, items []TaxItem) *OrderTax {
+	if p == nil || !p.Enabled {
+		return nil
+	}
+
+	tax := &OrderTax{
+		Inclusive:      p.Inclusive,
+		RoundingMode:   p.roundingMode(),
+		CustomerExempt: containsUint64(p.ExemptCustomerIDs, customerID),
+	}
+	regionRates, hasRegion := p.Regions[region]
+	if hasRegion && regionRates != nil {
+		tax.Region = region
+	}
+
+	lines := make([]OrderTaxLine, 0, len(items))
+	for _, item := range items {
+		line := OrderTaxLine{
+			ProductID:  item.ProductID,
+			CategoryID: item.CategoryID,
+			Exempt:     tax.CustomerExempt || containsUint64(p.ExemptProductIDs, item.ProductID),
+		}
+		if !line.Exempt {
+			line.Rate = p.rateFor(tax.Region, item.CategoryID)
+		}
+		lines = append(lines, line)
+	}
+	tax.apply(lines, items)
+	return tax
+}
+
+// Reprice 订单商品数量变更后按下单时确定的税率重新计算税费，不重新读取租户税费策略
+func (t *OrderTax) Reprice(items []OrderItem) *OrderTax {
+	if t == nil {
+		return nil
+	}
+
+	previous := make(map[uint64]OrderTaxLine, len(t.Lines))
+	for _, line := range t.Lines {
+		previous[line.ProductID] = line
+	}
+
+	repriced := &OrderTax{
+		Inclusive:      t.Inclusive,
+		RoundingMode:   t.RoundingMode,
+		Region:         t.Region,
+		CustomerExempt: t.CustomerExempt,
+	}
+	lines := make([]OrderTaxLine, 0, len(items))
+	taxItems := make([]TaxItem, 0, len(items))
+	for _, item := range items {
+		line := previous[item.ProductID]
+		line.ProductID = item.ProductID
+		lines = append(lines, line)
+		taxItems = append(taxItems, TaxItem{
+			ProductID:  item.ProductID,
+			CategoryID: line.CategoryID,
+			Amount:     item.Price * float64(item.Quantity),
+		})
+	}
+	repriced.apply(lines, taxItems)
+	return repriced
+}
+
+// apply 按各商品税率计算税额并汇总，lines 与 items 一一对应
+func (t *OrderTax) apply(lines []OrderTaxLine, items []TaxItem) {
+	t.Lines = lines
+	t.NetAmount, t.TaxAmount, t.GrossAmount = 0, 0, 0
+	for i := range t.Lines {
+		line := &t.Lines[i]
+		amount := items[i].Amount
+		if t.Inclusive {
+			line.TaxAmount = t.RoundingMode.Round(amount*line.Rate/(1+line.Rate), TaxDecimals)
+			line.NetAmount = TaxRoundingHalfUp.Round(amount-line.TaxAmount, TaxDecimals)
+		} else {
+			line.TaxAmount = t.RoundingMode.Round(amount*line.Rate, TaxDecimals)
+			line.NetAmount = TaxRoundingHalfUp.Round(amount, TaxDecimals)
+		}
+		t.NetAmount += line.NetAmount
+		t.TaxAmount += line.TaxAmount
+	}
+	t.NetAmount = TaxRoundingHalfUp.Round(t.NetAmount, TaxDecimals)
+	t.TaxAmount = TaxRoundingHalfUp.Round(t.TaxAmount, TaxDecimals)
+	t.GrossAmount = TaxRoundingHalfUp.Round(t.NetAmount+t.TaxAmount, TaxDecimals)
+}
+
+// rateFor 获取商品适用的税率：配置了地区税率时只使用地区的分类税率和默认税率，否则使用租户的
+func (p *TaxPolicy) rateFor(region string, categoryID *uint64) float64 {
+	rate, categoryRates := p.Rate, p.CategoryRates
+	if regionRates := p.Regions[region]; region != "" && regionRates != nil {
+		rate, categoryRates = regionRates.Rate, regionRates.CategoryRates
+	}
+	if categoryID != nil {
+		if categoryRate, ok := categoryRates[*categoryID]; ok {
+			return categoryRate
+		}
+	}
+	return rate
+}
+
+// roundingMode 获取生效的舍入方式
+func (p *TaxPolicy) roundingMode() TaxRoundingMode {
+	if p.RoundingMode == "" {
+		return TaxRoundingHalfUp
+	}
+	return p.RoundingMode
+}
+
+// ApplyTax 把税费明细计入订单金额：未计税时商品金额即订单金额
+func (o *Order) ApplyTax(tax *OrderTax) {
+	o.Tax = tax
+	if tax == nil {
+		o.SubtotalAmount = o.TotalAmount
+		o.TaxAmount = 0
+		return
+	}
+	o.SubtotalAmount = tax.NetAmount
+	o.TaxAmount = tax.TaxAmount
+	o.TotalAmount = tax.GrossAmount
+}
+
+// containsUint64 判断 ids 中是否包含 id
+func containsUint64(ids []uint64, id uint64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
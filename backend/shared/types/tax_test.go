@@ -0,0 +1,195 @@
+package types
+
+import "testing"
+
+func TestTaxRoundingModeRound(t *testing.T) {
+	tests := []struct {
+		mode   TaxRoundingMode
+		amount float64
+		want   float64
+	}{
+		{mode: "", amount: 1.125, want: 1.13},
+		{mode: TaxRoundingHalfUp, amount: 1.125, want: 1.13},
+		{mode: TaxRoundingHalfUp, amount: 1.005, want: 1.01},
+		{mode: TaxRoundingHalfEven, amount: 1.125, want: 1.12},
+		{mode: TaxRoundingHalfEven, amount: 1.135, want: 1.14},
+		{mode: TaxRoundingUp, amount: 1.121, want: 1.13},
+		{mode: TaxRoundingUp, amount: 1.12, want: 1.12},
+		{mode: TaxRoundingDown, amount: 1.129, want: 1.12},
+	}
+
+	for _, tt := range tests {
+		if got := tt.mode.Round(tt.amount, TaxDecimals); got != tt.want {
+			t.Errorf("%q.Round(%v) = %v, want %v", tt.mode, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestTaxPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  TaxPolicy
+		wantErr bool
+	}{
+		{name: "有效配置", policy: TaxPolicy{Enabled: true, Rate: 0.13, CategoryRates: map[uint64]float64{1: 0.06}, RoundingMode: TaxRoundingHalfEven}},
+		{name: "税率为负数", policy: TaxPolicy{Rate: -0.1}, wantErr: true},
+		{name: "分类税率超过上限", policy: TaxPolicy{Rate: 0.13, CategoryRates: map[uint64]float64{1: 1.5}}, wantErr: true},
+		{name: "地区缺少税率配置", policy: TaxPolicy{Regions: map[string]*TaxRegion{"HK": nil}}, wantErr: true},
+		{name: "地区税率超过上限", policy: TaxPolicy{Regions: map[string]*TaxRegion{"HK": {Rate: 2}}}, wantErr: true},
+		{name: "舍入方式无效", policy: TaxPolicy{RoundingMode: "ceiling"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTaxPolicyCalculate(t *testing.T) {
+	food := uint64(10)
+	books := uint64(20)
+	items := []TaxItem{
+		{ProductID: 1, CategoryID: &food, Amount: 200},
+		{ProductID: 2, CategoryID: &books, Amount: 100},
+		{ProductID: 3, Amount: 99.99},
+	}
+	base := TaxPolicy{
+		Enabled:       true,
+		Rate:          0.13,
+		CategoryRates: map[uint64]float64{food: 0.06, books: 0},
+	}
+
+	tests := []struct {
+		name       string
+		policy     func(p *TaxPolicy)
+		region     string
+		customerID uint64
+		wantRates  []float64
+		wantTaxes  []float64
+		wantNet    float64
+		wantTax    float64
+		wantGross  float64
+	}{
+		{
+			name:      "价格不含税",
+			wantRates: []float64{0.06, 0, 0.13},
+			wantTaxes: []float64{12, 0, 13},
+			wantNet:   399.99, wantTax: 25, wantGross: 424.99,
+		},
+		{
+			name:      "价格含税",
+			policy:    func(p *TaxPolicy) { p.Inclusive = true },
+			wantRates: []float64{0.06, 0, 0.13},
+			// 200 × 0.06 / 1.06 = 11.3207，99.99 × 0.13 / 1.13 = 11.5032
+			wantTaxes: []float64{11.32, 0, 11.50},
+			wantNet:   377.17, wantTax: 22.82, wantGross: 399.99,
+		},
+		{
+			name:      "含税价格按向下取整舍入",
+			policy:    func(p *TaxPolicy) { p.Inclusive, p.RoundingMode = true, TaxRoundingDown },
+			wantRates: []float64{0.06, 0, 0.13},
+			wantTaxes: []float64{11.32, 0, 11.50},
+			wantNet:   377.17, wantTax: 22.82, wantGross: 399.99,
+		},
+		{
+			name:      "不含税价格按向上取整舍入",
+			policy:    func(p *TaxPolicy) { p.RoundingMode = TaxRoundingUp },
+			wantRates: []float64{0.06, 0, 0.13},
+			// 99.99 × 0.13 = 12.9987
+			wantTaxes: []float64{12, 0, 13},
+			wantNet:   399.99, wantTax: 25, wantGross: 424.99,
+		},
+		{
+			name: "地区税率替代租户默认税率和分类税率",
+			policy: func(p *TaxPolicy) {
+				p.Regions = map[string]*TaxRegion{"SG": {Rate: 0.09, CategoryRates: map[uint64]float64{books: 0.05}}}
+			},
+			region:    "SG",
+			wantRates: []float64{0.09, 0.05, 0.09},
+			wantTaxes: []float64{18, 5, 9},
+			wantNet:   399.99, wantTax: 32, wantGross: 431.99,
+		},
+		{
+			name:      "未配置的地区使用租户税率",
+			policy:    func(p *TaxPolicy) { p.Regions = map[string]*TaxRegion{"SG": {Rate: 0.09}} },
+			region:    "JP",
+			wantRates: []float64{0.06, 0, 0.13},
+			wantTaxes: []float64{12, 0, 13},
+			wantNet:   399.99, wantTax: 25, wantGross: 424.99,
+		},
+		{
+			name:      "免税商品",
+			policy:    func(p *TaxPolicy) { p.ExemptProductIDs = []uint64{1} },
+			wantRates: []float64{0, 0, 0.13},
+			wantTaxes: []float64{0, 0, 13},
+			wantNet:   399.99, wantTax: 13, wantGross: 412.99,
+		},
+		{
+			name:       "免税客户",
+			policy:     func(p *TaxPolicy) { p.ExemptCustomerIDs = []uint64{100} },
+			customerID: 100,
+			wantRates:  []float64{0, 0, 0},
+			wantTaxes:  []float64{0, 0, 0},
+			wantNet:    399.99, wantTax: 0, wantGross: 399.99,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := base
+			if tt.policy != nil {
+				tt.policy(&policy)
+			}
+			tax := policy.Calculate(tt.region, tt.customerID, items)
+			for i, line := range tax.Lines {
+				if line.Rate != tt.wantRates[i] || line.TaxAmount != tt.wantTaxes[i] {
+					t.Errorf("line %d rate = %v tax = %v, want rate %v tax %v", i, line.Rate, line.TaxAmount, tt.wantRates[i], tt.wantTaxes[i])
+				}
+			}
+			if tax.NetAmount != tt.wantNet || tax.TaxAmount != tt.wantTax || tax.GrossAmount != tt.wantGross {
+				t.Errorf("net = %v tax = %v gross = %v, want %v %v %v", tax.NetAmount, tax.TaxAmount, tax.GrossAmount, tt.wantNet, tt.wantTax, tt.wantGross)
+			}
+		})
+	}
+}
+
+func TestTaxPolicyCalculateDisabled(t *testing.T) {
+	items := []TaxItem{{ProductID: 1, Amount: 100}}
+	if tax := (&TaxPolicy{Rate: 0.13}).Calculate("", 1, items); tax != nil {
+		t.Errorf("Calculate() on disabled policy = %+v, want nil", tax)
+	}
+	var policy *TaxPolicy
+	if tax := policy.Calculate("", 1, items); tax != nil {
+		t.Errorf("Calculate() on nil policy = %+v, want nil", tax)
+	}
+}
+
+func TestOrderTaxReprice(t *testing.T) {
+	food := uint64(10)
+	policy := &TaxPolicy{Enabled: true, Rate: 0.13, CategoryRates: map[uint64]float64{food: 0.06}}
+	tax := policy.Calculate("", 1, []TaxItem{
+		{ProductID: 1, CategoryID: &food, Amount: 200},
+		{ProductID: 2, Amount: 100},
+	})
+
+	// 商品1数量减为1件，商品2移除，税率沿用下单时的分类税率
+	order := &Order{TotalAmount: 100}
+	order.ApplyTax(tax.Reprice([]OrderItem{{ProductID: 1, Quantity: 1, Price: 100}}))
+
+	if len(order.Tax.Lines) != 1 || order.Tax.Lines[0].Rate != 0.06 {
+		t.Fatalf("Lines = %+v, want one line at rate 0.06", order.Tax.Lines)
+	}
+	if order.SubtotalAmount != 100 || order.TaxAmount != 6 || order.TotalAmount != 106 {
+		t.Errorf("subtotal = %v tax = %v total = %v, want 100 6 106", order.SubtotalAmount, order.TaxAmount, order.TotalAmount)
+	}
+
+	// 未计税的订单不含税金额即订单金额
+	untaxed := &Order{TotalAmount: 80}
+	untaxed.ApplyTax((*OrderTax)(nil).Reprice(nil))
+	if untaxed.SubtotalAmount != 80 || untaxed.TaxAmount != 0 || untaxed.Tax != nil {
+		t.Errorf("untaxed order = %+v", untaxed)
+	}
+}
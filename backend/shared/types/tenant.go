@@ -31,10 +31,87 @@ type Tenant struct {
 
 // TenantConfig represents tenant configuration (will be JSON marshaled)
 type TenantConfig struct {
-	MaxUsers     int               `json:"max_users"`
-	MaxMerchants int               `json:"max_merchants"`
-	Features     []string          `json:"features"`
-	Settings     map[string]string `json:"settings"`
+	MaxUsers             int                      `json:"max_users"`
+	MaxMerchants         int                      `json:"max_merchants"`
+	Features             []string                 `json:"features"`
+	Settings             map[string]string        `json:"settings"`
+	Plan                 string                   `json:"plan,omitempty"`                  // 订阅套餐，如 basic、premium，为空时使用默认限制
+	Session              *SessionPolicy           `json:"session,omitempty"`               // 会话策略，为空时使用系统默认值
+	Captcha              *CaptchaPolicy           `json:"captcha,omitempty"`               // 登录验证码策略，为空时不启用
+	Masking              *DataMaskingPolicy       `json:"masking,omitempty"`               // 日志脱敏策略，为空时使用全局策略
+	QuietHours           *QuietHoursPolicy        `json:"quiet_hours,omitempty"`           // 通知免打扰时段，为空时不限制
+	AuditSampling        *AuditSamplingPolicy     `json:"audit_sampling,omitempty"`        // 审计事件采样策略，为空时使用全局策略
+	AuditAlerts          *AuditAlertPolicy        `json:"audit_alerts,omitempty"`          // 审计事件告警规则，叠加在全局规则之上
+	MoneyFormat          *MoneyFormatPolicy       `json:"money_format,omitempty"`          // 通知和报表的金额显示格式，为空时使用人民币格式
+	PasswordPolicy       *PasswordPolicy          `json:"password_policy,omitempty"`       // 密码策略，为空时只要求默认最小长度
+	OrderReview          *OrderReviewPolicy       `json:"order_review,omitempty"`          // 下单风险审核规则，为空时不审核
+	NotificationBranding *NotificationBranding    `json:"notification_branding,omitempty"` // 客户订单通知的默认品牌，商户未配置时使用
+	DataRetention        *DataRetentionPolicy     `json:"data_retention,omitempty"`        // 客户个人信息保留策略，为空时不自动匿名化
+	Tax                  *TaxPolicy               `json:"tax,omitempty"`                   // 订单税费策略，为空时不计税
+	OrderStatusLabels    *OrderStatusLabelPolicy  `json:"order_status_labels,omitempty"`   // 面向客户的订单状态显示，为空时使用内置中文名称
+	Invoice              *InvoicePolicy           `json:"invoice,omitempty"`               // 订单发票策略，为空时启用发票并使用默认发票号格式
+	ReportBranding       *ReportBranding          `json:"report_branding,omitempty"`       // 报表品牌（Logo、公司名称、页眉页脚），为空时使用默认品牌
+	RightsRefund         *OrderRightsRefundPolicy `json:"rights_refund,omitempty"`         // 订单取消退款时退还权益的策略，为空时按退款比例退还
+	AnalyticsExport      *AnalyticsExportPolicy   `json:"analytics_export,omitempty"`      // 分析数据定时导出到对象存储，为空时不导出
+	Quota                *TenantQuotaPolicy       `json:"quota,omitempty"`                 // 用户数、商户数配额的软限制，为空时用量达到配额的80%即提醒
+	Settlement           *SettlementPolicy        `json:"settlement,omitempty"`            // 商户结算的平台手续费规则，为空时不收取手续费
+}
+
+// SessionPolicy represents per-tenant token lifetime and sliding session settings.
+// Zero values fall back to the system defaults.
+type SessionPolicy struct {
+	AccessTokenTTL  int64 `json:"access_token_ttl"`  // 访问令牌有效期（秒）
+	RefreshTokenTTL int64 `json:"refresh_token_ttl"` // 刷新令牌有效期（秒）
+	SlidingEnabled  bool  `json:"sliding_enabled"`   // 是否启用滑动会话
+	SlidingWindow   int64 `json:"sliding_window"`    // 滑动窗口（秒），刷新令牌剩余有效期低于该值时的活动会自动续期
+	AbsoluteMaxTTL  int64 `json:"absolute_max_ttl"`  // 会话绝对最长有效期（秒），从登录时刻起计算
+}
+
+// CaptchaPolicy represents per-tenant login CAPTCHA enforcement settings.
+type CaptchaPolicy struct {
+	Enabled           bool     `json:"enabled"`
+	Provider          string   `json:"provider"`           // hcaptcha, recaptcha, local
+	IPThreshold       int      `json:"ip_threshold"`       // 同一IP登录失败次数阈值
+	UsernameThreshold int      `json:"username_threshold"` // 同一用户名登录失败次数阈值
+	WindowSeconds     int64    `json:"window_seconds"`     // 失败次数统计窗口（秒）
+	TrustedIPs        []string `json:"trusted_ips"`        // 免验证的IP或CIDR网段
+}
+
+// DataMaskingPolicy represents per-tenant masking of sensitive fields in logs and audit details.
+// Fields are merged over the global policy; the rule "none" disables masking for a field.
+// Fields the global policy masks in full (passwords, tokens, secrets) cannot be weakened.
+type DataMaskingPolicy struct {
+	MaskAmounts *bool             `json:"mask_amounts,omitempty"` // 是否脱敏金额字段，为空时沿用全局策略
+	Fields      map[string]string `json:"fields,omitempty"`       // 字段名 -> 脱敏规则（phone, email, amount, full, none）
+}
+
+// AuditSamplingPolicy represents per-tenant sampling of high-volume info audit events.
+// Rates are merged over the global policy; a rate of 1 records every event of that type.
+type AuditSamplingPolicy struct {
+	Rates map[string]int `json:"rates,omitempty"` // 事件类型 -> 采样率，N 表示每 N 条记录 1 条
+}
+
+// AuditAlertPolicy represents per-tenant audit alerting rules.
+// A tenant rule replaces the global rule with the same event type and minimum severity.
+type AuditAlertPolicy struct {
+	Rules []AuditAlertRule `json:"rules,omitempty"`
+}
+
+// AuditAlertRule represents an alert fired when matching audit events reach a threshold within a window.
+type AuditAlertRule struct {
+	EventType     string `json:"event_type,omitempty"`   // 事件类型，为空时匹配所有事件类型
+	MinSeverity   string `json:"min_severity,omitempty"` // 最低严重程度（info, warning, error, critical），为空时不限
+	Threshold     int    `json:"threshold"`              // 窗口内匹配事件数达到该值时告警，小于等于1时每条事件都告警
+	WindowSeconds int    `json:"window_seconds"`         // 统计窗口（秒）
+}
+
+// QuietHoursPolicy represents per-tenant quiet hours for non-urgent SMS and email notifications.
+// Start and End use HH:MM in the policy timezone; a window with Start after End spans midnight.
+type QuietHoursPolicy struct {
+	Enabled  bool   `json:"enabled"`
+	Start    string `json:"start"`    // 免打扰开始时间，如 22:00
+	End      string `json:"end"`      // 免打扰结束时间，如 08:00
+	Timezone string `json:"timezone"` // 租户默认时区，如 Asia/Shanghai，为空时使用服务器时区
 }
 
 // CreateTenantRequest represents the request payload for tenant registration
@@ -46,6 +123,9 @@ type CreateTenantRequest struct {
 	ContactEmail  string `json:"contact_email" v:"required|email#联系邮箱不能为空|邮箱格式不正确"`
 	ContactPhone  string `json:"contact_phone" v:"phone#联系电话格式不正确"`
 	Address       string `json:"address"`
+	// 初始管理员账号，用户名默认为 admin，密码为空时由系统生成
+	AdminUsername string `json:"admin_username" v:"length:3,50#管理员用户名长度为3-50个字符"`
+	AdminPassword string `json:"admin_password"`
 }
 
 // UpdateTenantRequest represents the request payload for tenant updates
@@ -88,6 +168,8 @@ type TenantResponse struct {
 	ActivationTime   *time.Time `json:"activation_time"`
 	CreatedAt        time.Time  `json:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at"`
+	// 创建租户时的开通结果
+	Provisioning *TenantProvisionResult `json:"provisioning,omitempty"`
 }
 
 // ListTenantsResponse represents the response for tenant list
@@ -96,4 +178,4 @@ type ListTenantsResponse struct {
 	Page    int              `json:"page"`
 	Size    int              `json:"size"`
 	Tenants []TenantResponse `json:"tenants"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,194 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// TenantConfigBundleFormat 租户配置包格式标识
+	TenantConfigBundleFormat = "mer-sys.tenant-config"
+	// TenantConfigBundleSchemaVersion 当前支持的配置包结构版本
+	TenantConfigBundleSchemaVersion = 1
+	// TenantConfigRedactedValue 导出时替换密钥配置取值的占位符，导入时沿用目标租户的现有取值
+	TenantConfigRedactedValue = "[REDACTED]"
+)
+
+// 租户配置版本来源
+const (
+	TenantConfigVersionSourceUpdate = "update" // 通过配置接口更新
+	TenantConfigVersionSourceImport = "import" // 通过导入配置包更新
+)
+
+// TenantConfigBundle 可移植的租户配置包，用于在环境间迁移配置（如预发到生产）或备份配置。
+// 密钥配置的取值在导出时替换为占位符，Redacted 列出被替换的键名
+type TenantConfigBundle struct {
+	Format              string          `json:"format"`
+	SchemaVersion       int             `json:"schema_version"`
+	ExportedAt          time.Time       `json:"exported_at"`
+	SourceTenantCode    string          `json:"source_tenant_code,omitempty"`
+	SourceConfigVersion int             `json:"source_config_version,omitempty"`
+	Config              json.RawMessage `json:"config"`
+	Redacted            []string        `json:"redacted,omitempty"`
+}
+
+// TenantConfigImportIssue 配置包与目标环境不兼容的问题，Key 为配置键路径或包字段名
+type TenantConfigImportIssue struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// TenantConfigImportReport 配置包导入结果：存在问题时不应用，Changes 为应用后相对当前配置的变更
+type TenantConfigImportReport struct {
+	Valid   bool                      `json:"valid"`
+	Applied bool                      `json:"applied"`
+	DryRun  bool                      `json:"dry_run,omitempty"`
+	Version int                       `json:"version,omitempty"` // 应用后的配置版本
+	Issues  []TenantConfigImportIssue `json:"issues,omitempty"`
+	Changes []TenantConfigChange      `json:"changes,omitempty"`
+}
+
+// TenantConfigVersion 租户配置的历史版本，每次更新或导入配置生成一个递增的版本
+type TenantConfigVersion struct {
+	ID        uint64    `json:"id" db:"id"`
+	TenantID  uint64    `json:"tenant_id" db:"tenant_id"`
+	Version   int       `json:"version" db:"version"`
+	Config    string    `json:"config" db:"config"`
+	Source    string    `json:"source" db:"source"`
+	CreatedBy uint64    `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewTenantConfigBundle 导出租户配置包，密钥配置的取值替换为占位符
+func NewTenantConfigBundle(config *TenantConfig, sourceTenantCode string, sourceVersion int, exportedAt time.Time) (*TenantConfigBundle, error) {
+	if config == nil {
+		config = &TenantConfig{}
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("序列化租户配置失败: %v", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析租户配置失败: %v", err)
+	}
+
+	var redacted []string
+	redactConfigSecrets("", raw, &redacted)
+	sort.Strings(redacted)
+
+	redactedConfig, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("序列化租户配置失败: %v", err)
+	}
+	return &TenantConfigBundle{
+		Format:              TenantConfigBundleFormat,
+		SchemaVersion:       TenantConfigBundleSchemaVersion,
+		ExportedAt:          exportedAt,
+		SourceTenantCode:    sourceTenantCode,
+		SourceConfigVersion: sourceVersion,
+		Config:              redactedConfig,
+		Redacted:            redacted,
+	}, nil
+}
+
+// Decode 按配置包结构校验并解析配置，current 为目标租户的当前配置，用于填回被脱敏的密钥。
+// 返回的问题不为空时配置包不能应用；各配置策略的取值校验由调用方完成
+func (b *TenantConfigBundle) Decode(current *TenantConfig) (*TenantConfig, []TenantConfigImportIssue) {
+	var issues []TenantConfigImportIssue
+	if b.Format != TenantConfigBundleFormat {
+		issues = append(issues, TenantConfigImportIssue{Key: "format", Message: fmt.Sprintf("不支持的配置包格式 %q", b.Format)})
+	}
+	if b.SchemaVersion < 1 || b.SchemaVersion > TenantConfigBundleSchemaVersion {
+		issues = append(issues, TenantConfigImportIssue{
+			Key:     "schema_version",
+			Message: fmt.Sprintf("不支持的配置包结构版本 %d，当前支持 %d", b.SchemaVersion, TenantConfigBundleSchemaVersion),
+		})
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b.Config, &raw); err != nil || raw == nil {
+		return nil, append(issues, TenantConfigImportIssue{Key: "config", Message: "配置必须是 JSON 对象"})
+	}
+
+	var sections []string
+	for section := range raw {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	for _, section := range sections {
+		if !IsTenantConfigSection(section) {
+			issues = append(issues, TenantConfigImportIssue{Key: section, Message: "目标环境不支持该配置分区"})
+		}
+	}
+
+	currentValues, err := flattenTenantConfig(current)
+	if err != nil {
+		return nil, append(issues, TenantConfigImportIssue{Key: "config", Message: err.Error()})
+	}
+	issues = append(issues, restoreConfigSecrets("", raw, currentValues)...)
+	if len(issues) > 0 {
+		return nil, issues
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, []TenantConfigImportIssue{{Key: "config", Message: fmt.Sprintf("序列化配置失败: %v", err)}}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var config TenantConfig
+	if err := decoder.Decode(&config); err != nil {
+		return nil, []TenantConfigImportIssue{{Key: "config", Message: fmt.Sprintf("配置与目标环境的结构不兼容: %v", err)}}
+	}
+	return &config, nil
+}
+
+// redactConfigSecrets 将密钥配置的取值替换为占位符，记录被替换的键路径
+func redactConfigSecrets(prefix string, object map[string]interface{}, redacted *[]string) {
+	for key, value := range object {
+		path := joinConfigPath(prefix, key)
+		if child, ok := value.(map[string]interface{}); ok {
+			redactConfigSecrets(path, child, redacted)
+			continue
+		}
+		if value != nil && isTenantConfigSecret(path) {
+			object[key] = TenantConfigRedactedValue
+			*redacted = append(*redacted, path)
+		}
+	}
+}
+
+// restoreConfigSecrets 将占位符替换为目标租户的现有取值，目标租户没有该配置时报告问题
+func restoreConfigSecrets(prefix string, object map[string]interface{}, current map[string]interface{}) []TenantConfigImportIssue {
+	var issues []TenantConfigImportIssue
+	for key, value := range object {
+		path := joinConfigPath(prefix, key)
+		if child, ok := value.(map[string]interface{}); ok {
+			issues = append(issues, restoreConfigSecrets(path, child, current)...)
+			continue
+		}
+		if value != TenantConfigRedactedValue {
+			continue
+		}
+		if existing, ok := current[path]; ok {
+			object[key] = existing
+		} else {
+			issues = append(issues, TenantConfigImportIssue{Key: path, Message: "密钥配置已脱敏，目标租户没有可沿用的取值，请填写后再导入"})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Key < issues[j].Key
+	})
+	return issues
+}
+
+func joinConfigPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
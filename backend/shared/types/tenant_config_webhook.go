@@ -0,0 +1,198 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TenantConfigSections 可订阅的租户配置分区，与 TenantConfig 的顶层 JSON 字段一致
+var TenantConfigSections = []string{
+	"max_users",
+	"max_merchants",
+	"features",
+	"settings",
+	"plan",
+	"session",
+	"captcha",
+	"masking",
+	"quiet_hours",
+	"audit_sampling",
+	"money_format",
+	"password_policy",
+	"order_review",
+	"notification_branding",
+	"data_retention",
+	"tax",
+	"order_status_labels",
+}
+
+// 配置键名（路径最后一段）以以下片段结尾时视为密钥，变更推送中不包含其取值；
+// 按结尾匹配避免 access_token_ttl 这类普通配置被误判
+var tenantConfigSecretSuffixes = []string{"secret", "password", "token", "api_key", "private_key", "secret_key", "credential", "credentials"}
+
+// IsTenantConfigSection 是否为可订阅的配置分区
+func IsTenantConfigSection(section string) bool {
+	for _, s := range TenantConfigSections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantConfigChange 单个配置键的变更，键名为以点分隔的路径，如 settings.currency、session.access_token_ttl
+type TenantConfigChange struct {
+	Key      string      `json:"key"`
+	Section  string      `json:"section"`
+	OldValue interface{} `json:"old_value,omitempty"`
+	NewValue interface{} `json:"new_value,omitempty"`
+	Redacted bool        `json:"redacted,omitempty"` // 密钥类配置只推送键名，不推送取值
+}
+
+// DiffTenantConfig 比较新旧配置，返回按键名排序的变更列表；为空的配置按零值配置比较
+func DiffTenantConfig(old, new *TenantConfig) ([]TenantConfigChange, error) {
+	oldValues, err := flattenTenantConfig(old)
+	if err != nil {
+		return nil, err
+	}
+	newValues, err := flattenTenantConfig(new)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, len(oldValues)+len(newValues))
+	for key := range oldValues {
+		keys[key] = true
+	}
+	for key := range newValues {
+		keys[key] = true
+	}
+
+	var changes []TenantConfigChange
+	for key := range keys {
+		oldValue, newValue := oldValues[key], newValues[key]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		change := TenantConfigChange{
+			Key:     key,
+			Section: strings.SplitN(key, ".", 2)[0],
+		}
+		if isTenantConfigSecret(key) {
+			change.Redacted = true
+		} else {
+			change.OldValue = oldValue
+			change.NewValue = newValue
+		}
+		changes = append(changes, change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
+
+// FilterTenantConfigChanges 只保留订阅分区内的变更，sections 为空时保留全部
+func FilterTenantConfigChanges(changes []TenantConfigChange, sections []string) []TenantConfigChange {
+	if len(sections) == 0 {
+		return changes
+	}
+
+	subscribed := make(map[string]bool, len(sections))
+	for _, section := range sections {
+		subscribed[section] = true
+	}
+
+	var result []TenantConfigChange
+	for _, change := range changes {
+		if subscribed[change.Section] {
+			result = append(result, change)
+		}
+	}
+	return result
+}
+
+// TenantConfigWebhookPayload 租户配置变更推送内容，只包含 Webhook 订阅分区内的变更
+type TenantConfigWebhookPayload struct {
+	ID         string               `json:"id"`
+	Event      OrderWebhookEvent    `json:"event"`
+	TenantID   uint64               `json:"tenant_id"`
+	OccurredAt time.Time            `json:"occurred_at"`
+	Sections   []string             `json:"sections"`
+	Changes    []TenantConfigChange `json:"changes"`
+}
+
+// NewTenantConfigWebhookPayload 生成配置变更推送内容，Sections 为变更涉及的分区
+func NewTenantConfigWebhookPayload(id string, tenantID uint64, occurredAt time.Time, changes []TenantConfigChange) *TenantConfigWebhookPayload {
+	var sections []string
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		if !seen[change.Section] {
+			seen[change.Section] = true
+			sections = append(sections, change.Section)
+		}
+	}
+
+	return &TenantConfigWebhookPayload{
+		ID:         id,
+		Event:      OrderWebhookEventTenantConfigChanged,
+		TenantID:   tenantID,
+		OccurredAt: occurredAt,
+		Sections:   sections,
+		Changes:    changes,
+	}
+}
+
+// flattenTenantConfig 将配置展开为 路径 -> 取值，嵌套对象按点分隔展开，数组整体作为一个值
+func flattenTenantConfig(config *TenantConfig) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if config == nil {
+		config = &TenantConfig{}
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("序列化租户配置失败: %v", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析租户配置失败: %v", err)
+	}
+
+	flattenConfigValue("", raw, values)
+	return values, nil
+}
+
+func flattenConfigValue(prefix string, value interface{}, values map[string]interface{}) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		if value != nil {
+			values[prefix] = value
+		}
+		return
+	}
+
+	for key, child := range object {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		flattenConfigValue(path, child, values)
+	}
+}
+
+func isTenantConfigSecret(key string) bool {
+	name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	for _, suffix := range tenantConfigSecretSuffixes {
+		if name == suffix || strings.HasSuffix(name, "_"+suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffTenantConfig(t *testing.T) {
+	oldConfig := &TenantConfig{
+		MaxUsers: 10,
+		Features: []string{"orders"},
+		Settings: map[string]string{"currency": "CNY", "sms_api_key": "old-key", "theme": "light"},
+		Session:  &SessionPolicy{AccessTokenTTL: 3600},
+	}
+
+	tests := []struct {
+		name      string
+		old       *TenantConfig
+		new       *TenantConfig
+		want      []TenantConfigChange
+		wantEmpty bool
+	}{
+		{
+			name:      "配置未变化",
+			old:       oldConfig,
+			new:       oldConfig,
+			wantEmpty: true,
+		},
+		{
+			name: "展开嵌套配置并按键名排序",
+			old:  oldConfig,
+			new: &TenantConfig{
+				MaxUsers: 20,
+				Features: []string{"orders", "reports"},
+				Settings: map[string]string{"currency": "USD", "sms_api_key": "new-key"},
+				Session:  &SessionPolicy{AccessTokenTTL: 7200},
+			},
+			want: []TenantConfigChange{
+				{Key: "features", Section: "features", OldValue: []interface{}{"orders"}, NewValue: []interface{}{"orders", "reports"}},
+				{Key: "max_users", Section: "max_users", OldValue: float64(10), NewValue: float64(20)},
+				{Key: "session.access_token_ttl", Section: "session", OldValue: float64(3600), NewValue: float64(7200)},
+				{Key: "settings.currency", Section: "settings", OldValue: "CNY", NewValue: "USD"},
+				{Key: "settings.sms_api_key", Section: "settings", Redacted: true},
+				{Key: "settings.theme", Section: "settings", OldValue: "light"},
+			},
+		},
+		{
+			name: "旧配置为空时全部视为新增",
+			old:  nil,
+			new:  &TenantConfig{Plan: "premium"},
+			want: []TenantConfigChange{
+				{Key: "plan", Section: "plan", NewValue: "premium"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffTenantConfig(tt.old, tt.new)
+			if err != nil {
+				t.Fatalf("DiffTenantConfig() error = %v", err)
+			}
+			if tt.wantEmpty {
+				if len(got) != 0 {
+					t.Errorf("DiffTenantConfig() = %v, want empty", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffTenantConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterTenantConfigChanges(t *testing.T) {
+	changes := []TenantConfigChange{
+		{Key: "max_users", Section: "max_users"},
+		{Key: "settings.currency", Section: "settings"},
+		{Key: "session.access_token_ttl", Section: "session"},
+	}
+
+	tests := []struct {
+		name     string
+		sections []string
+		want     []string
+	}{
+		{name: "未指定分区时保留全部", sections: nil, want: []string{"max_users", "settings.currency", "session.access_token_ttl"}},
+		{name: "只保留订阅分区", sections: []string{"settings", "session"}, want: []string{"settings.currency", "session.access_token_ttl"}},
+		{name: "订阅分区没有变更", sections: []string{"captcha"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, change := range FilterTenantConfigChanges(changes, tt.sections) {
+				got = append(got, change.Key)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterTenantConfigChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderWebhookRequestConfigSections(t *testing.T) {
+	tests := []struct {
+		name     string
+		sections []string
+		wantErr  bool
+	}{
+		{name: "不限制分区", sections: nil},
+		{name: "订阅有效分区", sections: []string{"settings", "quiet_hours"}},
+		{name: "无效分区", sections: []string{"settings", "billing"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &OrderWebhookRequest{
+				URL:            "https://example.com/hooks",
+				Events:         []OrderWebhookEvent{OrderWebhookEventTenantConfigChanged},
+				ConfigSections: tt.sections,
+			}
+			if err := req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package types
+
+// TenantProvisionStep 租户开通步骤
+type TenantProvisionStep string
+
+const (
+	TenantProvisionStepConfig        TenantProvisionStep = "default_config"         // 租户默认配置
+	TenantProvisionStepTimeoutConfig TenantProvisionStep = "default_timeout_config" // 租户默认订单超时配置
+	TenantProvisionStepAdminUser     TenantProvisionStep = "admin_user"             // 初始管理员账号
+	TenantProvisionStepAdminRole     TenantProvisionStep = "admin_role"             // 初始管理员的租户管理员角色
+)
+
+// DefaultTenantAdminUsername 初始管理员默认用户名
+const DefaultTenantAdminUsername = "admin"
+
+// ProvisionTenantRequest 租户开通请求，重新开通时只补齐缺失的默认项
+type ProvisionTenantRequest struct {
+	AdminUsername string `json:"admin_username" v:"length:3,50#管理员用户名长度为3-50个字符"`
+	AdminEmail    string `json:"admin_email" v:"email#邮箱格式不正确"` // 为空时使用租户联系邮箱
+	AdminPassword string `json:"admin_password"`                // 为空时由系统生成，仅在新建管理员时使用
+}
+
+// TenantProvisionResult 租户开通结果
+type TenantProvisionResult struct {
+	TenantID      uint64                `json:"tenant_id"`
+	Created       []TenantProvisionStep `json:"created"`  // 本次补齐的默认项
+	Existing      []TenantProvisionStep `json:"existing"` // 已存在而跳过的默认项
+	AdminUserID   uint64                `json:"admin_user_id"`
+	AdminUsername string                `json:"admin_username"`
+	// 系统生成的初始密码，仅在新建管理员时返回一次
+	InitialPassword string `json:"initial_password,omitempty"`
+}
+
+// Record 记录开通步骤的执行结果
+func (r *TenantProvisionResult) Record(step TenantProvisionStep, created bool) {
+	if created {
+		r.Created = append(r.Created, step)
+	} else {
+		r.Existing = append(r.Existing, step)
+	}
+}
+
+// DefaultTenantConfig 新租户默认配置
+func DefaultTenantConfig() *TenantConfig {
+	return &TenantConfig{
+		MaxUsers:     100,
+		MaxMerchants: 50,
+		Features:     []string{"basic"},
+		Settings:     map[string]string{},
+	}
+}
+
+// DefaultTenantTimeoutConfig 新租户默认订单超时配置
+func DefaultTenantTimeoutConfig(tenantID uint64) *OrderTimeoutConfig {
+	return &OrderTimeoutConfig{
+		TenantID:               tenantID,
+		PaymentTimeoutMinutes:  30,
+		ProcessingTimeoutHours: 24,
+		AutoCompleteEnabled:    false,
+		AutoCompleteAfterHours: DefaultAutoCompleteAfterHours,
+	}
+}
@@ -0,0 +1,212 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrTenantQuotaExceeded 租户配额已用完，不能继续创建
+var ErrTenantQuotaExceeded = errors.New("租户配额已用完")
+
+const (
+	// DefaultQuotaSoftLimitPercent 未配置时用量达到配额的该百分比即提醒租户
+	DefaultQuotaSoftLimitPercent = 80
+	// TaskTypeQuotaWarning 租户配额即将用完或已用完的待处理事项
+	TaskTypeQuotaWarning TaskType = "quota_warning"
+)
+
+// TenantQuotaResource 受租户配额限制的资源
+type TenantQuotaResource string
+
+const (
+	TenantQuotaUsers     TenantQuotaResource = "users"     // 用户数，对应 max_users
+	TenantQuotaMerchants TenantQuotaResource = "merchants" // 商户数，对应 max_merchants
+)
+
+// TenantQuotaResources 所有受配额限制的资源
+var TenantQuotaResources = []TenantQuotaResource{TenantQuotaUsers, TenantQuotaMerchants}
+
+// DisplayName 资源的显示名称
+func (r TenantQuotaResource) DisplayName() string {
+	switch r {
+	case TenantQuotaUsers:
+		return "用户数"
+	case TenantQuotaMerchants:
+		return "商户数"
+	default:
+		return string(r)
+	}
+}
+
+// QuotaLimit 资源的配额（硬限制），为0时不限制
+func (c *TenantConfig) QuotaLimit(resource TenantQuotaResource) int {
+	switch resource {
+	case TenantQuotaUsers:
+		return c.MaxUsers
+	case TenantQuotaMerchants:
+		return c.MaxMerchants
+	default:
+		return 0
+	}
+}
+
+// TenantQuotaPolicy 租户配额软限制：用量达到配额的一定百分比时提醒租户，配额本身仍是硬限制
+type TenantQuotaPolicy struct {
+	SoftLimitPercent int                         `json:"soft_limit_percent"`  // 软限制百分比，为0时为80
+	Resources        map[TenantQuotaResource]int `json:"resources,omitempty"` // 按资源覆盖软限制百分比
+}
+
+// Validate 校验配额软限制配置
+func (p *TenantQuotaPolicy) Validate() error {
+	if p.SoftLimitPercent < 0 || p.SoftLimitPercent > 99 {
+		return fmt.Errorf("配额软限制百分比必须在1到99之间")
+	}
+	for resource, percent := range p.Resources {
+		if !isTenantQuotaResource(resource) {
+			return fmt.Errorf("不支持的配额资源: %s", resource)
+		}
+		if percent < 1 || percent > 99 {
+			return fmt.Errorf("%s配额软限制百分比必须在1到99之间", resource.DisplayName())
+		}
+	}
+	return nil
+}
+
+// SoftLimitPercentFor 资源生效的软限制百分比，未配置时为默认值
+func (p *TenantQuotaPolicy) SoftLimitPercentFor(resource TenantQuotaResource) int {
+	if p == nil {
+		return DefaultQuotaSoftLimitPercent
+	}
+	if percent := p.Resources[resource]; percent > 0 {
+		return percent
+	}
+	if p.SoftLimitPercent > 0 {
+		return p.SoftLimitPercent
+	}
+	return DefaultQuotaSoftLimitPercent
+}
+
+// isTenantQuotaResource 是否为受配额限制的资源
+func isTenantQuotaResource(resource TenantQuotaResource) bool {
+	for _, supported := range TenantQuotaResources {
+		if resource == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantQuotaLevel 租户资源用量所处的级别
+type TenantQuotaLevel string
+
+const (
+	TenantQuotaNormal   TenantQuotaLevel = "normal"   // 低于软限制
+	TenantQuotaWarning  TenantQuotaLevel = "warning"  // 达到软限制，仍可继续创建
+	TenantQuotaExceeded TenantQuotaLevel = "exceeded" // 达到配额，不能继续创建
+)
+
+// Rank 级别的严重程度，数值越大越严重
+func (l TenantQuotaLevel) Rank() int {
+	switch l {
+	case TenantQuotaExceeded:
+		return 2
+	case TenantQuotaWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// TenantQuotaUsage 租户资源的当前用量与配额
+type TenantQuotaUsage struct {
+	Resource         TenantQuotaResource `json:"resource"`
+	Used             int                 `json:"used"`
+	Limit            int                 `json:"limit"`              // 配额，为0时不限制
+	Remaining        int                 `json:"remaining"`          // 剩余可创建数量，不限制时为 -1
+	Percentage       float64             `json:"percentage"`         // 用量占配额的百分比，保留两位小数
+	SoftLimitPercent int                 `json:"soft_limit_percent"` // 软限制百分比
+	SoftLimit        int                 `json:"soft_limit"`         // 达到该用量时提醒
+	Level            TenantQuotaLevel    `json:"level"`
+}
+
+// NewTenantQuotaUsage 按用量、配额和软限制百分比计算用量级别
+func NewTenantQuotaUsage(resource TenantQuotaResource, used, limit, softLimitPercent int) *TenantQuotaUsage {
+	usage := &TenantQuotaUsage{
+		Resource:         resource,
+		Used:             used,
+		Limit:            limit,
+		Remaining:        -1,
+		SoftLimitPercent: softLimitPercent,
+		Level:            TenantQuotaNormal,
+	}
+	if limit <= 0 {
+		return usage
+	}
+
+	usage.Remaining = limit - used
+	if usage.Remaining < 0 {
+		usage.Remaining = 0
+	}
+	usage.Percentage = math.Round(float64(used)*10000/float64(limit)) / 100
+	usage.SoftLimit = int(math.Ceil(float64(limit) * float64(softLimitPercent) / 100))
+	switch {
+	case used >= limit:
+		usage.Level = TenantQuotaExceeded
+	case used >= usage.SoftLimit:
+		usage.Level = TenantQuotaWarning
+	}
+	return usage
+}
+
+// Allows 配额是否允许再创建 count 个资源
+func (u *TenantQuotaUsage) Allows(count int) bool {
+	return u.Limit <= 0 || u.Used+count <= u.Limit
+}
+
+// Summary 用量说明
+func (u *TenantQuotaUsage) Summary() string {
+	if u.Level == TenantQuotaExceeded {
+		return fmt.Sprintf("%s已达到配额上限 %d，无法继续创建，请联系平台提升配额", u.Resource.DisplayName(), u.Limit)
+	}
+	return fmt.Sprintf("%s已使用 %d/%d（%.2f%%），达到%d%%的提醒线，剩余 %d",
+		u.Resource.DisplayName(), u.Used, u.Limit, u.Percentage, u.SoftLimitPercent, u.Remaining)
+}
+
+// PendingTask 达到软限制时生成的待处理事项，配额已用完时为紧急，低于软限制时返回 nil
+func (u *TenantQuotaUsage) PendingTask() *PendingTask {
+	if u.Level == TenantQuotaNormal {
+		return nil
+	}
+	priority := PriorityHigh
+	if u.Level == TenantQuotaExceeded {
+		priority = PriorityUrgent
+	}
+	return &PendingTask{
+		ID:          fmt.Sprintf("%s_%s", TaskTypeQuotaWarning, u.Resource),
+		Type:        TaskTypeQuotaWarning,
+		Description: u.Summary(),
+		Priority:    priority,
+		Count:       1,
+	}
+}
+
+// TenantQuotaWarningState 租户资源最近一次检查时所处的用量级别。
+// 只在级别变得更严重时提醒租户，用量回落后级别随之降低，再次达到软限制时重新提醒
+type TenantQuotaWarningState struct {
+	TenantID  uint64              `json:"tenant_id" db:"tenant_id"`
+	Resource  TenantQuotaResource `json:"resource" db:"resource"`
+	Level     TenantQuotaLevel    `json:"level" db:"level"`
+	Used      int                 `json:"used" db:"used"`
+	Limit     int                 `json:"limit" db:"quota_limit"`
+	CrossedAt time.Time           `json:"crossed_at" db:"crossed_at"` // 进入当前级别的时间
+	UpdatedAt time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// TenantQuotaReport 租户各资源的用量与配额，以及需要处理的配额事项
+type TenantQuotaReport struct {
+	TenantID     uint64             `json:"tenant_id"`
+	Usages       []TenantQuotaUsage `json:"usages"`
+	PendingTasks []PendingTask      `json:"pending_tasks"`
+}
@@ -30,6 +30,12 @@ type User struct {
 	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time    `json:"updated_at" db:"updated_at"`
 	LastLoginAt  *time.Time   `json:"last_login_at,omitempty" db:"last_login_at"`
+	// PasswordChangedAt 最近一次设置密码的时间，为空时按创建时间计算密码有效期
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty" db:"password_changed_at"`
+	// AnonymizedAt 个人信息匿名化的时间，非空时用户名、邮箱等已替换为匿名令牌
+	AnonymizedAt *time.Time `json:"anonymized_at,omitempty" db:"anonymized_at"`
+	// MarketingOptOut 客户已退订营销信息，不会出现在客户分群营销导出中
+	MarketingOptOut bool `json:"marketing_opt_out" db:"marketing_opt_out"`
 }
 
 // UserProfile represents additional user profile information
@@ -74,8 +74,14 @@ const FundDepositPage: React.FC = () => {
     }
     
     try {
-      const results = await actions.batchDeposit(request);
-      toast.success(`批量充值成功，共处理 ${results.length} 笔`);
+      const result = await actions.batchDeposit(request);
+      if (result.status === 'partial') {
+        const failed = result.items.filter(item => !item.success).map(item => `第${item.index}笔: ${item.error}`);
+        toast.error(`批量充值部分成功：成功 ${result.succeeded_count} 笔，失败 ${result.failed_count} 笔。${failed.join('；')}`);
+        setBatchList(batchList.filter((_, i) => !result.items[i].success));
+        return;
+      }
+      toast.success(`批量充值成功，共处理 ${result.succeeded_count} 笔`);
       setBatchList([{ merchant_id: 0, amount: 0, currency: 'CNY', description: '' }]);
     } catch (error) {
       // Error already handled by store
@@ -7,6 +7,7 @@ import type {
   RightsBalance,
   DepositRequest,
   BatchDepositRequest,
+  BatchDepositResult,
   AllocateRequest,
   FundTransactionQuery,
   FundSummary,
@@ -26,8 +27,8 @@ export class FundService {
   }
 
   // 批量资金充值
-  async batchDeposit(request: BatchDepositRequest): Promise<Fund[]> {
-    const response = await api.post<ApiResponse<Fund[]>>('/api/v1/funds/batch-deposit', request);
+  async batchDeposit(request: BatchDepositRequest): Promise<BatchDepositResult> {
+    const response = await api.post<ApiResponse<BatchDepositResult>>('/api/v1/funds/batch-deposit', request);
     if (response.data.code !== 0) {
       throw new Error(response.data.message || '批量充值失败');
     }
@@ -10,6 +10,7 @@ import type {
   FundSummary,
   DepositRequest,
   BatchDepositRequest,
+  BatchDepositResult,
   AllocateRequest,
   FundTransactionQuery,
   FreezeRequest,
@@ -53,7 +54,7 @@ interface FundState {
   actions: {
     // 充值相关
     deposit: (request: DepositRequest) => Promise<Fund>;
-    batchDeposit: (request: BatchDepositRequest) => Promise<Fund[]>;
+    batchDeposit: (request: BatchDepositRequest) => Promise<BatchDepositResult>;
     
     // 权益分配
     allocate: (request: AllocateRequest) => Promise<Fund>;
@@ -151,21 +152,19 @@ export const useFundStore = create<FundState>()(
         }));
         
         try {
-          const funds = await fundService.batchDeposit(request);
+          const result = await fundService.batchDeposit(request);
           
-          // 更新fund列表
           set((state) => ({
-            fundList: [...funds, ...state.fundList],
             loading: { ...state.loading, deposit: false },
           }));
           
-          // 刷新相关商户的余额
-          const merchantIds = [...new Set(request.deposits.map(d => d.merchant_id))];
+          // 刷新充值成功的商户余额
+          const merchantIds = [...new Set(result.items.filter(item => item.success).map(item => item.merchant_id))];
           merchantIds.forEach(merchantId => {
             get().actions.refreshMerchantBalance(merchantId);
           });
           
-          return funds;
+          return result;
         } catch (error) {
           set((state) => ({
             loading: { ...state.loading, deposit: false },
@@ -70,9 +70,40 @@ export interface DepositRequest {
   description?: string;
 }
 
+// 批量充值模式：best_effort 逐笔入账，失败的充值不影响其他充值；all_or_nothing 任一笔失败整批回滚
+export type BatchDepositMode = 'best_effort' | 'all_or_nothing';
+
 // 批量充值请求
 export interface BatchDepositRequest {
   deposits: DepositRequest[];
+  mode?: BatchDepositMode;    // 默认 best_effort
+  idempotency_key?: string;   // 相同幂等键重复提交返回首次处理的结果，不重复入账
+}
+
+// 批量充值中单笔充值的结果
+export interface BatchDepositItemResult {
+  index: number;              // 在请求中的序号，从 1 开始
+  merchant_id: number;
+  amount: number;
+  currency: string;
+  success: boolean;
+  fund_id?: number;
+  fund_status?: FundStatus;
+  rolled_back?: boolean;      // 整批回滚而未入账
+  error?: string;
+}
+
+// 批量充值结果
+export interface BatchDepositResult {
+  batch_id?: number;
+  idempotency_key?: string;
+  mode: BatchDepositMode;
+  status: 'completed' | 'partial' | 'failed';
+  succeeded_count: number;
+  failed_count: number;
+  applied_amount: number;
+  items: BatchDepositItemResult[];
+  replayed: boolean;
 }
 
 // 权益分配请求